@@ -18,6 +18,18 @@ CGO_ENABLED=0 GOOS=linux go build \
   -ldflags "${LD_FLAGS}" \
   src/server/cmd/${BINARY}/main.go
 
+# pachd's image also holds the FlexVolume driver, which 'pachctl deploy
+# --flexvolume' installs on each node from it
+if [ ${BINARY} = "pachd" ]; then
+  CGO_ENABLED=0 GOOS=linux go build \
+    -a \
+    -installsuffix netgo \
+    -tags netgo \
+    -o _tmp/pfs-flexvolume \
+    -ldflags "${LD_FLAGS}" \
+    src/server/cmd/pfs-flexvolume/main.go
+fi
+
 echo "LD_FLAGS=$LD_FLAGS"
 
 # When creating profile binaries, we dont want to detach or do docker ops
@@ -0,0 +1,228 @@
+package client
+
+import (
+	"io"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/pachyderm/pachyderm/src/client/admin"
+	"github.com/pachyderm/pachyderm/src/client/pkg/grpcutil"
+)
+
+// InspectCluster returns information about the cluster, including pachd's
+// version and the parameters that it was deployed with.
+func (c APIClient) InspectCluster() (*admin.ClusterInfo, error) {
+	clusterInfo, err := c.AdminAPIClient.InspectCluster(
+		c.ctx(),
+		&types.Empty{},
+	)
+	if err != nil {
+		return nil, sanitizeErr(err)
+	}
+	return clusterInfo, nil
+}
+
+// Extract writes a backup of the cluster to w, which Restore can restore.
+// If objects is false, the content of files isn't backed up, see
+// admin.ExtractRequest. If since is the marker of an earlier backup, only
+// what has changed since it was extracted is backed up. Extract returns the
+// backup's own marker.
+func (c APIClient) Extract(objects bool, since string, w io.Writer) (string, error) {
+	return c.extract(&admin.ExtractRequest{NoObjects: !objects, Since: since}, w)
+}
+
+// ExtractURL is like Extract, but pachd writes the backup to the object
+// store URL, e.g. s3://bucket/backup, instead of sending it to the client.
+func (c APIClient) ExtractURL(URL string, objects bool, since string) (string, error) {
+	return c.extract(&admin.ExtractRequest{URL: URL, NoObjects: !objects, Since: since}, nil)
+}
+
+func (c APIClient) extract(request *admin.ExtractRequest, w io.Writer) (string, error) {
+	extractClient, err := c.AdminAPIClient.Extract(c.ctx(), request)
+	if err != nil {
+		return "", sanitizeErr(err)
+	}
+	for {
+		response, err := extractClient.Recv()
+		if err != nil {
+			if err == io.EOF {
+				return "", io.ErrUnexpectedEOF
+			}
+			return "", sanitizeErr(err)
+		}
+		if response.Marker != "" {
+			return response.Marker, nil
+		}
+		if _, err := w.Write(response.Data); err != nil {
+			return "", err
+		}
+	}
+}
+
+// Restore restores a backup, written by Extract, from r. Restoring an
+// incremental backup requires the backups before it to have been restored.
+func (c APIClient) Restore(r io.Reader) error {
+	restoreClient, err := c.AdminAPIClient.Restore(c.ctx())
+	if err != nil {
+		return sanitizeErr(err)
+	}
+	_, sendErr := grpcutil.ChunkReader(r, grpcutil.MaxMsgSize/2, func(chunk []byte) error {
+		return restoreClient.Send(&admin.RestoreRequest{Data: chunk})
+	})
+	// If pachd failed, Send returns io.EOF and CloseAndRecv the reason.
+	if _, err := restoreClient.CloseAndRecv(); err != nil {
+		return sanitizeErr(err)
+	}
+	return sanitizeErr(sendErr)
+}
+
+// RestoreURL is like Restore, but pachd reads the backup from the object
+// store URL, e.g. s3://bucket/backup.
+func (c APIClient) RestoreURL(URL string) error {
+	restoreClient, err := c.AdminAPIClient.Restore(c.ctx())
+	if err != nil {
+		return sanitizeErr(err)
+	}
+	if err := restoreClient.Send(&admin.RestoreRequest{URL: URL}); err != nil {
+		return sanitizeErr(err)
+	}
+	if _, err := restoreClient.CloseAndRecv(); err != nil {
+		return sanitizeErr(err)
+	}
+	return nil
+}
+
+// ExportRepo writes a tar archive of repo to w, which ImportRepo can import
+// into another cluster. If to is set, only the commits after from up to and
+// including to are exported, see admin.ExportRepoRequest.
+func (c APIClient) ExportRepo(repo string, from string, to string, w io.Writer) error {
+	exportRepoClient, err := c.AdminAPIClient.ExportRepo(
+		c.ctx(),
+		&admin.ExportRepoRequest{
+			Repo: repo,
+			From: from,
+			To:   to,
+		},
+	)
+	if err != nil {
+		return sanitizeErr(err)
+	}
+	for {
+		response, err := exportRepoClient.Recv()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return sanitizeErr(err)
+		}
+		if _, err := w.Write(response.Data); err != nil {
+			return err
+		}
+	}
+}
+
+// ImportRepo imports an archive, written by ExportRepo, from r. The repo is
+// named repo, or the name it was exported with if repo is empty, and must
+// not already exist. Imported commits have new IDs.
+func (c APIClient) ImportRepo(r io.Reader, repo string) error {
+	importRepoClient, err := c.AdminAPIClient.ImportRepo(c.ctx())
+	if err != nil {
+		return sanitizeErr(err)
+	}
+	first := true
+	_, sendErr := grpcutil.ChunkReader(r, grpcutil.MaxMsgSize/2, func(chunk []byte) error {
+		request := &admin.ImportRepoRequest{Data: chunk}
+		if first {
+			request.Repo = repo
+			first = false
+		}
+		return importRepoClient.Send(request)
+	})
+	// If pachd failed, Send returns io.EOF and CloseAndRecv the reason.
+	if _, err := importRepoClient.CloseAndRecv(); err != nil {
+		return sanitizeErr(err)
+	}
+	return sanitizeErr(sendErr)
+}
+
+// InspectReplication returns the status of the cluster's replication to, or
+// from, another cluster.
+func (c APIClient) InspectReplication() (*admin.ReplicationStatus, error) {
+	status, err := c.AdminAPIClient.InspectReplication(
+		c.ctx(),
+		&types.Empty{},
+	)
+	if err != nil {
+		return nil, sanitizeErr(err)
+	}
+	return status, nil
+}
+
+// PromoteReplica promotes a secondary cluster, which restores a primary's
+// backups, so that it stops restoring them and can be used in its place.
+func (c APIClient) PromoteReplica() error {
+	_, err := c.AdminAPIClient.PromoteReplica(
+		c.ctx(),
+		&types.Empty{},
+	)
+	return sanitizeErr(err)
+}
+
+// SetReadOnly makes the cluster read-only for maintenance: requests which
+// would change it fail with ErrReadOnly and reason until SetWritable is
+// called. Running jobs run to completion, unless pauseJobs is true, in which
+// case they don't start any more datums until then.
+func (c APIClient) SetReadOnly(reason string, pauseJobs bool) error {
+	_, err := c.AdminAPIClient.SetReadOnly(
+		c.ctx(),
+		&admin.SetReadOnlyRequest{
+			ReadOnly:  true,
+			Reason:    reason,
+			PauseJobs: pauseJobs,
+		},
+	)
+	return sanitizeErr(err)
+}
+
+// SetWritable makes a cluster which SetReadOnly made read-only writable
+// again, and resumes its jobs.
+func (c APIClient) SetWritable() error {
+	_, err := c.AdminAPIClient.SetReadOnly(
+		c.ctx(),
+		&admin.SetReadOnlyRequest{},
+	)
+	return sanitizeErr(err)
+}
+
+// CreateMirror creates a mirror, which copies the commits finished on a
+// branch in this cluster to a branch in another cluster, see
+// admin.MirrorSpec.
+func (c APIClient) CreateMirror(spec *admin.MirrorSpec) error {
+	_, err := c.AdminAPIClient.CreateMirror(
+		c.ctx(),
+		&admin.CreateMirrorRequest{Spec: spec},
+	)
+	return sanitizeErr(err)
+}
+
+// ListMirror returns the cluster's mirrors, and the last commit that each
+// has copied.
+func (c APIClient) ListMirror() ([]*admin.MirrorInfo, error) {
+	mirrorInfos, err := c.AdminAPIClient.ListMirror(
+		c.ctx(),
+		&types.Empty{},
+	)
+	if err != nil {
+		return nil, sanitizeErr(err)
+	}
+	return mirrorInfos.MirrorInfo, nil
+}
+
+// DeleteMirror stops the mirror called name copying commits. The commits
+// it has copied are left in the other cluster.
+func (c APIClient) DeleteMirror(name string) error {
+	_, err := c.AdminAPIClient.DeleteMirror(
+		c.ctx(),
+		&admin.DeleteMirrorRequest{Name: name},
+	)
+	return sanitizeErr(err)
+}
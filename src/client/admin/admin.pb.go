@@ -0,0 +1,1424 @@
+// Code generated by protoc-gen-gogo.
+// source: client/admin/admin.proto
+// DO NOT EDIT!
+
+/*
+Package admin is a generated protocol buffer package.
+
+It is generated from these files:
+	client/admin/admin.proto
+
+It has these top-level messages:
+	ClusterInfo
+	EtcdMemberStatus
+	ExtractRequest
+	ExtractResponse
+	RestoreRequest
+	ExportRepoRequest
+	ExportRepoResponse
+	ImportRepoRequest
+	ReplicationStatus
+	ReadOnlyStatus
+	SetReadOnlyRequest
+	MirrorSpec
+	MirrorInfo
+	CreateMirrorRequest
+	MirrorInfos
+	DeleteMirrorRequest
+*/
+package admin
+
+import proto "github.com/gogo/protobuf/proto"
+import fmt "fmt"
+import math "math"
+import google_protobuf "github.com/gogo/protobuf/types"
+import google_protobuf1 "github.com/gogo/protobuf/types"
+import versionpb "github.com/pachyderm/pachyderm/src/client/version/versionpb"
+import _ "github.com/gogo/protobuf/gogoproto"
+
+import (
+	context "golang.org/x/net/context"
+	grpc "google.golang.org/grpc"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.GoGoProtoPackageIsVersion2 // please upgrade the proto package
+
+// ReplicationRole is the part that a cluster plays in replication.
+type ReplicationRole int32
+
+const (
+	// NONE clusters don't replicate.
+	ReplicationRole_NONE ReplicationRole = 0
+	// PRIMARY clusters write their state to a bucket, as a series of
+	// incremental backups.
+	ReplicationRole_PRIMARY ReplicationRole = 1
+	// SECONDARY clusters restore the backups written by a primary.
+	ReplicationRole_SECONDARY ReplicationRole = 2
+	// PROMOTED clusters were secondaries, and have stopped restoring backups.
+	ReplicationRole_PROMOTED ReplicationRole = 3
+)
+
+var ReplicationRole_name = map[int32]string{
+	0: "NONE",
+	1: "PRIMARY",
+	2: "SECONDARY",
+	3: "PROMOTED",
+}
+var ReplicationRole_value = map[string]int32{
+	"NONE":      0,
+	"PRIMARY":   1,
+	"SECONDARY": 2,
+	"PROMOTED":  3,
+}
+
+func (x ReplicationRole) String() string {
+	return proto.EnumName(ReplicationRole_name, int32(x))
+}
+func (ReplicationRole) EnumDescriptor() ([]byte, []int) { return fileDescriptorAdmin, []int{0} }
+
+// ClusterInfo describes a Pachyderm cluster, and how it was deployed.
+type ClusterInfo struct {
+	// id is unique to the cluster, it's generated when pachd first starts.
+	ID                    string             `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Version               *versionpb.Version `protobuf:"bytes,2,opt,name=version" json:"version,omitempty"`
+	NumShards             uint64             `protobuf:"varint,3,opt,name=num_shards,json=numShards,proto3" json:"num_shards,omitempty"`
+	Namespace             string             `protobuf:"bytes,4,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	StorageBackend        string             `protobuf:"bytes,5,opt,name=storage_backend,json=storageBackend,proto3" json:"storage_backend,omitempty"`
+	BlockCacheSize        string             `protobuf:"bytes,6,opt,name=block_cache_size,json=blockCacheSize,proto3" json:"block_cache_size,omitempty"`
+	PfsCacheSize          string             `protobuf:"bytes,7,opt,name=pfs_cache_size,json=pfsCacheSize,proto3" json:"pfs_cache_size,omitempty"`
+	MaxMsgSize            string             `protobuf:"bytes,8,opt,name=max_msg_size,json=maxMsgSize,proto3" json:"max_msg_size,omitempty"`
+	WorkerImage           string             `protobuf:"bytes,9,opt,name=worker_image,json=workerImage,proto3" json:"worker_image,omitempty"`
+	WorkerSidecarImage    string             `protobuf:"bytes,10,opt,name=worker_sidecar_image,json=workerSidecarImage,proto3" json:"worker_sidecar_image,omitempty"`
+	WorkerImagePullPolicy string             `protobuf:"bytes,11,opt,name=worker_image_pull_policy,json=workerImagePullPolicy,proto3" json:"worker_image_pull_policy,omitempty"`
+	LogLevel              string             `protobuf:"bytes,12,opt,name=log_level,json=logLevel,proto3" json:"log_level,omitempty"`
+	Metrics               bool               `protobuf:"varint,13,opt,name=metrics,proto3" json:"metrics,omitempty"`
+	// etcd_members is the health of the members of the etcd cluster that pachd
+	// keeps its metadata in, as of the request.
+	EtcdMembers []*EtcdMemberStatus `protobuf:"bytes,14,rep,name=etcd_members,json=etcdMembers" json:"etcd_members,omitempty"`
+	// read_only is set if the cluster is read-only, see SetReadOnly.
+	ReadOnly *ReadOnlyStatus `protobuf:"bytes,15,opt,name=read_only,json=readOnly" json:"read_only,omitempty"`
+}
+
+func (m *ClusterInfo) Reset()                    { *m = ClusterInfo{} }
+func (m *ClusterInfo) String() string            { return proto.CompactTextString(m) }
+func (*ClusterInfo) ProtoMessage()               {}
+func (*ClusterInfo) Descriptor() ([]byte, []int) { return fileDescriptorAdmin, []int{0} }
+
+func (m *ClusterInfo) GetID() string {
+	if m != nil {
+		return m.ID
+	}
+	return ""
+}
+
+func (m *ClusterInfo) GetVersion() *versionpb.Version {
+	if m != nil {
+		return m.Version
+	}
+	return nil
+}
+
+func (m *ClusterInfo) GetNumShards() uint64 {
+	if m != nil {
+		return m.NumShards
+	}
+	return 0
+}
+
+func (m *ClusterInfo) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *ClusterInfo) GetStorageBackend() string {
+	if m != nil {
+		return m.StorageBackend
+	}
+	return ""
+}
+
+func (m *ClusterInfo) GetBlockCacheSize() string {
+	if m != nil {
+		return m.BlockCacheSize
+	}
+	return ""
+}
+
+func (m *ClusterInfo) GetPfsCacheSize() string {
+	if m != nil {
+		return m.PfsCacheSize
+	}
+	return ""
+}
+
+func (m *ClusterInfo) GetMaxMsgSize() string {
+	if m != nil {
+		return m.MaxMsgSize
+	}
+	return ""
+}
+
+func (m *ClusterInfo) GetWorkerImage() string {
+	if m != nil {
+		return m.WorkerImage
+	}
+	return ""
+}
+
+func (m *ClusterInfo) GetWorkerSidecarImage() string {
+	if m != nil {
+		return m.WorkerSidecarImage
+	}
+	return ""
+}
+
+func (m *ClusterInfo) GetWorkerImagePullPolicy() string {
+	if m != nil {
+		return m.WorkerImagePullPolicy
+	}
+	return ""
+}
+
+func (m *ClusterInfo) GetLogLevel() string {
+	if m != nil {
+		return m.LogLevel
+	}
+	return ""
+}
+
+func (m *ClusterInfo) GetMetrics() bool {
+	if m != nil {
+		return m.Metrics
+	}
+	return false
+}
+
+func (m *ClusterInfo) GetEtcdMembers() []*EtcdMemberStatus {
+	if m != nil {
+		return m.EtcdMembers
+	}
+	return nil
+}
+
+func (m *ClusterInfo) GetReadOnly() *ReadOnlyStatus {
+	if m != nil {
+		return m.ReadOnly
+	}
+	return nil
+}
+
+// EtcdMemberStatus is the health of one of etcd's members.
+type EtcdMemberStatus struct {
+	Name     string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Endpoint string `protobuf:"bytes,2,opt,name=endpoint,proto3" json:"endpoint,omitempty"`
+	// healthy is true if the member responded, in which case leader and
+	// db_size are set.
+	Healthy bool  `protobuf:"varint,3,opt,name=healthy,proto3" json:"healthy,omitempty"`
+	Leader  bool  `protobuf:"varint,4,opt,name=leader,proto3" json:"leader,omitempty"`
+	DbSize  int64 `protobuf:"varint,5,opt,name=db_size,json=dbSize,proto3" json:"db_size,omitempty"`
+	// error is why the member isn't healthy.
+	Error string `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (m *EtcdMemberStatus) Reset()                    { *m = EtcdMemberStatus{} }
+func (m *EtcdMemberStatus) String() string            { return proto.CompactTextString(m) }
+func (*EtcdMemberStatus) ProtoMessage()               {}
+func (*EtcdMemberStatus) Descriptor() ([]byte, []int) { return fileDescriptorAdmin, []int{1} }
+
+func (m *EtcdMemberStatus) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *EtcdMemberStatus) GetEndpoint() string {
+	if m != nil {
+		return m.Endpoint
+	}
+	return ""
+}
+
+func (m *EtcdMemberStatus) GetHealthy() bool {
+	if m != nil {
+		return m.Healthy
+	}
+	return false
+}
+
+func (m *EtcdMemberStatus) GetLeader() bool {
+	if m != nil {
+		return m.Leader
+	}
+	return false
+}
+
+func (m *EtcdMemberStatus) GetDbSize() int64 {
+	if m != nil {
+		return m.DbSize
+	}
+	return 0
+}
+
+func (m *EtcdMemberStatus) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+type ExtractRequest struct {
+	// URL, if set, is an object store URL, e.g. "s3://bucket/backup", that the
+	// backup is written to, instead of being returned in the responses.
+	URL string `protobuf:"bytes,1,opt,name=URL,proto3" json:"URL,omitempty"`
+	// NoObjects, if true, leaves the content of files out of the backup, which
+	// can then only be restored to a cluster that uses the same object store.
+	NoObjects bool `protobuf:"varint,2,opt,name=no_objects,json=noObjects,proto3" json:"no_objects,omitempty"`
+	// Since, if set, is the marker of a previous backup, in which case only
+	// what has changed since that backup was extracted is extracted.
+	Since string `protobuf:"bytes,3,opt,name=since,proto3" json:"since,omitempty"`
+}
+
+func (m *ExtractRequest) Reset()                    { *m = ExtractRequest{} }
+func (m *ExtractRequest) String() string            { return proto.CompactTextString(m) }
+func (*ExtractRequest) ProtoMessage()               {}
+func (*ExtractRequest) Descriptor() ([]byte, []int) { return fileDescriptorAdmin, []int{2} }
+
+func (m *ExtractRequest) GetURL() string {
+	if m != nil {
+		return m.URL
+	}
+	return ""
+}
+
+func (m *ExtractRequest) GetNoObjects() bool {
+	if m != nil {
+		return m.NoObjects
+	}
+	return false
+}
+
+func (m *ExtractRequest) GetSince() string {
+	if m != nil {
+		return m.Since
+	}
+	return ""
+}
+
+type ExtractResponse struct {
+	// Data is the next chunk of the backup.
+	Data []byte `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	// Marker is set in the last response. Passing it as ExtractRequest.since
+	// extracts what changes after this backup.
+	Marker string `protobuf:"bytes,2,opt,name=marker,proto3" json:"marker,omitempty"`
+}
+
+func (m *ExtractResponse) Reset()                    { *m = ExtractResponse{} }
+func (m *ExtractResponse) String() string            { return proto.CompactTextString(m) }
+func (*ExtractResponse) ProtoMessage()               {}
+func (*ExtractResponse) Descriptor() ([]byte, []int) { return fileDescriptorAdmin, []int{3} }
+
+func (m *ExtractResponse) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+func (m *ExtractResponse) GetMarker() string {
+	if m != nil {
+		return m.Marker
+	}
+	return ""
+}
+
+type RestoreRequest struct {
+	// Data is the next chunk of the backup.
+	Data []byte `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	// URL, if set in the first request, is an object store URL that the
+	// backup is read from, instead of from the requests.
+	URL string `protobuf:"bytes,2,opt,name=URL,proto3" json:"URL,omitempty"`
+}
+
+func (m *RestoreRequest) Reset()                    { *m = RestoreRequest{} }
+func (m *RestoreRequest) String() string            { return proto.CompactTextString(m) }
+func (*RestoreRequest) ProtoMessage()               {}
+func (*RestoreRequest) Descriptor() ([]byte, []int) { return fileDescriptorAdmin, []int{4} }
+
+func (m *RestoreRequest) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+func (m *RestoreRequest) GetURL() string {
+	if m != nil {
+		return m.URL
+	}
+	return ""
+}
+
+type ExportRepoRequest struct {
+	// Repo is the name of the repo to export.
+	Repo string `protobuf:"bytes,1,opt,name=repo,proto3" json:"repo,omitempty"`
+	// From and To, if set, limit the export to the commits after From (which
+	// isn't exported) up to and including To, which may be a branch. From can
+	// only be set along with To. If neither is set, all of the repo's commits
+	// are exported.
+	From string `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	To   string `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
+}
+
+func (m *ExportRepoRequest) Reset()                    { *m = ExportRepoRequest{} }
+func (m *ExportRepoRequest) String() string            { return proto.CompactTextString(m) }
+func (*ExportRepoRequest) ProtoMessage()               {}
+func (*ExportRepoRequest) Descriptor() ([]byte, []int) { return fileDescriptorAdmin, []int{5} }
+
+func (m *ExportRepoRequest) GetRepo() string {
+	if m != nil {
+		return m.Repo
+	}
+	return ""
+}
+
+func (m *ExportRepoRequest) GetFrom() string {
+	if m != nil {
+		return m.From
+	}
+	return ""
+}
+
+func (m *ExportRepoRequest) GetTo() string {
+	if m != nil {
+		return m.To
+	}
+	return ""
+}
+
+type ExportRepoResponse struct {
+	// Data is the next chunk of the archive.
+	Data []byte `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+func (m *ExportRepoResponse) Reset()                    { *m = ExportRepoResponse{} }
+func (m *ExportRepoResponse) String() string            { return proto.CompactTextString(m) }
+func (*ExportRepoResponse) ProtoMessage()               {}
+func (*ExportRepoResponse) Descriptor() ([]byte, []int) { return fileDescriptorAdmin, []int{6} }
+
+func (m *ExportRepoResponse) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+type ImportRepoRequest struct {
+	// Data is the next chunk of the archive.
+	Data []byte `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	// Repo, if set in the first request, is the name of the repo that the
+	// archive is imported into, instead of the name of the exported repo.
+	Repo string `protobuf:"bytes,2,opt,name=repo,proto3" json:"repo,omitempty"`
+}
+
+func (m *ImportRepoRequest) Reset()                    { *m = ImportRepoRequest{} }
+func (m *ImportRepoRequest) String() string            { return proto.CompactTextString(m) }
+func (*ImportRepoRequest) ProtoMessage()               {}
+func (*ImportRepoRequest) Descriptor() ([]byte, []int) { return fileDescriptorAdmin, []int{7} }
+
+func (m *ImportRepoRequest) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+func (m *ImportRepoRequest) GetRepo() string {
+	if m != nil {
+		return m.Repo
+	}
+	return ""
+}
+
+// ReplicationStatus describes how far a cluster's replication has got.
+type ReplicationStatus struct {
+	Role ReplicationRole `protobuf:"varint,1,opt,name=role,proto3,enum=admin.ReplicationRole" json:"role,omitempty"`
+	// URL is the object store URL of the bucket that the backups are written
+	// to, or restored from.
+	URL string `protobuf:"bytes,2,opt,name=URL,proto3" json:"URL,omitempty"`
+	// marker is the marker of the last backup written, or restored. Everything
+	// that changed before replicated was written to it.
+	Marker     string                      `protobuf:"bytes,3,opt,name=marker,proto3" json:"marker,omitempty"`
+	Replicated *google_protobuf1.Timestamp `protobuf:"bytes,4,opt,name=replicated" json:"replicated,omitempty"`
+	// pending is the number of backups written by the primary which a
+	// secondary hasn't restored yet.
+	Pending int64 `protobuf:"varint,5,opt,name=pending,proto3" json:"pending,omitempty"`
+	// error is why the last attempt to write, or restore, a backup failed. It's
+	// cleared by the next one that succeeds.
+	Error string `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (m *ReplicationStatus) Reset()                    { *m = ReplicationStatus{} }
+func (m *ReplicationStatus) String() string            { return proto.CompactTextString(m) }
+func (*ReplicationStatus) ProtoMessage()               {}
+func (*ReplicationStatus) Descriptor() ([]byte, []int) { return fileDescriptorAdmin, []int{8} }
+
+func (m *ReplicationStatus) GetRole() ReplicationRole {
+	if m != nil {
+		return m.Role
+	}
+	return ReplicationRole_NONE
+}
+
+func (m *ReplicationStatus) GetURL() string {
+	if m != nil {
+		return m.URL
+	}
+	return ""
+}
+
+func (m *ReplicationStatus) GetMarker() string {
+	if m != nil {
+		return m.Marker
+	}
+	return ""
+}
+
+func (m *ReplicationStatus) GetReplicated() *google_protobuf1.Timestamp {
+	if m != nil {
+		return m.Replicated
+	}
+	return nil
+}
+
+func (m *ReplicationStatus) GetPending() int64 {
+	if m != nil {
+		return m.Pending
+	}
+	return 0
+}
+
+func (m *ReplicationStatus) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+// ReadOnlyStatus describes why a cluster is read-only.
+type ReadOnlyStatus struct {
+	// reason is included in the errors returned to the requests which are
+	// rejected, e.g. "upgrading etcd until 14:00 UTC".
+	Reason string `protobuf:"bytes,1,opt,name=reason,proto3" json:"reason,omitempty"`
+	// pause_jobs, if true, stops running jobs from starting more datums until
+	// the cluster is writable again. Otherwise they run to completion.
+	PauseJobs bool                        `protobuf:"varint,2,opt,name=pause_jobs,json=pauseJobs,proto3" json:"pause_jobs,omitempty"`
+	Since     *google_protobuf1.Timestamp `protobuf:"bytes,3,opt,name=since" json:"since,omitempty"`
+}
+
+func (m *ReadOnlyStatus) Reset()                    { *m = ReadOnlyStatus{} }
+func (m *ReadOnlyStatus) String() string            { return proto.CompactTextString(m) }
+func (*ReadOnlyStatus) ProtoMessage()               {}
+func (*ReadOnlyStatus) Descriptor() ([]byte, []int) { return fileDescriptorAdmin, []int{9} }
+
+func (m *ReadOnlyStatus) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+func (m *ReadOnlyStatus) GetPauseJobs() bool {
+	if m != nil {
+		return m.PauseJobs
+	}
+	return false
+}
+
+func (m *ReadOnlyStatus) GetSince() *google_protobuf1.Timestamp {
+	if m != nil {
+		return m.Since
+	}
+	return nil
+}
+
+type SetReadOnlyRequest struct {
+	// read_only makes the cluster read-only if it's true, and writable if it's
+	// false, in which case reason and pause_jobs are ignored.
+	ReadOnly  bool   `protobuf:"varint,1,opt,name=read_only,json=readOnly,proto3" json:"read_only,omitempty"`
+	Reason    string `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	PauseJobs bool   `protobuf:"varint,3,opt,name=pause_jobs,json=pauseJobs,proto3" json:"pause_jobs,omitempty"`
+}
+
+func (m *SetReadOnlyRequest) Reset()                    { *m = SetReadOnlyRequest{} }
+func (m *SetReadOnlyRequest) String() string            { return proto.CompactTextString(m) }
+func (*SetReadOnlyRequest) ProtoMessage()               {}
+func (*SetReadOnlyRequest) Descriptor() ([]byte, []int) { return fileDescriptorAdmin, []int{10} }
+
+func (m *SetReadOnlyRequest) GetReadOnly() bool {
+	if m != nil {
+		return m.ReadOnly
+	}
+	return false
+}
+
+func (m *SetReadOnlyRequest) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+func (m *SetReadOnlyRequest) GetPauseJobs() bool {
+	if m != nil {
+		return m.PauseJobs
+	}
+	return false
+}
+
+// MirrorSpec describes a mirror, which copies the commits on a branch in this
+// cluster to a branch in another cluster as they're finished.
+type MirrorSpec struct {
+	// name identifies the mirror. It defaults to "<repo>-<branch>".
+	Name   string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Repo   string `protobuf:"bytes,2,opt,name=repo,proto3" json:"repo,omitempty"`
+	Branch string `protobuf:"bytes,3,opt,name=branch,proto3" json:"branch,omitempty"`
+	// address is the address of the other cluster's pachd, e.g.
+	// "prod.example.com:650".
+	Address string `protobuf:"bytes,4,opt,name=address,proto3" json:"address,omitempty"`
+	// token, if set, is the auth token that the commits are written to the
+	// other cluster with. It isn't returned by ListMirror.
+	Token string `protobuf:"bytes,5,opt,name=token,proto3" json:"token,omitempty"`
+	// dest_repo and dest_branch are the repo and branch in the other cluster
+	// that the commits are copied to. They default to repo and branch, and
+	// dest_repo is created if it doesn't exist. The commits are made on
+	// dest_branch, so the pipelines in the other cluster that take it as input
+	// process them.
+	DestRepo   string `protobuf:"bytes,6,opt,name=dest_repo,json=destRepo,proto3" json:"dest_repo,omitempty"`
+	DestBranch string `protobuf:"bytes,7,opt,name=dest_branch,json=destBranch,proto3" json:"dest_branch,omitempty"`
+}
+
+func (m *MirrorSpec) Reset()                    { *m = MirrorSpec{} }
+func (m *MirrorSpec) String() string            { return proto.CompactTextString(m) }
+func (*MirrorSpec) ProtoMessage()               {}
+func (*MirrorSpec) Descriptor() ([]byte, []int) { return fileDescriptorAdmin, []int{11} }
+
+func (m *MirrorSpec) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *MirrorSpec) GetRepo() string {
+	if m != nil {
+		return m.Repo
+	}
+	return ""
+}
+
+func (m *MirrorSpec) GetBranch() string {
+	if m != nil {
+		return m.Branch
+	}
+	return ""
+}
+
+func (m *MirrorSpec) GetAddress() string {
+	if m != nil {
+		return m.Address
+	}
+	return ""
+}
+
+func (m *MirrorSpec) GetToken() string {
+	if m != nil {
+		return m.Token
+	}
+	return ""
+}
+
+func (m *MirrorSpec) GetDestRepo() string {
+	if m != nil {
+		return m.DestRepo
+	}
+	return ""
+}
+
+func (m *MirrorSpec) GetDestBranch() string {
+	if m != nil {
+		return m.DestBranch
+	}
+	return ""
+}
+
+// MirrorInfo describes how far a mirror has got.
+type MirrorInfo struct {
+	Spec *MirrorSpec `protobuf:"bytes,1,opt,name=spec" json:"spec,omitempty"`
+	// mirrored is the last commit that was copied to the other cluster, and
+	// dest_commit is the commit that it was copied as.
+	Mirrored   string                      `protobuf:"bytes,2,opt,name=mirrored,proto3" json:"mirrored,omitempty"`
+	DestCommit string                      `protobuf:"bytes,3,opt,name=dest_commit,json=destCommit,proto3" json:"dest_commit,omitempty"`
+	Updated    *google_protobuf1.Timestamp `protobuf:"bytes,4,opt,name=updated" json:"updated,omitempty"`
+	// error is why the last attempt to copy commits failed. It's cleared by
+	// the next one that succeeds.
+	Error string `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (m *MirrorInfo) Reset()                    { *m = MirrorInfo{} }
+func (m *MirrorInfo) String() string            { return proto.CompactTextString(m) }
+func (*MirrorInfo) ProtoMessage()               {}
+func (*MirrorInfo) Descriptor() ([]byte, []int) { return fileDescriptorAdmin, []int{12} }
+
+func (m *MirrorInfo) GetSpec() *MirrorSpec {
+	if m != nil {
+		return m.Spec
+	}
+	return nil
+}
+
+func (m *MirrorInfo) GetMirrored() string {
+	if m != nil {
+		return m.Mirrored
+	}
+	return ""
+}
+
+func (m *MirrorInfo) GetDestCommit() string {
+	if m != nil {
+		return m.DestCommit
+	}
+	return ""
+}
+
+func (m *MirrorInfo) GetUpdated() *google_protobuf1.Timestamp {
+	if m != nil {
+		return m.Updated
+	}
+	return nil
+}
+
+func (m *MirrorInfo) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+type CreateMirrorRequest struct {
+	Spec *MirrorSpec `protobuf:"bytes,1,opt,name=spec" json:"spec,omitempty"`
+}
+
+func (m *CreateMirrorRequest) Reset()                    { *m = CreateMirrorRequest{} }
+func (m *CreateMirrorRequest) String() string            { return proto.CompactTextString(m) }
+func (*CreateMirrorRequest) ProtoMessage()               {}
+func (*CreateMirrorRequest) Descriptor() ([]byte, []int) { return fileDescriptorAdmin, []int{13} }
+
+func (m *CreateMirrorRequest) GetSpec() *MirrorSpec {
+	if m != nil {
+		return m.Spec
+	}
+	return nil
+}
+
+type MirrorInfos struct {
+	MirrorInfo []*MirrorInfo `protobuf:"bytes,1,rep,name=mirror_info,json=mirrorInfo" json:"mirror_info,omitempty"`
+}
+
+func (m *MirrorInfos) Reset()                    { *m = MirrorInfos{} }
+func (m *MirrorInfos) String() string            { return proto.CompactTextString(m) }
+func (*MirrorInfos) ProtoMessage()               {}
+func (*MirrorInfos) Descriptor() ([]byte, []int) { return fileDescriptorAdmin, []int{14} }
+
+func (m *MirrorInfos) GetMirrorInfo() []*MirrorInfo {
+	if m != nil {
+		return m.MirrorInfo
+	}
+	return nil
+}
+
+type DeleteMirrorRequest struct {
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+}
+
+func (m *DeleteMirrorRequest) Reset()                    { *m = DeleteMirrorRequest{} }
+func (m *DeleteMirrorRequest) String() string            { return proto.CompactTextString(m) }
+func (*DeleteMirrorRequest) ProtoMessage()               {}
+func (*DeleteMirrorRequest) Descriptor() ([]byte, []int) { return fileDescriptorAdmin, []int{15} }
+
+func (m *DeleteMirrorRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func init() {
+	proto.RegisterType((*ClusterInfo)(nil), "admin.ClusterInfo")
+	proto.RegisterType((*EtcdMemberStatus)(nil), "admin.EtcdMemberStatus")
+	proto.RegisterType((*ExtractRequest)(nil), "admin.ExtractRequest")
+	proto.RegisterType((*ExtractResponse)(nil), "admin.ExtractResponse")
+	proto.RegisterType((*RestoreRequest)(nil), "admin.RestoreRequest")
+	proto.RegisterType((*ExportRepoRequest)(nil), "admin.ExportRepoRequest")
+	proto.RegisterType((*ExportRepoResponse)(nil), "admin.ExportRepoResponse")
+	proto.RegisterType((*ImportRepoRequest)(nil), "admin.ImportRepoRequest")
+	proto.RegisterType((*ReplicationStatus)(nil), "admin.ReplicationStatus")
+	proto.RegisterType((*ReadOnlyStatus)(nil), "admin.ReadOnlyStatus")
+	proto.RegisterType((*SetReadOnlyRequest)(nil), "admin.SetReadOnlyRequest")
+	proto.RegisterType((*MirrorSpec)(nil), "admin.MirrorSpec")
+	proto.RegisterType((*MirrorInfo)(nil), "admin.MirrorInfo")
+	proto.RegisterType((*CreateMirrorRequest)(nil), "admin.CreateMirrorRequest")
+	proto.RegisterType((*MirrorInfos)(nil), "admin.MirrorInfos")
+	proto.RegisterType((*DeleteMirrorRequest)(nil), "admin.DeleteMirrorRequest")
+	proto.RegisterEnum("admin.ReplicationRole", ReplicationRole_name, ReplicationRole_value)
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// Client API for API service
+
+type APIClient interface {
+	InspectCluster(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*ClusterInfo, error)
+	// Extract writes a backup of the cluster, see admin.Extract.
+	Extract(ctx context.Context, in *ExtractRequest, opts ...grpc.CallOption) (API_ExtractClient, error)
+	// Restore restores a backup written by Extract.
+	Restore(ctx context.Context, opts ...grpc.CallOption) (API_RestoreClient, error)
+	// ExportRepo writes a tar archive of a repo, see admin.ExportRepo.
+	ExportRepo(ctx context.Context, in *ExportRepoRequest, opts ...grpc.CallOption) (API_ExportRepoClient, error)
+	// ImportRepo imports an archive written by ExportRepo.
+	ImportRepo(ctx context.Context, opts ...grpc.CallOption) (API_ImportRepoClient, error)
+	// InspectReplication returns the cluster's ReplicationStatus.
+	InspectReplication(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*ReplicationStatus, error)
+	// PromoteReplica stops a secondary restoring the primary's backups, so
+	// that it can take over from the primary.
+	PromoteReplica(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+	// SetReadOnly makes the cluster read-only, so that requests which would
+	// change it are rejected, or writable again.
+	SetReadOnly(ctx context.Context, in *SetReadOnlyRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+	// CreateMirror starts copying the commits on a branch to another cluster,
+	// see MirrorSpec.
+	CreateMirror(ctx context.Context, in *CreateMirrorRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+	// ListMirror returns the cluster's mirrors and how far they've got.
+	ListMirror(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*MirrorInfos, error)
+	// DeleteMirror stops a mirror. What it has copied is left in the other
+	// cluster.
+	DeleteMirror(ctx context.Context, in *DeleteMirrorRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+}
+
+type aPIClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewAPIClient(cc *grpc.ClientConn) APIClient {
+	return &aPIClient{cc}
+}
+
+func (c *aPIClient) InspectCluster(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*ClusterInfo, error) {
+	out := new(ClusterInfo)
+	err := grpc.Invoke(ctx, "/admin.API/InspectCluster", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) Extract(ctx context.Context, in *ExtractRequest, opts ...grpc.CallOption) (API_ExtractClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_API_serviceDesc.Streams[0], c.cc, "/admin.API/Extract", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &aPIExtractClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type API_ExtractClient interface {
+	Recv() (*ExtractResponse, error)
+	grpc.ClientStream
+}
+
+type aPIExtractClient struct {
+	grpc.ClientStream
+}
+
+func (x *aPIExtractClient) Recv() (*ExtractResponse, error) {
+	m := new(ExtractResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *aPIClient) Restore(ctx context.Context, opts ...grpc.CallOption) (API_RestoreClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_API_serviceDesc.Streams[1], c.cc, "/admin.API/Restore", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &aPIRestoreClient{stream}
+	return x, nil
+}
+
+type API_RestoreClient interface {
+	Send(*RestoreRequest) error
+	CloseAndRecv() (*google_protobuf.Empty, error)
+	grpc.ClientStream
+}
+
+type aPIRestoreClient struct {
+	grpc.ClientStream
+}
+
+func (x *aPIRestoreClient) Send(m *RestoreRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *aPIRestoreClient) CloseAndRecv() (*google_protobuf.Empty, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(google_protobuf.Empty)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *aPIClient) ExportRepo(ctx context.Context, in *ExportRepoRequest, opts ...grpc.CallOption) (API_ExportRepoClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_API_serviceDesc.Streams[2], c.cc, "/admin.API/ExportRepo", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &aPIExportRepoClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type API_ExportRepoClient interface {
+	Recv() (*ExportRepoResponse, error)
+	grpc.ClientStream
+}
+
+type aPIExportRepoClient struct {
+	grpc.ClientStream
+}
+
+func (x *aPIExportRepoClient) Recv() (*ExportRepoResponse, error) {
+	m := new(ExportRepoResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *aPIClient) ImportRepo(ctx context.Context, opts ...grpc.CallOption) (API_ImportRepoClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_API_serviceDesc.Streams[3], c.cc, "/admin.API/ImportRepo", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &aPIImportRepoClient{stream}
+	return x, nil
+}
+
+type API_ImportRepoClient interface {
+	Send(*ImportRepoRequest) error
+	CloseAndRecv() (*google_protobuf.Empty, error)
+	grpc.ClientStream
+}
+
+type aPIImportRepoClient struct {
+	grpc.ClientStream
+}
+
+func (x *aPIImportRepoClient) Send(m *ImportRepoRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *aPIImportRepoClient) CloseAndRecv() (*google_protobuf.Empty, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(google_protobuf.Empty)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *aPIClient) InspectReplication(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*ReplicationStatus, error) {
+	out := new(ReplicationStatus)
+	err := grpc.Invoke(ctx, "/admin.API/InspectReplication", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) PromoteReplica(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
+	out := new(google_protobuf.Empty)
+	err := grpc.Invoke(ctx, "/admin.API/PromoteReplica", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) SetReadOnly(ctx context.Context, in *SetReadOnlyRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
+	out := new(google_protobuf.Empty)
+	err := grpc.Invoke(ctx, "/admin.API/SetReadOnly", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) CreateMirror(ctx context.Context, in *CreateMirrorRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
+	out := new(google_protobuf.Empty)
+	err := grpc.Invoke(ctx, "/admin.API/CreateMirror", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) ListMirror(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*MirrorInfos, error) {
+	out := new(MirrorInfos)
+	err := grpc.Invoke(ctx, "/admin.API/ListMirror", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) DeleteMirror(ctx context.Context, in *DeleteMirrorRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
+	out := new(google_protobuf.Empty)
+	err := grpc.Invoke(ctx, "/admin.API/DeleteMirror", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for API service
+
+type APIServer interface {
+	InspectCluster(context.Context, *google_protobuf.Empty) (*ClusterInfo, error)
+	// Extract writes a backup of the cluster, see admin.Extract.
+	Extract(*ExtractRequest, API_ExtractServer) error
+	// Restore restores a backup written by Extract.
+	Restore(API_RestoreServer) error
+	// ExportRepo writes a tar archive of a repo, see admin.ExportRepo.
+	ExportRepo(*ExportRepoRequest, API_ExportRepoServer) error
+	// ImportRepo imports an archive written by ExportRepo.
+	ImportRepo(API_ImportRepoServer) error
+	// InspectReplication returns the cluster's ReplicationStatus.
+	InspectReplication(context.Context, *google_protobuf.Empty) (*ReplicationStatus, error)
+	// PromoteReplica stops a secondary restoring the primary's backups, so
+	// that it can take over from the primary.
+	PromoteReplica(context.Context, *google_protobuf.Empty) (*google_protobuf.Empty, error)
+	// SetReadOnly makes the cluster read-only, so that requests which would
+	// change it are rejected, or writable again.
+	SetReadOnly(context.Context, *SetReadOnlyRequest) (*google_protobuf.Empty, error)
+	// CreateMirror starts copying the commits on a branch to another cluster,
+	// see MirrorSpec.
+	CreateMirror(context.Context, *CreateMirrorRequest) (*google_protobuf.Empty, error)
+	// ListMirror returns the cluster's mirrors and how far they've got.
+	ListMirror(context.Context, *google_protobuf.Empty) (*MirrorInfos, error)
+	// DeleteMirror stops a mirror. What it has copied is left in the other
+	// cluster.
+	DeleteMirror(context.Context, *DeleteMirrorRequest) (*google_protobuf.Empty, error)
+}
+
+func RegisterAPIServer(s *grpc.Server, srv APIServer) {
+	s.RegisterService(&_API_serviceDesc, srv)
+}
+
+func _API_InspectCluster_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(google_protobuf.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).InspectCluster(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/admin.API/InspectCluster",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).InspectCluster(ctx, req.(*google_protobuf.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_Extract_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExtractRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(APIServer).Extract(m, &aPIExtractServer{stream})
+}
+
+type API_ExtractServer interface {
+	Send(*ExtractResponse) error
+	grpc.ServerStream
+}
+
+type aPIExtractServer struct {
+	grpc.ServerStream
+}
+
+func (x *aPIExtractServer) Send(m *ExtractResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _API_Restore_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(APIServer).Restore(&aPIRestoreServer{stream})
+}
+
+type API_RestoreServer interface {
+	SendAndClose(*google_protobuf.Empty) error
+	Recv() (*RestoreRequest, error)
+	grpc.ServerStream
+}
+
+type aPIRestoreServer struct {
+	grpc.ServerStream
+}
+
+func (x *aPIRestoreServer) SendAndClose(m *google_protobuf.Empty) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *aPIRestoreServer) Recv() (*RestoreRequest, error) {
+	m := new(RestoreRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _API_ExportRepo_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportRepoRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(APIServer).ExportRepo(m, &aPIExportRepoServer{stream})
+}
+
+type API_ExportRepoServer interface {
+	Send(*ExportRepoResponse) error
+	grpc.ServerStream
+}
+
+type aPIExportRepoServer struct {
+	grpc.ServerStream
+}
+
+func (x *aPIExportRepoServer) Send(m *ExportRepoResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _API_ImportRepo_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(APIServer).ImportRepo(&aPIImportRepoServer{stream})
+}
+
+type API_ImportRepoServer interface {
+	SendAndClose(*google_protobuf.Empty) error
+	Recv() (*ImportRepoRequest, error)
+	grpc.ServerStream
+}
+
+type aPIImportRepoServer struct {
+	grpc.ServerStream
+}
+
+func (x *aPIImportRepoServer) SendAndClose(m *google_protobuf.Empty) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *aPIImportRepoServer) Recv() (*ImportRepoRequest, error) {
+	m := new(ImportRepoRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _API_InspectReplication_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(google_protobuf.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).InspectReplication(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/admin.API/InspectReplication",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).InspectReplication(ctx, req.(*google_protobuf.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_PromoteReplica_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(google_protobuf.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).PromoteReplica(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/admin.API/PromoteReplica",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).PromoteReplica(ctx, req.(*google_protobuf.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_SetReadOnly_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetReadOnlyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).SetReadOnly(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/admin.API/SetReadOnly",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).SetReadOnly(ctx, req.(*SetReadOnlyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_CreateMirror_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateMirrorRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).CreateMirror(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/admin.API/CreateMirror",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).CreateMirror(ctx, req.(*CreateMirrorRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_ListMirror_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(google_protobuf.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).ListMirror(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/admin.API/ListMirror",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).ListMirror(ctx, req.(*google_protobuf.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_DeleteMirror_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteMirrorRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).DeleteMirror(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/admin.API/DeleteMirror",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).DeleteMirror(ctx, req.(*DeleteMirrorRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _API_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "admin.API",
+	HandlerType: (*APIServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "InspectCluster",
+			Handler:    _API_InspectCluster_Handler,
+		},
+		{
+			MethodName: "InspectReplication",
+			Handler:    _API_InspectReplication_Handler,
+		},
+		{
+			MethodName: "PromoteReplica",
+			Handler:    _API_PromoteReplica_Handler,
+		},
+		{
+			MethodName: "SetReadOnly",
+			Handler:    _API_SetReadOnly_Handler,
+		},
+		{
+			MethodName: "CreateMirror",
+			Handler:    _API_CreateMirror_Handler,
+		},
+		{
+			MethodName: "ListMirror",
+			Handler:    _API_ListMirror_Handler,
+		},
+		{
+			MethodName: "DeleteMirror",
+			Handler:    _API_DeleteMirror_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Extract",
+			Handler:       _API_Extract_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Restore",
+			Handler:       _API_Restore_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ExportRepo",
+			Handler:       _API_ExportRepo_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ImportRepo",
+			Handler:       _API_ImportRepo_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "client/admin/admin.proto",
+}
+
+func init() { proto.RegisterFile("client/admin/admin.proto", fileDescriptorAdmin) }
+
+var fileDescriptorAdmin = []byte{
+	// 1279 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x8d, 0x56, 0x5b, 0x6f, 0xe3, 0x44,
+	0x14, 0x6e, 0x92, 0xb6, 0x49, 0x4e, 0xb2, 0x69, 0x3b, 0xdb, 0xed, 0x9a, 0x14, 0xb4, 0xc5, 0xe2,
+	0x52, 0x56, 0x28, 0x5d, 0x05, 0x04, 0x12, 0x2c, 0x48, 0xbd, 0x44, 0x22, 0xd0, 0x36, 0x95, 0xb3,
+	0x80, 0x78, 0xb2, 0x1c, 0x7b, 0x9a, 0x78, 0x6b, 0x7b, 0x8c, 0x3d, 0x59, 0x5a, 0xfe, 0x0e, 0xff,
+	0x02, 0xf1, 0x37, 0x78, 0x45, 0x88, 0x07, 0x7e, 0x07, 0x67, 0x2e, 0x76, 0x4c, 0x2e, 0xdd, 0x7d,
+	0x49, 0xe6, 0x7c, 0xe7, 0xcc, 0xb9, 0xcc, 0xf9, 0xe6, 0x8c, 0xc1, 0x70, 0x03, 0x9f, 0x46, 0xfc,
+	0xc8, 0xf1, 0x42, 0x3f, 0x52, 0xbf, 0x9d, 0x38, 0x61, 0x9c, 0x91, 0x0d, 0x29, 0xb4, 0xf7, 0xc7,
+	0x8c, 0x8d, 0x03, 0x7a, 0x24, 0xc1, 0xd1, 0xf4, 0xfa, 0x88, 0x86, 0x31, 0xbf, 0x53, 0x36, 0xed,
+	0x27, 0xf3, 0x4a, 0xee, 0x87, 0x34, 0xe5, 0x4e, 0x18, 0x6b, 0x83, 0x0f, 0xb4, 0xfb, 0x57, 0x34,
+	0x49, 0x7d, 0x16, 0x65, 0xff, 0xf1, 0x28, 0x5b, 0x69, 0xbb, 0xdd, 0x31, 0x1b, 0x33, 0xb9, 0x3c,
+	0x12, 0x2b, 0x85, 0x9a, 0x7f, 0xaf, 0x43, 0xe3, 0x34, 0x98, 0xa6, 0x9c, 0x26, 0xfd, 0xe8, 0x9a,
+	0x91, 0x3d, 0x28, 0xfb, 0x9e, 0x51, 0x3a, 0x28, 0x1d, 0xd6, 0x4f, 0x36, 0xff, 0xf9, 0xeb, 0x49,
+	0xb9, 0x7f, 0x66, 0x21, 0x42, 0x3e, 0x86, 0xaa, 0x76, 0x67, 0x94, 0x51, 0xd9, 0xe8, 0x92, 0x4e,
+	0x1e, 0xa8, 0xf3, 0x83, 0x5a, 0x59, 0x99, 0x09, 0x79, 0x07, 0x20, 0x9a, 0x86, 0x76, 0x3a, 0x71,
+	0x12, 0x2f, 0x35, 0x2a, 0xb8, 0x61, 0xdd, 0xaa, 0x23, 0x32, 0x94, 0x00, 0x79, 0x1b, 0xea, 0x91,
+	0x83, 0x55, 0xc4, 0x8e, 0x4b, 0x8d, 0x75, 0x11, 0xcb, 0x9a, 0x01, 0xe4, 0x43, 0xd8, 0x4a, 0x39,
+	0x4b, 0x9c, 0x31, 0xb5, 0x47, 0x8e, 0x7b, 0x43, 0x23, 0xcf, 0xd8, 0x90, 0x36, 0x2d, 0x0d, 0x9f,
+	0x28, 0x94, 0x1c, 0xc2, 0xf6, 0x28, 0x60, 0xee, 0x8d, 0xed, 0x3a, 0xee, 0x84, 0xda, 0xa9, 0xff,
+	0x2b, 0x35, 0x36, 0x95, 0xa5, 0xc4, 0x4f, 0x05, 0x3c, 0x44, 0x94, 0xbc, 0x07, 0xad, 0xf8, 0x3a,
+	0x2d, 0xda, 0x55, 0xa5, 0x5d, 0x13, 0xd1, 0x99, 0xd5, 0x01, 0x34, 0x43, 0xe7, 0xd6, 0x0e, 0xd3,
+	0xb1, 0xb2, 0xa9, 0x49, 0x1b, 0x40, 0xec, 0x22, 0x1d, 0x4b, 0x8b, 0x77, 0xa1, 0xf9, 0x0b, 0x4b,
+	0x6e, 0x68, 0x62, 0xfb, 0x21, 0x26, 0x62, 0xd4, 0xa5, 0x45, 0x43, 0x61, 0x7d, 0x01, 0x91, 0x67,
+	0xb0, 0xab, 0x4d, 0x52, 0xdf, 0xa3, 0xae, 0x93, 0x99, 0x82, 0x34, 0x25, 0x4a, 0x37, 0x54, 0x2a,
+	0xb5, 0xe3, 0x73, 0x30, 0x8a, 0x4e, 0xed, 0x78, 0x1a, 0x04, 0x76, 0xcc, 0x02, 0xdf, 0xbd, 0x33,
+	0x1a, 0x72, 0xd7, 0xa3, 0x42, 0x80, 0x2b, 0xd4, 0x5e, 0x49, 0x25, 0xd9, 0x87, 0x7a, 0xc0, 0xc6,
+	0x76, 0x40, 0x5f, 0xd1, 0xc0, 0x68, 0x4a, 0xcb, 0x1a, 0x02, 0xe7, 0x42, 0x26, 0x06, 0x54, 0x43,
+	0xca, 0x13, 0xdf, 0x4d, 0x8d, 0x07, 0xa8, 0xaa, 0x59, 0x99, 0x48, 0xbe, 0x80, 0x26, 0xe5, 0xae,
+	0x67, 0x87, 0x34, 0x1c, 0x61, 0xbf, 0x8c, 0xd6, 0x41, 0x05, 0xfb, 0xf9, 0xb8, 0xa3, 0x98, 0xd9,
+	0x43, 0xd5, 0x85, 0xd4, 0x0c, 0xb9, 0xc3, 0xa7, 0xa9, 0xd5, 0xa0, 0x39, 0x92, 0x92, 0x2e, 0xd4,
+	0x13, 0xea, 0x78, 0x36, 0x8b, 0x82, 0x3b, 0x63, 0x4b, 0x12, 0xe1, 0x91, 0xde, 0x68, 0x21, 0x3e,
+	0x40, 0x58, 0x6f, 0xab, 0x25, 0x5a, 0x36, 0x7f, 0x2b, 0xc1, 0xf6, 0xbc, 0x57, 0x42, 0x60, 0x5d,
+	0x74, 0x5c, 0x31, 0xcd, 0x92, 0x6b, 0xd2, 0x86, 0x1a, 0xb6, 0x35, 0x66, 0x7e, 0xc4, 0x25, 0xc9,
+	0xb0, 0x9c, 0x4c, 0x16, 0xe5, 0x4c, 0xa8, 0x13, 0xf0, 0xc9, 0x9d, 0xa4, 0x13, 0x96, 0xa3, 0x45,
+	0x64, 0xec, 0x66, 0x80, 0xa1, 0x68, 0x22, 0x99, 0x54, 0xb3, 0xb4, 0x44, 0x1e, 0x43, 0xd5, 0x1b,
+	0xa9, 0x46, 0x0a, 0xfa, 0x54, 0xac, 0x4d, 0x6f, 0x24, 0x9b, 0xb8, 0x0b, 0x1b, 0x34, 0x49, 0x58,
+	0xa2, 0xb9, 0xa2, 0x04, 0xf3, 0x47, 0x68, 0xf5, 0x6e, 0x79, 0xe2, 0xb8, 0xdc, 0xa2, 0x3f, 0x4f,
+	0xf1, 0x86, 0x91, 0x6d, 0xa8, 0x7c, 0x6f, 0x9d, 0xeb, 0x0c, 0xc5, 0x52, 0xd2, 0x9a, 0xd9, 0x6c,
+	0xf4, 0x92, 0xba, 0x3c, 0x95, 0x29, 0xd6, 0x90, 0xb8, 0x6c, 0xa0, 0x00, 0xe1, 0x38, 0xf5, 0x23,
+	0xa4, 0x74, 0x45, 0x39, 0x96, 0x82, 0xf9, 0x15, 0x6c, 0xe5, 0x8e, 0xd3, 0x98, 0x45, 0x29, 0x15,
+	0xc5, 0x7b, 0x0e, 0x77, 0xa4, 0xeb, 0xa6, 0x25, 0xd7, 0xa2, 0x8c, 0xd0, 0x11, 0x5d, 0xd6, 0xa5,
+	0x6b, 0xc9, 0xfc, 0x0c, 0x5a, 0xb8, 0x0f, 0x89, 0x4f, 0xb3, 0xbc, 0x96, 0xed, 0xd6, 0xb9, 0x96,
+	0xf3, 0x5c, 0xcd, 0xef, 0x60, 0xa7, 0x77, 0x1b, 0xb3, 0x04, 0xa3, 0xc6, 0xac, 0xb0, 0x35, 0x41,
+	0x31, 0x3b, 0x75, 0xb1, 0x16, 0xd8, 0x75, 0xc2, 0x42, 0xbd, 0x57, 0xae, 0x49, 0x0b, 0xca, 0x9c,
+	0xe9, 0x32, 0x70, 0x65, 0x1e, 0x02, 0x29, 0x3a, 0x5b, 0x5d, 0x86, 0xf9, 0x25, 0xec, 0xf4, 0xc3,
+	0x25, 0x61, 0x17, 0x32, 0xce, 0x52, 0x29, 0xcf, 0x52, 0x31, 0xff, 0x2c, 0xc1, 0x0e, 0xee, 0x43,
+	0x72, 0x3b, 0x1c, 0xc7, 0x88, 0xa6, 0xca, 0x53, 0xb4, 0x64, 0x81, 0xa2, 0x4a, 0xab, 0xbb, 0x97,
+	0xd3, 0x2d, 0xb7, 0xb3, 0x50, 0x6b, 0x49, 0x9b, 0xc5, 0x73, 0x28, 0x9c, 0x6b, 0xa5, 0x78, 0xae,
+	0x78, 0x0b, 0x20, 0xd1, 0x2e, 0xa8, 0x27, 0xa9, 0xd3, 0xe8, 0xb6, 0x3b, 0x6a, 0xd8, 0x76, 0xb2,
+	0x61, 0xdb, 0x79, 0x91, 0x0d, 0x5b, 0xab, 0x60, 0x2d, 0xc8, 0x18, 0x23, 0x33, 0xfd, 0x68, 0xac,
+	0xa9, 0x95, 0x89, 0x2b, 0xb8, 0x75, 0x27, 0x7a, 0x58, 0xbc, 0x1d, 0x22, 0x2b, 0xbc, 0x1f, 0x29,
+	0x4e, 0x53, 0xd5, 0x0a, 0x2d, 0x09, 0x86, 0xc5, 0xce, 0x34, 0xa5, 0xf6, 0x4b, 0x36, 0xca, 0x19,
+	0x26, 0x91, 0x6f, 0x11, 0xc0, 0xe1, 0x52, 0x60, 0xd8, 0xfd, 0xf9, 0x6a, 0xf6, 0x4d, 0x80, 0x0c,
+	0x29, 0xcf, 0xa2, 0x67, 0x0d, 0xd9, 0x2f, 0x5e, 0xe3, 0x92, 0x8c, 0x92, 0xdf, 0xd7, 0x42, 0x6e,
+	0xe5, 0x7b, 0x72, 0xab, 0xcc, 0xe5, 0x66, 0xfe, 0x5e, 0x02, 0xb8, 0xf0, 0x45, 0xbd, 0xc3, 0x98,
+	0xba, 0x4b, 0x2f, 0xf8, 0x92, 0x9e, 0x8b, 0x68, 0xa3, 0xc4, 0x89, 0xdc, 0x49, 0xd6, 0x1f, 0x25,
+	0x89, 0x33, 0x76, 0x3c, 0x2f, 0xa1, 0x69, 0xaa, 0x5f, 0x88, 0x4c, 0x14, 0x67, 0xcc, 0x19, 0x3e,
+	0x00, 0xfa, 0x55, 0x50, 0x82, 0x28, 0xc9, 0xc3, 0xd2, 0x6c, 0x19, 0x40, 0x9d, 0x7e, 0x4d, 0x00,
+	0x82, 0x87, 0xe4, 0x09, 0x34, 0xa4, 0x52, 0x47, 0x52, 0xc3, 0x1f, 0x04, 0x74, 0x22, 0x11, 0xf3,
+	0x8f, 0x3c, 0x79, 0xf9, 0x0a, 0xbe, 0x0f, 0xeb, 0x29, 0x16, 0x21, 0x93, 0x6f, 0x74, 0x77, 0x34,
+	0xe5, 0x66, 0xd5, 0x59, 0x52, 0x2d, 0x06, 0x56, 0x28, 0x31, 0x64, 0x90, 0x1e, 0x58, 0x99, 0x9c,
+	0x87, 0x74, 0x59, 0x18, 0xfa, 0x5c, 0x17, 0x27, 0x43, 0x9e, 0x4a, 0x84, 0x7c, 0x0a, 0xd5, 0x69,
+	0xec, 0xbd, 0x21, 0xfb, 0x32, 0xd3, 0x19, 0xc1, 0x36, 0x8a, 0x04, 0x7b, 0x0e, 0x0f, 0x4f, 0xb1,
+	0x4b, 0x9c, 0xaa, 0x14, 0xb3, 0x36, 0xbf, 0x59, 0x19, 0xe6, 0x31, 0x34, 0x66, 0xb5, 0x8b, 0x19,
+	0xdf, 0x50, 0x55, 0xd8, 0x3e, 0xca, 0xb8, 0xb9, 0xb2, 0xb0, 0x59, 0x18, 0xe2, 0xc3, 0x98, 0xaf,
+	0xcd, 0x8f, 0xe0, 0xe1, 0x19, 0x0d, 0xe8, 0x7c, 0x02, 0x4b, 0x48, 0xf0, 0xb4, 0x07, 0x5b, 0x73,
+	0x77, 0x97, 0xd4, 0x60, 0xfd, 0x72, 0x70, 0xd9, 0xdb, 0x5e, 0x23, 0x0d, 0xa8, 0x5e, 0x59, 0xfd,
+	0x8b, 0x63, 0xeb, 0xa7, 0xed, 0x12, 0x79, 0x00, 0xf5, 0x61, 0xef, 0x74, 0x70, 0x79, 0x26, 0xc4,
+	0x32, 0x69, 0x42, 0xed, 0xca, 0x1a, 0x5c, 0x0c, 0x5e, 0xf4, 0xce, 0xb6, 0x2b, 0xdd, 0x7f, 0x37,
+	0xa0, 0x72, 0x7c, 0xd5, 0x27, 0x5f, 0x43, 0xab, 0x1f, 0x89, 0x32, 0xb8, 0xfe, 0x8c, 0x21, 0x7b,
+	0x0b, 0xe7, 0xd8, 0x13, 0xdf, 0x53, 0x6d, 0xa2, 0x4b, 0x28, 0x7c, 0xee, 0x98, 0x6b, 0xe4, 0x39,
+	0x54, 0xf5, 0x78, 0x26, 0xd9, 0x4b, 0xf6, 0xff, 0x77, 0xa0, 0xbd, 0x37, 0x0f, 0xab, 0xf1, 0x67,
+	0xae, 0x3d, 0x2b, 0x89, 0xdd, 0x7a, 0x3a, 0x93, 0xd9, 0x3b, 0x58, 0x9c, 0xd6, 0xed, 0x15, 0xd9,
+	0x98, 0x6b, 0x87, 0x25, 0xd2, 0x03, 0x98, 0x8d, 0x55, 0x62, 0xe4, 0x71, 0xe6, 0xe6, 0x67, 0xfb,
+	0xad, 0x25, 0x9a, 0x42, 0x12, 0x27, 0x00, 0xb3, 0x99, 0x9b, 0xbb, 0x59, 0x18, 0xc3, 0xf7, 0xa6,
+	0xf2, 0x0d, 0x10, 0x7d, 0x8c, 0x85, 0xe6, 0xac, 0x3c, 0x4a, 0x63, 0x71, 0x08, 0xab, 0xc1, 0x86,
+	0x07, 0x7a, 0x02, 0xad, 0x2b, 0x7c, 0x43, 0x18, 0xa7, 0x5a, 0xbb, 0xd2, 0xcb, 0xca, 0x7c, 0xd0,
+	0x47, 0xa3, 0x30, 0xb5, 0x48, 0x56, 0xff, 0xe2, 0x24, 0xbb, 0xc7, 0xc7, 0x19, 0x34, 0x8b, 0x77,
+	0x82, 0xb4, 0xb3, 0xf6, 0x2f, 0x5e, 0x94, 0x7b, 0xbc, 0xe0, 0x33, 0x71, 0xee, 0xa7, 0x5c, 0xfb,
+	0x78, 0x1d, 0xb5, 0x0a, 0xd7, 0x48, 0x65, 0x50, 0xbc, 0x14, 0x79, 0x06, 0x4b, 0x6e, 0xca, 0xea,
+	0x0c, 0x46, 0x9b, 0x12, 0xf9, 0xe4, 0x3f, 0x33, 0xe7, 0x56, 0x72, 0x47, 0x0c, 0x00, 0x00,
+}
@@ -0,0 +1,218 @@
+package client
+
+import (
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/pachyderm/pachyderm/src/client/auth"
+)
+
+// Activate turns auth on, after which every PFS and PPS request must carry a
+// token (see WithAuthToken). It returns a token for subject, the user who's
+// activating auth.
+func (c APIClient) Activate(subject string) (string, error) {
+	response, err := c.AuthAPIClient.Activate(
+		c.ctx(),
+		&auth.ActivateRequest{
+			Subject: subject,
+		},
+	)
+	if err != nil {
+		return "", sanitizeErr(err)
+	}
+	return response.PachToken, nil
+}
+
+// Deactivate turns auth off and revokes every token that pachd has issued.
+func (c APIClient) Deactivate() error {
+	_, err := c.AuthAPIClient.Deactivate(
+		c.ctx(),
+		&auth.DeactivateRequest{},
+	)
+	return sanitizeErr(err)
+}
+
+// WhoAmI returns the user that the client's token authenticates.
+func (c APIClient) WhoAmI() (string, error) {
+	response, err := c.AuthAPIClient.WhoAmI(
+		c.ctx(),
+		&auth.WhoAmIRequest{},
+	)
+	if err != nil {
+		return "", sanitizeErr(err)
+	}
+	return response.Username, nil
+}
+
+// GetToken issues a new token for subject, which expires after ttl, or
+// after pachd's default TTL if ttl is 0. Only admins can issue tokens for
+// other users.
+func (c APIClient) GetToken(subject string, ttl time.Duration) (string, error) {
+	response, err := c.AuthAPIClient.GetToken(
+		c.ctx(),
+		&auth.GetTokenRequest{
+			Subject:    subject,
+			TTLSeconds: int64(ttl / time.Second),
+		},
+	)
+	if err != nil {
+		return "", sanitizeErr(err)
+	}
+	return response.Token, nil
+}
+
+// GetOAuthLoginURL returns the page of an identity provider where users log
+// in to Pachyderm, and the provider that it belongs to, which is the one that
+// pachd is configured with if provider is auth.Provider_DEFAULT. Once they
+// have logged in, the provider sends them to redirectURI with state and a
+// code, which Authenticate exchanges for a token.
+func (c APIClient) GetOAuthLoginURL(provider auth.Provider, redirectURI string, state string) (string, auth.Provider, error) {
+	response, err := c.AuthAPIClient.GetOAuthLoginURL(
+		c.ctx(),
+		&auth.GetOAuthLoginURLRequest{
+			RedirectURI: redirectURI,
+			State:       state,
+			Provider:    provider,
+		},
+	)
+	if err != nil {
+		return "", 0, sanitizeErr(err)
+	}
+	return response.URL, response.Provider, nil
+}
+
+// Authenticate completes a login with provider, see GetOAuthLoginURL. It
+// returns a token for the user who logged in.
+func (c APIClient) Authenticate(provider auth.Provider, code string, redirectURI string) (string, error) {
+	request := &auth.AuthenticateRequest{
+		RedirectURI: redirectURI,
+	}
+	if provider == auth.Provider_OIDC {
+		request.OIDCCode = code
+	} else {
+		request.GitHubCode = code
+	}
+	response, err := c.AuthAPIClient.Authenticate(c.ctx(), request)
+	if err != nil {
+		return "", sanitizeErr(err)
+	}
+	return response.PachToken, nil
+}
+
+// GetACL returns repo's ACL, which is empty if repo doesn't have one.
+func (c APIClient) GetACL(repo string) (*auth.ACL, error) {
+	response, err := c.AuthAPIClient.GetACL(
+		c.ctx(),
+		&auth.GetACLRequest{
+			Repo: repo,
+		},
+	)
+	if err != nil {
+		return nil, sanitizeErr(err)
+	}
+	return response.ACL, nil
+}
+
+// SetScope sets the scope of username, a user or a group ("group:" followed
+// by its name), in repo's ACL. auth.Scope_NONE removes them from it.
+func (c APIClient) SetScope(repo string, username string, scope auth.Scope) error {
+	_, err := c.AuthAPIClient.SetScope(
+		c.ctx(),
+		&auth.SetScopeRequest{
+			Repo:     repo,
+			Username: username,
+			Scope:    scope,
+		},
+	)
+	return sanitizeErr(err)
+}
+
+// GetRobotToken issues a token for robot, e.g. a CI system, which only has
+// scopes, by repo (a pipeline's scope is that of its output repo). The token
+// expires after ttl, or never if ttl is 0. Only admins can issue robot
+// tokens.
+func (c APIClient) GetRobotToken(robot string, scopes map[string]auth.Scope, ttl time.Duration) (string, error) {
+	response, err := c.AuthAPIClient.GetRobotToken(
+		c.ctx(),
+		&auth.GetRobotTokenRequest{
+			Robot:      robot,
+			Scopes:     scopes,
+			TTLSeconds: int64(ttl / time.Second),
+		},
+	)
+	if err != nil {
+		return "", sanitizeErr(err)
+	}
+	return response.Token, nil
+}
+
+// ModifyAdmins adds the users (or groups, written "group:" followed by the
+// group's name) in add to the cluster's admins, and removes those in remove.
+// Only admins can modify the admins.
+func (c APIClient) ModifyAdmins(add []string, remove []string) error {
+	_, err := c.AuthAPIClient.ModifyAdmins(
+		c.ctx(),
+		&auth.ModifyAdminsRequest{
+			Add:    add,
+			Remove: remove,
+		},
+	)
+	return sanitizeErr(err)
+}
+
+// GetAdmins returns the cluster's admins.
+func (c APIClient) GetAdmins() ([]string, error) {
+	response, err := c.AuthAPIClient.GetAdmins(
+		c.ctx(),
+		&auth.GetAdminsRequest{},
+	)
+	if err != nil {
+		return nil, sanitizeErr(err)
+	}
+	return response.Admins, nil
+}
+
+// RenewToken extends the validity of c's token, so that it expires after
+// ttl, or after pachd's default TTL if ttl is 0. It returns when the token
+// now expires, which is the zero time if it doesn't.
+func (c APIClient) RenewToken(ttl time.Duration) (time.Time, error) {
+	response, err := c.AuthAPIClient.RenewToken(
+		c.ctx(),
+		&auth.RenewTokenRequest{
+			TTLSeconds: int64(ttl / time.Second),
+		},
+	)
+	if err != nil {
+		return time.Time{}, sanitizeErr(err)
+	}
+	if response.Expiration == nil {
+		return time.Time{}, nil
+	}
+	return types.TimestampFromProto(response.Expiration)
+}
+
+// RevokeToken revokes token, or c's own token if it's "".
+func (c APIClient) RevokeToken(token string) error {
+	_, err := c.AuthAPIClient.RevokeToken(
+		c.ctx(),
+		&auth.RevokeTokenRequest{
+			Token: token,
+		},
+	)
+	return sanitizeErr(err)
+}
+
+// RevokeUserTokens revokes every token of username and returns how many
+// there were. Users can revoke their own tokens, admins anyone's.
+func (c APIClient) RevokeUserTokens(username string) (int64, error) {
+	response, err := c.AuthAPIClient.RevokeUserTokens(
+		c.ctx(),
+		&auth.RevokeUserTokensRequest{
+			Username: username,
+		},
+	)
+	if err != nil {
+		return 0, sanitizeErr(err)
+	}
+	return response.Revoked, nil
+}
@@ -0,0 +1,1416 @@
+// Code generated by protoc-gen-gogo.
+// source: client/auth/auth.proto
+// DO NOT EDIT!
+
+/*
+Package auth is a generated protocol buffer package.
+
+It is generated from these files:
+	client/auth/auth.proto
+
+It has these top-level messages:
+	ActivateRequest
+	ActivateResponse
+	DeactivateRequest
+	DeactivateResponse
+	WhoAmIRequest
+	WhoAmIResponse
+	GetTokenRequest
+	GetTokenResponse
+	GetOAuthLoginURLRequest
+	GetOAuthLoginURLResponse
+	AuthenticateRequest
+	AuthenticateResponse
+	TokenInfo
+	ACL
+	GetACLRequest
+	GetACLResponse
+	SetScopeRequest
+	SetScopeResponse
+	GetRobotTokenRequest
+	GetRobotTokenResponse
+	Admins
+	ModifyAdminsRequest
+	ModifyAdminsResponse
+	GetAdminsRequest
+	GetAdminsResponse
+	RenewTokenRequest
+	RenewTokenResponse
+	RevokeTokenRequest
+	RevokeTokenResponse
+	RevokeUserTokensRequest
+	RevokeUserTokensResponse
+*/
+package auth
+
+import proto "github.com/gogo/protobuf/proto"
+import fmt "fmt"
+import math "math"
+import google_protobuf "github.com/gogo/protobuf/types"
+import _ "github.com/gogo/protobuf/gogoproto"
+
+import (
+	context "golang.org/x/net/context"
+	grpc "google.golang.org/grpc"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.GoGoProtoPackageIsVersion2 // please upgrade the proto package
+
+// Provider is an identity provider that users can log in with.
+type Provider int32
+
+const (
+	// DEFAULT is the provider that pachd is configured with, OIDC if it's
+	// configured with both.
+	Provider_DEFAULT Provider = 0
+	Provider_GITHUB  Provider = 1
+	Provider_OIDC    Provider = 2
+)
+
+var Provider_name = map[int32]string{
+	0: "DEFAULT",
+	1: "GITHUB",
+	2: "OIDC",
+}
+var Provider_value = map[string]int32{
+	"DEFAULT": 0,
+	"GITHUB":  1,
+	"OIDC":    2,
+}
+
+func (x Provider) String() string {
+	return proto.EnumName(Provider_name, int32(x))
+}
+func (Provider) EnumDescriptor() ([]byte, []int) { return fileDescriptorAuth, []int{0} }
+
+// Scope is the access that a user has to a repo. Each scope includes the
+// ones before it.
+type Scope int32
+
+const (
+	// NONE gives no access to the repo.
+	Scope_NONE Scope = 0
+	// READER can read the repo's commits and files, and use the repo as a
+	// pipeline's input.
+	Scope_READER Scope = 1
+	// WRITER can also start commits and write files to the repo, and manage
+	// the pipeline that outputs to it.
+	Scope_WRITER Scope = 2
+	// OWNER can also delete the repo and change its ACL.
+	Scope_OWNER Scope = 3
+)
+
+var Scope_name = map[int32]string{
+	0: "NONE",
+	1: "READER",
+	2: "WRITER",
+	3: "OWNER",
+}
+var Scope_value = map[string]int32{
+	"NONE":   0,
+	"READER": 1,
+	"WRITER": 2,
+	"OWNER":  3,
+}
+
+func (x Scope) String() string {
+	return proto.EnumName(Scope_name, int32(x))
+}
+func (Scope) EnumDescriptor() ([]byte, []int) { return fileDescriptorAuth, []int{1} }
+
+// ActivateRequest turns auth on. subject is the user who's activating it,
+// they're given the cluster's first token.
+type ActivateRequest struct {
+	Subject string `protobuf:"bytes,1,opt,name=subject,proto3" json:"subject,omitempty"`
+}
+
+func (m *ActivateRequest) Reset()                    { *m = ActivateRequest{} }
+func (m *ActivateRequest) String() string            { return proto.CompactTextString(m) }
+func (*ActivateRequest) ProtoMessage()               {}
+func (*ActivateRequest) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{0} }
+
+func (m *ActivateRequest) GetSubject() string {
+	if m != nil {
+		return m.Subject
+	}
+	return ""
+}
+
+type ActivateResponse struct {
+	// pach_token authenticates subject, it's sent to pachd with every request.
+	PachToken string `protobuf:"bytes,1,opt,name=pach_token,json=pachToken,proto3" json:"pach_token,omitempty"`
+}
+
+func (m *ActivateResponse) Reset()                    { *m = ActivateResponse{} }
+func (m *ActivateResponse) String() string            { return proto.CompactTextString(m) }
+func (*ActivateResponse) ProtoMessage()               {}
+func (*ActivateResponse) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{1} }
+
+func (m *ActivateResponse) GetPachToken() string {
+	if m != nil {
+		return m.PachToken
+	}
+	return ""
+}
+
+type DeactivateRequest struct {
+}
+
+func (m *DeactivateRequest) Reset()                    { *m = DeactivateRequest{} }
+func (m *DeactivateRequest) String() string            { return proto.CompactTextString(m) }
+func (*DeactivateRequest) ProtoMessage()               {}
+func (*DeactivateRequest) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{2} }
+
+type DeactivateResponse struct {
+}
+
+func (m *DeactivateResponse) Reset()                    { *m = DeactivateResponse{} }
+func (m *DeactivateResponse) String() string            { return proto.CompactTextString(m) }
+func (*DeactivateResponse) ProtoMessage()               {}
+func (*DeactivateResponse) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{3} }
+
+type WhoAmIRequest struct {
+}
+
+func (m *WhoAmIRequest) Reset()                    { *m = WhoAmIRequest{} }
+func (m *WhoAmIRequest) String() string            { return proto.CompactTextString(m) }
+func (*WhoAmIRequest) ProtoMessage()               {}
+func (*WhoAmIRequest) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{4} }
+
+type WhoAmIResponse struct {
+	Username string `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+}
+
+func (m *WhoAmIResponse) Reset()                    { *m = WhoAmIResponse{} }
+func (m *WhoAmIResponse) String() string            { return proto.CompactTextString(m) }
+func (*WhoAmIResponse) ProtoMessage()               {}
+func (*WhoAmIResponse) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{5} }
+
+func (m *WhoAmIResponse) GetUsername() string {
+	if m != nil {
+		return m.Username
+	}
+	return ""
+}
+
+// GetTokenRequest issues a new token for subject.
+type GetTokenRequest struct {
+	Subject string `protobuf:"bytes,1,opt,name=subject,proto3" json:"subject,omitempty"`
+	// ttl_seconds is how long the token is valid for, 0 means pachd's default
+	// TTL. Only admins can issue tokens with longer TTLs than the default.
+	TTLSeconds int64 `protobuf:"varint,2,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"`
+}
+
+func (m *GetTokenRequest) Reset()                    { *m = GetTokenRequest{} }
+func (m *GetTokenRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetTokenRequest) ProtoMessage()               {}
+func (*GetTokenRequest) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{6} }
+
+func (m *GetTokenRequest) GetSubject() string {
+	if m != nil {
+		return m.Subject
+	}
+	return ""
+}
+
+func (m *GetTokenRequest) GetTTLSeconds() int64 {
+	if m != nil {
+		return m.TTLSeconds
+	}
+	return 0
+}
+
+type GetTokenResponse struct {
+	Token string `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+}
+
+func (m *GetTokenResponse) Reset()                    { *m = GetTokenResponse{} }
+func (m *GetTokenResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetTokenResponse) ProtoMessage()               {}
+func (*GetTokenResponse) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{7} }
+
+func (m *GetTokenResponse) GetToken() string {
+	if m != nil {
+		return m.Token
+	}
+	return ""
+}
+
+type GetOAuthLoginURLRequest struct {
+	// redirect_uri is where GitHub sends the user once they've logged in, with
+	// a code that can be passed to Authenticate.
+	RedirectURI string `protobuf:"bytes,1,opt,name=redirect_uri,json=redirectUri,proto3" json:"redirect_uri,omitempty"`
+	// state is passed back to redirect_uri unchanged.
+	State    string   `protobuf:"bytes,2,opt,name=state,proto3" json:"state,omitempty"`
+	Provider Provider `protobuf:"varint,3,opt,name=provider,proto3,enum=auth.Provider" json:"provider,omitempty"`
+}
+
+func (m *GetOAuthLoginURLRequest) Reset()                    { *m = GetOAuthLoginURLRequest{} }
+func (m *GetOAuthLoginURLRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetOAuthLoginURLRequest) ProtoMessage()               {}
+func (*GetOAuthLoginURLRequest) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{8} }
+
+func (m *GetOAuthLoginURLRequest) GetRedirectURI() string {
+	if m != nil {
+		return m.RedirectURI
+	}
+	return ""
+}
+
+func (m *GetOAuthLoginURLRequest) GetState() string {
+	if m != nil {
+		return m.State
+	}
+	return ""
+}
+
+func (m *GetOAuthLoginURLRequest) GetProvider() Provider {
+	if m != nil {
+		return m.Provider
+	}
+	return 0
+}
+
+type GetOAuthLoginURLResponse struct {
+	// url is the identity provider's page where the user logs in.
+	URL string `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	// provider is the identity provider that url belongs to, the code that it
+	// sends to redirect_uri should be passed to Authenticate as github_code or
+	// oidc_code accordingly.
+	Provider Provider `protobuf:"varint,2,opt,name=provider,proto3,enum=auth.Provider" json:"provider,omitempty"`
+}
+
+func (m *GetOAuthLoginURLResponse) Reset()                    { *m = GetOAuthLoginURLResponse{} }
+func (m *GetOAuthLoginURLResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetOAuthLoginURLResponse) ProtoMessage()               {}
+func (*GetOAuthLoginURLResponse) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{9} }
+
+func (m *GetOAuthLoginURLResponse) GetURL() string {
+	if m != nil {
+		return m.URL
+	}
+	return ""
+}
+
+func (m *GetOAuthLoginURLResponse) GetProvider() Provider {
+	if m != nil {
+		return m.Provider
+	}
+	return 0
+}
+
+type AuthenticateRequest struct {
+	// github_code is the code that GitHub sends to the redirect_uri of a
+	// GetOAuthLoginURLRequest once the user has logged in.
+	GitHubCode string `protobuf:"bytes,1,opt,name=github_code,json=githubCode,proto3" json:"github_code,omitempty"`
+	// redirect_uri must be the one the code was sent to.
+	RedirectURI string `protobuf:"bytes,2,opt,name=redirect_uri,json=redirectUri,proto3" json:"redirect_uri,omitempty"`
+	// oidc_code is the code that the OIDC provider sends to the redirect_uri
+	// of a GetOAuthLoginURLRequest once the user has logged in.
+	OIDCCode string `protobuf:"bytes,3,opt,name=oidc_code,json=oidcCode,proto3" json:"oidc_code,omitempty"`
+}
+
+func (m *AuthenticateRequest) Reset()                    { *m = AuthenticateRequest{} }
+func (m *AuthenticateRequest) String() string            { return proto.CompactTextString(m) }
+func (*AuthenticateRequest) ProtoMessage()               {}
+func (*AuthenticateRequest) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{10} }
+
+func (m *AuthenticateRequest) GetGitHubCode() string {
+	if m != nil {
+		return m.GitHubCode
+	}
+	return ""
+}
+
+func (m *AuthenticateRequest) GetRedirectURI() string {
+	if m != nil {
+		return m.RedirectURI
+	}
+	return ""
+}
+
+func (m *AuthenticateRequest) GetOIDCCode() string {
+	if m != nil {
+		return m.OIDCCode
+	}
+	return ""
+}
+
+type AuthenticateResponse struct {
+	PachToken string `protobuf:"bytes,1,opt,name=pach_token,json=pachToken,proto3" json:"pach_token,omitempty"`
+}
+
+func (m *AuthenticateResponse) Reset()                    { *m = AuthenticateResponse{} }
+func (m *AuthenticateResponse) String() string            { return proto.CompactTextString(m) }
+func (*AuthenticateResponse) ProtoMessage()               {}
+func (*AuthenticateResponse) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{11} }
+
+func (m *AuthenticateResponse) GetPachToken() string {
+	if m != nil {
+		return m.PachToken
+	}
+	return ""
+}
+
+// TokenInfo is what pachd stores about each token it has issued.
+type TokenInfo struct {
+	// subject is the user that the token authenticates.
+	Subject string `protobuf:"bytes,1,opt,name=subject,proto3" json:"subject,omitempty"`
+	// groups are the groups that the identity provider said subject is in
+	// when they logged in.
+	Groups []string `protobuf:"bytes,2,rep,name=groups" json:"groups,omitempty"`
+	// robot is set for robot tokens (see GetRobotToken), which only have the
+	// scopes in robot_scopes, whatever the repos' ACLs say.
+	Robot       bool             `protobuf:"varint,3,opt,name=robot,proto3" json:"robot,omitempty"`
+	RobotScopes map[string]Scope `protobuf:"bytes,4,rep,name=robot_scopes,json=robotScopes" json:"robot_scopes,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3,enum=auth.Scope"`
+	// expiration is when the token stops being valid, it's unset if it
+	// doesn't expire.
+	Expiration *google_protobuf.Timestamp `protobuf:"bytes,5,opt,name=expiration" json:"expiration,omitempty"`
+}
+
+func (m *TokenInfo) Reset()                    { *m = TokenInfo{} }
+func (m *TokenInfo) String() string            { return proto.CompactTextString(m) }
+func (*TokenInfo) ProtoMessage()               {}
+func (*TokenInfo) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{12} }
+
+func (m *TokenInfo) GetSubject() string {
+	if m != nil {
+		return m.Subject
+	}
+	return ""
+}
+
+func (m *TokenInfo) GetGroups() []string {
+	if m != nil {
+		return m.Groups
+	}
+	return nil
+}
+
+func (m *TokenInfo) GetRobot() bool {
+	if m != nil {
+		return m.Robot
+	}
+	return false
+}
+
+func (m *TokenInfo) GetRobotScopes() map[string]Scope {
+	if m != nil {
+		return m.RobotScopes
+	}
+	return nil
+}
+
+func (m *TokenInfo) GetExpiration() *google_protobuf.Timestamp {
+	if m != nil {
+		return m.Expiration
+	}
+	return nil
+}
+
+// ACL says who can access a repo.
+type ACL struct {
+	// entries maps usernames, and groups (written "group:" followed by the
+	// group's name), to their scopes.
+	Entries map[string]Scope `protobuf:"bytes,1,rep,name=entries" json:"entries,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3,enum=auth.Scope"`
+}
+
+func (m *ACL) Reset()                    { *m = ACL{} }
+func (m *ACL) String() string            { return proto.CompactTextString(m) }
+func (*ACL) ProtoMessage()               {}
+func (*ACL) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{13} }
+
+func (m *ACL) GetEntries() map[string]Scope {
+	if m != nil {
+		return m.Entries
+	}
+	return nil
+}
+
+type GetACLRequest struct {
+	Repo string `protobuf:"bytes,1,opt,name=repo,proto3" json:"repo,omitempty"`
+}
+
+func (m *GetACLRequest) Reset()                    { *m = GetACLRequest{} }
+func (m *GetACLRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetACLRequest) ProtoMessage()               {}
+func (*GetACLRequest) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{14} }
+
+func (m *GetACLRequest) GetRepo() string {
+	if m != nil {
+		return m.Repo
+	}
+	return ""
+}
+
+type GetACLResponse struct {
+	ACL *ACL `protobuf:"bytes,1,opt,name=acl" json:"acl,omitempty"`
+}
+
+func (m *GetACLResponse) Reset()                    { *m = GetACLResponse{} }
+func (m *GetACLResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetACLResponse) ProtoMessage()               {}
+func (*GetACLResponse) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{15} }
+
+func (m *GetACLResponse) GetACL() *ACL {
+	if m != nil {
+		return m.ACL
+	}
+	return nil
+}
+
+type SetScopeRequest struct {
+	Repo string `protobuf:"bytes,1,opt,name=repo,proto3" json:"repo,omitempty"`
+	// username is a user or a group, see ACL.
+	Username string `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
+	// scope replaces username's previous scope, NONE removes them from the
+	// ACL.
+	Scope Scope `protobuf:"varint,3,opt,name=scope,proto3,enum=auth.Scope" json:"scope,omitempty"`
+}
+
+func (m *SetScopeRequest) Reset()                    { *m = SetScopeRequest{} }
+func (m *SetScopeRequest) String() string            { return proto.CompactTextString(m) }
+func (*SetScopeRequest) ProtoMessage()               {}
+func (*SetScopeRequest) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{16} }
+
+func (m *SetScopeRequest) GetRepo() string {
+	if m != nil {
+		return m.Repo
+	}
+	return ""
+}
+
+func (m *SetScopeRequest) GetUsername() string {
+	if m != nil {
+		return m.Username
+	}
+	return ""
+}
+
+func (m *SetScopeRequest) GetScope() Scope {
+	if m != nil {
+		return m.Scope
+	}
+	return 0
+}
+
+type SetScopeResponse struct {
+}
+
+func (m *SetScopeResponse) Reset()                    { *m = SetScopeResponse{} }
+func (m *SetScopeResponse) String() string            { return proto.CompactTextString(m) }
+func (*SetScopeResponse) ProtoMessage()               {}
+func (*SetScopeResponse) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{17} }
+
+type GetRobotTokenRequest struct {
+	// robot is the robot's name, its username is "robot:" followed by it.
+	Robot string `protobuf:"bytes,1,opt,name=robot,proto3" json:"robot,omitempty"`
+	// scopes are the robot's scopes, by repo. A pipeline's scope is that of
+	// its output repo, which has the pipeline's name.
+	Scopes map[string]Scope `protobuf:"bytes,2,rep,name=scopes" json:"scopes,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3,enum=auth.Scope"`
+	// ttl_seconds is how long the token is valid for, 0 means it doesn't
+	// expire.
+	TTLSeconds int64 `protobuf:"varint,3,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"`
+}
+
+func (m *GetRobotTokenRequest) Reset()                    { *m = GetRobotTokenRequest{} }
+func (m *GetRobotTokenRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetRobotTokenRequest) ProtoMessage()               {}
+func (*GetRobotTokenRequest) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{18} }
+
+func (m *GetRobotTokenRequest) GetRobot() string {
+	if m != nil {
+		return m.Robot
+	}
+	return ""
+}
+
+func (m *GetRobotTokenRequest) GetScopes() map[string]Scope {
+	if m != nil {
+		return m.Scopes
+	}
+	return nil
+}
+
+func (m *GetRobotTokenRequest) GetTTLSeconds() int64 {
+	if m != nil {
+		return m.TTLSeconds
+	}
+	return 0
+}
+
+type GetRobotTokenResponse struct {
+	Token string `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+}
+
+func (m *GetRobotTokenResponse) Reset()                    { *m = GetRobotTokenResponse{} }
+func (m *GetRobotTokenResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetRobotTokenResponse) ProtoMessage()               {}
+func (*GetRobotTokenResponse) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{19} }
+
+func (m *GetRobotTokenResponse) GetToken() string {
+	if m != nil {
+		return m.Token
+	}
+	return ""
+}
+
+// Admins are the users who can make destructive, cluster-wide changes, see
+// ModifyAdmins.
+type Admins struct {
+	// usernames holds the admins' usernames, and groups (written "group:"
+	// followed by the group's name) whose members are all admins.
+	Usernames map[string]bool `protobuf:"bytes,1,rep,name=usernames" json:"usernames,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+}
+
+func (m *Admins) Reset()                    { *m = Admins{} }
+func (m *Admins) String() string            { return proto.CompactTextString(m) }
+func (*Admins) ProtoMessage()               {}
+func (*Admins) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{20} }
+
+func (m *Admins) GetUsernames() map[string]bool {
+	if m != nil {
+		return m.Usernames
+	}
+	return nil
+}
+
+// ModifyAdminsRequest adds users to and removes them from the cluster's
+// admins. Groups may be admins too, written "group:" followed by the
+// group's name.
+type ModifyAdminsRequest struct {
+	Add    []string `protobuf:"bytes,1,rep,name=add" json:"add,omitempty"`
+	Remove []string `protobuf:"bytes,2,rep,name=remove" json:"remove,omitempty"`
+}
+
+func (m *ModifyAdminsRequest) Reset()                    { *m = ModifyAdminsRequest{} }
+func (m *ModifyAdminsRequest) String() string            { return proto.CompactTextString(m) }
+func (*ModifyAdminsRequest) ProtoMessage()               {}
+func (*ModifyAdminsRequest) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{21} }
+
+func (m *ModifyAdminsRequest) GetAdd() []string {
+	if m != nil {
+		return m.Add
+	}
+	return nil
+}
+
+func (m *ModifyAdminsRequest) GetRemove() []string {
+	if m != nil {
+		return m.Remove
+	}
+	return nil
+}
+
+type ModifyAdminsResponse struct {
+}
+
+func (m *ModifyAdminsResponse) Reset()                    { *m = ModifyAdminsResponse{} }
+func (m *ModifyAdminsResponse) String() string            { return proto.CompactTextString(m) }
+func (*ModifyAdminsResponse) ProtoMessage()               {}
+func (*ModifyAdminsResponse) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{22} }
+
+type GetAdminsRequest struct {
+}
+
+func (m *GetAdminsRequest) Reset()                    { *m = GetAdminsRequest{} }
+func (m *GetAdminsRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetAdminsRequest) ProtoMessage()               {}
+func (*GetAdminsRequest) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{23} }
+
+type GetAdminsResponse struct {
+	Admins []string `protobuf:"bytes,1,rep,name=admins" json:"admins,omitempty"`
+}
+
+func (m *GetAdminsResponse) Reset()                    { *m = GetAdminsResponse{} }
+func (m *GetAdminsResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetAdminsResponse) ProtoMessage()               {}
+func (*GetAdminsResponse) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{24} }
+
+func (m *GetAdminsResponse) GetAdmins() []string {
+	if m != nil {
+		return m.Admins
+	}
+	return nil
+}
+
+// RenewTokenRequest extends the validity of the token that the request
+// carries.
+type RenewTokenRequest struct {
+	// ttl_seconds is how long the token is valid for from now, as in
+	// GetTokenRequest.
+	TTLSeconds int64 `protobuf:"varint,1,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"`
+}
+
+func (m *RenewTokenRequest) Reset()                    { *m = RenewTokenRequest{} }
+func (m *RenewTokenRequest) String() string            { return proto.CompactTextString(m) }
+func (*RenewTokenRequest) ProtoMessage()               {}
+func (*RenewTokenRequest) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{25} }
+
+func (m *RenewTokenRequest) GetTTLSeconds() int64 {
+	if m != nil {
+		return m.TTLSeconds
+	}
+	return 0
+}
+
+type RenewTokenResponse struct {
+	// expiration is when the token now expires, it's unset if it doesn't.
+	Expiration *google_protobuf.Timestamp `protobuf:"bytes,1,opt,name=expiration" json:"expiration,omitempty"`
+}
+
+func (m *RenewTokenResponse) Reset()                    { *m = RenewTokenResponse{} }
+func (m *RenewTokenResponse) String() string            { return proto.CompactTextString(m) }
+func (*RenewTokenResponse) ProtoMessage()               {}
+func (*RenewTokenResponse) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{26} }
+
+func (m *RenewTokenResponse) GetExpiration() *google_protobuf.Timestamp {
+	if m != nil {
+		return m.Expiration
+	}
+	return nil
+}
+
+type RevokeTokenRequest struct {
+	// token is the token to revoke, the one that the request carries if it's
+	// unset.
+	Token string `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+}
+
+func (m *RevokeTokenRequest) Reset()                    { *m = RevokeTokenRequest{} }
+func (m *RevokeTokenRequest) String() string            { return proto.CompactTextString(m) }
+func (*RevokeTokenRequest) ProtoMessage()               {}
+func (*RevokeTokenRequest) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{27} }
+
+func (m *RevokeTokenRequest) GetToken() string {
+	if m != nil {
+		return m.Token
+	}
+	return ""
+}
+
+type RevokeTokenResponse struct {
+}
+
+func (m *RevokeTokenResponse) Reset()                    { *m = RevokeTokenResponse{} }
+func (m *RevokeTokenResponse) String() string            { return proto.CompactTextString(m) }
+func (*RevokeTokenResponse) ProtoMessage()               {}
+func (*RevokeTokenResponse) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{28} }
+
+type RevokeUserTokensRequest struct {
+	Username string `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+}
+
+func (m *RevokeUserTokensRequest) Reset()                    { *m = RevokeUserTokensRequest{} }
+func (m *RevokeUserTokensRequest) String() string            { return proto.CompactTextString(m) }
+func (*RevokeUserTokensRequest) ProtoMessage()               {}
+func (*RevokeUserTokensRequest) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{29} }
+
+func (m *RevokeUserTokensRequest) GetUsername() string {
+	if m != nil {
+		return m.Username
+	}
+	return ""
+}
+
+type RevokeUserTokensResponse struct {
+	// revoked is the number of tokens that were revoked.
+	Revoked int64 `protobuf:"varint,1,opt,name=revoked,proto3" json:"revoked,omitempty"`
+}
+
+func (m *RevokeUserTokensResponse) Reset()                    { *m = RevokeUserTokensResponse{} }
+func (m *RevokeUserTokensResponse) String() string            { return proto.CompactTextString(m) }
+func (*RevokeUserTokensResponse) ProtoMessage()               {}
+func (*RevokeUserTokensResponse) Descriptor() ([]byte, []int) { return fileDescriptorAuth, []int{30} }
+
+func (m *RevokeUserTokensResponse) GetRevoked() int64 {
+	if m != nil {
+		return m.Revoked
+	}
+	return 0
+}
+
+func init() {
+	proto.RegisterType((*ActivateRequest)(nil), "auth.ActivateRequest")
+	proto.RegisterType((*ActivateResponse)(nil), "auth.ActivateResponse")
+	proto.RegisterType((*DeactivateRequest)(nil), "auth.DeactivateRequest")
+	proto.RegisterType((*DeactivateResponse)(nil), "auth.DeactivateResponse")
+	proto.RegisterType((*WhoAmIRequest)(nil), "auth.WhoAmIRequest")
+	proto.RegisterType((*WhoAmIResponse)(nil), "auth.WhoAmIResponse")
+	proto.RegisterType((*GetTokenRequest)(nil), "auth.GetTokenRequest")
+	proto.RegisterType((*GetTokenResponse)(nil), "auth.GetTokenResponse")
+	proto.RegisterType((*GetOAuthLoginURLRequest)(nil), "auth.GetOAuthLoginURLRequest")
+	proto.RegisterType((*GetOAuthLoginURLResponse)(nil), "auth.GetOAuthLoginURLResponse")
+	proto.RegisterType((*AuthenticateRequest)(nil), "auth.AuthenticateRequest")
+	proto.RegisterType((*AuthenticateResponse)(nil), "auth.AuthenticateResponse")
+	proto.RegisterType((*TokenInfo)(nil), "auth.TokenInfo")
+	proto.RegisterType((*ACL)(nil), "auth.ACL")
+	proto.RegisterType((*GetACLRequest)(nil), "auth.GetACLRequest")
+	proto.RegisterType((*GetACLResponse)(nil), "auth.GetACLResponse")
+	proto.RegisterType((*SetScopeRequest)(nil), "auth.SetScopeRequest")
+	proto.RegisterType((*SetScopeResponse)(nil), "auth.SetScopeResponse")
+	proto.RegisterType((*GetRobotTokenRequest)(nil), "auth.GetRobotTokenRequest")
+	proto.RegisterType((*GetRobotTokenResponse)(nil), "auth.GetRobotTokenResponse")
+	proto.RegisterType((*Admins)(nil), "auth.Admins")
+	proto.RegisterType((*ModifyAdminsRequest)(nil), "auth.ModifyAdminsRequest")
+	proto.RegisterType((*ModifyAdminsResponse)(nil), "auth.ModifyAdminsResponse")
+	proto.RegisterType((*GetAdminsRequest)(nil), "auth.GetAdminsRequest")
+	proto.RegisterType((*GetAdminsResponse)(nil), "auth.GetAdminsResponse")
+	proto.RegisterType((*RenewTokenRequest)(nil), "auth.RenewTokenRequest")
+	proto.RegisterType((*RenewTokenResponse)(nil), "auth.RenewTokenResponse")
+	proto.RegisterType((*RevokeTokenRequest)(nil), "auth.RevokeTokenRequest")
+	proto.RegisterType((*RevokeTokenResponse)(nil), "auth.RevokeTokenResponse")
+	proto.RegisterType((*RevokeUserTokensRequest)(nil), "auth.RevokeUserTokensRequest")
+	proto.RegisterType((*RevokeUserTokensResponse)(nil), "auth.RevokeUserTokensResponse")
+	proto.RegisterEnum("auth.Provider", Provider_name, Provider_value)
+	proto.RegisterEnum("auth.Scope", Scope_name, Scope_value)
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// Client API for API service
+
+type APIClient interface {
+	// Activate turns auth on, after which every PFS and PPS request must carry
+	// a token. It fails if auth is already active.
+	Activate(ctx context.Context, in *ActivateRequest, opts ...grpc.CallOption) (*ActivateResponse, error)
+	// Deactivate turns auth off and revokes every token. Only admins can
+	// deactivate auth.
+	Deactivate(ctx context.Context, in *DeactivateRequest, opts ...grpc.CallOption) (*DeactivateResponse, error)
+	// WhoAmI returns the user that the caller's token authenticates.
+	WhoAmI(ctx context.Context, in *WhoAmIRequest, opts ...grpc.CallOption) (*WhoAmIResponse, error)
+	// GetToken issues a token for a user.
+	GetToken(ctx context.Context, in *GetTokenRequest, opts ...grpc.CallOption) (*GetTokenResponse, error)
+	// RenewToken extends the validity of the caller's token. Robot tokens
+	// can't be renewed, admins issue new ones instead.
+	RenewToken(ctx context.Context, in *RenewTokenRequest, opts ...grpc.CallOption) (*RenewTokenResponse, error)
+	// RevokeToken revokes a token, which anyone who has it can do.
+	RevokeToken(ctx context.Context, in *RevokeTokenRequest, opts ...grpc.CallOption) (*RevokeTokenResponse, error)
+	// RevokeUserTokens revokes every token of a user, e.g. one whose token may
+	// have been stolen. Users can revoke their own tokens, admins anyone's.
+	RevokeUserTokens(ctx context.Context, in *RevokeUserTokensRequest, opts ...grpc.CallOption) (*RevokeUserTokensResponse, error)
+	// GetOAuthLoginURL returns the page of an identity provider where users
+	// log in to Pachyderm. It fails if pachd isn't configured to use it.
+	GetOAuthLoginURL(ctx context.Context, in *GetOAuthLoginURLRequest, opts ...grpc.CallOption) (*GetOAuthLoginURLResponse, error)
+	// Authenticate completes a login, it returns a token for the user. The
+	// username of a GitHub user is "github:" followed by their GitHub login,
+	// that of an OIDC user is "oidc:" followed by their email address.
+	Authenticate(ctx context.Context, in *AuthenticateRequest, opts ...grpc.CallOption) (*AuthenticateResponse, error)
+	// GetACL returns a repo's ACL, which is empty if the repo doesn't have one.
+	GetACL(ctx context.Context, in *GetACLRequest, opts ...grpc.CallOption) (*GetACLResponse, error)
+	// SetScope sets a user's or group's scope in a repo's ACL. It requires
+	// the OWNER scope, unless the repo doesn't have an ACL yet.
+	SetScope(ctx context.Context, in *SetScopeRequest, opts ...grpc.CallOption) (*SetScopeResponse, error)
+	// GetRobotToken issues a token for a robot, e.g. a CI system, which only
+	// has the scopes it's given in the repos it's given them in. Only admins
+	// can issue robot tokens.
+	GetRobotToken(ctx context.Context, in *GetRobotTokenRequest, opts ...grpc.CallOption) (*GetRobotTokenResponse, error)
+	// ModifyAdmins changes who the cluster's admins are. Admins are the only
+	// users who can make destructive, cluster-wide changes, e.g. DeleteAll.
+	// The user who activates auth is the first admin. Only admins can modify
+	// the admins, and the last one can't be removed.
+	ModifyAdmins(ctx context.Context, in *ModifyAdminsRequest, opts ...grpc.CallOption) (*ModifyAdminsResponse, error)
+	// GetAdmins returns the cluster's admins.
+	GetAdmins(ctx context.Context, in *GetAdminsRequest, opts ...grpc.CallOption) (*GetAdminsResponse, error)
+}
+
+type aPIClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewAPIClient(cc *grpc.ClientConn) APIClient {
+	return &aPIClient{cc}
+}
+
+func (c *aPIClient) Activate(ctx context.Context, in *ActivateRequest, opts ...grpc.CallOption) (*ActivateResponse, error) {
+	out := new(ActivateResponse)
+	err := grpc.Invoke(ctx, "/auth.API/Activate", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) Deactivate(ctx context.Context, in *DeactivateRequest, opts ...grpc.CallOption) (*DeactivateResponse, error) {
+	out := new(DeactivateResponse)
+	err := grpc.Invoke(ctx, "/auth.API/Deactivate", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) WhoAmI(ctx context.Context, in *WhoAmIRequest, opts ...grpc.CallOption) (*WhoAmIResponse, error) {
+	out := new(WhoAmIResponse)
+	err := grpc.Invoke(ctx, "/auth.API/WhoAmI", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) GetToken(ctx context.Context, in *GetTokenRequest, opts ...grpc.CallOption) (*GetTokenResponse, error) {
+	out := new(GetTokenResponse)
+	err := grpc.Invoke(ctx, "/auth.API/GetToken", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) RenewToken(ctx context.Context, in *RenewTokenRequest, opts ...grpc.CallOption) (*RenewTokenResponse, error) {
+	out := new(RenewTokenResponse)
+	err := grpc.Invoke(ctx, "/auth.API/RenewToken", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) RevokeToken(ctx context.Context, in *RevokeTokenRequest, opts ...grpc.CallOption) (*RevokeTokenResponse, error) {
+	out := new(RevokeTokenResponse)
+	err := grpc.Invoke(ctx, "/auth.API/RevokeToken", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) RevokeUserTokens(ctx context.Context, in *RevokeUserTokensRequest, opts ...grpc.CallOption) (*RevokeUserTokensResponse, error) {
+	out := new(RevokeUserTokensResponse)
+	err := grpc.Invoke(ctx, "/auth.API/RevokeUserTokens", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) GetOAuthLoginURL(ctx context.Context, in *GetOAuthLoginURLRequest, opts ...grpc.CallOption) (*GetOAuthLoginURLResponse, error) {
+	out := new(GetOAuthLoginURLResponse)
+	err := grpc.Invoke(ctx, "/auth.API/GetOAuthLoginURL", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) Authenticate(ctx context.Context, in *AuthenticateRequest, opts ...grpc.CallOption) (*AuthenticateResponse, error) {
+	out := new(AuthenticateResponse)
+	err := grpc.Invoke(ctx, "/auth.API/Authenticate", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) GetACL(ctx context.Context, in *GetACLRequest, opts ...grpc.CallOption) (*GetACLResponse, error) {
+	out := new(GetACLResponse)
+	err := grpc.Invoke(ctx, "/auth.API/GetACL", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) SetScope(ctx context.Context, in *SetScopeRequest, opts ...grpc.CallOption) (*SetScopeResponse, error) {
+	out := new(SetScopeResponse)
+	err := grpc.Invoke(ctx, "/auth.API/SetScope", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) GetRobotToken(ctx context.Context, in *GetRobotTokenRequest, opts ...grpc.CallOption) (*GetRobotTokenResponse, error) {
+	out := new(GetRobotTokenResponse)
+	err := grpc.Invoke(ctx, "/auth.API/GetRobotToken", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) ModifyAdmins(ctx context.Context, in *ModifyAdminsRequest, opts ...grpc.CallOption) (*ModifyAdminsResponse, error) {
+	out := new(ModifyAdminsResponse)
+	err := grpc.Invoke(ctx, "/auth.API/ModifyAdmins", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) GetAdmins(ctx context.Context, in *GetAdminsRequest, opts ...grpc.CallOption) (*GetAdminsResponse, error) {
+	out := new(GetAdminsResponse)
+	err := grpc.Invoke(ctx, "/auth.API/GetAdmins", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for API service
+
+type APIServer interface {
+	// Activate turns auth on, after which every PFS and PPS request must carry
+	// a token. It fails if auth is already active.
+	Activate(context.Context, *ActivateRequest) (*ActivateResponse, error)
+	// Deactivate turns auth off and revokes every token. Only admins can
+	// deactivate auth.
+	Deactivate(context.Context, *DeactivateRequest) (*DeactivateResponse, error)
+	// WhoAmI returns the user that the caller's token authenticates.
+	WhoAmI(context.Context, *WhoAmIRequest) (*WhoAmIResponse, error)
+	// GetToken issues a token for a user.
+	GetToken(context.Context, *GetTokenRequest) (*GetTokenResponse, error)
+	// RenewToken extends the validity of the caller's token. Robot tokens
+	// can't be renewed, admins issue new ones instead.
+	RenewToken(context.Context, *RenewTokenRequest) (*RenewTokenResponse, error)
+	// RevokeToken revokes a token, which anyone who has it can do.
+	RevokeToken(context.Context, *RevokeTokenRequest) (*RevokeTokenResponse, error)
+	// RevokeUserTokens revokes every token of a user, e.g. one whose token may
+	// have been stolen. Users can revoke their own tokens, admins anyone's.
+	RevokeUserTokens(context.Context, *RevokeUserTokensRequest) (*RevokeUserTokensResponse, error)
+	// GetOAuthLoginURL returns the page of an identity provider where users
+	// log in to Pachyderm. It fails if pachd isn't configured to use it.
+	GetOAuthLoginURL(context.Context, *GetOAuthLoginURLRequest) (*GetOAuthLoginURLResponse, error)
+	// Authenticate completes a login, it returns a token for the user. The
+	// username of a GitHub user is "github:" followed by their GitHub login,
+	// that of an OIDC user is "oidc:" followed by their email address.
+	Authenticate(context.Context, *AuthenticateRequest) (*AuthenticateResponse, error)
+	// GetACL returns a repo's ACL, which is empty if the repo doesn't have one.
+	GetACL(context.Context, *GetACLRequest) (*GetACLResponse, error)
+	// SetScope sets a user's or group's scope in a repo's ACL. It requires
+	// the OWNER scope, unless the repo doesn't have an ACL yet.
+	SetScope(context.Context, *SetScopeRequest) (*SetScopeResponse, error)
+	// GetRobotToken issues a token for a robot, e.g. a CI system, which only
+	// has the scopes it's given in the repos it's given them in. Only admins
+	// can issue robot tokens.
+	GetRobotToken(context.Context, *GetRobotTokenRequest) (*GetRobotTokenResponse, error)
+	// ModifyAdmins changes who the cluster's admins are. Admins are the only
+	// users who can make destructive, cluster-wide changes, e.g. DeleteAll.
+	// The user who activates auth is the first admin. Only admins can modify
+	// the admins, and the last one can't be removed.
+	ModifyAdmins(context.Context, *ModifyAdminsRequest) (*ModifyAdminsResponse, error)
+	// GetAdmins returns the cluster's admins.
+	GetAdmins(context.Context, *GetAdminsRequest) (*GetAdminsResponse, error)
+}
+
+func RegisterAPIServer(s *grpc.Server, srv APIServer) {
+	s.RegisterService(&_API_serviceDesc, srv)
+}
+
+func _API_Activate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ActivateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).Activate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/auth.API/Activate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).Activate(ctx, req.(*ActivateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_Deactivate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeactivateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).Deactivate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/auth.API/Deactivate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).Deactivate(ctx, req.(*DeactivateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_WhoAmI_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WhoAmIRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).WhoAmI(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/auth.API/WhoAmI",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).WhoAmI(ctx, req.(*WhoAmIRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_GetToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).GetToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/auth.API/GetToken",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).GetToken(ctx, req.(*GetTokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_RenewToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenewTokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).RenewToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/auth.API/RenewToken",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).RenewToken(ctx, req.(*RenewTokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_RevokeToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeTokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).RevokeToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/auth.API/RevokeToken",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).RevokeToken(ctx, req.(*RevokeTokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_RevokeUserTokens_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeUserTokensRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).RevokeUserTokens(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/auth.API/RevokeUserTokens",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).RevokeUserTokens(ctx, req.(*RevokeUserTokensRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_GetOAuthLoginURL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOAuthLoginURLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).GetOAuthLoginURL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/auth.API/GetOAuthLoginURL",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).GetOAuthLoginURL(ctx, req.(*GetOAuthLoginURLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_Authenticate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AuthenticateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).Authenticate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/auth.API/Authenticate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).Authenticate(ctx, req.(*AuthenticateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_GetACL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetACLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).GetACL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/auth.API/GetACL",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).GetACL(ctx, req.(*GetACLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_SetScope_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetScopeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).SetScope(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/auth.API/SetScope",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).SetScope(ctx, req.(*SetScopeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_GetRobotToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRobotTokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).GetRobotToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/auth.API/GetRobotToken",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).GetRobotToken(ctx, req.(*GetRobotTokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_ModifyAdmins_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ModifyAdminsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).ModifyAdmins(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/auth.API/ModifyAdmins",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).ModifyAdmins(ctx, req.(*ModifyAdminsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_GetAdmins_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAdminsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).GetAdmins(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/auth.API/GetAdmins",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).GetAdmins(ctx, req.(*GetAdminsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _API_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "auth.API",
+	HandlerType: (*APIServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Activate",
+			Handler:    _API_Activate_Handler,
+		},
+		{
+			MethodName: "Deactivate",
+			Handler:    _API_Deactivate_Handler,
+		},
+		{
+			MethodName: "WhoAmI",
+			Handler:    _API_WhoAmI_Handler,
+		},
+		{
+			MethodName: "GetToken",
+			Handler:    _API_GetToken_Handler,
+		},
+		{
+			MethodName: "RenewToken",
+			Handler:    _API_RenewToken_Handler,
+		},
+		{
+			MethodName: "RevokeToken",
+			Handler:    _API_RevokeToken_Handler,
+		},
+		{
+			MethodName: "RevokeUserTokens",
+			Handler:    _API_RevokeUserTokens_Handler,
+		},
+		{
+			MethodName: "GetOAuthLoginURL",
+			Handler:    _API_GetOAuthLoginURL_Handler,
+		},
+		{
+			MethodName: "Authenticate",
+			Handler:    _API_Authenticate_Handler,
+		},
+		{
+			MethodName: "GetACL",
+			Handler:    _API_GetACL_Handler,
+		},
+		{
+			MethodName: "SetScope",
+			Handler:    _API_SetScope_Handler,
+		},
+		{
+			MethodName: "GetRobotToken",
+			Handler:    _API_GetRobotToken_Handler,
+		},
+		{
+			MethodName: "ModifyAdmins",
+			Handler:    _API_ModifyAdmins_Handler,
+		},
+		{
+			MethodName: "GetAdmins",
+			Handler:    _API_GetAdmins_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "client/auth/auth.proto",
+}
+
+func init() { proto.RegisterFile("client/auth/auth.proto", fileDescriptorAuth) }
+
+var fileDescriptorAuth = []byte{
+	// 1258 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xac, 0x56, 0xdd, 0x72, 0xdb, 0xc4,
+	0x17, 0xaf, 0xac, 0xc4, 0xb1, 0x8f, 0xd3, 0x44, 0xdd, 0xb8, 0x8e, 0xba, 0x9d, 0x36, 0xf9, 0xeb,
+	0xcf, 0x30, 0x26, 0xa5, 0x0e, 0x84, 0x96, 0x81, 0x96, 0x29, 0xe3, 0x26, 0xae, 0x6b, 0x08, 0x4d,
+	0x67, 0xe3, 0x4c, 0x6f, 0x98, 0xc9, 0x28, 0xf6, 0xd6, 0x11, 0x8d, 0xb5, 0x46, 0x5a, 0x07, 0x72,
+	0x07, 0x4f, 0xc0, 0x33, 0x70, 0xc1, 0x6b, 0xf0, 0x28, 0xb9, 0xc8, 0x0c, 0xef, 0xc1, 0xec, 0x97,
+	0x2c, 0xc9, 0x4e, 0x52, 0x18, 0x6e, 0x34, 0x67, 0xcf, 0x39, 0x7b, 0x3e, 0x7f, 0x3a, 0x7b, 0xa0,
+	0xd6, 0x3b, 0x09, 0x68, 0xc8, 0x37, 0xfd, 0x31, 0x3f, 0x96, 0x9f, 0xc6, 0x28, 0x62, 0x9c, 0xa1,
+	0x39, 0x41, 0xe3, 0xb5, 0x01, 0x63, 0x83, 0x13, 0xba, 0x29, 0x79, 0x47, 0xe3, 0xb7, 0x9b, 0x3c,
+	0x18, 0xd2, 0x98, 0xfb, 0xc3, 0x91, 0x52, 0xc3, 0xd5, 0x01, 0x1b, 0x30, 0x49, 0x6e, 0x0a, 0x4a,
+	0x71, 0xbd, 0x07, 0xb0, 0xdc, 0xec, 0xf1, 0xe0, 0xd4, 0xe7, 0x94, 0xd0, 0x1f, 0xc7, 0x34, 0xe6,
+	0xc8, 0x85, 0x85, 0x78, 0x7c, 0xf4, 0x03, 0xed, 0x71, 0xd7, 0x5a, 0xb7, 0xea, 0x65, 0x62, 0x8e,
+	0xde, 0xa7, 0xe0, 0x4c, 0x94, 0xe3, 0x11, 0x0b, 0x63, 0x8a, 0xee, 0x01, 0x8c, 0xfc, 0xde, 0xf1,
+	0x21, 0x67, 0xef, 0x68, 0xa8, 0x2f, 0x94, 0x05, 0xa7, 0x2b, 0x18, 0xde, 0x0a, 0xdc, 0xda, 0xa1,
+	0x7e, 0xd6, 0x83, 0x57, 0x05, 0x94, 0x66, 0x2a, 0x4b, 0xde, 0x32, 0xdc, 0x7c, 0x73, 0xcc, 0x9a,
+	0xc3, 0x8e, 0x51, 0xfb, 0x18, 0x96, 0x0c, 0x43, 0x3b, 0xc3, 0x50, 0x1a, 0xc7, 0x34, 0x0a, 0xfd,
+	0x21, 0xd5, 0xae, 0x92, 0xb3, 0xf7, 0x3d, 0x2c, 0xb7, 0x29, 0x97, 0x5e, 0xaf, 0xcd, 0x04, 0x6d,
+	0x42, 0x85, 0xf3, 0x93, 0xc3, 0x98, 0xf6, 0x58, 0xd8, 0x8f, 0xdd, 0xc2, 0xba, 0x55, 0xb7, 0x9f,
+	0x2f, 0x5d, 0x9c, 0xaf, 0x41, 0xb7, 0xbb, 0xbb, 0xaf, 0xb8, 0x04, 0x38, 0x3f, 0xd1, 0xb4, 0x57,
+	0x07, 0x67, 0x62, 0x5d, 0x47, 0x53, 0x85, 0xf9, 0x74, 0xd6, 0xea, 0xe0, 0xfd, 0x66, 0xc1, 0x6a,
+	0x9b, 0xf2, 0xbd, 0xe6, 0x98, 0x1f, 0xef, 0xb2, 0x41, 0x10, 0x1e, 0x90, 0x5d, 0x13, 0xd0, 0x16,
+	0x2c, 0x46, 0xb4, 0x1f, 0x44, 0xb4, 0xc7, 0x0f, 0xc7, 0x51, 0xa0, 0x2e, 0x3e, 0x5f, 0xbe, 0x38,
+	0x5f, 0xab, 0x10, 0xcd, 0x3f, 0x20, 0x1d, 0x52, 0x31, 0x4a, 0x07, 0x51, 0x20, 0xbc, 0xc4, 0xdc,
+	0xe7, 0x54, 0x06, 0x59, 0x26, 0xea, 0x80, 0x36, 0xa0, 0x34, 0x8a, 0xd8, 0x69, 0xd0, 0xa7, 0x91,
+	0x6b, 0xaf, 0x5b, 0xf5, 0xa5, 0xad, 0xa5, 0x86, 0xc4, 0xc4, 0x6b, 0xcd, 0x25, 0x89, 0xdc, 0xf3,
+	0xc1, 0x9d, 0x0e, 0x48, 0xe7, 0x70, 0x07, 0xec, 0x71, 0x74, 0xa2, 0x03, 0x59, 0xb8, 0x38, 0x5f,
+	0xb3, 0x85, 0x54, 0xf0, 0x32, 0x2e, 0x0a, 0xd7, 0xb8, 0xf8, 0xc3, 0x82, 0x15, 0x61, 0x9f, 0x86,
+	0x3c, 0xe8, 0xa5, 0xb0, 0xb4, 0x09, 0x95, 0x41, 0xc0, 0x8f, 0xc7, 0x47, 0x87, 0x3d, 0xd6, 0xd7,
+	0x3d, 0x53, 0x75, 0x6e, 0x07, 0xfc, 0xe5, 0xf8, 0x68, 0x9b, 0xf5, 0x29, 0x01, 0xa5, 0x22, 0xe8,
+	0xa9, 0x0a, 0x15, 0xde, 0xa3, 0x42, 0x1f, 0x41, 0x99, 0x05, 0xfd, 0x9e, 0x72, 0x61, 0xcb, 0x0b,
+	0x8b, 0x17, 0xe7, 0x6b, 0xa5, 0xbd, 0xce, 0xce, 0xb6, 0x74, 0x50, 0x12, 0x62, 0x41, 0x79, 0x8f,
+	0xa1, 0x9a, 0x0d, 0xf3, 0xfd, 0x50, 0xfc, 0x7b, 0x01, 0xca, 0x92, 0xea, 0x84, 0x6f, 0xd9, 0x15,
+	0xb0, 0xaa, 0x41, 0x71, 0x10, 0xb1, 0xf1, 0x48, 0x20, 0xca, 0xae, 0x97, 0x89, 0x3e, 0x89, 0x1e,
+	0x46, 0xec, 0x88, 0x71, 0x19, 0x5d, 0x89, 0xa8, 0x03, 0xda, 0x86, 0x45, 0x49, 0x1c, 0xc6, 0x3d,
+	0x36, 0xa2, 0xb1, 0x3b, 0xb7, 0x6e, 0xd7, 0x2b, 0x5b, 0xeb, 0xaa, 0xc8, 0x89, 0xbb, 0x06, 0x11,
+	0x3a, 0xfb, 0x52, 0xa5, 0x15, 0xf2, 0xe8, 0x8c, 0x54, 0xa2, 0x09, 0x07, 0x3d, 0x01, 0xa0, 0x3f,
+	0x8f, 0x82, 0xc8, 0xe7, 0x01, 0x0b, 0xdd, 0xf9, 0x75, 0xab, 0x5e, 0xd9, 0xc2, 0x0d, 0x35, 0x0c,
+	0x1a, 0x66, 0x18, 0x34, 0xba, 0x66, 0x18, 0x90, 0x94, 0x36, 0xfe, 0x16, 0x9c, 0xbc, 0x71, 0xe4,
+	0x80, 0xfd, 0x8e, 0x9e, 0xe9, 0xc4, 0x04, 0x89, 0xfe, 0x07, 0xf3, 0xa7, 0xfe, 0xc9, 0x98, 0x6a,
+	0x10, 0x54, 0x54, 0x7c, 0xf2, 0x0e, 0x51, 0x92, 0x27, 0x85, 0x2f, 0x2c, 0xef, 0x17, 0x0b, 0xec,
+	0xe6, 0xf6, 0x2e, 0xfa, 0x04, 0x16, 0x68, 0xc8, 0xa3, 0x80, 0xc6, 0xae, 0x25, 0x13, 0xaa, 0xa9,
+	0x0b, 0xcd, 0xed, 0xdd, 0x46, 0x4b, 0x09, 0x54, 0x1a, 0x46, 0x0d, 0xb7, 0x61, 0x31, 0x2d, 0xf8,
+	0xf7, 0x21, 0xfc, 0x1f, 0x6e, 0xb6, 0x29, 0x6f, 0x6e, 0x27, 0xff, 0x1b, 0x82, 0xb9, 0x88, 0x8e,
+	0x98, 0x36, 0x25, 0x69, 0xef, 0x73, 0x58, 0x32, 0x4a, 0xba, 0xf9, 0x1f, 0x80, 0xed, 0xf7, 0xd4,
+	0x3f, 0x50, 0xd9, 0x2a, 0x27, 0xd1, 0xaa, 0xdf, 0x41, 0x28, 0x0a, 0xb1, 0xd7, 0x87, 0xe5, 0x7d,
+	0xaa, 0x4a, 0x75, 0x85, 0xf9, 0xcc, 0x88, 0x2a, 0x64, 0x47, 0x94, 0x48, 0x43, 0xb6, 0xda, 0xb5,
+	0x67, 0xa4, 0x21, 0x25, 0x1e, 0x02, 0x67, 0xe2, 0x45, 0x0f, 0xc6, 0xbf, 0x2c, 0xa8, 0xb6, 0x29,
+	0x97, 0xad, 0xca, 0xcc, 0xb7, 0x04, 0x56, 0x7a, 0x00, 0xc9, 0x03, 0x7a, 0x06, 0x45, 0x0d, 0xa8,
+	0x82, 0xac, 0xff, 0x87, 0xca, 0xcd, 0x2c, 0x0b, 0x8d, 0x34, 0xac, 0xf4, 0xad, 0xfc, 0x6c, 0xb4,
+	0xaf, 0x9b, 0x8d, 0xf8, 0x05, 0x54, 0xfe, 0x13, 0x04, 0x3d, 0x84, 0xdb, 0xb9, 0x20, 0xaf, 0x1c,
+	0xb4, 0xbf, 0x5a, 0x50, 0x6c, 0xf6, 0x87, 0x41, 0x18, 0xa3, 0x2f, 0xa1, 0x6c, 0x8a, 0x6c, 0x50,
+	0x77, 0x57, 0xf7, 0x51, 0x2a, 0x34, 0x0e, 0x8c, 0x54, 0xa5, 0x3a, 0xd1, 0xc6, 0x5f, 0xc1, 0x52,
+	0x56, 0x38, 0x23, 0xfe, 0x6a, 0x3a, 0xfe, 0x52, 0x3a, 0xe4, 0xaf, 0x61, 0xe5, 0x3b, 0xd6, 0x0f,
+	0xde, 0x9e, 0x29, 0x3f, 0xa6, 0x31, 0x0e, 0xd8, 0x7e, 0xbf, 0x2f, 0x23, 0x29, 0x13, 0x41, 0x8a,
+	0xc9, 0x10, 0xd1, 0x21, 0x3b, 0xa5, 0x66, 0x32, 0xa8, 0x93, 0x57, 0x83, 0x6a, 0xd6, 0x80, 0xee,
+	0x39, 0x92, 0xef, 0x4d, 0xc6, 0xaa, 0xf7, 0x00, 0x6e, 0xa5, 0x78, 0xba, 0x36, 0x35, 0x28, 0xfa,
+	0x92, 0xa3, 0xbd, 0xe9, 0x93, 0xb7, 0x03, 0xb7, 0x08, 0x0d, 0xe9, 0x4f, 0x19, 0xc0, 0xe4, 0x5a,
+	0x6b, 0x5d, 0xfb, 0xec, 0xbd, 0x06, 0x94, 0xb6, 0xa2, 0x7d, 0x66, 0x67, 0x8e, 0xf5, 0x4f, 0x66,
+	0x8e, 0xb7, 0x21, 0x2c, 0x9e, 0xb2, 0x77, 0x34, 0x8f, 0xe4, 0x19, 0x1d, 0xbe, 0x0d, 0x2b, 0x19,
+	0x5d, 0x5d, 0x9b, 0xc7, 0xb0, 0xaa, 0xd8, 0xa2, 0x71, 0x52, 0x94, 0x14, 0xfe, 0xaa, 0x05, 0xe1,
+	0x11, 0xb8, 0xd3, 0xd7, 0x74, 0x46, 0x2e, 0x2c, 0x44, 0x52, 0xd6, 0x57, 0x45, 0x21, 0xe6, 0xb8,
+	0xf1, 0x10, 0x4a, 0xe6, 0xbd, 0x43, 0x15, 0x58, 0xd8, 0x69, 0xbd, 0x68, 0x1e, 0xec, 0x76, 0x9d,
+	0x1b, 0x08, 0xa0, 0xd8, 0xee, 0x74, 0x5f, 0x1e, 0x3c, 0x77, 0x2c, 0x54, 0x82, 0x39, 0xf1, 0xd8,
+	0x38, 0x85, 0x8d, 0x47, 0x30, 0x2f, 0x71, 0x2d, 0x58, 0xaf, 0xf6, 0x5e, 0xb5, 0x94, 0x22, 0x69,
+	0x35, 0x77, 0x5a, 0xc4, 0xb1, 0x04, 0xfd, 0x86, 0x74, 0xba, 0x2d, 0xe2, 0x14, 0x50, 0x19, 0xe6,
+	0xf7, 0xde, 0xbc, 0x6a, 0x11, 0xc7, 0xde, 0xfa, 0x73, 0x01, 0xec, 0xe6, 0xeb, 0x0e, 0x7a, 0x0a,
+	0x25, 0xb3, 0x60, 0xa1, 0xdb, 0x1a, 0xc0, 0xd9, 0xdd, 0x09, 0xd7, 0xf2, 0x6c, 0x5d, 0x94, 0x1b,
+	0xa8, 0x09, 0x30, 0xd9, 0xaa, 0xd0, 0xaa, 0xd2, 0x9b, 0x5a, 0xbe, 0xb0, 0x3b, 0x2d, 0x48, 0x4c,
+	0x3c, 0x86, 0xa2, 0xda, 0xb8, 0xd0, 0x8a, 0xd2, 0xca, 0x2c, 0x64, 0xb8, 0x9a, 0x65, 0x26, 0xd7,
+	0x9e, 0x42, 0xc9, 0x2c, 0x47, 0x26, 0xec, 0xdc, 0x2a, 0x86, 0x6b, 0x79, 0x76, 0x3a, 0xec, 0x09,
+	0xc4, 0x4c, 0xd8, 0x53, 0xd0, 0xc5, 0xee, 0xb4, 0x20, 0x31, 0xb1, 0x03, 0x95, 0x14, 0x4e, 0x50,
+	0xa2, 0x9a, 0x87, 0x19, 0xbe, 0x33, 0x43, 0x92, 0x58, 0xd9, 0x07, 0x27, 0x8f, 0x0f, 0x74, 0x2f,
+	0x7d, 0x61, 0x0a, 0x6e, 0xf8, 0xfe, 0x65, 0xe2, 0xb4, 0xd1, 0xfc, 0xee, 0x65, 0x8c, 0x5e, 0xb2,
+	0x24, 0xe2, 0xfb, 0x97, 0x89, 0x13, 0xa3, 0x6d, 0x58, 0x4c, 0x6f, 0x31, 0x48, 0xa7, 0x35, 0x63,
+	0x01, 0xc3, 0x78, 0x96, 0x28, 0xdd, 0x6f, 0xf5, 0x16, 0x9a, 0x7e, 0x67, 0x9e, 0x4f, 0x5c, 0xcd,
+	0x32, 0xd3, 0xfd, 0x36, 0x8f, 0x94, 0xe9, 0x77, 0xee, 0x69, 0xc4, 0xb5, 0x3c, 0x3b, 0xb9, 0xfc,
+	0x0d, 0xdc, 0xcc, 0x4c, 0x79, 0x84, 0x2f, 0x7f, 0x9f, 0xf0, 0xdd, 0x99, 0xb2, 0x74, 0x21, 0xd2,
+	0xd3, 0xd3, 0x14, 0x62, 0xc6, 0x48, 0xc6, 0x78, 0x96, 0x28, 0x31, 0xf4, 0x0c, 0xca, 0xc9, 0x68,
+	0x45, 0x13, 0xac, 0x66, 0x4d, 0xac, 0x4e, 0xf1, 0xcd, 0xfd, 0xa3, 0xa2, 0x9c, 0x7a, 0x9f, 0xfd,
+	0x3d, 0x00, 0xfa, 0xd8, 0x6f, 0x51, 0xa4, 0x0d, 0x00, 0x00,
+}
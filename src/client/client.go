@@ -1,23 +1,30 @@
 package client
 
 import (
-	"errors"
 	"fmt"
+	"net"
 	"os"
 	"time"
 
 	"golang.org/x/net/context"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/stats"
 
 	log "github.com/Sirupsen/logrus"
 	types "github.com/gogo/protobuf/types"
 
+	"github.com/pachyderm/pachyderm/src/client/admin"
+	"github.com/pachyderm/pachyderm/src/client/auth"
 	"github.com/pachyderm/pachyderm/src/client/health"
 	"github.com/pachyderm/pachyderm/src/client/pfs"
 	"github.com/pachyderm/pachyderm/src/client/pkg/config"
+	"github.com/pachyderm/pachyderm/src/client/pkg/grpcutil"
 	"github.com/pachyderm/pachyderm/src/client/pps"
+	"github.com/pachyderm/pachyderm/src/client/version"
+	"github.com/pachyderm/pachyderm/src/client/version/versionpb"
 )
 
 // PfsAPIClient is an alias for pfs.APIClient.
@@ -29,13 +36,27 @@ type PpsAPIClient pps.APIClient
 // ObjectAPIClient is an alias for pfs.ObjectAPIClient
 type ObjectAPIClient pfs.ObjectAPIClient
 
-// An APIClient is a wrapper around pfs, pps and block APIClients.
+// AdminAPIClient is an alias for admin.APIClient.
+type AdminAPIClient admin.APIClient
+
+// AuthAPIClient is an alias for auth.APIClient.
+type AuthAPIClient auth.APIClient
+
+// An APIClient is a wrapper around pfs, pps, block, admin and auth
+// APIClients.
+//
+// An APIClient is safe for concurrent use by multiple goroutines, with the
+// exception of the Set* methods which configure the client and must not be
+// called while other operations are outstanding.
 type APIClient struct {
 	PfsAPIClient
 	PpsAPIClient
 	ObjectAPIClient
+	AdminAPIClient
+	AuthAPIClient
 	addr              string
-	clientConn        *grpc.ClientConn
+	pool              *connPool
+	poolSize          int
 	healthClient      health.HealthClient
 	_ctx              context.Context
 	config            *config.Config
@@ -43,11 +64,313 @@ type APIClient struct {
 	reportUserMetrics bool
 	metricsPrefix     string
 	streamSemaphore   chan struct{}
+	progress          ProgressFunc
+	// dialOptions, unaryInterceptors and streamInterceptors are passed to
+	// grpc.Dial in addition to PachDialOptions
+	dialOptions        []grpc.DialOption
+	unaryInterceptors  []grpc.UnaryClientInterceptor
+	streamInterceptors []grpc.StreamClientInterceptor
+	// maxMsgSize is the largest message the client will send to pachd
+	maxMsgSize int
+	keepalive  time.Duration
+	// statsHandlers are registered on the client's connections, see
+	// WithStatsHandler
+	statsHandlers []stats.Handler
+	// creds secure the client's connections, if they're nil the connections
+	// are insecure
+	creds credentials.TransportCredentials
 }
 
 // DefaultMaxConcurrentStreams defines the max number of Putfiles or Getfiles happening simultaneously
 const DefaultMaxConcurrentStreams uint = 100
 
+// DefaultConnectionPoolSize is the number of connections an APIClient opens
+// to pachd unless WithConnectionPoolSize is used.
+const DefaultConnectionPoolSize = 1
+
+// DefaultKeepalivePeriod is the period of the TCP keepalives the client
+// sends to pachd unless WithKeepalive is used.
+const DefaultKeepalivePeriod = 30 * time.Second
+
+// DefaultPachdAddress is the address NewOnUserMachine connects to if neither
+// $ADDRESS nor the active context give one, it's the address that
+// `pachctl port-forward` forwards to pachd.
+const DefaultPachdAddress = "0.0.0.0:30650"
+
+// authTokenKey is the metadata key that auth tokens are sent to pachd with.
+const authTokenKey = "authn-token"
+
+// An Option configures an APIClient, Options are passed to the client's
+// constructors.
+type Option func(*APIClient)
+
+// WithConnectionPoolSize makes the client open size connections to pachd
+// and spread its streaming RPCs (PutFile, GetFile, PutObject etc.) across
+// them. A single connection is limited in the number of streams it can
+// carry concurrently, so callers doing lots of parallel transfers should use
+// a larger pool.
+func WithConnectionPoolSize(size int) Option {
+	return func(c *APIClient) {
+		if size > 0 {
+			c.poolSize = size
+		}
+	}
+}
+
+// WithKeepalive sets the period of the TCP keepalives the client sends on
+// its connections to pachd. Keepalives allow the client to notice that pachd
+// has gone away (after which it reconnects on the next RPC) without waiting
+// for the OS to time the connection out. A period of 0 disables them.
+func WithKeepalive(period time.Duration) Option {
+	return func(c *APIClient) {
+		c.keepalive = period
+	}
+}
+
+// WithMaxMsgSize sets the size of the largest message the client sends to
+// pachd, large writes (e.g. PutFile and PutObject) are split into chunks
+// that fit within it. It should be no larger than the MAX_MSG_SIZE pachd is
+// configured with. The default is grpcutil.MaxMsgSize.
+func WithMaxMsgSize(size int) Option {
+	return func(c *APIClient) {
+		if size > 0 {
+			c.maxMsgSize = size
+		}
+	}
+}
+
+// WithDialOptions passes additional options to grpc.Dial when the client
+// connects to pachd, they're applied after the client's defaults so they may
+// override them. Interceptors should be added with WithUnaryInterceptor and
+// WithStreamInterceptor rather than grpc.WithUnaryInterceptor and
+// grpc.WithStreamInterceptor, as grpc only allows one of each.
+func WithDialOptions(options ...grpc.DialOption) Option {
+	return func(c *APIClient) {
+		c.dialOptions = append(c.dialOptions, options...)
+	}
+}
+
+// WithUnaryInterceptor adds an interceptor that's called for every unary RPC
+// the client makes, e.g. to attach auth tokens or tracing. Interceptors are
+// called in the order they're added.
+func WithUnaryInterceptor(interceptor grpc.UnaryClientInterceptor) Option {
+	return func(c *APIClient) {
+		c.unaryInterceptors = append(c.unaryInterceptors, interceptor)
+	}
+}
+
+// WithStreamInterceptor adds an interceptor that's called for every
+// streaming RPC the client makes. Interceptors are called in the order
+// they're added.
+func WithStreamInterceptor(interceptor grpc.StreamClientInterceptor) Option {
+	return func(c *APIClient) {
+		c.streamInterceptors = append(c.streamInterceptors, interceptor)
+	}
+}
+
+// WithMaxConcurrentStreams sets the max number of streaming requests
+// (GetFile / PutFile) the client makes simultaneously, the default is
+// DefaultMaxConcurrentStreams.
+func WithMaxConcurrentStreams(streams uint) Option {
+	return func(c *APIClient) {
+		c.streamSemaphore = make(chan struct{}, streams)
+	}
+}
+
+// WithTransportCredentials makes the client connect to pachd using creds
+// (e.g. TLS) rather than an insecure connection.
+func WithTransportCredentials(creds credentials.TransportCredentials) Option {
+	return func(c *APIClient) {
+		c.creds = creds
+	}
+}
+
+// compressedMethods are the RPCs whose responses clients created with
+// WithCompression ask pachd to compress. They're the RPCs that return large
+// amounts of metadata, which compresses well.
+var compressedMethods = map[string]bool{
+	"/pfs.API/ListFile":       true,
+	"/pfs.API/ListFileStream": true,
+	"/pfs.API/GlobFile":       true,
+	"/pfs.API/GlobFileStream": true,
+	"/pps.API/ListJob":        true,
+	"/pps.API/GetLogs":        true,
+}
+
+// WithCompression makes the client ask pachd to gzip the responses of RPCs
+// that return lots of metadata, such as ListFile, ListJob and GetLogs. It
+// trades CPU on both sides for bandwidth, so it's worth using when pachd is
+// reached over a slow link. pachds that don't support compression send
+// uncompressed responses instead.
+func WithCompression() Option {
+	return func(c *APIClient) {
+		c.dialOptions = append(c.dialOptions, grpc.WithCodec(grpcutil.Codec))
+		c.unaryInterceptors = append(c.unaryInterceptors, compressUnaryInterceptor)
+		c.streamInterceptors = append(c.streamInterceptors, compressStreamInterceptor)
+	}
+}
+
+func compressUnaryInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if compressedMethods[method] {
+		ctx = grpcutil.WithCompression(ctx)
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+func compressStreamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	if compressedMethods[method] {
+		ctx = grpcutil.WithCompression(ctx)
+	}
+	return streamer(ctx, desc, cc, method, opts...)
+}
+
+// WithAuthToken makes the client send token to pachd with every request.
+func WithAuthToken(token string) Option {
+	return func(c *APIClient) {
+		c.unaryInterceptors = append(c.unaryInterceptors, authTokenUnaryInterceptor(token))
+		c.streamInterceptors = append(c.streamInterceptors, authTokenStreamInterceptor(token))
+	}
+}
+
+// AuthTokenDialOptions returns options for grpc.Dial which make every request
+// sent over the connection carry token, in place of any token already in the
+// request's context, and which recreate the errors pachd returns as an
+// APIClient does. They're for pachd's connections to itself, which are dialed
+// directly rather than with an APIClient.
+func AuthTokenDialOptions(token string) []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithUnaryInterceptor(chainUnaryInterceptors([]grpc.UnaryClientInterceptor{authTokenUnaryInterceptor(token), errorUnaryInterceptor})),
+		grpc.WithStreamInterceptor(chainStreamInterceptors([]grpc.StreamClientInterceptor{authTokenStreamInterceptor(token), errorStreamInterceptor})),
+	}
+}
+
+// AuthToken returns the auth token that a request to pachd carries, or "" if
+// ctx, the request's context, doesn't have one.
+func AuthToken(ctx context.Context) string {
+	md, ok := metadata.FromContext(ctx)
+	if !ok || len(md[authTokenKey]) == 0 {
+		return ""
+	}
+	return md[authTokenKey][0]
+}
+
+func withAuthToken(ctx context.Context, token string) context.Context {
+	md, ok := metadata.FromContext(ctx)
+	if ok {
+		md = md.Copy()
+	} else {
+		md = metadata.MD{}
+	}
+	md[authTokenKey] = []string{token}
+	return metadata.NewContext(ctx, md)
+}
+
+func authTokenUnaryInterceptor(token string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(withAuthToken(ctx, token), method, req, reply, cc, opts...)
+	}
+}
+
+func authTokenStreamInterceptor(token string) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(withAuthToken(ctx, token), desc, cc, method, opts...)
+	}
+}
+
+// NewOnUserMachine constructs a new APIClient for use on a user's machine,
+// e.g. by pachctl. It connects to the cluster described by the active
+// context in the user's config (~/.pachyderm/config.json), using TLS and
+// sending an auth token if the context says to. $ADDRESS, if it's set,
+// overrides the context's pachd address, if neither gives an address the
+// client connects to DefaultPachdAddress. options are applied after those
+// given by the context.
+func NewOnUserMachine(metrics bool, prefix string, options ...Option) (*APIClient, error) {
+	if sharedUserMachineClient != nil {
+		c := *sharedUserMachineClient
+		for _, option := range options {
+			option(&c)
+		}
+		c.reportUserMetrics = metrics
+		c.metricsPrefix = prefix
+		return &c, nil
+	}
+	cfg, err := config.Read()
+	if err != nil {
+		// as with metrics, a broken config isn't fatal, we connect to
+		// $ADDRESS or the default address instead
+		log.Errorf("error loading user config from ~/.pachyderm/config.json: %v", err)
+	}
+	activeContext, err := cfg.CurrentContext()
+	if err != nil {
+		return nil, err
+	}
+	addr := os.Getenv("ADDRESS")
+	var contextOptions []Option
+	if activeContext != nil {
+		if addr == "" {
+			addr = activeContext.PachdAddress
+		}
+		if activeContext.ServerCAs != "" {
+			creds, err := credentials.NewClientTLSFromFile(activeContext.ServerCAs, "")
+			if err != nil {
+				return nil, fmt.Errorf("error loading server CAs from %s: %v", activeContext.ServerCAs, err)
+			}
+			contextOptions = append(contextOptions, WithTransportCredentials(creds))
+		}
+		if activeContext.AuthToken != "" {
+			contextOptions = append(contextOptions, WithAuthToken(activeContext.AuthToken))
+		}
+		if activeContext.Compress {
+			contextOptions = append(contextOptions, WithCompression())
+		}
+	}
+	if addr == "" {
+		addr = DefaultPachdAddress
+	}
+	c, err := NewFromAddress(addr, append(contextOptions, options...)...)
+	if err != nil {
+		return nil, err
+	}
+	c.config = cfg
+	c.reportUserMetrics = metrics
+	c.metricsPrefix = prefix
+	c.checkVersion()
+	return c, nil
+}
+
+// checkVersion warns, on stderr, if pachd's version isn't compatible with
+// the client's. Commands run against an incompatible pachd tend to fail with
+// confusing errors, so the warning explains them.
+func (c *APIClient) checkVersion() {
+	ctx, cancel := context.WithTimeout(c.ctx(), time.Second)
+	defer cancel()
+	pachdVersion, err := versionpb.NewAPIClient(c.pool.conns[0]).GetVersion(ctx, &types.Empty{})
+	if err != nil {
+		// any problem reaching pachd is reported by the RPCs that the
+		// client is being created for
+		return
+	}
+	if !version.IsCompatible(version.Version, pachdVersion) {
+		fmt.Fprintf(os.Stderr, "WARNING: this client's version (%s) isn't compatible with pachd's (%s), so commands may fail. Use a %d.%d.x client with this cluster.\n",
+			version.PrettyPrintVersion(version.Version), version.PrettyPrintVersion(pachdVersion), pachdVersion.Major, pachdVersion.Minor)
+	}
+}
+
+// sharedUserMachineClient is copied by NewOnUserMachine, instead of
+// connecting to pachd, if it's set. See ShareOnUserMachine.
+var sharedUserMachineClient *APIClient
+
+// ShareOnUserMachine makes subsequent calls to NewOnUserMachine return
+// copies of c, which share c's connections to pachd, rather than connecting
+// again. pachctl shell uses it so that the commands in a session share one
+// connection. Options passed to NewOnUserMachine are applied to the copies,
+// but options which change how the client connects have no effect. Passing
+// nil makes NewOnUserMachine connect again. ShareOnUserMachine must not be
+// called concurrently with NewOnUserMachine.
+func ShareOnUserMachine(c *APIClient) {
+	sharedUserMachineClient = c
+}
+
 // NewMetricsClientFromAddress Creates a client that will report a user's Metrics
 func NewMetricsClientFromAddress(addr string, metrics bool, prefix string) (*APIClient, error) {
 	return NewMetricsClientFromAddressWithConcurrency(addr, metrics, prefix,
@@ -57,8 +380,8 @@ func NewMetricsClientFromAddress(addr string, metrics bool, prefix string) (*API
 // NewMetricsClientFromAddressWithConcurrency Creates a client that will report
 // a user's Metrics, and sets the max concurrency of streaming requests (GetFile
 // / PutFile)
-func NewMetricsClientFromAddressWithConcurrency(addr string, metrics bool, prefix string, maxConcurrentStreams uint) (*APIClient, error) {
-	c, err := NewFromAddress(addr)
+func NewMetricsClientFromAddressWithConcurrency(addr string, metrics bool, prefix string, maxConcurrentStreams uint, options ...Option) (*APIClient, error) {
+	c, err := NewFromAddressWithConcurrency(addr, maxConcurrentStreams, options...)
 	if err != nil {
 		return nil, err
 	}
@@ -76,11 +399,16 @@ func NewMetricsClientFromAddressWithConcurrency(addr string, metrics bool, prefi
 
 // NewFromAddressWithConcurrency constructs a new APIClient and sets the max
 // concurrency of streaming requests (GetFile / PutFile)
-func NewFromAddressWithConcurrency(addr string, maxConcurrentStreams uint) (*APIClient, error) {
+func NewFromAddressWithConcurrency(addr string, maxConcurrentStreams uint, options ...Option) (*APIClient, error) {
 	c := &APIClient{
 		addr:            addr,
+		poolSize:        DefaultConnectionPoolSize,
+		keepalive:       DefaultKeepalivePeriod,
 		streamSemaphore: make(chan struct{}, maxConcurrentStreams),
 	}
+	for _, option := range options {
+		option(c)
+	}
 	if err := c.connect(); err != nil {
 		return nil, err
 	}
@@ -88,30 +416,36 @@ func NewFromAddressWithConcurrency(addr string, maxConcurrentStreams uint) (*API
 }
 
 // NewFromAddress constructs a new APIClient for the server at addr.
-func NewFromAddress(addr string) (*APIClient, error) {
-	return NewFromAddressWithConcurrency(addr, DefaultMaxConcurrentStreams)
+func NewFromAddress(addr string, options ...Option) (*APIClient, error) {
+	return NewFromAddressWithConcurrency(addr, DefaultMaxConcurrentStreams, options...)
 }
 
 // NewInCluster constructs a new APIClient using env vars that Kubernetes creates.
 // This should be used to access Pachyderm from within a Kubernetes cluster
 // with Pachyderm running on it.
-func NewInCluster() (*APIClient, error) {
+func NewInCluster(options ...Option) (*APIClient, error) {
 	addr := os.Getenv("PACHD_PORT_650_TCP_ADDR")
 
 	if addr == "" {
 		return nil, fmt.Errorf("PACHD_PORT_650_TCP_ADDR not set")
 	}
 
-	return NewFromAddress(fmt.Sprintf("%v:650", addr))
+	return NewFromAddress(net.JoinHostPort(addr, "650"), options...)
 }
 
-// Close the connection to gRPC
+// Close the connections to gRPC
 func (c *APIClient) Close() error {
-	return c.clientConn.Close()
+	if c.pool == nil {
+		return nil
+	}
+	return c.pool.Close()
 }
 
-// KeepConnected periodically health checks the connection and attempts to
-// reconnect if it becomes unhealthy.
+// KeepConnected periodically health checks the connection, which causes the
+// client to reconnect if the connection has been lost, even if it isn't
+// otherwise being used. Note that the client reconnects on its own when an
+// RPC fails because the connection is gone, so calling KeepConnected is only
+// necessary to reconnect eagerly.
 func (c *APIClient) KeepConnected(cancel chan bool) {
 	for {
 		select {
@@ -120,8 +454,7 @@ func (c *APIClient) KeepConnected(cancel chan bool) {
 		case <-time.After(time.Second * 5):
 			ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 			if _, err := c.healthClient.Health(ctx, &types.Empty{}); err != nil {
-				c.cancel()
-				c.connect()
+				log.Errorf("error health checking pachd: %v", sanitizeErr(err))
 			}
 			cancel()
 		}
@@ -146,13 +479,31 @@ func (c APIClient) DeleteAll() error {
 	return nil
 }
 
+// chunkSize returns the largest amount of data the client should put in a
+// single streaming request. The message size includes the whole payload
+// including headers, so we're conservative and halve it.
+func (c APIClient) chunkSize() int {
+	if c.maxMsgSize == 0 {
+		return grpcutil.MaxMsgSize / 2
+	}
+	return c.maxMsgSize / 2
+}
+
 // SetMaxConcurrentStreams Sets the maximum number of concurrent streams the
 // client can have. It is not safe to call this operations while operations are
 // outstanding.
-func (c APIClient) SetMaxConcurrentStreams(n int) {
+func (c *APIClient) SetMaxConcurrentStreams(n int) {
 	c.streamSemaphore = make(chan struct{}, n)
 }
 
+// SetProgressFunc registers a callback that's invoked as PutFile and GetFile
+// transfer data, it's useful for displaying progress on long transfers. Pass
+// nil to unregister the callback. It is not safe to call this while
+// operations are outstanding.
+func (c *APIClient) SetProgressFunc(progress ProgressFunc) {
+	c.progress = progress
+}
+
 // EtcdDialOptions is a helper returning a slice of grpc.Dial options
 // such that grpc.Dial() is synchronous: the call doesn't return until
 // the connection has been established and it's safe to send RPCs
@@ -177,23 +528,74 @@ func PachDialOptions() []grpc.DialOption {
 	return append(EtcdDialOptions(), grpc.WithInsecure())
 }
 
+// PeerDialOptions returns options for grpc.Dial for pachd's connections to
+// itself and to other pachds, which are secured with creds if pachd serves
+// TLS (see grpcutil.LoadTLS), and are insecure if creds is nil.
+func PeerDialOptions(creds credentials.TransportCredentials) []grpc.DialOption {
+	if creds == nil {
+		return PachDialOptions()
+	}
+	return append(EtcdDialOptions(), grpc.WithTransportCredentials(creds))
+}
+
+// Addr returns the address of the pachd that the client is connected to.
+func (c *APIClient) Addr() string {
+	return c.addr
+}
+
+// ClientConn returns the client's connection to pachd, for use with APIs
+// that APIClient doesn't wrap (e.g. versionpb).
+func (c *APIClient) ClientConn() *grpc.ClientConn {
+	return c.pool.conns[0]
+}
+
 func (c *APIClient) connect() error {
-	clientConn, err := grpc.Dial(c.addr, PachDialOptions()...)
+	dialOptions := PachDialOptions()
+	if c.creds != nil {
+		dialOptions = append(EtcdDialOptions(), grpc.WithTransportCredentials(c.creds))
+	}
+	if c.keepalive > 0 {
+		dialOptions = append(dialOptions, grpc.WithDialer(keepaliveDialer(c.keepalive)))
+	}
+	switch len(c.statsHandlers) {
+	case 0:
+	case 1:
+		dialOptions = append(dialOptions, grpc.WithStatsHandler(c.statsHandlers[0]))
+	default:
+		dialOptions = append(dialOptions, grpc.WithStatsHandler(multiStatsHandler(c.statsHandlers)))
+	}
+	dialOptions = append(dialOptions, c.dialOptions...)
+	pool, err := newConnPool(c.addr, c.poolSize, dialOptions, c.unaryInterceptors, c.streamInterceptors)
 	if err != nil {
 		return err
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
-	c.PfsAPIClient = pfs.NewAPIClient(clientConn)
-	c.PpsAPIClient = pps.NewAPIClient(clientConn)
-	c.ObjectAPIClient = pfs.NewObjectAPIClient(clientConn)
-	c.clientConn = clientConn
-	c.healthClient = health.NewHealthClient(clientConn)
+	c.PfsAPIClient = &pooledPfsAPIClient{pool.pfs[0], pool}
+	c.PpsAPIClient = pps.NewAPIClient(pool.conns[0])
+	c.ObjectAPIClient = &pooledObjectAPIClient{pool.objects[0], pool}
+	c.AdminAPIClient = admin.NewAPIClient(pool.conns[0])
+	c.AuthAPIClient = auth.NewAPIClient(pool.conns[0])
+	c.pool = pool
+	c.healthClient = health.NewHealthClient(pool.conns[0])
 	c._ctx = ctx
 	c.cancel = cancel
 	return nil
 }
 
+// keepaliveDialer returns a dialer, for use with grpc.WithDialer, which
+// enables TCP keepalives with the given period, so that dead connections to
+// pachd are detected even when the client is idle.
+func keepaliveDialer(period time.Duration) func(string, time.Duration) (net.Conn, error) {
+	return func(addr string, timeout time.Duration) (net.Conn, error) {
+		dialer := &net.Dialer{
+			Timeout:   timeout,
+			KeepAlive: period,
+		}
+		return dialer.Dial("tcp", addr)
+	}
+}
+
 func (c *APIClient) addMetadata(ctx context.Context) context.Context {
 	if !c.reportUserMetrics {
 		return ctx
@@ -226,11 +628,3 @@ func (c *APIClient) ctx() context.Context {
 	}
 	return c.addMetadata(c._ctx)
 }
-
-func sanitizeErr(err error) error {
-	if err == nil {
-		return nil
-	}
-
-	return errors.New(grpc.ErrorDesc(err))
-}
@@ -0,0 +1,202 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+// The following errors are returned (wrapped) by the client when pachd
+// reports the corresponding condition. They should be tested for with
+// errors.Is, the message of the returned error is the one sent by pachd and
+// may change between releases.
+var (
+	// ErrRepoNotFound indicates that a repo doesn't exist.
+	ErrRepoNotFound = errors.New("repo not found")
+	// ErrRepoExists indicates that a repo already exists.
+	ErrRepoExists = errors.New("repo already exists")
+	// ErrCommitNotFound indicates that a commit or branch doesn't exist.
+	ErrCommitNotFound = errors.New("commit not found")
+	// ErrCommitFinished indicates that a commit has already been finished
+	// and can't be written to.
+	ErrCommitFinished = errors.New("commit has already finished")
+	// ErrCommitConflict indicates that a commit was started on a branch
+	// whose head is still open. Only one commit can be open on a branch at a
+	// time, so the request can be retried once the head is finished.
+	ErrCommitConflict = errors.New("branch has an open commit")
+	// ErrFileNotFound indicates that a file doesn't exist.
+	ErrFileNotFound = errors.New("file not found")
+	// ErrJobNotFound indicates that a job doesn't exist.
+	ErrJobNotFound = errors.New("job not found")
+	// ErrPipelineNotFound indicates that a pipeline doesn't exist.
+	ErrPipelineNotFound = errors.New("pipeline not found")
+	// ErrPipelineExists indicates that a pipeline already exists.
+	ErrPipelineExists = errors.New("pipeline already exists")
+	// ErrNotSignedIn indicates that auth is active and a request didn't
+	// carry a valid token.
+	ErrNotSignedIn = errors.New("not signed in")
+	// ErrAuthNotActivated indicates that auth hasn't been activated.
+	ErrAuthNotActivated = errors.New("auth is not activated")
+	// ErrAuthAlreadyActivated indicates that auth has already been
+	// activated.
+	ErrAuthAlreadyActivated = errors.New("auth is already activated")
+	// ErrNotAuthorized indicates that a user doesn't have the scope that a
+	// request requires in a repo's ACL.
+	ErrNotAuthorized = errors.New("not authorized")
+	// ErrReadOnly indicates that a request would change the cluster, which
+	// has been made read-only for maintenance.
+	ErrReadOnly = errors.New("cluster is read-only")
+)
+
+// errorKindKey is the trailer in which pachd names the kind of error (one of
+// errorKinds) that it's returning, which the client uses to recreate it. The
+// error's code is also set, for clients that don't read the trailer.
+const errorKindKey = "pachyderm-error-kind"
+
+// errorKinds are the errors above, the code pachd returns each with and the
+// name it's sent by in errorKindKey. Names must not change, as they're part
+// of pachd's API.
+var errorKinds = []struct {
+	name string
+	code codes.Code
+	kind error
+}{
+	{"RepoNotFound", codes.NotFound, ErrRepoNotFound},
+	{"RepoExists", codes.AlreadyExists, ErrRepoExists},
+	{"CommitNotFound", codes.NotFound, ErrCommitNotFound},
+	{"CommitFinished", codes.FailedPrecondition, ErrCommitFinished},
+	{"CommitConflict", codes.Aborted, ErrCommitConflict},
+	{"FileNotFound", codes.NotFound, ErrFileNotFound},
+	{"JobNotFound", codes.NotFound, ErrJobNotFound},
+	{"PipelineNotFound", codes.NotFound, ErrPipelineNotFound},
+	{"PipelineExists", codes.AlreadyExists, ErrPipelineExists},
+	{"NotSignedIn", codes.Unauthenticated, ErrNotSignedIn},
+	{"AuthNotActivated", codes.FailedPrecondition, ErrAuthNotActivated},
+	{"AuthAlreadyActivated", codes.FailedPrecondition, ErrAuthAlreadyActivated},
+	{"NotAuthorized", codes.PermissionDenied, ErrNotAuthorized},
+	{"ReadOnly", codes.FailedPrecondition, ErrReadOnly},
+}
+
+// apiError is an error returned by pachd. It preserves pachd's message while
+// allowing callers to match it against one of the exported errors above.
+type apiError struct {
+	desc string
+	kind error
+}
+
+// Errorf returns an error whose message is formatted from format and a, and
+// which is kind (one of the errors above) according to errors.Is. It's for
+// pachd and fakes of it; pachd sends the errors it returns to clients with
+// kind's code, and the client returns them as they were created.
+func Errorf(kind error, format string, a ...interface{}) error {
+	return &apiError{desc: fmt.Sprintf(format, a...), kind: kind}
+}
+
+func (e *apiError) Error() string {
+	return e.desc
+}
+
+// Unwrap returns the exported error that e corresponds to (or nil), it's
+// what makes errors.Is and errors.As work with the errors this client
+// returns.
+func (e *apiError) Unwrap() error {
+	return e.kind
+}
+
+// ErrorUnaryServerInterceptor sends the errors returned by pachd's handlers
+// which are (according to errors.Is) one of the errors above with that
+// error's code, and names it in the response's trailer, so that clients can
+// recreate it. Other errors are returned as they are.
+func ErrorUnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	if err != nil {
+		var trailer metadata.MD
+		if err, trailer = toGRPCErr(err); trailer != nil {
+			grpc.SetTrailer(ctx, trailer)
+		}
+	}
+	return resp, err
+}
+
+// ErrorStreamServerInterceptor is ErrorUnaryServerInterceptor for streaming
+// methods.
+func ErrorStreamServerInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	err := handler(srv, stream)
+	if err != nil {
+		var trailer metadata.MD
+		if err, trailer = toGRPCErr(err); trailer != nil {
+			stream.SetTrailer(trailer)
+		}
+	}
+	return err
+}
+
+// toGRPCErr returns the error that pachd sends in place of err, and the
+// trailer that names its kind, which is nil if err isn't one of the errors
+// above.
+func toGRPCErr(err error) (error, metadata.MD) {
+	for _, errorKind := range errorKinds {
+		if errors.Is(err, errorKind.kind) {
+			return grpc.Errorf(errorKind.code, "%s", err.Error()), metadata.Pairs(errorKindKey, errorKind.name)
+		}
+	}
+	return err, nil
+}
+
+// fromGRPCErr recreates the error that pachd returned as err, with the kind
+// named in trailer. err is returned as it is if it wasn't one of the errors
+// above.
+func fromGRPCErr(err error, trailer metadata.MD) error {
+	if err == nil || len(trailer[errorKindKey]) == 0 {
+		return err
+	}
+	for _, errorKind := range errorKinds {
+		if errorKind.name == trailer[errorKindKey][0] && errorKind.code == grpc.Code(err) {
+			return &apiError{desc: grpc.ErrorDesc(err), kind: errorKind.kind}
+		}
+	}
+	return err
+}
+
+func errorUnaryInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	var trailer metadata.MD
+	err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Trailer(&trailer))...)
+	return fromGRPCErr(err, trailer)
+}
+
+func errorStreamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	stream, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return errorClientStream{stream}, nil
+}
+
+// errorClientStream recreates the errors that pachd ends a stream with, as
+// they're only received (along with the stream's trailer) by RecvMsg.
+type errorClientStream struct {
+	grpc.ClientStream
+}
+
+func (s errorClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil && err != io.EOF {
+		return fromGRPCErr(err, s.Trailer())
+	}
+	return err
+}
+
+func sanitizeErr(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*apiError); ok {
+		return err
+	}
+	return errors.New(grpc.ErrorDesc(err))
+}
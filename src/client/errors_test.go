@@ -0,0 +1,55 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestErrorKinds(t *testing.T) {
+	for _, errorKind := range errorKinds {
+		desc := fmt.Sprintf("pachd's description of %s", errorKind.name)
+		// pachd's handlers may wrap the errors they return
+		for _, err := range []error{
+			Errorf(errorKind.kind, "%s", desc),
+			fmt.Errorf("%w", Errorf(errorKind.kind, "%s", desc)),
+		} {
+			sent, trailer := toGRPCErr(err)
+			require.Equal(t, errorKind.code, grpc.Code(sent))
+			require.Equal(t, desc, grpc.ErrorDesc(sent))
+
+			received := sanitizeErr(fromGRPCErr(sent, trailer))
+			require.Equal(t, desc, received.Error())
+			require.True(t, errors.Is(received, errorKind.kind), errorKind.name)
+			for _, other := range errorKinds {
+				if other.kind != errorKind.kind {
+					require.False(t, errors.Is(received, other.kind), errorKind.name)
+				}
+			}
+		}
+	}
+}
+
+func TestUntypedErrors(t *testing.T) {
+	// errors that aren't one of the exported errors are sent as they are
+	err := grpc.Errorf(codes.Unknown, "repo foo not found")
+	sent, trailer := toGRPCErr(err)
+	require.Equal(t, err, sent)
+	require.Equal(t, 0, len(trailer))
+	received := sanitizeErr(fromGRPCErr(sent, trailer))
+	require.Equal(t, "repo foo not found", received.Error())
+	require.False(t, errors.Is(received, ErrRepoNotFound))
+
+	// a kind is only recreated if the code matches it
+	err = grpc.Errorf(codes.Internal, "repo foo not found")
+	received = fromGRPCErr(err, metadata.Pairs(errorKindKey, "RepoNotFound"))
+	require.False(t, errors.Is(received, ErrRepoNotFound))
+
+	require.NoError(t, sanitizeErr(nil))
+}
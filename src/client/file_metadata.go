@@ -0,0 +1,101 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+// MetadataFile is the name of the file, in the directory that a local
+// directory is uploaded to, that the metadata of the uploaded files is kept
+// in when PutFilesOptions.PreserveMetadata is set. PFS doesn't store file
+// modes, modification times or symlinks itself, so this is what lets them be
+// restored when the directory is downloaded again.
+const MetadataFile = ".pfs_metadata"
+
+// FileMetadata is the local metadata of an uploaded file, as it's recorded
+// in a MetadataFile.
+type FileMetadata struct {
+	// Path is the path of the file relative to the directory that holds the
+	// MetadataFile, separated by slashes.
+	Path string `json:"path"`
+	// Mode is the file's permission bits, e.g. whether it's executable.
+	Mode os.FileMode `json:"mode"`
+	// ModTime is the file's modification time.
+	ModTime time.Time `json:"mod_time"`
+	// Symlink is the target of the link if the file is a symlink. Symlinks
+	// aren't uploaded as files, they're only recorded here.
+	Symlink string `json:"symlink,omitempty"`
+}
+
+// NewFileMetadata returns the metadata of the local file at localPath, which
+// info describes, that's uploaded as relPath.
+func NewFileMetadata(relPath string, localPath string, info os.FileInfo) (*FileMetadata, error) {
+	metadata := &FileMetadata{
+		Path:    filepath.ToSlash(relPath),
+		Mode:    info.Mode().Perm(),
+		ModTime: info.ModTime(),
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(localPath)
+		if err != nil {
+			return nil, err
+		}
+		metadata.Symlink = target
+	}
+	return metadata, nil
+}
+
+// PutFileMetadata records metadata in the MetadataFile of dir. Records are
+// appended, one JSON object per line, so several uploads to the same
+// directory can record metadata, later records replace earlier ones for the
+// same path.
+func (c APIClient) PutFileMetadata(repoName string, commitID string, dir string, metadata []*FileMetadata) error {
+	if len(metadata) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, m := range metadata {
+		if err := encoder.Encode(m); err != nil {
+			return err
+		}
+	}
+	_, err := c.PutFile(repoName, commitID, path.Join(dir, MetadataFile), &buf)
+	return err
+}
+
+// GetFileMetadata returns the metadata recorded in the MetadataFile of dir,
+// keyed by path. It returns an empty map if dir has no MetadataFile.
+func (c APIClient) GetFileMetadata(repoName string, commitID string, dir string) (map[string]*FileMetadata, error) {
+	var buf bytes.Buffer
+	if err := c.GetFile(repoName, commitID, path.Join(dir, MetadataFile), 0, 0, &buf); err != nil {
+		if errors.Is(err, ErrFileNotFound) {
+			return map[string]*FileMetadata{}, nil
+		}
+		return nil, err
+	}
+	return ReadFileMetadata(&buf)
+}
+
+// ReadFileMetadata reads the records of a MetadataFile from r, keyed by
+// path.
+func ReadFileMetadata(r io.Reader) (map[string]*FileMetadata, error) {
+	result := make(map[string]*FileMetadata)
+	decoder := json.NewDecoder(r)
+	for {
+		metadata := &FileMetadata{}
+		if err := decoder.Decode(metadata); err != nil {
+			if err == io.EOF {
+				return result, nil
+			}
+			return nil, err
+		}
+		result[metadata.Path] = metadata
+	}
+}
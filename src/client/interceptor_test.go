@@ -0,0 +1,52 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+func TestChainUnaryInterceptors(t *testing.T) {
+	var calls []string
+	interceptor := func(name string) grpc.UnaryClientInterceptor {
+		return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			calls = append(calls, name)
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			calls = append(calls, name)
+			return err
+		}
+	}
+	chain := chainUnaryInterceptors([]grpc.UnaryClientInterceptor{interceptor("a"), interceptor("b")})
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		calls = append(calls, method)
+		return nil
+	}
+	require.NoError(t, chain(context.Background(), "/pfs.API/ListRepo", nil, nil, nil, invoker))
+	require.Equal(t, []string{"a", "b", "/pfs.API/ListRepo", "b", "a"}, calls)
+
+	// the chain must be reusable
+	calls = nil
+	require.NoError(t, chain(context.Background(), "/pfs.API/ListRepo", nil, nil, nil, invoker))
+	require.Equal(t, 5, len(calls))
+}
+
+func TestChainStreamInterceptors(t *testing.T) {
+	var calls []string
+	interceptor := func(name string) grpc.StreamClientInterceptor {
+		return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			calls = append(calls, name)
+			return streamer(ctx, desc, cc, method, opts...)
+		}
+	}
+	chain := chainStreamInterceptors([]grpc.StreamClientInterceptor{interceptor("a"), interceptor("b")})
+	streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		calls = append(calls, method)
+		return nil, nil
+	}
+	_, err := chain(context.Background(), nil, nil, "/pfs.API/GetFile", streamer)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b", "/pfs.API/GetFile"}, calls)
+}
@@ -0,0 +1,148 @@
+package client
+
+import (
+	"io"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client/admin"
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+	"golang.org/x/net/context"
+)
+
+// PfsClient is the set of high-level PFS operations offered by APIClient.
+// Code that only needs to read and write data can depend on this interface
+// rather than the concrete client, which makes it easy to substitute a fake
+// or wrap the client (e.g. to add metrics or tracing).
+type PfsClient interface {
+	CreateRepo(repoName string) error
+	InspectRepo(repoName string) (*pfs.RepoInfo, error)
+	ListRepo(provenance []string) ([]*pfs.RepoInfo, error)
+	ListRepoByLabel(labelSelector string) ([]*pfs.RepoInfo, error)
+	SetRepoLabels(repoName string, labels map[string]string) error
+	RemoveRepoLabels(repoName string, keys ...string) error
+	DeleteRepo(repoName string, force bool) error
+
+	StartCommit(repoName string, branch string) (*pfs.Commit, error)
+	StartCommitParent(repoName string, branch string, parentCommit string) (*pfs.Commit, error)
+	FinishCommit(repoName string, commitID string) error
+	FinishCommits(commits []*pfs.Commit) (*pfs.Transaction, error)
+	InspectCommit(repoName string, commitID string) (*pfs.CommitInfo, error)
+	ListCommit(repoName string, to string, from string, number uint64) ([]*pfs.CommitInfo, error)
+	ListCommitIter(repoName string, to string, from string, number uint64) (CommitInfoIterator, error)
+	ListCommitByRepo(repoName string) ([]*pfs.CommitInfo, error)
+	DeleteCommit(repoName string, commitID string) error
+	FlushCommit(commits []*pfs.Commit, toRepos []*pfs.Repo) (CommitInfoIterator, error)
+	FlushCommits(flushes []*pfs.FlushCommitRequest, f func(int, *pfs.CommitInfo) error) error
+	SubscribeCommit(repo string, branch string, from string) (CommitInfoIterator, error)
+
+	ListBranch(repoName string) ([]*pfs.Branch, error)
+	SetBranch(repoName string, commit string, branch string) error
+	SetBranchTrigger(repoName string, branch string, trigger *pfs.Trigger) error
+	SetBranchRetention(repoName string, branch string, retention *pfs.RetentionPolicy) error
+	DeleteBranch(repoName string, branch string) error
+
+	PutFileWriter(repoName string, commitID string, path string) (io.WriteCloser, error)
+	PutFileSplitWriter(repoName string, commitID string, path string, delimiter pfs.Delimiter, targetFileDatums int64, targetFileBytes int64) (io.WriteCloser, error)
+	PutFile(repoName string, commitID string, path string, reader io.Reader) (int, error)
+	PutFileSplit(repoName string, commitID string, path string, delimiter pfs.Delimiter, targetFileDatums int64, targetFileBytes int64, reader io.Reader) (int, error)
+	PutFileURL(repoName string, commitID string, path string, url string, recursive bool) error
+	PutFiles(repoName string, commitID string, localDir string, opts *PutFilesOptions) error
+	NewPutFileBatch(repoName string, commitID string) (*PutFileBatch, error)
+	GetFile(repoName string, commitID string, path string, offset int64, size int64, writer io.Writer) error
+	GetFileReader(repoName string, commitID string, path string, offset int64, size int64) (io.Reader, error)
+	GetParquetFile(repoName string, commitID string, path string, columns []string, rowGroups []int64, writer io.Writer) error
+	GetFiles(repoName string, commitID string, paths []string, f func(fileInfo *pfs.FileInfo, r io.Reader) error) error
+	GetFileURL(repoName string, commitID string, path string, ttl time.Duration) (string, error)
+	InspectFile(repoName string, commitID string, path string) (*pfs.FileInfo, error)
+	ListFile(repoName string, commitID string, path string) ([]*pfs.FileInfo, error)
+	ListFilePage(repoName string, commitID string, path string, from string, number uint64) ([]*pfs.FileInfo, error)
+	ListFileIter(repoName string, commitID string, path string) (FileInfoIterator, error)
+	GlobFile(repoName string, commitID string, pattern string) ([]*pfs.FileInfo, error)
+	Walk(repoName string, commitID string, path string, walkFn WalkFn) error
+	DeleteFile(repoName string, commitID string, path string) error
+	DeleteFiles(repoName string, commitID string, paths []string, pattern string) error
+}
+
+// ObjectClient is the set of high-level object store operations offered by
+// APIClient.
+type ObjectClient interface {
+	PutObject(r io.Reader, tags ...string) (*pfs.Object, int64, error)
+	GetObject(hash string, writer io.Writer) error
+	ReadObject(hash string) ([]byte, error)
+	GetObjects(hashes []string, offset uint64, size uint64, writer io.Writer) error
+	ReadObjects(hashes []string, offset uint64, size uint64) ([]byte, error)
+	TagObject(hash string, tags ...string) error
+	InspectObject(hash string) (*pfs.ObjectInfo, error)
+	CheckObject(hash string) (bool, error)
+	GetTag(tag string, writer io.Writer) error
+	ReadTag(tag string) ([]byte, error)
+	Compact() error
+}
+
+// PpsClient is the set of high-level PPS operations offered by APIClient.
+type PpsClient interface {
+	CreateJob(image string, cmd []string, stdin []string, parallelismSpec *pps.ParallelismSpec, input *pps.Input, internalPort int32, externalPort int32) (*pps.Job, error)
+	InspectJob(jobID string, blockState bool) (*pps.JobInfo, error)
+	WatchJob(jobID string) *JobWatcher
+	WaitJob(ctx context.Context, jobID string) (*pps.JobInfo, error)
+	ListJob(pipelineName string, inputCommit []*pfs.Commit) ([]*pps.JobInfo, error)
+	ListJobNoFull(pipelineName string, inputCommit []*pfs.Commit) ([]*pps.JobInfo, error)
+	FlushJob(commits []*pfs.Commit, toPipelines []string) (JobInfoIterator, error)
+	DeleteJob(jobID string) error
+	StopJob(jobID string) error
+	RestartDatum(jobID string, datumFilter []string) error
+	ListDatum(jobID string) ([]*pps.DatumInfo, error)
+	InspectDatum(jobID string, datumID string) (*pps.DatumInfo, error)
+	GetLogs(pipelineName string, jobID string, data []string) *LogsIter
+	GetLogsWithOptions(pipelineName string, jobID string, data []string, datumID string, follow bool, tail int64) *LogsIter
+
+	CreatePipeline(name string, image string, cmd []string, stdin []string, parallelismSpec *pps.ParallelismSpec, input *pps.Input, outputBranch string, update bool) error
+	InspectPipeline(pipelineName string) (*pps.PipelineInfo, error)
+	ListPipeline() ([]*pps.PipelineInfo, error)
+	DeletePipeline(name string, deleteJobs bool) error
+	StartPipeline(name string) error
+	StopPipeline(name string) error
+	RerunPipeline(name string, include []*pfs.Commit, exclude []*pfs.Commit) error
+	ClearDatumCache(name string, glob string) error
+	RunPipeline(name string, provenance []*pfs.Commit) (*pps.Job, error)
+	RunTransaction(ops []*pps.TransactionOp) (*pps.TransactionInfo, error)
+}
+
+// AuthClient is the set of high-level auth operations offered by APIClient.
+type AuthClient interface {
+	Activate(subject string) (string, error)
+	Deactivate() error
+	WhoAmI() (string, error)
+	GetToken(subject string, ttl time.Duration) (string, error)
+	GetOAuthLoginURL(provider auth.Provider, redirectURI string, state string) (string, auth.Provider, error)
+	Authenticate(provider auth.Provider, code string, redirectURI string) (string, error)
+	GetACL(repo string) (*auth.ACL, error)
+	SetScope(repo string, username string, scope auth.Scope) error
+	GetRobotToken(robot string, scopes map[string]auth.Scope, ttl time.Duration) (string, error)
+	ModifyAdmins(add []string, remove []string) error
+	GetAdmins() ([]string, error)
+	RenewToken(ttl time.Duration) (time.Time, error)
+	RevokeToken(token string) error
+	RevokeUserTokens(username string) (int64, error)
+}
+
+// Client is the full high-level API offered by APIClient.
+type Client interface {
+	PfsClient
+	ObjectClient
+	PpsClient
+	AuthClient
+	InspectCluster() (*admin.ClusterInfo, error)
+	DeleteAll() error
+	Close() error
+}
+
+var (
+	_ PfsClient    = APIClient{}
+	_ ObjectClient = APIClient{}
+	_ PpsClient    = APIClient{}
+	_ AuthClient   = APIClient{}
+	_ Client       = &APIClient{}
+)
@@ -3,14 +3,24 @@ package client
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io"
+	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/gogo/protobuf/types"
+	"github.com/pachyderm/pachyderm/src/client/limit"
 	"github.com/pachyderm/pachyderm/src/client/pfs"
 	"github.com/pachyderm/pachyderm/src/client/pkg/grpcutil"
+
+	"golang.org/x/sync/errgroup"
 )
 
+// DefaultPutFilesParallelism is the number of files PutFiles uploads
+// concurrently when PutFilesOptions doesn't specify a parallelism.
+const DefaultPutFilesParallelism = 10
+
 // NewRepo creates a pfs.Repo.
 func NewRepo(repoName string) *pfs.Repo {
 	return &pfs.Repo{Name: repoName}
@@ -86,6 +96,44 @@ func (c APIClient) ListRepo(provenance []string) ([]*pfs.RepoInfo, error) {
 	return repoInfos.RepoInfo, nil
 }
 
+// ListRepoByLabel returns info about the repos whose labels match
+// labelSelector, a kubernetes style label selector, e.g. "team=data,pii".
+func (c APIClient) ListRepoByLabel(labelSelector string) ([]*pfs.RepoInfo, error) {
+	repoInfos, err := c.PfsAPIClient.ListRepo(
+		c.ctx(),
+		&pfs.ListRepoRequest{LabelSelector: labelSelector},
+	)
+	if err != nil {
+		return nil, sanitizeErr(err)
+	}
+	return repoInfos.RepoInfo, nil
+}
+
+// SetRepoLabels sets labels on a repo, replacing the values of any of them
+// that it already has. Its other labels are left as they are.
+func (c APIClient) SetRepoLabels(repoName string, labels map[string]string) error {
+	_, err := c.PfsAPIClient.UpdateRepo(
+		c.ctx(),
+		&pfs.UpdateRepoRequest{
+			Repo:   NewRepo(repoName),
+			Labels: labels,
+		},
+	)
+	return sanitizeErr(err)
+}
+
+// RemoveRepoLabels removes the labels with the given keys from a repo.
+func (c APIClient) RemoveRepoLabels(repoName string, keys ...string) error {
+	_, err := c.PfsAPIClient.UpdateRepo(
+		c.ctx(),
+		&pfs.UpdateRepoRequest{
+			Repo:         NewRepo(repoName),
+			RemoveLabels: keys,
+		},
+	)
+	return sanitizeErr(err)
+}
+
 // DeleteRepo deletes a repo and reclaims the storage space it was using. Note
 // that as of 1.0 we do not reclaim the blocks that the Repo was referencing,
 // this is because they may also be referenced by other Repos and deleting them
@@ -101,7 +149,7 @@ func (c APIClient) DeleteRepo(repoName string, force bool) error {
 			Force: force,
 		},
 	)
-	return err
+	return sanitizeErr(err)
 }
 
 // StartCommit begins the process of committing data to a Repo. Once started
@@ -164,6 +212,55 @@ func (c APIClient) StartCommitParent(repoName string, branch string, parentCommi
 	return commit, nil
 }
 
+// ForceStartCommit is like StartCommitParent, except that it can start
+// commits in output repos, which are otherwise only written by the pipelines
+// and jobs that created them. Manual commits in output repos have no
+// provenance, so they should only be used to repair them. If auth is active,
+// it needs the OWNER scope in the repo.
+func (c APIClient) ForceStartCommit(repoName string, branch string, parentCommit string) (*pfs.Commit, error) {
+	commit, err := c.PfsAPIClient.StartCommit(
+		c.ctx(),
+		&pfs.StartCommitRequest{
+			Parent: &pfs.Commit{
+				Repo: &pfs.Repo{
+					Name: repoName,
+				},
+				ID: parentCommit,
+			},
+			Branch: branch,
+			Force:  true,
+		},
+	)
+	if err != nil {
+		return nil, sanitizeErr(err)
+	}
+	return commit, nil
+}
+
+// StartCommitProvenance is like StartCommit, except that the new commit's
+// provenance includes the commits in provenance (and their provenance), so
+// that data derived outside of a pipeline, e.g. in a notebook, can still be
+// traced back to its inputs. provenance's commits must be given by ID, not
+// by branch.
+func (c APIClient) StartCommitProvenance(repoName string, branch string, provenance []*pfs.Commit) (*pfs.Commit, error) {
+	commit, err := c.PfsAPIClient.StartCommit(
+		c.ctx(),
+		&pfs.StartCommitRequest{
+			Parent: &pfs.Commit{
+				Repo: &pfs.Repo{
+					Name: repoName,
+				},
+			},
+			Branch:     branch,
+			Provenance: provenance,
+		},
+	)
+	if err != nil {
+		return nil, sanitizeErr(err)
+	}
+	return commit, nil
+}
+
 // FinishCommit ends the process of committing data to a Repo and persists the
 // Commit. Once a Commit is finished the data becomes immutable and future
 // attempts to write to it with PutFile will error.
@@ -177,6 +274,38 @@ func (c APIClient) FinishCommit(repoName string, commitID string) error {
 	return sanitizeErr(err)
 }
 
+// FinishCommitVerify is like FinishCommit, but the finished commit's tree is
+// checked for inconsistent sizes and references to missing objects first. If
+// it's inconsistent the commit is left open and an error describing the
+// inconsistency is returned.
+func (c APIClient) FinishCommitVerify(repoName string, commitID string) error {
+	_, err := c.PfsAPIClient.FinishCommit(
+		c.ctx(),
+		&pfs.FinishCommitRequest{
+			Commit: NewCommit(repoName, commitID),
+			Verify: true,
+		},
+	)
+	return sanitizeErr(err)
+}
+
+// FinishCommits finishes commits atomically: either all of them are
+// finished, at the same time, or none are. Pipelines with several of the
+// commits' branches as inputs process them in a single job, rather than one
+// job per commit.
+func (c APIClient) FinishCommits(commits []*pfs.Commit) (*pfs.Transaction, error) {
+	transaction, err := c.PfsAPIClient.FinishCommits(
+		c.ctx(),
+		&pfs.FinishCommitsRequest{
+			Commits: commits,
+		},
+	)
+	if err != nil {
+		return nil, sanitizeErr(err)
+	}
+	return transaction, nil
+}
+
 // InspectCommit returns info about a specific Commit.
 func (c APIClient) InspectCommit(repoName string, commitID string) (*pfs.CommitInfo, error) {
 	commitInfo, err := c.PfsAPIClient.InspectCommit(
@@ -200,6 +329,32 @@ func (c APIClient) InspectCommit(repoName string, commitID string) (*pfs.CommitI
 // `number` determines how many commits are returned.  If `number` is 0,
 // all commits that match the aforementioned criteria are returned.
 func (c APIClient) ListCommit(repoName string, to string, from string, number uint64) ([]*pfs.CommitInfo, error) {
+	iter, err := c.ListCommitIter(repoName, to, from, number)
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+	var commitInfos []*pfs.CommitInfo
+	for {
+		commitInfo, err := iter.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, sanitizeErr(err)
+		}
+		commitInfos = append(commitInfos, commitInfo)
+	}
+	return commitInfos, nil
+}
+
+// ListCommitIter is like ListCommit, but rather than returning all of the
+// CommitInfos at once it streams them back from pachd one at a time. It
+// should be used when listing repos with too many commits to hold in memory.
+// The returned iterator returns io.EOF once all commits have been returned.
+// NOTE: ListCommitIter returns a CommitInfoIterator you must call Close on
+// it when you are done with it.
+func (c APIClient) ListCommitIter(repoName string, to string, from string, number uint64) (CommitInfoIterator, error) {
 	req := &pfs.ListCommitRequest{
 		Repo:   NewRepo(repoName),
 		Number: number,
@@ -210,14 +365,13 @@ func (c APIClient) ListCommit(repoName string, to string, from string, number ui
 	if to != "" {
 		req.To = NewCommit(repoName, to)
 	}
-	commitInfos, err := c.PfsAPIClient.ListCommit(
-		c.ctx(),
-		req,
-	)
+	ctx, cancel := context.WithCancel(c.ctx())
+	stream, err := c.PfsAPIClient.ListCommitStream(ctx, req)
 	if err != nil {
+		cancel()
 		return nil, sanitizeErr(err)
 	}
-	return commitInfos.CommitInfo, nil
+	return &commitInfoIterator{stream, cancel}, nil
 }
 
 // ListCommitByRepo lists all commits in a repo.
@@ -251,6 +405,37 @@ func (c APIClient) SetBranch(repoName string, commit string, branch string) erro
 	return sanitizeErr(err)
 }
 
+// SetBranchTrigger sets the trigger of a branch, which moves it to the head
+// of trigger.Branch when the trigger's conditions are met. If trigger is
+// nil, the branch's trigger is removed.
+func (c APIClient) SetBranchTrigger(repoName string, branch string, trigger *pfs.Trigger) error {
+	_, err := c.PfsAPIClient.SetBranchTrigger(
+		c.ctx(),
+		&pfs.SetBranchTriggerRequest{
+			Repo:    NewRepo(repoName),
+			Branch:  branch,
+			Trigger: trigger,
+		},
+	)
+	return sanitizeErr(err)
+}
+
+// SetBranchRetention sets the retention policy of a branch, which prunes the
+// commits on it that the policy doesn't keep, unless they're the heads of
+// other branches or the provenance of commits downstream. If retention is
+// nil, the branch's policy is removed.
+func (c APIClient) SetBranchRetention(repoName string, branch string, retention *pfs.RetentionPolicy) error {
+	_, err := c.PfsAPIClient.SetBranchRetention(
+		c.ctx(),
+		&pfs.SetBranchRetentionRequest{
+			Repo:      NewRepo(repoName),
+			Branch:    branch,
+			Retention: retention,
+		},
+	)
+	return sanitizeErr(err)
+}
+
 // DeleteBranch deletes a branch, but leaves the commits themselves intact.
 // In other words, those commits can still be accessed via commit IDs and
 // other branches they happen to be on.
@@ -265,8 +450,10 @@ func (c APIClient) DeleteBranch(repoName string, branch string) error {
 	return sanitizeErr(err)
 }
 
-// DeleteCommit deletes a commit.
-// Note it is currently not implemented.
+// DeleteCommit deletes a commit, along with its descendants and the commits
+// downstream of them, such as the pipeline outputs computed from them.
+// Branches that pointed at deleted commits are moved back to their closest
+// ancestors that aren't deleted.
 func (c APIClient) DeleteCommit(repoName string, commitID string) error {
 	_, err := c.PfsAPIClient.DeleteCommit(
 		c.ctx(),
@@ -303,6 +490,35 @@ func (c APIClient) FlushCommit(commits []*pfs.Commit, toRepos []*pfs.Repo) (Comm
 	return &commitInfoIterator{stream, cancel}, nil
 }
 
+// FlushCommits does a FlushCommit for each of flushes at once, over a single
+// stream. f is called with each commit as soon as its jobs complete, along
+// with the index in flushes of the flush that it's downstream of, so callers
+// waiting on many branches don't need to hold a stream for each of them.
+func (c APIClient) FlushCommits(flushes []*pfs.FlushCommitRequest, f func(int, *pfs.CommitInfo) error) error {
+	ctx, cancel := context.WithCancel(c.ctx())
+	defer cancel()
+	stream, err := c.PfsAPIClient.FlushCommits(
+		ctx,
+		&pfs.FlushCommitsRequest{
+			Flushes: flushes,
+		},
+	)
+	if err != nil {
+		return sanitizeErr(err)
+	}
+	for {
+		response, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return sanitizeErr(err)
+		}
+		if err := f(int(response.Index), response.CommitInfo); err != nil {
+			return err
+		}
+	}
+}
+
 // CommitInfoIterator wraps a stream of commits and makes them easy to iterate.
 type CommitInfoIterator interface {
 	Next() (*pfs.CommitInfo, error)
@@ -458,6 +674,21 @@ func (c APIClient) InspectObject(hash string) (*pfs.ObjectInfo, error) {
 	return value, nil
 }
 
+// CheckObject returns true if an object exists and its content can be read
+// from object storage.
+func (c APIClient) CheckObject(hash string) (bool, error) {
+	response, err := c.ObjectAPIClient.CheckObject(
+		c.ctx(),
+		&pfs.CheckObjectRequest{
+			Object: &pfs.Object{Hash: hash},
+		},
+	)
+	if err != nil {
+		return false, sanitizeErr(err)
+	}
+	return response.Exists, nil
+}
+
 // GetTag gets an object out of the object store by tag.
 func (c APIClient) GetTag(tag string, writer io.Writer) error {
 	getTagClient, err := c.ObjectAPIClient.GetTag(
@@ -488,7 +719,7 @@ func (c APIClient) Compact() error {
 		c.ctx(),
 		&types.Empty{},
 	)
-	return err
+	return sanitizeErr(err)
 }
 
 // PutFileWriter writes a file to PFS.
@@ -527,11 +758,202 @@ func (c APIClient) PutFileSplit(repoName string, commitID string, path string, d
 		if err := writer.Close(); err != nil && retErr == nil {
 			retErr = err
 		}
+		if retErr == nil && c.progress != nil {
+			c.progress(0, 1)
+		}
 	}()
+	if c.progress != nil {
+		reader = &progressReader{reader, c.progress}
+	}
 	written, err := io.Copy(writer, reader)
 	return int(written), err
 }
 
+// PutFilesOptions are the options accepted by PutFiles.
+type PutFilesOptions struct {
+	// Path is the path in the commit under which the contents of the local
+	// directory are written. If empty the files are written at the root.
+	Path string
+	// Parallelism is the maximum number of files uploaded concurrently. If
+	// it's 0, DefaultPutFilesParallelism is used.
+	Parallelism int
+	// Overwrite causes each file to be deleted from the commit before it's
+	// written, rather than appended to.
+	Overwrite bool
+	// PreserveMetadata causes the files' modes and modification times, and
+	// symlinks, to be recorded in a MetadataFile under Path, so that they
+	// can be restored when the files are downloaded. Symlinks are recorded
+	// instead of being followed.
+	PreserveMetadata bool
+}
+
+// PutFiles recursively uploads the contents of localDir into a commit,
+// preserving the directory structure. Files are uploaded over several
+// concurrent streams, the number of which is controlled by opts. opts may be
+// nil in which case the defaults are used.
+func (c APIClient) PutFiles(repoName string, commitID string, localDir string, opts *PutFilesOptions) error {
+	if opts == nil {
+		opts = &PutFilesOptions{}
+	}
+	parallelism := opts.Parallelism
+	if parallelism == 0 {
+		parallelism = DefaultPutFilesParallelism
+	}
+	limiter := limit.New(parallelism)
+	var eg errgroup.Group
+	var metadata []*FileMetadata
+	if err := filepath.Walk(localDir, func(localPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		relPath, err := filepath.Rel(localDir, localPath)
+		if err != nil {
+			return err
+		}
+		if opts.PreserveMetadata {
+			if relPath == MetadataFile {
+				// the metadata of a previous download
+				return nil
+			}
+			m, err := NewFileMetadata(relPath, localPath, info)
+			if err != nil {
+				return err
+			}
+			metadata = append(metadata, m)
+			if m.Symlink != "" {
+				return nil
+			}
+		}
+		path := filepath.ToSlash(filepath.Join(opts.Path, relPath))
+		limiter.Acquire()
+		eg.Go(func() (retErr error) {
+			defer limiter.Release()
+			f, err := os.Open(localPath)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				if err := f.Close(); err != nil && retErr == nil {
+					retErr = err
+				}
+			}()
+			if opts.Overwrite {
+				if err := c.DeleteFile(repoName, commitID, path); err != nil {
+					return err
+				}
+			}
+			_, err = c.PutFile(repoName, commitID, path, f)
+			return err
+		})
+		return nil
+	}); err != nil {
+		eg.Wait()
+		return err
+	}
+	if err := eg.Wait(); err != nil {
+		return err
+	}
+	if opts.PreserveMetadata {
+		if opts.Overwrite {
+			if err := c.DeleteFile(repoName, commitID, filepath.ToSlash(filepath.Join(opts.Path, MetadataFile))); err != nil {
+				return err
+			}
+		}
+		return c.PutFileMetadata(repoName, commitID, filepath.ToSlash(opts.Path), metadata)
+	}
+	return nil
+}
+
+// PutFileBatch writes many files to PFS over a single stream, which is much
+// faster than calling PutFile for each file when the files are small.
+// PutFileBatch is not safe for concurrent use, it must be closed when you're
+// done with it and none of its writes are guaranteed to have happened until
+// Close returns successfully.
+type PutFileBatch struct {
+	repoName        string
+	commitID        string
+	putFileClient   pfs.API_PutFileBatchClient
+	buf             []byte
+	chunkSize       int
+	progress        ProgressFunc
+	streamSemaphore chan struct{}
+}
+
+// NewPutFileBatch creates a PutFileBatch which writes files to a commit.
+func (c APIClient) NewPutFileBatch(repoName string, commitID string) (*PutFileBatch, error) {
+	if c.streamSemaphore != nil {
+		c.streamSemaphore <- struct{}{}
+	}
+	putFileClient, err := c.PfsAPIClient.PutFileBatch(c.ctx())
+	if err != nil {
+		if c.streamSemaphore != nil {
+			<-c.streamSemaphore
+		}
+		return nil, sanitizeErr(err)
+	}
+	return &PutFileBatch{
+		repoName:        repoName,
+		commitID:        commitID,
+		putFileClient:   putFileClient,
+		chunkSize:       c.chunkSize(),
+		progress:        c.progress,
+		streamSemaphore: c.streamSemaphore,
+	}, nil
+}
+
+// PutFile adds a file to the batch, its semantics are the same as
+// APIClient.PutFile.
+func (b *PutFileBatch) PutFile(path string, reader io.Reader) (int, error) {
+	if b.buf == nil {
+		// Buffer the writes so that we don't exceed the grpc MaxMsgSize
+		b.buf = make([]byte, b.chunkSize)
+	}
+	if b.progress != nil {
+		reader = &progressReader{reader, b.progress}
+	}
+	// File is only set on the first request for each file, that's how the
+	// server knows where one file ends and the next begins.
+	request := &pfs.PutFileRequest{
+		File: NewFile(b.repoName, b.commitID, path),
+	}
+	written := 0
+	for {
+		n, err := io.ReadFull(reader, b.buf)
+		// we always send at least one request, otherwise it's impossible to
+		// create an empty file
+		if n > 0 || request.File != nil {
+			request.Value = b.buf[:n]
+			if err := b.putFileClient.Send(request); err != nil {
+				return written, sanitizeErr(err)
+			}
+			request.File = nil
+			written += n
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+	if b.progress != nil {
+		b.progress(0, 1)
+	}
+	return written, nil
+}
+
+// Close flushes the batch and waits for pachd to write all of its files.
+func (b *PutFileBatch) Close() error {
+	if b.streamSemaphore != nil {
+		defer func() { <-b.streamSemaphore }()
+	}
+	_, err := b.putFileClient.CloseAndRecv()
+	return sanitizeErr(err)
+}
+
 // PutFileURL puts a file using the content found at a URL.
 // The URL is sent to the server which performs the request.
 // recursive allow for recursive scraping of some types URLs for example on s3:// urls.
@@ -569,6 +991,42 @@ func (c APIClient) GetFile(repoName string, commitID string, path string, offset
 	if err != nil {
 		return sanitizeErr(err)
 	}
+	if c.progress != nil {
+		writer = &progressWriter{writer, c.progress}
+	}
+	if err := grpcutil.WriteFromStreamingBytesClient(apiGetFileClient, writer); err != nil {
+		return sanitizeErr(err)
+	}
+	if c.progress != nil {
+		c.progress(0, 1)
+	}
+	return nil
+}
+
+// GetParquetFile writes to writer a Parquet file that holds the top-level
+// 'columns', and the row groups whose indexes are 'rowGroups', of the
+// Parquet file at 'path' in a specific Commit. All of the file's columns or
+// row groups are written if 'columns' or 'rowGroups' is empty. pachd only
+// reads the selected column chunks, so analytical clients that only need
+// some of a wide file's columns read much less than GetFile would.
+func (c APIClient) GetParquetFile(repoName string, commitID string, path string, columns []string, rowGroups []int64, writer io.Writer) error {
+	if c.streamSemaphore != nil {
+		c.streamSemaphore <- struct{}{}
+		defer func() { <-c.streamSemaphore }()
+	}
+	apiGetFileClient, err := c.PfsAPIClient.GetFile(
+		c.ctx(),
+		&pfs.GetFileRequest{
+			File: NewFile(repoName, commitID, path),
+			Parquet: &pfs.ParquetSelection{
+				Columns:   columns,
+				RowGroups: rowGroups,
+			},
+		},
+	)
+	if err != nil {
+		return sanitizeErr(err)
+	}
 	if err := grpcutil.WriteFromStreamingBytesClient(apiGetFileClient, writer); err != nil {
 		return sanitizeErr(err)
 	}
@@ -588,6 +1046,86 @@ func (c APIClient) GetFileReader(repoName string, commitID string, path string,
 	return grpcutil.NewStreamingBytesReader(apiGetFileClient), nil
 }
 
+// GetFiles calls f with the FileInfo and the contents of each of the files at
+// 'paths' in a specific Commit, in order. The contents of small files are
+// returned along with their FileInfos, in one call for all of them, which
+// makes reading many small files much faster than calling GetFile for each.
+// The contents of directories are empty.
+func (c APIClient) GetFiles(repoName string, commitID string, paths []string, f func(fileInfo *pfs.FileInfo, r io.Reader) error) error {
+	var files []*pfs.File
+	for _, path := range paths {
+		files = append(files, NewFile(repoName, commitID, path))
+	}
+	ctx, cancel := context.WithCancel(c.ctx())
+	defer cancel()
+	stream, err := c.PfsAPIClient.GetFiles(ctx, &pfs.GetFilesRequest{Files: files})
+	if err != nil {
+		return sanitizeErr(err)
+	}
+	for {
+		fileContents, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return sanitizeErr(err)
+		}
+		fileInfo := fileContents.FileInfo
+		var r io.Reader = bytes.NewReader(fileContents.Value)
+		if fileInfo.FileType == pfs.FileType_FILE && uint64(len(fileContents.Value)) < fileInfo.SizeBytes {
+			// too big to be returned inline
+			r, err = c.GetFileReader(repoName, commitID, fileInfo.File.Path, 0, 0)
+			if err != nil {
+				return err
+			}
+		}
+		if err := f(fileInfo, r); err != nil {
+			return err
+		}
+	}
+}
+
+// NewFileRange creates a range of a file, for GetFileRanges. A size of 0
+// means the rest of the file.
+func NewFileRange(repoName string, commitID string, path string, offset int64, size int64) *pfs.GetFileRequest {
+	return &pfs.GetFileRequest{
+		File:        NewFile(repoName, commitID, path),
+		OffsetBytes: offset,
+		SizeBytes:   size,
+	}
+}
+
+// GetFileRanges writes the contents of each of 'ranges' to the writer at the
+// same index of 'writers'. The ranges, which may be of files in different
+// repos and commits, are read in one call, many of them at once, which makes
+// scanning many files (or many parts of big files) much faster than calling
+// GetFile for each. Writes to different writers are interleaved, but they're
+// never concurrent.
+func (c APIClient) GetFileRanges(ranges []*pfs.GetFileRequest, writers []io.Writer) error {
+	if len(ranges) != len(writers) {
+		return fmt.Errorf("got %d ranges but %d writers", len(ranges), len(writers))
+	}
+	ctx, cancel := context.WithCancel(c.ctx())
+	defer cancel()
+	stream, err := c.PfsAPIClient.GetFileRanges(ctx, &pfs.GetFileRangesRequest{Ranges: ranges})
+	if err != nil {
+		return sanitizeErr(err)
+	}
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return sanitizeErr(err)
+		}
+		if int(chunk.Index) >= len(writers) {
+			return fmt.Errorf("got a chunk of range %d, but there are only %d ranges", chunk.Index, len(ranges))
+		}
+		if _, err := writers[chunk.Index].Write(chunk.Value); err != nil {
+			return err
+		}
+	}
+}
+
 func (c APIClient) getFile(repoName string, commitID string, path string, offset int64,
 	size int64) (pfs.API_GetFileClient, error) {
 	return c.PfsAPIClient.GetFile(
@@ -600,6 +1138,23 @@ func (c APIClient) getFile(repoName string, commitID string, path string, offset
 	)
 }
 
+// GetFileURL returns a URL that the file at 'path' in a specific Commit can
+// be downloaded from over HTTP, by anyone who has it, for 'ttl' (or an hour,
+// if it's 0), for sharing files with systems that can't authenticate to
+// pachd. The URL names the commit by its ID, so it always returns the same
+// content, even if commitID is a branch that moves.
+func (c APIClient) GetFileURL(repoName string, commitID string, path string, ttl time.Duration) (string, error) {
+	request := &pfs.GetFileURLRequest{File: NewFile(repoName, commitID, path)}
+	if ttl != 0 {
+		request.Ttl = types.DurationProto(ttl)
+	}
+	response, err := c.PfsAPIClient.GetFileURL(c.ctx(), request)
+	if err != nil {
+		return "", sanitizeErr(err)
+	}
+	return response.Url, nil
+}
+
 // InspectFile returns info about a specific file.
 func (c APIClient) InspectFile(repoName string, commitID string, path string) (*pfs.FileInfo, error) {
 	return c.inspectFile(repoName, commitID, path)
@@ -619,17 +1174,110 @@ func (c APIClient) inspectFile(repoName string, commitID string, path string) (*
 }
 
 // ListFile returns info about all files in a Commit.
+// The FileInfos are streamed back from pachd rather than returned in a single
+// message, so listing very large directories won't exceed the grpc max
+// message size, however they're all held in memory; use ListFileIter to
+// avoid that.
 func (c APIClient) ListFile(repoName string, commitID string, path string) ([]*pfs.FileInfo, error) {
-	fileInfos, err := c.PfsAPIClient.ListFile(
-		c.ctx(),
-		&pfs.ListFileRequest{
-			File: NewFile(repoName, commitID, path),
-		},
-	)
+	iter, err := c.ListFileIter(repoName, commitID, path)
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+	var fileInfos []*pfs.FileInfo
+	for {
+		fileInfo, err := iter.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		fileInfos = append(fileInfos, fileInfo)
+	}
+	return fileInfos, nil
+}
+
+// ListFilePage returns a page of the files in a directory, so that
+// directories with millions of files can be listed incrementally. At most
+// 'number' files are returned (all of them, if 'number' is 0), starting after
+// the file at 'from', which should be the path of the last file of the
+// previous page, or "" for the first page. Fewer than 'number' files are only
+// returned for the last page.
+func (c APIClient) ListFilePage(repoName string, commitID string, path string, from string, number uint64) ([]*pfs.FileInfo, error) {
+	iter, err := c.listFileIter(&pfs.ListFileRequest{
+		File:   NewFile(repoName, commitID, path),
+		From:   from,
+		Number: number,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+	var fileInfos []*pfs.FileInfo
+	for {
+		fileInfo, err := iter.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		fileInfos = append(fileInfos, fileInfo)
+	}
+	return fileInfos, nil
+}
+
+// ListFileIter is like ListFile, but rather than returning all of the
+// FileInfos at once it streams them back from pachd one at a time. It should
+// be used when listing directories that are too large to hold in memory.
+// The returned iterator returns io.EOF once all files have been returned.
+// NOTE: ListFileIter returns a FileInfoIterator you must call Close on it
+// when you are done with it.
+func (c APIClient) ListFileIter(repoName string, commitID string, path string) (FileInfoIterator, error) {
+	return c.listFileIter(&pfs.ListFileRequest{
+		File: NewFile(repoName, commitID, path),
+	})
+}
+
+func (c APIClient) listFileIter(request *pfs.ListFileRequest) (FileInfoIterator, error) {
+	ctx, cancel := context.WithCancel(c.ctx())
+	stream, err := c.PfsAPIClient.ListFileStream(ctx, request)
 	if err != nil {
+		cancel()
 		return nil, sanitizeErr(err)
 	}
-	return fileInfos.FileInfo, nil
+	return &fileInfoIterator{stream, cancel}, nil
+}
+
+// FileInfoIterator wraps a stream of files and makes them easy to iterate.
+type FileInfoIterator interface {
+	Next() (*pfs.FileInfo, error)
+	Close()
+}
+
+type fileInfoIterator struct {
+	stream pfs.API_ListFileStreamClient
+	cancel context.CancelFunc
+}
+
+func (f *fileInfoIterator) Next() (*pfs.FileInfo, error) {
+	fileInfo, err := f.stream.Recv()
+	if err != nil && err != io.EOF {
+		return nil, sanitizeErr(err)
+	}
+	return fileInfo, err
+}
+
+func (f *fileInfoIterator) Close() {
+	f.cancel()
+	// drain the stream so that the server side is closed as well, see
+	// commitInfoIterator.Close
+	for {
+		if _, err := f.stream.Recv(); err != nil {
+			break
+		}
+	}
 }
 
 // GlobFile returns files that match a given glob pattern in a given commit.
@@ -684,12 +1332,61 @@ func (c APIClient) DeleteFile(repoName string, commitID string, path string) err
 			File: NewFile(repoName, commitID, path),
 		},
 	)
-	return err
+	return sanitizeErr(err)
+}
+
+// DeleteFiles deletes a set of files from a Commit in a single atomic
+// operation. paths are the paths of files (or directories) to delete and, if
+// pattern is not empty, all of the files matching it are deleted as well.
+// Like DeleteFile, deleting a file that doesn't exist is not an error.
+func (c APIClient) DeleteFiles(repoName string, commitID string, paths []string, pattern string) error {
+	_, err := c.PfsAPIClient.DeleteFiles(
+		c.ctx(),
+		&pfs.DeleteFilesRequest{
+			Commit:  NewCommit(repoName, commitID),
+			Paths:   paths,
+			Pattern: pattern,
+		},
+	)
+	return sanitizeErr(err)
+}
+
+// ProgressFunc is the type of the callback registered with SetProgressFunc.
+// bytes is the number of bytes transferred and files is the number of files
+// completed since the previous call. Because transfers may happen
+// concurrently, a ProgressFunc must be safe to call from multiple goroutines.
+type ProgressFunc func(bytes int64, files int64)
+
+type progressReader struct {
+	r        io.Reader
+	progress ProgressFunc
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.progress(int64(n), 0)
+	}
+	return n, err
+}
+
+type progressWriter struct {
+	w        io.Writer
+	progress ProgressFunc
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	if n > 0 {
+		w.progress(int64(n), 0)
+	}
+	return n, err
 }
 
 type putFileWriteCloser struct {
 	request       *pfs.PutFileRequest
 	putFileClient pfs.API_PutFileClient
+	chunkSize     int
 	sent          bool
 }
 
@@ -706,6 +1403,7 @@ func (c APIClient) newPutFileWriteCloser(repoName string, commitID string, path
 			TargetFileBytes:  targetFileBytes,
 		},
 		putFileClient: putFileClient,
+		chunkSize:     c.chunkSize(),
 	}, nil
 }
 
@@ -713,9 +1411,8 @@ func (w *putFileWriteCloser) Write(p []byte) (int, error) {
 	bytesWritten := 0
 	for {
 		// Buffer the write so that we don't exceed the grpc
-		// MaxMsgSize. This value includes the whole payload
-		// including headers, so we're conservative and halve it
-		ceil := bytesWritten + grpcutil.MaxMsgSize/2
+		// MaxMsgSize, see APIClient.chunkSize
+		ceil := bytesWritten + w.chunkSize
 		if ceil > len(p) {
 			ceil = len(p)
 		}
@@ -751,6 +1448,7 @@ func (w *putFileWriteCloser) Close() error {
 type putObjectWriteCloser struct {
 	request         *pfs.PutObjectRequest
 	putObjectClient pfs.ObjectAPI_PutObjectClient
+	chunkSize       int
 	object          *pfs.Object
 }
 
@@ -768,6 +1466,7 @@ func (c APIClient) newPutObjectWriteCloser(tags ...string) (*putObjectWriteClose
 			Tags: _tags,
 		},
 		putObjectClient: putObjectClient,
+		chunkSize:       c.chunkSize(),
 	}, nil
 }
 
@@ -775,9 +1474,8 @@ func (w *putObjectWriteCloser) Write(p []byte) (int, error) {
 	bytesWritten := 0
 	for {
 		// Buffer the write so that we don't exceed the grpc
-		// MaxMsgSize. This value includes the whole payload
-		// including headers, so we're conservative and halve it
-		ceil := bytesWritten + grpcutil.MaxMsgSize/2
+		// MaxMsgSize, see APIClient.chunkSize
+		ceil := bytesWritten + w.chunkSize
 		if ceil > len(p) {
 			ceil = len(p)
 		}
@@ -13,6 +13,8 @@ It has these top-level messages:
 	Commit
 	Commits
 	Branch
+	Trigger
+	RetentionPolicy
 	Branches
 	File
 	Block
@@ -21,7 +23,10 @@ It has these top-level messages:
 	RepoInfo
 	RepoInfos
 	CommitInfo
+	CommitStats
 	CommitInfos
+	Transaction
+	FileProvenance
 	FileInfo
 	FileInfos
 	ByteRange
@@ -31,26 +36,42 @@ It has these top-level messages:
 	InspectRepoRequest
 	ListRepoRequest
 	DeleteRepoRequest
+	UpdateRepoRequest
 	StartCommitRequest
 	BuildCommitRequest
 	FinishCommitRequest
+	FinishCommitsRequest
 	InspectCommitRequest
 	ListCommitRequest
 	ListBranchRequest
 	SetBranchRequest
 	DeleteBranchRequest
+	SetBranchTriggerRequest
+	SetBranchRetentionRequest
 	DeleteCommitRequest
 	FlushCommitRequest
+	FlushCommitsRequest
+	FlushCommitsResponse
 	SubscribeCommitRequest
 	GetFileRequest
+	ParquetSelection
+	GetFilesRequest
+	FileContents
+	GetFileRangesRequest
+	FileRangeChunk
+	GetFileURLRequest
+	GetFileURLResponse
 	PutFileRequest
 	InspectFileRequest
 	ListFileRequest
 	GlobFileRequest
 	DeleteFileRequest
+	DeleteFilesRequest
 	PutObjectRequest
 	GetObjectsRequest
 	TagObjectRequest
+	CheckObjectRequest
+	CheckObjectResponse
 	ObjectIndex
 */
 package pfs
@@ -61,7 +82,9 @@ import math "math"
 import google_protobuf "github.com/gogo/protobuf/types"
 import google_protobuf1 "github.com/gogo/protobuf/types"
 import google_protobuf2 "github.com/gogo/protobuf/types"
+import _ "google.golang.org/genproto/googleapis/api/annotations"
 import _ "github.com/gogo/protobuf/gogoproto"
+import google_protobuf3 "github.com/gogo/protobuf/types"
 
 import (
 	context "golang.org/x/net/context"
@@ -210,6 +233,12 @@ func (m *Commits) GetCommit() []*Commit {
 type Branch struct {
 	Name string  `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
 	Head *Commit `protobuf:"bytes,2,opt,name=head" json:"head,omitempty"`
+	// trigger, if set, moves the branch to the head of another branch when its
+	// conditions are met, see SetBranchTrigger.
+	Trigger *Trigger `protobuf:"bytes,3,opt,name=trigger" json:"trigger,omitempty"`
+	// retention, if set, prunes the branch's old commits, see
+	// SetBranchRetention.
+	Retention *RetentionPolicy `protobuf:"bytes,4,opt,name=retention" json:"retention,omitempty"`
 }
 
 func (m *Branch) Reset()                    { *m = Branch{} }
@@ -231,6 +260,111 @@ func (m *Branch) GetHead() *Commit {
 	return nil
 }
 
+func (m *Branch) GetTrigger() *Trigger {
+	if m != nil {
+		return m.Trigger
+	}
+	return nil
+}
+
+func (m *Branch) GetRetention() *RetentionPolicy {
+	if m != nil {
+		return m.Retention
+	}
+	return nil
+}
+
+// Trigger moves the branch that it's set on to the head of another branch,
+// once the commits made to that branch since meet its conditions. At least
+// one condition must be set.
+type Trigger struct {
+	// branch is the branch whose head the triggered branch is moved to.
+	Branch string `protobuf:"bytes,1,opt,name=branch,proto3" json:"branch,omitempty"`
+	// size, e.g. "1GB", is met once branch's head is at least that much bigger
+	// than the triggered branch's.
+	Size_ string `protobuf:"bytes,2,opt,name=size,proto3" json:"size,omitempty"`
+	// commits is met once branch has at least this many commits that the
+	// triggered branch doesn't.
+	Commits int64 `protobuf:"varint,3,opt,name=commits,proto3" json:"commits,omitempty"`
+	// cron_spec is met once the schedule has been due since the triggered
+	// branch's head was finished, e.g. "@hourly" moves the branch at most once
+	// an hour.
+	CronSpec string `protobuf:"bytes,4,opt,name=cron_spec,json=cronSpec,proto3" json:"cron_spec,omitempty"`
+	// all, if set, requires all of the conditions that are set to be met,
+	// rather than any of them.
+	All bool `protobuf:"varint,5,opt,name=all,proto3" json:"all,omitempty"`
+}
+
+func (m *Trigger) Reset()                    { *m = Trigger{} }
+func (m *Trigger) String() string            { return proto.CompactTextString(m) }
+func (*Trigger) ProtoMessage()               {}
+func (*Trigger) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{4} }
+
+func (m *Trigger) GetBranch() string {
+	if m != nil {
+		return m.Branch
+	}
+	return ""
+}
+
+func (m *Trigger) GetSize_() string {
+	if m != nil {
+		return m.Size_
+	}
+	return ""
+}
+
+func (m *Trigger) GetCommits() int64 {
+	if m != nil {
+		return m.Commits
+	}
+	return 0
+}
+
+func (m *Trigger) GetCronSpec() string {
+	if m != nil {
+		return m.CronSpec
+	}
+	return ""
+}
+
+func (m *Trigger) GetAll() bool {
+	if m != nil {
+		return m.All
+	}
+	return false
+}
+
+// RetentionPolicy decides which of the commits on a branch are kept when it's
+// pruned. Commits are kept if they meet any of its conditions, and at least
+// one must be set. The branch's head is always kept.
+type RetentionPolicy struct {
+	// keep_commits keeps the last keep_commits commits on the branch,
+	// including its head.
+	KeepCommits int64 `protobuf:"varint,1,opt,name=keep_commits,json=keepCommits,proto3" json:"keep_commits,omitempty"`
+	// keep_for keeps the commits that were finished less than keep_for ago.
+	KeepFor *google_protobuf3.Duration `protobuf:"bytes,2,opt,name=keep_for,json=keepFor" json:"keep_for,omitempty"`
+}
+
+func (m *RetentionPolicy) Reset()                    { *m = RetentionPolicy{} }
+func (m *RetentionPolicy) String() string            { return proto.CompactTextString(m) }
+func (*RetentionPolicy) ProtoMessage()               {}
+func (*RetentionPolicy) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{5} }
+
+func (m *RetentionPolicy) GetKeepCommits() int64 {
+	if m != nil {
+		return m.KeepCommits
+	}
+	return 0
+}
+
+func (m *RetentionPolicy) GetKeepFor() *google_protobuf3.Duration {
+	if m != nil {
+		return m.KeepFor
+	}
+	return nil
+}
+
 type Branches struct {
 	Branches []*Branch `protobuf:"bytes,1,rep,name=branches" json:"branches,omitempty"`
 }
@@ -238,7 +372,7 @@ type Branches struct {
 func (m *Branches) Reset()                    { *m = Branches{} }
 func (m *Branches) String() string            { return proto.CompactTextString(m) }
 func (*Branches) ProtoMessage()               {}
-func (*Branches) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{4} }
+func (*Branches) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{6} }
 
 func (m *Branches) GetBranches() []*Branch {
 	if m != nil {
@@ -255,7 +389,7 @@ type File struct {
 func (m *File) Reset()                    { *m = File{} }
 func (m *File) String() string            { return proto.CompactTextString(m) }
 func (*File) ProtoMessage()               {}
-func (*File) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{5} }
+func (*File) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{7} }
 
 func (m *File) GetCommit() *Commit {
 	if m != nil {
@@ -278,7 +412,7 @@ type Block struct {
 func (m *Block) Reset()                    { *m = Block{} }
 func (m *Block) String() string            { return proto.CompactTextString(m) }
 func (*Block) ProtoMessage()               {}
-func (*Block) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{6} }
+func (*Block) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{8} }
 
 func (m *Block) GetHash() string {
 	if m != nil {
@@ -294,7 +428,7 @@ type Object struct {
 func (m *Object) Reset()                    { *m = Object{} }
 func (m *Object) String() string            { return proto.CompactTextString(m) }
 func (*Object) ProtoMessage()               {}
-func (*Object) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{7} }
+func (*Object) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{9} }
 
 func (m *Object) GetHash() string {
 	if m != nil {
@@ -310,7 +444,7 @@ type Tag struct {
 func (m *Tag) Reset()                    { *m = Tag{} }
 func (m *Tag) String() string            { return proto.CompactTextString(m) }
 func (*Tag) ProtoMessage()               {}
-func (*Tag) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{8} }
+func (*Tag) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{10} }
 
 func (m *Tag) GetName() string {
 	if m != nil {
@@ -325,12 +459,15 @@ type RepoInfo struct {
 	SizeBytes   uint64                      `protobuf:"varint,3,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"`
 	Provenance  []*Repo                     `protobuf:"bytes,4,rep,name=provenance" json:"provenance,omitempty"`
 	Description string                      `protobuf:"bytes,5,opt,name=description,proto3" json:"description,omitempty"`
+	// labels are key/value pairs that describe the repo, which ListRepo can
+	// select repos by. Keys and values are validated like kubernetes labels.
+	Labels map[string]string `protobuf:"bytes,6,rep,name=labels" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
 }
 
 func (m *RepoInfo) Reset()                    { *m = RepoInfo{} }
 func (m *RepoInfo) String() string            { return proto.CompactTextString(m) }
 func (*RepoInfo) ProtoMessage()               {}
-func (*RepoInfo) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{9} }
+func (*RepoInfo) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{11} }
 
 func (m *RepoInfo) GetRepo() *Repo {
 	if m != nil {
@@ -367,6 +504,13 @@ func (m *RepoInfo) GetDescription() string {
 	return ""
 }
 
+func (m *RepoInfo) GetLabels() map[string]string {
+	if m != nil {
+		return m.Labels
+	}
+	return nil
+}
+
 type RepoInfos struct {
 	RepoInfo []*RepoInfo `protobuf:"bytes,1,rep,name=repo_info,json=repoInfo" json:"repo_info,omitempty"`
 }
@@ -374,7 +518,7 @@ type RepoInfos struct {
 func (m *RepoInfos) Reset()                    { *m = RepoInfos{} }
 func (m *RepoInfos) String() string            { return proto.CompactTextString(m) }
 func (*RepoInfos) ProtoMessage()               {}
-func (*RepoInfos) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{10} }
+func (*RepoInfos) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{12} }
 
 func (m *RepoInfos) GetRepoInfo() []*RepoInfo {
 	if m != nil {
@@ -393,12 +537,20 @@ type CommitInfo struct {
 	// this is the block that stores the serialized form of a tree that
 	// represents the entire file system hierarchy of the repo at this commit
 	Tree *Object `protobuf:"bytes,7,opt,name=tree" json:"tree,omitempty"`
+	// the user who started the commit, if auth was active. Commits that
+	// pipelines output are started by "pachd".
+	Author string `protobuf:"bytes,8,opt,name=author,proto3" json:"author,omitempty"`
+	// the transaction that finished the commit, if it was finished together
+	// with other commits by FinishCommits
+	Transaction *Transaction `protobuf:"bytes,9,opt,name=transaction" json:"transaction,omitempty"`
+	// what the commit changed in its parent, set when it's finished
+	Stats *CommitStats `protobuf:"bytes,10,opt,name=stats" json:"stats,omitempty"`
 }
 
 func (m *CommitInfo) Reset()                    { *m = CommitInfo{} }
 func (m *CommitInfo) String() string            { return proto.CompactTextString(m) }
 func (*CommitInfo) ProtoMessage()               {}
-func (*CommitInfo) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{11} }
+func (*CommitInfo) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{13} }
 
 func (m *CommitInfo) GetCommit() *Commit {
 	if m != nil {
@@ -449,6 +601,71 @@ func (m *CommitInfo) GetTree() *Object {
 	return nil
 }
 
+func (m *CommitInfo) GetAuthor() string {
+	if m != nil {
+		return m.Author
+	}
+	return ""
+}
+
+func (m *CommitInfo) GetTransaction() *Transaction {
+	if m != nil {
+		return m.Transaction
+	}
+	return nil
+}
+
+func (m *CommitInfo) GetStats() *CommitStats {
+	if m != nil {
+		return m.Stats
+	}
+	return nil
+}
+
+// CommitStats summarizes what a commit changed in its parent, or in an empty
+// repo if it has no parent.
+type CommitStats struct {
+	FilesAdded    int64 `protobuf:"varint,1,opt,name=files_added,json=filesAdded,proto3" json:"files_added,omitempty"`
+	FilesDeleted  int64 `protobuf:"varint,2,opt,name=files_deleted,json=filesDeleted,proto3" json:"files_deleted,omitempty"`
+	FilesModified int64 `protobuf:"varint,3,opt,name=files_modified,json=filesModified,proto3" json:"files_modified,omitempty"`
+	// size_delta_bytes is the commit's size minus its parent's, which is
+	// negative if the commit made the repo smaller.
+	SizeDeltaBytes int64 `protobuf:"varint,4,opt,name=size_delta_bytes,json=sizeDeltaBytes,proto3" json:"size_delta_bytes,omitempty"`
+}
+
+func (m *CommitStats) Reset()                    { *m = CommitStats{} }
+func (m *CommitStats) String() string            { return proto.CompactTextString(m) }
+func (*CommitStats) ProtoMessage()               {}
+func (*CommitStats) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{14} }
+
+func (m *CommitStats) GetFilesAdded() int64 {
+	if m != nil {
+		return m.FilesAdded
+	}
+	return 0
+}
+
+func (m *CommitStats) GetFilesDeleted() int64 {
+	if m != nil {
+		return m.FilesDeleted
+	}
+	return 0
+}
+
+func (m *CommitStats) GetFilesModified() int64 {
+	if m != nil {
+		return m.FilesModified
+	}
+	return 0
+}
+
+func (m *CommitStats) GetSizeDeltaBytes() int64 {
+	if m != nil {
+		return m.SizeDeltaBytes
+	}
+	return 0
+}
+
 type CommitInfos struct {
 	CommitInfo []*CommitInfo `protobuf:"bytes,1,rep,name=commit_info,json=commitInfo" json:"commit_info,omitempty"`
 }
@@ -456,7 +673,7 @@ type CommitInfos struct {
 func (m *CommitInfos) Reset()                    { *m = CommitInfos{} }
 func (m *CommitInfos) String() string            { return proto.CompactTextString(m) }
 func (*CommitInfos) ProtoMessage()               {}
-func (*CommitInfos) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{12} }
+func (*CommitInfos) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{15} }
 
 func (m *CommitInfos) GetCommitInfo() []*CommitInfo {
 	if m != nil {
@@ -465,6 +682,59 @@ func (m *CommitInfos) GetCommitInfo() []*CommitInfo {
 	return nil
 }
 
+// Transaction is a set of commits that were finished atomically.
+type Transaction struct {
+	ID string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// branches are the branches that the commits were the heads of when they
+	// were finished, so that pipelines subscribed to several of them can wait
+	// for all of the transaction's commits before starting a job
+	Branches []*Branch `protobuf:"bytes,2,rep,name=branches" json:"branches,omitempty"`
+}
+
+func (m *Transaction) Reset()                    { *m = Transaction{} }
+func (m *Transaction) String() string            { return proto.CompactTextString(m) }
+func (*Transaction) ProtoMessage()               {}
+func (*Transaction) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{16} }
+
+func (m *Transaction) GetID() string {
+	if m != nil {
+		return m.ID
+	}
+	return ""
+}
+
+func (m *Transaction) GetBranches() []*Branch {
+	if m != nil {
+		return m.Branches
+	}
+	return nil
+}
+
+// FileProvenance identifies a datum of a job that wrote to a file.
+type FileProvenance struct {
+	JobID   string `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	DatumID string `protobuf:"bytes,2,opt,name=datum_id,json=datumId,proto3" json:"datum_id,omitempty"`
+}
+
+func (m *FileProvenance) Reset()                    { *m = FileProvenance{} }
+func (m *FileProvenance) String() string            { return proto.CompactTextString(m) }
+func (*FileProvenance) ProtoMessage()               {}
+func (*FileProvenance) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{17} }
+
+func (m *FileProvenance) GetJobID() string {
+	if m != nil {
+		return m.JobID
+	}
+	return ""
+}
+
+func (m *FileProvenance) GetDatumID() string {
+	if m != nil {
+		return m.DatumID
+	}
+	return ""
+}
+
 type FileInfo struct {
 	File      *File    `protobuf:"bytes,1,opt,name=file" json:"file,omitempty"`
 	FileType  FileType `protobuf:"varint,2,opt,name=file_type,json=fileType,proto3,enum=pfs.FileType" json:"file_type,omitempty"`
@@ -474,12 +744,14 @@ type FileInfo struct {
 	Children []string  `protobuf:"bytes,6,rep,name=children" json:"children,omitempty"`
 	Objects  []*Object `protobuf:"bytes,8,rep,name=objects" json:"objects,omitempty"`
 	Hash     []byte    `protobuf:"bytes,7,opt,name=hash,proto3" json:"hash,omitempty"`
+	// the datums that wrote the file, if it was written by a pipeline
+	Provenance []*FileProvenance `protobuf:"bytes,9,rep,name=provenance" json:"provenance,omitempty"`
 }
 
 func (m *FileInfo) Reset()                    { *m = FileInfo{} }
 func (m *FileInfo) String() string            { return proto.CompactTextString(m) }
 func (*FileInfo) ProtoMessage()               {}
-func (*FileInfo) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{13} }
+func (*FileInfo) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{18} }
 
 func (m *FileInfo) GetFile() *File {
 	if m != nil {
@@ -523,6 +795,13 @@ func (m *FileInfo) GetHash() []byte {
 	return nil
 }
 
+func (m *FileInfo) GetProvenance() []*FileProvenance {
+	if m != nil {
+		return m.Provenance
+	}
+	return nil
+}
+
 type FileInfos struct {
 	FileInfo []*FileInfo `protobuf:"bytes,1,rep,name=file_info,json=fileInfo" json:"file_info,omitempty"`
 }
@@ -530,7 +809,7 @@ type FileInfos struct {
 func (m *FileInfos) Reset()                    { *m = FileInfos{} }
 func (m *FileInfos) String() string            { return proto.CompactTextString(m) }
 func (*FileInfos) ProtoMessage()               {}
-func (*FileInfos) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{14} }
+func (*FileInfos) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{19} }
 
 func (m *FileInfos) GetFileInfo() []*FileInfo {
 	if m != nil {
@@ -547,7 +826,7 @@ type ByteRange struct {
 func (m *ByteRange) Reset()                    { *m = ByteRange{} }
 func (m *ByteRange) String() string            { return proto.CompactTextString(m) }
 func (*ByteRange) ProtoMessage()               {}
-func (*ByteRange) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{15} }
+func (*ByteRange) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{20} }
 
 func (m *ByteRange) GetLower() uint64 {
 	if m != nil {
@@ -571,7 +850,7 @@ type BlockRef struct {
 func (m *BlockRef) Reset()                    { *m = BlockRef{} }
 func (m *BlockRef) String() string            { return proto.CompactTextString(m) }
 func (*BlockRef) ProtoMessage()               {}
-func (*BlockRef) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{16} }
+func (*BlockRef) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{21} }
 
 func (m *BlockRef) GetBlock() *Block {
 	if m != nil {
@@ -595,7 +874,7 @@ type ObjectInfo struct {
 func (m *ObjectInfo) Reset()                    { *m = ObjectInfo{} }
 func (m *ObjectInfo) String() string            { return proto.CompactTextString(m) }
 func (*ObjectInfo) ProtoMessage()               {}
-func (*ObjectInfo) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{17} }
+func (*ObjectInfo) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{22} }
 
 func (m *ObjectInfo) GetObject() *Object {
 	if m != nil {
@@ -612,15 +891,16 @@ func (m *ObjectInfo) GetBlockRef() *BlockRef {
 }
 
 type CreateRepoRequest struct {
-	Repo        *Repo   `protobuf:"bytes,1,opt,name=repo" json:"repo,omitempty"`
-	Provenance  []*Repo `protobuf:"bytes,2,rep,name=provenance" json:"provenance,omitempty"`
-	Description string  `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	Repo        *Repo             `protobuf:"bytes,1,opt,name=repo" json:"repo,omitempty"`
+	Provenance  []*Repo           `protobuf:"bytes,2,rep,name=provenance" json:"provenance,omitempty"`
+	Description string            `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	Labels      map[string]string `protobuf:"bytes,4,rep,name=labels" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
 }
 
 func (m *CreateRepoRequest) Reset()                    { *m = CreateRepoRequest{} }
 func (m *CreateRepoRequest) String() string            { return proto.CompactTextString(m) }
 func (*CreateRepoRequest) ProtoMessage()               {}
-func (*CreateRepoRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{18} }
+func (*CreateRepoRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{23} }
 
 func (m *CreateRepoRequest) GetRepo() *Repo {
 	if m != nil {
@@ -643,6 +923,13 @@ func (m *CreateRepoRequest) GetDescription() string {
 	return ""
 }
 
+func (m *CreateRepoRequest) GetLabels() map[string]string {
+	if m != nil {
+		return m.Labels
+	}
+	return nil
+}
+
 type InspectRepoRequest struct {
 	Repo *Repo `protobuf:"bytes,1,opt,name=repo" json:"repo,omitempty"`
 }
@@ -650,7 +937,7 @@ type InspectRepoRequest struct {
 func (m *InspectRepoRequest) Reset()                    { *m = InspectRepoRequest{} }
 func (m *InspectRepoRequest) String() string            { return proto.CompactTextString(m) }
 func (*InspectRepoRequest) ProtoMessage()               {}
-func (*InspectRepoRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{19} }
+func (*InspectRepoRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{24} }
 
 func (m *InspectRepoRequest) GetRepo() *Repo {
 	if m != nil {
@@ -661,12 +948,16 @@ func (m *InspectRepoRequest) GetRepo() *Repo {
 
 type ListRepoRequest struct {
 	Provenance []*Repo `protobuf:"bytes,1,rep,name=provenance" json:"provenance,omitempty"`
+	// label_selector, if set, is a kubernetes style label selector, e.g.
+	// "team=data,stage!=dev,pii", and only repos whose labels match it are
+	// returned.
+	LabelSelector string `protobuf:"bytes,2,opt,name=label_selector,json=labelSelector,proto3" json:"label_selector,omitempty"`
 }
 
 func (m *ListRepoRequest) Reset()                    { *m = ListRepoRequest{} }
 func (m *ListRepoRequest) String() string            { return proto.CompactTextString(m) }
 func (*ListRepoRequest) ProtoMessage()               {}
-func (*ListRepoRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{20} }
+func (*ListRepoRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{25} }
 
 func (m *ListRepoRequest) GetProvenance() []*Repo {
 	if m != nil {
@@ -675,6 +966,13 @@ func (m *ListRepoRequest) GetProvenance() []*Repo {
 	return nil
 }
 
+func (m *ListRepoRequest) GetLabelSelector() string {
+	if m != nil {
+		return m.LabelSelector
+	}
+	return ""
+}
+
 type DeleteRepoRequest struct {
 	Repo  *Repo `protobuf:"bytes,1,opt,name=repo" json:"repo,omitempty"`
 	Force bool  `protobuf:"varint,2,opt,name=force,proto3" json:"force,omitempty"`
@@ -683,7 +981,7 @@ type DeleteRepoRequest struct {
 func (m *DeleteRepoRequest) Reset()                    { *m = DeleteRepoRequest{} }
 func (m *DeleteRepoRequest) String() string            { return proto.CompactTextString(m) }
 func (*DeleteRepoRequest) ProtoMessage()               {}
-func (*DeleteRepoRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{21} }
+func (*DeleteRepoRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{26} }
 
 func (m *DeleteRepoRequest) GetRepo() *Repo {
 	if m != nil {
@@ -699,18 +997,65 @@ func (m *DeleteRepoRequest) GetForce() bool {
 	return false
 }
 
+type UpdateRepoRequest struct {
+	Repo *Repo `protobuf:"bytes,1,opt,name=repo" json:"repo,omitempty"`
+	// description, if set, replaces the repo's description.
+	Description string `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	// labels are set on the repo, replacing the values of any of them that it
+	// already has.
+	Labels map[string]string `protobuf:"bytes,3,rep,name=labels" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// remove_labels are the keys of labels to remove from the repo.
+	RemoveLabels []string `protobuf:"bytes,4,rep,name=remove_labels,json=removeLabels" json:"remove_labels,omitempty"`
+}
+
+func (m *UpdateRepoRequest) Reset()                    { *m = UpdateRepoRequest{} }
+func (m *UpdateRepoRequest) String() string            { return proto.CompactTextString(m) }
+func (*UpdateRepoRequest) ProtoMessage()               {}
+func (*UpdateRepoRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{27} }
+
+func (m *UpdateRepoRequest) GetRepo() *Repo {
+	if m != nil {
+		return m.Repo
+	}
+	return nil
+}
+
+func (m *UpdateRepoRequest) GetDescription() string {
+	if m != nil {
+		return m.Description
+	}
+	return ""
+}
+
+func (m *UpdateRepoRequest) GetLabels() map[string]string {
+	if m != nil {
+		return m.Labels
+	}
+	return nil
+}
+
+func (m *UpdateRepoRequest) GetRemoveLabels() []string {
+	if m != nil {
+		return m.RemoveLabels
+	}
+	return nil
+}
+
 type StartCommitRequest struct {
 	// Parent.ID may be empty in which case the commit that Branch points to will be used as the parent.
 	// If branch is empty, or if branch does not exist, the commit will have no parent.
 	Parent     *Commit   `protobuf:"bytes,1,opt,name=parent" json:"parent,omitempty"`
 	Branch     string    `protobuf:"bytes,3,opt,name=branch,proto3" json:"branch,omitempty"`
 	Provenance []*Commit `protobuf:"bytes,2,rep,name=provenance" json:"provenance,omitempty"`
+	// Force starts a commit in an output repo (one with provenance) without
+	// provenance, which needs the OWNER scope if auth is active.
+	Force bool `protobuf:"varint,4,opt,name=force,proto3" json:"force,omitempty"`
 }
 
 func (m *StartCommitRequest) Reset()                    { *m = StartCommitRequest{} }
 func (m *StartCommitRequest) String() string            { return proto.CompactTextString(m) }
 func (*StartCommitRequest) ProtoMessage()               {}
-func (*StartCommitRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{22} }
+func (*StartCommitRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{28} }
 
 func (m *StartCommitRequest) GetParent() *Commit {
 	if m != nil {
@@ -733,6 +1078,13 @@ func (m *StartCommitRequest) GetProvenance() []*Commit {
 	return nil
 }
 
+func (m *StartCommitRequest) GetForce() bool {
+	if m != nil {
+		return m.Force
+	}
+	return false
+}
+
 type BuildCommitRequest struct {
 	Parent     *Commit   `protobuf:"bytes,1,opt,name=parent" json:"parent,omitempty"`
 	Branch     string    `protobuf:"bytes,4,opt,name=branch,proto3" json:"branch,omitempty"`
@@ -743,7 +1095,7 @@ type BuildCommitRequest struct {
 func (m *BuildCommitRequest) Reset()                    { *m = BuildCommitRequest{} }
 func (m *BuildCommitRequest) String() string            { return proto.CompactTextString(m) }
 func (*BuildCommitRequest) ProtoMessage()               {}
-func (*BuildCommitRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{23} }
+func (*BuildCommitRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{29} }
 
 func (m *BuildCommitRequest) GetParent() *Commit {
 	if m != nil {
@@ -775,12 +1127,15 @@ func (m *BuildCommitRequest) GetTree() *Object {
 
 type FinishCommitRequest struct {
 	Commit *Commit `protobuf:"bytes,1,opt,name=commit" json:"commit,omitempty"`
+	// Verify re-checks the finished tree's sizes and the objects it refers
+	// to, and leaves the commit open if they're inconsistent.
+	Verify bool `protobuf:"varint,2,opt,name=verify,proto3" json:"verify,omitempty"`
 }
 
 func (m *FinishCommitRequest) Reset()                    { *m = FinishCommitRequest{} }
 func (m *FinishCommitRequest) String() string            { return proto.CompactTextString(m) }
 func (*FinishCommitRequest) ProtoMessage()               {}
-func (*FinishCommitRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{24} }
+func (*FinishCommitRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{30} }
 
 func (m *FinishCommitRequest) GetCommit() *Commit {
 	if m != nil {
@@ -789,6 +1144,40 @@ func (m *FinishCommitRequest) GetCommit() *Commit {
 	return nil
 }
 
+func (m *FinishCommitRequest) GetVerify() bool {
+	if m != nil {
+		return m.Verify
+	}
+	return false
+}
+
+type FinishCommitsRequest struct {
+	Commits []*Commit `protobuf:"bytes,1,rep,name=commits" json:"commits,omitempty"`
+	// delete_files are deleted from the commits, which they must be in,
+	// before they're finished. Unlike with DeleteFile, they're only deleted
+	// if all of the commits are finished.
+	DeleteFiles []*File `protobuf:"bytes,2,rep,name=delete_files,json=deleteFiles" json:"delete_files,omitempty"`
+}
+
+func (m *FinishCommitsRequest) Reset()                    { *m = FinishCommitsRequest{} }
+func (m *FinishCommitsRequest) String() string            { return proto.CompactTextString(m) }
+func (*FinishCommitsRequest) ProtoMessage()               {}
+func (*FinishCommitsRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{31} }
+
+func (m *FinishCommitsRequest) GetCommits() []*Commit {
+	if m != nil {
+		return m.Commits
+	}
+	return nil
+}
+
+func (m *FinishCommitsRequest) GetDeleteFiles() []*File {
+	if m != nil {
+		return m.DeleteFiles
+	}
+	return nil
+}
+
 type InspectCommitRequest struct {
 	Commit *Commit `protobuf:"bytes,1,opt,name=commit" json:"commit,omitempty"`
 }
@@ -796,7 +1185,7 @@ type InspectCommitRequest struct {
 func (m *InspectCommitRequest) Reset()                    { *m = InspectCommitRequest{} }
 func (m *InspectCommitRequest) String() string            { return proto.CompactTextString(m) }
 func (*InspectCommitRequest) ProtoMessage()               {}
-func (*InspectCommitRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{25} }
+func (*InspectCommitRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{32} }
 
 func (m *InspectCommitRequest) GetCommit() *Commit {
 	if m != nil {
@@ -815,7 +1204,7 @@ type ListCommitRequest struct {
 func (m *ListCommitRequest) Reset()                    { *m = ListCommitRequest{} }
 func (m *ListCommitRequest) String() string            { return proto.CompactTextString(m) }
 func (*ListCommitRequest) ProtoMessage()               {}
-func (*ListCommitRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{26} }
+func (*ListCommitRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{33} }
 
 func (m *ListCommitRequest) GetRepo() *Repo {
 	if m != nil {
@@ -852,7 +1241,7 @@ type ListBranchRequest struct {
 func (m *ListBranchRequest) Reset()                    { *m = ListBranchRequest{} }
 func (m *ListBranchRequest) String() string            { return proto.CompactTextString(m) }
 func (*ListBranchRequest) ProtoMessage()               {}
-func (*ListBranchRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{27} }
+func (*ListBranchRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{34} }
 
 func (m *ListBranchRequest) GetRepo() *Repo {
 	if m != nil {
@@ -869,7 +1258,7 @@ type SetBranchRequest struct {
 func (m *SetBranchRequest) Reset()                    { *m = SetBranchRequest{} }
 func (m *SetBranchRequest) String() string            { return proto.CompactTextString(m) }
 func (*SetBranchRequest) ProtoMessage()               {}
-func (*SetBranchRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{28} }
+func (*SetBranchRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{35} }
 
 func (m *SetBranchRequest) GetCommit() *Commit {
 	if m != nil {
@@ -893,7 +1282,7 @@ type DeleteBranchRequest struct {
 func (m *DeleteBranchRequest) Reset()                    { *m = DeleteBranchRequest{} }
 func (m *DeleteBranchRequest) String() string            { return proto.CompactTextString(m) }
 func (*DeleteBranchRequest) ProtoMessage()               {}
-func (*DeleteBranchRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{29} }
+func (*DeleteBranchRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{36} }
 
 func (m *DeleteBranchRequest) GetRepo() *Repo {
 	if m != nil {
@@ -909,6 +1298,74 @@ func (m *DeleteBranchRequest) GetBranch() string {
 	return ""
 }
 
+type SetBranchTriggerRequest struct {
+	Repo   *Repo  `protobuf:"bytes,1,opt,name=repo" json:"repo,omitempty"`
+	Branch string `protobuf:"bytes,2,opt,name=branch,proto3" json:"branch,omitempty"`
+	// trigger replaces the branch's trigger; if it's unset, the branch's
+	// trigger is removed.
+	Trigger *Trigger `protobuf:"bytes,3,opt,name=trigger" json:"trigger,omitempty"`
+}
+
+func (m *SetBranchTriggerRequest) Reset()                    { *m = SetBranchTriggerRequest{} }
+func (m *SetBranchTriggerRequest) String() string            { return proto.CompactTextString(m) }
+func (*SetBranchTriggerRequest) ProtoMessage()               {}
+func (*SetBranchTriggerRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{37} }
+
+func (m *SetBranchTriggerRequest) GetRepo() *Repo {
+	if m != nil {
+		return m.Repo
+	}
+	return nil
+}
+
+func (m *SetBranchTriggerRequest) GetBranch() string {
+	if m != nil {
+		return m.Branch
+	}
+	return ""
+}
+
+func (m *SetBranchTriggerRequest) GetTrigger() *Trigger {
+	if m != nil {
+		return m.Trigger
+	}
+	return nil
+}
+
+type SetBranchRetentionRequest struct {
+	Repo   *Repo  `protobuf:"bytes,1,opt,name=repo" json:"repo,omitempty"`
+	Branch string `protobuf:"bytes,2,opt,name=branch,proto3" json:"branch,omitempty"`
+	// retention replaces the branch's retention policy; if it's unset, the
+	// branch's policy is removed, and its commits are kept.
+	Retention *RetentionPolicy `protobuf:"bytes,3,opt,name=retention" json:"retention,omitempty"`
+}
+
+func (m *SetBranchRetentionRequest) Reset()                    { *m = SetBranchRetentionRequest{} }
+func (m *SetBranchRetentionRequest) String() string            { return proto.CompactTextString(m) }
+func (*SetBranchRetentionRequest) ProtoMessage()               {}
+func (*SetBranchRetentionRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{38} }
+
+func (m *SetBranchRetentionRequest) GetRepo() *Repo {
+	if m != nil {
+		return m.Repo
+	}
+	return nil
+}
+
+func (m *SetBranchRetentionRequest) GetBranch() string {
+	if m != nil {
+		return m.Branch
+	}
+	return ""
+}
+
+func (m *SetBranchRetentionRequest) GetRetention() *RetentionPolicy {
+	if m != nil {
+		return m.Retention
+	}
+	return nil
+}
+
 type DeleteCommitRequest struct {
 	Commit *Commit `protobuf:"bytes,1,opt,name=commit" json:"commit,omitempty"`
 }
@@ -916,7 +1373,7 @@ type DeleteCommitRequest struct {
 func (m *DeleteCommitRequest) Reset()                    { *m = DeleteCommitRequest{} }
 func (m *DeleteCommitRequest) String() string            { return proto.CompactTextString(m) }
 func (*DeleteCommitRequest) ProtoMessage()               {}
-func (*DeleteCommitRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{30} }
+func (*DeleteCommitRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{39} }
 
 func (m *DeleteCommitRequest) GetCommit() *Commit {
 	if m != nil {
@@ -933,7 +1390,7 @@ type FlushCommitRequest struct {
 func (m *FlushCommitRequest) Reset()                    { *m = FlushCommitRequest{} }
 func (m *FlushCommitRequest) String() string            { return proto.CompactTextString(m) }
 func (*FlushCommitRequest) ProtoMessage()               {}
-func (*FlushCommitRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{31} }
+func (*FlushCommitRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{40} }
 
 func (m *FlushCommitRequest) GetCommits() []*Commit {
 	if m != nil {
@@ -949,6 +1406,49 @@ func (m *FlushCommitRequest) GetToRepos() []*Repo {
 	return nil
 }
 
+type FlushCommitsRequest struct {
+	// each of flushes is flushed on its own, as if by FlushCommit
+	Flushes []*FlushCommitRequest `protobuf:"bytes,1,rep,name=flushes" json:"flushes,omitempty"`
+}
+
+func (m *FlushCommitsRequest) Reset()                    { *m = FlushCommitsRequest{} }
+func (m *FlushCommitsRequest) String() string            { return proto.CompactTextString(m) }
+func (*FlushCommitsRequest) ProtoMessage()               {}
+func (*FlushCommitsRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{41} }
+
+func (m *FlushCommitsRequest) GetFlushes() []*FlushCommitRequest {
+	if m != nil {
+		return m.Flushes
+	}
+	return nil
+}
+
+type FlushCommitsResponse struct {
+	// index is the index in FlushCommitsRequest.flushes of the flush that
+	// commit_info is downstream of
+	Index      int64       `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	CommitInfo *CommitInfo `protobuf:"bytes,2,opt,name=commit_info,json=commitInfo" json:"commit_info,omitempty"`
+}
+
+func (m *FlushCommitsResponse) Reset()                    { *m = FlushCommitsResponse{} }
+func (m *FlushCommitsResponse) String() string            { return proto.CompactTextString(m) }
+func (*FlushCommitsResponse) ProtoMessage()               {}
+func (*FlushCommitsResponse) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{42} }
+
+func (m *FlushCommitsResponse) GetIndex() int64 {
+	if m != nil {
+		return m.Index
+	}
+	return 0
+}
+
+func (m *FlushCommitsResponse) GetCommitInfo() *CommitInfo {
+	if m != nil {
+		return m.CommitInfo
+	}
+	return nil
+}
+
 type SubscribeCommitRequest struct {
 	Repo   *Repo  `protobuf:"bytes,1,opt,name=repo" json:"repo,omitempty"`
 	Branch string `protobuf:"bytes,2,opt,name=branch,proto3" json:"branch,omitempty"`
@@ -959,7 +1459,7 @@ type SubscribeCommitRequest struct {
 func (m *SubscribeCommitRequest) Reset()                    { *m = SubscribeCommitRequest{} }
 func (m *SubscribeCommitRequest) String() string            { return proto.CompactTextString(m) }
 func (*SubscribeCommitRequest) ProtoMessage()               {}
-func (*SubscribeCommitRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{32} }
+func (*SubscribeCommitRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{43} }
 
 func (m *SubscribeCommitRequest) GetRepo() *Repo {
 	if m != nil {
@@ -986,12 +1486,16 @@ type GetFileRequest struct {
 	File        *File `protobuf:"bytes,1,opt,name=file" json:"file,omitempty"`
 	OffsetBytes int64 `protobuf:"varint,2,opt,name=offset_bytes,json=offsetBytes,proto3" json:"offset_bytes,omitempty"`
 	SizeBytes   int64 `protobuf:"varint,3,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"`
+	// parquet, if it's set, returns a Parquet file that holds some of the
+	// columns and row groups of the file, which must be a Parquet file,
+	// rather than all of it. It can't be set with offset_bytes or size_bytes.
+	Parquet *ParquetSelection `protobuf:"bytes,4,opt,name=parquet" json:"parquet,omitempty"`
 }
 
 func (m *GetFileRequest) Reset()                    { *m = GetFileRequest{} }
 func (m *GetFileRequest) String() string            { return proto.CompactTextString(m) }
 func (*GetFileRequest) ProtoMessage()               {}
-func (*GetFileRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{33} }
+func (*GetFileRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{44} }
 
 func (m *GetFileRequest) GetFile() *File {
 	if m != nil {
@@ -1014,7 +1518,219 @@ func (m *GetFileRequest) GetSizeBytes() int64 {
 	return 0
 }
 
-type PutFileRequest struct {
+func (m *GetFileRequest) GetParquet() *ParquetSelection {
+	if m != nil {
+		return m.Parquet
+	}
+	return nil
+}
+
+// ParquetSelection selects columns and row groups of a Parquet file. Their
+// column chunks are returned as they're stored, so only the bytes of the
+// selected ones are read.
+type ParquetSelection struct {
+	// columns are the names of the top-level columns to return. A nested
+	// column's leaves are returned together. All of them are returned if it's
+	// empty.
+	Columns []string `protobuf:"bytes,1,rep,name=columns" json:"columns,omitempty"`
+	// row_groups are the indexes of the row groups to return. All of them are
+	// returned if it's empty.
+	RowGroups []int64 `protobuf:"varint,2,rep,packed,name=row_groups,json=rowGroups" json:"row_groups,omitempty"`
+}
+
+func (m *ParquetSelection) Reset()                    { *m = ParquetSelection{} }
+func (m *ParquetSelection) String() string            { return proto.CompactTextString(m) }
+func (*ParquetSelection) ProtoMessage()               {}
+func (*ParquetSelection) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{45} }
+
+func (m *ParquetSelection) GetColumns() []string {
+	if m != nil {
+		return m.Columns
+	}
+	return nil
+}
+
+func (m *ParquetSelection) GetRowGroups() []int64 {
+	if m != nil {
+		return m.RowGroups
+	}
+	return nil
+}
+
+type GetFilesRequest struct {
+	// files may be in different repos and commits
+	Files []*File `protobuf:"bytes,1,rep,name=files" json:"files,omitempty"`
+	// size_limit_bytes is the size of the biggest file whose content is
+	// returned. Bigger files are returned without their content, to be read
+	// with GetFile. If it's 0, the limit is 1MB.
+	SizeLimitBytes uint64 `protobuf:"varint,2,opt,name=size_limit_bytes,json=sizeLimitBytes,proto3" json:"size_limit_bytes,omitempty"`
+}
+
+func (m *GetFilesRequest) Reset()                    { *m = GetFilesRequest{} }
+func (m *GetFilesRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetFilesRequest) ProtoMessage()               {}
+func (*GetFilesRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{46} }
+
+func (m *GetFilesRequest) GetFiles() []*File {
+	if m != nil {
+		return m.Files
+	}
+	return nil
+}
+
+func (m *GetFilesRequest) GetSizeLimitBytes() uint64 {
+	if m != nil {
+		return m.SizeLimitBytes
+	}
+	return 0
+}
+
+// FileContents is a file's FileInfo and, if it's small enough, its content.
+type FileContents struct {
+	FileInfo *FileInfo `protobuf:"bytes,1,opt,name=file_info,json=fileInfo" json:"file_info,omitempty"`
+	// value is the file's content, unless it's bigger than
+	// GetFilesRequest.size_limit_bytes (or it's a directory)
+	Value []byte `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (m *FileContents) Reset()                    { *m = FileContents{} }
+func (m *FileContents) String() string            { return proto.CompactTextString(m) }
+func (*FileContents) ProtoMessage()               {}
+func (*FileContents) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{47} }
+
+func (m *FileContents) GetFileInfo() *FileInfo {
+	if m != nil {
+		return m.FileInfo
+	}
+	return nil
+}
+
+func (m *FileContents) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+type GetFileRangesRequest struct {
+	// ranges may be of files in different repos and commits, and of the same
+	// file. A range whose size_bytes is 0 extends to the end of its file.
+	Ranges []*GetFileRequest `protobuf:"bytes,1,rep,name=ranges" json:"ranges,omitempty"`
+	// parallelism is how many ranges are read from object storage at once.
+	// If it's 0, it's 16.
+	Parallelism uint32 `protobuf:"varint,2,opt,name=parallelism,proto3" json:"parallelism,omitempty"`
+}
+
+func (m *GetFileRangesRequest) Reset()                    { *m = GetFileRangesRequest{} }
+func (m *GetFileRangesRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetFileRangesRequest) ProtoMessage()               {}
+func (*GetFileRangesRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{48} }
+
+func (m *GetFileRangesRequest) GetRanges() []*GetFileRequest {
+	if m != nil {
+		return m.Ranges
+	}
+	return nil
+}
+
+func (m *GetFileRangesRequest) GetParallelism() uint32 {
+	if m != nil {
+		return m.Parallelism
+	}
+	return 0
+}
+
+// FileRangeChunk is a piece of the content of one of
+// GetFileRangesRequest.ranges. The chunks of a range are returned in order,
+// but they're interleaved with the chunks of the other ranges.
+type FileRangeChunk struct {
+	// index is the index of the range in GetFileRangesRequest.ranges
+	Index uint32 `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Value []byte `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	// done is set on the last chunk of a range, which may be empty
+	Done bool `protobuf:"varint,3,opt,name=done,proto3" json:"done,omitempty"`
+}
+
+func (m *FileRangeChunk) Reset()                    { *m = FileRangeChunk{} }
+func (m *FileRangeChunk) String() string            { return proto.CompactTextString(m) }
+func (*FileRangeChunk) ProtoMessage()               {}
+func (*FileRangeChunk) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{49} }
+
+func (m *FileRangeChunk) GetIndex() uint32 {
+	if m != nil {
+		return m.Index
+	}
+	return 0
+}
+
+func (m *FileRangeChunk) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (m *FileRangeChunk) GetDone() bool {
+	if m != nil {
+		return m.Done
+	}
+	return false
+}
+
+type GetFileURLRequest struct {
+	File *File `protobuf:"bytes,1,opt,name=file" json:"file,omitempty"`
+	// ttl is how long the URL can be used for. If it's unset, it's an hour, and
+	// it can be at most 7 days.
+	Ttl *google_protobuf3.Duration `protobuf:"bytes,2,opt,name=ttl" json:"ttl,omitempty"`
+}
+
+func (m *GetFileURLRequest) Reset()                    { *m = GetFileURLRequest{} }
+func (m *GetFileURLRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetFileURLRequest) ProtoMessage()               {}
+func (*GetFileURLRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{50} }
+
+func (m *GetFileURLRequest) GetFile() *File {
+	if m != nil {
+		return m.File
+	}
+	return nil
+}
+
+func (m *GetFileURLRequest) GetTtl() *google_protobuf3.Duration {
+	if m != nil {
+		return m.Ttl
+	}
+	return nil
+}
+
+type GetFileURLResponse struct {
+	// url is an HTTP URL of pachd's that the file can be downloaded from by
+	// anyone who has it, without authenticating, until expires. It names the
+	// commit by its ID, so it's always the same content.
+	Url     string                      `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	Expires *google_protobuf1.Timestamp `protobuf:"bytes,2,opt,name=expires" json:"expires,omitempty"`
+}
+
+func (m *GetFileURLResponse) Reset()                    { *m = GetFileURLResponse{} }
+func (m *GetFileURLResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetFileURLResponse) ProtoMessage()               {}
+func (*GetFileURLResponse) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{51} }
+
+func (m *GetFileURLResponse) GetUrl() string {
+	if m != nil {
+		return m.Url
+	}
+	return ""
+}
+
+func (m *GetFileURLResponse) GetExpires() *google_protobuf1.Timestamp {
+	if m != nil {
+		return m.Expires
+	}
+	return nil
+}
+
+type PutFileRequest struct {
 	File  *File  `protobuf:"bytes,1,opt,name=file" json:"file,omitempty"`
 	Value []byte `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
 	Url   string `protobuf:"bytes,5,opt,name=url,proto3" json:"url,omitempty"`
@@ -1035,7 +1751,7 @@ type PutFileRequest struct {
 func (m *PutFileRequest) Reset()                    { *m = PutFileRequest{} }
 func (m *PutFileRequest) String() string            { return proto.CompactTextString(m) }
 func (*PutFileRequest) ProtoMessage()               {}
-func (*PutFileRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{34} }
+func (*PutFileRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{52} }
 
 func (m *PutFileRequest) GetFile() *File {
 	if m != nil {
@@ -1093,7 +1809,7 @@ type InspectFileRequest struct {
 func (m *InspectFileRequest) Reset()                    { *m = InspectFileRequest{} }
 func (m *InspectFileRequest) String() string            { return proto.CompactTextString(m) }
 func (*InspectFileRequest) ProtoMessage()               {}
-func (*InspectFileRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{35} }
+func (*InspectFileRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{53} }
 
 func (m *InspectFileRequest) GetFile() *File {
 	if m != nil {
@@ -1104,12 +1820,17 @@ func (m *InspectFileRequest) GetFile() *File {
 
 type ListFileRequest struct {
 	File *File `protobuf:"bytes,1,opt,name=file" json:"file,omitempty"`
+	// from, if set, is the path of the last file of the previous page of a
+	// listing: only the files after it (in lexicographic order) are listed.
+	From string `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	// number, if nonzero, is the most files that are listed.
+	Number uint64 `protobuf:"varint,3,opt,name=number,proto3" json:"number,omitempty"`
 }
 
 func (m *ListFileRequest) Reset()                    { *m = ListFileRequest{} }
 func (m *ListFileRequest) String() string            { return proto.CompactTextString(m) }
 func (*ListFileRequest) ProtoMessage()               {}
-func (*ListFileRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{36} }
+func (*ListFileRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{54} }
 
 func (m *ListFileRequest) GetFile() *File {
 	if m != nil {
@@ -1118,6 +1839,20 @@ func (m *ListFileRequest) GetFile() *File {
 	return nil
 }
 
+func (m *ListFileRequest) GetFrom() string {
+	if m != nil {
+		return m.From
+	}
+	return ""
+}
+
+func (m *ListFileRequest) GetNumber() uint64 {
+	if m != nil {
+		return m.Number
+	}
+	return 0
+}
+
 type GlobFileRequest struct {
 	Commit  *Commit `protobuf:"bytes,1,opt,name=commit" json:"commit,omitempty"`
 	Pattern string  `protobuf:"bytes,2,opt,name=pattern,proto3" json:"pattern,omitempty"`
@@ -1126,7 +1861,7 @@ type GlobFileRequest struct {
 func (m *GlobFileRequest) Reset()                    { *m = GlobFileRequest{} }
 func (m *GlobFileRequest) String() string            { return proto.CompactTextString(m) }
 func (*GlobFileRequest) ProtoMessage()               {}
-func (*GlobFileRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{37} }
+func (*GlobFileRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{55} }
 
 func (m *GlobFileRequest) GetCommit() *Commit {
 	if m != nil {
@@ -1149,7 +1884,7 @@ type DeleteFileRequest struct {
 func (m *DeleteFileRequest) Reset()                    { *m = DeleteFileRequest{} }
 func (m *DeleteFileRequest) String() string            { return proto.CompactTextString(m) }
 func (*DeleteFileRequest) ProtoMessage()               {}
-func (*DeleteFileRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{38} }
+func (*DeleteFileRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{56} }
 
 func (m *DeleteFileRequest) GetFile() *File {
 	if m != nil {
@@ -1158,6 +1893,40 @@ func (m *DeleteFileRequest) GetFile() *File {
 	return nil
 }
 
+type DeleteFilesRequest struct {
+	Commit *Commit `protobuf:"bytes,1,opt,name=commit" json:"commit,omitempty"`
+	// paths are the paths of files (or directories) to delete.
+	Paths []string `protobuf:"bytes,2,rep,name=paths" json:"paths,omitempty"`
+	// pattern, if set, is a glob pattern; all files matching it are deleted.
+	Pattern string `protobuf:"bytes,3,opt,name=pattern,proto3" json:"pattern,omitempty"`
+}
+
+func (m *DeleteFilesRequest) Reset()                    { *m = DeleteFilesRequest{} }
+func (m *DeleteFilesRequest) String() string            { return proto.CompactTextString(m) }
+func (*DeleteFilesRequest) ProtoMessage()               {}
+func (*DeleteFilesRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{57} }
+
+func (m *DeleteFilesRequest) GetCommit() *Commit {
+	if m != nil {
+		return m.Commit
+	}
+	return nil
+}
+
+func (m *DeleteFilesRequest) GetPaths() []string {
+	if m != nil {
+		return m.Paths
+	}
+	return nil
+}
+
+func (m *DeleteFilesRequest) GetPattern() string {
+	if m != nil {
+		return m.Pattern
+	}
+	return ""
+}
+
 type PutObjectRequest struct {
 	Value []byte `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
 	Tags  []*Tag `protobuf:"bytes,2,rep,name=tags" json:"tags,omitempty"`
@@ -1166,7 +1935,7 @@ type PutObjectRequest struct {
 func (m *PutObjectRequest) Reset()                    { *m = PutObjectRequest{} }
 func (m *PutObjectRequest) String() string            { return proto.CompactTextString(m) }
 func (*PutObjectRequest) ProtoMessage()               {}
-func (*PutObjectRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{39} }
+func (*PutObjectRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{58} }
 
 func (m *PutObjectRequest) GetValue() []byte {
 	if m != nil {
@@ -1191,7 +1960,7 @@ type GetObjectsRequest struct {
 func (m *GetObjectsRequest) Reset()                    { *m = GetObjectsRequest{} }
 func (m *GetObjectsRequest) String() string            { return proto.CompactTextString(m) }
 func (*GetObjectsRequest) ProtoMessage()               {}
-func (*GetObjectsRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{40} }
+func (*GetObjectsRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{59} }
 
 func (m *GetObjectsRequest) GetObjects() []*Object {
 	if m != nil {
@@ -1222,7 +1991,7 @@ type TagObjectRequest struct {
 func (m *TagObjectRequest) Reset()                    { *m = TagObjectRequest{} }
 func (m *TagObjectRequest) String() string            { return proto.CompactTextString(m) }
 func (*TagObjectRequest) ProtoMessage()               {}
-func (*TagObjectRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{41} }
+func (*TagObjectRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{60} }
 
 func (m *TagObjectRequest) GetObject() *Object {
 	if m != nil {
@@ -1238,6 +2007,40 @@ func (m *TagObjectRequest) GetTags() []*Tag {
 	return nil
 }
 
+type CheckObjectRequest struct {
+	Object *Object `protobuf:"bytes,1,opt,name=object" json:"object,omitempty"`
+}
+
+func (m *CheckObjectRequest) Reset()                    { *m = CheckObjectRequest{} }
+func (m *CheckObjectRequest) String() string            { return proto.CompactTextString(m) }
+func (*CheckObjectRequest) ProtoMessage()               {}
+func (*CheckObjectRequest) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{61} }
+
+func (m *CheckObjectRequest) GetObject() *Object {
+	if m != nil {
+		return m.Object
+	}
+	return nil
+}
+
+type CheckObjectResponse struct {
+	// exists is true if the object is indexed and the block containing it is
+	// present in object storage.
+	Exists bool `protobuf:"varint,1,opt,name=exists,proto3" json:"exists,omitempty"`
+}
+
+func (m *CheckObjectResponse) Reset()                    { *m = CheckObjectResponse{} }
+func (m *CheckObjectResponse) String() string            { return proto.CompactTextString(m) }
+func (*CheckObjectResponse) ProtoMessage()               {}
+func (*CheckObjectResponse) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{62} }
+
+func (m *CheckObjectResponse) GetExists() bool {
+	if m != nil {
+		return m.Exists
+	}
+	return false
+}
+
 type ObjectIndex struct {
 	Objects map[string]*BlockRef `protobuf:"bytes,1,rep,name=objects" json:"objects,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value"`
 	Tags    map[string]*Object   `protobuf:"bytes,2,rep,name=tags" json:"tags,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value"`
@@ -1246,7 +2049,7 @@ type ObjectIndex struct {
 func (m *ObjectIndex) Reset()                    { *m = ObjectIndex{} }
 func (m *ObjectIndex) String() string            { return proto.CompactTextString(m) }
 func (*ObjectIndex) ProtoMessage()               {}
-func (*ObjectIndex) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{42} }
+func (*ObjectIndex) Descriptor() ([]byte, []int) { return fileDescriptorPfs, []int{63} }
 
 func (m *ObjectIndex) GetObjects() map[string]*BlockRef {
 	if m != nil {
@@ -1267,6 +2070,8 @@ func init() {
 	proto.RegisterType((*Commit)(nil), "pfs.Commit")
 	proto.RegisterType((*Commits)(nil), "pfs.Commits")
 	proto.RegisterType((*Branch)(nil), "pfs.Branch")
+	proto.RegisterType((*Trigger)(nil), "pfs.Trigger")
+	proto.RegisterType((*RetentionPolicy)(nil), "pfs.RetentionPolicy")
 	proto.RegisterType((*Branches)(nil), "pfs.Branches")
 	proto.RegisterType((*File)(nil), "pfs.File")
 	proto.RegisterType((*Block)(nil), "pfs.Block")
@@ -1275,7 +2080,10 @@ func init() {
 	proto.RegisterType((*RepoInfo)(nil), "pfs.RepoInfo")
 	proto.RegisterType((*RepoInfos)(nil), "pfs.RepoInfos")
 	proto.RegisterType((*CommitInfo)(nil), "pfs.CommitInfo")
+	proto.RegisterType((*CommitStats)(nil), "pfs.CommitStats")
 	proto.RegisterType((*CommitInfos)(nil), "pfs.CommitInfos")
+	proto.RegisterType((*Transaction)(nil), "pfs.Transaction")
+	proto.RegisterType((*FileProvenance)(nil), "pfs.FileProvenance")
 	proto.RegisterType((*FileInfo)(nil), "pfs.FileInfo")
 	proto.RegisterType((*FileInfos)(nil), "pfs.FileInfos")
 	proto.RegisterType((*ByteRange)(nil), "pfs.ByteRange")
@@ -1285,26 +2093,42 @@ func init() {
 	proto.RegisterType((*InspectRepoRequest)(nil), "pfs.InspectRepoRequest")
 	proto.RegisterType((*ListRepoRequest)(nil), "pfs.ListRepoRequest")
 	proto.RegisterType((*DeleteRepoRequest)(nil), "pfs.DeleteRepoRequest")
+	proto.RegisterType((*UpdateRepoRequest)(nil), "pfs.UpdateRepoRequest")
 	proto.RegisterType((*StartCommitRequest)(nil), "pfs.StartCommitRequest")
 	proto.RegisterType((*BuildCommitRequest)(nil), "pfs.BuildCommitRequest")
 	proto.RegisterType((*FinishCommitRequest)(nil), "pfs.FinishCommitRequest")
+	proto.RegisterType((*FinishCommitsRequest)(nil), "pfs.FinishCommitsRequest")
 	proto.RegisterType((*InspectCommitRequest)(nil), "pfs.InspectCommitRequest")
 	proto.RegisterType((*ListCommitRequest)(nil), "pfs.ListCommitRequest")
 	proto.RegisterType((*ListBranchRequest)(nil), "pfs.ListBranchRequest")
 	proto.RegisterType((*SetBranchRequest)(nil), "pfs.SetBranchRequest")
 	proto.RegisterType((*DeleteBranchRequest)(nil), "pfs.DeleteBranchRequest")
+	proto.RegisterType((*SetBranchTriggerRequest)(nil), "pfs.SetBranchTriggerRequest")
+	proto.RegisterType((*SetBranchRetentionRequest)(nil), "pfs.SetBranchRetentionRequest")
 	proto.RegisterType((*DeleteCommitRequest)(nil), "pfs.DeleteCommitRequest")
 	proto.RegisterType((*FlushCommitRequest)(nil), "pfs.FlushCommitRequest")
+	proto.RegisterType((*FlushCommitsRequest)(nil), "pfs.FlushCommitsRequest")
+	proto.RegisterType((*FlushCommitsResponse)(nil), "pfs.FlushCommitsResponse")
 	proto.RegisterType((*SubscribeCommitRequest)(nil), "pfs.SubscribeCommitRequest")
 	proto.RegisterType((*GetFileRequest)(nil), "pfs.GetFileRequest")
+	proto.RegisterType((*ParquetSelection)(nil), "pfs.ParquetSelection")
+	proto.RegisterType((*GetFilesRequest)(nil), "pfs.GetFilesRequest")
+	proto.RegisterType((*FileContents)(nil), "pfs.FileContents")
+	proto.RegisterType((*GetFileRangesRequest)(nil), "pfs.GetFileRangesRequest")
+	proto.RegisterType((*FileRangeChunk)(nil), "pfs.FileRangeChunk")
+	proto.RegisterType((*GetFileURLRequest)(nil), "pfs.GetFileURLRequest")
+	proto.RegisterType((*GetFileURLResponse)(nil), "pfs.GetFileURLResponse")
 	proto.RegisterType((*PutFileRequest)(nil), "pfs.PutFileRequest")
 	proto.RegisterType((*InspectFileRequest)(nil), "pfs.InspectFileRequest")
 	proto.RegisterType((*ListFileRequest)(nil), "pfs.ListFileRequest")
 	proto.RegisterType((*GlobFileRequest)(nil), "pfs.GlobFileRequest")
 	proto.RegisterType((*DeleteFileRequest)(nil), "pfs.DeleteFileRequest")
+	proto.RegisterType((*DeleteFilesRequest)(nil), "pfs.DeleteFilesRequest")
 	proto.RegisterType((*PutObjectRequest)(nil), "pfs.PutObjectRequest")
 	proto.RegisterType((*GetObjectsRequest)(nil), "pfs.GetObjectsRequest")
 	proto.RegisterType((*TagObjectRequest)(nil), "pfs.TagObjectRequest")
+	proto.RegisterType((*CheckObjectRequest)(nil), "pfs.CheckObjectRequest")
+	proto.RegisterType((*CheckObjectResponse)(nil), "pfs.CheckObjectResponse")
 	proto.RegisterType((*ObjectIndex)(nil), "pfs.ObjectIndex")
 	proto.RegisterEnum("pfs.FileType", FileType_name, FileType_value)
 	proto.RegisterEnum("pfs.Delimiter", Delimiter_name, Delimiter_value)
@@ -1332,19 +2156,31 @@ type APIClient interface {
 	ListRepo(ctx context.Context, in *ListRepoRequest, opts ...grpc.CallOption) (*RepoInfos, error)
 	// DeleteRepo deletes a repo.
 	DeleteRepo(ctx context.Context, in *DeleteRepoRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+	// UpdateRepo updates a repo's description and labels.
+	UpdateRepo(ctx context.Context, in *UpdateRepoRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
 	// Commit rpcs
 	// StartCommit creates a new write commit from a parent commit.
 	StartCommit(ctx context.Context, in *StartCommitRequest, opts ...grpc.CallOption) (*Commit, error)
 	// FinishCommit turns a write commit into a read commit.
 	FinishCommit(ctx context.Context, in *FinishCommitRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+	// FinishCommits finishes several commits atomically: either all of them
+	// are finished, at the same time, or none are.
+	FinishCommits(ctx context.Context, in *FinishCommitsRequest, opts ...grpc.CallOption) (*Transaction, error)
 	// InspectCommit returns the info about a commit.
 	InspectCommit(ctx context.Context, in *InspectCommitRequest, opts ...grpc.CallOption) (*CommitInfo, error)
 	// ListCommit returns info about all commits.
 	ListCommit(ctx context.Context, in *ListCommitRequest, opts ...grpc.CallOption) (*CommitInfos, error)
+	// ListCommitStream is like ListCommit but streams back one CommitInfo at a
+	// time.
+	ListCommitStream(ctx context.Context, in *ListCommitRequest, opts ...grpc.CallOption) (API_ListCommitStreamClient, error)
 	// DeleteCommit deletes a commit.
 	DeleteCommit(ctx context.Context, in *DeleteCommitRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
 	// FlushCommit waits for downstream commits to finish
 	FlushCommit(ctx context.Context, in *FlushCommitRequest, opts ...grpc.CallOption) (API_FlushCommitClient, error)
+	// FlushCommits does several FlushCommits at once, and returns their
+	// commits as they finish, so that many commits can be flushed with one
+	// stream
+	FlushCommits(ctx context.Context, in *FlushCommitsRequest, opts ...grpc.CallOption) (API_FlushCommitsClient, error)
 	// SubscribeCommit subscribes for new commits on a given branch
 	SubscribeCommit(ctx context.Context, in *SubscribeCommitRequest, opts ...grpc.CallOption) (API_SubscribeCommitClient, error)
 	// BuildCommit builds a commit that's backed by the given tree
@@ -1355,19 +2191,46 @@ type APIClient interface {
 	SetBranch(ctx context.Context, in *SetBranchRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
 	// DeleteBranch deletes a branch; note that the commits still exist.
 	DeleteBranch(ctx context.Context, in *DeleteBranchRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+	// SetBranchTrigger sets or removes the trigger of a branch, which moves it
+	// to the head of another branch when the trigger's conditions are met.
+	SetBranchTrigger(ctx context.Context, in *SetBranchTriggerRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+	// SetBranchRetention sets or removes the retention policy of a branch,
+	// which prunes its old commits.
+	SetBranchRetention(ctx context.Context, in *SetBranchRetentionRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
 	// File rpcs
 	// PutFile writes the specified file to pfs.
 	PutFile(ctx context.Context, opts ...grpc.CallOption) (API_PutFileClient, error)
+	// PutFileBatch writes several files to pfs in a single stream. A request
+	// with file set starts a new file, requests without it append to the
+	// previous file.
+	PutFileBatch(ctx context.Context, opts ...grpc.CallOption) (API_PutFileBatchClient, error)
 	// GetFile returns a byte stream of the contents of the file.
 	GetFile(ctx context.Context, in *GetFileRequest, opts ...grpc.CallOption) (API_GetFileClient, error)
+	// GetFiles returns the FileInfos of several files, along with the contents
+	// of the small ones, so that many small files can be read in one call.
+	GetFiles(ctx context.Context, in *GetFilesRequest, opts ...grpc.CallOption) (API_GetFilesClient, error)
+	// GetFileRanges returns the contents of many ranges of files in one
+	// stream, reading several of them at once, so that analytics engines can
+	// scan a commit's files efficiently.
+	GetFileRanges(ctx context.Context, in *GetFileRangesRequest, opts ...grpc.CallOption) (API_GetFileRangesClient, error)
+	// GetFileURL returns a signed, expiring URL that a file can be downloaded
+	// from over HTTP, for sharing it with systems that can't authenticate to
+	// pachd with gRPC.
+	GetFileURL(ctx context.Context, in *GetFileURLRequest, opts ...grpc.CallOption) (*GetFileURLResponse, error)
 	// InspectFile returns info about a file.
 	InspectFile(ctx context.Context, in *InspectFileRequest, opts ...grpc.CallOption) (*FileInfo, error)
 	// ListFile returns info about all files.
 	ListFile(ctx context.Context, in *ListFileRequest, opts ...grpc.CallOption) (*FileInfos, error)
+	// ListFileStream is like ListFile but streams back one FileInfo at a time.
+	ListFileStream(ctx context.Context, in *ListFileRequest, opts ...grpc.CallOption) (API_ListFileStreamClient, error)
 	// GlobFile returns info about all files.
 	GlobFile(ctx context.Context, in *GlobFileRequest, opts ...grpc.CallOption) (*FileInfos, error)
+	// GlobFileStream is like GlobFile but streams back one FileInfo at a time.
+	GlobFileStream(ctx context.Context, in *GlobFileRequest, opts ...grpc.CallOption) (API_GlobFileStreamClient, error)
 	// DeleteFile deletes a file.
 	DeleteFile(ctx context.Context, in *DeleteFileRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+	// DeleteFiles deletes a set of files from an open commit atomically.
+	DeleteFiles(ctx context.Context, in *DeleteFilesRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
 	// DeleteAll deletes everything
 	DeleteAll(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
 }
@@ -1416,6 +2279,15 @@ func (c *aPIClient) DeleteRepo(ctx context.Context, in *DeleteRepoRequest, opts
 	return out, nil
 }
 
+func (c *aPIClient) UpdateRepo(ctx context.Context, in *UpdateRepoRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
+	out := new(google_protobuf.Empty)
+	err := grpc.Invoke(ctx, "/pfs.API/UpdateRepo", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *aPIClient) StartCommit(ctx context.Context, in *StartCommitRequest, opts ...grpc.CallOption) (*Commit, error) {
 	out := new(Commit)
 	err := grpc.Invoke(ctx, "/pfs.API/StartCommit", in, out, c.cc, opts...)
@@ -1434,6 +2306,15 @@ func (c *aPIClient) FinishCommit(ctx context.Context, in *FinishCommitRequest, o
 	return out, nil
 }
 
+func (c *aPIClient) FinishCommits(ctx context.Context, in *FinishCommitsRequest, opts ...grpc.CallOption) (*Transaction, error) {
+	out := new(Transaction)
+	err := grpc.Invoke(ctx, "/pfs.API/FinishCommits", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *aPIClient) InspectCommit(ctx context.Context, in *InspectCommitRequest, opts ...grpc.CallOption) (*CommitInfo, error) {
 	out := new(CommitInfo)
 	err := grpc.Invoke(ctx, "/pfs.API/InspectCommit", in, out, c.cc, opts...)
@@ -1452,6 +2333,38 @@ func (c *aPIClient) ListCommit(ctx context.Context, in *ListCommitRequest, opts
 	return out, nil
 }
 
+func (c *aPIClient) ListCommitStream(ctx context.Context, in *ListCommitRequest, opts ...grpc.CallOption) (API_ListCommitStreamClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_API_serviceDesc.Streams[0], c.cc, "/pfs.API/ListCommitStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &aPIListCommitStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type API_ListCommitStreamClient interface {
+	Recv() (*CommitInfo, error)
+	grpc.ClientStream
+}
+
+type aPIListCommitStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *aPIListCommitStreamClient) Recv() (*CommitInfo, error) {
+	m := new(CommitInfo)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *aPIClient) DeleteCommit(ctx context.Context, in *DeleteCommitRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
 	out := new(google_protobuf.Empty)
 	err := grpc.Invoke(ctx, "/pfs.API/DeleteCommit", in, out, c.cc, opts...)
@@ -1462,7 +2375,7 @@ func (c *aPIClient) DeleteCommit(ctx context.Context, in *DeleteCommitRequest, o
 }
 
 func (c *aPIClient) FlushCommit(ctx context.Context, in *FlushCommitRequest, opts ...grpc.CallOption) (API_FlushCommitClient, error) {
-	stream, err := grpc.NewClientStream(ctx, &_API_serviceDesc.Streams[0], c.cc, "/pfs.API/FlushCommit", opts...)
+	stream, err := grpc.NewClientStream(ctx, &_API_serviceDesc.Streams[1], c.cc, "/pfs.API/FlushCommit", opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -1493,8 +2406,40 @@ func (x *aPIFlushCommitClient) Recv() (*CommitInfo, error) {
 	return m, nil
 }
 
+func (c *aPIClient) FlushCommits(ctx context.Context, in *FlushCommitsRequest, opts ...grpc.CallOption) (API_FlushCommitsClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_API_serviceDesc.Streams[2], c.cc, "/pfs.API/FlushCommits", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &aPIFlushCommitsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type API_FlushCommitsClient interface {
+	Recv() (*FlushCommitsResponse, error)
+	grpc.ClientStream
+}
+
+type aPIFlushCommitsClient struct {
+	grpc.ClientStream
+}
+
+func (x *aPIFlushCommitsClient) Recv() (*FlushCommitsResponse, error) {
+	m := new(FlushCommitsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *aPIClient) SubscribeCommit(ctx context.Context, in *SubscribeCommitRequest, opts ...grpc.CallOption) (API_SubscribeCommitClient, error) {
-	stream, err := grpc.NewClientStream(ctx, &_API_serviceDesc.Streams[1], c.cc, "/pfs.API/SubscribeCommit", opts...)
+	stream, err := grpc.NewClientStream(ctx, &_API_serviceDesc.Streams[3], c.cc, "/pfs.API/SubscribeCommit", opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -1561,8 +2506,25 @@ func (c *aPIClient) DeleteBranch(ctx context.Context, in *DeleteBranchRequest, o
 	return out, nil
 }
 
+func (c *aPIClient) SetBranchTrigger(ctx context.Context, in *SetBranchTriggerRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
+	out := new(google_protobuf.Empty)
+	err := grpc.Invoke(ctx, "/pfs.API/SetBranchTrigger", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+func (c *aPIClient) SetBranchRetention(ctx context.Context, in *SetBranchRetentionRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
+	out := new(google_protobuf.Empty)
+	err := grpc.Invoke(ctx, "/pfs.API/SetBranchRetention", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *aPIClient) PutFile(ctx context.Context, opts ...grpc.CallOption) (API_PutFileClient, error) {
-	stream, err := grpc.NewClientStream(ctx, &_API_serviceDesc.Streams[2], c.cc, "/pfs.API/PutFile", opts...)
+	stream, err := grpc.NewClientStream(ctx, &_API_serviceDesc.Streams[4], c.cc, "/pfs.API/PutFile", opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -1595,8 +2557,42 @@ func (x *aPIPutFileClient) CloseAndRecv() (*google_protobuf.Empty, error) {
 	return m, nil
 }
 
+func (c *aPIClient) PutFileBatch(ctx context.Context, opts ...grpc.CallOption) (API_PutFileBatchClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_API_serviceDesc.Streams[5], c.cc, "/pfs.API/PutFileBatch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &aPIPutFileBatchClient{stream}
+	return x, nil
+}
+
+type API_PutFileBatchClient interface {
+	Send(*PutFileRequest) error
+	CloseAndRecv() (*google_protobuf.Empty, error)
+	grpc.ClientStream
+}
+
+type aPIPutFileBatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *aPIPutFileBatchClient) Send(m *PutFileRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *aPIPutFileBatchClient) CloseAndRecv() (*google_protobuf.Empty, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(google_protobuf.Empty)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *aPIClient) GetFile(ctx context.Context, in *GetFileRequest, opts ...grpc.CallOption) (API_GetFileClient, error) {
-	stream, err := grpc.NewClientStream(ctx, &_API_serviceDesc.Streams[3], c.cc, "/pfs.API/GetFile", opts...)
+	stream, err := grpc.NewClientStream(ctx, &_API_serviceDesc.Streams[6], c.cc, "/pfs.API/GetFile", opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -1627,6 +2623,79 @@ func (x *aPIGetFileClient) Recv() (*google_protobuf2.BytesValue, error) {
 	return m, nil
 }
 
+func (c *aPIClient) GetFiles(ctx context.Context, in *GetFilesRequest, opts ...grpc.CallOption) (API_GetFilesClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_API_serviceDesc.Streams[7], c.cc, "/pfs.API/GetFiles", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &aPIGetFilesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type API_GetFilesClient interface {
+	Recv() (*FileContents, error)
+	grpc.ClientStream
+}
+
+type aPIGetFilesClient struct {
+	grpc.ClientStream
+}
+
+func (x *aPIGetFilesClient) Recv() (*FileContents, error) {
+	m := new(FileContents)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *aPIClient) GetFileRanges(ctx context.Context, in *GetFileRangesRequest, opts ...grpc.CallOption) (API_GetFileRangesClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_API_serviceDesc.Streams[8], c.cc, "/pfs.API/GetFileRanges", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &aPIGetFileRangesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type API_GetFileRangesClient interface {
+	Recv() (*FileRangeChunk, error)
+	grpc.ClientStream
+}
+
+type aPIGetFileRangesClient struct {
+	grpc.ClientStream
+}
+
+func (x *aPIGetFileRangesClient) Recv() (*FileRangeChunk, error) {
+	m := new(FileRangeChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *aPIClient) GetFileURL(ctx context.Context, in *GetFileURLRequest, opts ...grpc.CallOption) (*GetFileURLResponse, error) {
+	out := new(GetFileURLResponse)
+	err := grpc.Invoke(ctx, "/pfs.API/GetFileURL", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *aPIClient) InspectFile(ctx context.Context, in *InspectFileRequest, opts ...grpc.CallOption) (*FileInfo, error) {
 	out := new(FileInfo)
 	err := grpc.Invoke(ctx, "/pfs.API/InspectFile", in, out, c.cc, opts...)
@@ -1636,27 +2705,100 @@ func (c *aPIClient) InspectFile(ctx context.Context, in *InspectFileRequest, opt
 	return out, nil
 }
 
-func (c *aPIClient) ListFile(ctx context.Context, in *ListFileRequest, opts ...grpc.CallOption) (*FileInfos, error) {
-	out := new(FileInfos)
-	err := grpc.Invoke(ctx, "/pfs.API/ListFile", in, out, c.cc, opts...)
-	if err != nil {
+func (c *aPIClient) ListFile(ctx context.Context, in *ListFileRequest, opts ...grpc.CallOption) (*FileInfos, error) {
+	out := new(FileInfos)
+	err := grpc.Invoke(ctx, "/pfs.API/ListFile", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) ListFileStream(ctx context.Context, in *ListFileRequest, opts ...grpc.CallOption) (API_ListFileStreamClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_API_serviceDesc.Streams[9], c.cc, "/pfs.API/ListFileStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &aPIListFileStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type API_ListFileStreamClient interface {
+	Recv() (*FileInfo, error)
+	grpc.ClientStream
+}
+
+type aPIListFileStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *aPIListFileStreamClient) Recv() (*FileInfo, error) {
+	m := new(FileInfo)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *aPIClient) GlobFile(ctx context.Context, in *GlobFileRequest, opts ...grpc.CallOption) (*FileInfos, error) {
+	out := new(FileInfos)
+	err := grpc.Invoke(ctx, "/pfs.API/GlobFile", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) GlobFileStream(ctx context.Context, in *GlobFileRequest, opts ...grpc.CallOption) (API_GlobFileStreamClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_API_serviceDesc.Streams[10], c.cc, "/pfs.API/GlobFileStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &aPIGlobFileStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type API_GlobFileStreamClient interface {
+	Recv() (*FileInfo, error)
+	grpc.ClientStream
+}
+
+type aPIGlobFileStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *aPIGlobFileStreamClient) Recv() (*FileInfo, error) {
+	m := new(FileInfo)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
 		return nil, err
 	}
-	return out, nil
+	return m, nil
 }
 
-func (c *aPIClient) GlobFile(ctx context.Context, in *GlobFileRequest, opts ...grpc.CallOption) (*FileInfos, error) {
-	out := new(FileInfos)
-	err := grpc.Invoke(ctx, "/pfs.API/GlobFile", in, out, c.cc, opts...)
+func (c *aPIClient) DeleteFile(ctx context.Context, in *DeleteFileRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
+	out := new(google_protobuf.Empty)
+	err := grpc.Invoke(ctx, "/pfs.API/DeleteFile", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *aPIClient) DeleteFile(ctx context.Context, in *DeleteFileRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
+func (c *aPIClient) DeleteFiles(ctx context.Context, in *DeleteFilesRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
 	out := new(google_protobuf.Empty)
-	err := grpc.Invoke(ctx, "/pfs.API/DeleteFile", in, out, c.cc, opts...)
+	err := grpc.Invoke(ctx, "/pfs.API/DeleteFiles", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -1685,19 +2827,31 @@ type APIServer interface {
 	ListRepo(context.Context, *ListRepoRequest) (*RepoInfos, error)
 	// DeleteRepo deletes a repo.
 	DeleteRepo(context.Context, *DeleteRepoRequest) (*google_protobuf.Empty, error)
+	// UpdateRepo updates a repo's description and labels.
+	UpdateRepo(context.Context, *UpdateRepoRequest) (*google_protobuf.Empty, error)
 	// Commit rpcs
 	// StartCommit creates a new write commit from a parent commit.
 	StartCommit(context.Context, *StartCommitRequest) (*Commit, error)
 	// FinishCommit turns a write commit into a read commit.
 	FinishCommit(context.Context, *FinishCommitRequest) (*google_protobuf.Empty, error)
+	// FinishCommits finishes several commits atomically: either all of them
+	// are finished, at the same time, or none are.
+	FinishCommits(context.Context, *FinishCommitsRequest) (*Transaction, error)
 	// InspectCommit returns the info about a commit.
 	InspectCommit(context.Context, *InspectCommitRequest) (*CommitInfo, error)
 	// ListCommit returns info about all commits.
 	ListCommit(context.Context, *ListCommitRequest) (*CommitInfos, error)
+	// ListCommitStream is like ListCommit but streams back one CommitInfo at a
+	// time.
+	ListCommitStream(*ListCommitRequest, API_ListCommitStreamServer) error
 	// DeleteCommit deletes a commit.
 	DeleteCommit(context.Context, *DeleteCommitRequest) (*google_protobuf.Empty, error)
 	// FlushCommit waits for downstream commits to finish
 	FlushCommit(*FlushCommitRequest, API_FlushCommitServer) error
+	// FlushCommits does several FlushCommits at once, and returns their
+	// commits as they finish, so that many commits can be flushed with one
+	// stream
+	FlushCommits(*FlushCommitsRequest, API_FlushCommitsServer) error
 	// SubscribeCommit subscribes for new commits on a given branch
 	SubscribeCommit(*SubscribeCommitRequest, API_SubscribeCommitServer) error
 	// BuildCommit builds a commit that's backed by the given tree
@@ -1708,19 +2862,46 @@ type APIServer interface {
 	SetBranch(context.Context, *SetBranchRequest) (*google_protobuf.Empty, error)
 	// DeleteBranch deletes a branch; note that the commits still exist.
 	DeleteBranch(context.Context, *DeleteBranchRequest) (*google_protobuf.Empty, error)
+	// SetBranchTrigger sets or removes the trigger of a branch, which moves it
+	// to the head of another branch when the trigger's conditions are met.
+	SetBranchTrigger(context.Context, *SetBranchTriggerRequest) (*google_protobuf.Empty, error)
+	// SetBranchRetention sets or removes the retention policy of a branch,
+	// which prunes its old commits.
+	SetBranchRetention(context.Context, *SetBranchRetentionRequest) (*google_protobuf.Empty, error)
 	// File rpcs
 	// PutFile writes the specified file to pfs.
 	PutFile(API_PutFileServer) error
+	// PutFileBatch writes several files to pfs in a single stream. A request
+	// with file set starts a new file, requests without it append to the
+	// previous file.
+	PutFileBatch(API_PutFileBatchServer) error
 	// GetFile returns a byte stream of the contents of the file.
 	GetFile(*GetFileRequest, API_GetFileServer) error
+	// GetFiles returns the FileInfos of several files, along with the contents
+	// of the small ones, so that many small files can be read in one call.
+	GetFiles(*GetFilesRequest, API_GetFilesServer) error
+	// GetFileRanges returns the contents of many ranges of files in one
+	// stream, reading several of them at once, so that analytics engines can
+	// scan a commit's files efficiently.
+	GetFileRanges(*GetFileRangesRequest, API_GetFileRangesServer) error
+	// GetFileURL returns a signed, expiring URL that a file can be downloaded
+	// from over HTTP, for sharing it with systems that can't authenticate to
+	// pachd with gRPC.
+	GetFileURL(context.Context, *GetFileURLRequest) (*GetFileURLResponse, error)
 	// InspectFile returns info about a file.
 	InspectFile(context.Context, *InspectFileRequest) (*FileInfo, error)
 	// ListFile returns info about all files.
 	ListFile(context.Context, *ListFileRequest) (*FileInfos, error)
+	// ListFileStream is like ListFile but streams back one FileInfo at a time.
+	ListFileStream(*ListFileRequest, API_ListFileStreamServer) error
 	// GlobFile returns info about all files.
 	GlobFile(context.Context, *GlobFileRequest) (*FileInfos, error)
+	// GlobFileStream is like GlobFile but streams back one FileInfo at a time.
+	GlobFileStream(*GlobFileRequest, API_GlobFileStreamServer) error
 	// DeleteFile deletes a file.
 	DeleteFile(context.Context, *DeleteFileRequest) (*google_protobuf.Empty, error)
+	// DeleteFiles deletes a set of files from an open commit atomically.
+	DeleteFiles(context.Context, *DeleteFilesRequest) (*google_protobuf.Empty, error)
 	// DeleteAll deletes everything
 	DeleteAll(context.Context, *google_protobuf.Empty) (*google_protobuf.Empty, error)
 }
@@ -1801,6 +2982,24 @@ func _API_DeleteRepo_Handler(srv interface{}, ctx context.Context, dec func(inte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _API_UpdateRepo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateRepoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).UpdateRepo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pfs.API/UpdateRepo",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).UpdateRepo(ctx, req.(*UpdateRepoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _API_StartCommit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(StartCommitRequest)
 	if err := dec(in); err != nil {
@@ -1837,6 +3036,24 @@ func _API_FinishCommit_Handler(srv interface{}, ctx context.Context, dec func(in
 	return interceptor(ctx, in, info, handler)
 }
 
+func _API_FinishCommits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FinishCommitsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).FinishCommits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pfs.API/FinishCommits",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).FinishCommits(ctx, req.(*FinishCommitsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _API_InspectCommit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(InspectCommitRequest)
 	if err := dec(in); err != nil {
@@ -1873,6 +3090,27 @@ func _API_ListCommit_Handler(srv interface{}, ctx context.Context, dec func(inte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _API_ListCommitStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListCommitRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(APIServer).ListCommitStream(m, &aPIListCommitStreamServer{stream})
+}
+
+type API_ListCommitStreamServer interface {
+	Send(*CommitInfo) error
+	grpc.ServerStream
+}
+
+type aPIListCommitStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *aPIListCommitStreamServer) Send(m *CommitInfo) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _API_DeleteCommit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(DeleteCommitRequest)
 	if err := dec(in); err != nil {
@@ -1912,6 +3150,27 @@ func (x *aPIFlushCommitServer) Send(m *CommitInfo) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _API_FlushCommits_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(FlushCommitsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(APIServer).FlushCommits(m, &aPIFlushCommitsServer{stream})
+}
+
+type API_FlushCommitsServer interface {
+	Send(*FlushCommitsResponse) error
+	grpc.ServerStream
+}
+
+type aPIFlushCommitsServer struct {
+	grpc.ServerStream
+}
+
+func (x *aPIFlushCommitsServer) Send(m *FlushCommitsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _API_SubscribeCommit_Handler(srv interface{}, stream grpc.ServerStream) error {
 	m := new(SubscribeCommitRequest)
 	if err := stream.RecvMsg(m); err != nil {
@@ -2005,6 +3264,42 @@ func _API_DeleteBranch_Handler(srv interface{}, ctx context.Context, dec func(in
 	return interceptor(ctx, in, info, handler)
 }
 
+func _API_SetBranchTrigger_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetBranchTriggerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).SetBranchTrigger(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pfs.API/SetBranchTrigger",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).SetBranchTrigger(ctx, req.(*SetBranchTriggerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_SetBranchRetention_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetBranchRetentionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).SetBranchRetention(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pfs.API/SetBranchRetention",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).SetBranchRetention(ctx, req.(*SetBranchRetentionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _API_PutFile_Handler(srv interface{}, stream grpc.ServerStream) error {
 	return srv.(APIServer).PutFile(&aPIPutFileServer{stream})
 }
@@ -2031,6 +3326,32 @@ func (x *aPIPutFileServer) Recv() (*PutFileRequest, error) {
 	return m, nil
 }
 
+func _API_PutFileBatch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(APIServer).PutFileBatch(&aPIPutFileBatchServer{stream})
+}
+
+type API_PutFileBatchServer interface {
+	SendAndClose(*google_protobuf.Empty) error
+	Recv() (*PutFileRequest, error)
+	grpc.ServerStream
+}
+
+type aPIPutFileBatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *aPIPutFileBatchServer) SendAndClose(m *google_protobuf.Empty) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *aPIPutFileBatchServer) Recv() (*PutFileRequest, error) {
+	m := new(PutFileRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func _API_GetFile_Handler(srv interface{}, stream grpc.ServerStream) error {
 	m := new(GetFileRequest)
 	if err := stream.RecvMsg(m); err != nil {
@@ -2052,6 +3373,66 @@ func (x *aPIGetFileServer) Send(m *google_protobuf2.BytesValue) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _API_GetFiles_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetFilesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(APIServer).GetFiles(m, &aPIGetFilesServer{stream})
+}
+
+type API_GetFilesServer interface {
+	Send(*FileContents) error
+	grpc.ServerStream
+}
+
+type aPIGetFilesServer struct {
+	grpc.ServerStream
+}
+
+func (x *aPIGetFilesServer) Send(m *FileContents) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _API_GetFileRanges_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetFileRangesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(APIServer).GetFileRanges(m, &aPIGetFileRangesServer{stream})
+}
+
+type API_GetFileRangesServer interface {
+	Send(*FileRangeChunk) error
+	grpc.ServerStream
+}
+
+type aPIGetFileRangesServer struct {
+	grpc.ServerStream
+}
+
+func (x *aPIGetFileRangesServer) Send(m *FileRangeChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _API_GetFileURL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetFileURLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).GetFileURL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pfs.API/GetFileURL",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).GetFileURL(ctx, req.(*GetFileURLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _API_InspectFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(InspectFileRequest)
 	if err := dec(in); err != nil {
@@ -2088,6 +3469,27 @@ func _API_ListFile_Handler(srv interface{}, ctx context.Context, dec func(interf
 	return interceptor(ctx, in, info, handler)
 }
 
+func _API_ListFileStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListFileRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(APIServer).ListFileStream(m, &aPIListFileStreamServer{stream})
+}
+
+type API_ListFileStreamServer interface {
+	Send(*FileInfo) error
+	grpc.ServerStream
+}
+
+type aPIListFileStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *aPIListFileStreamServer) Send(m *FileInfo) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _API_GlobFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GlobFileRequest)
 	if err := dec(in); err != nil {
@@ -2106,6 +3508,27 @@ func _API_GlobFile_Handler(srv interface{}, ctx context.Context, dec func(interf
 	return interceptor(ctx, in, info, handler)
 }
 
+func _API_GlobFileStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GlobFileRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(APIServer).GlobFileStream(m, &aPIGlobFileStreamServer{stream})
+}
+
+type API_GlobFileStreamServer interface {
+	Send(*FileInfo) error
+	grpc.ServerStream
+}
+
+type aPIGlobFileStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *aPIGlobFileStreamServer) Send(m *FileInfo) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _API_DeleteFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(DeleteFileRequest)
 	if err := dec(in); err != nil {
@@ -2124,6 +3547,24 @@ func _API_DeleteFile_Handler(srv interface{}, ctx context.Context, dec func(inte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _API_DeleteFiles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteFilesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).DeleteFiles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pfs.API/DeleteFiles",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).DeleteFiles(ctx, req.(*DeleteFilesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _API_DeleteAll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(google_protobuf.Empty)
 	if err := dec(in); err != nil {
@@ -2162,6 +3603,10 @@ var _API_serviceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteRepo",
 			Handler:    _API_DeleteRepo_Handler,
 		},
+		{
+			MethodName: "UpdateRepo",
+			Handler:    _API_UpdateRepo_Handler,
+		},
 		{
 			MethodName: "StartCommit",
 			Handler:    _API_StartCommit_Handler,
@@ -2170,6 +3615,10 @@ var _API_serviceDesc = grpc.ServiceDesc{
 			MethodName: "FinishCommit",
 			Handler:    _API_FinishCommit_Handler,
 		},
+		{
+			MethodName: "FinishCommits",
+			Handler:    _API_FinishCommits_Handler,
+		},
 		{
 			MethodName: "InspectCommit",
 			Handler:    _API_InspectCommit_Handler,
@@ -2198,6 +3647,18 @@ var _API_serviceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteBranch",
 			Handler:    _API_DeleteBranch_Handler,
 		},
+		{
+			MethodName: "SetBranchTrigger",
+			Handler:    _API_SetBranchTrigger_Handler,
+		},
+		{
+			MethodName: "SetBranchRetention",
+			Handler:    _API_SetBranchRetention_Handler,
+		},
+		{
+			MethodName: "GetFileURL",
+			Handler:    _API_GetFileURL_Handler,
+		},
 		{
 			MethodName: "InspectFile",
 			Handler:    _API_InspectFile_Handler,
@@ -2214,17 +3675,31 @@ var _API_serviceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteFile",
 			Handler:    _API_DeleteFile_Handler,
 		},
+		{
+			MethodName: "DeleteFiles",
+			Handler:    _API_DeleteFiles_Handler,
+		},
 		{
 			MethodName: "DeleteAll",
 			Handler:    _API_DeleteAll_Handler,
 		},
 	},
 	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListCommitStream",
+			Handler:       _API_ListCommitStream_Handler,
+			ServerStreams: true,
+		},
 		{
 			StreamName:    "FlushCommit",
 			Handler:       _API_FlushCommit_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "FlushCommits",
+			Handler:       _API_FlushCommits_Handler,
+			ServerStreams: true,
+		},
 		{
 			StreamName:    "SubscribeCommit",
 			Handler:       _API_SubscribeCommit_Handler,
@@ -2235,11 +3710,36 @@ var _API_serviceDesc = grpc.ServiceDesc{
 			Handler:       _API_PutFile_Handler,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "PutFileBatch",
+			Handler:       _API_PutFileBatch_Handler,
+			ClientStreams: true,
+		},
 		{
 			StreamName:    "GetFile",
 			Handler:       _API_GetFile_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "GetFiles",
+			Handler:       _API_GetFiles_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "GetFileRanges",
+			Handler:       _API_GetFileRanges_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ListFileStream",
+			Handler:       _API_ListFileStream_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "GlobFileStream",
+			Handler:       _API_GlobFileStream_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "client/pfs/pfs.proto",
 }
@@ -2252,6 +3752,7 @@ type ObjectAPIClient interface {
 	GetObjects(ctx context.Context, in *GetObjectsRequest, opts ...grpc.CallOption) (ObjectAPI_GetObjectsClient, error)
 	TagObject(ctx context.Context, in *TagObjectRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
 	InspectObject(ctx context.Context, in *Object, opts ...grpc.CallOption) (*ObjectInfo, error)
+	CheckObject(ctx context.Context, in *CheckObjectRequest, opts ...grpc.CallOption) (*CheckObjectResponse, error)
 	GetTag(ctx context.Context, in *Tag, opts ...grpc.CallOption) (ObjectAPI_GetTagClient, error)
 	InspectTag(ctx context.Context, in *Tag, opts ...grpc.CallOption) (*ObjectInfo, error)
 	Compact(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
@@ -2381,6 +3882,15 @@ func (c *objectAPIClient) InspectObject(ctx context.Context, in *Object, opts ..
 	return out, nil
 }
 
+func (c *objectAPIClient) CheckObject(ctx context.Context, in *CheckObjectRequest, opts ...grpc.CallOption) (*CheckObjectResponse, error) {
+	out := new(CheckObjectResponse)
+	err := grpc.Invoke(ctx, "/pfs.ObjectAPI/CheckObject", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *objectAPIClient) GetTag(ctx context.Context, in *Tag, opts ...grpc.CallOption) (ObjectAPI_GetTagClient, error) {
 	stream, err := grpc.NewClientStream(ctx, &_ObjectAPI_serviceDesc.Streams[3], c.cc, "/pfs.ObjectAPI/GetTag", opts...)
 	if err != nil {
@@ -2439,6 +3949,7 @@ type ObjectAPIServer interface {
 	GetObjects(*GetObjectsRequest, ObjectAPI_GetObjectsServer) error
 	TagObject(context.Context, *TagObjectRequest) (*google_protobuf.Empty, error)
 	InspectObject(context.Context, *Object) (*ObjectInfo, error)
+	CheckObject(context.Context, *CheckObjectRequest) (*CheckObjectResponse, error)
 	GetTag(*Tag, ObjectAPI_GetTagServer) error
 	InspectTag(context.Context, *Tag) (*ObjectInfo, error)
 	Compact(context.Context, *google_protobuf.Empty) (*google_protobuf.Empty, error)
@@ -2552,6 +4063,24 @@ func _ObjectAPI_InspectObject_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ObjectAPI_CheckObject_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckObjectRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ObjectAPIServer).CheckObject(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pfs.ObjectAPI/CheckObject",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ObjectAPIServer).CheckObject(ctx, req.(*CheckObjectRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _ObjectAPI_GetTag_Handler(srv interface{}, stream grpc.ServerStream) error {
 	m := new(Tag)
 	if err := stream.RecvMsg(m); err != nil {
@@ -2621,6 +4150,10 @@ var _ObjectAPI_serviceDesc = grpc.ServiceDesc{
 			MethodName: "InspectObject",
 			Handler:    _ObjectAPI_InspectObject_Handler,
 		},
+		{
+			MethodName: "CheckObject",
+			Handler:    _ObjectAPI_CheckObject_Handler,
+		},
 		{
 			MethodName: "InspectTag",
 			Handler:    _ObjectAPI_InspectTag_Handler,
@@ -2658,124 +4191,206 @@ var _ObjectAPI_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("client/pfs/pfs.proto", fileDescriptorPfs) }
 
 var fileDescriptorPfs = []byte{
-	// 1898 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xa4, 0x58, 0x5b, 0x73, 0xdb, 0xc6,
-	0x15, 0x16, 0x40, 0x88, 0x97, 0x43, 0x5d, 0xa8, 0xb5, 0xaa, 0x32, 0x94, 0x5d, 0x29, 0xeb, 0x64,
-	0x6a, 0x2b, 0x19, 0xc9, 0x23, 0xc5, 0x55, 0xea, 0x4b, 0x3d, 0x96, 0x45, 0xb9, 0xca, 0x28, 0x92,
-	0x67, 0xa5, 0xe4, 0xad, 0xa3, 0x01, 0xc9, 0x25, 0x85, 0x86, 0x04, 0x10, 0x60, 0x69, 0x57, 0x9d,
-	0x4e, 0xfb, 0xd0, 0x87, 0xf6, 0x5f, 0xf4, 0x77, 0xf4, 0xa5, 0x7f, 0xa0, 0x33, 0xfd, 0x09, 0x7d,
-	0xe8, 0x2f, 0xe9, 0xec, 0x05, 0xc0, 0xe2, 0x22, 0x5e, 0x9c, 0x07, 0x8f, 0x17, 0x7b, 0xf6, 0x9c,
-	0x3d, 0xe7, 0xdb, 0x73, 0xf9, 0x28, 0x58, 0xef, 0x0e, 0x1d, 0xea, 0xb2, 0x3d, 0xbf, 0x1f, 0xf2,
-	0x7f, 0xbb, 0x7e, 0xe0, 0x31, 0x0f, 0x95, 0xfc, 0x7e, 0xd8, 0xda, 0x1c, 0x78, 0xde, 0x60, 0x48,
-	0xf7, 0xc4, 0x56, 0x67, 0xdc, 0xdf, 0xa3, 0x23, 0x9f, 0xdd, 0xca, 0x13, 0xad, 0xad, 0xac, 0x90,
-	0x39, 0x23, 0x1a, 0x32, 0x7b, 0xe4, 0xab, 0x03, 0xbf, 0xc8, 0x1e, 0xf8, 0x10, 0xd8, 0xbe, 0x4f,
-	0x03, 0x75, 0x45, 0x6b, 0x7d, 0xe0, 0x0d, 0x3c, 0xb1, 0xdc, 0xe3, 0x2b, 0xb9, 0x8b, 0x5b, 0x60,
-	0x11, 0xea, 0x7b, 0x08, 0x81, 0xe5, 0xda, 0x23, 0xda, 0x34, 0xb6, 0x8d, 0x47, 0x35, 0x22, 0xd6,
-	0xf8, 0x15, 0x94, 0xdf, 0x78, 0xa3, 0x91, 0xc3, 0xd0, 0x03, 0xb0, 0x02, 0xea, 0x7b, 0x42, 0x5a,
-	0xdf, 0xaf, 0xed, 0x72, 0xc7, 0xb9, 0x1a, 0x11, 0xdb, 0x68, 0x03, 0x4c, 0xa7, 0xd7, 0x34, 0xb9,
-	0xea, 0x51, 0xf9, 0x7f, 0xff, 0xdd, 0x32, 0x4f, 0x8f, 0x89, 0xe9, 0xf4, 0xf0, 0x2e, 0x54, 0xa4,
-	0x81, 0x10, 0x3d, 0x84, 0x72, 0x57, 0x2c, 0x9b, 0xc6, 0x76, 0xe9, 0x51, 0x7d, 0xbf, 0x2e, 0x6c,
-	0x48, 0x29, 0x51, 0x22, 0xfc, 0x12, 0xca, 0x47, 0x81, 0xed, 0x76, 0x6f, 0x8a, 0xdc, 0x41, 0x5b,
-	0x60, 0xdd, 0x50, 0x5b, 0xde, 0x93, 0x31, 0x20, 0x04, 0xf8, 0x00, 0xaa, 0x52, 0x9d, 0x86, 0xe8,
-	0x97, 0x50, 0xed, 0xa8, 0x75, 0xea, 0x46, 0x79, 0x80, 0xc4, 0x42, 0xfc, 0x0a, 0xac, 0x13, 0x67,
-	0x48, 0x53, 0x0e, 0x1a, 0x77, 0x38, 0xc8, 0xdd, 0xf2, 0x6d, 0x76, 0x23, 0x43, 0x25, 0x62, 0x8d,
-	0x37, 0x61, 0xf1, 0x68, 0xe8, 0x75, 0x7f, 0xe0, 0xc2, 0x1b, 0x3b, 0xbc, 0x89, 0x7c, 0xe6, 0x6b,
-	0x7c, 0x1f, 0xca, 0x17, 0x9d, 0xdf, 0xd3, 0x2e, 0x2b, 0x94, 0x7e, 0x02, 0xa5, 0x2b, 0x7b, 0x50,
-	0x88, 0xfd, 0xbf, 0x0d, 0xa8, 0x72, 0x84, 0x4f, 0xdd, 0xbe, 0x37, 0x0d, 0xfe, 0xaf, 0xa0, 0xd2,
-	0x0d, 0xa8, 0xcd, 0x68, 0x84, 0x4d, 0x6b, 0x57, 0xe6, 0xc2, 0x6e, 0x94, 0x0b, 0xbb, 0x57, 0x51,
-	0xb2, 0x90, 0xe8, 0x28, 0x7a, 0x00, 0x10, 0x3a, 0x7f, 0xa4, 0xd7, 0x9d, 0x5b, 0x46, 0xc3, 0x66,
-	0x69, 0xdb, 0x78, 0x64, 0x91, 0x1a, 0xdf, 0x39, 0xe2, 0x1b, 0xe8, 0x31, 0x80, 0x1f, 0x78, 0xef,
-	0xa9, 0x6b, 0xbb, 0x5d, 0xda, 0xb4, 0x04, 0x84, 0xda, 0xcd, 0x9a, 0x10, 0x6d, 0x43, 0xbd, 0x47,
-	0xc3, 0x6e, 0xe0, 0xf8, 0xcc, 0xf1, 0xdc, 0xe6, 0xa2, 0x08, 0x43, 0xdf, 0xc2, 0x87, 0x50, 0x8b,
-	0x82, 0x09, 0xd1, 0x0e, 0xd4, 0xb8, 0xdb, 0xd7, 0x8e, 0xdb, 0xf7, 0xd4, 0xdb, 0x2c, 0xc7, 0x86,
-	0xf9, 0x11, 0x52, 0x0d, 0xd4, 0x0a, 0xff, 0xcb, 0x04, 0x90, 0x6f, 0x20, 0x80, 0x98, 0xe9, 0x91,
-	0x9e, 0xc0, 0xb2, 0x6f, 0x07, 0xd4, 0x65, 0xd7, 0xea, 0x6c, 0x41, 0xc2, 0x2c, 0xc9, 0x13, 0x2a,
-	0xbd, 0xbf, 0x82, 0x4a, 0xc8, 0xec, 0x80, 0x03, 0x58, 0x9a, 0x0e, 0xa0, 0x3a, 0x8a, 0x7e, 0x05,
-	0xd5, 0xbe, 0xe3, 0x3a, 0xe1, 0x0d, 0xed, 0x35, 0xad, 0xa9, 0x6a, 0xf1, 0xd9, 0x0c, 0xf0, 0x8b,
-	0x59, 0xe0, 0xbf, 0x48, 0x01, 0x5f, 0xce, 0x57, 0x8b, 0x0e, 0xfd, 0x16, 0x58, 0x2c, 0xa0, 0xb4,
-	0x59, 0xd1, 0x42, 0x94, 0x09, 0x47, 0x84, 0x00, 0xbf, 0x82, 0x7a, 0x82, 0x5f, 0x88, 0x9e, 0x40,
-	0x5d, 0x82, 0xa2, 0xa3, 0xbf, 0xaa, 0x59, 0x17, 0xf8, 0x43, 0x37, 0x5e, 0x8b, 0x44, 0xe4, 0x05,
-	0x12, 0x25, 0x62, 0xdf, 0x19, 0xd2, 0x54, 0x22, 0x72, 0x21, 0x11, 0xdb, 0xfc, 0x65, 0xf9, 0xff,
-	0xd7, 0xec, 0xd6, 0xa7, 0x02, 0xf5, 0x15, 0xf5, 0xb2, 0xfc, 0xcc, 0xd5, 0xad, 0x4f, 0x39, 0x0a,
-	0x72, 0x35, 0x2d, 0xfd, 0x5a, 0x50, 0xed, 0xde, 0x38, 0xc3, 0x5e, 0x40, 0x5d, 0x81, 0x41, 0x8d,
-	0xc4, 0xdf, 0xe8, 0x73, 0xa8, 0x78, 0x22, 0xc6, 0xb0, 0x59, 0xd5, 0xe0, 0x51, 0x71, 0x47, 0xb2,
-	0xb8, 0xe2, 0x38, 0x36, 0x4b, 0xaa, 0xe2, 0x0e, 0xa1, 0x16, 0x05, 0x13, 0xc6, 0xee, 0xe6, 0x12,
-	0x31, 0x3a, 0x22, 0xdd, 0x15, 0x30, 0x1c, 0x42, 0x8d, 0x3b, 0x46, 0x6c, 0x77, 0x40, 0xd1, 0x3a,
-	0x2c, 0x0e, 0xbd, 0x0f, 0x34, 0x10, 0x38, 0x58, 0x44, 0x7e, 0xf0, 0xdd, 0x31, 0x6f, 0xb8, 0x22,
-	0x72, 0x8b, 0xc8, 0x0f, 0x4c, 0xa0, 0x2a, 0xda, 0x03, 0xa1, 0x7d, 0xb4, 0x0d, 0x8b, 0x1d, 0xbe,
-	0x56, 0xf8, 0x81, 0xec, 0x48, 0x42, 0x2a, 0x05, 0xe8, 0x33, 0x58, 0x0c, 0xf8, 0x15, 0x2a, 0x67,
-	0x57, 0xe4, 0x89, 0xe8, 0x62, 0x22, 0x85, 0xf8, 0x77, 0x00, 0x32, 0xd8, 0xa8, 0x28, 0x64, 0xc8,
-	0xa9, 0xa2, 0x50, 0x68, 0x28, 0x11, 0x8f, 0x55, 0xdc, 0x70, 0x1d, 0xd0, 0xbe, 0x32, 0xbe, 0xac,
-	0x5d, 0x4f, 0xfb, 0xa4, 0xda, 0x51, 0x2b, 0xfc, 0x17, 0x58, 0x7b, 0x23, 0x9a, 0x84, 0xa8, 0x74,
-	0xfa, 0xe3, 0x98, 0x86, 0x53, 0x47, 0x40, 0xba, 0x5d, 0x98, 0x73, 0xb4, 0x8b, 0x52, 0xbe, 0x5d,
-	0x1c, 0x00, 0x3a, 0x75, 0x43, 0x9f, 0xfb, 0x3f, 0xb3, 0x07, 0xf8, 0x05, 0xac, 0x9e, 0x39, 0x61,
-	0x4a, 0x23, 0xed, 0x94, 0x31, 0xc1, 0x29, 0xfc, 0x5b, 0x58, 0x3b, 0xa6, 0x43, 0x3a, 0x57, 0xcc,
-	0xeb, 0xb0, 0xd8, 0xf7, 0x82, 0xae, 0x7c, 0xac, 0x2a, 0x91, 0x1f, 0xf8, 0xcf, 0x80, 0x2e, 0x79,
-	0x87, 0x50, 0xd5, 0xaa, 0x4c, 0x3d, 0x84, 0xb2, 0x6c, 0x39, 0x85, 0x9d, 0x4b, 0x8a, 0xd0, 0x06,
-	0x94, 0xe5, 0x5c, 0x52, 0xa0, 0xa8, 0xaf, 0x4c, 0x4b, 0x30, 0x27, 0xb6, 0x04, 0xfc, 0x0f, 0x03,
-	0xd0, 0xd1, 0xd8, 0x19, 0xf6, 0x7e, 0x92, 0x03, 0xd6, 0x47, 0x3b, 0x10, 0xf7, 0xa4, 0xd2, 0x5d,
-	0x3d, 0xe9, 0x19, 0xdc, 0x3b, 0x11, 0xcd, 0x30, 0xe7, 0xe1, 0xd4, 0xe6, 0x8e, 0x9f, 0xc3, 0xba,
-	0x4a, 0x8d, 0x8f, 0x50, 0xfe, 0xbb, 0x01, 0x6b, 0x3c, 0x47, 0xd2, 0xaa, 0x53, 0x5e, 0x79, 0x0b,
-	0xac, 0x7e, 0xe0, 0x8d, 0x0a, 0x69, 0x07, 0x17, 0xa0, 0x4d, 0x30, 0x99, 0x97, 0x8a, 0x56, 0x89,
-	0x4d, 0xc6, 0xa9, 0x51, 0xd9, 0x1d, 0x8f, 0x3a, 0x34, 0x10, 0x88, 0x5a, 0x44, 0x7d, 0xe1, 0x7d,
-	0xe9, 0x89, 0xa2, 0x23, 0xb3, 0x65, 0xf8, 0x05, 0x34, 0x2e, 0x69, 0x46, 0x65, 0xa6, 0x89, 0x98,
-	0x3c, 0xab, 0xa9, 0x3f, 0x2b, 0x3e, 0x83, 0x7b, 0x32, 0xe9, 0xe7, 0x71, 0xe3, 0x4e, 0x6b, 0xcf,
-	0x22, 0x6b, 0x1f, 0xf1, 0x32, 0x36, 0xa0, 0x93, 0xe1, 0x38, 0x9b, 0x11, 0x9f, 0x43, 0x45, 0xca,
-	0xc3, 0x22, 0xd6, 0x18, 0xc9, 0xd0, 0x67, 0x50, 0x65, 0xde, 0x35, 0xf7, 0x2d, 0xcc, 0x77, 0x9e,
-	0x0a, 0xf3, 0xf8, 0xff, 0x21, 0xf6, 0x61, 0xe3, 0x72, 0xdc, 0xe1, 0x4d, 0xa6, 0x43, 0xe7, 0x4a,
-	0x80, 0x3b, 0xe2, 0x8d, 0x13, 0xa3, 0x74, 0x47, 0x62, 0xe0, 0x1f, 0x61, 0xe5, 0x2d, 0x65, 0x62,
-	0x3e, 0x26, 0x37, 0x4d, 0x9a, 0x9f, 0x9f, 0xc2, 0x92, 0xd7, 0xef, 0x87, 0x94, 0xa9, 0xa9, 0xc8,
-	0xef, 0x2b, 0x91, 0xba, 0xdc, 0x93, 0x73, 0x31, 0x3f, 0x36, 0x4b, 0xda, 0xd8, 0xc4, 0x7f, 0x35,
-	0x61, 0xe5, 0xdd, 0x78, 0x9e, 0x3b, 0xd7, 0x61, 0xf1, 0xbd, 0x3d, 0x1c, 0xcb, 0x72, 0x5d, 0x22,
-	0xf2, 0x03, 0x35, 0xa0, 0x34, 0x0e, 0x86, 0x8a, 0xca, 0xf1, 0x25, 0xba, 0xcf, 0x59, 0x5b, 0x77,
-	0x1c, 0x84, 0xce, 0x7b, 0xce, 0x4a, 0x78, 0xc3, 0x4b, 0x36, 0xd0, 0x97, 0x50, 0xeb, 0xd1, 0xa1,
-	0x33, 0x72, 0x18, 0x0d, 0xc4, 0xc0, 0x5d, 0x51, 0xb3, 0xeb, 0x38, 0xda, 0x25, 0xc9, 0x01, 0xf4,
-	0x25, 0x20, 0x66, 0x07, 0x03, 0xca, 0xae, 0xc5, 0xfc, 0xed, 0xd9, 0x6c, 0x3c, 0xe2, 0xb3, 0x9c,
-	0x07, 0xd3, 0x90, 0x12, 0xee, 0xe1, 0xb1, 0xd8, 0x47, 0x3b, 0xb0, 0xa6, 0x9f, 0x96, 0x91, 0xd7,
-	0xc4, 0xe1, 0xd5, 0xe4, 0xb0, 0x88, 0xff, 0x1b, 0xab, 0x6a, 0x36, 0x4a, 0xda, 0xfc, 0x98, 0x1d,
-	0x08, 0xfc, 0x44, 0xce, 0x8f, 0x39, 0x34, 0xde, 0xc1, 0xea, 0xdb, 0xa1, 0xd7, 0xd1, 0x35, 0x66,
-	0x2a, 0xc7, 0x26, 0x54, 0x7c, 0x9b, 0x31, 0x1a, 0xb8, 0x2a, 0xa3, 0xa2, 0x4f, 0xde, 0x15, 0x64,
-	0x09, 0xcd, 0xe1, 0xc5, 0x09, 0x34, 0xde, 0x8d, 0x99, 0x6a, 0xb0, 0x4a, 0x25, 0x7e, 0x54, 0x43,
-	0x7f, 0xd4, 0xfb, 0x60, 0x31, 0x7b, 0x10, 0xd5, 0x48, 0x55, 0x18, 0xba, 0xb2, 0x07, 0x44, 0xec,
-	0xe2, 0x3f, 0xc1, 0xda, 0x5b, 0xaa, 0xec, 0x84, 0x5a, 0x05, 0x46, 0x54, 0xcb, 0x98, 0x40, 0xb5,
-	0x8a, 0x12, 0xd7, 0x9a, 0x96, 0xb8, 0x3a, 0xdf, 0xc3, 0xdf, 0x41, 0xe3, 0xca, 0x1e, 0xa4, 0xa3,
-	0x98, 0x89, 0xd8, 0x4c, 0x0e, 0xea, 0x6f, 0x26, 0xd4, 0x23, 0xaa, 0xd4, 0xa3, 0x7f, 0x40, 0x87,
-	0xd9, 0x78, 0x1e, 0x68, 0x36, 0xc5, 0x11, 0xb5, 0x0e, 0xdb, 0x2e, 0x0b, 0x6e, 0x93, 0x08, 0x77,
-	0x53, 0xd7, 0xb4, 0x72, 0x5a, 0x57, 0xf6, 0x40, 0xa9, 0x88, 0x73, 0xad, 0x53, 0x58, 0xd2, 0x0d,
-	0xf1, 0x82, 0xfa, 0x81, 0xde, 0xaa, 0x9f, 0x78, 0x7c, 0x89, 0x1e, 0x46, 0x6f, 0x54, 0xc8, 0xc6,
-	0xa4, 0xec, 0x99, 0xf9, 0xb5, 0xd1, 0x3a, 0x86, 0x5a, 0x6c, 0xbd, 0xc0, 0xce, 0xa7, 0x69, 0x3b,
-	0x29, 0x90, 0x12, 0x2b, 0x3b, 0x5f, 0x48, 0x1a, 0x2f, 0xb8, 0xf7, 0x12, 0x54, 0x49, 0xfb, 0xb2,
-	0x4d, 0xbe, 0x6f, 0x1f, 0x37, 0x16, 0x50, 0x15, 0xac, 0x93, 0xd3, 0xb3, 0x76, 0xc3, 0x40, 0x15,
-	0x28, 0x1d, 0x9f, 0x92, 0x86, 0xb9, 0xf3, 0x18, 0x6a, 0x71, 0xe1, 0x72, 0xf9, 0xf9, 0xc5, 0x79,
-	0x5b, 0x9e, 0xfc, 0xe6, 0xf2, 0xe2, 0xbc, 0x61, 0xf0, 0xd5, 0xd9, 0xe9, 0x79, 0xbb, 0x61, 0xee,
-	0x9c, 0xc1, 0x52, 0x54, 0x36, 0xdf, 0x7a, 0x3d, 0x8a, 0xee, 0x25, 0x65, 0x74, 0x7d, 0x7e, 0x41,
-	0xbe, 0x7d, 0x7d, 0xd6, 0x58, 0x40, 0x6b, 0xb0, 0x1c, 0x6f, 0x9e, 0xbc, 0xbe, 0xbc, 0x6a, 0x18,
-	0x68, 0x1d, 0x1a, 0xf1, 0x16, 0x69, 0xbf, 0xf9, 0x8e, 0x5c, 0xb6, 0x1b, 0xe6, 0xfe, 0x3f, 0x01,
-	0x4a, 0xaf, 0xdf, 0x9d, 0xa2, 0xdf, 0x00, 0x24, 0x14, 0x14, 0x6d, 0xc8, 0x2a, 0xca, 0x72, 0xd2,
-	0xd6, 0x46, 0xee, 0xf7, 0x56, 0x7b, 0xe4, 0xb3, 0x5b, 0xbc, 0x80, 0x0e, 0xa1, 0xae, 0x31, 0x48,
-	0xf4, 0x73, 0x61, 0x20, 0xcf, 0x29, 0x5b, 0xe9, 0x1f, 0x9e, 0x78, 0x01, 0xed, 0x43, 0x35, 0x62,
-	0x91, 0x68, 0x5d, 0x08, 0x33, 0xa4, 0xb2, 0xb5, 0x92, 0x52, 0x09, 0xf1, 0x02, 0x77, 0x36, 0xe1,
-	0x8e, 0xca, 0xd9, 0x1c, 0x99, 0x9c, 0xe0, 0xec, 0x53, 0xa8, 0x6b, 0x8c, 0x51, 0x39, 0x9b, 0xe7,
-	0x90, 0x2d, 0xbd, 0x99, 0xe0, 0x05, 0x74, 0x04, 0x4b, 0x3a, 0x8d, 0x42, 0x4d, 0xd5, 0x19, 0x72,
-	0xcc, 0x6a, 0xc2, 0xd5, 0x2f, 0x61, 0x39, 0x45, 0xa7, 0xd0, 0x27, 0x3a, 0x52, 0x69, 0x2b, 0xd9,
-	0x9f, 0x89, 0x78, 0x01, 0x7d, 0x0d, 0x90, 0xf0, 0x29, 0x15, 0x79, 0x8e, 0x60, 0xb5, 0x1a, 0x19,
-	0xc5, 0x50, 0x3a, 0xaf, 0x93, 0x05, 0xe5, 0x7c, 0x01, 0x7f, 0x98, 0xe0, 0xfc, 0x73, 0xa8, 0x6b,
-	0xa4, 0x41, 0xe1, 0x96, 0xa7, 0x11, 0x05, 0x8e, 0x3f, 0x31, 0xd0, 0x1b, 0x58, 0xcd, 0xd0, 0x01,
-	0xb4, 0x29, 0x81, 0x2f, 0x24, 0x09, 0xc5, 0x46, 0x9e, 0x42, 0x5d, 0xa3, 0xda, 0xca, 0x83, 0x3c,
-	0xf9, 0xce, 0xbe, 0xdc, 0x53, 0x09, 0x9b, 0xfa, 0x5b, 0x57, 0x02, 0x5b, 0x8a, 0x86, 0xa9, 0xdc,
-	0x8c, 0xfe, 0xa2, 0x85, 0x17, 0xd0, 0x0b, 0xa8, 0xc5, 0xfc, 0x0f, 0xfd, 0x4c, 0x3a, 0x9b, 0xe1,
-	0x83, 0x13, 0xd0, 0x8a, 0x11, 0x57, 0x06, 0x74, 0xc4, 0x67, 0xb5, 0xf1, 0x0c, 0x2a, 0x8a, 0x5d,
-	0xa0, 0x7b, 0x42, 0x3d, 0xcd, 0x35, 0xee, 0xd6, 0x7c, 0x64, 0xa0, 0x57, 0x50, 0x51, 0x6c, 0x48,
-	0xe9, 0xa6, 0xb9, 0x51, 0x6b, 0x33, 0xa7, 0x2b, 0x26, 0xc3, 0xf7, 0xbc, 0x83, 0x09, 0xb0, 0x93,
-	0x9a, 0x16, 0x46, 0x52, 0x35, 0xad, 0x1b, 0x4a, 0xff, 0x86, 0x4f, 0x6a, 0x5a, 0x68, 0x25, 0x35,
-	0xad, 0xab, 0xac, 0xa4, 0x54, 0x42, 0xa9, 0x13, 0xcd, 0x76, 0xa5, 0x93, 0x19, 0xf5, 0x05, 0x3a,
-	0x71, 0x1f, 0x10, 0x5a, 0x7a, 0x1f, 0x98, 0x09, 0x23, 0xf4, 0x52, 0x74, 0x5d, 0xca, 0xe8, 0xeb,
-	0xe1, 0x10, 0xdd, 0x71, 0xec, 0x6e, 0xf5, 0xfd, 0xff, 0x94, 0xa0, 0x26, 0xfb, 0x3e, 0xef, 0xa0,
-	0x07, 0x50, 0x8b, 0x69, 0x81, 0x4a, 0x96, 0x2c, 0x4d, 0x68, 0xe9, 0xb3, 0x42, 0xbc, 0xd1, 0xaf,
-	0xa1, 0x16, 0x73, 0x00, 0xa4, 0x4b, 0xa7, 0xbf, 0x4e, 0x1b, 0x20, 0xa1, 0x0f, 0x2a, 0xf8, 0x1c,
-	0x9f, 0x98, 0x6e, 0xe6, 0x85, 0x18, 0x76, 0x29, 0xb7, 0xb3, 0xbc, 0x60, 0x02, 0x82, 0x7b, 0x71,
-	0x3b, 0x2b, 0x8a, 0x61, 0x35, 0x35, 0xb5, 0x45, 0x6a, 0x1c, 0x40, 0xf9, 0x2d, 0x65, 0x57, 0xf6,
-	0x00, 0xc5, 0xcc, 0x61, 0xba, 0x8f, 0x8f, 0x01, 0xd4, 0x2d, 0x69, 0xc5, 0x02, 0xfb, 0xcf, 0xc5,
-	0x5f, 0xc0, 0x7d, 0xbb, 0xcb, 0xe6, 0x7f, 0xd0, 0x4e, 0x59, 0xec, 0x1c, 0xfc, 0x3f, 0x00, 0x00,
-	0xff, 0xff, 0xf6, 0x63, 0xbb, 0x9a, 0x33, 0x18, 0x00, 0x00,
+	// 3207 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xb5, 0x1a, 0x5d, 0x73, 0x1b, 0x57,
+	0x35, 0xab, 0x95, 0xf5, 0x71, 0x24, 0xdb, 0xca, 0x8d, 0x9b, 0xd8, 0x4a, 0xda, 0x34, 0xdb, 0x16,
+	0x12, 0x87, 0xda, 0x6d, 0x1c, 0x1a, 0x92, 0xb4, 0x84, 0xf8, 0x2b, 0x38, 0xb8, 0x89, 0x67, 0x9d,
+	0x74, 0x98, 0xf2, 0xa1, 0x59, 0x49, 0x2b, 0x79, 0x1b, 0x49, 0x2b, 0x76, 0x57, 0x49, 0x0c, 0xa4,
+	0xc3, 0xc0, 0x50, 0x78, 0xe0, 0xa9, 0xf0, 0xd2, 0x19, 0x66, 0x98, 0x61, 0xf8, 0x05, 0xbc, 0xf1,
+	0x3b, 0x78, 0xe7, 0x81, 0xe1, 0x07, 0xf0, 0xc4, 0x33, 0xe7, 0x7e, 0xed, 0xde, 0xfd, 0xd0, 0x87,
+	0x13, 0x78, 0xc8, 0xe4, 0xee, 0xb9, 0xe7, 0x9e, 0x7b, 0xee, 0xf9, 0x3e, 0x47, 0x86, 0xa5, 0x56,
+	0xcf, 0xb1, 0x07, 0xc1, 0xfa, 0xb0, 0xe3, 0xd3, 0x7f, 0x6b, 0x43, 0xcf, 0x0d, 0x5c, 0xa2, 0xe3,
+	0xb2, 0x7e, 0xbe, 0xeb, 0xba, 0xdd, 0x9e, 0xbd, 0xce, 0x40, 0xcd, 0x51, 0x67, 0xdd, 0xee, 0x0f,
+	0x83, 0x63, 0x8e, 0x51, 0xbf, 0x98, 0xdc, 0x0c, 0x9c, 0xbe, 0xed, 0x07, 0x56, 0x7f, 0x28, 0x10,
+	0xde, 0x48, 0x22, 0x3c, 0xf3, 0xac, 0xe1, 0xd0, 0xf6, 0xc4, 0x15, 0xf5, 0x0b, 0x62, 0xdf, 0x1a,
+	0x3a, 0xeb, 0xd6, 0x60, 0xe0, 0x06, 0x56, 0xe0, 0xb8, 0x03, 0xb9, 0xbb, 0xd4, 0x75, 0xbb, 0x2e,
+	0x5b, 0xae, 0xd3, 0xd5, 0x38, 0x9a, 0xed, 0x91, 0xc7, 0x8e, 0xf1, 0x7d, 0xa3, 0x0e, 0x79, 0xd3,
+	0x1e, 0xba, 0x84, 0x40, 0x7e, 0x60, 0xf5, 0xed, 0x65, 0xed, 0x4d, 0xed, 0x72, 0xd9, 0x64, 0x6b,
+	0xe3, 0x0e, 0x14, 0xb6, 0xdc, 0x7e, 0xdf, 0x09, 0xc8, 0xeb, 0x90, 0xf7, 0x10, 0x8b, 0xed, 0x56,
+	0xae, 0x95, 0xd7, 0xe8, 0xb3, 0xe9, 0x31, 0x93, 0x81, 0xc9, 0x59, 0xc8, 0x39, 0xed, 0xe5, 0x1c,
+	0x3d, 0xba, 0x59, 0xf8, 0xe7, 0x3f, 0x2e, 0xe6, 0xf6, 0xb6, 0x4d, 0x84, 0x18, 0x6b, 0x50, 0xe4,
+	0x04, 0x7c, 0xf2, 0x16, 0x14, 0x5a, 0x6c, 0x89, 0x34, 0x74, 0xa4, 0x51, 0x61, 0x34, 0xf8, 0xae,
+	0x29, 0xb6, 0x8c, 0xaf, 0x34, 0x28, 0x6c, 0x7a, 0xd6, 0xa0, 0x75, 0x94, 0xc5, 0x0f, 0xb9, 0x08,
+	0xf9, 0x23, 0xdb, 0xe2, 0x17, 0x25, 0x28, 0xb0, 0x0d, 0xf2, 0x35, 0x28, 0x06, 0x9e, 0xd3, 0xed,
+	0xda, 0xde, 0xb2, 0xce, 0x70, 0xaa, 0x0c, 0xe7, 0x11, 0x87, 0x99, 0x72, 0x93, 0x5c, 0x83, 0xb2,
+	0x67, 0x07, 0xa8, 0x43, 0x94, 0xc3, 0x72, 0x9e, 0x61, 0x2e, 0x89, 0x37, 0x09, 0xe8, 0x81, 0xdb,
+	0x73, 0x5a, 0xc7, 0x66, 0x84, 0x66, 0x7c, 0x0e, 0x45, 0x41, 0x07, 0x9f, 0x5b, 0x68, 0x32, 0x2e,
+	0x05, 0x77, 0xe2, 0x8b, 0xf2, 0xec, 0x3b, 0x3f, 0xb5, 0xb9, 0x20, 0x4c, 0xb6, 0x26, 0xcb, 0x50,
+	0xe4, 0x8f, 0xf3, 0x19, 0x4b, 0xba, 0x29, 0x3f, 0xc9, 0x79, 0x28, 0xb7, 0x3c, 0x77, 0xd0, 0xf0,
+	0x87, 0x76, 0x8b, 0x31, 0x51, 0x36, 0x4b, 0x14, 0x70, 0x88, 0xdf, 0xa4, 0x06, 0xba, 0xd5, 0xeb,
+	0x2d, 0xcf, 0x21, 0xb8, 0x64, 0xd2, 0xa5, 0xf1, 0x19, 0x2c, 0x26, 0xb8, 0x23, 0x97, 0xa0, 0xfa,
+	0xc4, 0xb6, 0x87, 0x0d, 0x79, 0x81, 0xc6, 0x2e, 0xa8, 0x50, 0x98, 0x14, 0xfb, 0x75, 0x28, 0x31,
+	0x94, 0x8e, 0xeb, 0x09, 0xb1, 0xad, 0xac, 0x71, 0x8b, 0x58, 0x93, 0x16, 0xb1, 0xb6, 0x2d, 0x2c,
+	0xc2, 0x2c, 0x52, 0xd4, 0x5d, 0xd7, 0x33, 0x36, 0xa0, 0xc4, 0xd5, 0x60, 0xfb, 0xe4, 0xeb, 0x50,
+	0x6a, 0x8a, 0x75, 0x4c, 0x75, 0x1c, 0xc1, 0x0c, 0x37, 0xd1, 0x5a, 0xf2, 0xbb, 0x4e, 0xcf, 0x8e,
+	0x69, 0x5a, 0x1b, 0xa3, 0x69, 0x2a, 0xaa, 0xa1, 0x15, 0x1c, 0x49, 0x51, 0xd1, 0xb5, 0x71, 0x1e,
+	0xe6, 0x36, 0x7b, 0x6e, 0xeb, 0x09, 0xdd, 0x3c, 0xb2, 0x7c, 0x29, 0x5d, 0xb6, 0x36, 0x2e, 0x40,
+	0xe1, 0x61, 0xf3, 0x33, 0xbb, 0x15, 0x64, 0xee, 0xae, 0x80, 0xfe, 0xc8, 0xea, 0x66, 0x1a, 0xf1,
+	0xdf, 0x72, 0x50, 0xa2, 0xa6, 0xba, 0x37, 0xe8, 0xb8, 0xd3, 0xec, 0xf8, 0x3a, 0x2a, 0xcb, 0xb3,
+	0xad, 0xc0, 0x96, 0x36, 0x56, 0x4f, 0x09, 0xeb, 0x91, 0xf4, 0x59, 0x53, 0xa2, 0x22, 0x51, 0xa0,
+	0xaa, 0x6e, 0x34, 0x8f, 0x03, 0x9b, 0x6b, 0x39, 0x6f, 0x96, 0x29, 0x64, 0x93, 0x02, 0xc8, 0x15,
+	0x00, 0x3c, 0xfd, 0xd4, 0x1e, 0xa0, 0x9c, 0x6c, 0x54, 0xb4, 0x1e, 0xbf, 0x59, 0xd9, 0x24, 0x6f,
+	0x42, 0xa5, 0x6d, 0xfb, 0x2d, 0xcf, 0x19, 0x32, 0xcb, 0x9c, 0x63, 0xcf, 0x50, 0x41, 0xe4, 0x7d,
+	0x28, 0xf4, 0xac, 0xa6, 0xdd, 0xf3, 0x97, 0x0b, 0x8c, 0xd0, 0x4a, 0x48, 0x88, 0xbe, 0x6f, 0x6d,
+	0x9f, 0xed, 0xed, 0x0c, 0x02, 0xef, 0xd8, 0x14, 0x88, 0xf5, 0x9b, 0x50, 0x51, 0xc0, 0xd4, 0xb2,
+	0x9e, 0xd8, 0xc7, 0x42, 0x44, 0x74, 0x49, 0x96, 0x60, 0xee, 0xa9, 0xd5, 0x1b, 0x49, 0xbb, 0xe5,
+	0x1f, 0xb7, 0x72, 0xdf, 0xd2, 0x8c, 0x1b, 0x50, 0x96, 0xa4, 0x7d, 0xb2, 0x4a, 0x9d, 0x66, 0xe8,
+	0x36, 0x1c, 0xfc, 0x12, 0x96, 0x30, 0x1f, 0xbb, 0xdd, 0x2c, 0x79, 0x62, 0x65, 0xfc, 0x55, 0x07,
+	0xe0, 0x1a, 0x67, 0x62, 0x9f, 0xc9, 0x24, 0xde, 0x83, 0xf9, 0xa1, 0xe5, 0xa1, 0x81, 0x0b, 0x7b,
+	0xce, 0x72, 0xf3, 0x2a, 0xc7, 0x10, 0x51, 0x09, 0xd5, 0x85, 0xaa, 0xf0, 0xa8, 0xba, 0xf4, 0xe9,
+	0xea, 0x12, 0xa8, 0xe4, 0x03, 0x28, 0x75, 0x9c, 0x81, 0xe3, 0x1f, 0xe1, 0xb1, 0xfc, 0xd4, 0x63,
+	0x21, 0x6e, 0x42, 0xcd, 0x73, 0x49, 0x35, 0x5f, 0x8d, 0xa9, 0xb9, 0x90, 0x0e, 0x72, 0xaa, 0xa2,
+	0x31, 0x92, 0x05, 0x9e, 0x6d, 0x2f, 0x17, 0x95, 0x27, 0x72, 0xf3, 0x36, 0xd9, 0x06, 0x0d, 0x31,
+	0xd6, 0x28, 0x38, 0x42, 0xaf, 0x2d, 0xf1, 0x10, 0xc3, 0xbf, 0x30, 0x72, 0x55, 0x02, 0x74, 0x38,
+	0xdf, 0x6a, 0x31, 0x0b, 0x29, 0xb3, 0xf3, 0x35, 0x11, 0xe5, 0x42, 0xb8, 0xa9, 0x22, 0x61, 0x54,
+	0x9c, 0xc3, 0xb7, 0x60, 0x7c, 0x00, 0x05, 0x9b, 0x33, 0x75, 0x48, 0xe1, 0x26, 0xdf, 0x36, 0xfe,
+	0xa2, 0x41, 0x45, 0x01, 0x23, 0x93, 0x95, 0x0e, 0x3a, 0xb4, 0xdf, 0xb0, 0xda, 0x6d, 0x94, 0x15,
+	0x8f, 0x2e, 0xc0, 0x40, 0x77, 0x29, 0x04, 0xd5, 0x3a, 0xcf, 0x11, 0xda, 0x76, 0xcf, 0x96, 0x4e,
+	0xa3, 0x9b, 0x55, 0x06, 0xdc, 0xe6, 0x30, 0xf2, 0x0e, 0x2c, 0x70, 0xa4, 0xbe, 0xdb, 0x76, 0x3a,
+	0x8e, 0xd0, 0x95, 0x6e, 0xf2, 0xa3, 0x1f, 0x0b, 0x20, 0xb9, 0x0c, 0x35, 0x26, 0x5d, 0x24, 0x15,
+	0x58, 0x42, 0xc6, 0x79, 0x86, 0xb8, 0x40, 0xe1, 0xdb, 0x14, 0xcc, 0x04, 0x8d, 0x71, 0xa6, 0x12,
+	0x99, 0x96, 0x8f, 0x66, 0x53, 0xe1, 0xf6, 0xa2, 0x1a, 0xe6, 0xa2, 0xf2, 0x46, 0x66, 0x9a, 0xd0,
+	0x0a, 0xd7, 0xc6, 0x03, 0xa8, 0x28, 0xb2, 0x12, 0xc9, 0x4b, 0x4b, 0x26, 0xaf, 0x58, 0xe0, 0xcb,
+	0x4d, 0x0a, 0x7c, 0x9f, 0xc2, 0x02, 0x0d, 0x7c, 0x07, 0xaa, 0x1f, 0x17, 0x3e, 0x73, 0x9b, 0x8d,
+	0x90, 0x6c, 0x19, 0xc9, 0xce, 0xdd, 0x77, 0x9b, 0x48, 0x79, 0x0e, 0x37, 0xf6, 0x68, 0xa6, 0x2a,
+	0xb5, 0xad, 0x60, 0xd4, 0x6f, 0x84, 0x79, 0xb3, 0x82, 0x38, 0xc5, 0x6d, 0x0a, 0x43, 0xac, 0x22,
+	0xdb, 0xdc, 0x6b, 0x1b, 0xbf, 0xc0, 0xe8, 0x45, 0x89, 0xcb, 0xe8, 0x45, 0x85, 0x16, 0x8b, 0x5e,
+	0x74, 0xd3, 0x64, 0x60, 0xea, 0xa0, 0xf4, 0xff, 0x46, 0x70, 0x3c, 0xe4, 0xbe, 0xbc, 0x20, 0x1c,
+	0x94, 0xe2, 0x3c, 0x42, 0x20, 0x35, 0x66, 0xbe, 0x9a, 0x16, 0xb3, 0xea, 0x50, 0x6a, 0x1d, 0x39,
+	0xbd, 0x36, 0x3a, 0x1b, 0x33, 0x65, 0x9a, 0x9a, 0xc4, 0x37, 0x2a, 0xb4, 0xe8, 0x32, 0x53, 0xf5,
+	0xd1, 0x36, 0xf5, 0xa4, 0xf9, 0xca, 0xbd, 0x30, 0x4c, 0x53, 0x13, 0xaf, 0xf2, 0x30, 0x4d, 0x36,
+	0x62, 0x3e, 0x52, 0x66, 0xa7, 0xcf, 0x84, 0x2c, 0x46, 0x02, 0x54, 0x7d, 0x85, 0x06, 0x21, 0x29,
+	0x01, 0x3f, 0x7c, 0x63, 0x2a, 0x08, 0x49, 0x14, 0xfe, 0x46, 0xa6, 0x67, 0x3c, 0x48, 0x5f, 0x63,
+	0x5a, 0x83, 0xae, 0x4d, 0x83, 0x5c, 0xcf, 0x7d, 0x86, 0x85, 0x81, 0xc6, 0xde, 0xca, 0x3f, 0x28,
+	0x74, 0x44, 0x2b, 0x2c, 0x26, 0x2e, 0x84, 0xb2, 0x0f, 0xc3, 0xc4, 0xf4, 0x47, 0x13, 0x91, 0x69,
+	0x77, 0x50, 0x95, 0x73, 0x4d, 0xba, 0x16, 0x42, 0x07, 0x6e, 0x02, 0x6c, 0x97, 0x6f, 0x90, 0xb7,
+	0x61, 0xce, 0xa3, 0x57, 0x88, 0x78, 0xb5, 0xc0, 0x31, 0xe4, 0xc5, 0x26, 0xdf, 0x34, 0x7e, 0x04,
+	0xc0, 0x25, 0x24, 0x03, 0x22, 0x97, 0x53, 0x2c, 0x20, 0x0a, 0x11, 0x8a, 0x2d, 0xfa, 0x56, 0x76,
+	0x43, 0xc3, 0xb3, 0x3b, 0x82, 0xf8, 0xbc, 0x72, 0xbd, 0xdd, 0x41, 0x1b, 0x14, 0x2b, 0xe3, 0x3f,
+	0x1a, 0x9c, 0xde, 0x62, 0xf9, 0x88, 0x25, 0x15, 0xfb, 0x27, 0x23, 0x8c, 0x5f, 0xd3, 0xd2, 0x5d,
+	0x3c, 0x33, 0xe5, 0x4e, 0x90, 0x99, 0xf4, 0x74, 0x66, 0xba, 0x15, 0x66, 0x26, 0x9e, 0xe2, 0x0c,
+	0xee, 0x82, 0x49, 0x9e, 0xfe, 0xd7, 0x29, 0x6a, 0x03, 0xc8, 0xde, 0x80, 0x96, 0x50, 0xc1, 0xec,
+	0x0f, 0x37, 0x5a, 0xb0, 0xb8, 0xef, 0xf8, 0xb1, 0x13, 0x71, 0x59, 0x68, 0x93, 0x64, 0x81, 0x11,
+	0x8d, 0xf1, 0xdd, 0xf0, 0x31, 0xc4, 0xb5, 0x02, 0x51, 0x59, 0x95, 0xcd, 0x79, 0x06, 0x3d, 0x14,
+	0x40, 0xe3, 0xbb, 0x70, 0x9a, 0xc7, 0xc0, 0x13, 0x68, 0x04, 0xdf, 0x89, 0x95, 0x5a, 0x8b, 0xbf,
+	0xb3, 0x64, 0xf2, 0x0f, 0xe3, 0xdf, 0xa8, 0xdc, 0xc7, 0xc3, 0xf6, 0xc9, 0x94, 0x9b, 0xd0, 0x58,
+	0x6e, 0x92, 0xc6, 0x74, 0x45, 0x63, 0xa9, 0x8b, 0xb2, 0x34, 0x46, 0x43, 0xbf, 0x67, 0xf7, 0x51,
+	0x24, 0x0d, 0x45, 0xe9, 0x65, 0xb3, 0xca, 0x81, 0xfb, 0xaf, 0xac, 0xd6, 0x3f, 0x68, 0x40, 0x0e,
+	0x69, 0xc2, 0x16, 0xc9, 0x53, 0xbc, 0x19, 0xfd, 0x86, 0x57, 0x00, 0x99, 0x85, 0x04, 0xdf, 0x52,
+	0xca, 0x73, 0x3d, 0x56, 0x9e, 0x5f, 0xcd, 0x30, 0xf7, 0xb1, 0x19, 0x3a, 0xd4, 0x44, 0x5e, 0xd5,
+	0xc4, 0x9f, 0x90, 0xad, 0xcd, 0x11, 0x06, 0xc2, 0x57, 0x62, 0x2b, 0xff, 0xf2, 0x6c, 0xc9, 0xc2,
+	0x41, 0x1f, 0x53, 0x38, 0x60, 0xec, 0x3a, 0xb3, 0xcb, 0x2a, 0x96, 0x14, 0x87, 0xd3, 0x2b, 0x30,
+	0xe4, 0xf0, 0xa9, 0xed, 0x39, 0x9d, 0x63, 0x61, 0x7e, 0xe2, 0xcb, 0x78, 0x02, 0x4b, 0x2a, 0x4d,
+	0x5f, 0x12, 0x7d, 0x27, 0xea, 0x6d, 0x32, 0x9a, 0xba, 0xb0, 0xd1, 0xf9, 0x06, 0x54, 0x79, 0x81,
+	0xd0, 0x60, 0x29, 0x3f, 0x16, 0x68, 0x58, 0xfa, 0xaa, 0xf0, 0x6d, 0xba, 0xf6, 0x8d, 0xdb, 0xb0,
+	0x24, 0x1c, 0xfa, 0xe4, 0x2f, 0x30, 0x7e, 0x8b, 0x9e, 0x42, 0x3d, 0x3b, 0x7e, 0x74, 0x8a, 0xa7,
+	0xa0, 0x4c, 0x3b, 0x9e, 0xdb, 0xcf, 0x6c, 0x2b, 0xe9, 0x06, 0x76, 0x6a, 0xb9, 0xc0, 0x8d, 0x89,
+	0x5c, 0x6c, 0x23, 0x98, 0x0a, 0x6d, 0x30, 0xea, 0x37, 0x31, 0x87, 0xe4, 0x59, 0x0e, 0x11, 0x5f,
+	0xc6, 0x35, 0xce, 0x89, 0xa8, 0x16, 0x66, 0x8b, 0x4b, 0x0f, 0xa1, 0x76, 0x68, 0x27, 0x8e, 0xcc,
+	0xaa, 0x39, 0x61, 0x5b, 0x39, 0xd5, 0xb6, 0x8c, 0x7d, 0x38, 0xc3, 0x63, 0xd0, 0x49, 0xd8, 0x18,
+	0x4b, 0xed, 0x39, 0x9c, 0x0b, 0xd9, 0x93, 0x3d, 0xf5, 0x2b, 0x51, 0x9c, 0xb5, 0x61, 0x37, 0xbe,
+	0xd0, 0x60, 0x45, 0x91, 0x8c, 0x68, 0x83, 0x5f, 0xf1, 0xf2, 0xd8, 0x14, 0x40, 0x9f, 0x6d, 0x0a,
+	0x70, 0x4b, 0x0a, 0xf4, 0x25, 0x8c, 0xd3, 0x02, 0xb2, 0xdb, 0x1b, 0x25, 0x3d, 0x73, 0x46, 0x27,
+	0x7a, 0x1b, 0x4a, 0x81, 0xdb, 0xa0, 0xef, 0xf1, 0xd3, 0x99, 0xba, 0x18, 0xb8, 0xf4, 0x7f, 0x1f,
+	0x73, 0xce, 0x19, 0xe5, 0x8a, 0xd0, 0x51, 0xdf, 0x87, 0x62, 0x87, 0x82, 0xc3, 0x16, 0xfe, 0x1c,
+	0x77, 0xbe, 0x14, 0x37, 0xa6, 0xc4, 0x33, 0x7e, 0x8c, 0x3e, 0x1f, 0xa3, 0xe4, 0x0f, 0xdd, 0x81,
+	0xcf, 0xe2, 0xa2, 0x33, 0x68, 0xdb, 0xcf, 0x45, 0x3b, 0xc0, 0x3f, 0x92, 0x45, 0x38, 0xf7, 0xa4,
+	0x89, 0x45, 0xf8, 0x10, 0xce, 0x1e, 0x8e, 0x9a, 0x34, 0x19, 0x35, 0xed, 0x13, 0x79, 0xeb, 0x38,
+	0x6d, 0x4a, 0x2f, 0xd6, 0xc7, 0x78, 0xb1, 0xf1, 0x67, 0x0d, 0x16, 0xee, 0xd9, 0x01, 0x8b, 0x38,
+	0xd1, 0x55, 0x93, 0x0a, 0xea, 0x4b, 0x50, 0x75, 0x3b, 0x1d, 0xdf, 0x0e, 0x44, 0x99, 0xcc, 0xdb,
+	0x9b, 0x0a, 0x87, 0xf1, 0x42, 0x39, 0x5d, 0x47, 0xeb, 0x6a, 0x1d, 0xbd, 0x0e, 0x45, 0x8c, 0xfe,
+	0x78, 0x59, 0x20, 0x5a, 0xcd, 0xd7, 0xd8, 0x1d, 0x07, 0x1c, 0xc6, 0x4b, 0x05, 0x36, 0x79, 0x11,
+	0x58, 0xc6, 0xf7, 0xa0, 0x96, 0xdc, 0xe4, 0x23, 0xa4, 0xde, 0xa8, 0x3f, 0xe0, 0xda, 0x2b, 0x9b,
+	0xf2, 0x93, 0xde, 0xee, 0xb9, 0xcf, 0x1a, 0x5d, 0xcf, 0x1d, 0x0d, 0xb9, 0x59, 0xe0, 0xed, 0x08,
+	0xb9, 0xc7, 0x00, 0xc6, 0x0f, 0x61, 0x51, 0x3c, 0x38, 0xb4, 0x84, 0x8b, 0x98, 0xd6, 0x58, 0x10,
+	0xd6, 0x92, 0x41, 0x98, 0xc3, 0xc3, 0x3e, 0xac, 0xe7, 0x50, 0x6d, 0x46, 0xef, 0xce, 0xf3, 0x3e,
+	0x6c, 0x9f, 0x82, 0x79, 0x1f, 0x76, 0x00, 0x55, 0x7a, 0x70, 0xcb, 0x1d, 0x50, 0xe7, 0x48, 0x95,
+	0xe6, 0xda, 0x84, 0xd2, 0x3c, 0x9e, 0xf8, 0xab, 0x22, 0xf1, 0x1b, 0x36, 0x2c, 0x49, 0x05, 0xd1,
+	0x9a, 0x39, 0x64, 0xfa, 0x2a, 0x14, 0x58, 0x11, 0x2d, 0xb9, 0xe6, 0x2d, 0x43, 0x5c, 0x97, 0xa6,
+	0x40, 0xa1, 0x75, 0x0f, 0x0a, 0xd3, 0xea, 0xf5, 0xec, 0x9e, 0xe3, 0xf3, 0xa0, 0x3e, 0x6f, 0xaa,
+	0x20, 0x64, 0x7c, 0x21, 0xbc, 0x63, 0xeb, 0x68, 0x34, 0x78, 0x12, 0x37, 0xea, 0x79, 0x69, 0xd4,
+	0x99, 0x4c, 0xd2, 0xbe, 0xa6, 0xed, 0x0e, 0x78, 0x06, 0x2e, 0x99, 0x6c, 0x6d, 0x34, 0xe0, 0xb4,
+	0xe0, 0xe6, 0xb1, 0xb9, 0x3f, 0xa3, 0x71, 0x5d, 0x05, 0x3d, 0x08, 0x7a, 0xd3, 0x87, 0x72, 0x14,
+	0x0b, 0x35, 0x49, 0xd4, 0x0b, 0x84, 0x2f, 0x62, 0x41, 0x35, 0xf2, 0x7a, 0xb2, 0xa0, 0xc2, 0x25,
+	0x9d, 0x88, 0xd8, 0xcf, 0x87, 0x8e, 0x27, 0x94, 0x36, 0x65, 0x22, 0x22, 0x50, 0x8d, 0x5f, 0xe5,
+	0x60, 0xe1, 0x60, 0x74, 0x12, 0xcf, 0x08, 0x45, 0xa3, 0xab, 0xa2, 0x11, 0xfc, 0xcc, 0x45, 0xfc,
+	0x5c, 0xa0, 0x21, 0xb6, 0x35, 0xf2, 0x7c, 0xe7, 0x29, 0x9d, 0x89, 0x50, 0x89, 0x45, 0x00, 0x2c,
+	0x0c, 0xca, 0x98, 0xf9, 0xa9, 0x45, 0x61, 0xfc, 0x2f, 0xb2, 0x86, 0x95, 0x77, 0x4f, 0xdb, 0x12,
+	0x6a, 0x46, 0x08, 0x88, 0x4d, 0xb0, 0x20, 0xec, 0xa2, 0x37, 0x32, 0x33, 0x63, 0x1d, 0xb2, 0xcf,
+	0xc6, 0x23, 0xba, 0x59, 0xe3, 0x3b, 0x94, 0x43, 0xd6, 0x42, 0x53, 0x6b, 0x3c, 0xad, 0x62, 0x73,
+	0x43, 0x2e, 0x33, 0xe4, 0xc5, 0x08, 0x99, 0x59, 0xf2, 0xfd, 0x7c, 0x29, 0x57, 0xd3, 0x95, 0x4e,
+	0x62, 0x76, 0x41, 0x50, 0x17, 0xa3, 0x59, 0xfe, 0x04, 0xa2, 0x23, 0x4a, 0xb5, 0x51, 0x16, 0x05,
+	0x46, 0x54, 0x43, 0xe8, 0xb1, 0x1a, 0xe2, 0x00, 0x1d, 0xb8, 0xe7, 0x36, 0x55, 0xea, 0x33, 0x95,
+	0x03, 0xcb, 0x34, 0xec, 0x04, 0x28, 0x34, 0x59, 0xf7, 0xcb, 0x4f, 0x5a, 0x95, 0x6c, 0x87, 0xc5,
+	0xd6, 0x8c, 0x6f, 0x74, 0x80, 0x44, 0x67, 0xfc, 0x13, 0x31, 0x82, 0x76, 0x42, 0x47, 0xbb, 0x3c,
+	0x36, 0x61, 0x81, 0xcf, 0x3e, 0x54, 0xf6, 0xf4, 0x38, 0x7b, 0xbb, 0x18, 0xfe, 0x46, 0x81, 0x28,
+	0x68, 0xc5, 0x45, 0xa1, 0xad, 0x69, 0xaa, 0xad, 0x5d, 0xc0, 0x42, 0xd8, 0xea, 0xca, 0x5c, 0x58,
+	0xe2, 0x65, 0x83, 0xd5, 0x35, 0x19, 0xd4, 0xf8, 0x39, 0x73, 0x48, 0x4e, 0x47, 0x2d, 0x57, 0xe5,
+	0xe0, 0x42, 0x9b, 0x30, 0xb8, 0xc8, 0x8a, 0xfa, 0xf9, 0x69, 0x51, 0x5f, 0x9d, 0x9e, 0x18, 0x8f,
+	0xa1, 0x86, 0xac, 0xc4, 0x5f, 0x31, 0x53, 0xc7, 0x3f, 0xf9, 0x51, 0x37, 0x81, 0x6c, 0x1d, 0xd9,
+	0xad, 0x27, 0x27, 0x27, 0x6c, 0xbc, 0x0b, 0x67, 0x62, 0x47, 0x45, 0x00, 0x41, 0xbb, 0xb3, 0x9f,
+	0xa3, 0xf9, 0xf2, 0x9f, 0x0e, 0xb0, 0xe0, 0xe7, 0x5f, 0xc6, 0x6f, 0x72, 0x50, 0x91, 0xd3, 0x0a,
+	0x1a, 0x09, 0x6f, 0x24, 0x25, 0xf7, 0xba, 0x72, 0x09, 0x43, 0x11, 0x6b, 0xd1, 0x25, 0x86, 0xb2,
+	0x5c, 0x8b, 0x3d, 0xa8, 0x9e, 0x3a, 0x85, 0x8f, 0x13, 0x47, 0x18, 0x5e, 0x7d, 0x0f, 0xaa, 0x2a,
+	0xa1, 0x8c, 0x96, 0xf1, 0x2d, 0x35, 0x28, 0xa7, 0x06, 0x22, 0x51, 0x07, 0x59, 0xdf, 0x86, 0x72,
+	0x48, 0x3d, 0x83, 0xce, 0xa5, 0x38, 0x9d, 0x98, 0xd4, 0x22, 0x2a, 0xab, 0x57, 0xf9, 0xf8, 0x8d,
+	0xcd, 0xcc, 0xaa, 0x50, 0x32, 0x77, 0x0e, 0x77, 0xcc, 0x4f, 0x76, 0xb6, 0x6b, 0xa7, 0x48, 0x09,
+	0xf2, 0xbb, 0x7b, 0xfb, 0x3b, 0x35, 0x8d, 0x14, 0x41, 0xdf, 0xde, 0x33, 0x6b, 0xb9, 0xd5, 0x2b,
+	0x50, 0x0e, 0x23, 0x17, 0xdd, 0x7f, 0xf0, 0xf0, 0xc1, 0x0e, 0xc7, 0xbc, 0x7f, 0xf8, 0xf0, 0x01,
+	0x62, 0xe2, 0x6a, 0x7f, 0x0f, 0x61, 0xb9, 0xd5, 0x7d, 0xa8, 0xca, 0xb8, 0xf1, 0xb1, 0xdb, 0xb6,
+	0xc9, 0x99, 0x28, 0x8e, 0x34, 0x1e, 0x3c, 0x34, 0x3f, 0xbe, 0xbb, 0x8f, 0x07, 0x4f, 0xc3, 0x7c,
+	0x08, 0xdc, 0xbd, 0x7b, 0xf8, 0x08, 0x29, 0x2c, 0x41, 0x2d, 0x04, 0x99, 0x3b, 0x5b, 0x8f, 0xcd,
+	0x43, 0xa4, 0x76, 0xed, 0x8f, 0xe7, 0x40, 0xbf, 0x7b, 0xb0, 0x47, 0x3e, 0x01, 0x88, 0x06, 0x2e,
+	0xe4, 0x6c, 0xf6, 0x04, 0xa6, 0x7e, 0x36, 0x95, 0x13, 0x76, 0xe8, 0xaf, 0x94, 0xc6, 0xf2, 0x2f,
+	0xff, 0xfe, 0xaf, 0xdf, 0xe7, 0x88, 0x31, 0xbf, 0xfe, 0xf4, 0x7d, 0xf6, 0xe3, 0x26, 0xab, 0x36,
+	0x6f, 0x69, 0xab, 0xe4, 0xfb, 0x50, 0x51, 0x86, 0x2c, 0x84, 0x57, 0x8f, 0xe9, 0xb1, 0x4b, 0x3d,
+	0xfe, 0x7b, 0x80, 0x71, 0x89, 0x11, 0x3c, 0x4f, 0x56, 0x62, 0x04, 0xd7, 0x7f, 0x46, 0xff, 0x5b,
+	0xa3, 0x3f, 0xce, 0xbc, 0x20, 0xf7, 0xa0, 0x24, 0x27, 0x31, 0x84, 0x17, 0xdf, 0x89, 0xc1, 0x4c,
+	0x7d, 0x21, 0x46, 0xd3, 0x37, 0x5e, 0x63, 0x44, 0x17, 0x49, 0x9c, 0x4b, 0xd2, 0x00, 0x88, 0xa6,
+	0x2d, 0xe2, 0xe9, 0xa9, 0xf1, 0xcb, 0xd8, 0xa7, 0x0b, 0x4e, 0x57, 0x27, 0x70, 0xfa, 0x6d, 0x80,
+	0x68, 0x34, 0x22, 0x2e, 0x48, 0xcd, 0x4a, 0xc6, 0x5e, 0x70, 0x8a, 0x1c, 0x41, 0x45, 0x19, 0x68,
+	0x08, 0x19, 0xa6, 0x47, 0x1c, 0x75, 0x35, 0x8e, 0x1a, 0x1b, 0x8c, 0xaf, 0x77, 0x8d, 0xcb, 0x09,
+	0xbe, 0xf8, 0x48, 0x61, 0x2d, 0x62, 0x6f, 0x5d, 0xf4, 0x09, 0x54, 0x5b, 0xbf, 0xd6, 0x68, 0x65,
+	0x16, 0xf5, 0xeb, 0x64, 0x59, 0x44, 0xf4, 0xd4, 0x58, 0x60, 0x2c, 0xbb, 0x5b, 0xec, 0xde, 0x8f,
+	0x8c, 0xdb, 0x89, 0x7b, 0xf9, 0x2d, 0x19, 0xf7, 0x86, 0x5b, 0x4e, 0xfb, 0xc5, 0x3a, 0xff, 0xc9,
+	0x04, 0x25, 0x36, 0x1f, 0x1b, 0x1b, 0x90, 0x95, 0x14, 0x1f, 0x32, 0x36, 0xd7, 0x53, 0x3f, 0x61,
+	0xa0, 0xc4, 0x8e, 0x61, 0x3e, 0x36, 0x09, 0x10, 0xe7, 0xb3, 0xa6, 0x03, 0xf5, 0x64, 0xaf, 0x61,
+	0x7c, 0xc8, 0x5e, 0xf0, 0x01, 0xb9, 0xfe, 0x32, 0x2f, 0x20, 0x16, 0x40, 0x34, 0x46, 0x10, 0xca,
+	0x4e, 0xcd, 0x15, 0xea, 0xb5, 0xc4, 0xa5, 0xbe, 0x71, 0x85, 0xdd, 0xfa, 0x16, 0xb9, 0x34, 0xd6,
+	0x8e, 0xe4, 0x75, 0xe4, 0x0e, 0xf7, 0x64, 0xf9, 0x83, 0x0b, 0x7a, 0x69, 0x7f, 0xec, 0x45, 0xa9,
+	0xd7, 0x9d, 0x7a, 0x4f, 0x23, 0x9f, 0x43, 0x55, 0x6d, 0x45, 0x85, 0x96, 0x33, 0xba, 0xd3, 0xb1,
+	0x5a, 0x16, 0x32, 0x5a, 0x7d, 0x39, 0x19, 0xdd, 0x86, 0x8a, 0xd2, 0x21, 0x92, 0x71, 0x2d, 0x65,
+	0x36, 0xf3, 0xf7, 0xd0, 0x44, 0x95, 0xf6, 0x52, 0x9a, 0x68, 0xba, 0x77, 0xad, 0xaf, 0x64, 0xec,
+	0xf0, 0xf4, 0xc5, 0x08, 0x6d, 0xc1, 0x62, 0xa2, 0x8f, 0x24, 0xe7, 0xb9, 0x6b, 0x65, 0x76, 0x97,
+	0xd9, 0xdc, 0x7c, 0x13, 0x2a, 0xca, 0x54, 0x4f, 0x3c, 0x25, 0x3d, 0xe7, 0x8b, 0xfb, 0xe6, 0x29,
+	0x1a, 0x73, 0xa2, 0x11, 0x8f, 0xa2, 0xbc, 0xd8, 0xb0, 0x45, 0x04, 0x45, 0xf9, 0x7b, 0xba, 0xb1,
+	0xca, 0x84, 0xfe, 0x36, 0x31, 0xc6, 0x9b, 0x88, 0xfc, 0x65, 0x89, 0x7c, 0x08, 0xe5, 0x70, 0xea,
+	0x41, 0x78, 0xeb, 0x98, 0x9c, 0x0f, 0x4d, 0x88, 0x38, 0x9b, 0xd2, 0x40, 0x04, 0x01, 0xd5, 0x40,
+	0x66, 0xa5, 0x71, 0x5f, 0x99, 0x48, 0xc9, 0x3f, 0x7f, 0xb8, 0x10, 0x67, 0x24, 0x3e, 0x09, 0x9a,
+	0x40, 0xeb, 0x01, 0x90, 0xf4, 0x0c, 0x87, 0xbc, 0x91, 0x7c, 0x56, 0x7c, 0xb8, 0x33, 0x81, 0xde,
+	0x2d, 0x28, 0x8a, 0xae, 0x85, 0xf0, 0x8e, 0x30, 0xde, 0xc3, 0x8c, 0x3f, 0x79, 0x59, 0x43, 0xef,
+	0xab, 0x0a, 0xec, 0x4d, 0x2b, 0x40, 0xd9, 0xbc, 0x04, 0x81, 0xa2, 0xe8, 0xc8, 0x48, 0x56, 0x3b,
+	0x5a, 0x3f, 0x9f, 0x3a, 0xcb, 0x6a, 0xc3, 0x4f, 0x58, 0xa3, 0x4b, 0x6d, 0xee, 0x06, 0x94, 0x64,
+	0x73, 0x2e, 0x32, 0x5f, 0xa2, 0x57, 0xaf, 0x9f, 0x0e, 0x0b, 0x71, 0xd9, 0x63, 0x0b, 0x8b, 0x9f,
+	0x8f, 0x75, 0xc9, 0x22, 0x2c, 0x66, 0x75, 0xce, 0xf5, 0xe8, 0xc7, 0xb5, 0xa8, 0xdb, 0x65, 0x44,
+	0xee, 0x00, 0x44, 0x0d, 0xa5, 0x30, 0xdd, 0x54, 0x0b, 0x5b, 0x3f, 0x97, 0x82, 0x4b, 0xcf, 0x23,
+	0x5f, 0x6a, 0x61, 0x4d, 0xc0, 0x84, 0x10, 0xab, 0x09, 0x54, 0x41, 0xc4, 0x67, 0x00, 0xc6, 0x0f,
+	0x98, 0xf9, 0x3f, 0x26, 0x87, 0x09, 0xf3, 0xa7, 0x9d, 0xc6, 0xda, 0x84, 0xc0, 0xa3, 0xee, 0xf3,
+	0x1c, 0x83, 0x92, 0x12, 0x60, 0xda, 0x55, 0x7c, 0xb4, 0xba, 0xfa, 0x82, 0xfc, 0x4e, 0xe3, 0xe5,
+	0x04, 0xe3, 0x28, 0x2a, 0x27, 0x54, 0x76, 0x16, 0x62, 0xec, 0xf8, 0xc6, 0xa7, 0x8c, 0x9f, 0x47,
+	0xc4, 0x7c, 0x45, 0x7e, 0xe8, 0xf0, 0x3d, 0xc9, 0xce, 0x4d, 0x58, 0x90, 0xd7, 0x8b, 0x00, 0x9f,
+	0xcd, 0x53, 0x42, 0x44, 0x54, 0x3f, 0x3e, 0x5a, 0x87, 0xe8, 0xfc, 0xa4, 0x75, 0xc4, 0x1b, 0xc1,
+	0xd4, 0x43, 0xee, 0xb2, 0x87, 0xdc, 0x26, 0x37, 0x5f, 0x2a, 0x65, 0x77, 0x91, 0x3a, 0xe5, 0x57,
+	0xde, 0x12, 0xe3, 0x37, 0x79, 0x75, 0x06, 0xbf, 0x5f, 0x69, 0xb2, 0xfe, 0x62, 0x2c, 0xab, 0xf5,
+	0xd7, 0x2c, 0x1e, 0x25, 0xac, 0x62, 0xf5, 0xff, 0x62, 0x15, 0xdf, 0x81, 0x8a, 0xd2, 0xbf, 0x0a,
+	0x4b, 0x4d, 0x77, 0xb4, 0x13, 0x22, 0xcd, 0x47, 0xac, 0xb0, 0x47, 0xfc, 0xbb, 0xbd, 0x1e, 0x19,
+	0x83, 0x36, 0xfe, 0xf8, 0xb5, 0x2f, 0xf3, 0x50, 0xe6, 0xad, 0x05, 0x2d, 0xd2, 0x37, 0xa0, 0x1c,
+	0xf6, 0xb8, 0x22, 0xa8, 0x27, 0x7b, 0xde, 0xba, 0xda, 0x8e, 0xb0, 0x70, 0x73, 0x13, 0xca, 0x61,
+	0x43, 0x4b, 0xd4, 0xdd, 0xe9, 0x81, 0x66, 0x87, 0xb9, 0xba, 0x68, 0xab, 0x22, 0x57, 0x8f, 0x37,
+	0xc7, 0xd3, 0xc9, 0x7c, 0xc8, 0xfa, 0xa9, 0x18, 0xdb, 0xc9, 0x26, 0x77, 0x82, 0x04, 0xd7, 0xc3,
+	0x5a, 0x2e, 0xeb, 0x0d, 0x8b, 0xb1, 0xc6, 0x90, 0x9a, 0x14, 0x26, 0xaf, 0x8a, 0xd2, 0xb1, 0x0a,
+	0xa5, 0xa5, 0xdb, 0xdf, 0xfa, 0x72, 0x7a, 0x23, 0x8c, 0x51, 0x1b, 0x50, 0xc0, 0x87, 0xd2, 0x3f,
+	0x0c, 0x0b, 0x5b, 0xe9, 0xe9, 0xef, 0xbc, 0x02, 0x20, 0x38, 0x8d, 0x1f, 0xcc, 0xe0, 0xf1, 0x36,
+	0xfb, 0xf3, 0xc6, 0x21, 0x16, 0xac, 0x27, 0x37, 0x8a, 0x66, 0x81, 0x41, 0x36, 0xfe, 0x0b, 0x60,
+	0x6a, 0x3c, 0x49, 0x4e, 0x2a, 0x00, 0x00,
 }
@@ -37,7 +37,7 @@ func Example_pfs() {
 	// buffer now contains "foo\n"
 
 	// Start another commit with the previous commit as the parent.
-	commit2, err := c.StartCommit("repo", "master")
+	_, err = c.StartCommit("repo", "master")
 	if err != nil {
 		return //handle error
 	}
@@ -45,3 +45,29 @@ func createDefaults() (*Config, error) {
 	}
 	return c, nil
 }
+
+// Write writes c to the user's config file, replacing its previous contents.
+func Write(c *Config) error {
+	rawConfig, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(configDirPath, 0755); err != nil {
+		return err
+	}
+	// the config may contain auth tokens, so it's only readable by the user
+	return ioutil.WriteFile(configPath, rawConfig, 0600)
+}
+
+// CurrentContext returns the context named by c.ActiveContext, or nil if
+// no context is active. It may be called on a nil Config.
+func (c *Config) CurrentContext() (*Context, error) {
+	if c == nil || c.ActiveContext == "" {
+		return nil, nil
+	}
+	context, ok := c.Contexts[c.ActiveContext]
+	if !ok {
+		return nil, fmt.Errorf("active context %q doesn't exist", c.ActiveContext)
+	}
+	return context, nil
+}
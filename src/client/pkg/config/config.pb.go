@@ -10,6 +10,7 @@ It is generated from these files:
 
 It has these top-level messages:
 	Config
+	Context
 */
 package config
 
@@ -31,6 +32,10 @@ const _ = proto.GoGoProtoPackageIsVersion2 // please upgrade the proto package
 
 type Config struct {
 	UserID string `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// active_context is the name of the context, in contexts, that pachctl
+	// connects to.
+	ActiveContext string              `protobuf:"bytes,2,opt,name=active_context,json=activeContext,proto3" json:"active_context,omitempty"`
+	Contexts      map[string]*Context `protobuf:"bytes,3,rep,name=contexts" json:"contexts,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value"`
 }
 
 func (m *Config) Reset()                    { *m = Config{} }
@@ -45,19 +50,104 @@ func (m *Config) GetUserID() string {
 	return ""
 }
 
+func (m *Config) GetActiveContext() string {
+	if m != nil {
+		return m.ActiveContext
+	}
+	return ""
+}
+
+func (m *Config) GetContexts() map[string]*Context {
+	if m != nil {
+		return m.Contexts
+	}
+	return nil
+}
+
+// Context describes how to connect to a Pachyderm cluster.
+type Context struct {
+	// pachd_address is the host:port of pachd.
+	PachdAddress string `protobuf:"bytes,1,opt,name=pachd_address,json=pachdAddress,proto3" json:"pachd_address,omitempty"`
+	// server_cas is the path of a PEM file containing the certificates of the
+	// CAs which signed pachd's certificate. If it's empty TLS isn't used.
+	ServerCAs string `protobuf:"bytes,2,opt,name=server_cas,json=serverCas,proto3" json:"server_cas,omitempty"`
+	// auth_token is sent to pachd with every request.
+	AuthToken string `protobuf:"bytes,3,opt,name=auth_token,json=authToken,proto3" json:"auth_token,omitempty"`
+	// namespace is the kubernetes namespace that Pachyderm is deployed in.
+	Namespace string `protobuf:"bytes,4,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	// compress makes pachctl ask pachd to compress the responses of RPCs
+	// which return lots of metadata, such as ListFile and ListJob. It's
+	// worth setting for clusters that are reached over slow links.
+	Compress bool `protobuf:"varint,5,opt,name=compress,proto3" json:"compress,omitempty"`
+}
+
+func (m *Context) Reset()                    { *m = Context{} }
+func (m *Context) String() string            { return proto.CompactTextString(m) }
+func (*Context) ProtoMessage()               {}
+func (*Context) Descriptor() ([]byte, []int) { return fileDescriptorConfig, []int{1} }
+
+func (m *Context) GetPachdAddress() string {
+	if m != nil {
+		return m.PachdAddress
+	}
+	return ""
+}
+
+func (m *Context) GetServerCAs() string {
+	if m != nil {
+		return m.ServerCAs
+	}
+	return ""
+}
+
+func (m *Context) GetAuthToken() string {
+	if m != nil {
+		return m.AuthToken
+	}
+	return ""
+}
+
+func (m *Context) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *Context) GetCompress() bool {
+	if m != nil {
+		return m.Compress
+	}
+	return false
+}
+
 func init() {
 	proto.RegisterType((*Config)(nil), "Config")
+	proto.RegisterType((*Context)(nil), "Context")
 }
 
 func init() { proto.RegisterFile("client/pkg/config/config.proto", fileDescriptorConfig) }
 
 var fileDescriptorConfig = []byte{
-	// 109 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xe2, 0x92, 0x4b, 0xce, 0xc9, 0x4c,
-	0xcd, 0x2b, 0xd1, 0x2f, 0xc8, 0x4e, 0xd7, 0x4f, 0xce, 0xcf, 0x4b, 0xcb, 0x84, 0x51, 0x7a, 0x05,
-	0x45, 0xf9, 0x25, 0xf9, 0x52, 0x22, 0xe9, 0xf9, 0xe9, 0xf9, 0x60, 0xa6, 0x3e, 0x88, 0x05, 0x11,
-	0x55, 0xd2, 0xe5, 0x62, 0x73, 0x06, 0xab, 0x12, 0x52, 0xe6, 0x62, 0x2f, 0x2d, 0x4e, 0x2d, 0x8a,
-	0xcf, 0x4c, 0x91, 0x60, 0x54, 0x60, 0xd4, 0xe0, 0x74, 0xe2, 0x7a, 0x74, 0x4f, 0x9e, 0x2d, 0xb4,
-	0x38, 0xb5, 0xc8, 0xd3, 0x25, 0x88, 0x0d, 0x24, 0xe5, 0x99, 0x92, 0xc4, 0x06, 0xd6, 0x65, 0x0c,
-	0x08, 0x00, 0x00, 0xff, 0xff, 0x9c, 0x04, 0xa5, 0x4c, 0x6d, 0x00, 0x00, 0x00,
+	// 316 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x55, 0x91, 0xcd, 0x4a, 0xc3, 0x40,
+	0x14, 0x85, 0x49, 0x6b, 0xd3, 0xe6, 0xd6, 0x88, 0x0c, 0x0a, 0x21, 0x68, 0x2d, 0x2d, 0x42, 0x17,
+	0x92, 0x60, 0xdd, 0x88, 0xbb, 0x36, 0x76, 0xd1, 0xed, 0xa8, 0xeb, 0x30, 0x26, 0x63, 0x1b, 0xda,
+	0x26, 0x21, 0x33, 0x09, 0xf6, 0xc1, 0x7c, 0x0d, 0x1f, 0xc1, 0x85, 0x4f, 0xe2, 0xfc, 0x24, 0x01,
+	0x57, 0x73, 0xe7, 0x3b, 0x87, 0x7b, 0xef, 0x99, 0x81, 0x51, 0xb4, 0x4f, 0x68, 0xca, 0xfd, 0x7c,
+	0xb7, 0xf1, 0xa3, 0x2c, 0xfd, 0x48, 0x9a, 0xc3, 0xcb, 0x8b, 0x8c, 0x67, 0xee, 0xc5, 0x26, 0xdb,
+	0x64, 0xaa, 0xf4, 0x65, 0xa5, 0xe9, 0xe4, 0xdb, 0x00, 0x33, 0x50, 0x36, 0x34, 0x85, 0x7e, 0xc9,
+	0x68, 0x11, 0x26, 0xb1, 0x63, 0x8c, 0x8d, 0x99, 0xb5, 0x84, 0xdf, 0x9f, 0x1b, 0xf3, 0x4d, 0xa0,
+	0xf5, 0x33, 0x36, 0xa5, 0xb4, 0x8e, 0xd1, 0x2d, 0x9c, 0x91, 0x88, 0x27, 0x15, 0x0d, 0x45, 0x73,
+	0x4e, 0x3f, 0xb9, 0xd3, 0x91, 0x5e, 0x6c, 0x6b, 0x1a, 0x68, 0x88, 0xee, 0x61, 0x50, 0xeb, 0xcc,
+	0xe9, 0x8e, 0xbb, 0xb3, 0xe1, 0xfc, 0xd2, 0xd3, 0x63, 0xbc, 0xda, 0xc2, 0x56, 0x29, 0x2f, 0x8e,
+	0xb8, 0xb5, 0xb9, 0x2b, 0xb0, 0xff, 0x49, 0xe8, 0x1c, 0xba, 0x3b, 0x7a, 0xd4, 0xbb, 0x60, 0x59,
+	0xa2, 0x11, 0xf4, 0x2a, 0xb2, 0x2f, 0xa9, 0x9a, 0x39, 0x9c, 0x0f, 0x9a, 0x5e, 0x58, 0xe3, 0xa7,
+	0xce, 0xa3, 0x31, 0xf9, 0x32, 0xa0, 0xdf, 0x6c, 0x31, 0x05, 0x3b, 0x27, 0xd1, 0x36, 0x0e, 0x49,
+	0x1c, 0x17, 0x94, 0xb1, 0xba, 0xd7, 0xa9, 0x82, 0x0b, 0xcd, 0xd0, 0x1d, 0x80, 0x88, 0x56, 0x89,
+	0xe0, 0x11, 0x61, 0x3a, 0xcd, 0xd2, 0x16, 0xc9, 0xad, 0x17, 0x45, 0x83, 0x05, 0xc3, 0x96, 0x36,
+	0x04, 0x84, 0xa1, 0x6b, 0x00, 0x52, 0xf2, 0x6d, 0xc8, 0xb3, 0x1d, 0x4d, 0x45, 0x34, 0xd9, 0xcf,
+	0x92, 0xe4, 0x55, 0x02, 0x74, 0x05, 0x56, 0x4a, 0x0e, 0x94, 0x89, 0x09, 0xd4, 0x39, 0xd1, 0x6a,
+	0x0b, 0x90, 0x2b, 0x5f, 0xe5, 0x90, 0xab, 0x55, 0x7a, 0x42, 0x1c, 0xe0, 0xf6, 0xfe, 0x6e, 0xaa,
+	0xff, 0x78, 0xf8, 0x03, 0xbf, 0xca, 0x4f, 0x01, 0xc7, 0x01, 0x00, 0x00,
 }
@@ -1,6 +1,7 @@
 package discovery
 
 import (
+	"crypto/tls"
 	"fmt"
 )
 
@@ -44,3 +45,10 @@ type Client interface {
 func NewEtcdClient(addresses ...string) Client {
 	return newEtcdClient(addresses...)
 }
+
+// NewEtcdClientWithAuth creates an etcdClient with the given addresses, which
+// connects to them over TLS with tlsConfig if it's non-nil, and authenticates
+// as username if it's non-empty.
+func NewEtcdClientWithAuth(tlsConfig *tls.Config, username string, password string, addresses ...string) Client {
+	return newEtcdClientWithAuth(tlsConfig, username, password, addresses...)
+}
@@ -1,6 +1,8 @@
 package discovery
 
 import (
+	"crypto/tls"
+	"net/http"
 	"strings"
 
 	"github.com/coreos/go-etcd/etcd"
@@ -14,6 +16,20 @@ func newEtcdClient(addresses ...string) *etcdClient {
 	return &etcdClient{etcd.NewClient(addresses)}
 }
 
+func newEtcdClientWithAuth(tlsConfig *tls.Config, username string, password string, addresses ...string) *etcdClient {
+	client := etcd.NewClient(addresses)
+	if tlsConfig != nil {
+		client.SetTransport(&http.Transport{
+			Dial:            client.DefaultDial,
+			TLSClientConfig: tlsConfig,
+		})
+	}
+	if username != "" {
+		client.SetCredentials(username, password)
+	}
+	return &etcdClient{client}
+}
+
 func (c *etcdClient) Close() error {
 	c.client.Close()
 	return nil
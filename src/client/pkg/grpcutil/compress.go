@@ -0,0 +1,133 @@
+package grpcutil
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// acceptEncodingKey is the metadata key with which a client asks the server
+// to compress the responses to a request. grpc's own compression can't be
+// used for this, as the version we use compresses all of a server's
+// responses or none of them, which would break clients that can't
+// decompress them.
+const acceptEncodingKey = "pach-accept-encoding"
+
+const gzipEncoding = "gzip"
+
+// Codec is grpc's default codec, which marshals messages with proto, except
+// that it gzips the responses to requests made with WithCompression, and
+// ungzips them on the client's side. Compressed messages are told apart from
+// uncompressed ones by their gzip header, as serialized protobufs can't start
+// with it (its first byte would be a field with wire type 7, which doesn't
+// exist). Servers should use it with grpc.CustomCodec, as Serve does, and
+// clients that ask for compressed responses with grpc.WithCodec.
+var Codec grpc.Codec = codec{}
+
+type codec struct{}
+
+// gzipMessage wraps a message that Codec gzips when it marshals it.
+type gzipMessage struct {
+	msg interface{}
+}
+
+func (codec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(*gzipMessage)
+	if !ok {
+		return proto.Marshal(v.(proto.Message))
+	}
+	data, err := proto.Marshal(m.msg.(proto.Message))
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	w, err := gzip.NewWriterLevel(&buf, gzip.BestSpeed)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (codec) Unmarshal(data []byte, v interface{}) error {
+	if len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b {
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		if data, err = ioutil.ReadAll(r); err != nil {
+			return err
+		}
+	}
+	return proto.Unmarshal(data, v.(proto.Message))
+}
+
+func (codec) String() string {
+	return "proto"
+}
+
+// WithCompression returns a context which asks the server to compress the
+// responses to requests made with it. Servers which don't support
+// compression send uncompressed responses instead, which Codec also accepts.
+func WithCompression(ctx context.Context) context.Context {
+	md, ok := metadata.FromContext(ctx)
+	if ok {
+		md = md.Copy()
+	} else {
+		md = metadata.MD{}
+	}
+	md[acceptEncodingKey] = []string{gzipEncoding}
+	return metadata.NewContext(ctx, md)
+}
+
+// wantsCompression returns true if ctx, a request's context, asks for the
+// request's responses to be compressed.
+func wantsCompression(ctx context.Context) bool {
+	md, ok := metadata.FromContext(ctx)
+	if !ok {
+		return false
+	}
+	for _, encoding := range md[acceptEncodingKey] {
+		if encoding == gzipEncoding {
+			return true
+		}
+	}
+	return false
+}
+
+// CompressUnaryInterceptor compresses the responses to unary requests made
+// with WithCompression. It must be used with Codec.
+func CompressUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	if err != nil || resp == nil || !wantsCompression(ctx) {
+		return resp, err
+	}
+	return &gzipMessage{resp}, nil
+}
+
+// CompressStreamInterceptor is CompressUnaryInterceptor for streaming
+// methods.
+func CompressStreamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if !wantsCompression(stream.Context()) {
+		return handler(srv, stream)
+	}
+	return handler(srv, gzipServerStream{stream})
+}
+
+type gzipServerStream struct {
+	grpc.ServerStream
+}
+
+func (s gzipServerStream) SendMsg(m interface{}) error {
+	return s.ServerStream.SendMsg(&gzipMessage{m})
+}
@@ -0,0 +1,68 @@
+package grpcutil
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	"github.com/pachyderm/pachyderm/src/client/version"
+	"github.com/pachyderm/pachyderm/src/client/version/versionpb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// gzipRecordingCodec is Codec, but it records whether the last message it
+// unmarshalled was gzipped.
+type gzipRecordingCodec struct {
+	gzipped *bool
+}
+
+func (c gzipRecordingCodec) Marshal(v interface{}) ([]byte, error) {
+	return Codec.Marshal(v)
+}
+
+func (c gzipRecordingCodec) Unmarshal(data []byte, v interface{}) error {
+	*c.gzipped = len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b
+	return Codec.Unmarshal(data, v)
+}
+
+func (c gzipRecordingCodec) String() string {
+	return Codec.String()
+}
+
+func TestCompression(t *testing.T) {
+	grpcServer := grpc.NewServer(grpc.CustomCodec(Codec), grpc.UnaryInterceptor(CompressUnaryInterceptor))
+	versionpb.RegisterAPIServer(grpcServer, version.NewAPIServer(&versionpb.Version{Major: 1}, version.APIServerOptions{}))
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go grpcServer.Serve(listener)
+	defer grpcServer.Stop()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// the response is compressed if the client asks for it
+	var gzipped bool
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithInsecure(), grpc.WithCodec(gzipRecordingCodec{&gzipped}))
+	require.NoError(t, err)
+	defer conn.Close()
+	v, err := versionpb.NewAPIClient(conn).GetVersion(WithCompression(ctx), &types.Empty{})
+	require.NoError(t, err)
+	require.Equal(t, uint32(1), v.Major)
+	require.True(t, gzipped)
+
+	v, err = versionpb.NewAPIClient(conn).GetVersion(ctx, &types.Empty{})
+	require.NoError(t, err)
+	require.Equal(t, uint32(1), v.Major)
+	require.False(t, gzipped)
+
+	// clients that don't use Codec, and so can't decompress responses, can
+	// still talk to the server
+	plainConn, err := grpc.Dial(listener.Addr().String(), grpc.WithInsecure())
+	require.NoError(t, err)
+	defer plainConn.Close()
+	v, err = versionpb.NewAPIClient(plainConn).GetVersion(ctx, &types.Empty{})
+	require.NoError(t, err)
+	require.Equal(t, uint32(1), v.Major)
+}
@@ -9,7 +9,9 @@ import (
 	"github.com/pachyderm/pachyderm/src/client/version"
 	"github.com/pachyderm/pachyderm/src/client/version/versionpb"
 
+	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 var (
@@ -21,6 +23,13 @@ var (
 type ServeOptions struct {
 	Version    *versionpb.Version
 	MaxMsgSize int
+	// UnaryInterceptor and StreamInterceptor, if they're set, are called
+	// for every request the server receives, e.g. to authenticate it.
+	UnaryInterceptor  grpc.UnaryServerInterceptor
+	StreamInterceptor grpc.StreamServerInterceptor
+	// Creds, if they're set, secure the server's connections, e.g. with
+	// TLS (see LoadTLS).
+	Creds credentials.TransportCredentials
 }
 
 // ServeEnv are environment variables for serving.
@@ -41,10 +50,27 @@ func Serve(
 	if serveEnv.GRPCPort == 0 {
 		serveEnv.GRPCPort = 7070
 	}
-	grpcServer := grpc.NewServer(
+	// the server compresses the responses of clients that ask it to, see
+	// Codec
+	unaryInterceptor := grpc.UnaryServerInterceptor(CompressUnaryInterceptor)
+	if options.UnaryInterceptor != nil {
+		unaryInterceptor = ChainUnaryInterceptors(CompressUnaryInterceptor, options.UnaryInterceptor)
+	}
+	streamInterceptor := grpc.StreamServerInterceptor(CompressStreamInterceptor)
+	if options.StreamInterceptor != nil {
+		streamInterceptor = ChainStreamInterceptors(CompressStreamInterceptor, options.StreamInterceptor)
+	}
+	serverOptions := []grpc.ServerOption{
 		grpc.MaxConcurrentStreams(math.MaxUint32),
 		grpc.MaxMsgSize(options.MaxMsgSize),
-	)
+		grpc.CustomCodec(Codec),
+		grpc.UnaryInterceptor(unaryInterceptor),
+		grpc.StreamInterceptor(streamInterceptor),
+	}
+	if options.Creds != nil {
+		serverOptions = append(serverOptions, grpc.Creds(options.Creds))
+	}
+	grpcServer := grpc.NewServer(serverOptions...)
 	registerFunc(grpcServer)
 	if options.Version != nil {
 		versionpb.RegisterAPIServer(grpcServer, version.NewAPIServer(options.Version, version.APIServerOptions{}))
@@ -55,3 +81,30 @@ func Serve(
 	}
 	return grpcServer.Serve(listener)
 }
+
+// ChainUnaryInterceptors returns an interceptor which calls interceptors in
+// order, each wrapping the ones after it, as a server only takes one.
+func ChainUnaryInterceptors(interceptors ...grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, next := interceptors[i], handler
+			handler = func(ctx context.Context, req interface{}) (interface{}, error) {
+				return interceptor(ctx, req, info, next)
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// ChainStreamInterceptors is ChainUnaryInterceptors for streaming methods.
+func ChainStreamInterceptors(interceptors ...grpc.StreamServerInterceptor) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, next := interceptors[i], handler
+			handler = func(srv interface{}, stream grpc.ServerStream) error {
+				return interceptor(srv, stream, info, next)
+			}
+		}
+		return handler(srv, stream)
+	}
+}
@@ -0,0 +1,77 @@
+package grpcutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"google.golang.org/grpc/credentials"
+)
+
+// The files in a directory holding a TLS certificate, named as in the
+// kubernetes secrets of type kubernetes.io/tls, which cert-manager also
+// creates.
+const (
+	// TLSCertFile holds the certificate, followed by any intermediate
+	// certificates.
+	TLSCertFile = "tls.crt"
+	// TLSKeyFile holds the certificate's private key.
+	TLSKeyFile = "tls.key"
+	// TLSCAFile, which is optional, holds the certificate of the CA which
+	// signed the certificate.
+	TLSCAFile = "ca.crt"
+)
+
+// LoadTLS loads the TLS certificate in dir (see TLSCertFile). It returns nil
+// credentials, and no error, if dir doesn't hold a certificate, in which
+// case TLS isn't used.
+//
+// server are the credentials to serve with. peer are the credentials for
+// the server's connections to itself and to its replicas, which share its
+// certificate. They're dialed by IP address, which the certificate usually
+// doesn't name, so peer expects the first name that the certificate has,
+// and trusts the CA in dir (or, if there isn't one, the certificate itself).
+func LoadTLS(dir string) (server credentials.TransportCredentials, peer credentials.TransportCredentials, retErr error) {
+	certFile := filepath.Join(dir, TLSCertFile)
+	if _, err := os.Stat(certFile); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, filepath.Join(dir, TLSKeyFile))
+	if err != nil {
+		return nil, nil, fmt.Errorf("error loading TLS certificate from %s: %v", dir, err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return nil, nil, fmt.Errorf("error parsing TLS certificate from %s: %v", dir, err)
+	}
+	var serverName string
+	switch {
+	case len(leaf.DNSNames) > 0:
+		serverName = leaf.DNSNames[0]
+	case len(leaf.IPAddresses) > 0:
+		serverName = leaf.IPAddresses[0].String()
+	default:
+		return nil, nil, fmt.Errorf("TLS certificate in %s doesn't name any hosts", dir)
+	}
+	roots := x509.NewCertPool()
+	ca, err := ioutil.ReadFile(filepath.Join(dir, TLSCAFile))
+	switch {
+	case err == nil:
+		if !roots.AppendCertsFromPEM(ca) {
+			return nil, nil, fmt.Errorf("no certificates found in %s", filepath.Join(dir, TLSCAFile))
+		}
+	case os.IsNotExist(err):
+		roots.AddCert(leaf)
+	default:
+		return nil, nil, err
+	}
+	server = credentials.NewTLS(&tls.Config{Certificates: []tls.Certificate{cert}})
+	peer = credentials.NewTLS(&tls.Config{RootCAs: roots, ServerName: serverName})
+	return server, peer, nil
+}
@@ -0,0 +1,76 @@
+package grpcutil
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	"github.com/pachyderm/pachyderm/src/client/version"
+	"github.com/pachyderm/pachyderm/src/client/version/versionpb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// writeSelfSignedCert writes a self-signed certificate for pachd.example.com
+// to dir, as a kubernetes TLS secret would be mounted.
+func writeSelfSignedCert(t *testing.T, dir string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "pachd"},
+		DNSNames:              []string{"pachd.example.com"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, TLSCertFile), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, TLSKeyFile), pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0600))
+}
+
+func TestLoadTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// without a certificate TLS isn't used
+	server, peer, err := LoadTLS(dir)
+	require.NoError(t, err)
+	require.True(t, server == nil && peer == nil)
+
+	writeSelfSignedCert(t, dir)
+	server, peer, err = LoadTLS(dir)
+	require.NoError(t, err)
+
+	// peers can connect by IP address
+	grpcServer := grpc.NewServer(grpc.Creds(server))
+	versionpb.RegisterAPIServer(grpcServer, version.NewAPIServer(&versionpb.Version{Major: 1}, version.APIServerOptions{}))
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go grpcServer.Serve(listener)
+	defer grpcServer.Stop()
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithTransportCredentials(peer))
+	require.NoError(t, err)
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	v, err := versionpb.NewAPIClient(conn).GetVersion(ctx, &types.Empty{})
+	require.NoError(t, err)
+	require.Equal(t, uint32(1), v.Major)
+}
@@ -179,8 +179,15 @@ func (a *sharder) AssignRoles(address string, cancel chan bool) (retErr error) {
 		case <-cancel:
 			if oldValue != "" {
 				close(unsafeAssignRolesCancel)
-				return <-errChan
+				err := <-errChan
+				// Release the lock, so that another server starts assigning
+				// roles right away
+				if err := a.discoveryClient.CheckAndDelete("lock", oldValue); err != nil {
+					log.Errorf("sharder.AssignRoles error releasing lock: %+v", err)
+				}
+				return err
 			}
+			return nil
 		case <-time.After(time.Second * time.Duration(holdTTL/2)):
 		}
 	}
@@ -694,7 +701,9 @@ func (a *sharder) announceServers(
 		log.Debug(&SetServerState{serverState})
 		select {
 		case <-cancel:
-			return nil
+			// Remove the server right away, rather than once its state
+			// expires, so that its shards are reassigned
+			return a.discoveryClient.Delete(a.serverStateKey(address))
 		case version := <-versionChan:
 			serverState.Version = version
 		case <-time.After(time.Second * time.Duration(holdTTL/2)):
@@ -723,7 +732,7 @@ func (a *sharder) announceFrontends(
 		log.Debug(&SetFrontendState{frontendState})
 		select {
 		case <-cancel:
-			return nil
+			return a.discoveryClient.Delete(a.frontendStateKey(address))
 		case version := <-versionChan:
 			frontendState.Version = version
 		case <-time.After(time.Second * time.Duration(holdTTL/2)):
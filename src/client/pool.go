@@ -0,0 +1,208 @@
+package client
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+
+	log "github.com/Sirupsen/logrus"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// connPool is a set of gRPC connections to pachd. Each connection is a
+// single HTTP/2 connection, which limits the number of streams that can be
+// open on it concurrently, so streaming RPCs (PutFile, GetFile and the object
+// RPCs) are spread across the connections in the pool. Unary RPCs are cheap
+// and always use the first connection.
+//
+// The pool also reconnects to pachd when a connection is lost for good (e.g.
+// because pachd restarted), grpc only retries connections that fail in ways
+// it considers temporary. The generated clients are bound to the connections
+// they were created with, so rather than replacing the clients the pool
+// installs an interceptor which routes each RPC to the latest connection
+// dialed in place of the one the client was created with.
+type connPool struct {
+	conns   []*grpc.ClientConn
+	pfs     []pfs.APIClient
+	objects []pfs.ObjectAPIClient
+	counter uint64
+
+	addr        string
+	dialOptions []grpc.DialOption
+	// redialing is used as a semaphore so that only one redial happens at a
+	// time
+	redialing chan struct{}
+	mu        sync.RWMutex
+	// current maps each of conns to the connection that's currently
+	// standing in for it
+	current map[*grpc.ClientConn]*grpc.ClientConn
+	closed  bool
+}
+
+// newConnPool dials size connections to addr. unaryInterceptors and
+// streamInterceptors are installed on each connection, ahead of the
+// interceptors that recreate pachd's errors and the pool's own interceptors.
+func newConnPool(addr string, size int, dialOptions []grpc.DialOption,
+	unaryInterceptors []grpc.UnaryClientInterceptor, streamInterceptors []grpc.StreamClientInterceptor) (*connPool, error) {
+	p := &connPool{
+		addr:      addr,
+		redialing: make(chan struct{}, 1),
+		current:   make(map[*grpc.ClientConn]*grpc.ClientConn),
+	}
+	unaryInterceptors = append(unaryInterceptors[:len(unaryInterceptors):len(unaryInterceptors)], errorUnaryInterceptor, p.unaryInterceptor)
+	streamInterceptors = append(streamInterceptors[:len(streamInterceptors):len(streamInterceptors)], errorStreamInterceptor, p.streamInterceptor)
+	p.dialOptions = append(dialOptions[:len(dialOptions):len(dialOptions)],
+		grpc.WithUnaryInterceptor(chainUnaryInterceptors(unaryInterceptors)),
+		grpc.WithStreamInterceptor(chainStreamInterceptors(streamInterceptors)),
+	)
+	for i := 0; i < size; i++ {
+		conn, err := grpc.Dial(addr, p.dialOptions...)
+		if err != nil {
+			p.Close()
+			return nil, err
+		}
+		p.conns = append(p.conns, conn)
+		p.current[conn] = conn
+		p.pfs = append(p.pfs, pfs.NewAPIClient(conn))
+		p.objects = append(p.objects, pfs.NewObjectAPIClient(conn))
+	}
+	return p, nil
+}
+
+// next returns the index of the connection the next stream should use.
+func (p *connPool) next() int {
+	return int(atomic.AddUint64(&p.counter, 1) % uint64(len(p.conns)))
+}
+
+// currentConn returns the connection that's standing in for conn.
+func (p *connPool) currentConn(conn *grpc.ClientConn) *grpc.ClientConn {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if current, ok := p.current[conn]; ok {
+		return current
+	}
+	return conn
+}
+
+// redial replaces broken, which is standing in for conn, with a new
+// connection. If another redial is already in progress, or broken has
+// already been replaced, redial does nothing.
+func (p *connPool) redial(conn *grpc.ClientConn, broken *grpc.ClientConn) {
+	select {
+	case p.redialing <- struct{}{}:
+		defer func() { <-p.redialing }()
+	default:
+		return
+	}
+	p.mu.RLock()
+	stale := p.closed || p.current[conn] != broken
+	p.mu.RUnlock()
+	if stale {
+		return
+	}
+	newConn, err := grpc.Dial(p.addr, p.dialOptions...)
+	if err != nil {
+		// pachd is still unreachable, we'll try again on the next failure
+		log.Errorf("error reconnecting to pachd at %s: %v", p.addr, err)
+		return
+	}
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		newConn.Close()
+		return
+	}
+	p.current[conn] = newConn
+	p.mu.Unlock()
+	broken.Close()
+}
+
+// ShuttingDownDesc is the description of the error that pachd rejects
+// requests with once it's shutting down. Those requests were never served, so
+// the pool retries unary ones on a new connection, to another pachd.
+const ShuttingDownDesc = "pachd is shutting down"
+
+// isConnErr returns true if err indicates that an RPC failed because the
+// connection it was sent on is unusable.
+func isConnErr(err error) bool {
+	return grpc.Code(err) == codes.Unavailable ||
+		grpc.ErrorDesc(err) == grpc.ErrClientConnClosing.Error()
+}
+
+func (p *connPool) unaryInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	current := p.currentConn(cc)
+	err := invoker(ctx, method, req, reply, current, opts...)
+	if err != nil && isConnErr(err) {
+		p.redial(cc, current)
+		if grpc.ErrorDesc(err) == ShuttingDownDesc {
+			if retry := p.currentConn(cc); retry != current {
+				err = invoker(ctx, method, req, reply, retry, opts...)
+			}
+		}
+	}
+	return err
+}
+
+func (p *connPool) streamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	current := p.currentConn(cc)
+	stream, err := streamer(ctx, desc, current, method, opts...)
+	if err != nil && isConnErr(err) {
+		p.redial(cc, current)
+	}
+	return stream, err
+}
+
+// Close closes all of the connections in the pool.
+func (p *connPool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	var result error
+	for _, conn := range p.current {
+		if err := conn.Close(); err != nil && result == nil {
+			result = err
+		}
+	}
+	return result
+}
+
+// pooledPfsAPIClient is a pfs.APIClient that spreads streaming RPCs across
+// a connPool.
+type pooledPfsAPIClient struct {
+	pfs.APIClient
+	pool *connPool
+}
+
+func (c *pooledPfsAPIClient) PutFile(ctx context.Context, opts ...grpc.CallOption) (pfs.API_PutFileClient, error) {
+	return c.pool.pfs[c.pool.next()].PutFile(ctx, opts...)
+}
+
+func (c *pooledPfsAPIClient) GetFile(ctx context.Context, in *pfs.GetFileRequest, opts ...grpc.CallOption) (pfs.API_GetFileClient, error) {
+	return c.pool.pfs[c.pool.next()].GetFile(ctx, in, opts...)
+}
+
+// pooledObjectAPIClient is a pfs.ObjectAPIClient that spreads streaming RPCs
+// across a connPool.
+type pooledObjectAPIClient struct {
+	pfs.ObjectAPIClient
+	pool *connPool
+}
+
+func (c *pooledObjectAPIClient) PutObject(ctx context.Context, opts ...grpc.CallOption) (pfs.ObjectAPI_PutObjectClient, error) {
+	return c.pool.objects[c.pool.next()].PutObject(ctx, opts...)
+}
+
+func (c *pooledObjectAPIClient) GetObject(ctx context.Context, in *pfs.Object, opts ...grpc.CallOption) (pfs.ObjectAPI_GetObjectClient, error) {
+	return c.pool.objects[c.pool.next()].GetObject(ctx, in, opts...)
+}
+
+func (c *pooledObjectAPIClient) GetObjects(ctx context.Context, in *pfs.GetObjectsRequest, opts ...grpc.CallOption) (pfs.ObjectAPI_GetObjectsClient, error) {
+	return c.pool.objects[c.pool.next()].GetObjects(ctx, in, opts...)
+}
+
+func (c *pooledObjectAPIClient) GetTag(ctx context.Context, in *pfs.Tag, opts ...grpc.CallOption) (pfs.ObjectAPI_GetTagClient, error) {
+	return c.pool.objects[c.pool.next()].GetTag(ctx, in, opts...)
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"net"
+	"sync/atomic"
+	"testing"
+
+	types "github.com/gogo/protobuf/types"
+	"github.com/pachyderm/pachyderm/src/client/health"
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+type healthServer struct{}
+
+func (healthServer) Health(context.Context, *types.Empty) (*types.Empty, error) {
+	return &types.Empty{}, nil
+}
+
+func TestReconnect(t *testing.T) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	health.RegisterHealthServer(server, healthServer{})
+	go server.Serve(listener)
+	defer server.Stop()
+
+	c, err := NewFromAddress(listener.Addr().String(), WithConnectionPoolSize(2))
+	require.NoError(t, err)
+	defer c.Close()
+	_, err = c.healthClient.Health(context.Background(), &types.Empty{})
+	require.NoError(t, err)
+
+	// Simulate the connection being torn down for good, the first RPC fails
+	// but the client should reconnect for the next one.
+	require.NoError(t, c.pool.conns[0].Close())
+	_, err = c.healthClient.Health(context.Background(), &types.Empty{})
+	require.YesError(t, err)
+	_, err = c.healthClient.Health(context.Background(), &types.Empty{})
+	require.NoError(t, err)
+	require.True(t, c.pool.currentConn(c.pool.conns[0]) != c.pool.conns[0])
+
+	// Closing the client shouldn't cause it to reconnect.
+	require.NoError(t, c.Close())
+	_, err = c.healthClient.Health(context.Background(), &types.Empty{})
+	require.YesError(t, err)
+	_, err = c.healthClient.Health(context.Background(), &types.Empty{})
+	require.YesError(t, err)
+}
+
+// shuttingDownHealthServer rejects its first request the way that a pachd
+// which is shutting down does.
+type shuttingDownHealthServer struct {
+	calls int32
+}
+
+func (s *shuttingDownHealthServer) Health(context.Context, *types.Empty) (*types.Empty, error) {
+	if atomic.AddInt32(&s.calls, 1) == 1 {
+		return nil, grpc.Errorf(codes.Unavailable, ShuttingDownDesc)
+	}
+	return &types.Empty{}, nil
+}
+
+func TestRetryOnShutdown(t *testing.T) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	healthServer := &shuttingDownHealthServer{}
+	health.RegisterHealthServer(server, healthServer)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	c, err := NewFromAddress(listener.Addr().String())
+	require.NoError(t, err)
+	defer c.Close()
+	// The rejected request was never served, so it's retried on a new
+	// connection.
+	_, err = c.healthClient.Health(context.Background(), &types.Empty{})
+	require.NoError(t, err)
+	require.Equal(t, int32(2), atomic.LoadInt32(&healthServer.calls))
+	require.True(t, c.pool.currentConn(c.pool.conns[0]) != c.pool.conns[0])
+}
@@ -3,9 +3,12 @@ package client
 import (
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/pachyderm/pachyderm/src/client/pfs"
 	"github.com/pachyderm/pachyderm/src/client/pps"
+
+	"golang.org/x/net/context"
 )
 
 // NewJob creates a pps.Job.
@@ -38,8 +41,28 @@ const (
 	// PPSOutputPath is the path where the user code is
 	// expected to write its output to.
 	PPSOutputPath = "/pfs/out"
-	// PPSWorkerPort is the port that workers use for their gRPC server
+	// PPSWorkerPort is the default port that workers use for their gRPC
+	// server
 	PPSWorkerPort = 80
+	// PPSWorkerPortEnv is the env var that sets the port that workers use for
+	// their gRPC server, if it isn't PPSWorkerPort.
+	PPSWorkerPortEnv = "PPS_WORKER_PORT"
+	// PPSStatsdAddrEnv is the env var that tells user code the address that
+	// it can send metrics to, in the statsd protocol, see pps.UserMetric.
+	PPSStatsdAddrEnv = "PACH_STATSD_ADDR"
+	// PPSWorkerCountEnv, PPSWorkerRankEnv, PPSWorkerAddrsEnv,
+	// PPSMasterAddrEnv and PPSMasterPortEnv are the env vars that tell the
+	// code of a pipeline with a pps.Rendezvous how many workers it has, which
+	// of them it's running on, the IPs of all of them (in order of rank) and
+	// the address of the rank 0 worker.
+	PPSWorkerCountEnv = "PACH_WORKER_COUNT"
+	PPSWorkerRankEnv  = "PACH_WORKER_RANK"
+	PPSWorkerAddrsEnv = "PACH_WORKER_ADDRS"
+	PPSMasterAddrEnv  = "PACH_MASTER_ADDR"
+	PPSMasterPortEnv  = "PACH_MASTER_PORT"
+	// PPSDefaultRendezvousPort is the port that the rank 0 worker's code
+	// listens on, if its pps.Rendezvous doesn't set one.
+	PPSDefaultRendezvousPort = 29500
 	// PPSWorkerVolume is the name of the volume in which workers store
 	// data.
 	PPSWorkerVolume = "pachyderm-worker"
@@ -180,11 +203,127 @@ func (c APIClient) InspectJob(jobID string, blockState bool) (*pps.JobInfo, erro
 	return jobInfo, sanitizeErr(err)
 }
 
+// JobWatchInterval is how often a JobWatcher polls pachd for changes in the
+// state of the job it's watching.
+const JobWatchInterval = time.Second
+
+// JobWatcher delivers updates to the state of a job, it's returned by
+// WatchJob.
+type JobWatcher struct {
+	jobInfoCh chan *pps.JobInfo
+	err       error
+	cancel    context.CancelFunc
+}
+
+// Watch returns a channel that receives the job's JobInfo every time the
+// job changes state. The channel is closed once the job reaches a terminal
+// state, when the watcher is closed or when an error occurs, in which case
+// the error can be retrieved with Err.
+func (w *JobWatcher) Watch() <-chan *pps.JobInfo {
+	return w.jobInfoCh
+}
+
+// Err returns the error, if any, that caused the Watch channel to be closed.
+// It should only be called after the Watch channel has been closed.
+func (w *JobWatcher) Err() error {
+	return w.err
+}
+
+// Close stops the watcher, you should call it when you're done receiving
+// updates.
+func (w *JobWatcher) Close() {
+	w.cancel()
+}
+
+// WatchJob returns a JobWatcher that delivers the JobInfo of a job every time
+// its state changes, until the job reaches a terminal state.
+func (c APIClient) WatchJob(jobID string) *JobWatcher {
+	ctx, cancel := context.WithCancel(c.ctx())
+	w := &JobWatcher{
+		jobInfoCh: make(chan *pps.JobInfo),
+		cancel:    cancel,
+	}
+	go func() {
+		defer close(w.jobInfoCh)
+		w.err = c.watchJob(ctx, jobID, w.jobInfoCh)
+	}()
+	return w
+}
+
+func (c APIClient) watchJob(ctx context.Context, jobID string, jobInfoCh chan<- *pps.JobInfo) error {
+	lastState := pps.JobState(-1)
+	ticker := time.NewTicker(JobWatchInterval)
+	defer ticker.Stop()
+	for {
+		jobInfo, err := c.PpsAPIClient.InspectJob(
+			ctx,
+			&pps.InspectJobRequest{
+				Job: NewJob(jobID),
+			})
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return sanitizeErr(err)
+		}
+		if jobInfo.State != lastState {
+			lastState = jobInfo.State
+			select {
+			case jobInfoCh <- jobInfo:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if pps.IsTerminal(jobInfo.State) {
+			return nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// WaitJob blocks until a job reaches a terminal state (success, failure or
+// stopped) and returns its final JobInfo. It returns an error if ctx is
+// cancelled or its deadline passes before that happens.
+func (c APIClient) WaitJob(ctx context.Context, jobID string) (*pps.JobInfo, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ctx = c.addMetadata(ctx)
+	var result *pps.JobInfo
+	jobInfoCh := make(chan *pps.JobInfo)
+	errCh := make(chan error, 1)
+	go func() {
+		defer close(jobInfoCh)
+		errCh <- c.watchJob(ctx, jobID, jobInfoCh)
+	}()
+	for jobInfo := range jobInfoCh {
+		result = jobInfo
+	}
+	if err := <-errCh; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
 // ListJob returns info about all jobs.
 // If pipelineName is non empty then only jobs that were started by the named pipeline will be returned
 // If inputCommit is non-nil then only jobs which took the specific commits as inputs will be returned.
 // The order of the inputCommits doesn't matter.
 func (c APIClient) ListJob(pipelineName string, inputCommit []*pfs.Commit) ([]*pps.JobInfo, error) {
+	return c.listJob(pipelineName, inputCommit, false)
+}
+
+// ListJobNoFull is like ListJob, but the JobInfos it returns only have the
+// jobs' IDs, pipelines, output commits, states, progress and timestamps,
+// which makes it much cheaper when there are many jobs.
+func (c APIClient) ListJobNoFull(pipelineName string, inputCommit []*pfs.Commit) ([]*pps.JobInfo, error) {
+	return c.listJob(pipelineName, inputCommit, true)
+}
+
+func (c APIClient) listJob(pipelineName string, inputCommit []*pfs.Commit, noFull bool) ([]*pps.JobInfo, error) {
 	var pipeline *pps.Pipeline
 	if pipelineName != "" {
 		pipeline = NewPipeline(pipelineName)
@@ -194,6 +333,7 @@ func (c APIClient) ListJob(pipelineName string, inputCommit []*pfs.Commit) ([]*p
 		&pps.ListJobRequest{
 			Pipeline:    pipeline,
 			InputCommit: inputCommit,
+			NoFull:      noFull,
 		})
 	if err != nil {
 		return nil, sanitizeErr(err)
@@ -201,6 +341,64 @@ func (c APIClient) ListJob(pipelineName string, inputCommit []*pfs.Commit) ([]*p
 	return jobInfos.JobInfo, nil
 }
 
+// FlushJob returns an iterator over the jobs that have one of commits as
+// provenance, i.e. the jobs triggered by the commits and by the output
+// commits of those jobs. Each job is returned once it has finished, so
+// iterating until io.EOF waits for all of the jobs downstream of commits to
+// finish.
+// toPipelines restricts the jobs returned to those of the named pipelines, if
+// it's empty the jobs of all pipelines are returned.
+// Jobs aren't returned for pipelines that won't run because a job upstream of
+// them failed.
+func (c APIClient) FlushJob(commits []*pfs.Commit, toPipelines []string) (JobInfoIterator, error) {
+	var pipelines []*pps.Pipeline
+	for _, name := range toPipelines {
+		pipelines = append(pipelines, NewPipeline(name))
+	}
+	ctx, cancel := context.WithCancel(c.ctx())
+	stream, err := c.PpsAPIClient.FlushJob(
+		ctx,
+		&pps.FlushJobRequest{
+			Commits:     commits,
+			ToPipelines: pipelines,
+		},
+	)
+	if err != nil {
+		cancel()
+		return nil, sanitizeErr(err)
+	}
+	return &jobInfoIterator{stream, cancel}, nil
+}
+
+// JobInfoIterator wraps a stream of jobs and makes them easy to iterate.
+type JobInfoIterator interface {
+	Next() (*pps.JobInfo, error)
+	Close()
+}
+
+type jobInfoIterator struct {
+	stream pps.API_FlushJobClient
+	cancel context.CancelFunc
+}
+
+func (j *jobInfoIterator) Next() (*pps.JobInfo, error) {
+	jobInfo, err := j.stream.Recv()
+	if err != nil && err != io.EOF {
+		return nil, sanitizeErr(err)
+	}
+	return jobInfo, err
+}
+
+func (j *jobInfoIterator) Close() {
+	j.cancel()
+	// drain the stream, see commitInfoIterator.Close
+	for {
+		if _, err := j.stream.Recv(); err != nil {
+			break
+		}
+	}
+}
+
 // DeleteJob deletes a job.
 func (c APIClient) DeleteJob(jobID string) error {
 	_, err := c.PpsAPIClient.DeleteJob(
@@ -237,6 +435,34 @@ func (c APIClient) RestartDatum(jobID string, datumFilter []string) error {
 	return sanitizeErr(err)
 }
 
+// ListDatum returns info about the datums of a job: their input files, and
+// whether they've been processed successfully or failed.
+func (c APIClient) ListDatum(jobID string) ([]*pps.DatumInfo, error) {
+	datumInfos, err := c.PpsAPIClient.ListDatum(
+		c.ctx(),
+		&pps.ListDatumRequest{
+			Job: NewJob(jobID),
+		},
+	)
+	if err != nil {
+		return nil, sanitizeErr(err)
+	}
+	return datumInfos.DatumInfo, nil
+}
+
+// InspectDatum returns info about a single datum of a job, datumID is the ID
+// returned by ListDatum.
+func (c APIClient) InspectDatum(jobID string, datumID string) (*pps.DatumInfo, error) {
+	datumInfo, err := c.PpsAPIClient.InspectDatum(
+		c.ctx(),
+		&pps.InspectDatumRequest{
+			Job: NewJob(jobID),
+			ID:  datumID,
+		},
+	)
+	return datumInfo, sanitizeErr(err)
+}
+
 // LogsIter iterates through log messages returned from pps.GetLogs. Logs can
 // be fetched with 'Next()'. The log message received can be examined with
 // 'Message()', and any errors can be examined with 'Err()'.
@@ -244,6 +470,8 @@ type LogsIter struct {
 	logsClient pps.API_GetLogsClient
 	msg        *pps.LogMessage
 	err        error
+	ctx        context.Context
+	cancel     context.CancelFunc
 }
 
 // Next retrieves the next relevant log message from pachd
@@ -270,9 +498,21 @@ func (l *LogsIter) Err() error {
 	if l.err == io.EOF {
 		return nil
 	}
+	if l.ctx != nil && l.ctx.Err() == context.Canceled {
+		// the iterator was closed
+		return nil
+	}
 	return l.err
 }
 
+// Close stops the iterator, causing 'Next()' to return false. It's needed to
+// stop following logs, see GetLogsWithOptions.
+func (l *LogsIter) Close() {
+	if l.cancel != nil {
+		l.cancel()
+	}
+}
+
 // GetLogs gets logs from a job (logs includes stdout and stderr). 'pipelineName',
 // 'jobID', and 'data', are all filters. To forego any filter, simply pass an
 // empty value, though one of 'pipelineName' and 'jobID' must be set. Responses
@@ -281,6 +521,22 @@ func (c APIClient) GetLogs(
 	pipelineName string,
 	jobID string,
 	data []string,
+) *LogsIter {
+	return c.GetLogsWithOptions(pipelineName, jobID, data, "", false, 0)
+}
+
+// GetLogsWithOptions is like GetLogs, with more ways of choosing the log
+// lines returned. 'datumID' is a filter, like the arguments of GetLogs. If
+// 'tail' is nonzero, only the last 'tail' matching lines logged by each worker
+// are returned. If 'follow' is true, new log lines are returned as they're
+// logged, until the iterator is closed with 'Close()'.
+func (c APIClient) GetLogsWithOptions(
+	pipelineName string,
+	jobID string,
+	data []string,
+	datumID string,
+	follow bool,
+	tail int64,
 ) *LogsIter {
 	request := pps.GetLogsRequest{}
 	resp := &LogsIter{}
@@ -291,7 +547,11 @@ func (c APIClient) GetLogs(
 		request.Job = &pps.Job{jobID}
 	}
 	request.DataFilters = data
-	resp.logsClient, resp.err = c.PpsAPIClient.GetLogs(c.ctx(), &request)
+	request.DatumID = datumID
+	request.Follow = follow
+	request.Tail = tail
+	resp.ctx, resp.cancel = context.WithCancel(c.ctx())
+	resp.logsClient, resp.err = c.PpsAPIClient.GetLogs(resp.ctx, &request)
 	return resp
 }
 
@@ -415,3 +675,80 @@ func (c APIClient) RerunPipeline(name string, include []*pfs.Commit, exclude []*
 	)
 	return sanitizeErr(err)
 }
+
+// ClearDatumCache stops a pipeline reusing the outputs of its datums from
+// earlier jobs, so that its next job processes them again. If glob is set,
+// only the datums with an input file whose path matches it are processed
+// again.
+func (c APIClient) ClearDatumCache(name string, glob string) error {
+	_, err := c.PpsAPIClient.ClearDatumCache(
+		c.ctx(),
+		&pps.ClearDatumCacheRequest{
+			Pipeline: NewPipeline(name),
+			Glob:     glob,
+		},
+	)
+	return sanitizeErr(err)
+}
+
+// RunPipeline runs a pipeline once on a given set of input commits, without
+// waiting for new commits to arrive. Inputs which aren't given a commit are
+// run on the head of their branch. It returns the job that was created.
+func (c APIClient) RunPipeline(name string, provenance []*pfs.Commit) (*pps.Job, error) {
+	job, err := c.PpsAPIClient.RunPipeline(
+		c.ctx(),
+		&pps.RunPipelineRequest{
+			Pipeline:   NewPipeline(name),
+			Provenance: provenance,
+		},
+	)
+	return job, sanitizeErr(err)
+}
+
+// TriggerPipeline is like RunPipeline, except that it creates one job per
+// key: if the pipeline has already been triggered with key, the job that was
+// created then is returned, and provenance is ignored. It's meant for
+// external schedulers (e.g. Airflow or Argo), which can retry a trigger
+// without running the pipeline twice, and then wait for the job with
+// InspectTrigger.
+func (c APIClient) TriggerPipeline(name string, key string, provenance []*pfs.Commit) (*pps.Job, error) {
+	job, err := c.PpsAPIClient.TriggerPipeline(
+		c.ctx(),
+		&pps.TriggerPipelineRequest{
+			Pipeline:   NewPipeline(name),
+			Key:        key,
+			Provenance: provenance,
+		},
+	)
+	return job, sanitizeErr(err)
+}
+
+// InspectTrigger returns info about the job that the pipeline created when
+// it was triggered with key. blockState will cause the call to block until
+// the job reaches a terminal state (failure or success).
+func (c APIClient) InspectTrigger(name string, key string, blockState bool) (*pps.JobInfo, error) {
+	jobInfo, err := c.PpsAPIClient.InspectTrigger(
+		c.ctx(),
+		&pps.InspectTriggerRequest{
+			Pipeline:   NewPipeline(name),
+			Key:        key,
+			BlockState: blockState,
+		},
+	)
+	return jobInfo, sanitizeErr(err)
+}
+
+// RunTransaction applies ops atomically: if one of them fails, the ones that
+// have been applied are rolled back. The commits that the ops finish are
+// finished together, so pipelines that take several of them as inputs run a
+// single job on all of them, rather than one per commit. See
+// pps.API.RunTransaction for the order that the ops are applied in.
+func (c APIClient) RunTransaction(ops []*pps.TransactionOp) (*pps.TransactionInfo, error) {
+	transactionInfo, err := c.PpsAPIClient.RunTransaction(
+		c.ctx(),
+		&pps.RunTransactionRequest{
+			Ops: ops,
+		},
+	)
+	return transactionInfo, sanitizeErr(err)
+}
@@ -0,0 +1,36 @@
+package pps
+
+// IsTerminal returns true if a job in the given state will never transition
+// to another state, i.e. the job has either succeeded, failed or been
+// stopped.
+func IsTerminal(state JobState) bool {
+	switch state {
+	case JobState_JOB_SUCCESS, JobState_JOB_FAILURE, JobState_JOB_STOPPED:
+		return true
+	}
+	return false
+}
+
+// CreatePipelineRequestFromInfo returns the CreatePipelineRequest which would
+// create a pipeline with the same spec as pipelineInfo.
+func CreatePipelineRequestFromInfo(pipelineInfo *PipelineInfo) *CreatePipelineRequest {
+	request := &CreatePipelineRequest{
+		Pipeline:           pipelineInfo.Pipeline,
+		Transform:          pipelineInfo.Transform,
+		ParallelismSpec:    pipelineInfo.ParallelismSpec,
+		Egress:             pipelineInfo.Egress,
+		OutputBranch:       pipelineInfo.OutputBranch,
+		ScaleDownThreshold: pipelineInfo.ScaleDownThreshold,
+		ResourceSpec:       pipelineInfo.ResourceSpec,
+		ResourceLimits:     pipelineInfo.ResourceLimits,
+		Salt:               pipelineInfo.Salt,
+		Input:              pipelineInfo.Input,
+		Description:        pipelineInfo.Description,
+		DatumCacheTTL:      pipelineInfo.DatumCacheTTL,
+		SchedulingSpec:     pipelineInfo.SchedulingSpec,
+	}
+	if request.Input == nil {
+		request.Inputs = pipelineInfo.Inputs
+	}
+	return request
+}
@@ -21,21 +21,29 @@ It has these top-level messages:
 	Datum
 	WorkerStatus
 	ResourceSpec
+	SchedulingSpec
 	JobInfo
 	Worker
 	JobInfos
 	Pipeline
 	PipelineInput
+	DatumCacheInvalidation
 	PipelineInfo
 	PipelineInfos
 	CreateJobRequest
 	InspectJobRequest
 	ListJobRequest
+	FlushJobRequest
 	DeleteJobRequest
 	StopJobRequest
 	GetLogsRequest
 	LogMessage
 	RestartDatumRequest
+	ProcessStats
+	DatumInfo
+	DatumInfos
+	ListDatumRequest
+	InspectDatumRequest
 	CreatePipelineRequest
 	InspectPipelineRequest
 	ListPipelineRequest
@@ -43,6 +51,20 @@ It has these top-level messages:
 	StartPipelineRequest
 	StopPipelineRequest
 	RerunPipelineRequest
+	ClearDatumCacheRequest
+	RunPipelineRequest
+	TriggerPipelineRequest
+	InspectTriggerRequest
+	AllowedEgress
+	UserMetric
+	Rendezvous
+	SQLEgress
+	SQLEgressLoad
+	SQLInput
+	TransactionOp
+	RunTransactionRequest
+	TransactionInfo
+	DryRunInfo
 */
 package pps
 
@@ -52,6 +74,7 @@ import math "math"
 import google_protobuf "github.com/gogo/protobuf/types"
 import google_protobuf1 "github.com/gogo/protobuf/types"
 import google_protobuf2 "github.com/gogo/protobuf/types"
+import _ "google.golang.org/genproto/googleapis/api/annotations"
 import _ "github.com/gogo/protobuf/gogoproto"
 import pfs "github.com/pachyderm/pachyderm/src/client/pfs"
 
@@ -71,6 +94,12 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.GoGoProtoPackageIsVersion2 // please upgrade the proto package
 
+// JobState is the state of a job. Jobs start in JOB_STARTING, move to
+// JOB_RUNNING once they have workers, and finish in one of the terminal
+// states JOB_SUCCESS, JOB_FAILURE or JOB_STOPPED, which they never leave. A
+// job in either of the first two states may be stopped. A job whose workers
+// are lost, and stay lost, is moved to JOB_FAILURE, so that every job
+// reaches a terminal state.
 type JobState int32
 
 const (
@@ -166,6 +195,56 @@ func (x PipelineState) String() string {
 }
 func (PipelineState) EnumDescriptor() ([]byte, []int) { return fileDescriptorPps, []int{2} }
 
+type DatumState int32
+
+const (
+	DatumState_DATUM_PENDING DatumState = 0
+	DatumState_DATUM_SUCCESS DatumState = 1
+	DatumState_DATUM_FAILED  DatumState = 2
+	// DATUM_STOPPED datums were being processed when their job was stopped,
+	// and their user code was killed.
+	DatumState_DATUM_STOPPED DatumState = 3
+)
+
+var DatumState_name = map[int32]string{
+	0: "DATUM_PENDING",
+	1: "DATUM_SUCCESS",
+	2: "DATUM_FAILED",
+	3: "DATUM_STOPPED",
+}
+var DatumState_value = map[string]int32{
+	"DATUM_PENDING": 0,
+	"DATUM_SUCCESS": 1,
+	"DATUM_FAILED":  2,
+	"DATUM_STOPPED": 3,
+}
+
+func (x DatumState) String() string {
+	return proto.EnumName(DatumState_name, int32(x))
+}
+func (DatumState) EnumDescriptor() ([]byte, []int) { return fileDescriptorPps, []int{3} }
+
+type UserMetricType int32
+
+const (
+	UserMetricType_COUNTER UserMetricType = 0
+	UserMetricType_GAUGE   UserMetricType = 1
+)
+
+var UserMetricType_name = map[int32]string{
+	0: "COUNTER",
+	1: "GAUGE",
+}
+var UserMetricType_value = map[string]int32{
+	"COUNTER": 0,
+	"GAUGE":   1,
+}
+
+func (x UserMetricType) String() string {
+	return proto.EnumName(UserMetricType_name, int32(x))
+}
+func (UserMetricType) EnumDescriptor() ([]byte, []int) { return fileDescriptorPps, []int{4} }
+
 // Which Parallelism strategy to use. Depending on the value of
 // 'strategy', other messages in the spec will or will not be set.
 type ParallelismSpec_Strategy int32
@@ -173,15 +252,18 @@ type ParallelismSpec_Strategy int32
 const (
 	ParallelismSpec_CONSTANT    ParallelismSpec_Strategy = 0
 	ParallelismSpec_COEFFICIENT ParallelismSpec_Strategy = 1
+	ParallelismSpec_AUTOSCALE   ParallelismSpec_Strategy = 2
 )
 
 var ParallelismSpec_Strategy_name = map[int32]string{
 	0: "CONSTANT",
 	1: "COEFFICIENT",
+	2: "AUTOSCALE",
 }
 var ParallelismSpec_Strategy_value = map[string]int32{
 	"CONSTANT":    0,
 	"COEFFICIENT": 1,
+	"AUTOSCALE":   2,
 }
 
 func (x ParallelismSpec_Strategy) String() string {
@@ -193,8 +275,16 @@ func (ParallelismSpec_Strategy) EnumDescriptor() ([]byte, []int) {
 
 type Secret struct {
 	// Name must be the name of the secret in kubernetes.
-	Name      string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// MountPath, if set, is where the secret's keys are mounted as files.
 	MountPath string `protobuf:"bytes,2,opt,name=mount_path,json=mountPath,proto3" json:"mount_path,omitempty"`
+	// Items, if set, are the keys of the secret that are mounted at
+	// mount_path, rather than all of them.
+	Items []string `protobuf:"bytes,5,rep,name=items" json:"items,omitempty"`
+	// EnvVar, if set, is an environment variable which is set to the value of
+	// key in the secret.
+	EnvVar string `protobuf:"bytes,3,opt,name=env_var,json=envVar,proto3" json:"env_var,omitempty"`
+	Key    string `protobuf:"bytes,4,opt,name=key,proto3" json:"key,omitempty"`
 }
 
 func (m *Secret) Reset()                    { *m = Secret{} }
@@ -216,6 +306,27 @@ func (m *Secret) GetMountPath() string {
 	return ""
 }
 
+func (m *Secret) GetItems() []string {
+	if m != nil {
+		return m.Items
+	}
+	return nil
+}
+
+func (m *Secret) GetEnvVar() string {
+	if m != nil {
+		return m.EnvVar
+	}
+	return ""
+}
+
+func (m *Secret) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
 type Transform struct {
 	Image            string            `protobuf:"bytes,1,opt,name=image,proto3" json:"image,omitempty"`
 	Cmd              []string          `protobuf:"bytes,2,rep,name=cmd" json:"cmd,omitempty"`
@@ -225,6 +336,15 @@ type Transform struct {
 	Stdin            []string          `protobuf:"bytes,5,rep,name=stdin" json:"stdin,omitempty"`
 	AcceptReturnCode []int64           `protobuf:"varint,6,rep,packed,name=accept_return_code,json=acceptReturnCode" json:"accept_return_code,omitempty"`
 	Debug            bool              `protobuf:"varint,7,opt,name=debug,proto3" json:"debug,omitempty"`
+	// AllowedEgress are the destinations outside of the cluster that the
+	// workers may connect to, if pachd restricts their network access.
+	AllowedEgress []*AllowedEgress `protobuf:"bytes,10,rep,name=allowed_egress,json=allowedEgress" json:"allowed_egress,omitempty"`
+	// RunAsUser is the UID that the pipeline's code runs as. If it's 0, the
+	// code runs as the user that its image specifies, which is often root.
+	RunAsUser int64 `protobuf:"varint,11,opt,name=run_as_user,json=runAsUser,proto3" json:"run_as_user,omitempty"`
+	// Rendezvous, if set, lets the workers' code find each other, e.g. to
+	// train a model across them with Horovod or torch.distributed.
+	Rendezvous *Rendezvous `protobuf:"bytes,12,opt,name=rendezvous" json:"rendezvous,omitempty"`
 }
 
 func (m *Transform) Reset()                    { *m = Transform{} }
@@ -288,8 +408,32 @@ func (m *Transform) GetDebug() bool {
 	return false
 }
 
+func (m *Transform) GetAllowedEgress() []*AllowedEgress {
+	if m != nil {
+		return m.AllowedEgress
+	}
+	return nil
+}
+
+func (m *Transform) GetRunAsUser() int64 {
+	if m != nil {
+		return m.RunAsUser
+	}
+	return 0
+}
+
+func (m *Transform) GetRendezvous() *Rendezvous {
+	if m != nil {
+		return m.Rendezvous
+	}
+	return nil
+}
+
 type Egress struct {
 	URL string `protobuf:"bytes,1,opt,name=URL,json=uRL,proto3" json:"URL,omitempty"`
+	// SQL, if set, loads the output into a database's tables, rather than
+	// copying it to URL.
+	SQL *SQLEgress `protobuf:"bytes,2,opt,name=sql" json:"sql,omitempty"`
 }
 
 func (m *Egress) Reset()                    { *m = Egress{} }
@@ -304,6 +448,13 @@ func (m *Egress) GetURL() string {
 	return ""
 }
 
+func (m *Egress) GetSQL() *SQLEgress {
+	if m != nil {
+		return m.SQL
+	}
+	return nil
+}
+
 type Job struct {
 	ID string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
 }
@@ -412,6 +563,7 @@ type Input struct {
 	Atom  *AtomInput `protobuf:"bytes,1,opt,name=atom" json:"atom,omitempty"`
 	Cross []*Input   `protobuf:"bytes,2,rep,name=cross" json:"cross,omitempty"`
 	Union []*Input   `protobuf:"bytes,3,rep,name=union" json:"union,omitempty"`
+	SQL   *SQLInput  `protobuf:"bytes,4,opt,name=sql" json:"sql,omitempty"`
 }
 
 func (m *Input) Reset()                    { *m = Input{} }
@@ -440,6 +592,13 @@ func (m *Input) GetUnion() []*Input {
 	return nil
 }
 
+func (m *Input) GetSQL() *SQLInput {
+	if m != nil {
+		return m.SQL
+	}
+	return nil
+}
+
 type JobInput struct {
 	Name   string      `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
 	Commit *pfs.Commit `protobuf:"bytes,1,opt,name=commit" json:"commit,omitempty"`
@@ -500,6 +659,17 @@ type ParallelismSpec struct {
 	// reserve half the nodes in your cluster for other tasks, you might set
 	// 'coefficient' to 0.5.
 	Coefficient float64 `protobuf:"fixed64,3,opt,name=coefficient,proto3" json:"coefficient,omitempty"`
+	// If 'strategy' is set to AUTOSCALE, then the fields 'min' and 'max' are
+	// used.
+	//
+	// Starts the pipeline with 'min' workers, which may be zero, and scales
+	// them up to one worker per datum of each job, but at most 'max' workers,
+	// while the job runs. Workers that the cluster's nodes don't have room
+	// for aren't waited for, pachd scales them back down (though not below
+	// 'min'). Once the pipeline has no jobs to run, its workers are scaled
+	// back down to 'min' after its scale_down_threshold.
+	Min uint64 `protobuf:"varint,4,opt,name=min,proto3" json:"min,omitempty"`
+	Max uint64 `protobuf:"varint,5,opt,name=max,proto3" json:"max,omitempty"`
 }
 
 func (m *ParallelismSpec) Reset()                    { *m = ParallelismSpec{} }
@@ -528,6 +698,20 @@ func (m *ParallelismSpec) GetCoefficient() float64 {
 	return 0
 }
 
+func (m *ParallelismSpec) GetMin() uint64 {
+	if m != nil {
+		return m.Min
+	}
+	return 0
+}
+
+func (m *ParallelismSpec) GetMax() uint64 {
+	if m != nil {
+		return m.Max
+	}
+	return 0
+}
+
 type Datum struct {
 	// This file's absolute path within its pfs repo.
 	Path string `protobuf:"bytes,4,opt,name=path,proto3" json:"path,omitempty"`
@@ -634,6 +818,40 @@ func (m *ResourceSpec) GetGpu() int64 {
 	return 0
 }
 
+// SchedulingSpec decides which of the jobs that are waiting for workers,
+// when the cluster's maximum number of workers has been reached, gets them
+// first.
+type SchedulingSpec struct {
+	// Jobs with a higher priority get workers before jobs with a lower one.
+	// Priorities may be negative, e.g. for backfills that should only use
+	// the workers that no other job is waiting for.
+	Priority int64 `protobuf:"varint,1,opt,name=priority,proto3" json:"priority,omitempty"`
+	// Among jobs with the same priority, the team that has the fewest
+	// workers, relative to its weight (see pachctl deploy's --team-weights),
+	// gets workers first, so that one team's jobs can't take all of them.
+	// Pipelines without a team share the default team, "".
+	Team string `protobuf:"bytes,2,opt,name=team,proto3" json:"team,omitempty"`
+}
+
+func (m *SchedulingSpec) Reset()                    { *m = SchedulingSpec{} }
+func (m *SchedulingSpec) String() string            { return proto.CompactTextString(m) }
+func (*SchedulingSpec) ProtoMessage()               {}
+func (*SchedulingSpec) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{12} }
+
+func (m *SchedulingSpec) GetPriority() int64 {
+	if m != nil {
+		return m.Priority
+	}
+	return 0
+}
+
+func (m *SchedulingSpec) GetTeam() string {
+	if m != nil {
+		return m.Team
+	}
+	return ""
+}
+
 type JobInfo struct {
 	Job             *Job                        `protobuf:"bytes,1,opt,name=job" json:"job,omitempty"`
 	Transform       *Transform                  `protobuf:"bytes,2,opt,name=transform" json:"transform,omitempty"`
@@ -658,12 +876,32 @@ type JobInfo struct {
 	WorkerStatus    []*WorkerStatus             `protobuf:"bytes,24,rep,name=worker_status,json=workerStatus" json:"worker_status,omitempty"`
 	ResourceSpec    *ResourceSpec               `protobuf:"bytes,25,opt,name=resource_spec,json=resourceSpec" json:"resource_spec,omitempty"`
 	Input           *Input                      `protobuf:"bytes,26,opt,name=input" json:"input,omitempty"`
+	ResourceLimits  *ResourceSpec               `protobuf:"bytes,27,opt,name=resource_limits,json=resourceLimits" json:"resource_limits,omitempty"`
+	// user_metrics are the metrics that the job's user code reported, see
+	// UserMetric.
+	UserMetrics []*UserMetric `protobuf:"bytes,28,rep,name=user_metrics,json=userMetrics" json:"user_metrics,omitempty"`
+	// sql_egress_loads are the rows that the job's SQL egress loaded, see
+	// SQLEgress.
+	SQLEgressLoads []*SQLEgressLoad `protobuf:"bytes,29,rep,name=sql_egress_loads,json=sqlEgressLoads" json:"sql_egress_loads,omitempty"`
+	// salt and datum_hash_version are the pipeline's when the job was
+	// created, see PipelineInfo.
+	Salt             string `protobuf:"bytes,30,opt,name=salt,proto3" json:"salt,omitempty"`
+	DatumHashVersion int64  `protobuf:"varint,31,opt,name=datum_hash_version,json=datumHashVersion,proto3" json:"datum_hash_version,omitempty"`
+	// reason is why the job failed, if it failed for a reason other than its
+	// datums failing, such as its workers being lost.
+	Reason string `protobuf:"bytes,32,opt,name=reason,proto3" json:"reason,omitempty"`
+	// datum_cache_ttl and datum_cache_invalidations are the pipeline's when
+	// the job was created, see PipelineInfo.
+	DatumCacheTTL           *google_protobuf2.Duration `protobuf:"bytes,33,opt,name=datum_cache_ttl,json=datumCacheTtl" json:"datum_cache_ttl,omitempty"`
+	DatumCacheInvalidations []*DatumCacheInvalidation  `protobuf:"bytes,34,rep,name=datum_cache_invalidations,json=datumCacheInvalidations" json:"datum_cache_invalidations,omitempty"`
+	// scheduling_spec is the pipeline's when the job was created.
+	SchedulingSpec *SchedulingSpec `protobuf:"bytes,35,opt,name=scheduling_spec,json=schedulingSpec" json:"scheduling_spec,omitempty"`
 }
 
 func (m *JobInfo) Reset()                    { *m = JobInfo{} }
 func (m *JobInfo) String() string            { return proto.CompactTextString(m) }
 func (*JobInfo) ProtoMessage()               {}
-func (*JobInfo) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{12} }
+func (*JobInfo) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{13} }
 
 func (m *JobInfo) GetJob() *Job {
 	if m != nil {
@@ -826,6 +1064,69 @@ func (m *JobInfo) GetInput() *Input {
 	return nil
 }
 
+func (m *JobInfo) GetResourceLimits() *ResourceSpec {
+	if m != nil {
+		return m.ResourceLimits
+	}
+	return nil
+}
+
+func (m *JobInfo) GetUserMetrics() []*UserMetric {
+	if m != nil {
+		return m.UserMetrics
+	}
+	return nil
+}
+
+func (m *JobInfo) GetSQLEgressLoads() []*SQLEgressLoad {
+	if m != nil {
+		return m.SQLEgressLoads
+	}
+	return nil
+}
+
+func (m *JobInfo) GetSalt() string {
+	if m != nil {
+		return m.Salt
+	}
+	return ""
+}
+
+func (m *JobInfo) GetDatumHashVersion() int64 {
+	if m != nil {
+		return m.DatumHashVersion
+	}
+	return 0
+}
+
+func (m *JobInfo) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+func (m *JobInfo) GetDatumCacheTTL() *google_protobuf2.Duration {
+	if m != nil {
+		return m.DatumCacheTTL
+	}
+	return nil
+}
+
+func (m *JobInfo) GetDatumCacheInvalidations() []*DatumCacheInvalidation {
+	if m != nil {
+		return m.DatumCacheInvalidations
+	}
+	return nil
+}
+
+func (m *JobInfo) GetSchedulingSpec() *SchedulingSpec {
+	if m != nil {
+		return m.SchedulingSpec
+	}
+	return nil
+}
+
 type Worker struct {
 	Name  string      `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
 	State WorkerState `protobuf:"varint,2,opt,name=state,proto3,enum=pps.WorkerState" json:"state,omitempty"`
@@ -834,7 +1135,7 @@ type Worker struct {
 func (m *Worker) Reset()                    { *m = Worker{} }
 func (m *Worker) String() string            { return proto.CompactTextString(m) }
 func (*Worker) ProtoMessage()               {}
-func (*Worker) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{13} }
+func (*Worker) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{14} }
 
 func (m *Worker) GetName() string {
 	if m != nil {
@@ -857,7 +1158,7 @@ type JobInfos struct {
 func (m *JobInfos) Reset()                    { *m = JobInfos{} }
 func (m *JobInfos) String() string            { return proto.CompactTextString(m) }
 func (*JobInfos) ProtoMessage()               {}
-func (*JobInfos) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{14} }
+func (*JobInfos) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{15} }
 
 func (m *JobInfos) GetJobInfo() []*JobInfo {
 	if m != nil {
@@ -873,7 +1174,7 @@ type Pipeline struct {
 func (m *Pipeline) Reset()                    { *m = Pipeline{} }
 func (m *Pipeline) String() string            { return proto.CompactTextString(m) }
 func (*Pipeline) ProtoMessage()               {}
-func (*Pipeline) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{15} }
+func (*Pipeline) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{16} }
 
 func (m *Pipeline) GetName() string {
 	if m != nil {
@@ -894,7 +1195,7 @@ type PipelineInput struct {
 func (m *PipelineInput) Reset()                    { *m = PipelineInput{} }
 func (m *PipelineInput) String() string            { return proto.CompactTextString(m) }
 func (*PipelineInput) ProtoMessage()               {}
-func (*PipelineInput) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{16} }
+func (*PipelineInput) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{17} }
 
 func (m *PipelineInput) GetName() string {
 	if m != nil {
@@ -938,6 +1239,42 @@ func (m *PipelineInput) GetFrom() *pfs.Commit {
 	return nil
 }
 
+// DatumCacheInvalidation stops a pipeline reusing the outputs that its
+// datums had before it, see ClearDatumCache.
+type DatumCacheInvalidation struct {
+	ID string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// glob matches the paths of the input files of the datums whose outputs
+	// aren't reused. If it's empty, no datum's output is reused.
+	Glob    string                      `protobuf:"bytes,2,opt,name=glob,proto3" json:"glob,omitempty"`
+	Created *google_protobuf1.Timestamp `protobuf:"bytes,3,opt,name=created" json:"created,omitempty"`
+}
+
+func (m *DatumCacheInvalidation) Reset()                    { *m = DatumCacheInvalidation{} }
+func (m *DatumCacheInvalidation) String() string            { return proto.CompactTextString(m) }
+func (*DatumCacheInvalidation) ProtoMessage()               {}
+func (*DatumCacheInvalidation) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{18} }
+
+func (m *DatumCacheInvalidation) GetID() string {
+	if m != nil {
+		return m.ID
+	}
+	return ""
+}
+
+func (m *DatumCacheInvalidation) GetGlob() string {
+	if m != nil {
+		return m.Glob
+	}
+	return ""
+}
+
+func (m *DatumCacheInvalidation) GetCreated() *google_protobuf1.Timestamp {
+	if m != nil {
+		return m.Created
+	}
+	return nil
+}
+
 type PipelineInfo struct {
 	ID                 string                      `protobuf:"bytes,17,opt,name=id,proto3" json:"id,omitempty"`
 	Pipeline           *Pipeline                   `protobuf:"bytes,1,opt,name=pipeline" json:"pipeline,omitempty"`
@@ -956,12 +1293,33 @@ type PipelineInfo struct {
 	ResourceSpec       *ResourceSpec               `protobuf:"bytes,19,opt,name=resource_spec,json=resourceSpec" json:"resource_spec,omitempty"`
 	Input              *Input                      `protobuf:"bytes,20,opt,name=input" json:"input,omitempty"`
 	Description        string                      `protobuf:"bytes,21,opt,name=description,proto3" json:"description,omitempty"`
+	// the user who created the pipeline, or last updated it, if auth was
+	// active
+	Author         string        `protobuf:"bytes,22,opt,name=author,proto3" json:"author,omitempty"`
+	ResourceLimits *ResourceSpec `protobuf:"bytes,23,opt,name=resource_limits,json=resourceLimits" json:"resource_limits,omitempty"`
+	// salt is hashed along with each of the pipeline's datums, see
+	// CreatePipelineRequest.
+	Salt string `protobuf:"bytes,24,opt,name=salt,proto3" json:"salt,omitempty"`
+	// datum_hash_version is the version of the function that the pipeline's
+	// datums are hashed with, which decides whether a datum's output from
+	// an earlier job is reused. A pipeline keeps the version it was created
+	// with until it's updated, so that upgrading pachd doesn't change its
+	// datums' hashes.
+	DatumHashVersion int64 `protobuf:"varint,25,opt,name=datum_hash_version,json=datumHashVersion,proto3" json:"datum_hash_version,omitempty"`
+	// datum_cache_ttl is how long the outputs of the pipeline's datums are
+	// reused for, see CreatePipelineRequest.
+	DatumCacheTTL *google_protobuf2.Duration `protobuf:"bytes,26,opt,name=datum_cache_ttl,json=datumCacheTtl" json:"datum_cache_ttl,omitempty"`
+	// datum_cache_invalidations stop the pipeline reusing the outputs of some
+	// or all of its datums, see ClearDatumCache. Updating the pipeline keeps
+	// them.
+	DatumCacheInvalidations []*DatumCacheInvalidation `protobuf:"bytes,27,rep,name=datum_cache_invalidations,json=datumCacheInvalidations" json:"datum_cache_invalidations,omitempty"`
+	SchedulingSpec          *SchedulingSpec           `protobuf:"bytes,28,opt,name=scheduling_spec,json=schedulingSpec" json:"scheduling_spec,omitempty"`
 }
 
 func (m *PipelineInfo) Reset()                    { *m = PipelineInfo{} }
 func (m *PipelineInfo) String() string            { return proto.CompactTextString(m) }
 func (*PipelineInfo) ProtoMessage()               {}
-func (*PipelineInfo) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{17} }
+func (*PipelineInfo) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{19} }
 
 func (m *PipelineInfo) GetID() string {
 	if m != nil {
@@ -1082,6 +1440,55 @@ func (m *PipelineInfo) GetDescription() string {
 	return ""
 }
 
+func (m *PipelineInfo) GetAuthor() string {
+	if m != nil {
+		return m.Author
+	}
+	return ""
+}
+
+func (m *PipelineInfo) GetResourceLimits() *ResourceSpec {
+	if m != nil {
+		return m.ResourceLimits
+	}
+	return nil
+}
+
+func (m *PipelineInfo) GetSalt() string {
+	if m != nil {
+		return m.Salt
+	}
+	return ""
+}
+
+func (m *PipelineInfo) GetDatumHashVersion() int64 {
+	if m != nil {
+		return m.DatumHashVersion
+	}
+	return 0
+}
+
+func (m *PipelineInfo) GetDatumCacheTTL() *google_protobuf2.Duration {
+	if m != nil {
+		return m.DatumCacheTTL
+	}
+	return nil
+}
+
+func (m *PipelineInfo) GetDatumCacheInvalidations() []*DatumCacheInvalidation {
+	if m != nil {
+		return m.DatumCacheInvalidations
+	}
+	return nil
+}
+
+func (m *PipelineInfo) GetSchedulingSpec() *SchedulingSpec {
+	if m != nil {
+		return m.SchedulingSpec
+	}
+	return nil
+}
+
 type PipelineInfos struct {
 	PipelineInfo []*PipelineInfo `protobuf:"bytes,1,rep,name=pipeline_info,json=pipelineInfo" json:"pipeline_info,omitempty"`
 }
@@ -1089,7 +1496,7 @@ type PipelineInfos struct {
 func (m *PipelineInfos) Reset()                    { *m = PipelineInfos{} }
 func (m *PipelineInfos) String() string            { return proto.CompactTextString(m) }
 func (*PipelineInfos) ProtoMessage()               {}
-func (*PipelineInfos) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{18} }
+func (*PipelineInfos) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{20} }
 
 func (m *PipelineInfos) GetPipelineInfo() []*PipelineInfo {
 	if m != nil {
@@ -1107,18 +1514,21 @@ type CreateJobRequest struct {
 	Egress          *Egress          `protobuf:"bytes,9,opt,name=egress" json:"egress,omitempty"`
 	// When service is defined, we create a long running job
 	// by using a k8s RC and Service instead of a k8s Job
-	Service      *Service      `protobuf:"bytes,8,opt,name=service" json:"service,omitempty"`
-	OutputRepo   *pfs.Repo     `protobuf:"bytes,12,opt,name=outputRepo" json:"outputRepo,omitempty"`
-	OutputBranch string        `protobuf:"bytes,11,opt,name=outputBranch,proto3" json:"outputBranch,omitempty"`
-	ParentJob    *Job          `protobuf:"bytes,13,opt,name=parent_job,json=parentJob" json:"parent_job,omitempty"`
-	ResourceSpec *ResourceSpec `protobuf:"bytes,14,opt,name=resource_spec,json=resourceSpec" json:"resource_spec,omitempty"`
-	Input        *Input        `protobuf:"bytes,15,opt,name=input" json:"input,omitempty"`
+	Service        *Service      `protobuf:"bytes,8,opt,name=service" json:"service,omitempty"`
+	OutputRepo     *pfs.Repo     `protobuf:"bytes,12,opt,name=outputRepo" json:"outputRepo,omitempty"`
+	OutputBranch   string        `protobuf:"bytes,11,opt,name=outputBranch,proto3" json:"outputBranch,omitempty"`
+	ParentJob      *Job          `protobuf:"bytes,13,opt,name=parent_job,json=parentJob" json:"parent_job,omitempty"`
+	ResourceSpec   *ResourceSpec `protobuf:"bytes,14,opt,name=resource_spec,json=resourceSpec" json:"resource_spec,omitempty"`
+	Input          *Input        `protobuf:"bytes,15,opt,name=input" json:"input,omitempty"`
+	ResourceLimits *ResourceSpec `protobuf:"bytes,16,opt,name=resource_limits,json=resourceLimits" json:"resource_limits,omitempty"`
+	// DryRun validates the job without creating it, see DryRunJob.
+	DryRun bool `protobuf:"varint,17,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
 }
 
 func (m *CreateJobRequest) Reset()                    { *m = CreateJobRequest{} }
 func (m *CreateJobRequest) String() string            { return proto.CompactTextString(m) }
 func (*CreateJobRequest) ProtoMessage()               {}
-func (*CreateJobRequest) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{19} }
+func (*CreateJobRequest) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{21} }
 
 func (m *CreateJobRequest) GetTransform() *Transform {
 	if m != nil {
@@ -1204,6 +1614,20 @@ func (m *CreateJobRequest) GetInput() *Input {
 	return nil
 }
 
+func (m *CreateJobRequest) GetResourceLimits() *ResourceSpec {
+	if m != nil {
+		return m.ResourceLimits
+	}
+	return nil
+}
+
+func (m *CreateJobRequest) GetDryRun() bool {
+	if m != nil {
+		return m.DryRun
+	}
+	return false
+}
+
 type InspectJobRequest struct {
 	Job        *Job `protobuf:"bytes,1,opt,name=job" json:"job,omitempty"`
 	BlockState bool `protobuf:"varint,2,opt,name=block_state,json=blockState,proto3" json:"block_state,omitempty"`
@@ -1212,7 +1636,7 @@ type InspectJobRequest struct {
 func (m *InspectJobRequest) Reset()                    { *m = InspectJobRequest{} }
 func (m *InspectJobRequest) String() string            { return proto.CompactTextString(m) }
 func (*InspectJobRequest) ProtoMessage()               {}
-func (*InspectJobRequest) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{20} }
+func (*InspectJobRequest) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{22} }
 
 func (m *InspectJobRequest) GetJob() *Job {
 	if m != nil {
@@ -1231,12 +1655,16 @@ func (m *InspectJobRequest) GetBlockState() bool {
 type ListJobRequest struct {
 	Pipeline    *Pipeline     `protobuf:"bytes,1,opt,name=pipeline" json:"pipeline,omitempty"`
 	InputCommit []*pfs.Commit `protobuf:"bytes,2,rep,name=input_commit,json=inputCommit" json:"input_commit,omitempty"`
+	// no_full, if set, makes ListJob return only the jobs' IDs, pipelines,
+	// output commits, states, progress and timestamps, and not their
+	// transforms, inputs and other specs, which make up most of a JobInfo
+	NoFull bool `protobuf:"varint,3,opt,name=no_full,json=noFull,proto3" json:"no_full,omitempty"`
 }
 
 func (m *ListJobRequest) Reset()                    { *m = ListJobRequest{} }
 func (m *ListJobRequest) String() string            { return proto.CompactTextString(m) }
 func (*ListJobRequest) ProtoMessage()               {}
-func (*ListJobRequest) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{21} }
+func (*ListJobRequest) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{23} }
 
 func (m *ListJobRequest) GetPipeline() *Pipeline {
 	if m != nil {
@@ -1252,6 +1680,37 @@ func (m *ListJobRequest) GetInputCommit() []*pfs.Commit {
 	return nil
 }
 
+func (m *ListJobRequest) GetNoFull() bool {
+	if m != nil {
+		return m.NoFull
+	}
+	return false
+}
+
+type FlushJobRequest struct {
+	Commits     []*pfs.Commit `protobuf:"bytes,1,rep,name=commits" json:"commits,omitempty"`
+	ToPipelines []*Pipeline   `protobuf:"bytes,2,rep,name=to_pipelines,json=toPipelines" json:"to_pipelines,omitempty"`
+}
+
+func (m *FlushJobRequest) Reset()                    { *m = FlushJobRequest{} }
+func (m *FlushJobRequest) String() string            { return proto.CompactTextString(m) }
+func (*FlushJobRequest) ProtoMessage()               {}
+func (*FlushJobRequest) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{24} }
+
+func (m *FlushJobRequest) GetCommits() []*pfs.Commit {
+	if m != nil {
+		return m.Commits
+	}
+	return nil
+}
+
+func (m *FlushJobRequest) GetToPipelines() []*Pipeline {
+	if m != nil {
+		return m.ToPipelines
+	}
+	return nil
+}
+
 type DeleteJobRequest struct {
 	Job *Job `protobuf:"bytes,1,opt,name=job" json:"job,omitempty"`
 }
@@ -1259,7 +1718,7 @@ type DeleteJobRequest struct {
 func (m *DeleteJobRequest) Reset()                    { *m = DeleteJobRequest{} }
 func (m *DeleteJobRequest) String() string            { return proto.CompactTextString(m) }
 func (*DeleteJobRequest) ProtoMessage()               {}
-func (*DeleteJobRequest) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{22} }
+func (*DeleteJobRequest) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{25} }
 
 func (m *DeleteJobRequest) GetJob() *Job {
 	if m != nil {
@@ -1275,7 +1734,7 @@ type StopJobRequest struct {
 func (m *StopJobRequest) Reset()                    { *m = StopJobRequest{} }
 func (m *StopJobRequest) String() string            { return proto.CompactTextString(m) }
 func (*StopJobRequest) ProtoMessage()               {}
-func (*StopJobRequest) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{23} }
+func (*StopJobRequest) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{26} }
 
 func (m *StopJobRequest) GetJob() *Job {
 	if m != nil {
@@ -1296,12 +1755,20 @@ type GetLogsRequest struct {
 	// filter may be an absolute path of a file within a pps repo, or it may be
 	// a hash for that file (to search for files at specific versions)
 	DataFilters []string `protobuf:"bytes,3,rep,name=data_filters,json=dataFilters" json:"data_filters,omitempty"`
+	// The datum from which we want logs, as identified by ListDatum.
+	DatumID string `protobuf:"bytes,4,opt,name=datum_id,json=datumId,proto3" json:"datum_id,omitempty"`
+	// If true, keep returning log lines as they're written, until the call is
+	// cancelled.
+	Follow bool `protobuf:"varint,5,opt,name=follow,proto3" json:"follow,omitempty"`
+	// If nonzero, only the last 'tail' matching lines logged by each worker
+	// are returned (followed by new lines, if 'follow' is set).
+	Tail int64 `protobuf:"varint,6,opt,name=tail,proto3" json:"tail,omitempty"`
 }
 
 func (m *GetLogsRequest) Reset()                    { *m = GetLogsRequest{} }
 func (m *GetLogsRequest) String() string            { return proto.CompactTextString(m) }
 func (*GetLogsRequest) ProtoMessage()               {}
-func (*GetLogsRequest) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{24} }
+func (*GetLogsRequest) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{27} }
 
 func (m *GetLogsRequest) GetPipeline() *Pipeline {
 	if m != nil {
@@ -1324,6 +1791,27 @@ func (m *GetLogsRequest) GetDataFilters() []string {
 	return nil
 }
 
+func (m *GetLogsRequest) GetDatumID() string {
+	if m != nil {
+		return m.DatumID
+	}
+	return ""
+}
+
+func (m *GetLogsRequest) GetFollow() bool {
+	if m != nil {
+		return m.Follow
+	}
+	return false
+}
+
+func (m *GetLogsRequest) GetTail() int64 {
+	if m != nil {
+		return m.Tail
+	}
+	return 0
+}
+
 // LogMessage is a log line from a PPS worker, annotated with metadata
 // indicating when and why the line was logged.
 type LogMessage struct {
@@ -1335,6 +1823,8 @@ type LogMessage struct {
 	WorkerID     string `protobuf:"bytes,7,opt,name=worker_id,json=workerId,proto3" json:"worker_id,omitempty"`
 	// The PFS files being processed (one per pipeline/job input)
 	Data []*Datum `protobuf:"bytes,4,rep,name=data" json:"data,omitempty"`
+	// The ID of the datum being processed, as identified by ListDatum
+	DatumID string `protobuf:"bytes,9,opt,name=datum_id,json=datumId,proto3" json:"datum_id,omitempty"`
 	// User is true if log message comes from the users code.
 	User bool `protobuf:"varint,8,opt,name=user,proto3" json:"user,omitempty"`
 	// The message logged, and the time at which it was logged
@@ -1345,7 +1835,7 @@ type LogMessage struct {
 func (m *LogMessage) Reset()                    { *m = LogMessage{} }
 func (m *LogMessage) String() string            { return proto.CompactTextString(m) }
 func (*LogMessage) ProtoMessage()               {}
-func (*LogMessage) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{25} }
+func (*LogMessage) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{28} }
 
 func (m *LogMessage) GetPipelineName() string {
 	if m != nil {
@@ -1382,6 +1872,13 @@ func (m *LogMessage) GetData() []*Datum {
 	return nil
 }
 
+func (m *LogMessage) GetDatumID() string {
+	if m != nil {
+		return m.DatumID
+	}
+	return ""
+}
+
 func (m *LogMessage) GetUser() bool {
 	if m != nil {
 		return m.User
@@ -1411,7 +1908,7 @@ type RestartDatumRequest struct {
 func (m *RestartDatumRequest) Reset()                    { *m = RestartDatumRequest{} }
 func (m *RestartDatumRequest) String() string            { return proto.CompactTextString(m) }
 func (*RestartDatumRequest) ProtoMessage()               {}
-func (*RestartDatumRequest) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{26} }
+func (*RestartDatumRequest) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{29} }
 
 func (m *RestartDatumRequest) GetJob() *Job {
 	if m != nil {
@@ -1427,51 +1924,244 @@ func (m *RestartDatumRequest) GetDataFilters() []string {
 	return nil
 }
 
-type CreatePipelineRequest struct {
-	Pipeline           *Pipeline                  `protobuf:"bytes,1,opt,name=pipeline" json:"pipeline,omitempty"`
-	Transform          *Transform                 `protobuf:"bytes,2,opt,name=transform" json:"transform,omitempty"`
-	ParallelismSpec    *ParallelismSpec           `protobuf:"bytes,7,opt,name=parallelism_spec,json=parallelismSpec" json:"parallelism_spec,omitempty"`
-	Inputs             []*PipelineInput           `protobuf:"bytes,4,rep,name=inputs" json:"inputs,omitempty"`
-	Egress             *Egress                    `protobuf:"bytes,9,opt,name=egress" json:"egress,omitempty"`
-	Update             bool                       `protobuf:"varint,5,opt,name=update,proto3" json:"update,omitempty"`
-	OutputBranch       string                     `protobuf:"bytes,10,opt,name=outputBranch,proto3" json:"outputBranch,omitempty"`
-	ScaleDownThreshold *google_protobuf2.Duration `protobuf:"bytes,11,opt,name=scaleDownThreshold" json:"scaleDownThreshold,omitempty"`
-	ResourceSpec       *ResourceSpec              `protobuf:"bytes,12,opt,name=resource_spec,json=resourceSpec" json:"resource_spec,omitempty"`
-	Input              *Input                     `protobuf:"bytes,13,opt,name=input" json:"input,omitempty"`
-	Description        string                     `protobuf:"bytes,14,opt,name=description,proto3" json:"description,omitempty"`
+// ProcessStats are the stats of a worker's processing of a datum.
+type ProcessStats struct {
+	// download_time is how long the datum's input took to download.
+	DownloadTime *google_protobuf2.Duration `protobuf:"bytes,1,opt,name=download_time,json=downloadTime" json:"download_time,omitempty"`
+	// process_time is how long the user code ran for.
+	ProcessTime *google_protobuf2.Duration `protobuf:"bytes,2,opt,name=process_time,json=processTime" json:"process_time,omitempty"`
+	// upload_time is how long the datum's output took to upload.
+	UploadTime *google_protobuf2.Duration `protobuf:"bytes,3,opt,name=upload_time,json=uploadTime" json:"upload_time,omitempty"`
+	// download_bytes is the size of the datum's input.
+	DownloadBytes uint64 `protobuf:"varint,4,opt,name=download_bytes,json=downloadBytes,proto3" json:"download_bytes,omitempty"`
+	// upload_bytes is the size of the datum's output.
+	UploadBytes uint64 `protobuf:"varint,5,opt,name=upload_bytes,json=uploadBytes,proto3" json:"upload_bytes,omitempty"`
 }
 
-func (m *CreatePipelineRequest) Reset()                    { *m = CreatePipelineRequest{} }
-func (m *CreatePipelineRequest) String() string            { return proto.CompactTextString(m) }
-func (*CreatePipelineRequest) ProtoMessage()               {}
-func (*CreatePipelineRequest) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{27} }
+func (m *ProcessStats) Reset()                    { *m = ProcessStats{} }
+func (m *ProcessStats) String() string            { return proto.CompactTextString(m) }
+func (*ProcessStats) ProtoMessage()               {}
+func (*ProcessStats) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{30} }
 
-func (m *CreatePipelineRequest) GetPipeline() *Pipeline {
+func (m *ProcessStats) GetDownloadTime() *google_protobuf2.Duration {
 	if m != nil {
-		return m.Pipeline
+		return m.DownloadTime
 	}
 	return nil
 }
 
-func (m *CreatePipelineRequest) GetTransform() *Transform {
+func (m *ProcessStats) GetProcessTime() *google_protobuf2.Duration {
 	if m != nil {
-		return m.Transform
+		return m.ProcessTime
 	}
 	return nil
 }
 
-func (m *CreatePipelineRequest) GetParallelismSpec() *ParallelismSpec {
+func (m *ProcessStats) GetUploadTime() *google_protobuf2.Duration {
 	if m != nil {
-		return m.ParallelismSpec
+		return m.UploadTime
 	}
 	return nil
 }
 
-func (m *CreatePipelineRequest) GetInputs() []*PipelineInput {
+func (m *ProcessStats) GetDownloadBytes() uint64 {
 	if m != nil {
-		return m.Inputs
+		return m.DownloadBytes
 	}
-	return nil
+	return 0
+}
+
+func (m *ProcessStats) GetUploadBytes() uint64 {
+	if m != nil {
+		return m.UploadBytes
+	}
+	return 0
+}
+
+// DatumInfo describes one of the datums that a job processes.
+type DatumInfo struct {
+	// ID identifies the datum within its job, it's derived from the datum's
+	// input files.
+	ID    string     `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Job   *Job       `protobuf:"bytes,2,opt,name=job" json:"job,omitempty"`
+	State DatumState `protobuf:"varint,3,opt,name=state,proto3,enum=pps.DatumState" json:"state,omitempty"`
+	// The input files of the datum (one per atom input)
+	Data []*pfs.FileInfo `protobuf:"bytes,4,rep,name=data" json:"data,omitempty"`
+	// Reason is why the datum failed, if its state is DATUM_FAILED.
+	Reason string `protobuf:"bytes,5,opt,name=reason,proto3" json:"reason,omitempty"`
+	// Stats are the stats of the datum's last processing by the job. They're
+	// only returned by InspectDatum, and only if the job processed the
+	// datum, rather than reusing the output of an earlier job's.
+	Stats *ProcessStats `protobuf:"bytes,6,opt,name=stats" json:"stats,omitempty"`
+}
+
+func (m *DatumInfo) Reset()                    { *m = DatumInfo{} }
+func (m *DatumInfo) String() string            { return proto.CompactTextString(m) }
+func (*DatumInfo) ProtoMessage()               {}
+func (*DatumInfo) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{31} }
+
+func (m *DatumInfo) GetID() string {
+	if m != nil {
+		return m.ID
+	}
+	return ""
+}
+
+func (m *DatumInfo) GetJob() *Job {
+	if m != nil {
+		return m.Job
+	}
+	return nil
+}
+
+func (m *DatumInfo) GetState() DatumState {
+	if m != nil {
+		return m.State
+	}
+	return DatumState_DATUM_PENDING
+}
+
+func (m *DatumInfo) GetData() []*pfs.FileInfo {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+func (m *DatumInfo) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+func (m *DatumInfo) GetStats() *ProcessStats {
+	if m != nil {
+		return m.Stats
+	}
+	return nil
+}
+
+type DatumInfos struct {
+	DatumInfo []*DatumInfo `protobuf:"bytes,1,rep,name=datum_info,json=datumInfo" json:"datum_info,omitempty"`
+}
+
+func (m *DatumInfos) Reset()                    { *m = DatumInfos{} }
+func (m *DatumInfos) String() string            { return proto.CompactTextString(m) }
+func (*DatumInfos) ProtoMessage()               {}
+func (*DatumInfos) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{32} }
+
+func (m *DatumInfos) GetDatumInfo() []*DatumInfo {
+	if m != nil {
+		return m.DatumInfo
+	}
+	return nil
+}
+
+type ListDatumRequest struct {
+	Job *Job `protobuf:"bytes,1,opt,name=job" json:"job,omitempty"`
+}
+
+func (m *ListDatumRequest) Reset()                    { *m = ListDatumRequest{} }
+func (m *ListDatumRequest) String() string            { return proto.CompactTextString(m) }
+func (*ListDatumRequest) ProtoMessage()               {}
+func (*ListDatumRequest) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{33} }
+
+func (m *ListDatumRequest) GetJob() *Job {
+	if m != nil {
+		return m.Job
+	}
+	return nil
+}
+
+type InspectDatumRequest struct {
+	Job *Job   `protobuf:"bytes,1,opt,name=job" json:"job,omitempty"`
+	ID  string `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (m *InspectDatumRequest) Reset()                    { *m = InspectDatumRequest{} }
+func (m *InspectDatumRequest) String() string            { return proto.CompactTextString(m) }
+func (*InspectDatumRequest) ProtoMessage()               {}
+func (*InspectDatumRequest) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{34} }
+
+func (m *InspectDatumRequest) GetJob() *Job {
+	if m != nil {
+		return m.Job
+	}
+	return nil
+}
+
+func (m *InspectDatumRequest) GetID() string {
+	if m != nil {
+		return m.ID
+	}
+	return ""
+}
+
+type CreatePipelineRequest struct {
+	Pipeline           *Pipeline                  `protobuf:"bytes,1,opt,name=pipeline" json:"pipeline,omitempty"`
+	Transform          *Transform                 `protobuf:"bytes,2,opt,name=transform" json:"transform,omitempty"`
+	ParallelismSpec    *ParallelismSpec           `protobuf:"bytes,7,opt,name=parallelism_spec,json=parallelismSpec" json:"parallelism_spec,omitempty"`
+	Inputs             []*PipelineInput           `protobuf:"bytes,4,rep,name=inputs" json:"inputs,omitempty"`
+	Egress             *Egress                    `protobuf:"bytes,9,opt,name=egress" json:"egress,omitempty"`
+	Update             bool                       `protobuf:"varint,5,opt,name=update,proto3" json:"update,omitempty"`
+	OutputBranch       string                     `protobuf:"bytes,10,opt,name=outputBranch,proto3" json:"outputBranch,omitempty"`
+	ScaleDownThreshold *google_protobuf2.Duration `protobuf:"bytes,11,opt,name=scaleDownThreshold" json:"scaleDownThreshold,omitempty"`
+	ResourceSpec       *ResourceSpec              `protobuf:"bytes,12,opt,name=resource_spec,json=resourceSpec" json:"resource_spec,omitempty"`
+	Input              *Input                     `protobuf:"bytes,13,opt,name=input" json:"input,omitempty"`
+	Description        string                     `protobuf:"bytes,14,opt,name=description,proto3" json:"description,omitempty"`
+	// ResourceLimits are the most resources that each worker may use, unlike
+	// ResourceSpec, which is what they need to be scheduled.
+	ResourceLimits *ResourceSpec `protobuf:"bytes,15,opt,name=resource_limits,json=resourceLimits" json:"resource_limits,omitempty"`
+	// Salt is hashed along with each datum, so changing it makes the pipeline
+	// reprocess every datum, rather than reuse their outputs from earlier
+	// jobs.
+	Salt string `protobuf:"bytes,16,opt,name=salt,proto3" json:"salt,omitempty"`
+	// DryRun validates the pipeline without creating it, see DryRunPipeline.
+	DryRun bool `protobuf:"varint,17,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	// DatumCacheTTL is how long a datum's output is reused for by the later
+	// jobs that process the same datum. Outputs are only reused by jobs that
+	// start in the same period of DatumCacheTTL as the job that computed them,
+	// so they're computed again at least once a period. If it's unset,
+	// outputs are reused until they're cleared, see ClearDatumCache.
+	DatumCacheTTL *google_protobuf2.Duration `protobuf:"bytes,18,opt,name=datum_cache_ttl,json=datumCacheTtl" json:"datum_cache_ttl,omitempty"`
+	// SchedulingSpec decides how the pipeline's jobs queue for workers
+	// against other pipelines' when the cluster's maximum number of workers
+	// has been reached.
+	SchedulingSpec *SchedulingSpec `protobuf:"bytes,19,opt,name=scheduling_spec,json=schedulingSpec" json:"scheduling_spec,omitempty"`
+}
+
+func (m *CreatePipelineRequest) Reset()                    { *m = CreatePipelineRequest{} }
+func (m *CreatePipelineRequest) String() string            { return proto.CompactTextString(m) }
+func (*CreatePipelineRequest) ProtoMessage()               {}
+func (*CreatePipelineRequest) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{35} }
+
+func (m *CreatePipelineRequest) GetPipeline() *Pipeline {
+	if m != nil {
+		return m.Pipeline
+	}
+	return nil
+}
+
+func (m *CreatePipelineRequest) GetTransform() *Transform {
+	if m != nil {
+		return m.Transform
+	}
+	return nil
+}
+
+func (m *CreatePipelineRequest) GetParallelismSpec() *ParallelismSpec {
+	if m != nil {
+		return m.ParallelismSpec
+	}
+	return nil
+}
+
+func (m *CreatePipelineRequest) GetInputs() []*PipelineInput {
+	if m != nil {
+		return m.Inputs
+	}
+	return nil
 }
 
 func (m *CreatePipelineRequest) GetEgress() *Egress {
@@ -1523,6 +2213,41 @@ func (m *CreatePipelineRequest) GetDescription() string {
 	return ""
 }
 
+func (m *CreatePipelineRequest) GetResourceLimits() *ResourceSpec {
+	if m != nil {
+		return m.ResourceLimits
+	}
+	return nil
+}
+
+func (m *CreatePipelineRequest) GetSalt() string {
+	if m != nil {
+		return m.Salt
+	}
+	return ""
+}
+
+func (m *CreatePipelineRequest) GetDryRun() bool {
+	if m != nil {
+		return m.DryRun
+	}
+	return false
+}
+
+func (m *CreatePipelineRequest) GetDatumCacheTTL() *google_protobuf2.Duration {
+	if m != nil {
+		return m.DatumCacheTTL
+	}
+	return nil
+}
+
+func (m *CreatePipelineRequest) GetSchedulingSpec() *SchedulingSpec {
+	if m != nil {
+		return m.SchedulingSpec
+	}
+	return nil
+}
+
 type InspectPipelineRequest struct {
 	Pipeline *Pipeline `protobuf:"bytes,1,opt,name=pipeline" json:"pipeline,omitempty"`
 }
@@ -1530,7 +2255,7 @@ type InspectPipelineRequest struct {
 func (m *InspectPipelineRequest) Reset()                    { *m = InspectPipelineRequest{} }
 func (m *InspectPipelineRequest) String() string            { return proto.CompactTextString(m) }
 func (*InspectPipelineRequest) ProtoMessage()               {}
-func (*InspectPipelineRequest) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{28} }
+func (*InspectPipelineRequest) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{36} }
 
 func (m *InspectPipelineRequest) GetPipeline() *Pipeline {
 	if m != nil {
@@ -1545,7 +2270,7 @@ type ListPipelineRequest struct {
 func (m *ListPipelineRequest) Reset()                    { *m = ListPipelineRequest{} }
 func (m *ListPipelineRequest) String() string            { return proto.CompactTextString(m) }
 func (*ListPipelineRequest) ProtoMessage()               {}
-func (*ListPipelineRequest) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{29} }
+func (*ListPipelineRequest) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{37} }
 
 type DeletePipelineRequest struct {
 	Pipeline   *Pipeline `protobuf:"bytes,1,opt,name=pipeline" json:"pipeline,omitempty"`
@@ -1555,7 +2280,7 @@ type DeletePipelineRequest struct {
 func (m *DeletePipelineRequest) Reset()                    { *m = DeletePipelineRequest{} }
 func (m *DeletePipelineRequest) String() string            { return proto.CompactTextString(m) }
 func (*DeletePipelineRequest) ProtoMessage()               {}
-func (*DeletePipelineRequest) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{30} }
+func (*DeletePipelineRequest) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{38} }
 
 func (m *DeletePipelineRequest) GetPipeline() *Pipeline {
 	if m != nil {
@@ -1578,7 +2303,7 @@ type StartPipelineRequest struct {
 func (m *StartPipelineRequest) Reset()                    { *m = StartPipelineRequest{} }
 func (m *StartPipelineRequest) String() string            { return proto.CompactTextString(m) }
 func (*StartPipelineRequest) ProtoMessage()               {}
-func (*StartPipelineRequest) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{31} }
+func (*StartPipelineRequest) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{39} }
 
 func (m *StartPipelineRequest) GetPipeline() *Pipeline {
 	if m != nil {
@@ -1594,7 +2319,7 @@ type StopPipelineRequest struct {
 func (m *StopPipelineRequest) Reset()                    { *m = StopPipelineRequest{} }
 func (m *StopPipelineRequest) String() string            { return proto.CompactTextString(m) }
 func (*StopPipelineRequest) ProtoMessage()               {}
-func (*StopPipelineRequest) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{32} }
+func (*StopPipelineRequest) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{40} }
 
 func (m *StopPipelineRequest) GetPipeline() *Pipeline {
 	if m != nil {
@@ -1603,34 +2328,543 @@ func (m *StopPipelineRequest) GetPipeline() *Pipeline {
 	return nil
 }
 
-type RerunPipelineRequest struct {
-	Pipeline *Pipeline     `protobuf:"bytes,1,opt,name=pipeline" json:"pipeline,omitempty"`
-	Exclude  []*pfs.Commit `protobuf:"bytes,2,rep,name=exclude" json:"exclude,omitempty"`
-	Include  []*pfs.Commit `protobuf:"bytes,3,rep,name=include" json:"include,omitempty"`
+type RerunPipelineRequest struct {
+	Pipeline *Pipeline     `protobuf:"bytes,1,opt,name=pipeline" json:"pipeline,omitempty"`
+	Exclude  []*pfs.Commit `protobuf:"bytes,2,rep,name=exclude" json:"exclude,omitempty"`
+	Include  []*pfs.Commit `protobuf:"bytes,3,rep,name=include" json:"include,omitempty"`
+}
+
+func (m *RerunPipelineRequest) Reset()                    { *m = RerunPipelineRequest{} }
+func (m *RerunPipelineRequest) String() string            { return proto.CompactTextString(m) }
+func (*RerunPipelineRequest) ProtoMessage()               {}
+func (*RerunPipelineRequest) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{41} }
+
+func (m *RerunPipelineRequest) GetPipeline() *Pipeline {
+	if m != nil {
+		return m.Pipeline
+	}
+	return nil
+}
+
+func (m *RerunPipelineRequest) GetExclude() []*pfs.Commit {
+	if m != nil {
+		return m.Exclude
+	}
+	return nil
+}
+
+func (m *RerunPipelineRequest) GetInclude() []*pfs.Commit {
+	if m != nil {
+		return m.Include
+	}
+	return nil
+}
+
+type ClearDatumCacheRequest struct {
+	Pipeline *Pipeline `protobuf:"bytes,1,opt,name=pipeline" json:"pipeline,omitempty"`
+	// glob matches the paths of the input files of the datums whose outputs
+	// are cleared. If it's empty, every datum's output is cleared.
+	Glob string `protobuf:"bytes,2,opt,name=glob,proto3" json:"glob,omitempty"`
+}
+
+func (m *ClearDatumCacheRequest) Reset()                    { *m = ClearDatumCacheRequest{} }
+func (m *ClearDatumCacheRequest) String() string            { return proto.CompactTextString(m) }
+func (*ClearDatumCacheRequest) ProtoMessage()               {}
+func (*ClearDatumCacheRequest) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{42} }
+
+func (m *ClearDatumCacheRequest) GetPipeline() *Pipeline {
+	if m != nil {
+		return m.Pipeline
+	}
+	return nil
+}
+
+func (m *ClearDatumCacheRequest) GetGlob() string {
+	if m != nil {
+		return m.Glob
+	}
+	return ""
+}
+
+type RunPipelineRequest struct {
+	Pipeline *Pipeline `protobuf:"bytes,1,opt,name=pipeline" json:"pipeline,omitempty"`
+	// provenance is the set of input commits to run the pipeline on. Inputs
+	// which aren't given a commit use the head of their branch.
+	Provenance []*pfs.Commit `protobuf:"bytes,2,rep,name=provenance" json:"provenance,omitempty"`
+}
+
+func (m *RunPipelineRequest) Reset()                    { *m = RunPipelineRequest{} }
+func (m *RunPipelineRequest) String() string            { return proto.CompactTextString(m) }
+func (*RunPipelineRequest) ProtoMessage()               {}
+func (*RunPipelineRequest) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{43} }
+
+func (m *RunPipelineRequest) GetPipeline() *Pipeline {
+	if m != nil {
+		return m.Pipeline
+	}
+	return nil
+}
+
+func (m *RunPipelineRequest) GetProvenance() []*pfs.Commit {
+	if m != nil {
+		return m.Provenance
+	}
+	return nil
+}
+
+type TriggerPipelineRequest struct {
+	Pipeline *Pipeline `protobuf:"bytes,1,opt,name=pipeline" json:"pipeline,omitempty"`
+	// key identifies the run, e.g. an Airflow task instance's
+	// "<dag>.<task>.<execution date>". The first request with a key creates a
+	// job, and later requests with the same key (e.g. an orchestrator's
+	// retries) return that job rather than creating another.
+	Key string `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	// provenance is as in RunPipelineRequest. It's only used by the request
+	// that creates the job.
+	Provenance []*pfs.Commit `protobuf:"bytes,3,rep,name=provenance" json:"provenance,omitempty"`
+}
+
+func (m *TriggerPipelineRequest) Reset()                    { *m = TriggerPipelineRequest{} }
+func (m *TriggerPipelineRequest) String() string            { return proto.CompactTextString(m) }
+func (*TriggerPipelineRequest) ProtoMessage()               {}
+func (*TriggerPipelineRequest) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{44} }
+
+func (m *TriggerPipelineRequest) GetPipeline() *Pipeline {
+	if m != nil {
+		return m.Pipeline
+	}
+	return nil
+}
+
+func (m *TriggerPipelineRequest) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *TriggerPipelineRequest) GetProvenance() []*pfs.Commit {
+	if m != nil {
+		return m.Provenance
+	}
+	return nil
+}
+
+type InspectTriggerRequest struct {
+	Pipeline   *Pipeline `protobuf:"bytes,1,opt,name=pipeline" json:"pipeline,omitempty"`
+	Key        string    `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	BlockState bool      `protobuf:"varint,3,opt,name=block_state,json=blockState,proto3" json:"block_state,omitempty"`
+}
+
+func (m *InspectTriggerRequest) Reset()                    { *m = InspectTriggerRequest{} }
+func (m *InspectTriggerRequest) String() string            { return proto.CompactTextString(m) }
+func (*InspectTriggerRequest) ProtoMessage()               {}
+func (*InspectTriggerRequest) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{45} }
+
+func (m *InspectTriggerRequest) GetPipeline() *Pipeline {
+	if m != nil {
+		return m.Pipeline
+	}
+	return nil
+}
+
+func (m *InspectTriggerRequest) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *InspectTriggerRequest) GetBlockState() bool {
+	if m != nil {
+		return m.BlockState
+	}
+	return false
+}
+
+// AllowedEgress is a destination that a pipeline's workers may connect to.
+type AllowedEgress struct {
+	// CIDR is a block of IP addresses, e.g. "10.1.2.0/24".
+	CIDR string `protobuf:"bytes,1,opt,name=cidr,proto3" json:"cidr,omitempty"`
+	// Ports, if set, are the TCP ports that the workers may connect to, rather
+	// than all of them.
+	Ports []int32 `protobuf:"varint,2,rep,packed,name=ports" json:"ports,omitempty"`
+}
+
+func (m *AllowedEgress) Reset()                    { *m = AllowedEgress{} }
+func (m *AllowedEgress) String() string            { return proto.CompactTextString(m) }
+func (*AllowedEgress) ProtoMessage()               {}
+func (*AllowedEgress) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{46} }
+
+func (m *AllowedEgress) GetCIDR() string {
+	if m != nil {
+		return m.CIDR
+	}
+	return ""
+}
+
+func (m *AllowedEgress) GetPorts() []int32 {
+	if m != nil {
+		return m.Ports
+	}
+	return nil
+}
+
+// UserMetric is a metric that user code reported, by sending it to the
+// statsd address in its PACH_STATSD_ADDR env var. A job's counters are the
+// sums of its datums' counters, its gauges are the values that its datums
+// last reported.
+type UserMetric struct {
+	Name  string         `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Type  UserMetricType `protobuf:"varint,2,opt,name=type,proto3,enum=pps.UserMetricType" json:"type,omitempty"`
+	Value float64        `protobuf:"fixed64,3,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (m *UserMetric) Reset()                    { *m = UserMetric{} }
+func (m *UserMetric) String() string            { return proto.CompactTextString(m) }
+func (*UserMetric) ProtoMessage()               {}
+func (*UserMetric) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{47} }
+
+func (m *UserMetric) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *UserMetric) GetType() UserMetricType {
+	if m != nil {
+		return m.Type
+	}
+	return UserMetricType_COUNTER
+}
+
+func (m *UserMetric) GetValue() float64 {
+	if m != nil {
+		return m.Value
+	}
+	return 0
+}
+
+// Rendezvous tells each of a pipeline's workers its rank, the number of
+// workers and the address of the rank 0 worker, in the environment of its
+// code, so that distributed training frameworks can connect the workers.
+type Rendezvous struct {
+	// port is the port that the rank 0 worker's code listens on for the
+	// others (MASTER_PORT). If it's 0, it's 29500, torch.distributed's default.
+	Port int32 `protobuf:"varint,1,opt,name=port,proto3" json:"port,omitempty"`
+}
+
+func (m *Rendezvous) Reset()                    { *m = Rendezvous{} }
+func (m *Rendezvous) String() string            { return proto.CompactTextString(m) }
+func (*Rendezvous) ProtoMessage()               {}
+func (*Rendezvous) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{48} }
+
+func (m *Rendezvous) GetPort() int32 {
+	if m != nil {
+		return m.Port
+	}
+	return 0
+}
+
+// SQLEgress loads the CSV and JSON files of a job's output into a database,
+// once the job succeeds. Each file is loaded into the table named by its
+// top-level path component, without its extension, e.g. both /users.csv and
+// /users/part-1.json are loaded into the table "users".
+type SQLEgress struct {
+	// URL is the database's, e.g. "postgres://user@host:5432/db" (or its JDBC
+	// URL, "jdbc:postgresql://user@host:5432/db") or
+	// "snowflake://user@account/db/schema?warehouse=wh".
+	URL string `protobuf:"bytes,1,opt,name=URL,json=uRL,proto3" json:"URL,omitempty"`
+	// secret is the kubernetes secret, in pachd's namespace, with the
+	// credentials of URL's user: its "password" for postgres, or its
+	// "private_key" (a PEM-encoded RSA key) for snowflake.
+	Secret string `protobuf:"bytes,2,opt,name=secret,proto3" json:"secret,omitempty"`
+	// truncate, if set, empties the tables before the output is loaded into
+	// them, so that they only hold the latest job's output.
+	Truncate bool `protobuf:"varint,3,opt,name=truncate,proto3" json:"truncate,omitempty"`
+}
+
+func (m *SQLEgress) Reset()                    { *m = SQLEgress{} }
+func (m *SQLEgress) String() string            { return proto.CompactTextString(m) }
+func (*SQLEgress) ProtoMessage()               {}
+func (*SQLEgress) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{49} }
+
+func (m *SQLEgress) GetURL() string {
+	if m != nil {
+		return m.URL
+	}
+	return ""
+}
+
+func (m *SQLEgress) GetSecret() string {
+	if m != nil {
+		return m.Secret
+	}
+	return ""
+}
+
+func (m *SQLEgress) GetTruncate() bool {
+	if m != nil {
+		return m.Truncate
+	}
+	return false
+}
+
+// SQLEgressLoad is the number of rows that a SQL egress loaded into a table.
+type SQLEgressLoad struct {
+	Table string `protobuf:"bytes,1,opt,name=table,proto3" json:"table,omitempty"`
+	Rows  int64  `protobuf:"varint,2,opt,name=rows,proto3" json:"rows,omitempty"`
+}
+
+func (m *SQLEgressLoad) Reset()                    { *m = SQLEgressLoad{} }
+func (m *SQLEgressLoad) String() string            { return proto.CompactTextString(m) }
+func (*SQLEgressLoad) ProtoMessage()               {}
+func (*SQLEgressLoad) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{50} }
+
+func (m *SQLEgressLoad) GetTable() string {
+	if m != nil {
+		return m.Table
+	}
+	return ""
+}
+
+func (m *SQLEgressLoad) GetRows() int64 {
+	if m != nil {
+		return m.Rows
+	}
+	return 0
+}
+
+// SQLInput is an input whose repo pachd fills with snapshots of the results
+// of a SQL query, which it runs on a cron schedule. Each snapshot replaces
+// the last, and jobs process them like an atom input's commits, with each
+// snapshot being a single datum.
+type SQLInput struct {
+	// name is the input's name. Each job sees the snapshot that it processes
+	// in /pfs/<name>.
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// URL and secret are the database's URL, and the kubernetes secret with
+	// its credentials, as in SQLEgress.
+	URL    string `protobuf:"bytes,2,opt,name=URL,json=uRL,proto3" json:"URL,omitempty"`
+	Secret string `protobuf:"bytes,3,opt,name=secret,proto3" json:"secret,omitempty"`
+	// query is the SQL query whose results are snapshotted.
+	Query string `protobuf:"bytes,4,opt,name=query,proto3" json:"query,omitempty"`
+	// spec is the cron schedule that the query is run on, in UTC, e.g.
+	// "0 * * * *", "@daily" or "@every 15m".
+	Spec string `protobuf:"bytes,5,opt,name=spec,proto3" json:"spec,omitempty"`
+	// format is the format of the snapshots, "csv" (the default) or
+	// "parquet".
+	Format string `protobuf:"bytes,6,opt,name=format,proto3" json:"format,omitempty"`
+	// repo is the repo that snapshots are committed to, on its master
+	// branch. It defaults to "<pipeline>_<name>".
+	Repo string `protobuf:"bytes,7,opt,name=repo,proto3" json:"repo,omitempty"`
+	// commit is the snapshot that a job processes.
+	Commit string `protobuf:"bytes,8,opt,name=commit,proto3" json:"commit,omitempty"`
+}
+
+func (m *SQLInput) Reset()                    { *m = SQLInput{} }
+func (m *SQLInput) String() string            { return proto.CompactTextString(m) }
+func (*SQLInput) ProtoMessage()               {}
+func (*SQLInput) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{51} }
+
+func (m *SQLInput) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *SQLInput) GetURL() string {
+	if m != nil {
+		return m.URL
+	}
+	return ""
+}
+
+func (m *SQLInput) GetSecret() string {
+	if m != nil {
+		return m.Secret
+	}
+	return ""
+}
+
+func (m *SQLInput) GetQuery() string {
+	if m != nil {
+		return m.Query
+	}
+	return ""
+}
+
+func (m *SQLInput) GetSpec() string {
+	if m != nil {
+		return m.Spec
+	}
+	return ""
+}
+
+func (m *SQLInput) GetFormat() string {
+	if m != nil {
+		return m.Format
+	}
+	return ""
+}
+
+func (m *SQLInput) GetRepo() string {
+	if m != nil {
+		return m.Repo
+	}
+	return ""
+}
+
+func (m *SQLInput) GetCommit() string {
+	if m != nil {
+		return m.Commit
+	}
+	return ""
+}
+
+// TransactionOp is one of the operations of a transaction. Exactly one of
+// its fields is set.
+type TransactionOp struct {
+	StartCommit  *pfs.StartCommitRequest  `protobuf:"bytes,1,opt,name=start_commit,json=startCommit" json:"start_commit,omitempty"`
+	FinishCommit *pfs.FinishCommitRequest `protobuf:"bytes,2,opt,name=finish_commit,json=finishCommit" json:"finish_commit,omitempty"`
+	// delete_file's file must be in a commit that the transaction starts or
+	// finishes, so that the deletion can be rolled back.
+	DeleteFile     *pfs.DeleteFileRequest `protobuf:"bytes,3,opt,name=delete_file,json=deleteFile" json:"delete_file,omitempty"`
+	CreatePipeline *CreatePipelineRequest `protobuf:"bytes,4,opt,name=create_pipeline,json=createPipeline" json:"create_pipeline,omitempty"`
+}
+
+func (m *TransactionOp) Reset()                    { *m = TransactionOp{} }
+func (m *TransactionOp) String() string            { return proto.CompactTextString(m) }
+func (*TransactionOp) ProtoMessage()               {}
+func (*TransactionOp) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{52} }
+
+func (m *TransactionOp) GetStartCommit() *pfs.StartCommitRequest {
+	if m != nil {
+		return m.StartCommit
+	}
+	return nil
+}
+
+func (m *TransactionOp) GetFinishCommit() *pfs.FinishCommitRequest {
+	if m != nil {
+		return m.FinishCommit
+	}
+	return nil
+}
+
+func (m *TransactionOp) GetDeleteFile() *pfs.DeleteFileRequest {
+	if m != nil {
+		return m.DeleteFile
+	}
+	return nil
+}
+
+func (m *TransactionOp) GetCreatePipeline() *CreatePipelineRequest {
+	if m != nil {
+		return m.CreatePipeline
+	}
+	return nil
+}
+
+type RunTransactionRequest struct {
+	Ops []*TransactionOp `protobuf:"bytes,1,rep,name=ops" json:"ops,omitempty"`
+}
+
+func (m *RunTransactionRequest) Reset()                    { *m = RunTransactionRequest{} }
+func (m *RunTransactionRequest) String() string            { return proto.CompactTextString(m) }
+func (*RunTransactionRequest) ProtoMessage()               {}
+func (*RunTransactionRequest) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{53} }
+
+func (m *RunTransactionRequest) GetOps() []*TransactionOp {
+	if m != nil {
+		return m.Ops
+	}
+	return nil
+}
+
+type TransactionInfo struct {
+	// transaction is the transaction that the commits of finish_commit ops
+	// were finished in, it's unset if there were none.
+	Transaction *pfs.Transaction `protobuf:"bytes,1,opt,name=transaction" json:"transaction,omitempty"`
+	// started are the commits that start_commit ops started, in order.
+	Started []*pfs.Commit `protobuf:"bytes,2,rep,name=started" json:"started,omitempty"`
+}
+
+func (m *TransactionInfo) Reset()                    { *m = TransactionInfo{} }
+func (m *TransactionInfo) String() string            { return proto.CompactTextString(m) }
+func (*TransactionInfo) ProtoMessage()               {}
+func (*TransactionInfo) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{54} }
+
+func (m *TransactionInfo) GetTransaction() *pfs.Transaction {
+	if m != nil {
+		return m.Transaction
+	}
+	return nil
+}
+
+func (m *TransactionInfo) GetStarted() []*pfs.Commit {
+	if m != nil {
+		return m.Started
+	}
+	return nil
+}
+
+// DryRunInfo is what a pipeline or job would be, had it been created.
+type DryRunInfo struct {
+	// pipeline_info is the pipeline, with the defaults that pachd fills in,
+	// for DryRunPipeline, and job_info the job for DryRunJob.
+	PipelineInfo *PipelineInfo `protobuf:"bytes,1,opt,name=pipeline_info,json=pipelineInfo" json:"pipeline_info,omitempty"`
+	JobInfo      *JobInfo      `protobuf:"bytes,2,opt,name=job_info,json=jobInfo" json:"job_info,omitempty"`
+	// image_digest is the digest of the image, as its registry resolves it.
+	// It's empty if the registry couldn't be asked, see warnings.
+	ImageDigest string `protobuf:"bytes,3,opt,name=image_digest,json=imageDigest,proto3" json:"image_digest,omitempty"`
+	// datums are the datums that the pipeline's first job would process, on
+	// the heads of its input branches, or the job's. A pipeline whose input
+	// branches don't all have commits yet wouldn't run, so has none.
+	Datums []*DatumInfo `protobuf:"bytes,4,rep,name=datums" json:"datums,omitempty"`
+	// warnings are the checks that couldn't be made, e.g. because pachd
+	// can't reach the image's registry.
+	Warnings []string `protobuf:"bytes,5,rep,name=warnings" json:"warnings,omitempty"`
+}
+
+func (m *DryRunInfo) Reset()                    { *m = DryRunInfo{} }
+func (m *DryRunInfo) String() string            { return proto.CompactTextString(m) }
+func (*DryRunInfo) ProtoMessage()               {}
+func (*DryRunInfo) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{55} }
+
+func (m *DryRunInfo) GetPipelineInfo() *PipelineInfo {
+	if m != nil {
+		return m.PipelineInfo
+	}
+	return nil
+}
+
+func (m *DryRunInfo) GetJobInfo() *JobInfo {
+	if m != nil {
+		return m.JobInfo
+	}
+	return nil
 }
 
-func (m *RerunPipelineRequest) Reset()                    { *m = RerunPipelineRequest{} }
-func (m *RerunPipelineRequest) String() string            { return proto.CompactTextString(m) }
-func (*RerunPipelineRequest) ProtoMessage()               {}
-func (*RerunPipelineRequest) Descriptor() ([]byte, []int) { return fileDescriptorPps, []int{33} }
-
-func (m *RerunPipelineRequest) GetPipeline() *Pipeline {
+func (m *DryRunInfo) GetImageDigest() string {
 	if m != nil {
-		return m.Pipeline
+		return m.ImageDigest
 	}
-	return nil
+	return ""
 }
 
-func (m *RerunPipelineRequest) GetExclude() []*pfs.Commit {
+func (m *DryRunInfo) GetDatums() []*DatumInfo {
 	if m != nil {
-		return m.Exclude
+		return m.Datums
 	}
 	return nil
 }
 
-func (m *RerunPipelineRequest) GetInclude() []*pfs.Commit {
+func (m *DryRunInfo) GetWarnings() []string {
 	if m != nil {
-		return m.Include
+		return m.Warnings
 	}
 	return nil
 }
@@ -1648,21 +2882,29 @@ func init() {
 	proto.RegisterType((*Datum)(nil), "pps.Datum")
 	proto.RegisterType((*WorkerStatus)(nil), "pps.WorkerStatus")
 	proto.RegisterType((*ResourceSpec)(nil), "pps.ResourceSpec")
+	proto.RegisterType((*SchedulingSpec)(nil), "pps.SchedulingSpec")
 	proto.RegisterType((*JobInfo)(nil), "pps.JobInfo")
 	proto.RegisterType((*Worker)(nil), "pps.Worker")
 	proto.RegisterType((*JobInfos)(nil), "pps.JobInfos")
 	proto.RegisterType((*Pipeline)(nil), "pps.Pipeline")
 	proto.RegisterType((*PipelineInput)(nil), "pps.PipelineInput")
+	proto.RegisterType((*DatumCacheInvalidation)(nil), "pps.DatumCacheInvalidation")
 	proto.RegisterType((*PipelineInfo)(nil), "pps.PipelineInfo")
 	proto.RegisterType((*PipelineInfos)(nil), "pps.PipelineInfos")
 	proto.RegisterType((*CreateJobRequest)(nil), "pps.CreateJobRequest")
 	proto.RegisterType((*InspectJobRequest)(nil), "pps.InspectJobRequest")
 	proto.RegisterType((*ListJobRequest)(nil), "pps.ListJobRequest")
+	proto.RegisterType((*FlushJobRequest)(nil), "pps.FlushJobRequest")
 	proto.RegisterType((*DeleteJobRequest)(nil), "pps.DeleteJobRequest")
 	proto.RegisterType((*StopJobRequest)(nil), "pps.StopJobRequest")
 	proto.RegisterType((*GetLogsRequest)(nil), "pps.GetLogsRequest")
 	proto.RegisterType((*LogMessage)(nil), "pps.LogMessage")
 	proto.RegisterType((*RestartDatumRequest)(nil), "pps.RestartDatumRequest")
+	proto.RegisterType((*ProcessStats)(nil), "pps.ProcessStats")
+	proto.RegisterType((*DatumInfo)(nil), "pps.DatumInfo")
+	proto.RegisterType((*DatumInfos)(nil), "pps.DatumInfos")
+	proto.RegisterType((*ListDatumRequest)(nil), "pps.ListDatumRequest")
+	proto.RegisterType((*InspectDatumRequest)(nil), "pps.InspectDatumRequest")
 	proto.RegisterType((*CreatePipelineRequest)(nil), "pps.CreatePipelineRequest")
 	proto.RegisterType((*InspectPipelineRequest)(nil), "pps.InspectPipelineRequest")
 	proto.RegisterType((*ListPipelineRequest)(nil), "pps.ListPipelineRequest")
@@ -1670,9 +2912,25 @@ func init() {
 	proto.RegisterType((*StartPipelineRequest)(nil), "pps.StartPipelineRequest")
 	proto.RegisterType((*StopPipelineRequest)(nil), "pps.StopPipelineRequest")
 	proto.RegisterType((*RerunPipelineRequest)(nil), "pps.RerunPipelineRequest")
+	proto.RegisterType((*ClearDatumCacheRequest)(nil), "pps.ClearDatumCacheRequest")
+	proto.RegisterType((*RunPipelineRequest)(nil), "pps.RunPipelineRequest")
+	proto.RegisterType((*TriggerPipelineRequest)(nil), "pps.TriggerPipelineRequest")
+	proto.RegisterType((*InspectTriggerRequest)(nil), "pps.InspectTriggerRequest")
+	proto.RegisterType((*AllowedEgress)(nil), "pps.AllowedEgress")
+	proto.RegisterType((*UserMetric)(nil), "pps.UserMetric")
+	proto.RegisterType((*Rendezvous)(nil), "pps.Rendezvous")
+	proto.RegisterType((*SQLEgress)(nil), "pps.SQLEgress")
+	proto.RegisterType((*SQLEgressLoad)(nil), "pps.SQLEgressLoad")
+	proto.RegisterType((*SQLInput)(nil), "pps.SQLInput")
+	proto.RegisterType((*TransactionOp)(nil), "pps.TransactionOp")
+	proto.RegisterType((*RunTransactionRequest)(nil), "pps.RunTransactionRequest")
+	proto.RegisterType((*TransactionInfo)(nil), "pps.TransactionInfo")
+	proto.RegisterType((*DryRunInfo)(nil), "pps.DryRunInfo")
 	proto.RegisterEnum("pps.JobState", JobState_name, JobState_value)
 	proto.RegisterEnum("pps.WorkerState", WorkerState_name, WorkerState_value)
 	proto.RegisterEnum("pps.PipelineState", PipelineState_name, PipelineState_value)
+	proto.RegisterEnum("pps.DatumState", DatumState_name, DatumState_value)
+	proto.RegisterEnum("pps.UserMetricType", UserMetricType_name, UserMetricType_value)
 	proto.RegisterEnum("pps.ParallelismSpec_Strategy", ParallelismSpec_Strategy_name, ParallelismSpec_Strategy_value)
 }
 
@@ -1690,9 +2948,14 @@ type APIClient interface {
 	CreateJob(ctx context.Context, in *CreateJobRequest, opts ...grpc.CallOption) (*Job, error)
 	InspectJob(ctx context.Context, in *InspectJobRequest, opts ...grpc.CallOption) (*JobInfo, error)
 	ListJob(ctx context.Context, in *ListJobRequest, opts ...grpc.CallOption) (*JobInfos, error)
+	// FlushJob blocks until all of the jobs which have a set of commits as
+	// provenance have finished, and returns them.
+	FlushJob(ctx context.Context, in *FlushJobRequest, opts ...grpc.CallOption) (API_FlushJobClient, error)
 	DeleteJob(ctx context.Context, in *DeleteJobRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
 	StopJob(ctx context.Context, in *StopJobRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
 	RestartDatum(ctx context.Context, in *RestartDatumRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+	ListDatum(ctx context.Context, in *ListDatumRequest, opts ...grpc.CallOption) (*DatumInfos, error)
+	InspectDatum(ctx context.Context, in *InspectDatumRequest, opts ...grpc.CallOption) (*DatumInfo, error)
 	CreatePipeline(ctx context.Context, in *CreatePipelineRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
 	InspectPipeline(ctx context.Context, in *InspectPipelineRequest, opts ...grpc.CallOption) (*PipelineInfo, error)
 	ListPipeline(ctx context.Context, in *ListPipelineRequest, opts ...grpc.CallOption) (*PipelineInfos, error)
@@ -1700,8 +2963,32 @@ type APIClient interface {
 	StartPipeline(ctx context.Context, in *StartPipelineRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
 	StopPipeline(ctx context.Context, in *StopPipelineRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
 	RerunPipeline(ctx context.Context, in *RerunPipelineRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+	// ClearDatumCache stops a pipeline reusing the outputs of some or all of
+	// its datums from earlier jobs, so that its next job processes them again.
+	ClearDatumCache(ctx context.Context, in *ClearDatumCacheRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+	// RunPipeline creates a job for a pipeline on a specific set of input
+	// commits, rather than waiting for new commits to arrive.
+	RunPipeline(ctx context.Context, in *RunPipelineRequest, opts ...grpc.CallOption) (*Job, error)
+	// TriggerPipeline is an idempotent RunPipeline, for external schedulers:
+	// it creates one job per pipeline and key, however many times it's called.
+	TriggerPipeline(ctx context.Context, in *TriggerPipelineRequest, opts ...grpc.CallOption) (*Job, error)
+	// InspectTrigger returns info about the job that TriggerPipeline created
+	// for a key, optionally waiting for it to finish.
+	InspectTrigger(ctx context.Context, in *InspectTriggerRequest, opts ...grpc.CallOption) (*JobInfo, error)
+	// RunTransaction applies a set of PFS and PPS operations atomically. The
+	// commits that it finishes are finished together, so pipelines that take
+	// several of them as inputs run a single job on all of them.
+	RunTransaction(ctx context.Context, in *RunTransactionRequest, opts ...grpc.CallOption) (*TransactionInfo, error)
+	// DryRunPipeline and DryRunJob validate a pipeline or job as
+	// CreatePipeline and CreateJob do, and return what would be created,
+	// without creating anything.
+	DryRunPipeline(ctx context.Context, in *CreatePipelineRequest, opts ...grpc.CallOption) (*DryRunInfo, error)
+	DryRunJob(ctx context.Context, in *CreateJobRequest, opts ...grpc.CallOption) (*DryRunInfo, error)
 	// DeleteAll deletes everything
 	DeleteAll(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+	// GetLogs returns the log lines of a pipeline's or a job's workers, merged
+	// in the order they were logged, and tagged with the worker that logged
+	// them.
 	GetLogs(ctx context.Context, in *GetLogsRequest, opts ...grpc.CallOption) (API_GetLogsClient, error)
 }
 
@@ -1740,6 +3027,38 @@ func (c *aPIClient) ListJob(ctx context.Context, in *ListJobRequest, opts ...grp
 	return out, nil
 }
 
+func (c *aPIClient) FlushJob(ctx context.Context, in *FlushJobRequest, opts ...grpc.CallOption) (API_FlushJobClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_API_serviceDesc.Streams[0], c.cc, "/pps.API/FlushJob", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &aPIFlushJobClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type API_FlushJobClient interface {
+	Recv() (*JobInfo, error)
+	grpc.ClientStream
+}
+
+type aPIFlushJobClient struct {
+	grpc.ClientStream
+}
+
+func (x *aPIFlushJobClient) Recv() (*JobInfo, error) {
+	m := new(JobInfo)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *aPIClient) DeleteJob(ctx context.Context, in *DeleteJobRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
 	out := new(google_protobuf.Empty)
 	err := grpc.Invoke(ctx, "/pps.API/DeleteJob", in, out, c.cc, opts...)
@@ -1767,6 +3086,24 @@ func (c *aPIClient) RestartDatum(ctx context.Context, in *RestartDatumRequest, o
 	return out, nil
 }
 
+func (c *aPIClient) ListDatum(ctx context.Context, in *ListDatumRequest, opts ...grpc.CallOption) (*DatumInfos, error) {
+	out := new(DatumInfos)
+	err := grpc.Invoke(ctx, "/pps.API/ListDatum", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) InspectDatum(ctx context.Context, in *InspectDatumRequest, opts ...grpc.CallOption) (*DatumInfo, error) {
+	out := new(DatumInfo)
+	err := grpc.Invoke(ctx, "/pps.API/InspectDatum", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *aPIClient) CreatePipeline(ctx context.Context, in *CreatePipelineRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
 	out := new(google_protobuf.Empty)
 	err := grpc.Invoke(ctx, "/pps.API/CreatePipeline", in, out, c.cc, opts...)
@@ -1830,6 +3167,69 @@ func (c *aPIClient) RerunPipeline(ctx context.Context, in *RerunPipelineRequest,
 	return out, nil
 }
 
+func (c *aPIClient) ClearDatumCache(ctx context.Context, in *ClearDatumCacheRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
+	out := new(google_protobuf.Empty)
+	err := grpc.Invoke(ctx, "/pps.API/ClearDatumCache", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) RunPipeline(ctx context.Context, in *RunPipelineRequest, opts ...grpc.CallOption) (*Job, error) {
+	out := new(Job)
+	err := grpc.Invoke(ctx, "/pps.API/RunPipeline", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) TriggerPipeline(ctx context.Context, in *TriggerPipelineRequest, opts ...grpc.CallOption) (*Job, error) {
+	out := new(Job)
+	err := grpc.Invoke(ctx, "/pps.API/TriggerPipeline", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) InspectTrigger(ctx context.Context, in *InspectTriggerRequest, opts ...grpc.CallOption) (*JobInfo, error) {
+	out := new(JobInfo)
+	err := grpc.Invoke(ctx, "/pps.API/InspectTrigger", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) RunTransaction(ctx context.Context, in *RunTransactionRequest, opts ...grpc.CallOption) (*TransactionInfo, error) {
+	out := new(TransactionInfo)
+	err := grpc.Invoke(ctx, "/pps.API/RunTransaction", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) DryRunPipeline(ctx context.Context, in *CreatePipelineRequest, opts ...grpc.CallOption) (*DryRunInfo, error) {
+	out := new(DryRunInfo)
+	err := grpc.Invoke(ctx, "/pps.API/DryRunPipeline", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) DryRunJob(ctx context.Context, in *CreateJobRequest, opts ...grpc.CallOption) (*DryRunInfo, error) {
+	out := new(DryRunInfo)
+	err := grpc.Invoke(ctx, "/pps.API/DryRunJob", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *aPIClient) DeleteAll(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
 	out := new(google_protobuf.Empty)
 	err := grpc.Invoke(ctx, "/pps.API/DeleteAll", in, out, c.cc, opts...)
@@ -1840,7 +3240,7 @@ func (c *aPIClient) DeleteAll(ctx context.Context, in *google_protobuf.Empty, op
 }
 
 func (c *aPIClient) GetLogs(ctx context.Context, in *GetLogsRequest, opts ...grpc.CallOption) (API_GetLogsClient, error) {
-	stream, err := grpc.NewClientStream(ctx, &_API_serviceDesc.Streams[0], c.cc, "/pps.API/GetLogs", opts...)
+	stream, err := grpc.NewClientStream(ctx, &_API_serviceDesc.Streams[1], c.cc, "/pps.API/GetLogs", opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -1877,9 +3277,14 @@ type APIServer interface {
 	CreateJob(context.Context, *CreateJobRequest) (*Job, error)
 	InspectJob(context.Context, *InspectJobRequest) (*JobInfo, error)
 	ListJob(context.Context, *ListJobRequest) (*JobInfos, error)
+	// FlushJob blocks until all of the jobs which have a set of commits as
+	// provenance have finished, and returns them.
+	FlushJob(*FlushJobRequest, API_FlushJobServer) error
 	DeleteJob(context.Context, *DeleteJobRequest) (*google_protobuf.Empty, error)
 	StopJob(context.Context, *StopJobRequest) (*google_protobuf.Empty, error)
 	RestartDatum(context.Context, *RestartDatumRequest) (*google_protobuf.Empty, error)
+	ListDatum(context.Context, *ListDatumRequest) (*DatumInfos, error)
+	InspectDatum(context.Context, *InspectDatumRequest) (*DatumInfo, error)
 	CreatePipeline(context.Context, *CreatePipelineRequest) (*google_protobuf.Empty, error)
 	InspectPipeline(context.Context, *InspectPipelineRequest) (*PipelineInfo, error)
 	ListPipeline(context.Context, *ListPipelineRequest) (*PipelineInfos, error)
@@ -1887,8 +3292,32 @@ type APIServer interface {
 	StartPipeline(context.Context, *StartPipelineRequest) (*google_protobuf.Empty, error)
 	StopPipeline(context.Context, *StopPipelineRequest) (*google_protobuf.Empty, error)
 	RerunPipeline(context.Context, *RerunPipelineRequest) (*google_protobuf.Empty, error)
+	// ClearDatumCache stops a pipeline reusing the outputs of some or all of
+	// its datums from earlier jobs, so that its next job processes them again.
+	ClearDatumCache(context.Context, *ClearDatumCacheRequest) (*google_protobuf.Empty, error)
+	// RunPipeline creates a job for a pipeline on a specific set of input
+	// commits, rather than waiting for new commits to arrive.
+	RunPipeline(context.Context, *RunPipelineRequest) (*Job, error)
+	// TriggerPipeline is an idempotent RunPipeline, for external schedulers:
+	// it creates one job per pipeline and key, however many times it's called.
+	TriggerPipeline(context.Context, *TriggerPipelineRequest) (*Job, error)
+	// InspectTrigger returns info about the job that TriggerPipeline created
+	// for a key, optionally waiting for it to finish.
+	InspectTrigger(context.Context, *InspectTriggerRequest) (*JobInfo, error)
+	// RunTransaction applies a set of PFS and PPS operations atomically. The
+	// commits that it finishes are finished together, so pipelines that take
+	// several of them as inputs run a single job on all of them.
+	RunTransaction(context.Context, *RunTransactionRequest) (*TransactionInfo, error)
+	// DryRunPipeline and DryRunJob validate a pipeline or job as
+	// CreatePipeline and CreateJob do, and return what would be created,
+	// without creating anything.
+	DryRunPipeline(context.Context, *CreatePipelineRequest) (*DryRunInfo, error)
+	DryRunJob(context.Context, *CreateJobRequest) (*DryRunInfo, error)
 	// DeleteAll deletes everything
 	DeleteAll(context.Context, *google_protobuf.Empty) (*google_protobuf.Empty, error)
+	// GetLogs returns the log lines of a pipeline's or a job's workers, merged
+	// in the order they were logged, and tagged with the worker that logged
+	// them.
 	GetLogs(*GetLogsRequest, API_GetLogsServer) error
 }
 
@@ -1950,6 +3379,27 @@ func _API_ListJob_Handler(srv interface{}, ctx context.Context, dec func(interfa
 	return interceptor(ctx, in, info, handler)
 }
 
+func _API_FlushJob_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(FlushJobRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(APIServer).FlushJob(m, &aPIFlushJobServer{stream})
+}
+
+type API_FlushJobServer interface {
+	Send(*JobInfo) error
+	grpc.ServerStream
+}
+
+type aPIFlushJobServer struct {
+	grpc.ServerStream
+}
+
+func (x *aPIFlushJobServer) Send(m *JobInfo) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _API_DeleteJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(DeleteJobRequest)
 	if err := dec(in); err != nil {
@@ -2004,6 +3454,42 @@ func _API_RestartDatum_Handler(srv interface{}, ctx context.Context, dec func(in
 	return interceptor(ctx, in, info, handler)
 }
 
+func _API_ListDatum_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDatumRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).ListDatum(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pps.API/ListDatum",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).ListDatum(ctx, req.(*ListDatumRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_InspectDatum_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InspectDatumRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).InspectDatum(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pps.API/InspectDatum",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).InspectDatum(ctx, req.(*InspectDatumRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _API_CreatePipeline_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(CreatePipelineRequest)
 	if err := dec(in); err != nil {
@@ -2130,6 +3616,132 @@ func _API_RerunPipeline_Handler(srv interface{}, ctx context.Context, dec func(i
 	return interceptor(ctx, in, info, handler)
 }
 
+func _API_ClearDatumCache_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClearDatumCacheRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).ClearDatumCache(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pps.API/ClearDatumCache",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).ClearDatumCache(ctx, req.(*ClearDatumCacheRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_RunPipeline_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RunPipelineRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).RunPipeline(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pps.API/RunPipeline",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).RunPipeline(ctx, req.(*RunPipelineRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_TriggerPipeline_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TriggerPipelineRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).TriggerPipeline(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pps.API/TriggerPipeline",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).TriggerPipeline(ctx, req.(*TriggerPipelineRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_InspectTrigger_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InspectTriggerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).InspectTrigger(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pps.API/InspectTrigger",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).InspectTrigger(ctx, req.(*InspectTriggerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_RunTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RunTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).RunTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pps.API/RunTransaction",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).RunTransaction(ctx, req.(*RunTransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_DryRunPipeline_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreatePipelineRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).DryRunPipeline(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pps.API/DryRunPipeline",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).DryRunPipeline(ctx, req.(*CreatePipelineRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_DryRunJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).DryRunJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pps.API/DryRunJob",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).DryRunJob(ctx, req.(*CreateJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _API_DeleteAll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(google_protobuf.Empty)
 	if err := dec(in); err != nil {
@@ -2197,6 +3809,14 @@ var _API_serviceDesc = grpc.ServiceDesc{
 			MethodName: "RestartDatum",
 			Handler:    _API_RestartDatum_Handler,
 		},
+		{
+			MethodName: "ListDatum",
+			Handler:    _API_ListDatum_Handler,
+		},
+		{
+			MethodName: "InspectDatum",
+			Handler:    _API_InspectDatum_Handler,
+		},
 		{
 			MethodName: "CreatePipeline",
 			Handler:    _API_CreatePipeline_Handler,
@@ -2225,12 +3845,45 @@ var _API_serviceDesc = grpc.ServiceDesc{
 			MethodName: "RerunPipeline",
 			Handler:    _API_RerunPipeline_Handler,
 		},
+		{
+			MethodName: "ClearDatumCache",
+			Handler:    _API_ClearDatumCache_Handler,
+		},
+		{
+			MethodName: "RunPipeline",
+			Handler:    _API_RunPipeline_Handler,
+		},
+		{
+			MethodName: "TriggerPipeline",
+			Handler:    _API_TriggerPipeline_Handler,
+		},
+		{
+			MethodName: "InspectTrigger",
+			Handler:    _API_InspectTrigger_Handler,
+		},
+		{
+			MethodName: "RunTransaction",
+			Handler:    _API_RunTransaction_Handler,
+		},
+		{
+			MethodName: "DryRunPipeline",
+			Handler:    _API_DryRunPipeline_Handler,
+		},
+		{
+			MethodName: "DryRunJob",
+			Handler:    _API_DryRunJob_Handler,
+		},
 		{
 			MethodName: "DeleteAll",
 			Handler:    _API_DeleteAll_Handler,
 		},
 	},
 	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "FlushJob",
+			Handler:       _API_FlushJob_Handler,
+			ServerStreams: true,
+		},
 		{
 			StreamName:    "GetLogs",
 			Handler:       _API_GetLogs_Handler,
@@ -2243,157 +3896,260 @@ var _API_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("client/pps/pps.proto", fileDescriptorPps) }
 
 var fileDescriptorPps = []byte{
-	// 2420 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xb4, 0x58, 0xcd, 0x6e, 0x1b, 0xc9,
-	0xf1, 0x17, 0xbf, 0xc9, 0xe2, 0x87, 0xa8, 0xd6, 0xc7, 0x8e, 0xb9, 0xb0, 0xa5, 0x1d, 0xc3, 0xfb,
-	0x97, 0x0d, 0x83, 0x5a, 0xc8, 0x0b, 0x63, 0xf7, 0x9f, 0x4d, 0x1c, 0x49, 0xa4, 0x0d, 0x0a, 0x8a,
-	0x4c, 0x34, 0xe5, 0x04, 0xc8, 0x85, 0x19, 0x0e, 0x9b, 0x14, 0x6d, 0x72, 0x7a, 0x32, 0x33, 0xb4,
-	0x57, 0x7b, 0xcb, 0x39, 0x87, 0x3c, 0xc4, 0x9e, 0x02, 0xe4, 0xb2, 0x87, 0x1c, 0x73, 0xcc, 0x6b,
-	0xf8, 0xe0, 0x27, 0x09, 0xba, 0xba, 0x7b, 0x38, 0x43, 0x52, 0xb4, 0x64, 0x27, 0x07, 0x02, 0xdd,
-	0x55, 0x35, 0xd5, 0x55, 0xd5, 0x55, 0xbf, 0xaa, 0x26, 0x6c, 0xd9, 0xe3, 0x11, 0x73, 0x82, 0x03,
-	0xd7, 0xf5, 0xc5, 0xaf, 0xee, 0x7a, 0x3c, 0xe0, 0x24, 0xe5, 0xba, 0x7e, 0xed, 0xcb, 0x21, 0xe7,
-	0xc3, 0x31, 0x3b, 0x40, 0x52, 0x6f, 0x3a, 0x38, 0x60, 0x13, 0x37, 0xb8, 0x92, 0x12, 0xb5, 0xdd,
-	0x79, 0x66, 0x30, 0x9a, 0x30, 0x3f, 0xb0, 0x26, 0xae, 0x12, 0xb8, 0x37, 0x2f, 0xd0, 0x9f, 0x7a,
-	0x56, 0x30, 0xe2, 0x8e, 0xe2, 0x6f, 0x0d, 0xf9, 0x90, 0xe3, 0xf2, 0x40, 0xac, 0x34, 0x55, 0x9b,
-	0x33, 0xf0, 0xc5, 0x4f, 0x52, 0xcd, 0x5f, 0x41, 0xb6, 0xc3, 0x6c, 0x8f, 0x05, 0x84, 0x40, 0xda,
-	0xb1, 0x26, 0xcc, 0x48, 0xec, 0x25, 0xf6, 0x0b, 0x14, 0xd7, 0xe4, 0x2e, 0xc0, 0x84, 0x4f, 0x9d,
-	0xa0, 0xeb, 0x5a, 0xc1, 0xa5, 0x91, 0x44, 0x4e, 0x01, 0x29, 0x6d, 0x2b, 0xb8, 0x34, 0xff, 0x9d,
-	0x84, 0xc2, 0x85, 0x67, 0x39, 0xfe, 0x80, 0x7b, 0x13, 0xb2, 0x05, 0x99, 0xd1, 0xc4, 0x1a, 0x6a,
-	0x0d, 0x72, 0x43, 0xaa, 0x90, 0xb2, 0x27, 0x7d, 0x23, 0xb9, 0x97, 0xda, 0x2f, 0x50, 0xb1, 0x24,
-	0x0f, 0x21, 0xc5, 0x9c, 0xb7, 0x46, 0x6a, 0x2f, 0xb5, 0x5f, 0x3c, 0xfc, 0xa2, 0x2e, 0x42, 0x13,
-	0x2a, 0xa9, 0x37, 0x9d, 0xb7, 0x4d, 0x27, 0xf0, 0xae, 0xa8, 0x90, 0x21, 0x0f, 0x20, 0xe7, 0xa3,
-	0x75, 0xbe, 0x91, 0x46, 0xf1, 0x22, 0x8a, 0x4b, 0x8b, 0xa9, 0xe6, 0x91, 0xc7, 0x40, 0xf0, 0xb0,
-	0xae, 0x3b, 0x1d, 0x8f, 0xbb, 0xfa, 0x8b, 0x02, 0x1e, 0x59, 0x45, 0x4e, 0x7b, 0x3a, 0x1e, 0x77,
-	0x94, 0xf4, 0x16, 0x64, 0xfc, 0xa0, 0x3f, 0x72, 0x8c, 0x0c, 0x0a, 0xc8, 0x8d, 0xd0, 0x61, 0xd9,
-	0x36, 0x73, 0x83, 0xae, 0xc7, 0x82, 0xa9, 0xe7, 0x74, 0x6d, 0xde, 0x67, 0x46, 0x76, 0x2f, 0xb5,
-	0x9f, 0xa2, 0x55, 0xc9, 0xa1, 0xc8, 0x38, 0xe1, 0x7d, 0x26, 0x74, 0xf4, 0x59, 0x6f, 0x3a, 0x34,
-	0x72, 0x7b, 0x89, 0xfd, 0x3c, 0x95, 0x9b, 0xda, 0x53, 0xc8, 0x6b, 0xfb, 0x85, 0xdf, 0x6f, 0xd8,
-	0x95, 0x8a, 0x85, 0x58, 0x8a, 0x6f, 0xde, 0x5a, 0xe3, 0x29, 0x53, 0x71, 0x94, 0x9b, 0xff, 0x4f,
-	0x7e, 0x97, 0x30, 0x6b, 0x90, 0x6d, 0x0e, 0x3d, 0xe6, 0xfb, 0xe2, 0xab, 0x57, 0xf4, 0x4c, 0x7f,
-	0x35, 0xa5, 0x67, 0xe6, 0x5d, 0x48, 0x9d, 0xf2, 0x1e, 0xd9, 0x81, 0xe4, 0xa8, 0x2f, 0xe9, 0xc7,
-	0xd9, 0x0f, 0xef, 0x77, 0x93, 0xad, 0x06, 0x4d, 0x8e, 0xfa, 0x66, 0x07, 0x72, 0x1d, 0xe6, 0xbd,
-	0x1d, 0xd9, 0x8c, 0xdc, 0x87, 0xf2, 0xc8, 0x09, 0x98, 0xe7, 0x58, 0xe3, 0xae, 0xcb, 0xbd, 0x00,
-	0xa5, 0x33, 0xb4, 0xa4, 0x89, 0x6d, 0xee, 0x05, 0x42, 0x88, 0xfd, 0x18, 0x15, 0x4a, 0x4a, 0x21,
-	0x4d, 0x14, 0x42, 0xe6, 0x3f, 0x12, 0x50, 0x38, 0x0a, 0xf8, 0xa4, 0xe5, 0xb8, 0xd3, 0xe5, 0x89,
-	0x41, 0x20, 0xed, 0x31, 0x97, 0x2b, 0x57, 0x70, 0x4d, 0x76, 0x20, 0xdb, 0xf3, 0x2c, 0xc7, 0xbe,
-	0x34, 0x52, 0x48, 0x55, 0x3b, 0x41, 0xb7, 0xf9, 0x64, 0x32, 0x0a, 0x8c, 0xb4, 0xa4, 0xcb, 0x9d,
-	0xd0, 0x31, 0x1c, 0xf3, 0x9e, 0x91, 0x91, 0x3a, 0xc4, 0x5a, 0xd0, 0xc6, 0xd6, 0x4f, 0x57, 0x46,
-	0x16, 0xc3, 0x8a, 0x6b, 0xb2, 0x0b, 0xc5, 0x81, 0xc7, 0x27, 0x5d, 0xa5, 0x24, 0x87, 0xe2, 0x20,
-	0x48, 0x27, 0x48, 0x31, 0x39, 0x64, 0xa4, 0xa5, 0x26, 0xa4, 0xad, 0x80, 0x4f, 0xd0, 0xd2, 0xe2,
-	0x61, 0x05, 0x73, 0x25, 0xf4, 0x83, 0x22, 0x8f, 0xec, 0x41, 0xc6, 0xf6, 0xb8, 0xef, 0x63, 0x46,
-	0x16, 0x0f, 0x01, 0x85, 0xa4, 0x80, 0x64, 0x08, 0x89, 0xa9, 0x33, 0xe2, 0x8e, 0xca, 0xd0, 0x98,
-	0x04, 0x32, 0xcc, 0x37, 0x90, 0x3f, 0xe5, 0xbd, 0x78, 0x74, 0xd2, 0x91, 0xe8, 0xdc, 0x0f, 0x3d,
-	0x96, 0x96, 0x14, 0xeb, 0xa2, 0xe0, 0xa4, 0xb5, 0x0b, 0xee, 0x27, 0x97, 0xb8, 0x9f, 0x9a, 0xb9,
-	0x6f, 0xfe, 0x33, 0x01, 0xeb, 0x6d, 0xcb, 0xb3, 0xc6, 0x63, 0x36, 0x1e, 0xf9, 0x93, 0x8e, 0xcb,
-	0x6c, 0xf2, 0x3d, 0xe4, 0xfd, 0xc0, 0xb3, 0x02, 0x36, 0x94, 0x19, 0x56, 0x39, 0xbc, 0x8b, 0x56,
-	0xce, 0xc9, 0xd5, 0x3b, 0x4a, 0x88, 0x86, 0xe2, 0xa4, 0x06, 0x79, 0x9b, 0x3b, 0x7e, 0x60, 0x39,
-	0xf2, 0xee, 0xd3, 0x34, 0xdc, 0x93, 0x3d, 0x28, 0xda, 0x9c, 0x0d, 0x06, 0x23, 0x5b, 0x20, 0x05,
-	0x5a, 0x91, 0xa0, 0x51, 0x92, 0xf9, 0x10, 0xf2, 0x5a, 0x27, 0x29, 0x41, 0xfe, 0xe4, 0xe5, 0x79,
-	0xe7, 0xe2, 0xe8, 0xfc, 0xa2, 0xba, 0x46, 0xd6, 0xa1, 0x78, 0xf2, 0xb2, 0xf9, 0xfc, 0x79, 0xeb,
-	0xa4, 0xd5, 0x3c, 0xbf, 0xa8, 0x26, 0xcc, 0x03, 0xc8, 0x34, 0xac, 0x60, 0x3a, 0x11, 0x4e, 0x21,
-	0x7c, 0xa8, 0x08, 0x89, 0xb5, 0xa0, 0x5d, 0x5a, 0xfe, 0x25, 0xde, 0x7d, 0x89, 0xe2, 0xda, 0xfc,
-	0x25, 0x01, 0xa5, 0x3f, 0x70, 0xef, 0x0d, 0xf3, 0x3a, 0x81, 0x15, 0x4c, 0x7d, 0xf2, 0x10, 0x0a,
-	0xef, 0x70, 0xdf, 0x0d, 0x53, 0xbf, 0xf4, 0xe1, 0xfd, 0x6e, 0x5e, 0x0a, 0xb5, 0x1a, 0x34, 0x2f,
-	0xd9, 0xad, 0x3e, 0xd9, 0x83, 0xec, 0x6b, 0xde, 0x13, 0x72, 0x18, 0xce, 0xe3, 0xc2, 0x87, 0xf7,
-	0xbb, 0x19, 0x71, 0x47, 0x0d, 0x9a, 0x79, 0xcd, 0x7b, 0xad, 0x3e, 0xb9, 0x07, 0xe9, 0xbe, 0x15,
-	0x58, 0xb1, 0x4b, 0x45, 0xfb, 0x28, 0xd2, 0xc9, 0xb7, 0x90, 0xf3, 0x03, 0xcb, 0x0b, 0x58, 0x1f,
-	0x0d, 0x2d, 0x1e, 0xd6, 0xea, 0x12, 0x66, 0xeb, 0x1a, 0x66, 0xeb, 0x17, 0x1a, 0x87, 0xa9, 0x16,
-	0x35, 0x4f, 0xa1, 0x44, 0x99, 0xcf, 0xa7, 0x9e, 0xcd, 0xf0, 0x62, 0x04, 0xda, 0xb9, 0x53, 0x34,
-	0x36, 0x49, 0xc5, 0x52, 0x64, 0xff, 0x84, 0x4d, 0xb8, 0x77, 0xa5, 0x2e, 0x5a, 0xed, 0x84, 0xe4,
-	0xd0, 0x9d, 0x62, 0x8c, 0x53, 0x54, 0x2c, 0xcd, 0xf7, 0x39, 0xc8, 0x61, 0x5a, 0x0d, 0x38, 0xa9,
-	0x41, 0xea, 0x35, 0xef, 0xa9, 0xf4, 0xc9, 0xa3, 0xb1, 0xa7, 0xbc, 0x47, 0x05, 0x91, 0x3c, 0x86,
-	0x42, 0xa0, 0xf1, 0x12, 0x95, 0xea, 0x54, 0x0f, 0x51, 0x94, 0xce, 0x04, 0xc8, 0x01, 0x14, 0xdd,
-	0x91, 0xcb, 0xc6, 0x23, 0x87, 0x89, 0xf0, 0x6c, 0x62, 0x78, 0x2a, 0x1f, 0xde, 0xef, 0x42, 0x5b,
-	0x91, 0x5b, 0x0d, 0x0a, 0x5a, 0xa4, 0x25, 0xe0, 0x39, 0xaf, 0x77, 0x68, 0x5d, 0xf1, 0xb0, 0x2c,
-	0x73, 0x4b, 0x11, 0x69, 0xc8, 0x26, 0x0f, 0xa1, 0x1a, 0xea, 0x7e, 0xcb, 0x3c, 0x5f, 0x14, 0x4d,
-	0x19, 0x73, 0x6a, 0x5d, 0xd3, 0x7f, 0x2f, 0xc9, 0xe4, 0x19, 0x54, 0xdd, 0x59, 0x72, 0x76, 0x7d,
-	0x97, 0xd9, 0x46, 0x09, 0xb5, 0x6f, 0x2d, 0xcb, 0x5c, 0xba, 0xee, 0xce, 0xa5, 0xfc, 0x03, 0xc8,
-	0x8e, 0x44, 0xc1, 0xf9, 0x08, 0xdb, 0xda, 0x28, 0x5d, 0x86, 0x54, 0x31, 0x45, 0xe9, 0x31, 0x84,
-	0x52, 0x63, 0x5d, 0x97, 0x9e, 0xeb, 0xd7, 0x25, 0xba, 0x52, 0xc5, 0x22, 0xff, 0x07, 0xe0, 0x5a,
-	0x1e, 0x73, 0x82, 0xae, 0x08, 0x72, 0x76, 0x2e, 0xc8, 0x05, 0xc9, 0x13, 0xa8, 0x1b, 0x49, 0x8a,
-	0xdc, 0x8d, 0x93, 0x82, 0x3c, 0x85, 0xfc, 0x60, 0xe4, 0x8c, 0xfc, 0x4b, 0xd6, 0x37, 0xf2, 0x1f,
-	0xfd, 0x2c, 0x94, 0x25, 0xdf, 0x40, 0x99, 0x4f, 0x03, 0x77, 0x1a, 0x68, 0xa8, 0x2b, 0x2c, 0xa2,
-	0x47, 0x49, 0x4a, 0xc8, 0x1d, 0xb9, 0x2f, 0x5a, 0x99, 0x15, 0x30, 0x03, 0x10, 0x04, 0xc2, 0x98,
-	0x88, 0x02, 0x62, 0x54, 0xf2, 0xc8, 0xd7, 0xa2, 0x89, 0x62, 0x8b, 0x30, 0x2a, 0xa8, 0xb0, 0xa4,
-	0x9a, 0x28, 0xd2, 0xa8, 0x66, 0x12, 0x43, 0x38, 0xcb, 0x5d, 0x97, 0xf5, 0x8d, 0x2a, 0xe2, 0x8f,
-	0xde, 0x92, 0x87, 0x00, 0xf2, 0x58, 0x2a, 0x30, 0x9f, 0xa0, 0x92, 0x02, 0x5a, 0x25, 0x08, 0x34,
-	0xc2, 0x24, 0x26, 0x28, 0x0b, 0x8f, 0x65, 0x2b, 0xd8, 0xc0, 0xa4, 0x8f, 0xd1, 0xc4, 0x41, 0x1e,
-	0xc3, 0x60, 0x19, 0x5b, 0x98, 0x2d, 0x7a, 0x4b, 0x1e, 0x40, 0x45, 0x14, 0x63, 0xd7, 0xf5, 0xb8,
-	0xcd, 0x7c, 0x9f, 0xf5, 0x8d, 0x1d, 0xac, 0x8f, 0xb2, 0xa0, 0xb6, 0x35, 0x51, 0x8c, 0x25, 0x28,
-	0x16, 0xf0, 0xc0, 0x1a, 0x1b, 0x5f, 0xa0, 0x48, 0x41, 0x50, 0x2e, 0x04, 0x81, 0x3c, 0x85, 0xb2,
-	0xc2, 0x0d, 0x1f, 0x81, 0xc4, 0x30, 0x30, 0x63, 0x36, 0xd0, 0xed, 0x28, 0xc2, 0xd0, 0xd2, 0xbb,
-	0x28, 0xde, 0x3c, 0x85, 0xb2, 0xa7, 0x8a, 0x59, 0x26, 0xe8, 0x1d, 0xf4, 0x54, 0x7e, 0x17, 0x2d,
-	0x73, 0x5a, 0xf2, 0xa2, 0x45, 0xbf, 0x07, 0x19, 0xcc, 0x3e, 0xa3, 0x86, 0xf2, 0xb1, 0x86, 0x81,
-	0x8c, 0xd3, 0x74, 0x3e, 0x5d, 0xcd, 0x98, 0x0d, 0xc8, 0xca, 0xd3, 0x97, 0xb6, 0xd4, 0xaf, 0xf5,
-	0x5d, 0x26, 0xf1, 0x2e, 0xab, 0x73, 0xd6, 0xea, 0xeb, 0x34, 0x9f, 0xa8, 0xe6, 0x33, 0xe0, 0x22,
-	0x91, 0xf3, 0x08, 0x7b, 0xce, 0x80, 0x1b, 0x09, 0x74, 0xb2, 0x34, 0x2b, 0x8b, 0x01, 0xa7, 0xb9,
-	0xd7, 0x72, 0x61, 0xde, 0x83, 0xbc, 0xae, 0xdf, 0x65, 0x87, 0x9b, 0x3f, 0x27, 0xa0, 0x1c, 0xe2,
-	0x41, 0xac, 0xaf, 0x65, 0x62, 0xe3, 0xa0, 0xec, 0xfa, 0x89, 0xf9, 0x0c, 0x98, 0x1f, 0x00, 0x92,
-	0xb1, 0x01, 0x40, 0x77, 0xba, 0xd4, 0x92, 0x4e, 0x97, 0x8e, 0x35, 0xfa, 0xb4, 0xe8, 0xea, 0xaa,
-	0x20, 0x63, 0x69, 0x8f, 0x0c, 0xf3, 0x5f, 0x59, 0x28, 0xcd, 0xac, 0x1c, 0x70, 0x35, 0x15, 0x6d,
-	0xcc, 0x4f, 0x45, 0x31, 0x0c, 0x4b, 0xac, 0xc6, 0x30, 0x03, 0x72, 0x1a, 0xba, 0x8a, 0x32, 0x19,
-	0xd5, 0xf6, 0x96, 0x38, 0xbb, 0x0c, 0xe0, 0xe0, 0x36, 0x00, 0xf7, 0x28, 0x04, 0x38, 0x39, 0xea,
-	0x92, 0x98, 0xc5, 0x9f, 0x80, 0x72, 0xdf, 0x03, 0xd8, 0x1e, 0xb3, 0x02, 0xd6, 0xef, 0x5a, 0x81,
-	0x0a, 0xea, 0x2a, 0x20, 0x2a, 0x28, 0xe9, 0xa3, 0x80, 0xec, 0xeb, 0x5c, 0xcc, 0x61, 0x2e, 0xc6,
-	0x4d, 0x89, 0x81, 0xcb, 0x57, 0x50, 0xf2, 0x98, 0x2d, 0xa0, 0x94, 0x79, 0x1e, 0xf7, 0x10, 0xef,
-	0x0a, 0xb4, 0x28, 0x69, 0x4d, 0x41, 0x22, 0xcf, 0x00, 0x44, 0x92, 0xda, 0xe2, 0xd9, 0x20, 0xa7,
-	0xf2, 0xe2, 0xe1, 0xde, 0x9c, 0x73, 0x03, 0x2e, 0x72, 0xf6, 0x04, 0x45, 0xe4, 0xfc, 0x5f, 0x78,
-	0xad, 0xf7, 0x51, 0x60, 0x2a, 0xc7, 0x81, 0x69, 0x1e, 0x6d, 0xaa, 0x4b, 0xd0, 0xa6, 0x05, 0xc4,
-	0xb7, 0xad, 0x31, 0x6b, 0xf0, 0x77, 0xce, 0xc5, 0xa5, 0xc7, 0xfc, 0x4b, 0x3e, 0xee, 0x2b, 0x10,
-	0xbb, 0xb3, 0x10, 0x8e, 0x86, 0x7a, 0x4a, 0xd1, 0x25, 0x1f, 0x2d, 0x02, 0xc4, 0xe6, 0x2d, 0x01,
-	0x62, 0xeb, 0x1a, 0x80, 0x10, 0x93, 0x57, 0x9f, 0xf9, 0xb6, 0x37, 0x72, 0xc5, 0xe1, 0xc6, 0xb6,
-	0x8c, 0x62, 0x84, 0x54, 0xfb, 0x01, 0x2a, 0xf1, 0x08, 0x45, 0x5f, 0x18, 0x99, 0x25, 0x2f, 0x8c,
-	0x4c, 0xe4, 0x85, 0x71, 0x9a, 0xce, 0xa7, 0xaa, 0x69, 0xf3, 0x45, 0xb4, 0xc8, 0x05, 0x7e, 0x3c,
-	0x85, 0xf2, 0x6c, 0x38, 0x98, 0x81, 0xc8, 0xc6, 0xc2, 0xed, 0xd0, 0x92, 0x1b, 0xd9, 0x99, 0x3f,
-	0xa7, 0xa1, 0x7a, 0x82, 0xd9, 0x22, 0x1a, 0x26, 0xfb, 0xf3, 0x94, 0xf9, 0x41, 0xbc, 0x5e, 0x12,
-	0x1f, 0xab, 0x97, 0x68, 0x89, 0x26, 0x6f, 0x3f, 0x66, 0xc0, 0xcd, 0xc7, 0x8c, 0xdc, 0xa7, 0x8d,
-	0x19, 0xe9, 0x9b, 0x8d, 0x19, 0x85, 0xeb, 0x0b, 0x30, 0xd2, 0x78, 0xf3, 0xab, 0x1a, 0x6f, 0xbc,
-	0xbd, 0x96, 0x6e, 0xd3, 0x5e, 0x8b, 0x4b, 0x12, 0x3e, 0x3e, 0xdd, 0x94, 0xaf, 0x9f, 0x6e, 0x16,
-	0xd2, 0xb9, 0x72, 0xcb, 0x74, 0x5e, 0xbf, 0xbe, 0xdf, 0x89, 0x74, 0x6b, 0xc3, 0x46, 0xcb, 0x11,
-	0x8a, 0x83, 0x48, 0x96, 0xac, 0x9a, 0x6c, 0x77, 0xa1, 0xd8, 0x1b, 0x73, 0xfb, 0x4d, 0x77, 0xd6,
-	0x08, 0xf3, 0x14, 0x90, 0x84, 0xa0, 0x63, 0xbe, 0x81, 0xca, 0xd9, 0xc8, 0x8f, 0xaa, 0xbb, 0x05,
-	0xd2, 0xd7, 0xa1, 0x84, 0xd6, 0xe9, 0xe9, 0x2a, 0xa9, 0xff, 0x51, 0x98, 0xb5, 0x99, 0x22, 0x0a,
-	0xa8, 0x67, 0x65, 0x1d, 0xaa, 0x0d, 0x36, 0x66, 0xb1, 0x1c, 0x5f, 0x61, 0xbd, 0xf9, 0x18, 0x2a,
-	0x9d, 0x80, 0xbb, 0x37, 0x94, 0xfe, 0x09, 0x2a, 0x2f, 0x58, 0x70, 0xc6, 0x87, 0xfe, 0x32, 0x57,
-	0x3e, 0x52, 0x11, 0xab, 0x82, 0xf8, 0x15, 0x94, 0x70, 0x38, 0x1a, 0x8c, 0xc6, 0x01, 0xf3, 0x7c,
-	0x7c, 0xf0, 0x08, 0x2c, 0xb1, 0x02, 0xeb, 0xb9, 0x24, 0x99, 0x7f, 0x4f, 0x02, 0x9c, 0xf1, 0xe1,
-	0xef, 0x98, 0xef, 0x5b, 0x43, 0xfc, 0xe3, 0x20, 0xac, 0xaf, 0xc8, 0x64, 0x10, 0x96, 0xfc, 0xb9,
-	0xe8, 0xfd, 0x73, 0xef, 0x88, 0xe4, 0x47, 0xdf, 0x11, 0xb3, 0x27, 0x59, 0xea, 0x9a, 0x27, 0x59,
-	0xec, 0x7d, 0x97, 0x5b, 0xf9, 0xbe, 0xd3, 0xaf, 0xb7, 0xf4, 0x35, 0xaf, 0x37, 0x02, 0xe9, 0xa9,
-	0xcf, 0x64, 0xfb, 0xc9, 0x53, 0x5c, 0x93, 0x47, 0x90, 0xc4, 0xd7, 0xc2, 0xc7, 0xfa, 0x5e, 0x52,
-	0xb6, 0x98, 0x89, 0x8c, 0x06, 0x36, 0xca, 0x02, 0xd5, 0x5b, 0xf3, 0x02, 0x36, 0xa9, 0x9c, 0x4e,
-	0xe5, 0x79, 0x37, 0x48, 0xe3, 0xf9, 0x1b, 0x48, 0x2e, 0xde, 0xc0, 0x5f, 0xd3, 0xb0, 0x2d, 0x01,
-	0x34, 0xbc, 0xdd, 0xdb, 0x27, 0xf4, 0xe7, 0x0f, 0x28, 0xb9, 0xff, 0xfd, 0x80, 0xb2, 0x02, 0x1f,
-	0x77, 0x20, 0x3b, 0x75, 0xfb, 0xa2, 0xd2, 0x33, 0x78, 0x6d, 0x6a, 0xb7, 0x00, 0x72, 0x70, 0xe3,
-	0xae, 0x5e, 0xfc, 0xaf, 0x74, 0xf5, 0xd2, 0x2d, 0x61, 0xb0, 0x7c, 0xc3, 0xae, 0x5e, 0x59, 0xe8,
-	0xea, 0x0a, 0x28, 0x4f, 0x60, 0x47, 0x01, 0xe5, 0xa7, 0x67, 0x83, 0xb9, 0x0d, 0x9b, 0x02, 0x1b,
-	0xe7, 0x34, 0x98, 0x36, 0x6c, 0x4b, 0x14, 0xfb, 0x8c, 0x44, 0xdb, 0x15, 0x7e, 0x08, 0x1d, 0xa2,
-	0xa3, 0xf8, 0x1a, 0x97, 0xfb, 0x1a, 0x1c, 0x7d, 0xf3, 0x08, 0xb6, 0x3a, 0xa2, 0x44, 0x3e, 0xc3,
-	0xfc, 0xdf, 0xc2, 0xa6, 0x40, 0xcf, 0xcf, 0xd0, 0xf0, 0xb7, 0x04, 0x6c, 0x51, 0xe6, 0x4d, 0x9d,
-	0xcf, 0xf0, 0xf4, 0x01, 0xe4, 0xd8, 0x8f, 0xf6, 0x78, 0xda, 0x67, 0xcb, 0xda, 0x83, 0xe6, 0x09,
-	0xb1, 0x91, 0x23, 0xc5, 0x52, 0x4b, 0xc4, 0x14, 0xef, 0xd1, 0x9f, 0xf0, 0xa9, 0x86, 0xad, 0x8b,
-	0x54, 0xa1, 0x74, 0xfa, 0xf2, 0xb8, 0xdb, 0xb9, 0x38, 0xa2, 0x17, 0xad, 0xf3, 0x17, 0xf2, 0x1f,
-	0x33, 0x41, 0xa1, 0xaf, 0xce, 0xcf, 0x05, 0x21, 0xa1, 0x09, 0xcf, 0x8f, 0x5a, 0x67, 0xaf, 0x68,
-	0xb3, 0x9a, 0xd4, 0x84, 0xce, 0xab, 0x93, 0x93, 0x66, 0xa7, 0x53, 0x4d, 0x85, 0x84, 0x8b, 0x97,
-	0xed, 0x76, 0xb3, 0x51, 0x4d, 0x3f, 0x7a, 0x06, 0xc5, 0xc8, 0x13, 0x51, 0xf0, 0xdb, 0x2f, 0x1b,
-	0xa1, 0xca, 0x35, 0x4d, 0xd0, 0x1a, 0x12, 0xa4, 0x02, 0x20, 0x08, 0xe2, 0x8c, 0x66, 0xa3, 0x9a,
-	0x7c, 0xf4, 0x97, 0xc8, 0xc3, 0x4f, 0xea, 0xd8, 0x86, 0x8d, 0x76, 0xab, 0xdd, 0x3c, 0x6b, 0x9d,
-	0x37, 0xa3, 0xd6, 0x6e, 0x41, 0x35, 0x24, 0xcf, 0x4c, 0xfe, 0x02, 0x36, 0x67, 0xd4, 0x66, 0x28,
-	0x9e, 0x8c, 0x89, 0x6b, 0x87, 0x52, 0x31, 0x6a, 0xe8, 0xc4, 0xe1, 0x2f, 0x39, 0x48, 0x1d, 0xb5,
-	0x5b, 0xa4, 0x0e, 0x85, 0x70, 0xa8, 0x24, 0xdb, 0x78, 0x45, 0xf3, 0x43, 0x66, 0x2d, 0x84, 0x5a,
-	0x73, 0x8d, 0x7c, 0x0b, 0x30, 0x9b, 0x2f, 0xc8, 0x8e, 0xaa, 0xbf, 0xb9, 0x81, 0xa3, 0x16, 0x7b,
-	0x11, 0x9b, 0x6b, 0xe4, 0x00, 0x72, 0x6a, 0x86, 0x20, 0x9b, 0xc8, 0x8a, 0x4f, 0x14, 0xb5, 0x72,
-	0x54, 0xde, 0x37, 0xd7, 0xc8, 0x0f, 0x50, 0x08, 0xe7, 0x00, 0x65, 0xd6, 0xfc, 0x5c, 0x50, 0xdb,
-	0x59, 0x80, 0x9c, 0xe6, 0xc4, 0x0d, 0xae, 0xcc, 0x35, 0xf2, 0x1d, 0xe4, 0xd4, 0x54, 0xa0, 0x8e,
-	0x8b, 0xcf, 0x08, 0x2b, 0xbe, 0x3c, 0xc6, 0xff, 0x16, 0xc3, 0xce, 0x43, 0x0c, 0x0d, 0x48, 0xf3,
-	0xcd, 0x68, 0x85, 0x8e, 0xe7, 0x50, 0x89, 0xb7, 0x19, 0x52, 0x8b, 0xc4, 0x75, 0xae, 0x50, 0x56,
-	0xe8, 0x39, 0x81, 0xf5, 0x39, 0x84, 0x22, 0x5f, 0x46, 0xe3, 0x3d, 0xaf, 0x69, 0xf1, 0x05, 0x61,
-	0xae, 0x91, 0xdf, 0x40, 0x29, 0x8a, 0x50, 0xca, 0xa1, 0x25, 0xa0, 0x55, 0x23, 0x0b, 0x9f, 0xfb,
-	0xd2, 0x99, 0x38, 0x94, 0x29, 0x67, 0x96, 0xe2, 0xdb, 0x0a, 0x67, 0x1a, 0x50, 0x8e, 0xa1, 0x15,
-	0xb9, 0xa3, 0x2e, 0x66, 0x11, 0xc1, 0x56, 0x5f, 0x4f, 0x14, 0xb0, 0x94, 0x37, 0x4b, 0x30, 0x6c,
-	0xb5, 0x25, 0x31, 0xc4, 0x52, 0x96, 0x2c, 0x43, 0xb1, 0x15, 0x5a, 0x7e, 0xad, 0x13, 0xf4, 0x68,
-	0x3c, 0x26, 0xd7, 0x88, 0xad, 0xf8, 0xfc, 0x09, 0xe4, 0xd4, 0x24, 0xaa, 0x32, 0x34, 0x3e, 0x97,
-	0xd6, 0xd6, 0xe5, 0x35, 0x85, 0xf3, 0xa2, 0xb9, 0xf6, 0x4d, 0xe2, 0x38, 0xf3, 0xc7, 0x94, 0xeb,
-	0xfa, 0xbd, 0x2c, 0x6a, 0x7b, 0xf2, 0x9f, 0x00, 0x00, 0x00, 0xff, 0xff, 0x23, 0xb2, 0xf4, 0x16,
-	0xec, 0x1c, 0x00, 0x00,
+	// 4078 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xb5, 0x5b, 0x4b, 0x73, 0x23, 0xc9,
+	0x71, 0x1e, 0x3c, 0x48, 0x00, 0x89, 0x07, 0xc1, 0xe2, 0x0b, 0x83, 0x79, 0x6e, 0x8f, 0x77, 0x77,
+	0x76, 0x2c, 0x93, 0xf2, 0xc8, 0x0f, 0xed, 0x78, 0xd7, 0x2b, 0x12, 0xc4, 0x8c, 0xb8, 0xc1, 0xe5,
+	0x70, 0x9b, 0xa0, 0x26, 0xac, 0x0b, 0xdc, 0x04, 0x9a, 0x64, 0xef, 0x02, 0x68, 0xa8, 0xbb, 0xc1,
+	0xd9, 0x59, 0x59, 0x07, 0x2b, 0x7c, 0xd0, 0xcd, 0x11, 0xf6, 0xc9, 0x67, 0x5f, 0x7d, 0xf1, 0xc5,
+	0x67, 0x9f, 0x75, 0x50, 0x84, 0xc2, 0x3e, 0xfb, 0xe0, 0x50, 0x84, 0xfc, 0x0b, 0x1c, 0x3a, 0x2a,
+	0x33, 0xab, 0xaa, 0x1f, 0x40, 0x83, 0x8f, 0x9d, 0xd5, 0x81, 0x13, 0x55, 0x59, 0x59, 0xaf, 0xac,
+	0x7c, 0x7c, 0x99, 0xe8, 0x81, 0xd5, 0xde, 0xc0, 0xb1, 0x47, 0xc1, 0xd6, 0x78, 0xec, 0xd3, 0xdf,
+	0xe6, 0xd8, 0x73, 0x03, 0x57, 0xe4, 0xb0, 0xd9, 0xbc, 0x73, 0xe6, 0xba, 0x67, 0x03, 0x7b, 0x8b,
+	0x49, 0x27, 0x93, 0xd3, 0x2d, 0x7b, 0x38, 0x0e, 0xde, 0x48, 0x8e, 0xe6, 0x83, 0xe9, 0xc1, 0xc0,
+	0x19, 0xda, 0x7e, 0x60, 0x0d, 0xc7, 0x8a, 0xe1, 0xfe, 0x34, 0x43, 0x7f, 0xe2, 0x59, 0x81, 0xe3,
+	0x8e, 0xd4, 0xf8, 0x5d, 0x35, 0x6e, 0x8d, 0x9d, 0x2d, 0x6b, 0x34, 0x72, 0x03, 0x1e, 0x54, 0x07,
+	0x68, 0xae, 0x9e, 0xb9, 0x67, 0x2e, 0x37, 0xb7, 0xa8, 0xa5, 0xa9, 0xfa, 0xb0, 0xa7, 0x3e, 0xfd,
+	0x49, 0xaa, 0xf1, 0x77, 0xb0, 0x78, 0x64, 0xf7, 0x3c, 0x3b, 0x10, 0x02, 0xf2, 0x23, 0x6b, 0x68,
+	0x37, 0x32, 0x0f, 0x33, 0x8f, 0x4b, 0x26, 0xb7, 0xc5, 0x3d, 0x80, 0xa1, 0x3b, 0x19, 0x05, 0xdd,
+	0xb1, 0x15, 0x9c, 0x37, 0xb2, 0x3c, 0x52, 0x62, 0xca, 0x21, 0x12, 0xc4, 0x2a, 0x2c, 0x38, 0x81,
+	0x3d, 0xf4, 0x1b, 0x0b, 0x0f, 0x73, 0x38, 0x22, 0x3b, 0x62, 0x03, 0x0a, 0xf6, 0xe8, 0xa2, 0x7b,
+	0x61, 0x79, 0x8d, 0x1c, 0xcf, 0x58, 0xc4, 0xee, 0x8f, 0x2c, 0x4f, 0xd4, 0x21, 0xf7, 0xa5, 0xfd,
+	0xa6, 0x91, 0x67, 0x22, 0x35, 0x8d, 0x5f, 0xe7, 0xa0, 0xd4, 0xf1, 0xac, 0x91, 0x7f, 0xea, 0x7a,
+	0x43, 0x5e, 0x6e, 0x68, 0x9d, 0xe9, 0x23, 0xc8, 0x0e, 0xcd, 0xea, 0x0d, 0xfb, 0xb8, 0x39, 0x6d,
+	0x41, 0x4d, 0xf1, 0x01, 0xe4, 0x70, 0x45, 0x5c, 0x3c, 0xf7, 0xb8, 0xfc, 0x74, 0x63, 0x93, 0x24,
+	0x1f, 0x2e, 0xb2, 0xd9, 0x1e, 0x5d, 0xb4, 0x47, 0x81, 0xf7, 0xc6, 0x24, 0x1e, 0xf1, 0x2e, 0x14,
+	0x7c, 0xbe, 0x9e, 0x8f, 0xdb, 0x12, 0x7b, 0x99, 0xd9, 0xe5, 0x95, 0x4d, 0x3d, 0x26, 0xbe, 0x03,
+	0x82, 0x37, 0xeb, 0x8e, 0x27, 0x83, 0x41, 0x57, 0xcf, 0x28, 0xf1, 0x96, 0x75, 0x1e, 0x39, 0xc4,
+	0x81, 0x23, 0xc5, 0x8d, 0xe7, 0xf4, 0x83, 0xbe, 0x33, 0xd2, 0xd7, 0xe6, 0x0e, 0xad, 0x61, 0xf5,
+	0x7a, 0xf6, 0x38, 0xe8, 0x22, 0xd3, 0xc4, 0x1b, 0x75, 0x7b, 0x6e, 0xdf, 0x6e, 0x2c, 0x22, 0x4b,
+	0xce, 0xac, 0xcb, 0x11, 0x93, 0x07, 0x5a, 0x48, 0xa7, 0x35, 0xfa, 0xf6, 0xc9, 0xe4, 0xac, 0x51,
+	0xc0, 0xbb, 0x16, 0x4d, 0xd9, 0x11, 0x1f, 0x42, 0xcd, 0x1a, 0x0c, 0xdc, 0xd7, 0x76, 0xbf, 0x6b,
+	0x9f, 0x79, 0xb6, 0xef, 0x37, 0x80, 0x4f, 0x2d, 0xf8, 0xd4, 0xdb, 0x72, 0xa8, 0xcd, 0x23, 0x66,
+	0xd5, 0x8a, 0x77, 0xc5, 0x7d, 0x28, 0x7b, 0x93, 0x51, 0xd7, 0xf2, 0xbb, 0x13, 0xdf, 0xf6, 0x1a,
+	0x65, 0x5c, 0x36, 0x67, 0x96, 0x90, 0xb4, 0xed, 0x1f, 0x23, 0x41, 0x6c, 0x01, 0x78, 0xf6, 0xa8,
+	0x6f, 0x7f, 0x7d, 0xe1, 0x4e, 0xfc, 0x46, 0x05, 0x87, 0xcb, 0x4f, 0x97, 0x78, 0x59, 0x33, 0x24,
+	0x9b, 0x31, 0x96, 0xe6, 0x5f, 0x40, 0x51, 0xcb, 0x52, 0xbf, 0x5c, 0x26, 0x7c, 0x39, 0x3a, 0xff,
+	0x85, 0x35, 0x98, 0xd8, 0x4a, 0x29, 0x64, 0xe7, 0x59, 0xf6, 0xfb, 0x19, 0xa3, 0x0d, 0x8b, 0xea,
+	0x48, 0x38, 0xeb, 0xd8, 0xdc, 0xd7, 0xb3, 0xb0, 0x49, 0x2f, 0xe7, 0xff, 0x64, 0xc0, 0x73, 0xca,
+	0x4f, 0x6b, 0xf2, 0x29, 0x3e, 0xdf, 0x97, 0xec, 0x3b, 0x85, 0xff, 0xfd, 0x9f, 0x07, 0x39, 0xec,
+	0x9a, 0xc4, 0x63, 0xdc, 0x83, 0xdc, 0xa7, 0xee, 0x89, 0x58, 0x87, 0xac, 0xd3, 0x97, 0x4b, 0xec,
+	0x2c, 0x22, 0x43, 0x76, 0x6f, 0xd7, 0x44, 0x8a, 0x71, 0x04, 0x85, 0x23, 0xdb, 0xbb, 0x70, 0x7a,
+	0xb6, 0x78, 0x04, 0x55, 0x67, 0x14, 0xd8, 0xde, 0xc8, 0x1a, 0x74, 0xc7, 0xae, 0x17, 0x30, 0xf7,
+	0x82, 0x59, 0xd1, 0xc4, 0x43, 0xa4, 0x11, 0x93, 0xfd, 0x55, 0x9c, 0x29, 0x2b, 0x99, 0x34, 0x91,
+	0x98, 0x8c, 0x7f, 0xcb, 0x40, 0x69, 0x3b, 0x70, 0x87, 0x7b, 0xa3, 0xf1, 0x24, 0xdd, 0x20, 0x90,
+	0xe6, 0xd9, 0x63, 0x57, 0xdd, 0x9a, 0xdb, 0x78, 0xc4, 0xc5, 0x13, 0x54, 0xbf, 0xde, 0xb9, 0x56,
+	0x77, 0xd9, 0x23, 0x7a, 0xcf, 0x1d, 0x0e, 0x9d, 0x40, 0x69, 0xbc, 0xea, 0xd1, 0x1a, 0x67, 0x03,
+	0xf7, 0x04, 0xb5, 0x87, 0xd7, 0xa0, 0x36, 0xd1, 0x06, 0xd6, 0xd7, 0x6f, 0x50, 0x5d, 0x48, 0x1b,
+	0xb8, 0x2d, 0x1e, 0x40, 0xf9, 0xd4, 0x73, 0x87, 0x5d, 0xb5, 0x48, 0x81, 0xd9, 0x81, 0x48, 0x2d,
+	0xa6, 0x18, 0xff, 0x92, 0x81, 0x05, 0x79, 0x54, 0x03, 0xf2, 0x16, 0x9e, 0x9b, 0x8f, 0xaa, 0x05,
+	0x1b, 0x5e, 0xc4, 0xe4, 0x31, 0xf1, 0x10, 0x16, 0x7a, 0x9e, 0x8b, 0x2a, 0x95, 0x65, 0x95, 0x02,
+	0x66, 0x92, 0x0c, 0x72, 0x80, 0x38, 0x26, 0x23, 0xf4, 0x23, 0xca, 0xb2, 0x12, 0x1c, 0x3c, 0x20,
+	0x1e, 0xcb, 0xf7, 0xcb, 0xf3, 0x36, 0x55, 0xfd, 0x7e, 0xcc, 0x32, 0xf5, 0x7c, 0x5f, 0x42, 0x11,
+	0x9f, 0x2f, 0x29, 0xc8, 0x7c, 0x4c, 0x90, 0x8f, 0x42, 0xe1, 0xc8, 0x33, 0xa3, 0x5d, 0xa2, 0x4f,
+	0x92, 0x17, 0x9b, 0x91, 0x54, 0x36, 0x45, 0x52, 0xb9, 0x48, 0x52, 0xc6, 0xff, 0x65, 0x60, 0xe9,
+	0xd0, 0xf2, 0xd0, 0x20, 0xec, 0x81, 0xe3, 0x0f, 0x8f, 0xc6, 0x76, 0x0f, 0x4d, 0xa9, 0xe8, 0x07,
+	0xe8, 0x34, 0xed, 0x33, 0xa9, 0xb7, 0xb5, 0xa7, 0xf7, 0xf8, 0xbc, 0x53, 0x7c, 0x9b, 0x47, 0x8a,
+	0xc9, 0x0c, 0xd9, 0x45, 0x13, 0x8a, 0x3d, 0xf4, 0xa6, 0x81, 0x35, 0x92, 0x6a, 0x92, 0x37, 0xc3,
+	0x3e, 0xca, 0xa8, 0xdc, 0x73, 0xed, 0xd3, 0x53, 0xa7, 0x47, 0xce, 0x94, 0x4f, 0x91, 0x31, 0xe3,
+	0x24, 0xd2, 0xfa, 0x21, 0xfa, 0x86, 0x3c, 0x4f, 0xa4, 0x26, 0x53, 0xac, 0xaf, 0xf8, 0xbd, 0x89,
+	0x62, 0x7d, 0x65, 0x7c, 0x1f, 0x8a, 0x7a, 0x5f, 0x51, 0x81, 0x62, 0xeb, 0xe5, 0xc1, 0x51, 0x67,
+	0xfb, 0xa0, 0x53, 0xbf, 0x25, 0x96, 0xa0, 0xdc, 0x7a, 0xd9, 0x7e, 0xfe, 0x7c, 0xaf, 0xb5, 0xd7,
+	0x46, 0x42, 0x46, 0x54, 0x51, 0x25, 0x8f, 0x3b, 0x2f, 0x8f, 0x5a, 0xdb, 0xfb, 0xed, 0x7a, 0xd6,
+	0xd8, 0x82, 0x85, 0x5d, 0x2b, 0x98, 0x0c, 0x49, 0x0e, 0xec, 0x94, 0x95, 0x50, 0xa9, 0x4d, 0xb4,
+	0x73, 0xcb, 0x3f, 0xe7, 0x9d, 0x2a, 0x26, 0xb7, 0x8d, 0x7f, 0xcf, 0x40, 0xe5, 0x95, 0xeb, 0x7d,
+	0x69, 0x7b, 0x47, 0x18, 0x25, 0x26, 0x3e, 0xda, 0x60, 0xe9, 0x35, 0xf7, 0xbb, 0xa1, 0x61, 0x55,
+	0xf0, 0xe9, 0x8a, 0x92, 0x09, 0xcd, 0xab, 0x28, 0x87, 0xf7, 0xfa, 0x78, 0xd9, 0xc5, 0x2f, 0xdc,
+	0x13, 0xe2, 0xe3, 0x17, 0xd8, 0x29, 0x21, 0xdf, 0x02, 0x3d, 0xeb, 0xae, 0xb9, 0x80, 0x03, 0xc8,
+	0x71, 0x1f, 0xf2, 0x7d, 0x2b, 0xb0, 0x12, 0x1a, 0xc3, 0xe7, 0x33, 0x99, 0x2e, 0xfe, 0x0c, 0xfd,
+	0x6f, 0x60, 0x79, 0x81, 0xdd, 0x57, 0x4a, 0xd3, 0xdc, 0x94, 0xa1, 0x6b, 0x53, 0x87, 0xb6, 0xcd,
+	0x8e, 0x8e, 0x7d, 0xa6, 0x66, 0x35, 0x3e, 0x85, 0x8a, 0x69, 0xfb, 0xee, 0xc4, 0xeb, 0xd9, 0xfc,
+	0x96, 0x14, 0x02, 0xc6, 0x13, 0x3e, 0x6c, 0xd6, 0xa4, 0x26, 0xd9, 0xd6, 0xd0, 0x1e, 0xba, 0xde,
+	0x1b, 0xa5, 0x1b, 0xaa, 0x47, 0x9c, 0x67, 0xc8, 0x99, 0x63, 0xef, 0x47, 0x4d, 0xe3, 0x07, 0x50,
+	0x3b, 0xea, 0x9d, 0xdb, 0xfd, 0xc9, 0xc0, 0x19, 0x9d, 0xf1, 0x6a, 0xf8, 0xbc, 0x63, 0xcf, 0x71,
+	0x3d, 0x27, 0x90, 0x9a, 0x91, 0x33, 0xc3, 0x3e, 0x49, 0x30, 0xb0, 0xad, 0xa1, 0xd6, 0x38, 0x6a,
+	0x1b, 0xbf, 0x2d, 0x43, 0x81, 0x75, 0xf9, 0xd4, 0xc5, 0xb9, 0x39, 0xbc, 0xb8, 0xd2, 0xd9, 0x22,
+	0x5f, 0x17, 0x87, 0x4c, 0x22, 0x62, 0x00, 0x28, 0x05, 0x3a, 0x0c, 0x25, 0x5c, 0x5c, 0x18, 0x9c,
+	0xcc, 0x88, 0x01, 0xfd, 0x71, 0x79, 0xec, 0x8c, 0x51, 0x0f, 0x47, 0x36, 0x09, 0x78, 0x85, 0x05,
+	0x5c, 0x43, 0x01, 0xc3, 0xa1, 0x22, 0xa3, 0x94, 0x41, 0xb3, 0xec, 0x51, 0xd4, 0x2b, 0xea, 0x1e,
+	0xdf, 0x4f, 0x1b, 0xa0, 0x66, 0x37, 0xc3, 0x61, 0x64, 0xad, 0x87, 0x6b, 0x5f, 0xd8, 0x9e, 0x4f,
+	0x36, 0x5d, 0x65, 0xed, 0x5b, 0xd2, 0xf4, 0x1f, 0x49, 0xb2, 0xf8, 0x04, 0x59, 0x23, 0x8b, 0xe8,
+	0xfa, 0x28, 0x20, 0x15, 0x1c, 0x56, 0xd3, 0xcc, 0x05, 0x17, 0x98, 0xb2, 0xb3, 0x77, 0x61, 0xd1,
+	0x21, 0x2b, 0x97, 0x20, 0x40, 0x1f, 0x4a, 0xdb, 0xbe, 0xa9, 0x06, 0xc9, 0xde, 0x55, 0x44, 0x5b,
+	0xd2, 0xf6, 0x8e, 0x6c, 0x2a, 0x94, 0xa9, 0x21, 0xf1, 0x3e, 0x00, 0x2e, 0x8f, 0x46, 0xd4, 0x25,
+	0x21, 0x2f, 0x4e, 0x09, 0xb9, 0x24, 0xc7, 0x28, 0x2a, 0xc4, 0xd4, 0xaa, 0x70, 0x6d, 0xb5, 0x12,
+	0x18, 0xd1, 0x4e, 0x9d, 0x91, 0xe3, 0xa3, 0x36, 0x34, 0x8a, 0x57, 0x4e, 0x0b, 0x79, 0xc5, 0x77,
+	0xa1, 0xea, 0x4e, 0x02, 0xbc, 0x86, 0x76, 0xc5, 0xa5, 0x59, 0x97, 0x55, 0x91, 0x1c, 0xb2, 0x87,
+	0xb7, 0x45, 0x50, 0x80, 0xe6, 0x8d, 0xe1, 0x9b, 0x3c, 0x4f, 0x28, 0x13, 0x32, 0x41, 0xdb, 0x94,
+	0x63, 0xe2, 0x3d, 0xc2, 0x26, 0x1c, 0xc2, 0x1a, 0x35, 0x5e, 0xb0, 0xa2, 0xb0, 0x09, 0xd3, 0x4c,
+	0x3d, 0x28, 0x1a, 0x74, 0x59, 0x77, 0x3c, 0xc6, 0x53, 0xd7, 0xd9, 0xe9, 0xe9, 0x2e, 0xbe, 0x33,
+	0xc8, 0x6d, 0x4d, 0x8a, 0x49, 0x82, 0x17, 0x29, 0xf1, 0xa9, 0x88, 0x60, 0xc6, 0x06, 0x31, 0x42,
+	0xa8, 0x13, 0xee, 0xc8, 0x50, 0xb5, 0xcc, 0x0a, 0x9e, 0xa0, 0xd1, 0x46, 0x9e, 0xcd, 0xc2, 0x6a,
+	0xac, 0xb2, 0xb6, 0xe8, 0x2e, 0x3e, 0x72, 0x8d, 0xcc, 0xb9, 0x8b, 0x62, 0xea, 0xe1, 0x43, 0xe1,
+	0x49, 0xd6, 0xd9, 0x70, 0xaa, 0x44, 0x3d, 0xd4, 0x44, 0x82, 0x8b, 0xcc, 0x16, 0x20, 0x20, 0x1d,
+	0x34, 0x36, 0x24, 0x04, 0x21, 0x4a, 0x87, 0x08, 0x28, 0xff, 0xaa, 0xf2, 0x3c, 0x3e, 0xbb, 0xa2,
+	0x46, 0x83, 0x35, 0x66, 0x99, 0xaf, 0x1d, 0xf7, 0x51, 0x66, 0xe5, 0x75, 0xdc, 0x63, 0xe1, 0x3c,
+	0x4f, 0xb9, 0x03, 0xa9, 0xa0, 0xb7, 0xf9, 0xa6, 0xcb, 0x0a, 0xbd, 0x44, 0x8e, 0xc2, 0xac, 0x78,
+	0x71, 0xb7, 0x81, 0xf1, 0x8c, 0xb5, 0xaf, 0xd1, 0x64, 0xfe, 0x44, 0x3c, 0xe3, 0x01, 0xf1, 0x0c,
+	0x96, 0xc2, 0x95, 0x07, 0x0e, 0xbe, 0x9c, 0xdf, 0xb8, 0x33, 0x6f, 0xed, 0x9a, 0xe6, 0xdc, 0x67,
+	0x46, 0xf1, 0x14, 0x2a, 0x84, 0xb4, 0xba, 0x43, 0x3b, 0xf0, 0x9c, 0x9e, 0xdf, 0xb8, 0xcb, 0x97,
+	0x91, 0x90, 0x8a, 0x10, 0xd7, 0x67, 0x4c, 0x37, 0xcb, 0x93, 0xb0, 0xed, 0x8b, 0x43, 0xa8, 0x63,
+	0x70, 0x54, 0xd8, 0xae, 0x3b, 0x70, 0xad, 0xbe, 0xdf, 0xb8, 0x17, 0x43, 0x78, 0x21, 0x18, 0xda,
+	0xc7, 0xa1, 0x1d, 0x81, 0xde, 0xa0, 0x96, 0x20, 0xf9, 0x66, 0x0d, 0xe7, 0xc7, 0xfa, 0xe4, 0xb0,
+	0x7c, 0x6b, 0x10, 0x34, 0xee, 0x4b, 0x87, 0x45, 0x6d, 0x42, 0xa2, 0x7d, 0xf2, 0xc1, 0x5d, 0x0a,
+	0x00, 0xa1, 0x03, 0x78, 0xc0, 0xcf, 0x51, 0xe7, 0x91, 0x1f, 0xe2, 0x80, 0xf6, 0x00, 0xe8, 0x4a,
+	0x3d, 0xdb, 0xf2, 0x91, 0xe3, 0xa1, 0x74, 0xa5, 0xb2, 0x27, 0x3a, 0xb0, 0x24, 0x57, 0xe9, 0x59,
+	0xe8, 0x3f, 0xbb, 0x41, 0x30, 0x68, 0xbc, 0xc3, 0xb2, 0xb9, 0x3d, 0x63, 0x34, 0xbb, 0x2a, 0x3b,
+	0xd9, 0x59, 0xc6, 0x13, 0x57, 0xd9, 0xff, 0xb7, 0x68, 0x52, 0xa7, 0xb3, 0xcf, 0x2a, 0xa2, 0xbb,
+	0xc1, 0x40, 0xbc, 0x82, 0xdb, 0xf1, 0x55, 0x9d, 0x11, 0x02, 0x47, 0xa7, 0x2f, 0xd3, 0x97, 0x86,
+	0xc1, 0xa2, 0xb8, 0x13, 0x45, 0x11, 0x9e, 0xb6, 0x17, 0xe3, 0x31, 0x37, 0xfa, 0xa9, 0x74, 0x5f,
+	0x7c, 0x04, 0x4b, 0x7e, 0xe8, 0xe7, 0xa5, 0x9a, 0x3c, 0xe2, 0xe3, 0xae, 0x48, 0xc9, 0x26, 0x62,
+	0x00, 0x8a, 0x31, 0xd1, 0xff, 0x34, 0x5f, 0xcc, 0xd7, 0x17, 0x8c, 0x5d, 0x58, 0x94, 0x6a, 0x98,
+	0x8a, 0xfd, 0xde, 0xd3, 0x46, 0x9d, 0x65, 0xa3, 0xae, 0x4f, 0xa9, 0xad, 0xb6, 0x6b, 0xe3, 0x7b,
+	0x0a, 0xfa, 0x9c, 0xba, 0xe4, 0xd1, 0x8a, 0x1c, 0x41, 0xb1, 0x83, 0x6b, 0xe5, 0x42, 0x23, 0x57,
+	0x0c, 0x66, 0xe1, 0x0b, 0xd9, 0x30, 0xee, 0x43, 0x51, 0x3b, 0xf2, 0xb4, 0xcd, 0x8d, 0x7f, 0xcd,
+	0x40, 0x35, 0x0c, 0x0c, 0x09, 0x54, 0xb5, 0x90, 0xc8, 0xd7, 0x24, 0x3c, 0xcd, 0x4c, 0xbb, 0x82,
+	0x69, 0xa4, 0x9a, 0x4d, 0x20, 0x55, 0x8d, 0xb3, 0x72, 0x29, 0x38, 0x2b, 0x9f, 0x40, 0xa4, 0x79,
+	0x82, 0x9f, 0xca, 0x33, 0x27, 0xfc, 0x1f, 0x0f, 0x18, 0x5f, 0xc3, 0x7a, 0xfa, 0xbb, 0xcd, 0xc3,
+	0xf1, 0xa9, 0x10, 0x0f, 0xbd, 0x3b, 0x26, 0x5a, 0x16, 0x79, 0xf7, 0xdc, 0xd5, 0xde, 0x5d, 0xb1,
+	0x1a, 0xff, 0x59, 0x82, 0x4a, 0x24, 0xa1, 0x53, 0x57, 0x6d, 0xb9, 0x3c, 0xb3, 0x65, 0x3c, 0x90,
+	0x66, 0x2e, 0x0f, 0xa4, 0xe8, 0x11, 0xb5, 0xf9, 0x94, 0xa5, 0x47, 0x54, 0xdd, 0x1b, 0x06, 0xfb,
+	0xb4, 0x28, 0x0b, 0x37, 0x89, 0xb2, 0x4f, 0xc2, 0x28, 0x9b, 0x8f, 0xb9, 0x8b, 0x84, 0x42, 0xdc,
+	0x2c, 0xd4, 0x7e, 0x08, 0xa0, 0x04, 0xd7, 0xb5, 0x02, 0xf5, 0xa0, 0x97, 0x89, 0xb9, 0xa4, 0xb8,
+	0xb7, 0x03, 0x4c, 0x02, 0x94, 0x1d, 0x14, 0xd8, 0x0e, 0x92, 0x47, 0x49, 0x44, 0xb8, 0x77, 0x00,
+	0x1d, 0x72, 0x8f, 0xe2, 0xb9, 0xed, 0x79, 0xae, 0xc7, 0x41, 0xb7, 0x64, 0x96, 0x25, 0xad, 0x4d,
+	0x24, 0x94, 0x0c, 0x90, 0x81, 0xf4, 0xa8, 0xa6, 0x20, 0x33, 0xee, 0xf2, 0xd3, 0x87, 0x53, 0x97,
+	0x3b, 0x75, 0xc9, 0x5e, 0x5a, 0xcc, 0x22, 0x73, 0xfb, 0xd2, 0x17, 0xba, 0x1f, 0x8f, 0x8e, 0xd5,
+	0x64, 0x74, 0x9c, 0x0e, 0x79, 0xf5, 0x94, 0x90, 0xb7, 0x07, 0xc2, 0xef, 0x59, 0x03, 0x7b, 0xd7,
+	0x7d, 0x3d, 0xea, 0x9c, 0xa3, 0x64, 0xce, 0xdd, 0x41, 0x5f, 0x45, 0xd2, 0xf9, 0x7e, 0xce, 0x4c,
+	0x99, 0x34, 0x1b, 0xa5, 0x56, 0x6e, 0x18, 0xa5, 0x56, 0xe7, 0x45, 0x29, 0xcc, 0x39, 0xfa, 0xb6,
+	0xdf, 0xf3, 0x9c, 0x31, 0x6d, 0xde, 0x58, 0x93, 0x52, 0x8c, 0x91, 0xc8, 0xb0, 0xad, 0x49, 0x70,
+	0x8e, 0x22, 0x5e, 0x97, 0x86, 0x2d, 0x7b, 0x69, 0xf1, 0x6d, 0xe3, 0xba, 0xf1, 0x4d, 0x47, 0x96,
+	0xc6, 0x95, 0x91, 0xe5, 0xf6, 0x9c, 0xc8, 0x92, 0x12, 0x41, 0x9a, 0x7f, 0xe0, 0x08, 0x72, 0xe7,
+	0xdb, 0x8d, 0x20, 0x77, 0xaf, 0x1d, 0x41, 0x9a, 0x1f, 0x41, 0x2d, 0xa9, 0xa4, 0xf1, 0xa2, 0xc9,
+	0x42, 0x4a, 0xd1, 0x64, 0x21, 0x56, 0x34, 0xc1, 0xf8, 0x93, 0xab, 0xe7, 0x8d, 0x17, 0x71, 0x1f,
+	0x4f, 0xe1, 0x03, 0x75, 0x2a, 0x4a, 0x12, 0xa2, 0x18, 0xb2, 0x3c, 0x63, 0x20, 0x66, 0x65, 0x1c,
+	0xeb, 0x19, 0xbf, 0xcb, 0x43, 0xbd, 0xc5, 0x06, 0x4b, 0xc0, 0xd9, 0xfe, 0xc9, 0x04, 0xad, 0x38,
+	0xe9, 0xb2, 0x32, 0x57, 0xb9, 0xac, 0xb8, 0x97, 0xcc, 0xde, 0x3c, 0xdd, 0x80, 0xeb, 0xa7, 0x1b,
+	0x85, 0x6f, 0x96, 0x6e, 0xe4, 0xaf, 0x97, 0x6e, 0x94, 0xe6, 0xfb, 0xc0, 0x18, 0x00, 0x2f, 0x5e,
+	0x06, 0xc0, 0x93, 0x30, 0xbb, 0x72, 0x13, 0x98, 0x5d, 0x4e, 0xf1, 0x39, 0xc9, 0x2c, 0xa7, 0x3a,
+	0x3f, 0xcb, 0x99, 0xf1, 0x28, 0xb5, 0x1b, 0x7a, 0x94, 0xa5, 0x1b, 0xe0, 0xde, 0xfa, 0x75, 0xfd,
+	0xc2, 0x06, 0x14, 0xfa, 0xde, 0x9b, 0xae, 0x37, 0x19, 0x71, 0x6c, 0x2d, 0x9a, 0x8b, 0xd8, 0x35,
+	0x27, 0x23, 0xa5, 0xc3, 0x87, 0xb0, 0xbc, 0x37, 0xa2, 0xd3, 0x06, 0x31, 0xd5, 0xbb, 0x2c, 0x6d,
+	0x7e, 0x00, 0xe5, 0x93, 0x81, 0xdb, 0xfb, 0xb2, 0x1b, 0x81, 0xab, 0xa2, 0x09, 0x4c, 0xe2, 0x60,
+	0x62, 0xfc, 0x43, 0x06, 0x6a, 0xfb, 0x8e, 0x1f, 0x5f, 0xef, 0x06, 0x21, 0x7c, 0x13, 0x2a, 0x7c,
+	0x67, 0x9d, 0xbb, 0x65, 0x75, 0x19, 0x38, 0xc2, 0x2e, 0x65, 0x66, 0x50, 0xa9, 0x1b, 0x5e, 0x6f,
+	0xe4, 0x76, 0x4f, 0x27, 0x83, 0x81, 0x2a, 0x31, 0x2d, 0x8e, 0xdc, 0xe7, 0xd8, 0x33, 0xbe, 0x80,
+	0xa5, 0xe7, 0x83, 0x89, 0x7f, 0x1e, 0x3b, 0xc6, 0xbb, 0x08, 0x54, 0x78, 0x96, 0xaf, 0x0c, 0x33,
+	0xb1, 0xac, 0x1e, 0xc3, 0xfc, 0xb1, 0x12, 0xb8, 0x5d, 0x7d, 0x22, 0x5d, 0x80, 0x9b, 0x3a, 0x71,
+	0x39, 0x70, 0x75, 0xdb, 0x37, 0x36, 0xa1, 0xbe, 0x6b, 0x0f, 0xec, 0x84, 0xf9, 0x5e, 0x22, 0x43,
+	0xe3, 0x3b, 0x50, 0x3b, 0xc2, 0xa8, 0x77, 0x4d, 0xee, 0x5f, 0xa1, 0x40, 0x5f, 0xd8, 0xc1, 0xbe,
+	0x7b, 0xe6, 0xa7, 0x09, 0xf4, 0x0a, 0x6b, 0xbf, 0xec, 0x2d, 0x31, 0xe0, 0x73, 0x02, 0x78, 0xea,
+	0x0c, 0x02, 0xb4, 0x78, 0x2e, 0x0b, 0x51, 0xa8, 0x42, 0xda, 0x73, 0x49, 0x42, 0xa3, 0x2b, 0x4a,
+	0xf7, 0xed, 0xc8, 0x92, 0x50, 0x69, 0xa7, 0x8c, 0x2e, 0xbf, 0xc0, 0xce, 0x1a, 0x01, 0x5a, 0x81,
+	0x07, 0xf7, 0xfa, 0x14, 0xd2, 0x4e, 0x5d, 0xaa, 0x70, 0x33, 0xc0, 0xc5, 0x67, 0x90, 0x3d, 0xae,
+	0xd0, 0x58, 0xce, 0x80, 0x21, 0x4b, 0xce, 0xe4, 0xb6, 0xf1, 0xeb, 0x2c, 0x00, 0xde, 0xe6, 0x33,
+	0x34, 0x6a, 0xfa, 0xc5, 0xe0, 0x51, 0xcc, 0x6b, 0xc6, 0x80, 0x74, 0xe8, 0x22, 0x0f, 0x08, 0x2a,
+	0x4f, 0xd5, 0x5f, 0xb2, 0x57, 0xd6, 0x5f, 0xa2, 0x62, 0x58, 0x6e, 0x4e, 0x31, 0x2c, 0x51, 0x59,
+	0x2b, 0x5c, 0x5a, 0x59, 0xd3, 0x75, 0xb3, 0xfc, 0x9c, 0xba, 0x59, 0x5c, 0x4a, 0xa5, 0x4b, 0xa4,
+	0x84, 0xd2, 0xe0, 0x72, 0x7f, 0x51, 0xa2, 0x74, 0x6a, 0x23, 0x56, 0xcc, 0x72, 0x35, 0xe6, 0x2a,
+	0x48, 0x97, 0x95, 0xe8, 0x69, 0x28, 0xa5, 0xc6, 0x02, 0x2d, 0x99, 0xba, 0x6b, 0x74, 0x60, 0xc5,
+	0x94, 0xd9, 0xbf, 0x3c, 0xd7, 0x35, 0x2c, 0x79, 0xfa, 0xf5, 0xb3, 0x33, 0xaf, 0x6f, 0xfc, 0x53,
+	0x16, 0x41, 0xba, 0xac, 0x17, 0x90, 0x71, 0xfb, 0xe2, 0xaf, 0xa1, 0xda, 0x47, 0x18, 0x45, 0xa9,
+	0x70, 0x97, 0x7e, 0x05, 0x53, 0x2b, 0x5f, 0x82, 0xbd, 0x2a, 0x9a, 0x9f, 0x6e, 0x82, 0x41, 0xbb,
+	0xa2, 0x8a, 0x12, 0x72, 0x7a, 0xf6, 0xaa, 0xe9, 0x65, 0xc5, 0xce, 0xb3, 0x9f, 0x41, 0x79, 0x32,
+	0x8e, 0xf6, 0xce, 0x5d, 0x35, 0x19, 0x24, 0x37, 0xcf, 0xa5, 0x9a, 0x88, 0x3e, 0xf9, 0xc9, 0x9b,
+	0xc0, 0xf6, 0x55, 0xc9, 0x37, 0xbc, 0xcf, 0x0e, 0x11, 0x49, 0x28, 0x6a, 0x0b, 0xc9, 0x24, 0xab,
+	0xc0, 0x6a, 0x5b, 0x66, 0x31, 0x7e, 0x99, 0x81, 0x92, 0x7c, 0xd9, 0x28, 0x6d, 0x99, 0xcd, 0x94,
+	0x94, 0xe4, 0xb3, 0x69, 0x92, 0x7f, 0x57, 0x43, 0xf2, 0x1c, 0x43, 0xf2, 0xa5, 0x48, 0x9f, 0xa6,
+	0xf0, 0x78, 0x5c, 0xeb, 0xaa, 0xec, 0xac, 0xf0, 0x65, 0x24, 0x82, 0x90, 0x8a, 0x17, 0x55, 0x03,
+	0x16, 0x12, 0xd5, 0x80, 0xf7, 0xe5, 0x0e, 0xbe, 0x4a, 0x15, 0x14, 0x02, 0x89, 0xbd, 0xa4, 0xdc,
+	0xc3, 0x37, 0xfe, 0x0a, 0x20, 0xbc, 0x8b, 0x2f, 0xfe, 0x84, 0x2b, 0x42, 0xa4, 0xc7, 0x11, 0x7a,
+	0xa9, 0x45, 0xa7, 0xe3, 0x8d, 0x4b, 0x7d, 0xdd, 0x24, 0xbf, 0x47, 0x9e, 0xfe, 0xba, 0x1a, 0x67,
+	0xec, 0xc1, 0x8a, 0x0a, 0x36, 0xd7, 0x56, 0x52, 0x29, 0xde, 0xec, 0xcc, 0x0f, 0x4a, 0xbf, 0x58,
+	0x84, 0x35, 0x09, 0x99, 0x42, 0x9f, 0x77, 0xf3, 0x60, 0xf3, 0xf6, 0x59, 0x61, 0xe1, 0x0f, 0x9f,
+	0x15, 0x5e, 0x82, 0x88, 0xf0, 0xf5, 0x27, 0xe3, 0x3e, 0x29, 0x92, 0x72, 0xba, 0xb2, 0x37, 0x03,
+	0x6b, 0xe0, 0xda, 0xa9, 0x54, 0xf9, 0x5b, 0x49, 0xa5, 0x2a, 0x37, 0x04, 0x3e, 0xd5, 0x6b, 0xa6,
+	0x52, 0xb5, 0xd9, 0x54, 0x2a, 0x05, 0x1a, 0x2d, 0xdd, 0x34, 0x65, 0xaa, 0xc7, 0x52, 0xa6, 0x79,
+	0x70, 0x29, 0x2d, 0x3b, 0x12, 0x6f, 0x9f, 0x1d, 0xa5, 0x24, 0x31, 0x2b, 0x37, 0x29, 0x83, 0x11,
+	0x84, 0x6b, 0xc1, 0xba, 0xb2, 0xaa, 0x6f, 0x6e, 0x0a, 0xc6, 0x1a, 0xac, 0x90, 0x29, 0x4f, 0xad,
+	0x60, 0xf4, 0x60, 0x4d, 0x22, 0x9b, 0xb7, 0xb0, 0xb2, 0x07, 0xf4, 0x88, 0xb4, 0x06, 0x01, 0x68,
+	0x5f, 0x23, 0xc6, 0xbe, 0x06, 0x4c, 0xbe, 0xb1, 0x0d, 0xab, 0x47, 0x14, 0xb9, 0xde, 0xe2, 0xf8,
+	0x3f, 0x80, 0x15, 0x42, 0x54, 0x6f, 0xb1, 0xc2, 0x3f, 0x66, 0x60, 0xd5, 0xb4, 0xf1, 0xdd, 0xdf,
+	0xe2, 0xa6, 0x08, 0x30, 0xed, 0xaf, 0x7a, 0x83, 0x49, 0xdf, 0x4e, 0xc3, 0xad, 0x7a, 0x8c, 0xd8,
+	0x9c, 0x91, 0x64, 0xcb, 0xa5, 0xb0, 0xa9, 0x31, 0xe3, 0x15, 0xac, 0xb7, 0x06, 0xb6, 0xe5, 0x45,
+	0x0a, 0xf4, 0x0d, 0x8e, 0x94, 0x52, 0xb0, 0x33, 0x06, 0x20, 0xcc, 0xb7, 0xba, 0xe7, 0x1f, 0x63,
+	0x4a, 0xe4, 0xb9, 0x17, 0xf6, 0x08, 0x3d, 0x49, 0xea, 0x55, 0x63, 0xc3, 0xc6, 0xcf, 0x33, 0xb0,
+	0xde, 0xf1, 0x9c, 0xb3, 0x33, 0xdb, 0x7b, 0x8b, 0x2d, 0x55, 0x76, 0x9e, 0x8d, 0x3e, 0x69, 0x48,
+	0x1e, 0x22, 0x77, 0xf9, 0x21, 0x26, 0xb0, 0xa6, 0x6c, 0x44, 0x1d, 0xe5, 0x5b, 0x39, 0xc2, 0x54,
+	0x2e, 0x94, 0x9b, 0xc9, 0x85, 0x5a, 0x50, 0x4d, 0x7c, 0x05, 0x22, 0xee, 0x42, 0xbe, 0xe7, 0xf4,
+	0x3d, 0x85, 0x17, 0x8a, 0xe8, 0x1f, 0xf2, 0x2d, 0x8c, 0x68, 0x26, 0x53, 0xa9, 0xe0, 0x40, 0x1f,
+	0x3b, 0x48, 0x28, 0xb6, 0x60, 0xca, 0x8e, 0xd1, 0x05, 0x88, 0x7e, 0xa0, 0x48, 0x2d, 0x75, 0xbf,
+	0x8f, 0x20, 0xfb, 0xcd, 0x58, 0x57, 0xba, 0x57, 0xa6, 0x7e, 0xd3, 0xe8, 0xe0, 0x90, 0xc9, 0x0c,
+	0x51, 0x45, 0x43, 0xfe, 0x10, 0x2e, 0x3b, 0xc6, 0x43, 0x80, 0xe8, 0xa3, 0x12, 0xfe, 0xa5, 0x3a,
+	0xfa, 0x2c, 0x83, 0xdb, 0xc6, 0xe7, 0x50, 0x0a, 0x7f, 0xd8, 0x48, 0xf9, 0x4e, 0x04, 0xe3, 0x90,
+	0xfc, 0x08, 0x47, 0x17, 0xaa, 0x65, 0x8f, 0x7e, 0xba, 0x0d, 0xd0, 0xa2, 0x7a, 0x91, 0x70, 0xc2,
+	0xbe, 0xf1, 0x21, 0x54, 0x13, 0xbf, 0x95, 0xd0, 0xd9, 0x02, 0xeb, 0x64, 0x10, 0x7e, 0x4e, 0xc4,
+	0x1d, 0xfe, 0x82, 0xc3, 0x7d, 0x2d, 0xbd, 0x06, 0xe6, 0x0f, 0xd4, 0x36, 0xfe, 0x23, 0x03, 0x45,
+	0xfd, 0x1d, 0x43, 0xaa, 0x3c, 0xd4, 0x09, 0xb3, 0x69, 0x27, 0xcc, 0x25, 0x4e, 0x88, 0x9b, 0xa2,
+	0x1e, 0x78, 0xfa, 0x2b, 0x27, 0xd9, 0xe1, 0xc0, 0x40, 0xae, 0x58, 0xd5, 0xea, 0xa9, 0x2d, 0x13,
+	0x1c, 0x6f, 0xa8, 0xaa, 0xaf, 0x25, 0x53, 0xf5, 0xc2, 0x4f, 0x4c, 0x0a, 0xc9, 0x4f, 0x4c, 0x54,
+	0xfa, 0x5a, 0x8c, 0x7f, 0x4a, 0x62, 0xfc, 0x22, 0x0b, 0x55, 0x86, 0x16, 0x56, 0x8f, 0xe2, 0xc3,
+	0xcb, 0x31, 0x86, 0xaf, 0x0a, 0x83, 0xf6, 0x6e, 0xe2, 0xeb, 0x8a, 0x0d, 0x56, 0x63, 0xf6, 0x89,
+	0x4a, 0x97, 0xa5, 0xb6, 0x9a, 0x65, 0x3f, 0xa2, 0x89, 0x8f, 0xa1, 0x2a, 0x7f, 0xf3, 0x8c, 0x72,
+	0x65, 0x9a, 0xdc, 0x50, 0x38, 0x91, 0x46, 0x92, 0xb3, 0x2b, 0xa7, 0x31, 0xa2, 0xf8, 0xcb, 0xd0,
+	0x2d, 0x63, 0x02, 0xa0, 0xc1, 0xf4, 0x3a, 0x4f, 0x96, 0x2e, 0x9f, 0xa0, 0xa6, 0x9e, 0xaa, 0xdc,
+	0x35, 0x91, 0x44, 0x0b, 0x96, 0x64, 0x75, 0x39, 0xcc, 0x91, 0xc3, 0x8f, 0x05, 0x48, 0xf1, 0x52,
+	0x51, 0x99, 0x59, 0xeb, 0x25, 0xc8, 0xc6, 0xc7, 0xb0, 0x86, 0x3e, 0x28, 0x26, 0x0c, 0x6d, 0x90,
+	0x7f, 0x04, 0x39, 0x77, 0xac, 0x13, 0x74, 0x11, 0xa1, 0x31, 0x2d, 0x32, 0x93, 0x86, 0xd1, 0x85,
+	0x2d, 0xc5, 0xa8, 0x0c, 0xc4, 0x9f, 0x42, 0x39, 0x88, 0x48, 0x4a, 0x92, 0x75, 0xbe, 0x4f, 0x7c,
+	0x9b, 0x38, 0x13, 0x7f, 0x6f, 0xa6, 0x7e, 0x98, 0x4e, 0x73, 0xd8, 0xfa, 0x03, 0x87, 0xff, 0xce,
+	0x20, 0x4a, 0x66, 0x18, 0xc0, 0x3b, 0xa5, 0x94, 0xf9, 0x32, 0xd7, 0x28, 0xf3, 0x25, 0x7e, 0x5d,
+	0xca, 0xc6, 0x2a, 0x58, 0xd3, 0xbf, 0x2e, 0x51, 0x12, 0x22, 0xbf, 0x6f, 0xeb, 0x3b, 0x67, 0x28,
+	0x13, 0xa5, 0xb3, 0x65, 0xa6, 0xed, 0x32, 0x09, 0x33, 0xce, 0x45, 0x46, 0x12, 0x1a, 0x4b, 0x4e,
+	0xa3, 0x74, 0x35, 0x4a, 0x26, 0xf8, 0xda, 0xf2, 0x46, 0x88, 0x18, 0xf4, 0x67, 0x7f, 0x61, 0xff,
+	0xc9, 0xdf, 0xf2, 0x2f, 0x5f, 0xec, 0xa9, 0xd0, 0x64, 0x2a, 0x9f, 0xbe, 0xdc, 0xe9, 0x1e, 0x75,
+	0xb6, 0xcd, 0xce, 0xde, 0xc1, 0x0b, 0xf9, 0x69, 0x0b, 0x51, 0xcc, 0xe3, 0x83, 0x03, 0x22, 0x64,
+	0x34, 0xe1, 0xf9, 0xf6, 0xde, 0xfe, 0xb1, 0xd9, 0xae, 0x67, 0x35, 0xe1, 0xe8, 0xb8, 0xd5, 0x6a,
+	0x1f, 0x1d, 0xd5, 0x73, 0x21, 0xa1, 0xf3, 0xf2, 0xf0, 0xb0, 0xbd, 0x5b, 0xcf, 0x3f, 0xf9, 0x04,
+	0xca, 0xb1, 0x5f, 0xdc, 0x68, 0xfc, 0xf0, 0xe5, 0x6e, 0xb8, 0xe4, 0x2d, 0x4d, 0xd0, 0x2b, 0x64,
+	0x44, 0x0d, 0x80, 0x08, 0xb4, 0x07, 0x2e, 0x90, 0x7d, 0xf2, 0xf7, 0xb1, 0xdf, 0xd1, 0xe4, 0x1a,
+	0x6b, 0xb0, 0x7c, 0xb8, 0x77, 0xd8, 0xde, 0xdf, 0x3b, 0x68, 0xc7, 0x4f, 0xbb, 0x0a, 0xf5, 0x90,
+	0x1c, 0x1d, 0x79, 0x03, 0x56, 0x22, 0x6a, 0x3b, 0x64, 0xcf, 0x26, 0xd8, 0xf5, 0x85, 0x72, 0x09,
+	0x6a, 0x74, 0x89, 0x57, 0x2a, 0x45, 0x92, 0xfb, 0x2f, 0x43, 0x75, 0x77, 0xbb, 0x73, 0xfc, 0x59,
+	0xf7, 0xb0, 0x7d, 0xb0, 0x2b, 0xf7, 0x0e, 0x49, 0xd1, 0x3d, 0x50, 0x9c, 0x92, 0xa4, 0x6f, 0x12,
+	0x63, 0x52, 0x0b, 0xe7, 0x9e, 0x3c, 0x86, 0x5a, 0xd2, 0x4b, 0x8b, 0x32, 0x14, 0x5a, 0x2f, 0x8f,
+	0x0f, 0x3a, 0x6d, 0x13, 0x97, 0x2d, 0xc1, 0xc2, 0x8b, 0xed, 0xe3, 0x17, 0xed, 0x7a, 0xe6, 0xe9,
+	0xff, 0xd7, 0x20, 0xb7, 0x7d, 0xb8, 0x27, 0x36, 0xa1, 0x14, 0xd6, 0x89, 0xc5, 0x5a, 0xcc, 0xdc,
+	0xa2, 0x52, 0x52, 0x33, 0x4c, 0xa0, 0x8c, 0x5b, 0xe2, 0x73, 0x80, 0xa8, 0xba, 0x27, 0xd6, 0x15,
+	0xc0, 0x9e, 0x2a, 0xf7, 0x35, 0x13, 0x5a, 0x68, 0xdc, 0xfb, 0xf9, 0x7f, 0xfd, 0xe6, 0x9f, 0xb3,
+	0x1b, 0x62, 0x6d, 0xeb, 0xe2, 0x4f, 0xf9, 0x53, 0x59, 0x42, 0x6d, 0x5b, 0x3f, 0xc5, 0x7f, 0x37,
+	0x9d, 0xfe, 0xcf, 0xd0, 0xfa, 0x0b, 0xaa, 0xba, 0x27, 0x64, 0xa0, 0x49, 0xd6, 0xfa, 0x9a, 0xd5,
+	0xf8, 0x62, 0xbe, 0xb1, 0xca, 0xab, 0xd5, 0x44, 0x25, 0xbe, 0x1a, 0xda, 0x6a, 0x51, 0x17, 0xe7,
+	0x84, 0x4c, 0x9e, 0xa6, 0x6a, 0x75, 0x53, 0x67, 0xba, 0xf5, 0xdd, 0x8c, 0xf8, 0x1b, 0xcc, 0xba,
+	0x35, 0x66, 0x54, 0x77, 0x9f, 0x2e, 0xba, 0x35, 0xd7, 0x67, 0xb0, 0x78, 0x9b, 0xbe, 0xe3, 0xd5,
+	0x77, 0x7a, 0x32, 0xe7, 0x4e, 0x3f, 0x86, 0x82, 0xaa, 0xc7, 0xa9, 0x3b, 0x25, 0xab, 0x73, 0x73,
+	0x97, 0x35, 0x78, 0xd9, 0xbb, 0x46, 0x33, 0x75, 0xd9, 0x2d, 0xfa, 0x65, 0x4b, 0xec, 0xf0, 0xc7,
+	0x51, 0x61, 0x61, 0x46, 0x34, 0x74, 0x5e, 0x32, 0x5d, 0xab, 0x99, 0xbb, 0xcb, 0x2d, 0xf1, 0xe7,
+	0x50, 0x0a, 0xf3, 0x6c, 0x75, 0xf5, 0xe9, 0xbc, 0xbb, 0xb9, 0x94, 0x74, 0x00, 0x3e, 0x4e, 0xc3,
+	0xe0, 0x12, 0x4f, 0xb7, 0xd5, 0xd6, 0x29, 0x19, 0x78, 0x73, 0xca, 0x7b, 0xe0, 0xdc, 0x13, 0xa8,
+	0x25, 0x1d, 0xb9, 0xb8, 0xc4, 0xbb, 0xcf, 0x3d, 0xfa, 0x5d, 0x16, 0xd0, 0xba, 0xb1, 0xac, 0x05,
+	0x14, 0x56, 0x55, 0x9f, 0x65, 0x9e, 0x08, 0x74, 0xe2, 0x53, 0x89, 0x8b, 0xb8, 0x13, 0x3f, 0xe2,
+	0xf4, 0x2e, 0xb3, 0x0e, 0xd6, 0xf8, 0x80, 0x37, 0x78, 0x24, 0xde, 0x99, 0xd9, 0x60, 0xeb, 0xa7,
+	0xba, 0xb9, 0x49, 0x98, 0xe0, 0x67, 0xe2, 0x15, 0x54, 0xe2, 0x19, 0x8e, 0x92, 0x46, 0x4a, 0xd2,
+	0xd3, 0x14, 0x33, 0xfb, 0xf8, 0xc6, 0x6d, 0xde, 0x68, 0x45, 0xcc, 0xde, 0x44, 0xb8, 0x50, 0x4b,
+	0xe6, 0x48, 0x4a, 0x54, 0xa9, 0x89, 0xd3, 0x5c, 0x51, 0xa9, 0x9b, 0x3c, 0xb9, 0xc6, 0x4d, 0x7c,
+	0x84, 0x4e, 0xf1, 0x7c, 0x49, 0xdc, 0x56, 0x4a, 0x3b, 0x9b, 0x43, 0xcd, 0xdd, 0x6e, 0x8b, 0xb7,
+	0xfb, 0xc0, 0x78, 0xff, 0xca, 0xed, 0xb6, 0xe4, 0x37, 0x45, 0x63, 0xa8, 0xc4, 0x33, 0x2c, 0x25,
+	0xbe, 0x94, 0xa4, 0x6b, 0xee, 0x96, 0x9b, 0xbc, 0xe5, 0x63, 0xe3, 0xbd, 0xeb, 0x6c, 0x89, 0x96,
+	0xb3, 0x0b, 0xd5, 0x44, 0x42, 0xa6, 0xae, 0x99, 0x96, 0xa4, 0x5d, 0x62, 0x3b, 0x08, 0x0b, 0x62,
+	0xc9, 0x8e, 0x90, 0xdf, 0x9f, 0xcf, 0xa6, 0x3f, 0x09, 0xb7, 0xf9, 0x8c, 0xd0, 0x45, 0x22, 0x63,
+	0x51, 0x8a, 0x99, 0x9e, 0xc7, 0x24, 0xe6, 0x7e, 0x04, 0xb5, 0x64, 0xa6, 0xa1, 0xb4, 0x21, 0x35,
+	0xfd, 0x98, 0x76, 0x73, 0x78, 0xe7, 0x5a, 0x12, 0x16, 0xa9, 0xd9, 0xa9, 0x58, 0xa9, 0xb9, 0x3a,
+	0x0d, 0x8f, 0xd4, 0x2a, 0x1f, 0x6b, 0x57, 0x89, 0xc9, 0x87, 0x98, 0x23, 0x9a, 0x4b, 0x44, 0xf6,
+	0x02, 0x0a, 0xea, 0xf7, 0x06, 0xe5, 0x0e, 0x93, 0xbf, 0x3e, 0x28, 0x57, 0x13, 0x55, 0xf0, 0x67,
+	0x9d, 0xfc, 0x00, 0xb9, 0xd1, 0x65, 0x7f, 0x82, 0x96, 0xc1, 0xb0, 0xe9, 0x5a, 0x4e, 0x44, 0x79,
+	0xb0, 0x10, 0x67, 0x49, 0xc7, 0x27, 0xfb, 0x97, 0xc4, 0xbb, 0x94, 0x69, 0x3f, 0x84, 0xa5, 0xa9,
+	0xcc, 0x59, 0xbd, 0x5f, 0x7a, 0x3e, 0x3d, 0x5f, 0x14, 0x3b, 0x0b, 0x3f, 0xa6, 0xff, 0x1e, 0x72,
+	0xb2, 0xc8, 0x03, 0xdf, 0xfb, 0x3d, 0x52, 0x5e, 0xb6, 0x68, 0x42, 0x32, 0x00, 0x00,
 }
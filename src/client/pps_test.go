@@ -4,7 +4,6 @@ import (
 	"bytes"
 
 	"github.com/pachyderm/pachyderm/src/client"
-	"github.com/pachyderm/pachyderm/src/client/pps"
 )
 
 func Example_pps() {
@@ -19,15 +18,14 @@ func Example_pps() {
 
 	// Create a map pipeline
 	if err := c.CreatePipeline(
-		"map", // the name of the pipeline
+		"map",                  // the name of the pipeline
 		"pachyderm/test_image", // your docker image
 		[]string{"map"},        // the command run in your docker image
 		nil,                    // no stdin
 		nil,                    // let pachyderm decide the parallelism
-		[]*pps.PipelineInput{
-			// map over "repo"
-			client.NewPipelineInput("repo", client.MapMethod),
-		},
+		// map over "repo"
+		client.NewAtomInput("repo", "/*"),
+		"",    // the default output branch
 		false, // not an update
 	); err != nil {
 		return // handle error
@@ -39,29 +37,23 @@ func Example_pps() {
 		[]string{"reduce"},     // the command run in your docker image
 		nil,                    // no stdin
 		nil,                    // let pachyderm decide the parallelism
-		[]*pps.PipelineInput{
-			// reduce over "map"
-			client.NewPipelineInput("map", client.ReduceMethod),
-		},
+		// reduce over "map"
+		client.NewAtomInput("map", "/"),
+		"",    // the default output branch
 		false, // not an update
 	); err != nil {
 		return // handle error
 	}
 
-	commits, err := c.ListCommitByRepo( // List commits that are...
-		[]string{"reduce"}, // from the "reduce" repo (which the "reduce" pipeline outputs)
-		nil,                // no provenance
-		client.CommitTypeRead,     // are readable
-		client.CommitStatusNormal, // ignore cancelled commits
-		true, // block until commits are available
-	)
+	// List commits from the "reduce" repo (which the "reduce" pipeline outputs)
+	commits, err := c.ListCommitByRepo("reduce")
 	if err != nil {
 		return // handle error
 	}
 	for _, commitInfo := range commits {
 		// Read output from the pipeline
 		var buffer bytes.Buffer
-		if err := c.GetFile("reduce", commitInfo.Commit.ID, "file", 0, 0, "", false, nil, &buffer); err != nil {
+		if err := c.GetFile("reduce", commitInfo.Commit.ID, "file", 0, 0, &buffer); err != nil {
 			return //handle error
 		}
 	}
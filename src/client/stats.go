@@ -0,0 +1,128 @@
+package client
+
+import (
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/stats"
+)
+
+// RPCStats describes a single RPC made by the client.
+type RPCStats struct {
+	// Method is the full name of the RPC, e.g. "/pfs.API/PutFile".
+	Method string
+	// Duration is the time from the start of the RPC until it finished, for
+	// streaming RPCs this includes all of the time the stream was open.
+	Duration time.Duration
+	// BytesSent and BytesReceived are the number of bytes (on the wire) that
+	// the client sent to and received from pachd.
+	BytesSent     int64
+	BytesReceived int64
+	// Code is the status code the RPC finished with, codes.OK if it
+	// succeeded.
+	Code codes.Code
+}
+
+// StatsFunc is the type of the callback registered with WithStatsFunc. It's
+// called once for each RPC when it finishes. RPCs may happen concurrently so
+// a StatsFunc must be safe to call from multiple goroutines.
+type StatsFunc func(*RPCStats)
+
+// WithStatsHandler registers a grpc stats.Handler on the client's
+// connections, it receives the full stream of grpc stats events. Most
+// callers will find WithStatsFunc easier to use.
+func WithStatsHandler(handler stats.Handler) Option {
+	return func(c *APIClient) {
+		c.statsHandlers = append(c.statsHandlers, handler)
+	}
+}
+
+// WithStatsFunc registers a callback that's called with the latency, bytes
+// transferred and status code of each RPC the client makes, it's intended
+// for exporting call metrics to a monitoring system.
+func WithStatsFunc(f StatsFunc) Option {
+	return WithStatsHandler(&statsFuncHandler{f})
+}
+
+type rpcStatsKey struct{}
+
+// rpcStats accumulates the stats of an in progress RPC, the bytes are
+// updated atomically because a stream's sends and receives may happen in
+// different goroutines.
+type rpcStats struct {
+	method        string
+	begin         time.Time
+	bytesSent     int64
+	bytesReceived int64
+}
+
+// statsFuncHandler is a stats.Handler which calls a StatsFunc.
+type statsFuncHandler struct {
+	f StatsFunc
+}
+
+func (h *statsFuncHandler) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	return context.WithValue(ctx, rpcStatsKey{}, &rpcStats{method: info.FullMethodName})
+}
+
+func (h *statsFuncHandler) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	r, ok := ctx.Value(rpcStatsKey{}).(*rpcStats)
+	if !ok {
+		return
+	}
+	switch s := s.(type) {
+	case *stats.Begin:
+		r.begin = s.BeginTime
+	case *stats.OutPayload:
+		atomic.AddInt64(&r.bytesSent, int64(s.WireLength))
+	case *stats.InPayload:
+		atomic.AddInt64(&r.bytesReceived, int64(s.WireLength))
+	case *stats.End:
+		h.f(&RPCStats{
+			Method:        r.method,
+			Duration:      s.EndTime.Sub(r.begin),
+			BytesSent:     atomic.LoadInt64(&r.bytesSent),
+			BytesReceived: atomic.LoadInt64(&r.bytesReceived),
+			Code:          grpc.Code(s.Error),
+		})
+	}
+}
+
+func (h *statsFuncHandler) TagConn(ctx context.Context, info *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (h *statsFuncHandler) HandleConn(ctx context.Context, s stats.ConnStats) {}
+
+// multiStatsHandler combines several stats.Handlers into one, as grpc only
+// accepts one per connection.
+type multiStatsHandler []stats.Handler
+
+func (m multiStatsHandler) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	for _, h := range m {
+		ctx = h.TagRPC(ctx, info)
+	}
+	return ctx
+}
+
+func (m multiStatsHandler) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	for _, h := range m {
+		h.HandleRPC(ctx, s)
+	}
+}
+
+func (m multiStatsHandler) TagConn(ctx context.Context, info *stats.ConnTagInfo) context.Context {
+	for _, h := range m {
+		ctx = h.TagConn(ctx, info)
+	}
+	return ctx
+}
+
+func (m multiStatsHandler) HandleConn(ctx context.Context, s stats.ConnStats) {
+	for _, h := range m {
+		h.HandleConn(ctx, s)
+	}
+}
@@ -0,0 +1,50 @@
+package client
+
+import (
+	"net"
+	"sync"
+	"testing"
+
+	types "github.com/gogo/protobuf/types"
+	"github.com/pachyderm/pachyderm/src/client/health"
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func TestStatsFunc(t *testing.T) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	health.RegisterHealthServer(server, healthServer{})
+	go server.Serve(listener)
+	defer server.Stop()
+
+	var mu sync.Mutex
+	var rpcStats []*RPCStats
+	c, err := NewFromAddress(listener.Addr().String(), WithStatsFunc(func(s *RPCStats) {
+		mu.Lock()
+		defer mu.Unlock()
+		rpcStats = append(rpcStats, s)
+	}))
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.healthClient.Health(context.Background(), &types.Empty{})
+	require.NoError(t, err)
+	// pfs isn't registered on the server so this should fail
+	_, err = c.ListRepo(nil)
+	require.YesError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, 2, len(rpcStats))
+	require.Equal(t, "/health.Health/Health", rpcStats[0].Method)
+	require.Equal(t, codes.OK, rpcStats[0].Code)
+	require.True(t, rpcStats[0].Duration > 0)
+	require.True(t, rpcStats[0].BytesSent > 0)
+	require.Equal(t, "/pfs.API/ListRepo", rpcStats[1].Method)
+	require.Equal(t, codes.Unimplemented, rpcStats[1].Code)
+}
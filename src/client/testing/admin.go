@@ -0,0 +1,60 @@
+package testing
+
+import (
+	"github.com/gogo/protobuf/types"
+	"github.com/pachyderm/pachyderm/src/client/admin"
+	"github.com/pachyderm/pachyderm/src/client/version"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// fakeAdminAPIClient is an admin.APIClient which describes a fake cluster
+// running the client's version of pachd.
+type fakeAdminAPIClient struct{}
+
+func (fakeAdminAPIClient) InspectCluster(ctx context.Context, request *types.Empty, opts ...grpc.CallOption) (*admin.ClusterInfo, error) {
+	return &admin.ClusterInfo{
+		ID:      "fake",
+		Version: version.Version,
+	}, nil
+}
+
+func (fakeAdminAPIClient) Extract(ctx context.Context, request *admin.ExtractRequest, opts ...grpc.CallOption) (admin.API_ExtractClient, error) {
+	return nil, ErrUnimplemented
+}
+
+func (fakeAdminAPIClient) Restore(ctx context.Context, opts ...grpc.CallOption) (admin.API_RestoreClient, error) {
+	return nil, ErrUnimplemented
+}
+
+func (fakeAdminAPIClient) ExportRepo(ctx context.Context, request *admin.ExportRepoRequest, opts ...grpc.CallOption) (admin.API_ExportRepoClient, error) {
+	return nil, ErrUnimplemented
+}
+
+func (fakeAdminAPIClient) ImportRepo(ctx context.Context, opts ...grpc.CallOption) (admin.API_ImportRepoClient, error) {
+	return nil, ErrUnimplemented
+}
+
+func (fakeAdminAPIClient) InspectReplication(ctx context.Context, request *types.Empty, opts ...grpc.CallOption) (*admin.ReplicationStatus, error) {
+	return &admin.ReplicationStatus{}, nil
+}
+
+func (fakeAdminAPIClient) PromoteReplica(ctx context.Context, request *types.Empty, opts ...grpc.CallOption) (*types.Empty, error) {
+	return nil, ErrUnimplemented
+}
+
+func (fakeAdminAPIClient) SetReadOnly(ctx context.Context, request *admin.SetReadOnlyRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	return nil, ErrUnimplemented
+}
+
+func (fakeAdminAPIClient) CreateMirror(ctx context.Context, request *admin.CreateMirrorRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	return nil, ErrUnimplemented
+}
+
+func (fakeAdminAPIClient) ListMirror(ctx context.Context, request *types.Empty, opts ...grpc.CallOption) (*admin.MirrorInfos, error) {
+	return nil, ErrUnimplemented
+}
+
+func (fakeAdminAPIClient) DeleteMirror(ctx context.Context, request *admin.DeleteMirrorRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	return nil, ErrUnimplemented
+}
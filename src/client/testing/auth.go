@@ -0,0 +1,68 @@
+package testing
+
+import (
+	"github.com/pachyderm/pachyderm/src/client/auth"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// fakeAuthAPIClient is an auth.APIClient for a fake cluster which doesn't
+// have auth activated. The fake doesn't see the tokens that requests carry,
+// so it can't tell who's calling it.
+type fakeAuthAPIClient struct{}
+
+func (fakeAuthAPIClient) Activate(ctx context.Context, request *auth.ActivateRequest, opts ...grpc.CallOption) (*auth.ActivateResponse, error) {
+	return nil, ErrUnimplemented
+}
+
+func (fakeAuthAPIClient) Deactivate(ctx context.Context, request *auth.DeactivateRequest, opts ...grpc.CallOption) (*auth.DeactivateResponse, error) {
+	return nil, ErrUnimplemented
+}
+
+func (fakeAuthAPIClient) WhoAmI(ctx context.Context, request *auth.WhoAmIRequest, opts ...grpc.CallOption) (*auth.WhoAmIResponse, error) {
+	return nil, ErrUnimplemented
+}
+
+func (fakeAuthAPIClient) GetToken(ctx context.Context, request *auth.GetTokenRequest, opts ...grpc.CallOption) (*auth.GetTokenResponse, error) {
+	return nil, ErrUnimplemented
+}
+
+func (fakeAuthAPIClient) GetOAuthLoginURL(ctx context.Context, request *auth.GetOAuthLoginURLRequest, opts ...grpc.CallOption) (*auth.GetOAuthLoginURLResponse, error) {
+	return nil, ErrUnimplemented
+}
+
+func (fakeAuthAPIClient) Authenticate(ctx context.Context, request *auth.AuthenticateRequest, opts ...grpc.CallOption) (*auth.AuthenticateResponse, error) {
+	return nil, ErrUnimplemented
+}
+
+func (fakeAuthAPIClient) GetACL(ctx context.Context, request *auth.GetACLRequest, opts ...grpc.CallOption) (*auth.GetACLResponse, error) {
+	return nil, ErrUnimplemented
+}
+
+func (fakeAuthAPIClient) SetScope(ctx context.Context, request *auth.SetScopeRequest, opts ...grpc.CallOption) (*auth.SetScopeResponse, error) {
+	return nil, ErrUnimplemented
+}
+
+func (fakeAuthAPIClient) GetRobotToken(ctx context.Context, request *auth.GetRobotTokenRequest, opts ...grpc.CallOption) (*auth.GetRobotTokenResponse, error) {
+	return nil, ErrUnimplemented
+}
+
+func (fakeAuthAPIClient) ModifyAdmins(ctx context.Context, request *auth.ModifyAdminsRequest, opts ...grpc.CallOption) (*auth.ModifyAdminsResponse, error) {
+	return nil, ErrUnimplemented
+}
+
+func (fakeAuthAPIClient) GetAdmins(ctx context.Context, request *auth.GetAdminsRequest, opts ...grpc.CallOption) (*auth.GetAdminsResponse, error) {
+	return nil, ErrUnimplemented
+}
+
+func (fakeAuthAPIClient) RenewToken(ctx context.Context, request *auth.RenewTokenRequest, opts ...grpc.CallOption) (*auth.RenewTokenResponse, error) {
+	return nil, ErrUnimplemented
+}
+
+func (fakeAuthAPIClient) RevokeToken(ctx context.Context, request *auth.RevokeTokenRequest, opts ...grpc.CallOption) (*auth.RevokeTokenResponse, error) {
+	return nil, ErrUnimplemented
+}
+
+func (fakeAuthAPIClient) RevokeUserTokens(ctx context.Context, request *auth.RevokeUserTokensRequest, opts ...grpc.CallOption) (*auth.RevokeUserTokensResponse, error) {
+	return nil, ErrUnimplemented
+}
@@ -0,0 +1,875 @@
+package testing
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/uuid"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+type fakeRepo struct {
+	info     *pfs.RepoInfo
+	commits  map[string]*fakeCommit
+	branches map[string]string
+}
+
+type fakeCommit struct {
+	info *pfs.CommitInfo
+	// seq orders commits by the time they were started
+	seq int
+	// files maps clean paths (see clean) to file contents, directories are
+	// implied by the files they contain.
+	files map[string][]byte
+}
+
+// fakePfsAPIClient is an in-memory implementation of pfs.APIClient.
+type fakePfsAPIClient struct {
+	mu    sync.Mutex
+	repos map[string]*fakeRepo
+	seq   int
+}
+
+func newFakePfsAPIClient() *fakePfsAPIClient {
+	return &fakePfsAPIClient{repos: make(map[string]*fakeRepo)}
+}
+
+func now() *types.Timestamp {
+	t, err := types.TimestampProto(time.Now())
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func clean(p string) string {
+	return path.Clean("/" + p)
+}
+
+func (f *fakePfsAPIClient) getRepo(repo *pfs.Repo) (*fakeRepo, error) {
+	r, ok := f.repos[repo.Name]
+	if !ok {
+		return nil, client.Errorf(client.ErrRepoNotFound, "repo %v not found", repo.Name)
+	}
+	return r, nil
+}
+
+// getCommit returns the commit that commit refers to, commit.ID may be
+// either a commit ID or a branch name.
+func (f *fakePfsAPIClient) getCommit(commit *pfs.Commit) (*fakeCommit, error) {
+	r, err := f.getRepo(commit.Repo)
+	if err != nil {
+		return nil, err
+	}
+	id := commit.ID
+	if head, ok := r.branches[id]; ok {
+		id = head
+	}
+	c, ok := r.commits[id]
+	if !ok {
+		return nil, client.Errorf(client.ErrCommitNotFound, "commit %v not found in repo %v", commit.ID, commit.Repo.Name)
+	}
+	return c, nil
+}
+
+func (f *fakePfsAPIClient) createRepo(repo *pfs.Repo, provenance []*pfs.Repo, description string, labels map[string]string) error {
+	if _, ok := f.repos[repo.Name]; ok {
+		return client.Errorf(client.ErrRepoExists, "repo %v already exists", repo.Name)
+	}
+	for _, provRepo := range provenance {
+		if _, err := f.getRepo(provRepo); err != nil {
+			return err
+		}
+	}
+	f.repos[repo.Name] = &fakeRepo{
+		info: &pfs.RepoInfo{
+			Repo:        repo,
+			Created:     now(),
+			Provenance:  provenance,
+			Description: description,
+			Labels:      labels,
+		},
+		commits:  make(map[string]*fakeCommit),
+		branches: make(map[string]string),
+	}
+	return nil
+}
+
+func (f *fakePfsAPIClient) CreateRepo(ctx context.Context, request *pfs.CreateRepoRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.createRepo(request.Repo, request.Provenance, request.Description, request.Labels); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+func (f *fakePfsAPIClient) InspectRepo(ctx context.Context, request *pfs.InspectRepoRequest, opts ...grpc.CallOption) (*pfs.RepoInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r, err := f.getRepo(request.Repo)
+	if err != nil {
+		return nil, err
+	}
+	return r.info, nil
+}
+
+func (f *fakePfsAPIClient) ListRepo(ctx context.Context, request *pfs.ListRepoRequest, opts ...grpc.CallOption) (*pfs.RepoInfos, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	result := &pfs.RepoInfos{}
+nextRepo:
+	for _, r := range f.repos {
+		for _, provRepo := range request.Provenance {
+			if !f.hasProvenance(r, provRepo.Name) {
+				continue nextRepo
+			}
+		}
+		matches, err := matchLabels(request.LabelSelector, r.info.Labels)
+		if err != nil {
+			return nil, err
+		}
+		if !matches {
+			continue
+		}
+		result.RepoInfo = append(result.RepoInfo, r.info)
+	}
+	sort.Slice(result.RepoInfo, func(i, j int) bool {
+		return result.RepoInfo[i].Repo.Name < result.RepoInfo[j].Repo.Name
+	})
+	return result, nil
+}
+
+// matchLabels returns true if labels match selector. Only equality-based
+// selectors, e.g. "a=b,c!=d,e,!f", are supported.
+func matchLabels(selector string, labels map[string]string) (bool, error) {
+	if strings.ContainsAny(selector, "()") {
+		return false, ErrUnimplemented
+	}
+	for _, requirement := range strings.Split(selector, ",") {
+		requirement = strings.TrimSpace(requirement)
+		var matches bool
+		switch {
+		case requirement == "":
+			continue
+		case strings.Contains(requirement, "!="):
+			parts := strings.SplitN(requirement, "!=", 2)
+			matches = labels[strings.TrimSpace(parts[0])] != strings.TrimSpace(parts[1])
+		case strings.Contains(requirement, "="):
+			parts := strings.SplitN(strings.Replace(requirement, "==", "=", 1), "=", 2)
+			value, ok := labels[strings.TrimSpace(parts[0])]
+			matches = ok && value == strings.TrimSpace(parts[1])
+		case strings.HasPrefix(requirement, "!"):
+			_, ok := labels[strings.TrimSpace(requirement[1:])]
+			matches = !ok
+		default:
+			_, matches = labels[requirement]
+		}
+		if !matches {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// hasProvenance returns true if the repo named provRepo is in the
+// (transitive) provenance of r.
+func (f *fakePfsAPIClient) hasProvenance(r *fakeRepo, provRepo string) bool {
+	for _, repo := range r.info.Provenance {
+		if repo.Name == provRepo {
+			return true
+		}
+		if parent, ok := f.repos[repo.Name]; ok && f.hasProvenance(parent, provRepo) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fakePfsAPIClient) DeleteRepo(ctx context.Context, request *pfs.DeleteRepoRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, err := f.getRepo(request.Repo); err != nil {
+		return nil, err
+	}
+	if !request.Force {
+		for _, r := range f.repos {
+			if f.hasProvenance(r, request.Repo.Name) {
+				return nil, fmt.Errorf("cannot delete repo %v because repo %v depends on it", request.Repo.Name, r.info.Repo.Name)
+			}
+		}
+	}
+	delete(f.repos, request.Repo.Name)
+	return &types.Empty{}, nil
+}
+
+func (f *fakePfsAPIClient) UpdateRepo(ctx context.Context, request *pfs.UpdateRepoRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r, err := f.getRepo(request.Repo)
+	if err != nil {
+		return nil, err
+	}
+	if request.Description != "" {
+		r.info.Description = request.Description
+	}
+	for key, value := range request.Labels {
+		if r.info.Labels == nil {
+			r.info.Labels = make(map[string]string)
+		}
+		r.info.Labels[key] = value
+	}
+	for _, key := range request.RemoveLabels {
+		delete(r.info.Labels, key)
+	}
+	return &types.Empty{}, nil
+}
+
+func (f *fakePfsAPIClient) StartCommit(ctx context.Context, request *pfs.StartCommitRequest, opts ...grpc.CallOption) (*pfs.Commit, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r, err := f.getRepo(request.Parent.Repo)
+	if err != nil {
+		return nil, err
+	}
+	commit := &pfs.Commit{
+		Repo: request.Parent.Repo,
+		ID:   uuid.NewWithoutDashes(),
+	}
+	c := &fakeCommit{
+		info: &pfs.CommitInfo{
+			Commit:     commit,
+			Started:    now(),
+			Provenance: request.Provenance,
+		},
+		files: make(map[string][]byte),
+		seq:   f.seq,
+	}
+	f.seq++
+	parentID := request.Parent.ID
+	if parentID == "" {
+		parentID = r.branches[request.Branch]
+	}
+	if parentID != "" {
+		parent, err := f.getCommit(&pfs.Commit{Repo: request.Parent.Repo, ID: parentID})
+		if err != nil {
+			return nil, err
+		}
+		if parent.info.Finished == nil {
+			return nil, fmt.Errorf("parent commit %v has not been finished", parentID)
+		}
+		c.info.ParentCommit = parent.info.Commit
+		for p, data := range parent.files {
+			c.files[p] = data
+		}
+	}
+	r.commits[commit.ID] = c
+	if request.Branch != "" {
+		r.branches[request.Branch] = commit.ID
+	}
+	return commit, nil
+}
+
+func (f *fakePfsAPIClient) FinishCommit(ctx context.Context, request *pfs.FinishCommitRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, err := f.getCommit(request.Commit)
+	if err != nil {
+		return nil, err
+	}
+	if c.info.Finished != nil {
+		return nil, client.Errorf(client.ErrCommitFinished, "commit %v in repo %v has already finished", request.Commit.ID, request.Commit.Repo.Name)
+	}
+	c.info.Finished = now()
+	c.info.SizeBytes = 0
+	for _, data := range c.files {
+		c.info.SizeBytes += uint64(len(data))
+	}
+	r := f.repos[request.Commit.Repo.Name]
+	r.info.SizeBytes = c.info.SizeBytes
+	return &types.Empty{}, nil
+}
+
+func (f *fakePfsAPIClient) FinishCommits(ctx context.Context, request *pfs.FinishCommitsRequest, opts ...grpc.CallOption) (*pfs.Transaction, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	transaction := &pfs.Transaction{ID: fmt.Sprintf("transaction-%d", f.seq)}
+	f.seq++
+	var commits []*fakeCommit
+	for _, commit := range request.Commits {
+		c, err := f.getCommit(commit)
+		if err != nil {
+			return nil, err
+		}
+		if c.info.Finished != nil {
+			return nil, client.Errorf(client.ErrCommitFinished, "commit %v in repo %v has already finished", commit.ID, commit.Repo.Name)
+		}
+		commits = append(commits, c)
+		for branch, id := range f.repos[commit.Repo.Name].branches {
+			if id == c.info.Commit.ID {
+				transaction.Branches = append(transaction.Branches, &pfs.Branch{Name: branch, Head: c.info.Commit})
+			}
+		}
+	}
+	var deleteFrom []*fakeCommit
+	for _, file := range request.DeleteFiles {
+		c, err := f.getCommit(file.Commit)
+		if err != nil {
+			return nil, err
+		}
+		finishing := false
+		for _, finished := range commits {
+			finishing = finishing || finished == c
+		}
+		if !finishing {
+			return nil, fmt.Errorf("file %v is deleted from commit %v in repo %v, which isn't being finished", file.Path, file.Commit.ID, file.Commit.Repo.Name)
+		}
+		deleteFrom = append(deleteFrom, c)
+	}
+	for i, c := range deleteFrom {
+		deleteFile(c, request.DeleteFiles[i].Path)
+	}
+	finished := now()
+	for _, c := range commits {
+		c.info.Finished = finished
+		c.info.Transaction = transaction
+		c.info.SizeBytes = 0
+		for _, data := range c.files {
+			c.info.SizeBytes += uint64(len(data))
+		}
+		f.repos[c.info.Commit.Repo.Name].info.SizeBytes = c.info.SizeBytes
+	}
+	return transaction, nil
+}
+
+func (f *fakePfsAPIClient) InspectCommit(ctx context.Context, request *pfs.InspectCommitRequest, opts ...grpc.CallOption) (*pfs.CommitInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, err := f.getCommit(request.Commit)
+	if err != nil {
+		return nil, err
+	}
+	return c.info, nil
+}
+
+func (f *fakePfsAPIClient) ListCommit(ctx context.Context, request *pfs.ListCommitRequest, opts ...grpc.CallOption) (*pfs.CommitInfos, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r, err := f.getRepo(request.Repo)
+	if err != nil {
+		return nil, err
+	}
+	var commitInfos []*pfs.CommitInfo
+	if request.To != nil {
+		// walk back from To until we reach From (or the first commit)
+		c, err := f.getCommit(request.To)
+		if err != nil {
+			return nil, err
+		}
+		var fromID string
+		if request.From != nil {
+			from, err := f.getCommit(request.From)
+			if err != nil {
+				return nil, err
+			}
+			fromID = from.info.Commit.ID
+		}
+		for c != nil {
+			commitInfos = append(commitInfos, c.info)
+			if c.info.Commit.ID == fromID || c.info.ParentCommit == nil {
+				break
+			}
+			c = r.commits[c.info.ParentCommit.ID]
+		}
+	} else {
+		var commits []*fakeCommit
+		for _, c := range r.commits {
+			commits = append(commits, c)
+		}
+		// newest commits first
+		sort.Slice(commits, func(i, j int) bool {
+			return commits[i].seq > commits[j].seq
+		})
+		for _, c := range commits {
+			commitInfos = append(commitInfos, c.info)
+		}
+	}
+	if request.Number != 0 && uint64(len(commitInfos)) > request.Number {
+		commitInfos = commitInfos[:request.Number]
+	}
+	return &pfs.CommitInfos{CommitInfo: commitInfos}, nil
+}
+
+func (f *fakePfsAPIClient) ListCommitStream(ctx context.Context, request *pfs.ListCommitRequest, opts ...grpc.CallOption) (pfs.API_ListCommitStreamClient, error) {
+	commitInfos, err := f.ListCommit(ctx, request, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &listCommitStreamClient{clientStream{ctx}, commitInfos.CommitInfo}, nil
+}
+
+func (f *fakePfsAPIClient) DeleteCommit(ctx context.Context, request *pfs.DeleteCommitRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	return nil, ErrUnimplemented
+}
+
+func (f *fakePfsAPIClient) FlushCommit(ctx context.Context, request *pfs.FlushCommitRequest, opts ...grpc.CallOption) (pfs.API_FlushCommitClient, error) {
+	return nil, ErrUnimplemented
+}
+
+func (f *fakePfsAPIClient) FlushCommits(ctx context.Context, request *pfs.FlushCommitsRequest, opts ...grpc.CallOption) (pfs.API_FlushCommitsClient, error) {
+	return nil, ErrUnimplemented
+}
+
+func (f *fakePfsAPIClient) SubscribeCommit(ctx context.Context, request *pfs.SubscribeCommitRequest, opts ...grpc.CallOption) (pfs.API_SubscribeCommitClient, error) {
+	return nil, ErrUnimplemented
+}
+
+func (f *fakePfsAPIClient) BuildCommit(ctx context.Context, request *pfs.BuildCommitRequest, opts ...grpc.CallOption) (*pfs.Commit, error) {
+	return nil, ErrUnimplemented
+}
+
+func (f *fakePfsAPIClient) ListBranch(ctx context.Context, request *pfs.ListBranchRequest, opts ...grpc.CallOption) (*pfs.Branches, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r, err := f.getRepo(request.Repo)
+	if err != nil {
+		return nil, err
+	}
+	result := &pfs.Branches{}
+	for name, head := range r.branches {
+		result.Branches = append(result.Branches, &pfs.Branch{
+			Name: name,
+			Head: r.commits[head].info.Commit,
+		})
+	}
+	sort.Slice(result.Branches, func(i, j int) bool {
+		return result.Branches[i].Name < result.Branches[j].Name
+	})
+	return result, nil
+}
+
+func (f *fakePfsAPIClient) SetBranch(ctx context.Context, request *pfs.SetBranchRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, err := f.getCommit(request.Commit)
+	if err != nil {
+		return nil, err
+	}
+	f.repos[request.Commit.Repo.Name].branches[request.Branch] = c.info.Commit.ID
+	return &types.Empty{}, nil
+}
+
+func (f *fakePfsAPIClient) SetBranchTrigger(ctx context.Context, request *pfs.SetBranchTriggerRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	return nil, ErrUnimplemented
+}
+
+func (f *fakePfsAPIClient) SetBranchRetention(ctx context.Context, request *pfs.SetBranchRetentionRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	return nil, ErrUnimplemented
+}
+
+func (f *fakePfsAPIClient) DeleteBranch(ctx context.Context, request *pfs.DeleteBranchRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r, err := f.getRepo(request.Repo)
+	if err != nil {
+		return nil, err
+	}
+	delete(r.branches, request.Branch)
+	return &types.Empty{}, nil
+}
+
+func (f *fakePfsAPIClient) PutFile(ctx context.Context, opts ...grpc.CallOption) (pfs.API_PutFileClient, error) {
+	return &putFileClient{
+		clientStream: clientStream{ctx},
+		close:        f.putFile,
+	}, nil
+}
+
+func (f *fakePfsAPIClient) PutFileBatch(ctx context.Context, opts ...grpc.CallOption) (pfs.API_PutFileBatchClient, error) {
+	return &putFileClient{
+		clientStream: clientStream{ctx},
+		close:        f.putFileBatch,
+	}, nil
+}
+
+// putFileBatch splits requests into files, each of which begins with a
+// request that has File set, and writes them in order.
+func (f *fakePfsAPIClient) putFileBatch(requests []*pfs.PutFileRequest) error {
+	for len(requests) > 0 {
+		i := 1
+		for i < len(requests) && requests[i].File == nil {
+			i++
+		}
+		if err := f.putFile(requests[:i]); err != nil {
+			return err
+		}
+		requests = requests[i:]
+	}
+	return nil
+}
+
+func (f *fakePfsAPIClient) putFile(requests []*pfs.PutFileRequest) error {
+	if len(requests) == 0 || requests[0].File == nil {
+		return fmt.Errorf("no file specified in PutFile request")
+	}
+	file := requests[0].File
+	if requests[0].Url != "" || requests[0].Delimiter != pfs.Delimiter_NONE {
+		return ErrUnimplemented
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, err := f.getCommit(file.Commit)
+	if err != nil {
+		return err
+	}
+	if c.info.Finished != nil {
+		return client.Errorf(client.ErrCommitFinished, "commit %v in repo %v has already finished", file.Commit.ID, file.Commit.Repo.Name)
+	}
+	p := clean(file.Path)
+	if p == "/" || f.isDir(c, p) {
+		return fmt.Errorf("cannot write to directory %v", file.Path)
+	}
+	// data may be shared with the commit's parent so we copy it rather
+	// than appending to it in place
+	data := append([]byte{}, c.files[p]...)
+	for _, request := range requests {
+		data = append(data, request.Value...)
+	}
+	c.files[p] = data
+	return nil
+}
+
+// isDir returns true if p is a directory in c, root is always a directory.
+func (f *fakePfsAPIClient) isDir(c *fakeCommit, p string) bool {
+	if p == "/" {
+		return true
+	}
+	for filePath := range c.files {
+		if strings.HasPrefix(filePath, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// fileInfo returns the FileInfo of the file or directory at p in c. The
+// returned FileInfo names the file with name, which is what the caller used
+// to refer to it.
+func (f *fakePfsAPIClient) fileInfo(commit *pfs.Commit, c *fakeCommit, p string, name string) (*pfs.FileInfo, error) {
+	fileInfo := &pfs.FileInfo{
+		File: &pfs.File{
+			Commit: commit,
+			Path:   name,
+		},
+	}
+	if data, ok := c.files[p]; ok {
+		fileInfo.FileType = pfs.FileType_FILE
+		fileInfo.SizeBytes = uint64(len(data))
+		return fileInfo, nil
+	}
+	if !f.isDir(c, p) {
+		return nil, client.Errorf(client.ErrFileNotFound, "file %v not found in repo %v at commit %v", name, commit.Repo.Name, commit.ID)
+	}
+	fileInfo.FileType = pfs.FileType_DIR
+	children := make(map[string]bool)
+	prefix := strings.TrimSuffix(p, "/") + "/"
+	for filePath, data := range c.files {
+		if strings.HasPrefix(filePath, prefix) {
+			children[strings.SplitN(strings.TrimPrefix(filePath, prefix), "/", 2)[0]] = true
+			fileInfo.SizeBytes += uint64(len(data))
+		}
+	}
+	for child := range children {
+		fileInfo.Children = append(fileInfo.Children, child)
+	}
+	sort.Strings(fileInfo.Children)
+	return fileInfo, nil
+}
+
+func (f *fakePfsAPIClient) GetFile(ctx context.Context, request *pfs.GetFileRequest, opts ...grpc.CallOption) (pfs.API_GetFileClient, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, err := f.getCommit(request.File.Commit)
+	if err != nil {
+		return nil, err
+	}
+	data, ok := c.files[clean(request.File.Path)]
+	if !ok {
+		if f.isDir(c, clean(request.File.Path)) {
+			return nil, fmt.Errorf("%v is a directory", request.File.Path)
+		}
+		return nil, client.Errorf(client.ErrFileNotFound, "file %v not found in repo %v at commit %v", request.File.Path, request.File.Commit.Repo.Name, request.File.Commit.ID)
+	}
+	if request.OffsetBytes > int64(len(data)) {
+		request.OffsetBytes = int64(len(data))
+	}
+	data = data[request.OffsetBytes:]
+	if request.SizeBytes != 0 && request.SizeBytes < int64(len(data)) {
+		data = data[:request.SizeBytes]
+	}
+	return &getFileClient{
+		clientStream: clientStream{ctx},
+		data:         data,
+	}, nil
+}
+
+func (f *fakePfsAPIClient) GetFiles(ctx context.Context, request *pfs.GetFilesRequest, opts ...grpc.CallOption) (pfs.API_GetFilesClient, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sizeLimit := request.SizeLimitBytes
+	if sizeLimit == 0 {
+		sizeLimit = 1024 * 1024
+	}
+	var fileContents []*pfs.FileContents
+	for _, file := range request.Files {
+		c, err := f.getCommit(file.Commit)
+		if err != nil {
+			return nil, err
+		}
+		fileInfo, err := f.fileInfo(file.Commit, c, clean(file.Path), file.Path)
+		if err != nil {
+			return nil, err
+		}
+		fileInfo.Children = nil
+		contents := &pfs.FileContents{FileInfo: fileInfo}
+		if fileInfo.FileType == pfs.FileType_FILE && fileInfo.SizeBytes <= sizeLimit {
+			contents.Value = c.files[clean(file.Path)]
+		}
+		fileContents = append(fileContents, contents)
+	}
+	return &getFilesClient{clientStream{ctx}, fileContents}, nil
+}
+
+func (f *fakePfsAPIClient) GetFileRanges(ctx context.Context, request *pfs.GetFileRangesRequest, opts ...grpc.CallOption) (pfs.API_GetFileRangesClient, error) {
+	var chunks []*pfs.FileRangeChunk
+	for i, r := range request.Ranges {
+		stream, err := f.GetFile(ctx, r, opts...)
+		if err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, &pfs.FileRangeChunk{
+			Index: uint32(i),
+			Value: stream.(*getFileClient).data,
+			Done:  true,
+		})
+	}
+	return &getFileRangesClient{clientStream{ctx}, chunks}, nil
+}
+
+func (f *fakePfsAPIClient) GetFileURL(ctx context.Context, request *pfs.GetFileURLRequest, opts ...grpc.CallOption) (*pfs.GetFileURLResponse, error) {
+	return nil, ErrUnimplemented
+}
+
+func (f *fakePfsAPIClient) InspectFile(ctx context.Context, request *pfs.InspectFileRequest, opts ...grpc.CallOption) (*pfs.FileInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, err := f.getCommit(request.File.Commit)
+	if err != nil {
+		return nil, err
+	}
+	return f.fileInfo(request.File.Commit, c, clean(request.File.Path), request.File.Path)
+}
+
+func (f *fakePfsAPIClient) ListFile(ctx context.Context, request *pfs.ListFileRequest, opts ...grpc.CallOption) (*pfs.FileInfos, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, err := f.getCommit(request.File.Commit)
+	if err != nil {
+		return nil, err
+	}
+	fileInfo, err := f.fileInfo(request.File.Commit, c, clean(request.File.Path), request.File.Path)
+	if err != nil {
+		return nil, err
+	}
+	if fileInfo.FileType == pfs.FileType_FILE {
+		return &pfs.FileInfos{FileInfo: []*pfs.FileInfo{fileInfo}}, nil
+	}
+	result := &pfs.FileInfos{}
+	for _, child := range fileInfo.Children {
+		if request.From != "" && child <= path.Base(request.From) {
+			continue
+		}
+		if request.Number > 0 && uint64(len(result.FileInfo)) >= request.Number {
+			break
+		}
+		childInfo, err := f.fileInfo(request.File.Commit, c, path.Join(clean(request.File.Path), child), path.Join(request.File.Path, child))
+		if err != nil {
+			return nil, err
+		}
+		childInfo.Children = nil
+		result.FileInfo = append(result.FileInfo, childInfo)
+	}
+	return result, nil
+}
+
+func (f *fakePfsAPIClient) ListFileStream(ctx context.Context, request *pfs.ListFileRequest, opts ...grpc.CallOption) (pfs.API_ListFileStreamClient, error) {
+	fileInfos, err := f.ListFile(ctx, request, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &listFileStreamClient{clientStream{ctx}, fileInfos.FileInfo}, nil
+}
+
+func (f *fakePfsAPIClient) GlobFile(ctx context.Context, request *pfs.GlobFileRequest, opts ...grpc.CallOption) (*pfs.FileInfos, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, err := f.getCommit(request.Commit)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := glob(c, request.Pattern)
+	if err != nil {
+		return nil, err
+	}
+	result := &pfs.FileInfos{}
+	for _, p := range matches {
+		fileInfo, err := f.fileInfo(request.Commit, c, p, p)
+		if err != nil {
+			return nil, err
+		}
+		fileInfo.Children = nil
+		result.FileInfo = append(result.FileInfo, fileInfo)
+	}
+	return result, nil
+}
+
+// glob returns the sorted paths of the files and directories in c which
+// match pattern.
+func glob(c *fakeCommit, pattern string) ([]string, error) {
+	// collect every file and directory in the commit, the root only matches
+	// itself
+	paths := map[string]bool{}
+	for filePath := range c.files {
+		for p := filePath; p != "/"; p = path.Dir(p) {
+			paths[p] = true
+		}
+	}
+	pattern = clean(pattern)
+	var matches []string
+	if pattern == "/" {
+		matches = append(matches, "/")
+	}
+	for p := range paths {
+		match, err := path.Match(pattern, p)
+		if err != nil {
+			return nil, err
+		}
+		if match {
+			matches = append(matches, p)
+		}
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+func (f *fakePfsAPIClient) GlobFileStream(ctx context.Context, request *pfs.GlobFileRequest, opts ...grpc.CallOption) (pfs.API_GlobFileStreamClient, error) {
+	fileInfos, err := f.GlobFile(ctx, request, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &listFileStreamClient{clientStream{ctx}, fileInfos.FileInfo}, nil
+}
+
+func (f *fakePfsAPIClient) DeleteFile(ctx context.Context, request *pfs.DeleteFileRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, err := f.getCommit(request.File.Commit)
+	if err != nil {
+		return nil, err
+	}
+	if c.info.Finished != nil {
+		return nil, client.Errorf(client.ErrCommitFinished, "commit %v in repo %v has already finished", request.File.Commit.ID, request.File.Commit.Repo.Name)
+	}
+	deleteFile(c, request.File.Path)
+	return &types.Empty{}, nil
+}
+
+func (f *fakePfsAPIClient) DeleteFiles(ctx context.Context, request *pfs.DeleteFilesRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, err := f.getCommit(request.Commit)
+	if err != nil {
+		return nil, err
+	}
+	if c.info.Finished != nil {
+		return nil, client.Errorf(client.ErrCommitFinished, "commit %v in repo %v has already finished", request.Commit.ID, request.Commit.Repo.Name)
+	}
+	paths := append([]string{}, request.Paths...)
+	if request.Pattern != "" {
+		matches, err := glob(c, request.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, matches...)
+	}
+	for _, p := range paths {
+		deleteFile(c, p)
+	}
+	return &types.Empty{}, nil
+}
+
+// deleteFile deletes the file or directory at p from c.
+func deleteFile(c *fakeCommit, p string) {
+	p = clean(p)
+	for filePath := range c.files {
+		if p == "/" || filePath == p || strings.HasPrefix(filePath, p+"/") {
+			delete(c.files, filePath)
+		}
+	}
+}
+
+func (f *fakePfsAPIClient) DeleteAll(ctx context.Context, request *types.Empty, opts ...grpc.CallOption) (*types.Empty, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.repos = make(map[string]*fakeRepo)
+	return &types.Empty{}, nil
+}
+
+// fakeObjectAPIClient is a pfs.ObjectAPIClient that doesn't implement any of
+// the object RPCs, the fake stores file contents directly.
+type fakeObjectAPIClient struct{}
+
+func (fakeObjectAPIClient) PutObject(ctx context.Context, opts ...grpc.CallOption) (pfs.ObjectAPI_PutObjectClient, error) {
+	return nil, ErrUnimplemented
+}
+
+func (fakeObjectAPIClient) GetObject(ctx context.Context, request *pfs.Object, opts ...grpc.CallOption) (pfs.ObjectAPI_GetObjectClient, error) {
+	return nil, ErrUnimplemented
+}
+
+func (fakeObjectAPIClient) GetObjects(ctx context.Context, request *pfs.GetObjectsRequest, opts ...grpc.CallOption) (pfs.ObjectAPI_GetObjectsClient, error) {
+	return nil, ErrUnimplemented
+}
+
+func (fakeObjectAPIClient) TagObject(ctx context.Context, request *pfs.TagObjectRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	return nil, ErrUnimplemented
+}
+
+func (fakeObjectAPIClient) InspectObject(ctx context.Context, request *pfs.Object, opts ...grpc.CallOption) (*pfs.ObjectInfo, error) {
+	return nil, ErrUnimplemented
+}
+
+func (fakeObjectAPIClient) CheckObject(ctx context.Context, request *pfs.CheckObjectRequest, opts ...grpc.CallOption) (*pfs.CheckObjectResponse, error) {
+	return nil, ErrUnimplemented
+}
+
+func (fakeObjectAPIClient) GetTag(ctx context.Context, request *pfs.Tag, opts ...grpc.CallOption) (pfs.ObjectAPI_GetTagClient, error) {
+	return nil, ErrUnimplemented
+}
+
+func (fakeObjectAPIClient) InspectTag(ctx context.Context, request *pfs.Tag, opts ...grpc.CallOption) (*pfs.ObjectInfo, error) {
+	return nil, ErrUnimplemented
+}
+
+func (fakeObjectAPIClient) Compact(ctx context.Context, request *types.Empty, opts ...grpc.CallOption) (*types.Empty, error) {
+	return nil, ErrUnimplemented
+}
@@ -0,0 +1,244 @@
+package testing
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/uuid"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// fakePpsAPIClient is an in-memory implementation of pps.APIClient. It
+// stores pipelines but never runs any jobs.
+type fakePpsAPIClient struct {
+	mu        sync.Mutex
+	pfs       *fakePfsAPIClient
+	pipelines map[string]*pps.PipelineInfo
+}
+
+func newFakePpsAPIClient(pfsClient *fakePfsAPIClient) *fakePpsAPIClient {
+	return &fakePpsAPIClient{
+		pfs:       pfsClient,
+		pipelines: make(map[string]*pps.PipelineInfo),
+	}
+}
+
+// inputRepos returns the repos that input reads from.
+func inputRepos(input *pps.Input) []*pfs.Repo {
+	if input == nil {
+		return nil
+	}
+	var result []*pfs.Repo
+	if input.Atom != nil {
+		result = append(result, &pfs.Repo{Name: input.Atom.Repo})
+	}
+	if input.SQL != nil {
+		result = append(result, &pfs.Repo{Name: input.SQL.Repo})
+	}
+	for _, input := range append(input.Cross, input.Union...) {
+		result = append(result, inputRepos(input)...)
+	}
+	return result
+}
+
+func (f *fakePpsAPIClient) getPipeline(pipeline *pps.Pipeline) (*pps.PipelineInfo, error) {
+	pipelineInfo, ok := f.pipelines[pipeline.Name]
+	if !ok {
+		return nil, client.Errorf(client.ErrPipelineNotFound, "pipeline %v not found", pipeline.Name)
+	}
+	return pipelineInfo, nil
+}
+
+func (f *fakePpsAPIClient) CreateJob(ctx context.Context, request *pps.CreateJobRequest, opts ...grpc.CallOption) (*pps.Job, error) {
+	return nil, ErrUnimplemented
+}
+
+func (f *fakePpsAPIClient) InspectJob(ctx context.Context, request *pps.InspectJobRequest, opts ...grpc.CallOption) (*pps.JobInfo, error) {
+	return nil, client.Errorf(client.ErrJobNotFound, "job %v not found", request.Job.ID)
+}
+
+func (f *fakePpsAPIClient) ListJob(ctx context.Context, request *pps.ListJobRequest, opts ...grpc.CallOption) (*pps.JobInfos, error) {
+	return &pps.JobInfos{}, nil
+}
+
+func (f *fakePpsAPIClient) FlushJob(ctx context.Context, request *pps.FlushJobRequest, opts ...grpc.CallOption) (pps.API_FlushJobClient, error) {
+	return nil, ErrUnimplemented
+}
+
+func (f *fakePpsAPIClient) DeleteJob(ctx context.Context, request *pps.DeleteJobRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	return nil, client.Errorf(client.ErrJobNotFound, "job %v not found", request.Job.ID)
+}
+
+func (f *fakePpsAPIClient) StopJob(ctx context.Context, request *pps.StopJobRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	return nil, client.Errorf(client.ErrJobNotFound, "job %v not found", request.Job.ID)
+}
+
+func (f *fakePpsAPIClient) RestartDatum(ctx context.Context, request *pps.RestartDatumRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	return nil, client.Errorf(client.ErrJobNotFound, "job %v not found", request.Job.ID)
+}
+
+func (f *fakePpsAPIClient) ListDatum(ctx context.Context, request *pps.ListDatumRequest, opts ...grpc.CallOption) (*pps.DatumInfos, error) {
+	return nil, client.Errorf(client.ErrJobNotFound, "job %v not found", request.Job.ID)
+}
+
+func (f *fakePpsAPIClient) InspectDatum(ctx context.Context, request *pps.InspectDatumRequest, opts ...grpc.CallOption) (*pps.DatumInfo, error) {
+	return nil, client.Errorf(client.ErrJobNotFound, "job %v not found", request.Job.ID)
+}
+
+func (f *fakePpsAPIClient) CreatePipeline(ctx context.Context, request *pps.CreatePipelineRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if request.Pipeline == nil || request.Pipeline.Name == "" {
+		return nil, fmt.Errorf("invalid pipeline spec: request.Pipeline cannot be nil")
+	}
+	if request.Transform == nil {
+		return nil, fmt.Errorf("invalid pipeline spec: request.Transform cannot be nil")
+	}
+	pipelineInfo := &pps.PipelineInfo{
+		ID:                 uuid.NewWithoutDashes(),
+		Pipeline:           request.Pipeline,
+		Version:            1,
+		Transform:          request.Transform,
+		ParallelismSpec:    request.ParallelismSpec,
+		Inputs:             request.Inputs,
+		Input:              request.Input,
+		Egress:             request.Egress,
+		CreatedAt:          now(),
+		State:              pps.PipelineState_PIPELINE_RUNNING,
+		OutputBranch:       request.OutputBranch,
+		ScaleDownThreshold: request.ScaleDownThreshold,
+		ResourceSpec:       request.ResourceSpec,
+		ResourceLimits:     request.ResourceLimits,
+		Description:        request.Description,
+	}
+	if pipelineInfo.OutputBranch == "" {
+		pipelineInfo.OutputBranch = "master"
+	}
+	if oldPipelineInfo, ok := f.pipelines[request.Pipeline.Name]; ok {
+		if !request.Update {
+			return nil, client.Errorf(client.ErrPipelineExists, "pipeline %v already exists", request.Pipeline.Name)
+		}
+		pipelineInfo.ID = oldPipelineInfo.ID
+		pipelineInfo.Version = oldPipelineInfo.Version + 1
+		pipelineInfo.CreatedAt = oldPipelineInfo.CreatedAt
+		f.pipelines[request.Pipeline.Name] = pipelineInfo
+		return &types.Empty{}, nil
+	}
+	provenance := inputRepos(request.Input)
+	for _, input := range request.Inputs {
+		provenance = append(provenance, input.Repo)
+	}
+	f.pfs.mu.Lock()
+	defer f.pfs.mu.Unlock()
+	if err := f.pfs.createRepo(&pfs.Repo{Name: request.Pipeline.Name}, provenance, "", nil); err != nil {
+		return nil, err
+	}
+	f.pipelines[request.Pipeline.Name] = pipelineInfo
+	return &types.Empty{}, nil
+}
+
+func (f *fakePpsAPIClient) InspectPipeline(ctx context.Context, request *pps.InspectPipelineRequest, opts ...grpc.CallOption) (*pps.PipelineInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.getPipeline(request.Pipeline)
+}
+
+func (f *fakePpsAPIClient) ListPipeline(ctx context.Context, request *pps.ListPipelineRequest, opts ...grpc.CallOption) (*pps.PipelineInfos, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	result := &pps.PipelineInfos{}
+	for _, pipelineInfo := range f.pipelines {
+		result.PipelineInfo = append(result.PipelineInfo, pipelineInfo)
+	}
+	sort.Slice(result.PipelineInfo, func(i, j int) bool {
+		return result.PipelineInfo[i].Pipeline.Name < result.PipelineInfo[j].Pipeline.Name
+	})
+	return result, nil
+}
+
+func (f *fakePpsAPIClient) DeletePipeline(ctx context.Context, request *pps.DeletePipelineRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, err := f.getPipeline(request.Pipeline); err != nil {
+		return nil, err
+	}
+	delete(f.pipelines, request.Pipeline.Name)
+	f.pfs.mu.Lock()
+	defer f.pfs.mu.Unlock()
+	delete(f.pfs.repos, request.Pipeline.Name)
+	return &types.Empty{}, nil
+}
+
+func (f *fakePpsAPIClient) setStopped(pipeline *pps.Pipeline, stopped bool) (*types.Empty, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	pipelineInfo, err := f.getPipeline(pipeline)
+	if err != nil {
+		return nil, err
+	}
+	pipelineInfo.Stopped = stopped
+	if stopped {
+		pipelineInfo.State = pps.PipelineState_PIPELINE_STOPPED
+	} else {
+		pipelineInfo.State = pps.PipelineState_PIPELINE_RUNNING
+	}
+	return &types.Empty{}, nil
+}
+
+func (f *fakePpsAPIClient) StartPipeline(ctx context.Context, request *pps.StartPipelineRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	return f.setStopped(request.Pipeline, false)
+}
+
+func (f *fakePpsAPIClient) StopPipeline(ctx context.Context, request *pps.StopPipelineRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	return f.setStopped(request.Pipeline, true)
+}
+
+func (f *fakePpsAPIClient) RerunPipeline(ctx context.Context, request *pps.RerunPipelineRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	return nil, ErrUnimplemented
+}
+
+func (f *fakePpsAPIClient) ClearDatumCache(ctx context.Context, request *pps.ClearDatumCacheRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	return nil, ErrUnimplemented
+}
+
+func (f *fakePpsAPIClient) RunPipeline(ctx context.Context, request *pps.RunPipelineRequest, opts ...grpc.CallOption) (*pps.Job, error) {
+	return nil, ErrUnimplemented
+}
+
+func (f *fakePpsAPIClient) TriggerPipeline(ctx context.Context, request *pps.TriggerPipelineRequest, opts ...grpc.CallOption) (*pps.Job, error) {
+	return nil, ErrUnimplemented
+}
+
+func (f *fakePpsAPIClient) InspectTrigger(ctx context.Context, request *pps.InspectTriggerRequest, opts ...grpc.CallOption) (*pps.JobInfo, error) {
+	return nil, ErrUnimplemented
+}
+
+func (f *fakePpsAPIClient) RunTransaction(ctx context.Context, request *pps.RunTransactionRequest, opts ...grpc.CallOption) (*pps.TransactionInfo, error) {
+	return nil, ErrUnimplemented
+}
+
+func (f *fakePpsAPIClient) DryRunPipeline(ctx context.Context, request *pps.CreatePipelineRequest, opts ...grpc.CallOption) (*pps.DryRunInfo, error) {
+	return nil, ErrUnimplemented
+}
+
+func (f *fakePpsAPIClient) DryRunJob(ctx context.Context, request *pps.CreateJobRequest, opts ...grpc.CallOption) (*pps.DryRunInfo, error) {
+	return nil, ErrUnimplemented
+}
+
+func (f *fakePpsAPIClient) DeleteAll(ctx context.Context, request *types.Empty, opts ...grpc.CallOption) (*types.Empty, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pipelines = make(map[string]*pps.PipelineInfo)
+	return &types.Empty{}, nil
+}
+
+func (f *fakePpsAPIClient) GetLogs(ctx context.Context, request *pps.GetLogsRequest, opts ...grpc.CallOption) (pps.API_GetLogsClient, error) {
+	return nil, ErrUnimplemented
+}
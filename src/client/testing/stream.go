@@ -0,0 +1,142 @@
+package testing
+
+import (
+	"io"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/grpcutil"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/metadata"
+)
+
+// clientStream implements the parts of grpc.ClientStream that are common to
+// all of the fake's streams.
+type clientStream struct {
+	ctx context.Context
+}
+
+func (s *clientStream) Header() (metadata.MD, error) {
+	return nil, nil
+}
+
+func (s *clientStream) Trailer() metadata.MD {
+	return nil
+}
+
+func (s *clientStream) CloseSend() error {
+	return nil
+}
+
+func (s *clientStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *clientStream) SendMsg(m interface{}) error {
+	return ErrUnimplemented
+}
+
+func (s *clientStream) RecvMsg(m interface{}) error {
+	return ErrUnimplemented
+}
+
+// putFileClient buffers the requests sent to it and applies them when the
+// stream is closed.
+type putFileClient struct {
+	clientStream
+	requests []*pfs.PutFileRequest
+	close    func([]*pfs.PutFileRequest) error
+}
+
+func (c *putFileClient) Send(request *pfs.PutFileRequest) error {
+	// the client reuses requests, so we need to copy them
+	r := *request
+	r.Value = append([]byte{}, request.Value...)
+	c.requests = append(c.requests, &r)
+	return nil
+}
+
+func (c *putFileClient) CloseAndRecv() (*types.Empty, error) {
+	if err := c.close(c.requests); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+// getFileClient streams data back in chunks, like pachd does.
+type getFileClient struct {
+	clientStream
+	data []byte
+}
+
+func (c *getFileClient) Recv() (*types.BytesValue, error) {
+	if len(c.data) == 0 {
+		return nil, io.EOF
+	}
+	n := grpcutil.MaxMsgSize / 2
+	if n > len(c.data) {
+		n = len(c.data)
+	}
+	value := &types.BytesValue{Value: c.data[:n]}
+	c.data = c.data[n:]
+	return value, nil
+}
+
+// listFileStreamClient streams back a precomputed list of FileInfos.
+type listFileStreamClient struct {
+	clientStream
+	fileInfos []*pfs.FileInfo
+}
+
+func (c *listFileStreamClient) Recv() (*pfs.FileInfo, error) {
+	if len(c.fileInfos) == 0 {
+		return nil, io.EOF
+	}
+	fileInfo := c.fileInfos[0]
+	c.fileInfos = c.fileInfos[1:]
+	return fileInfo, nil
+}
+
+// listCommitStreamClient streams back a precomputed list of CommitInfos.
+type listCommitStreamClient struct {
+	clientStream
+	commitInfos []*pfs.CommitInfo
+}
+
+func (c *listCommitStreamClient) Recv() (*pfs.CommitInfo, error) {
+	if len(c.commitInfos) == 0 {
+		return nil, io.EOF
+	}
+	commitInfo := c.commitInfos[0]
+	c.commitInfos = c.commitInfos[1:]
+	return commitInfo, nil
+}
+
+type getFilesClient struct {
+	clientStream
+	fileContents []*pfs.FileContents
+}
+
+func (c *getFilesClient) Recv() (*pfs.FileContents, error) {
+	if len(c.fileContents) == 0 {
+		return nil, io.EOF
+	}
+	fileContents := c.fileContents[0]
+	c.fileContents = c.fileContents[1:]
+	return fileContents, nil
+}
+
+type getFileRangesClient struct {
+	clientStream
+	chunks []*pfs.FileRangeChunk
+}
+
+func (c *getFileRangesClient) Recv() (*pfs.FileRangeChunk, error) {
+	if len(c.chunks) == 0 {
+		return nil, io.EOF
+	}
+	chunk := c.chunks[0]
+	c.chunks = c.chunks[1:]
+	return chunk, nil
+}
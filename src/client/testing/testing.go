@@ -0,0 +1,32 @@
+// Package testing provides an in-memory fake of pachd for unit testing code
+// that uses the Pachyderm client, without needing a running cluster.
+//
+// The fake implements the repo, commit, branch and file RPCs of PFS, the
+// pipeline RPCs of PPS and InspectCluster. It doesn't run jobs, so pipelines
+// never produce output; tests that need to simulate a pipeline's output can
+// write to the pipeline's output repo directly. RPCs that the fake doesn't
+// implement return ErrUnimplemented.
+package testing
+
+import (
+	"errors"
+
+	"github.com/pachyderm/pachyderm/src/client"
+)
+
+// ErrUnimplemented is returned by RPCs that the fake doesn't implement.
+var ErrUnimplemented = errors.New("not implemented by the fake pachd")
+
+// NewAPIClient returns a client.APIClient that's backed by a new, empty,
+// in-memory fake of pachd. Each call returns a client with its own,
+// independent state.
+func NewAPIClient() *client.APIClient {
+	pfsClient := newFakePfsAPIClient()
+	return &client.APIClient{
+		PfsAPIClient:    pfsClient,
+		PpsAPIClient:    newFakePpsAPIClient(pfsClient),
+		ObjectAPIClient: fakeObjectAPIClient{},
+		AdminAPIClient:  fakeAdminAPIClient{},
+		AuthAPIClient:   fakeAuthAPIClient{},
+	}
+}
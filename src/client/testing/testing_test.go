@@ -0,0 +1,204 @@
+package testing
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+
+	"golang.org/x/net/context"
+)
+
+func TestRepos(t *testing.T) {
+	c := NewAPIClient()
+	require.NoError(t, c.CreateRepo("a"))
+	require.NoError(t, c.CreateRepo("b"))
+	require.True(t, errors.Is(c.CreateRepo("a"), client.ErrRepoExists))
+	repoInfos, err := c.ListRepo(nil)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(repoInfos))
+	require.NoError(t, c.DeleteRepo("a", false))
+	_, err = c.InspectRepo("a")
+	require.True(t, errors.Is(err, client.ErrRepoNotFound))
+}
+
+func TestRepoLabels(t *testing.T) {
+	c := NewAPIClient()
+	_, err := c.PfsAPIClient.CreateRepo(context.Background(), &pfs.CreateRepoRequest{
+		Repo:   client.NewRepo("a"),
+		Labels: map[string]string{"team": "data"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, c.CreateRepo("b"))
+	repoInfos, err := c.ListRepoByLabel("team=data")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(repoInfos))
+	require.Equal(t, "a", repoInfos[0].Repo.Name)
+	require.NoError(t, c.SetRepoLabels("b", map[string]string{"team": "ml"}))
+	repoInfos, err = c.ListRepoByLabel("team!=data")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(repoInfos))
+	require.Equal(t, "b", repoInfos[0].Repo.Name)
+	require.NoError(t, c.RemoveRepoLabels("a", "team"))
+	repoInfos, err = c.ListRepoByLabel("!team")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(repoInfos))
+	require.Equal(t, "a", repoInfos[0].Repo.Name)
+}
+
+func TestFinishCommits(t *testing.T) {
+	c := NewAPIClient()
+	require.NoError(t, c.CreateRepo("a"))
+	require.NoError(t, c.CreateRepo("b"))
+	_, err := c.StartCommit("a", "master")
+	require.NoError(t, err)
+	_, err = c.StartCommit("b", "master")
+	require.NoError(t, err)
+	transaction, err := c.FinishCommits([]*pfs.Commit{client.NewCommit("a", "master"), client.NewCommit("b", "master")})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(transaction.Branches))
+	commitInfoA, err := c.InspectCommit("a", "master")
+	require.NoError(t, err)
+	commitInfoB, err := c.InspectCommit("b", "master")
+	require.NoError(t, err)
+	require.Equal(t, transaction.ID, commitInfoA.Transaction.ID)
+	require.Equal(t, commitInfoA.Finished, commitInfoB.Finished)
+	_, err = c.FinishCommits([]*pfs.Commit{client.NewCommit("a", "master")})
+	require.YesError(t, err)
+}
+
+func TestCommitsAndFiles(t *testing.T) {
+	c := NewAPIClient()
+	require.NoError(t, c.CreateRepo("repo"))
+
+	commit1, err := c.StartCommit("repo", "master")
+	require.NoError(t, err)
+	_, err = c.PutFile("repo", "master", "dir/foo", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	_, err = c.PutFile("repo", "master", "bar", strings.NewReader("bar\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit("repo", "master"))
+	_, err = c.PutFile("repo", "master", "bar", strings.NewReader("bar\n"))
+	require.True(t, errors.Is(err, client.ErrCommitFinished))
+
+	commit2, err := c.StartCommit("repo", "master")
+	require.NoError(t, err)
+	_, err = c.PutFile("repo", "master", "dir/foo", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.DeleteFile("repo", "master", "bar"))
+	require.NoError(t, c.FinishCommit("repo", "master"))
+
+	var buffer bytes.Buffer
+	require.NoError(t, c.GetFile("repo", commit1.ID, "dir/foo", 0, 0, &buffer))
+	require.Equal(t, "foo\n", buffer.String())
+	buffer.Reset()
+	require.NoError(t, c.GetFile("repo", "master", "dir/foo", 0, 0, &buffer))
+	require.Equal(t, "foo\nfoo\n", buffer.String())
+	buffer.Reset()
+	require.NoError(t, c.GetFile("repo", "master", "dir/foo", 4, 3, &buffer))
+	require.Equal(t, "foo", buffer.String())
+	require.True(t, errors.Is(c.GetFile("repo", "master", "bar", 0, 0, &buffer), client.ErrFileNotFound))
+
+	fileInfos, err := c.ListFile("repo", commit1.ID, "")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(fileInfos))
+	fileInfo, err := c.InspectFile("repo", commit2.ID, "dir")
+	require.NoError(t, err)
+	require.Equal(t, pfs.FileType_DIR, fileInfo.FileType)
+	require.Equal(t, []string{"foo"}, fileInfo.Children)
+	fileInfos, err = c.GlobFile("repo", commit1.ID, "*")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(fileInfos))
+
+	commitInfos, err := c.ListCommitByRepo("repo")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(commitInfos))
+	require.Equal(t, commit2.ID, commitInfos[0].Commit.ID)
+	require.Equal(t, commit1.ID, commitInfos[0].ParentCommit.ID)
+	branches, err := c.ListBranch("repo")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(branches))
+	require.Equal(t, commit2.ID, branches[0].Head.ID)
+}
+
+func TestPutFileBatch(t *testing.T) {
+	c := NewAPIClient()
+	require.NoError(t, c.CreateRepo("repo"))
+	_, err := c.StartCommit("repo", "master")
+	require.NoError(t, err)
+
+	batch, err := c.NewPutFileBatch("repo", "master")
+	require.NoError(t, err)
+	_, err = batch.PutFile("foo", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	_, err = batch.PutFile("empty", strings.NewReader(""))
+	require.NoError(t, err)
+	_, err = batch.PutFile("bar", strings.NewReader("bar\n"))
+	require.NoError(t, err)
+	require.NoError(t, batch.Close())
+
+	var buffer bytes.Buffer
+	require.NoError(t, c.GetFile("repo", "master", "foo", 0, 0, &buffer))
+	require.Equal(t, "foo\n", buffer.String())
+	buffer.Reset()
+	require.NoError(t, c.GetFile("repo", "master", "bar", 0, 0, &buffer))
+	require.Equal(t, "bar\n", buffer.String())
+	fileInfo, err := c.InspectFile("repo", "master", "empty")
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), fileInfo.SizeBytes)
+}
+
+func TestDeleteFiles(t *testing.T) {
+	c := NewAPIClient()
+	require.NoError(t, c.CreateRepo("repo"))
+	_, err := c.StartCommit("repo", "master")
+	require.NoError(t, err)
+	for _, file := range []string{"a", "b", "dir/c", "logs/1.log", "logs/2.txt"} {
+		_, err = c.PutFile("repo", "master", file, strings.NewReader("foo\n"))
+		require.NoError(t, err)
+	}
+	require.NoError(t, c.DeleteFiles("repo", "master", []string{"a", "dir"}, "logs/*.log"))
+	require.NoError(t, c.FinishCommit("repo", "master"))
+
+	var paths []string
+	for _, pattern := range []string{"*", "*/*"} {
+		fileInfos, err := c.GlobFile("repo", "master", pattern)
+		require.NoError(t, err)
+		for _, fileInfo := range fileInfos {
+			paths = append(paths, fileInfo.File.Path)
+		}
+	}
+	require.Equal(t, []string{"/b", "/logs", "/logs/2.txt"}, paths)
+	require.True(t, errors.Is(c.DeleteFiles("repo", "master", []string{"b"}, ""), client.ErrCommitFinished))
+}
+
+func TestPipelines(t *testing.T) {
+	c := NewAPIClient()
+	require.NoError(t, c.CreateRepo("input"))
+	require.NoError(t, c.CreatePipeline("pipeline", "image", []string{"cmd"}, nil, nil, client.NewAtomInput("input", "/*"), "", false))
+	require.True(t, errors.Is(c.CreatePipeline("pipeline", "image", []string{"cmd"}, nil, nil, client.NewAtomInput("input", "/*"), "", false), client.ErrPipelineExists))
+	require.NoError(t, c.CreatePipeline("pipeline", "image2", []string{"cmd"}, nil, nil, client.NewAtomInput("input", "/*"), "", true))
+	pipelineInfo, err := c.InspectPipeline("pipeline")
+	require.NoError(t, err)
+	require.Equal(t, "image2", pipelineInfo.Transform.Image)
+	require.Equal(t, uint64(2), pipelineInfo.Version)
+	repoInfos, err := c.ListRepo([]string{"input"})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(repoInfos))
+	require.Equal(t, "pipeline", repoInfos[0].Repo.Name)
+
+	require.NoError(t, c.StopPipeline("pipeline"))
+	pipelineInfo, err = c.InspectPipeline("pipeline")
+	require.NoError(t, err)
+	require.True(t, pipelineInfo.Stopped)
+
+	require.NoError(t, c.DeletePipeline("pipeline", false))
+	_, err = c.InspectPipeline("pipeline")
+	require.True(t, errors.Is(err, client.ErrPipelineNotFound))
+	_, err = c.InspectRepo("pipeline")
+	require.True(t, errors.Is(err, client.ErrRepoNotFound))
+}
@@ -38,3 +38,10 @@ func PrettyPrintVersion(version *pb.Version) string {
 	}
 	return result
 }
+
+// IsCompatible returns true if a client of version clientVersion can be used
+// with a pachd of version pachdVersion. The API is only guaranteed to be
+// compatible between releases with the same major and minor versions.
+func IsCompatible(clientVersion *pb.Version, pachdVersion *pb.Version) bool {
+	return clientVersion.Major == pachdVersion.Major && clientVersion.Minor == pachdVersion.Minor
+}
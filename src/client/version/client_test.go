@@ -0,0 +1,16 @@
+package version
+
+import (
+	"testing"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	pb "github.com/pachyderm/pachyderm/src/client/version/versionpb"
+)
+
+func TestIsCompatible(t *testing.T) {
+	v := &pb.Version{Major: 1, Minor: 4, Micro: 7}
+	require.True(t, IsCompatible(v, &pb.Version{Major: 1, Minor: 4, Micro: 7}))
+	require.True(t, IsCompatible(v, &pb.Version{Major: 1, Minor: 4, Micro: 0, Additional: "rc1"}))
+	require.False(t, IsCompatible(v, &pb.Version{Major: 1, Minor: 5, Micro: 7}))
+	require.False(t, IsCompatible(v, &pb.Version{Major: 2, Minor: 4, Micro: 7}))
+}
@@ -0,0 +1,122 @@
+// Code generated by protoc-gen-gogo.
+// source: server/admin/admin.proto
+// DO NOT EDIT!
+
+/*
+Package admin is a generated protocol buffer package.
+
+It is generated from these files:
+	server/admin/admin.proto
+
+It has these top-level messages:
+	Op
+*/
+package admin
+
+import proto "github.com/gogo/protobuf/proto"
+import fmt "fmt"
+import math "math"
+import pfs "github.com/pachyderm/pachyderm/src/client/pfs"
+import pps "github.com/pachyderm/pachyderm/src/client/pps"
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.GoGoProtoPackageIsVersion2 // please upgrade the proto package
+
+// Op is a single operation needed to restore a cluster. Exactly one of its
+// fields is set.
+type Op struct {
+	// Object is the content of an object referenced by a commit, objects always
+	// appear in the stream before the commits which reference them.
+	Object *pfs.PutObjectRequest `protobuf:"bytes,1,opt,name=object" json:"object,omitempty"`
+	Repo   *pfs.RepoInfo         `protobuf:"bytes,2,opt,name=repo" json:"repo,omitempty"`
+	// Commit is a finished commit. Its ID, and the IDs of its parent and
+	// provenance, are the IDs in the extracted cluster; restore maps them to
+	// the IDs of the commits it creates.
+	Commit   *pfs.CommitInfo            `protobuf:"bytes,3,opt,name=commit" json:"commit,omitempty"`
+	Branch   *pfs.Branch                `protobuf:"bytes,4,opt,name=branch" json:"branch,omitempty"`
+	Pipeline *pps.CreatePipelineRequest `protobuf:"bytes,5,opt,name=pipeline" json:"pipeline,omitempty"`
+	// Since is only set in the first Op of an incremental backup, to the
+	// marker of the backup that it follows. It must be restored on top of
+	// that backup.
+	Since string `protobuf:"bytes,6,opt,name=since,proto3" json:"since,omitempty"`
+}
+
+func (m *Op) Reset()                    { *m = Op{} }
+func (m *Op) String() string            { return proto.CompactTextString(m) }
+func (*Op) ProtoMessage()               {}
+func (*Op) Descriptor() ([]byte, []int) { return fileDescriptorAdmin, []int{0} }
+
+func (m *Op) GetObject() *pfs.PutObjectRequest {
+	if m != nil {
+		return m.Object
+	}
+	return nil
+}
+
+func (m *Op) GetRepo() *pfs.RepoInfo {
+	if m != nil {
+		return m.Repo
+	}
+	return nil
+}
+
+func (m *Op) GetCommit() *pfs.CommitInfo {
+	if m != nil {
+		return m.Commit
+	}
+	return nil
+}
+
+func (m *Op) GetBranch() *pfs.Branch {
+	if m != nil {
+		return m.Branch
+	}
+	return nil
+}
+
+func (m *Op) GetPipeline() *pps.CreatePipelineRequest {
+	if m != nil {
+		return m.Pipeline
+	}
+	return nil
+}
+
+func (m *Op) GetSince() string {
+	if m != nil {
+		return m.Since
+	}
+	return ""
+}
+
+func init() {
+	proto.RegisterType((*Op)(nil), "admin.Op")
+}
+
+func init() { proto.RegisterFile("server/admin/admin.proto", fileDescriptorAdmin) }
+
+var fileDescriptorAdmin = []byte{
+	// 230 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x44, 0xcf, 0xc1, 0x4a, 0x03, 0x31,
+	0x10, 0x06, 0x60, 0xb6, 0x76, 0x83, 0x4e, 0x11, 0x21, 0x54, 0x08, 0x3d, 0x55, 0x3d, 0xd8, 0x8b,
+	0x5b, 0x50, 0xf0, 0x01, 0xec, 0xc9, 0x53, 0x4b, 0xde, 0x60, 0x37, 0x4e, 0x31, 0xd2, 0x4d, 0xc6,
+	0x24, 0xeb, 0x83, 0xfb, 0x04, 0x92, 0x49, 0xd4, 0xc3, 0x2c, 0xcc, 0xff, 0x7f, 0x0b, 0x19, 0x50,
+	0x11, 0xc3, 0x17, 0x86, 0x6d, 0xff, 0x36, 0x5a, 0x57, 0xbe, 0x1d, 0x05, 0x9f, 0xbc, 0x6c, 0x79,
+	0x59, 0x2d, 0xcd, 0xc9, 0xa2, 0x4b, 0x5b, 0x3a, 0xc6, 0x3c, 0xa5, 0xfc, 0x4f, 0x29, 0xe6, 0x29,
+	0xe9, 0xed, 0x77, 0x03, 0xb3, 0x3d, 0xc9, 0x07, 0x10, 0x7e, 0xf8, 0x40, 0x93, 0x54, 0xb3, 0x6e,
+	0x36, 0x8b, 0xc7, 0xeb, 0x2e, 0xff, 0x78, 0x98, 0xd2, 0x9e, 0x53, 0x8d, 0x9f, 0x13, 0xc6, 0xa4,
+	0x2b, 0x92, 0x37, 0x30, 0x0f, 0x48, 0x5e, 0xcd, 0x18, 0x5f, 0x32, 0xd6, 0x48, 0xfe, 0xd5, 0x1d,
+	0xbd, 0xe6, 0x4a, 0xde, 0x83, 0x30, 0x7e, 0x1c, 0x6d, 0x52, 0x67, 0x8c, 0xae, 0x18, 0xed, 0x38,
+	0x62, 0x56, 0x6b, 0x79, 0x07, 0x62, 0x08, 0xbd, 0x33, 0xef, 0x6a, 0xce, 0x70, 0xc1, 0xf0, 0x85,
+	0x23, 0x5d, 0x2b, 0xf9, 0x0c, 0xe7, 0x64, 0x09, 0x4f, 0xd6, 0xa1, 0x6a, 0x99, 0xad, 0xba, 0x7c,
+	0xc4, 0x2e, 0x60, 0x9f, 0xf0, 0x50, 0xab, 0xdf, 0x67, 0xfe, 0x59, 0xb9, 0x84, 0x36, 0x5a, 0x67,
+	0x50, 0x89, 0x75, 0xb3, 0xb9, 0xd0, 0x65, 0x19, 0x04, 0xdf, 0xfe, 0xf4, 0x33, 0x00, 0x49, 0x26,
+	0x74, 0x20, 0x4a, 0x01, 0x00, 0x00,
+}
@@ -0,0 +1,503 @@
+package cmds
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	adminclient "github.com/pachyderm/pachyderm/src/client/admin"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/server/admin"
+	"github.com/pachyderm/pachyderm/src/server/admin/pretty"
+	"github.com/pachyderm/pachyderm/src/server/pkg/cmdutil"
+	"github.com/spf13/cobra"
+)
+
+const (
+	codestart = "```sh\n\n"
+	codeend   = "\n```"
+)
+
+// Cmds returns a slice containing admin commands.
+func Cmds(noMetrics *bool) []*cobra.Command {
+	metrics := !*noMetrics
+
+	var outputFile string
+	var noObjects bool
+	var since string
+	var extractURL string
+	extract := &cobra.Command{
+		Use:   "extract",
+		Short: "Extract Pachyderm state to stdout or a file.",
+		Long: `Extract Pachyderm state to stdout or a file, so that it can be restored
+to a cluster with restore.
+
+The backup contains the input repos, their commits and branches, and the
+pipelines. The output repos of pipelines aren't extracted, the restored
+pipelines recreate them by processing their inputs again. Job history and open
+commits aren't extracted.
+
+extract prints the backup's marker to stderr. Passing it to --since extracts an
+incremental backup, of only the repos and pipelines created and the commits
+finished since the marked backup was extracted. Incremental backups are
+restored on top of the backups before them.
+
+Examples:
+
+` + codestart + `# Extract the cluster's state to a file:
+$ pachctl extract -o backup
+
+# Extract the cluster's state to an s3 bucket:
+$ pachctl extract | aws s3 cp - s3://bucket/backup
+
+# Extract only metadata, for restoring to a cluster which uses the same
+# object store as this one:
+$ pachctl extract --no-objects -o backup
+
+# Have pachd write the backup straight to an object store bucket, and then
+# extract what has changed since:
+$ pachctl extract --url s3://bucket/backup
+$ pachctl extract --url s3://bucket/backup-2 --since <marker>
+` + codeend,
+		Run: cmdutil.RunFixedArgs(0, func(args []string) (retErr error) {
+			c, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			if extractURL != "" {
+				marker, err := c.ExtractURL(extractURL, !noObjects, since)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(os.Stderr, "marker: %s\n", marker)
+				return nil
+			}
+			var w io.Writer = os.Stdout
+			if outputFile != "" {
+				f, err := os.Create(outputFile)
+				if err != nil {
+					return err
+				}
+				defer func() {
+					if err := f.Close(); err != nil && retErr == nil {
+						retErr = err
+					}
+				}()
+				w = f
+			}
+			bw := bufio.NewWriter(w)
+			marker, err := c.Extract(!noObjects, since, bw)
+			if err != nil {
+				return err
+			}
+			if err := bw.Flush(); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "marker: %s\n", marker)
+			return nil
+		}),
+	}
+	extract.Flags().StringVarP(&outputFile, "output", "o", "", "The file to write the backup to, defaults to stdout.")
+	extract.Flags().BoolVar(&noObjects, "no-objects", false, "Don't extract the content of files, the backup can then only be restored to a cluster which uses the same object store.")
+	extract.Flags().StringVar(&since, "since", "", "The marker of an earlier backup, only extract what has changed since it was extracted.")
+	extract.Flags().StringVar(&extractURL, "url", "", "An object store URL (e.g. s3://bucket/backup) for pachd to write the backup to, instead of stdout.")
+
+	var inputFile string
+	var restoreURL string
+	restore := &cobra.Command{
+		Use:   "restore",
+		Short: "Restore Pachyderm state from stdin or a file.",
+		Long: `Restore Pachyderm state, extracted with extract, from stdin or a file.
+
+The cluster being restored to should be empty, restore fails if any of the
+repos or pipelines it restores already exist. Restored commits have new IDs.
+Incremental backups, extracted with --since, are restored on top of the
+backups before them, which must be restored first.
+
+Examples:
+
+` + codestart + `# Restore the cluster's state from a file:
+$ pachctl restore -i backup
+
+# Restore the cluster's state from an s3 bucket:
+$ aws s3 cp s3://bucket/backup - | pachctl restore
+
+# Have pachd read the backup straight from an object store bucket, followed
+# by an incremental backup:
+$ pachctl restore --url s3://bucket/backup
+$ pachctl restore --url s3://bucket/backup-2
+` + codeend,
+		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
+			c, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			if restoreURL != "" {
+				return c.RestoreURL(restoreURL)
+			}
+			var r io.Reader = os.Stdin
+			if inputFile != "" {
+				f, err := os.Open(inputFile)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				r = f
+			}
+			return c.Restore(r)
+		}),
+	}
+	restore.Flags().StringVarP(&inputFile, "input", "i", "", "The file to read the backup from, defaults to stdin.")
+	restore.Flags().StringVar(&restoreURL, "url", "", "An object store URL (e.g. s3://bucket/backup) for pachd to read the backup from, instead of stdin.")
+
+	var exportOutputFile string
+	var from string
+	exportRepo := &cobra.Command{
+		Use:   "export-repo repo-name [commit-id]",
+		Short: "Export a repo to a tar archive on stdout or in a file.",
+		Long: `Export a repo to a tar archive on stdout or in a file, so that it can be
+imported into another cluster with import-repo.
+
+The archive contains the repo's finished commits, the files in each of them,
+and the branches whose heads are exported. If commit-id (which may be a
+branch) is given, only it and its ancestors are exported, and --from
+excludes a commit and its ancestors.
+
+The archive can be read with tar: commits/<id>/files holds the files in the
+commit <id>, and commits/<id>/commit.json describes it.
+
+Examples:
+
+` + codestart + `# Export the repo foo to a file:
+$ pachctl export-repo foo -o foo.tar
+
+# Export the commits on foo's master branch since commit XXX:
+$ pachctl export-repo foo master --from XXX -o foo.tar
+
+# Look at the files in an exported commit:
+$ tar -xf foo.tar commits/XXX/files
+` + codeend,
+		Run: cmdutil.RunBoundedArgs(1, 2, func(args []string) (retErr error) {
+			c, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			var to string
+			if len(args) > 1 {
+				to = args[1]
+			}
+			if from != "" && to == "" {
+				return fmt.Errorf("--from can only be used along with a commit-id")
+			}
+			var w io.Writer = os.Stdout
+			if exportOutputFile != "" {
+				f, err := os.Create(exportOutputFile)
+				if err != nil {
+					return err
+				}
+				defer func() {
+					if err := f.Close(); err != nil && retErr == nil {
+						retErr = err
+					}
+				}()
+				w = f
+			}
+			bw := bufio.NewWriter(w)
+			if err := c.ExportRepo(args[0], from, to, bw); err != nil {
+				return err
+			}
+			return bw.Flush()
+		}),
+	}
+	exportRepo.Flags().StringVarP(&exportOutputFile, "output", "o", "", "The file to write the archive to, defaults to stdout.")
+	exportRepo.Flags().StringVar(&from, "from", "", "Only export the commits after this one.")
+
+	var importInputFile string
+	var importRepoName string
+	importRepo := &cobra.Command{
+		Use:   "import-repo",
+		Short: "Import a repo from a tar archive on stdin or in a file.",
+		Long: `Import a repo, exported with export-repo, from a tar archive on stdin or in
+a file.
+
+The repo is created with the name it was exported with, or the name given
+with --repo, and must not already exist. Imported commits have new IDs.
+
+Examples:
+
+` + codestart + `# Import the repo in a file:
+$ pachctl import-repo -i foo.tar
+
+# Import the repo in a file as bar:
+$ pachctl import-repo -i foo.tar --repo bar
+` + codeend,
+		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
+			c, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			var r io.Reader = os.Stdin
+			if importInputFile != "" {
+				f, err := os.Open(importInputFile)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				r = f
+			}
+			return c.ImportRepo(r, importRepoName)
+		}),
+	}
+	importRepo.Flags().StringVarP(&importInputFile, "input", "i", "", "The file to read the archive from, defaults to stdin.")
+	importRepo.Flags().StringVar(&importRepoName, "repo", "", "The name of the imported repo, defaults to the name it was exported with.")
+
+	var fix bool
+	fsck := &cobra.Command{
+		Use:   "fsck",
+		Short: "Check that all the data referenced by commits exists in object storage.",
+		Long: `Check that all the data referenced by commits exists in object storage.
+
+fsck reads the tree of every commit and checks that each object it references
+can be read from object storage, printing every dangling reference it finds.
+Objects can be lost if they're deleted from the bucket by something other than
+Pachyderm, e.g. a lifecycle policy.
+
+With --fix, fsck also repairs each branch whose head has dangling references
+by adding a commit to it which deletes the affected files, so that pipelines
+can process the branch again. Earlier commits can't be repaired.`,
+		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
+			c, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			problems := 0
+			if err := admin.Fsck(c, func(problem *admin.Problem) error {
+				problems++
+				fmt.Println(problem)
+				return nil
+			}); err != nil {
+				return err
+			}
+			if fix {
+				if err := admin.Repair(c, func(branch string, commit *pfs.Commit) error {
+					fmt.Printf("Repaired branch %s/%s with commit %s\n", commit.Repo.Name, branch, commit.ID)
+					return nil
+				}); err != nil {
+					return err
+				}
+			}
+			if problems > 0 && !fix {
+				return fmt.Errorf("found %d dangling references", problems)
+			}
+			return nil
+		}),
+	}
+	fsck.Flags().BoolVar(&fix, "fix", false, "Repair branches whose heads have dangling references.")
+
+	inspectCluster := &cobra.Command{
+		Use:   "inspect-cluster",
+		Short: "Return info about the cluster.",
+		Long: `Return info about the cluster: its ID, the versions of pachd and pachctl,
+the parameters that pachd was deployed with, and the health of each of the
+members of etcd.
+
+pachctl is only guaranteed to work with a pachd which has the same major and
+minor version, e.g. pachctl 1.4.x with pachd 1.4.x. Every command warns if
+pachctl and pachd are incompatible.`,
+		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
+			c, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			clusterInfo, err := c.InspectCluster()
+			if err != nil {
+				return err
+			}
+			return pretty.PrintClusterInfo(clusterInfo)
+		}),
+	}
+
+	inspectReplication := &cobra.Command{
+		Use:   "inspect-replication",
+		Short: "Return the status of the cluster's replication.",
+		Long: `Return the status of the cluster's replication to, or from, another cluster.
+
+A primary cluster, deployed with --replicate-to, writes incremental backups of
+its state to a bucket, which a secondary cluster, deployed with
+--replicate-from, restores. inspect-replication prints the cluster's role, the
+marker of the last backup that it wrote or restored, how far behind that is,
+and, on a secondary, how many backups are waiting to be restored.`,
+		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
+			c, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			status, err := c.InspectReplication()
+			if err != nil {
+				return err
+			}
+			return pretty.PrintReplicationStatus(status)
+		}),
+	}
+
+	promoteReplica := &cobra.Command{
+		Use:   "promote-replica",
+		Short: "Promote a secondary cluster so that it can replace its primary.",
+		Long: `Promote a secondary cluster, deployed with --replicate-from, so that it stops
+restoring its primary's backups and can be used in the primary's place.
+
+promote-replica waits for a backup that's being restored to finish. Backups
+that haven't been restored are left in the bucket, so the cluster has
+everything up to the marker printed by inspect-replication. Redeploy the
+cluster without --replicate-from once it's been promoted.`,
+		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
+			c, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			return c.PromoteReplica()
+		}),
+	}
+
+	var reason string
+	var pauseJobs bool
+	var off bool
+	setReadOnly := &cobra.Command{
+		Use:   "set-read-only",
+		Short: "Make the cluster read-only for maintenance, or writable again.",
+		Long: `Make the cluster read-only for maintenance, or writable again.
+
+While the cluster is read-only, requests that would change it (e.g. put-file,
+create-pipeline or restore) fail with an error that includes --reason. Reads,
+including extract, keep working. Running jobs run to completion, unless
+--pause-jobs is given, in which case they finish the datums they've started
+and then wait until the cluster is writable again. inspect-cluster shows
+whether the cluster is read-only.
+
+Examples:
+
+` + codestart + `# Make the cluster read-only while it's backed up:
+$ pachctl set-read-only --reason "backing up until 02:00 UTC"
+$ pachctl extract -o backup
+
+# Make the cluster read-only and pause its jobs while etcd is upgraded:
+$ pachctl set-read-only --reason "upgrading etcd" --pause-jobs
+
+# Make the cluster writable again, and resume its jobs:
+$ pachctl set-read-only --off
+` + codeend,
+		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
+			c, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			if off {
+				if reason != "" || pauseJobs {
+					return fmt.Errorf("--reason and --pause-jobs can't be used with --off")
+				}
+				return c.SetWritable()
+			}
+			if reason == "" {
+				return fmt.Errorf("--reason must be given, it's shown to users whose requests are rejected")
+			}
+			return c.SetReadOnly(reason, pauseJobs)
+		}),
+	}
+	setReadOnly.Flags().StringVar(&reason, "reason", "", "Why the cluster is read-only, shown to users whose requests are rejected.")
+	setReadOnly.Flags().BoolVar(&pauseJobs, "pause-jobs", false, "Stop running jobs from starting more datums until the cluster is writable again.")
+	setReadOnly.Flags().BoolVar(&off, "off", false, "Make the cluster writable again.")
+
+	var mirrorName string
+	var destRepo string
+	var destBranch string
+	var token string
+	createMirror := &cobra.Command{
+		Use:   "create-mirror repo-name branch-name address",
+		Short: "Copy the commits on a branch to another cluster as they're finished.",
+		Long: `Copy the commits on a branch to another cluster as they're finished.
+
+A mirror keeps a branch in another cluster, whose pachd is at address, up to
+date with a branch in this one. Every commit finished on the branch, including
+the ones already on it, is copied to the other cluster, with the same files,
+as a new commit on --dest-branch in --dest-repo (which default to the same
+repo and branch). The repo is created in the other cluster if it doesn't
+exist. Each copy is made on the branch, so the pipelines in the other cluster
+that take it as input process it, just as they would a commit made there. To
+copy commits without running pipelines, mirror to a branch that no pipeline
+takes as input, and move the pipelines' branch with set-branch, or a branch
+trigger, when the data should be processed.
+
+list-mirror shows the last commit each mirror has copied, and why copying
+failed, if it did. Copying is retried every few seconds.
+
+Examples:
+
+` + codestart + `# Keep the master branch of the curated repo in the prod cluster up to
+# date with the one in this cluster:
+$ pachctl create-mirror curated master prod.example.com:650
+
+# Copy the commits to the staging branch instead, without triggering the
+# pipelines that take master as input there:
+$ pachctl create-mirror curated master prod.example.com:650 --dest-branch staging
+` + codeend,
+		Run: cmdutil.RunFixedArgs(3, func(args []string) error {
+			c, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			return c.CreateMirror(&adminclient.MirrorSpec{
+				Name:       mirrorName,
+				Repo:       args[0],
+				Branch:     args[1],
+				Address:    args[2],
+				Token:      token,
+				DestRepo:   destRepo,
+				DestBranch: destBranch,
+			})
+		}),
+	}
+	createMirror.Flags().StringVar(&mirrorName, "name", "", "The name of the mirror, which defaults to <repo-name>-<branch-name>.")
+	createMirror.Flags().StringVar(&destRepo, "dest-repo", "", "The repo in the other cluster that commits are copied to, which defaults to repo-name.")
+	createMirror.Flags().StringVar(&destBranch, "dest-branch", "", "The branch in the other cluster that commits are copied to, which defaults to branch-name.")
+	createMirror.Flags().StringVar(&token, "token", "", "The auth token that commits are written to the other cluster with, if auth is activated there.")
+
+	listMirror := &cobra.Command{
+		Use:   "list-mirror",
+		Short: "Return the cluster's mirrors.",
+		Long:  "Return the cluster's mirrors, the last commit that each has copied to the other cluster, and the error that stopped it copying more, if there was one.",
+		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
+			c, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			mirrorInfos, err := c.ListMirror()
+			if err != nil {
+				return err
+			}
+			writer := tabwriter.NewWriter(os.Stdout, 20, 1, 3, ' ', 0)
+			pretty.PrintMirrorInfoHeader(writer)
+			for _, mirrorInfo := range mirrorInfos {
+				pretty.PrintMirrorInfo(writer, mirrorInfo)
+			}
+			return writer.Flush()
+		}),
+	}
+
+	deleteMirror := &cobra.Command{
+		Use:   "delete-mirror mirror-name",
+		Short: "Stop a mirror copying commits to another cluster.",
+		Long:  "Stop a mirror copying commits to another cluster. The commits that it has copied are left there.",
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			c, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			return c.DeleteMirror(args[0])
+		}),
+	}
+
+	return []*cobra.Command{extract, restore, exportRepo, importRepo, fsck, inspectCluster, inspectReplication, promoteReplica, setReadOnly, createMirror, listMirror, deleteMirror}
+}
@@ -0,0 +1,249 @@
+package admin
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/server/pkg/hashtree"
+)
+
+// The entries of the archives written by ExportRepo. The archive starts with
+// repoEntry, followed by each of the exported commits' commitEntry and then
+// the files in the commit, under filesDir. It ends with branchesEntry.
+const (
+	repoEntry     = "repo.json"
+	commitsDir    = "commits"
+	commitEntry   = "commit.json"
+	filesDir      = "files"
+	branchesEntry = "branches.json"
+)
+
+// ExportRepo writes a tar archive of the repo to w, which ImportRepo can
+// import into another cluster. The archive holds the repo's metadata, the
+// finished commits after from up to and including to (all of the repo's
+// commits if neither is set), the files in each of them, and the branches
+// whose heads are exported.
+//
+// The archive can also be read with tar: commits/<id>/files holds the files
+// in the commit <id> as they were when it was finished, and
+// commits/<id>/commit.json describes it. Files that a commit doesn't change
+// are hard links to its parent's, so they're only stored once.
+func ExportRepo(c *client.APIClient, repo string, from string, to string, w io.Writer) error {
+	if from != "" && to == "" {
+		return fmt.Errorf("from can only be set along with to")
+	}
+	repoInfo, err := c.InspectRepo(repo)
+	if err != nil {
+		return err
+	}
+	var commitInfos []*pfs.CommitInfo
+	if to == "" && from == "" {
+		commitInfos, err = c.ListCommitByRepo(repo)
+	} else {
+		commitInfos, err = c.ListCommit(repo, to, from, 0)
+	}
+	if err != nil {
+		return err
+	}
+	var finished []*pfs.CommitInfo
+	for _, commitInfo := range commitInfos {
+		// Open commits can't be exported, they're still being written.
+		if commitInfo.Finished != nil {
+			finished = append(finished, commitInfo)
+		}
+	}
+	commitInfos = finished
+	// Commits are created after their parents, so sorting by start time
+	// orders commits so that their parents are exported before them.
+	sort.SliceStable(commitInfos, func(i, j int) bool {
+		a, b := commitInfos[i].Started, commitInfos[j].Started
+		return a.Seconds < b.Seconds || (a.Seconds == b.Seconds && a.Nanos < b.Nanos)
+	})
+	// children counts the exported children of each commit, so that the
+	// files of a commit are forgotten once all of its children are exported.
+	children := make(map[string]int)
+	for _, commitInfo := range commitInfos {
+		if commitInfo.ParentCommit != nil {
+			children[commitInfo.ParentCommit.ID]++
+		}
+	}
+
+	tw := tar.NewWriter(w)
+	if err := writeJSONEntry(tw, repoEntry, repoInfo, timestampToTime(repoInfo.Created)); err != nil {
+		return err
+	}
+	exported := make(map[string]map[string]exportedFile)
+	for _, commitInfo := range commitInfos {
+		files, err := exportCommit(c, tw, commitInfo, exported)
+		if err != nil {
+			return fmt.Errorf("error exporting commit %s: %v", commitInfo.Commit.FullID(), err)
+		}
+		if children[commitInfo.Commit.ID] > 0 {
+			exported[commitInfo.Commit.ID] = files
+		}
+		if commitInfo.ParentCommit != nil {
+			parentID := commitInfo.ParentCommit.ID
+			if children[parentID]--; children[parentID] == 0 {
+				delete(exported, parentID)
+			}
+		}
+	}
+
+	branches, err := c.ListBranch(repo)
+	if err != nil {
+		return err
+	}
+	exportedCommits := make(map[string]bool)
+	for _, commitInfo := range commitInfos {
+		exportedCommits[commitInfo.Commit.ID] = true
+	}
+	result := &pfs.Branches{}
+	for _, branch := range branches {
+		if exportedCommits[branch.Head.ID] {
+			result.Branches = append(result.Branches, branch)
+		}
+	}
+	if err := writeJSONEntry(tw, branchesEntry, result, time.Now()); err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// exportedFile is a file in an exported commit.
+type exportedFile struct {
+	hash []byte
+	// entry is the name of the archive entry that holds the file's content.
+	entry string
+}
+
+// exportCommit writes commitInfo, and the files in it, to tw. Files which
+// are the same as in the commit's parent (if the parent is in exported) are
+// written as links to the parent's entries. It returns the commit's files.
+func exportCommit(c *client.APIClient, tw *tar.Writer, commitInfo *pfs.CommitInfo, exported map[string]map[string]exportedFile) (map[string]exportedFile, error) {
+	dir := path.Join(commitsDir, commitInfo.Commit.ID)
+	finished := timestampToTime(commitInfo.Finished)
+	if err := writeJSONEntry(tw, path.Join(dir, commitEntry), commitInfo, finished); err != nil {
+		return nil, err
+	}
+	var parentFiles map[string]exportedFile
+	if commitInfo.ParentCommit != nil {
+		parentFiles = exported[commitInfo.ParentCommit.ID]
+	}
+	nodes, err := commitFiles(c, commitInfo)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for p := range nodes {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	files := make(map[string]exportedFile)
+	for _, p := range paths {
+		entry := path.Join(dir, filesDir, p)
+		if parentFile, ok := parentFiles[p]; ok && bytes.Equal(parentFile.hash, nodes[p].Hash) {
+			if err := tw.WriteHeader(&tar.Header{
+				Typeflag: tar.TypeLink,
+				Name:     entry,
+				Linkname: parentFile.entry,
+				Mode:     0644,
+				ModTime:  finished,
+			}); err != nil {
+				return nil, err
+			}
+			files[p] = parentFile
+			continue
+		}
+		if err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     entry,
+			Size:     nodes[p].SubtreeSize,
+			Mode:     0644,
+			ModTime:  finished,
+		}); err != nil {
+			return nil, err
+		}
+		if err := c.GetFile(commitInfo.Commit.Repo.Name, commitInfo.Commit.ID, p, 0, 0, tw); err != nil {
+			return nil, err
+		}
+		files[p] = exportedFile{hash: nodes[p].Hash, entry: entry}
+	}
+	return files, nil
+}
+
+// commitFiles returns the nodes of the files in the finished commit
+// commitInfo, by path.
+func commitFiles(c *client.APIClient, commitInfo *pfs.CommitInfo) (map[string]*hashtree.NodeProto, error) {
+	result := make(map[string]*hashtree.NodeProto)
+	// Empty commits have no tree
+	if commitInfo.Tree == nil {
+		return result, nil
+	}
+	t, err := hashtree.Load(hashtree.NewObjectStore(c), commitInfo.Tree)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.Walk(func(p string, node *hashtree.NodeProto) error {
+		if node.FileNode != nil {
+			result[clean(p)] = node
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// clean returns p without its leading slash, as archive entries are
+// relative.
+func clean(p string) string {
+	return path.Clean("/" + p)[1:]
+}
+
+// writeJSONEntry writes msg to tw as JSON, in the entry name.
+func writeJSONEntry(tw *tar.Writer, name string, msg proto.Message, modTime time.Time) error {
+	var buf bytes.Buffer
+	if err := (&jsonpb.Marshaler{Indent: "  "}).Marshal(&buf, msg); err != nil {
+		return err
+	}
+	buf.WriteString("\n")
+	if err := tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     int64(buf.Len()),
+		Mode:     0644,
+		ModTime:  modTime,
+	}); err != nil {
+		return err
+	}
+	_, err := tw.Write(buf.Bytes())
+	return err
+}
+
+// readJSONEntry reads msg from the JSON in r, an archive entry.
+func readJSONEntry(r io.Reader, name string, msg proto.Message) error {
+	if err := jsonpb.Unmarshal(r, msg); err != nil {
+		return fmt.Errorf("error reading %s: %v", name, err)
+	}
+	return nil
+}
+
+// timestampToTime returns timestamp as a time.Time, or the zero time if it
+// isn't valid.
+func timestampToTime(timestamp *types.Timestamp) time.Time {
+	t, err := types.TimestampFromProto(timestamp)
+	if err != nil {
+		return time.Unix(0, 0)
+	}
+	return t
+}
@@ -0,0 +1,367 @@
+package admin
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+	"github.com/pachyderm/pachyderm/src/server/pkg/hashtree"
+)
+
+// Extract calls f with each of the Ops needed to restore the cluster that c
+// is connected to, in the order in which they must be applied. This covers
+// the input repos (and their finished commits and branches) and the
+// pipelines; the output repos of pipelines aren't extracted because the
+// restored pipelines regenerate them from their inputs. If objects is false,
+// the content of the objects referenced by commits isn't extracted, in which
+// case the cluster being restored must use the same object store as the
+// extracted one.
+//
+// If since is the marker of an earlier backup, only what has changed since
+// it was extracted is: the repos and pipelines created since, the commits
+// finished since, and the objects they reference which their parents
+// didn't. Branches are always extracted. Extract returns the backup's own
+// marker.
+func Extract(c *client.APIClient, objects bool, since string, f func(op *Op) error) (string, error) {
+	// The marker is taken before anything is read, so that everything
+	// that changes while the cluster is extracted is in the next backup.
+	marker := time.Now().UTC().Format(time.RFC3339Nano)
+	changed := func(*types.Timestamp) bool { return true }
+	if since != "" {
+		sinceTime, err := time.Parse(time.RFC3339Nano, since)
+		if err != nil {
+			return "", fmt.Errorf("invalid backup marker %q: %v", since, err)
+		}
+		changed = func(timestamp *types.Timestamp) bool {
+			t, err := types.TimestampFromProto(timestamp)
+			return err != nil || t.After(sinceTime)
+		}
+		if err := f(&Op{Since: since}); err != nil {
+			return "", err
+		}
+	}
+	return marker, extract(c, objects, changed, f)
+}
+
+// extract is Extract, for the repos, commits and pipelines which changed
+// returns true for the creation or finishing time of.
+func extract(c *client.APIClient, objects bool, changed func(*types.Timestamp) bool, f func(op *Op) error) error {
+	pipelineInfos, err := c.ListPipeline()
+	if err != nil {
+		return err
+	}
+	outputRepos := make(map[string]bool)
+	for _, pipelineInfo := range pipelineInfos {
+		outputRepos[pipelineInfo.Pipeline.Name] = true
+	}
+	repoInfos, err := c.ListRepo(nil)
+	if err != nil {
+		return err
+	}
+	var inputRepoInfos []*pfs.RepoInfo
+	for _, repoInfo := range repoInfos {
+		if !outputRepos[repoInfo.Repo.Name] {
+			inputRepoInfos = append(inputRepoInfos, repoInfo)
+		}
+	}
+	repoInfos = sortRepoInfos(inputRepoInfos)
+
+	var commitInfos []*pfs.CommitInfo
+	commitInfosByID := make(map[string]*pfs.CommitInfo)
+	for _, repoInfo := range repoInfos {
+		// Provenance on an output repo can't be restored, the repo is
+		// recreated by its pipeline after the input repos are restored.
+		var provenance []*pfs.Repo
+		for _, repo := range repoInfo.Provenance {
+			if !outputRepos[repo.Name] {
+				provenance = append(provenance, repo)
+			}
+		}
+		repoInfo.Provenance = provenance
+		if changed(repoInfo.Created) {
+			if err := f(&Op{Repo: repoInfo}); err != nil {
+				return err
+			}
+		}
+		repoCommitInfos, err := c.ListCommitByRepo(repoInfo.Repo.Name)
+		if err != nil {
+			return err
+		}
+		for _, commitInfo := range repoCommitInfos {
+			commitInfosByID[commitInfo.Commit.ID] = commitInfo
+			// Open commits can't be restored, they're still being written.
+			if commitInfo.Finished != nil && changed(commitInfo.Finished) {
+				commitInfos = append(commitInfos, commitInfo)
+			}
+		}
+	}
+	// Commits are created after their parents and provenance, so sorting by
+	// start time orders commits so that the commits they refer to are
+	// restored before them.
+	sort.SliceStable(commitInfos, func(i, j int) bool {
+		a, b := commitInfos[i].Started, commitInfos[j].Started
+		return a.Seconds < b.Seconds || (a.Seconds == b.Seconds && a.Nanos < b.Nanos)
+	})
+	extracted := make(map[string]bool)
+	for _, commitInfo := range commitInfos {
+		var provenance []*pfs.Commit
+		for _, commit := range commitInfo.Provenance {
+			if !outputRepos[commit.Repo.Name] {
+				provenance = append(provenance, commit)
+			}
+		}
+		commitInfo.Provenance = provenance
+		if objects && commitInfo.Tree != nil {
+			// The objects referenced by a parent in an earlier backup are
+			// already in that backup.
+			if commitInfo.ParentCommit != nil {
+				parent := commitInfosByID[commitInfo.ParentCommit.ID]
+				if parent != nil && parent.Tree != nil && !changed(parent.Finished) {
+					if err := extractObjects(c, parent.Tree, extracted, nil); err != nil {
+						return err
+					}
+				}
+			}
+			if err := extractObjects(c, commitInfo.Tree, extracted, f); err != nil {
+				return err
+			}
+		}
+		if err := f(&Op{Commit: commitInfo}); err != nil {
+			return err
+		}
+	}
+	for _, repoInfo := range repoInfos {
+		branches, err := c.ListBranch(repoInfo.Repo.Name)
+		if err != nil {
+			return err
+		}
+		for _, branch := range branches {
+			// A branch whose head is open is restored pointing at the
+			// head's most recent finished ancestor.
+			head := commitInfosByID[branch.Head.ID]
+			for head != nil && head.Finished == nil {
+				if head.ParentCommit == nil {
+					head = nil
+					break
+				}
+				head = commitInfosByID[head.ParentCommit.ID]
+			}
+			if head == nil {
+				continue
+			}
+			branch.Head = head.Commit
+			if err := f(&Op{Branch: branch}); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, pipelineInfo := range sortPipelineInfos(pipelineInfos) {
+		if !changed(pipelineInfo.CreatedAt) {
+			continue
+		}
+		if err := f(&Op{Pipeline: pps.CreatePipelineRequestFromInfo(pipelineInfo)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExtractWriter writes the Ops returned by Extract to w, and returns the
+// backup's marker.
+func ExtractWriter(c *client.APIClient, objects bool, since string, w io.Writer) (string, error) {
+	return Extract(c, objects, since, func(op *Op) error {
+		return writeOp(w, op)
+	})
+}
+
+// extractObjects calls f with an Op for each of tree's chunks, and for each
+// of the objects that tree references, which aren't already in extracted. If
+// f is nil, the objects are only added to extracted.
+func extractObjects(c *client.APIClient, tree *pfs.Object, extracted map[string]bool, f func(op *Op) error) error {
+	if extracted[tree.Hash] {
+		return nil
+	}
+	t, err := hashtree.Load(&extractStore{
+		ObjectStore: hashtree.NewObjectStore(c),
+		extracted:   extracted,
+		f:           f,
+	}, tree)
+	if err != nil {
+		return err
+	}
+	return t.Walk(func(path string, node *hashtree.NodeProto) error {
+		if node.FileNode == nil {
+			return nil
+		}
+		for _, object := range node.FileNode.Objects {
+			if extracted[object.Hash] {
+				continue
+			}
+			extracted[object.Hash] = true
+			if f == nil {
+				continue
+			}
+			value, err := c.ReadObject(object.Hash)
+			if err != nil {
+				return err
+			}
+			if err := f(&Op{Object: &pfs.PutObjectRequest{Value: value}}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// extractStore is an ObjectStore which extracts the chunks of a tree as
+// they're read, like extractObjects does objects.
+type extractStore struct {
+	hashtree.ObjectStore
+	extracted map[string]bool
+	f         func(op *Op) error
+}
+
+func (s *extractStore) Get(object *pfs.Object) ([]byte, error) {
+	value, err := s.ObjectStore.Get(object)
+	if err != nil {
+		return nil, err
+	}
+	if !s.extracted[object.Hash] {
+		s.extracted[object.Hash] = true
+		if s.f != nil {
+			if err := s.f(&Op{Object: &pfs.PutObjectRequest{Value: value}}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return value, nil
+}
+
+// sortRepoInfos orders repoInfos so that each repo comes after the repos in
+// its provenance.
+func sortRepoInfos(repoInfos []*pfs.RepoInfo) []*pfs.RepoInfo {
+	deps := make(map[string][]string)
+	byName := make(map[string]*pfs.RepoInfo)
+	var names []string
+	for _, repoInfo := range repoInfos {
+		name := repoInfo.Repo.Name
+		names = append(names, name)
+		byName[name] = repoInfo
+		for _, repo := range repoInfo.Provenance {
+			deps[name] = append(deps[name], repo.Name)
+		}
+	}
+	var result []*pfs.RepoInfo
+	for _, name := range topoSort(names, deps) {
+		result = append(result, byName[name])
+	}
+	return result
+}
+
+// sortPipelineInfos orders pipelineInfos so that each pipeline comes after
+// the pipelines whose output it takes as input.
+func sortPipelineInfos(pipelineInfos []*pps.PipelineInfo) []*pps.PipelineInfo {
+	deps := make(map[string][]string)
+	byName := make(map[string]*pps.PipelineInfo)
+	var names []string
+	for _, pipelineInfo := range pipelineInfos {
+		name := pipelineInfo.Pipeline.Name
+		names = append(names, name)
+		byName[name] = pipelineInfo
+		for _, input := range pipelineInfo.Inputs {
+			deps[name] = append(deps[name], input.Repo.Name)
+		}
+		deps[name] = append(deps[name], inputRepos(pipelineInfo.Input)...)
+	}
+	var result []*pps.PipelineInfo
+	for _, name := range topoSort(names, deps) {
+		result = append(result, byName[name])
+	}
+	return result
+}
+
+// inputRepos returns the repos of all the atom inputs in input.
+func inputRepos(input *pps.Input) []string {
+	if input == nil {
+		return nil
+	}
+	if input.Atom != nil {
+		return []string{input.Atom.Repo}
+	}
+	var result []string
+	for _, input := range input.Cross {
+		result = append(result, inputRepos(input)...)
+	}
+	for _, input := range input.Union {
+		result = append(result, inputRepos(input)...)
+	}
+	return result
+}
+
+// topoSort returns names ordered so that each name comes after the names it
+// depends on, names which don't depend on each other stay in the order in
+// which they were given. Dependencies which aren't in names are ignored.
+func topoSort(names []string, deps map[string][]string) []string {
+	var result []string
+	visited := make(map[string]bool)
+	present := make(map[string]bool)
+	for _, name := range names {
+		present[name] = true
+	}
+	var visit func(name string)
+	visit = func(name string) {
+		if visited[name] || !present[name] {
+			return
+		}
+		visited[name] = true
+		for _, dep := range deps[name] {
+			visit(dep)
+		}
+		result = append(result, name)
+	}
+	for _, name := range names {
+		visit(name)
+	}
+	return result
+}
+
+// writeOp writes op to w, preceded by its length.
+func writeOp(w io.Writer, op *Op) error {
+	data, err := proto.Marshal(op)
+	if err != nil {
+		return err
+	}
+	var length [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(length[:], uint64(len(data)))
+	if _, err := w.Write(length[:n]); err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// readOp reads an Op written by writeOp from r, it returns io.EOF if there
+// are no more Ops.
+func readOp(r *bufio.Reader, op *Op) error {
+	length, err := binary.ReadUvarint(r)
+	if err != nil {
+		return err
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return fmt.Errorf("error reading op: %v", err)
+	}
+	return proto.Unmarshal(data, op)
+}
@@ -0,0 +1,53 @@
+package admin
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+)
+
+func TestTopoSort(t *testing.T) {
+	deps := map[string][]string{
+		"a": {"c"},
+		"b": {"a", "d"},
+		"c": {"missing"},
+	}
+	require.Equal(t, []string{"c", "a", "d", "b", "e"}, topoSort([]string{"a", "b", "c", "d", "e"}, deps))
+}
+
+func TestWriteReadOp(t *testing.T) {
+	ops := []*Op{
+		{Repo: &pfs.RepoInfo{Repo: client.NewRepo("repo")}},
+		{Object: &pfs.PutObjectRequest{Value: []byte("foo")}},
+		{Commit: &pfs.CommitInfo{Commit: client.NewCommit("repo", "id")}},
+		{Branch: &pfs.Branch{Name: "master", Head: client.NewCommit("repo", "id")}},
+		// the salt must survive a restore, or the restored pipeline would
+		// reprocess all of its datums
+		{Pipeline: pps.CreatePipelineRequestFromInfo(&pps.PipelineInfo{
+			Pipeline:  client.NewPipeline("pipeline"),
+			Transform: &pps.Transform{Image: "image", Cmd: []string{"cmd"}},
+			Input:     client.NewAtomInput("repo", "/*"),
+			Salt:      "salt",
+		})},
+	}
+	var buf bytes.Buffer
+	for _, op := range ops {
+		require.NoError(t, writeOp(&buf, op))
+	}
+	r := bufio.NewReader(&buf)
+	for _, op := range ops {
+		got := &Op{}
+		require.NoError(t, readOp(r, got))
+		require.Equal(t, op.String(), got.String())
+		if op.Pipeline != nil {
+			require.Equal(t, "salt", got.Pipeline.Salt)
+		}
+	}
+	require.Equal(t, io.EOF, readOp(r, &Op{}))
+}
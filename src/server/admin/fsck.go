@@ -0,0 +1,216 @@
+package admin
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/server/pkg/hashtree"
+	"golang.org/x/net/context"
+)
+
+// Problem is a reference from a commit to an object which doesn't exist in
+// object storage.
+type Problem struct {
+	Commit *pfs.Commit
+	// Path is the file which references Object, it's empty if Object is the
+	// commit's tree.
+	Path   string
+	Object *pfs.Object
+}
+
+func (p *Problem) String() string {
+	if p.Path == "" {
+		return fmt.Sprintf("%s: tree object %s is missing", p.Commit.FullID(), p.Object.Hash)
+	}
+	return fmt.Sprintf("%s: file %s references missing object %s", p.Commit.FullID(), p.Path, p.Object.Hash)
+}
+
+// Fsck checks that every object referenced by the finished commits in the
+// cluster that c is connected to exists, and calls f with each dangling
+// reference that it finds.
+func Fsck(c *client.APIClient, f func(problem *Problem) error) error {
+	checker := newChecker(c)
+	repoInfos, err := c.ListRepo(nil)
+	if err != nil {
+		return err
+	}
+	for _, repoInfo := range repoInfos {
+		commitInfos, err := c.ListCommitByRepo(repoInfo.Repo.Name)
+		if err != nil {
+			return err
+		}
+		for _, commitInfo := range commitInfos {
+			problems, err := checker.checkCommit(commitInfo)
+			if err != nil {
+				return err
+			}
+			for _, problem := range problems {
+				if err := f(problem); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Repair makes the branches in the cluster that c is connected to usable
+// again by adding a commit to each branch whose head has dangling references,
+// which deletes the files that reference missing objects. Earlier commits
+// aren't changed, so their problems are still reported by Fsck. Repair calls
+// f with each of the commits it creates and the branch it was added to.
+// Branches whose head's tree is missing can't be repaired, Repair returns an
+// error if it finds one after repairing the rest.
+func Repair(c *client.APIClient, f func(branch string, commit *pfs.Commit) error) error {
+	checker := newChecker(c)
+	repoInfos, err := c.ListRepo(nil)
+	if err != nil {
+		return err
+	}
+	var unrepairable []string
+	for _, repoInfo := range repoInfos {
+		branches, err := c.ListBranch(repoInfo.Repo.Name)
+		if err != nil {
+			return err
+		}
+		for _, branch := range branches {
+			headInfo, err := c.InspectCommit(branch.Head.Repo.Name, branch.Head.ID)
+			if err != nil {
+				return err
+			}
+			problems, err := checker.checkCommit(headInfo)
+			if err != nil {
+				return err
+			}
+			if len(problems) == 0 {
+				continue
+			}
+			if problems[0].Path == "" {
+				unrepairable = append(unrepairable, fmt.Sprintf("%s/%s", repoInfo.Repo.Name, branch.Name))
+				continue
+			}
+			commit, err := repairCommit(c, headInfo, branch.Name, problems)
+			if err != nil {
+				return err
+			}
+			if err := f(branch.Name, commit); err != nil {
+				return err
+			}
+		}
+	}
+	if len(unrepairable) > 0 {
+		return fmt.Errorf("the trees of the heads of %v are missing, these branches can't be repaired", unrepairable)
+	}
+	return nil
+}
+
+// repairCommit adds a commit to branch, on top of commitInfo, which deletes
+// the files in problems.
+func repairCommit(c *client.APIClient, commitInfo *pfs.CommitInfo, branch string, problems []*Problem) (*pfs.Commit, error) {
+	store := hashtree.NewObjectStore(c)
+	tree, err := hashtree.Load(store, commitInfo.Tree)
+	if err != nil {
+		return nil, err
+	}
+	openTree := tree.Open()
+	for _, problem := range problems {
+		if err := openTree.DeleteFile(problem.Path); err != nil {
+			// the file may already have been deleted if it references more
+			// than one missing object
+			if hashtree.Code(err) != hashtree.PathNotFound {
+				return nil, err
+			}
+		}
+	}
+	if tree, err = openTree.Finish(); err != nil {
+		return nil, err
+	}
+	object, err := hashtree.Store(store, tree)
+	if err != nil {
+		return nil, err
+	}
+	return c.PfsAPIClient.BuildCommit(
+		context.Background(),
+		&pfs.BuildCommitRequest{
+			Parent:     commitInfo.Commit,
+			Branch:     branch,
+			Provenance: commitInfo.Provenance,
+			Tree:       object,
+		},
+	)
+}
+
+// checker checks commits for dangling references. Commits share most of
+// their objects with their parents, so checker remembers which objects it's
+// already checked.
+type checker struct {
+	c       *client.APIClient
+	objects map[string]bool
+}
+
+func newChecker(c *client.APIClient) *checker {
+	return &checker{
+		c:       c,
+		objects: make(map[string]bool),
+	}
+}
+
+func (c *checker) exists(object *pfs.Object) (bool, error) {
+	if exists, ok := c.objects[object.Hash]; ok {
+		return exists, nil
+	}
+	exists, err := c.c.CheckObject(object.Hash)
+	if err != nil {
+		return false, err
+	}
+	c.objects[object.Hash] = exists
+	return exists, nil
+}
+
+// checkCommit returns the dangling references in commitInfo, sorted by path.
+// If the commit's tree is missing that's the only problem returned.
+func (c *checker) checkCommit(commitInfo *pfs.CommitInfo) ([]*Problem, error) {
+	// Open commits and empty commits have no tree
+	if commitInfo.Tree == nil {
+		return nil, nil
+	}
+	exists, err := c.exists(commitInfo.Tree)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return []*Problem{{Commit: commitInfo.Commit, Object: commitInfo.Tree}}, nil
+	}
+	tree, err := hashtree.Load(hashtree.NewObjectStore(c.c), commitInfo.Tree)
+	if err != nil {
+		return nil, err
+	}
+	var problems []*Problem
+	if err := tree.Walk(func(path string, node *hashtree.NodeProto) error {
+		if node.FileNode == nil {
+			return nil
+		}
+		for _, object := range node.FileNode.Objects {
+			exists, err := c.exists(object)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				problems = append(problems, &Problem{
+					Commit: commitInfo.Commit,
+					Path:   path,
+					Object: object,
+				})
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	sort.Slice(problems, func(i, j int) bool {
+		return problems[i].Path < problems[j].Path
+	})
+	return problems, nil
+}
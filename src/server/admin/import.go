@@ -0,0 +1,260 @@
+package admin
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/server/pkg/hashtree"
+	"golang.org/x/net/context"
+)
+
+// importer imports the entries of an archive written by ExportRepo. Commits
+// get new IDs when they're imported, so importer keeps track of the ID each
+// exported commit was imported as.
+type importer struct {
+	c    *client.APIClient
+	repo string
+	// branch, if set, is the branch that the commits are made on, as
+	// MirrorBranch does, rather than the exported branches being imported.
+	branch string
+	// commits maps the IDs of the exported commits to their new IDs.
+	commits map[string]string
+	// last is the ID of the last exported commit that was imported.
+	last string
+
+	// commit is the commit being imported, its files follow it in the
+	// archive. parentFiles holds the files in its imported parent, so that
+	// the files which it deleted can be deleted from it.
+	commit      *pfs.CommitInfo
+	commitID    string
+	commitFiles map[string]bool
+	parentFiles map[string]*hashtree.NodeProto
+}
+
+// ImportRepo imports an archive written by ExportRepo, read from r, into the
+// cluster that c is connected to. The repo is created with the name repo,
+// or the name it was exported with if repo is empty, and must not already
+// exist. Imported commits have new IDs, and the repo has no provenance, as
+// the repos in its provenance may not exist in the cluster.
+func ImportRepo(c *client.APIClient, r io.Reader, repo string) error {
+	i := &importer{
+		c:       c,
+		repo:    repo,
+		commits: make(map[string]string),
+	}
+	return i.importArchive(r)
+}
+
+// MirrorBranch copies the finished commits on branch in repo, in the cluster
+// that src is connected to, to destBranch in destRepo, in the cluster that
+// dest is connected to. Only the commits after from are copied, or all of
+// the branch's commits if from is empty, and from must have been copied as
+// destFrom. destRepo is created if it doesn't exist. The copies are made on
+// destBranch, one at a time, so the pipelines that take it as input process
+// each of them. MirrorBranch returns the last commit copied and its copy,
+// which are from and destFrom if there was nothing to copy.
+func MirrorBranch(src *client.APIClient, repo string, branch string, from string, dest *client.APIClient, destRepo string, destBranch string, destFrom string) (string, string, error) {
+	i := &importer{
+		c:       dest,
+		repo:    destRepo,
+		branch:  destBranch,
+		commits: make(map[string]string),
+		last:    from,
+	}
+	if from != "" {
+		i.commits[from] = destFrom
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(ExportRepo(src, repo, from, branch, pw))
+	}()
+	err := i.importArchive(pr)
+	// stops ExportRepo if the import failed part way through the archive
+	pr.CloseWithError(err)
+	if err != nil && i.commit != nil {
+		// the half-copied commit would stop the next one being started on
+		// destBranch
+		if deleteErr := dest.DeleteCommit(destRepo, i.commitID); deleteErr != nil {
+			err = fmt.Errorf("%v (and error deleting the partly copied commit %s: %v)", err, i.commitID, deleteErr)
+		}
+	}
+	return i.last, i.commits[i.last], err
+}
+
+func (i *importer) importArchive(r io.Reader) error {
+	tr := tar.NewReader(r)
+	for first := true; ; first = false {
+		hdr, err := tr.Next()
+		if err != nil {
+			if err == io.EOF {
+				return fmt.Errorf("archive ended before %s", branchesEntry)
+			}
+			return err
+		}
+		if first && hdr.Name != repoEntry {
+			return fmt.Errorf("archive doesn't start with %s", repoEntry)
+		}
+		switch {
+		case hdr.Typeflag == tar.TypeDir:
+		case hdr.Name == repoEntry:
+			if err := i.importRepo(tr); err != nil {
+				return err
+			}
+		case hdr.Name == branchesEntry:
+			if err := i.finishCommit(); err != nil {
+				return err
+			}
+			if i.branch != "" {
+				return nil
+			}
+			return i.importBranches(tr)
+		case path.Base(hdr.Name) == commitEntry:
+			if err := i.finishCommit(); err != nil {
+				return err
+			}
+			if err := i.startCommit(tr, hdr.Name); err != nil {
+				return err
+			}
+		default:
+			if err := i.importFile(tr, hdr); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (i *importer) importRepo(r io.Reader) error {
+	repoInfo := &pfs.RepoInfo{}
+	if err := readJSONEntry(r, repoEntry, repoInfo); err != nil {
+		return err
+	}
+	if i.repo == "" {
+		i.repo = repoInfo.Repo.Name
+	}
+	if i.branch != "" {
+		// mirrors copy commits into the repo if it exists already
+		if _, err := i.c.InspectRepo(i.repo); err == nil {
+			return nil
+		}
+	}
+	if _, err := i.c.PfsAPIClient.CreateRepo(
+		context.Background(),
+		&pfs.CreateRepoRequest{
+			Repo:        client.NewRepo(i.repo),
+			Description: repoInfo.Description,
+		},
+	); err != nil {
+		return fmt.Errorf("error importing repo %s: %v", i.repo, err)
+	}
+	return nil
+}
+
+// startCommit starts the commit described by the entry name, read from r.
+// The new commit's parent is the imported parent of the exported commit,
+// if its parent was exported, or else the head of i.branch, if it's set.
+func (i *importer) startCommit(r io.Reader, name string) error {
+	commitInfo := &pfs.CommitInfo{}
+	if err := readJSONEntry(r, name, commitInfo); err != nil {
+		return err
+	}
+	var parentID string
+	if commitInfo.ParentCommit != nil {
+		parentID = i.commits[commitInfo.ParentCommit.ID]
+	}
+	commit, err := i.c.StartCommitParent(i.repo, i.branch, parentID)
+	if err != nil {
+		return fmt.Errorf("error importing commit %s: %v", commitInfo.Commit.ID, err)
+	}
+	i.commit = commitInfo
+	i.commitID = commit.ID
+	i.commitFiles = make(map[string]bool)
+	i.parentFiles = nil
+	newInfo, err := i.c.InspectCommit(i.repo, commit.ID)
+	if err != nil {
+		return err
+	}
+	if newInfo.ParentCommit != nil {
+		parentInfo, err := i.c.InspectCommit(i.repo, newInfo.ParentCommit.ID)
+		if err != nil {
+			return err
+		}
+		if i.parentFiles, err = commitFiles(i.c, parentInfo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importFile writes the file in the archive entry hdr to the commit being
+// imported. Links are files which are the same as in the commit's parent,
+// so they're already in the new commit.
+func (i *importer) importFile(r io.Reader, hdr *tar.Header) error {
+	if i.commit == nil {
+		return fmt.Errorf("unexpected entry %s before the first commit", hdr.Name)
+	}
+	prefix := path.Join(commitsDir, i.commit.Commit.ID, filesDir) + "/"
+	if !strings.HasPrefix(hdr.Name, prefix) {
+		return fmt.Errorf("unexpected entry %s in commit %s", hdr.Name, i.commit.Commit.ID)
+	}
+	p := strings.TrimPrefix(hdr.Name, prefix)
+	i.commitFiles[p] = true
+	switch hdr.Typeflag {
+	case tar.TypeLink:
+		return nil
+	case tar.TypeReg, tar.TypeRegA:
+		if _, ok := i.parentFiles[p]; ok {
+			if err := i.c.DeleteFile(i.repo, i.commitID, p); err != nil {
+				return err
+			}
+		}
+		_, err := i.c.PutFile(i.repo, i.commitID, p, r)
+		return err
+	default:
+		return fmt.Errorf("unexpected entry %s of type %q", hdr.Name, hdr.Typeflag)
+	}
+}
+
+// finishCommit deletes the files in the commit being imported's parent which
+// aren't in the commit, and finishes it.
+func (i *importer) finishCommit() error {
+	if i.commit == nil {
+		return nil
+	}
+	for p := range i.parentFiles {
+		if !i.commitFiles[p] {
+			if err := i.c.DeleteFile(i.repo, i.commitID, p); err != nil {
+				return err
+			}
+		}
+	}
+	if err := i.c.FinishCommit(i.repo, i.commitID); err != nil {
+		return fmt.Errorf("error importing commit %s: %v", i.commit.Commit.ID, err)
+	}
+	i.commits[i.commit.Commit.ID] = i.commitID
+	i.last = i.commit.Commit.ID
+	i.commit = nil
+	return nil
+}
+
+// importBranches points the branches read from r at the imported commits.
+func (i *importer) importBranches(r io.Reader) error {
+	branches := &pfs.Branches{}
+	if err := readJSONEntry(r, branchesEntry, branches); err != nil {
+		return err
+	}
+	for _, branch := range branches.Branches {
+		id, ok := i.commits[branch.Head.ID]
+		if !ok {
+			return fmt.Errorf("branch %s's head %s isn't in the archive", branch.Name, branch.Head.ID)
+		}
+		if err := i.c.SetBranch(i.repo, id, branch.Name); err != nil {
+			return fmt.Errorf("error importing branch %s: %v", branch.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package pretty
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/template"
+
+	units "github.com/docker/go-units"
+	"github.com/pachyderm/pachyderm/src/client/admin"
+	"github.com/pachyderm/pachyderm/src/client/version"
+	"github.com/pachyderm/pachyderm/src/server/pkg/pretty"
+)
+
+// PrintClusterInfo pretty-prints cluster info, along with the version of
+// pachctl.
+func PrintClusterInfo(clusterInfo *admin.ClusterInfo) error {
+	template, err := template.New("ClusterInfo").Funcs(template.FuncMap{
+		"prettyVersion": version.PrettyPrintVersion,
+		"pachctlVersion": func() string {
+			return version.PrettyPrintVersion(version.Version)
+		},
+		"compatible": func() bool {
+			return version.IsCompatible(version.Version, clusterInfo.Version)
+		},
+		"prettySize": func(size int64) string {
+			return units.BytesSize(float64(size))
+		},
+		"prettyAgo": pretty.Ago,
+	}).Parse(
+		`ID: {{.ID}}
+pachd version: {{prettyVersion .Version}}
+pachctl version: {{pachctlVersion}}{{if not compatible}} (incompatible with pachd){{end}}
+Namespace: {{.Namespace}}
+Shards: {{.NumShards}}
+Storage backend: {{if .StorageBackend}}{{.StorageBackend}}{{else}}local{{end}}
+Block cache size: {{.BlockCacheSize}}
+PFS cache size: {{.PfsCacheSize}}
+Max message size: {{.MaxMsgSize}}{{if .WorkerImage}}
+Worker image: {{.WorkerImage}}{{end}}{{if .WorkerSidecarImage}}
+Worker sidecar image: {{.WorkerSidecarImage}}{{end}}{{if .WorkerImagePullPolicy}}
+Worker image pull policy: {{.WorkerImagePullPolicy}}{{end}}
+Log level: {{.LogLevel}}
+Metrics: {{.Metrics}}{{if .ReadOnly}}
+Read-only since {{prettyAgo .ReadOnly.Since}}: {{.ReadOnly.Reason}}{{if .ReadOnly.PauseJobs}} (jobs paused){{end}}{{end}}{{if .EtcdMembers}}
+etcd members:{{range .EtcdMembers}}
+  {{if .Name}}{{.Name}} {{end}}{{if .Endpoint}}({{.Endpoint}}) {{end}}{{if .Healthy}}healthy{{if .Leader}}, leader{{end}}, database {{prettySize .DbSize}}{{else}}unhealthy: {{.Error}}{{end}}{{end}}{{end}}
+`)
+	if err != nil {
+		return err
+	}
+	return template.Execute(os.Stdout, clusterInfo)
+}
+
+// PrintReplicationStatus pretty-prints the status of a cluster's replication.
+func PrintReplicationStatus(status *admin.ReplicationStatus) error {
+	template, err := template.New("ReplicationStatus").Funcs(template.FuncMap{
+		"prettyAgo": pretty.Ago,
+	}).Parse(
+		`Role: {{.Role}}{{if .URL}}
+URL: {{.URL}}{{end}}{{if .Marker}}
+Marker: {{.Marker}}
+Replicated up to: {{prettyAgo .Replicated}}{{end}}{{if eq .Role.String "SECONDARY"}}
+Pending backups: {{.Pending}}{{end}}{{if .Error}}
+Error: {{.Error}}{{end}}
+`)
+	if err != nil {
+		return err
+	}
+	return template.Execute(os.Stdout, status)
+}
+
+// PrintMirrorInfoHeader prints a mirror info header.
+func PrintMirrorInfoHeader(w io.Writer) {
+	fmt.Fprint(w, "NAME\tSOURCE\tDESTINATION\tMIRRORED\tUPDATED\tERROR\t\n")
+}
+
+// PrintMirrorInfo pretty-prints mirror info.
+func PrintMirrorInfo(w io.Writer, mirrorInfo *admin.MirrorInfo) {
+	spec := mirrorInfo.Spec
+	fmt.Fprintf(w, "%s\t", spec.Name)
+	fmt.Fprintf(w, "%s@%s\t", spec.Repo, spec.Branch)
+	fmt.Fprintf(w, "%s/%s@%s\t", spec.Address, spec.DestRepo, spec.DestBranch)
+	if mirrorInfo.Mirrored != "" {
+		fmt.Fprintf(w, "%s\t", mirrorInfo.Mirrored)
+	} else {
+		fmt.Fprint(w, "<none>\t")
+	}
+	if mirrorInfo.Updated != nil {
+		fmt.Fprintf(w, "%s\t", pretty.Ago(mirrorInfo.Updated))
+	} else {
+		fmt.Fprint(w, "-\t")
+	}
+	fmt.Fprintf(w, "%s\t\n", mirrorInfo.Error)
+}
@@ -0,0 +1,194 @@
+package admin
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"golang.org/x/net/context"
+)
+
+// restoredCommitTag prefixes the tags that record the ID each extracted
+// commit was restored as, so that incremental backups, whose commits'
+// parents were restored from an earlier backup, can be restored too.
+const restoredCommitTag = "restored-commit-"
+
+// restorer applies Ops to a cluster. Commits get new IDs when they're
+// restored, so restorer keeps track of the ID each extracted commit was
+// restored as.
+type restorer struct {
+	c       *client.APIClient
+	commits map[string]string
+	// incremental is set if the Ops are an incremental backup, which is
+	// applied on top of the earlier backups it was extracted since.
+	incremental bool
+}
+
+// Restore applies ops, as returned by Extract, to the cluster that c is
+// connected to. The cluster should be empty, Restore fails if any of the
+// repos or pipelines it restores already exist, unless ops is an
+// incremental backup, which must be restored on top of the backups before
+// it.
+func Restore(c *client.APIClient, ops []*Op) error {
+	r := newRestorer(c)
+	for _, op := range ops {
+		if err := r.apply(op); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RestoreReader applies the Ops written by ExtractWriter to r to the cluster
+// that c is connected to.
+func RestoreReader(c *client.APIClient, r io.Reader) error {
+	restorer := newRestorer(c)
+	br := bufio.NewReader(r)
+	for {
+		op := &Op{}
+		if err := readOp(br, op); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if err := restorer.apply(op); err != nil {
+			return err
+		}
+	}
+}
+
+func newRestorer(c *client.APIClient) *restorer {
+	return &restorer{
+		c:       c,
+		commits: make(map[string]string),
+	}
+}
+
+func (r *restorer) apply(op *Op) error {
+	switch {
+	case op.Since != "":
+		r.incremental = true
+	case op.Object != nil:
+		object, _, err := r.c.PutObject(bytes.NewReader(op.Object.Value))
+		if err != nil {
+			return err
+		}
+		var tags []string
+		for _, tag := range op.Object.Tags {
+			tags = append(tags, tag.Name)
+		}
+		if len(tags) > 0 {
+			return r.c.TagObject(object.Hash, tags...)
+		}
+	case op.Repo != nil:
+		if r.incremental {
+			if _, err := r.c.InspectRepo(op.Repo.Repo.Name); err == nil {
+				return nil
+			}
+		}
+		if _, err := r.c.PfsAPIClient.CreateRepo(
+			context.Background(),
+			&pfs.CreateRepoRequest{
+				Repo:        op.Repo.Repo,
+				Provenance:  op.Repo.Provenance,
+				Description: op.Repo.Description,
+			},
+		); err != nil {
+			return fmt.Errorf("error restoring repo %s: %v", op.Repo.Repo.Name, err)
+		}
+	case op.Commit != nil:
+		return r.restoreCommit(op.Commit)
+	case op.Branch != nil:
+		id, err := r.commitID(op.Branch.Head)
+		if err != nil {
+			return err
+		}
+		if err := r.c.SetBranch(op.Branch.Head.Repo.Name, id, op.Branch.Name); err != nil {
+			return fmt.Errorf("error restoring branch %s/%s: %v", op.Branch.Head.Repo.Name, op.Branch.Name, err)
+		}
+	case op.Pipeline != nil:
+		if r.incremental {
+			if _, err := r.c.InspectPipeline(op.Pipeline.Pipeline.Name); err == nil {
+				op.Pipeline.Update = true
+			}
+		}
+		if _, err := r.c.PpsAPIClient.CreatePipeline(
+			context.Background(),
+			op.Pipeline,
+		); err != nil {
+			return fmt.Errorf("error restoring pipeline %s: %v", op.Pipeline.Pipeline.Name, err)
+		}
+	default:
+		return fmt.Errorf("empty op")
+	}
+	return nil
+}
+
+func (r *restorer) restoreCommit(commitInfo *pfs.CommitInfo) error {
+	if r.incremental {
+		// A commit that finished while its backup was extracted is in the
+		// next backup as well.
+		if _, err := r.commitID(commitInfo.Commit); err == nil {
+			return nil
+		}
+	}
+	parent := &pfs.Commit{Repo: commitInfo.Commit.Repo}
+	if commitInfo.ParentCommit != nil {
+		id, err := r.commitID(commitInfo.ParentCommit)
+		if err != nil {
+			return err
+		}
+		parent.ID = id
+	}
+	var provenance []*pfs.Commit
+	for _, commit := range commitInfo.Provenance {
+		id, err := r.commitID(commit)
+		if err != nil {
+			return err
+		}
+		provenance = append(provenance, client.NewCommit(commit.Repo.Name, id))
+	}
+	commit, err := r.c.PfsAPIClient.BuildCommit(
+		context.Background(),
+		&pfs.BuildCommitRequest{
+			Parent:     parent,
+			Provenance: provenance,
+			Tree:       commitInfo.Tree,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("error restoring commit %s: %v", commitInfo.Commit.FullID(), err)
+	}
+	// Empty commits have no tree, so BuildCommit leaves them open.
+	if commitInfo.Tree == nil {
+		if err := r.c.FinishCommit(commit.Repo.Name, commit.ID); err != nil {
+			return err
+		}
+	}
+	if _, _, err := r.c.PutObject(strings.NewReader(commit.ID), restoredCommitTag+commitInfo.Commit.ID); err != nil {
+		return err
+	}
+	r.commits[commitInfo.Commit.ID] = commit.ID
+	return nil
+}
+
+// commitID returns the ID that commit, from the extracted cluster, was
+// restored as.
+func (r *restorer) commitID(commit *pfs.Commit) (string, error) {
+	if id, ok := r.commits[commit.ID]; ok {
+		return id, nil
+	}
+	if r.incremental {
+		// commit may have been restored from an earlier backup
+		if id, err := r.c.ReadTag(restoredCommitTag + commit.ID); err == nil {
+			r.commits[commit.ID] = string(id)
+			return string(id), nil
+		}
+	}
+	return "", fmt.Errorf("commit %s hasn't been restored", commit.FullID())
+}
@@ -0,0 +1,356 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	etcd "github.com/coreos/etcd/clientv3"
+	"github.com/gogo/protobuf/types"
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/admin"
+	"github.com/pachyderm/pachyderm/src/client/pkg/grpcutil"
+	backup "github.com/pachyderm/pachyderm/src/server/admin"
+	"github.com/pachyderm/pachyderm/src/server/pkg/dlock"
+	"github.com/pachyderm/pachyderm/src/server/pkg/etcdutil"
+	"github.com/pachyderm/pachyderm/src/server/pkg/obj"
+	"github.com/pachyderm/pachyderm/src/server/pkg/readonly"
+	"go.pedge.io/proto/rpclog"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/credentials"
+)
+
+// NewAPIServer returns an admin.APIServer which describes the cluster with
+// clusterInfo, and the health of the etcd that etcdConfig connects to. It
+// extracts and restores the cluster, and exports and imports repos, through
+// the pachd at address, as the internal user with internalToken. replication
+// is how the cluster replicates, see Replicate. Requests carrying
+// internalToken are allowed while the cluster is read-only.
+func NewAPIServer(address string, etcdConfig etcd.Config, internalToken string, peerCreds credentials.TransportCredentials, clusterInfo *admin.ClusterInfo, replication ReplicationOptions) APIServer {
+	return &apiServer{
+		Logger:        protorpclog.NewLogger("admin.API"),
+		address:       address,
+		etcdConfig:    etcdConfig,
+		internalToken: internalToken,
+		peerCreds:     peerCreds,
+		clusterInfo:   clusterInfo,
+		replication:   replication,
+	}
+}
+
+type apiServer struct {
+	protorpclog.Logger
+	address       string
+	etcdConfig    etcd.Config
+	internalToken string
+	peerCreds     credentials.TransportCredentials
+	clusterInfo   *admin.ClusterInfo
+	replication   ReplicationOptions
+
+	pachClient     *client.APIClient
+	pachClientOnce sync.Once
+	pachClientErr  error
+
+	etcdClient     *etcd.Client
+	etcdClientOnce sync.Once
+	etcdClientErr  error
+}
+
+func (a *apiServer) InspectCluster(ctx context.Context, request *types.Empty) (response *admin.ClusterInfo, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	clusterInfo := *a.clusterInfo
+	etcdMembers, err := etcdutil.Status(ctx, a.etcdConfig)
+	if err != nil {
+		// etcd's health is reported, rather than failing the request, as
+		// it's most needed when etcd is down
+		etcdMembers = []*admin.EtcdMemberStatus{{Error: fmt.Sprintf("error listing etcd's members: %v", err)}}
+	}
+	clusterInfo.EtcdMembers = etcdMembers
+	// for the same reason, whether the cluster is read-only is left out if
+	// etcd can't be read
+	if etcdClient, err := a.getEtcdClient(); err == nil {
+		ctx, cancel := context.WithTimeout(ctx, readOnlyTimeout)
+		defer cancel()
+		clusterInfo.ReadOnly, _ = readonly.Get(ctx, etcdClient)
+	}
+	return &clusterInfo, nil
+}
+
+func (a *apiServer) Extract(request *admin.ExtractRequest, extractServer admin.API_ExtractServer) (retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, nil, retErr, time.Since(start)) }(time.Now())
+	pachClient, err := a.getPachClient()
+	if err != nil {
+		return err
+	}
+	var marker string
+	if request.URL != "" {
+		marker, err = a.extractURL(pachClient, request)
+	} else {
+		w := bufio.NewWriterSize(&extractWriter{extractServer}, grpcutil.MaxMsgSize/2)
+		marker, err = backup.ExtractWriter(pachClient, !request.NoObjects, request.Since, w)
+		if err == nil {
+			err = w.Flush()
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return extractServer.Send(&admin.ExtractResponse{Marker: marker})
+}
+
+// extractURL writes a backup to the object store URL in request.
+func (a *apiServer) extractURL(pachClient *client.APIClient, request *admin.ExtractRequest) (_ string, retErr error) {
+	objClient, path, err := objClientAndPath(request.URL)
+	if err != nil {
+		return "", err
+	}
+	objW, err := objClient.Writer(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := objW.Close(); err != nil && retErr == nil {
+			retErr = err
+		}
+	}()
+	w := bufio.NewWriter(objW)
+	marker, err := backup.ExtractWriter(pachClient, !request.NoObjects, request.Since, w)
+	if err != nil {
+		return "", err
+	}
+	return marker, w.Flush()
+}
+
+func (a *apiServer) Restore(restoreServer admin.API_RestoreServer) (retErr error) {
+	func() { a.Log(nil, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(nil, nil, retErr, time.Since(start)) }(time.Now())
+	pachClient, err := a.getPachClient()
+	if err != nil {
+		return err
+	}
+	request, err := restoreServer.Recv()
+	if err != nil && err != io.EOF {
+		return err
+	}
+	var r io.Reader
+	if request != nil && request.URL != "" {
+		objClient, path, err := objClientAndPath(request.URL)
+		if err != nil {
+			return err
+		}
+		objR, err := objClient.Reader(path, 0, 0)
+		if err != nil {
+			return err
+		}
+		defer objR.Close()
+		r = objR
+	} else {
+		var data []byte
+		if request != nil {
+			data = request.Data
+		}
+		r = io.MultiReader(bytes.NewReader(data), &restoreReader{restoreServer: restoreServer})
+	}
+	if err := backup.RestoreReader(pachClient, r); err != nil {
+		return err
+	}
+	return restoreServer.SendAndClose(&types.Empty{})
+}
+
+func (a *apiServer) ExportRepo(request *admin.ExportRepoRequest, exportRepoServer admin.API_ExportRepoServer) (retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, nil, retErr, time.Since(start)) }(time.Now())
+	pachClient, err := a.getPachClient()
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriterSize(&exportRepoWriter{exportRepoServer}, grpcutil.MaxMsgSize/2)
+	if err := backup.ExportRepo(pachClient, request.Repo, request.From, request.To, w); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+func (a *apiServer) ImportRepo(importRepoServer admin.API_ImportRepoServer) (retErr error) {
+	func() { a.Log(nil, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(nil, nil, retErr, time.Since(start)) }(time.Now())
+	pachClient, err := a.getPachClient()
+	if err != nil {
+		return err
+	}
+	request, err := importRepoServer.Recv()
+	if err != nil && err != io.EOF {
+		return err
+	}
+	if request == nil {
+		request = &admin.ImportRepoRequest{}
+	}
+	r := io.MultiReader(bytes.NewReader(request.Data), &importRepoReader{importRepoServer: importRepoServer})
+	if err := backup.ImportRepo(pachClient, r, request.Repo); err != nil {
+		return err
+	}
+	return importRepoServer.SendAndClose(&types.Empty{})
+}
+
+func (a *apiServer) InspectReplication(ctx context.Context, request *types.Empty) (response *admin.ReplicationStatus, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	if a.replication.Role == admin.ReplicationRole_NONE {
+		return &admin.ReplicationStatus{}, nil
+	}
+	etcdClient, err := etcd.New(a.etcdConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer etcdClient.Close()
+	status, err := getReplicationStatus(ctx, etcdClient, a.replication)
+	if err != nil {
+		return nil, err
+	}
+	if status.Role == admin.ReplicationRole_SECONDARY {
+		objClient, prefix, err := objClientAndPath(a.replication.URL)
+		if err != nil {
+			return nil, err
+		}
+		backups, err := listReplicatedBackups(objClient, prefix)
+		if err != nil {
+			return nil, fmt.Errorf("error listing the backups in %s: %v", a.replication.URL, err)
+		}
+		status.Pending = int64(len(pendingBackups(backups, status)))
+	}
+	return status, nil
+}
+
+func (a *apiServer) PromoteReplica(ctx context.Context, request *types.Empty) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	if a.replication.Role != admin.ReplicationRole_SECONDARY {
+		return nil, fmt.Errorf("this cluster isn't a secondary, it was deployed without --replicate-from")
+	}
+	etcdClient, err := etcd.New(a.etcdConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer etcdClient.Close()
+	// taking the lock waits for the backup being restored, if there is one
+	lock := dlock.NewDLock(etcdClient, replicationLockKey)
+	lockCtx, err := lock.Lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := lock.Unlock(context.Background()); err != nil && retErr == nil {
+			retErr = err
+		}
+	}()
+	status, err := getReplicationStatus(lockCtx, etcdClient, a.replication)
+	if err != nil {
+		return nil, err
+	}
+	status.Role = admin.ReplicationRole_PROMOTED
+	if err := putReplicationStatus(lockCtx, etcdClient, status); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+func (a *apiServer) getPachClient() (*client.APIClient, error) {
+	a.pachClientOnce.Do(func() {
+		a.pachClient, a.pachClientErr = client.NewFromAddress(a.address, client.WithAuthToken(a.internalToken), client.WithTransportCredentials(a.peerCreds))
+	})
+	return a.pachClient, a.pachClientErr
+}
+
+func (a *apiServer) getEtcdClient() (*etcd.Client, error) {
+	a.etcdClientOnce.Do(func() {
+		a.etcdClient, a.etcdClientErr = etcd.New(a.etcdConfig)
+	})
+	return a.etcdClient, a.etcdClientErr
+}
+
+// objClientAndPath returns a client for the object store in URL, e.g.
+// s3://bucket/path/to/backup, and the path of the object in it.
+func objClientAndPath(URL string) (obj.Client, string, error) {
+	objClient, err := obj.NewClientFromURLAndSecret(context.Background(), URL)
+	if err != nil {
+		return nil, "", err
+	}
+	u, err := url.Parse(URL)
+	if err != nil {
+		return nil, "", err
+	}
+	path := strings.TrimPrefix(u.Path, "/")
+	if path == "" {
+		return nil, "", fmt.Errorf("object store URL %q doesn't name an object", URL)
+	}
+	return objClient, path, nil
+}
+
+// extractWriter sends the data written to it to the caller of Extract.
+type extractWriter struct {
+	extractServer admin.API_ExtractServer
+}
+
+func (w *extractWriter) Write(p []byte) (int, error) {
+	for _, chunk := range grpcutil.Chunk(p, grpcutil.MaxMsgSize/2) {
+		if err := w.extractServer.Send(&admin.ExtractResponse{Data: chunk}); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// restoreReader reads the data sent by the caller of Restore.
+type restoreReader struct {
+	restoreServer admin.API_RestoreServer
+	buf           bytes.Buffer
+}
+
+func (r *restoreReader) Read(p []byte) (int, error) {
+	for r.buf.Len() == 0 {
+		request, err := r.restoreServer.Recv()
+		if err != nil {
+			return 0, err
+		}
+		r.buf.Write(request.Data)
+	}
+	return r.buf.Read(p)
+}
+
+// exportRepoWriter sends the data written to it to the caller of ExportRepo.
+type exportRepoWriter struct {
+	exportRepoServer admin.API_ExportRepoServer
+}
+
+func (w *exportRepoWriter) Write(p []byte) (int, error) {
+	for _, chunk := range grpcutil.Chunk(p, grpcutil.MaxMsgSize/2) {
+		if err := w.exportRepoServer.Send(&admin.ExportRepoResponse{Data: chunk}); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// importRepoReader reads the data sent by the caller of ImportRepo.
+type importRepoReader struct {
+	importRepoServer admin.API_ImportRepoServer
+	buf              bytes.Buffer
+}
+
+func (r *importRepoReader) Read(p []byte) (int, error) {
+	for r.buf.Len() == 0 {
+		request, err := r.importRepoServer.Recv()
+		if err != nil {
+			return 0, err
+		}
+		r.buf.Write(request.Data)
+	}
+	return r.buf.Read(p)
+}
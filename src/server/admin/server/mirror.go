@@ -0,0 +1,236 @@
+package server
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	etcd "github.com/coreos/etcd/clientv3"
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/admin"
+	backup "github.com/pachyderm/pachyderm/src/server/admin"
+	"github.com/pachyderm/pachyderm/src/server/pkg/dlock"
+	"go.pedge.io/lion/proto"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/credentials"
+)
+
+const (
+	// mirrorsPrefix is the etcd prefix of the keys that hold the cluster's
+	// mirrors, as admin.MirrorInfos keyed by name.
+	mirrorsPrefix = "pachyderm_mirrors"
+	// mirrorLockKey is the etcd key of the lock that's held while mirrors
+	// copy commits, so that each commit is copied by one pachd.
+	mirrorLockKey = "pachyderm_mirror_lock"
+	// mirrorInterval is how often each mirror checks for new commits to
+	// copy.
+	mirrorInterval = 10 * time.Second
+)
+
+func mirrorKey(name string) string {
+	return path.Join(mirrorsPrefix, name)
+}
+
+func (a *apiServer) CreateMirror(ctx context.Context, request *admin.CreateMirrorRequest) (response *types.Empty, retErr error) {
+	// the request isn't logged, as its token is a secret
+	func() { a.Log(nil, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(nil, response, retErr, time.Since(start)) }(time.Now())
+	spec := request.Spec
+	if spec == nil || spec.Repo == "" || spec.Branch == "" {
+		return nil, fmt.Errorf("a mirror must have a repo and a branch to copy commits from")
+	}
+	if spec.Address == "" {
+		return nil, fmt.Errorf("a mirror must have the address of the cluster to copy commits to")
+	}
+	if spec.Name == "" {
+		spec.Name = fmt.Sprintf("%s-%s", spec.Repo, spec.Branch)
+	}
+	if strings.Contains(spec.Name, "/") {
+		return nil, fmt.Errorf("mirror name %q can't contain a slash", spec.Name)
+	}
+	if spec.DestRepo == "" {
+		spec.DestRepo = spec.Repo
+	}
+	if spec.DestBranch == "" {
+		spec.DestBranch = spec.Branch
+	}
+	pachClient, err := a.getPachClient()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := pachClient.InspectRepo(spec.Repo); err != nil {
+		return nil, err
+	}
+	etcdClient, err := a.getEtcdClient()
+	if err != nil {
+		return nil, err
+	}
+	value, err := proto.Marshal(&admin.MirrorInfo{Spec: spec})
+	if err != nil {
+		return nil, err
+	}
+	key := mirrorKey(spec.Name)
+	resp, err := etcdClient.Txn(ctx).
+		If(etcd.Compare(etcd.CreateRevision(key), "=", 0)).
+		Then(etcd.OpPut(key, string(value))).
+		Commit()
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Succeeded {
+		return nil, fmt.Errorf("mirror %s already exists", spec.Name)
+	}
+	return &types.Empty{}, nil
+}
+
+func (a *apiServer) ListMirror(ctx context.Context, request *types.Empty) (response *admin.MirrorInfos, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	etcdClient, err := a.getEtcdClient()
+	if err != nil {
+		return nil, err
+	}
+	mirrorInfos, err := listMirrors(ctx, etcdClient)
+	if err != nil {
+		return nil, err
+	}
+	for _, mirrorInfo := range mirrorInfos {
+		mirrorInfo.Spec.Token = ""
+	}
+	return &admin.MirrorInfos{MirrorInfo: mirrorInfos}, nil
+}
+
+func (a *apiServer) DeleteMirror(ctx context.Context, request *admin.DeleteMirrorRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	etcdClient, err := a.getEtcdClient()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := etcdClient.Delete(ctx, mirrorKey(request.Name))
+	if err != nil {
+		return nil, err
+	}
+	if resp.Deleted == 0 {
+		return nil, fmt.Errorf("mirror %s not found", request.Name)
+	}
+	return &types.Empty{}, nil
+}
+
+// Mirror copies the new commits of each of the cluster's mirrors to the
+// other clusters every mirrorInterval. Only the pachd that holds the mirror
+// lock does so, so it can be run on every pachd. It reads the commits through
+// the pachd at address, as the internal user with internalToken.
+func Mirror(etcdConfig etcd.Config, address string, internalToken string, peerCreds credentials.TransportCredentials) {
+	etcdClient, err := etcd.New(etcdConfig)
+	if err != nil {
+		protolion.Errorf("error connecting to etcd; this pachd won't mirror: %v", err)
+		return
+	}
+	defer etcdClient.Close()
+	lock := dlock.NewDLock(etcdClient, mirrorLockKey)
+	for {
+		if err := mirrorOnce(etcdClient, lock, address, internalToken, peerCreds); err != nil {
+			protolion.Errorf("error mirroring: %v", err)
+		}
+		time.Sleep(mirrorInterval)
+	}
+}
+
+// mirrorOnce copies the new commits of each mirror while holding lock. An
+// error copying a mirror's commits is recorded in its MirrorInfo, rather than
+// returned, so that the other mirrors still copy theirs.
+func mirrorOnce(etcdClient *etcd.Client, lock dlock.DLock, address string, internalToken string, peerCreds credentials.TransportCredentials) (retErr error) {
+	ctx, err := lock.Lock(context.Background())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := lock.Unlock(context.Background()); err != nil && retErr == nil {
+			retErr = err
+		}
+	}()
+	mirrorInfos, err := listMirrors(ctx, etcdClient)
+	if err != nil || len(mirrorInfos) == 0 {
+		return err
+	}
+	pachClient, err := client.NewFromAddress(address, client.WithAuthToken(internalToken), client.WithTransportCredentials(peerCreds))
+	if err != nil {
+		return err
+	}
+	defer pachClient.Close()
+	for _, mirrorInfo := range mirrorInfos {
+		head, err := pachClient.InspectCommit(mirrorInfo.Spec.Repo, mirrorInfo.Spec.Branch)
+		if err == nil && head.Commit.ID == mirrorInfo.Mirrored {
+			continue
+		}
+		if err == nil {
+			err = mirrorCommits(pachClient, mirrorInfo)
+		}
+		mirrorInfo.Error = ""
+		if err != nil {
+			mirrorInfo.Error = err.Error()
+		}
+		if mirrorInfo.Updated, err = types.TimestampProto(time.Now()); err != nil {
+			return err
+		}
+		if err := putMirror(ctx, etcdClient, mirrorInfo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mirrorCommits copies the commits that have been finished on
+// mirrorInfo's branch since it last copied them, and records the last one
+// copied in it.
+func mirrorCommits(pachClient *client.APIClient, mirrorInfo *admin.MirrorInfo) error {
+	spec := mirrorInfo.Spec
+	var options []client.Option
+	if spec.Token != "" {
+		options = append(options, client.WithAuthToken(spec.Token))
+	}
+	destClient, err := client.NewFromAddress(spec.Address, options...)
+	if err != nil {
+		return fmt.Errorf("error connecting to %s: %v", spec.Address, err)
+	}
+	defer destClient.Close()
+	mirrorInfo.Mirrored, mirrorInfo.DestCommit, err = backup.MirrorBranch(
+		pachClient, spec.Repo, spec.Branch, mirrorInfo.Mirrored,
+		destClient, spec.DestRepo, spec.DestBranch, mirrorInfo.DestCommit,
+	)
+	return err
+}
+
+func listMirrors(ctx context.Context, etcdClient *etcd.Client) ([]*admin.MirrorInfo, error) {
+	resp, err := etcdClient.Get(ctx, mirrorsPrefix+"/", etcd.WithPrefix(), etcd.WithSort(etcd.SortByKey, etcd.SortAscend))
+	if err != nil {
+		return nil, err
+	}
+	var result []*admin.MirrorInfo
+	for _, kv := range resp.Kvs {
+		mirrorInfo := &admin.MirrorInfo{}
+		if err := proto.Unmarshal(kv.Value, mirrorInfo); err != nil {
+			return nil, err
+		}
+		result = append(result, mirrorInfo)
+	}
+	return result, nil
+}
+
+// putMirror writes mirrorInfo, unless its mirror has been deleted.
+func putMirror(ctx context.Context, etcdClient *etcd.Client, mirrorInfo *admin.MirrorInfo) error {
+	value, err := proto.Marshal(mirrorInfo)
+	if err != nil {
+		return err
+	}
+	key := mirrorKey(mirrorInfo.Spec.Name)
+	_, err = etcdClient.Txn(ctx).
+		If(etcd.Compare(etcd.CreateRevision(key), ">", 0)).
+		Then(etcd.OpPut(key, string(value))).
+		Commit()
+	return err
+}
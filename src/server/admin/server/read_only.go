@@ -0,0 +1,142 @@
+package server
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/admin"
+	"github.com/pachyderm/pachyderm/src/server/pkg/readonly"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// readOnlyTimeout bounds how long InspectCluster waits to read whether the
+// cluster is read-only.
+const readOnlyTimeout = 10 * time.Second
+
+// mutatingMethods are the methods which change the cluster, and so are
+// rejected while it's read-only. Everything else, including Extract, is
+// allowed, as are users logging in.
+var mutatingMethods = map[string]bool{
+	"/pfs.API/CreateRepo":         true,
+	"/pfs.API/UpdateRepo":         true,
+	"/pfs.API/DeleteRepo":         true,
+	"/pfs.API/StartCommit":        true,
+	"/pfs.API/FinishCommit":       true,
+	"/pfs.API/FinishCommits":      true,
+	"/pfs.API/DeleteCommit":       true,
+	"/pfs.API/BuildCommit":        true,
+	"/pfs.API/SetBranch":          true,
+	"/pfs.API/DeleteBranch":       true,
+	"/pfs.API/SetBranchTrigger":   true,
+	"/pfs.API/SetBranchRetention": true,
+	"/pfs.API/PutFile":            true,
+	"/pfs.API/PutFileBatch":       true,
+	"/pfs.API/DeleteFile":         true,
+	"/pfs.API/DeleteFiles":        true,
+	"/pfs.API/DeleteAll":          true,
+	"/pfs.ObjectAPI/PutObject":    true,
+	"/pfs.ObjectAPI/TagObject":    true,
+	"/pfs.ObjectAPI/Compact":      true,
+	"/pps.API/CreateJob":          true,
+	"/pps.API/DeleteJob":          true,
+	"/pps.API/StopJob":            true,
+	"/pps.API/RestartDatum":       true,
+	"/pps.API/CreatePipeline":     true,
+	"/pps.API/DeletePipeline":     true,
+	"/pps.API/StartPipeline":      true,
+	"/pps.API/StopPipeline":       true,
+	"/pps.API/RerunPipeline":      true,
+	"/pps.API/ClearDatumCache":    true,
+	"/pps.API/RunPipeline":        true,
+	"/pps.API/TriggerPipeline":    true,
+	"/pps.API/RunTransaction":     true,
+	"/pps.API/DeleteAll":          true,
+	"/auth.API/Activate":          true,
+	"/auth.API/Deactivate":        true,
+	"/auth.API/SetScope":          true,
+	"/auth.API/ModifyAdmins":      true,
+	"/admin.API/Restore":          true,
+	"/admin.API/ImportRepo":       true,
+	"/admin.API/CreateMirror":     true,
+	"/admin.API/DeleteMirror":     true,
+}
+
+func (a *apiServer) SetReadOnly(ctx context.Context, request *admin.SetReadOnlyRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	etcdClient, err := a.getEtcdClient()
+	if err != nil {
+		return nil, err
+	}
+	if !request.ReadOnly {
+		if err := readonly.Clear(ctx, etcdClient); err != nil {
+			return nil, err
+		}
+		return &types.Empty{}, nil
+	}
+	if request.Reason == "" {
+		return nil, fmt.Errorf("a reason must be given for making the cluster read-only, it's shown to the users whose requests are rejected")
+	}
+	status := &admin.ReadOnlyStatus{
+		Reason:    request.Reason,
+		PauseJobs: request.PauseJobs,
+	}
+	// a cluster which is already read-only stays read-only since it first was
+	current, err := readonly.Get(ctx, etcdClient)
+	if err != nil {
+		return nil, err
+	}
+	if current != nil {
+		status.Since = current.Since
+	} else if status.Since, err = types.TimestampProto(time.Now()); err != nil {
+		return nil, err
+	}
+	if err := readonly.Set(ctx, etcdClient, status); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+func (a *apiServer) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := a.checkWritable(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *apiServer) StreamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := a.checkWritable(stream.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, stream)
+}
+
+// checkWritable returns an error if fullMethod is one of mutatingMethods and
+// the cluster is read-only. Requests made by pachd and its workers, which
+// carry the internal token, are always allowed, so that running jobs can
+// write their output.
+func (a *apiServer) checkWritable(ctx context.Context, fullMethod string) error {
+	if !mutatingMethods[fullMethod] {
+		return nil
+	}
+	token := client.AuthToken(ctx)
+	if token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(a.internalToken)) == 1 {
+		return nil
+	}
+	etcdClient, err := a.getEtcdClient()
+	if err != nil {
+		return err
+	}
+	status, err := readonly.Get(ctx, etcdClient)
+	if err != nil {
+		return err
+	}
+	if status == nil {
+		return nil
+	}
+	return client.Errorf(client.ErrReadOnly, "cluster is read-only for maintenance (%s), %s can't be called until it's writable again", status.Reason, fullMethod)
+}
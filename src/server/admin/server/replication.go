@@ -0,0 +1,322 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	etcd "github.com/coreos/etcd/clientv3"
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/admin"
+	backup "github.com/pachyderm/pachyderm/src/server/admin"
+	"github.com/pachyderm/pachyderm/src/server/pkg/dlock"
+	"github.com/pachyderm/pachyderm/src/server/pkg/obj"
+	"go.pedge.io/lion/proto"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/credentials"
+)
+
+const (
+	// replicationKey is the etcd key of the cluster's admin.ReplicationStatus.
+	replicationKey = "pachyderm_replication"
+	// replicationLockKey is the etcd key of the lock that's held while a
+	// backup is written or restored, so that that's done by one pachd at a
+	// time, and a secondary isn't promoted half way through a backup.
+	replicationLockKey = "pachyderm_replication_lock"
+	// markerSuffix is the suffix of the objects that hold the markers of the
+	// backups in the replication bucket. A backup's marker is written once
+	// the backup has been, so backups without markers are incomplete.
+	markerSuffix = ".marker"
+)
+
+// ReplicationOptions configure how a cluster replicates its state to, or
+// from, another cluster.
+type ReplicationOptions struct {
+	// Role is PRIMARY if the cluster writes incremental backups to the
+	// bucket at URL (e.g. s3://bucket/replication), and SECONDARY if it
+	// restores them from it.
+	Role admin.ReplicationRole
+	URL  string
+	// Interval is how often a primary writes a backup, and a secondary checks
+	// for new ones.
+	Interval time.Duration
+}
+
+// replicatedBackup is a backup in the replication bucket.
+type replicatedBackup struct {
+	seq    int
+	marker string
+	// markerTime is the time that marker records, everything that changed
+	// before it is in the backup or the ones before it.
+	markerTime time.Time
+}
+
+// Replicate writes the backups of a primary, or restores them on a
+// secondary, every options.Interval. Only the pachd that holds the
+// replication lock does so, so it can be run on every pachd. It returns
+// once a secondary has been promoted.
+func Replicate(etcdConfig etcd.Config, address string, internalToken string, peerCreds credentials.TransportCredentials, options ReplicationOptions) {
+	if options.Role == admin.ReplicationRole_NONE {
+		return
+	}
+	etcdClient, err := etcd.New(etcdConfig)
+	if err != nil {
+		protolion.Errorf("error connecting to etcd; this pachd won't replicate: %v", err)
+		return
+	}
+	defer etcdClient.Close()
+	lock := dlock.NewDLock(etcdClient, replicationLockKey)
+	for {
+		promoted, err := replicateOnce(etcdClient, lock, address, internalToken, peerCreds, options)
+		if err != nil {
+			protolion.Errorf("error replicating to or from %s: %v", options.URL, err)
+		}
+		if promoted {
+			protolion.Infof("this cluster has been promoted; it has stopped restoring backups from %s", options.URL)
+			return
+		}
+		time.Sleep(options.Interval)
+	}
+}
+
+// replicateOnce writes, or restores, the next backups while holding lock. It
+// returns true if the cluster was a secondary, and has been promoted.
+func replicateOnce(etcdClient *etcd.Client, lock dlock.DLock, address string, internalToken string, peerCreds credentials.TransportCredentials, options ReplicationOptions) (_ bool, retErr error) {
+	ctx, err := lock.Lock(context.Background())
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err := lock.Unlock(context.Background()); err != nil && retErr == nil {
+			retErr = err
+		}
+	}()
+	status, err := getReplicationStatus(ctx, etcdClient, options)
+	if err != nil {
+		return false, err
+	}
+	if status.Role == admin.ReplicationRole_PROMOTED {
+		return true, nil
+	}
+	pachClient, err := client.NewFromAddress(address, client.WithAuthToken(internalToken), client.WithTransportCredentials(peerCreds))
+	if err != nil {
+		return false, err
+	}
+	defer pachClient.Close()
+	objClient, prefix, err := objClientAndPath(options.URL)
+	if err != nil {
+		return false, err
+	}
+	if status.Role == admin.ReplicationRole_PRIMARY {
+		err = writeReplicatedBackup(ctx, etcdClient, pachClient, objClient, prefix, status)
+	} else {
+		err = restoreReplicatedBackups(ctx, etcdClient, pachClient, objClient, prefix, status)
+	}
+	status.Error = ""
+	if err != nil {
+		status.Error = err.Error()
+	}
+	if err := putReplicationStatus(ctx, etcdClient, status); err != nil {
+		return false, err
+	}
+	return false, err
+}
+
+// writeReplicatedBackup writes a backup of everything that has changed since
+// the last backup in the bucket to it.
+func writeReplicatedBackup(ctx context.Context, etcdClient *etcd.Client, pachClient *client.APIClient, objClient obj.Client, prefix string, status *admin.ReplicationStatus) error {
+	backups, err := listReplicatedBackups(objClient, prefix)
+	if err != nil {
+		return err
+	}
+	seq := 0
+	var since string
+	if len(backups) > 0 {
+		last := backups[len(backups)-1]
+		seq = last.seq + 1
+		since = last.marker
+	}
+	name := backupObject(prefix, seq)
+	// a backup without a marker was left by a primary which failed while
+	// writing it
+	if objClient.Exists(name) {
+		if err := objClient.Delete(name); err != nil {
+			return err
+		}
+	}
+	objW, err := objClient.Writer(name)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(objW)
+	marker, err := backup.ExtractWriter(pachClient, true, since, w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := objW.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	markerW, err := objClient.Writer(name + markerSuffix)
+	if err != nil {
+		return err
+	}
+	if _, err := markerW.Write([]byte(marker)); err != nil {
+		markerW.Close()
+		return err
+	}
+	if err := markerW.Close(); err != nil {
+		return err
+	}
+	return setReplicated(ctx, etcdClient, status, marker)
+}
+
+// restoreReplicatedBackups restores the backups in the bucket which are newer
+// than the last one restored, in order.
+func restoreReplicatedBackups(ctx context.Context, etcdClient *etcd.Client, pachClient *client.APIClient, objClient obj.Client, prefix string, status *admin.ReplicationStatus) error {
+	backups, err := listReplicatedBackups(objClient, prefix)
+	if err != nil {
+		return err
+	}
+	for _, b := range pendingBackups(backups, status) {
+		if err := restoreReplicatedBackup(pachClient, objClient, backupObject(prefix, b.seq)); err != nil {
+			return fmt.Errorf("error restoring backup %d: %v", b.seq, err)
+		}
+		if err := setReplicated(ctx, etcdClient, status, b.marker); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func restoreReplicatedBackup(pachClient *client.APIClient, objClient obj.Client, name string) error {
+	r, err := objClient.Reader(name, 0, 0)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return backup.RestoreReader(pachClient, r)
+}
+
+// setReplicated records that everything up to marker has been replicated.
+func setReplicated(ctx context.Context, etcdClient *etcd.Client, status *admin.ReplicationStatus, marker string) error {
+	markerTime, err := time.Parse(time.RFC3339Nano, marker)
+	if err != nil {
+		return fmt.Errorf("invalid backup marker %q: %v", marker, err)
+	}
+	replicated, err := types.TimestampProto(markerTime)
+	if err != nil {
+		return err
+	}
+	status.Marker = marker
+	status.Replicated = replicated
+	return putReplicationStatus(ctx, etcdClient, status)
+}
+
+// pendingBackups returns the backups which were written after the last one
+// that status records as restored.
+func pendingBackups(backups []*replicatedBackup, status *admin.ReplicationStatus) []*replicatedBackup {
+	if status.Marker == "" {
+		return backups
+	}
+	restored, err := time.Parse(time.RFC3339Nano, status.Marker)
+	if err != nil {
+		return backups
+	}
+	var result []*replicatedBackup
+	for _, b := range backups {
+		if b.markerTime.After(restored) {
+			result = append(result, b)
+		}
+	}
+	return result
+}
+
+// listReplicatedBackups returns the complete backups in the bucket, in the
+// order in which they were written.
+func listReplicatedBackups(objClient obj.Client, prefix string) ([]*replicatedBackup, error) {
+	var result []*replicatedBackup
+	if err := objClient.Walk(prefix+"/", func(name string) error {
+		if !strings.HasSuffix(name, markerSuffix) {
+			return nil
+		}
+		seq, err := strconv.Atoi(strings.TrimSuffix(path.Base(name), markerSuffix))
+		if err != nil {
+			// not one of ours
+			return nil
+		}
+		r, err := objClient.Reader(name, 0, 0)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		marker, err := ioutil.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		markerTime, err := time.Parse(time.RFC3339Nano, string(marker))
+		if err != nil {
+			return fmt.Errorf("invalid marker in %s: %v", name, err)
+		}
+		result = append(result, &replicatedBackup{
+			seq:        seq,
+			marker:     string(marker),
+			markerTime: markerTime,
+		})
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].seq < result[j].seq })
+	return result, nil
+}
+
+// backupObject returns the name of the object that holds the backup seq.
+func backupObject(prefix string, seq int) string {
+	return path.Join(prefix, fmt.Sprintf("%012d", seq))
+}
+
+// getReplicationStatus returns the status stored in etcd, or a new status
+// for options if there isn't one. A primary which was promoted from a
+// secondary is a primary again.
+func getReplicationStatus(ctx context.Context, etcdClient *etcd.Client, options ReplicationOptions) (*admin.ReplicationStatus, error) {
+	resp, err := etcdClient.Get(ctx, replicationKey)
+	if err != nil {
+		return nil, err
+	}
+	status := &admin.ReplicationStatus{}
+	if len(resp.Kvs) > 0 {
+		if err := proto.Unmarshal(resp.Kvs[0].Value, status); err != nil {
+			return nil, err
+		}
+	}
+	if status.Role == admin.ReplicationRole_PROMOTED && options.Role == admin.ReplicationRole_SECONDARY {
+		return status, nil
+	}
+	if status.Role != options.Role || status.URL != options.URL {
+		// the cluster has been redeployed to replicate differently, so the
+		// old status doesn't apply
+		status = &admin.ReplicationStatus{}
+	}
+	status.Role = options.Role
+	status.URL = options.URL
+	return status, nil
+}
+
+func putReplicationStatus(ctx context.Context, etcdClient *etcd.Client, status *admin.ReplicationStatus) error {
+	value, err := proto.Marshal(status)
+	if err != nil {
+		return err
+	}
+	_, err = etcdClient.Put(ctx, replicationKey, string(value))
+	return err
+}
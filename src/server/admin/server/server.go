@@ -0,0 +1,16 @@
+package server
+
+import (
+	"github.com/pachyderm/pachyderm/src/client/admin"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// APIServer represents an admin API server. It also rejects the requests to
+// the other APIs that pachd serves which would change the cluster while it's
+// read-only, its interceptors should be installed in pachd's grpc server.
+type APIServer interface {
+	admin.APIServer
+	UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error)
+	StreamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error
+}
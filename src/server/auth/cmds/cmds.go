@@ -0,0 +1,466 @@
+package cmds
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	authclient "github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pkg/config"
+	"github.com/pachyderm/pachyderm/src/server/pkg/cmdutil"
+	"github.com/spf13/cobra"
+)
+
+const (
+	codestart = "```sh\n\n"
+	codeend   = "\n```"
+)
+
+// defaultContext is the name of the context that activate stores its token
+// in if there's no active context.
+const defaultContext = "default"
+
+// Cmds returns a slice containing auth commands.
+func Cmds(noMetrics *bool) []*cobra.Command {
+	metrics := !*noMetrics
+
+	auth := &cobra.Command{
+		Use:   "auth",
+		Short: "Manage authentication.",
+		Long: `Manage authentication.
+
+Once auth is activated every PFS and PPS request must carry a token, which
+identifies the user making it. pachctl sends the token of the active context
+in its config (see pachctl config).`,
+		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
+			return nil
+		}),
+	}
+
+	activate := &cobra.Command{
+		Use:   "activate username",
+		Short: "Activate auth.",
+		Long: `Activate auth, after which every PFS and PPS request must carry a token.
+
+username is the cluster's first admin (see pachctl auth modify-admins), and
+is given the cluster's first token, which is stored in the active
+context (or in a new context named "default" if there isn't one), so that
+subsequent commands are authenticated.
+
+Examples:
+
+` + codestart + `$ pachctl auth activate alice
+` + codeend,
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			c, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			token, err := c.Activate(args[0])
+			if err != nil {
+				return err
+			}
+			return saveToken(token, c.Addr())
+		}),
+	}
+
+	deactivate := &cobra.Command{
+		Use:   "deactivate",
+		Short: "Deactivate auth.",
+		Long:  "Deactivate auth, which revokes every token and allows requests without one. Only admins can deactivate auth.",
+		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
+			c, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			return c.Deactivate()
+		}),
+	}
+
+	whoami := &cobra.Command{
+		Use:   "whoami",
+		Short: "Print the user that pachctl is authenticated as.",
+		Long:  "Print the user that the active context's token authenticates.",
+		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
+			c, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			username, err := c.WhoAmI()
+			if err != nil {
+				return err
+			}
+			fmt.Println(username)
+			return nil
+		}),
+	}
+
+	var tokenTTL time.Duration
+	getToken := &cobra.Command{
+		Use:   "get-token username",
+		Short: "Issue a token for a user.",
+		Long: `Issue a token for a user and print it.
+
+The user can authenticate with the token by adding it to their context. Only
+admins can issue tokens for other users. The token expires after pachd's
+default TTL (AUTH_TOKEN_TTL, 30 days unless it's configured otherwise) unless
+--ttl is given, only admins can issue tokens with longer TTLs.
+
+Examples:
+
+` + codestart + `# issue a token for bob
+$ pachctl auth get-token bob
+
+# bob then runs
+$ pachctl config set-context prod --auth-token=<token>
+` + codeend,
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			c, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			token, err := c.GetToken(args[0], tokenTTL)
+			if err != nil {
+				return err
+			}
+			fmt.Println(token)
+			return nil
+		}),
+	}
+	getToken.Flags().DurationVar(&tokenTTL, "ttl", 0, "How long the token is valid for (e.g. 24h), pachd's default TTL if this isn't given.")
+
+	var renewTTL time.Duration
+	renewToken := &cobra.Command{
+		Use:   "renew-token",
+		Short: "Renew pachctl's token.",
+		Long: `Renew the token of the active context, so that it's valid for pachd's default
+TTL (or --ttl) from now, and print when it expires. Robot tokens can't be
+renewed.`,
+		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
+			c, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			expiration, err := c.RenewToken(renewTTL)
+			if err != nil {
+				return err
+			}
+			if expiration.IsZero() {
+				fmt.Println("The token doesn't expire.")
+				return nil
+			}
+			fmt.Printf("The token expires at %s.\n", expiration.Format(time.RFC3339))
+			return nil
+		}),
+	}
+	renewToken.Flags().DurationVar(&renewTTL, "ttl", 0, "How long the token is valid for from now (e.g. 24h), pachd's default TTL if this isn't given.")
+
+	revokeToken := &cobra.Command{
+		Use:   "revoke-token [token]",
+		Short: "Revoke a token.",
+		Long: `Revoke a token, e.g. one that may have been stolen, so that it can't be used
+any more. Without a token, the token of the active context is revoked, and
+removed from the context, which logs pachctl out.`,
+		Run: cmdutil.RunBoundedArgs(0, 1, func(args []string) error {
+			c, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			if len(args) == 1 {
+				return c.RevokeToken(args[0])
+			}
+			if err := c.RevokeToken(""); err != nil {
+				return err
+			}
+			return saveToken("", c.Addr())
+		}),
+	}
+
+	revokeUserTokens := &cobra.Command{
+		Use:   "revoke-user-tokens username",
+		Short: "Revoke every token of a user.",
+		Long: `Revoke every token of a user, including robots (e.g. robot:ci), and print how
+many were revoked. Users can revoke their own tokens, admins anyone's.`,
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			c, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			revoked, err := c.RevokeUserTokens(args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Revoked %d tokens of %s.\n", revoked, args[0])
+			return nil
+		}),
+	}
+
+	var provider string
+	login := &cobra.Command{
+		Use:   "login",
+		Short: "Log in to Pachyderm with an identity provider.",
+		Long: `Log in to Pachyderm with an identity provider: GitHub or an OIDC provider
+such as Okta or Azure AD.
+
+pachctl prints the address of the provider's login page. Once you've logged
+in there, the provider sends your browser back to pachctl, which stores a
+Pachyderm token in the active context (or in a new context named "default" if
+there isn't one). Your Pachyderm username is "github:" followed by your GitHub
+login, or "oidc:" followed by your email address.
+
+pachd must be configured with the provider, see the Authentication docs
+(doc/deployment/auth.md). If it's configured with both, OIDC is used unless
+--provider is given.`,
+		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
+			p, ok := authclient.Provider_value[strings.ToUpper(provider)]
+			if !ok {
+				return fmt.Errorf("unknown provider %q, must be github or oidc", provider)
+			}
+			c, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			token, err := providerLogin(c, authclient.Provider(p), os.Stdout)
+			if err != nil {
+				return err
+			}
+			return saveToken(token, c.Addr())
+		}),
+	}
+	login.Flags().StringVar(&provider, "provider", "default", "The identity provider to log in with, github or oidc.")
+
+	getACL := &cobra.Command{
+		Use:   "get-acl repo",
+		Short: "Print a repo's ACL.",
+		Long: `Print a repo's ACL, which lists the users and groups who can access it and
+their scopes.
+
+A repo's ACL is created when it is, with its creator as its OWNER, or when an
+OWNER first sets a scope in it. Repos without an ACL can be accessed by every
+user.`,
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			c, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			acl, err := c.GetACL(args[0])
+			if err != nil {
+				return err
+			}
+			var usernames []string
+			for username := range acl.Entries {
+				usernames = append(usernames, username)
+			}
+			sort.Strings(usernames)
+			for _, username := range usernames {
+				fmt.Printf("%s: %s\n", username, acl.Entries[username])
+			}
+			return nil
+		}),
+	}
+
+	setScope := &cobra.Command{
+		Use:   "set-scope repo username scope",
+		Short: "Set a user's or group's scope in a repo's ACL.",
+		Long: `Set a user's or group's scope in a repo's ACL.
+
+scope is one of:
+  none:   removes username from the ACL
+  reader: can read the repo and use it as a pipeline's input
+  writer: can also write to the repo and manage the pipeline that outputs to it
+  owner:  can also delete the repo and change its ACL
+
+Groups are written "group:" followed by the group's name, as the OIDC
+provider names them in the ID token's groups claim. Changing an ACL requires
+the owner scope, unless the repo doesn't have one yet.
+
+Examples:
+
+` + codestart + `# allow bob to read the repo "data"
+$ pachctl auth set-scope data github:bob reader
+
+# allow everyone in the "data-science" group to write to it
+$ pachctl auth set-scope data group:data-science writer
+` + codeend,
+		Run: cmdutil.RunFixedArgs(3, func(args []string) error {
+			scope, err := parseScope(args[2])
+			if err != nil {
+				return err
+			}
+			c, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			return c.SetScope(args[0], args[1], scope)
+		}),
+	}
+
+	var robotScopes []string
+	var ttl time.Duration
+	getRobotToken := &cobra.Command{
+		Use:   "get-robot-token robot",
+		Short: "Issue a token for a robot.",
+		Long: `Issue a token for a robot, e.g. a CI system, and print it.
+
+A robot's username is "robot:" followed by its name. Unlike users, robots
+only have the scopes that their tokens are issued with, whatever the repos'
+ACLs say, so they can only access the repos given with --scope (a pipeline's
+scope is that of its output repo, which has the pipeline's name). Robot tokens
+don't expire unless --ttl is given. Only admins can issue them.
+
+Examples:
+
+` + codestart + `# allow CI to read the repo "data", and to create and update the pipeline
+# "model"
+$ pachctl auth get-robot-token ci --scope data=reader --scope model=writer
+
+# the CI system then runs
+$ pachctl config set-context ci --auth-token=<token>
+` + codeend,
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			scopes, err := parseRobotScopes(robotScopes)
+			if err != nil {
+				return err
+			}
+			c, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			token, err := c.GetRobotToken(args[0], scopes, ttl)
+			if err != nil {
+				return err
+			}
+			fmt.Println(token)
+			return nil
+		}),
+	}
+	getRobotToken.Flags().StringSliceVar(&robotScopes, "scope", nil, "A repo and the robot's scope in it, written repo=scope (e.g. data=writer), may be given several times.")
+	getRobotToken.Flags().DurationVar(&ttl, "ttl", 0, "How long the token is valid for (e.g. 720h), it doesn't expire if this isn't given.")
+
+	var add []string
+	var remove []string
+	modifyAdmins := &cobra.Command{
+		Use:   "modify-admins",
+		Short: "Add or remove cluster admins.",
+		Long: `Add users to, or remove them from, the cluster's admins.
+
+Admins are the only users who can make destructive, cluster-wide changes:
+deleting everything (pachctl delete-all), deactivating auth, issuing robot
+tokens and modifying the admins. The user who activated auth is the first
+admin. Groups may be admins too, written "group:" followed by the group's
+name, which makes all their members admins. The last admin can't be removed.
+
+Examples:
+
+` + codestart + `# make bob an admin, and stop alice being one
+$ pachctl auth modify-admins --add github:bob --remove github:alice
+
+# make everyone in the "ops" group an admin
+$ pachctl auth modify-admins --add group:ops
+` + codeend,
+		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
+			if len(add) == 0 && len(remove) == 0 {
+				return fmt.Errorf("at least one of --add and --remove must be given")
+			}
+			c, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			return c.ModifyAdmins(add, remove)
+		}),
+	}
+	modifyAdmins.Flags().StringSliceVar(&add, "add", nil, "Users or groups to make admins, may be given several times.")
+	modifyAdmins.Flags().StringSliceVar(&remove, "remove", nil, "Users or groups to stop being admins, may be given several times.")
+
+	listAdmins := &cobra.Command{
+		Use:   "list-admins",
+		Short: "List the cluster's admins.",
+		Long:  "List the users and groups who are the cluster's admins.",
+		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
+			c, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			admins, err := c.GetAdmins()
+			if err != nil {
+				return err
+			}
+			for _, admin := range admins {
+				fmt.Println(admin)
+			}
+			return nil
+		}),
+	}
+
+	auth.AddCommand(activate)
+	auth.AddCommand(deactivate)
+	auth.AddCommand(whoami)
+	auth.AddCommand(getToken)
+	auth.AddCommand(renewToken)
+	auth.AddCommand(revokeToken)
+	auth.AddCommand(revokeUserTokens)
+	auth.AddCommand(getRobotToken)
+	auth.AddCommand(login)
+	auth.AddCommand(getACL)
+	auth.AddCommand(setScope)
+	auth.AddCommand(modifyAdmins)
+	auth.AddCommand(listAdmins)
+	return []*cobra.Command{auth}
+}
+
+// parseScope parses the name of a scope, e.g. "reader".
+func parseScope(name string) (authclient.Scope, error) {
+	scope, ok := authclient.Scope_value[strings.ToUpper(name)]
+	if !ok {
+		return 0, fmt.Errorf("unknown scope %q, must be none, reader, writer or owner", name)
+	}
+	return authclient.Scope(scope), nil
+}
+
+// parseRobotScopes parses the --scope flags of get-robot-token, each of
+// which is written repo=scope.
+func parseRobotScopes(flags []string) (map[string]authclient.Scope, error) {
+	scopes := make(map[string]authclient.Scope)
+	for _, flag := range flags {
+		parts := strings.SplitN(flag, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("malformed scope %q, must be written repo=scope", flag)
+		}
+		scope, err := parseScope(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		scopes[parts[0]] = scope
+	}
+	return scopes, nil
+}
+
+// saveToken stores token in the active context of the user's config. If
+// there's no active context, one named "default" is created for the cluster
+// at addr and made active.
+func saveToken(token string, addr string) error {
+	cfg, err := config.Read()
+	if err != nil {
+		return err
+	}
+	context, err := cfg.CurrentContext()
+	if err != nil {
+		return err
+	}
+	if context == nil {
+		if cfg.Contexts == nil {
+			cfg.Contexts = make(map[string]*config.Context)
+		}
+		context = cfg.Contexts[defaultContext]
+		if context == nil {
+			context = &config.Context{PachdAddress: addr}
+			cfg.Contexts[defaultContext] = context
+		}
+		cfg.ActiveContext = defaultContext
+	}
+	context.AuthToken = token
+	return config.Write(cfg)
+}
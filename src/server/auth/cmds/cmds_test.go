@@ -0,0 +1,24 @@
+package cmds
+
+import (
+	"testing"
+
+	authclient "github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+)
+
+func TestParseRobotScopes(t *testing.T) {
+	scopes, err := parseRobotScopes([]string{"data=reader", "model=WRITER"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]authclient.Scope{
+		"data":  authclient.Scope_READER,
+		"model": authclient.Scope_WRITER,
+	}, scopes)
+
+	_, err = parseRobotScopes([]string{"data"})
+	require.YesError(t, err)
+	_, err = parseRobotScopes([]string{"=reader"})
+	require.YesError(t, err)
+	_, err = parseRobotScopes([]string{"data=admin"})
+	require.YesError(t, err)
+}
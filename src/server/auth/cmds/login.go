@@ -0,0 +1,82 @@
+package cmds
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	authclient "github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pkg/uuid"
+)
+
+// providerNames are the names of the identity providers in messages to the
+// user.
+var providerNames = map[authclient.Provider]string{
+	authclient.Provider_GITHUB: "GitHub",
+	authclient.Provider_OIDC:   "your identity provider",
+}
+
+// providerLogin logs the user in to Pachyderm with provider (the one pachd is
+// configured with if it's authclient.Provider_DEFAULT) and returns their Pachyderm
+// token. It prints the address of the provider's login page to w and serves
+// the page that the provider sends the user's browser to once they've logged
+// in, which carries the code that pachd exchanges for a token.
+func providerLogin(c *client.APIClient, provider authclient.Provider, w io.Writer) (string, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	defer listener.Close()
+	redirectURI := fmt.Sprintf("http://%s/", listener.Addr())
+	state := uuid.NewWithoutDashes()
+	loginURL, provider, err := c.GetOAuthLoginURL(provider, redirectURI, state)
+	if err != nil {
+		return "", err
+	}
+	name := providerNames[provider]
+	fmt.Fprintf(w, "Open this page in your browser to log in with %s:\n\n%s\n\n", name, loginURL)
+
+	type result struct {
+		token string
+		err   error
+	}
+	results := make(chan result, 1)
+	done := func(r result) {
+		select {
+		case results <- r:
+		default:
+		}
+	}
+	go http.Serve(listener, http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		// other requests, e.g. for favicon.ico, don't carry the state
+		if query.Get("state") != state {
+			http.NotFound(rw, r)
+			return
+		}
+		if query.Get("error") != "" {
+			err := fmt.Errorf("logging in with %s failed: %s", name, query.Get("error"))
+			http.Error(rw, err.Error(), http.StatusUnauthorized)
+			done(result{err: err})
+			return
+		}
+		if query.Get("code") == "" {
+			err := fmt.Errorf("%s didn't send a code", name)
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			done(result{err: err})
+			return
+		}
+		token, err := c.Authenticate(provider, query.Get("code"), redirectURI)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusUnauthorized)
+			done(result{err: err})
+			return
+		}
+		fmt.Fprintln(rw, "You're logged in to Pachyderm, you can close this page.")
+		done(result{token: token})
+	}))
+	r := <-results
+	return r.token, r.err
+}
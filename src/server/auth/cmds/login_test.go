@@ -0,0 +1,65 @@
+package cmds
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	authclient "github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// fakeAuth is an authclient.APIClient whose login page "redirects" straight back to
+// the redirect URI, the way a browser would, with the code "code". Its
+// default provider is OIDC.
+type fakeAuth struct {
+	authclient.APIClient
+	t *testing.T
+}
+
+func (f *fakeAuth) GetOAuthLoginURL(ctx context.Context, request *authclient.GetOAuthLoginURLRequest, opts ...grpc.CallOption) (*authclient.GetOAuthLoginURLResponse, error) {
+	go func() {
+		// a request without the state is ignored
+		resp, err := http.Get(request.RedirectURI + "favicon.ico")
+		require.NoError(f.t, err)
+		require.Equal(f.t, http.StatusNotFound, resp.StatusCode)
+		resp, err = http.Get(fmt.Sprintf("%s?state=%s&code=code", request.RedirectURI, request.State))
+		require.NoError(f.t, err)
+		require.Equal(f.t, http.StatusOK, resp.StatusCode)
+	}()
+	if request.Provider == authclient.Provider_GITHUB {
+		return &authclient.GetOAuthLoginURLResponse{URL: "https://github.com/login", Provider: authclient.Provider_GITHUB}, nil
+	}
+	return &authclient.GetOAuthLoginURLResponse{URL: "https://idp.example.com/login", Provider: authclient.Provider_OIDC}, nil
+}
+
+func (f *fakeAuth) Authenticate(ctx context.Context, request *authclient.AuthenticateRequest, opts ...grpc.CallOption) (*authclient.AuthenticateResponse, error) {
+	if request.GitHubCode != "" {
+		require.Equal(f.t, "code", request.GitHubCode)
+		return &authclient.AuthenticateResponse{PachToken: "github-token"}, nil
+	}
+	require.Equal(f.t, "code", request.OIDCCode)
+	return &authclient.AuthenticateResponse{PachToken: "oidc-token"}, nil
+}
+
+func TestGitHubLogin(t *testing.T) {
+	c := &client.APIClient{AuthAPIClient: &fakeAuth{t: t}}
+	var out bytes.Buffer
+	token, err := providerLogin(c, authclient.Provider_GITHUB, &out)
+	require.NoError(t, err)
+	require.Equal(t, "github-token", token)
+	require.True(t, bytes.Contains(out.Bytes(), []byte("https://github.com/login")))
+}
+
+func TestDefaultLogin(t *testing.T) {
+	c := &client.APIClient{AuthAPIClient: &fakeAuth{t: t}}
+	var out bytes.Buffer
+	token, err := providerLogin(c, authclient.Provider_DEFAULT, &out)
+	require.NoError(t, err)
+	require.Equal(t, "oidc-token", token)
+	require.True(t, bytes.Contains(out.Bytes(), []byte("https://idp.example.com/login")))
+}
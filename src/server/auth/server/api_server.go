@@ -0,0 +1,1026 @@
+package server
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	etcd "github.com/coreos/etcd/clientv3"
+	"github.com/gogo/protobuf/types"
+	"github.com/pachyderm/pachyderm/src/client"
+	authclient "github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/uuid"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+	"go.pedge.io/proto/rpclog"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// activationKey is the only key in the activation collection, it exists
+// while auth is active and holds the TokenInfo of the user who activated it.
+const activationKey = "activation"
+
+// adminsKey is the only key in the admins collection, it holds the cluster's
+// Admins while auth is active.
+const adminsKey = "admins"
+
+// internalSubject is the user that the internal token authenticates.
+const internalSubject = "pachd"
+
+// robotSubjectPrefix is the prefix of the usernames of robots, see
+// GetRobotToken.
+const robotSubjectPrefix = "robot:"
+
+// authenticatedServices are the prefixes of the methods whose requests must
+// carry a token once auth is active.
+var authenticatedServices = []string{"/pfs.", "/pps."}
+
+var (
+	errNotSignedIn      = client.Errorf(client.ErrNotSignedIn, "not signed in: auth is active and the request didn't carry a valid token")
+	errNotActivated     = client.Errorf(client.ErrAuthNotActivated, "auth is not activated")
+	errAlreadyActivated = client.Errorf(client.ErrAuthAlreadyActivated, "auth is already activated")
+	errNoSubject        = errors.New("subject must be set")
+	errGitHubDisabled   = errors.New("pachd isn't configured to log in with GitHub")
+	errOIDCDisabled     = errors.New("pachd isn't configured to log in with OIDC")
+	errReservedSubject  = fmt.Errorf("%s is reserved for pachd itself", internalSubject)
+	errNoRepo           = errors.New("repo must be set")
+	errNoRobot          = errors.New("robot must be set")
+	errNegativeTTL      = errors.New("ttl must not be negative")
+	errTokenExpired     = client.Errorf(client.ErrNotSignedIn, "not signed in: the request's token has expired")
+	errRobot            = client.Errorf(client.ErrNotAuthorized, "not authorized: robot tokens can only access the repos they're scoped to")
+	errLastAdmin        = errors.New("the cluster must have at least one admin")
+	errInternalToken    = errors.New("the internal token can't be renewed")
+)
+
+// deleteAllMethod is PFS's DeleteAll, which deletes all ACLs along with the
+// repos.
+const deleteAllMethod = "/pfs.API/DeleteAll"
+
+// adminMethods are the methods that only admins may call, as they make
+// destructive changes to the whole cluster, or read all of its data.
+var adminMethods = []string{deleteAllMethod, "/pps.API/DeleteAll", "/admin.API/Extract", "/admin.API/Restore", "/admin.API/ExportRepo", "/admin.API/ImportRepo", "/admin.API/PromoteReplica", "/admin.API/SetReadOnly", "/admin.API/CreateMirror", "/admin.API/ListMirror", "/admin.API/DeleteMirror"}
+
+type apiServer struct {
+	protorpclog.Logger
+	etcdClient    *etcd.Client
+	internalToken string
+	// tokenTTL is how long the tokens issued to users are valid for by
+	// default, they don't expire if it's 0
+	tokenTTL time.Duration
+	github   *githubClient
+	oidc     *oidcClient
+	// tokens maps the hashes of the tokens that have been issued to their
+	// TokenInfos, so the tokens themselves aren't stored
+	tokens     col.Collection
+	activation col.Collection
+	// acls maps repo names to their ACLs
+	acls   col.Collection
+	admins col.Collection
+	// jobs is PPS's collection of JobInfos, which is read to find the
+	// output repos of the jobs that requests involve
+	jobs col.Collection
+}
+
+func (a *apiServer) Activate(ctx context.Context, request *authclient.ActivateRequest) (response *authclient.ActivateResponse, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	// the response isn't logged, as it holds a token
+	defer func(start time.Time) { a.Log(request, nil, retErr, time.Since(start)) }(time.Now())
+	if err := checkSubject(request.Subject); err != nil {
+		return nil, err
+	}
+	expiration, err := expiresAfter(a.tokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	token := uuid.NewWithoutDashes()
+	if _, err := col.NewSTM(ctx, a.etcdClient, func(stm col.STM) error {
+		if err := a.activation.ReadWrite(stm).Create(activationKey, &authclient.TokenInfo{Subject: request.Subject}); err != nil {
+			if _, ok := err.(col.ErrExists); ok {
+				return errAlreadyActivated
+			}
+			return err
+		}
+		a.admins.ReadWrite(stm).Put(adminsKey, &authclient.Admins{
+			Usernames: map[string]bool{request.Subject: true},
+		})
+		return a.tokens.ReadWrite(stm).Create(hashToken(token), &authclient.TokenInfo{
+			Subject:    request.Subject,
+			Expiration: expiration,
+		})
+	}); err != nil {
+		return nil, err
+	}
+	return &authclient.ActivateResponse{PachToken: token}, nil
+}
+
+func (a *apiServer) Deactivate(ctx context.Context, request *authclient.DeactivateRequest) (response *authclient.DeactivateResponse, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	tokenInfo, err := a.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	isAdmin, err := a.isAdmin(ctx, tokenInfo)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin {
+		return nil, notAdmin(tokenInfo, "deactivate auth")
+	}
+	if _, err := col.NewSTM(ctx, a.etcdClient, func(stm col.STM) error {
+		a.activation.ReadWrite(stm).DeleteAll()
+		a.tokens.ReadWrite(stm).DeleteAll()
+		a.acls.ReadWrite(stm).DeleteAll()
+		a.admins.ReadWrite(stm).DeleteAll()
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return &authclient.DeactivateResponse{}, nil
+}
+
+func (a *apiServer) WhoAmI(ctx context.Context, request *authclient.WhoAmIRequest) (response *authclient.WhoAmIResponse, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	tokenInfo, err := a.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &authclient.WhoAmIResponse{Username: tokenInfo.Subject}, nil
+}
+
+func (a *apiServer) GetToken(ctx context.Context, request *authclient.GetTokenRequest) (response *authclient.GetTokenResponse, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	// the response isn't logged, as it holds a token
+	defer func(start time.Time) { a.Log(request, nil, retErr, time.Since(start)) }(time.Now())
+	tokenInfo, err := a.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tokenInfo.Robot {
+		return nil, errRobot
+	}
+	if err := checkSubject(request.Subject); err != nil {
+		return nil, err
+	}
+	// a token for another user would let its holder act as them
+	if request.Subject != tokenInfo.Subject {
+		isAdmin, err := a.isAdmin(ctx, tokenInfo)
+		if err != nil {
+			return nil, err
+		}
+		if !isAdmin {
+			return nil, notAdmin(tokenInfo, "issue tokens for other users")
+		}
+	}
+	expiration, err := a.requestedExpiration(ctx, tokenInfo, request.TTLSeconds)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.deleteExpiredTokens(ctx, request.Subject); err != nil {
+		return nil, err
+	}
+	token := uuid.NewWithoutDashes()
+	if _, err := col.NewSTM(ctx, a.etcdClient, func(stm col.STM) error {
+		return a.tokens.ReadWrite(stm).Create(hashToken(token), &authclient.TokenInfo{
+			Subject:    request.Subject,
+			Expiration: expiration,
+		})
+	}); err != nil {
+		return nil, err
+	}
+	return &authclient.GetTokenResponse{Token: token}, nil
+}
+
+func (a *apiServer) RenewToken(ctx context.Context, request *authclient.RenewTokenRequest) (response *authclient.RenewTokenResponse, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	tokenInfo, err := a.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tokenInfo.Subject == internalSubject {
+		return nil, errInternalToken
+	}
+	if tokenInfo.Robot {
+		return nil, errRobot
+	}
+	expiration, err := a.requestedExpiration(ctx, tokenInfo, request.TTLSeconds)
+	if err != nil {
+		return nil, err
+	}
+	key := hashToken(client.AuthToken(ctx))
+	if _, err := col.NewSTM(ctx, a.etcdClient, func(stm col.STM) error {
+		tokens := a.tokens.ReadWrite(stm)
+		tokenInfo := &authclient.TokenInfo{}
+		if err := tokens.Get(key, tokenInfo); err != nil {
+			if _, ok := err.(col.ErrNotFound); ok {
+				// the token was revoked since it was authenticated
+				return errNotSignedIn
+			}
+			return err
+		}
+		tokenInfo.Expiration = expiration
+		tokens.Put(key, tokenInfo)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return &authclient.RenewTokenResponse{Expiration: expiration}, nil
+}
+
+func (a *apiServer) RevokeToken(ctx context.Context, request *authclient.RevokeTokenRequest) (response *authclient.RevokeTokenResponse, retErr error) {
+	// the request isn't logged, as it holds a token
+	func() { a.Log(nil, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(nil, response, retErr, time.Since(start)) }(time.Now())
+	if _, err := a.authenticate(ctx); err != nil {
+		return nil, err
+	}
+	// having a token is enough to revoke it
+	token := request.Token
+	if token == "" {
+		token = client.AuthToken(ctx)
+	}
+	if _, err := col.NewSTM(ctx, a.etcdClient, func(stm col.STM) error {
+		if err := a.tokens.ReadWrite(stm).Delete(hashToken(token)); err != nil {
+			if _, ok := err.(col.ErrNotFound); ok {
+				return nil
+			}
+			return err
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return &authclient.RevokeTokenResponse{}, nil
+}
+
+func (a *apiServer) RevokeUserTokens(ctx context.Context, request *authclient.RevokeUserTokensRequest) (response *authclient.RevokeUserTokensResponse, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	tokenInfo, err := a.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSubject(request.Username); err != nil {
+		return nil, err
+	}
+	if request.Username != tokenInfo.Subject {
+		isAdmin, err := a.isAdmin(ctx, tokenInfo)
+		if err != nil {
+			return nil, err
+		}
+		if !isAdmin {
+			return nil, notAdmin(tokenInfo, "revoke other users' tokens")
+		}
+	}
+	keys, err := a.userTokens(ctx, request.Username, false)
+	if err != nil {
+		return nil, err
+	}
+	revoked, err := a.deleteTokens(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	return &authclient.RevokeUserTokensResponse{Revoked: revoked}, nil
+}
+
+func (a *apiServer) GetOAuthLoginURL(ctx context.Context, request *authclient.GetOAuthLoginURLRequest) (response *authclient.GetOAuthLoginURLResponse, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	provider, err := a.provider(request.Provider)
+	if err != nil {
+		return nil, err
+	}
+	response = &authclient.GetOAuthLoginURLResponse{Provider: provider}
+	switch provider {
+	case authclient.Provider_OIDC:
+		if response.URL, err = a.oidc.loginURL(request.RedirectURI, request.State); err != nil {
+			return nil, err
+		}
+	case authclient.Provider_GITHUB:
+		response.URL = a.github.loginURL(request.RedirectURI, request.State)
+	}
+	return response, nil
+}
+
+func (a *apiServer) Authenticate(ctx context.Context, request *authclient.AuthenticateRequest) (response *authclient.AuthenticateResponse, retErr error) {
+	// the request and response aren't logged, as they hold credentials
+	func() { a.Log(nil, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(nil, nil, retErr, time.Since(start)) }(time.Now())
+	provider := authclient.Provider_GITHUB
+	if request.OIDCCode != "" {
+		provider = authclient.Provider_OIDC
+	}
+	if _, err := a.provider(provider); err != nil {
+		return nil, err
+	}
+	if err := a.activation.ReadOnly(ctx).Get(activationKey, &authclient.TokenInfo{}); err != nil {
+		if _, ok := err.(col.ErrNotFound); ok {
+			return nil, errNotActivated
+		}
+		return nil, err
+	}
+	tokenInfo := &authclient.TokenInfo{}
+	switch provider {
+	case authclient.Provider_OIDC:
+		email, groups, err := a.oidc.login(ctx, request.OIDCCode, request.RedirectURI)
+		if err != nil {
+			return nil, err
+		}
+		tokenInfo.Subject = oidcSubjectPrefix + email
+		tokenInfo.Groups = groups
+	case authclient.Provider_GITHUB:
+		login, err := a.github.login(ctx, request.GitHubCode, request.RedirectURI)
+		if err != nil {
+			return nil, err
+		}
+		tokenInfo.Subject = githubSubjectPrefix + login
+	}
+	expiration, err := expiresAfter(a.tokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	tokenInfo.Expiration = expiration
+	if err := a.deleteExpiredTokens(ctx, tokenInfo.Subject); err != nil {
+		return nil, err
+	}
+	token := uuid.NewWithoutDashes()
+	if _, err := col.NewSTM(ctx, a.etcdClient, func(stm col.STM) error {
+		return a.tokens.ReadWrite(stm).Create(hashToken(token), tokenInfo)
+	}); err != nil {
+		return nil, err
+	}
+	return &authclient.AuthenticateResponse{PachToken: token}, nil
+}
+
+func (a *apiServer) GetACL(ctx context.Context, request *authclient.GetACLRequest) (response *authclient.GetACLResponse, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	if _, err := a.authenticate(ctx); err != nil {
+		return nil, err
+	}
+	if request.Repo == "" {
+		return nil, errNoRepo
+	}
+	acl := &authclient.ACL{}
+	if err := a.acls.ReadOnly(ctx).Get(request.Repo, acl); err != nil {
+		if _, ok := err.(col.ErrNotFound); !ok {
+			return nil, err
+		}
+	}
+	return &authclient.GetACLResponse{ACL: acl}, nil
+}
+
+func (a *apiServer) SetScope(ctx context.Context, request *authclient.SetScopeRequest) (response *authclient.SetScopeResponse, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	tokenInfo, err := a.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if request.Repo == "" {
+		return nil, errNoRepo
+	}
+	if request.Username == "" {
+		return nil, errNoSubject
+	}
+	if tokenInfo.Robot {
+		if err := a.checkAccess(ctx, tokenInfo, access{repo: request.Repo, scope: authclient.Scope_OWNER}); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := col.NewSTM(ctx, a.etcdClient, func(stm col.STM) error {
+		acls := a.acls.ReadWrite(stm)
+		acl := &authclient.ACL{}
+		if err := acls.Get(request.Repo, acl); err != nil {
+			if _, ok := err.(col.ErrNotFound); !ok {
+				return err
+			}
+			// whoever gives a repo its ACL owns it
+			acl.Entries = map[string]authclient.Scope{tokenInfo.Subject: authclient.Scope_OWNER}
+		} else if !tokenInfo.Robot && tokenInfo.Subject != internalSubject && scopeOf(acl, tokenInfo) < authclient.Scope_OWNER {
+			return notAuthorized(tokenInfo, access{repo: request.Repo, scope: authclient.Scope_OWNER})
+		}
+		if request.Scope == authclient.Scope_NONE {
+			delete(acl.Entries, request.Username)
+		} else {
+			acl.Entries[request.Username] = request.Scope
+		}
+		acls.Put(request.Repo, acl)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return &authclient.SetScopeResponse{}, nil
+}
+
+func (a *apiServer) GetRobotToken(ctx context.Context, request *authclient.GetRobotTokenRequest) (response *authclient.GetRobotTokenResponse, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	// the response isn't logged, as it holds a token
+	defer func(start time.Time) { a.Log(request, nil, retErr, time.Since(start)) }(time.Now())
+	tokenInfo, err := a.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	isAdmin, err := a.isAdmin(ctx, tokenInfo)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin {
+		return nil, notAdmin(tokenInfo, "issue robot tokens")
+	}
+	if request.Robot == "" {
+		return nil, errNoRobot
+	}
+	if request.TTLSeconds < 0 {
+		return nil, errNegativeTTL
+	}
+	robotInfo := &authclient.TokenInfo{
+		Subject:     robotSubjectPrefix + request.Robot,
+		Robot:       true,
+		RobotScopes: request.Scopes,
+	}
+	if robotInfo.Expiration, err = expiresAfter(time.Duration(request.TTLSeconds) * time.Second); err != nil {
+		return nil, err
+	}
+	if err := a.deleteExpiredTokens(ctx, robotInfo.Subject); err != nil {
+		return nil, err
+	}
+	token := uuid.NewWithoutDashes()
+	if _, err := col.NewSTM(ctx, a.etcdClient, func(stm col.STM) error {
+		return a.tokens.ReadWrite(stm).Create(hashToken(token), robotInfo)
+	}); err != nil {
+		return nil, err
+	}
+	return &authclient.GetRobotTokenResponse{Token: token}, nil
+}
+
+func (a *apiServer) ModifyAdmins(ctx context.Context, request *authclient.ModifyAdminsRequest) (response *authclient.ModifyAdminsResponse, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	tokenInfo, err := a.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	isAdmin, err := a.isAdmin(ctx, tokenInfo)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin {
+		return nil, notAdmin(tokenInfo, "modify the admins")
+	}
+	for _, username := range request.Add {
+		if err := checkSubject(username); err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(username, robotSubjectPrefix) {
+			return nil, fmt.Errorf("%s is a robot, robots can't be admins", username)
+		}
+	}
+	if _, err := col.NewSTM(ctx, a.etcdClient, func(stm col.STM) error {
+		admins := a.admins.ReadWrite(stm)
+		adminsInfo := &authclient.Admins{}
+		if err := admins.Get(adminsKey, adminsInfo); err != nil {
+			return err
+		}
+		if adminsInfo.Usernames == nil {
+			adminsInfo.Usernames = make(map[string]bool)
+		}
+		for _, username := range request.Add {
+			adminsInfo.Usernames[username] = true
+		}
+		for _, username := range request.Remove {
+			delete(adminsInfo.Usernames, username)
+		}
+		if len(adminsInfo.Usernames) == 0 {
+			return errLastAdmin
+		}
+		admins.Put(adminsKey, adminsInfo)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return &authclient.ModifyAdminsResponse{}, nil
+}
+
+func (a *apiServer) GetAdmins(ctx context.Context, request *authclient.GetAdminsRequest) (response *authclient.GetAdminsResponse, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	if _, err := a.authenticate(ctx); err != nil {
+		return nil, err
+	}
+	adminsInfo := &authclient.Admins{}
+	if err := a.admins.ReadOnly(ctx).Get(adminsKey, adminsInfo); err != nil {
+		return nil, err
+	}
+	response = &authclient.GetAdminsResponse{}
+	for username := range adminsInfo.Usernames {
+		response.Admins = append(response.Admins, username)
+	}
+	sort.Strings(response.Admins)
+	return response, nil
+}
+
+func (a *apiServer) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if !isAuthenticated(info.FullMethod) {
+		return handler(ctx, req)
+	}
+	tokenInfo, err := a.checkRequest(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.authorize(ctx, tokenInfo, req); err != nil {
+		return nil, err
+	}
+	resp, err := handler(withSubject(ctx, tokenInfo), req)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.updateACLs(ctx, tokenInfo, info.FullMethod, req); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (a *apiServer) StreamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if !isAuthenticated(info.FullMethod) {
+		return handler(srv, stream)
+	}
+	tokenInfo, err := a.checkRequest(stream.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authorizedStream{
+		ServerStream: stream,
+		ctx:          withSubject(stream.Context(), tokenInfo),
+		apiServer:    a,
+		tokenInfo:    tokenInfo,
+		authorized:   make(map[access]bool),
+	})
+}
+
+// authorizedStream authorizes each request received on a stream, as the
+// interceptor can't see them.
+type authorizedStream struct {
+	grpc.ServerStream
+	ctx       context.Context
+	apiServer *apiServer
+	tokenInfo *authclient.TokenInfo
+	// authorized caches the accesses which have been authorized, so that
+	// e.g. each chunk of a PutFile doesn't read the repo's ACL again
+	authorized map[access]bool
+}
+
+func (s *authorizedStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *authorizedStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	accesses, err := s.apiServer.accesses(s.Context(), m)
+	if err != nil {
+		return err
+	}
+	for _, access := range accesses {
+		if s.authorized[access] {
+			continue
+		}
+		if err := s.apiServer.checkAccess(s.Context(), s.tokenInfo, access); err != nil {
+			return err
+		}
+		s.authorized[access] = true
+	}
+	return nil
+}
+
+// subjectKey is the key of the user that authenticated a request in its
+// context, see Subject.
+type subjectKey struct{}
+
+// Subject returns the user that the request to pachd with context ctx was
+// authenticated as, or "" if auth isn't active. pachd's servers record it as
+// the author of the commits and pipelines they create.
+func Subject(ctx context.Context) string {
+	subject, _ := ctx.Value(subjectKey{}).(string)
+	return subject
+}
+
+// withSubject returns a copy of ctx which carries the user that tokenInfo
+// authenticates, see Subject. tokenInfo is nil if auth isn't active.
+func withSubject(ctx context.Context, tokenInfo *authclient.TokenInfo) context.Context {
+	if tokenInfo == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, subjectKey{}, tokenInfo.Subject)
+}
+
+// checkRequest returns the TokenInfo of the token carried by the request to
+// fullMethod with context ctx, or an error if it doesn't carry a valid one,
+// or if fullMethod is one of adminMethods and the token isn't an admin's.
+// It returns nil if auth isn't active.
+func (a *apiServer) checkRequest(ctx context.Context, fullMethod string) (*authclient.TokenInfo, error) {
+	tokenInfo, err := a.authenticate(ctx)
+	if err == errNotActivated {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, method := range adminMethods {
+		if fullMethod != method {
+			continue
+		}
+		isAdmin, err := a.isAdmin(ctx, tokenInfo)
+		if err != nil {
+			return nil, err
+		}
+		if !isAdmin {
+			return nil, notAdmin(tokenInfo, "call "+fullMethod)
+		}
+	}
+	return tokenInfo, nil
+}
+
+// authorize returns an error if the user that tokenInfo authenticates
+// doesn't have the scopes that req requires (see requiredAccess). tokenInfo
+// is nil if auth isn't active, in which case every request is allowed.
+func (a *apiServer) authorize(ctx context.Context, tokenInfo *authclient.TokenInfo, req interface{}) error {
+	accesses, err := a.accesses(ctx, req)
+	if err != nil {
+		return err
+	}
+	for _, access := range accesses {
+		if err := a.checkAccess(ctx, tokenInfo, access); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// accesses returns the scopes that the user making req must have, which are
+// those of requiredAccess and those of requiredJobAccess in the jobs' output
+// repos. Jobs that don't exist don't require anything, PPS rejects requests
+// for them.
+func (a *apiServer) accesses(ctx context.Context, req interface{}) ([]access, error) {
+	result := requiredAccess(req)
+	for _, jobAccess := range requiredJobAccess(req) {
+		jobInfo := &pps.JobInfo{}
+		if err := a.jobs.ReadOnly(ctx).Get(jobAccess.job, jobInfo); err != nil {
+			if _, ok := err.(col.ErrNotFound); ok {
+				continue
+			}
+			return nil, err
+		}
+		if repo := jobRepo(jobInfo); repo != "" {
+			result = append(result, access{repo: repo, scope: jobAccess.scope})
+		}
+	}
+	return result, nil
+}
+
+// checkAccess returns an error if the user that tokenInfo authenticates
+// doesn't have access.scope in access.repo. Repos without an ACL may be
+// accessed by every user, as may every repo by pachd itself. Robots only
+// have the scopes that their tokens were issued with.
+func (a *apiServer) checkAccess(ctx context.Context, tokenInfo *authclient.TokenInfo, access access) error {
+	if tokenInfo == nil || tokenInfo.Subject == internalSubject {
+		return nil
+	}
+	if tokenInfo.Robot {
+		if tokenInfo.RobotScopes[access.repo] < access.scope {
+			return notAuthorized(tokenInfo, access)
+		}
+		return nil
+	}
+	acl := &authclient.ACL{}
+	if err := a.acls.ReadOnly(ctx).Get(access.repo, acl); err != nil {
+		if _, ok := err.(col.ErrNotFound); ok {
+			return nil
+		}
+		return err
+	}
+	if scopeOf(acl, tokenInfo) < access.scope {
+		return notAuthorized(tokenInfo, access)
+	}
+	return nil
+}
+
+// updateACLs updates the ACLs once req, a request to fullMethod, has
+// succeeded: the user who creates a repo owns it, as does the user who
+// creates a pipeline its output repo if that doesn't have an ACL yet, and
+// deleted repos' ACLs are deleted.
+func (a *apiServer) updateACLs(ctx context.Context, tokenInfo *authclient.TokenInfo, fullMethod string, req interface{}) error {
+	var created string
+	var pipeline string
+	var deleted string
+	switch req := req.(type) {
+	case *pfs.CreateRepoRequest:
+		created = repoName(req.Repo)
+	case *pps.CreatePipelineRequest:
+		pipeline = pipelineRepo(req.Pipeline)
+	case *pfs.DeleteRepoRequest:
+		deleted = repoName(req.Repo)
+	}
+	if tokenInfo == nil || tokenInfo.Subject == internalSubject {
+		// repos created while auth is inactive, and those that pachd
+		// creates for pipelines, don't have an owner
+		created, pipeline = "", ""
+	}
+	if created == "" && pipeline == "" && deleted == "" && fullMethod != deleteAllMethod {
+		return nil
+	}
+	_, err := col.NewSTM(ctx, a.etcdClient, func(stm col.STM) error {
+		acls := a.acls.ReadWrite(stm)
+		if fullMethod == deleteAllMethod {
+			acls.DeleteAll()
+		}
+		if deleted != "" {
+			if err := acls.Delete(deleted); err != nil {
+				if _, ok := err.(col.ErrNotFound); !ok {
+					return err
+				}
+			}
+		}
+		owner := &authclient.ACL{}
+		if tokenInfo != nil {
+			owner.Entries = map[string]authclient.Scope{tokenInfo.Subject: authclient.Scope_OWNER}
+		}
+		if created != "" {
+			// this replaces the ACL of an earlier repo with the same name
+			// that was deleted while auth was inactive
+			acls.Put(created, owner)
+		}
+		if pipeline != "" {
+			if err := acls.Create(pipeline, owner); err != nil {
+				if _, ok := err.(col.ErrExists); !ok {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	return err
+}
+
+// authenticate returns the TokenInfo of the token carried by the request
+// with context ctx. It fails if auth isn't active, unless the token is the
+// internal token.
+func (a *apiServer) authenticate(ctx context.Context) (*authclient.TokenInfo, error) {
+	token := client.AuthToken(ctx)
+	if token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(a.internalToken)) == 1 {
+		return &authclient.TokenInfo{Subject: internalSubject}, nil
+	}
+	if err := a.activation.ReadOnly(ctx).Get(activationKey, &authclient.TokenInfo{}); err != nil {
+		if _, ok := err.(col.ErrNotFound); ok {
+			return nil, errNotActivated
+		}
+		return nil, err
+	}
+	if token == "" {
+		return nil, errNotSignedIn
+	}
+	tokenInfo := &authclient.TokenInfo{}
+	if err := a.tokens.ReadOnly(ctx).Get(hashToken(token), tokenInfo); err != nil {
+		if _, ok := err.(col.ErrNotFound); ok {
+			return nil, errNotSignedIn
+		}
+		return nil, err
+	}
+	expired, err := isExpired(tokenInfo)
+	if err != nil {
+		return nil, err
+	}
+	if expired {
+		return nil, errTokenExpired
+	}
+	return tokenInfo, nil
+}
+
+// isExpired returns true if tokenInfo's token has expired.
+func isExpired(tokenInfo *authclient.TokenInfo) (bool, error) {
+	if tokenInfo.Expiration == nil {
+		return false, nil
+	}
+	expiration, err := types.TimestampFromProto(tokenInfo.Expiration)
+	if err != nil {
+		return false, err
+	}
+	return time.Now().After(expiration), nil
+}
+
+// userTokens returns the keys of subject's tokens, or only of those that
+// have expired if expiredOnly is set.
+func (a *apiServer) userTokens(ctx context.Context, subject string, expiredOnly bool) ([]string, error) {
+	iter, err := a.tokens.ReadOnly(ctx).GetByIndex(tokensSubjectIndex, subject)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for {
+		var key string
+		tokenInfo := &authclient.TokenInfo{}
+		ok, err := iter.Next(&key, tokenInfo)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return keys, nil
+		}
+		if expiredOnly {
+			expired, err := isExpired(tokenInfo)
+			if err != nil {
+				return nil, err
+			}
+			if !expired {
+				continue
+			}
+		}
+		keys = append(keys, key)
+	}
+}
+
+// tokensPerTxn is how many tokens deleteTokens deletes in each transaction,
+// deleting a token deletes both it and its entry in the subject index.
+const tokensPerTxn = col.MaxTxnOps / 2
+
+// deleteTokens deletes the tokens with keys, tokensPerTxn at a time so that
+// users with many tokens don't exceed etcd's limit on the size of a
+// transaction, and returns how many it deleted. Tokens that were already
+// deleted are skipped.
+func (a *apiServer) deleteTokens(ctx context.Context, keys []string) (int64, error) {
+	var deleted int64
+	for len(keys) > 0 {
+		batch := keys
+		if len(batch) > tokensPerTxn {
+			batch = batch[:tokensPerTxn]
+		}
+		keys = keys[len(batch):]
+		var batchDeleted int64
+		if _, err := col.NewSTM(ctx, a.etcdClient, func(stm col.STM) error {
+			batchDeleted = 0
+			tokens := a.tokens.ReadWrite(stm)
+			for _, key := range batch {
+				if err := tokens.Delete(key); err != nil {
+					if _, ok := err.(col.ErrNotFound); ok {
+						continue
+					}
+					return err
+				}
+				batchDeleted++
+			}
+			return nil
+		}); err != nil {
+			return deleted, err
+		}
+		deleted += batchDeleted
+	}
+	return deleted, nil
+}
+
+// deleteExpiredTokens deletes subject's expired tokens. It's called whenever
+// subject is issued a new token, so that tokens that are never used again
+// don't pile up in etcd.
+func (a *apiServer) deleteExpiredTokens(ctx context.Context, subject string) error {
+	keys, err := a.userTokens(ctx, subject, true)
+	if err != nil {
+		return err
+	}
+	_, err = a.deleteTokens(ctx, keys)
+	return err
+}
+
+// isAdmin returns true if the user that tokenInfo authenticates is an admin,
+// either themselves or through one of their groups. pachd itself is always
+// an admin, robots never are.
+func (a *apiServer) isAdmin(ctx context.Context, tokenInfo *authclient.TokenInfo) (bool, error) {
+	if tokenInfo.Subject == internalSubject {
+		return true, nil
+	}
+	if tokenInfo.Robot {
+		return false, nil
+	}
+	adminsInfo := &authclient.Admins{}
+	if err := a.admins.ReadOnly(ctx).Get(adminsKey, adminsInfo); err != nil {
+		return false, err
+	}
+	return isAdminIn(adminsInfo, tokenInfo), nil
+}
+
+func isAdminIn(adminsInfo *authclient.Admins, tokenInfo *authclient.TokenInfo) bool {
+	if adminsInfo.Usernames[tokenInfo.Subject] {
+		return true
+	}
+	for _, group := range tokenInfo.Groups {
+		if adminsInfo.Usernames[groupPrefix+group] {
+			return true
+		}
+	}
+	return false
+}
+
+// requestedExpiration returns when a token requested by the user that
+// tokenInfo authenticates with a TTL of ttlSeconds expires. A TTL of 0 means
+// a.tokenTTL, and only admins may request longer TTLs than that.
+func (a *apiServer) requestedExpiration(ctx context.Context, tokenInfo *authclient.TokenInfo, ttlSeconds int64) (*types.Timestamp, error) {
+	if ttlSeconds < 0 {
+		return nil, errNegativeTTL
+	}
+	ttl := time.Duration(ttlSeconds) * time.Second
+	if ttl == 0 {
+		ttl = a.tokenTTL
+	}
+	if a.tokenTTL > 0 && (ttl == 0 || ttl > a.tokenTTL) {
+		isAdmin, err := a.isAdmin(ctx, tokenInfo)
+		if err != nil {
+			return nil, err
+		}
+		if !isAdmin {
+			return nil, notAdmin(tokenInfo, fmt.Sprintf("request tokens valid for longer than %s", a.tokenTTL))
+		}
+	}
+	return expiresAfter(ttl)
+}
+
+// expiresAfter returns when a token issued now with a TTL of ttl expires, or
+// nil if ttl is 0 and it doesn't.
+func expiresAfter(ttl time.Duration) (*types.Timestamp, error) {
+	if ttl == 0 {
+		return nil, nil
+	}
+	return types.TimestampProto(time.Now().Add(ttl))
+}
+
+// provider returns the identity provider that logins with provider use,
+// resolving DEFAULT, or an error if pachd isn't configured to use it.
+func (a *apiServer) provider(provider authclient.Provider) (authclient.Provider, error) {
+	switch provider {
+	case authclient.Provider_DEFAULT:
+		if a.oidc.enabled() {
+			return authclient.Provider_OIDC, nil
+		}
+		if a.github.enabled() {
+			return authclient.Provider_GITHUB, nil
+		}
+		return 0, errors.New("pachd isn't configured with an identity provider to log in with")
+	case authclient.Provider_GITHUB:
+		if !a.github.enabled() {
+			return 0, errGitHubDisabled
+		}
+	case authclient.Provider_OIDC:
+		if !a.oidc.enabled() {
+			return 0, errOIDCDisabled
+		}
+	default:
+		return 0, fmt.Errorf("unknown identity provider %v", provider)
+	}
+	return provider, nil
+}
+
+func notAuthorized(tokenInfo *authclient.TokenInfo, access access) error {
+	return client.Errorf(client.ErrNotAuthorized, "not authorized: %s doesn't have the %s scope in repo %s", tokenInfo.Subject, access.scope, access.repo)
+}
+
+func notAdmin(tokenInfo *authclient.TokenInfo, what string) error {
+	return client.Errorf(client.ErrNotAuthorized, "not authorized: %s isn't an admin, only admins can %s", tokenInfo.Subject, what)
+}
+
+func checkSubject(subject string) error {
+	switch subject {
+	case "":
+		return errNoSubject
+	case internalSubject:
+		return errReservedSubject
+	}
+	return nil
+}
+
+// isAuthenticated returns true if requests to fullMethod must carry a token
+// once auth is active.
+func isAuthenticated(fullMethod string) bool {
+	for _, prefix := range authenticatedServices {
+		if strings.HasPrefix(fullMethod, prefix) {
+			return true
+		}
+	}
+	for _, method := range adminMethods {
+		if fullMethod == method {
+			return true
+		}
+	}
+	return false
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	authclient "github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	"golang.org/x/net/context"
+)
+
+func TestIsAuthenticated(t *testing.T) {
+	require.True(t, isAuthenticated("/pfs.API/DeleteAll"))
+	require.True(t, isAuthenticated("/pfs.ObjectAPI/GetObject"))
+	require.True(t, isAuthenticated("/pps.API/CreatePipeline"))
+	require.False(t, isAuthenticated("/auth.API/Activate"))
+	require.False(t, isAuthenticated("/admin.API/InspectCluster"))
+	require.True(t, isAuthenticated("/admin.API/Extract"))
+	require.True(t, isAuthenticated("/admin.API/CreateMirror"))
+	require.False(t, isAuthenticated("/versionpb.API/GetVersion"))
+	require.False(t, isAuthenticated("/health.Health/Health"))
+}
+
+func TestHashToken(t *testing.T) {
+	require.Equal(t, hashToken("abc"), hashToken("abc"))
+	require.NotEqual(t, hashToken("abc"), hashToken("abd"))
+	require.NotEqual(t, "abc", hashToken("abc"))
+}
+
+func TestRobotAccess(t *testing.T) {
+	// robots' scopes don't depend on ACLs, so this doesn't need etcd
+	a := &apiServer{}
+	robot := &authclient.TokenInfo{
+		Subject: "robot:ci",
+		Robot:   true,
+		RobotScopes: map[string]authclient.Scope{
+			"data":  authclient.Scope_READER,
+			"model": authclient.Scope_WRITER,
+		},
+	}
+	ctx := context.Background()
+	require.NoError(t, a.checkAccess(ctx, robot, access{"data", authclient.Scope_READER}))
+	require.YesError(t, a.checkAccess(ctx, robot, access{"data", authclient.Scope_WRITER}))
+	require.NoError(t, a.checkAccess(ctx, robot, access{"model", authclient.Scope_WRITER}))
+	require.YesError(t, a.checkAccess(ctx, robot, access{"model", authclient.Scope_OWNER}))
+	require.YesError(t, a.checkAccess(ctx, robot, access{"other", authclient.Scope_READER}))
+}
+
+func TestIsAdminIn(t *testing.T) {
+	adminsInfo := &authclient.Admins{
+		Usernames: map[string]bool{
+			"alice":     true,
+			"group:ops": true,
+		},
+	}
+	require.True(t, isAdminIn(adminsInfo, &authclient.TokenInfo{Subject: "alice"}))
+	require.False(t, isAdminIn(adminsInfo, &authclient.TokenInfo{Subject: "bob"}))
+	require.True(t, isAdminIn(adminsInfo, &authclient.TokenInfo{Subject: "bob", Groups: []string{"dev", "ops"}}))
+	// a user named like a group isn't in it
+	require.False(t, isAdminIn(adminsInfo, &authclient.TokenInfo{Subject: "ops"}))
+}
+
+func TestRequestedExpiration(t *testing.T) {
+	// pachd is an admin and robots never are, so this doesn't need etcd
+	a := &apiServer{tokenTTL: time.Hour}
+	ctx := context.Background()
+	admin := &authclient.TokenInfo{Subject: internalSubject}
+	robot := &authclient.TokenInfo{Subject: "robot:ci", Robot: true}
+	expiresIn := func(tokenInfo *authclient.TokenInfo, ttlSeconds int64) time.Duration {
+		expiration, err := a.requestedExpiration(ctx, tokenInfo, ttlSeconds)
+		require.NoError(t, err)
+		expirationTime, err := types.TimestampFromProto(expiration)
+		require.NoError(t, err)
+		return expirationTime.Sub(time.Now())
+	}
+	require.True(t, expiresIn(robot, 0) > 59*time.Minute)
+	require.True(t, expiresIn(robot, 0) <= time.Hour)
+	require.True(t, expiresIn(robot, 60) <= time.Minute)
+	require.True(t, expiresIn(admin, 7200) > time.Hour)
+	// only admins may exceed the default TTL
+	_, err := a.requestedExpiration(ctx, robot, 7200)
+	require.YesError(t, err)
+	_, err = a.requestedExpiration(ctx, robot, -1)
+	require.YesError(t, err)
+
+	// without a default TTL tokens don't expire unless they're given one
+	a = &apiServer{}
+	expiration, err := a.requestedExpiration(ctx, robot, 0)
+	require.NoError(t, err)
+	require.Nil(t, expiration)
+	require.True(t, expiresIn(robot, 7200) > time.Hour)
+}
+
+func TestIsExpired(t *testing.T) {
+	expiresAt := func(at time.Time) *authclient.TokenInfo {
+		expiration, err := types.TimestampProto(at)
+		require.NoError(t, err)
+		return &authclient.TokenInfo{Subject: "alice", Expiration: expiration}
+	}
+	for _, c := range []struct {
+		tokenInfo *authclient.TokenInfo
+		expired   bool
+	}{
+		{&authclient.TokenInfo{Subject: "alice"}, false},
+		{expiresAt(time.Now().Add(time.Hour)), false},
+		{expiresAt(time.Now().Add(-time.Second)), true},
+	} {
+		expired, err := isExpired(c.tokenInfo)
+		require.NoError(t, err)
+		require.Equal(t, c.expired, expired)
+	}
+}
+
+func TestSubject(t *testing.T) {
+	ctx := context.Background()
+	require.Equal(t, "", Subject(ctx))
+	// requests made while auth is inactive don't have a subject
+	require.Equal(t, "", Subject(withSubject(ctx, nil)))
+	require.Equal(t, "alice", Subject(withSubject(ctx, &authclient.TokenInfo{Subject: "alice"})))
+}
@@ -0,0 +1,281 @@
+package server
+
+import (
+	authclient "github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+	ppsserver "github.com/pachyderm/pachyderm/src/server/pps"
+)
+
+// access is the scope that a request requires in a repo.
+type access struct {
+	repo  string
+	scope authclient.Scope
+}
+
+// jobAccess is the scope that a request requires in the output repo of a
+// job, which is only known once the job has been read (see
+// apiServer.accesses).
+type jobAccess struct {
+	job   string
+	scope authclient.Scope
+}
+
+// requiredAccess returns the scopes that the user making req must have in
+// the repos it involves. Requests that don't involve particular repos (e.g.
+// ListRepo) require none. Requests for jobs are checked against the jobs'
+// output repos, see requiredJobAccess.
+func requiredAccess(req interface{}) []access {
+	var result []access
+	add := func(scope authclient.Scope, repos ...string) {
+		for _, repo := range repos {
+			if repo != "" {
+				result = append(result, access{repo: repo, scope: scope})
+			}
+		}
+	}
+	switch req := req.(type) {
+	// PFS
+	case *pfs.CreateRepoRequest:
+		for _, repo := range req.Provenance {
+			add(authclient.Scope_READER, repoName(repo))
+		}
+	case *pfs.InspectRepoRequest:
+		add(authclient.Scope_READER, repoName(req.Repo))
+	case *pfs.UpdateRepoRequest:
+		add(authclient.Scope_WRITER, repoName(req.Repo))
+	case *pfs.DeleteRepoRequest:
+		add(authclient.Scope_OWNER, repoName(req.Repo))
+	case *pfs.StartCommitRequest:
+		if req.Force {
+			// forced commits bypass the protection of output repos
+			add(authclient.Scope_OWNER, commitRepo(req.Parent))
+		} else {
+			add(authclient.Scope_WRITER, commitRepo(req.Parent))
+		}
+	case *pfs.BuildCommitRequest:
+		add(authclient.Scope_WRITER, commitRepo(req.Parent))
+	case *pfs.FinishCommitRequest:
+		add(authclient.Scope_WRITER, commitRepo(req.Commit))
+	case *pfs.FinishCommitsRequest:
+		for _, commit := range req.Commits {
+			add(authclient.Scope_WRITER, commitRepo(commit))
+		}
+		for _, file := range req.DeleteFiles {
+			add(authclient.Scope_WRITER, fileRepo(file))
+		}
+	case *pfs.DeleteCommitRequest:
+		add(authclient.Scope_WRITER, commitRepo(req.Commit))
+	case *pfs.InspectCommitRequest:
+		add(authclient.Scope_READER, commitRepo(req.Commit))
+	case *pfs.ListCommitRequest:
+		add(authclient.Scope_READER, repoName(req.Repo))
+	case *pfs.FlushCommitRequest:
+		for _, commit := range req.Commits {
+			add(authclient.Scope_READER, commitRepo(commit))
+		}
+		for _, repo := range req.ToRepos {
+			add(authclient.Scope_READER, repoName(repo))
+		}
+	case *pfs.FlushCommitsRequest:
+		for _, flush := range req.Flushes {
+			result = append(result, requiredAccess(flush)...)
+		}
+	case *pfs.SubscribeCommitRequest:
+		add(authclient.Scope_READER, repoName(req.Repo))
+	case *pfs.ListBranchRequest:
+		add(authclient.Scope_READER, repoName(req.Repo))
+	case *pfs.SetBranchRequest:
+		add(authclient.Scope_WRITER, commitRepo(req.Commit))
+	case *pfs.DeleteBranchRequest:
+		add(authclient.Scope_WRITER, repoName(req.Repo))
+	case *pfs.SetBranchTriggerRequest:
+		// the trigger moves the branch
+		add(authclient.Scope_WRITER, repoName(req.Repo))
+	case *pfs.SetBranchRetentionRequest:
+		// a retention policy deletes the branch's old commits
+		add(authclient.Scope_OWNER, repoName(req.Repo))
+	case *pfs.PutFileRequest:
+		add(authclient.Scope_WRITER, fileRepo(req.File))
+	case *pfs.GetFileRequest:
+		add(authclient.Scope_READER, fileRepo(req.File))
+	case *pfs.GetFilesRequest:
+		for _, file := range req.Files {
+			add(authclient.Scope_READER, fileRepo(file))
+		}
+	case *pfs.GetFileRangesRequest:
+		for _, getFile := range req.Ranges {
+			result = append(result, requiredAccess(getFile)...)
+		}
+	case *pfs.GetFileURLRequest:
+		// the URL lets anyone who has it read the file
+		add(authclient.Scope_READER, fileRepo(req.File))
+	case *pfs.InspectFileRequest:
+		add(authclient.Scope_READER, fileRepo(req.File))
+	case *pfs.ListFileRequest:
+		add(authclient.Scope_READER, fileRepo(req.File))
+	case *pfs.GlobFileRequest:
+		add(authclient.Scope_READER, commitRepo(req.Commit))
+	case *pfs.DeleteFileRequest:
+		add(authclient.Scope_WRITER, fileRepo(req.File))
+	case *pfs.DeleteFilesRequest:
+		add(authclient.Scope_WRITER, commitRepo(req.Commit))
+	// PPS
+	case *pps.CreatePipelineRequest:
+		add(authclient.Scope_READER, inputRepos(req.Input)...)
+		for _, input := range req.Inputs {
+			add(authclient.Scope_READER, repoName(input.Repo))
+		}
+		// the output repo may already exist, in which case the pipeline
+		// writes to it, and so may the repos of its SQL inputs
+		add(authclient.Scope_WRITER, pipelineRepo(req.Pipeline))
+		add(authclient.Scope_WRITER, sqlInputRepos(req.Pipeline, req.Input)...)
+	case *pps.CreateJobRequest:
+		add(authclient.Scope_READER, inputRepos(req.Input)...)
+		for _, input := range req.Inputs {
+			add(authclient.Scope_READER, commitRepo(input.Commit))
+		}
+		add(authclient.Scope_WRITER, pipelineRepo(req.Pipeline))
+	case *pps.DeletePipelineRequest:
+		add(authclient.Scope_WRITER, pipelineRepo(req.Pipeline))
+	case *pps.StartPipelineRequest:
+		add(authclient.Scope_WRITER, pipelineRepo(req.Pipeline))
+	case *pps.StopPipelineRequest:
+		add(authclient.Scope_WRITER, pipelineRepo(req.Pipeline))
+	case *pps.RerunPipelineRequest:
+		add(authclient.Scope_WRITER, pipelineRepo(req.Pipeline))
+	case *pps.ClearDatumCacheRequest:
+		add(authclient.Scope_WRITER, pipelineRepo(req.Pipeline))
+	case *pps.RunPipelineRequest:
+		add(authclient.Scope_WRITER, pipelineRepo(req.Pipeline))
+	case *pps.TriggerPipelineRequest:
+		add(authclient.Scope_WRITER, pipelineRepo(req.Pipeline))
+	case *pps.GetLogsRequest:
+		add(authclient.Scope_READER, pipelineRepo(req.Pipeline))
+	case *pps.RunTransactionRequest:
+		// PPS applies the ops as pachd, so each needs the access that it
+		// would on its own
+		for _, op := range req.Ops {
+			switch {
+			case op.StartCommit != nil:
+				result = append(result, requiredAccess(op.StartCommit)...)
+			case op.FinishCommit != nil:
+				result = append(result, requiredAccess(op.FinishCommit)...)
+			case op.DeleteFile != nil:
+				result = append(result, requiredAccess(op.DeleteFile)...)
+			case op.CreatePipeline != nil:
+				result = append(result, requiredAccess(op.CreatePipeline)...)
+			}
+		}
+	}
+	return result
+}
+
+// requiredJobAccess returns the scopes that the user making req must have in
+// the output repos of the jobs it involves. Like their pipelines, jobs are
+// stopped and deleted, and their datums restarted, by their output repos'
+// writers, and their logs and datums are read by its readers.
+func requiredJobAccess(req interface{}) []jobAccess {
+	var result []jobAccess
+	add := func(scope authclient.Scope, job *pps.Job) {
+		if job != nil && job.ID != "" {
+			result = append(result, jobAccess{job: job.ID, scope: scope})
+		}
+	}
+	switch req := req.(type) {
+	case *pps.StopJobRequest:
+		add(authclient.Scope_WRITER, req.Job)
+	case *pps.DeleteJobRequest:
+		add(authclient.Scope_WRITER, req.Job)
+	case *pps.RestartDatumRequest:
+		add(authclient.Scope_WRITER, req.Job)
+	case *pps.GetLogsRequest:
+		add(authclient.Scope_READER, req.Job)
+	case *pps.ListDatumRequest:
+		add(authclient.Scope_READER, req.Job)
+	case *pps.InspectDatumRequest:
+		add(authclient.Scope_READER, req.Job)
+	}
+	return result
+}
+
+// scopeOf returns the scope that the user tokenInfo authenticates has in
+// acl, which is the highest of their own scope and those of their groups.
+func scopeOf(acl *authclient.ACL, tokenInfo *authclient.TokenInfo) authclient.Scope {
+	scope := acl.Entries[tokenInfo.Subject]
+	for _, group := range tokenInfo.Groups {
+		if groupScope := acl.Entries[groupPrefix+group]; groupScope > scope {
+			scope = groupScope
+		}
+	}
+	return scope
+}
+
+func inputRepos(input *pps.Input) []string {
+	switch {
+	case input == nil:
+		return nil
+	case input.Atom != nil:
+		return []string{input.Atom.Repo}
+	}
+	var result []string
+	for _, input := range append(input.Cross, input.Union...) {
+		result = append(result, inputRepos(input)...)
+	}
+	return result
+}
+
+// sqlInputRepos returns the repos that the SQL inputs in input commit their
+// snapshots to.
+func sqlInputRepos(pipeline *pps.Pipeline, input *pps.Input) []string {
+	switch {
+	case input == nil:
+		return nil
+	case input.SQL != nil:
+		if pipeline == nil {
+			return nil
+		}
+		return []string{ppsserver.SQLInputRepo(pipeline, input.SQL).Name}
+	}
+	var result []string
+	for _, input := range append(input.Cross, input.Union...) {
+		result = append(result, sqlInputRepos(pipeline, input)...)
+	}
+	return result
+}
+
+func repoName(repo *pfs.Repo) string {
+	if repo == nil {
+		return ""
+	}
+	return repo.Name
+}
+
+func commitRepo(commit *pfs.Commit) string {
+	if commit == nil {
+		return ""
+	}
+	return repoName(commit.Repo)
+}
+
+func fileRepo(file *pfs.File) string {
+	if file == nil {
+		return ""
+	}
+	return commitRepo(file.Commit)
+}
+
+func pipelineRepo(pipeline *pps.Pipeline) string {
+	if pipeline == nil {
+		return ""
+	}
+	return ppsserver.PipelineRepo(pipeline).Name
+}
+
+// jobRepo returns the output repo of the job jobInfo describes.
+func jobRepo(jobInfo *pps.JobInfo) string {
+	if jobInfo.OutputRepo != nil {
+		return jobInfo.OutputRepo.Name
+	}
+	return pipelineRepo(jobInfo.Pipeline)
+}
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"testing"
+
+	authclient "github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+)
+
+func TestRequiredAccess(t *testing.T) {
+	commit := &pfs.Commit{Repo: &pfs.Repo{Name: "data"}, ID: "master"}
+	require.Equal(t, []access{{"data", authclient.Scope_READER}},
+		requiredAccess(&pfs.GetFileRequest{File: &pfs.File{Commit: commit, Path: "file"}}))
+	// files of a batch may be in different repos
+	require.Equal(t, []access{
+		{"data", authclient.Scope_READER},
+		{"labels", authclient.Scope_READER},
+	}, requiredAccess(&pfs.GetFilesRequest{Files: []*pfs.File{
+		{Commit: commit, Path: "file"},
+		{Commit: &pfs.Commit{Repo: &pfs.Repo{Name: "labels"}, ID: "master"}, Path: "file"},
+	}}))
+	require.Equal(t, []access{
+		{"data", authclient.Scope_READER},
+		{"labels", authclient.Scope_READER},
+	}, requiredAccess(&pfs.GetFileRangesRequest{Ranges: []*pfs.GetFileRequest{
+		{File: &pfs.File{Commit: commit, Path: "file"}},
+		{File: &pfs.File{Commit: &pfs.Commit{Repo: &pfs.Repo{Name: "labels"}, ID: "master"}, Path: "file"}, OffsetBytes: 10},
+	}}))
+	// each flush of a batch needs the access it would on its own
+	require.Equal(t, []access{
+		{"data", authclient.Scope_READER},
+		{"model", authclient.Scope_READER},
+		{"labels", authclient.Scope_READER},
+	}, requiredAccess(&pfs.FlushCommitsRequest{Flushes: []*pfs.FlushCommitRequest{
+		{Commits: []*pfs.Commit{commit}, ToRepos: []*pfs.Repo{{Name: "model"}}},
+		{Commits: []*pfs.Commit{{Repo: &pfs.Repo{Name: "labels"}, ID: "master"}}},
+	}}))
+	require.Equal(t, []access{{"data", authclient.Scope_WRITER}},
+		requiredAccess(&pfs.PutFileRequest{File: &pfs.File{Commit: commit, Path: "file"}}))
+	require.Equal(t, []access{{"data", authclient.Scope_WRITER}},
+		requiredAccess(&pfs.UpdateRepoRequest{Repo: &pfs.Repo{Name: "data"}, Description: "raw data"}))
+	require.Equal(t, []access{{"data", authclient.Scope_OWNER}},
+		requiredAccess(&pfs.DeleteRepoRequest{Repo: &pfs.Repo{Name: "data"}}))
+	require.Equal(t, []access{{"data", authclient.Scope_WRITER}},
+		requiredAccess(&pfs.SetBranchTriggerRequest{Repo: &pfs.Repo{Name: "data"}, Branch: "staging"}))
+	require.Equal(t, []access{{"data", authclient.Scope_OWNER}},
+		requiredAccess(&pfs.SetBranchRetentionRequest{Repo: &pfs.Repo{Name: "data"}, Branch: "master"}))
+	// forcing a commit into an output repo needs more than writing to it
+	require.Equal(t, []access{{"data", authclient.Scope_WRITER}},
+		requiredAccess(&pfs.StartCommitRequest{Parent: commit}))
+	require.Equal(t, []access{{"data", authclient.Scope_OWNER}},
+		requiredAccess(&pfs.StartCommitRequest{Parent: commit, Force: true}))
+	// a commit without a parent doesn't involve a repo, the request is
+	// rejected by PFS
+	require.Equal(t, 0, len(requiredAccess(&pfs.StartCommitRequest{})))
+	require.Equal(t, 0, len(requiredAccess(&pfs.ListRepoRequest{})))
+
+	require.Equal(t, []access{
+		{"data", authclient.Scope_READER},
+		{"labels", authclient.Scope_READER},
+		{"model", authclient.Scope_WRITER},
+	}, requiredAccess(&pps.CreatePipelineRequest{
+		Pipeline: &pps.Pipeline{Name: "model"},
+		Input: &pps.Input{Cross: []*pps.Input{
+			{Atom: &pps.AtomInput{Repo: "data"}},
+			{Atom: &pps.AtomInput{Repo: "labels"}},
+		}},
+	}))
+	// clearing the cache makes the pipeline rewrite its output
+	require.Equal(t, []access{{"model", authclient.Scope_WRITER}},
+		requiredAccess(&pps.ClearDatumCacheRequest{Pipeline: &pps.Pipeline{Name: "model"}}))
+
+	// logs may be read by pipeline, by job or both
+	require.Equal(t, []access{{"model", authclient.Scope_READER}},
+		requiredAccess(&pps.GetLogsRequest{Pipeline: &pps.Pipeline{Name: "model"}}))
+	require.Equal(t, 0, len(requiredAccess(&pps.StopJobRequest{Job: &pps.Job{ID: "abc"}})))
+
+	// a transaction needs the access of each of its ops
+	require.Equal(t, []access{
+		{"data", authclient.Scope_WRITER},
+		{"labels", authclient.Scope_WRITER},
+	}, requiredAccess(&pps.RunTransactionRequest{Ops: []*pps.TransactionOp{
+		{FinishCommit: &pfs.FinishCommitRequest{Commit: commit}},
+		{DeleteFile: &pfs.DeleteFileRequest{File: &pfs.File{
+			Commit: &pfs.Commit{Repo: &pfs.Repo{Name: "labels"}, ID: "master"},
+			Path:   "file",
+		}}},
+	}}))
+}
+
+func TestRequiredJobAccess(t *testing.T) {
+	job := &pps.Job{ID: "abc"}
+	// jobs are changed by their output repos' writers, like pipelines
+	require.Equal(t, []jobAccess{{"abc", authclient.Scope_WRITER}},
+		requiredJobAccess(&pps.StopJobRequest{Job: job}))
+	require.Equal(t, []jobAccess{{"abc", authclient.Scope_WRITER}},
+		requiredJobAccess(&pps.DeleteJobRequest{Job: job}))
+	require.Equal(t, []jobAccess{{"abc", authclient.Scope_WRITER}},
+		requiredJobAccess(&pps.RestartDatumRequest{Job: job, DataFilters: []string{"file"}}))
+	// and read by their readers
+	require.Equal(t, []jobAccess{{"abc", authclient.Scope_READER}},
+		requiredJobAccess(&pps.GetLogsRequest{Job: job}))
+	require.Equal(t, []jobAccess{{"abc", authclient.Scope_READER}},
+		requiredJobAccess(&pps.ListDatumRequest{Job: job}))
+	require.Equal(t, []jobAccess{{"abc", authclient.Scope_READER}},
+		requiredJobAccess(&pps.InspectDatumRequest{Job: job, ID: "datum"}))
+	require.Equal(t, 0, len(requiredJobAccess(&pps.GetLogsRequest{Pipeline: &pps.Pipeline{Name: "model"}})))
+	require.Equal(t, 0, len(requiredJobAccess(&pfs.GetFileRequest{})))
+}
+
+func TestJobRepo(t *testing.T) {
+	require.Equal(t, "model", jobRepo(&pps.JobInfo{Pipeline: &pps.Pipeline{Name: "model"}}))
+	// orphan jobs (see CreateJob) output to their own repos
+	require.Equal(t, "job_abc", jobRepo(&pps.JobInfo{OutputRepo: &pfs.Repo{Name: "job_abc"}}))
+}
+
+func TestScopeOf(t *testing.T) {
+	acl := &authclient.ACL{Entries: map[string]authclient.Scope{
+		"oidc:alice@example.com": authclient.Scope_READER,
+		"group:data-science":     authclient.Scope_WRITER,
+		"group:admins":           authclient.Scope_OWNER,
+	}}
+	require.Equal(t, authclient.Scope_READER, scopeOf(acl, &authclient.TokenInfo{Subject: "oidc:alice@example.com"}))
+	require.Equal(t, authclient.Scope_WRITER, scopeOf(acl, &authclient.TokenInfo{
+		Subject: "oidc:alice@example.com",
+		Groups:  []string{"data-science"},
+	}))
+	require.Equal(t, authclient.Scope_OWNER, scopeOf(acl, &authclient.TokenInfo{
+		Subject: "oidc:bob@example.com",
+		Groups:  []string{"data-science", "admins"},
+	}))
+	require.Equal(t, authclient.Scope_NONE, scopeOf(acl, &authclient.TokenInfo{Subject: "github:alice"}))
+}
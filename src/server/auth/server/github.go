@@ -0,0 +1,118 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+// githubSubjectPrefix is the prefix of the usernames of users who log in
+// with GitHub, it keeps them apart from the users created with Activate and
+// GetToken.
+const githubSubjectPrefix = "github:"
+
+// GitHubOptions configure logging in to Pachyderm with GitHub. ClientID and
+// ClientSecret are those of a GitHub OAuth app, if they aren't set users
+// can't log in with GitHub. If Organization is set only its members can log
+// in, otherwise every GitHub user can.
+type GitHubOptions struct {
+	ClientID     string
+	ClientSecret string
+	Organization string
+}
+
+var githubEndpoint = oauth2.Endpoint{
+	AuthURL:  "https://github.com/login/oauth/authorize",
+	TokenURL: "https://github.com/login/oauth/access_token",
+}
+
+const githubAPIURL = "https://api.github.com"
+
+// githubClient logs users in with GitHub. endpoint and apiURL are GitHub's,
+// except in tests.
+type githubClient struct {
+	options  GitHubOptions
+	endpoint oauth2.Endpoint
+	apiURL   string
+}
+
+func newGitHubClient(options GitHubOptions) *githubClient {
+	return &githubClient{
+		options:  options,
+		endpoint: githubEndpoint,
+		apiURL:   githubAPIURL,
+	}
+}
+
+func (g *githubClient) enabled() bool {
+	return g.options.ClientID != "" && g.options.ClientSecret != ""
+}
+
+func (g *githubClient) config(redirectURI string) *oauth2.Config {
+	config := &oauth2.Config{
+		ClientID:     g.options.ClientID,
+		ClientSecret: g.options.ClientSecret,
+		Endpoint:     g.endpoint,
+		RedirectURL:  redirectURI,
+	}
+	if g.options.Organization != "" {
+		// needed to read the user's organization membership
+		config.Scopes = []string{"read:org"}
+	}
+	return config
+}
+
+// loginURL returns the page where the user logs in to GitHub, after which
+// GitHub sends them to redirectURI with a code for login.
+func (g *githubClient) loginURL(redirectURI string, state string) string {
+	return g.config(redirectURI).AuthCodeURL(state)
+}
+
+// login exchanges code for a GitHub access token and returns the login of
+// the GitHub user that it belongs to. It fails if the user isn't a member of
+// the organization.
+func (g *githubClient) login(ctx context.Context, code string, redirectURI string) (string, error) {
+	token, err := g.config(redirectURI).Exchange(ctx, code)
+	if err != nil {
+		return "", fmt.Errorf("error logging in with GitHub: %v", err)
+	}
+	client := g.config(redirectURI).Client(ctx, token)
+	var user struct {
+		Login string `json:"login"`
+	}
+	if err := g.get(client, "/user", &user); err != nil {
+		return "", err
+	}
+	if user.Login == "" {
+		return "", fmt.Errorf("GitHub didn't return the user's login")
+	}
+	if g.options.Organization == "" {
+		return user.Login, nil
+	}
+	var membership struct {
+		State string `json:"state"`
+	}
+	if err := g.get(client, path.Join("/user/memberships/orgs", url.PathEscape(g.options.Organization)), &membership); err != nil || membership.State != "active" {
+		return "", fmt.Errorf("GitHub user %s isn't a member of the %s organization", user.Login, g.options.Organization)
+	}
+	return user.Login, nil
+}
+
+// get decodes the JSON response to a GET of GitHub's API at path into v.
+func (g *githubClient) get(client *http.Client, path string, v interface{}) error {
+	resp, err := client.Get(strings.TrimSuffix(g.apiURL, "/") + path)
+	if err != nil {
+		return fmt.Errorf("error calling GitHub: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GitHub returned %s for %s", resp.Status, path)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
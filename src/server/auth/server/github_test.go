@@ -0,0 +1,95 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+// fakeGitHub serves the parts of GitHub's OAuth flow and API that
+// githubClient uses. The code "good" is exchanged for a token of the user
+// alice, who is a member of the organization pachyderm.
+func fakeGitHub(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		w.Header().Set("Content-Type", "application/json")
+		if r.Form.Get("code") != "good" {
+			fmt.Fprint(w, `{"error": "bad_verification_code"}`)
+			return
+		}
+		fmt.Fprint(w, `{"access_token": "alice-token", "token_type": "bearer"}`)
+	})
+	authenticated := func(handler http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer alice-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			handler(w, r)
+		}
+	}
+	mux.HandleFunc("/user", authenticated(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"login": "alice"}`)
+	}))
+	mux.HandleFunc("/user/memberships/orgs/pachyderm", authenticated(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"state": "active"}`)
+	}))
+	return httptest.NewServer(mux)
+}
+
+func testGitHubClient(server *httptest.Server, organization string) *githubClient {
+	return &githubClient{
+		options: GitHubOptions{
+			ClientID:     "id",
+			ClientSecret: "secret",
+			Organization: organization,
+		},
+		endpoint: oauth2.Endpoint{
+			AuthURL:  server.URL + "/login/oauth/authorize",
+			TokenURL: server.URL + "/login/oauth/access_token",
+		},
+		apiURL: server.URL,
+	}
+}
+
+func TestGitHubLogin(t *testing.T) {
+	server := fakeGitHub(t)
+	defer server.Close()
+
+	g := testGitHubClient(server, "")
+	login, err := g.login(context.Background(), "good", "http://localhost:1234/")
+	require.NoError(t, err)
+	require.Equal(t, "alice", login)
+	_, err = g.login(context.Background(), "bad", "http://localhost:1234/")
+	require.YesError(t, err)
+
+	// alice is a member of pachyderm, but not of other
+	g = testGitHubClient(server, "pachyderm")
+	login, err = g.login(context.Background(), "good", "http://localhost:1234/")
+	require.NoError(t, err)
+	require.Equal(t, "alice", login)
+	g = testGitHubClient(server, "other")
+	_, err = g.login(context.Background(), "good", "http://localhost:1234/")
+	require.YesError(t, err)
+}
+
+func TestGitHubLoginURL(t *testing.T) {
+	g := newGitHubClient(GitHubOptions{ClientID: "id", ClientSecret: "secret", Organization: "pachyderm"})
+	require.True(t, g.enabled())
+	loginURL, err := url.Parse(g.loginURL("http://localhost:1234/", "state"))
+	require.NoError(t, err)
+	require.Equal(t, "github.com", loginURL.Host)
+	require.Equal(t, "id", loginURL.Query().Get("client_id"))
+	require.Equal(t, "http://localhost:1234/", loginURL.Query().Get("redirect_uri"))
+	require.Equal(t, "state", loginURL.Query().Get("state"))
+	require.Equal(t, "read:org", loginURL.Query().Get("scope"))
+
+	require.False(t, newGitHubClient(GitHubOptions{}).enabled())
+}
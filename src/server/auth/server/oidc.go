@@ -0,0 +1,296 @@
+package server
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/jws"
+)
+
+// oidcSubjectPrefix is the prefix of the usernames of users who log in with
+// OIDC.
+const oidcSubjectPrefix = "oidc:"
+
+// oidcTimeout bounds the requests that pachd makes to the OIDC provider, as
+// the provider's configuration and keys are fetched while holding
+// oidcClient.mu, which every login waits for.
+const oidcTimeout = 30 * time.Second
+
+// groupPrefix is the prefix of the groups in ACLs, which distinguishes them
+// from users.
+const groupPrefix = "group:"
+
+// OIDCOptions configure logging in to Pachyderm with an OpenID Connect
+// provider (e.g. Okta or Azure AD). Issuer is the provider's URL, which it
+// publishes its configuration under, and ClientID and ClientSecret are those
+// of the client registered with it for pachd. If they aren't set users can't
+// log in with OIDC.
+type OIDCOptions struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// GroupsClaim is the claim of the ID token which lists the groups that
+	// the user is in, it's "groups" if it isn't set.
+	GroupsClaim string
+	// Scopes are requested in addition to "openid" and "email", some
+	// providers only include the user's groups in the ID token if a scope
+	// such as "groups" is requested.
+	Scopes []string
+}
+
+// oidcProvider is the configuration that an OIDC provider publishes.
+type oidcProvider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcClient logs users in with an OIDC provider. The provider's
+// configuration and signing keys are fetched when they're first needed.
+type oidcClient struct {
+	options    OIDCOptions
+	httpClient *http.Client
+
+	mu       sync.Mutex
+	provider *oidcProvider
+	// keys are the provider's keys, by key ID
+	keys map[string]*rsa.PublicKey
+}
+
+func newOIDCClient(options OIDCOptions) *oidcClient {
+	if options.GroupsClaim == "" {
+		options.GroupsClaim = "groups"
+	}
+	return &oidcClient{
+		options:    options,
+		httpClient: &http.Client{Timeout: oidcTimeout},
+	}
+}
+
+func (o *oidcClient) enabled() bool {
+	return o.options.Issuer != "" && o.options.ClientID != "" && o.options.ClientSecret != ""
+}
+
+func (o *oidcClient) getProvider() (*oidcProvider, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.provider != nil {
+		return o.provider, nil
+	}
+	provider := &oidcProvider{}
+	if err := o.getJSON(strings.TrimSuffix(o.options.Issuer, "/")+"/.well-known/openid-configuration", provider); err != nil {
+		return nil, err
+	}
+	if provider.Issuer != o.options.Issuer {
+		return nil, fmt.Errorf("OIDC provider's issuer is %q, not %q", provider.Issuer, o.options.Issuer)
+	}
+	o.provider = provider
+	return provider, nil
+}
+
+func (o *oidcClient) config(provider *oidcProvider, redirectURI string) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     o.options.ClientID,
+		ClientSecret: o.options.ClientSecret,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  provider.AuthorizationEndpoint,
+			TokenURL: provider.TokenEndpoint,
+		},
+		RedirectURL: redirectURI,
+		Scopes:      append([]string{"openid", "email"}, o.options.Scopes...),
+	}
+}
+
+// loginURL returns the page where the user logs in to the provider, after
+// which the provider sends them to redirectURI with a code for login.
+func (o *oidcClient) loginURL(redirectURI string, state string) (string, error) {
+	provider, err := o.getProvider()
+	if err != nil {
+		return "", err
+	}
+	return o.config(provider, redirectURI).AuthCodeURL(state), nil
+}
+
+// login exchanges code for an ID token, and returns the email address of the
+// user it identifies and the groups they're in. Tokens whose email addresses
+// the provider hasn't verified are rejected, as some providers let users set
+// any address, including another user's.
+func (o *oidcClient) login(ctx context.Context, code string, redirectURI string) (string, []string, error) {
+	provider, err := o.getProvider()
+	if err != nil {
+		return "", nil, err
+	}
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, o.httpClient)
+	token, err := o.config(provider, redirectURI).Exchange(ctx, code)
+	if err != nil {
+		return "", nil, fmt.Errorf("error logging in with OIDC: %v", err)
+	}
+	idToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return "", nil, fmt.Errorf("OIDC provider didn't return an ID token")
+	}
+	claims, err := o.verify(provider, idToken)
+	if err != nil {
+		return "", nil, err
+	}
+	email, _ := claims["email"].(string)
+	if email == "" {
+		return "", nil, fmt.Errorf("OIDC provider's ID token doesn't have an email claim")
+	}
+	if !emailVerified(claims["email_verified"]) {
+		return "", nil, fmt.Errorf("OIDC provider hasn't verified the email address %s", email)
+	}
+	var groups []string
+	if values, ok := claims[o.options.GroupsClaim].([]interface{}); ok {
+		for _, value := range values {
+			if group, ok := value.(string); ok {
+				groups = append(groups, group)
+			}
+		}
+	}
+	return email, groups, nil
+}
+
+// verify checks idToken's signature and that it was issued by the provider
+// to pachd and hasn't expired, and returns its claims.
+func (o *oidcClient) verify(provider *oidcProvider, idToken string) (map[string]interface{}, error) {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed ID token")
+	}
+	var header struct {
+		Algorithm string `json:"alg"`
+		KeyID     string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	if header.Algorithm != "RS256" {
+		return nil, fmt.Errorf("ID token is signed with %s, only RS256 is supported", header.Algorithm)
+	}
+	key, err := o.getKey(provider, header.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := jws.Verify(idToken, key); err != nil {
+		return nil, fmt.Errorf("invalid ID token: %v", err)
+	}
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	if claims["iss"] != provider.Issuer {
+		return nil, fmt.Errorf("ID token was issued by %v, not %s", claims["iss"], provider.Issuer)
+	}
+	if !hasAudience(claims["aud"], o.options.ClientID) {
+		return nil, fmt.Errorf("ID token wasn't issued to %s", o.options.ClientID)
+	}
+	if exp, ok := claims["exp"].(float64); !ok || time.Unix(int64(exp), 0).Before(time.Now()) {
+		return nil, fmt.Errorf("ID token has expired")
+	}
+	return claims, nil
+}
+
+// getKey returns the provider's key with ID keyID, refetching the provider's
+// keys if it isn't known, as providers rotate them.
+func (o *oidcClient) getKey(provider *oidcProvider, keyID string) (*rsa.PublicKey, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if key, ok := o.keys[keyID]; ok {
+		return key, nil
+	}
+	var jwks struct {
+		Keys []struct {
+			KeyID string `json:"kid"`
+			Type  string `json:"kty"`
+			N     string `json:"n"`
+			E     string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := o.getJSON(provider.JWKSURI, &jwks); err != nil {
+		return nil, err
+	}
+	o.keys = make(map[string]*rsa.PublicKey)
+	for _, jwk := range jwks.Keys {
+		if jwk.Type != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return nil, fmt.Errorf("malformed key %s: %v", jwk.KeyID, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			return nil, fmt.Errorf("malformed key %s: %v", jwk.KeyID, err)
+		}
+		o.keys[jwk.KeyID] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	key, ok := o.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("OIDC provider doesn't have key %q", keyID)
+	}
+	return key, nil
+}
+
+// emailVerified returns false if verified, an ID token's "email_verified"
+// claim, says that its email address isn't verified. Providers that don't
+// send the claim only issue tokens for verified addresses, and some send it
+// as a string.
+func emailVerified(verified interface{}) bool {
+	switch verified := verified.(type) {
+	case bool:
+		return verified
+	case string:
+		return verified != "false"
+	}
+	return true
+}
+
+// hasAudience returns true if aud, an ID token's "aud" claim (which is either
+// a string or a list of them), includes clientID.
+func hasAudience(aud interface{}, clientID string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == clientID
+	case []interface{}:
+		for _, a := range aud {
+			if a == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+	if err != nil {
+		return fmt.Errorf("malformed ID token: %v", err)
+	}
+	return json.Unmarshal(b, v)
+}
+
+func (o *oidcClient) getJSON(url string, v interface{}) error {
+	resp, err := o.httpClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
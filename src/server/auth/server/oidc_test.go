@@ -0,0 +1,204 @@
+package server
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	"golang.org/x/net/context"
+)
+
+// fakeOIDC serves the parts of an OIDC provider that oidcClient uses. The
+// code "good" is exchanged for an ID token with claims, which is signed with
+// key.
+func fakeOIDC(t *testing.T, key *rsa.PrivateKey, claims func(issuer string) map[string]interface{}) *httptest.Server {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 server.URL,
+			"authorization_endpoint": server.URL + "/authorize",
+			"token_endpoint":         server.URL + "/token",
+			"jwks_uri":               server.URL + "/keys",
+		}))
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kid": "key",
+				"kty": "RSA",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		}))
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		w.Header().Set("Content-Type", "application/json")
+		if r.Form.Get("code") != "good" {
+			fmt.Fprint(w, `{"error": "invalid_grant"}`)
+			return
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "alice-token",
+			"token_type":   "bearer",
+			"id_token":     signIDToken(t, key, claims(server.URL)),
+		}))
+	})
+	return server
+}
+
+func signIDToken(t *testing.T, key *rsa.PrivateKey, claims map[string]interface{}) string {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "kid": "key", "typ": "JWT"})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	sum := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	require.NoError(t, err)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func aliceClaims(issuer string) map[string]interface{} {
+	return map[string]interface{}{
+		"iss":    issuer,
+		"aud":    []string{"id", "other"},
+		"exp":    time.Now().Add(time.Hour).Unix(),
+		"sub":    "1234",
+		"email":  "alice@example.com",
+		"groups": []string{"data-science", "admins"},
+	}
+}
+
+func TestOIDCLogin(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	server := fakeOIDC(t, key, aliceClaims)
+	defer server.Close()
+
+	o := newOIDCClient(OIDCOptions{Issuer: server.URL, ClientID: "id", ClientSecret: "secret"})
+	require.True(t, o.enabled())
+	email, groups, err := o.login(context.Background(), "good", "http://localhost:1234/")
+	require.NoError(t, err)
+	require.Equal(t, "alice@example.com", email)
+	require.Equal(t, []string{"data-science", "admins"}, groups)
+	_, _, err = o.login(context.Background(), "bad", "http://localhost:1234/")
+	require.YesError(t, err)
+
+	// the groups are read from the configured claim
+	o = newOIDCClient(OIDCOptions{Issuer: server.URL, ClientID: "id", ClientSecret: "secret", GroupsClaim: "roles"})
+	_, groups, err = o.login(context.Background(), "good", "http://localhost:1234/")
+	require.NoError(t, err)
+	require.Equal(t, 0, len(groups))
+
+	// the ID token wasn't issued to other clients
+	o = newOIDCClient(OIDCOptions{Issuer: server.URL, ClientID: "someone-else", ClientSecret: "secret"})
+	_, _, err = o.login(context.Background(), "good", "http://localhost:1234/")
+	require.YesError(t, err)
+
+	require.False(t, newOIDCClient(OIDCOptions{}).enabled())
+}
+
+func TestOIDCInvalidIDToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	expired := fakeOIDC(t, key, func(issuer string) map[string]interface{} {
+		claims := aliceClaims(issuer)
+		claims["exp"] = time.Now().Add(-time.Minute).Unix()
+		return claims
+	})
+	defer expired.Close()
+	o := newOIDCClient(OIDCOptions{Issuer: expired.URL, ClientID: "id", ClientSecret: "secret"})
+	_, _, err = o.login(context.Background(), "good", "http://localhost:1234/")
+	require.YesError(t, err)
+
+	wrongIssuer := fakeOIDC(t, key, func(issuer string) map[string]interface{} {
+		claims := aliceClaims(issuer)
+		claims["iss"] = "https://evil.example.com"
+		return claims
+	})
+	defer wrongIssuer.Close()
+	o = newOIDCClient(OIDCOptions{Issuer: wrongIssuer.URL, ClientID: "id", ClientSecret: "secret"})
+	_, _, err = o.login(context.Background(), "good", "http://localhost:1234/")
+	require.YesError(t, err)
+
+	// so are tokens for addresses that the provider hasn't verified, which
+	// anyone might have set
+	for _, verified := range []interface{}{false, "false"} {
+		unverified := fakeOIDC(t, key, func(issuer string) map[string]interface{} {
+			claims := aliceClaims(issuer)
+			claims["email_verified"] = verified
+			return claims
+		})
+		defer unverified.Close()
+		o = newOIDCClient(OIDCOptions{Issuer: unverified.URL, ClientID: "id", ClientSecret: "secret"})
+		_, _, err = o.login(context.Background(), "good", "http://localhost:1234/")
+		require.YesError(t, err)
+	}
+	verified := fakeOIDC(t, key, func(issuer string) map[string]interface{} {
+		claims := aliceClaims(issuer)
+		claims["email_verified"] = true
+		return claims
+	})
+	defer verified.Close()
+	o = newOIDCClient(OIDCOptions{Issuer: verified.URL, ClientID: "id", ClientSecret: "secret"})
+	_, _, err = o.login(context.Background(), "good", "http://localhost:1234/")
+	require.NoError(t, err)
+
+	// an ID token signed with a key other than the provider's is rejected
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	server := fakeOIDC(t, key, aliceClaims)
+	defer server.Close()
+	o = newOIDCClient(OIDCOptions{Issuer: server.URL, ClientID: "id", ClientSecret: "secret"})
+	provider, err := o.getProvider()
+	require.NoError(t, err)
+	_, err = o.verify(provider, signIDToken(t, otherKey, aliceClaims(server.URL)))
+	require.YesError(t, err)
+	_, err = o.verify(provider, signIDToken(t, key, aliceClaims(server.URL)))
+	require.NoError(t, err)
+}
+
+func TestOIDCLoginURL(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	server := fakeOIDC(t, key, aliceClaims)
+	defer server.Close()
+
+	o := newOIDCClient(OIDCOptions{Issuer: server.URL, ClientID: "id", ClientSecret: "secret", Scopes: []string{"groups"}})
+	rawURL, err := o.loginURL("http://localhost:1234/", "state")
+	require.NoError(t, err)
+	loginURL, err := url.Parse(rawURL)
+	require.NoError(t, err)
+	require.Equal(t, "/authorize", loginURL.Path)
+	require.Equal(t, "id", loginURL.Query().Get("client_id"))
+	require.Equal(t, "http://localhost:1234/", loginURL.Query().Get("redirect_uri"))
+	require.Equal(t, "state", loginURL.Query().Get("state"))
+	require.Equal(t, "openid email groups", loginURL.Query().Get("scope"))
+}
+
+func TestOIDCTimeout(t *testing.T) {
+	// a provider that doesn't respond doesn't block logins forever
+	hung := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-hung
+	}))
+	defer server.Close()
+	defer close(hung)
+	o := newOIDCClient(OIDCOptions{Issuer: server.URL, ClientID: "id", ClientSecret: "secret"})
+	o.httpClient.Timeout = 100 * time.Millisecond
+	_, err := o.loginURL("http://localhost:1234/", "state")
+	require.YesError(t, err)
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	etcd "github.com/coreos/etcd/clientv3"
+	authclient "github.com/pachyderm/pachyderm/src/client/auth"
+	"github.com/pachyderm/pachyderm/src/client/pkg/uuid"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+	ppsserver "github.com/pachyderm/pachyderm/src/server/pps"
+	"go.pedge.io/proto/rpclog"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// APIServer represents an auth API server. It also authenticates the
+// requests made to the other APIs that pachd serves, its interceptors should
+// be installed in pachd's grpc server.
+type APIServer interface {
+	authclient.APIServer
+	UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error)
+	StreamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error
+}
+
+const (
+	tokensPrefix     = "/tokens"
+	activationPrefix = "/activation"
+	aclsPrefix       = "/acls"
+	adminsPrefix     = "/admins"
+	internalTokenKey = "/internal-token"
+)
+
+// tokensSubjectIndex indexes tokens by the user they authenticate, so that
+// they can all be revoked.
+var tokensSubjectIndex = col.Index{Field: "Subject"}
+
+// NewAPIServer creates an APIServer which keeps its state in etcd, under
+// etcdPrefix, and reads PPS's jobs from under ppsEtcdPrefix. internalToken is the token pachd and its workers send with
+// their requests to pachd (see InternalToken), they're allowed whether or not
+// auth is active. Users' tokens are valid for tokenTTL unless they're
+// renewed, or forever if it's 0. githubOptions and oidcOptions configure
+// logging in with GitHub and with an OIDC provider. If cipher isn't nil,
+// tokens, ACLs and the other auth state are encrypted with it in etcd.
+func NewAPIServer(etcdConfig etcd.Config, etcdPrefix string, ppsEtcdPrefix string, internalToken string, tokenTTL time.Duration, githubOptions GitHubOptions, oidcOptions OIDCOptions, cipher *col.Cipher) (APIServer, error) {
+	etcdClient, err := etcd.New(etcdConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &apiServer{
+		Logger:        protorpclog.NewLogger("auth.API"),
+		etcdClient:    etcdClient,
+		internalToken: internalToken,
+		tokenTTL:      tokenTTL,
+		github:        newGitHubClient(githubOptions),
+		oidc:          newOIDCClient(oidcOptions),
+		tokens: col.NewEncryptedCollection(
+			etcdClient,
+			path.Join(etcdPrefix, tokensPrefix),
+			[]col.Index{tokensSubjectIndex},
+			&authclient.TokenInfo{},
+			cipher,
+		),
+		activation: col.NewEncryptedCollection(
+			etcdClient,
+			path.Join(etcdPrefix, activationPrefix),
+			nil,
+			&authclient.TokenInfo{},
+			cipher,
+		),
+		acls: col.NewEncryptedCollection(
+			etcdClient,
+			path.Join(etcdPrefix, aclsPrefix),
+			nil,
+			&authclient.ACL{},
+			cipher,
+		),
+		admins: col.NewEncryptedCollection(
+			etcdClient,
+			path.Join(etcdPrefix, adminsPrefix),
+			nil,
+			&authclient.Admins{},
+			cipher,
+		),
+		jobs: col.NewEncryptedCollection(
+			etcdClient,
+			path.Join(ppsEtcdPrefix, ppsserver.JobsPrefix),
+			nil,
+			&pps.JobInfo{},
+			cipher,
+		),
+	}, nil
+}
+
+// InternalToken returns the token that pachd and its workers authenticate
+// their requests to pachd with, creating it if it doesn't exist yet. It's
+// kept in etcd, under etcdPrefix, so that every pachd and worker uses the
+// same one. If cipher isn't nil, the token is encrypted with it in etcd.
+func InternalToken(etcdClient *etcd.Client, etcdPrefix string, cipher *col.Cipher) (string, error) {
+	key := path.Join(etcdPrefix, internalTokenKey)
+	ctx := context.Background()
+	// if several pachds start at once only the first one's token is stored
+	if _, err := etcdClient.Txn(ctx).If(
+		etcd.Compare(etcd.CreateRevision(key), "=", 0),
+	).Then(
+		etcd.OpPut(key, cipher.Encrypt(uuid.NewWithoutDashes())),
+	).Commit(); err != nil {
+		return "", err
+	}
+	resp, err := etcdClient.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Kvs) != 1 {
+		return "", fmt.Errorf("internal token not found at %s", key)
+	}
+	return cipher.Decrypt(string(resp.Kvs[0].Value))
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/server/pfs/gitremote"
+	"github.com/pachyderm/pachyderm/src/server/pkg/cmdutil"
+)
+
+type appEnv struct {
+	// GitDir is set by git, when it runs the helper
+	GitDir string `env:"GIT_DIR,required"`
+}
+
+// git-remote-pfs is run by git, as `git-remote-pfs <remote> <url>`, to fetch
+// from remotes whose URLs are pfs::<repo> or pfs://<address>/<repo>, see
+// package gitremote.
+func main() {
+	cmdutil.Main(do, &appEnv{})
+}
+
+func do(appEnvObj interface{}) error {
+	appEnv := appEnvObj.(*appEnv)
+	if len(os.Args) != 3 {
+		return fmt.Errorf("usage: %s <remote> <url>", os.Args[0])
+	}
+	remote, err := gitremote.ParseURL(os.Args[2])
+	if err != nil {
+		return err
+	}
+	var c *client.APIClient
+	if remote.Address != "" {
+		c, err = client.NewFromAddress(remote.Address)
+	} else {
+		c, err = client.NewOnUserMachine(false, "git-remote-pfs")
+	}
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	return gitremote.NewHelper(c, remote.Repo, os.Args[1], appEnv.GitDir).Run(os.Stdin, os.Stdout)
+}
@@ -14,9 +14,7 @@ func main() {
 }
 
 func do(appEnvObj interface{}) error {
-	// passing empty addresses for pfsd and ppsd, that's fine because we're not
-	// going to execute the command but print docs with it
-	rootCmd, err := cmd.PachctlCmd("")
+	rootCmd, err := cmd.PachctlCmd()
 	if err != nil {
 		return err
 	}
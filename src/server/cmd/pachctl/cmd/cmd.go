@@ -1,20 +1,21 @@
 package cmd
 
 import (
-	"bufio"
 	"bytes"
 	"errors"
 	"fmt"
 	"io"
 	"os"
-	"strings"
 	"text/tabwriter"
 	"time"
 
 	"github.com/gogo/protobuf/types"
 	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pkg/config"
 	"github.com/pachyderm/pachyderm/src/client/version"
 	"github.com/pachyderm/pachyderm/src/client/version/versionpb"
+	admincmds "github.com/pachyderm/pachyderm/src/server/admin/cmds"
+	authcmds "github.com/pachyderm/pachyderm/src/server/auth/cmds"
 	pfscmds "github.com/pachyderm/pachyderm/src/server/pfs/cmds"
 	"github.com/pachyderm/pachyderm/src/server/pkg/cmdutil"
 	deploycmds "github.com/pachyderm/pachyderm/src/server/pkg/deploy/cmds"
@@ -29,9 +30,9 @@ import (
 	"google.golang.org/grpc/grpclog"
 )
 
-// PachctlCmd takes a pachd host-address and creates a cobra.Command
-// which may interact with the host.
-func PachctlCmd(address string) (*cobra.Command, error) {
+// PachctlCmd creates a cobra.Command which may interact with the pachd of
+// the active context.
+func PachctlCmd() (*cobra.Command, error) {
 	var verbose bool
 	var noMetrics bool
 	rootCmd := &cobra.Command{
@@ -39,7 +40,8 @@ func PachctlCmd(address string) (*cobra.Command, error) {
 		Long: `Access the Pachyderm API.
 
 Environment variables:
-  ADDRESS=<host>:<port>, the pachd server to connect to (e.g. 127.0.0.1:30650).
+  ADDRESS=<host>:<port>, the pachd server to connect to (e.g. 127.0.0.1:30650),
+  overrides the pachd address of the active context (see pachctl config).
 `,
 		PersistentPreRun: func(cmd *cobra.Command, args []string) {
 			if !verbose {
@@ -53,11 +55,11 @@ Environment variables:
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Output verbose logs")
 	rootCmd.PersistentFlags().BoolVarP(&noMetrics, "no-metrics", "", false, "Don't report user metrics for this command")
 
-	pfsCmds := pfscmds.Cmds(address, &noMetrics)
+	pfsCmds := pfscmds.Cmds(&noMetrics)
 	for _, cmd := range pfsCmds {
 		rootCmd.AddCommand(cmd)
 	}
-	ppsCmds, err := ppscmds.Cmds(address, &noMetrics)
+	ppsCmds, err := ppscmds.Cmds(&noMetrics)
 	if err != nil {
 		return nil, sanitizeErr(err)
 	}
@@ -68,6 +70,14 @@ Environment variables:
 	for _, cmd := range deployCmds {
 		rootCmd.AddCommand(cmd)
 	}
+	adminCmds := admincmds.Cmds(&noMetrics)
+	for _, cmd := range adminCmds {
+		rootCmd.AddCommand(cmd)
+	}
+	authCmds := authcmds.Cmds(&noMetrics)
+	for _, cmd := range authCmds {
+		rootCmd.AddCommand(cmd)
+	}
 
 	version := &cobra.Command{
 		Use:   "version",
@@ -83,17 +93,18 @@ Environment variables:
 			printVersion(writer, "pachctl", version.Version)
 			writer.Flush()
 
-			versionClient, err := getVersionAPIClient(address)
+			c, err := client.NewOnUserMachine(!noMetrics, "user")
 			if err != nil {
 				return sanitizeErr(err)
 			}
+			versionClient := versionpb.NewAPIClient(c.ClientConn())
 			ctx, _ := context.WithTimeout(context.Background(), time.Second)
 			version, err := versionClient.GetVersion(ctx, &types.Empty{})
 
 			if err != nil {
 				buf := bytes.NewBufferString("")
 				errWriter := tabwriter.NewWriter(buf, 20, 1, 3, ' ', 0)
-				fmt.Fprintf(errWriter, "pachd\t(version unknown) : error connecting to pachd server at address (%v): %v\n\nplease make sure pachd is up (`kubectl get all`) and portforwarding is enabled\n", address, sanitizeErr(err))
+				fmt.Fprintf(errWriter, "pachd\t(version unknown) : error connecting to pachd server at address (%v): %v\n\nplease make sure pachd is up (`kubectl get all`) and portforwarding is enabled\n", c.Addr(), sanitizeErr(err))
 				errWriter.Flush()
 				return errors.New(buf.String())
 			}
@@ -102,98 +113,113 @@ Environment variables:
 			return writer.Flush()
 		}),
 	}
-	deleteAll := &cobra.Command{
-		Use:   "delete-all",
-		Short: "Delete everything.",
-		Long: `Delete all repos, commits, files, pipelines and jobs.
-This resets the cluster to its initial state.`,
-		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
-			client, err := client.NewMetricsClientFromAddress(address, !noMetrics, "user")
-			if err != nil {
-				return sanitizeErr(err)
-			}
-			fmt.Printf("Are you sure you want to delete all repos, commits, files, pipelines and jobs? yN\n")
-			r := bufio.NewReader(os.Stdin)
-			bytes, err := r.ReadBytes('\n')
-			if err != nil {
-				return err
-			}
-			if bytes[0] == 'y' || bytes[0] == 'Y' {
-				return client.DeleteAll()
-			}
-			return nil
-		}),
-	}
 	var port int
+	var pachdPort int
 	var uiPort int
 	var uiWebsocketPort int
+	var s3GatewayPort int
 	var kubeCtlFlags string
+	var namespace string
+	var forwards cmdutil.RepeatedStringArg
 	portForward := &cobra.Command{
 		Use:   "port-forward",
 		Short: "Forward a port on the local machine to pachd. This command blocks.",
-		Long:  "Forward a port on the local machine to pachd. This command blocks.",
+		Long: `Forward a port on the local machine to pachd. This command blocks.
+
+The ports of pachd's S3 gateway, of dash, if it's deployed, and of any
+other pods given with --forward are forwarded too. Forwarded ports are
+reconnected automatically when the pod they forward to is restarted.
+
+Examples:
+
+` + "```sh" + `
+# forward pachd and dash
+$ pachctl port-forward
+
+# also forward local port 8000 to port 80 of the pods labelled
+# app=pipeline-foo-v1 (the workers of version 1 of pipeline "foo")
+$ pachctl port-forward --forward 8000:pipeline-foo-v1:80
+` + "```",
 		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
+			pachd := &portForward{app: "pachd", localPort: port, remotePort: pachdPort}
+			dashUI := &portForward{app: "dash", localPort: uiPort, remotePort: 8080, optional: true}
+			dashWebsocket := &portForward{app: "dash", localPort: uiWebsocketPort, remotePort: 8081, optional: true}
+			s3Gateway := &portForward{app: "pachd", localPort: s3GatewayPort, remotePort: 600, optional: true}
+			var others []*portForward
+			for _, spec := range forwards {
+				forward, err := parsePortForward(spec)
+				if err != nil {
+					return err
+				}
+				others = append(others, forward)
+			}
+			if namespace == "" {
+				cfg, err := config.Read()
+				if err != nil {
+					return err
+				}
+				activeContext, err := cfg.CurrentContext()
+				if err != nil {
+					return err
+				}
+				if activeContext != nil {
+					namespace = activeContext.Namespace
+				}
+			}
+			if namespace != "" {
+				kubeCtlFlags += " --namespace=" + namespace
+			}
 
 			var eg errgroup.Group
-
 			eg.Go(func() error {
-				stdin := strings.NewReader(fmt.Sprintf(`
-pod=$(kubectl %v get pod -l app=pachd | awk '{if (NR!=1) { print $1; exit 0 }}')
-kubectl %v port-forward "$pod" %d:650
-`, kubeCtlFlags, kubeCtlFlags, port))
-				return cmdutil.RunIO(cmdutil.IO{
-					Stdin:  stdin,
-					Stderr: os.Stderr,
-				}, "sh")
+				return pachd.run(kubeCtlFlags)
 			})
-
 			eg.Go(func() error {
-				stdin := strings.NewReader(fmt.Sprintf(`
-pod=$(kubectl %v get pod -l app=dash | awk '{if (NR!=1) { print $1; exit 0 }}')
-kubectl %v port-forward "$pod" %d:8080
-`, kubeCtlFlags, kubeCtlFlags, uiPort))
-				if err := cmdutil.RunIO(cmdutil.IO{
-					Stdin: stdin,
-				}, "sh"); err != nil {
-					fmt.Errorf("UI not enabled, deploy with --dashboard")
+				if err := dashUI.run(kubeCtlFlags); err != nil {
+					fmt.Fprintf(os.Stderr, "UI not enabled, deploy with --dashboard\n")
 				}
 				return nil
 			})
-
 			eg.Go(func() error {
-				stdin := strings.NewReader(fmt.Sprintf(`
-pod=$(kubectl %v get pod -l app=dash | awk '{if (NR!=1) { print $1; exit 0 }}')
-kubectl %v port-forward "$pod" %d:8081
-`, kubeCtlFlags, kubeCtlFlags, uiWebsocketPort))
-				cmdutil.RunIO(cmdutil.IO{
-					Stdin: stdin,
-				}, "sh")
+				dashWebsocket.run(kubeCtlFlags)
 				return nil
 			})
+			eg.Go(func() error {
+				s3Gateway.run(kubeCtlFlags)
+				return nil
+			})
+			for _, forward := range others {
+				forward := forward
+				eg.Go(func() error {
+					return forward.run(kubeCtlFlags)
+				})
+			}
 
-			fmt.Printf("Pachd port forwarded\nDash websocket port forwarded\nDash UI port forwarded, navigate to localhost:%v\nCTRL-C to exit", uiPort)
+			fmt.Printf("Pachd port forwarded\nS3 gateway port forwarded, S3 clients can use localhost:%v\nDash websocket port forwarded\nDash UI port forwarded, navigate to localhost:%v\n", s3GatewayPort, uiPort)
+			for _, forward := range others {
+				fmt.Printf("Forwarding %s\n", forward)
+			}
+			fmt.Printf("CTRL-C to exit\n")
 			return eg.Wait()
 		}),
 	}
 	portForward.Flags().IntVarP(&port, "port", "p", 30650, "The local port to bind to.")
+	portForward.Flags().IntVar(&pachdPort, "pachd-port", 650, "The port that pachd listens on in its pod, if it was deployed with --pachd-port.")
 	portForward.Flags().IntVarP(&uiPort, "ui-port", "u", 38080, "The local port to bind to.")
 	portForward.Flags().IntVarP(&uiWebsocketPort, "proxy-port", "x", 38081, "The local port to bind to.")
+	portForward.Flags().IntVar(&s3GatewayPort, "s3gateway-port", 30600, "The local port to bind pachd's S3 gateway to.")
 	portForward.Flags().StringVarP(&kubeCtlFlags, "kubectlflags", "k", "", "Any kubectl flags to proxy, e.g. --kubectlflags='--kubeconfig /some/path/kubeconfig'")
+	portForward.Flags().StringVar(&namespace, "namespace", "", "Kubernetes namespace Pachyderm is deployed in, defaults to the namespace of the active pachctl context, or else of the current kubectl context.")
+	portForward.Flags().VarP(&forwards, "forward", "f", "Forward an additional port, of the form local-port:app:remote-port, to a pod labelled app=<app>. May be specified multiple times.")
 
 	rootCmd.AddCommand(version)
-	rootCmd.AddCommand(deleteAll)
+	rootCmd.AddCommand(deleteAllCmd(&noMetrics))
 	rootCmd.AddCommand(portForward)
+	rootCmd.AddCommand(configCmd())
+	rootCmd.AddCommand(shellCmd(&noMetrics))
 	return rootCmd, nil
 }
 
-func getVersionAPIClient(address string) (versionpb.APIClient, error) {
-	clientConn, err := grpc.Dial(address, client.PachDialOptions()...)
-	if err != nil {
-		return nil, err
-	}
-	return versionpb.NewAPIClient(clientConn), nil
-}
-
 func printVersionHeader(w io.Writer) {
 	fmt.Fprintf(w, "COMPONENT\tVERSION\t\n")
 }
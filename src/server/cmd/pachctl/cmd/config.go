@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/config"
+	"github.com/pachyderm/pachyderm/src/server/pkg/cmdutil"
+	"github.com/spf13/cobra"
+)
+
+// configCmd returns the config command, whose subcommands manage the
+// contexts (i.e. clusters) that pachctl can connect to.
+func configCmd() *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage the clusters that pachctl connects to.",
+		Long: `Manage the clusters that pachctl connects to.
+
+pachctl's config (~/.pachyderm/config.json) contains named contexts, each of
+which describes how to connect to a Pachyderm cluster. pachctl connects to the
+active context's cluster, $ADDRESS overrides the active context's pachd
+address.`,
+		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
+			return nil
+		}),
+	}
+
+	var pachdAddress string
+	var serverCAs string
+	var authToken string
+	var namespace string
+	var compress bool
+	var setContext *cobra.Command
+	setContext = &cobra.Command{
+		Use:   "set-context context-name",
+		Short: "Create or update a context.",
+		Long: `Create or update a context. Only the fields given by flags are changed
+when updating a context.
+
+Examples:
+
+` + "```sh" + `
+# create a context for a cluster that uses TLS
+$ pachctl config set-context prod --pachd-address=pachd.example.com:650 --server-cas=ca.pem
+
+# switch to it
+$ pachctl config use-context prod
+` + "```",
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			cfg, err := config.Read()
+			if err != nil {
+				return err
+			}
+			if cfg.Contexts == nil {
+				cfg.Contexts = make(map[string]*config.Context)
+			}
+			context, ok := cfg.Contexts[args[0]]
+			if !ok {
+				context = &config.Context{}
+				cfg.Contexts[args[0]] = context
+			}
+			flags := setContext.Flags()
+			if flags.Changed("pachd-address") {
+				context.PachdAddress = pachdAddress
+			}
+			if flags.Changed("server-cas") {
+				context.ServerCAs = serverCAs
+			}
+			if flags.Changed("auth-token") {
+				context.AuthToken = authToken
+			}
+			if flags.Changed("namespace") {
+				context.Namespace = namespace
+			}
+			if flags.Changed("compress") {
+				context.Compress = compress
+			}
+			return config.Write(cfg)
+		}),
+	}
+	setContext.Flags().StringVar(&pachdAddress, "pachd-address", "", "The host:port of pachd.")
+	setContext.Flags().StringVar(&serverCAs, "server-cas", "", "A PEM file containing the certificates of the CAs which signed pachd's certificate, pachctl connects with TLS if it's set.")
+	setContext.Flags().StringVar(&authToken, "auth-token", "", "A token to send to pachd with every request.")
+	setContext.Flags().StringVar(&namespace, "namespace", "", "The kubernetes namespace Pachyderm is deployed in, used by deploy, undeploy and port-forward.")
+	setContext.Flags().BoolVar(&compress, "compress", false, "Ask pachd to compress the responses of RPCs that return lots of metadata (e.g. list-file and list-job), for clusters reached over slow links.")
+
+	useContext := &cobra.Command{
+		Use:   "use-context context-name",
+		Short: "Make a context the active context.",
+		Long:  "Make a context the active context.",
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			cfg, err := config.Read()
+			if err != nil {
+				return err
+			}
+			if _, ok := cfg.Contexts[args[0]]; !ok {
+				return fmt.Errorf("context %q doesn't exist, create it with set-context", args[0])
+			}
+			cfg.ActiveContext = args[0]
+			return config.Write(cfg)
+		}),
+	}
+
+	getContexts := &cobra.Command{
+		Use:   "get-contexts",
+		Short: "List the contexts.",
+		Long:  "List the contexts, the active context is marked with a *.",
+		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
+			cfg, err := config.Read()
+			if err != nil {
+				return err
+			}
+			var names []string
+			for name := range cfg.Contexts {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			writer := tabwriter.NewWriter(os.Stdout, 20, 1, 3, ' ', 0)
+			fmt.Fprintf(writer, "CURRENT\tNAME\tPACHD ADDRESS\tNAMESPACE\tTLS\t\n")
+			for _, name := range names {
+				context := cfg.Contexts[name]
+				current := ""
+				if name == cfg.ActiveContext {
+					current = "*"
+				}
+				fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%t\t\n", current, name, context.PachdAddress, context.Namespace, context.ServerCAs != "")
+			}
+			return writer.Flush()
+		}),
+	}
+
+	configCmd.AddCommand(setContext)
+	configCmd.AddCommand(useContext)
+	configCmd.AddCommand(getContexts)
+	return configCmd
+}
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/server/pkg/cmdutil"
+	"github.com/spf13/cobra"
+)
+
+func deleteAllCmd(noMetrics *bool) *cobra.Command {
+	var pipelines bool
+	var repoPrefix string
+	var confirm string
+	deleteAll := &cobra.Command{
+		Use:   "delete-all",
+		Short: "Delete everything.",
+		Long: `Delete all repos, commits, files, pipelines and jobs.
+This resets the cluster to its initial state.
+
+Because this can't be undone, you're asked to confirm it by typing the address
+of the pachd that's being reset, which may also be given with --confirm. Once
+auth is active, only admins can delete everything.
+
+--pipelines and --repos delete less: --pipelines deletes the pipelines and
+their jobs, but leaves all repos and their data. --repos deletes the repos
+whose names start with a prefix, along with the pipelines that output to
+them.
+
+Examples:
+
+` + "```sh" + `
+# delete the pipelines, but not their input or output repos
+$ pachctl delete-all --pipelines
+
+# delete the repos and pipelines whose names start with "test-"
+$ pachctl delete-all --repos test-
+
+# delete everything without being prompted, e.g. in a script
+$ pachctl delete-all --confirm localhost:30650
+` + "```",
+		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
+			if pipelines && repoPrefix != "" {
+				return fmt.Errorf("only one of --pipelines and --repos may be given")
+			}
+			c, err := client.NewOnUserMachine(!*noMetrics, "user")
+			if err != nil {
+				return sanitizeErr(err)
+			}
+			if !pipelines && repoPrefix == "" {
+				if confirm == "" {
+					fmt.Printf("This deletes all repos, commits, files, pipelines and jobs in the cluster at %s.\n", c.Addr())
+					fmt.Printf("Type the address of the cluster to confirm: ")
+					if confirm, err = readLine(); err != nil {
+						return err
+					}
+				}
+				if confirm != c.Addr() {
+					return fmt.Errorf("%q doesn't match the cluster's address %q, nothing was deleted", confirm, c.Addr())
+				}
+				return c.DeleteAll()
+			}
+
+			pipelineInfos, err := c.ListPipeline()
+			if err != nil {
+				return sanitizeErr(err)
+			}
+			var pipelineNames []string
+			for _, pipelineInfo := range pipelineInfos {
+				if pipelines || strings.HasPrefix(pipelineInfo.Pipeline.Name, repoPrefix) {
+					pipelineNames = append(pipelineNames, pipelineInfo.Pipeline.Name)
+				}
+			}
+			var repoNames []string
+			if repoPrefix != "" {
+				repoInfos, err := c.ListRepo(nil)
+				if err != nil {
+					return sanitizeErr(err)
+				}
+				repoNames = reposToDelete(repoInfos, repoPrefix)
+			}
+			if len(pipelineNames) == 0 && len(repoNames) == 0 {
+				fmt.Println("Nothing to delete.")
+				return nil
+			}
+			if len(pipelineNames) > 0 {
+				fmt.Printf("Pipelines (and their jobs) to delete: %s\n", strings.Join(pipelineNames, ", "))
+			}
+			if len(repoNames) > 0 {
+				fmt.Printf("Repos to delete: %s\n", strings.Join(repoNames, ", "))
+			}
+			fmt.Printf("Are you sure you want to delete them? yN\n")
+			answer, err := readLine()
+			if err != nil {
+				return err
+			}
+			if answer != "y" && answer != "Y" {
+				return nil
+			}
+			for _, pipelineName := range pipelineNames {
+				if err := c.DeletePipeline(pipelineName, true); err != nil {
+					return fmt.Errorf("error deleting pipeline %s: %v", pipelineName, err)
+				}
+			}
+			for _, repoName := range repoNames {
+				if err := c.DeleteRepo(repoName, false); err != nil {
+					return fmt.Errorf("error deleting repo %s: %v", repoName, err)
+				}
+			}
+			return nil
+		}),
+	}
+	deleteAll.Flags().BoolVar(&pipelines, "pipelines", false, "Only delete pipelines and their jobs, not repos.")
+	deleteAll.Flags().StringVar(&repoPrefix, "repos", "", "Only delete the repos whose names start with this prefix, and the pipelines that output to them.")
+	deleteAll.Flags().StringVar(&confirm, "confirm", "", "The address of the cluster being reset, to delete everything without being prompted.")
+	return deleteAll
+}
+
+// reposToDelete returns the names of the repos in repoInfos whose names start
+// with prefix, ordered so that each repo is deleted before the repos in its
+// provenance, which can't be deleted while another repo depends on them.
+func reposToDelete(repoInfos []*pfs.RepoInfo, prefix string) []string {
+	var matches []*pfs.RepoInfo
+	for _, repoInfo := range repoInfos {
+		if strings.HasPrefix(repoInfo.Repo.Name, prefix) {
+			matches = append(matches, repoInfo)
+		}
+	}
+	// a repo's provenance includes the provenance of the repos in it, so
+	// downstream repos have longer provenance than their inputs
+	sort.SliceStable(matches, func(i, j int) bool {
+		return len(matches[i].Provenance) > len(matches[j].Provenance)
+	})
+	var names []string
+	for _, repoInfo := range matches {
+		names = append(names, repoInfo.Repo.Name)
+	}
+	return names
+}
+
+func readLine() (string, error) {
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+)
+
+func TestReposToDelete(t *testing.T) {
+	repoInfo := func(name string, provenance ...string) *pfs.RepoInfo {
+		result := &pfs.RepoInfo{Repo: client.NewRepo(name)}
+		for _, prov := range provenance {
+			result.Provenance = append(result.Provenance, client.NewRepo(prov))
+		}
+		return result
+	}
+	repoInfos := []*pfs.RepoInfo{
+		repoInfo("test-input"),
+		repoInfo("test-edges", "test-input"),
+		repoInfo("images"),
+		repoInfo("test-montage", "test-edges", "test-input", "images"),
+	}
+	require.Equal(t, []string{"test-montage", "test-edges", "test-input"}, reposToDelete(repoInfos, "test-"))
+	require.Equal(t, []string{"images"}, reposToDelete(repoInfos, "images"))
+	require.Equal(t, 0, len(reposToDelete(repoInfos, "prod-")))
+}
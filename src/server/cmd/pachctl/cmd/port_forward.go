@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/server/pkg/backoff"
+	"github.com/pachyderm/pachyderm/src/server/pkg/cmdutil"
+)
+
+// portForward forwards a local port to a port on a pod with the label
+// app=<app>.
+type portForward struct {
+	app        string
+	localPort  int
+	remotePort int
+	// optional port forwards give up if there's no pod to forward to when
+	// they start, e.g. because dash wasn't deployed.
+	optional bool
+}
+
+// parsePortForward parses a port forward of the form
+// "local-port:app:remote-port".
+func parsePortForward(spec string) (*portForward, error) {
+	parts := strings.Split(spec, ":")
+	if len(parts) != 3 || parts[1] == "" {
+		return nil, fmt.Errorf("invalid port forward %q, must be of the form local-port:app:remote-port", spec)
+	}
+	localPort, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid local port in port forward %q: %v", spec, err)
+	}
+	remotePort, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid remote port in port forward %q: %v", spec, err)
+	}
+	return &portForward{
+		app:        parts[1],
+		localPort:  localPort,
+		remotePort: remotePort,
+	}, nil
+}
+
+func (f *portForward) String() string {
+	return fmt.Sprintf("localhost:%d -> %s:%d", f.localPort, f.app, f.remotePort)
+}
+
+// findPod returns the name of a running pod to forward to, or "" if there
+// isn't one.
+func (f *portForward) findPod(kubeCtlFlags string) (string, error) {
+	stdin := strings.NewReader(fmt.Sprintf(`
+kubectl %v get pod -l app=%s | awk '{if (NR!=1 && $3=="Running") { print $1; exit 0 }}'
+`, kubeCtlFlags, f.app))
+	stdout := &bytes.Buffer{}
+	if err := cmdutil.RunIO(cmdutil.IO{
+		Stdin:  stdin,
+		Stdout: stdout,
+		Stderr: os.Stderr,
+	}, "sh"); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// run forwards the port until the process exits. The connection is broken
+// whenever the pod it's forwarding to goes away (e.g. because it was
+// restarted), so run reconnects to a new pod when that happens.
+func (f *portForward) run(kubeCtlFlags string) error {
+	b := backoff.NewInfiniteBackOff()
+	connected := false
+	for {
+		pod, err := f.findPod(kubeCtlFlags)
+		if err == nil && pod == "" {
+			if f.optional && !connected {
+				return fmt.Errorf("no running pods with label app=%s", f.app)
+			}
+			err = fmt.Errorf("no running pods with label app=%s", f.app)
+		}
+		if err == nil {
+			if connected {
+				fmt.Fprintf(os.Stderr, "Reconnected %s\n", f)
+			}
+			connected = true
+			start := time.Now()
+			stdin := strings.NewReader(fmt.Sprintf(`
+kubectl %v port-forward "%s" %d:%d
+`, kubeCtlFlags, pod, f.localPort, f.remotePort))
+			err = cmdutil.RunIO(cmdutil.IO{
+				Stdin: stdin,
+			}, "sh")
+			if err == nil {
+				err = fmt.Errorf("kubectl port-forward exited")
+			}
+			// Only back off if we're failing repeatedly, a connection that
+			// lasted a while should be re-established straight away.
+			if time.Since(start) > time.Minute {
+				b.Reset()
+			}
+		}
+		d := b.NextBackOff()
+		fmt.Fprintf(os.Stderr, "Error forwarding %s: %v; retrying in %v\n", f, err, d)
+		time.Sleep(d)
+	}
+}
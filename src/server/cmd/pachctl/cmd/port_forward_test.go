@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+)
+
+func TestParsePortForward(t *testing.T) {
+	forward, err := parsePortForward("8000:pipeline-foo-v1:80")
+	require.NoError(t, err)
+	require.Equal(t, "pipeline-foo-v1", forward.app)
+	require.Equal(t, 8000, forward.localPort)
+	require.Equal(t, 80, forward.remotePort)
+	require.False(t, forward.optional)
+
+	for _, spec := range []string{"", "8000", "8000:80", "8000::80", "foo:pachd:650", "30650:pachd:foo", "1:2:3:4"} {
+		_, err := parsePortForward(spec)
+		require.YesError(t, err)
+	}
+}
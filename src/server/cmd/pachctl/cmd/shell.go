@@ -0,0 +1,429 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/server/pkg/cmdutil"
+	"github.com/pachyderm/pachyderm/src/server/pkg/shell"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func shellCmd(noMetrics *bool) *cobra.Command {
+	return &cobra.Command{
+		Use:   "shell",
+		Short: "Run pachctl commands interactively.",
+		Long: `Run pachctl commands interactively.
+
+Each line is run as the arguments to pachctl, the commands in a session share
+one connection to pachd. Pressing tab completes command and flag names, and
+the names of repos, branches, pipelines and jobs, which are fetched from
+pachd. Lines are split into words the way a POSIX shell does, but aren't
+otherwise expanded. Exit with "exit" or CTRL-D, interrupting a command with
+CTRL-C also exits the shell.
+
+Examples:
+
+` + "```sh" + `
+$ pachctl shell
+pachctl> list-repo
+pachctl> list-commit images
+pachctl> exit
+` + "```",
+		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
+			rootCmd, err := PachctlCmd()
+			if err != nil {
+				return err
+			}
+			s := &pachctlShell{
+				metrics: !*noMetrics,
+				rootCmd: rootCmd,
+			}
+			return s.run()
+		}),
+	}
+}
+
+// pachctlShell runs the commands typed in a pachctl shell session.
+type pachctlShell struct {
+	metrics bool
+	// rootCmd is used to complete commands, each command is run by a new
+	// root command so that its flags start with their default values.
+	rootCmd *cobra.Command
+	// c is shared by the session's commands, it's nil if pachd couldn't be
+	// reached
+	c     *client.APIClient
+	names nameCache
+}
+
+func (s *pachctlShell) run() error {
+	s.connect()
+	defer s.disconnect()
+	lineReader := shell.NewLineReader(os.Stdin, os.Stdout, "pachctl> ", s.complete)
+	for {
+		line, err := lineReader.ReadLine()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		args, err := shell.Split(line)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			continue
+		}
+		if len(args) == 0 {
+			continue
+		}
+		switch args[0] {
+		case "exit", "quit":
+			return nil
+		case "shell":
+			fmt.Fprintln(os.Stderr, "already in a shell")
+			continue
+		}
+		if err := s.execute(args); err != nil {
+			return err
+		}
+		// the command may have created or deleted anything
+		s.names = nameCache{c: s.c}
+		if args[0] == "config" {
+			// the active context may have changed
+			s.disconnect()
+			s.connect()
+		}
+	}
+}
+
+func (s *pachctlShell) execute(args []string) error {
+	rootCmd, err := PachctlCmd()
+	if err != nil {
+		return err
+	}
+	if !s.metrics {
+		args = append(args, "--no-metrics")
+	}
+	rootCmd.SetArgs(args)
+	cmdutil.RunWithoutExit(func() {
+		// cobra prints any error itself
+		rootCmd.Execute()
+	})
+	return nil
+}
+
+// connect connects the client which the session's commands share. If pachd
+// can't be reached the shell still starts, e.g. so that the user can switch
+// contexts, and each command connects itself.
+func (s *pachctlShell) connect() {
+	c, err := client.NewOnUserMachine(s.metrics, "user")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error connecting to pachd: %v\n", err)
+		return
+	}
+	s.c = c
+	s.names = nameCache{c: c}
+	client.ShareOnUserMachine(c)
+}
+
+func (s *pachctlShell) disconnect() {
+	client.ShareOnUserMachine(nil)
+	if s.c != nil {
+		s.c.Close()
+		s.c = nil
+	}
+}
+
+// complete is the shell's shell.Completer.
+func (s *pachctlShell) complete(words []string, word string) []string {
+	// find the command being completed, its positional args, and the flag
+	// whose value word is, if any
+	cmd := s.rootCmd
+	var args []string
+	var valueFlag *pflag.Flag
+	for _, w := range words {
+		if valueFlag != nil {
+			valueFlag = nil
+			continue
+		}
+		if strings.HasPrefix(w, "-") && w != "-" {
+			if flag := lookupFlag(cmd, w); flag != nil && !strings.Contains(w, "=") && flag.Value.Type() != "bool" {
+				valueFlag = flag
+			}
+			continue
+		}
+		if len(args) == 0 {
+			if sub := findSubcommand(cmd, w); sub != nil {
+				cmd = sub
+				continue
+			}
+		}
+		args = append(args, w)
+	}
+	if valueFlag != nil {
+		return s.flagValues(valueFlag.Name, "")
+	}
+	if strings.HasPrefix(word, "-") {
+		if i := strings.Index(word, "="); i >= 0 {
+			if flag := lookupFlag(cmd, word[:i]); flag != nil {
+				return s.flagValues(flag.Name, word[:i+1])
+			}
+			return nil
+		}
+		return flagNames(cmd)
+	}
+	var candidates []string
+	if len(args) == 0 {
+		for _, sub := range cmd.Commands() {
+			if !sub.Hidden {
+				candidates = append(candidates, sub.Name())
+			}
+		}
+		if cmd == s.rootCmd {
+			candidates = append(candidates, "exit")
+		}
+	}
+	return append(candidates, s.argValues(cmd, args, word)...)
+}
+
+// flagValues returns the values that the flag called name may be given,
+// prefixed with prefix.
+func (s *pachctlShell) flagValues(name string, prefix string) []string {
+	var values []string
+	switch name {
+	case "pipeline":
+		values = s.names.pipelines()
+	case "job":
+		values = s.names.jobs()
+	}
+	for i, value := range values {
+		values[i] = prefix + value
+	}
+	return values
+}
+
+// argValues returns the values that the positional arg following args may
+// be given, for the argument kinds named in cmd's usage.
+func (s *pachctlShell) argValues(cmd *cobra.Command, args []string, word string) []string {
+	kinds := argKinds(cmd.Use)
+	i := len(args)
+	if n := len(kinds); n > 1 && kinds[n-1] == repeatedArg && i >= n-1 {
+		i = n - 2
+	}
+	if i >= len(kinds) {
+		return nil
+	}
+	switch kinds[i] {
+	case repoArg:
+		return s.names.repos()
+	case branchArg:
+		for j := i - 1; j >= 0; j-- {
+			if kinds[j] == repoArg {
+				return s.names.branches(args[j])
+			}
+		}
+	case commitArg:
+		if slash := strings.Index(word, "/"); slash >= 0 {
+			repo := word[:slash]
+			var result []string
+			for _, branch := range s.names.branches(repo) {
+				result = append(result, repo+"/"+branch)
+			}
+			return result
+		}
+		var result []string
+		for _, repo := range s.names.repos() {
+			result = append(result, repo+"/")
+		}
+		return result
+	case pipelineArg:
+		return s.names.pipelines()
+	case jobArg:
+		return s.names.jobs()
+	}
+	return nil
+}
+
+type argKind int
+
+const (
+	otherArg argKind = iota
+	repoArg
+	// branchArg is a branch (or commit) of the preceding repoArg
+	branchArg
+	// commitArg is of the form repo/branch
+	commitArg
+	pipelineArg
+	jobArg
+	// repeatedArg means that the preceding arg may be repeated
+	repeatedArg
+)
+
+// argKinds returns the kinds of the positional args described by a
+// command's usage, e.g. "get-file repo-name commit-id path/to/file".
+func argKinds(use string) []argKind {
+	words := strings.Fields(use)
+	var kinds []argKind
+	for i := 1; i < len(words); i++ {
+		word := words[i]
+		// skip flags, and their arguments
+		if strings.HasPrefix(word, "[-") {
+			for i < len(words) && !strings.Contains(words[i], "]") {
+				i++
+			}
+			continue
+		}
+		if strings.HasPrefix(word, "-") {
+			if !strings.Contains(word, "=") {
+				i++
+			}
+			continue
+		}
+		word = strings.Trim(word, "<>[]")
+		switch {
+		case word == "...":
+			kinds = append(kinds, repeatedArg)
+		case word == "repo-name":
+			kinds = append(kinds, repoArg)
+		case word == "branch" || strings.HasPrefix(word, "commit-id") || word == "branch-name":
+			kinds = append(kinds, branchArg)
+		case word == "commit" || word == "commits" || strings.HasPrefix(word, "repo/") || strings.HasPrefix(word, "repo["):
+			kinds = append(kinds, commitArg)
+		case word == "pipeline-name":
+			kinds = append(kinds, pipelineArg)
+		case word == "job-id":
+			kinds = append(kinds, jobArg)
+		default:
+			kinds = append(kinds, otherArg)
+		}
+	}
+	return kinds
+}
+
+func findSubcommand(cmd *cobra.Command, name string) *cobra.Command {
+	for _, sub := range cmd.Commands() {
+		if sub.Name() == name || sub.HasAlias(name) {
+			return sub
+		}
+	}
+	return nil
+}
+
+// lookupFlag returns the flag that word (e.g. "--pipeline" or "-p=foo")
+// refers to, or nil.
+func lookupFlag(cmd *cobra.Command, word string) *pflag.Flag {
+	name := word
+	if i := strings.Index(name, "="); i >= 0 {
+		name = name[:i]
+	}
+	long := strings.HasPrefix(name, "--")
+	name = strings.TrimLeft(name, "-")
+	var result *pflag.Flag
+	for _, flags := range []*pflag.FlagSet{cmd.Flags(), cmd.InheritedFlags()} {
+		flags.VisitAll(func(flag *pflag.Flag) {
+			if (long && flag.Name == name) || (!long && flag.Shorthand == name) {
+				result = flag
+			}
+		})
+	}
+	return result
+}
+
+func flagNames(cmd *cobra.Command) []string {
+	var names []string
+	for _, flags := range []*pflag.FlagSet{cmd.Flags(), cmd.InheritedFlags()} {
+		flags.VisitAll(func(flag *pflag.Flag) {
+			if !flag.Hidden {
+				names = append(names, "--"+flag.Name)
+			}
+		})
+	}
+	return names
+}
+
+// nameCache fetches the names of the objects in the cluster for completion,
+// and caches them until the next command is run.
+type nameCache struct {
+	c     *client.APIClient
+	names map[string][]string
+}
+
+// get returns the names cached under key, fetching them if they aren't
+// cached. Errors aren't reported, there's just nothing to complete.
+func (n *nameCache) get(key string, fetch func() ([]string, error)) []string {
+	if n.c == nil {
+		return nil
+	}
+	if names, ok := n.names[key]; ok {
+		return append([]string(nil), names...)
+	}
+	names, err := fetch()
+	if err != nil {
+		return nil
+	}
+	if n.names == nil {
+		n.names = make(map[string][]string)
+	}
+	n.names[key] = names
+	return append([]string(nil), names...)
+}
+
+func (n *nameCache) repos() []string {
+	return n.get("repos", func() ([]string, error) {
+		repoInfos, err := n.c.ListRepo(nil)
+		if err != nil {
+			return nil, err
+		}
+		var names []string
+		for _, repoInfo := range repoInfos {
+			names = append(names, repoInfo.Repo.Name)
+		}
+		return names, nil
+	})
+}
+
+func (n *nameCache) branches(repo string) []string {
+	return n.get("branches/"+repo, func() ([]string, error) {
+		branches, err := n.c.ListBranch(repo)
+		if err != nil {
+			return nil, err
+		}
+		var names []string
+		for _, branch := range branches {
+			names = append(names, branch.Name)
+		}
+		return names, nil
+	})
+}
+
+func (n *nameCache) pipelines() []string {
+	return n.get("pipelines", func() ([]string, error) {
+		pipelineInfos, err := n.c.ListPipeline()
+		if err != nil {
+			return nil, err
+		}
+		var names []string
+		for _, pipelineInfo := range pipelineInfos {
+			names = append(names, pipelineInfo.Pipeline.Name)
+		}
+		return names, nil
+	})
+}
+
+func (n *nameCache) jobs() []string {
+	return n.get("jobs", func() ([]string, error) {
+		jobInfos, err := n.c.ListJob("", nil)
+		if err != nil {
+			return nil, err
+		}
+		var ids []string
+		for _, jobInfo := range jobInfos {
+			ids = append(ids, jobInfo.Job.ID)
+		}
+		return ids, nil
+	})
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+)
+
+func TestArgKinds(t *testing.T) {
+	require.Equal(t, []argKind{repoArg, branchArg, otherArg}, argKinds("get-file repo-name commit-id path/to/file"))
+	require.Equal(t, []argKind{repoArg, branchArg, otherArg}, argKinds("set-branch <repo-name> <commit-id/branch-name> <new-branch-name>"))
+	require.Equal(t, []argKind{commitArg}, argKinds("list-job [-p pipeline-name] [commits]"))
+	require.Equal(t, []argKind{commitArg, commitArg, repeatedArg}, argKinds("flush-commit commit [commit ...]"))
+	require.Equal(t, []argKind{pipelineArg, commitArg, repeatedArg}, argKinds("run-pipeline pipeline-name [repo/commit-or-branch ...] [-f job.json]"))
+	require.Equal(t, 0, len(argKinds("create-pipeline -f pipeline.json")))
+	require.Equal(t, 0, len(argKinds("get-logs [--pipeline=<pipeline>|--job=<job id>]")))
+}
+
+func TestShellComplete(t *testing.T) {
+	rootCmd, err := PachctlCmd()
+	require.NoError(t, err)
+	s := &pachctlShell{rootCmd: rootCmd}
+	// names are normally fetched from pachd
+	s.names.c = &client.APIClient{}
+	s.names.names = map[string][]string{
+		"repos":           {"images", "edges"},
+		"branches/images": {"master", "dev"},
+		"pipelines":       {"montage"},
+		"jobs":            {"0123"},
+	}
+	complete := func(words ...string) []string {
+		result := s.complete(words[:len(words)-1], words[len(words)-1])
+		sort.Strings(result)
+		return result
+	}
+	require.True(t, contains(complete(""), "list-repo"))
+	require.True(t, contains(complete("deploy", ""), "local"))
+	require.Equal(t, []string{"edges", "images"}, complete("list-commit", ""))
+	require.Equal(t, []string{"dev", "master"}, complete("get-file", "images", ""))
+	require.Equal(t, []string{"dev", "master"}, complete("get-file", "-o", "out", "images", ""))
+	require.Equal(t, 0, len(complete("get-file", "images", "master", "")))
+	require.Equal(t, []string{"edges/", "images/"}, complete("flush-commit", "images/master", ""))
+	require.Equal(t, []string{"images/dev", "images/master"}, complete("flush-commit", "images/"))
+	require.Equal(t, []string{"montage"}, complete("list-job", "-p", ""))
+	require.Equal(t, []string{"--pipeline=montage"}, complete("list-job", "--pipeline="))
+	require.Equal(t, []string{"0123"}, complete("inspect-job", ""))
+	require.True(t, contains(complete("inspect-job", "--"), "--block"))
+}
+
+func contains(ss []string, s string) bool {
+	for _, x := range ss {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
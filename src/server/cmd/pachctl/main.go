@@ -9,9 +9,7 @@ import (
 	"github.com/spf13/pflag"
 )
 
-type appEnv struct {
-	Address string `env:"ADDRESS,default=0.0.0.0:30650"`
-}
+type appEnv struct{}
 
 func main() {
 	cmdutil.Main(do, &appEnv{})
@@ -19,8 +17,7 @@ func main() {
 
 func do(appEnvObj interface{}) error {
 	pflag.CommandLine = pflag.NewFlagSet(os.Args[0], pflag.ExitOnError)
-	appEnv := appEnvObj.(*appEnv)
-	rootCmd, err := cmd.PachctlCmd(appEnv.Address)
+	rootCmd, err := cmd.PachctlCmd()
 	if err != nil {
 		return err
 	}
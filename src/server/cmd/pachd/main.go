@@ -1,15 +1,27 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"net"
 	"net/http"
 	_ "net/http/pprof"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 
+	etcd "github.com/coreos/etcd/clientv3"
 	units "github.com/docker/go-units"
 	"github.com/pachyderm/pachyderm/src/client"
+	adminclient "github.com/pachyderm/pachyderm/src/client/admin"
+	authclient "github.com/pachyderm/pachyderm/src/client/auth"
 	healthclient "github.com/pachyderm/pachyderm/src/client/health"
 	pfsclient "github.com/pachyderm/pachyderm/src/client/pfs"
 	"github.com/pachyderm/pachyderm/src/client/pkg/discovery"
@@ -18,19 +30,31 @@ import (
 	"github.com/pachyderm/pachyderm/src/client/pkg/uuid"
 	ppsclient "github.com/pachyderm/pachyderm/src/client/pps"
 	"github.com/pachyderm/pachyderm/src/client/version"
+	adminserver "github.com/pachyderm/pachyderm/src/server/admin/server"
+	authserver "github.com/pachyderm/pachyderm/src/server/auth/server"
 	"github.com/pachyderm/pachyderm/src/server/health"
+	"github.com/pachyderm/pachyderm/src/server/pfs/s3"
 	pfs_server "github.com/pachyderm/pachyderm/src/server/pfs/server"
 	cache_pb "github.com/pachyderm/pachyderm/src/server/pkg/cache/groupcachepb"
 	cache_server "github.com/pachyderm/pachyderm/src/server/pkg/cache/server"
 	"github.com/pachyderm/pachyderm/src/server/pkg/cmdutil"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+	"github.com/pachyderm/pachyderm/src/server/pkg/deploy/assets"
+	"github.com/pachyderm/pachyderm/src/server/pkg/dlock"
+	"github.com/pachyderm/pachyderm/src/server/pkg/etcdutil"
+	"github.com/pachyderm/pachyderm/src/server/pkg/lite"
 	"github.com/pachyderm/pachyderm/src/server/pkg/metrics"
+	"github.com/pachyderm/pachyderm/src/server/pkg/migration"
 	"github.com/pachyderm/pachyderm/src/server/pkg/netutil"
+	"github.com/pachyderm/pachyderm/src/server/pkg/stats"
 	ppsserver "github.com/pachyderm/pachyderm/src/server/pps"
 	pps_server "github.com/pachyderm/pachyderm/src/server/pps/server"
+	"github.com/pachyderm/pachyderm/src/server/rest"
 
 	flag "github.com/spf13/pflag"
 	"go.pedge.io/lion"
 	"go.pedge.io/lion/proto"
+	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 	"k8s.io/kubernetes/pkg/api"
 	kube_client "k8s.io/kubernetes/pkg/client/restclient"
@@ -42,7 +66,7 @@ var readinessCheck bool
 var migrate string
 
 func init() {
-	flag.StringVar(&mode, "mode", "full", "Pachd currently supports two modes: full and pfs.  The former includes everything you need in a full pachd node.  The later runs only PFS.")
+	flag.StringVar(&mode, "mode", "full", "Pachd currently supports three modes: full, pfs and lite.  The first includes everything you need in a full pachd node.  The second runs only PFS.  The third runs a full pachd on one machine without kubernetes, running pipelines with the local Docker daemon.")
 	flag.BoolVar(&readinessCheck, "readiness-check", false, "Set to true when checking if local pod is ready")
 	flag.StringVar(&migrate, "migrate", "", "Use the format FROM_VERSION-TO_VERSION; e.g. 1.2.4-1.3.0")
 	flag.Parse()
@@ -50,23 +74,161 @@ func init() {
 
 type appEnv struct {
 	Port                  uint16 `env:"PORT,default=650"`
+	HTTPPort              uint16 `env:"HTTP_PORT,default=651"`
+	WorkerPort            uint16 `env:"WORKER_PORT,default=80"`
+	S3GatewayPort         uint16 `env:"S3GATEWAY_PORT,default=600"`
 	NumShards             uint64 `env:"NUM_SHARDS,default=32"`
 	StorageRoot           string `env:"PACH_ROOT,default=/pach"`
 	StorageBackend        string `env:"STORAGE_BACKEND,default="`
 	StorageHostPath       string `env:"STORAGE_HOST_PATH,default="`
 	PPSEtcdPrefix         string `env:"PPS_ETCD_PREFIX,default=pachyderm_pps"`
 	PFSEtcdPrefix         string `env:"PFS_ETCD_PREFIX,default=pachyderm_pfs"`
+	AuthEtcdPrefix        string `env:"AUTH_ETCD_PREFIX,default=pachyderm_auth"`
+	AuthTokenTTL          string `env:"AUTH_TOKEN_TTL,default=720h"`
+	GitHubClientID        string `env:"GITHUB_CLIENT_ID,default="`
+	GitHubClientSecret    string `env:"GITHUB_CLIENT_SECRET,default="`
+	GitHubOrganization    string `env:"GITHUB_ORGANIZATION,default="`
+	OIDCIssuer            string `env:"OIDC_ISSUER,default="`
+	OIDCClientID          string `env:"OIDC_CLIENT_ID,default="`
+	OIDCClientSecret      string `env:"OIDC_CLIENT_SECRET,default="`
+	OIDCGroupsClaim       string `env:"OIDC_GROUPS_CLAIM,default=groups"`
+	OIDCScopes            string `env:"OIDC_SCOPES,default="`
 	KubeAddress           string `env:"KUBERNETES_PORT_443_TCP_ADDR,required"`
-	EtcdAddress           string `env:"ETCD_PORT_2379_TCP_ADDR,required"`
-	Namespace             string `env:"NAMESPACE,default=default"`
+	EtcdAddress           string `env:"ETCD_PORT_2379_TCP_ADDR,default="`
 	Metrics               bool   `env:"METRICS,default=true"`
 	Init                  bool   `env:"INIT,default=false"`
 	BlockCacheBytes       string `env:"BLOCK_CACHE_BYTES,default=1G"`
 	PFSCacheBytes         string `env:"PFS_CACHE_BYTES,default=500M"`
+	MaxMsgSize            string `env:"MAX_MSG_SIZE,default=20M"`
 	WorkerImage           string `env:"WORKER_IMAGE,default="`
 	WorkerSidecarImage    string `env:"WORKER_SIDECAR_IMAGE,default="`
 	WorkerImagePullPolicy string `env:"WORKER_IMAGE_PULL_POLICY,default="`
 	LogLevel              string `env:"LOG_LEVEL,default=info"`
+	// ImageRegistry, if set, is the registry that the image of pipelines
+	// which don't name one is pulled from, see assets.AddRegistry.
+	ImageRegistry string `env:"IMAGE_REGISTRY,default="`
+	// TLSCertDir holds pachd's TLS certificate, see grpcutil.LoadTLS. pachd
+	// only serves TLS if it's there.
+	TLSCertDir string `env:"TLS_CERT_DIR,default=/pachd-tls-cert"`
+	// EtcdEncryptionKey encrypts tokens, ACLs, pipelines and jobs in etcd,
+	// see col.CipherFromKey. It's read from the secret named by
+	// EtcdEncryptionSecret, which pachd's workers read it from too.
+	EtcdEncryptionKey    string `env:"ETCD_ENCRYPTION_KEY,default="`
+	EtcdEncryptionSecret string `env:"ETCD_ENCRYPTION_SECRET,default="`
+	// EtcdEndpoints is a comma-separated list of the endpoints of an
+	// external etcd, which pachd uses instead of the one at EtcdAddress if
+	// it's set. EtcdTLSDir, EtcdUsername and EtcdPassword are how pachd
+	// connects to it, see etcdutil.Options, and are read from the secrets
+	// named by EtcdTLSSecret and EtcdCredentialsSecret, which pachd's
+	// workers read them from too.
+	EtcdEndpoints         string `env:"ETCD_ENDPOINTS,default="`
+	EtcdTLSDir            string `env:"ETCD_TLS_DIR,default=/etcd-tls"`
+	EtcdUsername          string `env:"ETCD_USERNAME,default="`
+	EtcdPassword          string `env:"ETCD_PASSWORD,default="`
+	EtcdTLSSecret         string `env:"ETCD_TLS_SECRET,default="`
+	EtcdCredentialsSecret string `env:"ETCD_CREDENTIALS_SECRET,default="`
+	// EtcdCompactionInterval and EtcdDefragmentInterval are how often pachd
+	// compacts and defragments etcd, see etcdutil.MaintenanceOptions. "0"
+	// turns either off.
+	EtcdCompactionInterval string `env:"ETCD_COMPACTION_INTERVAL,default=1h"`
+	EtcdDefragmentInterval string `env:"ETCD_DEFRAGMENT_INTERVAL,default=24h"`
+	// WorkerNetworkPolicies restricts the network access of pipelines'
+	// workers with network policies, see pps_server.NewAPIServer
+	WorkerNetworkPolicies bool `env:"WORKER_NETWORK_POLICIES,default=false"`
+	// PrepullImages pulls pipelines' images onto the nodes that their
+	// workers may run on when the pipelines are created, see
+	// pps_server.NewAPIServer
+	PrepullImages bool `env:"PREPULL_IMAGES,default=false"`
+	// RegistryCredentials is a comma-separated list of the ECR, GCR and ACR
+	// registries whose short-lived credentials pachd refreshes for workers
+	// to pull their images with, see pps_server.RefreshRegistryCredentials
+	RegistryCredentials string `env:"REGISTRY_CREDENTIALS,default="`
+	// AllowedImagePrefixes is a comma-separated list of the images that
+	// pipelines may use, see pps_server.NewAPIServer. Any image may be used
+	// if it's empty.
+	AllowedImagePrefixes string `env:"ALLOWED_IMAGE_PREFIXES,default="`
+	// RequireNonRoot and RequireResourceLimits are policies that pipelines
+	// must follow, see pps_server.NewAPIServer
+	RequireNonRoot        bool `env:"REQUIRE_NON_ROOT,default=false"`
+	RequireResourceLimits bool `env:"REQUIRE_RESOURCE_LIMITS,default=false"`
+	// WorkerDefaultCPURequest, WorkerDefaultMemoryRequest,
+	// WorkerDefaultCPULimit, WorkerDefaultMemoryLimit, WorkerMaxCPU and
+	// WorkerMaxMemory are the cluster's defaults and maximums for workers'
+	// resources, see pps_server.WorkerResources. Those that are empty aren't
+	// set.
+	WorkerDefaultCPURequest    string `env:"WORKER_DEFAULT_CPU_REQUEST,default="`
+	WorkerDefaultMemoryRequest string `env:"WORKER_DEFAULT_MEMORY_REQUEST,default="`
+	WorkerDefaultCPULimit      string `env:"WORKER_DEFAULT_CPU_LIMIT,default="`
+	WorkerDefaultMemoryLimit   string `env:"WORKER_DEFAULT_MEMORY_LIMIT,default="`
+	WorkerMaxCPU               string `env:"WORKER_MAX_CPU,default="`
+	WorkerMaxMemory            string `env:"WORKER_MAX_MEMORY,default="`
+	// MaxWorkers, if not 0, caps the total number of workers of all
+	// pipelines and jobs. Jobs wait for workers once it's reached.
+	MaxWorkers int32 `env:"MAX_WORKERS,default=0"`
+	// TeamWeights is a comma-separated list of the weights of the teams that
+	// pipelines belong to, see assets.ParseTeamWeights.
+	TeamWeights string `env:"TEAM_WEIGHTS,default="`
+	// PrivilegedWorkers, if true, runs the user code of pipelines which
+	// don't set run_as_user in privileged containers.
+	PrivilegedWorkers bool `env:"PRIVILEGED_WORKERS,default=false"`
+	// SQLEgress lets pipelines load their output into databases, with the
+	// credentials in kubernetes secrets, see pps.SQLEgress
+	SQLEgress bool `env:"SQL_EGRESS,default=false"`
+	// SQLInputs lets pipelines snapshot the results of queries into repos,
+	// with the credentials in kubernetes secrets, see pps.SQLInput
+	SQLInputs bool `env:"SQL_INPUTS,default=false"`
+	// WorkerNodeSelector and WorkerTolerations are comma-separated lists of
+	// the node labels and taints that schedule pipelines' workers on the
+	// cluster's worker nodes, see assets.ParseNodePool.
+	WorkerNodeSelector string `env:"WORKER_NODE_SELECTOR,default="`
+	WorkerTolerations  string `env:"WORKER_TOLERATIONS,default="`
+	// WorkerEnv is a JSON object of the env vars that are set in every
+	// pipeline's workers, unless the pipeline sets them, see
+	// assets.AssetOpts.WorkerEnv.
+	WorkerEnv string `env:"WORKER_ENV,default="`
+	// MigrationTarget and MigrationDryRun configure the migration of etcd's
+	// schema when pachd starts, see migration.Options. pachd migrates etcd
+	// to the latest version if MigrationTarget is empty.
+	MigrationTarget string `env:"MIGRATION_TARGET,default="`
+	MigrationDryRun bool   `env:"MIGRATION_DRY_RUN,default=false"`
+	// ReplicateTo and ReplicateFrom are object store URLs that a primary
+	// writes its backups to, and a secondary restores them from, every
+	// ReplicationInterval, see adminserver.Replicate. At most one is set.
+	ReplicateTo         string `env:"REPLICATE_TO,default="`
+	ReplicateFrom       string `env:"REPLICATE_FROM,default="`
+	ReplicationInterval string `env:"REPLICATION_INTERVAL,default=5m"`
+	// LineageEndpoint is the URL (e.g. Marquez's /api/v1/lineage) that
+	// jobs' lineage is exported to, as OpenLineage events in
+	// LineageNamespace, see pps_server.ExportLineage. It isn't exported if
+	// LineageEndpoint is empty.
+	LineageEndpoint  string `env:"LINEAGE_ENDPOINT,default="`
+	LineageNamespace string `env:"LINEAGE_NAMESPACE,default=pachyderm"`
+	// EventSink is the SNS topic, Pub/Sub topic or webhook that events are
+	// published to as commits on EventBranches finish, and jobs of
+	// EventPipelines finish, see pps_server.PublishEvents. Both are
+	// comma-separated.
+	EventSink      string `env:"EVENT_SINK,default="`
+	EventBranches  string `env:"EVENT_BRANCHES,default="`
+	EventPipelines string `env:"EVENT_PIPELINES,default="`
+	// MetricsExporter is where metrics about pipelines, jobs and repos are
+	// exported to, see stats.NewExporter and pps_server.ExportMetrics. They
+	// aren't exported if it's empty.
+	MetricsExporter string `env:"METRICS_EXPORTER,default="`
+	// DownloadURL is the URL that clients reach pachd's HTTP port at, which
+	// the download URLs of files start with, see pfs.API.GetFileURL. Files
+	// can't be downloaded by URL if it's empty.
+	DownloadURL string `env:"DOWNLOAD_URL,default="`
+	// DrainTimeout bounds how long pachd waits, once it's been told to shut
+	// down, for the requests it's serving to finish, see handOffOnTerm.
+	DrainTimeout string `env:"DRAIN_TIMEOUT,default=30s"`
+	// LiteDir is where pachd keeps its data, and its local stand-in for
+	// kubernetes keeps its state, in lite mode, see setLiteEnv and
+	// lite.Options.
+	LiteDir string `env:"LITE_DIR,default="`
+	// LiteKubeAddress is the address that pachd serves its stand-in for
+	// kubernetes' API on in lite mode, e.g. for kubectl or the integration
+	// tests. It's a free port on localhost if it's empty.
+	LiteKubeAddress string `env:"LITE_KUBE_ADDRESS,default="`
 }
 
 func main() {
@@ -75,16 +237,113 @@ func main() {
 		cmdutil.Main(doFullMode, &appEnv{})
 	case "pfs":
 		cmdutil.Main(doPFSMode, &appEnv{})
+	case "lite":
+		if err := setLiteEnv(); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		cmdutil.Main(doLiteMode, &appEnv{})
 	default:
 		fmt.Println("unrecognized mode: %s", mode)
 	}
 }
 
-func doPFSMode(appEnvObj interface{}) error {
+// liteCluster is the local stand-in for kubernetes that pachd runs in, in
+// lite mode, see doLiteMode.
+var liteCluster *lite.Cluster
+
+// setLiteEnv sets the env that kubernetes would otherwise set, or that
+// differs in lite mode, unless it's set already: pachd serves its API on the
+// ports that pachctl connects to by default, keeps its data in LITE_DIR
+// (~/.pachyderm/lite by default), and runs the workers of its own version.
+func setLiteEnv() error {
+	dir := os.Getenv("LITE_DIR")
+	if dir == "" {
+		home := os.Getenv("HOME")
+		if home == "" {
+			return fmt.Errorf("LITE_DIR or HOME must be set to run pachd in lite mode")
+		}
+		dir = filepath.Join(home, ".pachyderm", "lite")
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	registry := os.Getenv("IMAGE_REGISTRY")
+	tag := version.PrettyPrintVersion(version.Version)
+	defaults := map[string]string{
+		"LITE_DIR":          dir,
+		"PORT":              "30650",
+		"HTTP_PORT":         "30651",
+		"S3GATEWAY_PORT":    "30600",
+		"PACH_ROOT":         filepath.Join(dir, "pach"),
+		"STORAGE_HOST_PATH": filepath.Join(dir, "pach"),
+		"METRICS":           "false",
+		// pachd doesn't talk to kubernetes in lite mode, but the address
+		// is required
+		"KUBERNETES_PORT_443_TCP_ADDR": "localhost",
+		"WORKER_IMAGE":                 fmt.Sprintf("%s:%s", assets.AddRegistry(registry, "pachyderm/worker"), tag),
+		"WORKER_SIDECAR_IMAGE":         fmt.Sprintf("%s:%s", assets.AddRegistry(registry, "pachyderm/pachd"), tag),
+		"WORKER_IMAGE_PULL_POLICY":     "IfNotPresent",
+	}
+	for name, value := range defaults {
+		if os.Getenv(name) != "" {
+			continue
+		}
+		if err := os.Setenv(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// doLiteMode runs a full pachd on this machine, without kubernetes. Its
+// pipelines' workers, and etcd, unless it's given one, run as containers of
+// the local Docker daemon, see lite.Cluster.
+func doLiteMode(appEnvObj interface{}) error {
+	appEnv := appEnvObj.(*appEnv)
+	address, err := netutil.ExternalIP()
+	if err != nil {
+		return err
+	}
+	runEtcd := appEnv.EtcdAddress == "" && appEnv.EtcdEndpoints == ""
+	protolion.Infof("starting pachd in lite mode, in %s", appEnv.LiteDir)
+	liteCluster, err = lite.Start(lite.Options{
+		Dir:         appEnv.LiteDir,
+		HostIP:      address,
+		Etcd:        runEtcd,
+		Registry:    appEnv.ImageRegistry,
+		KubeAddress: appEnv.LiteKubeAddress,
+	})
+	if err != nil {
+		return err
+	}
+	if runEtcd {
+		appEnv.EtcdAddress = liteCluster.EtcdAddress()
+	}
+	// pachd's containers are removed when it's interrupted, rather than
+	// left running without it; they're started again when it restarts
 	go func() {
-		lion.Println(http.ListenAndServe(":651", nil))
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, os.Interrupt)
+		<-sigCh
+		if err := liteCluster.Close(); err != nil {
+			protolion.Errorf("error removing lite mode's containers: %v", err)
+		}
+		os.Exit(0)
 	}()
+	return doFullMode(appEnv)
+}
+
+func doPFSMode(appEnvObj interface{}) error {
 	appEnv := appEnvObj.(*appEnv)
+	// the health server answers the liveness and readiness probes on the
+	// trace port, which kubernetes probes while pachd is still starting
+	healthServer := health.NewHealthServer()
+	healthServer.RegisterHTTP(http.DefaultServeMux)
+	go func() {
+		lion.Println(http.ListenAndServe(fmt.Sprintf(":%d", appEnv.HTTPPort), nil))
+	}()
 	switch appEnv.LogLevel {
 	case "debug":
 		lion.SetLevel(lion.LevelDebug)
@@ -97,13 +356,43 @@ func doPFSMode(appEnvObj interface{}) error {
 		lion.SetLevel(lion.LevelInfo)
 	}
 
-	etcdAddress := fmt.Sprintf("http://%s:2379", appEnv.EtcdAddress)
-	etcdClient := getEtcdClient(etcdAddress)
+	etcdOptions, err := getEtcdOptions(appEnv)
+	if err != nil {
+		return err
+	}
+	etcdConfig, err := etcdOptions.Config()
+	if err != nil {
+		return err
+	}
+	etcdClient, err := etcdOptions.NewDiscoveryClient()
+	if err != nil {
+		return err
+	}
 
 	clusterID, err := getClusterID(etcdClient)
 	if err != nil {
 		return err
 	}
+	cipher, err := col.CipherFromKey(appEnv.EtcdEncryptionKey)
+	if err != nil {
+		return fmt.Errorf("error parsing ETCD_ENCRYPTION_KEY: %v", err)
+	}
+	internalToken, err := getInternalToken(etcdConfig, appEnv.AuthEtcdPrefix, cipher)
+	if err != nil {
+		return err
+	}
+	serverCreds, peerCreds, err := grpcutil.LoadTLS(appEnv.TLSCertDir)
+	if err != nil {
+		return err
+	}
+	tokenTTL, err := time.ParseDuration(appEnv.AuthTokenTTL)
+	if err != nil {
+		return fmt.Errorf("error parsing AUTH_TOKEN_TTL: %v", err)
+	}
+	authAPIServer, err := authserver.NewAPIServer(etcdConfig, appEnv.AuthEtcdPrefix, appEnv.PPSEtcdPrefix, internalToken, tokenTTL, getGitHubOptions(appEnv), getOIDCOptions(appEnv), cipher)
+	if err != nil {
+		return err
+	}
 	kubeClient, err := getKubeClient(appEnv)
 	if err != nil {
 		return err
@@ -116,12 +405,16 @@ func doPFSMode(appEnvObj interface{}) error {
 	if err != nil {
 		return err
 	}
-	address = fmt.Sprintf("%s:%d", address, appEnv.Port)
+	address = net.JoinHostPort(address, strconv.Itoa(int(appEnv.Port)))
 	pfsCacheBytes, err := units.RAMInBytes(appEnv.PFSCacheBytes)
 	if err != nil {
 		return err
 	}
-	pfsAPIServer, err := pfs_server.NewAPIServer(address, []string{etcdAddress}, appEnv.PFSEtcdPrefix, pfsCacheBytes, reporter)
+	maxMsgSize, err := units.RAMInBytes(appEnv.MaxMsgSize)
+	if err != nil {
+		return err
+	}
+	pfsAPIServer, err := pfs_server.NewAPIServer(address, etcdConfig, appEnv.PFSEtcdPrefix, pfsCacheBytes, internalToken, peerCreds, reporter, "", cipher)
 	if err != nil {
 		return err
 	}
@@ -133,16 +426,30 @@ func doPFSMode(appEnvObj interface{}) error {
 	if err != nil {
 		return err
 	}
-	healthServer := health.NewHealthServer()
+	replicationOptions, err := getReplicationOptions(appEnv)
+	if err != nil {
+		return err
+	}
+	adminAPIServer := adminserver.NewAPIServer(address, etcdConfig, internalToken, peerCreds, getClusterInfo(clusterID, appEnv), replicationOptions)
+	healthChecks, err := getHealthChecks(etcdConfig, blockAPIServer)
+	if err != nil {
+		return err
+	}
+	healthServer.Ready(internalToken, healthChecks)
 	return grpcutil.Serve(
 		func(s *grpc.Server) {
 			pfsclient.RegisterAPIServer(s, pfsAPIServer)
 			pfsclient.RegisterObjectAPIServer(s, blockAPIServer)
 			healthclient.RegisterHealthServer(s, healthServer)
+			adminclient.RegisterAPIServer(s, adminAPIServer)
+			authclient.RegisterAPIServer(s, authAPIServer)
 		},
 		grpcutil.ServeOptions{
-			Version:    version.Version,
-			MaxMsgSize: grpcutil.MaxMsgSize,
+			Version:           version.Version,
+			MaxMsgSize:        int(maxMsgSize),
+			UnaryInterceptor:  grpcutil.ChainUnaryInterceptors(client.ErrorUnaryServerInterceptor, healthServer.UnaryInterceptor, adminAPIServer.UnaryInterceptor, authAPIServer.UnaryInterceptor),
+			StreamInterceptor: grpcutil.ChainStreamInterceptors(client.ErrorStreamServerInterceptor, healthServer.StreamInterceptor, adminAPIServer.StreamInterceptor, authAPIServer.StreamInterceptor),
+			Creds:             serverCreds,
 		},
 		grpcutil.ServeEnv{
 			GRPCPort: appEnv.Port,
@@ -151,10 +458,14 @@ func doPFSMode(appEnvObj interface{}) error {
 }
 
 func doFullMode(appEnvObj interface{}) error {
+	appEnv := appEnvObj.(*appEnv)
+	// the health server answers the liveness and readiness probes on the
+	// trace port, which kubernetes probes while pachd is still starting
+	healthServer := health.NewHealthServer()
+	healthServer.RegisterHTTP(http.DefaultServeMux)
 	go func() {
-		lion.Println(http.ListenAndServe(":651", nil))
+		lion.Println(http.ListenAndServe(fmt.Sprintf(":%d", appEnv.HTTPPort), nil))
 	}()
-	appEnv := appEnvObj.(*appEnv)
 	switch appEnv.LogLevel {
 	case "debug":
 		lion.SetLevel(lion.LevelDebug)
@@ -166,10 +477,42 @@ func doFullMode(appEnvObj interface{}) error {
 		lion.Errorf("Unrecognized log level %s, falling back to default of \"info\"", appEnv.LogLevel)
 		lion.SetLevel(lion.LevelInfo)
 	}
-	etcdAddress := fmt.Sprintf("http://%s:2379", appEnv.EtcdAddress)
-	etcdClient := getEtcdClient(etcdAddress)
+	etcdOptions, err := getEtcdOptions(appEnv)
+	if err != nil {
+		return err
+	}
+	etcdConfig, err := etcdOptions.Config()
+	if err != nil {
+		return err
+	}
+	etcdClient, err := etcdOptions.NewDiscoveryClient()
+	if err != nil {
+		return err
+	}
+	cipher, err := col.CipherFromKey(appEnv.EtcdEncryptionKey)
+	if err != nil {
+		return fmt.Errorf("error parsing ETCD_ENCRYPTION_KEY: %v", err)
+	}
+	if !readinessCheck {
+		if err := migrateEtcd(etcdConfig, appEnv, cipher); err != nil {
+			return err
+		}
+		maintenanceOptions, err := getEtcdMaintenanceOptions(appEnv)
+		if err != nil {
+			return err
+		}
+		go maintainEtcd(etcdConfig, maintenanceOptions)
+	}
+	internalToken, err := getInternalToken(etcdConfig, appEnv.AuthEtcdPrefix, cipher)
+	if err != nil {
+		return err
+	}
+	serverCreds, peerCreds, err := grpcutil.LoadTLS(appEnv.TLSCertDir)
+	if err != nil {
+		return err
+	}
 	if readinessCheck {
-		c, err := client.NewFromAddress("127.0.0.1:650")
+		c, err := client.NewFromAddress(net.JoinHostPort("localhost", strconv.Itoa(int(appEnv.Port))), client.WithAuthToken(internalToken), client.WithTransportCredentials(peerCreds))
 		if err != nil {
 			return err
 		}
@@ -195,6 +538,14 @@ func doFullMode(appEnvObj interface{}) error {
 	if err != nil {
 		return err
 	}
+	tokenTTL, err := time.ParseDuration(appEnv.AuthTokenTTL)
+	if err != nil {
+		return fmt.Errorf("error parsing AUTH_TOKEN_TTL: %v", err)
+	}
+	authAPIServer, err := authserver.NewAPIServer(etcdConfig, appEnv.AuthEtcdPrefix, appEnv.PPSEtcdPrefix, internalToken, tokenTTL, getGitHubOptions(appEnv), getOIDCOptions(appEnv), cipher)
+	if err != nil {
+		return err
+	}
 	kubeClient, err := getKubeClient(appEnv)
 	if err != nil {
 		return err
@@ -207,14 +558,17 @@ func doFullMode(appEnvObj interface{}) error {
 	if err != nil {
 		return err
 	}
-	address = fmt.Sprintf("%s:%d", address, appEnv.Port)
+	address = net.JoinHostPort(address, strconv.Itoa(int(appEnv.Port)))
 	sharder := shard.NewSharder(
 		etcdClient,
 		appEnv.NumShards,
-		appEnv.Namespace,
+		getNamespace(),
 	)
+	// shutdown is closed when pachd is shutting down, which makes it leave
+	// the sharder, see handOffOnTerm
+	shutdown := make(chan bool)
 	go func() {
-		if err := sharder.AssignRoles(address, nil); err != nil {
+		if err := sharder.AssignRoles(address, shutdown); err != nil && !isCancelled(err) {
 			protolion.Printf("error from sharder.AssignRoles: %s", sanitizeErr(err))
 		}
 	}()
@@ -222,20 +576,51 @@ func doFullMode(appEnvObj interface{}) error {
 	if err != nil {
 		return err
 	}
+	maxMsgSize, err := units.RAMInBytes(appEnv.MaxMsgSize)
+	if err != nil {
+		return err
+	}
+	// the router connects to the other pachds, which serve TLS if this one
+	// does
+	routerDialOption := grpc.WithInsecure()
+	if peerCreds != nil {
+		routerDialOption = grpc.WithTransportCredentials(peerCreds)
+	}
 	router := shard.NewRouter(
 		sharder,
 		grpcutil.NewDialer(
-			grpc.WithInsecure(),
+			routerDialOption,
 		),
 		address,
 	)
 	cacheServer := cache_server.NewCacheServer(router, appEnv.NumShards)
-	pfsAPIServer, err := pfs_server.NewAPIServer(address, []string{etcdAddress}, appEnv.PFSEtcdPrefix, pfsCacheBytes, reporter)
+	pfsAPIServer, err := pfs_server.NewAPIServer(address, etcdConfig, appEnv.PFSEtcdPrefix, pfsCacheBytes, internalToken, peerCreds, reporter, appEnv.DownloadURL, cipher)
+	if err != nil {
+		return err
+	}
+	workerResources, err := getWorkerResources(appEnv)
 	if err != nil {
 		return err
 	}
+	if appEnv.MaxWorkers < 0 {
+		return fmt.Errorf("MAX_WORKERS can't be negative")
+	}
+	teamWeights, err := assets.ParseTeamWeights(splitList(appEnv.TeamWeights))
+	if err != nil {
+		return fmt.Errorf("error parsing TEAM_WEIGHTS: %v", err)
+	}
+	workerNodePool, err := assets.ParseNodePool(splitList(appEnv.WorkerNodeSelector), splitList(appEnv.WorkerTolerations))
+	if err != nil {
+		return fmt.Errorf("error parsing WORKER_NODE_SELECTOR or WORKER_TOLERATIONS: %v", err)
+	}
+	var workerEnv map[string]string
+	if appEnv.WorkerEnv != "" {
+		if err := json.Unmarshal([]byte(appEnv.WorkerEnv), &workerEnv); err != nil {
+			return fmt.Errorf("error parsing WORKER_ENV: %v", err)
+		}
+	}
 	ppsAPIServer, err := pps_server.NewAPIServer(
-		etcdAddress,
+		etcdConfig,
 		appEnv.PPSEtcdPrefix,
 		ppsserver.NewHasher(appEnv.NumShards, appEnv.NumShards),
 		address,
@@ -247,21 +632,49 @@ func doFullMode(appEnvObj interface{}) error {
 		appEnv.StorageRoot,
 		appEnv.StorageBackend,
 		appEnv.StorageHostPath,
+		internalToken,
+		peerCreds,
+		cipher,
+		appEnv.EtcdEncryptionSecret,
+		splitList(appEnv.EtcdEndpoints),
+		appEnv.EtcdTLSSecret,
+		appEnv.EtcdCredentialsSecret,
+		appEnv.WorkerNetworkPolicies,
+		appEnv.PrepullImages,
+		appEnv.RegistryCredentials != "",
+		splitList(appEnv.AllowedImagePrefixes),
+		appEnv.RequireNonRoot,
+		appEnv.RequireResourceLimits,
+		workerResources,
+		appEnv.MaxWorkers,
+		teamWeights,
+		workerNodePool,
+		assets.AddRegistry(appEnv.ImageRegistry, pps_server.DefaultUserImage),
+		appEnv.PrivilegedWorkers,
+		appEnv.SQLEgress,
+		appEnv.SQLInputs,
+		appEnv.WorkerPort,
+		workerEnv,
 		reporter,
 	)
 	if err != nil {
 		return err
 	}
 	go func() {
-		if err := sharder.RegisterFrontends(nil, address, []shard.Frontend{cacheServer}); err != nil {
+		if err := sharder.RegisterFrontends(shutdown, address, []shard.Frontend{cacheServer}); err != nil && !isCancelled(err) {
 			protolion.Printf("error from sharder.RegisterFrontend %s", sanitizeErr(err))
 		}
 	}()
 	go func() {
-		if err := sharder.Register(nil, address, []shard.Server{ppsAPIServer, cacheServer}); err != nil {
+		if err := sharder.Register(shutdown, address, []shard.Server{ppsAPIServer, cacheServer}); err != nil && !isCancelled(err) {
 			protolion.Printf("error from sharder.Register %s", sanitizeErr(err))
 		}
 	}()
+	drainTimeout, err := time.ParseDuration(appEnv.DrainTimeout)
+	if err != nil {
+		return fmt.Errorf("error parsing DRAIN_TIMEOUT: %v", err)
+	}
+	go handOffOnTerm(shutdown, healthServer, drainTimeout, ppsAPIServer)
 	blockCacheBytes, err := units.RAMInBytes(appEnv.BlockCacheBytes)
 	if err != nil {
 		return err
@@ -270,7 +683,55 @@ func doFullMode(appEnvObj interface{}) error {
 	if err != nil {
 		return err
 	}
-	healthServer := health.NewHealthServer()
+	replicationOptions, err := getReplicationOptions(appEnv)
+	if err != nil {
+		return err
+	}
+	adminAPIServer := adminserver.NewAPIServer(address, etcdConfig, internalToken, peerCreds, getClusterInfo(clusterID, appEnv), replicationOptions)
+	go adminserver.Replicate(etcdConfig, address, internalToken, peerCreds, replicationOptions)
+	go adminserver.Mirror(etcdConfig, address, internalToken, peerCreds)
+	go pps_server.ExportLineage(etcdConfig, appEnv.PPSEtcdPrefix, cipher, pps_server.LineageOptions{
+		Endpoint:  appEnv.LineageEndpoint,
+		Namespace: appEnv.LineageNamespace,
+	})
+	go pps_server.PublishEvents(etcdConfig, appEnv.PPSEtcdPrefix, cipher, address, internalToken, peerCreds, pps_server.EventOptions{
+		Sink:      appEnv.EventSink,
+		Branches:  splitList(appEnv.EventBranches),
+		Pipelines: splitList(appEnv.EventPipelines),
+	})
+	// the exporter is created here, rather than by ExportMetrics, since
+	// Prometheus's is served on the HTTP port
+	var metricsExporter stats.Exporter
+	if appEnv.MetricsExporter != "" {
+		metricsExporter, err = stats.NewExporter(appEnv.MetricsExporter)
+		if err != nil {
+			return fmt.Errorf("error creating the metrics exporter: %v", err)
+		}
+		if handler, ok := metricsExporter.(http.Handler); ok {
+			http.Handle("/metrics", handler)
+		}
+	}
+	go pps_server.ExportMetrics(etcdConfig, appEnv.PPSEtcdPrefix, cipher, address, internalToken, peerCreds, metricsExporter)
+	go pps_server.RefreshRegistryCredentials(etcdConfig, appEnv.PPSEtcdPrefix, kubeClient, getNamespace(), splitList(appEnv.RegistryCredentials))
+	// the S3 gateway talks to this pachd as the users whose access keys sign
+	// its requests, rather than with the internal token, see s3.Server
+	go func() {
+		s3Server := s3.Server(appEnv.S3GatewayPort, net.JoinHostPort("localhost", strconv.Itoa(int(appEnv.Port))), client.WithTransportCredentials(peerCreds))
+		lion.Println(s3Server.ListenAndServe())
+	}()
+	// the REST API is served on the HTTP port, alongside the health checks,
+	// and like the S3 gateway, it talks to this pachd as its users
+	restHandler, err := rest.NewHandler(context.Background(), net.JoinHostPort("localhost", strconv.Itoa(int(appEnv.Port))), []grpc.DialOption{routerDialOption})
+	if err != nil {
+		return err
+	}
+	http.Handle(rest.Prefix, restHandler)
+	http.Handle(pfs_server.DownloadPrefix, pfsAPIServer.DownloadHandler())
+	healthChecks, err := getHealthChecks(etcdConfig, blockAPIServer)
+	if err != nil {
+		return err
+	}
+	healthServer.Ready(internalToken, healthChecks)
 	return grpcutil.Serve(
 		func(s *grpc.Server) {
 			pfsclient.RegisterAPIServer(s, pfsAPIServer)
@@ -278,10 +739,15 @@ func doFullMode(appEnvObj interface{}) error {
 			ppsclient.RegisterAPIServer(s, ppsAPIServer)
 			cache_pb.RegisterGroupCacheServer(s, cacheServer)
 			healthclient.RegisterHealthServer(s, healthServer)
+			adminclient.RegisterAPIServer(s, adminAPIServer)
+			authclient.RegisterAPIServer(s, authAPIServer)
 		},
 		grpcutil.ServeOptions{
-			Version:    version.Version,
-			MaxMsgSize: grpcutil.MaxMsgSize,
+			Version:           version.Version,
+			MaxMsgSize:        int(maxMsgSize),
+			UnaryInterceptor:  grpcutil.ChainUnaryInterceptors(client.ErrorUnaryServerInterceptor, healthServer.UnaryInterceptor, adminAPIServer.UnaryInterceptor, authAPIServer.UnaryInterceptor),
+			StreamInterceptor: grpcutil.ChainStreamInterceptors(client.ErrorStreamServerInterceptor, healthServer.StreamInterceptor, adminAPIServer.StreamInterceptor, authAPIServer.StreamInterceptor),
+			Creds:             serverCreds,
 		},
 		grpcutil.ServeEnv{
 			GRPCPort: appEnv.Port,
@@ -289,8 +755,294 @@ func doFullMode(appEnvObj interface{}) error {
 	)
 }
 
-func getEtcdClient(etcdAddress string) discovery.Client {
-	return discovery.NewEtcdClient(etcdAddress)
+// handOffTimeout bounds how long pachd waits for its masters to stop when it's
+// shutting down. It's less than the grace period that kubernetes gives pachd
+// to exit before it kills it, see assets.PachdDeployment.
+const handOffTimeout = 20 * time.Second
+
+// handOffOnTerm waits for SIGTERM, which kubernetes sends to pachd before it
+// stops it (e.g. when pachd is upgraded), and then hands this pachd's shards
+// and masters off to the other pachds and exits. Jobs keep running on their
+// workers meanwhile, and the masters that take them over pick up where these
+// left off. Meanwhile pachd stops being ready, so that it's removed from the
+// service, and waits up to drainTimeout for the requests it's serving, such
+// as long GetFile and FlushCommit streams, to finish. Clients' new requests
+// are rejected, so that they reconnect to another pachd.
+func handOffOnTerm(shutdown chan bool, healthServer health.Server, drainTimeout time.Duration, ppsAPIServer pps_server.APIServer) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGTERM)
+	<-sigCh
+	protolion.Infof("shutting down; draining requests and handing masters off to other pachds")
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+		defer cancel()
+		if err := healthServer.ShutDown(ctx); err != nil {
+			protolion.Errorf("error draining requests: %v", err)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		close(shutdown)
+		ctx, cancel := context.WithTimeout(context.Background(), handOffTimeout)
+		defer cancel()
+		if err := ppsAPIServer.StopMasters(ctx); err != nil {
+			protolion.Errorf("error stopping masters: %v", err)
+		}
+	}()
+	wg.Wait()
+	os.Exit(0)
+}
+
+// getHealthChecks returns the checks of the things that pachd depends on,
+// which decide whether it's ready, see health.Server.
+func getHealthChecks(etcdConfig etcd.Config, blockAPIServer pfs_server.BlockAPIServer) (map[string]health.Check, error) {
+	etcdClient, err := etcd.New(etcdConfig)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]health.Check{
+		"etcd": func(ctx context.Context) error {
+			_, err := etcdClient.Get(ctx, "health")
+			return err
+		},
+		"object storage": func(ctx context.Context) error {
+			errCh := make(chan error, 1)
+			go func() {
+				errCh <- blockAPIServer.CheckStorage()
+			}()
+			select {
+			case err := <-errCh:
+				return err
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	}, nil
+}
+
+// isCancelled returns true if err is the error that the sharder returns once
+// pachd has left it, see handOffOnTerm.
+func isCancelled(err error) bool {
+	return err == shard.ErrCancelled || err == discovery.ErrCancelled
+}
+
+// getClusterInfo returns the description of the cluster reported by
+// InspectCluster.
+func getClusterInfo(clusterID string, env *appEnv) *adminclient.ClusterInfo {
+	return &adminclient.ClusterInfo{
+		ID:                    clusterID,
+		Version:               version.Version,
+		NumShards:             env.NumShards,
+		Namespace:             getNamespace(),
+		StorageBackend:        env.StorageBackend,
+		BlockCacheSize:        env.BlockCacheBytes,
+		PfsCacheSize:          env.PFSCacheBytes,
+		MaxMsgSize:            env.MaxMsgSize,
+		WorkerImage:           env.WorkerImage,
+		WorkerSidecarImage:    env.WorkerSidecarImage,
+		WorkerImagePullPolicy: env.WorkerImagePullPolicy,
+		LogLevel:              env.LogLevel,
+		Metrics:               env.Metrics,
+	}
+}
+
+// getEtcdOptions returns how pachd connects to etcd, which is either the one
+// that pachyderm deploys or an external one.
+func getEtcdOptions(env *appEnv) (*etcdutil.Options, error) {
+	endpoints, err := etcdutil.Endpoints(env.EtcdEndpoints, env.EtcdAddress)
+	if err != nil {
+		return nil, err
+	}
+	return &etcdutil.Options{
+		Endpoints: endpoints,
+		TLSDir:    env.EtcdTLSDir,
+		Username:  env.EtcdUsername,
+		Password:  env.EtcdPassword,
+	}, nil
+}
+
+// getEtcdMaintenanceOptions returns how often pachd compacts and
+// defragments etcd.
+func getEtcdMaintenanceOptions(env *appEnv) (etcdutil.MaintenanceOptions, error) {
+	compactionInterval, err := time.ParseDuration(env.EtcdCompactionInterval)
+	if err != nil {
+		return etcdutil.MaintenanceOptions{}, fmt.Errorf("error parsing ETCD_COMPACTION_INTERVAL: %v", err)
+	}
+	defragmentInterval, err := time.ParseDuration(env.EtcdDefragmentInterval)
+	if err != nil {
+		return etcdutil.MaintenanceOptions{}, fmt.Errorf("error parsing ETCD_DEFRAGMENT_INTERVAL: %v", err)
+	}
+	return etcdutil.MaintenanceOptions{
+		CompactionInterval: compactionInterval,
+		DefragmentInterval: defragmentInterval,
+	}, nil
+}
+
+// maintenanceLockKey is the etcd key of the lock that's held by the pachd
+// which maintains etcd, so that etcd is compacted and defragmented by one
+// pachd, however many are running.
+const maintenanceLockKey = "pachyderm_etcd_maintenance"
+
+// maintainEtcd runs etcdutil.Maintain on whichever pachd holds the
+// maintenance lock, taking it over if the pachd that held it dies.
+func maintainEtcd(etcdConfig etcd.Config, options etcdutil.MaintenanceOptions) {
+	etcdClient, err := etcd.New(etcdConfig)
+	if err != nil {
+		protolion.Errorf("error connecting to etcd; it won't be maintained by this pachd: %v", err)
+		return
+	}
+	defer etcdClient.Close()
+	lock := dlock.NewDLock(etcdClient, maintenanceLockKey)
+	for {
+		ctx, err := lock.Lock(context.Background())
+		if err != nil {
+			protolion.Errorf("error acquiring the etcd maintenance lock: %v", err)
+			time.Sleep(time.Minute)
+			continue
+		}
+		// Maintain returns once the lock is lost
+		etcdutil.Maintain(ctx, etcdConfig, options)
+		if err := lock.Unlock(context.Background()); err != nil {
+			protolion.Errorf("error releasing the etcd maintenance lock: %v", err)
+		}
+	}
+}
+
+// getReplicationOptions returns how the cluster replicates its state, if it
+// does.
+func getReplicationOptions(env *appEnv) (adminserver.ReplicationOptions, error) {
+	interval, err := time.ParseDuration(env.ReplicationInterval)
+	if err != nil {
+		return adminserver.ReplicationOptions{}, fmt.Errorf("error parsing REPLICATION_INTERVAL: %v", err)
+	}
+	result := adminserver.ReplicationOptions{Interval: interval}
+	switch {
+	case env.ReplicateTo != "" && env.ReplicateFrom != "":
+		return adminserver.ReplicationOptions{}, fmt.Errorf("only one of REPLICATE_TO and REPLICATE_FROM can be set")
+	case env.ReplicateTo != "":
+		result.Role = adminclient.ReplicationRole_PRIMARY
+		result.URL = env.ReplicateTo
+	case env.ReplicateFrom != "":
+		result.Role = adminclient.ReplicationRole_SECONDARY
+		result.URL = env.ReplicateFrom
+	}
+	if result.Role != adminclient.ReplicationRole_NONE && interval <= 0 {
+		return adminserver.ReplicationOptions{}, fmt.Errorf("REPLICATION_INTERVAL must be positive")
+	}
+	return result, nil
+}
+
+// getWorkerResources returns the cluster's defaults and maximums for workers'
+// resources.
+func getWorkerResources(env *appEnv) (pps_server.WorkerResources, error) {
+	var result pps_server.WorkerResources
+	for _, cpu := range []struct {
+		name  string
+		value string
+		cpu   *float32
+	}{
+		{"WORKER_DEFAULT_CPU_REQUEST", env.WorkerDefaultCPURequest, &result.DefaultRequests.Cpu},
+		{"WORKER_DEFAULT_CPU_LIMIT", env.WorkerDefaultCPULimit, &result.DefaultLimits.Cpu},
+		{"WORKER_MAX_CPU", env.WorkerMaxCPU, &result.Max.Cpu},
+	} {
+		if cpu.value == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(cpu.value, 32)
+		if err != nil {
+			return pps_server.WorkerResources{}, fmt.Errorf("error parsing %s: %v", cpu.name, err)
+		}
+		*cpu.cpu = float32(value)
+	}
+	result.DefaultRequests.Memory = env.WorkerDefaultMemoryRequest
+	result.DefaultLimits.Memory = env.WorkerDefaultMemoryLimit
+	result.Max.Memory = env.WorkerMaxMemory
+	if err := result.Validate(); err != nil {
+		return pps_server.WorkerResources{}, fmt.Errorf("invalid worker resources: %v", err)
+	}
+	return result, nil
+}
+
+// getGitHubOptions returns the options for logging in with GitHub that pachd
+// is configured with.
+func getGitHubOptions(env *appEnv) authserver.GitHubOptions {
+	return authserver.GitHubOptions{
+		ClientID:     env.GitHubClientID,
+		ClientSecret: env.GitHubClientSecret,
+		Organization: env.GitHubOrganization,
+	}
+}
+
+// getOIDCOptions returns the options for logging in with an OIDC provider
+// that pachd is configured with. OIDC_SCOPES is a comma-separated list.
+func getOIDCOptions(env *appEnv) authserver.OIDCOptions {
+	return authserver.OIDCOptions{
+		Issuer:       env.OIDCIssuer,
+		ClientID:     env.OIDCClientID,
+		ClientSecret: env.OIDCClientSecret,
+		GroupsClaim:  env.OIDCGroupsClaim,
+		Scopes:       splitList(env.OIDCScopes),
+	}
+}
+
+// splitList splits a comma-separated list from pachd's environment, dropping
+// empty items.
+func splitList(list string) []string {
+	var result []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// getInternalToken returns the token that pachd sends with its requests to
+// itself, see authserver.InternalToken.
+func getInternalToken(etcdConfig etcd.Config, etcdPrefix string, cipher *col.Cipher) (string, error) {
+	etcdClient, err := etcd.New(etcdConfig)
+	if err != nil {
+		return "", err
+	}
+	defer etcdClient.Close()
+	return authserver.InternalToken(etcdClient, etcdPrefix, cipher)
+}
+
+// migrateEtcd migrates etcd to the schema version that this pachd uses, see
+// migration.Run. It returns an error if etcd is left at another version,
+// e.g. after a dry run or a rollback, as pachd can't start until it's been
+// migrated.
+func migrateEtcd(etcdConfig etcd.Config, env *appEnv, cipher *col.Cipher) error {
+	options := migration.Options{Target: -1, DryRun: env.MigrationDryRun}
+	if env.MigrationTarget != "" {
+		target, err := strconv.Atoi(env.MigrationTarget)
+		if err != nil {
+			return fmt.Errorf("error parsing MIGRATION_TARGET: %v", err)
+		}
+		options.Target = target
+	}
+	etcdClient, err := etcd.New(etcdConfig)
+	if err != nil {
+		return err
+	}
+	defer etcdClient.Close()
+	version, err := migration.Run(context.Background(), &migration.Env{
+		EtcdClient: etcdClient,
+		PFSPrefix:  env.PFSEtcdPrefix,
+		PPSPrefix:  env.PPSEtcdPrefix,
+		AuthPrefix: env.AuthEtcdPrefix,
+		Cipher:     cipher,
+	}, migration.Migrations, options)
+	if err != nil {
+		return fmt.Errorf("error migrating etcd: %v", err)
+	}
+	if latest := migration.Latest(migration.Migrations); version != latest {
+		return fmt.Errorf("etcd is at schema version %d, but this pachd uses version %d; unset MIGRATION_DRY_RUN and MIGRATION_TARGET to migrate it", version, latest)
+	}
+	return nil
 }
 
 const clusterIDKey = "cluster-id"
@@ -311,6 +1063,9 @@ func getClusterID(client discovery.Client) (string, error) {
 }
 
 func getKubeClient(env *appEnv) (*kube.Client, error) {
+	if liteCluster != nil {
+		return liteCluster.KubeClient()
+	}
 	kubeClient, err := kube.NewInCluster()
 	if err != nil {
 		protolion.Errorf("falling back to insecure kube client due to error from NewInCluster: %s", sanitizeErr(err))
@@ -324,12 +1079,24 @@ func getKubeClient(env *appEnv) (*kube.Client, error) {
 	return kube.New(config)
 }
 
-// getNamespace returns the kubernetes namespace that this pachd pod runs in
+// serviceAccountNamespaceFile is where kubernetes tells pods which namespace
+// they run in, along with their service account's credentials.
+const serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// getNamespace returns the kubernetes namespace that this pachd pod runs in,
+// which its workers, and everything else that it creates, run in too.
 func getNamespace() string {
 	namespace := os.Getenv("PACHD_POD_NAMESPACE")
 	if namespace != "" {
 		return namespace
 	}
+	// pachd was deployed without PACHD_POD_NAMESPACE, e.g. by an older
+	// pachctl
+	if namespace, err := ioutil.ReadFile(serviceAccountNamespaceFile); err == nil {
+		if namespace := strings.TrimSpace(string(namespace)); namespace != "" {
+			return namespace
+		}
+	}
 	return api.NamespaceDefault
 }
 
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/pachyderm/pachyderm/src/server/pfs/flexvolume"
+	"github.com/pachyderm/pachyderm/src/server/pkg/cmdutil"
+)
+
+type appEnv struct {
+	// PachdHost and PachdPort are set by kubernetes in the installer's pod,
+	// from pachd's service
+	PachdHost string `env:"PACHD_SERVICE_HOST,default="`
+	PachdPort string `env:"PACHD_SERVICE_PORT_API_GRPC_PORT,default=650"`
+}
+
+// pfs-flexvolume is the FlexVolume driver that mounts PFS commits into pods,
+// see package flexvolume. kubelet runs it as:
+//
+//	pfs init
+//	pfs mount <dir> <options>
+//	pfs unmount <dir>
+//
+// It's also run as 'pfs-flexvolume install <plugin dir>', by the DaemonSet
+// that installs it on each node, and as 'pfs serve ...' by mount, to serve
+// a mount in the background.
+func main() {
+	cmdutil.Main(do, &appEnv{})
+}
+
+func do(appEnvObj interface{}) error {
+	appEnv := appEnvObj.(*appEnv)
+	if len(os.Args) < 2 {
+		return reply(flexvolume.NotSupported())
+	}
+	driverPath, err := filepath.Abs(os.Args[0])
+	if err != nil {
+		return err
+	}
+	args := os.Args[2:]
+	switch os.Args[1] {
+	case "init":
+		return reply(flexvolume.Init())
+	case "mount":
+		if len(args) != 2 {
+			return reply(flexvolume.Failure(fmt.Errorf("usage: %s mount <dir> <options>", os.Args[0])))
+		}
+		return replyErr(flexvolume.Mount(driverPath, args[0], args[1]))
+	case "unmount":
+		if len(args) != 1 {
+			return reply(flexvolume.Failure(fmt.Errorf("usage: %s unmount <dir>", os.Args[0])))
+		}
+		return replyErr(flexvolume.Unmount(args[0]))
+	case "serve":
+		if len(args) != 4 {
+			return fmt.Errorf("usage: %s serve <dir> <repo> <commit> <address>", os.Args[0])
+		}
+		return flexvolume.Serve(args[0], args[1], args[2], args[3], os.NewFile(3, "ready"))
+	case "install":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: %s install <plugin dir>", os.Args[0])
+		}
+		if appEnv.PachdHost == "" {
+			return fmt.Errorf("PACHD_SERVICE_HOST isn't set, the installer must be created after pachd's service")
+		}
+		if err := flexvolume.Install(driverPath, args[0], &flexvolume.Config{
+			Address: net.JoinHostPort(appEnv.PachdHost, appEnv.PachdPort),
+		}); err != nil {
+			return err
+		}
+		fmt.Printf("installed %s in %s\n", flexvolume.DriverName, args[0])
+		// DaemonSets' pods are restarted when they exit, so the installer
+		// waits until it's stopped
+		sigChan := make(chan os.Signal, 1)
+		signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+		<-sigChan
+		return nil
+	default:
+		return reply(flexvolume.NotSupported())
+	}
+}
+
+// replyErr replies Success if err is nil, or Failure otherwise.
+func replyErr(err error) error {
+	if err != nil {
+		return reply(flexvolume.Failure(err))
+	}
+	return reply(flexvolume.Success())
+}
+
+// reply writes result to stdout, where kubelet reads it, and exits
+// unsuccessfully if it's a Failure.
+func reply(result *flexvolume.Result) error {
+	if err := json.NewEncoder(os.Stdout).Encode(result); err != nil {
+		return err
+	}
+	if result.Status == "Failure" {
+		os.Exit(1)
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"os"
 	"path"
 	"time"
 
@@ -15,7 +16,10 @@ import (
 	"github.com/pachyderm/pachyderm/src/client/pkg/grpcutil"
 	"github.com/pachyderm/pachyderm/src/client/pps"
 	"github.com/pachyderm/pachyderm/src/client/version"
+	authserver "github.com/pachyderm/pachyderm/src/server/auth/server"
 	"github.com/pachyderm/pachyderm/src/server/pkg/cmdutil"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+	"github.com/pachyderm/pachyderm/src/server/pkg/etcdutil"
 	"github.com/pachyderm/pachyderm/src/server/pkg/worker"
 	ppsserver "github.com/pachyderm/pachyderm/src/server/pps/server"
 	"google.golang.org/grpc"
@@ -24,7 +28,15 @@ import (
 // appEnv stores the environment variables that this worker needs
 type appEnv struct {
 	// Address of etcd, so that worker can write its own IP there for discoverh
-	EtcdAddress string `env:"ETCD_PORT_2379_TCP_ADDR,required"`
+	EtcdAddress string `env:"ETCD_PORT_2379_TCP_ADDR,default="`
+
+	// Endpoints of an external etcd, which is used instead of the one at
+	// EtcdAddress if they're set, and how to connect to it, see
+	// etcdutil.Options
+	EtcdEndpoints string `env:"ETCD_ENDPOINTS,default="`
+	EtcdTLSDir    string `env:"ETCD_TLS_DIR,default=/etcd-tls"`
+	EtcdUsername  string `env:"ETCD_USERNAME,default="`
+	EtcdPassword  string `env:"ETCD_PASSWORD,default="`
 
 	// Address for connecting to pachd (so this can download input data)
 	PachdAddress string `env:"PACHD_PORT_650_TCP_ADDR,required"`
@@ -32,17 +44,37 @@ type appEnv struct {
 	// Prefix in etcd for all pachd-related records
 	PPSPrefix string `env:"PPS_ETCD_PREFIX,required"`
 
+	// Prefix in etcd for auth records, the worker reads pachd's internal
+	// token from there
+	AuthPrefix string `env:"AUTH_ETCD_PREFIX,default=pachyderm_auth"`
+
 	// worker gets its own IP here, via the k8s downward API. It then writes that
 	// IP back to etcd so that pachd can discover it
 	PPSWorkerIP string `env:"PPS_WORKER_IP,required"`
 
+	// Port that the worker's gRPC server listens on
+	PPSWorkerPort uint16 `env:"PPS_WORKER_PORT,default=80"`
+
 	// Either pipeline name or job name must be set
 	PPSPipelineName string `env:"PPS_PIPELINE_NAME"`
 	PPSJobID        string `env:"PPS_JOB_ID"`
 	PodName         string `env:"PPS_POD_NAME,required"`
+
+	// Number of workers whose ranks this worker is among, if its pipeline or
+	// job has a rendezvous
+	WorkerCount int `env:"PACH_WORKER_COUNT,default=0"`
+
+	// Key that pachd encrypts pipelines, jobs and its internal token with in
+	// etcd, if it's configured with one
+	EtcdEncryptionKey string `env:"ETCD_ENCRYPTION_KEY,default="`
 }
 
 func main() {
+	// the pods that pull pipelines' images onto the nodes only run the
+	// worker to have something to run in the user's image
+	if len(os.Args) > 1 && os.Args[1] == ppsserver.PrepullArg {
+		return
+	}
 	cmdutil.Main(do, &appEnv{})
 }
 
@@ -57,7 +89,7 @@ func validateEnv(appEnv *appEnv) error {
 
 // getPipelineInfo gets the PipelineInfo proto describing the pipeline that this
 // worker is part of
-func getPipelineInfo(etcdClient *etcd.Client, appEnv *appEnv) (*pps.PipelineInfo, error) {
+func getPipelineInfo(etcdClient *etcd.Client, cipher *col.Cipher, appEnv *appEnv) (*pps.PipelineInfo, error) {
 	ctx, _ := context.WithTimeout(context.Background(), 30*time.Second)
 	resp, err := etcdClient.Get(ctx, path.Join(appEnv.PPSPrefix, "pipelines", appEnv.PPSPipelineName))
 	if err != nil {
@@ -66,14 +98,18 @@ func getPipelineInfo(etcdClient *etcd.Client, appEnv *appEnv) (*pps.PipelineInfo
 	if len(resp.Kvs) != 1 {
 		return nil, fmt.Errorf("expected to find 1 pipeline, got %d: %v", len(resp.Kvs), resp)
 	}
+	value, err := cipher.Decrypt(string(resp.Kvs[0].Value))
+	if err != nil {
+		return nil, err
+	}
 	pipelineInfo := new(pps.PipelineInfo)
-	if err := proto.UnmarshalText(string(resp.Kvs[0].Value), pipelineInfo); err != nil {
+	if err := proto.UnmarshalText(value, pipelineInfo); err != nil {
 		return nil, err
 	}
 	return pipelineInfo, nil
 }
 
-func getJobInfo(etcdClient *etcd.Client, appEnv *appEnv) (*pps.JobInfo, error) {
+func getJobInfo(etcdClient *etcd.Client, cipher *col.Cipher, appEnv *appEnv) (*pps.JobInfo, error) {
 	ctx, _ := context.WithTimeout(context.Background(), 30*time.Second)
 	resp, err := etcdClient.Get(ctx, path.Join(appEnv.PPSPrefix, "jobs", appEnv.PPSJobID))
 	if err != nil {
@@ -82,8 +118,12 @@ func getJobInfo(etcdClient *etcd.Client, appEnv *appEnv) (*pps.JobInfo, error) {
 	if len(resp.Kvs) != 1 {
 		return nil, fmt.Errorf("expected to find 1 job, got %d: %v", len(resp.Kvs), resp)
 	}
+	value, err := cipher.Decrypt(string(resp.Kvs[0].Value))
+	if err != nil {
+		return nil, err
+	}
 	jobInfo := new(pps.JobInfo)
-	if err := proto.UnmarshalText(string(resp.Kvs[0].Value), jobInfo); err != nil {
+	if err := proto.UnmarshalText(value, jobInfo); err != nil {
 		return nil, err
 	}
 	return jobInfo, nil
@@ -96,40 +136,61 @@ func do(appEnvObj interface{}) error {
 		return fmt.Errorf("error validating env: %v", err)
 	}
 
-	// get pachd client, so we can upload output data from the user binary
-	pachClient, err := client.NewFromAddress("localhost:650")
+	// Get etcd client, so we can register our IP (so pachd can discover us)
+	etcdEndpoints, err := etcdutil.Endpoints(appEnv.EtcdEndpoints, appEnv.EtcdAddress)
 	if err != nil {
-		return fmt.Errorf("error constructing pachClient: %v", err)
+		return err
 	}
-	go pachClient.KeepConnected(make(chan bool)) // we never cancel the connection
-
-	// Get etcd client, so we can register our IP (so pachd can discover us)
-	etcdClient, err := etcd.New(etcd.Config{
-		Endpoints:   []string{fmt.Sprintf("%s:2379", appEnv.EtcdAddress)},
-		DialOptions: client.EtcdDialOptions(),
-	})
+	etcdOptions := &etcdutil.Options{
+		Endpoints: etcdEndpoints,
+		TLSDir:    appEnv.EtcdTLSDir,
+		Username:  appEnv.EtcdUsername,
+		Password:  appEnv.EtcdPassword,
+	}
+	etcdClient, err := etcdOptions.NewClient()
 	if err != nil {
 		return fmt.Errorf("error constructing etcdClient: %v", err)
 	}
 
+	cipher, err := col.CipherFromKey(appEnv.EtcdEncryptionKey)
+	if err != nil {
+		return fmt.Errorf("error parsing ETCD_ENCRYPTION_KEY: %v", err)
+	}
+
+	// get pachd client, so we can upload output data from the user binary.
+	// It sends pachd's internal token, so that the worker can read and write
+	// data whether or not auth is active.
+	internalToken, err := authserver.InternalToken(etcdClient, appEnv.AuthPrefix, cipher)
+	if err != nil {
+		return fmt.Errorf("error getting internal token: %v", err)
+	}
+	pachClient, err := client.NewFromAddress("localhost:650", client.WithAuthToken(internalToken))
+	if err != nil {
+		return fmt.Errorf("error constructing pachClient: %v", err)
+	}
+	go pachClient.KeepConnected(make(chan bool)) // we never cancel the connection
+
 	// Construct worker API server. Get relevant pipeline or job info, and then
 	// use that to create a worker.APIServer.
 	var workerRcName string
 	var apiServer *worker.APIServer
+	var transform *pps.Transform
 	if appEnv.PPSPipelineName != "" {
-		pipelineInfo, err := getPipelineInfo(etcdClient, appEnv)
+		pipelineInfo, err := getPipelineInfo(etcdClient, cipher, appEnv)
 		if err != nil {
 			return fmt.Errorf("error getting pipelineInfo: %v", err)
 		}
 		workerRcName = ppsserver.PipelineRcName(pipelineInfo.Pipeline.Name, pipelineInfo.Version)
 		apiServer = worker.NewPipelineAPIServer(pachClient, pipelineInfo, appEnv.PodName)
+		transform = pipelineInfo.Transform
 	} else if appEnv.PPSJobID != "" {
-		jobInfo, err := getJobInfo(etcdClient, appEnv)
+		jobInfo, err := getJobInfo(etcdClient, cipher, appEnv)
 		if err != nil {
 			return fmt.Errorf("error getting jobInfo: %v", err)
 		}
 		workerRcName = ppsserver.JobRcName(jobInfo.Job.ID)
 		apiServer = worker.NewJobAPIServer(pachClient, jobInfo, appEnv.PodName)
+		transform = jobInfo.Transform
 	}
 
 	// Start worker api server
@@ -146,7 +207,7 @@ func do(appEnvObj interface{}) error {
 				MaxMsgSize: grpcutil.MaxMsgSize,
 			},
 			grpcutil.ServeEnv{
-				GRPCPort: client.PPSWorkerPort,
+				GRPCPort: appEnv.PPSWorkerPort,
 			},
 		)
 	})
@@ -169,6 +230,16 @@ func do(appEnvObj interface{}) error {
 		return fmt.Errorf("error with KeepAlive: %v", err)
 	}
 
+	// Claim a rank among the workers before pachd can discover us, so that
+	// the user code always knows its rank. The rank is released along with
+	// our IP if the worker dies.
+	if transform.Rendezvous != nil {
+		prefix := path.Join(appEnv.PPSPrefix, "rendezvous", workerRcName)
+		if err := apiServer.JoinRendezvous(context.Background(), etcdClient, prefix, appEnv.PPSWorkerIP, appEnv.WorkerCount, resp.ID); err != nil {
+			return fmt.Errorf("error joining rendezvous: %v", err)
+		}
+	}
+
 	// Actually write "key" into etcd
 	ctx, _ = context.WithTimeout(context.Background(), 10*time.Second) // new ctx
 	if _, err := etcdClient.Put(ctx, key, "", etcd.WithLease(resp.ID)); err != nil {
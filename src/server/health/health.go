@@ -1,18 +1,177 @@
 package health
 
 import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
 	"github.com/gogo/protobuf/types"
+	"github.com/pachyderm/pachyderm/src/client"
 	"github.com/pachyderm/pachyderm/src/client/health"
 	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// checkTimeout bounds how long each of pachd's checks may take.
+const checkTimeout = 5 * time.Second
+
+var (
+	errStarting     = grpc.Errorf(codes.Unavailable, "pachd is starting")
+	errShuttingDown = grpc.Errorf(codes.Unavailable, client.ShuttingDownDesc)
 )
 
+// Check returns an error if something that pachd depends on, such as etcd or
+// its object store, can't be used.
+type Check func(ctx context.Context) error
+
+// Server is pachd's health server. pachd is ready once Ready has been called,
+// as long as its checks pass, until ShutDown is called. Its interceptors
+// track the requests that are being served, so that ShutDown can wait for
+// them.
+type Server interface {
+	health.HealthServer
+	// RegisterHTTP registers pachd's liveness and readiness endpoints,
+	// /healthz/live and /healthz/ready, on mux.
+	RegisterHTTP(mux *http.ServeMux)
+	// Ready marks pachd as ready to serve, as long as checks pass. Requests
+	// which carry internalToken are pachd's own.
+	Ready(internalToken string, checks map[string]Check)
+	// ShutDown marks pachd as not ready, rejects new requests from clients,
+	// and waits until the ones being served have finished, or ctx is done.
+	ShutDown(ctx context.Context) error
+	UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error)
+	StreamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error
+}
+
 // NewHealthServer returns a new health server
-func NewHealthServer() health.HealthServer {
+func NewHealthServer() Server {
 	return &healthServer{}
 }
 
-type healthServer struct{}
+type healthServer struct {
+	mu            sync.Mutex
+	ready         bool
+	shuttingDown  bool
+	internalToken string
+	checks        map[string]Check
+	// inFlight counts the clients' requests that are being served. It's
+	// only added to while mu is held and pachd isn't shutting down, so that
+	// nothing is added once ShutDown waits for it.
+	inFlight sync.WaitGroup
+}
 
-func (*healthServer) Health(context.Context, *types.Empty) (*types.Empty, error) {
+func (s *healthServer) Health(ctx context.Context, request *types.Empty) (*types.Empty, error) {
+	if err := s.check(ctx); err != nil {
+		return nil, err
+	}
 	return &types.Empty{}, nil
 }
+
+func (s *healthServer) RegisterHTTP(mux *http.ServeMux) {
+	// pachd is alive as long as it answers. It isn't restarted when its
+	// checks fail, as that wouldn't fix etcd or its object store, it's only
+	// removed from the service until they pass again.
+	mux.HandleFunc("/healthz/live", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("/healthz/ready", func(w http.ResponseWriter, r *http.Request) {
+		if err := s.check(context.Background()); err != nil {
+			http.Error(w, grpc.ErrorDesc(err), http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+}
+
+func (s *healthServer) Ready(internalToken string, checks map[string]Check) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ready = true
+	s.internalToken = internalToken
+	s.checks = checks
+}
+
+func (s *healthServer) ShutDown(ctx context.Context) error {
+	s.mu.Lock()
+	s.shuttingDown = true
+	s.mu.Unlock()
+	drained := make(chan struct{})
+	go func() {
+		s.inFlight.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("requests were still being served when pachd stopped waiting for them: %v", ctx.Err())
+	}
+}
+
+func (s *healthServer) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	done, err := s.start(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	return handler(ctx, req)
+}
+
+func (s *healthServer) StreamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	done, err := s.start(stream.Context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	return handler(srv, stream)
+}
+
+// start records that the request with context ctx is being served, and
+// returns a func that records that it has finished. Once pachd is shutting
+// down clients' new requests are rejected with codes.Unavailable, which makes
+// them reconnect, to another pachd. pachd's own requests are always served,
+// as the requests that are being drained may depend on them.
+func (s *healthServer) start(ctx context.Context) (func(), error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token := client.AuthToken(ctx)
+	if s.internalToken != "" && token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.internalToken)) == 1 {
+		return func() {}, nil
+	}
+	if s.shuttingDown {
+		return nil, errShuttingDown
+	}
+	s.inFlight.Add(1)
+	return s.inFlight.Done, nil
+}
+
+// check returns an error if pachd isn't ready, or one of its checks fails.
+func (s *healthServer) check(ctx context.Context) error {
+	s.mu.Lock()
+	ready, shuttingDown, checks := s.ready, s.shuttingDown, s.checks
+	s.mu.Unlock()
+	if shuttingDown {
+		return errShuttingDown
+	}
+	if !ready {
+		return errStarting
+	}
+	var names []string
+	for name := range checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+		err := checks[name](checkCtx)
+		cancel()
+		if err != nil {
+			return grpc.Errorf(codes.Unavailable, "%s is unhealthy: %v", name, err)
+		}
+	}
+	return nil
+}
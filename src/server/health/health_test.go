@@ -0,0 +1,82 @@
+package health
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+const internalToken = "internal"
+
+func TestReadiness(t *testing.T) {
+	s := NewHealthServer()
+	_, err := s.Health(context.Background(), &types.Empty{})
+	require.YesError(t, err)
+
+	var etcdErr error
+	s.Ready(internalToken, map[string]Check{
+		"etcd": func(context.Context) error { return etcdErr },
+	})
+	_, err = s.Health(context.Background(), &types.Empty{})
+	require.NoError(t, err)
+	etcdErr = errors.New("etcd is down")
+	_, err = s.Health(context.Background(), &types.Empty{})
+	require.YesError(t, err)
+	require.Matches(t, "etcd is down", err.Error())
+}
+
+func TestDrain(t *testing.T) {
+	s := NewHealthServer()
+	s.Ready(internalToken, nil)
+	info := &grpc.UnaryServerInfo{FullMethod: "/pfs.API/InspectRepo"}
+
+	// start a request that's still being served when pachd shuts down
+	started := make(chan struct{})
+	release := make(chan struct{})
+	served := make(chan error)
+	go func() {
+		_, err := s.UnaryInterceptor(context.Background(), nil, info, func(context.Context, interface{}) (interface{}, error) {
+			close(started)
+			<-release
+			return nil, nil
+		})
+		served <- err
+	}()
+	<-started
+	shutDown := make(chan error)
+	go func() {
+		shutDown <- s.ShutDown(context.Background())
+	}()
+	select {
+	case <-shutDown:
+		t.Fatal("ShutDown returned while a request was still being served")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// new requests from clients are rejected, pachd's own are served
+	_, err := s.UnaryInterceptor(context.Background(), nil, info, func(context.Context, interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	require.YesError(t, err)
+	require.Equal(t, codes.Unavailable, grpc.Code(err))
+	require.Equal(t, client.ShuttingDownDesc, grpc.ErrorDesc(err))
+	internalCtx := metadata.NewContext(context.Background(), metadata.Pairs("authn-token", internalToken))
+	_, err = s.UnaryInterceptor(internalCtx, nil, info, func(context.Context, interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	require.NoError(t, err)
+	_, err = s.Health(context.Background(), &types.Empty{})
+	require.YesError(t, err)
+
+	close(release)
+	require.NoError(t, <-served)
+	require.NoError(t, <-shutDown)
+}
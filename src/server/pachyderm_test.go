@@ -3,6 +3,7 @@ package server
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -10,23 +11,34 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/pachyderm/pachyderm"
 	"github.com/pachyderm/pachyderm/src/client"
+	adminclient "github.com/pachyderm/pachyderm/src/client/admin"
+	"github.com/pachyderm/pachyderm/src/client/auth"
 	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/config"
 	"github.com/pachyderm/pachyderm/src/client/pkg/require"
 	"github.com/pachyderm/pachyderm/src/client/pkg/uuid"
 	"github.com/pachyderm/pachyderm/src/client/pps"
+	"github.com/pachyderm/pachyderm/src/client/version"
+	"github.com/pachyderm/pachyderm/src/server/admin"
 	pfspretty "github.com/pachyderm/pachyderm/src/server/pfs/pretty"
 	"github.com/pachyderm/pachyderm/src/server/pkg/backoff"
 	ppspretty "github.com/pachyderm/pachyderm/src/server/pps/pretty"
 	pps_server "github.com/pachyderm/pachyderm/src/server/pps/server"
 
 	"github.com/gogo/protobuf/types"
+	netcontext "golang.org/x/net/context"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"k8s.io/kubernetes/pkg/api"
 	"k8s.io/kubernetes/pkg/apis/extensions"
 	kube_client "k8s.io/kubernetes/pkg/client/restclient"
@@ -583,6 +595,202 @@ func TestPipelineFailure(t *testing.T) {
 	require.Equal(t, pps.JobState_JOB_FAILURE, jobInfo.State)
 }
 
+func TestListDatum(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+	c := getPachClient(t)
+
+	dataRepo := uniqueString("TestListDatum_data")
+	require.NoError(t, c.CreateRepo(dataRepo))
+
+	commit, err := c.StartCommit(dataRepo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(dataRepo, commit.ID, "good", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	_, err = c.PutFile(dataRepo, commit.ID, "bad", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(dataRepo, commit.ID))
+
+	pipeline := uniqueString("pipeline")
+	require.NoError(t, c.CreatePipeline(
+		pipeline,
+		"",
+		[]string{"bash"},
+		[]string{
+			fmt.Sprintf("test ! -e /pfs/%s/bad", dataRepo),
+			fmt.Sprintf("cp /pfs/%s/* /pfs/out/", dataRepo),
+		},
+		&pps.ParallelismSpec{
+			Strategy: pps.ParallelismSpec_CONSTANT,
+			Constant: 1,
+		},
+		client.NewAtomInput(dataRepo, "/*"),
+		"",
+		false,
+	))
+	time.Sleep(20 * time.Second)
+	jobInfos, err := c.ListJob(pipeline, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(jobInfos))
+	jobInfo, err := c.InspectJob(jobInfos[0].Job.ID, true)
+	require.NoError(t, err)
+	require.Equal(t, pps.JobState_JOB_FAILURE, jobInfo.State)
+
+	datumInfos, err := c.ListDatum(jobInfo.Job.ID)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(datumInfos))
+	for _, datumInfo := range datumInfos {
+		require.Equal(t, 1, len(datumInfo.Data))
+		switch datumInfo.Data[0].File.Path {
+		case "/good":
+			require.Equal(t, pps.DatumState_DATUM_SUCCESS, datumInfo.State)
+			require.Equal(t, "", datumInfo.Reason)
+		case "/bad":
+			require.Equal(t, pps.DatumState_DATUM_FAILED, datumInfo.State)
+			require.Equal(t, "exit status 1", datumInfo.Reason)
+		default:
+			t.Fatalf("unexpected datum %v", datumInfo.Data[0].File.Path)
+		}
+		inspected, err := c.InspectDatum(jobInfo.Job.ID, datumInfo.ID)
+		require.NoError(t, err)
+		require.Equal(t, datumInfo.State, inspected.State)
+	}
+	_, err = c.InspectDatum(jobInfo.Job.ID, "nonexistent")
+	require.YesError(t, err)
+}
+
+func TestInspectDatumStats(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+	c := getPachClient(t)
+
+	dataRepo := uniqueString("TestInspectDatumStats_data")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	commit, err := c.StartCommit(dataRepo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(dataRepo, commit.ID, "file", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(dataRepo, commit.ID))
+
+	pipeline := uniqueString("pipeline")
+	require.NoError(t, c.CreatePipeline(
+		pipeline,
+		"",
+		[]string{"bash"},
+		[]string{
+			"sleep 1",
+			fmt.Sprintf("cp /pfs/%s/* /pfs/out/", dataRepo),
+		},
+		&pps.ParallelismSpec{
+			Strategy: pps.ParallelismSpec_CONSTANT,
+			Constant: 1,
+		},
+		client.NewAtomInput(dataRepo, "/*"),
+		"",
+		false,
+	))
+	commitIter, err := c.FlushCommit([]*pfs.Commit{commit}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(collectCommitInfos(t, commitIter)))
+	jobInfos, err := c.ListJob(pipeline, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(jobInfos))
+
+	datumInfos, err := c.ListDatum(jobInfos[0].Job.ID)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(datumInfos))
+	datumInfo, err := c.InspectDatum(jobInfos[0].Job.ID, datumInfos[0].ID)
+	require.NoError(t, err)
+
+	// the input files are resolved, with their hashes and sizes
+	require.Equal(t, 1, len(datumInfo.Data))
+	fileInfo, err := c.InspectFile(dataRepo, commit.ID, "file")
+	require.NoError(t, err)
+	require.Equal(t, "/file", datumInfo.Data[0].File.Path)
+	require.Equal(t, fileInfo.Hash, datumInfo.Data[0].Hash)
+	require.Equal(t, uint64(4), datumInfo.Data[0].SizeBytes)
+
+	require.NotNil(t, datumInfo.Stats)
+	processTime, err := types.DurationFromProto(datumInfo.Stats.ProcessTime)
+	require.NoError(t, err)
+	require.True(t, processTime >= time.Second)
+	require.Equal(t, uint64(4), datumInfo.Stats.DownloadBytes)
+	require.Equal(t, uint64(4), datumInfo.Stats.UploadBytes)
+}
+
+func TestFileProvenance(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+	c := getPachClient(t)
+
+	dataRepo := uniqueString("TestFileProvenance_data")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	commit, err := c.StartCommit(dataRepo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(dataRepo, commit.ID, "a", strings.NewReader("a\n"))
+	require.NoError(t, err)
+	_, err = c.PutFile(dataRepo, commit.ID, "b", strings.NewReader("b\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(dataRepo, commit.ID))
+
+	// each datum writes its own file, and appends to a shared one
+	pipeline := uniqueString("pipeline")
+	require.NoError(t, c.CreatePipeline(
+		pipeline,
+		"",
+		[]string{"bash"},
+		[]string{
+			fmt.Sprintf("cp /pfs/%s/* /pfs/out/", dataRepo),
+			fmt.Sprintf("cat /pfs/%s/* >/pfs/out/all", dataRepo),
+		},
+		&pps.ParallelismSpec{
+			Strategy: pps.ParallelismSpec_CONSTANT,
+			Constant: 1,
+		},
+		client.NewAtomInput(dataRepo, "/*"),
+		"",
+		false,
+	))
+	commitIter, err := c.FlushCommit([]*pfs.Commit{commit}, nil)
+	require.NoError(t, err)
+	commitInfos := collectCommitInfos(t, commitIter)
+	require.Equal(t, 1, len(commitInfos))
+	outputCommit := commitInfos[0].Commit
+	jobInfos, err := c.ListJob(pipeline, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(jobInfos))
+
+	inputPaths := func(path string) []string {
+		fileInfo, err := c.InspectFile(pipeline, outputCommit.ID, path)
+		require.NoError(t, err)
+		var result []string
+		for _, provenance := range fileInfo.Provenance {
+			require.Equal(t, jobInfos[0].Job.ID, provenance.JobID)
+			datumInfo, err := c.InspectDatum(provenance.JobID, provenance.DatumID)
+			require.NoError(t, err)
+			for _, data := range datumInfo.Data {
+				result = append(result, data.File.Path)
+			}
+		}
+		sort.Strings(result)
+		return result
+	}
+	require.Equal(t, []string{"/a"}, inputPaths("a"))
+	require.Equal(t, []string{"/b"}, inputPaths("b"))
+	require.Equal(t, []string{"/a", "/b"}, inputPaths("all"))
+
+	// files that weren't written by a pipeline have no provenance
+	fileInfo, err := c.InspectFile(dataRepo, commit.ID, "a")
+	require.NoError(t, err)
+	require.Equal(t, 0, len(fileInfo.Provenance))
+}
+
 func TestLazyPipelinePropagation(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration tests in short mode")
@@ -942,148 +1150,333 @@ func TestFlushCommit(t *testing.T) {
 	}
 }
 
-func TestFlushCommitAfterCreatePipeline(t *testing.T) {
+func TestFlushJob(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration tests in short mode")
 	}
 
 	t.Parallel()
 	c := getPachClient(t)
-	repo := uniqueString("data")
-	require.NoError(t, c.CreateRepo(repo))
+	prefix := uniqueString("repo")
+	makeRepoName := func(i int) string {
+		return fmt.Sprintf("%s-%d", prefix, i)
+	}
 
-	var commit *pfs.Commit
-	var err error
-	for i := 0; i < 10; i++ {
-		commit, err = c.StartCommit(repo, "")
-		require.NoError(t, err)
-		_, err = c.PutFile(repo, commit.ID, "file", strings.NewReader(fmt.Sprintf("foo%d\n", i)))
-		require.NoError(t, err)
-		require.NoError(t, c.FinishCommit(repo, commit.ID))
+	sourceRepo := makeRepoName(0)
+	require.NoError(t, c.CreateRepo(sourceRepo))
+
+	// Create a three-stage pipeline, the last stage fails if the file
+	// contains "fail"
+	numStages := 3
+	for i := 0; i < numStages; i++ {
+		repo := makeRepoName(i)
+		cmd := []string{"cp", path.Join("/pfs", repo, "file"), "/pfs/out/file"}
+		if i == numStages-1 {
+			cmd = []string{"sh", "-c", fmt.Sprintf("! grep fail %s && cp %s /pfs/out/file", path.Join("/pfs", repo, "file"), path.Join("/pfs", repo, "file"))}
+		}
+		require.NoError(t, c.CreatePipeline(
+			makeRepoName(i+1),
+			"",
+			cmd,
+			nil,
+			&pps.ParallelismSpec{
+				Strategy: pps.ParallelismSpec_CONSTANT,
+				Constant: 1,
+			},
+			client.NewAtomInput(repo, "/*"),
+			"",
+			false,
+		))
 	}
-	require.NoError(t, c.SetBranch(repo, commit.ID, "master"))
+
+	commit, err := c.StartCommit(sourceRepo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(sourceRepo, commit.ID, "file", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(sourceRepo, commit.ID))
+	jobIter, err := c.FlushJob([]*pfs.Commit{client.NewCommit(sourceRepo, commit.ID)}, nil)
+	require.NoError(t, err)
+	jobInfos := collectJobInfos(t, jobIter)
+	require.Equal(t, numStages, len(jobInfos))
+	for i, jobInfo := range jobInfos {
+		require.Equal(t, makeRepoName(i+1), jobInfo.Pipeline.Name)
+		require.Equal(t, pps.JobState_JOB_SUCCESS, jobInfo.State)
+	}
+
+	// Only return the jobs of the last stage
+	jobIter, err = c.FlushJob([]*pfs.Commit{client.NewCommit(sourceRepo, "master")}, []string{makeRepoName(numStages)})
+	require.NoError(t, err)
+	jobInfos = collectJobInfos(t, jobIter)
+	require.Equal(t, 1, len(jobInfos))
+	require.Equal(t, makeRepoName(numStages), jobInfos[0].Pipeline.Name)
+
+	// Failed jobs are returned too
+	commit, err = c.StartCommit(sourceRepo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(sourceRepo, commit.ID, "file", strings.NewReader("fail\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(sourceRepo, commit.ID))
+	jobIter, err = c.FlushJob([]*pfs.Commit{client.NewCommit(sourceRepo, commit.ID)}, nil)
+	require.NoError(t, err)
+	jobInfos = collectJobInfos(t, jobIter)
+	require.Equal(t, numStages, len(jobInfos))
+	require.Equal(t, pps.JobState_JOB_FAILURE, jobInfos[numStages-1].State)
+}
+
+func TestRunPipeline(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	t.Parallel()
+	c := getPachClient(t)
+	dataRepo := uniqueString("TestRunPipeline_data")
+	require.NoError(t, c.CreateRepo(dataRepo))
+
+	commit1, err := c.StartCommit(dataRepo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(dataRepo, commit1.ID, "file", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(dataRepo, commit1.ID))
+	commit2, err := c.StartCommit(dataRepo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(dataRepo, commit2.ID, "file", strings.NewReader("bar\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(dataRepo, commit2.ID))
 
 	pipeline := uniqueString("pipeline")
 	require.NoError(t, c.CreatePipeline(
 		pipeline,
 		"",
-		[]string{"cp", path.Join("/pfs", repo, "file"), "/pfs/out/file"},
+		[]string{"cp", path.Join("/pfs", dataRepo, "file"), "/pfs/out/file"},
 		nil,
 		&pps.ParallelismSpec{
 			Strategy: pps.ParallelismSpec_CONSTANT,
 			Constant: 1,
 		},
-		client.NewAtomInput(repo, "/*"),
+		client.NewAtomInput(dataRepo, "/*"),
 		"",
 		false,
 	))
-	commitIter, err := c.FlushCommit([]*pfs.Commit{client.NewCommit(repo, "master")}, nil)
+	commitIter, err := c.FlushCommit([]*pfs.Commit{commit2}, []*pfs.Repo{client.NewRepo(pipeline)})
 	require.NoError(t, err)
-	collectCommitInfos(t, commitIter)
-}
+	require.Equal(t, 1, len(collectCommitInfos(t, commitIter)))
 
-// TestRecreatePipeline tracks #432
-func TestRecreatePipeline(t *testing.T) {
-	if testing.Short() {
-		t.Skip("Skipping integration tests in short mode")
-	}
+	// Run the pipeline on the older commit, which it hasn't processed
+	job, err := c.RunPipeline(pipeline, []*pfs.Commit{commit1})
+	require.NoError(t, err)
+	jobInfo, err := c.InspectJob(job.ID, true)
+	require.NoError(t, err)
+	require.Equal(t, pps.JobState_JOB_SUCCESS, jobInfo.State)
+	require.Equal(t, commit1.ID, jobInfo.Input.Atom.Commit)
+	commitInfo, err := c.InspectCommit(pipeline, jobInfo.OutputCommit.ID)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(commitInfo.Provenance))
+	require.Equal(t, commit1.ID, commitInfo.Provenance[0].ID)
+	var buf bytes.Buffer
+	require.NoError(t, c.GetFile(pipeline, jobInfo.OutputCommit.ID, "file", 0, 0, &buf))
+	require.Equal(t, "foo\n", buf.String())
 
-	t.Parallel()
-	c := getPachClient(t)
-	repo := uniqueString("data")
-	require.NoError(t, c.CreateRepo(repo))
-	commit, err := c.StartCommit(repo, "master")
+	// Without any commits the pipeline is run on the head of its input branch
+	job, err = c.RunPipeline(pipeline, nil)
 	require.NoError(t, err)
-	_, err = c.PutFile(repo, commit.ID, "file", strings.NewReader("foo"))
+	jobInfo, err = c.InspectJob(job.ID, true)
 	require.NoError(t, err)
-	require.NoError(t, c.FinishCommit(repo, commit.ID))
-	pipeline := uniqueString("pipeline")
-	createPipeline := func() {
-		require.NoError(t, c.CreatePipeline(
-			pipeline,
-			"",
-			[]string{"cp", path.Join("/pfs", repo, "file"), "/pfs/out/file"},
-			nil,
-			&pps.ParallelismSpec{
-				Strategy: pps.ParallelismSpec_CONSTANT,
-				Constant: 1,
-			},
-			client.NewAtomInput(repo, "/*"),
-			"",
-			false,
-		))
-		commitIter, err := c.FlushCommit([]*pfs.Commit{commit}, nil)
-		require.NoError(t, err)
-		require.Equal(t, 1, len(collectCommitInfos(t, commitIter)))
-	}
+	require.Equal(t, commit2.ID, jobInfo.Input.Atom.Commit)
 
-	// Do it twice.  We expect jobs to be created on both runs.
-	createPipeline()
-	time.Sleep(5 * time.Second)
-	require.NoError(t, c.DeleteRepo(pipeline, false))
-	require.NoError(t, c.DeletePipeline(pipeline, true))
-	time.Sleep(5 * time.Second)
-	createPipeline()
+	// Commits must belong to one of the pipeline's inputs
+	_, err = c.RunPipeline(pipeline, []*pfs.Commit{client.NewCommit(pipeline, "master")})
+	require.YesError(t, err)
 }
 
-func TestDeletePipeline(t *testing.T) {
+func TestTriggerPipeline(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration tests in short mode")
 	}
 
 	t.Parallel()
 	c := getPachClient(t)
-	repo := uniqueString("data")
-	require.NoError(t, c.CreateRepo(repo))
-	commit, err := c.StartCommit(repo, "master")
+	dataRepo := uniqueString("TestTriggerPipeline_data")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	commit1, err := c.StartCommit(dataRepo, "master")
 	require.NoError(t, err)
-	_, err = c.PutFile(repo, commit.ID, uuid.NewWithoutDashes(), strings.NewReader("foo"))
+	_, err = c.PutFile(dataRepo, commit1.ID, "file", strings.NewReader("foo\n"))
 	require.NoError(t, err)
-	require.NoError(t, c.FinishCommit(repo, commit.ID))
+	require.NoError(t, c.FinishCommit(dataRepo, commit1.ID))
+
 	pipeline := uniqueString("pipeline")
-	createPipeline := func() {
-		require.NoError(t, c.CreatePipeline(
-			pipeline,
-			"",
-			[]string{"sleep", "20"},
-			nil,
-			&pps.ParallelismSpec{
+	require.NoError(t, c.CreatePipeline(
+		pipeline,
+		"",
+		[]string{"cp", path.Join("/pfs", dataRepo, "file"), "/pfs/out/file"},
+		nil,
+		&pps.ParallelismSpec{
+			Strategy: pps.ParallelismSpec_CONSTANT,
+			Constant: 1,
+		},
+		client.NewAtomInput(dataRepo, "/*"),
+		"",
+		false,
+	))
+	_, err = c.InspectTrigger(pipeline, "run1", false)
+	require.YesError(t, err)
+
+	// Retried triggers return the job that the first one created
+	job, err := c.TriggerPipeline(pipeline, "run1", []*pfs.Commit{commit1})
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		retriedJob, err := c.TriggerPipeline(pipeline, "run1", nil)
+		require.NoError(t, err)
+		require.Equal(t, job.ID, retriedJob.ID)
+	}
+	jobInfo, err := c.InspectTrigger(pipeline, "run1", true)
+	require.NoError(t, err)
+	require.Equal(t, job.ID, jobInfo.Job.ID)
+	require.Equal(t, pps.JobState_JOB_SUCCESS, jobInfo.State)
+	require.Equal(t, commit1.ID, jobInfo.Input.Atom.Commit)
+
+	// Another key creates another job, on the head of the input branch
+	commit2, err := c.StartCommit(dataRepo, "master")
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(dataRepo, commit2.ID))
+	job2, err := c.TriggerPipeline(pipeline, "run2", nil)
+	require.NoError(t, err)
+	require.NotEqual(t, job.ID, job2.ID)
+	jobInfo, err = c.InspectTrigger(pipeline, "run2", true)
+	require.NoError(t, err)
+	require.Equal(t, commit2.ID, jobInfo.Input.Atom.Commit)
+
+	// Concurrent triggers with the same key create one job
+	var eg errgroup.Group
+	jobIDs := make([]string, 5)
+	for i := range jobIDs {
+		i := i
+		eg.Go(func() error {
+			job, err := c.TriggerPipeline(pipeline, "run3", nil)
+			if err != nil {
+				return err
+			}
+			jobIDs[i] = job.ID
+			return nil
+		})
+	}
+	require.NoError(t, eg.Wait())
+	for _, jobID := range jobIDs {
+		require.Equal(t, jobIDs[0], jobID)
+	}
+
+	_, err = c.TriggerPipeline(pipeline, "", nil)
+	require.YesError(t, err)
+}
+
+func TestRunTransaction(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	t.Parallel()
+	c := getPachClient(t)
+	dataRepo1 := uniqueString("TestRunTransaction_data1")
+	dataRepo2 := uniqueString("TestRunTransaction_data2")
+	require.NoError(t, c.CreateRepo(dataRepo1))
+	require.NoError(t, c.CreateRepo(dataRepo2))
+	for _, repo := range []string{dataRepo1, dataRepo2} {
+		_, err := c.StartCommit(repo, "master")
+		require.NoError(t, err)
+		_, err = c.PutFile(repo, "master", "file", strings.NewReader("foo\n"))
+		require.NoError(t, err)
+	}
+
+	// The pipeline and the first commits are created together
+	pipeline := uniqueString("pipeline")
+	transactionInfo, err := c.RunTransaction([]*pps.TransactionOp{
+		{FinishCommit: &pfs.FinishCommitRequest{Commit: client.NewCommit(dataRepo1, "master")}},
+		{FinishCommit: &pfs.FinishCommitRequest{Commit: client.NewCommit(dataRepo2, "master")}},
+		{CreatePipeline: &pps.CreatePipelineRequest{
+			Pipeline: client.NewPipeline(pipeline),
+			Transform: &pps.Transform{
+				Cmd: []string{"bash"},
+				Stdin: []string{
+					fmt.Sprintf("cat /pfs/%s/file /pfs/%s/file > /pfs/out/file", dataRepo1, dataRepo2),
+				},
+			},
+			ParallelismSpec: &pps.ParallelismSpec{
 				Strategy: pps.ParallelismSpec_CONSTANT,
 				Constant: 1,
 			},
-			client.NewAtomInput(repo, "/*"),
-			"",
-			false,
-		))
-	}
-
-	createPipeline()
-	// Wait for the job to start running
-	time.Sleep(5 * time.Second)
-	require.NoError(t, c.DeleteRepo(pipeline, false))
-	require.NoError(t, c.DeletePipeline(pipeline, true))
-	time.Sleep(5 * time.Second)
+			Input: client.NewCrossInput(
+				client.NewAtomInput(dataRepo1, "/"),
+				client.NewAtomInput(dataRepo2, "/"),
+			),
+		}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(transactionInfo.Transaction.Branches))
+	commitInfo1, err := c.InspectCommit(dataRepo1, "master")
+	require.NoError(t, err)
+	commitInfo2, err := c.InspectCommit(dataRepo2, "master")
+	require.NoError(t, err)
+	require.Equal(t, transactionInfo.Transaction.ID, commitInfo1.Transaction.ID)
+	require.Equal(t, commitInfo1.Finished, commitInfo2.Finished)
 
-	// The job should be gone
-	jobs, err := c.ListJob(pipeline, nil)
+	// Commits to both inputs, finished together, are processed by one job
+	transactionInfo, err = c.RunTransaction([]*pps.TransactionOp{
+		{StartCommit: &pfs.StartCommitRequest{Parent: client.NewCommit(dataRepo1, ""), Branch: "master"}},
+		{StartCommit: &pfs.StartCommitRequest{Parent: client.NewCommit(dataRepo2, ""), Branch: "master"}},
+		{DeleteFile: &pfs.DeleteFileRequest{File: client.NewFile(dataRepo1, "master", "file")}},
+		{DeleteFile: &pfs.DeleteFileRequest{File: client.NewFile(dataRepo2, "master", "file")}},
+		{FinishCommit: &pfs.FinishCommitRequest{Commit: client.NewCommit(dataRepo1, "master")}},
+		{FinishCommit: &pfs.FinishCommitRequest{Commit: client.NewCommit(dataRepo2, "master")}},
+	})
 	require.NoError(t, err)
-	require.Equal(t, len(jobs), 0)
+	require.Equal(t, 2, len(transactionInfo.Started))
+	commitIter, err := c.FlushCommit(transactionInfo.Started, []*pfs.Repo{client.NewRepo(pipeline)})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(collectCommitInfos(t, commitIter)))
+	jobInfos, err := c.ListJob(pipeline, nil)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(jobInfos))
 
-	createPipeline()
-	// Wait for the job to start running
-	time.Sleep(5 * time.Second)
-	require.NoError(t, c.DeleteRepo(pipeline, false))
-	require.NoError(t, c.DeletePipeline(pipeline, false))
-	time.Sleep(5 * time.Second)
+	// A failed transaction is rolled back
+	_, err = c.RunTransaction([]*pps.TransactionOp{
+		{StartCommit: &pfs.StartCommitRequest{Parent: client.NewCommit(dataRepo1, ""), Branch: "master"}},
+		{FinishCommit: &pfs.FinishCommitRequest{Commit: client.NewCommit(dataRepo2, "master")}},
+	})
+	require.YesError(t, err)
+	commitInfo, err := c.InspectCommit(dataRepo1, "master")
+	require.NoError(t, err)
+	require.Equal(t, transactionInfo.Started[0].ID, commitInfo.Commit.ID)
 
-	// The job should still be there, and its state should be "KILLED"
-	jobs, err = c.ListJob(pipeline, nil)
+	// So is one whose commits can't be finished: the commits that it
+	// started are deleted, and the files that it deleted are kept
+	commit, err := c.StartCommit(dataRepo2, "master")
 	require.NoError(t, err)
-	require.Equal(t, len(jobs), 1)
-	require.Equal(t, pps.JobState_JOB_STOPPED, jobs[0].State)
+	_, err = c.PutFile(dataRepo2, commit.ID, "file", strings.NewReader("bar\n"))
+	require.NoError(t, err)
+	_, err = c.RunTransaction([]*pps.TransactionOp{
+		{StartCommit: &pfs.StartCommitRequest{Parent: client.NewCommit(dataRepo1, ""), Branch: "master"}},
+		{DeleteFile: &pfs.DeleteFileRequest{File: client.NewFile(dataRepo2, commit.ID, "file")}},
+		// a commit can't be finished twice
+		{FinishCommit: &pfs.FinishCommitRequest{Commit: commit}},
+		{FinishCommit: &pfs.FinishCommitRequest{Commit: commit}},
+	})
+	require.YesError(t, err)
+	commitInfo, err = c.InspectCommit(dataRepo1, "master")
+	require.NoError(t, err)
+	require.Equal(t, transactionInfo.Started[0].ID, commitInfo.Commit.ID)
+	commitInfos, err := c.ListCommit(dataRepo1, "", "", 0)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(commitInfos))
+	require.NoError(t, c.FinishCommit(dataRepo2, commit.ID))
+	var buf bytes.Buffer
+	require.NoError(t, c.GetFile(dataRepo2, commit.ID, "file", 0, 0, &buf))
+	require.Equal(t, "bar\n", buf.String())
+
+	_, err = c.RunTransaction([]*pps.TransactionOp{{}})
+	require.YesError(t, err)
 }
 
-func TestPipelineState(t *testing.T) {
+func TestFlushCommitAfterCreatePipeline(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration tests in short mode")
 	}
@@ -1092,6 +1485,18 @@ func TestPipelineState(t *testing.T) {
 	c := getPachClient(t)
 	repo := uniqueString("data")
 	require.NoError(t, c.CreateRepo(repo))
+
+	var commit *pfs.Commit
+	var err error
+	for i := 0; i < 10; i++ {
+		commit, err = c.StartCommit(repo, "")
+		require.NoError(t, err)
+		_, err = c.PutFile(repo, commit.ID, "file", strings.NewReader(fmt.Sprintf("foo%d\n", i)))
+		require.NoError(t, err)
+		require.NoError(t, c.FinishCommit(repo, commit.ID))
+	}
+	require.NoError(t, c.SetBranch(repo, commit.ID, "master"))
+
 	pipeline := uniqueString("pipeline")
 	require.NoError(t, c.CreatePipeline(
 		pipeline,
@@ -1106,29 +1511,56 @@ func TestPipelineState(t *testing.T) {
 		"",
 		false,
 	))
-	// Wait for pipeline to get picked up
-	time.Sleep(5 * time.Second)
-
-	pipelineInfo, err := c.InspectPipeline(pipeline)
+	commitIter, err := c.FlushCommit([]*pfs.Commit{client.NewCommit(repo, "master")}, nil)
 	require.NoError(t, err)
-	require.Equal(t, pps.PipelineState_PIPELINE_RUNNING, pipelineInfo.State)
+	collectCommitInfos(t, commitIter)
+}
 
-	require.NoError(t, c.StopPipeline(pipeline))
-	time.Sleep(5 * time.Second)
+// TestRecreatePipeline tracks #432
+func TestRecreatePipeline(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
 
-	pipelineInfo, err = c.InspectPipeline(pipeline)
+	t.Parallel()
+	c := getPachClient(t)
+	repo := uniqueString("data")
+	require.NoError(t, c.CreateRepo(repo))
+	commit, err := c.StartCommit(repo, "master")
 	require.NoError(t, err)
-	require.Equal(t, pps.PipelineState_PIPELINE_STOPPED, pipelineInfo.State)
+	_, err = c.PutFile(repo, commit.ID, "file", strings.NewReader("foo"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+	pipeline := uniqueString("pipeline")
+	createPipeline := func() {
+		require.NoError(t, c.CreatePipeline(
+			pipeline,
+			"",
+			[]string{"cp", path.Join("/pfs", repo, "file"), "/pfs/out/file"},
+			nil,
+			&pps.ParallelismSpec{
+				Strategy: pps.ParallelismSpec_CONSTANT,
+				Constant: 1,
+			},
+			client.NewAtomInput(repo, "/*"),
+			"",
+			false,
+		))
+		commitIter, err := c.FlushCommit([]*pfs.Commit{commit}, nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(collectCommitInfos(t, commitIter)))
+	}
 
-	require.NoError(t, c.StartPipeline(pipeline))
+	// Do it twice.  We expect jobs to be created on both runs.
+	createPipeline()
 	time.Sleep(5 * time.Second)
-
-	pipelineInfo, err = c.InspectPipeline(pipeline)
-	require.NoError(t, err)
-	require.Equal(t, pps.PipelineState_PIPELINE_RUNNING, pipelineInfo.State)
+	require.NoError(t, c.DeleteRepo(pipeline, false))
+	require.NoError(t, c.DeletePipeline(pipeline, true))
+	time.Sleep(5 * time.Second)
+	createPipeline()
 }
 
-func TestPipelineJobCounts(t *testing.T) {
+func TestRewriteInputHistory(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration tests in short mode")
 	}
@@ -1147,141 +1579,1299 @@ func TestPipelineJobCounts(t *testing.T) {
 			Strategy: pps.ParallelismSpec_CONSTANT,
 			Constant: 1,
 		},
-		client.NewAtomInput(repo, "/*"),
+		client.NewAtomInput(repo, "/"),
 		"",
 		false,
 	))
+	var commits []*pfs.Commit
+	for i, content := range []string{"foo", "bar", "baz"} {
+		commit, err := c.StartCommit(repo, "master")
+		require.NoError(t, err)
+		if i > 0 {
+			require.NoError(t, c.DeleteFile(repo, commit.ID, "file"))
+		}
+		_, err = c.PutFile(repo, commit.ID, "file", strings.NewReader(content))
+		require.NoError(t, err)
+		require.NoError(t, c.FinishCommit(repo, commit.ID))
+		commitIter, err := c.FlushCommit([]*pfs.Commit{commit}, []*pfs.Repo{client.NewRepo(pipeline)})
+		require.NoError(t, err)
+		require.Equal(t, 1, len(collectCommitInfos(t, commitIter)))
+		commits = append(commits, commit)
+	}
+	// waitForOutput waits for the output branch to have content
+	waitForOutput := func(content string) {
+		started := time.Now()
+		for {
+			var buf bytes.Buffer
+			if err := c.GetFile(pipeline, "master", "file", 0, 0, &buf); err == nil && buf.String() == content {
+				return
+			}
+			if time.Since(started) > 30*time.Second {
+				t.Fatalf("output is %q rather than %q", buf.String(), content)
+			}
+			time.Sleep(time.Second)
+		}
+	}
+	waitForOutput("baz")
 
-	// Trigger a job by creating a commit
-	commit, err := c.StartCommit(repo, "master")
-	require.NoError(t, err)
-	_, err = c.PutFile(repo, commit.ID, "file", strings.NewReader("foo"))
-	require.NoError(t, err)
-	require.NoError(t, c.FinishCommit(repo, commit.ID))
-	commitIter, err := c.FlushCommit([]*pfs.Commit{commit}, nil)
-	require.NoError(t, err)
-	collectCommitInfos(t, commitIter)
+	// deleting the last input commit deletes its output, and moves the
+	// output branch back to the second commit's output
+	require.NoError(t, c.DeleteCommit(repo, commits[2].ID))
+	_, err := c.InspectCommit(repo, commits[2].ID)
+	require.YesError(t, err)
+	waitForOutput("bar")
+
+	// moving the input branch back moves the output branch back to the
+	// output the pipeline already computed for it, without a new job
 	jobInfos, err := c.ListJob(pipeline, nil)
 	require.NoError(t, err)
-	require.Equal(t, 1, len(jobInfos))
-	inspectJobRequest := &pps.InspectJobRequest{
-		Job:        jobInfos[0].Job,
-		BlockState: true,
-	}
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
-	defer cancel() //cleanup resources
-	_, err = c.PpsAPIClient.InspectJob(ctx, inspectJobRequest)
-	require.NoError(t, err)
-
-	// check that the job has been accounted for
-	pipelineInfo, err := c.InspectPipeline(pipeline)
+	require.NoError(t, c.SetBranch(repo, commits[0].ID, "master"))
+	waitForOutput("foo")
+	jobInfosAfter, err := c.ListJob(pipeline, nil)
 	require.NoError(t, err)
-	require.Equal(t, int32(1), pipelineInfo.JobCounts[int32(pps.JobState_JOB_SUCCESS)])
+	require.Equal(t, len(jobInfos), len(jobInfosAfter))
 }
 
-//func TestJobState(t *testing.T) {
-//if testing.Short() {
-//t.Skip("Skipping integration tests in short mode")
-//}
+func TestPipelinesShareDatums(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
 
-//t.Parallel()
-//c := getPachClient(t)
+	t.Parallel()
+	c := getPachClient(t)
+	repo := uniqueString("data")
+	require.NoError(t, c.CreateRepo(repo))
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit.ID, "file", strings.NewReader("foo"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
 
-//// This job uses a nonexistent image; it's supposed to stay in the
-//// "creating" state
+	// The output is different each time the datum is processed, so the
+	// pipelines only have the same output if the second reuses the first's
+	var outputs []string
+	for i := 0; i < 2; i++ {
+		pipeline := uniqueString("pipeline")
+		require.NoError(t, c.CreatePipeline(
+			pipeline,
+			"",
+			[]string{"bash"},
+			[]string{
+				fmt.Sprintf("cat /pfs/%s/file > /pfs/out/file", repo),
+				"date +%s%N >> /pfs/out/file",
+			},
+			nil,
+			client.NewAtomInput(repo, "/*"),
+			"",
+			false,
+		))
+		commitIter, err := c.FlushCommit([]*pfs.Commit{commit}, []*pfs.Repo{client.NewRepo(pipeline)})
+		require.NoError(t, err)
+		commitInfos := collectCommitInfos(t, commitIter)
+		require.Equal(t, 1, len(commitInfos))
+		var buffer bytes.Buffer
+		require.NoError(t, c.GetFile(pipeline, commitInfos[0].Commit.ID, "file", 0, 0, &buffer))
+		outputs = append(outputs, buffer.String())
+	}
+	require.Equal(t, outputs[0], outputs[1])
+}
+
+func TestClearDatumCache(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	t.Parallel()
+	c := getPachClient(t)
+	repo := uniqueString("TestClearDatumCache_data")
+	require.NoError(t, c.CreateRepo(repo))
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit.ID, "a.csv", strings.NewReader("foo"))
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit.ID, "b.txt", strings.NewReader("bar"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+
+	// The output is different each time a datum is processed, so it only
+	// stays the same if the datum's output is reused
+	pipeline := uniqueString("pipeline")
+	require.NoError(t, c.CreatePipeline(
+		pipeline,
+		"",
+		[]string{"bash"},
+		[]string{
+			fmt.Sprintf("for f in /pfs/%s/*; do date +%%s%%N > /pfs/out/$(basename $f); done", repo),
+		},
+		nil,
+		client.NewAtomInput(repo, "/*"),
+		"",
+		false,
+	))
+	commitIter, err := c.FlushCommit([]*pfs.Commit{commit}, []*pfs.Repo{client.NewRepo(pipeline)})
+	require.NoError(t, err)
+	commitInfos := collectCommitInfos(t, commitIter)
+	require.Equal(t, 1, len(commitInfos))
+	outputs := func(outputCommit *pfs.Commit) (string, string) {
+		var a, b bytes.Buffer
+		require.NoError(t, c.GetFile(pipeline, outputCommit.ID, "a.csv", 0, 0, &a))
+		require.NoError(t, c.GetFile(pipeline, outputCommit.ID, "b.txt", 0, 0, &b))
+		return a.String(), b.String()
+	}
+	run := func() *pfs.Commit {
+		job, err := c.RunPipeline(pipeline, nil)
+		require.NoError(t, err)
+		jobInfo, err := c.InspectJob(job.ID, true)
+		require.NoError(t, err)
+		require.Equal(t, pps.JobState_JOB_SUCCESS, jobInfo.State)
+		return jobInfo.OutputCommit
+	}
+	a1, b1 := outputs(commitInfos[0].Commit)
+
+	// running the pipeline again reuses both outputs
+	a2, b2 := outputs(run())
+	require.Equal(t, a1, a2)
+	require.Equal(t, b1, b2)
+
+	// only the datums matching the glob are processed again
+	require.NoError(t, c.ClearDatumCache(pipeline, "*.csv"))
+	a3, b3 := outputs(run())
+	require.NotEqual(t, a2, a3)
+	require.Equal(t, b2, b3)
+
+	// and then their new outputs are reused
+	a4, _ := outputs(run())
+	require.Equal(t, a3, a4)
+
+	// without a glob, every datum is processed again
+	require.NoError(t, c.ClearDatumCache(pipeline, ""))
+	a5, b5 := outputs(run())
+	require.NotEqual(t, a4, a5)
+	require.NotEqual(t, b3, b5)
+
+	pipelineInfo, err := c.InspectPipeline(pipeline)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(pipelineInfo.DatumCacheInvalidations))
+
+	require.YesError(t, c.ClearDatumCache(pipeline, "["))
+	require.YesError(t, c.ClearDatumCache(uniqueString("no-such-pipeline"), ""))
+}
+
+func TestDatumCacheTTL(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	t.Parallel()
+	c := getPachClient(t)
+	repo := uniqueString("TestDatumCacheTTL_data")
+	require.NoError(t, c.CreateRepo(repo))
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit.ID, "file", strings.NewReader("foo"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+
+	pipeline := uniqueString("pipeline")
+	_, err = c.PpsAPIClient.CreatePipeline(context.Background(), &pps.CreatePipelineRequest{
+		Pipeline: client.NewPipeline(pipeline),
+		Transform: &pps.Transform{
+			Cmd:   []string{"bash"},
+			Stdin: []string{"date +%s%N > /pfs/out/file"},
+		},
+		Input:         client.NewAtomInput(repo, "/*"),
+		DatumCacheTTL: types.DurationProto(5 * time.Second),
+	})
+	require.NoError(t, err)
+	commitIter, err := c.FlushCommit([]*pfs.Commit{commit}, []*pfs.Repo{client.NewRepo(pipeline)})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(collectCommitInfos(t, commitIter)))
+	pipelineInfo, err := c.InspectPipeline(pipeline)
+	require.NoError(t, err)
+	require.NotNil(t, pipelineInfo.DatumCacheTTL)
+
+	// once the TTL has passed, the datum is processed again
+	var outputs []string
+	for i := 0; i < 2; i++ {
+		time.Sleep(5 * time.Second)
+		job, err := c.RunPipeline(pipeline, nil)
+		require.NoError(t, err)
+		jobInfo, err := c.InspectJob(job.ID, true)
+		require.NoError(t, err)
+		var buf bytes.Buffer
+		require.NoError(t, c.GetFile(pipeline, jobInfo.OutputCommit.ID, "file", 0, 0, &buf))
+		outputs = append(outputs, buf.String())
+	}
+	require.NotEqual(t, outputs[0], outputs[1])
+
+	// the TTL must be positive
+	_, err = c.PpsAPIClient.CreatePipeline(context.Background(), &pps.CreatePipelineRequest{
+		Pipeline: client.NewPipeline(uniqueString("pipeline")),
+		Transform: &pps.Transform{
+			Cmd: []string{"true"},
+		},
+		Input:         client.NewAtomInput(repo, "/*"),
+		DatumCacheTTL: types.DurationProto(-time.Second),
+	})
+	require.YesError(t, err)
+}
+
+func TestSchedulingSpec(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	t.Parallel()
+	c := getPachClient(t)
+	repo := uniqueString("TestSchedulingSpec_data")
+	require.NoError(t, c.CreateRepo(repo))
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit.ID, "file", strings.NewReader("foo"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+
+	pipeline := uniqueString("pipeline")
+	_, err = c.PpsAPIClient.CreatePipeline(context.Background(), &pps.CreatePipelineRequest{
+		Pipeline: client.NewPipeline(pipeline),
+		Transform: &pps.Transform{
+			Cmd:   []string{"bash"},
+			Stdin: []string{"cp /pfs/*/file /pfs/out/file"},
+		},
+		Input: client.NewAtomInput(repo, "/*"),
+		SchedulingSpec: &pps.SchedulingSpec{
+			Priority: -1,
+			Team:     "backfills",
+		},
+	})
+	require.NoError(t, err)
+	commitIter, err := c.FlushCommit([]*pfs.Commit{commit}, []*pfs.Repo{client.NewRepo(pipeline)})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(collectCommitInfos(t, commitIter)))
+	pipelineInfo, err := c.InspectPipeline(pipeline)
+	require.NoError(t, err)
+	require.Equal(t, int64(-1), pipelineInfo.SchedulingSpec.Priority)
+	require.Equal(t, "backfills", pipelineInfo.SchedulingSpec.Team)
+	jobInfos, err := c.ListJob(pipeline, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(jobInfos))
+	require.Equal(t, "backfills", jobInfos[0].SchedulingSpec.Team)
+
+	// teams can't contain commas, which separate pachd's team weights
+	_, err = c.PpsAPIClient.CreatePipeline(context.Background(), &pps.CreatePipelineRequest{
+		Pipeline: client.NewPipeline(uniqueString("pipeline")),
+		Transform: &pps.Transform{
+			Cmd: []string{"true"},
+		},
+		Input:          client.NewAtomInput(repo, "/*"),
+		SchedulingSpec: &pps.SchedulingSpec{Team: "a,b"},
+	})
+	require.YesError(t, err)
+}
+
+func TestListJobNoFull(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	t.Parallel()
+	c := getPachClient(t)
+	repo := uniqueString("data")
+	require.NoError(t, c.CreateRepo(repo))
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit.ID, "file", strings.NewReader("foo"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+	pipeline := uniqueString("pipeline")
+	require.NoError(t, c.CreatePipeline(
+		pipeline,
+		"",
+		[]string{"cp", path.Join("/pfs", repo, "file"), "/pfs/out/file"},
+		nil,
+		nil,
+		client.NewAtomInput(repo, "/*"),
+		"",
+		false,
+	))
+	commitIter, err := c.FlushCommit([]*pfs.Commit{commit}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(collectCommitInfos(t, commitIter)))
+
+	jobInfos, err := c.ListJob(pipeline, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(jobInfos))
+	require.NotNil(t, jobInfos[0].Transform)
+	require.NotNil(t, jobInfos[0].Input)
+
+	briefJobInfos, err := c.ListJobNoFull(pipeline, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(briefJobInfos))
+	require.Equal(t, jobInfos[0].Job.ID, briefJobInfos[0].Job.ID)
+	require.Equal(t, pps.JobState_JOB_SUCCESS, briefJobInfos[0].State)
+	require.Equal(t, jobInfos[0].OutputCommit.ID, briefJobInfos[0].OutputCommit.ID)
+	require.Nil(t, briefJobInfos[0].Transform)
+	require.Nil(t, briefJobInfos[0].Input)
+}
+
+func TestDeletePipeline(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	t.Parallel()
+	c := getPachClient(t)
+	repo := uniqueString("data")
+	require.NoError(t, c.CreateRepo(repo))
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit.ID, uuid.NewWithoutDashes(), strings.NewReader("foo"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+	pipeline := uniqueString("pipeline")
+	createPipeline := func() {
+		require.NoError(t, c.CreatePipeline(
+			pipeline,
+			"",
+			[]string{"sleep", "20"},
+			nil,
+			&pps.ParallelismSpec{
+				Strategy: pps.ParallelismSpec_CONSTANT,
+				Constant: 1,
+			},
+			client.NewAtomInput(repo, "/*"),
+			"",
+			false,
+		))
+	}
+
+	createPipeline()
+	// Wait for the job to start running
+	time.Sleep(5 * time.Second)
+	require.NoError(t, c.DeleteRepo(pipeline, false))
+	require.NoError(t, c.DeletePipeline(pipeline, true))
+	time.Sleep(5 * time.Second)
+
+	// The job should be gone
+	jobs, err := c.ListJob(pipeline, nil)
+	require.NoError(t, err)
+	require.Equal(t, len(jobs), 0)
+
+	createPipeline()
+	// Wait for the job to start running
+	time.Sleep(5 * time.Second)
+	require.NoError(t, c.DeleteRepo(pipeline, false))
+	require.NoError(t, c.DeletePipeline(pipeline, false))
+	time.Sleep(5 * time.Second)
+
+	// The job should still be there, and its state should be "KILLED"
+	jobs, err = c.ListJob(pipeline, nil)
+	require.NoError(t, err)
+	require.Equal(t, len(jobs), 1)
+	require.Equal(t, pps.JobState_JOB_STOPPED, jobs[0].State)
+}
+
+func TestPipelineState(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	t.Parallel()
+	c := getPachClient(t)
+	repo := uniqueString("data")
+	require.NoError(t, c.CreateRepo(repo))
+	pipeline := uniqueString("pipeline")
+	require.NoError(t, c.CreatePipeline(
+		pipeline,
+		"",
+		[]string{"cp", path.Join("/pfs", repo, "file"), "/pfs/out/file"},
+		nil,
+		&pps.ParallelismSpec{
+			Strategy: pps.ParallelismSpec_CONSTANT,
+			Constant: 1,
+		},
+		client.NewAtomInput(repo, "/*"),
+		"",
+		false,
+	))
+	// Wait for pipeline to get picked up
+	time.Sleep(5 * time.Second)
+
+	pipelineInfo, err := c.InspectPipeline(pipeline)
+	require.NoError(t, err)
+	require.Equal(t, pps.PipelineState_PIPELINE_RUNNING, pipelineInfo.State)
+
+	require.NoError(t, c.StopPipeline(pipeline))
+	time.Sleep(5 * time.Second)
+
+	pipelineInfo, err = c.InspectPipeline(pipeline)
+	require.NoError(t, err)
+	require.Equal(t, pps.PipelineState_PIPELINE_STOPPED, pipelineInfo.State)
+
+	require.NoError(t, c.StartPipeline(pipeline))
+	time.Sleep(5 * time.Second)
+
+	pipelineInfo, err = c.InspectPipeline(pipeline)
+	require.NoError(t, err)
+	require.Equal(t, pps.PipelineState_PIPELINE_RUNNING, pipelineInfo.State)
+}
+
+func TestPipelineJobCounts(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	t.Parallel()
+	c := getPachClient(t)
+	repo := uniqueString("data")
+	require.NoError(t, c.CreateRepo(repo))
+	pipeline := uniqueString("pipeline")
+	require.NoError(t, c.CreatePipeline(
+		pipeline,
+		"",
+		[]string{"cp", path.Join("/pfs", repo, "file"), "/pfs/out/file"},
+		nil,
+		&pps.ParallelismSpec{
+			Strategy: pps.ParallelismSpec_CONSTANT,
+			Constant: 1,
+		},
+		client.NewAtomInput(repo, "/*"),
+		"",
+		false,
+	))
+
+	// Trigger a job by creating a commit
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit.ID, "file", strings.NewReader("foo"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+	commitIter, err := c.FlushCommit([]*pfs.Commit{commit}, nil)
+	require.NoError(t, err)
+	collectCommitInfos(t, commitIter)
+	jobInfos, err := c.ListJob(pipeline, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(jobInfos))
+	inspectJobRequest := &pps.InspectJobRequest{
+		Job:        jobInfos[0].Job,
+		BlockState: true,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel() //cleanup resources
+	_, err = c.PpsAPIClient.InspectJob(ctx, inspectJobRequest)
+	require.NoError(t, err)
+
+	// check that the job has been accounted for
+	pipelineInfo, err := c.InspectPipeline(pipeline)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), pipelineInfo.JobCounts[int32(pps.JobState_JOB_SUCCESS)])
+}
+
+//func TestJobState(t *testing.T) {
+//if testing.Short() {
+//t.Skip("Skipping integration tests in short mode")
+//}
+
+//t.Parallel()
+//c := getPachClient(t)
+
+//// This job uses a nonexistent image; it's supposed to stay in the
+//// "creating" state
 //job, err := c.CreateJob(
 //"nonexistent",
 //[]string{"bash"},
 //nil,
 //&pps.ParallelismSpec{},
-//nil,
+//nil,
+//"",
+//0,
+//0,
+//)
+//require.NoError(t, err)
+//time.Sleep(10 * time.Second)
+//jobInfo, err := c.InspectJob(job.ID, false)
+//require.NoError(t, err)
+//require.Equal(t, pps.JobState_JOB_CREATING, jobInfo.State)
+
+//// This job sleeps for 20 secs
+//job, err = c.CreateJob(
+//"",
+//[]string{"bash"},
+//[]string{"sleep 20"},
+//&pps.ParallelismSpec{},
+//nil,
+//"",
+//0,
+//0,
+//)
+//require.NoError(t, err)
+//time.Sleep(10 * time.Second)
+//jobInfo, err = c.InspectJob(job.ID, false)
+//require.NoError(t, err)
+//require.Equal(t, pps.JobState_JOB_RUNNING, jobInfo.State)
+
+//// Wait for the job to complete
+//jobInfo, err = c.InspectJob(job.ID, true)
+//require.NoError(t, err)
+//require.Equal(t, pps.JobState_JOB_SUCCESS, jobInfo.State)
+//}
+
+//func TestClusterFunctioningAfterMembershipChange(t *testing.T) {
+//t.Skip("this test is flaky")
+//if testing.Short() {
+//t.Skip("Skipping integration tests in short mode")
+//}
+
+//scalePachd(t, true)
+//testJob(t, 4)
+//scalePachd(t, false)
+//testJob(t, 4)
+//}
+
+// TODO(msteffen): This test breaks the suite when run against cloud providers,
+// because killing the pachd pod breaks the connection with pachctl port-forward
+func TestDeleteAfterMembershipChange(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	test := func(up bool) {
+		repo := uniqueString("TestDeleteAfterMembershipChange")
+		c := getPachClient(t)
+		require.NoError(t, c.CreateRepo(repo))
+		_, err := c.StartCommit(repo, "master")
+		require.NoError(t, err)
+		require.NoError(t, c.FinishCommit(repo, "master"))
+		scalePachdRandom(t, up)
+		c = getUsablePachClient(t)
+		require.NoError(t, c.DeleteRepo(repo, false))
+	}
+	test(true)
+	test(false)
+}
+
+// TODO(msteffen): This test breaks the suite when run against cloud providers,
+// because killing the pachd pod breaks the connection with pachctl port-forward
+func TestPachdRestartResumesRunningJobs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	// this test cannot be run in parallel because it restarts everything which breaks other tests.
+	c := getPachClient(t)
+	// create repos
+	dataRepo := uniqueString("TestPachdRestartPickUpRunningJobs")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	// create pipeline
+	pipelineName := uniqueString("pipeline")
+	require.NoError(t, c.CreatePipeline(
+		pipelineName,
+		"",
+		[]string{"bash"},
+		[]string{
+			"sleep 10",
+		},
+		&pps.ParallelismSpec{
+			Strategy: pps.ParallelismSpec_CONSTANT,
+			Constant: 1,
+		},
+		client.NewAtomInput(dataRepo, "/"),
+		"",
+		false,
+	))
+	commit, err := c.StartCommit(dataRepo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(dataRepo, commit.ID, "file", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(dataRepo, commit.ID))
+
+	time.Sleep(5 * time.Second)
+
+	jobInfos, err := c.ListJob(pipelineName, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(jobInfos))
+	require.Equal(t, pps.JobState_JOB_RUNNING, jobInfos[0].State)
+
+	restartOne(t)
+	// need a new client because the old one will have a defunct connection
+	c = getUsablePachClient(t)
+
+	jobInfo, err := c.InspectJob(jobInfos[0].Job.ID, true)
+	require.NoError(t, err)
+	require.Equal(t, pps.JobState_JOB_SUCCESS, jobInfo.State)
+}
+
+// TODO(msteffen): This test breaks the suite when run against cloud providers,
+// because killing the pachd pod breaks the connection with pachctl port-forward
+func TestPachdGracefulRestartDoesntRestartDatums(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	// this test cannot be run in parallel because it restarts everything which breaks other tests.
+	c := getPachClient(t)
+	dataRepo := uniqueString("TestPachdGracefulRestartDoesntRestartDatums_data")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	pipelineName := uniqueString("pipeline")
+	// /tmp outlives the datum in the worker's container, so if the datum is
+	// processed again, "runs" has two lines
+	require.NoError(t, c.CreatePipeline(
+		pipelineName,
+		"",
+		[]string{"bash"},
+		[]string{
+			"echo run >> /tmp/runs",
+			"sleep 30",
+			"cp /tmp/runs /pfs/out/runs",
+		},
+		&pps.ParallelismSpec{
+			Strategy: pps.ParallelismSpec_CONSTANT,
+			Constant: 1,
+		},
+		client.NewAtomInput(dataRepo, "/"),
+		"",
+		false,
+	))
+	commit, err := c.StartCommit(dataRepo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(dataRepo, commit.ID, "file", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(dataRepo, commit.ID))
+
+	time.Sleep(10 * time.Second)
+	jobInfos, err := c.ListJob(pipelineName, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(jobInfos))
+	require.Equal(t, pps.JobState_JOB_RUNNING, jobInfos[0].State)
+
+	restartOneGracefully(t)
+	c = getUsablePachClient(t)
+
+	jobInfo, err := c.InspectJob(jobInfos[0].Job.ID, true)
+	require.NoError(t, err)
+	require.Equal(t, pps.JobState_JOB_SUCCESS, jobInfo.State)
+	require.Equal(t, uint64(0), jobInfo.Restart)
+	var buf bytes.Buffer
+	require.NoError(t, c.GetFile(jobInfo.OutputCommit.Repo.Name, jobInfo.OutputCommit.ID, "runs", 0, 0, &buf))
+	require.Equal(t, "run\n", buf.String())
+}
+
+// TODO(msteffen): This test breaks the suite when run against cloud providers,
+// because killing the pachd pod breaks the connection with pachctl port-forward
+func TestPachdReplicasCreateOneJobPerCommit(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	// this test cannot be run in parallel because it scales pachd, which
+	// breaks other tests.
+	scalePachdN(t, 3)
+	defer scalePachdN(t, 1)
+	c := getUsablePachClient(t)
+	dataRepo := uniqueString("TestPachdReplicasCreateOneJobPerCommit_data")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	pipelineName := uniqueString("pipeline")
+	require.NoError(t, c.CreatePipeline(
+		pipelineName,
+		"",
+		[]string{"bash"},
+		[]string{
+			fmt.Sprintf("cp /pfs/%s/* /pfs/out/", dataRepo),
+		},
+		&pps.ParallelismSpec{
+			Strategy: pps.ParallelismSpec_CONSTANT,
+			Constant: 1,
+		},
+		client.NewAtomInput(dataRepo, "/"),
+		"",
+		false,
+	))
+
+	numCommits := 5
+	var commit *pfs.Commit
+	for i := 0; i < numCommits; i++ {
+		var err error
+		commit, err = c.StartCommit(dataRepo, "master")
+		require.NoError(t, err)
+		_, err = c.PutFile(dataRepo, commit.ID, fmt.Sprintf("file-%d", i), strings.NewReader("foo\n"))
+		require.NoError(t, err)
+		require.NoError(t, c.FinishCommit(dataRepo, commit.ID))
+		if i == numCommits/2 {
+			// moving the pipeline's master between pachds mustn't create
+			// jobs twice either
+			scalePachdN(t, 2)
+			c = getUsablePachClient(t)
+		}
+	}
+	commitIter, err := c.FlushCommit([]*pfs.Commit{commit}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(collectCommitInfos(t, commitIter)))
+
+	jobInfos, err := c.ListJob(pipelineName, nil)
+	require.NoError(t, err)
+	seen := make(map[string]bool)
+	for _, jobInfo := range jobInfos {
+		commitID := jobInfo.Input.Atom.Commit
+		require.False(t, seen[commitID], "more than one job was created for commit %s", commitID)
+		seen[commitID] = true
+	}
+}
+
+//func TestScrubbedErrors(t *testing.T) {
+//if testing.Short() {
+//t.Skip("Skipping integration tests in short mode")
+//}
+
+//t.Parallel()
+//c := getPachClient(t)
+
+//_, err := c.InspectPipeline("blah")
+//require.Equal(t, "PipelineInfos blah not found", err.Error())
+
+//err = c.CreatePipeline(
+//"lskdjf$#%^ERTYC",
+//"",
+//[]string{},
+//nil,
+//&pps.ParallelismSpec{
+//Strategy: pps.ParallelismSpec_CONSTANT,
+//Constant: 1,
+//},
+//[]*pps.PipelineInput{{Repo: &pfs.Repo{Name: "test"}}},
+//false,
+//)
+//require.Equal(t, "repo test not found", err.Error())
+
+//_, err = c.CreateJob(
+//"askjdfhgsdflkjh",
+//[]string{},
+//[]string{},
+//&pps.ParallelismSpec{},
+//[]*pps.JobInput{client.NewJobInput("bogusRepo", "bogusCommit", client.DefaultMethod)},
 //"",
 //0,
 //0,
 //)
+//require.Matches(t, "could not create repo job_.*, not all provenance repos exist", err.Error())
+
+//_, err = c.InspectJob("blah", true)
+//require.Equal(t, "JobInfos blah not found", err.Error())
+
+//home := os.Getenv("HOME")
+//f, err := os.Create(filepath.Join(home, "/tmpfile"))
+//defer func() {
+//os.Remove(filepath.Join(home, "/tmpfile"))
+//}()
 //require.NoError(t, err)
-//time.Sleep(10 * time.Second)
-//jobInfo, err := c.InspectJob(job.ID, false)
+//err = c.GetLogs("bogusJobId", f)
+//require.Equal(t, "job bogusJobId not found", err.Error())
+//}
+
+//func TestLeakingRepo(t *testing.T) {
+//// If CreateJob fails, it should also destroy the output repo it creates
+//// If it doesn't, it can cause flush commit to fail, as a bogus repo will
+//// be listed in the output repo's provenance
+
+//// This test can't be run in parallel, since it requires using the repo counts as controls
+//if testing.Short() {
+//t.Skip("Skipping integration tests in short mode")
+//}
+
+//c := getPachClient(t)
+
+//repoInfos, err := c.ListRepo(nil)
 //require.NoError(t, err)
-//require.Equal(t, pps.JobState_JOB_CREATING, jobInfo.State)
+//initialCount := len(repoInfos)
+
+//_, err = c.CreateJob(
+//"bogusImage",
+//[]string{},
+//[]string{},
+//&pps.ParallelismSpec{},
+//[]*pps.JobInput{client.NewJobInput("bogusRepo", "bogusCommit", client.DefaultMethod)},
+//"",
+//0,
+//0,
+//)
+//require.Matches(t, "could not create repo job_.*, not all provenance repos exist", err.Error())
+
+//repoInfos, err = c.ListRepo(nil)
+//require.NoError(t, err)
+//require.Equal(t, initialCount, len(repoInfos))
+//}
+
+// TestUpdatePipelineThatHasNoOutput tracks #1637
+func TestUpdatePipelineThatHasNoOutput(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+	c := getPachClient(t)
+
+	dataRepo := uniqueString("TestUpdatePipelineThatHasNoOutput")
+	require.NoError(t, c.CreateRepo(dataRepo))
+
+	commit, err := c.StartCommit(dataRepo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(dataRepo, commit.ID, "file", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(dataRepo, commit.ID))
+
+	pipeline := uniqueString("pipeline")
+	require.NoError(t, c.CreatePipeline(
+		pipeline,
+		"",
+		[]string{"sh"},
+		[]string{"exit 1"},
+		nil,
+		client.NewAtomInput(dataRepo, "/"),
+		"",
+		false,
+	))
+
+	// Wait for job to spawn
+	time.Sleep(5 * time.Second)
+	jobInfos, err := c.ListJob(pipeline, nil)
+	require.NoError(t, err)
+
+	jobInfo, err := c.InspectJob(jobInfos[0].Job.ID, true)
+	require.NoError(t, err)
+	require.Equal(t, pps.JobState_JOB_FAILURE, jobInfo.State)
+
+	// Now we update the pipeline, its spec has to change or the update is a
+	// no-op
+	require.NoError(t, c.CreatePipeline(
+		pipeline,
+		"",
+		[]string{"sh"},
+		[]string{"exit 2"},
+		nil,
+		client.NewAtomInput(dataRepo, "/"),
+		"",
+		true,
+	))
+}
+
+func TestUpdatePipelineUnchanged(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+	c := getPachClient(t)
+
+	dataRepo := uniqueString("TestUpdatePipelineUnchanged")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	commit, err := c.StartCommit(dataRepo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(dataRepo, commit.ID, "file", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(dataRepo, commit.ID))
+
+	pipeline := uniqueString("pipeline")
+	createPipeline := func(stdin string, update bool) {
+		require.NoError(t, c.CreatePipeline(
+			pipeline,
+			"",
+			[]string{"bash"},
+			[]string{stdin},
+			nil,
+			client.NewAtomInput(dataRepo, "/*"),
+			"",
+			update,
+		))
+	}
+	createPipeline("cp /pfs/*/* /pfs/out/", false)
+	commitIter, err := c.FlushCommit([]*pfs.Commit{commit}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(collectCommitInfos(t, commitIter)))
+
+	// applying the same spec again doesn't make a new version of the
+	// pipeline, or a new job
+	createPipeline("cp /pfs/*/* /pfs/out/", true)
+	pipelineInfo, err := c.InspectPipeline(pipeline)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), pipelineInfo.Version)
+	time.Sleep(5 * time.Second)
+	jobInfos, err := c.ListJob(pipeline, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(jobInfos))
+
+	// changing it does
+	createPipeline("cp /pfs/*/* /pfs/out/\n", true)
+	pipelineInfo, err = c.InspectPipeline(pipeline)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), pipelineInfo.Version)
+
+	// applying the same spec to a stopped pipeline restarts it
+	require.NoError(t, c.StopPipeline(pipeline))
+	createPipeline("cp /pfs/*/* /pfs/out/\n", true)
+	pipelineInfo, err = c.InspectPipeline(pipeline)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), pipelineInfo.Version)
+	require.False(t, pipelineInfo.Stopped)
+}
+
+func TestAcceptReturnCode(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+	c := getPachClient(t)
+
+	dataRepo := uniqueString("TestAcceptReturnCode")
+	require.NoError(t, c.CreateRepo(dataRepo))
+
+	commit, err := c.StartCommit(dataRepo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(dataRepo, commit.ID, "file", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(dataRepo, commit.ID))
+
+	job, err := c.PpsAPIClient.CreateJob(
+		context.Background(),
+		&pps.CreateJobRequest{
+			Transform: &pps.Transform{
+				Cmd:              []string{"sh"},
+				Stdin:            []string{"exit 1"},
+				AcceptReturnCode: []int64{1},
+			},
+			Inputs: []*pps.JobInput{{
+				Name:   dataRepo,
+				Commit: commit,
+				Glob:   "/*",
+			}},
+			OutputBranch: "master",
+		},
+	)
+	require.NoError(t, err)
+	inspectJobRequest := &pps.InspectJobRequest{
+		Job:        job,
+		BlockState: true,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel() //cleanup resources
+	jobInfo, err := c.PpsAPIClient.InspectJob(ctx, inspectJobRequest)
+	require.NoError(t, err)
+	require.Equal(t, pps.JobState_JOB_SUCCESS.String(), jobInfo.State.String())
+}
+
+func TestConnectionPool(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+	c, err := client.NewFromAddress("0.0.0.0:30650", client.WithConnectionPoolSize(4))
+	require.NoError(t, err)
+	defer c.Close()
+
+	dataRepo := uniqueString("TestConnectionPool")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	commit, err := c.StartCommit(dataRepo, "master")
+	require.NoError(t, err)
+	var eg errgroup.Group
+	for i := 0; i < 100; i++ {
+		i := i
+		eg.Go(func() error {
+			_, err := c.PutFile(dataRepo, commit.ID, fmt.Sprintf("file%d", i), strings.NewReader(fmt.Sprintf("%d\n", i)))
+			return err
+		})
+	}
+	require.NoError(t, eg.Wait())
+	require.NoError(t, c.FinishCommit(dataRepo, commit.ID))
+	fileInfos, err := c.ListFile(dataRepo, commit.ID, "")
+	require.NoError(t, err)
+	require.Equal(t, 100, len(fileInfos))
+}
 
-//// This job sleeps for 20 secs
-//job, err = c.CreateJob(
-//"",
-//[]string{"bash"},
-//[]string{"sleep 20"},
-//&pps.ParallelismSpec{},
-//nil,
-//"",
-//0,
-//0,
-//)
-//require.NoError(t, err)
-//time.Sleep(10 * time.Second)
-//jobInfo, err = c.InspectJob(job.ID, false)
-//require.NoError(t, err)
-//require.Equal(t, pps.JobState_JOB_RUNNING, jobInfo.State)
+func TestClientInterceptors(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+	unaryMethods := make(map[string]int)
+	streamMethods := make(map[string]int)
+	var mu sync.Mutex
+	c, err := client.NewFromAddress("0.0.0.0:30650",
+		client.WithUnaryInterceptor(func(ctx netcontext.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			mu.Lock()
+			unaryMethods[method]++
+			mu.Unlock()
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}),
+		client.WithStreamInterceptor(func(ctx netcontext.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			mu.Lock()
+			streamMethods[method]++
+			mu.Unlock()
+			return streamer(ctx, desc, cc, method, opts...)
+		}),
+	)
+	require.NoError(t, err)
+	defer c.Close()
 
-//// Wait for the job to complete
-//jobInfo, err = c.InspectJob(job.ID, true)
-//require.NoError(t, err)
-//require.Equal(t, pps.JobState_JOB_SUCCESS, jobInfo.State)
-//}
+	dataRepo := uniqueString("TestClientInterceptors")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	_, err = c.PutFile(dataRepo, "master", "file", strings.NewReader("foo\n"))
+	require.YesError(t, err) // there's no open commit
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, 1, unaryMethods["/pfs.API/CreateRepo"])
+	require.Equal(t, 1, streamMethods["/pfs.API/PutFile"])
+}
 
-//func TestClusterFunctioningAfterMembershipChange(t *testing.T) {
-//t.Skip("this test is flaky")
-//if testing.Short() {
-//t.Skip("Skipping integration tests in short mode")
-//}
+func TestErrorCodes(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+	c := getPachClient(t)
 
-//scalePachd(t, true)
-//testJob(t, 4)
-//scalePachd(t, false)
-//testJob(t, 4)
-//}
+	dataRepo := uniqueString("TestErrorCodes")
+	missingRepo := uniqueString("TestErrorCodes_missing")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	require.True(t, errors.Is(c.CreateRepo(dataRepo), client.ErrRepoExists))
+	_, err := c.InspectRepo(missingRepo)
+	require.True(t, errors.Is(err, client.ErrRepoNotFound))
+	_, err = c.InspectCommit(dataRepo, "master")
+	require.True(t, errors.Is(err, client.ErrCommitNotFound))
+	_, err = c.InspectPipeline(uniqueString("TestErrorCodes_pipeline"))
+	require.True(t, errors.Is(err, client.ErrPipelineNotFound))
+
+	// clients that don't recreate pachd's errors can still use their codes
+	conn, err := grpc.Dial("0.0.0.0:30650", client.PachDialOptions()...)
+	require.NoError(t, err)
+	defer conn.Close()
+	pfsClient := pfs.NewAPIClient(conn)
+	_, err = pfsClient.InspectRepo(netcontext.Background(), &pfs.InspectRepoRequest{Repo: client.NewRepo(missingRepo)})
+	require.Equal(t, codes.NotFound, grpc.Code(err))
+	_, err = pfsClient.CreateRepo(netcontext.Background(), &pfs.CreateRepoRequest{Repo: client.NewRepo(dataRepo)})
+	require.Equal(t, codes.AlreadyExists, grpc.Code(err))
+}
+
+func TestWatchJob(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+	c := getPachClient(t)
+
+	dataRepo := uniqueString("TestWatchJob")
+	require.NoError(t, c.CreateRepo(dataRepo))
+
+	commit, err := c.StartCommit(dataRepo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(dataRepo, commit.ID, "file", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(dataRepo, commit.ID))
+
+	job, err := c.PpsAPIClient.CreateJob(
+		context.Background(),
+		&pps.CreateJobRequest{
+			Transform: &pps.Transform{
+				Cmd:   []string{"bash"},
+				Stdin: []string{fmt.Sprintf("cp /pfs/%s/* /pfs/out/", dataRepo)},
+			},
+			Inputs: []*pps.JobInput{{
+				Name:   dataRepo,
+				Commit: commit,
+				Glob:   "/*",
+			}},
+			OutputBranch: "master",
+		},
+	)
+	require.NoError(t, err)
+
+	watcher := c.WatchJob(job.ID)
+	defer watcher.Close()
+	var states []pps.JobState
+	for jobInfo := range watcher.Watch() {
+		states = append(states, jobInfo.State)
+	}
+	require.NoError(t, watcher.Err())
+	require.True(t, len(states) > 0)
+	require.Equal(t, pps.JobState_JOB_SUCCESS.String(), states[len(states)-1].String())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel() //cleanup resources
+	jobInfo, err := c.WaitJob(ctx, job.ID)
+	require.NoError(t, err)
+	require.Equal(t, pps.JobState_JOB_SUCCESS.String(), jobInfo.State.String())
+}
 
 // TODO(msteffen): This test breaks the suite when run against cloud providers,
 // because killing the pachd pod breaks the connection with pachctl port-forward
-func TestDeleteAfterMembershipChange(t *testing.T) {
+func TestRestartAll(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration tests in short mode")
 	}
+	// this test cannot be run in parallel because it restarts everything which breaks other tests.
+	c := getPachClient(t)
+	// create repos
+	dataRepo := uniqueString("TestRestartAll_data")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	// create pipeline
+	pipelineName := uniqueString("pipeline")
+	require.NoError(t, c.CreatePipeline(
+		pipelineName,
+		"",
+		[]string{"cp", path.Join("/pfs", dataRepo, "file"), "/pfs/out/file"},
+		nil,
+		&pps.ParallelismSpec{
+			Strategy: pps.ParallelismSpec_CONSTANT,
+			Constant: 1,
+		},
+		client.NewAtomInput(dataRepo, "/*"),
+		"",
+		false,
+	))
+	// Do first commit to repo
+	commit, err := c.StartCommit(dataRepo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(dataRepo, commit.ID, "file", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(dataRepo, commit.ID))
+	commitIter, err := c.FlushCommit([]*pfs.Commit{commit}, nil)
+	require.NoError(t, err)
+	collectCommitInfos(t, commitIter)
 
-	test := func(up bool) {
-		repo := uniqueString("TestDeleteAfterMembershipChange")
-		c := getPachClient(t)
-		require.NoError(t, c.CreateRepo(repo))
-		_, err := c.StartCommit(repo, "master")
-		require.NoError(t, err)
-		require.NoError(t, c.FinishCommit(repo, "master"))
-		scalePachdRandom(t, up)
-		c = getUsablePachClient(t)
-		require.NoError(t, c.DeleteRepo(repo, false))
+	restartAll(t)
+
+	// need a new client because the old one will have a defunct connection
+	c = getUsablePachClient(t)
+
+	// Wait a little for pipelines to restart
+	time.Sleep(10 * time.Second)
+	pipelineInfo, err := c.InspectPipeline(pipelineName)
+	require.NoError(t, err)
+	require.Equal(t, pps.PipelineState_PIPELINE_RUNNING, pipelineInfo.State)
+	_, err = c.InspectRepo(dataRepo)
+	require.NoError(t, err)
+	_, err = c.InspectCommit(dataRepo, commit.ID)
+	require.NoError(t, err)
+}
+
+// TODO(msteffen): This test breaks the suite when run against cloud providers,
+// because killing the pachd pod breaks the connection with pachctl port-forward
+func TestRestartOne(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
 	}
-	test(true)
-	test(false)
+	// this test cannot be run in parallel because it restarts everything which breaks other tests.
+	c := getPachClient(t)
+	// create repos
+	dataRepo := uniqueString("TestRestartOne_data")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	// create pipeline
+	pipelineName := uniqueString("pipeline")
+	require.NoError(t, c.CreatePipeline(
+		pipelineName,
+		"",
+		[]string{"cp", path.Join("/pfs", dataRepo, "file"), "/pfs/out/file"},
+		nil,
+		&pps.ParallelismSpec{
+			Strategy: pps.ParallelismSpec_CONSTANT,
+			Constant: 1,
+		},
+		client.NewAtomInput(dataRepo, "/"),
+		"",
+		false,
+	))
+	// Do first commit to repo
+	commit, err := c.StartCommit(dataRepo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(dataRepo, commit.ID, "file", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(dataRepo, commit.ID))
+	commitIter, err := c.FlushCommit([]*pfs.Commit{commit}, nil)
+	require.NoError(t, err)
+	collectCommitInfos(t, commitIter)
+
+	restartOne(t)
+
+	// need a new client because the old one will have a defunct connection
+	c = getUsablePachClient(t)
+
+	_, err = c.InspectPipeline(pipelineName)
+	require.NoError(t, err)
+	_, err = c.InspectRepo(dataRepo)
+	require.NoError(t, err)
+	_, err = c.InspectCommit(dataRepo, commit.ID)
+	require.NoError(t, err)
+}
+
+func TestPrettyPrinting(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getPachClient(t)
+	// create repos
+	dataRepo := uniqueString("TestPrettyPrinting_data")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	// create pipeline
+	pipelineName := uniqueString("pipeline")
+	_, err := c.PpsAPIClient.CreatePipeline(
+		context.Background(),
+		&pps.CreatePipelineRequest{
+			Pipeline: &pps.Pipeline{pipelineName},
+			Transform: &pps.Transform{
+				Cmd: []string{"cp", path.Join("/pfs", dataRepo, "file"), "/pfs/out/file"},
+			},
+			ParallelismSpec: &pps.ParallelismSpec{
+				Strategy: pps.ParallelismSpec_CONSTANT,
+				Constant: 1,
+			},
+			ResourceSpec: &pps.ResourceSpec{
+				Memory: "100M",
+				Cpu:    0.5,
+			},
+			Inputs: []*pps.PipelineInput{{
+				Repo: &pfs.Repo{Name: dataRepo},
+				Glob: "/*",
+			}},
+		})
+	require.NoError(t, err)
+	// Do a commit to repo
+	commit, err := c.StartCommit(dataRepo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(dataRepo, commit.ID, "file", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(dataRepo, commit.ID))
+	commitIter, err := c.FlushCommit([]*pfs.Commit{commit}, nil)
+	require.NoError(t, err)
+	commitInfos := collectCommitInfos(t, commitIter)
+	require.Equal(t, 1, len(commitInfos))
+	repoInfo, err := c.InspectRepo(dataRepo)
+	require.NoError(t, err)
+	require.NoError(t, pfspretty.PrintDetailedRepoInfo(repoInfo))
+	for _, commitInfo := range commitInfos {
+		require.NoError(t, pfspretty.PrintDetailedCommitInfo(commitInfo))
+	}
+	fileInfo, err := c.InspectFile(dataRepo, commit.ID, "file")
+	require.NoError(t, err)
+	require.NoError(t, pfspretty.PrintDetailedFileInfo(fileInfo))
+	pipelineInfo, err := c.InspectPipeline(pipelineName)
+	require.NoError(t, err)
+	require.NoError(t, ppspretty.PrintDetailedPipelineInfo(pipelineInfo))
+	jobInfos, err := c.ListJob("", nil)
+	require.NoError(t, err)
+	require.True(t, len(jobInfos) > 0)
+	require.NoError(t, ppspretty.PrintDetailedJobInfo(jobInfos[0]))
 }
 
-// TODO(msteffen): This test breaks the suite when run against cloud providers,
-// because killing the pachd pod breaks the connection with pachctl port-forward
-func TestPachdRestartResumesRunningJobs(t *testing.T) {
+func TestDeleteAll(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration tests in short mode")
 	}
-	// this test cannot be run in parallel because it restarts everything which breaks other tests.
+	// this test cannot be run in parallel because it deletes everything
 	c := getPachClient(t)
 	// create repos
-	dataRepo := uniqueString("TestPachdRestartPickUpRunningJobs")
+	dataRepo := uniqueString("TestDeleteAll_data")
 	require.NoError(t, c.CreateRepo(dataRepo))
 	// create pipeline
 	pipelineName := uniqueString("pipeline")
 	require.NoError(t, c.CreatePipeline(
 		pipelineName,
 		"",
-		[]string{"bash"},
-		[]string{
-			"sleep 10",
-		},
+		[]string{"cp", path.Join("/pfs", dataRepo, "file"), "/pfs/out/file"},
+		nil,
 		&pps.ParallelismSpec{
 			Strategy: pps.ParallelismSpec_CONSTANT,
 			Constant: 1,
@@ -1290,381 +2880,719 @@ func TestPachdRestartResumesRunningJobs(t *testing.T) {
 		"",
 		false,
 	))
+	// Do commit to repo
 	commit, err := c.StartCommit(dataRepo, "master")
 	require.NoError(t, err)
 	_, err = c.PutFile(dataRepo, commit.ID, "file", strings.NewReader("foo\n"))
 	require.NoError(t, err)
 	require.NoError(t, c.FinishCommit(dataRepo, commit.ID))
-
-	time.Sleep(5 * time.Second)
-
-	jobInfos, err := c.ListJob(pipelineName, nil)
+	commitIter, err := c.FlushCommit([]*pfs.Commit{commit}, nil)
 	require.NoError(t, err)
-	require.Equal(t, 1, len(jobInfos))
-	require.Equal(t, pps.JobState_JOB_RUNNING, jobInfos[0].State)
-
-	restartOne(t)
-	// need a new client because the old one will have a defunct connection
-	c = getUsablePachClient(t)
-
-	jobInfo, err := c.InspectJob(jobInfos[0].Job.ID, true)
+	require.Equal(t, 1, len(collectCommitInfos(t, commitIter)))
+	require.NoError(t, c.DeleteAll())
+	repoInfos, err := c.ListRepo(nil)
 	require.NoError(t, err)
-	require.Equal(t, pps.JobState_JOB_SUCCESS, jobInfo.State)
+	require.Equal(t, 0, len(repoInfos))
+	pipelineInfos, err := c.ListPipeline()
+	require.NoError(t, err)
+	require.Equal(t, 0, len(pipelineInfos))
+	jobInfos, err := c.ListJob("", nil)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(jobInfos))
 }
 
-//func TestScrubbedErrors(t *testing.T) {
-//if testing.Short() {
-//t.Skip("Skipping integration tests in short mode")
-//}
-
-//t.Parallel()
-//c := getPachClient(t)
+func TestExtractRestore(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	// this test cannot be run in parallel because it deletes everything
+	c := getPachClient(t)
+	require.NoError(t, c.DeleteAll())
+	dataRepo := uniqueString("TestExtractRestore_data")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	var commits []*pfs.Commit
+	for i := 0; i < 3; i++ {
+		commit, err := c.StartCommit(dataRepo, "master")
+		require.NoError(t, err)
+		_, err = c.PutFile(dataRepo, commit.ID, fmt.Sprintf("file%d", i), strings.NewReader(fmt.Sprintf("%d\n", i)))
+		require.NoError(t, err)
+		require.NoError(t, c.FinishCommit(dataRepo, commit.ID))
+		commits = append(commits, commit)
+	}
 
-//_, err := c.InspectPipeline("blah")
-//require.Equal(t, "PipelineInfos blah not found", err.Error())
+	pipeline := uniqueString("pipeline")
+	require.NoError(t, c.CreatePipeline(
+		pipeline,
+		"",
+		[]string{"bash"},
+		[]string{fmt.Sprintf("cp /pfs/%s/* /pfs/out/", dataRepo)},
+		&pps.ParallelismSpec{
+			Strategy: pps.ParallelismSpec_CONSTANT,
+			Constant: 1,
+		},
+		client.NewAtomInput(dataRepo, "/*"),
+		"",
+		false,
+	))
+	commitIter, err := c.FlushCommit(commits[2:], []*pfs.Repo{client.NewRepo(pipeline)})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(collectCommitInfos(t, commitIter)))
 
-//err = c.CreatePipeline(
-//"lskdjf$#%^ERTYC",
-//"",
-//[]string{},
-//nil,
-//&pps.ParallelismSpec{
-//Strategy: pps.ParallelismSpec_CONSTANT,
-//Constant: 1,
-//},
-//[]*pps.PipelineInput{{Repo: &pfs.Repo{Name: "test"}}},
-//false,
-//)
-//require.Equal(t, "repo test not found", err.Error())
+	var buf bytes.Buffer
+	_, err = c.Extract(true, "", &buf)
+	require.NoError(t, err)
+	require.NoError(t, c.DeleteAll())
+	require.NoError(t, c.Restore(&buf))
 
-//_, err = c.CreateJob(
-//"askjdfhgsdflkjh",
-//[]string{},
-//[]string{},
-//&pps.ParallelismSpec{},
-//[]*pps.JobInput{client.NewJobInput("bogusRepo", "bogusCommit", client.DefaultMethod)},
-//"",
-//0,
-//0,
-//)
-//require.Matches(t, "could not create repo job_.*, not all provenance repos exist", err.Error())
+	commitInfos, err := c.ListCommitByRepo(dataRepo)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(commitInfos))
+	fileInfos, err := c.ListFile(dataRepo, "master", "")
+	require.NoError(t, err)
+	require.Equal(t, 3, len(fileInfos))
+	pipelineInfos, err := c.ListPipeline()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(pipelineInfos))
 
-//_, err = c.InspectJob("blah", true)
-//require.Equal(t, "JobInfos blah not found", err.Error())
+	// The restored pipeline processes the restored commits
+	commitIter, err = c.FlushCommit([]*pfs.Commit{client.NewCommit(dataRepo, "master")}, []*pfs.Repo{client.NewRepo(pipeline)})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(collectCommitInfos(t, commitIter)))
+	buf.Reset()
+	require.NoError(t, c.GetFile(pipeline, "master", "file2", 0, 0, &buf))
+	require.Equal(t, "2\n", buf.String())
+}
 
-//home := os.Getenv("HOME")
-//f, err := os.Create(filepath.Join(home, "/tmpfile"))
-//defer func() {
-//os.Remove(filepath.Join(home, "/tmpfile"))
-//}()
-//require.NoError(t, err)
-//err = c.GetLogs("bogusJobId", f)
-//require.Equal(t, "job bogusJobId not found", err.Error())
-//}
+func TestExtractRestoreIncremental(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	// this test cannot be run in parallel because it deletes everything
+	c := getPachClient(t)
+	require.NoError(t, c.DeleteAll())
+	dataRepo := uniqueString("TestExtractRestoreIncremental_data")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	putFile := func(i int) {
+		commit, err := c.StartCommit(dataRepo, "master")
+		require.NoError(t, err)
+		_, err = c.PutFile(dataRepo, commit.ID, fmt.Sprintf("file%d", i), strings.NewReader(fmt.Sprintf("%d\n", i)))
+		require.NoError(t, err)
+		require.NoError(t, c.FinishCommit(dataRepo, commit.ID))
+	}
+	putFile(0)
+	putFile(1)
+	var full bytes.Buffer
+	marker, err := c.Extract(true, "", &full)
+	require.NoError(t, err)
 
-//func TestLeakingRepo(t *testing.T) {
-//// If CreateJob fails, it should also destroy the output repo it creates
-//// If it doesn't, it can cause flush commit to fail, as a bogus repo will
-//// be listed in the output repo's provenance
+	putFile(2)
+	var incremental bytes.Buffer
+	_, err = c.Extract(true, marker, &incremental)
+	require.NoError(t, err)
+	require.True(t, incremental.Len() < full.Len())
 
-//// This test can't be run in parallel, since it requires using the repo counts as controls
-//if testing.Short() {
-//t.Skip("Skipping integration tests in short mode")
-//}
+	// the incremental backup can't be restored on its own
+	require.NoError(t, c.DeleteAll())
+	require.YesError(t, c.Restore(bytes.NewReader(incremental.Bytes())))
 
-//c := getPachClient(t)
+	require.NoError(t, c.DeleteAll())
+	require.NoError(t, c.Restore(&full))
+	require.NoError(t, c.Restore(&incremental))
+	commitInfos, err := c.ListCommitByRepo(dataRepo)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(commitInfos))
+	var buf bytes.Buffer
+	require.NoError(t, c.GetFile(dataRepo, "master", "file2", 0, 0, &buf))
+	require.Equal(t, "2\n", buf.String())
+	fileInfos, err := c.ListFile(dataRepo, "master", "")
+	require.NoError(t, err)
+	require.Equal(t, 3, len(fileInfos))
+}
 
-//repoInfos, err := c.ListRepo(nil)
-//require.NoError(t, err)
-//initialCount := len(repoInfos)
+func TestExportImportRepo(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+	c := getPachClient(t)
+	dataRepo := uniqueString("TestExportImportRepo_data")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	var commits []*pfs.Commit
+	putFile := func(i int) {
+		commit, err := c.StartCommit(dataRepo, "master")
+		require.NoError(t, err)
+		_, err = c.PutFile(dataRepo, commit.ID, fmt.Sprintf("file%d", i), strings.NewReader(fmt.Sprintf("%d\n", i)))
+		require.NoError(t, err)
+		require.NoError(t, c.FinishCommit(dataRepo, commit.ID))
+		commits = append(commits, commit)
+	}
+	putFile(0)
+	putFile(1)
+	commit, err := c.StartCommit(dataRepo, "master")
+	require.NoError(t, err)
+	require.NoError(t, c.DeleteFile(dataRepo, commit.ID, "file0"))
+	require.NoError(t, c.FinishCommit(dataRepo, commit.ID))
+	putFile(2)
 
-//_, err = c.CreateJob(
-//"bogusImage",
-//[]string{},
-//[]string{},
-//&pps.ParallelismSpec{},
-//[]*pps.JobInput{client.NewJobInput("bogusRepo", "bogusCommit", client.DefaultMethod)},
-//"",
-//0,
-//0,
-//)
-//require.Matches(t, "could not create repo job_.*, not all provenance repos exist", err.Error())
+	var buf bytes.Buffer
+	require.NoError(t, c.ExportRepo(dataRepo, "", "", &buf))
+	importedRepo := uniqueString("TestExportImportRepo_imported")
+	require.NoError(t, c.ImportRepo(bytes.NewReader(buf.Bytes()), importedRepo))
+	// the repo can't be imported twice
+	require.YesError(t, c.ImportRepo(bytes.NewReader(buf.Bytes()), importedRepo))
+	commitInfos, err := c.ListCommitByRepo(importedRepo)
+	require.NoError(t, err)
+	require.Equal(t, 4, len(commitInfos))
+	fileInfos, err := c.ListFile(importedRepo, "master", "")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(fileInfos))
+	var content bytes.Buffer
+	require.NoError(t, c.GetFile(importedRepo, "master", "file2", 0, 0, &content))
+	require.Equal(t, "2\n", content.String())
 
-//repoInfos, err = c.ListRepo(nil)
-//require.NoError(t, err)
-//require.Equal(t, initialCount, len(repoInfos))
-//}
+	// export only the commits after the first one
+	buf.Reset()
+	require.NoError(t, c.ExportRepo(dataRepo, commits[0].ID, "master", &buf))
+	partialRepo := uniqueString("TestExportImportRepo_partial")
+	require.NoError(t, c.ImportRepo(&buf, partialRepo))
+	commitInfos, err = c.ListCommitByRepo(partialRepo)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(commitInfos))
+	fileInfos, err = c.ListFile(partialRepo, "master", "")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(fileInfos))
+}
 
-// TestUpdatePipelineThatHasNoOutput tracks #1637
-func TestUpdatePipelineThatHasNoOutput(t *testing.T) {
+func TestMirror(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration tests in short mode")
 	}
 	t.Parallel()
 	c := getPachClient(t)
+	dataRepo := uniqueString("TestMirror_data")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	putFile := func(file string, content string) *pfs.Commit {
+		commit, err := c.StartCommit(dataRepo, "master")
+		require.NoError(t, err)
+		_, err = c.PutFile(dataRepo, commit.ID, file, strings.NewReader(content))
+		require.NoError(t, err)
+		require.NoError(t, c.FinishCommit(dataRepo, commit.ID))
+		return commit
+	}
+	putFile("file0", "0\n")
+	commit := putFile("file1", "1\n")
+
+	// mirror the repo to another repo in the same cluster, which pachd
+	// reaches through its service
+	mirrorRepo := uniqueString("TestMirror_mirror")
+	name := uniqueString("TestMirror")
+	require.NoError(t, c.CreateMirror(&adminclient.MirrorSpec{
+		Name:       name,
+		Repo:       dataRepo,
+		Branch:     "master",
+		Address:    fmt.Sprintf("pachd.%s:650", getNamespace(t)),
+		DestRepo:   mirrorRepo,
+		DestBranch: "mirrored",
+	}))
+	defer func() {
+		require.NoError(t, c.DeleteMirror(name))
+	}()
+	// the name must be unique
+	require.YesError(t, c.CreateMirror(&adminclient.MirrorSpec{
+		Name:    name,
+		Repo:    dataRepo,
+		Branch:  "master",
+		Address: "localhost:650",
+	}))
+	waitForMirrored := func(id string) {
+		b := backoff.NewExponentialBackOff()
+		b.MaxElapsedTime = time.Minute
+		require.NoError(t, backoff.Retry(func() error {
+			mirrorInfos, err := c.ListMirror()
+			if err != nil {
+				return err
+			}
+			for _, mirrorInfo := range mirrorInfos {
+				if mirrorInfo.Spec.Name != name {
+					continue
+				}
+				if mirrorInfo.Mirrored != id {
+					return fmt.Errorf("mirror has copied up to %q (error: %q), not %s", mirrorInfo.Mirrored, mirrorInfo.Error, id)
+				}
+				return nil
+			}
+			return fmt.Errorf("mirror %s not found", name)
+		}, b))
+	}
+	waitForMirrored(commit.ID)
+	commitInfos, err := c.ListCommit(mirrorRepo, "mirrored", "", 0)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(commitInfos))
+	var buf bytes.Buffer
+	require.NoError(t, c.GetFile(mirrorRepo, "mirrored", "file1", 0, 0, &buf))
+	require.Equal(t, "1\n", buf.String())
 
-	dataRepo := uniqueString("TestUpdatePipelineThatHasNoOutput")
+	// new commits are copied as they're finished, including deletions
+	commit, err = c.StartCommit(dataRepo, "master")
+	require.NoError(t, err)
+	require.NoError(t, c.DeleteFile(dataRepo, commit.ID, "file0"))
+	require.NoError(t, c.FinishCommit(dataRepo, commit.ID))
+	commit = putFile("file2", "2\n")
+	waitForMirrored(commit.ID)
+	commitInfos, err = c.ListCommit(mirrorRepo, "mirrored", "", 0)
+	require.NoError(t, err)
+	require.Equal(t, 4, len(commitInfos))
+	fileInfos, err := c.ListFile(mirrorRepo, "mirrored", "")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(fileInfos))
+}
+
+func TestReadOnly(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	// this test cannot be run in parallel because it makes the whole cluster
+	// read-only
+	c := getPachClient(t)
+	dataRepo := uniqueString("TestReadOnly_data")
 	require.NoError(t, c.CreateRepo(dataRepo))
+	_, err := c.StartCommit(dataRepo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(dataRepo, "master", "file", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(dataRepo, "master"))
 
-	commit, err := c.StartCommit(dataRepo, "master")
+	require.NoError(t, c.SetReadOnly("testing", false))
+	defer func() {
+		require.NoError(t, c.SetWritable())
+	}()
+	clusterInfo, err := c.InspectCluster()
 	require.NoError(t, err)
-	_, err = c.PutFile(dataRepo, commit.ID, "file", strings.NewReader("foo\n"))
+	require.NotNil(t, clusterInfo.ReadOnly)
+	require.Equal(t, "testing", clusterInfo.ReadOnly.Reason)
+
+	// writes are rejected
+	err = c.CreateRepo(uniqueString("TestReadOnly_other"))
+	require.YesError(t, err)
+	require.True(t, errors.Is(err, client.ErrReadOnly))
+	_, err = c.StartCommit(dataRepo, "master")
+	require.True(t, errors.Is(err, client.ErrReadOnly))
+	// reads aren't
+	var buffer bytes.Buffer
+	require.NoError(t, c.GetFile(dataRepo, "master", "file", 0, 0, &buffer))
+	require.Equal(t, "foo\n", buffer.String())
+
+	require.NoError(t, c.SetWritable())
+	clusterInfo, err = c.InspectCluster()
 	require.NoError(t, err)
-	require.NoError(t, c.FinishCommit(dataRepo, commit.ID))
+	require.Nil(t, clusterInfo.ReadOnly)
+	_, err = c.StartCommit(dataRepo, "master")
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(dataRepo, "master"))
+}
 
-	pipeline := uniqueString("pipeline")
-	require.NoError(t, c.CreatePipeline(
-		pipeline,
-		"",
-		[]string{"sh"},
-		[]string{"exit 1"},
-		nil,
-		client.NewAtomInput(dataRepo, "/"),
-		"",
-		false,
-	))
+func TestInspectCluster(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
 
-	// Wait for job to spawn
-	time.Sleep(5 * time.Second)
-	jobInfos, err := c.ListJob(pipeline, nil)
+	t.Parallel()
+	c := getPachClient(t)
+	clusterInfo, err := c.InspectCluster()
 	require.NoError(t, err)
+	require.NotEqual(t, "", clusterInfo.ID)
+	require.Equal(t, version.Version.Major, clusterInfo.Version.Major)
+	require.Equal(t, version.Version.Minor, clusterInfo.Version.Minor)
+	require.True(t, clusterInfo.NumShards > 0)
 
-	jobInfo, err := c.InspectJob(jobInfos[0].Job.ID, true)
+	// the ID doesn't change
+	clusterInfo2, err := c.InspectCluster()
 	require.NoError(t, err)
-	require.Equal(t, pps.JobState_JOB_FAILURE, jobInfo.State)
-
-	// Now we update the pipeline
-	require.NoError(t, c.CreatePipeline(
-		pipeline,
-		"",
-		[]string{"sh"},
-		[]string{"exit 1"},
-		nil,
-		client.NewAtomInput(dataRepo, "/"),
-		"",
-		true,
-	))
+	require.Equal(t, clusterInfo.ID, clusterInfo2.ID)
 }
 
-func TestAcceptReturnCode(t *testing.T) {
+// TestAuth isn't parallel, as other tests' requests fail while auth is active.
+func TestAuth(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration tests in short mode")
 	}
-	t.Parallel()
+
 	c := getPachClient(t)
+	repo := uniqueString("TestAuth")
+	require.NoError(t, c.CreateRepo(repo))
 
-	dataRepo := uniqueString("TestAcceptReturnCode")
-	require.NoError(t, c.CreateRepo(dataRepo))
+	token, err := c.Activate("alice")
+	require.NoError(t, err)
+	alice, err := client.NewFromAddress(c.Addr(), client.WithAuthToken(token))
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, alice.Deactivate())
+		// once auth is deactivated requests don't need a token
+		_, err := c.InspectRepo(repo)
+		require.NoError(t, err)
+	}()
+	_, err = c.Activate("bob")
+	require.True(t, errors.Is(err, client.ErrAuthAlreadyActivated))
 
-	commit, err := c.StartCommit(dataRepo, "master")
+	// requests without a valid token are rejected
+	_, err = c.InspectRepo(repo)
+	require.True(t, errors.Is(err, client.ErrNotSignedIn))
+	_, err = c.ListPipeline()
+	require.True(t, errors.Is(err, client.ErrNotSignedIn))
+	invalid, err := client.NewFromAddress(c.Addr(), client.WithAuthToken("invalid"))
 	require.NoError(t, err)
-	_, err = c.PutFile(dataRepo, commit.ID, "file", strings.NewReader("foo\n"))
+	_, err = invalid.InspectRepo(repo)
+	require.True(t, errors.Is(err, client.ErrNotSignedIn))
+
+	username, err := alice.WhoAmI()
+	require.NoError(t, err)
+	require.Equal(t, "alice", username)
+	_, err = alice.InspectRepo(repo)
 	require.NoError(t, err)
-	require.NoError(t, c.FinishCommit(dataRepo, commit.ID))
 
-	job, err := c.PpsAPIClient.CreateJob(
-		context.Background(),
-		&pps.CreateJobRequest{
-			Transform: &pps.Transform{
-				Cmd:              []string{"sh"},
-				Stdin:            []string{"exit 1"},
-				AcceptReturnCode: []int64{1},
-			},
-			Inputs: []*pps.JobInput{{
-				Name:   dataRepo,
-				Commit: commit,
-				Glob:   "/*",
-			}},
-			OutputBranch: "master",
-		},
-	)
+	// alice can issue tokens for other users
+	bobToken, err := alice.GetToken("bob", 0)
 	require.NoError(t, err)
-	inspectJobRequest := &pps.InspectJobRequest{
-		Job:        job,
-		BlockState: true,
-	}
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
-	defer cancel() //cleanup resources
-	jobInfo, err := c.PpsAPIClient.InspectJob(ctx, inspectJobRequest)
+	bob, err := client.NewFromAddress(c.Addr(), client.WithAuthToken(bobToken))
 	require.NoError(t, err)
-	require.Equal(t, pps.JobState_JOB_SUCCESS.String(), jobInfo.State.String())
-}
+	username, err = bob.WhoAmI()
+	require.NoError(t, err)
+	require.Equal(t, "bob", username)
+	_, err = c.GetToken("bob", 0)
+	require.True(t, errors.Is(err, client.ErrNotSignedIn))
 
-// TODO(msteffen): This test breaks the suite when run against cloud providers,
-// because killing the pachd pod breaks the connection with pachctl port-forward
-func TestRestartAll(t *testing.T) {
-	if testing.Short() {
-		t.Skip("Skipping integration tests in short mode")
-	}
-	// this test cannot be run in parallel because it restarts everything which breaks other tests.
-	c := getPachClient(t)
-	// create repos
-	dataRepo := uniqueString("TestRestartAll_data")
-	require.NoError(t, c.CreateRepo(dataRepo))
-	// create pipeline
-	pipelineName := uniqueString("pipeline")
-	require.NoError(t, c.CreatePipeline(
-		pipelineName,
+	// pipelines, whose workers authenticate themselves, still run
+	pipeline := uniqueString("TestAuthPipeline")
+	require.NoError(t, bob.CreatePipeline(
+		pipeline,
 		"",
-		[]string{"cp", path.Join("/pfs", dataRepo, "file"), "/pfs/out/file"},
+		[]string{"cp", path.Join("/pfs", repo, "file"), "/pfs/out/file"},
 		nil,
 		&pps.ParallelismSpec{
 			Strategy: pps.ParallelismSpec_CONSTANT,
 			Constant: 1,
 		},
-		client.NewAtomInput(dataRepo, "/*"),
+		client.NewAtomInput(repo, "/*"),
 		"",
 		false,
 	))
-	// Do first commit to repo
-	commit, err := c.StartCommit(dataRepo, "master")
+	commit, err := bob.StartCommit(repo, "master")
 	require.NoError(t, err)
-	_, err = c.PutFile(dataRepo, commit.ID, "file", strings.NewReader("foo\n"))
+	_, err = bob.PutFile(repo, commit.ID, "file", strings.NewReader("foo\n"))
 	require.NoError(t, err)
-	require.NoError(t, c.FinishCommit(dataRepo, commit.ID))
-	commitIter, err := c.FlushCommit([]*pfs.Commit{commit}, nil)
+	require.NoError(t, bob.FinishCommit(repo, commit.ID))
+	commitIter, err := bob.FlushCommit([]*pfs.Commit{commit}, nil)
 	require.NoError(t, err)
-	collectCommitInfos(t, commitIter)
+	commitInfos := collectCommitInfos(t, commitIter)
+	require.Equal(t, 1, len(commitInfos))
+	var buf bytes.Buffer
+	require.NoError(t, bob.GetFile(pipeline, commitInfos[0].Commit.ID, "file", 0, 0, &buf))
+	require.Equal(t, "foo\n", buf.String())
+}
 
-	restartAll(t)
+func TestAuthACL(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
 
-	// need a new client because the old one will have a defunct connection
-	c = getUsablePachClient(t)
+	c := getPachClient(t)
+	token, err := c.Activate("alice")
+	require.NoError(t, err)
+	alice, err := client.NewFromAddress(c.Addr(), client.WithAuthToken(token))
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, alice.Deactivate())
+	}()
+	bobToken, err := alice.GetToken("bob", 0)
+	require.NoError(t, err)
+	bob, err := client.NewFromAddress(c.Addr(), client.WithAuthToken(bobToken))
+	require.NoError(t, err)
 
-	// Wait a little for pipelines to restart
-	time.Sleep(10 * time.Second)
-	pipelineInfo, err := c.InspectPipeline(pipelineName)
+	// the repo's creator, alice, owns it, and bob can't read it
+	repo := uniqueString("TestAuthACL")
+	require.NoError(t, alice.CreateRepo(repo))
+	acl, err := alice.GetACL(repo)
 	require.NoError(t, err)
-	require.Equal(t, pps.PipelineState_PIPELINE_RUNNING, pipelineInfo.State)
-	_, err = c.InspectRepo(dataRepo)
+	require.Equal(t, map[string]auth.Scope{"alice": auth.Scope_OWNER}, acl.Entries)
+	_, err = bob.InspectRepo(repo)
+	require.True(t, errors.Is(err, client.ErrNotAuthorized))
+	require.True(t, errors.Is(bob.SetScope(repo, "bob", auth.Scope_OWNER), client.ErrNotAuthorized))
+
+	// once bob is a reader they can read the repo, but not write to it
+	require.NoError(t, alice.SetScope(repo, "bob", auth.Scope_READER))
+	_, err = bob.InspectRepo(repo)
 	require.NoError(t, err)
-	_, err = c.InspectCommit(dataRepo, commit.ID)
+	_, err = bob.StartCommit(repo, "master")
+	require.True(t, errors.Is(err, client.ErrNotAuthorized))
+	commit, err := alice.StartCommit(repo, "master")
 	require.NoError(t, err)
-}
+	_, err = bob.PutFile(repo, commit.ID, "file", strings.NewReader("foo\n"))
+	require.True(t, errors.Is(err, client.ErrNotAuthorized))
+	_, err = alice.PutFile(repo, commit.ID, "file", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	require.NoError(t, alice.FinishCommit(repo, commit.ID))
+	var buf bytes.Buffer
+	require.NoError(t, bob.GetFile(repo, commit.ID, "file", 0, 0, &buf))
+	require.Equal(t, "foo\n", buf.String())
 
-// TODO(msteffen): This test breaks the suite when run against cloud providers,
-// because killing the pachd pod breaks the connection with pachctl port-forward
-func TestRestartOne(t *testing.T) {
-	if testing.Short() {
-		t.Skip("Skipping integration tests in short mode")
-	}
-	// this test cannot be run in parallel because it restarts everything which breaks other tests.
-	c := getPachClient(t)
-	// create repos
-	dataRepo := uniqueString("TestRestartOne_data")
-	require.NoError(t, c.CreateRepo(dataRepo))
-	// create pipeline
-	pipelineName := uniqueString("pipeline")
-	require.NoError(t, c.CreatePipeline(
-		pipelineName,
+	// bob can use the repo as a pipeline's input, and owns its output repo
+	pipeline := uniqueString("TestAuthACLPipeline")
+	require.NoError(t, bob.CreatePipeline(
+		pipeline,
 		"",
-		[]string{"cp", path.Join("/pfs", dataRepo, "file"), "/pfs/out/file"},
+		[]string{"cp", path.Join("/pfs", repo, "file"), "/pfs/out/file"},
 		nil,
 		&pps.ParallelismSpec{
 			Strategy: pps.ParallelismSpec_CONSTANT,
 			Constant: 1,
 		},
-		client.NewAtomInput(dataRepo, "/"),
+		client.NewAtomInput(repo, "/*"),
 		"",
 		false,
 	))
-	// Do first commit to repo
-	commit, err := c.StartCommit(dataRepo, "master")
+	acl, err = bob.GetACL(pipeline)
+	require.NoError(t, err)
+	require.Equal(t, map[string]auth.Scope{"bob": auth.Scope_OWNER}, acl.Entries)
+	require.True(t, errors.Is(alice.StopPipeline(pipeline), client.ErrNotAuthorized))
+	// nor can she stop its jobs, or read their logs and datums
+	jobs, err := bob.FlushJob([]*pfs.Commit{commit}, []string{pipeline})
+	require.NoError(t, err)
+	jobInfo, err := jobs.Next()
+	require.NoError(t, err)
+	jobs.Close()
+	jobID := jobInfo.Job.ID
+	require.True(t, errors.Is(alice.StopJob(jobID), client.ErrNotAuthorized))
+	require.True(t, errors.Is(alice.DeleteJob(jobID), client.ErrNotAuthorized))
+	require.True(t, errors.Is(alice.RestartDatum(jobID, nil), client.ErrNotAuthorized))
+	_, err = alice.ListDatum(jobID)
+	require.True(t, errors.Is(err, client.ErrNotAuthorized))
+	logs := alice.GetLogs("", jobID, nil)
+	for logs.Next() {
+	}
+	require.True(t, errors.Is(logs.Err(), client.ErrNotAuthorized))
+	_, err = bob.ListDatum(jobID)
 	require.NoError(t, err)
-	_, err = c.PutFile(dataRepo, commit.ID, "file", strings.NewReader("foo\n"))
+	require.NoError(t, bob.DeletePipeline(pipeline, true))
+
+	// removing bob from the ACL revokes their access
+	require.NoError(t, alice.SetScope(repo, "bob", auth.Scope_NONE))
+	_, err = bob.InspectRepo(repo)
+	require.True(t, errors.Is(err, client.ErrNotAuthorized))
+}
+
+func TestAuthRobotToken(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	c := getPachClient(t)
+	token, err := c.Activate("alice")
 	require.NoError(t, err)
-	require.NoError(t, c.FinishCommit(dataRepo, commit.ID))
-	commitIter, err := c.FlushCommit([]*pfs.Commit{commit}, nil)
+	alice, err := client.NewFromAddress(c.Addr(), client.WithAuthToken(token))
 	require.NoError(t, err)
-	collectCommitInfos(t, commitIter)
+	defer func() {
+		require.NoError(t, alice.Deactivate())
+	}()
+	dataRepo := uniqueString("TestAuthRobotToken_data")
+	require.NoError(t, alice.CreateRepo(dataRepo))
+	otherRepo := uniqueString("TestAuthRobotToken_other")
+	require.NoError(t, alice.CreateRepo(otherRepo))
 
-	restartOne(t)
+	// only admins can issue robot tokens
+	bobToken, err := alice.GetToken("bob", 0)
+	require.NoError(t, err)
+	bob, err := client.NewFromAddress(c.Addr(), client.WithAuthToken(bobToken))
+	require.NoError(t, err)
+	_, err = bob.GetRobotToken("ci", map[string]auth.Scope{dataRepo: auth.Scope_WRITER}, 0)
+	require.True(t, errors.Is(err, client.ErrNotAuthorized))
 
-	// need a new client because the old one will have a defunct connection
-	c = getUsablePachClient(t)
+	robotToken, err := alice.GetRobotToken("ci", map[string]auth.Scope{dataRepo: auth.Scope_WRITER}, 0)
+	require.NoError(t, err)
+	robot, err := client.NewFromAddress(c.Addr(), client.WithAuthToken(robotToken))
+	require.NoError(t, err)
+	username, err := robot.WhoAmI()
+	require.NoError(t, err)
+	require.Equal(t, "robot:ci", username)
 
-	_, err = c.InspectPipeline(pipelineName)
+	// the robot can write to the repo it's scoped to, even though it isn't in
+	// the repo's ACL, but can't access other repos
+	commit, err := robot.StartCommit(dataRepo, "master")
 	require.NoError(t, err)
-	_, err = c.InspectRepo(dataRepo)
+	_, err = robot.PutFile(dataRepo, commit.ID, "file", strings.NewReader("foo\n"))
 	require.NoError(t, err)
-	_, err = c.InspectCommit(dataRepo, commit.ID)
+	require.NoError(t, robot.FinishCommit(dataRepo, commit.ID))
+	_, err = robot.InspectRepo(otherRepo)
+	require.True(t, errors.Is(err, client.ErrNotAuthorized))
+	require.True(t, errors.Is(robot.DeleteRepo(dataRepo, false), client.ErrNotAuthorized))
+	_, err = robot.GetToken("alice", 0)
+	require.True(t, errors.Is(err, client.ErrNotAuthorized))
+	require.True(t, errors.Is(robot.DeleteAll(), client.ErrNotAuthorized))
+
+	// tokens with a TTL expire
+	expiringToken, err := alice.GetRobotToken("ci", map[string]auth.Scope{dataRepo: auth.Scope_READER}, time.Second)
+	require.NoError(t, err)
+	expiring, err := client.NewFromAddress(c.Addr(), client.WithAuthToken(expiringToken))
 	require.NoError(t, err)
+	time.Sleep(2 * time.Second)
+	_, err = expiring.InspectRepo(dataRepo)
+	require.True(t, errors.Is(err, client.ErrNotSignedIn))
 }
 
-func TestPrettyPrinting(t *testing.T) {
+func TestAuthAdmins(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration tests in short mode")
 	}
-	t.Parallel()
 
 	c := getPachClient(t)
-	// create repos
-	dataRepo := uniqueString("TestPrettyPrinting_data")
-	require.NoError(t, c.CreateRepo(dataRepo))
-	// create pipeline
-	pipelineName := uniqueString("pipeline")
-	_, err := c.PpsAPIClient.CreatePipeline(
-		context.Background(),
-		&pps.CreatePipelineRequest{
-			Pipeline: &pps.Pipeline{pipelineName},
-			Transform: &pps.Transform{
-				Cmd: []string{"cp", path.Join("/pfs", dataRepo, "file"), "/pfs/out/file"},
-			},
-			ParallelismSpec: &pps.ParallelismSpec{
-				Strategy: pps.ParallelismSpec_CONSTANT,
-				Constant: 1,
-			},
-			ResourceSpec: &pps.ResourceSpec{
-				Memory: "100M",
-				Cpu:    0.5,
-			},
-			Inputs: []*pps.PipelineInput{{
-				Repo: &pfs.Repo{Name: dataRepo},
-				Glob: "/*",
-			}},
-		})
+	token, err := c.Activate("alice")
+	require.NoError(t, err)
+	alice, err := client.NewFromAddress(c.Addr(), client.WithAuthToken(token))
+	require.NoError(t, err)
+	admins, err := alice.GetAdmins()
+	require.NoError(t, err)
+	require.Equal(t, []string{"alice"}, admins)
+
+	// bob isn't an admin, so can't delete everything, deactivate auth or
+	// make themselves an admin
+	bobToken, err := alice.GetToken("bob", 0)
+	require.NoError(t, err)
+	bob, err := client.NewFromAddress(c.Addr(), client.WithAuthToken(bobToken))
+	require.NoError(t, err)
+	require.True(t, errors.Is(bob.DeleteAll(), client.ErrNotAuthorized))
+	require.True(t, errors.Is(bob.Deactivate(), client.ErrNotAuthorized))
+	require.True(t, errors.Is(bob.ModifyAdmins([]string{"bob"}, nil), client.ErrNotAuthorized))
+	// bob can issue tokens for themselves, but not for others
+	_, err = bob.GetToken("bob", 0)
+	require.NoError(t, err)
+	_, err = bob.GetToken("carol", 0)
+	require.True(t, errors.Is(err, client.ErrNotAuthorized))
+	// nor can they mirror repos to other clusters, as mirrors read and write
+	// repos regardless of their ACLs
+	require.True(t, errors.Is(bob.CreateMirror(&adminclient.MirrorSpec{
+		Name:    "mirror",
+		Repo:    "repo",
+		Branch:  "master",
+		Address: "localhost:650",
+	}), client.ErrNotAuthorized))
+	_, err = bob.ListMirror()
+	require.True(t, errors.Is(err, client.ErrNotAuthorized))
+	require.True(t, errors.Is(bob.DeleteMirror("mirror"), client.ErrNotAuthorized))
+
+	// once alice makes bob an admin and stops being one, only bob can
+	require.NoError(t, alice.ModifyAdmins([]string{"bob"}, []string{"alice"}))
+	admins, err = bob.GetAdmins()
+	require.NoError(t, err)
+	require.Equal(t, []string{"bob"}, admins)
+	require.True(t, errors.Is(alice.Deactivate(), client.ErrNotAuthorized))
+	// the last admin can't be removed
+	require.YesError(t, bob.ModifyAdmins(nil, []string{"bob"}))
+	require.NoError(t, bob.Deactivate())
+}
+
+func TestAuthTokenRevocation(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	c := getPachClient(t)
+	token, err := c.Activate("alice")
 	require.NoError(t, err)
-	// Do a commit to repo
-	commit, err := c.StartCommit(dataRepo, "master")
+	alice, err := client.NewFromAddress(c.Addr(), client.WithAuthToken(token))
 	require.NoError(t, err)
-	_, err = c.PutFile(dataRepo, commit.ID, "file", strings.NewReader("foo\n"))
+	defer func() {
+		require.NoError(t, alice.Deactivate())
+	}()
+
+	// tokens expire after the default TTL unless they're renewed, and only
+	// admins can exceed it
+	bobToken, err := alice.GetToken("bob", 0)
 	require.NoError(t, err)
-	require.NoError(t, c.FinishCommit(dataRepo, commit.ID))
-	commitIter, err := c.FlushCommit([]*pfs.Commit{commit}, nil)
+	bob, err := client.NewFromAddress(c.Addr(), client.WithAuthToken(bobToken))
 	require.NoError(t, err)
-	commitInfos := collectCommitInfos(t, commitIter)
-	require.Equal(t, 1, len(commitInfos))
-	repoInfo, err := c.InspectRepo(dataRepo)
+	expiration, err := bob.RenewToken(time.Hour)
 	require.NoError(t, err)
-	require.NoError(t, pfspretty.PrintDetailedRepoInfo(repoInfo))
-	for _, commitInfo := range commitInfos {
-		require.NoError(t, pfspretty.PrintDetailedCommitInfo(commitInfo))
+	require.True(t, expiration.After(time.Now().Add(59*time.Minute)))
+	_, err = bob.RenewToken(100000 * time.Hour)
+	require.True(t, errors.Is(err, client.ErrNotAuthorized))
+	_, err = bob.GetToken("bob", 100000*time.Hour)
+	require.True(t, errors.Is(err, client.ErrNotAuthorized))
+
+	// a revoked token can't be used
+	require.NoError(t, bob.RevokeToken(""))
+	_, err = bob.WhoAmI()
+	require.True(t, errors.Is(err, client.ErrNotSignedIn))
+
+	// bob can't revoke alice's tokens, but alice can revoke all of bob's
+	bobToken, err = alice.GetToken("bob", 0)
+	require.NoError(t, err)
+	bob, err = client.NewFromAddress(c.Addr(), client.WithAuthToken(bobToken))
+	require.NoError(t, err)
+	_, err = alice.GetToken("bob", 0)
+	require.NoError(t, err)
+	_, err = bob.RevokeUserTokens("alice")
+	require.True(t, errors.Is(err, client.ErrNotAuthorized))
+	revoked, err := alice.RevokeUserTokens("bob")
+	require.NoError(t, err)
+	require.Equal(t, int64(2), revoked)
+	_, err = bob.WhoAmI()
+	require.True(t, errors.Is(err, client.ErrNotSignedIn))
+
+	// revoking more tokens than fit in one etcd transaction works
+	for i := 0; i < 200; i++ {
+		_, err = alice.GetToken("carol", 0)
+		require.NoError(t, err)
 	}
-	fileInfo, err := c.InspectFile(dataRepo, commit.ID, "file")
+	revoked, err = alice.RevokeUserTokens("carol")
 	require.NoError(t, err)
-	require.NoError(t, pfspretty.PrintDetailedFileInfo(fileInfo))
-	pipelineInfo, err := c.InspectPipeline(pipelineName)
+	require.Equal(t, int64(200), revoked)
+
+	// expired tokens are deleted when their user is issued a new one
+	_, err = alice.GetToken("carol", time.Second)
 	require.NoError(t, err)
-	require.NoError(t, ppspretty.PrintDetailedPipelineInfo(pipelineInfo))
-	jobInfos, err := c.ListJob("", nil)
+	time.Sleep(2 * time.Second)
+	_, err = alice.GetToken("carol", 0)
 	require.NoError(t, err)
-	require.True(t, len(jobInfos) > 0)
-	require.NoError(t, ppspretty.PrintDetailedJobInfo(jobInfos[0]))
+	revoked, err = alice.RevokeUserTokens("carol")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), revoked)
 }
 
-func TestDeleteAll(t *testing.T) {
+func TestAuthAuthorship(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration tests in short mode")
 	}
-	// this test cannot be run in parallel because it deletes everything
+
 	c := getPachClient(t)
-	// create repos
-	dataRepo := uniqueString("TestDeleteAll_data")
-	require.NoError(t, c.CreateRepo(dataRepo))
-	// create pipeline
-	pipelineName := uniqueString("pipeline")
-	require.NoError(t, c.CreatePipeline(
-		pipelineName,
+	token, err := c.Activate("alice")
+	require.NoError(t, err)
+	alice, err := client.NewFromAddress(c.Addr(), client.WithAuthToken(token))
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, alice.Deactivate())
+	}()
+	dataRepo := uniqueString("TestAuthAuthorship_data")
+	require.NoError(t, alice.CreateRepo(dataRepo))
+	require.NoError(t, alice.SetScope(dataRepo, "bob", auth.Scope_WRITER))
+	bobToken, err := alice.GetToken("bob", 0)
+	require.NoError(t, err)
+	bob, err := client.NewFromAddress(c.Addr(), client.WithAuthToken(bobToken))
+	require.NoError(t, err)
+
+	// commits are authored by the user who started them
+	commit, err := bob.StartCommit(dataRepo, "master")
+	require.NoError(t, err)
+	_, err = bob.PutFile(dataRepo, commit.ID, "file", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	require.NoError(t, bob.FinishCommit(dataRepo, commit.ID))
+	commitInfo, err := alice.InspectCommit(dataRepo, commit.ID)
+	require.NoError(t, err)
+	require.Equal(t, "bob", commitInfo.Author)
+
+	// pipelines by the user who created them, and their output commits by
+	// pachd
+	pipeline := uniqueString("TestAuthAuthorship")
+	require.NoError(t, alice.CreatePipeline(
+		pipeline,
 		"",
 		[]string{"cp", path.Join("/pfs", dataRepo, "file"), "/pfs/out/file"},
 		nil,
@@ -1672,29 +3600,49 @@ func TestDeleteAll(t *testing.T) {
 			Strategy: pps.ParallelismSpec_CONSTANT,
 			Constant: 1,
 		},
-		client.NewAtomInput(dataRepo, "/"),
+		client.NewAtomInput(dataRepo, "/*"),
 		"",
 		false,
 	))
-	// Do commit to repo
+	pipelineInfo, err := alice.InspectPipeline(pipeline)
+	require.NoError(t, err)
+	require.Equal(t, "alice", pipelineInfo.Author)
+	commitIter, err := alice.FlushCommit([]*pfs.Commit{commit}, nil)
+	require.NoError(t, err)
+	commitInfos := collectCommitInfos(t, commitIter)
+	require.Equal(t, 1, len(commitInfos))
+	require.Equal(t, "pachd", commitInfos[0].Author)
+	require.NoError(t, alice.DeletePipeline(pipeline, true))
+}
+
+func TestFsck(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	t.Parallel()
+	c := getPachClient(t)
+	dataRepo := uniqueString("TestFsck_data")
+	require.NoError(t, c.CreateRepo(dataRepo))
 	commit, err := c.StartCommit(dataRepo, "master")
 	require.NoError(t, err)
 	_, err = c.PutFile(dataRepo, commit.ID, "file", strings.NewReader("foo\n"))
 	require.NoError(t, err)
-	require.NoError(t, c.FinishCommit(dataRepo, commit.ID))
-	commitIter, err := c.FlushCommit([]*pfs.Commit{commit}, nil)
-	require.NoError(t, err)
-	require.Equal(t, 1, len(collectCommitInfos(t, commitIter)))
-	require.NoError(t, c.DeleteAll())
-	repoInfos, err := c.ListRepo(nil)
+	require.NoError(t, c.FinishCommit(dataRepo, commit.ID))
+
+	fileInfo, err := c.InspectFile(dataRepo, commit.ID, "file")
 	require.NoError(t, err)
-	require.Equal(t, 0, len(repoInfos))
-	pipelineInfos, err := c.ListPipeline()
+	require.Equal(t, 1, len(fileInfo.Objects))
+	exists, err := c.CheckObject(fileInfo.Objects[0].Hash)
 	require.NoError(t, err)
-	require.Equal(t, 0, len(pipelineInfos))
-	jobInfos, err := c.ListJob("", nil)
+	require.True(t, exists)
+	exists, err = c.CheckObject(uuid.NewWithoutDashes())
 	require.NoError(t, err)
-	require.Equal(t, 0, len(jobInfos))
+	require.False(t, exists)
+
+	require.NoError(t, admin.Fsck(c, func(problem *admin.Problem) error {
+		return fmt.Errorf("unexpected problem: %s", problem)
+	}))
 }
 
 func TestRecursiveCp(t *testing.T) {
@@ -2002,86 +3950,434 @@ func TestStopPipeline(t *testing.T) {
 	// create pipeline
 	pipelineName := uniqueString("pipeline")
 	require.NoError(t, c.CreatePipeline(
-		pipelineName,
+		pipelineName,
+		"",
+		[]string{"cp", path.Join("/pfs", dataRepo, "file"), "/pfs/out/file"},
+		nil,
+		&pps.ParallelismSpec{
+			Strategy: pps.ParallelismSpec_CONSTANT,
+			Constant: 1,
+		},
+		client.NewAtomInput(dataRepo, "/*"),
+		"",
+		false,
+	))
+	require.NoError(t, c.StopPipeline(pipelineName))
+	// Do first commit to repo
+	commit1, err := c.StartCommit(dataRepo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(dataRepo, commit1.ID, "file", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(dataRepo, commit1.ID))
+	// wait for 10 seconds and check that no commit has been outputted
+	time.Sleep(10 * time.Second)
+	commits, err := c.ListCommit(pipelineName, "", "", 0)
+	require.NoError(t, err)
+	require.Equal(t, len(commits), 0)
+	require.NoError(t, c.StartPipeline(pipelineName))
+	commitIter, err := c.FlushCommit([]*pfs.Commit{commit1}, nil)
+	require.NoError(t, err)
+	commitInfos := collectCommitInfos(t, commitIter)
+	require.Equal(t, 1, len(commitInfos))
+	var buffer bytes.Buffer
+	require.NoError(t, c.GetFile(pipelineName, commitInfos[0].Commit.ID, "file", 0, 0, &buffer))
+	require.Equal(t, "foo\n", buffer.String())
+}
+
+func TestPipelineAutoScaledown(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getPachClient(t)
+	// create repos
+	dataRepo := uniqueString("TestPipelineAutoScaleDown")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	// create pipeline
+	pipelineName := uniqueString("pipeline-auto-scaledown")
+	parallelism := 4
+	scaleDownThreshold := time.Duration(10 * time.Second)
+	_, err := c.PpsAPIClient.CreatePipeline(
+		context.Background(),
+		&pps.CreatePipelineRequest{
+			Pipeline: client.NewPipeline(pipelineName),
+			Transform: &pps.Transform{
+				Cmd: []string{"sh"},
+				Stdin: []string{
+					"echo success",
+				},
+			},
+			ParallelismSpec: &pps.ParallelismSpec{
+				Strategy: pps.ParallelismSpec_CONSTANT,
+				Constant: uint64(parallelism),
+			},
+			Inputs: []*pps.PipelineInput{{
+				Repo: &pfs.Repo{Name: dataRepo},
+				Glob: "/",
+			}},
+			ScaleDownThreshold: types.DurationProto(scaleDownThreshold),
+		})
+	require.NoError(t, err)
+
+	// Wait for the pipeline to scale down
+	time.Sleep(scaleDownThreshold + 5*time.Second)
+
+	pipelineInfo, err := c.InspectPipeline(pipelineName)
+	require.NoError(t, err)
+
+	rc := pipelineRc(t, pipelineInfo)
+	require.Equal(t, 0, int(rc.Spec.Replicas))
+
+	// Trigger a job
+	commit, err := c.StartCommit(dataRepo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(dataRepo, commit.ID, "file", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(dataRepo, commit.ID))
+	commitIter, err := c.FlushCommit([]*pfs.Commit{commit}, nil)
+	require.NoError(t, err)
+	commitInfos := collectCommitInfos(t, commitIter)
+	require.Equal(t, 1, len(commitInfos))
+
+	rc = pipelineRc(t, pipelineInfo)
+	require.Equal(t, parallelism, int(rc.Spec.Replicas))
+
+	// Wait for the pipeline to scale down
+	time.Sleep(scaleDownThreshold + 5*time.Second)
+
+	rc = pipelineRc(t, pipelineInfo)
+	require.Equal(t, 0, int(rc.Spec.Replicas))
+}
+
+func TestPipelineEnv(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	// make a secret to reference
+	k := getKubeClient(t)
+	secretName := uniqueString("test-secret")
+	_, err := k.Secrets(getNamespace(t)).Create(
+		&api.Secret{
+			ObjectMeta: api.ObjectMeta{
+				Name: secretName,
+			},
+			Data: map[string][]byte{
+				"foo": []byte("foo\n"),
+			},
+		},
+	)
+	require.NoError(t, err)
+	c := getPachClient(t)
+	// create repos
+	dataRepo := uniqueString("TestPipelineEnv_data")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	// create pipeline
+	pipelineName := uniqueString("pipeline")
+	_, err = c.PpsAPIClient.CreatePipeline(
+		context.Background(),
+		&pps.CreatePipelineRequest{
+			Pipeline: client.NewPipeline(pipelineName),
+			Transform: &pps.Transform{
+				Cmd: []string{"sh"},
+				Stdin: []string{
+					"ls /var/secret",
+					"cat /var/secret/foo > /pfs/out/foo",
+					"echo $bar> /pfs/out/bar",
+				},
+				Env: map[string]string{"bar": "bar"},
+				Secrets: []*pps.Secret{
+					{
+						Name:      secretName,
+						MountPath: "/var/secret",
+					},
+				},
+			},
+			ParallelismSpec: &pps.ParallelismSpec{
+				Strategy: pps.ParallelismSpec_CONSTANT,
+				Constant: 1,
+			},
+			Inputs: []*pps.PipelineInput{{
+				Repo: &pfs.Repo{Name: dataRepo},
+				Glob: "/*",
+			}},
+		})
+	require.NoError(t, err)
+	// Do first commit to repo
+	commit, err := c.StartCommit(dataRepo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(dataRepo, commit.ID, "file", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(dataRepo, commit.ID))
+	commitIter, err := c.FlushCommit([]*pfs.Commit{commit}, nil)
+	require.NoError(t, err)
+	commitInfos := collectCommitInfos(t, commitIter)
+	require.Equal(t, 1, len(commitInfos))
+	var buffer bytes.Buffer
+	require.NoError(t, c.GetFile(pipelineName, commitInfos[0].Commit.ID, "foo", 0, 0, &buffer))
+	require.Equal(t, "foo\n", buffer.String())
+	buffer = bytes.Buffer{}
+	require.NoError(t, c.GetFile(pipelineName, commitInfos[0].Commit.ID, "bar", 0, 0, &buffer))
+	require.Equal(t, "bar\n", buffer.String())
+}
+
+func TestPipelineAllowedEgressValidation(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getPachClient(t)
+	dataRepo := uniqueString("TestPipelineAllowedEgressValidation_data")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	createPipeline := func(allowedEgress ...*pps.AllowedEgress) error {
+		_, err := c.PpsAPIClient.CreatePipeline(
+			context.Background(),
+			&pps.CreatePipelineRequest{
+				Pipeline: client.NewPipeline(uniqueString("pipeline")),
+				Transform: &pps.Transform{
+					Cmd:           []string{"true"},
+					AllowedEgress: allowedEgress,
+				},
+				Input: client.NewAtomInput(dataRepo, "/*"),
+			})
+		return err
+	}
+	require.YesError(t, createPipeline(&pps.AllowedEgress{CIDR: "10.1.2.3"}))
+	require.YesError(t, createPipeline(&pps.AllowedEgress{CIDR: "10.1.2.0/24", Ports: []int32{0}}))
+	require.NoError(t, createPipeline(&pps.AllowedEgress{CIDR: "10.1.2.0/24", Ports: []int32{5432}}))
+}
+
+func TestPipelineCycleValidation(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getPachClient(t)
+	dataRepo := uniqueString("TestPipelineCycleValidation_data")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	createPipeline := func(pipeline string, update bool, input *pps.Input) error {
+		_, err := c.PpsAPIClient.CreatePipeline(
+			context.Background(),
+			&pps.CreatePipelineRequest{
+				Pipeline:  client.NewPipeline(pipeline),
+				Transform: &pps.Transform{Cmd: []string{"true"}},
+				Input:     input,
+				Update:    update,
+			})
+		return err
+	}
+	a := uniqueString("A")
+	b := uniqueString("B")
+	require.NoError(t, createPipeline(a, false, client.NewAtomInput(dataRepo, "/*")))
+	require.NoError(t, createPipeline(b, false, client.NewAtomInput(a, "/*")))
+
+	// a pipeline can't take its own output as input, on any branch
+	self := uniqueString("self")
+	err := createPipeline(self, false, client.NewCrossInput(
+		client.NewAtomInput(dataRepo, "/*"),
+		client.NewAtomInputOpts("", self, "stats", "/*", false, ""),
+	))
+	require.YesError(t, err)
+	require.Matches(t, "takes its own output repo as input", err.Error())
+
+	// nor depend on it through other pipelines
+	err = createPipeline(a, true, client.NewUnionInput(
+		client.NewAtomInput(dataRepo, "/*"),
+		client.NewAtomInput(b, "/*"),
+	))
+	require.YesError(t, err)
+	require.Matches(t, fmt.Sprintf("pipeline %s takes %s as input", b, a), err.Error())
+}
+
+func TestPipelineDryRun(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getPachClient(t)
+	dataRepo := uniqueString("TestPipelineDryRun_data")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	pipeline := uniqueString("pipeline")
+	request := &pps.CreatePipelineRequest{
+		Pipeline:  client.NewPipeline(pipeline),
+		Transform: &pps.Transform{Cmd: []string{"true"}},
+		Input:     client.NewAtomInput(dataRepo, "/*"),
+	}
+
+	// the input branch has no commits, so there are no datums yet
+	dryRunInfo, err := c.PpsAPIClient.DryRunPipeline(context.Background(), request)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(dryRunInfo.Datums))
+	// the defaults are filled in
+	require.Equal(t, "master", dryRunInfo.PipelineInfo.OutputBranch)
+	require.Equal(t, "master", dryRunInfo.PipelineInfo.Input.Atom.Branch)
+
+	commit, err := c.StartCommit(dataRepo, "master")
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		_, err = c.PutFile(dataRepo, commit.ID, fmt.Sprintf("file%d", i), strings.NewReader("foo"))
+		require.NoError(t, err)
+	}
+	require.NoError(t, c.FinishCommit(dataRepo, commit.ID))
+	dryRunInfo, err = c.PpsAPIClient.DryRunPipeline(context.Background(), request)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(dryRunInfo.Datums))
+	require.Equal(t, "/file0", dryRunInfo.Datums[0].Data[0].File.Path)
+
+	// neither a dry run nor CreatePipeline with dry_run creates anything
+	request.DryRun = true
+	_, err = c.PpsAPIClient.CreatePipeline(context.Background(), request)
+	require.NoError(t, err)
+	_, err = c.InspectPipeline(pipeline)
+	require.YesError(t, err)
+	_, err = c.InspectRepo(pipeline)
+	require.YesError(t, err)
+
+	// the spec is still validated
+	request.Input = client.NewAtomInput(uniqueString("missing"), "/*")
+	_, err = c.PpsAPIClient.DryRunPipeline(context.Background(), request)
+	require.YesError(t, err)
+	request.Input = client.NewAtomInput(dataRepo, "/*")
+	request.Update = true
+	_, err = c.PpsAPIClient.DryRunPipeline(context.Background(), request)
+	require.YesError(t, err)
+
+	// and so are jobs
+	dryRunInfo, err = c.PpsAPIClient.DryRunJob(context.Background(), &pps.CreateJobRequest{
+		Transform: &pps.Transform{Cmd: []string{"true"}},
+		Input: &pps.Input{Atom: &pps.AtomInput{
+			Name:   dataRepo,
+			Repo:   dataRepo,
+			Commit: commit.ID,
+			Glob:   "/",
+		}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(dryRunInfo.Datums))
+	jobInfos, err := c.ListJob("", nil)
+	require.NoError(t, err)
+	for _, jobInfo := range jobInfos {
+		require.NotEqual(t, dataRepo, jobInfo.Input.GetAtom().GetRepo())
+	}
+}
+
+func TestOutputCommitOrder(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getPachClient(t)
+	dataRepo := uniqueString("TestOutputCommitOrder_data")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	pipeline := uniqueString("pipeline")
+	// jobs take a random amount of time, so that they'd finish out of order
+	// if they weren't serialized
+	require.NoError(t, c.CreatePipeline(
+		pipeline,
 		"",
-		[]string{"cp", path.Join("/pfs", dataRepo, "file"), "/pfs/out/file"},
-		nil,
+		[]string{"bash"},
+		[]string{
+			"sleep $((RANDOM % 5))",
+			fmt.Sprintf("cp /pfs/%s/* /pfs/out/", dataRepo),
+		},
 		&pps.ParallelismSpec{
 			Strategy: pps.ParallelismSpec_CONSTANT,
 			Constant: 1,
 		},
-		client.NewAtomInput(dataRepo, "/*"),
+		client.NewAtomInput(dataRepo, "/"),
 		"",
 		false,
 	))
-	require.NoError(t, c.StopPipeline(pipelineName))
-	// Do first commit to repo
-	commit1, err := c.StartCommit(dataRepo, "master")
-	require.NoError(t, err)
-	_, err = c.PutFile(dataRepo, commit1.ID, "file", strings.NewReader("foo\n"))
+
+	var commits []*pfs.Commit
+	for i := 0; i < 5; i++ {
+		commit, err := c.StartCommit(dataRepo, "master")
+		require.NoError(t, err)
+		_, err = c.PutFile(dataRepo, commit.ID, fmt.Sprintf("file%d", i), strings.NewReader("foo"))
+		require.NoError(t, err)
+		require.NoError(t, c.FinishCommit(dataRepo, commit.ID))
+		commits = append(commits, commit)
+	}
+	commitIter, err := c.FlushCommit([]*pfs.Commit{commits[len(commits)-1]}, []*pfs.Repo{client.NewRepo(pipeline)})
 	require.NoError(t, err)
-	require.NoError(t, c.FinishCommit(dataRepo, commit1.ID))
-	// wait for 10 seconds and check that no commit has been outputted
-	time.Sleep(10 * time.Second)
-	commits, err := c.ListCommit(pipelineName, "", "", 0)
+	require.Equal(t, 1, len(collectCommitInfos(t, commitIter)))
+
+	// each output commit's parent is the output of an earlier input commit,
+	// so walking back from the head visits the input commits in reverse
+	index := make(map[string]int)
+	for i, commit := range commits {
+		index[commit.ID] = i
+	}
+	last := len(commits)
+	commitInfo, err := c.InspectCommit(pipeline, "master")
 	require.NoError(t, err)
-	require.Equal(t, len(commits), 0)
-	require.NoError(t, c.StartPipeline(pipelineName))
-	commitIter, err := c.FlushCommit([]*pfs.Commit{commit1}, nil)
+	for {
+		i := -1
+		for _, commit := range commitInfo.Provenance {
+			if commit.Repo.Name == dataRepo {
+				i = index[commit.ID]
+			}
+		}
+		if last == len(commits) {
+			require.Equal(t, len(commits)-1, i)
+		}
+		require.True(t, i >= 0 && i < last)
+		last = i
+		if commitInfo.ParentCommit == nil {
+			break
+		}
+		commitInfo, err = c.InspectCommit(pipeline, commitInfo.ParentCommit.ID)
+		require.NoError(t, err)
+	}
+
+	// every job but the first waited for the one created before it
+	jobInfos, err := c.ListJob(pipeline, nil)
 	require.NoError(t, err)
-	commitInfos := collectCommitInfos(t, commitIter)
-	require.Equal(t, 1, len(commitInfos))
-	var buffer bytes.Buffer
-	require.NoError(t, c.GetFile(pipelineName, commitInfos[0].Commit.ID, "file", 0, 0, &buffer))
-	require.Equal(t, "foo\n", buffer.String())
+	var parents int
+	for _, jobInfo := range jobInfos {
+		if jobInfo.ParentJob != nil {
+			parents++
+		}
+	}
+	require.Equal(t, len(jobInfos)-1, parents)
 }
 
-func TestPipelineAutoScaledown(t *testing.T) {
+func TestPipelineRunAsUserAndResourceLimits(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration tests in short mode")
 	}
 	t.Parallel()
 
 	c := getPachClient(t)
-	// create repos
-	dataRepo := uniqueString("TestPipelineAutoScaleDown")
+	dataRepo := uniqueString("TestPipelineRunAsUserAndResourceLimits_data")
 	require.NoError(t, c.CreateRepo(dataRepo))
-	// create pipeline
-	pipelineName := uniqueString("pipeline-auto-scaledown")
-	parallelism := 4
-	scaleDownThreshold := time.Duration(10 * time.Second)
+	pipelineName := uniqueString("pipeline")
 	_, err := c.PpsAPIClient.CreatePipeline(
 		context.Background(),
 		&pps.CreatePipelineRequest{
 			Pipeline: client.NewPipeline(pipelineName),
 			Transform: &pps.Transform{
-				Cmd: []string{"sh"},
-				Stdin: []string{
-					"echo success",
-				},
+				Cmd:       []string{"sh"},
+				Stdin:     []string{"id -u > /pfs/out/uid"},
+				RunAsUser: 1000,
 			},
-			ParallelismSpec: &pps.ParallelismSpec{
-				Strategy: pps.ParallelismSpec_CONSTANT,
-				Constant: uint64(parallelism),
+			ResourceSpec: &pps.ResourceSpec{
+				Cpu:    0.1,
+				Memory: "50M",
 			},
-			Inputs: []*pps.PipelineInput{{
-				Repo: &pfs.Repo{Name: dataRepo},
-				Glob: "/",
-			}},
-			ScaleDownThreshold: types.DurationProto(scaleDownThreshold),
+			ResourceLimits: &pps.ResourceSpec{
+				Cpu:    0.5,
+				Memory: "100M",
+			},
+			Input: client.NewAtomInput(dataRepo, "/*"),
 		})
 	require.NoError(t, err)
-
-	// Wait for the pipeline to scale down
-	time.Sleep(scaleDownThreshold + 5*time.Second)
-
-	pipelineInfo, err := c.InspectPipeline(pipelineName)
-	require.NoError(t, err)
-
-	rc := pipelineRc(t, pipelineInfo)
-	require.Equal(t, 0, int(rc.Spec.Replicas))
-
-	// Trigger a job
 	commit, err := c.StartCommit(dataRepo, "master")
 	require.NoError(t, err)
 	_, err = c.PutFile(dataRepo, commit.ID, "file", strings.NewReader("foo\n"))
@@ -2091,18 +4387,30 @@ func TestPipelineAutoScaledown(t *testing.T) {
 	require.NoError(t, err)
 	commitInfos := collectCommitInfos(t, commitIter)
 	require.Equal(t, 1, len(commitInfos))
-
-	rc = pipelineRc(t, pipelineInfo)
-	require.Equal(t, parallelism, int(rc.Spec.Replicas))
-
-	// Wait for the pipeline to scale down
-	time.Sleep(scaleDownThreshold + 5*time.Second)
-
-	rc = pipelineRc(t, pipelineInfo)
-	require.Equal(t, 0, int(rc.Spec.Replicas))
+	var buffer bytes.Buffer
+	require.NoError(t, c.GetFile(pipelineName, commitInfos[0].Commit.ID, "uid", 0, 0, &buffer))
+	require.Equal(t, "1000\n", buffer.String())
+
+	createPipeline := func(transform *pps.Transform, limits *pps.ResourceSpec) error {
+		_, err := c.PpsAPIClient.CreatePipeline(
+			context.Background(),
+			&pps.CreatePipelineRequest{
+				Pipeline:       client.NewPipeline(uniqueString("pipeline")),
+				Transform:      transform,
+				ResourceSpec:   &pps.ResourceSpec{Cpu: 0.5, Memory: "100M"},
+				ResourceLimits: limits,
+				Input:          client.NewAtomInput(dataRepo, "/*"),
+			})
+		return err
+	}
+	// limits can't be less than the resources the workers request
+	require.YesError(t, createPipeline(&pps.Transform{Cmd: []string{"true"}}, &pps.ResourceSpec{Memory: "50M"}))
+	require.YesError(t, createPipeline(&pps.Transform{Cmd: []string{"true"}}, &pps.ResourceSpec{Cpu: 0.1}))
+	require.YesError(t, createPipeline(&pps.Transform{Cmd: []string{"true"}}, &pps.ResourceSpec{Memory: "lots"}))
+	require.YesError(t, createPipeline(&pps.Transform{Cmd: []string{"true"}, RunAsUser: -1}, nil))
 }
 
-func TestPipelineEnv(t *testing.T) {
+func TestPipelineSecretEnv(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration tests in short mode")
 	}
@@ -2111,20 +4419,21 @@ func TestPipelineEnv(t *testing.T) {
 	// make a secret to reference
 	k := getKubeClient(t)
 	secretName := uniqueString("test-secret")
-	_, err := k.Secrets(api.NamespaceDefault).Create(
+	_, err := k.Secrets(getNamespace(t)).Create(
 		&api.Secret{
 			ObjectMeta: api.ObjectMeta{
 				Name: secretName,
 			},
 			Data: map[string][]byte{
-				"foo": []byte("foo\n"),
+				"foo": []byte("foo"),
+				"bar": []byte("bar"),
 			},
 		},
 	)
 	require.NoError(t, err)
 	c := getPachClient(t)
 	// create repos
-	dataRepo := uniqueString("TestPipelineEnv_data")
+	dataRepo := uniqueString("TestPipelineSecretEnv_data")
 	require.NoError(t, c.CreateRepo(dataRepo))
 	// create pipeline
 	pipelineName := uniqueString("pipeline")
@@ -2135,15 +4444,25 @@ func TestPipelineEnv(t *testing.T) {
 			Transform: &pps.Transform{
 				Cmd: []string{"sh"},
 				Stdin: []string{
-					"ls /var/secret",
-					"cat /var/secret/foo > /pfs/out/foo",
-					"echo $bar> /pfs/out/bar",
+					"echo $FOO > /pfs/out/foo",
+					"echo $BAR > /pfs/out/bar",
+					"ls /var/secret > /pfs/out/ls",
 				},
-				Env: map[string]string{"bar": "bar"},
 				Secrets: []*pps.Secret{
+					{
+						Name:   secretName,
+						EnvVar: "FOO",
+						Key:    "foo",
+					},
+					{
+						Name:   secretName,
+						EnvVar: "BAR",
+						Key:    "bar",
+					},
 					{
 						Name:      secretName,
 						MountPath: "/var/secret",
+						Items:     []string{"foo"},
 					},
 				},
 			},
@@ -2157,7 +4476,6 @@ func TestPipelineEnv(t *testing.T) {
 			}},
 		})
 	require.NoError(t, err)
-	// Do first commit to repo
 	commit, err := c.StartCommit(dataRepo, "master")
 	require.NoError(t, err)
 	_, err = c.PutFile(dataRepo, commit.ID, "file", strings.NewReader("foo\n"))
@@ -2167,12 +4485,27 @@ func TestPipelineEnv(t *testing.T) {
 	require.NoError(t, err)
 	commitInfos := collectCommitInfos(t, commitIter)
 	require.Equal(t, 1, len(commitInfos))
-	var buffer bytes.Buffer
-	require.NoError(t, c.GetFile(pipelineName, commitInfos[0].Commit.ID, "foo", 0, 0, &buffer))
-	require.Equal(t, "foo\n", buffer.String())
-	buffer = bytes.Buffer{}
-	require.NoError(t, c.GetFile(pipelineName, commitInfos[0].Commit.ID, "bar", 0, 0, &buffer))
-	require.Equal(t, "bar\n", buffer.String())
+	for file, expected := range map[string]string{"foo": "foo\n", "bar": "bar\n", "ls": "foo\n"} {
+		var buffer bytes.Buffer
+		require.NoError(t, c.GetFile(pipelineName, commitInfos[0].Commit.ID, file, 0, 0, &buffer))
+		require.Equal(t, expected, buffer.String())
+	}
+
+	// a secret needs a mount path or an env var
+	_, err = c.PpsAPIClient.CreatePipeline(
+		context.Background(),
+		&pps.CreatePipelineRequest{
+			Pipeline: client.NewPipeline(uniqueString("pipeline")),
+			Transform: &pps.Transform{
+				Cmd:     []string{"true"},
+				Secrets: []*pps.Secret{{Name: secretName}},
+			},
+			Inputs: []*pps.PipelineInput{{
+				Repo: &pfs.Repo{Name: dataRepo},
+				Glob: "/*",
+			}},
+		})
+	require.YesError(t, err)
 }
 
 func TestPipelineWithFullObjects(t *testing.T) {
@@ -2535,6 +4868,18 @@ func collectCommitInfos(t testing.TB, commitInfoIter client.CommitInfoIterator)
 	}
 }
 
+func collectJobInfos(t testing.TB, jobInfoIter client.JobInfoIterator) []*pps.JobInfo {
+	var jobInfos []*pps.JobInfo
+	for {
+		jobInfo, err := jobInfoIter.Next()
+		if err == io.EOF {
+			return jobInfos
+		}
+		require.NoError(t, err)
+		jobInfos = append(jobInfos, jobInfo)
+	}
+}
+
 func TestParallelismSpec(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration tests in short mode")
@@ -2583,6 +4928,77 @@ func TestParallelismSpec(t *testing.T) {
 	parellelism, err = pps_server.GetExpectedNumWorkers(getKubeClient(t), nil)
 	require.NoError(t, err)
 	require.Equal(t, uint64(1), parellelism)
+
+	// Autoscaled workers start at their minimum
+	parellelism, err = pps_server.GetExpectedNumWorkers(getKubeClient(t), &pps.ParallelismSpec{
+		Strategy: pps.ParallelismSpec_AUTOSCALE,
+		Min:      2,
+		Max:      5,
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), parellelism)
+}
+
+func TestPipelineAutoscale(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getPachClient(t)
+	dataRepo := uniqueString("TestPipelineAutoscale_data")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	pipelineName := uniqueString("TestPipelineAutoscale")
+	// max can't be less than min
+	require.YesError(t, c.CreatePipeline(
+		pipelineName,
+		"",
+		[]string{"bash"},
+		[]string{fmt.Sprintf("cp /pfs/%s/* /pfs/out/", dataRepo)},
+		&pps.ParallelismSpec{
+			Strategy: pps.ParallelismSpec_AUTOSCALE,
+			Min:      2,
+			Max:      1,
+		},
+		client.NewAtomInput(dataRepo, "/*"),
+		"",
+		false,
+	))
+	require.NoError(t, c.CreatePipeline(
+		pipelineName,
+		"",
+		[]string{"bash"},
+		[]string{fmt.Sprintf("cp /pfs/%s/* /pfs/out/", dataRepo)},
+		&pps.ParallelismSpec{
+			Strategy: pps.ParallelismSpec_AUTOSCALE,
+			Max:      3,
+		},
+		client.NewAtomInput(dataRepo, "/*"),
+		"",
+		false,
+	))
+	pipelineInfo, err := c.InspectPipeline(pipelineName)
+	require.NoError(t, err)
+	rcName := pps_server.PipelineRcName(pipelineInfo.Pipeline.Name, pipelineInfo.Version)
+	rc := getKubeClient(t).ReplicationControllers(getNamespace(t))
+	workerRc, err := rc.Get(rcName)
+	require.NoError(t, err)
+	require.Equal(t, int32(0), workerRc.Spec.Replicas)
+
+	// a job with more datums than max gets max workers
+	commit, err := c.StartCommit(dataRepo, "master")
+	require.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		_, err = c.PutFile(dataRepo, commit.ID, fmt.Sprintf("file%d", i), strings.NewReader("foo\n"))
+		require.NoError(t, err)
+	}
+	require.NoError(t, c.FinishCommit(dataRepo, commit.ID))
+	commitIter, err := c.FlushCommit([]*pfs.Commit{commit}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(collectCommitInfos(t, commitIter)))
+	workerRc, err = rc.Get(rcName)
+	require.NoError(t, err)
+	require.Equal(t, int32(3), workerRc.Spec.Replicas)
 }
 
 func TestPipelineJobDeletion(t *testing.T) {
@@ -2688,11 +5104,24 @@ func TestStopJob(t *testing.T) {
 	jobInfo, err := c.InspectJob(jobInfos[1].Job.ID, true)
 	require.NoError(t, err)
 	require.Equal(t, pps.JobState_JOB_STOPPED, jobInfo.State)
+	// the datum that was being processed is stopped, rather than pending
+	datumInfos, err := c.ListDatum(jobInfos[1].Job.ID)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(datumInfos))
+	require.Equal(t, pps.DatumState_DATUM_STOPPED, datumInfos[0].State)
+	require.Equal(t, int64(0), jobInfo.DataProcessed)
 
 	// Check that the second job completes
 	jobInfo, err = c.InspectJob(jobInfos[0].Job.ID, true)
 	require.NoError(t, err)
 	require.Equal(t, pps.JobState_JOB_SUCCESS, jobInfo.State)
+
+	// finished jobs can't be stopped, and keep the state they finished in
+	require.YesError(t, c.StopJob(jobInfos[0].Job.ID))
+	require.YesError(t, c.StopJob(jobInfos[1].Job.ID))
+	jobInfo, err = c.InspectJob(jobInfos[0].Job.ID, false)
+	require.NoError(t, err)
+	require.Equal(t, pps.JobState_JOB_SUCCESS, jobInfo.State)
 }
 
 func TestGetLogs(t *testing.T) {
@@ -2808,6 +5237,100 @@ func TestGetLogs(t *testing.T) {
 	iter = c.GetLogs("", jobInfos[0].Job.ID, []string{"__DOES_NOT_EXIST__"})
 	require.False(t, iter.Next())
 	require.NoError(t, iter.Err())
+
+	// Filter logs based on datum, the job has only one
+	datumInfos, err := c.ListDatum(jobInfos[0].Job.ID)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(datumInfos))
+	iter = c.GetLogsWithOptions("", jobInfos[0].Job.ID, nil, datumInfos[0].ID, false, 0)
+	numDatumLogs := 0
+	for iter.Next() {
+		numDatumLogs++
+		require.Equal(t, datumInfos[0].ID, iter.Message().DatumID)
+	}
+	require.NoError(t, iter.Err())
+	require.Equal(t, numLogs, numDatumLogs)
+	iter = c.GetLogsWithOptions("", jobInfos[0].Job.ID, nil, "__DOES_NOT_EXIST__", false, 0)
+	require.False(t, iter.Next())
+	require.NoError(t, iter.Err())
+
+	// Get only the last line (there's one worker)
+	iter = c.GetLogsWithOptions("", jobInfos[0].Job.ID, nil, "", false, 1)
+	require.True(t, iter.Next())
+	require.False(t, iter.Next())
+	require.NoError(t, iter.Err())
+
+	// Follow the logs, the last line is returned and then the iterator
+	// blocks until it's closed
+	iter = c.GetLogsWithOptions("", jobInfos[0].Job.ID, nil, "", true, 1)
+	require.True(t, iter.Next())
+	time.AfterFunc(5*time.Second, iter.Close)
+	require.False(t, iter.Next())
+	require.NoError(t, iter.Err())
+}
+
+func TestGetLogsMerged(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	t.Parallel()
+
+	c := getPachClient(t)
+	dataRepo := uniqueString("TestGetLogsMerged_data")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	pipelineName := uniqueString("TestGetLogsMerged")
+	require.NoError(t, c.CreatePipeline(
+		pipelineName,
+		"",
+		[]string{"sh"},
+		[]string{
+			"for i in 1 2 3; do echo $i; sleep 1; done",
+		},
+		&pps.ParallelismSpec{
+			Strategy: pps.ParallelismSpec_CONSTANT,
+			Constant: 4,
+		},
+		client.NewAtomInput(dataRepo, "/*"),
+		"",
+		false,
+	))
+	commit, err := c.StartCommit(dataRepo, "master")
+	require.NoError(t, err)
+	for i := 0; i < 8; i++ {
+		_, err = c.PutFile(dataRepo, commit.ID, fmt.Sprintf("file%d", i), strings.NewReader("foo\n"))
+		require.NoError(t, err)
+	}
+	require.NoError(t, c.FinishCommit(dataRepo, commit.ID))
+	commitIter, err := c.FlushCommit([]*pfs.Commit{commit}, nil)
+	require.NoError(t, err)
+	commitInfos := collectCommitInfos(t, commitIter)
+	require.Equal(t, 1, len(commitInfos))
+	jobInfos, err := c.ListJob(pipelineName, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(jobInfos))
+
+	// the lines of every worker are returned in the order they were logged,
+	// tagged with the worker and datum that logged them
+	iter := c.GetLogs("", jobInfos[0].Job.ID, nil)
+	var last time.Time
+	workers := make(map[string]bool)
+	for iter.Next() {
+		msg := iter.Message()
+		require.True(t, msg.WorkerID != "")
+		workers[msg.WorkerID] = true
+		if msg.Ts == nil {
+			continue
+		}
+		ts, err := types.TimestampFromProto(msg.Ts)
+		require.NoError(t, err)
+		require.False(t, ts.Before(last), "%v logged before %v", msg, last)
+		last = ts
+		if msg.User {
+			require.True(t, msg.DatumID != "")
+		}
+	}
+	require.NoError(t, iter.Err())
+	require.True(t, len(workers) > 1)
 }
 
 func TestPfsPutFile(t *testing.T) {
@@ -3084,7 +5607,7 @@ func TestSystemResourceRequests(t *testing.T) {
 		b := backoff.NewExponentialBackOff()
 		b.MaxElapsedTime = 10 * time.Second
 		err := backoff.Retry(func() error {
-			podList, err := kubeClient.Pods(api.NamespaceDefault).List(api.ListOptions{
+			podList, err := kubeClient.Pods(getNamespace(t)).List(api.ListOptions{
 				LabelSelector: labels.SelectorFromSet(
 					map[string]string{"app": app, "suite": "pachyderm"}),
 			})
@@ -3178,7 +5701,7 @@ func TestPipelineResourceRequest(t *testing.T) {
 	b := backoff.NewExponentialBackOff()
 	b.MaxElapsedTime = 10 * time.Second
 	err = backoff.Retry(func() error {
-		podList, err := kubeClient.Pods(api.NamespaceDefault).List(api.ListOptions{
+		podList, err := kubeClient.Pods(getNamespace(t)).List(api.ListOptions{
 			LabelSelector: labels.SelectorFromSet(
 				map[string]string{"app": rcName}),
 		})
@@ -3247,7 +5770,7 @@ func TestJobResourceRequest(t *testing.T) {
 	b := backoff.NewExponentialBackOff()
 	b.MaxElapsedTime = 10 * time.Second
 	err = backoff.Retry(func() error {
-		podList, err := kubeClient.Pods(api.NamespaceDefault).List(api.ListOptions{
+		podList, err := kubeClient.Pods(getNamespace(t)).List(api.ListOptions{
 			LabelSelector: labels.SelectorFromSet(
 				map[string]string{"app": rcName}),
 		})
@@ -3461,7 +5984,7 @@ func TestUnionInput(t *testing.T) {
 
 func restartAll(t *testing.T) {
 	k := getKubeClient(t)
-	podsInterface := k.Pods(api.NamespaceDefault)
+	podsInterface := k.Pods(getNamespace(t))
 	labelSelector, err := labels.Parse("suite=pachyderm")
 	require.NoError(t, err)
 	podList, err := podsInterface.List(
@@ -3477,7 +6000,7 @@ func restartAll(t *testing.T) {
 
 func restartOne(t *testing.T) {
 	k := getKubeClient(t)
-	podsInterface := k.Pods(api.NamespaceDefault)
+	podsInterface := k.Pods(getNamespace(t))
 	labelSelector, err := labels.Parse("app=pachd")
 	require.NoError(t, err)
 	podList, err := podsInterface.List(
@@ -3489,6 +6012,22 @@ func restartOne(t *testing.T) {
 	waitForReadiness(t)
 }
 
+// restartOneGracefully is like restartOne, except that the pachd is given the
+// time to shut down that kubernetes gives it during an upgrade.
+func restartOneGracefully(t *testing.T) {
+	k := getKubeClient(t)
+	podsInterface := k.Pods(getNamespace(t))
+	labelSelector, err := labels.Parse("app=pachd")
+	require.NoError(t, err)
+	podList, err := podsInterface.List(
+		api.ListOptions{
+			LabelSelector: labelSelector,
+		})
+	require.NoError(t, err)
+	require.NoError(t, podsInterface.Delete(podList.Items[rand.Intn(len(podList.Items))].Name, nil))
+	waitForReadiness(t)
+}
+
 const (
 	retries = 10
 )
@@ -3519,14 +6058,14 @@ func waitForReadiness(t testing.TB) {
 		// broke it due to a type error.  We should see if we can go back to
 		// using that code but I(jdoliner) couldn't figure out how to fanagle
 		// the types into compiling.
-		newDeployment, err := k.Extensions().Deployments(api.NamespaceDefault).Get(deployment.Name)
+		newDeployment, err := k.Extensions().Deployments(getNamespace(t)).Get(deployment.Name)
 		require.NoError(t, err)
 		if newDeployment.Status.ObservedGeneration >= deployment.Generation && newDeployment.Status.Replicas == newDeployment.Spec.Replicas {
 			break
 		}
 		time.Sleep(time.Second * 5)
 	}
-	watch, err := k.Pods(api.NamespaceDefault).Watch(api.ListOptions{
+	watch, err := k.Pods(getNamespace(t)).Watch(api.ListOptions{
 		LabelSelector: labels.SelectorFromSet(map[string]string{"app": "pachd"}),
 	})
 	defer watch.Stop()
@@ -3550,7 +6089,7 @@ func waitForReadiness(t testing.TB) {
 
 func pipelineRc(t testing.TB, pipelineInfo *pps.PipelineInfo) *api.ReplicationController {
 	k := getKubeClient(t)
-	rc := k.ReplicationControllers(api.NamespaceDefault)
+	rc := k.ReplicationControllers(getNamespace(t))
 	result, err := rc.Get(pps_server.PipelineRcName(pipelineInfo.Pipeline.Name, pipelineInfo.Version))
 	require.NoError(t, err)
 	return result
@@ -3558,7 +6097,7 @@ func pipelineRc(t testing.TB, pipelineInfo *pps.PipelineInfo) *api.ReplicationCo
 
 func pachdDeployment(t testing.TB) *extensions.Deployment {
 	k := getKubeClient(t)
-	result, err := k.Extensions().Deployments(api.NamespaceDefault).Get("pachd")
+	result, err := k.Extensions().Deployments(getNamespace(t)).Get("pachd")
 	require.NoError(t, err)
 	return result
 }
@@ -3588,7 +6127,7 @@ func scalePachdN(t testing.TB, n int) {
 	k := getKubeClient(t)
 	pachdDeployment := pachdDeployment(t)
 	pachdDeployment.Spec.Replicas = int32(n)
-	_, err := k.Extensions().Deployments(api.NamespaceDefault).Update(pachdDeployment)
+	_, err := k.Extensions().Deployments(getNamespace(t)).Update(pachdDeployment)
 	require.NoError(t, err)
 	waitForReadiness(t)
 	// Unfortunately, even when all pods are ready, the cluster membership
@@ -3619,6 +6158,18 @@ func getKubeClient(t testing.TB) *kube.Client {
 	return k
 }
 
+// getNamespace returns the kubernetes namespace that the Pachyderm instance
+// under test is deployed in: that of the active pachctl context, or else the
+// default namespace.
+func getNamespace(t testing.TB) string {
+	cfg, err := config.Read()
+	require.NoError(t, err)
+	if context, err := cfg.CurrentContext(); err == nil && context != nil && context.Namespace != "" {
+		return context.Namespace
+	}
+	return api.NamespaceDefault
+}
+
 func getPachClient(t testing.TB) *client.APIClient {
 	var c *client.APIClient
 	var err error
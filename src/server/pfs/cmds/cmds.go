@@ -6,14 +6,16 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 	"os"
-	"path"
+	"os/signal"
 	"path/filepath"
 	"strings"
 	"syscall"
 	"text/tabwriter"
+	"time"
 
 	"golang.org/x/sync/errgroup"
 
@@ -21,10 +23,14 @@ import (
 	"github.com/pachyderm/pachyderm/src/client/limit"
 	pfsclient "github.com/pachyderm/pachyderm/src/client/pfs"
 	"github.com/pachyderm/pachyderm/src/server/pfs/fuse"
+	"github.com/pachyderm/pachyderm/src/server/pfs/notebook"
 	"github.com/pachyderm/pachyderm/src/server/pfs/pretty"
 	"github.com/pachyderm/pachyderm/src/server/pkg/cmdutil"
 	"github.com/pachyderm/pachyderm/src/server/pkg/sync"
 
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/types"
+	"github.com/mattn/go-isatty"
 	"github.com/spf13/cobra"
 )
 
@@ -38,7 +44,7 @@ const (
 )
 
 // Cmds returns a slice containing pfs commands.
-func Cmds(address string, noMetrics *bool) []*cobra.Command {
+func Cmds(noMetrics *bool) []*cobra.Command {
 	metrics := !*noMetrics
 
 	repo := &cobra.Command{
@@ -53,12 +59,17 @@ func Cmds(address string, noMetrics *bool) []*cobra.Command {
 	}
 
 	var description string
+	var labels cmdutil.RepeatedStringArg
 	createRepo := &cobra.Command{
 		Use:   "create-repo repo-name",
 		Short: "Create a new repo.",
 		Long:  "Create a new repo.",
 		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
-			c, err := client.NewMetricsClientFromAddress(address, metrics, "user")
+			c, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			repoLabels, err := parseLabels(labels)
 			if err != nil {
 				return err
 			}
@@ -68,19 +79,63 @@ func Cmds(address string, noMetrics *bool) []*cobra.Command {
 				&pfsclient.CreateRepoRequest{
 					Repo:        client.NewRepo(args[0]),
 					Description: description,
+					Labels:      repoLabels,
 				},
 			)
 			return err
 		}),
 	}
 	createRepo.Flags().StringVarP(&description, "description", "d", "", "A description of the repo.")
+	createRepo.Flags().Var(&labels, "label", "A label for the repo, written key=value; can be repeated.")
+
+	var removeLabels cmdutil.RepeatedStringArg
+	updateRepo := &cobra.Command{
+		Use:   "update-repo repo-name",
+		Short: "Update a repo's description and labels.",
+		Long: `Update a repo's description and labels.
+
+Labels that are set replace the values of any labels with the same keys that
+the repo already has; its other labels are left as they are.
+
+Examples:
+
+` + codestart + `# label the repo "logs"
+$ pachctl update-repo logs --label team=data --label stage=prod
+
+# change the repo's description and remove its "stage" label
+$ pachctl update-repo logs --description "Web server logs." --remove-label stage
+` + codeend,
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			c, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			repoLabels, err := parseLabels(labels)
+			if err != nil {
+				return err
+			}
+			_, err = c.PfsAPIClient.UpdateRepo(
+				context.Background(),
+				&pfsclient.UpdateRepoRequest{
+					Repo:         client.NewRepo(args[0]),
+					Description:  description,
+					Labels:       repoLabels,
+					RemoveLabels: removeLabels,
+				},
+			)
+			return err
+		}),
+	}
+	updateRepo.Flags().StringVarP(&description, "description", "d", "", "A new description for the repo.")
+	updateRepo.Flags().Var(&labels, "label", "A label to set on the repo, written key=value; can be repeated.")
+	updateRepo.Flags().Var(&removeLabels, "remove-label", "The key of a label to remove from the repo; can be repeated.")
 
 	inspectRepo := &cobra.Command{
 		Use:   "inspect-repo repo-name",
 		Short: "Return info about a repo.",
 		Long:  "Return info about a repo.",
 		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
-			client, err := client.NewMetricsClientFromAddress(address, metrics, "user")
+			client, err := client.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return err
 			}
@@ -96,28 +151,40 @@ func Cmds(address string, noMetrics *bool) []*cobra.Command {
 	}
 
 	var listRepoProvenance cmdutil.RepeatedStringArg
+	var selector string
 	listRepo := &cobra.Command{
 		Use:   "list-repo",
 		Short: "Return all repos.",
-		Long:  "Reutrn all repos.",
+		Long: `Return all repos.
+
+Examples:
+
+` + codestart + `# return the repos labeled team=data that aren't labeled stage=dev
+$ pachctl list-repo -l "team=data,stage!=dev"
+` + codeend,
 		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
-			c, err := client.NewMetricsClientFromAddress(address, metrics, "user")
+			c, err := client.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return err
 			}
-			repoInfos, err := c.ListRepo(listRepoProvenance)
+			request := &pfsclient.ListRepoRequest{LabelSelector: selector}
+			for _, repoName := range listRepoProvenance {
+				request.Provenance = append(request.Provenance, client.NewRepo(repoName))
+			}
+			repoInfos, err := c.PfsAPIClient.ListRepo(context.Background(), request)
 			if err != nil {
 				return err
 			}
 			writer := tabwriter.NewWriter(os.Stdout, 20, 1, 3, ' ', 0)
 			pretty.PrintRepoHeader(writer)
-			for _, repoInfo := range repoInfos {
+			for _, repoInfo := range repoInfos.RepoInfo {
 				pretty.PrintRepoInfo(writer, repoInfo)
 			}
 			return writer.Flush()
 		}),
 	}
 	listRepo.Flags().VarP(&listRepoProvenance, "provenance", "p", "list only repos with the specified repos provenance")
+	listRepo.Flags().StringVarP(&selector, "selector", "l", "", "list only repos whose labels match this label selector, e.g. \"team=data,stage!=dev\"")
 
 	var force bool
 	deleteRepo := &cobra.Command{
@@ -125,7 +192,7 @@ func Cmds(address string, noMetrics *bool) []*cobra.Command {
 		Short: "Delete a repo.",
 		Long:  "Delete a repo.",
 		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
-			client, err := client.NewMetricsClientFromAddress(address, metrics, "user")
+			client, err := client.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return err
 			}
@@ -156,6 +223,7 @@ This layers the data in the commit over the data in the parent.
 	}
 
 	var parent string
+	var forceStartCommit bool
 	startCommit := &cobra.Command{
 		Use:   "start-commit repo-name [branch]",
 		Short: "Start a new commit.",
@@ -174,9 +242,17 @@ $ pachctl start-commit test patch -p master
 
 # Start a commit with XXX as the parent in repo "test", not on any branch
 $ pachctl start-commit test -p XXX
-` + codeend,
+` + codeend + `
+
+Output repos, which pipelines write to, can't have commits started in them
+manually unless --force is passed, as manual commits have no provenance.
+
+A branch has at most one open commit, its head. Starting a commit on a branch
+whose head is open fails with a conflict error naming that commit, and can
+be retried once it's finished; when several commits are started on a branch
+at once, exactly one of them succeeds.`,
 		Run: cmdutil.RunBoundedArgs(1, 2, func(args []string) error {
-			client, err := client.NewMetricsClientFromAddress(address, metrics, "user")
+			client, err := client.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return err
 			}
@@ -184,7 +260,12 @@ $ pachctl start-commit test -p XXX
 			if len(args) == 2 {
 				branch = args[1]
 			}
-			commit, err := client.StartCommitParent(args[0], branch, parent)
+			var commit *pfsclient.Commit
+			if forceStartCommit {
+				commit, err = client.ForceStartCommit(args[0], branch, parent)
+			} else {
+				commit, err = client.StartCommitParent(args[0], branch, parent)
+			}
 			if err != nil {
 				return err
 			}
@@ -193,26 +274,36 @@ $ pachctl start-commit test -p XXX
 		}),
 	}
 	startCommit.Flags().StringVarP(&parent, "parent", "p", "", "The parent of the new commit, unneeded if branch is specified and you want to use the previous head of the branch as the parent.")
+	startCommit.Flags().BoolVarP(&forceStartCommit, "force", "f", false, "start the commit even if the repo is a pipeline's output repo; this needs the OWNER scope if auth is active")
 
+	var verify bool
 	finishCommit := &cobra.Command{
 		Use:   "finish-commit repo-name commit-id",
 		Short: "Finish a started commit.",
-		Long:  "Finish a started commit. Commit-id must be a writeable commit.",
+		Long: `Finish a started commit. Commit-id must be a writeable commit.
+
+With --verify the commit's file sizes and the objects its files refer to are
+checked before it's finished; if they're inconsistent the commit is left open
+and the inconsistency is reported.`,
 		Run: cmdutil.RunFixedArgs(2, func(args []string) error {
-			client, err := client.NewMetricsClientFromAddress(address, metrics, "user")
+			client, err := client.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return err
 			}
+			if verify {
+				return client.FinishCommitVerify(args[0], args[1])
+			}
 			return client.FinishCommit(args[0], args[1])
 		}),
 	}
+	finishCommit.Flags().BoolVar(&verify, "verify", false, "Check the commit's sizes and object references before finishing it.")
 
 	inspectCommit := &cobra.Command{
 		Use:   "inspect-commit repo-name commit-id",
 		Short: "Return info about a commit.",
 		Long:  "Return info about a commit.",
 		Run: cmdutil.RunFixedArgs(2, func(args []string) error {
-			client, err := client.NewMetricsClientFromAddress(address, metrics, "user")
+			client, err := client.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return err
 			}
@@ -227,6 +318,21 @@ $ pachctl start-commit test -p XXX
 		}),
 	}
 
+	deleteCommit := &cobra.Command{
+		Use:   "delete-commit repo-name commit-id",
+		Short: "Delete a commit and everything built on it.",
+		Long: `Delete a commit, along with its descendants and every commit downstream of them, such as the output commits that pipelines computed from them.
+
+Branches that point at deleted commits are moved back to their closest ancestors that aren't deleted, or deleted if there aren't any. Pipelines see their input branches move back, and move their output branches back to the output that they computed from the new heads, or compute it again if it's been deleted.`,
+		Run: cmdutil.RunFixedArgs(2, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			return client.DeleteCommit(args[0], args[1])
+		}),
+	}
+
 	var from string
 	var number int
 	listCommit := &cobra.Command{
@@ -252,7 +358,7 @@ $ pachctl list-commit foo XXX
 $ pachctl list-commit foo master --from XXX
 ` + codeend,
 		Run: cmdutil.RunBoundedArgs(1, 2, func(args []string) (retErr error) {
-			c, err := client.NewMetricsClientFromAddress(address, metrics, "user")
+			c, err := client.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return err
 			}
@@ -262,14 +368,21 @@ $ pachctl list-commit foo master --from XXX
 				to = args[1]
 			}
 
-			commitInfos, err := c.ListCommit(args[0], to, from, uint64(number))
+			iter, err := c.ListCommitIter(args[0], to, from, uint64(number))
 			if err != nil {
 				return err
 			}
+			defer iter.Close()
 
 			writer := tabwriter.NewWriter(os.Stdout, 20, 1, 3, ' ', 0)
 			pretty.PrintCommitInfoHeader(writer)
-			for _, commitInfo := range commitInfos {
+			for {
+				commitInfo, err := iter.Next()
+				if err == io.EOF {
+					break
+				} else if err != nil {
+					return err
+				}
 				pretty.PrintCommitInfo(writer, commitInfo)
 			}
 			return writer.Flush()
@@ -298,7 +411,7 @@ $ pachctl flush-commit foo/XXX -r bar -r baz
 				return err
 			}
 
-			c, err := client.NewMetricsClientFromAddress(address, metrics, "user")
+			c, err := client.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return err
 			}
@@ -335,7 +448,7 @@ $ pachctl flush-commit foo/XXX -r bar -r baz
 		Short: "Return all branches on a repo.",
 		Long:  "Return all branches on a repo.",
 		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
-			client, err := client.NewMetricsClientFromAddress(address, metrics, "user")
+			client, err := client.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return err
 			}
@@ -367,7 +480,7 @@ $ pachctl set-branch foo XXX master
 # same commit.
 $ pachctl set-branch foo test master` + codeend,
 		Run: cmdutil.RunFixedArgs(3, func(args []string) error {
-			client, err := client.NewMetricsClientFromAddress(address, metrics, "user")
+			client, err := client.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return err
 			}
@@ -375,12 +488,119 @@ $ pachctl set-branch foo test master` + codeend,
 		}),
 	}
 
+	var triggerSize string
+	var triggerCommits int64
+	var triggerCron string
+	var triggerAll bool
+	var removeTrigger bool
+	setBranchTrigger := &cobra.Command{
+		Use:   "set-branch-trigger <repo-name> <branch-name> [<trigger-branch-name>]",
+		Short: "Set a trigger that moves a branch when conditions are met.",
+		Long: `Set a trigger that moves a branch to the head of another branch, the
+trigger branch, when the trigger's conditions are met. A trigger can wait for
+the data committed to the trigger branch to reach a size, for a number of
+commits to be made to it, or for a cron schedule to fire. Unless --all is
+given, the branch is moved when any one of the conditions is met. This is
+useful for batching the processing of data that's committed in many small
+commits: commit to a staging branch and trigger the branch that pipelines
+take as input.
+
+Examples:
+
+` + codestart + `# Move master to the head of staging in repo foo every 100 commits.
+$ pachctl set-branch-trigger foo master staging --commits 100
+
+# Move master to the head of staging when 1GB of data has been committed to
+# it, or every hour, whichever comes first.
+$ pachctl set-branch-trigger foo master staging --size 1GB --cron @hourly
+
+# Remove master's trigger.
+$ pachctl set-branch-trigger foo master --remove` + codeend,
+		Run: cmdutil.RunBoundedArgs(2, 3, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			if removeTrigger {
+				if len(args) > 2 {
+					return fmt.Errorf("a trigger branch can't be given with --remove")
+				}
+				return client.SetBranchTrigger(args[0], args[1], nil)
+			}
+			if len(args) < 3 {
+				return fmt.Errorf("a trigger branch must be given, unless --remove is")
+			}
+			return client.SetBranchTrigger(args[0], args[1], &pfsclient.Trigger{
+				Branch:   args[2],
+				Size_:    triggerSize,
+				Commits:  triggerCommits,
+				CronSpec: triggerCron,
+				All:      triggerAll,
+			})
+		}),
+	}
+	setBranchTrigger.Flags().StringVar(&triggerSize, "size", "", "Move the branch when this much data (e.g. 100MB) has been committed to the trigger branch.")
+	setBranchTrigger.Flags().Int64Var(&triggerCommits, "commits", 0, "Move the branch when this many commits have been made to the trigger branch.")
+	setBranchTrigger.Flags().StringVar(&triggerCron, "cron", "", "Move the branch on this cron schedule (e.g. @hourly).")
+	setBranchTrigger.Flags().BoolVar(&triggerAll, "all", false, "Move the branch only when all of the conditions are met.")
+	setBranchTrigger.Flags().BoolVar(&removeTrigger, "remove", false, "Remove the branch's trigger.")
+
+	var keepCommits int64
+	var keepDays int64
+	var removeRetention bool
+	setBranchRetention := &cobra.Command{
+		Use:   "set-branch-retention <repo-name> <branch-name>",
+		Short: "Set a retention policy that prunes a branch's old commits.",
+		Long: `Set a retention policy that prunes a branch's old commits. A policy can
+keep the last N commits on the branch, the commits finished in the last M
+days, or both, in which case commits are kept if they meet either condition.
+The branch's head is always kept, as are commits that are the heads of other
+branches or the provenance of commits in other repos, such as the input of a
+pipeline's output commits. The commits after pruned ones are kept, with their
+data intact. Branches are pruned when their policy is set, and periodically
+after that.
+
+Examples:
+
+` + codestart + `# Keep the last 100 commits on master in repo foo.
+$ pachctl set-branch-retention foo master --keep-commits 100
+
+# Keep the last 10 commits on master, and any finished in the last 30 days.
+$ pachctl set-branch-retention foo master --keep-commits 10 --keep-days 30
+
+# Remove master's retention policy.
+$ pachctl set-branch-retention foo master --remove` + codeend,
+		Run: cmdutil.RunFixedArgs(2, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			if removeRetention {
+				if keepCommits != 0 || keepDays != 0 {
+					return fmt.Errorf("--keep-commits and --keep-days can't be given with --remove")
+				}
+				return client.SetBranchRetention(args[0], args[1], nil)
+			}
+			if keepDays < 0 {
+				return fmt.Errorf("--keep-days can't be negative")
+			}
+			retention := &pfsclient.RetentionPolicy{KeepCommits: keepCommits}
+			if keepDays > 0 {
+				retention.KeepFor = types.DurationProto(time.Duration(keepDays) * 24 * time.Hour)
+			}
+			return client.SetBranchRetention(args[0], args[1], retention)
+		}),
+	}
+	setBranchRetention.Flags().Int64Var(&keepCommits, "keep-commits", 0, "Keep this many of the branch's most recent commits.")
+	setBranchRetention.Flags().Int64Var(&keepDays, "keep-days", 0, "Keep the commits finished in this many days.")
+	setBranchRetention.Flags().BoolVar(&removeRetention, "remove", false, "Remove the branch's retention policy.")
+
 	deleteBranch := &cobra.Command{
 		Use:   "delete-branch <repo-name> <branch-name>",
 		Short: "Delete a branch",
 		Long:  "Delete a branch, while leaving the commits intact",
 		Run: cmdutil.RunFixedArgs(2, func(args []string) error {
-			client, err := client.NewMetricsClientFromAddress(address, metrics, "user")
+			client, err := client.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return err
 			}
@@ -408,6 +628,9 @@ $ pachctl set-branch foo test master` + codeend,
 	var targetFileDatums uint
 	var targetFileBytes uint
 	var putFileCommit bool
+	var journalPath string
+	var showProgress bool
+	var preserveMetadata bool
 	putFile := &cobra.Command{
 		Use:   "put-file repo-name branch path/to/file/in/pfs",
 		Short: "Put a file into the filesystem.",
@@ -444,9 +667,21 @@ pachctl put-file repo branch -i file
 # NOTE this URL can reference local files, so it could cause you to put sensitive
 # files into your Pachyderm cluster.
 pachctl put-file repo branch -i http://host/path
+
+# Put the contents of a directory, recording the files that are uploaded in
+# journal. If the upload fails, re-running the same command skips the files
+# that were already uploaded.
+pachctl put-file -r repo branch -f dir --journal journal
+
+# Put the contents of a directory, preserving its files' modes, modification
+# times and symlinks, which get-file -r restores.
+pachctl put-file -r repo branch -f dir --preserve-metadata
 ` + codeend,
 		Run: cmdutil.RunBoundedArgs(2, 3, func(args []string) (retErr error) {
-			client, err := client.NewMetricsClientFromAddressWithConcurrency(address, metrics, "user", parallelism)
+			if preserveMetadata && !recursive {
+				return fmt.Errorf("--preserve-metadata can only be used with --recursive")
+			}
+			client, err := client.NewOnUserMachine(metrics, "user", client.WithMaxConcurrentStreams(parallelism))
 			if err != nil {
 				return err
 			}
@@ -506,6 +741,25 @@ pachctl put-file repo branch -i http://host/path
 			} else {
 				sources = filePaths
 			}
+			var j *journal
+			if journalPath != "" {
+				j, err = openJournal(journalPath)
+				if err != nil {
+					return err
+				}
+				defer func() {
+					if err := j.Close(); err != nil && retErr == nil {
+						retErr = err
+					}
+				}()
+			}
+			var bar *progressBar
+			if showProgress {
+				totalBytes, totalFiles := localSize(sources, recursive)
+				bar = newProgressBar(os.Stderr, totalBytes, totalFiles)
+				defer bar.Stop()
+				client.SetProgressFunc(bar.progress)
+			}
 			var eg errgroup.Group
 			for _, source := range sources {
 				source := source
@@ -515,19 +769,19 @@ pachctl put-file repo branch -i http://host/path
 						return fmt.Errorf("no filename specified")
 					}
 					eg.Go(func() error {
-						return putFileHelper(client, repoName, branch, joinPaths("", source), source, recursive, limiter, split, targetFileDatums, targetFileBytes)
+						return putFileHelper(client, repoName, branch, joinPaths("", source), source, recursive, preserveMetadata, limiter, split, targetFileDatums, targetFileBytes, j, bar)
 					})
 				} else if len(sources) == 1 && len(args) == 3 {
 					// We have a single source and the user has specified a path,
 					// we use the path and ignore source (in terms of naming the file).
 					eg.Go(func() error {
-						return putFileHelper(client, repoName, branch, path, source, recursive, limiter, split, targetFileDatums, targetFileBytes)
+						return putFileHelper(client, repoName, branch, path, source, recursive, preserveMetadata, limiter, split, targetFileDatums, targetFileBytes, j, bar)
 					})
 				} else if len(sources) > 1 && len(args) == 3 {
 					// We have multiple sources and the user has specified a path,
 					// we use that path as a prefix for the filepaths.
 					eg.Go(func() error {
-						return putFileHelper(client, repoName, branch, joinPaths(path, source), source, recursive, limiter, split, targetFileDatums, targetFileBytes)
+						return putFileHelper(client, repoName, branch, joinPaths(path, source), source, recursive, preserveMetadata, limiter, split, targetFileDatums, targetFileBytes, j, bar)
 					})
 				}
 			}
@@ -542,23 +796,55 @@ pachctl put-file repo branch -i http://host/path
 	putFile.Flags().UintVar(&targetFileDatums, "target-file-datums", 0, "the target upper bound of the number of datums that each file contains; needs to be used with --split")
 	putFile.Flags().UintVar(&targetFileBytes, "target-file-bytes", 0, "the target upper bound of the number of bytes that each file contains; needs to be used with --split")
 	putFile.Flags().BoolVarP(&putFileCommit, "commit", "c", false, "Put file(s) in a new commit.")
+	putFile.Flags().StringVar(&journalPath, "journal", "", "Record the files that are uploaded in this file and skip files that are already recorded in it, so that a failed upload can be resumed by re-running the same command.")
+	putFile.Flags().BoolVar(&showProgress, "progress", isatty.IsTerminal(os.Stderr.Fd()), "Print the progress of the upload to stderr.")
+	putFile.Flags().BoolVar(&preserveMetadata, "preserve-metadata", false, "Record the modes, modification times and symlinks of the files in a directory put with --recursive, so that get-file --recursive restores them.")
 
 	var outputPath string
+	var parquetColumns []string
+	var parquetRowGroups []int
 	getFile := &cobra.Command{
 		Use:   "get-file repo-name commit-id path/to/file",
 		Short: "Return the contents of a file.",
-		Long:  "Return the contents of a file.",
+		Long: `Return the contents of a file.
+
+Examples:
+
+` + codestart + `# get file "XXX" on branch "master" in repo "foo"
+$ pachctl get-file foo master XXX
+
+# get the directory "dir" on branch "master" in repo "foo", writing its
+# contents under the local directory "localdir". If "dir" was put with
+# --preserve-metadata, its files' modes, modification times and symlinks are
+# restored.
+$ pachctl get-file foo master dir -r -o localdir
+
+# get the columns "id" and "price" of the first two row groups of the
+# Parquet file "sales.parquet", as a Parquet file
+$ pachctl get-file foo master sales.parquet --columns id,price --row-groups 0,1 -o sales-prices.parquet
+` + codeend,
 		Run: cmdutil.RunFixedArgs(3, func(args []string) error {
-			client, err := client.NewMetricsClientFromAddress(address, metrics, "user")
-			if err != nil {
-				return err
+			parquet := len(parquetColumns) > 0 || len(parquetRowGroups) > 0
+			if parquet && recursive {
+				return fmt.Errorf("--columns and --row-groups can't be used with --recursive")
 			}
 			if recursive {
 				if outputPath == "" {
 					return fmt.Errorf("an output path needs to be specified when using the --recursive flag")
 				}
+				client, err := client.NewOnUserMachine(metrics, "user", client.WithMaxConcurrentStreams(parallelism))
+				if err != nil {
+					return err
+				}
 				puller := sync.NewPuller()
-				return puller.Pull(client, outputPath, args[0], args[1], args[2], false, int(parallelism))
+				if err := puller.Pull(client, outputPath, args[0], args[1], args[2], false, int(parallelism)); err != nil {
+					return err
+				}
+				return sync.RestoreMetadata(client, outputPath, args[0], args[1], args[2])
+			}
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
 			}
 			var w io.Writer
 			// If an output path is given, print the output to stdout
@@ -572,19 +858,73 @@ pachctl put-file repo branch -i http://host/path
 				defer f.Close()
 				w = f
 			}
+			if parquet {
+				var rowGroups []int64
+				for _, rowGroup := range parquetRowGroups {
+					rowGroups = append(rowGroups, int64(rowGroup))
+				}
+				return client.GetParquetFile(args[0], args[1], args[2], parquetColumns, rowGroups, w)
+			}
 			return client.GetFile(args[0], args[1], args[2], 0, 0, w)
 		}),
 	}
 	getFile.Flags().BoolVarP(&recursive, "recursive", "r", false, "Recursively download a directory.")
 	getFile.Flags().StringVarP(&outputPath, "output", "o", "", "The path where data will be downloaded.")
 	getFile.Flags().UintVarP(&parallelism, "parallelism", "p", DefaultParallelism, "The maximum number of files that can be downloaded in parallel")
+	getFile.Flags().StringSliceVar(&parquetColumns, "columns", nil, "Only get these top-level columns of a Parquet file, as a Parquet file. Can be given more than once, or as a comma-separated list.")
+	getFile.Flags().IntSliceVar(&parquetRowGroups, "row-groups", nil, "Only get the row groups with these indexes, starting at 0, of a Parquet file, as a Parquet file. Can be given more than once, or as a comma-separated list.")
+
+	var urlTTL time.Duration
+	getFileURL := &cobra.Command{
+		Use:   "get-file-url repo-name commit-id path/to/file",
+		Short: "Return a URL that a file can be downloaded from.",
+		Long: `Return a URL that a file can be downloaded from.
+
+The URL is signed by pachd and lets anyone who has it download the file over
+HTTP, without authenticating, until it expires. It's for the commit that
+commit-id refers to now, so it keeps returning the same content if the branch
+moves. pachd must be deployed with --download-url.
+
+Examples:
+
+` + codestart + `# get a URL for file "XXX" on branch "master" in repo "foo"
+$ pachctl get-file-url foo master XXX
+
+# get a URL that's valid for a day
+$ pachctl get-file-url foo master XXX --ttl 24h
+` + codeend,
+		Run: cmdutil.RunFixedArgs(3, func(args []string) error {
+			client, err := client.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			fileURL, err := client.GetFileURL(args[0], args[1], args[2], urlTTL)
+			if err != nil {
+				return err
+			}
+			fmt.Println(fileURL)
+			return nil
+		}),
+	}
+	getFileURL.Flags().DurationVar(&urlTTL, "ttl", time.Hour, "How long the URL can be used for, at most 168h (7 days).")
 
+	var showProvenance bool
 	inspectFile := &cobra.Command{
 		Use:   "inspect-file repo-name commit-id path/to/file",
 		Short: "Return info about a file.",
-		Long:  "Return info about a file.",
+		Long: `Return info about a file.
+
+If the file was written by a pipeline, --provenance shows the job and datum
+that wrote it, and the input files that the datum was made up of.
+
+Examples:
+
+` + codestart + `# trace the file "XXX" on branch "master" in the output repo of pipeline "foo"
+# back to the input files it was computed from
+$ pachctl inspect-file foo master XXX --provenance
+` + codeend,
 		Run: cmdutil.RunFixedArgs(3, func(args []string) error {
-			client, err := client.NewMetricsClientFromAddress(address, metrics, "user")
+			client, err := client.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return err
 			}
@@ -595,16 +935,52 @@ pachctl put-file repo branch -i http://host/path
 			if fileInfo == nil {
 				return fmt.Errorf("file %s not found", args[2])
 			}
-			return pretty.PrintDetailedFileInfo(fileInfo)
+			if err := pretty.PrintDetailedFileInfo(fileInfo); err != nil {
+				return err
+			}
+			if !showProvenance {
+				return nil
+			}
+			if len(fileInfo.Provenance) == 0 {
+				fmt.Println("Provenance: none, the file wasn't written by a pipeline")
+				return nil
+			}
+			fmt.Println("Provenance:")
+			for _, provenance := range fileInfo.Provenance {
+				datumInfo, err := client.InspectDatum(provenance.JobID, provenance.DatumID)
+				var data []*pfsclient.FileInfo
+				if err == nil {
+					data = datumInfo.Data
+				}
+				pretty.PrintFileProvenance(os.Stdout, provenance, data, err)
+			}
+			return nil
 		}),
 	}
+	inspectFile.Flags().BoolVar(&showProvenance, "provenance", false, "Show the job and datum that wrote the file, and the datum's input files.")
 
+	var fromFile string
+	var numberFiles int
 	listFile := &cobra.Command{
 		Use:   "list-file repo-name commit-id path/to/dir",
 		Short: "Return the files in a directory.",
-		Long:  "Return the files in a directory.",
+		Long: `Return the files in a directory.
+
+Large directories can be listed a page at a time with --number and --from.
+
+Examples:
+
+` + codestart + `# list the files in directory "data" of repo "foo" on branch "master"
+$ pachctl list-file foo master data
+
+# list the first 1000 files in "data"
+$ pachctl list-file foo master data -n 1000
+
+# list the next 1000, after the last file of the previous page
+$ pachctl list-file foo master data -n 1000 --from data/file0999
+` + codeend,
 		Run: cmdutil.RunBoundedArgs(2, 3, func(args []string) error {
-			client, err := client.NewMetricsClientFromAddress(address, metrics, "user")
+			client, err := client.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return err
 			}
@@ -612,7 +988,10 @@ pachctl put-file repo branch -i http://host/path
 			if len(args) == 3 {
 				path = args[2]
 			}
-			fileInfos, err := client.ListFile(args[0], args[1], path)
+			if numberFiles < 0 {
+				return fmt.Errorf("--number must not be negative")
+			}
+			fileInfos, err := client.ListFilePage(args[0], args[1], path, fromFile, uint64(numberFiles))
 			if err != nil {
 				return err
 			}
@@ -624,13 +1003,22 @@ pachctl put-file repo branch -i http://host/path
 			return writer.Flush()
 		}),
 	}
+	listFile.Flags().StringVar(&fromFile, "from", "", "list only the files after this one, the path of the last file of the previous page")
+	listFile.Flags().IntVarP(&numberFiles, "number", "n", 0, "list only this many files; if set to zero, list all files")
 
+	var raw bool
 	globFile := &cobra.Command{
 		Use:   "glob-file repo-name commit-id pattern",
 		Short: "Return files that match a glob pattern in a commit.",
 		Long: `Return files that match a glob pattern in a commit.
 
-The glob pattern is documented here: https://golang.org/pkg/path/filepath/#Match
+The files are matched exactly as they are when a pipeline with the same glob
+in its input creates datums, so each file (or directory) returned would be a
+separate datum. The glob pattern is documented here:
+https://golang.org/pkg/path/filepath/#Match
+Patterns are matched against absolute paths, so "*" and "/*" are the same,
+and "*" doesn't match "/", so "*" only matches top-level files and
+directories.
 
 Examples:
 
@@ -641,9 +1029,12 @@ $ pachctl glob-file foo master "A*"
 
 # Return files in repo "foo" on branch "master" under directory "data".
 $ pachctl glob-file foo master "data/*"
+
+# Return the FileInfos, as the workers of a pipeline would see them.
+$ pachctl glob-file foo master "data/*" --raw
 ` + codeend,
 		Run: cmdutil.RunFixedArgs(3, func(args []string) error {
-			client, err := client.NewMetricsClientFromAddress(address, metrics, "user")
+			client, err := client.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return err
 			}
@@ -651,6 +1042,16 @@ $ pachctl glob-file foo master "data/*"
 			if err != nil {
 				return err
 			}
+			if raw {
+				marshaller := &jsonpb.Marshaler{Indent: "  "}
+				for _, fileInfo := range fileInfos {
+					if err := marshaller.Marshal(os.Stdout, fileInfo); err != nil {
+						return err
+					}
+					fmt.Println()
+				}
+				return nil
+			}
 			writer := tabwriter.NewWriter(os.Stdout, 20, 1, 3, ' ', 0)
 			pretty.PrintFileInfoHeader(writer)
 			for _, fileInfo := range fileInfos {
@@ -660,12 +1061,14 @@ $ pachctl glob-file foo master "data/*"
 		}),
 	}
 
+	globFile.Flags().BoolVar(&raw, "raw", false, "Print the FileInfos as JSON.")
+
 	deleteFile := &cobra.Command{
 		Use:   "delete-file repo-name commit-id path/to/file",
 		Short: "Delete a file.",
 		Long:  "Delete a file.",
 		Run: cmdutil.RunFixedArgs(3, func(args []string) error {
-			client, err := client.NewMetricsClientFromAddress(address, metrics, "user")
+			client, err := client.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return err
 			}
@@ -678,7 +1081,7 @@ $ pachctl glob-file foo master "data/*"
 		Short: "Return the contents of an object",
 		Long:  "Return the contents of an object",
 		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
-			client, err := client.NewMetricsClientFromAddress(address, metrics, "user")
+			client, err := client.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return err
 			}
@@ -691,7 +1094,7 @@ $ pachctl glob-file foo master "data/*"
 		Short: "Return the contents of a tag",
 		Long:  "Return the contents of a tag",
 		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
-			client, err := client.NewMetricsClientFromAddress(address, metrics, "user")
+			client, err := client.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return err
 			}
@@ -701,24 +1104,56 @@ $ pachctl glob-file foo master "data/*"
 
 	var debug bool
 	var allCommits bool
+	var write bool
 	mount := &cobra.Command{
-		Use:   "mount path/to/mount/point",
+		Use:   "mount path/to/mount/point [repo[/commit-or-branch][:alias] ...]",
 		Short: "Mount pfs locally. This command blocks.",
-		Long:  "Mount pfs locally. This command blocks.",
-		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
-			client, err := client.NewMetricsClientFromAddress(address, metrics, "fuse")
+		Long: `Mount pfs locally. This command blocks.
+
+Each repo is exposed as a directory containing a directory for each of its
+branches and commits. If repos are specified only those repos are mounted,
+a repo can be pinned to a single branch or commit, in which case its directory
+contains the files in that commit, and given an alias to mount it under a
+different name.
+
+The mount is read only unless --write is passed, in which case files in open
+commits can be written, and are put to pfs when they're closed.
+
+Examples:
+
+` + codestart + `# mount all repos at /pfs
+$ pachctl mount /pfs
+
+# mount only the master branch of repo "foo", at /pfs/foo
+$ pachctl mount /pfs foo/master
+
+# mount commit XXX of repo "foo" at /pfs/prev and branch master at /pfs/foo
+$ pachctl mount /pfs foo/XXX:prev foo/master
+
+# mount with write-back, so that files can be written to open commits
+$ pachctl mount /pfs --write
+` + codeend,
+		Run: cmdutil.Run(func(args []string) error {
+			if len(args) < 1 {
+				return fmt.Errorf("missing mount point")
+			}
+			client, err := client.NewOnUserMachine(metrics, "fuse")
 			if err != nil {
 				return err
 			}
 			go func() { client.KeepConnected(nil) }()
-			mounter := fuse.NewMounter(address, client)
+			mounter := fuse.NewMounter(client.Addr(), client)
 			mountPoint := args[0]
+			var commitMounts []*fuse.CommitMount
+			if len(args) > 1 {
+				commitMounts = parseCommitMounts(args[1:])
+			}
 			ready := make(chan bool)
 			go func() {
 				<-ready
 				fmt.Println("Filesystem mounted, CTRL-C to exit.")
 			}()
-			err = mounter.Mount(mountPoint, nil, ready, debug, false)
+			err = mounter.Mount(mountPoint, commitMounts, ready, debug, false, write)
 			if err != nil {
 				return err
 			}
@@ -727,6 +1162,7 @@ $ pachctl glob-file foo master "data/*"
 	}
 	mount.Flags().BoolVarP(&debug, "debug", "d", false, "Turn on debug messages.")
 	mount.Flags().BoolVarP(&allCommits, "all-commits", "a", false, "Show archived and cancelled commits.")
+	mount.Flags().BoolVarP(&write, "write", "w", false, "Allow writing to open commits through the mount.")
 
 	var all bool
 	unmount := &cobra.Command{
@@ -781,11 +1217,83 @@ $ pachctl glob-file foo master "data/*"
 	}
 	unmount.Flags().BoolVarP(&all, "all", "a", false, "unmount all pfs mounts")
 
+	var sidecarPort uint16
+	var mountRoot string
+	var workDir string
+	var authTokenFile string
+	notebookSidecar := &cobra.Command{
+		Use:   "notebook-sidecar",
+		Short: "Serve the API with which notebooks mount repos and push commits.",
+		Long: `Serve the API with which notebooks mount repos and push commits. This command blocks.
+
+The sidecar runs alongside a notebook (e.g. in the pod of a JupyterHub user's
+server), and serves a JSON API on localhost, with which the notebook's kernels
+mount repos read only under --mount-root, and push directories under
+--work-dir as commits, whose provenance is the mounted commits:
+
+    GET    /mounts         list the mounts
+    POST   /mounts         mount a repo: {"repo": ..., "ref": ..., "name": ...}
+    DELETE /mounts/<name>  unmount a repo
+    POST   /commits        push a directory: {"repo": ..., "branch": ...,
+                           "path": ..., "dest": ...}
+
+Examples:
+
+` + codestart + `# serve the API on localhost:8081, mounting repos under /pfs
+$ pachctl notebook-sidecar --mount-root /pfs --work-dir /home/jovyan
+
+# mount the master branch of repo "images" at /pfs/images
+$ curl -X POST localhost:8081/mounts -d '{"repo": "images"}'
+
+# push /home/jovyan/results as a commit on the master branch of repo "results"
+$ curl -X POST localhost:8081/commits -d '{"repo": "results", "path": "results"}'
+` + codeend,
+		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
+			var options []client.Option
+			if authTokenFile != "" {
+				token, err := ioutil.ReadFile(authTokenFile)
+				if err != nil {
+					return err
+				}
+				options = append(options, client.WithAuthToken(strings.TrimSpace(string(token))))
+			}
+			c, err := client.NewOnUserMachine(metrics, "notebook", options...)
+			if err != nil {
+				return err
+			}
+			go func() { c.KeepConnected(nil) }()
+			sidecar := notebook.NewSidecar(c, fuse.NewMounter(c.Addr(), c), mountRoot, workDir)
+			server := &http.Server{
+				Addr:    fmt.Sprintf("localhost:%d", sidecarPort),
+				Handler: sidecar,
+			}
+			sigChan := make(chan os.Signal, 1)
+			signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+			go func() {
+				<-sigChan
+				if err := sidecar.Close(); err != nil {
+					fmt.Fprintf(os.Stderr, "error unmounting: %v\n", err)
+				}
+				server.Close()
+			}()
+			fmt.Printf("Serving the notebook sidecar on %s, CTRL-C to exit.\n", server.Addr)
+			if err := server.ListenAndServe(); err != http.ErrServerClosed {
+				return err
+			}
+			return nil
+		}),
+	}
+	notebookSidecar.Flags().Uint16VarP(&sidecarPort, "port", "p", 8081, "The port on localhost on which the API is served.")
+	notebookSidecar.Flags().StringVar(&mountRoot, "mount-root", "/pfs", "The directory under which repos are mounted, which should be a volume that's shared with the notebook.")
+	notebookSidecar.Flags().StringVar(&workDir, "work-dir", ".", "The directory that pushed paths are relative to, which should be a volume that's shared with the notebook.")
+	notebookSidecar.Flags().StringVar(&authTokenFile, "auth-token-file", "", "A file containing the auth token with which the sidecar talks to pachd (e.g. a mounted secret), rather than the token in the user's config.")
+
 	var result []*cobra.Command
 	result = append(result, repo)
 	result = append(result, createRepo)
 	result = append(result, inspectRepo)
 	result = append(result, listRepo)
+	result = append(result, updateRepo)
 	result = append(result, deleteRepo)
 	result = append(result, commit)
 	result = append(result, startCommit)
@@ -793,12 +1301,16 @@ $ pachctl glob-file foo master "data/*"
 	result = append(result, inspectCommit)
 	result = append(result, listCommit)
 	result = append(result, flushCommit)
+	result = append(result, deleteCommit)
 	result = append(result, listBranch)
 	result = append(result, setBranch)
+	result = append(result, setBranchTrigger)
+	result = append(result, setBranchRetention)
 	result = append(result, deleteBranch)
 	result = append(result, file)
 	result = append(result, putFile)
 	result = append(result, getFile)
+	result = append(result, getFileURL)
 	result = append(result, inspectFile)
 	result = append(result, listFile)
 	result = append(result, globFile)
@@ -807,28 +1319,47 @@ $ pachctl glob-file foo master "data/*"
 	result = append(result, getTag)
 	result = append(result, mount)
 	result = append(result, unmount)
+	result = append(result, notebookSidecar)
 	return result
 }
 
+// parseCommitMounts parses args of the form repo[/commit-or-branch][:alias]
+// parseLabels parses the --label flags of create-repo and update-repo, each of
+// which is written key=value.
+func parseLabels(flags []string) (map[string]string, error) {
+	if len(flags) == 0 {
+		return nil, nil
+	}
+	labels := make(map[string]string)
+	for _, flag := range flags {
+		parts := strings.SplitN(flag, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("malformed label %q, must be written key=value", flag)
+		}
+		labels[parts[0]] = parts[1]
+	}
+	return labels, nil
+}
+
 func parseCommitMounts(args []string) []*fuse.CommitMount {
 	var result []*fuse.CommitMount
 	for _, arg := range args {
 		commitMount := &fuse.CommitMount{Commit: client.NewCommit("", "")}
-		repo, commitAlias := path.Split(arg)
-		commitMount.Commit.Repo.Name = path.Clean(repo)
-		split := strings.Split(commitAlias, ":")
-		if len(split) > 0 {
-			commitMount.Commit.ID = split[0]
+		if i := strings.LastIndex(arg, ":"); i >= 0 {
+			commitMount.Alias = arg[i+1:]
+			arg = arg[:i]
 		}
+		split := strings.SplitN(arg, "/", 2)
+		commitMount.Commit.Repo.Name = split[0]
 		if len(split) > 1 {
-			commitMount.Alias = split[1]
+			commitMount.Commit.ID = split[1]
 		}
 		result = append(result, commitMount)
 	}
 	return result
 }
 
-func putFileHelper(client *client.APIClient, repo, commit, path, source string, recursive bool, limiter limit.ConcurrencyLimiter, split string, targetFileDatums uint, targetFileBytes uint) (retErr error) {
+func putFileHelper(client *client.APIClient, repo, commit, path, source string, recursive bool, preserveMetadata bool, limiter limit.ConcurrencyLimiter, split string, targetFileDatums uint, targetFileBytes uint, j *journal, bar *progressBar) (retErr error) {
 	putFile := func(reader io.Reader) error {
 		if split == "" {
 			_, err := client.PutFile(repo, commit, path, reader)
@@ -856,9 +1387,15 @@ func putFileHelper(client *client.APIClient, repo, commit, path, source string,
 	}
 	// try parsing the filename as a url, if it is one do a PutFileURL
 	if url, err := url.Parse(source); err == nil && url.Scheme != "" {
+		if j.Contains(repo, commit, path) {
+			return nil
+		}
 		limiter.Acquire()
 		defer limiter.Release()
-		return client.PutFileURL(repo, commit, path, url.String(), recursive)
+		if err := client.PutFileURL(repo, commit, path, url.String(), recursive); err != nil {
+			return err
+		}
+		return j.Add(repo, commit, path)
 	}
 	if recursive {
 		var eg errgroup.Group
@@ -870,14 +1407,38 @@ func putFileHelper(client *client.APIClient, repo, commit, path, source string,
 			if info.IsDir() {
 				return nil
 			}
+			relPath := strings.TrimPrefix(filePath, source)
+			if preserveMetadata && isMetadataOnly(relPath, info) {
+				return nil
+			}
 			eg.Go(func() error {
-				return putFileHelper(client, repo, commit, filepath.Join(path, strings.TrimPrefix(filePath, source)), filePath, false, limiter, split, targetFileDatums, targetFileBytes)
+				return putFileHelper(client, repo, commit, filepath.Join(path, relPath), filePath, false, false, limiter, split, targetFileDatums, targetFileBytes, j, bar)
 			})
 			return nil
 		}); err != nil {
 			return err
 		}
-		return eg.Wait()
+		if err := eg.Wait(); err != nil {
+			return err
+		}
+		if preserveMetadata {
+			metadata, err := localMetadata(source)
+			if err != nil {
+				return err
+			}
+			return client.PutFileMetadata(repo, commit, filepath.ToSlash(path), metadata)
+		}
+		return nil
+	}
+	if j.Contains(repo, commit, path) {
+		if bar != nil {
+			// the file was uploaded previously, but it still counts towards
+			// the total
+			if info, err := os.Stat(source); err == nil {
+				bar.progress(info.Size(), 1)
+			}
+		}
+		return nil
 	}
 	limiter.Acquire()
 	defer limiter.Release()
@@ -890,7 +1451,78 @@ func putFileHelper(client *client.APIClient, repo, commit, path, source string,
 			retErr = err
 		}
 	}()
-	return putFile(f)
+	if err := putFile(f); err != nil {
+		return err
+	}
+	return j.Add(repo, commit, path)
+}
+
+// isMetadataOnly returns true if the local file info, at relPath in a
+// directory being put with --preserve-metadata, is only recorded in the
+// directory's metadata, rather than put: symlinks are, and so is the
+// metadata file of a previous download.
+func isMetadataOnly(relPath string, info os.FileInfo) bool {
+	relPath = strings.TrimPrefix(relPath, string(filepath.Separator))
+	return info.Mode()&os.ModeSymlink != 0 || relPath == client.MetadataFile
+}
+
+// localMetadata returns the metadata of the files in the local directory
+// source, for --preserve-metadata.
+func localMetadata(source string) ([]*client.FileMetadata, error) {
+	var result []*client.FileMetadata
+	if err := filepath.Walk(source, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		relPath, err := filepath.Rel(source, filePath)
+		if err != nil {
+			return err
+		}
+		if relPath == client.MetadataFile {
+			return nil
+		}
+		metadata, err := client.NewFileMetadata(relPath, filePath, info)
+		if err != nil {
+			return err
+		}
+		result = append(result, metadata)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// localSize returns the total size and number of the local files in sources,
+// sources that aren't local files, or which can't be read, are ignored.
+func localSize(sources []string, recursive bool) (int64, int64) {
+	var bytes, files int64
+	for _, source := range sources {
+		if source == "-" {
+			continue
+		}
+		if url, err := url.Parse(source); err == nil && url.Scheme != "" {
+			continue
+		}
+		filepath.Walk(source, func(filePath string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() {
+				if !recursive && filePath == source {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			bytes += info.Size()
+			files++
+			return nil
+		})
+	}
+	return bytes, files
 }
 
 func joinPaths(prefix, filePath string) string {
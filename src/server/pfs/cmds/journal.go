@@ -0,0 +1,78 @@
+package cmds
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"sync"
+)
+
+// journal records the files that put-file has uploaded successfully, so that
+// if an upload fails it can be resumed by re-running put-file with the same
+// journal, which skips the files that were already uploaded.
+type journal struct {
+	mu    sync.Mutex
+	f     *os.File
+	files map[string]bool
+}
+
+// openJournal opens the journal at filePath, creating it if it doesn't exist.
+func openJournal(filePath string) (*journal, error) {
+	f, err := os.OpenFile(filePath, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	j := &journal{
+		f:     f,
+		files: make(map[string]bool),
+	}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		j.files[scanner.Text()] = true
+	}
+	if err := scanner.Err(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("error reading journal %s: %v", filePath, err)
+	}
+	return j, nil
+}
+
+func journalKey(repo, commit, filePath string) string {
+	return path.Join(repo, commit, path.Clean("/"+filePath))
+}
+
+// Contains returns true if the file has already been uploaded. Contains may
+// be called on a nil journal, in which case it always returns false.
+func (j *journal) Contains(repo, commit, filePath string) bool {
+	if j == nil {
+		return false
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.files[journalKey(repo, commit, filePath)]
+}
+
+// Add records that the file has been uploaded. Add may be called on a nil
+// journal, in which case it does nothing.
+func (j *journal) Add(repo, commit, filePath string) error {
+	if j == nil {
+		return nil
+	}
+	key := journalKey(repo, commit, filePath)
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if _, err := fmt.Fprintln(j.f, key); err != nil {
+		return err
+	}
+	j.files[key] = true
+	return nil
+}
+
+// Close closes the journal's file.
+func (j *journal) Close() error {
+	if j == nil {
+		return nil
+	}
+	return j.f.Close()
+}
@@ -0,0 +1,95 @@
+package cmds
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/go-units"
+)
+
+const progressInterval = 100 * time.Millisecond
+
+// progressBar prints a single, continuously updated, line describing the
+// progress of a transfer. Its progress method is a client.ProgressFunc.
+type progressBar struct {
+	w          io.Writer
+	totalBytes int64
+	totalFiles int64
+	start      time.Time
+
+	mu    sync.Mutex
+	bytes int64
+	files int64
+	width int
+
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// newProgressBar creates a progressBar which prints to w until Stop is
+// called. totalBytes and totalFiles may be 0 if they aren't known.
+func newProgressBar(w io.Writer, totalBytes int64, totalFiles int64) *progressBar {
+	p := &progressBar{
+		w:          w,
+		totalBytes: totalBytes,
+		totalFiles: totalFiles,
+		start:      time.Now(),
+		done:       make(chan struct{}),
+		stopped:    make(chan struct{}),
+	}
+	go func() {
+		defer close(p.stopped)
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.print()
+			case <-p.done:
+				p.print()
+				fmt.Fprintln(p.w)
+				return
+			}
+		}
+	}()
+	return p
+}
+
+func (p *progressBar) progress(bytes int64, files int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.bytes += bytes
+	p.files += files
+}
+
+func (p *progressBar) print() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	files := fmt.Sprint(p.files)
+	if p.totalFiles > 0 {
+		files += fmt.Sprintf("/%d", p.totalFiles)
+	}
+	bytes := units.BytesSize(float64(p.bytes))
+	if p.totalBytes > 0 {
+		bytes += "/" + units.BytesSize(float64(p.totalBytes))
+	}
+	line := fmt.Sprintf("%s files, %s", files, bytes)
+	if elapsed := time.Since(p.start).Seconds(); elapsed > 0 {
+		line += fmt.Sprintf(" (%s/s)", units.BytesSize(float64(p.bytes)/elapsed))
+	}
+	// pad the line so that it overwrites the whole of the previous one
+	if pad := p.width - len(line); pad > 0 {
+		line += strings.Repeat(" ", pad)
+	}
+	p.width = len(line)
+	fmt.Fprintf(p.w, "\r%s", line)
+}
+
+// Stop prints the final state of the transfer and stops the progressBar.
+func (p *progressBar) Stop() {
+	close(p.done)
+	<-p.stopped
+}
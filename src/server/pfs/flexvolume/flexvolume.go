@@ -0,0 +1,356 @@
+// Package flexvolume implements a kubernetes FlexVolume driver that mounts
+// PFS commits, read only, into any pod, so that workloads that aren't
+// pipelines (model servers, ad-hoc analysis pods) can read versioned data
+// straight out of PFS. Mounts are the same FUSE filesystem as 'pachctl
+// mount', so files are only read from pachd as they're read from the mount.
+//
+// A pod mounts a commit with a volume such as:
+//
+//	flexVolume:
+//	  driver: pachyderm.io/pfs
+//	  readOnly: true
+//	  options:
+//	    repo: images
+//	    commit: master
+//
+// kubelet runs the driver on the pod's node, which needs FUSE (and
+// fusermount). The driver is installed on each node by Install, which
+// pachctl deploy's --flexvolume runs in a DaemonSet.
+package flexvolume
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/server/pfs/fuse"
+)
+
+const (
+	// DriverName is the name that pods' flexVolumes name the driver by.
+	DriverName = "pachyderm.io/pfs"
+	// driverDir is the directory, in kubelet's plugin directory, that the
+	// driver is installed in. kubelet runs the executable in it that's
+	// named after the last part of DriverName.
+	driverDir = "pachyderm.io~pfs"
+	driverExe = "pfs"
+	// configFile is the file, next to the driver, that Install writes the
+	// driver's Config to.
+	configFile = "pfs.json"
+	// logFile is the file, next to the driver, that mounts log to.
+	logFile = "pfs.log"
+	// tokenSecretKey is the option, set by kubelet from the item "token" of
+	// a volume's secretRef, that holds the auth token that the volume is
+	// mounted with.
+	tokenSecretKey = "kubernetes.io/secret/token"
+	// tokenEnv passes the auth token from Mount to Serve, so that it doesn't
+	// show up in the mount's command line.
+	tokenEnv = "PACH_FLEXVOLUME_TOKEN"
+)
+
+// Result is what the driver writes to stdout, as JSON, in reply to each of
+// kubelet's calls.
+type Result struct {
+	// Status is "Success", "Failure" or "Not supported".
+	Status       string          `json:"status"`
+	Message      string          `json:"message,omitempty"`
+	Capabilities map[string]bool `json:"capabilities,omitempty"`
+}
+
+// Config is what Install tells the driver about the cluster it's part of.
+type Config struct {
+	// Address is pachd's address, which volumes are mounted from unless
+	// they set the option "pachd".
+	Address string `json:"pachd"`
+}
+
+// Options are a volume's options, as kubelet passes them to Mount.
+type Options struct {
+	// Repo is the repo that's mounted.
+	Repo string
+	// Commit is the branch or commit of Repo that's mounted, master if it's
+	// empty. A branch is resolved to its head when the volume is mounted, so
+	// the pod sees the same commit for as long as it runs.
+	Commit string
+	// Address is pachd's address, if the volume sets it.
+	Address string
+	// Token is the auth token that the volume is mounted with, from the
+	// volume's secretRef, if it has one.
+	Token string
+}
+
+// Success is the Result of a call that succeeded.
+func Success() *Result {
+	return &Result{Status: "Success"}
+}
+
+// Failure is the Result of a call that failed with err.
+func Failure(err error) *Result {
+	return &Result{Status: "Failure", Message: err.Error()}
+}
+
+// NotSupported is the Result of the calls that the driver doesn't implement,
+// which kubelet then does itself or skips.
+func NotSupported() *Result {
+	return &Result{Status: "Not supported"}
+}
+
+// Init is the Result of kubelet's init call. Volumes don't need attaching,
+// so kubelet only calls mount and unmount.
+func Init() *Result {
+	return &Result{Status: "Success", Capabilities: map[string]bool{"attach": false}}
+}
+
+// ParseOptions parses the options that kubelet passes to mount, a JSON
+// object of strings.
+func ParseOptions(data string) (*Options, error) {
+	raw := make(map[string]string)
+	if err := json.Unmarshal([]byte(data), &raw); err != nil {
+		return nil, fmt.Errorf("invalid options: %v", err)
+	}
+	options := &Options{
+		Repo:    raw["repo"],
+		Commit:  raw["commit"],
+		Address: raw["pachd"],
+	}
+	if options.Repo == "" {
+		return nil, fmt.Errorf("the volume's options must set repo")
+	}
+	if options.Commit == "" {
+		options.Commit = "master"
+	}
+	if token, ok := raw[tokenSecretKey]; ok {
+		decoded, err := base64.StdEncoding.DecodeString(token)
+		if err != nil {
+			return nil, fmt.Errorf("invalid token in the volume's secret: %v", err)
+		}
+		options.Token = strings.TrimSpace(string(decoded))
+	}
+	return options, nil
+}
+
+// Mount mounts the commit that options names at dir. The mount is served by
+// a copy of the driver, run in the background, since kubelet waits for the
+// driver to exit; it exits once the mount is unmounted. driverPath is the
+// driver's executable.
+func Mount(driverPath string, dir string, options string) error {
+	o, err := ParseOptions(options)
+	if err != nil {
+		return err
+	}
+	if o.Address == "" {
+		config, err := readConfig(filepath.Dir(driverPath))
+		if err != nil {
+			return err
+		}
+		o.Address = config.Address
+	}
+	c, err := newClient(o.Address, o.Token)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	commitInfo, err := c.InspectCommit(o.Repo, o.Commit)
+	if err != nil {
+		return err
+	}
+	if commitInfo.Finished == nil {
+		return fmt.Errorf("commit %s of %s hasn't been finished", commitInfo.Commit.ID, o.Repo)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	log, err := os.OpenFile(filepath.Join(filepath.Dir(driverPath), logFile), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer log.Close()
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer readyR.Close()
+	cmd := exec.Command(driverPath, "serve", dir, o.Repo, commitInfo.Commit.ID, o.Address)
+	cmd.Env = append(os.Environ(), tokenEnv+"="+o.Token)
+	// the mount mustn't hold on to the driver's stdout, which kubelet reads
+	// until it's closed
+	cmd.Stdout = log
+	cmd.Stderr = log
+	cmd.ExtraFiles = []*os.File{readyW}
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		readyW.Close()
+		return err
+	}
+	readyW.Close()
+	if err := cmd.Process.Release(); err != nil {
+		return err
+	}
+	// Serve writes "ok" once the commit is mounted, or why it couldn't be
+	ready, err := ioutil.ReadAll(readyR)
+	if err != nil {
+		return err
+	}
+	switch msg := strings.TrimSpace(string(ready)); msg {
+	case "ok":
+		return nil
+	case "":
+		return fmt.Errorf("the mount of %s@%s exited before it was mounted, see %s", o.Repo, commitInfo.Commit.ID, log.Name())
+	default:
+		return fmt.Errorf("%s", msg)
+	}
+}
+
+// Serve mounts commit of repo, read only, at dir, and serves the mount until
+// it's unmounted. It writes "ok" to ready, and closes it, once the commit is
+// mounted, or writes the error if it can't be. It's what Mount runs in the
+// background.
+func Serve(dir string, repo string, commit string, address string, ready io.WriteCloser) error {
+	c, err := newClient(address, os.Getenv(tokenEnv))
+	if err != nil {
+		return mountFailed(ready, err)
+	}
+	defer c.Close()
+	mounter := fuse.NewMounter(address, c)
+	mounted := make(chan bool)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- mounter.Mount(dir, []*fuse.CommitMount{{
+			Commit: client.NewCommit(repo, commit),
+		}}, mounted, false, true, false)
+	}()
+	<-mounted
+	// mounted is also closed if the mount fails, in which case the error
+	// follows straight away
+	select {
+	case err := <-errCh:
+		if err == nil {
+			err = fmt.Errorf("%s was unmounted as soon as it was mounted", dir)
+		}
+		return mountFailed(ready, err)
+	default:
+	}
+	fmt.Fprintf(ready, "ok")
+	ready.Close()
+	fmt.Fprintf(os.Stderr, "mounted %s@%s at %s\n", repo, commit, dir)
+	err = <-errCh
+	fmt.Fprintf(os.Stderr, "unmounted %s@%s from %s: %v\n", repo, commit, dir, err)
+	return err
+}
+
+// mountFailed tells Mount, through ready, why the mount failed.
+func mountFailed(ready io.WriteCloser, err error) error {
+	fmt.Fprintf(ready, "%v", err)
+	ready.Close()
+	return err
+}
+
+// Unmount unmounts the volume mounted at dir, which makes the mount that
+// serves it exit. A dir that isn't mounted, e.g. because its mount crashed,
+// is left as it is.
+func Unmount(dir string) error {
+	mounted, err := isMountPoint(dir)
+	if os.IsNotExist(err) || err == nil && !mounted {
+		return nil
+	}
+	// a mount whose server has exited can't be stat'd, but it can still be
+	// unmounted
+	return fuse.NewMounter("", nil).Unmount(dir)
+}
+
+// Install installs the driver, whose executable is driverPath, in kubelet's
+// plugin directory pluginDir, configured with config. The driver is copied
+// next to where it's installed and renamed into place, so that kubelet never
+// runs a partial copy.
+func Install(driverPath string, pluginDir string, config *Config) error {
+	dir := filepath.Join(pluginDir, driverDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(dir, configFile), 0644, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	}); err != nil {
+		return err
+	}
+	src, err := os.Open(driverPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	return writeFileAtomic(filepath.Join(dir, driverExe), 0755, func(w io.Writer) error {
+		_, err := io.Copy(w, src)
+		return err
+	})
+}
+
+func writeFileAtomic(path string, mode os.FileMode, write func(io.Writer) error) (retErr error) {
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if retErr != nil {
+			os.Remove(f.Name())
+		}
+	}()
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(mode); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+func readConfig(dir string) (*Config, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, configFile))
+	if err != nil {
+		return nil, fmt.Errorf("could not read the driver's config, which 'pachctl deploy --flexvolume' installs: %v", err)
+	}
+	config := &Config{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("invalid driver config: %v", err)
+	}
+	return config, nil
+}
+
+func newClient(address string, token string) (*client.APIClient, error) {
+	var options []client.Option
+	if token != "" {
+		options = append(options, client.WithAuthToken(token))
+	}
+	return client.NewFromAddress(address, options...)
+}
+
+// isMountPoint returns whether dir is a mount point, i.e. whether it's on a
+// different device from its parent.
+func isMountPoint(dir string) (bool, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return false, err
+	}
+	parentInfo, err := os.Stat(filepath.Dir(filepath.Clean(dir)))
+	if err != nil {
+		return false, err
+	}
+	return info.Sys().(*syscall.Stat_t).Dev != parentInfo.Sys().(*syscall.Stat_t).Dev, nil
+}
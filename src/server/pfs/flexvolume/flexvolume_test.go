@@ -0,0 +1,78 @@
+package flexvolume
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+)
+
+func TestParseOptions(t *testing.T) {
+	options, err := ParseOptions(`{"repo": "images", "kubernetes.io/readwrite": "ro", "kubernetes.io/pod.name": "server"}`)
+	require.NoError(t, err)
+	require.Equal(t, &Options{Repo: "images", Commit: "master"}, options)
+
+	token := base64.StdEncoding.EncodeToString([]byte("secret-token\n"))
+	options, err = ParseOptions(`{"repo": "images", "commit": "abc123", "pachd": "10.0.0.1:650", "kubernetes.io/secret/token": "` + token + `"}`)
+	require.NoError(t, err)
+	require.Equal(t, &Options{Repo: "images", Commit: "abc123", Address: "10.0.0.1:650", Token: "secret-token"}, options)
+
+	_, err = ParseOptions(`{"commit": "master"}`)
+	require.YesError(t, err)
+	_, err = ParseOptions(`{"repo": "images", "kubernetes.io/secret/token": "not base64!"}`)
+	require.YesError(t, err)
+	_, err = ParseOptions(`not json`)
+	require.YesError(t, err)
+}
+
+func TestResults(t *testing.T) {
+	data, err := json.Marshal(Init())
+	require.NoError(t, err)
+	require.Equal(t, `{"status":"Success","capabilities":{"attach":false}}`, string(data))
+	data, err = json.Marshal(NotSupported())
+	require.NoError(t, err)
+	require.Equal(t, `{"status":"Not supported"}`, string(data))
+}
+
+func TestInstall(t *testing.T) {
+	dir, err := ioutil.TempDir("", "flexvolume")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	driverPath := filepath.Join(dir, "pfs-flexvolume")
+	require.NoError(t, ioutil.WriteFile(driverPath, []byte("driver"), 0755))
+
+	pluginDir := filepath.Join(dir, "plugins")
+	require.NoError(t, Install(driverPath, pluginDir, &Config{Address: "10.0.0.1:650"}))
+	// installing again, e.g. when the installer's pod restarts, replaces
+	// the driver
+	require.NoError(t, Install(driverPath, pluginDir, &Config{Address: "10.0.0.2:650"}))
+
+	installed := filepath.Join(pluginDir, "pachyderm.io~pfs", "pfs")
+	data, err := ioutil.ReadFile(installed)
+	require.NoError(t, err)
+	require.Equal(t, "driver", string(data))
+	info, err := os.Stat(installed)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0755), info.Mode().Perm())
+	config, err := readConfig(filepath.Dir(installed))
+	require.NoError(t, err)
+	require.Equal(t, "10.0.0.2:650", config.Address)
+	files, err := ioutil.ReadDir(filepath.Dir(installed))
+	require.NoError(t, err)
+	require.Equal(t, 2, len(files))
+}
+
+func TestUnmountNotMounted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "flexvolume")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	mounted, err := isMountPoint(dir)
+	require.NoError(t, err)
+	require.False(t, mounted)
+	require.NoError(t, Unmount(dir))
+	require.NoError(t, Unmount(filepath.Join(dir, "missing")))
+}
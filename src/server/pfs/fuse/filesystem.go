@@ -2,6 +2,7 @@ package fuse
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -21,13 +22,14 @@ import (
 	log "github.com/Sirupsen/logrus"
 	"github.com/gogo/protobuf/types"
 	"golang.org/x/net/context"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
 )
 
 type filesystem struct {
 	apiClient *client.APIClient
 	Filesystem
+	// write is true if files in open commits may be written, otherwise the
+	// whole filesystem is read only
+	write  bool
 	inodes map[string]uint64
 	lock   sync.RWMutex
 }
@@ -35,12 +37,14 @@ type filesystem struct {
 func newFilesystem(
 	apiClient *client.APIClient,
 	commitMounts []*CommitMount,
+	write bool,
 ) *filesystem {
 	return &filesystem{
 		apiClient: apiClient,
 		Filesystem: Filesystem{
 			commitMounts,
 		},
+		write:  write,
 		inodes: make(map[string]uint64),
 	}
 }
@@ -54,14 +58,9 @@ type repoFilesystem struct {
 func newRepoFilesystem(
 	apiClient *client.APIClient,
 	commitMount *CommitMount,
+	write bool,
 ) *repoFilesystem {
-	return &repoFilesystem{&filesystem{
-		apiClient: apiClient,
-		Filesystem: Filesystem{
-			[]*CommitMount{commitMount},
-		},
-		inodes: make(map[string]uint64),
-	}}
+	return &repoFilesystem{newFilesystem(apiClient, []*CommitMount{commitMount}, write)}
 }
 
 func (f *repoFilesystem) Root() (result fs.Node, retErr error) {
@@ -72,14 +71,24 @@ func (f *repoFilesystem) Root() (result fs.Node, retErr error) {
 			log.Error(&Root{&f.Filesystem, getNode(result), errorToString(retErr)})
 		}
 	}()
-	return &directory{
+	commit := f.filesystem.CommitMounts[0].Commit
+	root := &directory{
 		f.filesystem,
 		Node{
 			File: &pfsclient.File{
-				Commit: f.filesystem.CommitMounts[0].Commit,
+				Commit: commit,
 			},
 		},
-	}, nil
+	}
+	if commit.ID != "" {
+		commitInfo, err := f.apiClient.InspectCommit(commit.Repo.Name, commit.ID)
+		if err != nil {
+			return nil, err
+		}
+		root.Write = f.canWrite(commitInfo)
+		root.Modified = commitInfo.Finished
+	}
+	return root, nil
 }
 
 func (f *filesystem) Root() (result fs.Node, retErr error) {
@@ -178,7 +187,7 @@ func (d *directory) Create(ctx context.Context, request *fuse.CreateRequest, res
 			log.Error(&DirectoryCreate{&d.Node, getNode(result), errorToString(retErr)})
 		}
 	}()
-	if d.File.Commit.ID == "" {
+	if d.File.Commit.ID == "" || !d.Write {
 		return nil, 0, fuse.EPERM
 	}
 	directory := d.copy()
@@ -207,7 +216,7 @@ func (d *directory) Mkdir(ctx context.Context, request *fuse.MkdirRequest) (resu
 			log.Error(&DirectoryMkdir{&d.Node, getNode(result), errorToString(retErr)})
 		}
 	}()
-	if d.File.Commit.ID == "" {
+	if d.File.Commit.ID == "" || !d.Write {
 		return nil, fuse.EPERM
 	}
 	// Server has no concept of empty directories.
@@ -224,6 +233,9 @@ func (d *directory) Remove(ctx context.Context, req *fuse.RemoveRequest) (retErr
 			log.Error(&FileRemove{&d.Node, req.Name, req.Dir, errorToString(retErr)})
 		}
 	}()
+	if !d.Write {
+		return fuse.EPERM
+	}
 	return d.fs.apiClient.DeleteFile(d.Node.File.Commit.Repo.Name,
 		d.Node.File.Commit.ID, filepath.Join(d.Node.File.Path, req.Name))
 }
@@ -254,7 +266,11 @@ func (f *file) Attr(ctx context.Context, a *fuse.Attr) (retErr error) {
 	if fileInfo != nil {
 		a.Size = fileInfo.SizeBytes
 	}
-	a.Mode = 0666
+	if f.Write {
+		a.Mode = 0666
+	} else {
+		a.Mode = 0444
+	}
 	a.Inode = f.fs.inode(f.File)
 	return nil
 }
@@ -268,6 +284,9 @@ func (f *file) Setattr(ctx context.Context, req *fuse.SetattrRequest, resp *fuse
 		}
 	}()
 	if req.Size == 0 && (req.Valid&fuse.SetattrSize) > 0 {
+		if !f.Write {
+			return fuse.EPERM
+		}
 		err := f.fs.apiClient.DeleteFile(f.Node.File.Commit.Repo.Name,
 			f.Node.File.Commit.ID, f.Node.File.Path)
 		if err != nil {
@@ -345,6 +364,12 @@ func (f *file) touch() error {
 	return nil
 }
 
+// canWrite returns true if files in the commit described by commitInfo may be
+// written through the filesystem.
+func (f *filesystem) canWrite(commitInfo *pfsclient.CommitInfo) bool {
+	return f.write && commitInfo.Finished == nil
+}
+
 func (f *filesystem) inode(file *pfsclient.File) uint64 {
 	f.lock.RLock()
 	inode, ok := f.inodes[key(file)]
@@ -400,7 +425,7 @@ func (h *handle) Read(ctx context.Context, request *fuse.ReadRequest, response *
 		int64(request.Size),
 		&buffer,
 	); err != nil {
-		if grpc.Code(err) == codes.NotFound {
+		if errors.Is(err, client.ErrFileNotFound) {
 			// ENOENT from read(2) is weird, let's call this EINVAL
 			// instead.
 			return fuse.Errno(syscall.EINVAL)
@@ -552,11 +577,7 @@ func (d *directory) lookUpRepo(ctx context.Context, name string) (fs.Node, error
 	if err != nil {
 		return nil, err
 	}
-	if commitInfo.Finished != nil {
-		result.Write = false
-	} else {
-		result.Write = true
-	}
+	result.Write = d.fs.canWrite(commitInfo)
 	result.Modified = commitInfo.Finished
 
 	return result, nil
@@ -576,11 +597,7 @@ func (d *directory) lookUpCommit(ctx context.Context, name string) (fs.Node, err
 	}
 	result := d.copy()
 	result.File.Commit.ID = commitID
-	if commitInfo.Finished != nil {
-		result.Write = false
-	} else {
-		result.Write = true
-	}
+	result.Write = d.fs.canWrite(commitInfo)
 	result.Modified = commitInfo.Finished
 	return result, nil
 }
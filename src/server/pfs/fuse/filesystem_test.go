@@ -899,7 +899,7 @@ func testFuse(
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		require.NoError(t, mounter.MountAndCreate(mountpoint, nil, nil, ready, false, allCommits, false))
+		require.NoError(t, mounter.MountAndCreate(mountpoint, nil, ready, false, false, true))
 	}()
 
 	<-ready
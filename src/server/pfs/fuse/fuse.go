@@ -13,6 +13,9 @@ type Mounter interface {
 		debug bool,
 		// if oneMount is true, mount only one CommitMount
 		oneMount bool,
+		// if write is false the filesystem is read only, otherwise files in
+		// open commits can be written
+		write bool,
 	) error
 
 	Mount(
@@ -21,6 +24,7 @@ type Mounter interface {
 		ready chan bool,
 		debug bool,
 		oneMount bool,
+		write bool,
 	) error
 	// Unmount unmounts a mounted filesystem (duh).
 	// There's nothing special about this unmount, it's just doing a syscall under the hood.
@@ -35,11 +35,12 @@ func (m *mounter) MountAndCreate(
 	ready chan bool,
 	debug bool,
 	oneMount bool,
+	write bool,
 ) error {
 	if err := os.MkdirAll(mountPoint, 0777); err != nil {
 		return err
 	}
-	return m.Mount(mountPoint, commitMounts, ready, debug, oneMount)
+	return m.Mount(mountPoint, commitMounts, ready, debug, oneMount, write)
 }
 
 func (m *mounter) Mount(
@@ -48,6 +49,7 @@ func (m *mounter) Mount(
 	ready chan bool,
 	debug bool,
 	oneMount bool,
+	write bool,
 ) (retErr error) {
 	var once sync.Once
 	defer once.Do(func() {
@@ -56,15 +58,18 @@ func (m *mounter) Mount(
 		}
 	})
 	name := namePrefix + m.address
-	conn, err := fuse.Mount(
-		mountPoint,
+	options := []fuse.MountOption{
 		fuse.FSName(name),
 		fuse.VolumeName(name),
 		fuse.Subtype(subtype),
 		fuse.AllowOther(),
 		fuse.WritebackCache(),
-		fuse.MaxReadahead(1<<32-1),
-	)
+		fuse.MaxReadahead(1<<32 - 1),
+	}
+	if !write {
+		options = append(options, fuse.ReadOnly())
+	}
+	conn, err := fuse.Mount(mountPoint, options...)
 	if err != nil {
 		return err
 	}
@@ -95,9 +100,9 @@ func (m *mounter) Mount(
 		if len(commitMounts) != 1 {
 			return fmt.Errorf("expect 1 CommitMount, got %d", len(commitMounts))
 		}
-		filesystem = newRepoFilesystem(m.apiClient, commitMounts[0])
+		filesystem = newRepoFilesystem(m.apiClient, commitMounts[0], write)
 	} else {
-		filesystem = newFilesystem(m.apiClient, commitMounts)
+		filesystem = newFilesystem(m.apiClient, commitMounts, write)
 	}
 	if err := fs.New(conn, config).Serve(filesystem); err != nil {
 		return err
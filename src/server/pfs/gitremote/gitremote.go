@@ -0,0 +1,407 @@
+// Package gitremote implements git-remote-pfs, a git remote helper (see
+// gitremote-helpers(7)) that lets git fetch the branches of a PFS repo, so
+// that a repo's history can be browsed with git's tools, e.g.
+//
+//	$ git clone pfs::images
+//	$ git log --stat origin/master
+//
+// Each finished PFS commit on a branch is imported as a git commit, whose
+// parent is the PFS commit's parent. The helper is read-only; git can't push
+// to PFS.
+package gitremote
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+)
+
+// Remote is where a PFS repo is fetched from, parsed from a git remote's URL.
+type Remote struct {
+	// Address is the address of pachd, or "" if the repo is fetched from
+	// the cluster of pachctl's active context.
+	Address string
+	Repo    string
+}
+
+// ParseURL parses the URL of a pfs remote, which is either
+// pfs://<pachd address>/<repo>, or pfs::<repo>, in which case git passes the
+// helper just <repo>, and the repo is fetched from the cluster of pachctl's
+// active context.
+func ParseURL(rawURL string) (*Remote, error) {
+	if !strings.Contains(rawURL, "://") {
+		if rawURL == "" || strings.Contains(rawURL, "/") {
+			return nil, fmt.Errorf("invalid pfs remote %q, remotes are pfs::<repo> or pfs://<address>/<repo>", rawURL)
+		}
+		return &Remote{Repo: rawURL}, nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	repo := strings.Trim(u.Path, "/")
+	if u.Scheme != "pfs" || u.Host == "" || repo == "" || strings.Contains(repo, "/") {
+		return nil, fmt.Errorf("invalid pfs remote %q, remotes are pfs::<repo> or pfs://<address>/<repo>", rawURL)
+	}
+	return &Remote{Address: u.Host, Repo: repo}, nil
+}
+
+// Helper speaks git's remote helper protocol for a PFS repo.
+type Helper struct {
+	c    *client.APIClient
+	repo string
+	// stateDir is where the helper keeps fast-import's marks, and which
+	// PFS commit each mark is, so that fetches only import new commits
+	stateDir string
+	// refPrefix is the prefix of the private refs that branches are
+	// imported to, git maps them to the remote's tracking refs
+	refPrefix string
+
+	// marks maps the ID of each PFS commit that's been imported to its mark
+	marks    map[string]int
+	lastMark int
+}
+
+// NewHelper returns a Helper that fetches repo, using c, for the remote
+// called alias of the git repo at gitDir.
+func NewHelper(c *client.APIClient, repo string, alias string, gitDir string) *Helper {
+	alias = sanitizeAlias(alias)
+	return &Helper{
+		c:         c,
+		repo:      repo,
+		stateDir:  filepath.Join(gitDir, "pfs", alias),
+		refPrefix: fmt.Sprintf("refs/pfs/%s/heads/", alias),
+	}
+}
+
+var unsafeAliasChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// sanitizeAlias makes alias safe to use in refs and paths. git passes the
+// remote's URL as its alias when fetching from a URL rather than a named
+// remote.
+func sanitizeAlias(alias string) string {
+	return unsafeAliasChars.ReplaceAllString(alias, "_")
+}
+
+// Run reads git's commands from in and writes the responses to out, until
+// git closes in or sends a blank line.
+func (h *Helper) Run(in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	w := bufio.NewWriter(out)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			return nil
+		case line == "capabilities":
+			fmt.Fprintf(w, "import\nrefspec refs/heads/*:%s*\n\n", h.refPrefix)
+		case line == "list":
+			if err := h.list(w); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "import "):
+			// imports come in batches, terminated by a blank line
+			branches := []string{strings.TrimPrefix(strings.TrimPrefix(line, "import "), "refs/heads/")}
+			for scanner.Scan() && scanner.Text() != "" {
+				branches = append(branches, strings.TrimPrefix(strings.TrimPrefix(scanner.Text(), "import "), "refs/heads/"))
+			}
+			if err := h.Import(w, branches); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported command %q", line)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// list lists the repo's branches, with master as HEAD.
+func (h *Helper) list(w io.Writer) error {
+	branches, err := h.c.ListBranch(h.repo)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, branch := range branches {
+		names = append(names, branch.Name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		// the refs' values are unknown to git until they're imported
+		fmt.Fprintf(w, "? refs/heads/%s\n", name)
+		if name == "master" {
+			fmt.Fprintf(w, "@refs/heads/master HEAD\n")
+		}
+	}
+	fmt.Fprintf(w, "\n")
+	return nil
+}
+
+// Import writes a fast-import stream to w, which imports the commits of
+// branches that haven't been imported already.
+func (h *Helper) Import(w io.Writer, branches []string) error {
+	if err := os.MkdirAll(h.stateDir, 0755); err != nil {
+		return err
+	}
+	if err := h.loadMarks(); err != nil {
+		return err
+	}
+	commitsFile, err := os.OpenFile(filepath.Join(h.stateDir, "commits"), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer commitsFile.Close()
+	marksPath := filepath.Join(h.stateDir, "marks")
+	fmt.Fprintf(w, "feature done\n")
+	fmt.Fprintf(w, "feature import-marks-if-exists=%s\n", marksPath)
+	fmt.Fprintf(w, "feature export-marks=%s\n", marksPath)
+	for _, branch := range branches {
+		if err := h.importBranch(w, commitsFile, branch); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(w, "done\n")
+	return nil
+}
+
+// loadMarks reads which PFS commits have been imported. A commit has only
+// been imported if fast-import exported its mark, so commits whose marks
+// aren't in fast-import's marks file (because the fetch that imported them
+// failed) are imported again.
+func (h *Helper) loadMarks() error {
+	h.marks = make(map[string]int)
+	h.lastMark = 0
+	exported := make(map[int]bool)
+	if err := readLines(filepath.Join(h.stateDir, "marks"), func(fields []string) error {
+		mark, err := strconv.Atoi(strings.TrimPrefix(fields[0], ":"))
+		if err != nil {
+			return fmt.Errorf("invalid mark %q: %v", fields[0], err)
+		}
+		exported[mark] = true
+		return nil
+	}); err != nil {
+		return err
+	}
+	return readLines(filepath.Join(h.stateDir, "commits"), func(fields []string) error {
+		if len(fields) != 2 {
+			return fmt.Errorf("invalid commit mark %q", strings.Join(fields, " "))
+		}
+		mark, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return fmt.Errorf("invalid mark %q: %v", fields[0], err)
+		}
+		// marks aren't reused, even if they weren't exported
+		if mark > h.lastMark {
+			h.lastMark = mark
+		}
+		if exported[mark] {
+			h.marks[fields[1]] = mark
+		}
+		return nil
+	})
+}
+
+// readLines calls f with the fields of each line of the file at filename, if
+// it exists.
+func readLines(filename string, f func(fields []string) error) error {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if err := f(fields); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importBranch imports the finished commits of branch that haven't been
+// imported, oldest first.
+func (h *Helper) importBranch(w io.Writer, commitsFile io.Writer, branch string) error {
+	ref := h.refPrefix + branch
+	// newCommits are newest first, parent is the newest commit on the
+	// branch that's already been imported
+	var newCommits []*pfs.CommitInfo
+	var parent *pfs.Commit
+	iter, err := h.c.ListCommitIter(h.repo, branch, "", 0)
+	if err != nil {
+		return err
+	}
+	for {
+		commitInfo, err := iter.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			iter.Close()
+			return err
+		}
+		if _, ok := h.marks[commitInfo.Commit.ID]; ok {
+			parent = commitInfo.Commit
+			break
+		}
+		// the branch's head may be open, it's imported once it's finished
+		if commitInfo.Finished == nil {
+			continue
+		}
+		newCommits = append(newCommits, commitInfo)
+	}
+	iter.Close()
+
+	if len(newCommits) == 0 {
+		if parent != nil {
+			fmt.Fprintf(w, "reset %s\nfrom :%d\n\n", ref, h.marks[parent.ID])
+		}
+		return nil
+	}
+	files := make(map[string]string)
+	if parent != nil {
+		if files, err = h.files(parent.ID); err != nil {
+			return err
+		}
+	}
+	for i := len(newCommits) - 1; i >= 0; i-- {
+		commitInfo := newCommits[i]
+		newFiles, err := h.files(commitInfo.Commit.ID)
+		if err != nil {
+			return err
+		}
+		h.lastMark++
+		mark := h.lastMark
+		if err := h.writeCommit(w, ref, mark, parent, commitInfo, files, newFiles); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(commitsFile, "%d %s\n", mark, commitInfo.Commit.ID); err != nil {
+			return err
+		}
+		h.marks[commitInfo.Commit.ID] = mark
+		parent = commitInfo.Commit
+		files = newFiles
+	}
+	return nil
+}
+
+// files returns the hash of each file in a commit, by its path (relative to
+// the root of the repo, as git writes it).
+func (h *Helper) files(commitID string) (map[string]string, error) {
+	files := make(map[string]string)
+	var walk func(dir string) error
+	walk = func(dir string) error {
+		fileInfos, err := h.c.ListFile(h.repo, commitID, dir)
+		if err != nil {
+			return err
+		}
+		for _, fileInfo := range fileInfos {
+			if fileInfo.FileType == pfs.FileType_DIR {
+				if err := walk(fileInfo.File.Path); err != nil {
+					return err
+				}
+				continue
+			}
+			files[strings.TrimPrefix(path.Clean(fileInfo.File.Path), "/")] = fmt.Sprintf("%x", fileInfo.Hash)
+		}
+		return nil
+	}
+	if err := walk(""); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// writeCommit writes a fast-import commit command, which changes the files of
+// parent (oldFiles) to those of commitInfo (newFiles). Only the files whose
+// hashes have changed are read from pachd.
+func (h *Helper) writeCommit(w io.Writer, ref string, mark int, parent *pfs.Commit, commitInfo *pfs.CommitInfo, oldFiles map[string]string, newFiles map[string]string) error {
+	fmt.Fprintf(w, "commit %s\nmark :%d\n", ref, mark)
+	// the commits are a function of the PFS commits, so that the same PFS
+	// commit is always imported as the same git commit
+	committer := commitInfo.Author
+	if committer == "" {
+		committer = "pachyderm"
+	}
+	fmt.Fprintf(w, "committer %s <> %d +0000\n", committer, commitInfo.Finished.Seconds)
+	writeData(w, []byte(commitMessage(h.repo, commitInfo)))
+	if parent != nil {
+		fmt.Fprintf(w, "from :%d\n", h.marks[parent.ID])
+	}
+	var paths []string
+	for filePath := range newFiles {
+		paths = append(paths, filePath)
+	}
+	sort.Strings(paths)
+	for _, filePath := range paths {
+		if hash, ok := oldFiles[filePath]; ok && hash == newFiles[filePath] {
+			continue
+		}
+		var buf bytes.Buffer
+		if err := h.c.GetFile(h.repo, commitInfo.Commit.ID, filePath, 0, 0, &buf); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "M 644 inline %s\n", quotePath(filePath))
+		writeData(w, buf.Bytes())
+	}
+	paths = paths[:0]
+	for filePath := range oldFiles {
+		if _, ok := newFiles[filePath]; !ok {
+			paths = append(paths, filePath)
+		}
+	}
+	sort.Strings(paths)
+	for _, filePath := range paths {
+		fmt.Fprintf(w, "D %s\n", quotePath(filePath))
+	}
+	_, err := fmt.Fprintf(w, "\n")
+	return err
+}
+
+// commitMessage is the message of the git commit that a PFS commit is
+// imported as, which names the PFS commit and its provenance.
+func commitMessage(repo string, commitInfo *pfs.CommitInfo) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s@%s\n\nPFS-Commit: %s@%s\n", repo, commitInfo.Commit.ID, repo, commitInfo.Commit.ID)
+	for _, commit := range commitInfo.Provenance {
+		fmt.Fprintf(&buf, "PFS-Provenance: %s@%s\n", commit.Repo.Name, commit.ID)
+	}
+	return buf.String()
+}
+
+func writeData(w io.Writer, data []byte) {
+	fmt.Fprintf(w, "data %d\n", len(data))
+	w.Write(data)
+	fmt.Fprintf(w, "\n")
+}
+
+var pathQuoter = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// quotePath C-style quotes the paths that fast-import can't read unquoted,
+// i.e. those that start with a quote or contain a newline.
+func quotePath(filePath string) string {
+	if strings.HasPrefix(filePath, `"`) || strings.Contains(filePath, "\n") {
+		return `"` + pathQuoter.Replace(filePath) + `"`
+	}
+	return filePath
+}
@@ -0,0 +1,168 @@
+package gitremote
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	"github.com/pachyderm/pachyderm/src/client/pkg/uuid"
+)
+
+func TestParseURL(t *testing.T) {
+	for rawURL, expected := range map[string]*Remote{
+		"images":                           {Repo: "images"},
+		"pfs://localhost:30650/images":     {Address: "localhost:30650", Repo: "images"},
+		"pfs://localhost:30650/images/":    {Address: "localhost:30650", Repo: "images"},
+		"pfs://localhost:30650/":           nil,
+		"pfs://localhost:30650/images/sub": nil,
+		"http://localhost:30650/images":    nil,
+		"images/sub":                       nil,
+		"":                                 nil,
+	} {
+		remote, err := ParseURL(rawURL)
+		if expected == nil {
+			require.YesError(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		require.Equal(t, expected, remote)
+	}
+}
+
+func TestSanitizeAlias(t *testing.T) {
+	require.Equal(t, "origin", sanitizeAlias("origin"))
+	require.Equal(t, "pfs___localhost_30650_images", sanitizeAlias("pfs://localhost:30650/images"))
+}
+
+func TestQuotePath(t *testing.T) {
+	require.Equal(t, "dir/a file", quotePath("dir/a file"))
+	require.Equal(t, `"\"quoted\""`, quotePath(`"quoted"`))
+	require.Equal(t, `"a\nb\\c"`, quotePath("a\nb\\c"))
+}
+
+func TestLoadMarks(t *testing.T) {
+	gitDir, err := ioutil.TempDir("", "gitremote")
+	require.NoError(t, err)
+	defer os.RemoveAll(gitDir)
+	h := NewHelper(nil, "repo", "origin", gitDir)
+	require.NoError(t, os.MkdirAll(h.stateDir, 0755))
+
+	// no fetches yet
+	require.NoError(t, h.loadMarks())
+	require.Equal(t, 0, len(h.marks))
+	require.Equal(t, 0, h.lastMark)
+
+	// commit c's mark wasn't exported, so it's imported again, with a new
+	// mark
+	require.NoError(t, ioutil.WriteFile(filepath.Join(h.stateDir, "commits"), []byte("1 a\n2 b\n3 c\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(h.stateDir, "marks"), []byte(":1 0123\n:2 4567\n"), 0644))
+	require.NoError(t, h.loadMarks())
+	require.Equal(t, map[string]int{"a": 1, "b": 2}, h.marks)
+	require.Equal(t, 3, h.lastMark)
+}
+
+func TestCapabilities(t *testing.T) {
+	h := NewHelper(nil, "repo", "origin", "")
+	var out bytes.Buffer
+	require.NoError(t, h.Run(strings.NewReader("capabilities\n\n"), &out))
+	require.Equal(t, "import\nrefspec refs/heads/*:refs/pfs/origin/heads/*\n\n", out.String())
+	require.YesError(t, h.Run(strings.NewReader("push refs/heads/master:refs/heads/master\n\n"), &out))
+}
+
+func TestCommitMessage(t *testing.T) {
+	require.Equal(t, "out@abc\n\nPFS-Commit: out@abc\nPFS-Provenance: in@def\n", commitMessage("out", &pfs.CommitInfo{
+		Commit:     client.NewCommit("out", "abc"),
+		Provenance: []*pfs.Commit{client.NewCommit("in", "def")},
+	}))
+}
+
+func getPachClient(t *testing.T) *client.APIClient {
+	address := "0.0.0.0:30650"
+	if addr := os.Getenv("PACHD_PORT_650_TCP_ADDR"); addr != "" {
+		address = net.JoinHostPort(addr, "650")
+	}
+	c, err := client.NewFromAddress(address)
+	require.NoError(t, err)
+	return c
+}
+
+// fetch imports the master branch of h's repo into the git repo at gitDir,
+// as git does when it fetches.
+func fetch(t *testing.T, h *Helper, gitDir string) {
+	cmd := exec.Command("git", "fast-import", "--quiet", "--allow-unsafe-features")
+	cmd.Env = append(os.Environ(), "GIT_DIR="+gitDir)
+	stdin, err := cmd.StdinPipe()
+	require.NoError(t, err)
+	require.NoError(t, cmd.Start())
+	require.NoError(t, h.Import(stdin, []string{"master"}))
+	require.NoError(t, stdin.Close())
+	require.NoError(t, cmd.Wait())
+}
+
+func git(t *testing.T, gitDir string, args ...string) string {
+	cmd := exec.Command("git", args...)
+	cmd.Env = append(os.Environ(), "GIT_DIR="+gitDir)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	return strings.TrimSpace(string(out))
+}
+
+func TestFetch(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("Skipping git tests, as git isn't installed")
+	}
+	c := getPachClient(t)
+	repo := "gitremote" + uuid.NewWithoutDashes()[0:12]
+	require.NoError(t, c.CreateRepo(repo))
+	put := func(path string, content string) {
+		_, err := c.PutFile(repo, "master", path, strings.NewReader(content))
+		require.NoError(t, err)
+	}
+	put("a", "a")
+	put("dir/b", "b")
+
+	gitDir, err := ioutil.TempDir("", "gitremote")
+	require.NoError(t, err)
+	defer os.RemoveAll(gitDir)
+	git(t, gitDir, "init", "--bare", "--quiet", gitDir)
+	h := NewHelper(c, repo, "origin", gitDir)
+	ref := "refs/pfs/origin/heads/master"
+
+	fetch(t, h, gitDir)
+	require.Equal(t, "2", git(t, gitDir, "rev-list", "--count", ref))
+	require.Equal(t, "b", git(t, gitDir, "show", ref+":dir/b"))
+	head := git(t, gitDir, "rev-parse", ref)
+
+	// fetching again imports nothing new, and the same PFS commits are
+	// always the same git commits
+	fetch(t, h, gitDir)
+	require.Equal(t, head, git(t, gitDir, "rev-parse", ref))
+
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = c.PutFile(repo, commit.ID, "a", strings.NewReader("a"))
+	require.NoError(t, err)
+	require.NoError(t, c.DeleteFile(repo, commit.ID, "dir/b"))
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+	fetch(t, h, gitDir)
+	require.Equal(t, "3", git(t, gitDir, "rev-list", "--count", ref))
+	require.Equal(t, head, git(t, gitDir, "rev-parse", ref+"^"))
+	require.Equal(t, "aa", git(t, gitDir, "show", ref+":a"))
+	require.Equal(t, "a", git(t, gitDir, "ls-tree", "--name-only", ref))
+	require.True(t, strings.Contains(git(t, gitDir, "log", "-1", "--format=%B", ref), "PFS-Commit: "+repo+"@"+commit.ID))
+
+	var out bytes.Buffer
+	require.NoError(t, h.Run(strings.NewReader("list\n\n"), &out))
+	require.Equal(t, "? refs/heads/master\n@refs/heads/master HEAD\n\n", out.String())
+}
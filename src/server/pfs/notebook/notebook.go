@@ -0,0 +1,390 @@
+// Package notebook implements a sidecar that gives notebooks (e.g. the
+// single-user servers that JupyterHub spawns) access to PFS. The sidecar
+// shares a pod with the notebook, and serves a small JSON API on localhost
+// with which the notebook's kernels mount repos, read only, under the mount
+// root (a volume shared with the notebook), and push directories of results
+// as commits. The commits that kernels push have the mounted commits as their
+// provenance, so results computed in notebooks can be traced back to the data
+// they were computed from, as a pipeline's output can.
+//
+// The API is:
+//
+//	GET    /mounts        lists the mounts, as Mounts
+//	POST   /mounts        mounts a repo, given a MountRequest, returns a Mount
+//	DELETE /mounts/<name> unmounts the mount called name
+//	POST   /commits       pushes a directory, given a PushRequest, returns a
+//	                      PushResponse
+//
+// Errors are returned as {"error": "<message>"}.
+package notebook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/server/pfs/fuse"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// MountRequest asks the sidecar to mount a repo.
+type MountRequest struct {
+	// Repo is the repo that's mounted.
+	Repo string `json:"repo"`
+	// Ref is the branch or commit of Repo that's mounted, master if it's
+	// empty. A branch is resolved to its head when it's mounted, so the
+	// mount doesn't change under the notebook as the branch moves; mount
+	// the branch again to see new commits.
+	Ref string `json:"ref"`
+	// Name is the name of the directory under the mount root at which Repo
+	// is mounted, Repo if it's empty.
+	Name string `json:"name"`
+}
+
+// Mount describes a mounted repo.
+type Mount struct {
+	Name string `json:"name"`
+	// Path is where the repo is mounted, in the sidecar's filesystem, which
+	// is also its path in the notebook's if they mount the shared volume at
+	// the same path.
+	Path   string `json:"path"`
+	Repo   string `json:"repo"`
+	Commit string `json:"commit"`
+}
+
+// PushRequest asks the sidecar to push a directory as a commit.
+type PushRequest struct {
+	// Repo is the repo that the commit is made in. It's created if it
+	// doesn't exist.
+	Repo string `json:"repo"`
+	// Branch is the branch that the commit is made on, master if it's
+	// empty.
+	Branch string `json:"branch"`
+	// Path is the directory that's pushed, relative to the sidecar's work
+	// directory.
+	Path string `json:"path"`
+	// Dest is the path in the commit at which the directory's contents are
+	// written, the root if it's empty. Files that are already in the commit
+	// are overwritten by the pushed files at the same paths, other files
+	// are left as they are.
+	Dest string `json:"dest"`
+}
+
+// PushResponse describes the commit that a push made.
+type PushResponse struct {
+	Repo   string `json:"repo"`
+	Commit string `json:"commit"`
+	// Provenance is the mounts whose commits were made the commit's
+	// provenance, which is all of the sidecar's mounts at the time of the
+	// push.
+	Provenance []*Mount `json:"provenance"`
+}
+
+// Sidecar serves the sidecar's API.
+type Sidecar struct {
+	c         *client.APIClient
+	mounter   fuse.Mounter
+	mountRoot string
+	workDir   string
+
+	// mounts are the sidecar's mounts, by name
+	mounts   map[string]*mount
+	mountsMu sync.Mutex
+}
+
+type mount struct {
+	Mount
+	commit *pfs.Commit
+	// done is closed once the mount's filesystem has been unmounted
+	done chan struct{}
+}
+
+// NewSidecar returns a Sidecar that talks to pachd with c, mounts repos with
+// mounter under mountRoot, and pushes directories under workDir.
+func NewSidecar(c *client.APIClient, mounter fuse.Mounter, mountRoot string, workDir string) *Sidecar {
+	return &Sidecar{
+		c:         c,
+		mounter:   mounter,
+		mountRoot: mountRoot,
+		workDir:   workDir,
+		mounts:    make(map[string]*mount),
+	}
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Sidecar) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/mounts" && r.Method == "GET":
+		writeJSON(w, http.StatusOK, s.listMounts())
+	case r.URL.Path == "/mounts" && r.Method == "POST":
+		request := &MountRequest{}
+		if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+			writeError(w, r, badRequest(err.Error()))
+			return
+		}
+		m, err := s.Mount(request)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, m)
+	case strings.HasPrefix(r.URL.Path, "/mounts/") && r.Method == "DELETE":
+		if err := s.Unmount(strings.TrimPrefix(r.URL.Path, "/mounts/")); err != nil {
+			writeError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case r.URL.Path == "/commits" && r.Method == "POST":
+		request := &PushRequest{}
+		if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+			writeError(w, r, badRequest(err.Error()))
+			return
+		}
+		response, err := s.Push(request)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, response)
+	default:
+		writeError(w, r, &statusError{http.StatusNotFound, fmt.Sprintf("%s %s isn't part of the sidecar's API", r.Method, r.URL.Path)})
+	}
+}
+
+// Mount mounts the repo that request asks for.
+func (s *Sidecar) Mount(request *MountRequest) (*Mount, error) {
+	if request.Repo == "" {
+		return nil, badRequest("repo must be set")
+	}
+	ref := request.Ref
+	if ref == "" {
+		ref = "master"
+	}
+	name := request.Name
+	if name == "" {
+		name = request.Repo
+	}
+	if name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return nil, badRequest(fmt.Sprintf("invalid mount name %q", name))
+	}
+	s.mountsMu.Lock()
+	defer s.mountsMu.Unlock()
+	if _, ok := s.mounts[name]; ok {
+		return nil, &statusError{http.StatusConflict, fmt.Sprintf("there's already a mount called %s", name)}
+	}
+	commitInfo, err := s.c.InspectCommit(request.Repo, ref)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, &statusError{http.StatusNotFound, err.Error()}
+		}
+		return nil, err
+	}
+	if commitInfo.Finished == nil {
+		return nil, &statusError{http.StatusConflict, fmt.Sprintf("commit %s of %s hasn't been finished", commitInfo.Commit.ID, request.Repo)}
+	}
+
+	m := &mount{
+		Mount: Mount{
+			Name:   name,
+			Path:   filepath.Join(s.mountRoot, name),
+			Repo:   request.Repo,
+			Commit: commitInfo.Commit.ID,
+		},
+		commit: commitInfo.Commit,
+		done:   make(chan struct{}),
+	}
+	ready := make(chan bool)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.mounter.MountAndCreate(m.Path, []*fuse.CommitMount{{
+			Commit: commitInfo.Commit,
+			Alias:  name,
+		}}, ready, false, true, false)
+	}()
+	<-ready
+	// ready is also closed if the mount fails, in which case the error
+	// follows straight away
+	select {
+	case err := <-errCh:
+		if err == nil {
+			err = fmt.Errorf("%s was unmounted as soon as it was mounted", m.Path)
+		}
+		return nil, err
+	default:
+	}
+	s.mounts[name] = m
+	go func() {
+		if err := <-errCh; err != nil {
+			log.Errorf("notebook sidecar: mount of %s at %s exited: %v", m.Repo, m.Path, err)
+		}
+		s.mountsMu.Lock()
+		if s.mounts[name] == m {
+			delete(s.mounts, name)
+		}
+		s.mountsMu.Unlock()
+		close(m.done)
+	}()
+	result := m.Mount
+	return &result, nil
+}
+
+// Unmount unmounts the mount called name.
+func (s *Sidecar) Unmount(name string) error {
+	s.mountsMu.Lock()
+	m, ok := s.mounts[name]
+	s.mountsMu.Unlock()
+	if !ok {
+		return &statusError{http.StatusNotFound, fmt.Sprintf("there's no mount called %s", name)}
+	}
+	if err := s.mounter.Unmount(m.Path); err != nil {
+		return err
+	}
+	<-m.done
+	return nil
+}
+
+// Close unmounts all of the sidecar's mounts.
+func (s *Sidecar) Close() error {
+	var retErr error
+	for _, m := range s.listMounts() {
+		if err := s.Unmount(m.Name); err != nil && retErr == nil {
+			retErr = err
+		}
+	}
+	return retErr
+}
+
+// Push pushes the directory that request asks for as a new commit, with the
+// sidecar's mounted commits as its provenance.
+func (s *Sidecar) Push(request *PushRequest) (*PushResponse, error) {
+	if request.Repo == "" {
+		return nil, badRequest("repo must be set")
+	}
+	branch := request.Branch
+	if branch == "" {
+		branch = "master"
+	}
+	localDir, err := s.localPath(request.Path)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(localDir); err != nil {
+		return nil, badRequest(err.Error())
+	} else if !info.IsDir() {
+		return nil, badRequest(fmt.Sprintf("%s isn't a directory", request.Path))
+	}
+
+	// the mounts are read before the repo is written to, so a repo that's
+	// mounted and pushed to isn't its own commit's provenance
+	var provenance []*pfs.Commit
+	response := &PushResponse{Repo: request.Repo}
+	s.mountsMu.Lock()
+	for _, m := range s.sortedMounts() {
+		provenance = append(provenance, m.commit)
+		result := m.Mount
+		response.Provenance = append(response.Provenance, &result)
+	}
+	s.mountsMu.Unlock()
+
+	if _, err := s.c.InspectRepo(request.Repo); err != nil {
+		if !strings.Contains(err.Error(), "not found") {
+			return nil, err
+		}
+		if err := s.c.CreateRepo(request.Repo); err != nil {
+			return nil, err
+		}
+	}
+	commit, err := s.c.StartCommitProvenance(request.Repo, branch, provenance)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.c.PutFiles(request.Repo, commit.ID, localDir, &client.PutFilesOptions{
+		Path:      request.Dest,
+		Overwrite: true,
+	}); err != nil {
+		s.c.DeleteCommit(request.Repo, commit.ID)
+		return nil, err
+	}
+	if err := s.c.FinishCommit(request.Repo, commit.ID); err != nil {
+		return nil, err
+	}
+	response.Commit = commit.ID
+	return response, nil
+}
+
+// localPath returns the path in the sidecar's filesystem of path, which is
+// relative to the work directory, and must not be outside of it.
+func (s *Sidecar) localPath(path string) (string, error) {
+	if path == "" {
+		return "", badRequest("path must be set")
+	}
+	localPath := filepath.Join(s.workDir, path)
+	rel, err := filepath.Rel(s.workDir, localPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", badRequest(fmt.Sprintf("%s is outside of the work directory", path))
+	}
+	return localPath, nil
+}
+
+func (s *Sidecar) listMounts() []*Mount {
+	s.mountsMu.Lock()
+	defer s.mountsMu.Unlock()
+	result := []*Mount{}
+	for _, m := range s.sortedMounts() {
+		mount := m.Mount
+		result = append(result, &mount)
+	}
+	return result
+}
+
+// sortedMounts returns the sidecar's mounts sorted by name. s.mountsMu must
+// be held.
+func (s *Sidecar) sortedMounts() []*mount {
+	var result []*mount
+	for _, m := range s.mounts {
+		result = append(result, m)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// statusError is an error that's returned with a status other than 500.
+type statusError struct {
+	status  int
+	message string
+}
+
+func (e *statusError) Error() string {
+	return e.message
+}
+
+func badRequest(message string) error {
+	return &statusError{http.StatusBadRequest, message}
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	status := http.StatusInternalServerError
+	if err, ok := err.(*statusError); ok {
+		status = err.status
+	}
+	if status >= 500 {
+		log.Errorf("notebook sidecar: %s %s: %v", r.Method, r.URL.Path, err)
+	}
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Errorf("notebook sidecar: could not write response: %v", err)
+	}
+}
@@ -0,0 +1,167 @@
+package notebook
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	"github.com/pachyderm/pachyderm/src/client/pkg/uuid"
+	"github.com/pachyderm/pachyderm/src/server/pfs/fuse"
+)
+
+// fakeMounter is a fuse.Mounter whose mounts don't mount anything, so that
+// the sidecar can be tested without fuse.
+type fakeMounter struct {
+	mounts map[string]chan struct{}
+	mu     sync.Mutex
+}
+
+func newFakeMounter() *fakeMounter {
+	return &fakeMounter{mounts: make(map[string]chan struct{})}
+}
+
+func (f *fakeMounter) MountAndCreate(mountPoint string, commitMounts []*fuse.CommitMount, ready chan bool, debug bool, oneMount bool, write bool) error {
+	return f.Mount(mountPoint, commitMounts, ready, debug, oneMount, write)
+}
+
+func (f *fakeMounter) Mount(mountPoint string, commitMounts []*fuse.CommitMount, ready chan bool, debug bool, oneMount bool, write bool) error {
+	unmounted := make(chan struct{})
+	f.mu.Lock()
+	f.mounts[mountPoint] = unmounted
+	f.mu.Unlock()
+	close(ready)
+	<-unmounted
+	return nil
+}
+
+func (f *fakeMounter) Unmount(mountPoint string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	close(f.mounts[mountPoint])
+	delete(f.mounts, mountPoint)
+	return nil
+}
+
+func TestLocalPath(t *testing.T) {
+	s := NewSidecar(nil, newFakeMounter(), "/pfs", "/home/jovyan")
+	for path, expected := range map[string]string{
+		"results":          "/home/jovyan/results",
+		"results/":         "/home/jovyan/results",
+		"/results":         "/home/jovyan/results",
+		".":                "/home/jovyan",
+		"a/../results":     "/home/jovyan/results",
+		"..results":        "/home/jovyan/..results",
+		"results/../../..": "",
+		"..":               "",
+		"../other":         "",
+		"":                 "",
+	} {
+		localPath, err := s.localPath(path)
+		if expected == "" {
+			require.YesError(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		require.Equal(t, expected, localPath)
+	}
+}
+
+func TestBadRequests(t *testing.T) {
+	server := httptest.NewServer(NewSidecar(nil, newFakeMounter(), "/pfs", "/home/jovyan"))
+	defer server.Close()
+	for _, test := range []struct {
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{"POST", "/mounts", `{}`, http.StatusBadRequest},
+		{"POST", "/mounts", `{"repo": "foo", "name": "../foo"}`, http.StatusBadRequest},
+		{"POST", "/mounts", `not json`, http.StatusBadRequest},
+		{"DELETE", "/mounts/foo", ``, http.StatusNotFound},
+		{"POST", "/commits", `{"repo": "foo"}`, http.StatusBadRequest},
+		{"POST", "/commits", `{"repo": "foo", "path": "../foo"}`, http.StatusBadRequest},
+		{"PUT", "/commits", `{}`, http.StatusNotFound},
+	} {
+		req, err := http.NewRequest(test.method, server.URL+test.path, strings.NewReader(test.body))
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		resp.Body.Close()
+		require.Equal(t, test.status, resp.StatusCode)
+		require.NotEqual(t, "", body["error"])
+	}
+
+	resp, err := http.Get(server.URL + "/mounts")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, "[]\n", string(data))
+}
+
+func getPachClient(t *testing.T) *client.APIClient {
+	address := "0.0.0.0:30650"
+	if addr := os.Getenv("PACHD_PORT_650_TCP_ADDR"); addr != "" {
+		address = net.JoinHostPort(addr, "650")
+	}
+	c, err := client.NewFromAddress(address)
+	require.NoError(t, err)
+	return c
+}
+
+func TestMountAndPush(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	c := getPachClient(t)
+	dataRepo := "notebook" + uuid.NewWithoutDashes()[0:12]
+	require.NoError(t, c.CreateRepo(dataRepo))
+	_, err := c.PutFile(dataRepo, "master", "data", strings.NewReader("data"))
+	require.NoError(t, err)
+	dataCommitInfo, err := c.InspectCommit(dataRepo, "master")
+	require.NoError(t, err)
+
+	workDir, err := ioutil.TempDir("", "notebook")
+	require.NoError(t, err)
+	defer os.RemoveAll(workDir)
+	require.NoError(t, os.MkdirAll(filepath.Join(workDir, "results", "dir"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(workDir, "results", "dir", "result"), []byte("result"), 0644))
+
+	s := NewSidecar(c, newFakeMounter(), "/pfs", workDir)
+	m, err := s.Mount(&MountRequest{Repo: dataRepo, Name: "data"})
+	require.NoError(t, err)
+	require.Equal(t, "/pfs/data", m.Path)
+	require.Equal(t, dataCommitInfo.Commit.ID, m.Commit)
+	_, err = s.Mount(&MountRequest{Repo: dataRepo, Name: "data"})
+	require.YesError(t, err)
+	require.Equal(t, 1, len(s.listMounts()))
+
+	resultsRepo := "notebook" + uuid.NewWithoutDashes()[0:12]
+	response, err := s.Push(&PushRequest{Repo: resultsRepo, Path: "results", Dest: "out"})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(response.Provenance))
+	var buffer bytes.Buffer
+	require.NoError(t, c.GetFile(resultsRepo, "master", "out/dir/result", 0, 0, &buffer))
+	require.Equal(t, "result", buffer.String())
+	commitInfo, err := c.InspectCommit(resultsRepo, response.Commit)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(commitInfo.Provenance))
+	require.Equal(t, dataCommitInfo.Commit.ID, commitInfo.Provenance[0].ID)
+
+	require.NoError(t, s.Unmount("data"))
+	require.Equal(t, 0, len(s.listMounts()))
+	require.NoError(t, s.Close())
+}
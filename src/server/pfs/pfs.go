@@ -3,6 +3,7 @@ package pfs
 import (
 	"fmt"
 
+	"github.com/pachyderm/pachyderm/src/client"
 	"github.com/pachyderm/pachyderm/src/client/pfs"
 )
 
@@ -69,6 +70,37 @@ func (e ErrParentCommitNotFound) Error() string {
 	return fmt.Sprintf("parent commit %v not found in repo %v", e.Commit.ID, e.Commit.Repo.Name)
 }
 
+// Unwrap returns client.ErrFileNotFound, so that clients can test for e with
+// errors.Is.
+func (e ErrFileNotFound) Unwrap() error {
+	return client.ErrFileNotFound
+}
+
+// Unwrap returns client.ErrRepoNotFound.
+func (e ErrRepoNotFound) Unwrap() error {
+	return client.ErrRepoNotFound
+}
+
+// Unwrap returns client.ErrRepoExists.
+func (e ErrRepoExists) Unwrap() error {
+	return client.ErrRepoExists
+}
+
+// Unwrap returns client.ErrCommitNotFound.
+func (e ErrCommitNotFound) Unwrap() error {
+	return client.ErrCommitNotFound
+}
+
+// Unwrap returns client.ErrCommitFinished.
+func (e ErrCommitFinished) Unwrap() error {
+	return client.ErrCommitFinished
+}
+
+// Unwrap returns client.ErrCommitNotFound.
+func (e ErrParentCommitNotFound) Unwrap() error {
+	return client.ErrCommitNotFound
+}
+
 // ByteRangeSize returns byteRange.Upper - byteRange.Lower.
 func ByteRangeSize(byteRange *pfs.ByteRange) uint64 {
 	return byteRange.Upper - byteRange.Lower
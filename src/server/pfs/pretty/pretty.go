@@ -5,8 +5,11 @@ import (
 	"html/template"
 	"io"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/docker/go-units"
+	"github.com/gogo/protobuf/types"
 	"github.com/pachyderm/pachyderm/src/client/pfs"
 	"github.com/pachyderm/pachyderm/src/server/pkg/pretty"
 )
@@ -31,9 +34,11 @@ func PrintRepoInfo(w io.Writer, repoInfo *pfs.RepoInfo) {
 func PrintDetailedRepoInfo(repoInfo *pfs.RepoInfo) error {
 	template, err := template.New("RepoInfo").Funcs(funcMap).Parse(
 		`Name: {{.Repo.Name}}{{if .Description}}
-Description: {{.Description}}{{end}}
+Description: {{.Description}}{{end}}{{if .Labels}}
+Labels: {{range $key, $value := .Labels}} {{$key}}={{$value}} {{end}}{{end}}
 Created: {{prettyAgo .Created}}
-Size: {{prettySize .SizeBytes}}{{if .Provenance}}
+Size: {{prettySize .SizeBytes}}{{if .Stats}}
+Changes: {{.Stats.FilesAdded}} added, {{.Stats.FilesDeleted}} deleted, {{.Stats.FilesModified}} modified ({{sizeDelta .Stats.SizeDeltaBytes}}) {{end}}{{if .Provenance}}
 Provenance: {{range .Provenance}} {{.Name}} {{end}} {{end}}
 `)
 	if err != nil {
@@ -48,13 +53,60 @@ Provenance: {{range .Provenance}} {{.Name}} {{end}} {{end}}
 
 // PrintBranchHeader prints a branch header.
 func PrintBranchHeader(w io.Writer) {
-	fmt.Fprint(w, "BRANCH\tHEAD\t\n")
+	fmt.Fprint(w, "BRANCH\tHEAD\tTRIGGER\tRETENTION\t\n")
 }
 
 // PrintBranch pretty-prints a Branch.
 func PrintBranch(w io.Writer, branch *pfs.Branch) {
 	fmt.Fprintf(w, "%s\t", branch.Name)
-	fmt.Fprintf(w, "%s\t\n", branch.Head.ID)
+	fmt.Fprintf(w, "%s\t", branch.Head.ID)
+	fmt.Fprintf(w, "%s\t", Trigger(branch.Trigger))
+	fmt.Fprintf(w, "%s\t\n", Retention(branch.Retention))
+}
+
+// Trigger describes a branch's trigger, e.g. "staging: 1GB or @hourly".
+func Trigger(trigger *pfs.Trigger) string {
+	if trigger == nil {
+		return "-"
+	}
+	var conditions []string
+	if trigger.Size_ != "" {
+		conditions = append(conditions, trigger.Size_)
+	}
+	if trigger.Commits > 0 {
+		conditions = append(conditions, fmt.Sprintf("%d commits", trigger.Commits))
+	}
+	if trigger.CronSpec != "" {
+		conditions = append(conditions, trigger.CronSpec)
+	}
+	sep := " or "
+	if trigger.All {
+		sep = " and "
+	}
+	return fmt.Sprintf("%s: %s", trigger.Branch, strings.Join(conditions, sep))
+}
+
+// Retention describes a branch's retention policy, e.g. "10 commits or 30
+// days".
+func Retention(retention *pfs.RetentionPolicy) string {
+	if retention == nil {
+		return "-"
+	}
+	var conditions []string
+	if retention.KeepCommits > 0 {
+		conditions = append(conditions, fmt.Sprintf("%d commits", retention.KeepCommits))
+	}
+	if retention.KeepFor != nil {
+		keepFor, err := types.DurationFromProto(retention.KeepFor)
+		if err != nil {
+			conditions = append(conditions, "invalid duration")
+		} else if keepFor%(24*time.Hour) == 0 {
+			conditions = append(conditions, fmt.Sprintf("%d days", keepFor/(24*time.Hour)))
+		} else {
+			conditions = append(conditions, keepFor.String())
+		}
+	}
+	return strings.Join(conditions, " or ")
 }
 
 // PrintCommitInfoHeader prints a commit info header.
@@ -88,11 +140,14 @@ func PrintCommitInfo(w io.Writer, commitInfo *pfs.CommitInfo) {
 func PrintDetailedCommitInfo(commitInfo *pfs.CommitInfo) error {
 	template, err := template.New("CommitInfo").Funcs(funcMap).Parse(
 		`Commit: {{.Commit.Repo.Name}}/{{.Commit.ID}}{{if .ParentCommit}}
-Parent: {{.ParentCommit.ID}} {{end}}
+Parent: {{.ParentCommit.ID}} {{end}}{{if .Author}}
+Author: {{.Author}} {{end}}
 Started: {{prettyAgo .Started}}{{if .Finished}}
 Finished: {{prettyAgo .Finished}} {{end}}
-Size: {{prettySize .SizeBytes}}{{if .Provenance}}
-Provenance: {{range .Provenance}} {{.Repo.Name}}/{{.ID}} {{end}} {{end}}
+Size: {{prettySize .SizeBytes}}{{if .Stats}}
+Changes: {{.Stats.FilesAdded}} added, {{.Stats.FilesDeleted}} deleted, {{.Stats.FilesModified}} modified ({{sizeDelta .Stats.SizeDeltaBytes}}) {{end}}{{if .Provenance}}
+Provenance: {{range .Provenance}} {{.Repo.Name}}/{{.ID}} {{end}} {{end}}{{if .Transaction}}
+Transaction: {{.Transaction.ID}} {{end}}
 `)
 	if err != nil {
 		return err
@@ -139,6 +194,21 @@ Children: {{range .Children}} {{.Path}} {{end}}
 	return nil
 }
 
+// PrintFileProvenance pretty-prints a datum that wrote to a file, along with
+// the input files that the datum was made up of, which are data. If they
+// couldn't be looked up, err is printed instead.
+func PrintFileProvenance(w io.Writer, provenance *pfs.FileProvenance, data []*pfs.FileInfo, err error) {
+	fmt.Fprintf(w, "Job: %s Datum: %s\n", provenance.JobID, provenance.DatumID)
+	if err != nil {
+		fmt.Fprintf(w, "  inputs unavailable: %v\n", err)
+		return
+	}
+	for _, fileInfo := range data {
+		file := fileInfo.File
+		fmt.Fprintf(w, "  %s/%s:%s\n", file.Commit.Repo.Name, file.Commit.ID, file.Path)
+	}
+}
+
 type uint64Slice []uint64
 
 func (s uint64Slice) Len() int           { return len(s) }
@@ -156,4 +226,13 @@ var funcMap = template.FuncMap{
 	"prettyAgo":  pretty.Ago,
 	"prettySize": pretty.Size,
 	"fileType":   fileType,
+	"sizeDelta":  sizeDelta,
+}
+
+// sizeDelta pretty-prints a change in size, e.g. "+1.5KiB" or "-200B".
+func sizeDelta(delta int64) string {
+	if delta < 0 {
+		return "-" + pretty.Size(uint64(-delta))
+	}
+	return "+" + pretty.Size(uint64(delta))
 }
@@ -0,0 +1,341 @@
+package s3
+
+import (
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+)
+
+// defaultMaxKeys is the number of keys that ListObjects returns if the
+// request doesn't say, it's also the most that it returns.
+const defaultMaxKeys = 1000
+
+type owner struct {
+	ID          string `xml:"ID"`
+	DisplayName string `xml:"DisplayName"`
+}
+
+// the gateway doesn't know who owns buckets and objects, so they're all
+// owned by this
+var defaultOwner = owner{ID: "pachyderm", DisplayName: "pachyderm"}
+
+type bucketInfo struct {
+	Name         string    `xml:"Name"`
+	CreationDate time.Time `xml:"CreationDate"`
+}
+
+type listBucketsResponse struct {
+	XMLName xml.Name     `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListAllMyBucketsResult"`
+	Owner   owner        `xml:"Owner"`
+	Buckets []bucketInfo `xml:"Buckets>Bucket"`
+}
+
+// listBuckets lists a bucket for each branch of each repo. Repos without
+// any branches have a bucket for their master branch, so that buckets are
+// listed as soon as they're created.
+func (g *gateway) listBuckets(c *client.APIClient, w http.ResponseWriter, r *http.Request) {
+	repoInfos, err := c.ListRepo(nil)
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+	response := &listBucketsResponse{Owner: defaultOwner}
+	for _, repoInfo := range repoInfos {
+		created, err := types.TimestampFromProto(repoInfo.Created)
+		if err != nil {
+			internalError(w, r, err)
+			return
+		}
+		branches, err := c.ListBranch(repoInfo.Repo.Name)
+		if err != nil {
+			internalError(w, r, err)
+			return
+		}
+		if len(branches) == 0 {
+			branches = []*pfs.Branch{{Name: "master"}}
+		}
+		for _, branch := range branches {
+			response.Buckets = append(response.Buckets, bucketInfo{
+				Name:         fmt.Sprintf("%s@%s", repoInfo.Repo.Name, branch.Name),
+				CreationDate: created,
+			})
+		}
+	}
+	writeXML(w, http.StatusOK, response)
+}
+
+type locationConstraint struct {
+	XMLName  xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ LocationConstraint"`
+	Location string   `xml:",chardata"`
+}
+
+// getBucketLocation says that every bucket is in the default region, which
+// S3 clients ask before they make requests to a bucket.
+func getBucketLocation(w http.ResponseWriter, r *http.Request) {
+	writeXML(w, http.StatusOK, &locationConstraint{})
+}
+
+// headBucket succeeds if bucket's repo exists, its branch needn't, as
+// branches only exist once they have a commit.
+func (g *gateway) headBucket(c *client.APIClient, w http.ResponseWriter, r *http.Request, bucket string) {
+	repo, _ := parseBucket(bucket)
+	if _, err := c.InspectRepo(repo); err != nil {
+		if isNotFoundErr(err) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		internalError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// createBucket creates bucket's repo, if it doesn't already exist.
+func (g *gateway) createBucket(c *client.APIClient, w http.ResponseWriter, r *http.Request, bucket string) {
+	repo, _ := parseBucket(bucket)
+	if _, err := c.InspectRepo(repo); err == nil {
+		writeError(w, r, http.StatusConflict, "BucketAlreadyOwnedByYou", fmt.Sprintf("the bucket %s already exists", bucket))
+		return
+	} else if !isNotFoundErr(err) {
+		internalError(w, r, err)
+		return
+	}
+	if err := c.CreateRepo(repo); err != nil {
+		invalidArgument(w, r, err.Error())
+		return
+	}
+	w.Header().Set("Location", "/"+bucket)
+	w.WriteHeader(http.StatusOK)
+}
+
+type object struct {
+	Key          string    `xml:"Key"`
+	LastModified time.Time `xml:"LastModified"`
+	ETag         string    `xml:"ETag"`
+	Size         uint64    `xml:"Size"`
+	StorageClass string    `xml:"StorageClass"`
+	Owner        *owner    `xml:"Owner,omitempty"`
+}
+
+type commonPrefix struct {
+	Prefix string `xml:"Prefix"`
+}
+
+// listObjectsResponse is the response to both versions of ListObjects, the
+// fields that only one of them has are omitted from the other.
+type listObjectsResponse struct {
+	XMLName               xml.Name       `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListBucketResult"`
+	Name                  string         `xml:"Name"`
+	Prefix                string         `xml:"Prefix"`
+	Marker                *string        `xml:"Marker,omitempty"`
+	NextMarker            string         `xml:"NextMarker,omitempty"`
+	StartAfter            string         `xml:"StartAfter,omitempty"`
+	ContinuationToken     string         `xml:"ContinuationToken,omitempty"`
+	NextContinuationToken string         `xml:"NextContinuationToken,omitempty"`
+	KeyCount              *int           `xml:"KeyCount,omitempty"`
+	MaxKeys               int            `xml:"MaxKeys"`
+	Delimiter             string         `xml:"Delimiter,omitempty"`
+	IsTruncated           bool           `xml:"IsTruncated"`
+	Contents              []object       `xml:"Contents"`
+	CommonPrefixes        []commonPrefix `xml:"CommonPrefixes"`
+}
+
+// listObjects implements both versions of ListObjects. Keys are listed in
+// lexicographic order, as S3 lists them, and paged through with max-keys
+// and marker (or, in version 2, continuation-token and start-after).
+func (g *gateway) listObjects(c *client.APIClient, w http.ResponseWriter, r *http.Request, bucket string) {
+	query := r.URL.Query()
+	prefix := query.Get("prefix")
+	delimiter := query.Get("delimiter")
+	v2 := query.Get("list-type") == "2"
+	maxKeys := defaultMaxKeys
+	if query.Get("max-keys") != "" {
+		n, err := strconv.Atoi(query.Get("max-keys"))
+		if err != nil || n < 0 {
+			invalidArgument(w, r, fmt.Sprintf("invalid max-keys %q", query.Get("max-keys")))
+			return
+		}
+		if n < maxKeys {
+			maxKeys = n
+		}
+	}
+	marker := query.Get("marker")
+	if v2 {
+		marker = query.Get("start-after")
+		if token := query.Get("continuation-token"); token != "" {
+			marker = token
+		}
+	}
+
+	repo, branch := parseBucket(bucket)
+	commitInfo, ok := g.inspectBranch(c, w, r, bucket, repo, branch)
+	if !ok {
+		return
+	}
+	var objects []object
+	var prefixes []commonPrefix
+	if commitInfo != nil {
+		lastModified, err := commitTime(commitInfo)
+		if err != nil {
+			internalError(w, r, err)
+			return
+		}
+		seenPrefixes := make(map[string]bool)
+		addFile := func(fileInfo *pfs.FileInfo) {
+			key := strings.TrimPrefix(fileInfo.File.Path, "/")
+			if !strings.HasPrefix(key, prefix) || key <= marker {
+				return
+			}
+			if delimiter != "" {
+				if i := strings.Index(key[len(prefix):], delimiter); i >= 0 {
+					p := key[:len(prefix)+i+len(delimiter)]
+					if !seenPrefixes[p] && p > marker {
+						seenPrefixes[p] = true
+						prefixes = append(prefixes, commonPrefix{p})
+					}
+					return
+				}
+			}
+			objects = append(objects, object{
+				Key:          key,
+				LastModified: lastModified,
+				ETag:         etag(fileInfo),
+				Size:         fileInfo.SizeBytes,
+				StorageClass: "STANDARD",
+				Owner:        &defaultOwner,
+			})
+		}
+		if err := listFiles(c, commitInfo.Commit, prefix, delimiter, addFile); err != nil {
+			internalError(w, r, err)
+			return
+		}
+	}
+
+	// merge objects and prefixes in lexicographic order, and truncate
+	// them to maxKeys
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	sort.Slice(prefixes, func(i, j int) bool { return prefixes[i].Prefix < prefixes[j].Prefix })
+	response := &listObjectsResponse{
+		Name:      bucket,
+		Prefix:    prefix,
+		MaxKeys:   maxKeys,
+		Delimiter: delimiter,
+	}
+	var last string
+	for len(objects)+len(prefixes) > 0 {
+		if len(response.Contents)+len(response.CommonPrefixes) == maxKeys {
+			response.IsTruncated = true
+			break
+		}
+		if len(prefixes) == 0 || len(objects) > 0 && objects[0].Key < prefixes[0].Prefix {
+			last = objects[0].Key
+			response.Contents = append(response.Contents, objects[0])
+			objects = objects[1:]
+		} else {
+			last = prefixes[0].Prefix
+			response.CommonPrefixes = append(response.CommonPrefixes, prefixes[0])
+			prefixes = prefixes[1:]
+		}
+	}
+	if v2 {
+		keyCount := len(response.Contents) + len(response.CommonPrefixes)
+		response.KeyCount = &keyCount
+		response.StartAfter = query.Get("start-after")
+		response.ContinuationToken = query.Get("continuation-token")
+		if response.IsTruncated {
+			response.NextContinuationToken = last
+		}
+	} else {
+		response.Marker = &marker
+		if response.IsTruncated {
+			response.NextMarker = last
+		}
+	}
+	writeXML(w, http.StatusOK, response)
+}
+
+// listFiles calls f with the files in commit that may have keys starting
+// with prefix. If delimiter is "/", only the files in the directory that
+// prefix is in are listed, and subdirectories stand for their contents,
+// otherwise all of the files under that directory are.
+func listFiles(c *client.APIClient, commit *pfs.Commit, prefix string, delimiter string, f func(*pfs.FileInfo)) error {
+	dir := "/"
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		dir = "/" + prefix[:i]
+	}
+	if delimiter == "/" {
+		fileInfos, err := c.ListFile(commit.Repo.Name, commit.ID, dir)
+		if err != nil {
+			if isNotFoundErr(err) {
+				return nil
+			}
+			return err
+		}
+		for _, fileInfo := range fileInfos {
+			if fileInfo.FileType == pfs.FileType_DIR {
+				// the trailing delimiter makes the directory a
+				// common prefix, which stands for its contents
+				fileInfo.File.Path += "/"
+			}
+			f(fileInfo)
+		}
+		return nil
+	}
+	if err := c.Walk(commit.Repo.Name, commit.ID, dir, func(fileInfo *pfs.FileInfo) error {
+		if fileInfo.FileType == pfs.FileType_FILE {
+			f(fileInfo)
+		}
+		return nil
+	}); err != nil && !isNotFoundErr(err) {
+		return err
+	}
+	return nil
+}
+
+// inspectBranch returns the head of branch, which is nil if the branch
+// doesn't have any commits yet. If bucket doesn't exist, or inspectBranch
+// fails, it writes the error to w and returns false.
+func (g *gateway) inspectBranch(c *client.APIClient, w http.ResponseWriter, r *http.Request, bucket string, repo string, branch string) (*pfs.CommitInfo, bool) {
+	commitInfo, err := c.InspectCommit(repo, branch)
+	if err == nil {
+		return commitInfo, true
+	}
+	if !isNotFoundErr(err) {
+		internalError(w, r, err)
+		return nil, false
+	}
+	if _, err := c.InspectRepo(repo); err != nil {
+		if isNotFoundErr(err) {
+			noSuchBucket(w, r, bucket)
+			return nil, false
+		}
+		internalError(w, r, err)
+		return nil, false
+	}
+	return nil, true
+}
+
+// commitTime returns when commitInfo's commit was finished, or started if
+// it's still open, which is used as the time that its files were last
+// modified.
+func commitTime(commitInfo *pfs.CommitInfo) (time.Time, error) {
+	if commitInfo.Finished != nil {
+		return types.TimestampFromProto(commitInfo.Finished)
+	}
+	return types.TimestampFromProto(commitInfo.Started)
+}
+
+// etag returns the ETag of the object for fileInfo, which is its file's
+// hash.
+func etag(fileInfo *pfs.FileInfo) string {
+	return fmt.Sprintf("%q", hex.EncodeToString(fileInfo.Hash))
+}
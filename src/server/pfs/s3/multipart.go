@@ -0,0 +1,159 @@
+package s3
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/uuid"
+)
+
+// Multipart uploads are stateless: each part is put into the object store
+// as it's uploaded, and its ETag is its object's hash, so completing an
+// upload only needs the parts' ETags, which the client sends. The file isn't
+// written to the branch until the upload is completed. Parts that aren't
+// part of a completed upload are left for garbage collection.
+
+type initiateMultipartUploadResult struct {
+	XMLName  xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ InitiateMultipartUploadResult"`
+	Bucket   string   `xml:"Bucket"`
+	Key      string   `xml:"Key"`
+	UploadID string   `xml:"UploadId"`
+}
+
+func (g *gateway) initiateMultipartUpload(c *client.APIClient, w http.ResponseWriter, r *http.Request, bucket string, key string) {
+	repo, _ := parseBucket(bucket)
+	if _, err := c.InspectRepo(repo); err != nil {
+		if isNotFoundErr(err) {
+			noSuchBucket(w, r, bucket)
+			return
+		}
+		internalError(w, r, err)
+		return
+	}
+	writeXML(w, http.StatusOK, &initiateMultipartUploadResult{
+		Bucket:   bucket,
+		Key:      key,
+		UploadID: uuid.NewWithoutDashes(),
+	})
+}
+
+// uploadPart puts a part of a multipart upload into the object store.
+func (g *gateway) uploadPart(c *client.APIClient, w http.ResponseWriter, r *http.Request, bucket string, key string, uploadID string) {
+	partNumber, err := strconv.Atoi(r.URL.Query().Get("partNumber"))
+	if err != nil || partNumber < 1 || partNumber > 10000 {
+		invalidArgument(w, r, fmt.Sprintf("invalid partNumber %q", r.URL.Query().Get("partNumber")))
+		return
+	}
+	object, _, err := c.PutObject(body(r), fmt.Sprintf("s3-multipart/%s/%d", uploadID, partNumber))
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+	w.Header().Set("ETag", fmt.Sprintf("%q", object.Hash))
+	w.WriteHeader(http.StatusOK)
+}
+
+type completeMultipartUpload struct {
+	Parts []completePart `xml:"Part"`
+}
+
+type completePart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type completeMultipartUploadResult struct {
+	XMLName  xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ CompleteMultipartUploadResult"`
+	Location string   `xml:"Location"`
+	Bucket   string   `xml:"Bucket"`
+	Key      string   `xml:"Key"`
+	ETag     string   `xml:"ETag"`
+}
+
+// completeMultipartUpload writes the concatenation of the upload's parts to
+// key, in a new commit on bucket's branch.
+func (g *gateway) completeMultipartUpload(c *client.APIClient, w http.ResponseWriter, r *http.Request, bucket string, key string, uploadID string) {
+	var request completeMultipartUpload
+	if err := xml.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, r, http.StatusBadRequest, "MalformedXML", err.Error())
+		return
+	}
+	if len(request.Parts) == 0 {
+		writeError(w, r, http.StatusBadRequest, "MalformedXML", "the upload must have at least one part")
+		return
+	}
+	var hashes []string
+	for i, part := range request.Parts {
+		if i > 0 && part.PartNumber <= request.Parts[i-1].PartNumber {
+			writeError(w, r, http.StatusBadRequest, "InvalidPartOrder", "parts must be listed in ascending order")
+			return
+		}
+		hash := strings.Trim(part.ETag, "\"")
+		if _, err := c.InspectObject(hash); err != nil {
+			writeError(w, r, http.StatusBadRequest, "InvalidPart", fmt.Sprintf("part %d with ETag %s wasn't uploaded", part.PartNumber, part.ETag))
+			return
+		}
+		hashes = append(hashes, hash)
+	}
+
+	repo, branch := parseBucket(bucket)
+	if _, err := c.InspectRepo(repo); err != nil {
+		if isNotFoundErr(err) {
+			noSuchBucket(w, r, bucket)
+			return
+		}
+		internalError(w, r, err)
+		return
+	}
+	fileInfo, err := g.writeBranch(c, repo, branch, key, func(commit *pfs.Commit) error {
+		if err := c.DeleteFile(repo, commit.ID, key); err != nil {
+			return err
+		}
+		pr, pw := io.Pipe()
+		go func() {
+			pw.CloseWithError(c.GetObjects(hashes, 0, 0, pw))
+		}()
+		_, err := c.PutFile(repo, commit.ID, key, pr)
+		pr.CloseWithError(err)
+		return err
+	})
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+	writeXML(w, http.StatusOK, &completeMultipartUploadResult{
+		Location: "/" + bucket + "/" + key,
+		Bucket:   bucket,
+		Key:      key,
+		ETag:     etag(fileInfo),
+	})
+}
+
+// abortMultipartUpload has nothing to do, as the upload's parts aren't
+// referenced by anything until it's completed.
+func abortMultipartUpload(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type listMultipartUploadsResult struct {
+	XMLName     xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListMultipartUploadsResult"`
+	Bucket      string   `xml:"Bucket"`
+	MaxUploads  int      `xml:"MaxUploads"`
+	IsTruncated bool     `xml:"IsTruncated"`
+}
+
+// listMultipartUploads never lists any uploads, as the gateway doesn't keep
+// track of them. Clients that resume uploads (e.g. minio-go) list them
+// before they upload a file, so they start a new upload instead.
+func listMultipartUploads(w http.ResponseWriter, r *http.Request, bucket string) {
+	writeXML(w, http.StatusOK, &listMultipartUploadsResult{
+		Bucket:     bucket,
+		MaxUploads: defaultMaxKeys,
+	})
+}
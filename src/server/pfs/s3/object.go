@@ -0,0 +1,272 @@
+package s3
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/server/pkg/backoff"
+)
+
+// getObject serves GET and HEAD requests for key. Single byte ranges are
+// supported, as tools such as Spark read large objects a range at a time.
+func (g *gateway) getObject(c *client.APIClient, w http.ResponseWriter, r *http.Request, bucket string, key string) {
+	repo, branch := parseBucket(bucket)
+	commitInfo, ok := g.inspectBranch(c, w, r, bucket, repo, branch)
+	if !ok {
+		return
+	}
+	if commitInfo == nil {
+		noSuchKey(w, r, key)
+		return
+	}
+	// read the file from the branch's head, rather than the branch, so
+	// that its info and its contents are from the same commit
+	fileInfo, err := c.InspectFile(repo, commitInfo.Commit.ID, key)
+	if err != nil {
+		if isNotFoundErr(err) {
+			noSuchKey(w, r, key)
+			return
+		}
+		internalError(w, r, err)
+		return
+	}
+	if fileInfo.FileType != pfs.FileType_FILE {
+		noSuchKey(w, r, key)
+		return
+	}
+	lastModified, err := commitTime(commitInfo)
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	size := int64(fileInfo.SizeBytes)
+	offset, length, err := parseRange(r.Header.Get("Range"), size)
+	if err != nil {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+		writeError(w, r, http.StatusRequestedRangeNotSatisfiable, "InvalidRange", err.Error())
+		return
+	}
+	w.Header().Set("Accept-Ranges", "bytes")
+	w.Header().Set("Content-Length", strconv.FormatInt(length, 10))
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("ETag", etag(fileInfo))
+	w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	status := http.StatusOK
+	if length != size {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, offset+length-1, size))
+		status = http.StatusPartialContent
+	}
+	w.WriteHeader(status)
+	if r.Method == "HEAD" || length == 0 {
+		return
+	}
+	if err := c.GetFile(repo, commitInfo.Commit.ID, key, offset, length, w); err != nil {
+		// the status has already been sent, so all we can do is log
+		// the error and cut the response short
+		internalError(w, r, err)
+	}
+}
+
+// parseRange parses a Range header for an object of size bytes, and returns
+// the offset and length of the bytes that it asks for. If header is empty,
+// the range is the whole object.
+func parseRange(header string, size int64) (offset int64, length int64, retErr error) {
+	if header == "" {
+		return 0, size, nil
+	}
+	if !strings.HasPrefix(header, "bytes=") || strings.Contains(header, ",") {
+		return 0, 0, fmt.Errorf("unsupported range %q, only single byte ranges are supported", header)
+	}
+	spec := strings.SplitN(strings.TrimPrefix(header, "bytes="), "-", 2)
+	if len(spec) != 2 {
+		return 0, 0, fmt.Errorf("invalid range %q", header)
+	}
+	if spec[0] == "" {
+		// the last spec[1] bytes
+		n, err := strconv.ParseInt(spec[1], 10, 64)
+		if err != nil || n <= 0 {
+			return 0, 0, fmt.Errorf("invalid range %q", header)
+		}
+		if n > size {
+			n = size
+		}
+		return size - n, n, nil
+	}
+	start, err := strconv.ParseInt(spec[0], 10, 64)
+	if err != nil || start < 0 || start >= size {
+		return 0, 0, fmt.Errorf("invalid range %q for an object of %d bytes", header, size)
+	}
+	end := size - 1
+	if spec[1] != "" {
+		if end, err = strconv.ParseInt(spec[1], 10, 64); err != nil || end < start {
+			return 0, 0, fmt.Errorf("invalid range %q", header)
+		}
+		if end >= size {
+			end = size - 1
+		}
+	}
+	return start, end - start + 1, nil
+}
+
+// putObject writes the request's body to key, replacing the file that's
+// there, in a new commit on bucket's branch.
+func (g *gateway) putObject(c *client.APIClient, w http.ResponseWriter, r *http.Request, bucket string, key string) {
+	repo, branch := parseBucket(bucket)
+	if _, err := c.InspectRepo(repo); err != nil {
+		if isNotFoundErr(err) {
+			noSuchBucket(w, r, bucket)
+			return
+		}
+		internalError(w, r, err)
+		return
+	}
+	fileInfo, err := g.writeBranch(c, repo, branch, key, func(commit *pfs.Commit) error {
+		if err := c.DeleteFile(repo, commit.ID, key); err != nil {
+			return err
+		}
+		_, err := c.PutFile(repo, commit.ID, key, body(r))
+		return err
+	})
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+	w.Header().Set("ETag", etag(fileInfo))
+	w.WriteHeader(http.StatusOK)
+}
+
+// deleteObject deletes key in a new commit on bucket's branch. As in S3,
+// deleting a key that doesn't exist succeeds.
+func (g *gateway) deleteObject(c *client.APIClient, w http.ResponseWriter, r *http.Request, bucket string, key string) {
+	repo, branch := parseBucket(bucket)
+	commitInfo, ok := g.inspectBranch(c, w, r, bucket, repo, branch)
+	if !ok {
+		return
+	}
+	if commitInfo != nil {
+		if _, err := c.InspectFile(repo, commitInfo.Commit.ID, key); err != nil && !isNotFoundErr(err) {
+			internalError(w, r, err)
+			return
+		} else if err == nil {
+			if _, err := g.writeBranch(c, repo, branch, "", func(commit *pfs.Commit) error {
+				return c.DeleteFile(repo, commit.ID, key)
+			}); err != nil {
+				internalError(w, r, err)
+				return
+			}
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeBranch calls f with a new commit on branch of repo, and finishes the
+// commit once f returns, or deletes it if f fails. If key is set, the info
+// of the file at key in the finished commit is returned. The gateway's
+// writes to each branch are serialized, but other writers (e.g. another
+// pachd's gateway, or pachctl) may have a commit open on the branch, in
+// which case writeBranch waits for them to finish it.
+func (g *gateway) writeBranch(c *client.APIClient, repo string, branch string, key string, f func(*pfs.Commit) error) (*pfs.FileInfo, error) {
+	lock := g.branchLock(repo, branch)
+	lock.Lock()
+	defer lock.Unlock()
+	var commit *pfs.Commit
+	b := backoff.NewExponentialBackOff()
+	b.MaxElapsedTime = time.Minute
+	if err := backoff.RetryNotify(func() error {
+		var err error
+		commit, err = c.StartCommit(repo, branch)
+		return err
+	}, b, func(err error, d time.Duration) error {
+		if !strings.Contains(err.Error(), "has not been finished") {
+			return err
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if err := f(commit); err != nil {
+		c.DeleteCommit(repo, commit.ID)
+		return nil, err
+	}
+	if err := c.FinishCommit(repo, commit.ID); err != nil {
+		return nil, err
+	}
+	if key == "" {
+		return nil, nil
+	}
+	return c.InspectFile(repo, commit.ID, key)
+}
+
+// body returns the body of r, decoded if the client sent it with AWS's
+// chunked encoding (which signs each chunk), as the V4 signing SDKs do.
+func body(r *http.Request) io.Reader {
+	if r.Header.Get("x-amz-content-sha256") == "STREAMING-AWS4-HMAC-SHA256-PAYLOAD" {
+		return &awsChunkedReader{r: bufio.NewReader(r.Body)}
+	}
+	return r.Body
+}
+
+// awsChunkedReader decodes a body sent with AWS's chunked encoding, in which
+// each chunk is preceded by a line of the form
+// "<hex size>;chunk-signature=<signature>" and followed by "\r\n", and the
+// last chunk is empty. The signatures aren't checked.
+type awsChunkedReader struct {
+	r         *bufio.Reader
+	remaining int64
+	done      bool
+}
+
+func (a *awsChunkedReader) Read(p []byte) (int, error) {
+	for a.remaining == 0 {
+		if a.done {
+			return 0, io.EOF
+		}
+		if err := a.nextChunk(); err != nil {
+			return 0, err
+		}
+	}
+	if int64(len(p)) > a.remaining {
+		p = p[:a.remaining]
+	}
+	n, err := a.r.Read(p)
+	a.remaining -= int64(n)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err == nil && a.remaining == 0 {
+		// consume the "\r\n" that ends the chunk
+		if _, err := a.r.Discard(2); err != nil {
+			return n, err
+		}
+	}
+	return n, err
+}
+
+func (a *awsChunkedReader) nextChunk() error {
+	line, err := a.r.ReadString('\n')
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	size := strings.TrimSpace(strings.SplitN(line, ";", 2)[0])
+	n, err := strconv.ParseInt(size, 16, 64)
+	if err != nil || n < 0 {
+		return fmt.Errorf("invalid chunk header %q", line)
+	}
+	if n == 0 {
+		a.done = true
+		return nil
+	}
+	a.remaining = n
+	return nil
+}
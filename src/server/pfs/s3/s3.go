@@ -0,0 +1,251 @@
+// Package s3 implements a gateway that serves PFS over a subset of the S3
+// API, so that tools which speak S3 (Spark, boto, the AWS CLI etc.) can read
+// and write PFS. Buckets are branches of repos and objects are files, see
+// parseBucket. Only path-style requests (http://<gateway>/<bucket>/<key>)
+// are supported.
+package s3
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/pachyderm/pachyderm/src/client"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// Server returns an http.Server that serves the S3 gateway on port, and
+// talks to the pachd at pachdAddress, with options.
+func Server(port uint16, pachdAddress string, options ...client.Option) *http.Server {
+	return &http.Server{
+		Addr:    fmt.Sprintf(":%d", port),
+		Handler: NewHandler(pachdAddress, options...),
+	}
+}
+
+// NewHandler returns an http.Handler that serves the S3 gateway, see Server.
+func NewHandler(pachdAddress string, options ...client.Option) http.Handler {
+	return &gateway{
+		pachdAddress: pachdAddress,
+		options:      options,
+		clients:      make(map[string]*client.APIClient),
+		branchLocks:  make(map[string]*sync.Mutex),
+	}
+}
+
+type gateway struct {
+	pachdAddress string
+	options      []client.Option
+
+	// clients are the gateway's clients of pachd, by the auth token they
+	// send, see client
+	clients   map[string]*client.APIClient
+	clientsMu sync.Mutex
+
+	// branchLocks serialize the gateway's writes to each branch, which are
+	// each a commit, see writeBranch
+	branchLocks   map[string]*sync.Mutex
+	branchLocksMu sync.Mutex
+}
+
+// client returns a client of pachd for r. The access key that r is signed
+// with, if it has one, is used as the client's pachd auth token, so that
+// requests are authorized as they would be if they were made with pachctl.
+// r's signature isn't checked, as the gateway doesn't know the secret keys.
+func (g *gateway) client(r *http.Request) (*client.APIClient, error) {
+	token := accessKey(r)
+	g.clientsMu.Lock()
+	defer g.clientsMu.Unlock()
+	if c, ok := g.clients[token]; ok {
+		return c, nil
+	}
+	options := g.options
+	if token != "" {
+		options = append(options[:len(options):len(options)], client.WithAuthToken(token))
+	}
+	c, err := client.NewFromAddress(g.pachdAddress, options...)
+	if err != nil {
+		return nil, err
+	}
+	g.clients[token] = c
+	return c, nil
+}
+
+// accessKey returns the access key that r is signed with, or "" if it isn't
+// signed. Both V2 ("AWS key:signature") and V4 ("AWS4-HMAC-SHA256
+// Credential=key/...") signatures, and presigned URLs, are understood.
+func accessKey(r *http.Request) string {
+	auth := r.Header.Get("Authorization")
+	switch {
+	case strings.HasPrefix(auth, "AWS4-HMAC-SHA256 "):
+		for _, field := range strings.Split(strings.TrimPrefix(auth, "AWS4-HMAC-SHA256 "), ",") {
+			field = strings.TrimSpace(field)
+			if strings.HasPrefix(field, "Credential=") {
+				return strings.SplitN(strings.TrimPrefix(field, "Credential="), "/", 2)[0]
+			}
+		}
+	case strings.HasPrefix(auth, "AWS "):
+		return strings.SplitN(strings.TrimPrefix(auth, "AWS "), ":", 2)[0]
+	}
+	query := r.URL.Query()
+	if credential := query.Get("X-Amz-Credential"); credential != "" {
+		return strings.SplitN(credential, "/", 2)[0]
+	}
+	return query.Get("AWSAccessKeyId")
+}
+
+// branchLock returns the lock that serializes the gateway's writes to
+// branch of repo.
+func (g *gateway) branchLock(repo string, branch string) *sync.Mutex {
+	g.branchLocksMu.Lock()
+	defer g.branchLocksMu.Unlock()
+	key := repo + "@" + branch
+	lock, ok := g.branchLocks[key]
+	if !ok {
+		lock = &sync.Mutex{}
+		g.branchLocks[key] = lock
+	}
+	return lock
+}
+
+func (g *gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, key := splitPath(r.URL.Path)
+	c, err := g.client(r)
+	if err != nil {
+		writeError(w, r, http.StatusServiceUnavailable, "ServiceUnavailable", err.Error())
+		return
+	}
+	query := r.URL.Query()
+	_, uploads := query["uploads"]
+	uploadID := query.Get("uploadId")
+	switch {
+	case bucket == "" && r.Method == "GET":
+		g.listBuckets(c, w, r)
+	case bucket == "":
+		methodNotAllowed(w, r)
+	case key == "" && r.Method == "GET" && hasKey(query, "location"):
+		getBucketLocation(w, r)
+	case key == "" && r.Method == "GET" && uploads:
+		listMultipartUploads(w, r, bucket)
+	case key == "" && r.Method == "GET":
+		g.listObjects(c, w, r, bucket)
+	case key == "" && r.Method == "HEAD":
+		g.headBucket(c, w, r, bucket)
+	case key == "" && r.Method == "PUT":
+		g.createBucket(c, w, r, bucket)
+	case key == "":
+		notImplemented(w, r)
+	case r.Method == "POST" && uploads:
+		g.initiateMultipartUpload(c, w, r, bucket, key)
+	case r.Method == "PUT" && uploadID != "":
+		g.uploadPart(c, w, r, bucket, key, uploadID)
+	case r.Method == "POST" && uploadID != "":
+		g.completeMultipartUpload(c, w, r, bucket, key, uploadID)
+	case r.Method == "DELETE" && uploadID != "":
+		abortMultipartUpload(w, r)
+	case uploadID != "":
+		notImplemented(w, r)
+	case r.Method == "GET" || r.Method == "HEAD":
+		g.getObject(c, w, r, bucket, key)
+	case r.Method == "PUT" && r.Header.Get("x-amz-copy-source") != "":
+		notImplemented(w, r)
+	case r.Method == "PUT":
+		g.putObject(c, w, r, bucket, key)
+	case r.Method == "DELETE":
+		g.deleteObject(c, w, r, bucket, key)
+	default:
+		methodNotAllowed(w, r)
+	}
+}
+
+// splitPath splits the path of a path-style request into its bucket and
+// key.
+func splitPath(path string) (bucket string, key string) {
+	parts := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)
+	bucket = parts[0]
+	if len(parts) == 2 {
+		key = parts[1]
+	}
+	return bucket, key
+}
+
+func hasKey(query map[string][]string, key string) bool {
+	_, ok := query[key]
+	return ok
+}
+
+// parseBucket returns the repo and branch that bucket refers to. Buckets
+// are named repo@branch, or just repo for repo's master branch. As many S3
+// clients refuse bucket names that contain characters other than lowercase
+// letters, digits, dashes and dots, buckets may also be named branch.repo,
+// which is unambiguous as repo names can't contain dots.
+func parseBucket(bucket string) (repo string, branch string) {
+	if i := strings.Index(bucket, "@"); i >= 0 {
+		return bucket[:i], bucket[i+1:]
+	}
+	if i := strings.LastIndex(bucket, "."); i >= 0 {
+		return bucket[i+1:], bucket[:i]
+	}
+	return bucket, "master"
+}
+
+// isNotFoundErr returns true if err is pachd saying that a repo, commit
+// (e.g. a branch with no commits) or file doesn't exist.
+func isNotFoundErr(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "not found")
+}
+
+// errorResponse is the body of S3 error responses.
+type errorResponse struct {
+	XMLName  xml.Name `xml:"Error"`
+	Code     string   `xml:"Code"`
+	Message  string   `xml:"Message"`
+	Resource string   `xml:"Resource"`
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, status int, code string, message string) {
+	if status >= 500 {
+		log.Errorf("s3 gateway: %s %s: %s", r.Method, r.URL.Path, message)
+	}
+	writeXML(w, status, &errorResponse{
+		Code:     code,
+		Message:  message,
+		Resource: r.URL.Path,
+	})
+}
+
+func internalError(w http.ResponseWriter, r *http.Request, err error) {
+	writeError(w, r, http.StatusInternalServerError, "InternalError", err.Error())
+}
+
+func noSuchBucket(w http.ResponseWriter, r *http.Request, bucket string) {
+	writeError(w, r, http.StatusNotFound, "NoSuchBucket", fmt.Sprintf("the bucket %s doesn't exist", bucket))
+}
+
+func noSuchKey(w http.ResponseWriter, r *http.Request, key string) {
+	writeError(w, r, http.StatusNotFound, "NoSuchKey", fmt.Sprintf("the key %s doesn't exist", key))
+}
+
+func invalidArgument(w http.ResponseWriter, r *http.Request, message string) {
+	writeError(w, r, http.StatusBadRequest, "InvalidArgument", message)
+}
+
+func methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, http.StatusMethodNotAllowed, "MethodNotAllowed", fmt.Sprintf("%s isn't allowed on %s", r.Method, r.URL.Path))
+}
+
+func notImplemented(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, http.StatusNotImplemented, "NotImplemented", "the S3 gateway doesn't implement this request")
+}
+
+func writeXML(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	w.Write([]byte(xml.Header))
+	if err := xml.NewEncoder(w).Encode(v); err != nil {
+		log.Errorf("s3 gateway: could not write response: %v", err)
+	}
+}
@@ -0,0 +1,219 @@
+package s3
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	minio "github.com/minio/minio-go"
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	"github.com/pachyderm/pachyderm/src/client/pkg/uuid"
+)
+
+func TestParseBucket(t *testing.T) {
+	for bucket, expected := range map[string][2]string{
+		"repo":            {"repo", "master"},
+		"repo@branch":     {"repo", "branch"},
+		"branch.repo":     {"repo", "branch"},
+		"v1.0.repo":       {"repo", "v1.0"},
+		"repo@feature.v2": {"repo", "feature.v2"},
+	} {
+		repo, branch := parseBucket(bucket)
+		require.Equal(t, expected[0], repo)
+		require.Equal(t, expected[1], branch)
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	for header, expected := range map[string][2]int64{
+		"":               {0, 100},
+		"bytes=0-9":      {0, 10},
+		"bytes=90-":      {90, 10},
+		"bytes=-10":      {90, 10},
+		"bytes=-1000":    {0, 100},
+		"bytes=50-99":    {50, 50},
+		"bytes=50-10000": {50, 50},
+	} {
+		offset, length, err := parseRange(header, 100)
+		require.NoError(t, err)
+		require.Equal(t, expected[0], offset)
+		require.Equal(t, expected[1], length)
+	}
+	for _, header := range []string{"bytes=100-", "bytes=10-5", "bytes=0-1,5-6", "lines=0-1", "bytes=-0"} {
+		_, _, err := parseRange(header, 100)
+		require.YesError(t, err)
+	}
+}
+
+func TestAWSChunkedReader(t *testing.T) {
+	body := "5;chunk-signature=abc\r\nhello\r\n6;chunk-signature=def\r\n world\r\n0;chunk-signature=ghi\r\n\r\n"
+	r := &awsChunkedReader{r: bufioReader(body)}
+	data, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, "hello world", string(data))
+
+	r = &awsChunkedReader{r: bufioReader("5;chunk-signature=abc\r\nhel")}
+	_, err = ioutil.ReadAll(r)
+	require.YesError(t, err)
+}
+
+func bufioReader(s string) *bufio.Reader {
+	return bufio.NewReader(strings.NewReader(s))
+}
+
+func xmlDecode(resp *http.Response, v interface{}) error {
+	defer resp.Body.Close()
+	return xml.NewDecoder(resp.Body).Decode(v)
+}
+
+func xmlEncode(t *testing.T, v interface{}) io.Reader {
+	data, err := xml.Marshal(v)
+	require.NoError(t, err)
+	return bytes.NewReader(data)
+}
+
+// getPachClient returns a client of the pachd that the tests run against,
+// and its address.
+func getPachClient(t *testing.T) (*client.APIClient, string) {
+	address := "0.0.0.0:30650"
+	if addr := os.Getenv("PACHD_PORT_650_TCP_ADDR"); addr != "" {
+		address = net.JoinHostPort(addr, "650")
+	}
+	c, err := client.NewFromAddress(address)
+	require.NoError(t, err)
+	return c, address
+}
+
+func TestGateway(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	c, address := getPachClient(t)
+	server := httptest.NewServer(NewHandler(address))
+	defer server.Close()
+	minioClient, err := minio.New(strings.TrimPrefix(server.URL, "http://"), "id", "secret", false)
+	require.NoError(t, err)
+	repo := "s3" + uuid.NewWithoutDashes()[0:12]
+	bucket := "master." + repo
+
+	require.NoError(t, minioClient.MakeBucket(bucket, ""))
+	exists, err := minioClient.BucketExists(bucket)
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	_, err = minioClient.PutObject(bucket, "dir/foo", strings.NewReader("foo"), "text/plain")
+	require.NoError(t, err)
+	_, err = minioClient.PutObject(bucket, "bar", strings.NewReader("bar"), "text/plain")
+	require.NoError(t, err)
+	// putting an object again replaces it
+	_, err = minioClient.PutObject(bucket, "bar", strings.NewReader("barbar"), "text/plain")
+	require.NoError(t, err)
+	var buffer bytes.Buffer
+	require.NoError(t, c.GetFile(repo, "master", "bar", 0, 0, &buffer))
+	require.Equal(t, "barbar", buffer.String())
+
+	object, err := minioClient.GetObject(bucket, "bar")
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(object)
+	require.NoError(t, err)
+	require.Equal(t, "barbar", string(data))
+	objectInfo, err := minioClient.StatObject(bucket, "dir/foo")
+	require.NoError(t, err)
+	require.Equal(t, int64(3), objectInfo.Size)
+	_, err = minioClient.StatObject(bucket, "missing")
+	require.YesError(t, err)
+	require.Equal(t, "NoSuchKey", minio.ToErrorResponse(err).Code)
+
+	// ranges of objects can be read
+	object, err = minioClient.GetObject(bucket, "bar")
+	require.NoError(t, err)
+	part := make([]byte, 3)
+	_, err = object.ReadAt(part, 2)
+	require.NoError(t, err)
+	require.Equal(t, "rba", string(part))
+
+	var keys []string
+	for objectInfo := range minioClient.ListObjects(bucket, "", true, nil) {
+		require.NoError(t, objectInfo.Err)
+		keys = append(keys, objectInfo.Key)
+	}
+	require.Equal(t, []string{"bar", "dir/foo"}, keys)
+	keys = nil
+	for objectInfo := range minioClient.ListObjects(bucket, "", false, nil) {
+		require.NoError(t, objectInfo.Err)
+		keys = append(keys, objectInfo.Key)
+	}
+	require.Equal(t, []string{"bar", "dir/"}, keys)
+	keys = nil
+	for objectInfo := range minioClient.ListObjectsV2(bucket, "dir/", true, nil) {
+		require.NoError(t, objectInfo.Err)
+		keys = append(keys, objectInfo.Key)
+	}
+	require.Equal(t, []string{"dir/foo"}, keys)
+
+	require.NoError(t, minioClient.RemoveObject(bucket, "bar"))
+	require.NoError(t, minioClient.RemoveObject(bucket, "bar"))
+	_, err = c.InspectFile(repo, "master", "bar")
+	require.YesError(t, err)
+
+	buckets, err := minioClient.ListBuckets()
+	require.NoError(t, err)
+	var found bool
+	for _, bucketInfo := range buckets {
+		if bucketInfo.Name == repo+"@master" {
+			found = true
+		}
+	}
+	require.True(t, found)
+}
+
+func TestGatewayMultipartUpload(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	c, address := getPachClient(t)
+	repo := "s3" + uuid.NewWithoutDashes()[0:12]
+	require.NoError(t, c.CreateRepo(repo))
+	server := httptest.NewServer(NewHandler(address))
+	defer server.Close()
+	url := fmt.Sprintf("%s/%s@master/file", server.URL, repo)
+
+	// the gateway doesn't check signatures, so the requests needn't be
+	// signed
+	resp, err := http.Post(url+"?uploads", "", nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var initiated initiateMultipartUploadResult
+	require.NoError(t, xmlDecode(resp, &initiated))
+	var complete completeMultipartUpload
+	for i, part := range []string{"foo", "bar", "buzz"} {
+		req, err := http.NewRequest("PUT", fmt.Sprintf("%s?uploadId=%s&partNumber=%d", url, initiated.UploadID, i+1), strings.NewReader(part))
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		complete.Parts = append(complete.Parts, completePart{PartNumber: i + 1, ETag: resp.Header.Get("ETag")})
+	}
+	resp, err = http.Post(fmt.Sprintf("%s?uploadId=%s", url, initiated.UploadID), "application/xml", xmlEncode(t, &complete))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var buffer bytes.Buffer
+	require.NoError(t, c.GetFile(repo, "master", "file", 0, 0, &buffer))
+	require.Equal(t, "foobarbuzz", buffer.String())
+
+	// parts that weren't uploaded are refused
+	complete.Parts = append(complete.Parts, completePart{PartNumber: 4, ETag: `"nonexistent"`})
+	resp, err = http.Post(fmt.Sprintf("%s?uploadId=%s", url, initiated.UploadID), "application/xml", xmlEncode(t, &complete))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
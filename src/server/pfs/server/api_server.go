@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 	"path"
@@ -12,10 +13,12 @@ import (
 	"strings"
 	"time"
 
+	etcd "github.com/coreos/etcd/clientv3"
 	"github.com/gogo/protobuf/types"
 	"github.com/pachyderm/pachyderm/src/client"
 	"github.com/pachyderm/pachyderm/src/client/pfs"
 	"github.com/pachyderm/pachyderm/src/client/pkg/grpcutil"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
 	"github.com/pachyderm/pachyderm/src/server/pkg/metrics"
 	"github.com/pachyderm/pachyderm/src/server/pkg/obj"
 
@@ -24,6 +27,7 @@ import (
 	"golang.org/x/net/context"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
 )
 
@@ -40,6 +44,10 @@ type apiServer struct {
 	protorpclog.Logger
 	driver   *driver
 	reporter *metrics.Reporter
+	// downloadURL is the URL that clients reach pachd's HTTP server at, which
+	// the URLs that GetFileURL returns start with. If it's empty, pachd
+	// doesn't return download URLs.
+	downloadURL string
 }
 
 func newLocalAPIServer(address string, etcdPrefix string, reporter *metrics.Reporter) (*apiServer, error) {
@@ -54,15 +62,18 @@ func newLocalAPIServer(address string, etcdPrefix string, reporter *metrics.Repo
 	}, nil
 }
 
-func newAPIServer(address string, etcdAddresses []string, etcdPrefix string, cacheBytes int64, reporter *metrics.Reporter) (*apiServer, error) {
-	d, err := newDriver(address, etcdAddresses, etcdPrefix, cacheBytes)
+func newAPIServer(address string, etcdConfig etcd.Config, etcdPrefix string, cacheBytes int64, internalToken string, peerCreds credentials.TransportCredentials, reporter *metrics.Reporter, downloadURL string, cipher *col.Cipher) (*apiServer, error) {
+	d, err := newDriver(address, etcdConfig, etcdPrefix, cacheBytes, internalToken, peerCreds, cipher)
 	if err != nil {
 		return nil, err
 	}
+	go d.watchTriggers(context.Background())
+	go d.watchRetention(context.Background())
 	return &apiServer{
-		Logger:   protorpclog.NewLogger("pfs.API"),
-		driver:   d,
-		reporter: reporter,
+		Logger:      protorpclog.NewLogger("pfs.API"),
+		driver:      d,
+		reporter:    reporter,
+		downloadURL: downloadURL,
 	}, nil
 }
 
@@ -72,7 +83,7 @@ func (a *apiServer) CreateRepo(ctx context.Context, request *pfs.CreateRepoReque
 	metricsFn := metrics.ReportUserAction(ctx, a.reporter, "CreateRepo")
 	defer func(start time.Time) { metricsFn(start, retErr) }(time.Now())
 
-	if err := a.driver.createRepo(ctx, request.Repo, request.Provenance, request.Description); err != nil {
+	if err := a.driver.createRepo(ctx, request.Repo, request.Provenance, request.Description, request.Labels); err != nil {
 		return nil, err
 	}
 	return &types.Empty{}, nil
@@ -93,7 +104,7 @@ func (a *apiServer) ListRepo(ctx context.Context, request *pfs.ListRepoRequest)
 	metricsFn := metrics.ReportUserAction(ctx, a.reporter, "ListRepo")
 	defer func(start time.Time) { metricsFn(start, retErr) }(time.Now())
 
-	repoInfos, err := a.driver.listRepo(ctx, request.Provenance)
+	repoInfos, err := a.driver.listRepo(ctx, request.Provenance, request.LabelSelector)
 	return &pfs.RepoInfos{RepoInfo: repoInfos}, err
 }
 
@@ -110,13 +121,25 @@ func (a *apiServer) DeleteRepo(ctx context.Context, request *pfs.DeleteRepoReque
 	return &types.Empty{}, nil
 }
 
+func (a *apiServer) UpdateRepo(ctx context.Context, request *pfs.UpdateRepoRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	metricsFn := metrics.ReportUserAction(ctx, a.reporter, "UpdateRepo")
+	defer func(start time.Time) { metricsFn(start, retErr) }(time.Now())
+
+	if err := a.driver.updateRepo(ctx, request.Repo, request.Description, request.Labels, request.RemoveLabels); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
 func (a *apiServer) StartCommit(ctx context.Context, request *pfs.StartCommitRequest) (response *pfs.Commit, retErr error) {
 	func() { a.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
 	metricsFn := metrics.ReportUserAction(ctx, a.reporter, "StartCommit")
 	defer func(start time.Time) { metricsFn(start, retErr) }(time.Now())
 
-	commit, err := a.driver.startCommit(ctx, request.Parent, request.Branch, request.Provenance)
+	commit, err := a.driver.startCommit(ctx, request.Parent, request.Branch, request.Provenance, request.Force)
 	if err != nil {
 		return nil, err
 	}
@@ -142,12 +165,21 @@ func (a *apiServer) FinishCommit(ctx context.Context, request *pfs.FinishCommitR
 	metricsFn := metrics.ReportUserAction(ctx, a.reporter, "FinishCommit")
 	defer func(start time.Time) { metricsFn(start, retErr) }(time.Now())
 
-	if err := a.driver.finishCommit(ctx, request.Commit); err != nil {
+	if err := a.driver.finishCommit(ctx, request.Commit, request.Verify); err != nil {
 		return nil, err
 	}
 	return &types.Empty{}, nil
 }
 
+func (a *apiServer) FinishCommits(ctx context.Context, request *pfs.FinishCommitsRequest) (response *pfs.Transaction, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	metricsFn := metrics.ReportUserAction(ctx, a.reporter, "FinishCommits")
+	defer func(start time.Time) { metricsFn(start, retErr) }(time.Now())
+
+	return a.driver.finishCommits(ctx, request.Commits, request.DeleteFiles)
+}
+
 func (a *apiServer) InspectCommit(ctx context.Context, request *pfs.InspectCommitRequest) (response *pfs.CommitInfo, retErr error) {
 	func() { a.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
@@ -172,6 +204,18 @@ func (a *apiServer) ListCommit(ctx context.Context, request *pfs.ListCommitReque
 	}, nil
 }
 
+func (a *apiServer) ListCommitStream(request *pfs.ListCommitRequest, stream pfs.API_ListCommitStreamServer) (retErr error) {
+	ctx := stream.Context()
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, nil, retErr, time.Since(start)) }(time.Now())
+	metricsFn := metrics.ReportUserAction(ctx, a.reporter, "ListCommitStream")
+	defer func(start time.Time) { metricsFn(start, retErr) }(time.Now())
+
+	return a.driver.listCommitF(ctx, request.Repo, request.To, request.From, request.Number, func(commitInfo *pfs.CommitInfo) error {
+		return stream.Send(commitInfo)
+	})
+}
+
 func (a *apiServer) ListBranch(ctx context.Context, request *pfs.ListBranchRequest) (response *pfs.Branches, retErr error) {
 	func() { a.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
@@ -209,6 +253,30 @@ func (a *apiServer) DeleteBranch(ctx context.Context, request *pfs.DeleteBranchR
 	return &types.Empty{}, nil
 }
 
+func (a *apiServer) SetBranchTrigger(ctx context.Context, request *pfs.SetBranchTriggerRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	metricsFn := metrics.ReportUserAction(ctx, a.reporter, "SetBranchTrigger")
+	defer func(start time.Time) { metricsFn(start, retErr) }(time.Now())
+
+	if err := a.driver.setBranchTrigger(ctx, request.Repo, request.Branch, request.Trigger); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+func (a *apiServer) SetBranchRetention(ctx context.Context, request *pfs.SetBranchRetentionRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	metricsFn := metrics.ReportUserAction(ctx, a.reporter, "SetBranchRetention")
+	defer func(start time.Time) { metricsFn(start, retErr) }(time.Now())
+
+	if err := a.driver.setBranchRetention(ctx, request.Repo, request.Branch, request.Retention); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
 func (a *apiServer) DeleteCommit(ctx context.Context, request *pfs.DeleteCommitRequest) (response *types.Empty, retErr error) {
 	func() { a.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
@@ -250,6 +318,21 @@ func (a *apiServer) FlushCommit(request *pfs.FlushCommitRequest, stream pfs.API_
 	}
 }
 
+func (a *apiServer) FlushCommits(request *pfs.FlushCommitsRequest, stream pfs.API_FlushCommitsServer) (retErr error) {
+	ctx := stream.Context()
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, nil, retErr, time.Since(start)) }(time.Now())
+	metricsFn := metrics.ReportUserAction(ctx, a.reporter, "FlushCommits")
+	defer func(start time.Time) { metricsFn(start, retErr) }(time.Now())
+
+	return a.driver.flushCommits(ctx, request.Flushes, func(index int, commitInfo *pfs.CommitInfo) error {
+		return stream.Send(&pfs.FlushCommitsResponse{
+			Index:      int64(index),
+			CommitInfo: commitInfo,
+		})
+	})
+}
+
 func (a *apiServer) SubscribeCommit(request *pfs.SubscribeCommitRequest, stream pfs.API_SubscribeCommitServer) (retErr error) {
 	ctx := stream.Context()
 	func() { a.Log(request, nil, nil, 0) }()
@@ -357,6 +440,51 @@ func (a *apiServer) PutFile(putFileServer pfs.API_PutFileServer) (retErr error)
 	return nil
 }
 
+func (a *apiServer) PutFileBatch(putFileBatchServer pfs.API_PutFileBatchServer) (retErr error) {
+	ctx := putFileBatchServer.Context()
+	defer drainFileServer(putFileBatchServer)
+	defer func() {
+		if err := putFileBatchServer.SendAndClose(&types.Empty{}); err != nil && retErr == nil {
+			retErr = err
+		}
+	}()
+	func() { a.Log(nil, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(nil, nil, retErr, time.Since(start)) }(time.Now())
+	metricsFn := metrics.ReportUserAction(ctx, a.reporter, "PutFileBatch")
+	defer func(start time.Time) { metricsFn(start, retErr) }(time.Now())
+
+	request, err := putFileBatchServer.Recv()
+	if err != nil && err != io.EOF {
+		return err
+	}
+	if err == io.EOF {
+		return nil
+	}
+	for request != nil {
+		if request.File == nil {
+			return fmt.Errorf("no file specified in PutFileBatch request")
+		}
+		if request.Url != "" {
+			return fmt.Errorf("PutFileBatch does not support URLs, use PutFile instead")
+		}
+		request.File.Path = path.Clean(request.File.Path)
+		reader := &putFileBatchReader{
+			server: putFileBatchServer,
+		}
+		reader.buffer.Write(request.Value)
+		if err := a.driver.putFile(ctx, request.File, request.Delimiter, request.TargetFileDatums, request.TargetFileBytes, reader); err != nil {
+			return err
+		}
+		// make sure we've consumed all of this file's requests before moving
+		// on to the next one
+		if _, err := io.Copy(ioutil.Discard, reader); err != nil {
+			return err
+		}
+		request = reader.next
+	}
+	return nil
+}
+
 func (a *apiServer) putFilePfs(ctx context.Context, request *pfs.PutFileRequest, url *url.URL) error {
 	pClient, err := client.NewFromAddress(url.Host)
 	if err != nil {
@@ -458,6 +586,17 @@ func (a *apiServer) GetFile(request *pfs.GetFileRequest, apiGetFileServer pfs.AP
 	metricsFn := metrics.ReportUserAction(apiGetFileServer.Context(), a.reporter, "GetFile")
 	defer func(start time.Time) { metricsFn(start, retErr) }(time.Now())
 
+	if request.Parquet != nil {
+		if request.OffsetBytes != 0 || request.SizeBytes != 0 {
+			return fmt.Errorf("offset_bytes and size_bytes can't be set with parquet")
+		}
+		file, err := a.driver.getParquet(ctx, request.File, request.Parquet)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		return grpcutil.WriteToStreamingBytesServer(file, apiGetFileServer)
+	}
 	file, err := a.driver.getFile(ctx, request.File, request.OffsetBytes, request.SizeBytes)
 	if err != nil {
 		return err
@@ -465,6 +604,30 @@ func (a *apiServer) GetFile(request *pfs.GetFileRequest, apiGetFileServer pfs.AP
 	return grpcutil.WriteToStreamingBytesServer(file, apiGetFileServer)
 }
 
+func (a *apiServer) GetFiles(request *pfs.GetFilesRequest, stream pfs.API_GetFilesServer) (retErr error) {
+	ctx := stream.Context()
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, nil, retErr, time.Since(start)) }(time.Now())
+	metricsFn := metrics.ReportUserAction(ctx, a.reporter, "GetFiles")
+	defer func(start time.Time) { metricsFn(start, retErr) }(time.Now())
+
+	return a.driver.getFiles(ctx, request.Files, request.SizeLimitBytes, func(fileContents *pfs.FileContents) error {
+		return stream.Send(fileContents)
+	})
+}
+
+func (a *apiServer) GetFileRanges(request *pfs.GetFileRangesRequest, stream pfs.API_GetFileRangesServer) (retErr error) {
+	ctx := stream.Context()
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, nil, retErr, time.Since(start)) }(time.Now())
+	metricsFn := metrics.ReportUserAction(ctx, a.reporter, "GetFileRanges")
+	defer func(start time.Time) { metricsFn(start, retErr) }(time.Now())
+
+	return a.driver.getFileRanges(ctx, request.Ranges, int(request.Parallelism), func(chunk *pfs.FileRangeChunk) error {
+		return stream.Send(chunk)
+	})
+}
+
 func (a *apiServer) InspectFile(ctx context.Context, request *pfs.InspectFileRequest) (response *pfs.FileInfo, retErr error) {
 	func() { a.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
@@ -487,7 +650,7 @@ func (a *apiServer) ListFile(ctx context.Context, request *pfs.ListFileRequest)
 	metricsFn := metrics.ReportUserAction(ctx, a.reporter, "ListFile")
 	defer func(start time.Time) { metricsFn(start, retErr) }(time.Now())
 
-	fileInfos, err := a.driver.listFile(ctx, request.File)
+	fileInfos, err := a.driver.listFile(ctx, request.File, request.From, request.Number)
 	if err != nil {
 		return nil, err
 	}
@@ -496,6 +659,18 @@ func (a *apiServer) ListFile(ctx context.Context, request *pfs.ListFileRequest)
 	}, nil
 }
 
+func (a *apiServer) ListFileStream(request *pfs.ListFileRequest, stream pfs.API_ListFileStreamServer) (retErr error) {
+	ctx := stream.Context()
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, nil, retErr, time.Since(start)) }(time.Now())
+	metricsFn := metrics.ReportUserAction(ctx, a.reporter, "ListFileStream")
+	defer func(start time.Time) { metricsFn(start, retErr) }(time.Now())
+
+	return a.driver.listFileF(ctx, request.File, request.From, request.Number, func(fileInfo *pfs.FileInfo) error {
+		return stream.Send(fileInfo)
+	})
+}
+
 func (a *apiServer) GlobFile(ctx context.Context, request *pfs.GlobFileRequest) (response *pfs.FileInfos, retErr error) {
 	func() { a.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) {
@@ -518,6 +693,18 @@ func (a *apiServer) GlobFile(ctx context.Context, request *pfs.GlobFileRequest)
 	}, nil
 }
 
+func (a *apiServer) GlobFileStream(request *pfs.GlobFileRequest, stream pfs.API_GlobFileStreamServer) (retErr error) {
+	ctx := stream.Context()
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, nil, retErr, time.Since(start)) }(time.Now())
+	metricsFn := metrics.ReportUserAction(ctx, a.reporter, "GlobFileStream")
+	defer func(start time.Time) { metricsFn(start, retErr) }(time.Now())
+
+	return a.driver.globFileF(ctx, request.Commit, request.Pattern, func(fileInfo *pfs.FileInfo) error {
+		return stream.Send(fileInfo)
+	})
+}
+
 func (a *apiServer) DeleteFile(ctx context.Context, request *pfs.DeleteFileRequest) (response *types.Empty, retErr error) {
 	func() { a.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
@@ -531,6 +718,18 @@ func (a *apiServer) DeleteFile(ctx context.Context, request *pfs.DeleteFileReque
 	return &types.Empty{}, nil
 }
 
+func (a *apiServer) DeleteFiles(ctx context.Context, request *pfs.DeleteFilesRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	metricsFn := metrics.ReportUserAction(ctx, a.reporter, "DeleteFiles")
+	defer func(start time.Time) { metricsFn(start, retErr) }(time.Now())
+
+	if err := a.driver.deleteFiles(ctx, request.Commit, request.Paths, request.Pattern); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
 func (a *apiServer) DeleteAll(ctx context.Context, request *types.Empty) (response *types.Empty, retErr error) {
 	func() { a.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
@@ -560,6 +759,40 @@ func (r *putFileReader) Read(p []byte) (int, error) {
 	return r.buffer.Read(p)
 }
 
+// putFileBatchReader reads the contents of a single file out of a
+// PutFileBatch stream. It returns io.EOF when it encounters the first request
+// of the next file, which it stores in next.
+type putFileBatchReader struct {
+	server pfs.API_PutFileBatchServer
+	buffer bytes.Buffer
+	next   *pfs.PutFileRequest
+	done   bool
+}
+
+func (r *putFileBatchReader) Read(p []byte) (int, error) {
+	for r.buffer.Len() == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		request, err := r.server.Recv()
+		if err != nil {
+			if err == io.EOF {
+				r.done = true
+				continue
+			}
+			return 0, err
+		}
+		if request.File != nil {
+			r.next = request
+			r.done = true
+			continue
+		}
+		//buffer.Write cannot error
+		r.buffer.Write(request.Value)
+	}
+	return r.buffer.Read(p)
+}
+
 func (a *apiServer) getVersion(ctx context.Context) (int64, error) {
 	md, ok := metadata.FromContext(ctx)
 	if !ok {
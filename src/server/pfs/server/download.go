@@ -0,0 +1,233 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	etcd "github.com/coreos/etcd/clientv3"
+	"github.com/gogo/protobuf/types"
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/server/pkg/metrics"
+
+	protolion "go.pedge.io/lion/proto"
+	"golang.org/x/net/context"
+)
+
+const (
+	// DownloadPrefix is the path under which pachd serves the files that
+	// GetFileURL returns URLs for.
+	DownloadPrefix = "/download/"
+	// downloadKey is the etcd key of the key that download URLs are signed
+	// with. It's shared by every pachd, so that any of them can serve a URL
+	// that another one signed. Deleting it invalidates every URL.
+	downloadKey = "/download_key"
+	// defaultFileURLTTL is how long a download URL is valid for, if the
+	// request doesn't say.
+	defaultFileURLTTL = time.Hour
+	// maxFileURLTTL is the longest a download URL can be valid for.
+	maxFileURLTTL = 7 * 24 * time.Hour
+)
+
+func (a *apiServer) GetFileURL(ctx context.Context, request *pfs.GetFileURLRequest) (response *pfs.GetFileURLResponse, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	metricsFn := metrics.ReportUserAction(ctx, a.reporter, "GetFileURL")
+	defer func(start time.Time) { metricsFn(start, retErr) }(time.Now())
+
+	if a.downloadURL == "" {
+		return nil, fmt.Errorf("pachd doesn't serve download URLs; deploy it with --download-url to enable them")
+	}
+	if request.File == nil || request.File.Commit == nil || request.File.Commit.Repo == nil {
+		return nil, fmt.Errorf("file must be set")
+	}
+	ttl := defaultFileURLTTL
+	if request.Ttl != nil {
+		var err error
+		if ttl, err = types.DurationFromProto(request.Ttl); err != nil {
+			return nil, err
+		}
+		if ttl <= 0 || ttl > maxFileURLTTL {
+			return nil, fmt.Errorf("ttl must be positive and at most %v, got %v", maxFileURLTTL, ttl)
+		}
+	}
+	// the URL is for the commit that the branch points to now, so that it
+	// keeps returning the same content when the branch moves
+	commitInfo, err := a.driver.inspectCommit(ctx, request.File.Commit)
+	if err != nil {
+		return nil, err
+	}
+	if commitInfo.Finished == nil {
+		return nil, fmt.Errorf("commit %s is not finished", commitInfo.Commit.ID)
+	}
+	file := client.NewFile(commitInfo.Commit.Repo.Name, commitInfo.Commit.ID, request.File.Path)
+	fileInfo, err := a.driver.inspectFile(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	if fileInfo.FileType != pfs.FileType_FILE {
+		return nil, fmt.Errorf("%s is a directory", file.Path)
+	}
+	key, err := a.driver.downloadKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	expires := time.Now().Add(ttl).Truncate(time.Second)
+	expiresProto, err := types.TimestampProto(expires)
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires.Unix(), 10))
+	query.Set("signature", signDownload(key, file, expires.Unix()))
+	return &pfs.GetFileURLResponse{
+		Url:     strings.TrimSuffix(a.downloadURL, "/") + downloadPath(file) + "?" + query.Encode(),
+		Expires: expiresProto,
+	}, nil
+}
+
+// DownloadHandler returns the handler that serves the URLs returned by
+// GetFileURL. It's mounted at DownloadPrefix.
+func (a *apiServer) DownloadHandler() http.Handler {
+	return http.HandlerFunc(a.serveDownload)
+}
+
+func (a *apiServer) serveDownload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	// paths are of the form /download/<repo>/<commit>/<path>
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, DownloadPrefix), "/", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		http.NotFound(w, r)
+		return
+	}
+	file := client.NewFile(parts[0], parts[1], "/"+parts[2])
+	expires, err := strconv.ParseInt(r.URL.Query().Get("expires"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid expires", http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	key, err := a.driver.downloadKey(ctx)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// the signature is checked before the expiry, so that the response
+	// doesn't say whether a forged URL would have expired
+	signature := signDownload(key, file, expires)
+	if !hmac.Equal([]byte(signature), []byte(r.URL.Query().Get("signature"))) {
+		http.Error(w, "invalid signature", http.StatusForbidden)
+		return
+	}
+	if time.Now().Unix() > expires {
+		http.Error(w, "URL has expired", http.StatusForbidden)
+		return
+	}
+	fileInfo, err := a.driver.inspectFile(ctx, file)
+	if err != nil {
+		if isNotFoundErr(err) {
+			http.NotFound(w, r)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if fileInfo.FileType != pfs.FileType_FILE {
+		http.NotFound(w, r)
+		return
+	}
+	contentType := mime.TypeByExtension(path.Ext(file.Path))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.FormatUint(fileInfo.SizeBytes, 10))
+	// a commit's files never change, so the file can be cached until the
+	// URL expires
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", expires-time.Now().Unix()))
+	if r.Method == "HEAD" {
+		return
+	}
+	reader, err := a.driver.getFile(ctx, file, 0, 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if _, err := io.Copy(w, reader); err != nil {
+		// the headers have already been sent, so all that can be done is
+		// to log the error, the client sees a short response
+		protolion.Errorf("error serving %s@%s:%s: %v", file.Commit.Repo.Name, file.Commit.ID, file.Path, err)
+	}
+}
+
+// downloadPath returns the path, under DownloadPrefix, that file is served
+// at.
+func downloadPath(file *pfs.File) string {
+	p := (&url.URL{Path: strings.TrimPrefix(file.Path, "/")}).EscapedPath()
+	return DownloadPrefix + url.PathEscape(file.Commit.Repo.Name) + "/" + url.PathEscape(file.Commit.ID) + "/" + p
+}
+
+// signDownload returns the signature of a URL that serves file until
+// expires, a unix time.
+func signDownload(key []byte, file *pfs.File, expires int64) string {
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%d", file.Commit.Repo.Name, file.Commit.ID, path.Join("/", file.Path), expires)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// downloadKey returns the key that download URLs are signed with, creating
+// it if it doesn't exist yet. It's encrypted with d.cipher in etcd, if it's
+// set; a key that was created before then is encrypted when it's next read.
+func (d *driver) downloadKey(ctx context.Context) ([]byte, error) {
+	k := path.Join(d.prefix, downloadKey)
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	// only create the key if no pachd has yet, otherwise read theirs
+	resp, err := d.etcdClient.Txn(ctx).
+		If(etcd.Compare(etcd.CreateRevision(k), "=", 0)).
+		Then(etcd.OpPut(k, d.cipher.Encrypt(string(key)))).
+		Else(etcd.OpGet(k)).
+		Commit()
+	if err != nil {
+		return nil, err
+	}
+	if resp.Succeeded {
+		return key, nil
+	}
+	kvs := resp.Responses[0].GetResponseRange().Kvs
+	if len(kvs) == 0 {
+		return nil, fmt.Errorf("the key that download URLs are signed with was deleted, try again")
+	}
+	stored := string(kvs[0].Value)
+	value, err := d.cipher.Decrypt(stored)
+	if err != nil {
+		return nil, err
+	}
+	// a key that was created in plaintext is encrypted now, unless another
+	// pachd has already
+	if d.cipher != nil && value == stored {
+		if _, err := d.etcdClient.Txn(ctx).
+			If(etcd.Compare(etcd.ModRevision(k), "=", kvs[0].ModRevision)).
+			Then(etcd.OpPut(k, d.cipher.Encrypt(value))).
+			Commit(); err != nil {
+			return nil, err
+		}
+	}
+	return []byte(value), nil
+}
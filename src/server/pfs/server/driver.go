@@ -7,30 +7,38 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"math"
 	"path"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
-	"time"
+	"sync/atomic"
 
 	"golang.org/x/sync/errgroup"
 
 	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/limit"
 	"github.com/pachyderm/pachyderm/src/client/pfs"
 	"github.com/pachyderm/pachyderm/src/client/pkg/grpcutil"
 	"github.com/pachyderm/pachyderm/src/client/pkg/uuid"
+	authserver "github.com/pachyderm/pachyderm/src/server/auth/server"
 	pfsserver "github.com/pachyderm/pachyderm/src/server/pfs"
+	"github.com/pachyderm/pachyderm/src/server/pkg/clock"
 	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
 	"github.com/pachyderm/pachyderm/src/server/pkg/hashtree"
+	"github.com/pachyderm/pachyderm/src/server/pkg/parquet"
 	"github.com/pachyderm/pachyderm/src/server/pkg/watch"
 
 	etcd "github.com/coreos/etcd/clientv3"
 	"github.com/gogo/protobuf/proto"
-	"github.com/gogo/protobuf/types"
 	"github.com/hashicorp/golang-lru"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	kube_labels "k8s.io/kubernetes/pkg/labels"
+	"k8s.io/kubernetes/pkg/util/validation"
 )
 
 const (
@@ -39,6 +47,16 @@ const (
 	splitSuffixFmt   = "%016x"
 )
 
+const (
+	// putFileBlockSize is the size of the blocks that PutFile splits files
+	// into, each of which is uploaded as a separate object.
+	putFileBlockSize = 8 * 1024 * 1024
+	// putFileConcurrency is the most blocks (or, with a delimiter, split
+	// files) that each PutFile uploads at once, which bounds the memory it
+	// uses to putFileConcurrency * putFileBlockSize.
+	putFileConcurrency = 8
+)
+
 // ValidateRepoName determines if a repo name is valid
 func ValidateRepoName(name string) error {
 	match, _ := regexp.MatchString("^[a-zA-Z0-9_-]+$", name)
@@ -50,6 +68,20 @@ func ValidateRepoName(name string) error {
 	return nil
 }
 
+// validateRepoLabels checks that labels' keys and values are valid
+// kubernetes label keys and values, so that selectors can select by them.
+func validateRepoLabels(labels map[string]string) error {
+	for key, value := range labels {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("invalid label key %q: %s", key, strings.Join(errs, "; "))
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return fmt.Errorf("invalid value %q for label %s: %s", value, key, strings.Join(errs, "; "))
+		}
+	}
+	return nil
+}
+
 // ListFileMode specifies how ListFile executes.
 type ListFileMode int
 
@@ -82,17 +114,30 @@ type CommitStream interface {
 type collectionFactory func(string) col.Collection
 
 type driver struct {
-	address      string
+	address string
+	// internalToken is sent with the driver's requests to pachd, see
+	// authserver.InternalToken
+	internalToken string
+	// peerCreds secure the driver's connection to pachd if it serves TLS,
+	// see grpcutil.LoadTLS
+	peerCreds    credentials.TransportCredentials
 	pachConnOnce sync.Once
 	pachConn     *grpc.ClientConn
 	etcdClient   *etcd.Client
 	prefix       string
+	// cipher encrypts the key that download URLs are signed with, it's nil
+	// if it's stored in plaintext
+	cipher *col.Cipher
 
 	// collections
 	repos         col.Collection
 	repoRefCounts col.Collection
 	commits       collectionFactory
 	branches      collectionFactory
+	// triggers are the branches' triggers, keyed by the triggered branch
+	triggers collectionFactory
+	// retentions are the branches' retention policies, keyed by branch
+	retentions collectionFactory
 
 	// a cache for commit IDs that we know exist
 	commitCache *lru.Cache
@@ -102,6 +147,10 @@ type driver struct {
 
 const (
 	tombstone = "delete"
+	// deleteFilesKey is the path in a commit's scratch space under which
+	// DeleteFilesRecords are stored. File paths can't contain null
+	// characters, so it can't collide with a real file.
+	deleteFilesKey = "\x00deletefiles"
 )
 
 // Instead of making the user specify the respective size for each cache,
@@ -122,6 +171,8 @@ const (
 	repoRefCountsPrefix = "/repoRefCounts"
 	commitsPrefix       = "/commits"
 	branchesPrefix      = "/branches"
+	triggersPrefix      = "/triggers"
+	retentionsPrefix    = "/retentions"
 )
 
 var (
@@ -132,11 +183,8 @@ var (
 )
 
 // newDriver is used to create a new Driver instance
-func newDriver(address string, etcdAddresses []string, etcdPrefix string, cacheBytes int64) (*driver, error) {
-	etcdClient, err := etcd.New(etcd.Config{
-		Endpoints:   etcdAddresses,
-		DialOptions: client.EtcdDialOptions(),
-	})
+func newDriver(address string, etcdConfig etcd.Config, etcdPrefix string, cacheBytes int64, internalToken string, peerCreds credentials.TransportCredentials, cipher *col.Cipher) (*driver, error) {
+	etcdClient, err := etcd.New(etcdConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -151,15 +199,18 @@ func newDriver(address string, etcdAddresses []string, etcdPrefix string, cacheB
 	}
 
 	return &driver{
-		address:    address,
-		etcdClient: etcdClient,
-		prefix:     etcdPrefix,
-		repos: col.NewCollection(
+		address:       address,
+		internalToken: internalToken,
+		peerCreds:     peerCreds,
+		etcdClient:    etcdClient,
+		prefix:        etcdPrefix,
+		cipher:        cipher,
+		repos: col.WithErrors(col.NewCollection(
 			etcdClient,
 			path.Join(etcdPrefix, reposPrefix),
 			[]col.Index{provenanceIndex},
 			&pfs.RepoInfo{},
-		),
+		), client.ErrRepoNotFound, client.ErrRepoExists),
 		repoRefCounts: col.NewCollection(
 			etcdClient,
 			path.Join(etcdPrefix, repoRefCountsPrefix),
@@ -167,19 +218,35 @@ func newDriver(address string, etcdAddresses []string, etcdPrefix string, cacheB
 			nil,
 		),
 		commits: func(repo string) col.Collection {
-			return col.NewCollection(
+			return col.WithErrors(col.NewCollection(
 				etcdClient,
 				path.Join(etcdPrefix, commitsPrefix, repo),
 				[]col.Index{provenanceIndex},
 				&pfs.CommitInfo{},
-			)
+			), client.ErrCommitNotFound, nil)
 		},
 		branches: func(repo string) col.Collection {
-			return col.NewCollection(
+			return col.WithErrors(col.NewCollection(
 				etcdClient,
 				path.Join(etcdPrefix, branchesPrefix, repo),
 				nil,
 				&pfs.Commit{},
+			), client.ErrCommitNotFound, nil)
+		},
+		triggers: func(repo string) col.Collection {
+			return col.NewCollection(
+				etcdClient,
+				path.Join(etcdPrefix, triggersPrefix, repo),
+				nil,
+				&pfs.Trigger{},
+			)
+		},
+		retentions: func(repo string) col.Collection {
+			return col.NewCollection(
+				etcdClient,
+				path.Join(etcdPrefix, retentionsPrefix, repo),
+				nil,
+				&pfs.RetentionPolicy{},
 			)
 		},
 		commitCache: commitCache,
@@ -190,14 +257,17 @@ func newDriver(address string, etcdAddresses []string, etcdPrefix string, cacheB
 // newLocalDriver creates a driver using an local etcd instance.  This
 // function is intended for testing purposes
 func newLocalDriver(blockAddress string, etcdPrefix string) (*driver, error) {
-	return newDriver(blockAddress, []string{"localhost:32379"}, etcdPrefix, defaultCacheSize)
+	return newDriver(blockAddress, etcd.Config{
+		Endpoints:   []string{"localhost:32379"},
+		DialOptions: client.EtcdDialOptions(),
+	}, etcdPrefix, defaultCacheSize, "", nil, nil)
 }
 
 func (d *driver) getObjectClient() (*client.APIClient, error) {
 	if d.pachConn == nil {
 		var onceErr error
 		d.pachConnOnce.Do(func() {
-			pachConn, err := grpc.Dial(d.address, client.PachDialOptions()...)
+			pachConn, err := grpc.Dial(d.address, append(client.PeerDialOptions(d.peerCreds), client.AuthTokenDialOptions(d.internalToken)...)...)
 			if err != nil {
 				onceErr = err
 			}
@@ -210,14 +280,6 @@ func (d *driver) getObjectClient() (*client.APIClient, error) {
 	return &client.APIClient{ObjectAPIClient: pfs.NewObjectAPIClient(d.pachConn)}, nil
 }
 
-func now() *types.Timestamp {
-	t, err := types.TimestampProto(time.Now())
-	if err != nil {
-		panic(err)
-	}
-	return t
-}
-
 func present(key string) etcd.Cmp {
 	return etcd.Compare(etcd.CreateRevision(key), ">", 0)
 }
@@ -226,10 +288,13 @@ func absent(key string) etcd.Cmp {
 	return etcd.Compare(etcd.CreateRevision(key), "=", 0)
 }
 
-func (d *driver) createRepo(ctx context.Context, repo *pfs.Repo, provenance []*pfs.Repo, description string) error {
+func (d *driver) createRepo(ctx context.Context, repo *pfs.Repo, provenance []*pfs.Repo, description string, labels map[string]string) error {
 	if err := ValidateRepoName(repo.Name); err != nil {
 		return err
 	}
+	if err := validateRepoLabels(labels); err != nil {
+		return err
+	}
 
 	_, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
 		repos := d.repos.ReadWrite(stm)
@@ -263,9 +328,10 @@ func (d *driver) createRepo(ctx context.Context, repo *pfs.Repo, provenance []*p
 
 		repoInfo := &pfs.RepoInfo{
 			Repo:        repo,
-			Created:     now(),
+			Created:     clock.Now(),
 			Provenance:  fullProvRepos,
 			Description: description,
+			Labels:      labels,
 		}
 		return repos.Create(repo.Name, repoInfo)
 	})
@@ -280,7 +346,13 @@ func (d *driver) inspectRepo(ctx context.Context, repo *pfs.Repo) (*pfs.RepoInfo
 	return repoInfo, nil
 }
 
-func (d *driver) listRepo(ctx context.Context, provenance []*pfs.Repo) ([]*pfs.RepoInfo, error) {
+// listRepo lists the repos that have all of provenance as provenance and
+// whose labels match labelSelector, a kubernetes label selector.
+func (d *driver) listRepo(ctx context.Context, provenance []*pfs.Repo, labelSelector string) ([]*pfs.RepoInfo, error) {
+	selector, err := kube_labels.Parse(labelSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid label selector %q: %v", labelSelector, err)
+	}
 	var result []*pfs.RepoInfo
 	repos := d.repos.ReadOnly(ctx)
 	// Ensure that all provenance repos exist
@@ -318,6 +390,9 @@ nextRepo:
 				continue nextRepo
 			}
 		}
+		if !selector.Matches(kube_labels.Set(repoInfo.Labels)) {
+			continue
+		}
 		result = append(result, repoInfo)
 	}
 	return result, nil
@@ -329,6 +404,8 @@ func (d *driver) deleteRepo(ctx context.Context, repo *pfs.Repo, force bool) err
 		repoRefCounts := d.repoRefCounts.ReadWriteInt(stm)
 		commits := d.commits(repo.Name).ReadWrite(stm)
 		branches := d.branches(repo.Name).ReadWrite(stm)
+		triggers := d.triggers(repo.Name).ReadWrite(stm)
+		retentions := d.retentions(repo.Name).ReadWrite(stm)
 
 		// Check if this repo is the provenance of some other repos
 		if !force {
@@ -363,15 +440,78 @@ func (d *driver) deleteRepo(ctx context.Context, repo *pfs.Repo, force bool) err
 		}
 		commits.DeleteAll()
 		branches.DeleteAll()
+		triggers.DeleteAll()
+		retentions.DeleteAll()
+		return nil
+	})
+	return err
+}
+
+// updateRepo sets repo's description, if description is set, sets labels
+// on it and removes the labels in removeLabels from it.
+func (d *driver) updateRepo(ctx context.Context, repo *pfs.Repo, description string, labels map[string]string, removeLabels []string) error {
+	if err := validateRepoLabels(labels); err != nil {
+		return err
+	}
+	for _, key := range removeLabels {
+		if _, ok := labels[key]; ok {
+			return fmt.Errorf("label %s can't be both set and removed", key)
+		}
+	}
+	_, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		repos := d.repos.ReadWrite(stm)
+		repoInfo := new(pfs.RepoInfo)
+		if err := repos.Get(repo.Name, repoInfo); err != nil {
+			return err
+		}
+		if description != "" {
+			repoInfo.Description = description
+		}
+		for key, value := range labels {
+			if repoInfo.Labels == nil {
+				repoInfo.Labels = make(map[string]string)
+			}
+			repoInfo.Labels[key] = value
+		}
+		for _, key := range removeLabels {
+			delete(repoInfo.Labels, key)
+		}
+		repos.Put(repo.Name, repoInfo)
 		return nil
 	})
 	return err
 }
 
-func (d *driver) startCommit(ctx context.Context, parent *pfs.Commit, branch string, provenance []*pfs.Commit) (*pfs.Commit, error) {
+// startCommit starts a commit. Commits can't be started in output repos,
+// i.e. repos with provenance, which are written by the pipelines and jobs
+// that created them, without provenance, unless force is set: the manual
+// commit would be in the output's provenance as if a job had made it.
+func (d *driver) startCommit(ctx context.Context, parent *pfs.Commit, branch string, provenance []*pfs.Commit, force bool) (*pfs.Commit, error) {
+	if parent == nil {
+		return nil, fmt.Errorf("parent cannot be nil")
+	}
+	if len(provenance) == 0 && !force {
+		if err := d.checkNotOutputRepo(ctx, parent.Repo); err != nil {
+			return nil, err
+		}
+	}
 	return d.makeCommit(ctx, parent, branch, provenance, nil)
 }
 
+// checkNotOutputRepo returns an error if repo is an output repo, which
+// can't be written to manually. A repo's provenance is set when it's
+// created, so it can be checked outside of the transaction that writes it.
+func (d *driver) checkNotOutputRepo(ctx context.Context, repo *pfs.Repo) error {
+	repoInfo, err := d.inspectRepo(ctx, repo)
+	if err != nil {
+		return err
+	}
+	if len(repoInfo.Provenance) > 0 {
+		return fmt.Errorf("repo %s is an output repo, which is written by its pipeline; pass force to start a commit in it anyway", repo.Name)
+	}
+	return nil
+}
+
 func (d *driver) buildCommit(ctx context.Context, parent *pfs.Commit, branch string, provenance []*pfs.Commit, tree *pfs.Object) (*pfs.Commit, error) {
 	return d.makeCommit(ctx, parent, branch, provenance, tree)
 }
@@ -385,16 +525,14 @@ func (d *driver) makeCommit(ctx context.Context, parent *pfs.Commit, branch stri
 		ID:   uuid.NewWithoutDashes(),
 	}
 	var commitSize uint64
+	var tree hashtree.HashTree
 	if treeRef != nil {
 		objClient, err := d.getObjectClient()
 		if err != nil {
 			return nil, err
 		}
-		var buf bytes.Buffer
-		if err := objClient.GetObject(treeRef.Hash, &buf); err != nil {
-			return nil, err
-		}
-		tree, err := hashtree.Deserialize(buf.Bytes())
+		// only the tree's root chunk is read, which has its size
+		tree, err = hashtree.Load(hashtree.NewObjectStore(objClient), treeRef)
 		if err != nil {
 			return nil, err
 		}
@@ -413,7 +551,8 @@ func (d *driver) makeCommit(ctx context.Context, parent *pfs.Commit, branch stri
 
 		commitInfo := &pfs.CommitInfo{
 			Commit:  commit,
-			Started: now(),
+			Started: clock.Now(),
+			Author:  authserver.Subject(ctx),
 		}
 
 		// Use a map to de-dup provenance
@@ -439,37 +578,74 @@ func (d *driver) makeCommit(ctx context.Context, parent *pfs.Commit, branch stri
 			commitInfo.Provenance = append(commitInfo.Provenance, c)
 		}
 
+		// The parent and the branch's head are read in the transaction, and
+		// parent isn't modified, so that if a concurrent commit on the branch
+		// makes this transaction retry, the retry sees that commit.
+		parentID := parent.ID
+		if parentID != "" {
+			// the parent may be given as a branch
+			head := new(pfs.Commit)
+			if err := branches.Get(parentID, head); err != nil {
+				if _, ok := err.(col.ErrNotFound); !ok {
+					return err
+				}
+			} else {
+				parentID = head.ID
+			}
+		}
 		if branch != "" {
-			// If we don't have an explicit parent we use the previous head of
-			// branch as the parent, if it exists.
-			if parent.ID == "" {
-				head := new(pfs.Commit)
-				if err := branches.Get(branch, head); err != nil {
-					if _, ok := err.(col.ErrNotFound); !ok {
-						return err
-					}
-				} else {
-					parent.ID = head.ID
+			head := new(pfs.Commit)
+			if err := branches.Get(branch, head); err != nil {
+				if _, ok := err.(col.ErrNotFound); !ok {
+					return err
+				}
+			} else {
+				headInfo := new(pfs.CommitInfo)
+				if err := commits.Get(head.ID, headInfo); err != nil {
+					return err
+				}
+				// A branch has at most one open commit, its head. Another
+				// commit started on it would either orphan that commit's
+				// writes, or be unable to build on them.
+				if headInfo.Finished == nil {
+					return client.Errorf(client.ErrCommitConflict, "branch %v in repo %v has an open commit %v, which must be finished before another commit can be started on it", branch, parent.Repo.Name, head.ID)
+				}
+				// If we don't have an explicit parent we use the previous
+				// head of branch as the parent.
+				if parentID == "" {
+					parentID = head.ID
 				}
 			}
 			// Make commit the new head of the branch
 			branches.Put(branch, commit)
 		}
-		if parent.ID != "" {
-			parentCommitInfo, err := d.inspectCommit(ctx, parent)
-			if err != nil {
+		if parentID != "" {
+			parentCommitInfo := new(pfs.CommitInfo)
+			if err := commits.Get(parentID, parentCommitInfo); err != nil {
 				return err
 			}
 			// fail if the parent commit has not been finished
 			if parentCommitInfo.Finished == nil {
-				return fmt.Errorf("parent commit %s has not been finished", parent.ID)
+				return fmt.Errorf("parent commit %s has not been finished", parentID)
 			}
-			commitInfo.ParentCommit = parent
+			commitInfo.ParentCommit = &pfs.Commit{Repo: parent.Repo, ID: parentID}
+			// the parent may have been finished by another pachd, whose
+			// clock is ahead of this one's
+			commitInfo.Started = clock.After(parentCommitInfo.Finished)
 		}
 		if treeRef != nil {
+			// the parent is finished, so its tree doesn't change if the
+			// transaction is retried, and it's cached after the first try
+			parentTree, err := d.getTreeForCommit(ctx, commitInfo.ParentCommit)
+			if err != nil {
+				return err
+			}
+			if commitInfo.Stats, err = commitStats(tree, parentTree); err != nil {
+				return err
+			}
 			commitInfo.Tree = treeRef
 			commitInfo.SizeBytes = commitSize
-			commitInfo.Finished = now()
+			commitInfo.Finished = clock.After(commitInfo.Started)
 			repoInfo.SizeBytes += commitSize
 			repos.Put(parent.Repo.Name, repoInfo)
 		}
@@ -477,37 +653,179 @@ func (d *driver) makeCommit(ctx context.Context, parent *pfs.Commit, branch stri
 	}); err != nil {
 		return nil, err
 	}
+	if treeRef != nil {
+		d.fireTriggersAfter(ctx, parent.Repo)
+	}
 
 	return commit, nil
 }
 
-func (d *driver) finishCommit(ctx context.Context, commit *pfs.Commit) error {
+// finishCommit finishes commit. If verify is set, the tree that it's
+// finished with is checked first, see verifyTree.
+func (d *driver) finishCommit(ctx context.Context, commit *pfs.Commit, verify bool) error {
 	commitInfo, err := d.inspectCommit(ctx, commit)
 	if err != nil {
 		return err
 	}
+	touched, err := d.buildTree(ctx, commitInfo)
+	if err != nil {
+		return err
+	}
+	if verify {
+		if err := d.verifyTree(ctx, commitInfo, touched); err != nil {
+			return fmt.Errorf("commit %s failed verification, so it's still open: %v", commit.FullID(), err)
+		}
+	}
+	commitInfo.Finished = clock.After(commitInfo.Started)
+
+	if _, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		return d.putFinishedCommit(stm, commitInfo)
+	}); err != nil {
+		return err
+	}
+	d.fireTriggersAfter(ctx, commit.Repo)
+	return nil
+}
+
+// finishCommits finishes commits in a single etcd transaction, so that
+// either all of them are finished or none are, and marks each of them with
+// the pfs.Transaction that it was finished in. deleteFiles, which must be in
+// the commits, are deleted from them first, and only if they're finished.
+func (d *driver) finishCommits(ctx context.Context, commits []*pfs.Commit, deleteFiles []*pfs.File) (_ *pfs.Transaction, retErr error) {
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("no commits to finish")
+	}
+	transaction := &pfs.Transaction{ID: uuid.NewWithoutDashes()}
+	var commitInfos []*pfs.CommitInfo
+	seen := make(map[string]bool)
+	for _, commit := range commits {
+		if commit == nil || commit.Repo == nil {
+			return nil, fmt.Errorf("commit must be set")
+		}
+		commitInfo, err := d.inspectCommit(ctx, commit)
+		if err != nil {
+			return nil, err
+		}
+		if seen[commitInfo.Commit.FullID()] {
+			return nil, fmt.Errorf("commit %s is finished more than once", commitInfo.Commit.FullID())
+		}
+		seen[commitInfo.Commit.FullID()] = true
+		commitInfos = append(commitInfos, commitInfo)
+		branches, err := d.listBranch(ctx, commitInfo.Commit.Repo)
+		if err != nil {
+			return nil, err
+		}
+		for _, branch := range branches {
+			if branch.Head.ID == commitInfo.Commit.ID {
+				transaction.Branches = append(transaction.Branches, &pfs.Branch{
+					Name: branch.Name,
+					Head: commitInfo.Commit,
+				})
+			}
+		}
+	}
+	// the files are deleted with tombstones, as by DeleteFile, which are
+	// removed again if the commits aren't finished
+	var tombstones []string
+	defer func() {
+		if retErr == nil {
+			return
+		}
+		for _, key := range tombstones {
+			if _, err := d.etcdClient.Delete(context.Background(), key); err != nil {
+				retErr = fmt.Errorf("%v (and deleted files couldn't be restored: %v)", retErr, err)
+				return
+			}
+		}
+	}()
+	for _, file := range deleteFiles {
+		if file == nil || file.Commit == nil || file.Commit.Repo == nil {
+			return nil, fmt.Errorf("file must be set")
+		}
+		if err := checkPath(file.Path); err != nil {
+			return nil, err
+		}
+		commitInfo, err := d.inspectCommit(ctx, file.Commit)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[commitInfo.Commit.FullID()] {
+			return nil, fmt.Errorf("file %s is deleted from commit %s, which isn't being finished", file.Path, commitInfo.Commit.FullID())
+		}
+		prefix, err := d.scratchFilePrefix(ctx, &pfs.File{Commit: commitInfo.Commit, Path: file.Path})
+		if err != nil {
+			return nil, err
+		}
+		key := path.Join(prefix, uuid.NewWithoutDashes())
+		if _, err := d.etcdClient.Put(ctx, key, tombstone); err != nil {
+			return nil, err
+		}
+		tombstones = append(tombstones, key)
+	}
+	// the trees are built before anything is written, since building them is
+	// what's most likely to fail
+	for _, commitInfo := range commitInfos {
+		if _, err := d.buildTree(ctx, commitInfo); err != nil {
+			return nil, err
+		}
+	}
+	for _, commitInfo := range commitInfos {
+		clock.Observe(commitInfo.Started)
+	}
+	finished := clock.Now()
+	if _, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		for _, commitInfo := range commitInfos {
+			commitInfo.Finished = finished
+			commitInfo.Transaction = transaction
+			if err := d.putFinishedCommit(stm, commitInfo); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	fired := make(map[string]bool)
+	for _, commitInfo := range commitInfos {
+		if repo := commitInfo.Commit.Repo; !fired[repo.Name] {
+			d.fireTriggersAfter(ctx, repo)
+			fired[repo.Name] = true
+		}
+	}
+	return transaction, nil
+}
 
+// buildTree builds the tree of the open commit commitInfo from its parent's
+// tree and the changes in its scratch space, stores it in the object store
+// and sets commitInfo's Tree, SizeBytes and Stats. It returns the paths that
+// the changes touched: the files that were put, and the parents of those that
+// were deleted.
+func (d *driver) buildTree(ctx context.Context, commitInfo *pfs.CommitInfo) ([]string, error) {
+	commit := commitInfo.Commit
 	prefix, err := d.scratchCommitPrefix(ctx, commit)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Read everything under the scratch space for this commit
 	resp, err := d.etcdClient.Get(ctx, prefix, etcd.WithPrefix(), etcd.WithSort(etcd.SortByModRevision, etcd.SortAscend))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if commitInfo.Finished != nil {
-		return fmt.Errorf("commit %s has already been finished", commit.FullID())
+		return nil, fmt.Errorf("commit %s has already been finished", commit.FullID())
 	}
 
+	// only the chunks of the parent's tree that the commit touches are
+	// loaded and stored again, see hashtree.Store
 	_tree, err := d.getTreeForCommit(ctx, commitInfo.ParentCommit)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	tree := _tree.Open()
 
+	var touched []string
 	for _, kv := range resp.Kvs {
 		// fileStr is going to look like "some/path/UUID"
 		fileStr := strings.TrimPrefix(string(kv.Key), prefix)
@@ -516,44 +834,70 @@ func (d *driver) finishCommit(ctx context.Context, commit *pfs.Commit) error {
 		// filePath should look like "some/path"
 		filePath := strings.Join(parts[:len(parts)-1], "/")
 
-		if string(kv.Value) == tombstone {
+		if filePath == path.Join("/", deleteFilesKey) {
+			record := &DeleteFilesRecord{}
+			if err := proto.Unmarshal(kv.Value, record); err != nil {
+				return nil, err
+			}
+			for _, p := range record.Paths {
+				touched = append(touched, path.Dir(p))
+			}
+			if record.Pattern != "" {
+				touched = append(touched, "/")
+			}
+			if err := applyDeleteFiles(tree, record); err != nil {
+				return nil, err
+			}
+		} else if string(kv.Value) == tombstone {
+			touched = append(touched, path.Dir(filePath))
 			if err := tree.DeleteFile(filePath); err != nil {
 				// Deleting a non-existent file in an open commit should
 				// be a no-op
 				if hashtree.Code(err) != hashtree.PathNotFound {
-					return err
+					return nil, err
 				}
 			}
 		} else {
+			touched = append(touched, filePath)
 			records := &PutFileRecords{}
 			if err := proto.Unmarshal(kv.Value, records); err != nil {
-				return err
+				return nil, err
 			}
 			if !records.Split {
-				if len(records.Records) != 1 {
-					return fmt.Errorf("unexpect %d length PutFileRecord (this is likely a bug)", len(records.Records))
+				if len(records.Records) == 0 {
+					return nil, fmt.Errorf("unexpected empty PutFileRecords (this is likely a bug)")
 				}
-				if err := tree.PutFile(filePath, []*pfs.Object{{Hash: records.Records[0].ObjectHash}}, records.Records[0].SizeBytes); err != nil {
-					return err
+				// the file's blocks are appended to it in order
+				var objects []*pfs.Object
+				var size int64
+				for _, record := range records.Records {
+					objects = append(objects, &pfs.Object{Hash: record.ObjectHash})
+					size += record.SizeBytes
+				}
+				if err := tree.PutFile(filePath, objects, size); err != nil {
+					return nil, err
 				}
 			} else {
-				nodes, err := tree.List(filePath)
+				// only the last child of the directory is needed, and its
+				// children are sorted, so it isn't listed
+				node, err := tree.Get(filePath)
 				if err != nil && hashtree.Code(err) != hashtree.PathNotFound {
-					return err
+					return nil, err
 				}
 				var indexOffset int64
-				if len(nodes) > 0 {
-					indexOffset, err = strconv.ParseInt(path.Base(nodes[len(nodes)-1].Name), splitSuffixBase, splitSuffixWidth)
+				if node != nil && node.DirNode != nil && len(node.DirNode.Children) > 0 {
+					last := node.DirNode.Children[len(node.DirNode.Children)-1]
+					indexOffset, err = strconv.ParseInt(last, splitSuffixBase, splitSuffixWidth)
 					if err != nil {
-						return fmt.Errorf("error parsing filename %s as int, this likely means you're "+
+						return nil, fmt.Errorf("error parsing filename %s as int, this likely means you're "+
 							"using split on a directory which contains other data that wasn't put with split",
-							path.Base(nodes[len(nodes)-1].Name))
+							last)
 					}
 					indexOffset++ // start writing to the file after the last file
 				}
 				for i, record := range records.Records {
 					if err := tree.PutFile(path.Join(filePath, fmt.Sprintf(splitSuffixFmt, i+int(indexOffset))), []*pfs.Object{{Hash: record.ObjectHash}}, record.SizeBytes); err != nil {
-						return err
+						return nil, err
 					}
 				}
 			}
@@ -562,47 +906,71 @@ func (d *driver) finishCommit(ctx context.Context, commit *pfs.Commit) error {
 
 	finishedTree, err := tree.Finish()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	// Serialize the tree
-	data, err := hashtree.Serialize(finishedTree)
+
+	// Put the tree into the blob store
+	objClient, err := d.getObjectClient()
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	if len(data) > 0 {
-		// Put the tree into the blob store
-		objClient, err := d.getObjectClient()
-		if err != nil {
-			return err
-		}
-
-		obj, _, err := objClient.PutObject(bytes.NewReader(data))
-		if err != nil {
-			return err
-		}
-
-		commitInfo.Tree = obj
+	obj, err := hashtree.Store(hashtree.NewObjectStore(objClient), finishedTree)
+	if err != nil {
+		return nil, err
 	}
-
+	if commitInfo.Stats, err = commitStats(finishedTree, _tree); err != nil {
+		return nil, err
+	}
+	commitInfo.Tree = obj
 	commitInfo.SizeBytes = uint64(finishedTree.Size())
-	commitInfo.Finished = now()
-
-	_, err = col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
-		commits := d.commits(commit.Repo.Name).ReadWrite(stm)
-		repos := d.repos.ReadWrite(stm)
+	return touched, nil
+}
 
-		commits.Put(commit.ID, commitInfo)
-		// update repo size
-		repoInfo := new(pfs.RepoInfo)
-		if err := repos.Get(commit.Repo.Name, repoInfo); err != nil {
-			return err
+// commitStats returns what the commit whose tree is tree changed in its
+// parent, whose tree is parentTree. Only the parts of the trees that differ
+// are compared, so it's quick for commits that change little of a big repo.
+func commitStats(tree hashtree.HashTree, parentTree hashtree.HashTree) (*pfs.CommitStats, error) {
+	stats := &pfs.CommitStats{SizeDeltaBytes: tree.Size() - parentTree.Size()}
+	if err := hashtree.Diff(tree, parentTree, func(path string, node *hashtree.NodeProto, parentNode *hashtree.NodeProto) error {
+		switch {
+		case parentNode == nil:
+			stats.FilesAdded++
+		case node == nil:
+			stats.FilesDeleted++
+		default:
+			stats.FilesModified++
 		}
-		repoInfo.SizeBytes += commitInfo.SizeBytes
-		repos.Put(commit.Repo.Name, repoInfo)
 		return nil
-	})
-	return err
+	}); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// putFinishedCommit writes commitInfo, which has been finished, in stm and
+// adds its size to its repo's.
+func (d *driver) putFinishedCommit(stm col.STM, commitInfo *pfs.CommitInfo) error {
+	commit := commitInfo.Commit
+	commits := d.commits(commit.Repo.Name).ReadWrite(stm)
+	repos := d.repos.ReadWrite(stm)
+
+	// the commit may have been finished since it was inspected
+	current := new(pfs.CommitInfo)
+	if err := commits.Get(commit.ID, current); err != nil {
+		return err
+	}
+	if current.Finished != nil {
+		return fmt.Errorf("commit %s has already been finished", commit.FullID())
+	}
+	commits.Put(commit.ID, commitInfo)
+	// update repo size
+	repoInfo := new(pfs.RepoInfo)
+	if err := repos.Get(commit.Repo.Name, repoInfo); err != nil {
+		return err
+	}
+	repoInfo.SizeBytes += commitInfo.SizeBytes
+	repos.Put(commit.Repo.Name, repoInfo)
+	return nil
 }
 
 // inspectCommit takes a Commit and returns the corresponding CommitInfo.
@@ -644,25 +1012,40 @@ func (d *driver) inspectCommit(ctx context.Context, commit *pfs.Commit) (*pfs.Co
 }
 
 func (d *driver) listCommit(ctx context.Context, repo *pfs.Repo, to *pfs.Commit, from *pfs.Commit, number uint64) ([]*pfs.CommitInfo, error) {
+	var commitInfos []*pfs.CommitInfo
+	if err := d.listCommitF(ctx, repo, to, from, number, func(commitInfo *pfs.CommitInfo) error {
+		commitInfos = append(commitInfos, commitInfo)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return commitInfos, nil
+}
+
+// listCommitF calls f with the CommitInfo of each commit that listCommit
+// would return, in the same order, stopping at the first error f returns.
+// Commits are read from etcd a page at a time, so the commits of repos with
+// millions of them are never all in memory at once.
+func (d *driver) listCommitF(ctx context.Context, repo *pfs.Repo, to *pfs.Commit, from *pfs.Commit, number uint64, f func(*pfs.CommitInfo) error) error {
 	if from != nil && from.Repo.Name != repo.Name || to != nil && to.Repo.Name != repo.Name {
-		return nil, fmt.Errorf("`from` and `to` commits need to be from repo %s", repo.Name)
+		return fmt.Errorf("`from` and `to` commits need to be from repo %s", repo.Name)
 	}
 
 	// Make sure that the repo exists
 	_, err := d.inspectRepo(ctx, repo)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	// Make sure that both from and to are valid commits
 	if from != nil {
 		if _, err := d.inspectCommit(ctx, from); err != nil {
-			return nil, err
+			return err
 		}
 	}
 	if to != nil {
 		if _, err := d.inspectCommit(ctx, to); err != nil {
-			return nil, err
+			return err
 		}
 	}
 
@@ -670,29 +1053,30 @@ func (d *driver) listCommit(ctx context.Context, repo *pfs.Repo, to *pfs.Commit,
 	if number == 0 {
 		number = math.MaxUint64
 	}
-	var commitInfos []*pfs.CommitInfo
 	commits := d.commits(repo.Name).ReadOnly(ctx)
 
 	if from != nil && to == nil {
-		return nil, fmt.Errorf("cannot use `from` commit without `to` commit")
+		return fmt.Errorf("cannot use `from` commit without `to` commit")
 	} else if from == nil && to == nil {
 		// if neither from and to is given, we list all commits in
 		// the repo, sorted by revision timestamp
 		iterator, err := commits.List()
 		if err != nil {
-			return nil, err
+			return err
 		}
 		var commitID string
 		for number != 0 {
 			var commitInfo pfs.CommitInfo
 			ok, err := iterator.Next(&commitID, &commitInfo)
 			if err != nil {
-				return nil, err
+				return err
 			}
 			if !ok {
 				break
 			}
-			commitInfos = append(commitInfos, &commitInfo)
+			if err := f(&commitInfo); err != nil {
+				return err
+			}
 			number--
 		}
 	} else {
@@ -700,14 +1084,16 @@ func (d *driver) listCommit(ctx context.Context, repo *pfs.Repo, to *pfs.Commit,
 		for number != 0 && cursor != nil && (from == nil || cursor.ID != from.ID) {
 			var commitInfo pfs.CommitInfo
 			if err := commits.Get(cursor.ID, &commitInfo); err != nil {
-				return nil, err
+				return err
+			}
+			if err := f(&commitInfo); err != nil {
+				return err
 			}
-			commitInfos = append(commitInfos, &commitInfo)
 			cursor = commitInfo.ParentCommit
 			number--
 		}
 	}
-	return commitInfos, nil
+	return nil
 }
 
 type commitStream struct {
@@ -752,8 +1138,9 @@ func (d *driver) subscribeCommit(ctx context.Context, repo *pfs.Repo, branch str
 			}
 			close(stream)
 		}()
-		// keep track of the commits that have been sent
+		// keep track of the commits that have been sent, and the last one
 		seen := make(map[string]bool)
+		var last string
 		// include all commits that are currently on the given branch,
 		// but only the ones that have been finished
 		commitInfos, err := d.listCommit(ctx, repo, &pfs.Commit{
@@ -779,6 +1166,7 @@ func (d *driver) subscribeCommit(ctx context.Context, repo *pfs.Repo, branch str
 					Value: commitInfo,
 				}:
 					seen[commitInfo.Commit.ID] = true
+					last = commitInfo.Commit.ID
 				case <-done:
 					return nil
 				}
@@ -811,6 +1199,21 @@ func (d *driver) subscribeCommit(ctx context.Context, repo *pfs.Repo, branch str
 				if !seen[commit.ID] {
 					break
 				}
+				// A branch that's moved back to a commit that's been
+				// sent, e.g. because its head was deleted, sends it
+				// again, so that subscribers see the branch's new head.
+				// The watch may replay heads from before the commits
+				// were listed though, so it's only sent if it's still
+				// the head.
+				if commit.ID != last {
+					head := new(pfs.Commit)
+					if err := branches.Get(branch, head); err != nil && !isNotFoundErr(err) {
+						return err
+					}
+					if head.ID == commit.ID {
+						break
+					}
+				}
 			}
 			// Now we watch the CommitInfo until the commit has been finished
 			commits := d.commits(commit.Repo.Name).ReadOnly(ctx)
@@ -839,6 +1242,7 @@ func (d *driver) subscribeCommit(ctx context.Context, repo *pfs.Repo, branch str
 						Value: commitInfo,
 					}:
 						seen[commitInfo.Commit.ID] = true
+						last = commitInfo.Commit.ID
 					case <-done:
 						return nil
 					}
@@ -854,62 +1258,39 @@ func (d *driver) subscribeCommit(ctx context.Context, repo *pfs.Repo, branch str
 	}, nil
 }
 
+// flushCommit returns a stream of the commits that have all of fromCommits
+// as provenance, one per repo in toRepos or, if toRepos is empty, per repo
+// that's downstream of all of fromCommits' repos. Each repo is watched for
+// its commit concurrently, once however many of fromCommits it's downstream
+// of, so commits are streamed as soon as they exist, in the order that they
+// appear, rather than after every repo's watch has been set up.
 func (d *driver) flushCommit(ctx context.Context, fromCommits []*pfs.Commit, toRepos []*pfs.Repo) (CommitStream, error) {
 	if len(fromCommits) == 0 {
 		return nil, fmt.Errorf("fromCommits cannot be empty")
 	}
 
+	// resolve fromCommits, which may name branches, to the commits that
+	// downstream commits have as provenance
+	var provenance []*pfs.Commit
 	for _, commit := range fromCommits {
-		if _, err := d.inspectCommit(ctx, commit); err != nil {
+		commitInfo, err := d.inspectCommit(ctx, commit)
+		if err != nil {
 			return nil, err
 		}
+		provenance = append(provenance, commitInfo.Commit)
 	}
 
-	var repos []*pfs.Repo
-	if toRepos != nil {
-		repos = toRepos
-	} else {
-		var downstreamRepos []*pfs.Repo
-		// keep track of how many times a repo appears downstream of
-		// a repo in fromCommits.
-		repoCounts := make(map[string]int)
-		// Find the repos that have *all* the given repos as provenance
-		for _, commit := range fromCommits {
-			// get repos that have the commit's repo as provenance
-			repoInfos, err := d.flushRepo(ctx, commit.Repo)
-			if err != nil {
-				return nil, err
-			}
-
-		NextRepoInfo:
-			for _, repoInfo := range repoInfos {
-				repoCounts[repoInfo.Repo.Name]++
-				for _, repo := range downstreamRepos {
-					if repoInfo.Repo.Name == repo.Name {
-						// Already in the list; skip it
-						continue NextRepoInfo
-					}
-				}
-				downstreamRepos = append(downstreamRepos, repoInfo.Repo)
-			}
-		}
-		for _, repo := range downstreamRepos {
-			// Only the repos that showed up as a downstream repo for
-			// len(fromCommits) repos will contain commits that are
-			// downstream of all fromCommits.
-			if repoCounts[repo.Name] == len(fromCommits) {
-				repos = append(repos, repo)
-			}
+	repos := toRepos
+	if repos == nil {
+		var err error
+		if repos, err = d.flushRepos(ctx, provenance); err != nil {
+			return nil, err
 		}
 	}
+	repos = uniqueRepos(repos)
 
-	// A commit needs to show up len(fromCommits) times in order to
-	// prove that it indeed has all the fromCommits as provenance.
-	commitCounts := make(map[string]int)
-	var commitCountsLock sync.Mutex
-	stream := make(chan CommitEvent, len(repos))
+	stream := make(chan CommitEvent)
 	done := make(chan struct{})
-
 	if len(repos) == 0 {
 		close(stream)
 		return &commitStream{
@@ -918,97 +1299,190 @@ func (d *driver) flushCommit(ctx context.Context, fromCommits []*pfs.Commit, toR
 		}, nil
 	}
 
-	for _, commit := range fromCommits {
-		for _, repo := range repos {
-			commitWatcher, err := d.commits(repo.Name).ReadOnly(ctx).WatchByIndex(provenanceIndex, commit)
-			if err != nil {
-				return nil, err
+	ctx, cancel := context.WithCancel(ctx)
+	events := make(chan CommitEvent)
+	for _, repo := range repos {
+		go func(repo *pfs.Repo) {
+			commitInfo, err := d.flushRepoCommit(ctx, repo, provenance)
+			select {
+			case events <- CommitEvent{Value: commitInfo, Err: err}:
+			case <-ctx.Done():
 			}
-			go func(commit *pfs.Commit) (retErr error) {
-				defer func() {
-					if retErr != nil {
-						select {
-						case stream <- CommitEvent{
-							Err: retErr,
-						}:
-						case <-done:
-						}
-					}
-				}()
-				for {
-					var ev *watch.Event
-					var ok bool
-					select {
-					case ev, ok = <-commitWatcher.Watch():
-					case <-done:
-						return
-					}
-					if !ok {
-						return
-					}
-					var commitID string
-					var commitInfo pfs.CommitInfo
-					switch ev.Type {
-					case watch.EventError:
-						return ev.Err
-					case watch.EventDelete:
-						continue
-					case watch.EventPut:
-						if err := ev.Unmarshal(&commitID, &commitInfo); err != nil {
-							return err
-						}
-
-					}
-					// Using a func just so we can unlock the commits in
-					// a refer function
-					if func() bool {
-						commitCountsLock.Lock()
-						defer commitCountsLock.Unlock()
-						commitCounts[commitID]++
-						return commitCounts[commitID] == len(fromCommits)
-					}() {
-						select {
-						case stream <- CommitEvent{
-							Value: &commitInfo,
-						}:
-						case <-done:
-							return
-						}
-					}
-				}
-			}(commit)
-		}
+		}(repo)
 	}
-
-	respStream := make(chan CommitEvent)
-	respDone := make(chan struct{})
-
 	go func() {
-		// When we've sent len(repos) commits, we are done
-		var numCommitsSent int
-		for {
+		defer cancel()
+		defer close(stream)
+		// the stream ends once every repo has sent its commit, or one of
+		// them has failed
+		for i := 0; i < len(repos); i++ {
+			var ev CommitEvent
 			select {
-			case ev := <-stream:
-				respStream <- ev
-				numCommitsSent++
-				if numCommitsSent == len(repos) {
-					close(respStream)
-					close(done)
-					return
-				}
-			case <-respDone:
-				close(done)
+			case ev = <-events:
+			case <-done:
+				return
+			}
+			select {
+			case stream <- ev:
+			case <-done:
+				return
+			}
+			if ev.Err != nil {
 				return
 			}
 		}
 	}()
-
 	return &commitStream{
-		stream: respStream,
-		done:   respDone,
+		stream: stream,
+		done:   done,
 	}, nil
 }
 
+// flushCommits does a flushCommit for each of requests concurrently, and
+// calls f with each commit as soon as it's available, along with the index in
+// requests of the flush that it belongs to. Calls to f are never concurrent.
+func (d *driver) flushCommits(ctx context.Context, requests []*pfs.FlushCommitRequest, f func(int, *pfs.CommitInfo) error) error {
+	// ctx is cancelled as soon as one of the flushes fails, which stops the
+	// others
+	eg, ctx := errgroup.WithContext(ctx)
+	var lock sync.Mutex
+	for i, request := range requests {
+		i, request := i, request
+		eg.Go(func() error {
+			commitStream, err := d.flushCommit(ctx, request.Commits, request.ToRepos)
+			if err != nil {
+				return err
+			}
+			defer commitStream.Close()
+			for {
+				var ev CommitEvent
+				var ok bool
+				select {
+				case ev, ok = <-commitStream.Stream():
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+				if !ok {
+					return nil
+				}
+				if ev.Err != nil {
+					return ev.Err
+				}
+				if err := func() error {
+					lock.Lock()
+					defer lock.Unlock()
+					return f(i, ev.Value)
+				}(); err != nil {
+					return err
+				}
+			}
+		})
+	}
+	return eg.Wait()
+}
+
+// flushRepos returns the repos that are downstream of all of the repos of
+// fromCommits. Each repo's downstream repos are only looked up once, however
+// many of fromCommits are in it.
+func (d *driver) flushRepos(ctx context.Context, fromCommits []*pfs.Commit) ([]*pfs.Repo, error) {
+	var downstreamRepos []*pfs.Repo
+	// keep track of how many of fromCommits' repos each repo is
+	// downstream of
+	repoCounts := make(map[string]int)
+	fromRepos := make(map[string]bool)
+	for _, commit := range fromCommits {
+		if fromRepos[commit.Repo.Name] {
+			continue
+		}
+		fromRepos[commit.Repo.Name] = true
+		repoInfos, err := d.flushRepo(ctx, commit.Repo)
+		if err != nil {
+			return nil, err
+		}
+		for _, repoInfo := range repoInfos {
+			if repoCounts[repoInfo.Repo.Name] == 0 {
+				downstreamRepos = append(downstreamRepos, repoInfo.Repo)
+			}
+			repoCounts[repoInfo.Repo.Name]++
+		}
+	}
+	var result []*pfs.Repo
+	for _, repo := range downstreamRepos {
+		// Only the repos that are downstream of every one of
+		// fromCommits' repos will contain commits that are downstream of
+		// all fromCommits.
+		if repoCounts[repo.Name] == len(fromRepos) {
+			result = append(result, repo)
+		}
+	}
+	return result, nil
+}
+
+// flushRepoCommit waits for a commit in repo that has all of fromCommits as
+// provenance, and returns it. Only the commits downstream of the first of
+// fromCommits are watched, the rest are checked in their provenance.
+func (d *driver) flushRepoCommit(ctx context.Context, repo *pfs.Repo, fromCommits []*pfs.Commit) (*pfs.CommitInfo, error) {
+	commitWatcher, err := d.commits(repo.Name).ReadOnly(ctx).WatchByIndex(provenanceIndex, fromCommits[0])
+	if err != nil {
+		return nil, err
+	}
+	defer commitWatcher.Close()
+	for {
+		var ev *watch.Event
+		var ok bool
+		select {
+		case ev, ok = <-commitWatcher.Watch():
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if !ok {
+			return nil, fmt.Errorf("stopped watching %s's commits before one with the flushed commits as provenance appeared", repo.Name)
+		}
+		switch ev.Type {
+		case watch.EventError:
+			return nil, ev.Err
+		case watch.EventDelete:
+			continue
+		}
+		var commitID string
+		commitInfo := &pfs.CommitInfo{}
+		if err := ev.Unmarshal(&commitID, commitInfo); err != nil {
+			return nil, err
+		}
+		if hasProvenance(commitInfo, fromCommits[1:]) {
+			return commitInfo, nil
+		}
+	}
+}
+
+// hasProvenance returns true if all of commits are in commitInfo's
+// provenance.
+func hasProvenance(commitInfo *pfs.CommitInfo, commits []*pfs.Commit) bool {
+	provenance := make(map[string]bool)
+	for _, commit := range commitInfo.Provenance {
+		provenance[path.Join(commit.Repo.Name, commit.ID)] = true
+	}
+	for _, commit := range commits {
+		if !provenance[path.Join(commit.Repo.Name, commit.ID)] {
+			return false
+		}
+	}
+	return true
+}
+
+// uniqueRepos returns repos without the repos that are already in it.
+func uniqueRepos(repos []*pfs.Repo) []*pfs.Repo {
+	var result []*pfs.Repo
+	seen := make(map[string]bool)
+	for _, repo := range repos {
+		if !seen[repo.Name] {
+			seen[repo.Name] = true
+			result = append(result, repo)
+		}
+	}
+	return result
+}
+
 func (d *driver) flushRepo(ctx context.Context, repo *pfs.Repo) ([]*pfs.RepoInfo, error) {
 	iter, err := d.repos.ReadOnly(ctx).GetByIndex(provenanceIndex, repo)
 	if err != nil {
@@ -1030,12 +1504,336 @@ func (d *driver) flushRepo(ctx context.Context, repo *pfs.Repo) ([]*pfs.RepoInfo
 	panic("unreachable")
 }
 
+// deleteCommit deletes commit, along with every commit that's built on it:
+// its descendants, whose trees include its changes, and the commits in other
+// repos that have one of them as provenance, such as the output commits of
+// pipelines. Branches whose heads are deleted are moved back to their
+// closest ancestors that aren't, or deleted if there isn't one, and the
+// children of deleted commits that aren't deleted themselves are reparented
+// the same way. Pipelines see their input branches move back, and either
+// reuse the output that they've already computed for the new heads, or
+// compute it again, so no output is left whose provenance doesn't exist.
 func (d *driver) deleteCommit(ctx context.Context, commit *pfs.Commit) error {
+	if _, err := d.inspectCommit(ctx, commit); err != nil {
+		return err
+	}
+	// commitInfos maps each repo with deleted commits to all of its commits,
+	// and deleted maps it to the IDs of the ones that are deleted
+	commitInfos := make(map[string]map[string]*pfs.CommitInfo)
+	deleted := make(map[string]map[string]bool)
+	var err error
+	if commitInfos[commit.Repo.Name], err = d.allCommits(ctx, commit.Repo); err != nil {
+		return err
+	}
+	deleted[commit.Repo.Name] = descendants(commitInfos[commit.Repo.Name], commit.ID)
+	// provenance is transitive, so the commits downstream of the deleted
+	// ones are all in the repos that have commit's repo as provenance
+	iter, err := d.repos.ReadOnly(ctx).GetByIndex(provenanceIndex, commit.Repo)
+	if err != nil {
+		return err
+	}
+	for {
+		var repoName string
+		repoInfo := new(pfs.RepoInfo)
+		ok, err := iter.Next(&repoName, repoInfo)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		repo := repoInfo.Repo.Name
+		if commitInfos[repo], err = d.allCommits(ctx, repoInfo.Repo); err != nil {
+			return err
+		}
+		deleted[repo] = make(map[string]bool)
+		for id, commitInfo := range commitInfos[repo] {
+			for _, prov := range commitInfo.Provenance {
+				if prov.Repo.Name == commit.Repo.Name && deleted[commit.Repo.Name][prov.ID] {
+					deleted[repo][id] = true
+					break
+				}
+			}
+		}
+	}
+	return d.removeCommits(ctx, commitInfos, deleted)
+}
+
+// removeCommits removes the commits in deleted, which maps repos to the IDs
+// of the commits to remove from them, given all of the commits in those repos
+// in commitInfos. The children of removed commits are reparented to their
+// closest ancestors that aren't removed, and branches are moved back to
+// them, or deleted if there isn't one. Commits that have already been
+// removed, e.g. by another pachd, are skipped.
+//
+// There may be more commits than etcd allows in one transaction, so they're
+// removed in several, ordered so that every transaction leaves the repos
+// consistent: branches are moved first, then commits are removed from
+// downstream repos before the repos that are their provenance, and children
+// are removed before their parents.
+func (d *driver) removeCommits(ctx context.Context, commitInfos map[string]map[string]*pfs.CommitInfo, deleted map[string]map[string]bool) error {
+	branches := make(map[string][]*pfs.Branch)
+	for repo := range deleted {
+		var err error
+		if branches[repo], err = d.listBranch(ctx, &pfs.Repo{repo}); err != nil {
+			return err
+		}
+	}
+	// survivor returns the closest ancestor of the commit id in repo that
+	// isn't deleted, or nil if there isn't one
+	survivor := func(repo string, id string) *pfs.Commit {
+		for deleted[repo][id] {
+			parent := commitInfos[repo][id].ParentCommit
+			if parent == nil {
+				return nil
+			}
+			id = parent.ID
+		}
+		return &pfs.Commit{Repo: &pfs.Repo{repo}, ID: id}
+	}
+
+	var steps []txnStep
+	for repo, ids := range deleted {
+		for _, branch := range branches[repo] {
+			if branch.Head == nil || !ids[branch.Head.ID] {
+				continue
+			}
+			repo, branch := repo, branch.Name
+			steps = append(steps, txnStep{ops: 1, apply: func(stm col.STM) error {
+				repoBranches := d.branches(repo).ReadWrite(stm)
+				head := new(pfs.Commit)
+				if err := repoBranches.Get(branch, head); err != nil {
+					if _, ok := err.(col.ErrNotFound); ok {
+						return nil
+					}
+					return err
+				}
+				if !deleted[repo][head.ID] {
+					return nil
+				}
+				if newHead := survivor(repo, head.ID); newHead != nil {
+					repoBranches.Put(branch, newHead)
+					return nil
+				}
+				return repoBranches.Delete(branch)
+			}})
+		}
+	}
+	for _, repo := range downstreamFirst(commitInfos, deleted) {
+		ids := deleted[repo]
+		// keptChildren maps deleted commits to their children that aren't
+		// deleted, which are reparented when they're removed
+		keptChildren := make(map[string][]string)
+		for id, commitInfo := range commitInfos[repo] {
+			if !ids[id] && commitInfo.ParentCommit != nil && ids[commitInfo.ParentCommit.ID] {
+				keptChildren[commitInfo.ParentCommit.ID] = append(keptChildren[commitInfo.ParentCommit.ID], id)
+			}
+		}
+		for _, id := range childrenFirst(commitInfos[repo], ids) {
+			repo, id := repo, id
+			// deleting or reparenting a commit also deletes or reads its
+			// entries in the provenance index, and the repo's size is updated
+			ops := 3 + len(commitInfos[repo][id].Provenance)
+			for _, child := range keptChildren[id] {
+				ops += 1 + len(commitInfos[repo][child].Provenance)
+			}
+			steps = append(steps, txnStep{ops: ops, apply: func(stm col.STM) error {
+				repos := d.repos.ReadWrite(stm)
+				commits := d.commits(repo).ReadWrite(stm)
+				commitInfo := new(pfs.CommitInfo)
+				if err := commits.Get(id, commitInfo); err != nil {
+					if isNotFoundErr(err) {
+						return nil
+					}
+					return err
+				}
+				repoInfo := new(pfs.RepoInfo)
+				if err := repos.Get(repo, repoInfo); err != nil {
+					return err
+				}
+				if commitInfo.SizeBytes > repoInfo.SizeBytes {
+					repoInfo.SizeBytes = 0
+				} else {
+					repoInfo.SizeBytes -= commitInfo.SizeBytes
+				}
+				repos.Put(repo, repoInfo)
+				if err := commits.Delete(id); err != nil {
+					return err
+				}
+				for _, childID := range keptChildren[id] {
+					child := new(pfs.CommitInfo)
+					if err := commits.Get(childID, child); err != nil {
+						if isNotFoundErr(err) {
+							continue
+						}
+						return err
+					}
+					child.ParentCommit = survivor(repo, id)
+					commits.Put(childID, child)
+				}
+				return nil
+			}})
+		}
+	}
+	if err := d.applySteps(ctx, steps); err != nil {
+		return err
+	}
+
+	// the deleted commits that were open may have changes in scratch space
+	for repo, ids := range deleted {
+		for id := range ids {
+			d.commitCache.Remove(id)
+			if commitInfos[repo][id].Finished != nil {
+				continue
+			}
+			prefix := path.Join(d.prefix, "scratch", repo, id)
+			if _, err := d.etcdClient.Delete(ctx, prefix, etcd.WithPrefix()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// txnStep is part of a change that's too big for one etcd transaction, see
+// applySteps.
+type txnStep struct {
+	// ops is the most etcd operations that apply adds to a transaction
+	ops   int
+	apply func(stm col.STM) error
+}
+
+// applySteps applies steps in order, grouping consecutive steps into
+// transactions of at most col.MaxTxnOps operations.
+func (d *driver) applySteps(ctx context.Context, steps []txnStep) error {
+	for len(steps) > 0 {
+		n, ops := 1, steps[0].ops
+		for n < len(steps) && ops+steps[n].ops <= col.MaxTxnOps {
+			ops += steps[n].ops
+			n++
+		}
+		batch := steps[:n]
+		steps = steps[n:]
+		if _, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+			for _, step := range batch {
+				if err := step.apply(stm); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// downstreamFirst returns the repos in deleted ordered so that each comes
+// before the repos that are its provenance. Provenance is transitive, so a
+// repo's commits have provenance in more repos than those of the repos
+// upstream of it.
+func downstreamFirst(commitInfos map[string]map[string]*pfs.CommitInfo, deleted map[string]map[string]bool) []string {
+	provRepos := make(map[string]int)
+	var result []string
+	for repo := range deleted {
+		seen := make(map[string]bool)
+		for _, commitInfo := range commitInfos[repo] {
+			for _, prov := range commitInfo.Provenance {
+				seen[prov.Repo.Name] = true
+			}
+		}
+		provRepos[repo] = len(seen)
+		result = append(result, repo)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if provRepos[result[i]] != provRepos[result[j]] {
+			return provRepos[result[i]] > provRepos[result[j]]
+		}
+		return result[i] < result[j]
+	})
+	return result
+}
+
+// childrenFirst returns the IDs in ids ordered so that every commit comes
+// before its ancestors, given all of the commits in their repo in
+// commitInfos.
+func childrenFirst(commitInfos map[string]*pfs.CommitInfo, ids map[string]bool) []string {
+	depths := make(map[string]int)
+	var depth func(id string) int
+	depth = func(id string) int {
+		if d, ok := depths[id]; ok {
+			return d
+		}
+		commitInfo, ok := commitInfos[id]
+		if !ok || commitInfo.ParentCommit == nil {
+			depths[id] = 0
+			return 0
+		}
+		depths[id] = depth(commitInfo.ParentCommit.ID) + 1
+		return depths[id]
+	}
+	var result []string
+	for id := range ids {
+		depth(id)
+		result = append(result, id)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if depths[result[i]] != depths[result[j]] {
+			return depths[result[i]] > depths[result[j]]
+		}
+		return result[i] < result[j]
+	})
+	return result
+}
+
+// allCommits returns every commit in repo, by ID.
+func (d *driver) allCommits(ctx context.Context, repo *pfs.Repo) (map[string]*pfs.CommitInfo, error) {
+	iter, err := d.commits(repo.Name).ReadOnly(ctx).List()
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]*pfs.CommitInfo)
+	for {
+		var commitID string
+		commitInfo := new(pfs.CommitInfo)
+		ok, err := iter.Next(&commitID, commitInfo)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return result, nil
+		}
+		result[commitInfo.Commit.ID] = commitInfo
+	}
+}
+
+// descendants returns the IDs of the commit id and of all of its descendants
+// among commitInfos.
+func descendants(commitInfos map[string]*pfs.CommitInfo, id string) map[string]bool {
+	children := make(map[string][]string)
+	for childID, commitInfo := range commitInfos {
+		if commitInfo.ParentCommit != nil {
+			children[commitInfo.ParentCommit.ID] = append(children[commitInfo.ParentCommit.ID], childID)
+		}
+	}
+	result := map[string]bool{id: true}
+	queue := []string{id}
+	for len(queue) > 0 {
+		for _, child := range children[queue[0]] {
+			if !result[child] {
+				result[child] = true
+				queue = append(queue, child)
+			}
+		}
+		queue = queue[1:]
+	}
+	return result
+}
+
 func (d *driver) listBranch(ctx context.Context, repo *pfs.Repo) ([]*pfs.Branch, error) {
 	branches := d.branches(repo.Name).ReadOnly(ctx)
+	triggers := d.triggers(repo.Name).ReadOnly(ctx)
+	retentions := d.retentions(repo.Name).ReadOnly(ctx)
 	iterator, err := branches.List()
 	if err != nil {
 		return nil, err
@@ -1052,10 +1850,23 @@ func (d *driver) listBranch(ctx context.Context, repo *pfs.Repo) ([]*pfs.Branch,
 		if !ok {
 			break
 		}
-		res = append(res, &pfs.Branch{
+		branch := &pfs.Branch{
 			Name: path.Base(branchName),
 			Head: head,
-		})
+		}
+		trigger := new(pfs.Trigger)
+		if err := triggers.Get(branch.Name, trigger); err == nil {
+			branch.Trigger = trigger
+		} else if !isNotFoundErr(err) {
+			return nil, err
+		}
+		retention := new(pfs.RetentionPolicy)
+		if err := retentions.Get(branch.Name, retention); err == nil {
+			branch.Retention = retention
+		} else if !isNotFoundErr(err) {
+			return nil, err
+		}
+		res = append(res, branch)
 	}
 	return res, nil
 }
@@ -1064,7 +1875,7 @@ func (d *driver) setBranch(ctx context.Context, commit *pfs.Commit, name string)
 	if _, err := d.inspectCommit(ctx, commit); err != nil {
 		return err
 	}
-	_, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+	if _, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
 		commits := d.commits(commit.Repo.Name).ReadWrite(stm)
 		branches := d.branches(commit.Repo.Name).ReadWrite(stm)
 
@@ -1076,13 +1887,26 @@ func (d *driver) setBranch(ctx context.Context, commit *pfs.Commit, name string)
 
 		branches.Put(name, commit)
 		return nil
-	})
-	return err
+	}); err != nil {
+		return err
+	}
+	d.fireTriggersAfter(ctx, commit.Repo)
+	return nil
 }
 
+// deleteBranch deletes a branch, and its trigger and retention policy if it
+// has them.
 func (d *driver) deleteBranch(ctx context.Context, repo *pfs.Repo, name string) error {
 	_, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
 		branches := d.branches(repo.Name).ReadWrite(stm)
+		triggers := d.triggers(repo.Name).ReadWrite(stm)
+		retentions := d.retentions(repo.Name).ReadWrite(stm)
+		if err := triggers.Delete(name); err != nil && !isNotFoundErr(err) {
+			return err
+		}
+		if err := retentions.Delete(name); err != nil && !isNotFoundErr(err) {
+			return err
+		}
 		return branches.Delete(name)
 	})
 	return err
@@ -1128,10 +1952,17 @@ func (d *driver) putFile(ctx context.Context, file *pfs.File, delimiter pfs.Deli
 	// PutFile call.
 	records := &PutFileRecords{}
 	if !d.commitExists(file.Commit.ID) {
-		_, err := d.inspectCommit(ctx, file.Commit)
+		commitInfo, err := d.inspectCommit(ctx, file.Commit)
 		if err != nil {
 			return err
 		}
+		// Output repos' finished commits are their pipelines' output, and
+		// the only open commits in them are those started with force
+		if commitInfo.Finished != nil {
+			if err := d.checkNotOutputRepo(ctx, file.Commit.Repo); err != nil {
+				return err
+			}
+		}
 		d.setCommitExist(file.Commit.ID)
 	}
 
@@ -1149,14 +1980,10 @@ func (d *driver) putFile(ctx context.Context, file *pfs.File, delimiter pfs.Deli
 		return err
 	}
 	if delimiter == pfs.Delimiter_NONE {
-		object, size, err := objClient.PutObject(reader)
+		records.Records, err = putBlocks(objClient, reader)
 		if err != nil {
 			return err
 		}
-		records.Records = append(records.Records, &PutFileRecord{
-			SizeBytes:  size,
-			ObjectHash: object.Hash,
-		})
 		marshalledRecords, err := proto.Marshal(records)
 		if err != nil {
 			return err
@@ -1170,6 +1997,7 @@ func (d *driver) putFile(ctx context.Context, file *pfs.File, delimiter pfs.Deli
 	var filesPut int
 	EOF := false
 	var eg errgroup.Group
+	limiter := limit.New(putFileConcurrency)
 	decoder := json.NewDecoder(reader)
 	bufioR := bufio.NewReader(reader)
 
@@ -1205,7 +2033,9 @@ func (d *driver) putFile(ctx context.Context, file *pfs.File, delimiter pfs.Deli
 				EOF) {
 			_buffer := buffer
 			index := filesPut
+			limiter.Acquire()
 			eg.Go(func() error {
+				defer limiter.Release()
 				object, size, err := objClient.PutObject(_buffer)
 				if err != nil {
 					return err
@@ -1240,6 +2070,54 @@ func (d *driver) putFile(ctx context.Context, file *pfs.File, delimiter pfs.Deli
 	return err
 }
 
+// putBlocks splits the data in r into blocks of putFileBlockSize bytes, and
+// uploads them as objects, up to putFileConcurrency at a time. A single
+// upload is limited by the throughput of one connection to the object store,
+// uploading blocks concurrently isn't. It returns a record for each block, in
+// order, and always at least one, so that empty files are put too.
+func putBlocks(objClient *client.APIClient, r io.Reader) ([]*PutFileRecord, error) {
+	var records []*PutFileRecord
+	var eg errgroup.Group
+	var failed int32
+	limiter := limit.New(putFileConcurrency)
+	for atomic.LoadInt32(&failed) == 0 {
+		// acquire before reading the block, so that at most
+		// putFileConcurrency blocks are held in memory
+		limiter.Acquire()
+		block := &bytes.Buffer{}
+		n, err := io.CopyN(block, r, putFileBlockSize)
+		if err != nil && err != io.EOF {
+			limiter.Release()
+			eg.Wait()
+			return nil, err
+		}
+		if n == 0 && len(records) > 0 {
+			limiter.Release()
+			break
+		}
+		record := &PutFileRecord{}
+		records = append(records, record)
+		eg.Go(func() error {
+			defer limiter.Release()
+			object, size, err := objClient.PutObject(block)
+			if err != nil {
+				atomic.StoreInt32(&failed, 1)
+				return err
+			}
+			record.SizeBytes = size
+			record.ObjectHash = object.Hash
+			return nil
+		})
+		if err == io.EOF {
+			break
+		}
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
 func (d *driver) getTreeForCommit(ctx context.Context, commit *pfs.Commit) (hashtree.HashTree, error) {
 	if commit == nil {
 		t, err := hashtree.NewHashTree().Finish()
@@ -1280,18 +2158,14 @@ func (d *driver) getTreeForCommit(ctx context.Context, commit *pfs.Commit) (hash
 		return t, nil
 	}
 
-	// read the tree from the block store
+	// read the tree from the block store, its chunks are loaded as they're
+	// needed
 	objClient, err := d.getObjectClient()
 	if err != nil {
 		return nil, err
 	}
 
-	var buf bytes.Buffer
-	if err := objClient.GetObject(treeRef.Hash, &buf); err != nil {
-		return nil, err
-	}
-
-	h, err := hashtree.Deserialize(buf.Bytes())
+	h, err := hashtree.Load(hashtree.NewObjectStore(objClient), treeRef)
 	if err != nil {
 		return nil, err
 	}
@@ -1331,8 +2205,253 @@ func (d *driver) getFile(ctx context.Context, file *pfs.File, offset int64, size
 	return grpcutil.NewStreamingBytesReader(getObjectsClient), nil
 }
 
-// If full is false, exclude potentially large fields such as `Objects`
-// and `Children`
+// getParquet returns the Parquet file that holds the columns and row groups
+// of file that selection selects, see parquet.Select. Only the file's footer
+// and the selected column chunks are read from object storage. The reader
+// must be closed.
+func (d *driver) getParquet(ctx context.Context, file *pfs.File, selection *pfs.ParquetSelection) (io.ReadCloser, error) {
+	tree, err := d.getTreeForCommit(ctx, file.Commit)
+	if err != nil {
+		return nil, err
+	}
+	node, err := tree.Get(file.Path)
+	if err != nil {
+		return nil, pfsserver.ErrFileNotFound{file}
+	}
+	if node.FileNode == nil {
+		return nil, fmt.Errorf("%s is a directory", file.Path)
+	}
+	objClient, err := d.getObjectClient()
+	if err != nil {
+		return nil, err
+	}
+	// every range is read from the tree's node, so that they're all from the
+	// same version of the file, even if file.Commit is a branch that moves
+	read := func(offset int64, size int64) (io.Reader, error) {
+		getObjectsClient, err := objClient.ObjectAPIClient.GetObjects(ctx, &pfs.GetObjectsRequest{
+			Objects:     node.FileNode.Objects,
+			OffsetBytes: uint64(offset),
+			SizeBytes:   uint64(size),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return grpcutil.NewStreamingBytesReader(getObjectsClient), nil
+	}
+	readAll := func(offset int64, size int64) ([]byte, error) {
+		r, err := read(offset, size)
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.ReadAll(r)
+	}
+	size := node.SubtreeSize
+	if size < parquet.TailSize+int64(len(parquet.Magic)) {
+		return nil, fmt.Errorf("%s isn't a Parquet file", file.Path)
+	}
+	tail, err := readAll(size-parquet.TailSize, parquet.TailSize)
+	if err != nil {
+		return nil, err
+	}
+	metadataSize, err := parquet.MetadataSize(tail)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file.Path, err)
+	}
+	if metadataSize > size-parquet.TailSize-int64(len(parquet.Magic)) {
+		return nil, fmt.Errorf("%s isn't a Parquet file", file.Path)
+	}
+	metadata, err := readAll(size-parquet.TailSize-metadataSize, metadataSize)
+	if err != nil {
+		return nil, err
+	}
+	s, err := parquet.Select(metadata, size, selection.Columns, selection.RowGroups)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file.Path, err)
+	}
+	r, w := io.Pipe()
+	go func() {
+		w.CloseWithError(func() error {
+			if _, err := io.WriteString(w, parquet.Magic); err != nil {
+				return err
+			}
+			for _, rg := range s.Ranges {
+				if rg.Size == 0 {
+					continue
+				}
+				chunks, err := read(rg.Offset, rg.Size)
+				if err != nil {
+					return err
+				}
+				if _, err := io.Copy(w, chunks); err != nil {
+					return err
+				}
+			}
+			_, err := w.Write(s.Footer)
+			return err
+		}())
+	}()
+	return r, nil
+}
+
+// defaultGetFilesLimit is the size of the biggest file whose content GetFiles
+// returns, if the request doesn't set one.
+const defaultGetFilesLimit = 1024 * 1024
+
+// getFiles calls f with the FileContents of each of 'files', in order. The
+// contents of files that are no bigger than 'sizeLimit' are included, and
+// are read from object storage together, with as few GetObjects calls as
+// fit in a message, rather than one per file.
+func (d *driver) getFiles(ctx context.Context, files []*pfs.File, sizeLimit uint64, f func(*pfs.FileContents) error) error {
+	if sizeLimit == 0 {
+		sizeLimit = defaultGetFilesLimit
+	}
+	// the contents of a batch are read into memory and sent in messages, so
+	// they're bounded by the size of a message
+	batchLimit := uint64(grpcutil.MaxMsgSize / 2)
+	if sizeLimit > batchLimit {
+		sizeLimit = batchLimit
+	}
+	var batch []*pfs.FileContents
+	var objects []*pfs.Object
+	var batchSize uint64
+	flush := func() error {
+		if len(objects) > 0 {
+			objClient, err := d.getObjectClient()
+			if err != nil {
+				return err
+			}
+			getObjectsClient, err := objClient.ObjectAPIClient.GetObjects(ctx, &pfs.GetObjectsRequest{
+				Objects: objects,
+			})
+			if err != nil {
+				return err
+			}
+			var buf bytes.Buffer
+			if err := grpcutil.WriteFromStreamingBytesClient(getObjectsClient, &buf); err != nil {
+				return err
+			}
+			value := buf.Bytes()
+			for _, fileContents := range batch {
+				size := fileContents.FileInfo.SizeBytes
+				if fileContents.FileInfo.FileType != pfs.FileType_FILE || size > sizeLimit {
+					continue
+				}
+				if uint64(len(value)) < size {
+					return fmt.Errorf("content of %s is shorter than its size", fileContents.FileInfo.File.Path)
+				}
+				fileContents.Value = value[:size]
+				value = value[size:]
+			}
+		}
+		for _, fileContents := range batch {
+			if err := f(fileContents); err != nil {
+				return err
+			}
+		}
+		batch, objects, batchSize = nil, nil, 0
+		return nil
+	}
+	for _, file := range files {
+		tree, err := d.getTreeForCommit(ctx, file.Commit)
+		if err != nil {
+			return err
+		}
+		node, err := tree.Get(file.Path)
+		if err != nil {
+			return pfsserver.ErrFileNotFound{file}
+		}
+		fileContents := &pfs.FileContents{
+			FileInfo: nodeToFileInfo(file.Commit, file.Path, node, false),
+		}
+		size := fileContents.FileInfo.SizeBytes
+		if node.FileNode != nil && size <= sizeLimit {
+			if batchSize+size > batchLimit {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+			objects = append(objects, node.FileNode.Objects...)
+			batchSize += size
+		}
+		batch = append(batch, fileContents)
+	}
+	return flush()
+}
+
+// defaultGetFileRangesParallelism is how many ranges GetFileRanges reads at
+// once, if the request doesn't set it.
+const defaultGetFileRangesParallelism = 16
+
+// getFileRanges calls f with the chunks of the contents of each of 'ranges'.
+// Up to 'parallelism' ranges are read from object storage at once, and f is
+// called with their chunks as they arrive, one at a time, so the chunks of
+// different ranges are interleaved. Every range's file is looked up before
+// any is read, so that a missing file fails the call before anything's sent.
+func (d *driver) getFileRanges(ctx context.Context, ranges []*pfs.GetFileRequest, parallelism int, f func(*pfs.FileRangeChunk) error) error {
+	if parallelism == 0 {
+		parallelism = defaultGetFileRangesParallelism
+	}
+	objects := make([][]*pfs.Object, len(ranges))
+	for i, r := range ranges {
+		tree, err := d.getTreeForCommit(ctx, r.File.Commit)
+		if err != nil {
+			return err
+		}
+		node, err := tree.Get(r.File.Path)
+		if err != nil {
+			return pfsserver.ErrFileNotFound{r.File}
+		}
+		if node.FileNode == nil {
+			return fmt.Errorf("%s is a directory", r.File.Path)
+		}
+		objects[i] = node.FileNode.Objects
+	}
+	objClient, err := d.getObjectClient()
+	if err != nil {
+		return err
+	}
+	var mu sync.Mutex
+	send := func(chunk *pfs.FileRangeChunk) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return f(chunk)
+	}
+	eg, ctx := errgroup.WithContext(ctx)
+	limiter := limit.New(parallelism)
+	for i, r := range ranges {
+		i, r := i, r
+		limiter.Acquire()
+		eg.Go(func() error {
+			defer limiter.Release()
+			getObjectsClient, err := objClient.ObjectAPIClient.GetObjects(ctx, &pfs.GetObjectsRequest{
+				Objects:     objects[i],
+				OffsetBytes: uint64(r.OffsetBytes),
+				SizeBytes:   uint64(r.SizeBytes),
+			})
+			if err != nil {
+				return err
+			}
+			for {
+				value, err := getObjectsClient.Recv()
+				if err == io.EOF {
+					return send(&pfs.FileRangeChunk{Index: uint32(i), Done: true})
+				} else if err != nil {
+					return err
+				}
+				if len(value.Value) == 0 {
+					continue
+				}
+				if err := send(&pfs.FileRangeChunk{Index: uint32(i), Value: value.Value}); err != nil {
+					return err
+				}
+			}
+		})
+	}
+	return eg.Wait()
+}
+
+// If full is false, exclude potentially large fields such as `Objects`,
+// `Provenance` and `Children`
 func nodeToFileInfo(commit *pfs.Commit, path string, node *hashtree.NodeProto, full bool) *pfs.FileInfo {
 	fileInfo := &pfs.FileInfo{
 		File: &pfs.File{
@@ -1346,6 +2465,7 @@ func nodeToFileInfo(commit *pfs.Commit, path string, node *hashtree.NodeProto, f
 		fileInfo.FileType = pfs.FileType_FILE
 		if full {
 			fileInfo.Objects = node.FileNode.Objects
+			fileInfo.Provenance = node.FileNode.Provenance
 		}
 	} else if node.DirNode != nil {
 		fileInfo.FileType = pfs.FileType_DIR
@@ -1370,40 +2490,79 @@ func (d *driver) inspectFile(ctx context.Context, file *pfs.File) (*pfs.FileInfo
 	return nodeToFileInfo(file.Commit, file.Path, node, true), nil
 }
 
-func (d *driver) listFile(ctx context.Context, file *pfs.File) ([]*pfs.FileInfo, error) {
+func (d *driver) listFile(ctx context.Context, file *pfs.File, from string, number uint64) ([]*pfs.FileInfo, error) {
+	var fileInfos []*pfs.FileInfo
+	if err := d.listFileF(ctx, file, from, number, func(fileInfo *pfs.FileInfo) error {
+		fileInfos = append(fileInfos, fileInfo)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return fileInfos, nil
+}
+
+// listFileF calls f with the FileInfo of each child of 'file', stopping at the
+// first error f returns. If 'from' is set, only the children after the file
+// at 'from' are listed, and if 'number' is nonzero at most 'number' of them
+// are, so that huge directories can be listed a page at a time.
+func (d *driver) listFileF(ctx context.Context, file *pfs.File, from string, number uint64, f func(*pfs.FileInfo) error) error {
 	tree, err := d.getTreeForCommit(ctx, file.Commit)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	nodes, err := tree.List(file.Path)
+	// 'from' is the path of the last file of the previous page, but the tree
+	// indexes a directory's children by name
+	var fromName string
+	if from != "" {
+		if path.Clean("/"+path.Dir(from)) != path.Clean("/"+file.Path) {
+			return fmt.Errorf("%s is not in the directory %s", from, file.Path)
+		}
+		fromName = path.Base(from)
+	}
+	nodes, err := tree.ListPage(file.Path, fromName, int64(number))
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	var fileInfos []*pfs.FileInfo
 	for _, node := range nodes {
-		fileInfos = append(fileInfos, nodeToFileInfo(file.Commit, path.Join(file.Path, node.Name), node, false))
+		if err := f(nodeToFileInfo(file.Commit, path.Join(file.Path, node.Name), node, false)); err != nil {
+			return err
+		}
 	}
-	return fileInfos, nil
+	return nil
 }
 
 func (d *driver) globFile(ctx context.Context, commit *pfs.Commit, pattern string) ([]*pfs.FileInfo, error) {
+	var fileInfos []*pfs.FileInfo
+	if err := d.globFileF(ctx, commit, pattern, func(fileInfo *pfs.FileInfo) error {
+		fileInfos = append(fileInfos, fileInfo)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return fileInfos, nil
+}
+
+// globFileF calls f with the FileInfo of each file that matches 'pattern', in
+// order of their paths, stopping at the first error f returns.
+func (d *driver) globFileF(ctx context.Context, commit *pfs.Commit, pattern string, f func(*pfs.FileInfo) error) error {
 	tree, err := d.getTreeForCommit(ctx, commit)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	nodes, err := tree.Glob(pattern)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	var fileInfos []*pfs.FileInfo
 	for _, node := range nodes {
-		fileInfos = append(fileInfos, nodeToFileInfo(commit, node.Name, node, false))
+		if err := f(nodeToFileInfo(commit, node.Name, node, false)); err != nil {
+			return err
+		}
 	}
-	return fileInfos, nil
+	return nil
 }
 
 func (d *driver) deleteFile(ctx context.Context, file *pfs.File) error {
@@ -1425,8 +2584,76 @@ func (d *driver) deleteFile(ctx context.Context, file *pfs.File) error {
 	return err
 }
 
+// deleteFiles deletes a set of files, and/or all of the files matching a
+// glob pattern, from an open commit. All of the deletions are recorded in a
+// single etcd key so they're applied atomically when the commit is finished.
+// The pattern is evaluated against the state of the commit at the time of
+// the call, i.e. files put after DeleteFiles returns aren't deleted.
+func (d *driver) deleteFiles(ctx context.Context, commit *pfs.Commit, paths []string, pattern string) error {
+	commitInfo, err := d.inspectCommit(ctx, commit)
+	if err != nil {
+		return err
+	}
+
+	if commitInfo.Finished != nil {
+		return pfsserver.ErrCommitFinished{commit}
+	}
+
+	for _, p := range paths {
+		if err := checkPath(p); err != nil {
+			return err
+		}
+	}
+	if pattern != "" {
+		// validate the pattern now, rather than failing when the commit is
+		// finished
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("glob \"%s\" is malformed", pattern)
+		}
+	}
+
+	prefix, err := d.scratchCommitPrefix(ctx, commit)
+	if err != nil {
+		return err
+	}
+	marshalledRecord, err := proto.Marshal(&DeleteFilesRecord{
+		Paths:   paths,
+		Pattern: pattern,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = d.etcdClient.Put(ctx, path.Join(prefix, deleteFilesKey, uuid.NewWithoutDashes()), string(marshalledRecord))
+	return err
+}
+
+// applyDeleteFiles deletes the files recorded in a DeleteFilesRecord from tree.
+func applyDeleteFiles(tree hashtree.OpenHashTree, record *DeleteFilesRecord) error {
+	paths := record.Paths
+	if record.Pattern != "" {
+		nodes, err := tree.Glob(record.Pattern)
+		if err != nil {
+			return err
+		}
+		for _, node := range nodes {
+			paths = append(paths, node.Name)
+		}
+	}
+	for _, p := range paths {
+		if err := tree.DeleteFile(p); err != nil {
+			// Deleting a non-existent file in an open commit should
+			// be a no-op, the file may also have been a child of a
+			// directory we've already deleted.
+			if hashtree.Code(err) != hashtree.PathNotFound {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func (d *driver) deleteAll(ctx context.Context) error {
-	repoInfos, err := d.listRepo(ctx, nil)
+	repoInfos, err := d.listRepo(ctx, nil, "")
 	if err != nil {
 		return err
 	}
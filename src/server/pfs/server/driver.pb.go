@@ -11,6 +11,7 @@ It is generated from these files:
 It has these top-level messages:
 	PutFileRecord
 	PutFileRecords
+	DeleteFilesRecord
 */
 package server
 
@@ -78,24 +79,53 @@ func (m *PutFileRecords) GetRecords() []*PutFileRecord {
 	return nil
 }
 
+// DeleteFilesRecord is used to record DeleteFiles requests in etcd temporarily.
+type DeleteFilesRecord struct {
+	Paths   []string `protobuf:"bytes,1,rep,name=paths" json:"paths,omitempty"`
+	Pattern string   `protobuf:"bytes,2,opt,name=pattern,proto3" json:"pattern,omitempty"`
+}
+
+func (m *DeleteFilesRecord) Reset()                    { *m = DeleteFilesRecord{} }
+func (m *DeleteFilesRecord) String() string            { return proto.CompactTextString(m) }
+func (*DeleteFilesRecord) ProtoMessage()               {}
+func (*DeleteFilesRecord) Descriptor() ([]byte, []int) { return fileDescriptorDriver, []int{2} }
+
+func (m *DeleteFilesRecord) GetPaths() []string {
+	if m != nil {
+		return m.Paths
+	}
+	return nil
+}
+
+func (m *DeleteFilesRecord) GetPattern() string {
+	if m != nil {
+		return m.Pattern
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterType((*PutFileRecord)(nil), "server.PutFileRecord")
 	proto.RegisterType((*PutFileRecords)(nil), "server.PutFileRecords")
+	proto.RegisterType((*DeleteFilesRecord)(nil), "server.DeleteFilesRecord")
 }
 
 func init() { proto.RegisterFile("server/pfs/server/driver.proto", fileDescriptorDriver) }
 
 var fileDescriptorDriver = []byte{
-	// 173 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xe2, 0x92, 0x2b, 0x4e, 0x2d, 0x2a,
-	0x4b, 0x2d, 0xd2, 0x2f, 0x48, 0x2b, 0xd6, 0x87, 0x32, 0x53, 0x8a, 0x32, 0xcb, 0x52, 0x8b, 0xf4,
-	0x0a, 0x8a, 0xf2, 0x4b, 0xf2, 0x85, 0xd8, 0x20, 0x82, 0x4a, 0x7e, 0x5c, 0xbc, 0x01, 0xa5, 0x25,
-	0x6e, 0x99, 0x39, 0xa9, 0x41, 0xa9, 0xc9, 0xf9, 0x45, 0x29, 0x42, 0xb2, 0x5c, 0x5c, 0xc5, 0x99,
-	0x55, 0xa9, 0xf1, 0x49, 0x95, 0x25, 0xa9, 0xc5, 0x12, 0x8c, 0x0a, 0x8c, 0x1a, 0xcc, 0x41, 0x9c,
-	0x20, 0x11, 0x27, 0x90, 0x80, 0x90, 0x1c, 0x17, 0x57, 0x7e, 0x52, 0x56, 0x6a, 0x72, 0x89, 0x47,
-	0x62, 0x71, 0x86, 0x04, 0x93, 0x02, 0xa3, 0x06, 0x67, 0x10, 0x92, 0x88, 0x52, 0x38, 0x17, 0x1f,
-	0x8a, 0x79, 0xc5, 0x42, 0x22, 0x5c, 0xac, 0xc5, 0x05, 0x39, 0x99, 0x25, 0x60, 0xb3, 0x38, 0x82,
-	0x20, 0x1c, 0x21, 0x7d, 0x2e, 0xf6, 0x22, 0x88, 0x02, 0x09, 0x26, 0x05, 0x66, 0x0d, 0x6e, 0x23,
-	0x51, 0x3d, 0x88, 0x8b, 0xf4, 0x50, 0xb4, 0x07, 0xc1, 0x54, 0x25, 0xb1, 0x81, 0xdd, 0x6d, 0x0c,
-	0x08, 0x00, 0x00, 0xff, 0xff, 0x03, 0xd5, 0x7a, 0x70, 0xd9, 0x00, 0x00, 0x00,
+	// 211 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x54, 0x8f, 0x41, 0x4b, 0xc4, 0x30,
+	0x10, 0x85, 0xe9, 0x96, 0xdd, 0xb5, 0x23, 0x0a, 0x86, 0x15, 0x72, 0x71, 0x29, 0x3d, 0xe5, 0xd4,
+	0x82, 0xfe, 0x03, 0x15, 0xf1, 0x24, 0x92, 0x8b, 0x47, 0x69, 0x77, 0x47, 0x36, 0x52, 0x4c, 0x98,
+	0x19, 0x0b, 0xfa, 0xeb, 0x25, 0x4d, 0x0b, 0xf6, 0x96, 0xf7, 0xe5, 0xe5, 0xf1, 0x05, 0xf6, 0x8c,
+	0x34, 0x20, 0x35, 0xe1, 0x83, 0x9b, 0xe9, 0x78, 0x24, 0x37, 0x20, 0xd5, 0x81, 0xbc, 0x78, 0xb5,
+	0x49, 0xb0, 0x7a, 0x81, 0x8b, 0xd7, 0x6f, 0x79, 0x72, 0x3d, 0x5a, 0x3c, 0x78, 0x3a, 0xaa, 0x1b,
+	0x00, 0x76, 0xbf, 0xf8, 0xde, 0xfd, 0x08, 0xb2, 0xce, 0xca, 0xcc, 0xe4, 0xb6, 0x88, 0xe4, 0x3e,
+	0x02, 0xb5, 0x07, 0xf0, 0xdd, 0x27, 0x1e, 0xe4, 0xb9, 0xe5, 0x93, 0x5e, 0x95, 0x99, 0x29, 0xec,
+	0x3f, 0x52, 0xbd, 0xc1, 0xe5, 0x62, 0x8f, 0xd5, 0x0e, 0xd6, 0x1c, 0x7a, 0x27, 0xe3, 0xd6, 0x99,
+	0x4d, 0x41, 0x35, 0xb0, 0xa5, 0x54, 0xd0, 0xab, 0x32, 0x37, 0xe7, 0xb7, 0xd7, 0x75, 0x32, 0xaa,
+	0x17, 0xcf, 0xed, 0xdc, 0xaa, 0x1e, 0xe0, 0xea, 0x11, 0x7b, 0x14, 0x8c, 0x97, 0x3c, 0xc9, 0xee,
+	0x60, 0x1d, 0x5a, 0x39, 0x45, 0xcf, 0xdc, 0x14, 0x36, 0x05, 0xa5, 0x61, 0x1b, 0x5a, 0x11, 0xa4,
+	0xaf, 0x49, 0x70, 0x8e, 0xdd, 0x66, 0xfc, 0xfc, 0xdd, 0xdf, 0x00, 0x7d, 0xa6, 0xff, 0x45, 0x1e,
+	0x01, 0x00, 0x00,
 }
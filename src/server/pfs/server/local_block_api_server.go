@@ -173,6 +173,18 @@ func (s *localBlockAPIServer) InspectObject(ctx context.Context, request *pfscli
 	}, nil
 }
 
+func (s *localBlockAPIServer) CheckObject(ctx context.Context, request *pfsclient.CheckObjectRequest) (response *pfsclient.CheckObjectResponse, retErr error) {
+	func() { s.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { s.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	if _, err := os.Stat(s.objectPath(request.Object)); err != nil {
+		if os.IsNotExist(err) {
+			return &pfsclient.CheckObjectResponse{}, nil
+		}
+		return nil, err
+	}
+	return &pfsclient.CheckObjectResponse{Exists: true}, nil
+}
+
 func (s *localBlockAPIServer) GetTag(request *pfsclient.Tag, getTagServer pfsclient.ObjectAPI_GetTagServer) (retErr error) {
 	func() { s.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) { s.Log(request, nil, retErr, time.Since(start)) }(time.Now())
@@ -202,6 +214,11 @@ func (s *localBlockAPIServer) Compact(ctx context.Context, request *types.Empty)
 	return &types.Empty{}, nil
 }
 
+func (s *localBlockAPIServer) CheckStorage() error {
+	_, err := os.Stat(s.objectDir())
+	return err
+}
+
 func (s *localBlockAPIServer) blockDir() string {
 	return filepath.Join(s.dir, "block")
 }
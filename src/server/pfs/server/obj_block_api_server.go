@@ -143,17 +143,12 @@ func (s *objBlockAPIServer) PutObject(server pfsclient.ObjectAPI_PutObjectServer
 		return err
 	}
 	object := &pfsclient.Object{Hash: hex.EncodeToString(hash.Sum(nil))}
-	if err := server.SendAndClose(object); err != nil {
-		return err
-	}
-	var eg errgroup.Group
 	// Now that we have a hash of the object we can check if it already exists.
-	_, err := s.InspectObject(server.Context(), object)
-	if err == nil {
+	if _, err := s.InspectObject(server.Context(), object); err == nil {
 		// the object already exists so we delete the block we put
-		eg.Go(func() error {
-			return s.objClient.Delete(s.localServer.blockPath(block))
-		})
+		if err := s.objClient.Delete(s.localServer.blockPath(block)); err != nil {
+			return err
+		}
 	} else {
 		blockRef := &pfsclient.BlockRef{
 			Block: block,
@@ -162,10 +157,15 @@ func (s *objBlockAPIServer) PutObject(server pfsclient.ObjectAPI_PutObjectServer
 				Upper: uint64(size),
 			},
 		}
-		eg.Go(func() error {
-			return s.writeProto(s.localServer.objectPath(object), blockRef)
-		})
+		if err := s.writeProto(s.localServer.objectPath(object), blockRef); err != nil {
+			return err
+		}
 	}
+	// The tags are only written once the object is indexed, and the object
+	// is only returned once both are, so that if pachd dies part way
+	// through, no tag, and nothing the client built from the object,
+	// refers to an object that doesn't exist.
+	var eg errgroup.Group
 	for _, tag := range putObjectReader.tags {
 		tag := hashTag(tag)
 		eg.Go(func() (retErr error) {
@@ -173,7 +173,10 @@ func (s *objBlockAPIServer) PutObject(server pfsclient.ObjectAPI_PutObjectServer
 			return s.writeProto(s.localServer.tagPath(tag), index)
 		})
 	}
-	return eg.Wait()
+	if err := eg.Wait(); err != nil {
+		return err
+	}
+	return server.SendAndClose(object)
 }
 
 func (s *objBlockAPIServer) GetObject(request *pfsclient.Object, getObjectServer pfsclient.ObjectAPI_GetObjectServer) (retErr error) {
@@ -296,6 +299,25 @@ func (s *objBlockAPIServer) InspectObject(ctx context.Context, request *pfsclien
 	return objectInfo, nil
 }
 
+// CheckObject checks both that the object is in an index and that the block
+// the index says it's in exists, objects can be lost if blocks are deleted
+// from the bucket behind our back (e.g. by a lifecycle policy).
+func (s *objBlockAPIServer) CheckObject(ctx context.Context, request *pfsclient.CheckObjectRequest) (response *pfsclient.CheckObjectResponse, retErr error) {
+	func() { s.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { s.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	objectInfo := &pfsclient.ObjectInfo{}
+	sink := groupcache.ProtoSink(objectInfo)
+	if err := s.objectInfoCache.Get(ctx, splitKey(request.Object.Hash), sink); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return &pfsclient.CheckObjectResponse{}, nil
+		}
+		return nil, err
+	}
+	return &pfsclient.CheckObjectResponse{
+		Exists: s.objClient.Exists(s.localServer.blockPath(objectInfo.BlockRef.Block)),
+	}, nil
+}
+
 func (s *objBlockAPIServer) GetTag(request *pfsclient.Tag, getTagServer pfsclient.ObjectAPI_GetTagServer) (retErr error) {
 	func() { s.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) { s.Log(request, nil, retErr, time.Since(start)) }(time.Now())
@@ -327,6 +349,19 @@ func (s *objBlockAPIServer) Compact(ctx context.Context, request *types.Empty) (
 	return &types.Empty{}, nil
 }
 
+// CheckStorage reads an object that doesn't exist, which fails with a
+// NotExist error as long as the object store can be reached.
+func (s *objBlockAPIServer) CheckStorage() error {
+	r, err := s.objClient.Reader(uuid.NewWithoutDashes(), 0, 0)
+	if err == nil {
+		return r.Close()
+	}
+	if s.objClient.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
 func (s *objBlockAPIServer) objectPrefix(prefix string) string {
 	return s.localServer.objectPath(&pfsclient.Object{Hash: prefix})
 }
@@ -0,0 +1,222 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+
+	"github.com/gogo/protobuf/types"
+	protolion "go.pedge.io/lion/proto"
+)
+
+// retentionInterval is how often pachd prunes the branches that have
+// retention policies. Branches are also pruned when their policies are set.
+const retentionInterval = 10 * time.Minute
+
+// validateRetention checks that retention is well formed.
+func validateRetention(retention *pfs.RetentionPolicy) error {
+	if retention.KeepCommits < 0 {
+		return fmt.Errorf("retention policy can't keep a negative number of commits")
+	}
+	if retention.KeepFor != nil {
+		keepFor, err := types.DurationFromProto(retention.KeepFor)
+		if err != nil {
+			return err
+		}
+		if keepFor <= 0 {
+			return fmt.Errorf("retention policy must keep commits for a positive duration")
+		}
+	}
+	if retention.KeepCommits == 0 && retention.KeepFor == nil {
+		return fmt.Errorf("retention policy must have at least one condition: a number of commits or a duration to keep them for")
+	}
+	return nil
+}
+
+// prunedCommits returns the IDs of the ancestors of the commit headID, the
+// head of a branch, that retention doesn't keep, given all of the commits in
+// its repo in commitInfos. The head and commits that aren't finished are
+// always kept.
+func prunedCommits(retention *pfs.RetentionPolicy, headID string, commitInfos map[string]*pfs.CommitInfo, now time.Time) (map[string]bool, error) {
+	var keepFor time.Duration
+	if retention.KeepFor != nil {
+		var err error
+		if keepFor, err = types.DurationFromProto(retention.KeepFor); err != nil {
+			return nil, err
+		}
+	}
+	result := make(map[string]bool)
+	var n int64
+	for commitInfo := commitInfos[headID]; commitInfo != nil; n++ {
+		keep := n == 0 || commitInfo.Finished == nil || n < retention.KeepCommits
+		if !keep && retention.KeepFor != nil {
+			finished, err := types.TimestampFromProto(commitInfo.Finished)
+			if err != nil {
+				return nil, err
+			}
+			keep = now.Sub(finished) < keepFor
+		}
+		if !keep {
+			result[commitInfo.Commit.ID] = true
+		}
+		if commitInfo.ParentCommit == nil {
+			break
+		}
+		commitInfo = commitInfos[commitInfo.ParentCommit.ID]
+	}
+	return result, nil
+}
+
+// setBranchRetention sets the retention policy of branch, which must exist,
+// or removes it if retention is nil. The branch is pruned right away.
+func (d *driver) setBranchRetention(ctx context.Context, repo *pfs.Repo, branch string, retention *pfs.RetentionPolicy) error {
+	if retention != nil {
+		if err := validateRetention(retention); err != nil {
+			return err
+		}
+	}
+	if _, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		branches := d.branches(repo.Name).ReadWrite(stm)
+		retentions := d.retentions(repo.Name).ReadWrite(stm)
+		head := new(pfs.Commit)
+		if err := branches.Get(branch, head); err != nil {
+			if isNotFoundErr(err) {
+				return fmt.Errorf("branch %s not found in repo %s, it must be set before it can have a retention policy", branch, repo.Name)
+			}
+			return err
+		}
+		if retention == nil {
+			if err := retentions.Delete(branch); err != nil && !isNotFoundErr(err) {
+				return err
+			}
+			return nil
+		}
+		retentions.Put(branch, retention)
+		return nil
+	}); err != nil {
+		return err
+	}
+	if retention != nil {
+		// the policy has been set already, so an error pruning the branch
+		// isn't returned, it's retried by watchRetention
+		if err := d.pruneBranch(ctx, repo, branch, retention); err != nil {
+			protolion.Errorf("error pruning branch %s in repo %s: %v", branch, repo.Name, err)
+		}
+	}
+	return nil
+}
+
+// pruneRepo prunes each of repo's branches that has a retention policy.
+func (d *driver) pruneRepo(ctx context.Context, repo *pfs.Repo) error {
+	iterator, err := d.retentions(repo.Name).ReadOnly(ctx).List()
+	if err != nil {
+		return err
+	}
+	for {
+		var branch string
+		retention := new(pfs.RetentionPolicy)
+		ok, err := iterator.Next(&branch, retention)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+		if err := d.pruneBranch(ctx, repo, branch, retention); err != nil {
+			return fmt.Errorf("error pruning branch %s in repo %s: %v", branch, repo.Name, err)
+		}
+	}
+}
+
+// pruneBranch deletes the commits on branch that retention doesn't keep.
+// Commits that are the heads of other branches, or that are the provenance
+// of commits in other repos, are never deleted, so pruning doesn't move
+// branches or remove the inputs of pipelines' output. Unlike deleteCommit,
+// the descendants of pruned commits are kept; they're reparented to their
+// closest ancestors that are kept.
+func (d *driver) pruneBranch(ctx context.Context, repo *pfs.Repo, branch string, retention *pfs.RetentionPolicy) error {
+	head := new(pfs.Commit)
+	if err := d.branches(repo.Name).ReadOnly(ctx).Get(branch, head); err != nil {
+		if isNotFoundErr(err) {
+			return nil
+		}
+		return err
+	}
+	commitInfos, err := d.allCommits(ctx, repo)
+	if err != nil {
+		return err
+	}
+	pruned, err := prunedCommits(retention, head.ID, commitInfos, time.Now())
+	if err != nil || len(pruned) == 0 {
+		return err
+	}
+	branches, err := d.listBranch(ctx, repo)
+	if err != nil {
+		return err
+	}
+	for _, branch := range branches {
+		delete(pruned, branch.Head.ID)
+	}
+	// provenance is transitive, so the commits that have pruned commits as
+	// provenance are all in the repos that have repo as provenance
+	iter, err := d.repos.ReadOnly(ctx).GetByIndex(provenanceIndex, repo)
+	if err != nil {
+		return err
+	}
+	for len(pruned) > 0 {
+		var repoName string
+		repoInfo := new(pfs.RepoInfo)
+		ok, err := iter.Next(&repoName, repoInfo)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		downstream, err := d.allCommits(ctx, repoInfo.Repo)
+		if err != nil {
+			return err
+		}
+		for _, commitInfo := range downstream {
+			for _, prov := range commitInfo.Provenance {
+				if prov.Repo.Name == repo.Name {
+					delete(pruned, prov.ID)
+				}
+			}
+		}
+	}
+	if len(pruned) == 0 {
+		return nil
+	}
+	return d.removeCommits(ctx,
+		map[string]map[string]*pfs.CommitInfo{repo.Name: commitInfos},
+		map[string]map[string]bool{repo.Name: pruned},
+	)
+}
+
+// watchRetention prunes every repo's branches every retentionInterval,
+// until ctx is cancelled.
+func (d *driver) watchRetention(ctx context.Context) {
+	ticker := time.NewTicker(retentionInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		repoInfos, err := d.listRepo(ctx, nil, "")
+		if err != nil {
+			protolion.Errorf("error listing repos to prune their branches: %v", err)
+			continue
+		}
+		for _, repoInfo := range repoInfos {
+			if err := d.pruneRepo(ctx, repoInfo.Repo); err != nil {
+				protolion.Errorf("error pruning branches: %v", err)
+			}
+		}
+	}
+}
@@ -1,9 +1,14 @@
 package server
 
 import (
+	"net/http"
+
+	etcd "github.com/coreos/etcd/clientv3"
 	pfsclient "github.com/pachyderm/pachyderm/src/client/pfs"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
 	"github.com/pachyderm/pachyderm/src/server/pkg/metrics"
 	"github.com/pachyderm/pachyderm/src/server/pkg/obj"
+	"google.golang.org/grpc/credentials"
 )
 
 // Valid object storage backends
@@ -23,16 +28,26 @@ var (
 // APIServer represents and api server.
 type APIServer interface {
 	pfsclient.APIServer
+	// DownloadHandler returns the HTTP handler that serves the URLs that
+	// GetFileURL returns, it's mounted at DownloadPrefix.
+	DownloadHandler() http.Handler
 }
 
 // BlockAPIServer combines BlockAPIServer and ObjectAPIServer.
 type BlockAPIServer interface {
 	pfsclient.ObjectAPIServer
+	// CheckStorage returns an error if the server's storage can't be
+	// reached, it's one of pachd's health checks.
+	CheckStorage() error
 }
 
-// NewAPIServer creates an APIServer.
-func NewAPIServer(address string, etcdAddresses []string, etcdPrefix string, cacheBytes int64, reporter *metrics.Reporter) (APIServer, error) {
-	return newAPIServer(address, etcdAddresses, etcdPrefix, cacheBytes, reporter)
+// NewAPIServer creates an APIServer. peerCreds secure its connection to
+// pachd at address if pachd serves TLS, they're nil if it doesn't.
+// downloadURL is the URL that clients reach pachd's HTTP server at, download
+// URLs aren't returned if it's empty. If cipher isn't nil, the key that
+// download URLs are signed with is encrypted with it in etcd.
+func NewAPIServer(address string, etcdConfig etcd.Config, etcdPrefix string, cacheBytes int64, internalToken string, peerCreds credentials.TransportCredentials, reporter *metrics.Reporter, downloadURL string, cipher *col.Cipher) (APIServer, error) {
+	return newAPIServer(address, etcdConfig, etcdPrefix, cacheBytes, internalToken, peerCreds, reporter, downloadURL, cipher)
 }
 
 // NewLocalBlockAPIServer creates a BlockAPIServer.
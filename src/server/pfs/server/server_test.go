@@ -2,11 +2,19 @@ package server
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
 	"path"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -20,6 +28,7 @@ import (
 	"github.com/pachyderm/pachyderm/src/client/pkg/require"
 	"github.com/pachyderm/pachyderm/src/client/pkg/uuid"
 	"github.com/pachyderm/pachyderm/src/client/version"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
 	pfssync "github.com/pachyderm/pachyderm/src/server/pkg/sync"
 
 	"golang.org/x/net/context"
@@ -69,6 +78,310 @@ func TestCreateRepoNonexistantProvenance(t *testing.T) {
 	require.YesError(t, err)
 }
 
+func TestRepoLabels(t *testing.T) {
+	client := getClient(t)
+	for _, repo := range []struct {
+		name   string
+		labels map[string]string
+	}{
+		{"logs", map[string]string{"team": "data", "stage": "prod"}},
+		{"metrics", map[string]string{"team": "data", "stage": "dev"}},
+		{"images", map[string]string{"team": "ml"}},
+	} {
+		_, err := client.PfsAPIClient.CreateRepo(
+			context.Background(),
+			&pfs.CreateRepoRequest{
+				Repo:   pclient.NewRepo(repo.name),
+				Labels: repo.labels,
+			},
+		)
+		require.NoError(t, err)
+	}
+	repoNames := func(selector string) []string {
+		repoInfos, err := client.ListRepoByLabel(selector)
+		require.NoError(t, err)
+		var names []string
+		for _, repoInfo := range repoInfos {
+			names = append(names, repoInfo.Repo.Name)
+		}
+		sort.Strings(names)
+		return names
+	}
+	require.Equal(t, []string{"logs", "metrics"}, repoNames("team=data"))
+	require.Equal(t, []string{"logs"}, repoNames("team=data,stage!=dev"))
+	require.Equal(t, []string{"images"}, repoNames("!stage"))
+	require.Equal(t, 3, len(repoNames("")))
+
+	require.NoError(t, client.SetRepoLabels("images", map[string]string{"stage": "dev"}))
+	require.NoError(t, client.RemoveRepoLabels("metrics", "team"))
+	require.Equal(t, []string{"images", "metrics"}, repoNames("stage=dev"))
+	require.Equal(t, []string{"logs"}, repoNames("team=data"))
+	repoInfo, err := client.InspectRepo("images")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"team": "ml", "stage": "dev"}, repoInfo.Labels)
+
+	// invalid labels and selectors are rejected
+	require.YesError(t, client.SetRepoLabels("images", map[string]string{"bad key": "value"}))
+	_, err = client.ListRepoByLabel("team in (")
+	require.YesError(t, err)
+}
+
+func TestBranchTrigger(t *testing.T) {
+	client := getClient(t)
+	repo := "TestBranchTrigger"
+	require.NoError(t, client.CreateRepo(repo))
+	commit := func() string {
+		commit, err := client.StartCommit(repo, "staging")
+		require.NoError(t, err)
+		_, err = client.PutFile(repo, commit.ID, commit.ID, strings.NewReader("foo\n"))
+		require.NoError(t, err)
+		require.NoError(t, client.FinishCommit(repo, commit.ID))
+		return commit.ID
+	}
+	master := func() string {
+		branches, err := client.ListBranch(repo)
+		require.NoError(t, err)
+		for _, branch := range branches {
+			if branch.Name == "master" {
+				require.Equal(t, "staging", branch.Trigger.Branch)
+				return branch.Head.ID
+			}
+		}
+		t.Fatal("master not found")
+		return ""
+	}
+
+	// the branch must exist before it can have a trigger
+	require.YesError(t, client.SetBranchTrigger(repo, "master", &pfs.Trigger{Branch: "staging", Commits: 2}))
+	first := commit()
+	require.NoError(t, client.SetBranch(repo, first, "master"))
+	require.YesError(t, client.SetBranchTrigger(repo, "master", &pfs.Trigger{Branch: "staging"}))
+	require.YesError(t, client.SetBranchTrigger(repo, "master", &pfs.Trigger{Branch: "master", Commits: 2}))
+	require.NoError(t, client.SetBranchTrigger(repo, "master", &pfs.Trigger{Branch: "staging", Commits: 2}))
+
+	commit()
+	require.Equal(t, first, master())
+	third := commit()
+	require.Equal(t, third, master())
+
+	// an unfinished head doesn't move the branch
+	require.NoError(t, client.SetBranchTrigger(repo, "master", &pfs.Trigger{Branch: "staging", Commits: 1}))
+	fourth, err := client.StartCommit(repo, "staging")
+	require.NoError(t, err)
+	require.Equal(t, third, master())
+	require.NoError(t, client.FinishCommit(repo, fourth.ID))
+	require.Equal(t, fourth.ID, master())
+
+	// without its trigger the branch stays put
+	require.NoError(t, client.SetBranchTrigger(repo, "master", nil))
+	commit()
+	branches, err := client.ListBranch(repo)
+	require.NoError(t, err)
+	for _, branch := range branches {
+		if branch.Name == "master" {
+			require.Nil(t, branch.Trigger)
+			require.Equal(t, fourth.ID, branch.Head.ID)
+		}
+	}
+}
+
+func TestTriggerMet(t *testing.T) {
+	start := time.Unix(1000, 0)
+	timestamp := func(t time.Time) *types.Timestamp {
+		ts, _ := types.TimestampProto(t)
+		return ts
+	}
+	// a chain of commits, each 1KB bigger and a minute later than its parent
+	var commitInfos []*pfs.CommitInfo
+	for i := 0; i < 5; i++ {
+		commitInfo := &pfs.CommitInfo{
+			Commit:    pclient.NewCommit("repo", strconv.Itoa(i)),
+			SizeBytes: uint64(i * 1024),
+			Started:   timestamp(start.Add(time.Duration(i) * time.Minute)),
+			Finished:  timestamp(start.Add(time.Duration(i) * time.Minute)),
+		}
+		if i > 0 {
+			commitInfo.ParentCommit = commitInfos[i-1].Commit
+		}
+		commitInfos = append(commitInfos, commitInfo)
+	}
+	getCommit := func(id string) (*pfs.CommitInfo, error) {
+		i, err := strconv.Atoi(id)
+		if err != nil {
+			return nil, err
+		}
+		return commitInfos[i], nil
+	}
+	now := start.Add(4 * time.Minute)
+	for _, c := range []struct {
+		trigger *pfs.Trigger
+		met     bool
+	}{
+		{&pfs.Trigger{Size_: "3KB"}, true},
+		{&pfs.Trigger{Size_: "4KB"}, false},
+		{&pfs.Trigger{Commits: 3}, true},
+		{&pfs.Trigger{Commits: 4}, false},
+		{&pfs.Trigger{CronSpec: "@every 3m"}, true},
+		{&pfs.Trigger{CronSpec: "@every 4m"}, false},
+		{&pfs.Trigger{Size_: "4KB", Commits: 3}, true},
+		{&pfs.Trigger{Size_: "4KB", Commits: 3, All: true}, false},
+		{&pfs.Trigger{Size_: "3KB", Commits: 3, All: true}, true},
+	} {
+		met, err := triggerMet(c.trigger, commitInfos[4], commitInfos[1], getCommit, now)
+		require.NoError(t, err)
+		require.Equal(t, c.met, met, "%v", c.trigger)
+	}
+}
+
+func TestBranchRetention(t *testing.T) {
+	client := getClient(t)
+	repo := "TestBranchRetention"
+	require.NoError(t, client.CreateRepo(repo))
+	var commits []string
+	for i := 0; i < 5; i++ {
+		commit, err := client.StartCommit(repo, "master")
+		require.NoError(t, err)
+		_, err = client.PutFile(repo, commit.ID, commit.ID, strings.NewReader("foo\n"))
+		require.NoError(t, err)
+		require.NoError(t, client.FinishCommit(repo, commit.ID))
+		commits = append(commits, commit.ID)
+	}
+	// the second commit is the head of another branch, so it's kept
+	require.NoError(t, client.SetBranch(repo, commits[1], "old"))
+
+	require.YesError(t, client.SetBranchRetention(repo, "nonexistent", &pfs.RetentionPolicy{KeepCommits: 2}))
+	require.YesError(t, client.SetBranchRetention(repo, "master", &pfs.RetentionPolicy{}))
+	require.YesError(t, client.SetBranchRetention(repo, "master", &pfs.RetentionPolicy{KeepCommits: -1}))
+	require.NoError(t, client.SetBranchRetention(repo, "master", &pfs.RetentionPolicy{KeepCommits: 2}))
+
+	commitInfos, err := client.ListCommit(repo, "", "", 0)
+	require.NoError(t, err)
+	var ids []string
+	for _, commitInfo := range commitInfos {
+		ids = append(ids, commitInfo.Commit.ID)
+	}
+	expected := []string{commits[1], commits[3], commits[4]}
+	sort.Strings(expected)
+	sort.Strings(ids)
+	require.Equal(t, expected, ids)
+	// the kept commits still have all of their data, and are reparented
+	commitInfo, err := client.InspectCommit(repo, commits[3])
+	require.NoError(t, err)
+	require.Equal(t, commits[1], commitInfo.ParentCommit.ID)
+	fileInfos, err := client.ListFile(repo, commits[4], "")
+	require.NoError(t, err)
+	require.Equal(t, 5, len(fileInfos))
+
+	branches, err := client.ListBranch(repo)
+	require.NoError(t, err)
+	for _, branch := range branches {
+		if branch.Name == "master" {
+			require.Equal(t, int64(2), branch.Retention.KeepCommits)
+		}
+	}
+	require.NoError(t, client.SetBranchRetention(repo, "master", nil))
+	branches, err = client.ListBranch(repo)
+	require.NoError(t, err)
+	for _, branch := range branches {
+		require.Nil(t, branch.Retention)
+	}
+}
+
+func TestRemoveManyCommits(t *testing.T) {
+	t.Parallel()
+	client := getClient(t)
+	// there are more commits than etcd allows in one transaction
+	repo := "TestRemoveManyCommits"
+	require.NoError(t, client.CreateRepo(repo))
+	var commits []string
+	for i := 0; i < 300; i++ {
+		commit, err := client.StartCommit(repo, "master")
+		require.NoError(t, err)
+		require.NoError(t, client.FinishCommit(repo, commit.ID))
+		commits = append(commits, commit.ID)
+	}
+	require.NoError(t, client.SetBranch(repo, commits[100], "old"))
+
+	require.NoError(t, client.SetBranchRetention(repo, "master", &pfs.RetentionPolicy{KeepCommits: 2}))
+	commitInfos, err := client.ListCommit(repo, "", "", 0)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(commitInfos))
+	commitInfo, err := client.InspectCommit(repo, commits[298])
+	require.NoError(t, err)
+	require.Equal(t, commits[100], commitInfo.ParentCommit.ID)
+
+	require.NoError(t, client.SetBranchRetention(repo, "master", nil))
+	for i := 0; i < 300; i++ {
+		commit, err := client.StartCommit(repo, "master")
+		require.NoError(t, err)
+		require.NoError(t, client.FinishCommit(repo, commit.ID))
+	}
+	require.NoError(t, client.DeleteCommit(repo, commits[100]))
+	commitInfos, err = client.ListCommit(repo, "", "", 0)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(commitInfos))
+	branches, err := client.ListBranch(repo)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(branches))
+}
+
+func TestChildrenFirst(t *testing.T) {
+	commitInfos := make(map[string]*pfs.CommitInfo)
+	ids := make(map[string]bool)
+	var parent *pfs.Commit
+	for _, id := range []string{"a", "b", "c", "d"} {
+		commitInfos[id] = &pfs.CommitInfo{Commit: pclient.NewCommit("repo", id), ParentCommit: parent}
+		ids[id] = true
+		parent = pclient.NewCommit("repo", id)
+	}
+	commitInfos["e"] = &pfs.CommitInfo{Commit: pclient.NewCommit("repo", "e"), ParentCommit: pclient.NewCommit("repo", "b")}
+	ids["e"] = true
+	delete(ids, "a")
+	require.Equal(t, []string{"d", "c", "e", "b"}, childrenFirst(commitInfos, ids))
+}
+
+func TestPrunedCommits(t *testing.T) {
+	start := time.Unix(1000, 0)
+	timestamp := func(t time.Time) *types.Timestamp {
+		ts, _ := types.TimestampProto(t)
+		return ts
+	}
+	// a chain of commits, each finished a day after its parent, the last of
+	// which isn't finished
+	commitInfos := make(map[string]*pfs.CommitInfo)
+	for i := 0; i < 5; i++ {
+		commitInfo := &pfs.CommitInfo{
+			Commit:   pclient.NewCommit("repo", strconv.Itoa(i)),
+			Finished: timestamp(start.Add(time.Duration(i) * 24 * time.Hour)),
+		}
+		if i > 0 {
+			commitInfo.ParentCommit = pclient.NewCommit("repo", strconv.Itoa(i-1))
+		}
+		commitInfos[strconv.Itoa(i)] = commitInfo
+	}
+	commitInfos["4"].Finished = nil
+	now := start.Add(4 * 24 * time.Hour)
+	for _, c := range []struct {
+		retention *pfs.RetentionPolicy
+		pruned    []string
+	}{
+		{&pfs.RetentionPolicy{KeepCommits: 1}, []string{"0", "1", "2", "3"}},
+		{&pfs.RetentionPolicy{KeepCommits: 3}, []string{"0", "1"}},
+		{&pfs.RetentionPolicy{KeepCommits: 10}, nil},
+		{&pfs.RetentionPolicy{KeepFor: types.DurationProto(36 * time.Hour)}, []string{"0", "1", "2"}},
+		{&pfs.RetentionPolicy{KeepCommits: 2, KeepFor: types.DurationProto(60 * time.Hour)}, []string{"0", "1"}},
+	} {
+		pruned, err := prunedCommits(c.retention, "4", commitInfos, now)
+		require.NoError(t, err)
+		var ids []string
+		for id := range pruned {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		require.Equal(t, c.pruned, ids, "%v", c.retention)
+	}
+}
+
 func TestCreateSameRepoInParallel(t *testing.T) {
 	client := getClient(t)
 
@@ -390,17 +703,48 @@ func TestInspectCommit(t *testing.T) {
 	require.True(t, finished.After(tFinished))
 }
 
-func TestDeleteCommitFuture(t *testing.T) {
-	// For when DeleteCommit gets implemented
-	t.Skip()
+func TestCommitStats(t *testing.T) {
+	t.Parallel()
+	client := getClient(t)
+
+	repo := "TestCommitStats"
+	require.NoError(t, client.CreateRepo(repo))
+
+	commit1, err := client.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = client.PutFile(repo, commit1.ID, "dir/a", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	_, err = client.PutFile(repo, commit1.ID, "dir/b", strings.NewReader("bar\n"))
+	require.NoError(t, err)
+	_, err = client.PutFile(repo, commit1.ID, "c", strings.NewReader("baz\n"))
+	require.NoError(t, err)
+	require.NoError(t, client.FinishCommit(repo, commit1.ID))
+	commitInfo, err := client.InspectCommit(repo, commit1.ID)
+	require.NoError(t, err)
+	require.Equal(t, &pfs.CommitStats{FilesAdded: 3, SizeDeltaBytes: 12}, commitInfo.Stats)
+
+	commit2, err := client.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = client.PutFile(repo, commit2.ID, "dir/a", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	require.NoError(t, client.DeleteFile(repo, commit2.ID, "dir/b"))
+	_, err = client.PutFile(repo, commit2.ID, "d", strings.NewReader("quux\n"))
+	require.NoError(t, err)
+	require.NoError(t, client.FinishCommit(repo, commit2.ID))
+	commitInfo, err = client.InspectCommit(repo, commit2.ID)
+	require.NoError(t, err)
+	// dir/a is appended to, c is unchanged
+	require.Equal(t, &pfs.CommitStats{FilesAdded: 1, FilesDeleted: 1, FilesModified: 1, SizeDeltaBytes: 5}, commitInfo.Stats)
+}
 
+func TestDeleteCommit(t *testing.T) {
 	t.Parallel()
 	client := getClient(t)
 
-	repo := "test"
+	repo := "TestDeleteCommit"
 	require.NoError(t, client.CreateRepo(repo))
 
-	commit, err := client.StartCommit(repo, "")
+	commit, err := client.StartCommit(repo, "master")
 	require.NoError(t, err)
 
 	fileContent := "foo\n"
@@ -410,15 +754,72 @@ func TestDeleteCommitFuture(t *testing.T) {
 	require.NoError(t, client.FinishCommit(repo, commit.ID))
 
 	commitInfo, err := client.InspectCommit(repo, commit.ID)
+	require.NoError(t, err)
 	require.NotNil(t, commitInfo)
 
 	require.NoError(t, client.DeleteCommit(repo, commit.ID))
 
-	commitInfo, err = client.InspectCommit(repo, commit.ID)
-	require.Nil(t, commitInfo)
+	_, err = client.InspectCommit(repo, commit.ID)
+	require.YesError(t, err)
 
 	repoInfo, err := client.InspectRepo(repo)
-	require.Equal(t, 0, repoInfo.SizeBytes)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), repoInfo.SizeBytes)
+
+	// the branch had no other commits, so it's deleted
+	branches, err := client.ListBranch(repo)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(branches))
+}
+
+func TestDeleteCommitDownstream(t *testing.T) {
+	t.Parallel()
+	client := getClient(t)
+
+	require.NoError(t, client.CreateRepo("TestDeleteCommitDownstreamIn"))
+	_, err := client.PfsAPIClient.CreateRepo(
+		context.Background(),
+		&pfs.CreateRepoRequest{
+			Repo:       pclient.NewRepo("TestDeleteCommitDownstreamOut"),
+			Provenance: []*pfs.Repo{pclient.NewRepo("TestDeleteCommitDownstreamIn")},
+		},
+	)
+	require.NoError(t, err)
+	// each input commit has an output commit, as a pipeline would make
+	var in, out []*pfs.Commit
+	for i := 0; i < 3; i++ {
+		commit, err := client.StartCommit("TestDeleteCommitDownstreamIn", "master")
+		require.NoError(t, err)
+		require.NoError(t, client.FinishCommit("TestDeleteCommitDownstreamIn", commit.ID))
+		in = append(in, commit)
+		commit, err = client.PfsAPIClient.StartCommit(
+			context.Background(),
+			&pfs.StartCommitRequest{
+				Parent:     pclient.NewCommit("TestDeleteCommitDownstreamOut", ""),
+				Branch:     "master",
+				Provenance: []*pfs.Commit{commit},
+			},
+		)
+		require.NoError(t, err)
+		require.NoError(t, client.FinishCommit("TestDeleteCommitDownstreamOut", commit.ID))
+		out = append(out, commit)
+	}
+
+	// deleting the second input commit deletes the third, its child, and
+	// their output commits, and moves both branches back to the first
+	require.NoError(t, client.DeleteCommit("TestDeleteCommitDownstreamIn", in[1].ID))
+	for i := 1; i < 3; i++ {
+		_, err = client.InspectCommit("TestDeleteCommitDownstreamIn", in[i].ID)
+		require.YesError(t, err)
+		_, err = client.InspectCommit("TestDeleteCommitDownstreamOut", out[i].ID)
+		require.YesError(t, err)
+	}
+	commitInfo, err := client.InspectCommit("TestDeleteCommitDownstreamIn", "master")
+	require.NoError(t, err)
+	require.Equal(t, in[0].ID, commitInfo.Commit.ID)
+	commitInfo, err = client.InspectCommit("TestDeleteCommitDownstreamOut", "master")
+	require.NoError(t, err)
+	require.Equal(t, out[0].ID, commitInfo.Commit.ID)
 }
 
 func TestCleanPath(t *testing.T) {
@@ -504,6 +905,149 @@ func TestStartCommitWithUnfinishedParent(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestStartCommitConcurrent(t *testing.T) {
+	t.Parallel()
+	client := getClient(t)
+	repo := "TestStartCommitConcurrent"
+	require.NoError(t, client.CreateRepo(repo))
+
+	// exactly one of the commits started at once wins, the others are told
+	// which commit they lost to
+	n := 10
+	commits := make([]*pfs.Commit, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			commits[i], errs[i] = client.StartCommit(repo, "master")
+		}()
+	}
+	wg.Wait()
+	var winner *pfs.Commit
+	for i := 0; i < n; i++ {
+		if errs[i] == nil {
+			require.True(t, winner == nil)
+			winner = commits[i]
+		}
+	}
+	require.NotNil(t, winner)
+	for i := 0; i < n; i++ {
+		if errs[i] != nil {
+			require.True(t, errors.Is(errs[i], pclient.ErrCommitConflict))
+			require.True(t, strings.Contains(errs[i].Error(), winner.ID))
+		}
+	}
+	require.NoError(t, client.FinishCommit(repo, winner.ID))
+
+	// writers that retry on conflicts are serialized, and none of their
+	// writes are lost
+	for i := 0; i < n; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				commit, err := client.StartCommit(repo, "master")
+				if errors.Is(err, pclient.ErrCommitConflict) {
+					time.Sleep(10 * time.Millisecond)
+					continue
+				}
+				require.NoError(t, err)
+				_, err = client.PutFile(repo, commit.ID, fmt.Sprintf("file%d", i), strings.NewReader("foo"))
+				require.NoError(t, err)
+				require.NoError(t, client.FinishCommit(repo, commit.ID))
+				return
+			}
+		}()
+	}
+	wg.Wait()
+	fileInfos, err := client.ListFile(repo, "master", "")
+	require.NoError(t, err)
+	require.Equal(t, n, len(fileInfos))
+	commitInfos, err := client.ListCommit(repo, "master", "", 0)
+	require.NoError(t, err)
+	require.Equal(t, n+1, len(commitInfos))
+}
+
+func TestStartCommitOutputRepo(t *testing.T) {
+	t.Parallel()
+	client := getClient(t)
+	require.NoError(t, client.CreateRepo("in"))
+	_, err := client.PfsAPIClient.CreateRepo(context.Background(), &pfs.CreateRepoRequest{
+		Repo:       pclient.NewRepo("out"),
+		Provenance: []*pfs.Repo{pclient.NewRepo("in")},
+	})
+	require.NoError(t, err)
+	inCommit, err := client.StartCommit("in", "master")
+	require.NoError(t, err)
+	require.NoError(t, client.FinishCommit("in", inCommit.ID))
+
+	// commits in output repos are their pipelines', which have provenance
+	_, err = client.StartCommit("out", "master")
+	require.YesError(t, err)
+	outCommit, err := client.PfsAPIClient.StartCommit(context.Background(), &pfs.StartCommitRequest{
+		Parent:     pclient.NewCommit("out", ""),
+		Branch:     "master",
+		Provenance: []*pfs.Commit{inCommit},
+	})
+	require.NoError(t, err)
+	_, err = client.PutFile("out", outCommit.ID, "file", strings.NewReader("foo"))
+	require.NoError(t, err)
+	require.NoError(t, client.FinishCommit("out", outCommit.ID))
+	// the finished output can't be written to
+	_, err = client.PutFile("out", "master", "file", strings.NewReader("bar"))
+	require.YesError(t, err)
+
+	// unless a commit is forced
+	forced, err := client.ForceStartCommit("out", "master", "")
+	require.NoError(t, err)
+	_, err = client.PutFile("out", forced.ID, "file", strings.NewReader("bar"))
+	require.NoError(t, err)
+	require.NoError(t, client.FinishCommit("out", forced.ID))
+	var buffer bytes.Buffer
+	require.NoError(t, client.GetFile("out", "master", "file", 0, 0, &buffer))
+	require.Equal(t, "foobar", buffer.String())
+}
+
+func TestFinishCommitVerify(t *testing.T) {
+	t.Parallel()
+	client := getClient(t)
+	repo := "TestFinishCommitVerify"
+	require.NoError(t, client.CreateRepo(repo))
+
+	commit1, err := client.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = client.PutFile(repo, commit1.ID, "dir/file", strings.NewReader("foo"))
+	require.NoError(t, err)
+	_, err = client.PutFile(repo, commit1.ID, "dir/deleted", strings.NewReader("bar"))
+	require.NoError(t, err)
+	_, err = client.PutFileSplit(repo, commit1.ID, "split", pfs.Delimiter_LINE, 0, 0, strings.NewReader("foo\nbar\nbuz\n"))
+	require.NoError(t, err)
+	require.NoError(t, client.FinishCommitVerify(repo, commit1.ID))
+
+	// a commit that appends to, deletes from and deletes whole parts of its
+	// parent's tree is consistent too
+	commit2, err := client.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = client.PutFile(repo, commit2.ID, "dir/file", strings.NewReader("foo"))
+	require.NoError(t, err)
+	require.NoError(t, client.DeleteFile(repo, commit2.ID, "dir/deleted"))
+	_, err = client.PutFile(repo, commit2.ID, "gone/file", strings.NewReader("foo"))
+	require.NoError(t, err)
+	require.NoError(t, client.DeleteFile(repo, commit2.ID, "gone"))
+	require.NoError(t, client.FinishCommitVerify(repo, commit2.ID))
+
+	commitInfo, err := client.InspectCommit(repo, commit2.ID)
+	require.NoError(t, err)
+	require.Equal(t, uint64(len("foofoo")+len("foo\nbar\nbuz\n")), commitInfo.SizeBytes)
+	var buffer bytes.Buffer
+	require.NoError(t, client.GetFile(repo, commit2.ID, "dir/file", 0, 0, &buffer))
+	require.Equal(t, "foofoo", buffer.String())
+}
+
 func TestProvenance2(t *testing.T) {
 	t.Parallel()
 	client := getClient(t)
@@ -670,6 +1214,43 @@ func TestPutFileBig(t *testing.T) {
 	require.Equal(t, string(expectedOutputA), buffer.String())
 }
 
+func TestPutFileBlocks(t *testing.T) {
+	t.Parallel()
+	client := getClient(t)
+
+	repo := "test"
+	require.NoError(t, client.CreateRepo(repo))
+
+	// files bigger than a block are uploaded as several, concurrently
+	data := make([]byte, 2*putFileBlockSize+1024)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	commit, err := client.StartCommit(repo, "")
+	require.NoError(t, err)
+	_, err = client.PutFile(repo, commit.ID, "file", bytes.NewReader(data))
+	require.NoError(t, err)
+	_, err = client.PutFile(repo, commit.ID, "empty", strings.NewReader(""))
+	require.NoError(t, err)
+	require.NoError(t, client.FinishCommit(repo, commit.ID))
+
+	fileInfo, err := client.InspectFile(repo, commit.ID, "file")
+	require.NoError(t, err)
+	require.Equal(t, 3, len(fileInfo.Objects))
+	require.Equal(t, len(data), int(fileInfo.SizeBytes))
+	var buffer bytes.Buffer
+	require.NoError(t, client.GetFile(repo, commit.ID, "file", 0, 0, &buffer))
+	require.True(t, bytes.Equal(data, buffer.Bytes()))
+	// reads that span blocks
+	buffer.Reset()
+	require.NoError(t, client.GetFile(repo, commit.ID, "file", putFileBlockSize-10, 20, &buffer))
+	require.True(t, bytes.Equal(data[putFileBlockSize-10:putFileBlockSize+10], buffer.Bytes()))
+
+	fileInfo, err = client.InspectFile(repo, commit.ID, "empty")
+	require.NoError(t, err)
+	require.Equal(t, 0, int(fileInfo.SizeBytes))
+}
+
 func TestPutFile(t *testing.T) {
 	t.Parallel()
 	client := getClient(t)
@@ -1025,18 +1606,300 @@ func TestListFileTwoCommits(t *testing.T) {
 		require.NoError(t, err)
 	}
 
-	require.NoError(t, client.FinishCommit(repo, commit2.ID))
+	require.NoError(t, client.FinishCommit(repo, commit2.ID))
+
+	fileInfos, err := client.ListFile(repo, commit1.ID, "")
+	require.NoError(t, err)
+	require.Equal(t, numFiles, len(fileInfos))
+
+	fileInfos, err = client.ListFile(repo, commit2.ID, "")
+	require.NoError(t, err)
+	require.Equal(t, 2*numFiles, len(fileInfos))
+}
+
+func TestListFile(t *testing.T) {
+	t.Parallel()
+	client := getClient(t)
+
+	repo := "test"
+	require.NoError(t, client.CreateRepo(repo))
+
+	commit, err := client.StartCommit(repo, "")
+	require.NoError(t, err)
+
+	fileContent1 := "foo\n"
+	_, err = client.PutFile(repo, commit.ID, "dir/foo", strings.NewReader(fileContent1))
+	require.NoError(t, err)
+
+	fileContent2 := "bar\n"
+	_, err = client.PutFile(repo, commit.ID, "dir/bar", strings.NewReader(fileContent2))
+	require.NoError(t, err)
+
+	require.NoError(t, client.FinishCommit(repo, commit.ID))
+
+	fileInfos, err := client.ListFile(repo, commit.ID, "dir")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(fileInfos))
+	require.True(t, fileInfos[0].File.Path == "dir/foo" && fileInfos[1].File.Path == "dir/bar" || fileInfos[0].File.Path == "dir/bar" && fileInfos[1].File.Path == "dir/foo")
+	require.True(t, fileInfos[0].SizeBytes == fileInfos[1].SizeBytes && fileInfos[0].SizeBytes == uint64(len(fileContent1)))
+
+	fileInfos, err = client.ListFile(repo, commit.ID, "dir/foo")
+	require.YesError(t, err)
+}
+
+func TestPutFileBatch(t *testing.T) {
+	t.Parallel()
+	client := getClient(t)
+
+	repo := "test"
+	require.NoError(t, client.CreateRepo(repo))
+
+	commit, err := client.StartCommit(repo, "")
+	require.NoError(t, err)
+	batch, err := client.NewPutFileBatch(repo, commit.ID)
+	require.NoError(t, err)
+	numFiles := 100
+	for i := 0; i < numFiles; i++ {
+		_, err = batch.PutFile(fmt.Sprintf("dir/file%d", i), strings.NewReader(fmt.Sprintf("%d\n", i)))
+		require.NoError(t, err)
+	}
+	_, err = batch.PutFile("empty", strings.NewReader(""))
+	require.NoError(t, err)
+	// large enough to be split across several requests
+	big := make([]byte, grpcutil.MaxMsgSize)
+	_, err = batch.PutFile("big", bytes.NewReader(big))
+	require.NoError(t, err)
+	require.NoError(t, batch.Close())
+	require.NoError(t, client.FinishCommit(repo, commit.ID))
+
+	fileInfos, err := client.ListFile(repo, commit.ID, "dir")
+	require.NoError(t, err)
+	require.Equal(t, numFiles, len(fileInfos))
+	for i := 0; i < numFiles; i++ {
+		var buffer bytes.Buffer
+		require.NoError(t, client.GetFile(repo, commit.ID, fmt.Sprintf("dir/file%d", i), 0, 0, &buffer))
+		require.Equal(t, fmt.Sprintf("%d\n", i), buffer.String())
+	}
+	fileInfo, err := client.InspectFile(repo, commit.ID, "empty")
+	require.NoError(t, err)
+	require.Equal(t, 0, int(fileInfo.SizeBytes))
+	fileInfo, err = client.InspectFile(repo, commit.ID, "big")
+	require.NoError(t, err)
+	require.Equal(t, len(big), int(fileInfo.SizeBytes))
+}
+
+func TestMaxMsgSize(t *testing.T) {
+	t.Parallel()
+	client := getClient(t, pclient.WithMaxMsgSize(1024))
+
+	repo := "test"
+	require.NoError(t, client.CreateRepo(repo))
+	commit, err := client.StartCommit(repo, "")
+	require.NoError(t, err)
+	data := make([]byte, 1024*1024)
+	_, err = client.PutFile(repo, commit.ID, "file", bytes.NewReader(data))
+	require.NoError(t, err)
+	require.NoError(t, client.FinishCommit(repo, commit.ID))
+
+	var buffer bytes.Buffer
+	require.NoError(t, client.GetFile(repo, commit.ID, "file", 0, 0, &buffer))
+	require.Equal(t, len(data), buffer.Len())
+}
+
+func TestListFileIter(t *testing.T) {
+	t.Parallel()
+	client := getClient(t)
+
+	repo := "test"
+	require.NoError(t, client.CreateRepo(repo))
+
+	commit, err := client.StartCommit(repo, "")
+	require.NoError(t, err)
+	numFiles := 100
+	for i := 0; i < numFiles; i++ {
+		_, err = client.PutFile(repo, commit.ID, fmt.Sprintf("dir/file%d", i), strings.NewReader("foo\n"))
+		require.NoError(t, err)
+	}
+	require.NoError(t, client.FinishCommit(repo, commit.ID))
+
+	iter, err := client.ListFileIter(repo, commit.ID, "dir")
+	require.NoError(t, err)
+	seen := make(map[string]bool)
+	for {
+		fileInfo, err := iter.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		seen[fileInfo.File.Path] = true
+	}
+	iter.Close()
+	require.Equal(t, numFiles, len(seen))
+
+	// closing the iterator early shouldn't hang
+	iter, err = client.ListFileIter(repo, commit.ID, "dir")
+	require.NoError(t, err)
+	_, err = iter.Next()
+	require.NoError(t, err)
+	iter.Close()
+}
+
+func TestListFilePage(t *testing.T) {
+	t.Parallel()
+	client := getClient(t)
+
+	repo := "test"
+	require.NoError(t, client.CreateRepo(repo))
+
+	commit, err := client.StartCommit(repo, "")
+	require.NoError(t, err)
+	numFiles := 100
+	for i := 0; i < numFiles; i++ {
+		_, err = client.PutFile(repo, commit.ID, fmt.Sprintf("dir/file%03d", i), strings.NewReader("foo\n"))
+		require.NoError(t, err)
+	}
+	require.NoError(t, client.FinishCommit(repo, commit.ID))
+
+	// list the directory 30 files at a time
+	var paths []string
+	from := ""
+	for {
+		fileInfos, err := client.ListFilePage(repo, commit.ID, "dir", from, 30)
+		require.NoError(t, err)
+		for _, fileInfo := range fileInfos {
+			paths = append(paths, fileInfo.File.Path)
+		}
+		if len(fileInfos) < 30 {
+			break
+		}
+		from = fileInfos[len(fileInfos)-1].File.Path
+	}
+	require.Equal(t, numFiles, len(paths))
+	for i, path := range paths {
+		require.Equal(t, fmt.Sprintf("/dir/file%03d", i), path)
+	}
+
+	// 'from' must be in the directory being listed
+	_, err = client.ListFilePage(repo, commit.ID, "dir", "/other/file000", 30)
+	require.YesError(t, err)
+}
+
+func TestGlobFileStream(t *testing.T) {
+	t.Parallel()
+	client := getClient(t)
+
+	repo := "test"
+	require.NoError(t, client.CreateRepo(repo))
+
+	commit, err := client.StartCommit(repo, "")
+	require.NoError(t, err)
+	numFiles := 100
+	for i := 0; i < numFiles; i++ {
+		_, err = client.PutFile(repo, commit.ID, fmt.Sprintf("dir/file%03d", i), strings.NewReader("foo\n"))
+		require.NoError(t, err)
+	}
+	require.NoError(t, client.FinishCommit(repo, commit.ID))
+
+	stream, err := client.PfsAPIClient.GlobFileStream(context.Background(), &pfs.GlobFileRequest{
+		Commit:  commit,
+		Pattern: "dir/*",
+	})
+	require.NoError(t, err)
+	var paths []string
+	for {
+		fileInfo, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		paths = append(paths, fileInfo.File.Path)
+	}
+	require.Equal(t, numFiles, len(paths))
+	for i, path := range paths {
+		require.Equal(t, fmt.Sprintf("/dir/file%03d", i), path)
+	}
+}
+
+func TestGetFiles(t *testing.T) {
+	t.Parallel()
+	client := getClient(t)
+
+	repo := "test"
+	require.NoError(t, client.CreateRepo(repo))
+
+	commit, err := client.StartCommit(repo, "")
+	require.NoError(t, err)
+	var paths []string
+	numFiles := 100
+	for i := 0; i < numFiles; i++ {
+		path := fmt.Sprintf("dir/file%03d", i)
+		_, err = client.PutFile(repo, commit.ID, path, strings.NewReader(path))
+		require.NoError(t, err)
+		paths = append(paths, path)
+	}
+	// too big to be returned inline
+	bigContent := strings.Repeat("big", 1024*1024)
+	_, err = client.PutFile(repo, commit.ID, "big", strings.NewReader(bigContent))
+	require.NoError(t, err)
+	require.NoError(t, client.FinishCommit(repo, commit.ID))
+
+	var contents []string
+	require.NoError(t, client.GetFiles(repo, commit.ID, append(paths, "big", "dir"), func(fileInfo *pfs.FileInfo, r io.Reader) error {
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		if fileInfo.FileType == pfs.FileType_FILE {
+			require.Equal(t, int(fileInfo.SizeBytes), len(data))
+		}
+		contents = append(contents, string(data))
+		return nil
+	}))
+	require.Equal(t, numFiles+2, len(contents))
+	for i, path := range paths {
+		require.Equal(t, path, contents[i])
+	}
+	require.Equal(t, bigContent, contents[numFiles])
+	require.Equal(t, "", contents[numFiles+1])
+
+	err = client.GetFiles(repo, commit.ID, []string{"nonexistent"}, func(*pfs.FileInfo, io.Reader) error { return nil })
+	require.YesError(t, err)
+}
 
-	fileInfos, err := client.ListFile(repo, commit1.ID, "")
+func TestGetParquetFileErrors(t *testing.T) {
+	t.Parallel()
+	client := getClient(t)
+
+	repo := "test"
+	require.NoError(t, client.CreateRepo(repo))
+	commit, err := client.StartCommit(repo, "")
 	require.NoError(t, err)
-	require.Equal(t, numFiles, len(fileInfos))
+	_, err = client.PutFile(repo, commit.ID, "file.csv", strings.NewReader("a,b\n1,2\n"))
+	require.NoError(t, err)
+	_, err = client.PutFile(repo, commit.ID, "dir/file", strings.NewReader("foo"))
+	require.NoError(t, err)
+	require.NoError(t, client.FinishCommit(repo, commit.ID))
 
-	fileInfos, err = client.ListFile(repo, commit2.ID, "")
+	var buf bytes.Buffer
+	err = client.GetParquetFile(repo, commit.ID, "file.csv", []string{"a"}, nil, &buf)
+	require.YesError(t, err)
+	require.Matches(t, "isn't a Parquet file", err.Error())
+	require.YesError(t, client.GetParquetFile(repo, commit.ID, "dir/file", nil, []int64{0}, &buf))
+	require.YesError(t, client.GetParquetFile(repo, commit.ID, "dir", []string{"a"}, nil, &buf))
+	require.YesError(t, client.GetParquetFile(repo, commit.ID, "missing", []string{"a"}, nil, &buf))
+	require.Equal(t, 0, buf.Len())
+
+	// offsets can't be combined with a selection
+	getFileClient, err := client.PfsAPIClient.GetFile(context.Background(), &pfs.GetFileRequest{
+		File:        pclient.NewFile(repo, commit.ID, "file.csv"),
+		OffsetBytes: 1,
+		Parquet:     &pfs.ParquetSelection{Columns: []string{"a"}},
+	})
 	require.NoError(t, err)
-	require.Equal(t, 2*numFiles, len(fileInfos))
+	require.YesError(t, grpcutil.WriteFromStreamingBytesClient(getFileClient, &buf))
 }
 
-func TestListFile(t *testing.T) {
+func TestGetFileRanges(t *testing.T) {
 	t.Parallel()
 	client := getClient(t)
 
@@ -1045,24 +1908,34 @@ func TestListFile(t *testing.T) {
 
 	commit, err := client.StartCommit(repo, "")
 	require.NoError(t, err)
-
-	fileContent1 := "foo\n"
-	_, err = client.PutFile(repo, commit.ID, "dir/foo", strings.NewReader(fileContent1))
-	require.NoError(t, err)
-
-	fileContent2 := "bar\n"
-	_, err = client.PutFile(repo, commit.ID, "dir/bar", strings.NewReader(fileContent2))
-	require.NoError(t, err)
-
+	numFiles := 50
+	var ranges []*pfs.GetFileRequest
+	var expected []string
+	for i := 0; i < numFiles; i++ {
+		path := fmt.Sprintf("file%03d", i)
+		content := strings.Repeat(path, 1000)
+		_, err = client.PutFile(repo, commit.ID, path, strings.NewReader(content))
+		require.NoError(t, err)
+		ranges = append(ranges, pclient.NewFileRange(repo, commit.ID, path, int64(i), int64(i*10)))
+		if i == 0 {
+			// a size of 0 is the rest of the file
+			expected = append(expected, content)
+		} else {
+			expected = append(expected, content[i:i+i*10])
+		}
+	}
 	require.NoError(t, client.FinishCommit(repo, commit.ID))
 
-	fileInfos, err := client.ListFile(repo, commit.ID, "dir")
-	require.NoError(t, err)
-	require.Equal(t, 2, len(fileInfos))
-	require.True(t, fileInfos[0].File.Path == "dir/foo" && fileInfos[1].File.Path == "dir/bar" || fileInfos[0].File.Path == "dir/bar" && fileInfos[1].File.Path == "dir/foo")
-	require.True(t, fileInfos[0].SizeBytes == fileInfos[1].SizeBytes && fileInfos[0].SizeBytes == uint64(len(fileContent1)))
+	writers := make([]io.Writer, numFiles)
+	for i := range writers {
+		writers[i] = &bytes.Buffer{}
+	}
+	require.NoError(t, client.GetFileRanges(ranges, writers))
+	for i, w := range writers {
+		require.Equal(t, expected[i], w.(*bytes.Buffer).String())
+	}
 
-	fileInfos, err = client.ListFile(repo, commit.ID, "dir/foo")
+	err = client.GetFileRanges([]*pfs.GetFileRequest{pclient.NewFileRange(repo, commit.ID, "nonexistent", 0, 0)}, []io.Writer{&bytes.Buffer{}})
 	require.YesError(t, err)
 }
 
@@ -1338,6 +2211,54 @@ func TestDeleteDir(t *testing.T) {
 	// TODO: test deleting "."
 }
 
+func TestDeleteFiles(t *testing.T) {
+	t.Parallel()
+	client := getClient(t)
+
+	repo := "test"
+	require.NoError(t, client.CreateRepo(repo))
+
+	commit1, err := client.StartCommit(repo, "master")
+	require.NoError(t, err)
+	for _, file := range []string{"a", "b", "c", "dir/d", "dir/e", "logs/1.log", "logs/2.log", "logs/3.txt"} {
+		_, err = client.PutFile(repo, commit1.ID, file, strings.NewReader("foo\n"))
+		require.NoError(t, err)
+	}
+	require.NoError(t, client.FinishCommit(repo, commit1.ID))
+
+	commit2, err := client.StartCommit(repo, "master")
+	require.NoError(t, err)
+	require.NoError(t, client.DeleteFiles(repo, commit2.ID, []string{"a", "dir", "nonexistent"}, "logs/*.log"))
+	// files written in the same commit are unaffected by the deletion
+	_, err = client.PutFile(repo, commit2.ID, "logs/4.log", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	require.YesError(t, client.DeleteFiles(repo, commit2.ID, nil, "["))
+	require.NoError(t, client.FinishCommit(repo, commit2.ID))
+
+	fileInfos, err := client.ListFile(repo, commit2.ID, "")
+	require.NoError(t, err)
+	var names []string
+	for _, fileInfo := range fileInfos {
+		names = append(names, fileInfo.File.Path)
+	}
+	require.Equal(t, []string{"/b", "/c", "/logs"}, names)
+	fileInfos, err = client.ListFile(repo, commit2.ID, "logs")
+	require.NoError(t, err)
+	names = nil
+	for _, fileInfo := range fileInfos {
+		names = append(names, fileInfo.File.Path)
+	}
+	require.Equal(t, []string{"/logs/3.txt", "/logs/4.log"}, names)
+
+	// the parent commit is unchanged
+	fileInfos, err = client.ListFile(repo, commit1.ID, "")
+	require.NoError(t, err)
+	require.Equal(t, 5, len(fileInfos))
+
+	// deleting from a finished commit is an error
+	require.YesError(t, client.DeleteFiles(repo, commit2.ID, []string{"b"}, ""))
+}
+
 func TestDeleteFile2(t *testing.T) {
 	t.Parallel()
 	client := getClient(t)
@@ -1438,6 +2359,41 @@ func TestListCommit(t *testing.T) {
 	}
 }
 
+func TestListCommitIter(t *testing.T) {
+	client := getClient(t)
+	repo := "test"
+	require.NoError(t, client.CreateRepo(repo))
+
+	numCommits := 10
+	var commits []*pfs.Commit
+	for i := 0; i < numCommits; i++ {
+		commit, err := client.StartCommit(repo, "master")
+		require.NoError(t, err)
+		require.NoError(t, client.FinishCommit(repo, "master"))
+		commits = append(commits, commit)
+	}
+
+	// commits are streamed back newest first
+	iter, err := client.ListCommitIter(repo, "", "", 0)
+	require.NoError(t, err)
+	for i := numCommits - 1; i >= 0; i-- {
+		commitInfo, err := iter.Next()
+		require.NoError(t, err)
+		require.Equal(t, commits[i].ID, commitInfo.Commit.ID)
+	}
+	_, err = iter.Next()
+	require.Equal(t, io.EOF, err)
+	iter.Close()
+
+	// the iterator can be closed before all of the commits have been read
+	iter, err = client.ListCommitIter(repo, "master", "", 0)
+	require.NoError(t, err)
+	commitInfo, err := iter.Next()
+	require.NoError(t, err)
+	require.Equal(t, commits[numCommits-1].ID, commitInfo.Commit.ID)
+	iter.Close()
+}
+
 func TestOffsetRead(t *testing.T) {
 	t.Parallel()
 	client := getClient(t)
@@ -1538,6 +2494,12 @@ func TestSubscribeCommit(t *testing.T) {
 		require.Equal(t, commits[i], commitInfo.Commit)
 	}
 
+	// moving the branch back to a commit that's been sent sends it again
+	require.NoError(t, client.SetBranch(repo, commits[0].ID, "master"))
+	commitInfo, err := commitIter.Next()
+	require.NoError(t, err)
+	require.Equal(t, commits[0], commitInfo.Commit)
+
 	commitIter.Close()
 }
 
@@ -1883,6 +2845,160 @@ func TestSyncPullPush(t *testing.T) {
 	require.NoError(t, puller.CleanUp())
 }
 
+func TestSyncPullDir(t *testing.T) {
+	t.Parallel()
+	client := getClient(t)
+
+	repo := "test"
+	require.NoError(t, client.CreateRepo(repo))
+
+	commit, err := client.StartCommit(repo, "master")
+	require.NoError(t, err)
+	for _, file := range []string{"foo", "dir/bar", "dir/sub/baz", "dir/sub/sub/buzz"} {
+		_, err = client.PutFile(repo, commit.ID, file, strings.NewReader(file+"\n"))
+		require.NoError(t, err)
+	}
+	require.NoError(t, client.FinishCommit(repo, commit.ID))
+
+	// pull a directory, the path doesn't need a leading slash
+	tmpDir, err := ioutil.TempDir("/tmp", "pfs")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	puller := pfssync.NewPuller()
+	require.NoError(t, puller.Pull(&client, tmpDir, repo, commit.ID, "dir", false, 2))
+	require.NoError(t, puller.CleanUp())
+	for _, file := range []string{"bar", "sub/baz", "sub/sub/buzz"} {
+		data, err := ioutil.ReadFile(path.Join(tmpDir, file))
+		require.NoError(t, err)
+		require.Equal(t, path.Join("dir", file)+"\n", string(data))
+	}
+	_, err = os.Stat(path.Join(tmpDir, "foo"))
+	require.True(t, os.IsNotExist(err))
+
+	// pull a single file
+	tmpFile := path.Join(tmpDir, "file")
+	puller = pfssync.NewPuller()
+	require.NoError(t, puller.Pull(&client, tmpFile, repo, commit.ID, "/dir/sub/baz", false, 2))
+	require.NoError(t, puller.CleanUp())
+	data, err := ioutil.ReadFile(tmpFile)
+	require.NoError(t, err)
+	require.Equal(t, "dir/sub/baz\n", string(data))
+}
+
+func TestPutFiles(t *testing.T) {
+	t.Parallel()
+	client := getClient(t)
+
+	tmpDir, err := ioutil.TempDir("/tmp", "pfs")
+	require.NoError(t, err)
+	for i := 0; i < 20; i++ {
+		dir := path.Join(tmpDir, fmt.Sprintf("dir%d", i%3))
+		require.NoError(t, os.MkdirAll(dir, 0700))
+		require.NoError(t, ioutil.WriteFile(path.Join(dir, fmt.Sprintf("file%d", i)), []byte(fmt.Sprintf("%d\n", i)), 0600))
+	}
+
+	repo := "TestPutFiles"
+	require.NoError(t, client.CreateRepo(repo))
+	commit, err := client.StartCommit(repo, "master")
+	require.NoError(t, err)
+	require.NoError(t, client.PutFiles(repo, commit.ID, tmpDir, &pclient.PutFilesOptions{
+		Path:        "prefix",
+		Parallelism: 4,
+	}))
+	require.NoError(t, client.FinishCommit(repo, commit.ID))
+
+	fileInfos, err := client.ListFile(repo, commit.ID, "prefix")
+	require.NoError(t, err)
+	require.Equal(t, 3, len(fileInfos))
+	for i := 0; i < 20; i++ {
+		var buffer bytes.Buffer
+		require.NoError(t, client.GetFile(repo, commit.ID, fmt.Sprintf("prefix/dir%d/file%d", i%3, i), 0, 0, &buffer))
+		require.Equal(t, fmt.Sprintf("%d\n", i), buffer.String())
+	}
+}
+
+func TestPutFilesPreserveMetadata(t *testing.T) {
+	t.Parallel()
+	client := getClient(t)
+
+	srcDir, err := ioutil.TempDir("/tmp", "pfs")
+	require.NoError(t, err)
+	defer os.RemoveAll(srcDir)
+	require.NoError(t, os.MkdirAll(path.Join(srcDir, "bin"), 0700))
+	require.NoError(t, ioutil.WriteFile(path.Join(srcDir, "bin", "run"), []byte("#!/bin/sh\n"), 0755))
+	require.NoError(t, ioutil.WriteFile(path.Join(srcDir, "data"), []byte("data\n"), 0600))
+	require.NoError(t, os.Symlink("bin/run", path.Join(srcDir, "run")))
+	modTime := time.Unix(1500000000, 0)
+	require.NoError(t, os.Chtimes(path.Join(srcDir, "data"), modTime, modTime))
+
+	repo := "TestPutFilesPreserveMetadata"
+	require.NoError(t, client.CreateRepo(repo))
+	commit, err := client.StartCommit(repo, "master")
+	require.NoError(t, err)
+	require.NoError(t, client.PutFiles(repo, commit.ID, srcDir, &pclient.PutFilesOptions{
+		Path:             "bundle",
+		PreserveMetadata: true,
+	}))
+	require.NoError(t, client.FinishCommit(repo, commit.ID))
+
+	// the symlink is recorded rather than uploaded
+	_, err = client.InspectFile(repo, commit.ID, "bundle/run")
+	require.YesError(t, err)
+	metadata, err := client.GetFileMetadata(repo, commit.ID, "bundle")
+	require.NoError(t, err)
+	require.Equal(t, 3, len(metadata))
+	require.Equal(t, "bin/run", metadata["run"].Symlink)
+
+	dstDir, err := ioutil.TempDir("/tmp", "pfs")
+	require.NoError(t, err)
+	defer os.RemoveAll(dstDir)
+	puller := pfssync.NewPuller()
+	require.NoError(t, puller.Pull(&client, dstDir, repo, commit.ID, "bundle", false, 2))
+	require.NoError(t, puller.CleanUp())
+	require.NoError(t, pfssync.RestoreMetadata(&client, dstDir, repo, commit.ID, "bundle"))
+
+	info, err := os.Stat(path.Join(dstDir, "bin", "run"))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0755), info.Mode().Perm())
+	info, err = os.Stat(path.Join(dstDir, "data"))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	require.True(t, modTime.Equal(info.ModTime()))
+	target, err := os.Readlink(path.Join(dstDir, "run"))
+	require.NoError(t, err)
+	require.Equal(t, "bin/run", target)
+	_, err = os.Stat(path.Join(dstDir, pclient.MetadataFile))
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestProgressFunc(t *testing.T) {
+	t.Parallel()
+	client := getClient(t)
+
+	var bytesTransferred, filesCompleted int64
+	client.SetProgressFunc(func(bytes int64, files int64) {
+		atomic.AddInt64(&bytesTransferred, bytes)
+		atomic.AddInt64(&filesCompleted, files)
+	})
+
+	repo := "TestProgressFunc"
+	require.NoError(t, client.CreateRepo(repo))
+	commit, err := client.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = client.PutFile(repo, commit.ID, "foo", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	_, err = client.PutFile(repo, commit.ID, "bar", strings.NewReader("bar\n"))
+	require.NoError(t, err)
+	require.NoError(t, client.FinishCommit(repo, commit.ID))
+	require.Equal(t, int64(8), atomic.LoadInt64(&bytesTransferred))
+	require.Equal(t, int64(2), atomic.LoadInt64(&filesCompleted))
+
+	var buffer bytes.Buffer
+	require.NoError(t, client.GetFile(repo, commit.ID, "foo", 0, 0, &buffer))
+	require.Equal(t, int64(12), atomic.LoadInt64(&bytesTransferred))
+	require.Equal(t, int64(3), atomic.LoadInt64(&filesCompleted))
+}
+
 func generateRandomString(n int) string {
 	rand.Seed(time.Now().UnixNano())
 	b := make([]byte, n)
@@ -1913,7 +3029,7 @@ func runServers(t *testing.T, port int32, apiServer pfs.APIServer,
 	<-ready
 }
 
-func getClient(t *testing.T) pclient.APIClient {
+func getClient(t *testing.T, options ...pclient.Option) pclient.APIClient {
 	dbName := "pachyderm_test_" + uuid.NewWithoutDashes()[0:12]
 	testDBs = append(testDBs, dbName)
 
@@ -1936,7 +3052,7 @@ func getClient(t *testing.T) pclient.APIClient {
 		require.NoError(t, err)
 		runServers(t, port, apiServer, blockAPIServer)
 	}
-	c, err := pclient.NewFromAddress(addresses[0])
+	c, err := pclient.NewFromAddress(addresses[0], options...)
 	require.NoError(t, err)
 	return *c
 }
@@ -2076,6 +3192,63 @@ func TestFlush2(t *testing.T) {
 	require.Equal(t, commitInfos[0].Commit.ID, CCommit.ID)
 }
 
+// TestFlushWide flushes a commit with many downstream repos, whose commits
+// only appear after FlushCommit has been called.
+func TestFlushWide(t *testing.T) {
+	t.Parallel()
+	c := getClient(t)
+	repo := uniqueString("TestFlushWide")
+	require.NoError(t, c.CreateRepo(repo))
+	var downstream []string
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("%s-%d", repo, i)
+		_, err := c.PfsAPIClient.CreateRepo(context.Background(), &pfs.CreateRepoRequest{
+			Repo:       pclient.NewRepo(name),
+			Provenance: []*pfs.Repo{pclient.NewRepo(repo)},
+		})
+		require.NoError(t, err)
+		downstream = append(downstream, name)
+	}
+	commit, err := c.StartCommit(repo, "master")
+	require.NoError(t, err)
+	require.NoError(t, c.FinishCommit(repo, commit.ID))
+
+	// flush the commit by its branch
+	commitIter, err := c.FlushCommit([]*pfs.Commit{pclient.NewCommit(repo, "master")}, nil)
+	require.NoError(t, err)
+	go func() {
+		for _, name := range downstream {
+			downstreamCommit, err := c.PfsAPIClient.StartCommit(
+				context.Background(),
+				&pfs.StartCommitRequest{
+					Parent:     pclient.NewCommit(name, ""),
+					Provenance: []*pfs.Commit{commit},
+				},
+			)
+			require.NoError(t, err)
+			require.NoError(t, c.FinishCommit(name, downstreamCommit.ID))
+		}
+	}()
+	commitInfos, err := collectCommitInfos(commitIter)
+	require.NoError(t, err)
+	require.Equal(t, len(downstream), len(commitInfos))
+	repos := make(map[string]bool)
+	for _, commitInfo := range commitInfos {
+		repos[commitInfo.Commit.Repo.Name] = true
+	}
+	require.Equal(t, len(downstream), len(repos))
+
+	// a repo that's asked for twice only gets one commit
+	commitIter, err = c.FlushCommit(
+		[]*pfs.Commit{commit},
+		[]*pfs.Repo{pclient.NewRepo(downstream[0]), pclient.NewRepo(downstream[0])},
+	)
+	require.NoError(t, err)
+	commitInfos, err = collectCommitInfos(commitIter)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(commitInfos))
+}
+
 func TestFlushCommitWithNoDownstreamRepos(t *testing.T) {
 	t.Parallel()
 	c := getClient(t)
@@ -2091,6 +3264,56 @@ func TestFlushCommitWithNoDownstreamRepos(t *testing.T) {
 	require.Equal(t, 0, len(commitInfos))
 }
 
+func TestFlushCommits(t *testing.T) {
+	t.Parallel()
+	client := getClient(t)
+	require.NoError(t, client.CreateRepo("A"))
+	require.NoError(t, client.CreateRepo("B"))
+	for _, repo := range []string{"A", "B"} {
+		_, err := client.PfsAPIClient.CreateRepo(context.Background(), &pfs.CreateRepoRequest{
+			Repo:       pclient.NewRepo(repo + "out"),
+			Provenance: []*pfs.Repo{pclient.NewRepo(repo)},
+		})
+		require.NoError(t, err)
+	}
+	ACommit, err := client.StartCommit("A", "master")
+	require.NoError(t, err)
+	require.NoError(t, client.FinishCommit("A", ACommit.ID))
+	BCommit, err := client.StartCommit("B", "master")
+	require.NoError(t, err)
+	require.NoError(t, client.FinishCommit("B", BCommit.ID))
+
+	go func() {
+		for _, commit := range []*pfs.Commit{BCommit, ACommit} {
+			outCommit, err := client.PfsAPIClient.StartCommit(
+				context.Background(),
+				&pfs.StartCommitRequest{
+					Parent:     pclient.NewCommit(commit.Repo.Name+"out", ""),
+					Provenance: []*pfs.Commit{commit},
+				},
+			)
+			require.NoError(t, err)
+			require.NoError(t, client.FinishCommit(commit.Repo.Name+"out", outCommit.ID))
+		}
+	}()
+
+	repos := make(map[int]string)
+	require.NoError(t, client.FlushCommits([]*pfs.FlushCommitRequest{
+		{Commits: []*pfs.Commit{pclient.NewCommit("A", ACommit.ID)}},
+		{Commits: []*pfs.Commit{pclient.NewCommit("B", BCommit.ID)}},
+	}, func(index int, commitInfo *pfs.CommitInfo) error {
+		repos[index] = commitInfo.Commit.Repo.Name
+		return nil
+	}))
+	require.Equal(t, map[int]string{0: "Aout", 1: "Bout"}, repos)
+
+	// a flush that fails fails the whole call
+	require.YesError(t, client.FlushCommits([]*pfs.FlushCommitRequest{
+		{Commits: []*pfs.Commit{pclient.NewCommit("A", ACommit.ID)}},
+		{Commits: []*pfs.Commit{pclient.NewCommit("fake-repo", "fake-commit")}},
+	}, func(int, *pfs.CommitInfo) error { return nil }))
+}
+
 func TestFlushOpenCommit(t *testing.T) {
 	t.Parallel()
 	client := getClient(t)
@@ -2300,3 +3523,119 @@ func TestPutFileSplitBig(t *testing.T) {
 func uniqueString(prefix string) string {
 	return prefix + "-" + uuid.NewWithoutDashes()[0:12]
 }
+
+func TestDownloadKeyEncryption(t *testing.T) {
+	t.Parallel()
+	d, err := newLocalDriver("", generateRandomString(32))
+	require.NoError(t, err)
+	ctx := context.Background()
+	stored := func() string {
+		resp, err := d.etcdClient.Get(ctx, path.Join(d.prefix, downloadKey))
+		require.NoError(t, err)
+		require.Equal(t, 1, len(resp.Kvs))
+		return string(resp.Kvs[0].Value)
+	}
+	key, err := d.downloadKey(ctx)
+	require.NoError(t, err)
+	require.Equal(t, string(key), stored())
+
+	// once encryption is turned on the existing key is encrypted, but
+	// doesn't change, so URLs that were signed with it stay valid
+	d.cipher, err = col.NewCipher([]byte(generateRandomString(col.KeySize)))
+	require.NoError(t, err)
+	encryptedKey, err := d.downloadKey(ctx)
+	require.NoError(t, err)
+	require.Equal(t, key, encryptedKey)
+	require.NotEqual(t, string(key), stored())
+	encryptedKey, err = d.downloadKey(ctx)
+	require.NoError(t, err)
+	require.Equal(t, key, encryptedKey)
+}
+
+func TestGetFileURL(t *testing.T) {
+	t.Parallel()
+	// GetFileURL's URLs are served by the pfs server's own HTTP handler, so
+	// the server is started here, rather than by getClient
+	address := fmt.Sprintf("localhost:%d", atomic.AddInt32(&port, 1))
+	blockAPIServer, err := NewLocalBlockAPIServer(uniqueString("/tmp/pach_test/run"))
+	require.NoError(t, err)
+	apiServer, err := newLocalAPIServer(address, generateRandomString(32), nil)
+	require.NoError(t, err)
+	downloads := httptest.NewServer(apiServer.DownloadHandler())
+	defer downloads.Close()
+	apiServer.downloadURL = downloads.URL
+	_, p, err := net.SplitHostPort(address)
+	require.NoError(t, err)
+	portNum, err := strconv.Atoi(p)
+	require.NoError(t, err)
+	runServers(t, int32(portNum), apiServer, blockAPIServer)
+	client, err := pclient.NewFromAddress(address)
+	require.NoError(t, err)
+
+	repo := "test"
+	require.NoError(t, client.CreateRepo(repo))
+	commit1, err := client.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = client.PutFile(repo, commit1.ID, "dir/my file.txt", strings.NewReader("foo\n"))
+	require.NoError(t, err)
+	// URLs are only returned for finished commits
+	_, err = client.GetFileURL(repo, "master", "dir/my file.txt", 0)
+	require.YesError(t, err)
+	require.NoError(t, client.FinishCommit(repo, commit1.ID))
+
+	get := func(url string) (int, string, http.Header) {
+		resp, err := http.Get(url)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(body), resp.Header
+	}
+	fileURL, err := client.GetFileURL(repo, "master", "dir/my file.txt", time.Minute)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(fileURL, downloads.URL+DownloadPrefix+repo+"/"+commit1.ID+"/"), fileURL)
+	status, body, header := get(fileURL)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "foo\n", body)
+	require.True(t, strings.HasPrefix(header.Get("Content-Type"), "text/plain"), header.Get("Content-Type"))
+
+	// the URL keeps returning the commit it was signed for once the branch
+	// moves
+	commit2, err := client.StartCommit(repo, "master")
+	require.NoError(t, err)
+	_, err = client.PutFile(repo, commit2.ID, "dir/my file.txt", strings.NewReader("bar\n"))
+	require.NoError(t, err)
+	require.NoError(t, client.FinishCommit(repo, commit2.ID))
+	_, body, _ = get(fileURL)
+	require.Equal(t, "foo\n", body)
+
+	// changing any part of the URL invalidates it
+	status, _, _ = get(strings.Replace(fileURL, commit1.ID, commit2.ID, 1))
+	require.Equal(t, http.StatusForbidden, status)
+	status, _, _ = get(strings.Replace(fileURL, "my%20file", "other", 1))
+	require.Equal(t, http.StatusForbidden, status)
+	u, err := url.Parse(fileURL)
+	require.NoError(t, err)
+	query := u.Query()
+	query.Set("expires", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+	u.RawQuery = query.Encode()
+	status, _, _ = get(u.String())
+	require.Equal(t, http.StatusForbidden, status)
+
+	// expired URLs are refused
+	file := pclient.NewFile(repo, commit1.ID, "dir/my file.txt")
+	key, err := apiServer.driver.downloadKey(context.Background())
+	require.NoError(t, err)
+	expired := time.Now().Add(-time.Minute).Unix()
+	query = url.Values{}
+	query.Set("expires", strconv.FormatInt(expired, 10))
+	query.Set("signature", signDownload(key, file, expired))
+	status, _, _ = get(downloads.URL + downloadPath(file) + "?" + query.Encode())
+	require.Equal(t, http.StatusForbidden, status)
+
+	// directories, and TTLs longer than the maximum, are refused
+	_, err = client.GetFileURL(repo, "master", "dir", 0)
+	require.YesError(t, err)
+	_, err = client.GetFileURL(repo, "master", "dir/my file.txt", maxFileURLTTL+time.Hour)
+	require.YesError(t, err)
+}
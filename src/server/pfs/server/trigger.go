@@ -0,0 +1,272 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+	"github.com/pachyderm/pachyderm/src/server/pkg/cron"
+
+	"github.com/docker/go-units"
+	"github.com/gogo/protobuf/types"
+	protolion "go.pedge.io/lion/proto"
+)
+
+// triggerInterval is how often pachd checks every branch trigger. Triggers
+// are also checked whenever a commit is finished or a branch is moved, so
+// this only matters for cron conditions, and for retrying triggers that
+// failed to fire.
+const triggerInterval = time.Minute
+
+// validateTrigger checks that trigger, which is to be set on branch, is
+// well formed.
+func validateTrigger(branch string, trigger *pfs.Trigger) error {
+	if trigger.Branch == "" {
+		return fmt.Errorf("trigger must have a branch to move %s to", branch)
+	}
+	if trigger.Branch == branch {
+		return fmt.Errorf("branch %s can't be triggered by itself", branch)
+	}
+	if trigger.Size_ == "" && trigger.Commits == 0 && trigger.CronSpec == "" {
+		return fmt.Errorf("trigger must have at least one condition: a size, a number of commits or a cron spec")
+	}
+	if trigger.Size_ != "" {
+		if _, err := units.FromHumanSize(trigger.Size_); err != nil {
+			return fmt.Errorf("invalid trigger size %q: %v", trigger.Size_, err)
+		}
+	}
+	if trigger.Commits < 0 {
+		return fmt.Errorf("trigger commits can't be negative")
+	}
+	if trigger.CronSpec != "" {
+		if _, err := cron.Parse(trigger.CronSpec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// triggerMet returns true if trigger's conditions are met by head, the
+// head of the branch that it watches, when the triggered branch is at
+// oldHead. getCommit gets the commits on the watched branch, for counting
+// them.
+func triggerMet(trigger *pfs.Trigger, head *pfs.CommitInfo, oldHead *pfs.CommitInfo, getCommit func(id string) (*pfs.CommitInfo, error), now time.Time) (bool, error) {
+	var results []bool
+	if trigger.Size_ != "" {
+		size, err := units.FromHumanSize(trigger.Size_)
+		if err != nil {
+			return false, err
+		}
+		results = append(results, int64(head.SizeBytes)-int64(oldHead.SizeBytes) >= size)
+	}
+	if trigger.Commits > 0 {
+		// count the commits back from head, until oldHead or as many as
+		// are needed
+		var n int64
+		for commitInfo := head; n < trigger.Commits && commitInfo.Commit.ID != oldHead.Commit.ID; {
+			n++
+			if commitInfo.ParentCommit == nil {
+				break
+			}
+			var err error
+			if commitInfo, err = getCommit(commitInfo.ParentCommit.ID); err != nil {
+				return false, err
+			}
+		}
+		results = append(results, n >= trigger.Commits)
+	}
+	if trigger.CronSpec != "" {
+		schedule, err := cron.Parse(trigger.CronSpec)
+		if err != nil {
+			return false, err
+		}
+		moved := oldHead.Finished
+		if moved == nil {
+			moved = oldHead.Started
+		}
+		last, err := types.TimestampFromProto(moved)
+		if err != nil {
+			return false, err
+		}
+		results = append(results, !schedule.Next(last).After(now))
+	}
+	for _, result := range results {
+		if result && !trigger.All {
+			return true, nil
+		}
+		if !result && trigger.All {
+			return false, nil
+		}
+	}
+	return trigger.All, nil
+}
+
+// setBranchTrigger sets the trigger of branch, which must exist, or removes
+// it if trigger is nil.
+func (d *driver) setBranchTrigger(ctx context.Context, repo *pfs.Repo, branch string, trigger *pfs.Trigger) error {
+	if trigger != nil {
+		if err := validateTrigger(branch, trigger); err != nil {
+			return err
+		}
+	}
+	if _, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		branches := d.branches(repo.Name).ReadWrite(stm)
+		triggers := d.triggers(repo.Name).ReadWrite(stm)
+		head := new(pfs.Commit)
+		if err := branches.Get(branch, head); err != nil {
+			if isNotFoundErr(err) {
+				return fmt.Errorf("branch %s not found in repo %s, it must be set before it can have a trigger", branch, repo.Name)
+			}
+			return err
+		}
+		if trigger == nil {
+			if err := triggers.Delete(branch); err != nil && !isNotFoundErr(err) {
+				return err
+			}
+			return nil
+		}
+		triggers.Put(branch, trigger)
+		return nil
+	}); err != nil {
+		return err
+	}
+	d.fireTriggersAfter(ctx, repo)
+	return nil
+}
+
+// fireTriggers moves each of repo's triggered branches whose trigger's
+// conditions are met. Moving a branch can meet the conditions of the triggers
+// that watch it in turn, so this is repeated until no branch moves.
+func (d *driver) fireTriggers(ctx context.Context, repo *pfs.Repo) error {
+	iterator, err := d.triggers(repo.Name).ReadOnly(ctx).List()
+	if err != nil {
+		return err
+	}
+	var branches []string
+	for {
+		var branch string
+		trigger := new(pfs.Trigger)
+		ok, err := iterator.Next(&branch, trigger)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		branches = append(branches, branch)
+	}
+	// a chain of triggers is at most as long as the number of them
+	for range branches {
+		var moved bool
+		for _, branch := range branches {
+			fired, err := d.fireTrigger(ctx, repo, branch)
+			if err != nil {
+				return fmt.Errorf("error checking the trigger of branch %s in repo %s: %v", branch, repo.Name, err)
+			}
+			moved = moved || fired
+		}
+		if !moved {
+			break
+		}
+	}
+	return nil
+}
+
+// fireTrigger moves branch to the head of the branch that its trigger
+// watches, if the trigger's conditions are met, and returns true if it did.
+// The conditions are checked in the same transaction that moves the branch,
+// so pachds checking them at once move it at most once.
+func (d *driver) fireTrigger(ctx context.Context, repo *pfs.Repo, branch string) (bool, error) {
+	var fired bool
+	_, err := col.NewSTM(ctx, d.etcdClient, func(stm col.STM) error {
+		fired = false
+		branches := d.branches(repo.Name).ReadWrite(stm)
+		commits := d.commits(repo.Name).ReadWrite(stm)
+		triggers := d.triggers(repo.Name).ReadWrite(stm)
+		trigger := new(pfs.Trigger)
+		if err := triggers.Get(branch, trigger); err != nil {
+			if isNotFoundErr(err) {
+				return nil
+			}
+			return err
+		}
+		head, oldHead := new(pfs.Commit), new(pfs.Commit)
+		if err := branches.Get(trigger.Branch, head); err != nil {
+			if isNotFoundErr(err) {
+				return nil
+			}
+			return err
+		}
+		if err := branches.Get(branch, oldHead); err != nil {
+			if isNotFoundErr(err) {
+				return nil
+			}
+			return err
+		}
+		if head.ID == oldHead.ID {
+			return nil
+		}
+		getCommit := func(id string) (*pfs.CommitInfo, error) {
+			commitInfo := new(pfs.CommitInfo)
+			if err := commits.Get(id, commitInfo); err != nil {
+				return nil, err
+			}
+			return commitInfo, nil
+		}
+		headInfo, err := getCommit(head.ID)
+		if err != nil {
+			return err
+		}
+		// the branch is only moved to finished commits
+		if headInfo.Finished == nil {
+			return nil
+		}
+		oldHeadInfo, err := getCommit(oldHead.ID)
+		if err != nil {
+			return err
+		}
+		met, err := triggerMet(trigger, headInfo, oldHeadInfo, getCommit, time.Now())
+		if err != nil || !met {
+			return err
+		}
+		branches.Put(branch, head)
+		fired = true
+		return nil
+	})
+	return fired, err
+}
+
+// fireTriggersAfter fires repo's triggers after a commit in it is finished
+// or one of its branches is moved. The change has been made already, so an
+// error firing them isn't returned, they're retried by watchTriggers.
+func (d *driver) fireTriggersAfter(ctx context.Context, repo *pfs.Repo) {
+	if err := d.fireTriggers(ctx, repo); err != nil {
+		protolion.Errorf("error firing triggers: %v", err)
+	}
+}
+
+// watchTriggers fires every repo's triggers every triggerInterval, until
+// ctx is cancelled.
+func (d *driver) watchTriggers(ctx context.Context) {
+	ticker := time.NewTicker(triggerInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		repoInfos, err := d.listRepo(ctx, nil, "")
+		if err != nil {
+			protolion.Errorf("error listing repos to fire their triggers: %v", err)
+			continue
+		}
+		for _, repoInfo := range repoInfos {
+			if err := d.fireTriggers(ctx, repoInfo.Repo); err != nil {
+				protolion.Errorf("error firing triggers: %v", err)
+			}
+		}
+	}
+}
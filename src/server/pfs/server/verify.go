@@ -0,0 +1,170 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/server/pkg/hashtree"
+)
+
+// verifyTree checks the tree that buildTree stored for commitInfo before the
+// commit is finished with it. Only cheap invariants are checked, and only
+// around the paths that the commit touched: that each directory's size is the
+// sum of its children's, that the tree's size is the commit's, and that each
+// touched file's objects exist and add up to its size. Inconsistencies found
+// here would otherwise only surface later, as failures reading the files.
+func (d *driver) verifyTree(ctx context.Context, commitInfo *pfs.CommitInfo, touched []string) error {
+	if commitInfo.Tree == nil {
+		return nil
+	}
+	objClient, err := d.getObjectClient()
+	if err != nil {
+		return err
+	}
+	// the stored tree is read back, rather than the one buildTree built, so
+	// that what's checked is what reads will see
+	tree, err := hashtree.Load(hashtree.NewObjectStore(objClient), commitInfo.Tree)
+	if err != nil {
+		return fmt.Errorf("could not load tree %s: %v", commitInfo.Tree.Hash, err)
+	}
+	if tree.Size() != int64(commitInfo.SizeBytes) {
+		return fmt.Errorf("tree has size %d, but the commit has size %d", tree.Size(), commitInfo.SizeBytes)
+	}
+	v := &treeVerifier{
+		tree:      tree,
+		objClient: objClient,
+		dirs:      make(map[string]bool),
+		objects:   make(map[string]int64),
+	}
+	for _, p := range touched {
+		// later changes in the commit may have deleted the path, or its
+		// ancestors, in which case what's left of them is verified
+		var node *hashtree.NodeProto
+		for p = path.Join("/", p); p != "/"; p = path.Dir(p) {
+			node, err = tree.Get(p)
+			if err != nil && hashtree.Code(err) != hashtree.PathNotFound {
+				return err
+			}
+			if node != nil {
+				break
+			}
+		}
+		if node != nil {
+			if err := v.verifyTouched(p, node); err != nil {
+				return err
+			}
+		}
+		// the sizes of all of the path's ancestors changed with it
+		for dir := path.Dir(p); ; dir = path.Dir(dir) {
+			if err := v.verifyDir(dir); err != nil {
+				return err
+			}
+			if dir == "/" {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+type treeVerifier struct {
+	tree      hashtree.HashTree
+	objClient *client.APIClient
+	// dirs are the directories that have been verified
+	dirs map[string]bool
+	// objects are the sizes of the objects that have been verified
+	objects map[string]int64
+}
+
+// verifyTouched verifies the node at p, a path that was put, deleted from or
+// split into. If it's a directory the files directly under it are verified
+// too, since they're what split puts.
+func (v *treeVerifier) verifyTouched(p string, node *hashtree.NodeProto) error {
+	if node.FileNode != nil {
+		return v.verifyFile(p, node)
+	}
+	if err := v.verifyDir(p); err != nil {
+		return err
+	}
+	children, err := v.tree.List(p)
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		if child.FileNode != nil {
+			if err := v.verifyFile(path.Join(p, child.Name), child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// verifyDir checks that the directory at p exists, that its children all
+// exist, and that its size is the sum of theirs.
+func (v *treeVerifier) verifyDir(p string) error {
+	if v.dirs[p] {
+		return nil
+	}
+	node, err := v.tree.Get(p)
+	if err != nil {
+		if hashtree.Code(err) == hashtree.PathNotFound && p == "/" {
+			// an empty tree has no root
+			v.dirs[p] = true
+			return nil
+		}
+		return fmt.Errorf("could not get directory %s: %v", p, err)
+	}
+	if node.DirNode == nil {
+		return fmt.Errorf("%s has children, but isn't a directory", p)
+	}
+	children, err := v.tree.List(p)
+	if err != nil {
+		return fmt.Errorf("could not list directory %s: %v", p, err)
+	}
+	if len(children) != len(node.DirNode.Children) {
+		return fmt.Errorf("directory %s has %d children, but %d of them exist", p, len(node.DirNode.Children), len(children))
+	}
+	var size int64
+	for _, child := range children {
+		size += child.SubtreeSize
+	}
+	if size != node.SubtreeSize {
+		return fmt.Errorf("directory %s has size %d, but its children's sizes add up to %d", p, node.SubtreeSize, size)
+	}
+	v.dirs[p] = true
+	return nil
+}
+
+// verifyFile checks that the objects of the file at p exist and that their
+// sizes add up to the file's.
+func (v *treeVerifier) verifyFile(p string, node *hashtree.NodeProto) error {
+	var size int64
+	for _, object := range node.FileNode.Objects {
+		objectSize, ok := v.objects[object.Hash]
+		if !ok {
+			exists, err := v.objClient.CheckObject(object.Hash)
+			if err != nil {
+				return fmt.Errorf("could not check object %s of file %s: %v", object.Hash, p, err)
+			}
+			if !exists {
+				return fmt.Errorf("file %s refers to object %s, which doesn't exist", p, object.Hash)
+			}
+			objectInfo, err := v.objClient.InspectObject(object.Hash)
+			if err != nil {
+				return fmt.Errorf("could not inspect object %s of file %s: %v", object.Hash, p, err)
+			}
+			blockRange := objectInfo.BlockRef.Range
+			objectSize = int64(blockRange.Upper - blockRange.Lower)
+			v.objects[object.Hash] = objectSize
+		}
+		size += objectSize
+	}
+	if size != node.SubtreeSize {
+		return fmt.Errorf("file %s has size %d, but its objects' sizes add up to %d", p, node.SubtreeSize, size)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+// Package clock provides the clock that pachd timestamps commits and jobs
+// with. It's a hybrid logical clock: it follows the wall clock, but never
+// goes backwards, and timestamps that it observes, e.g. ones written by other
+// pachds, whose wall clocks may be skewed from this one's, move it forwards.
+// So a timestamp taken after observing another is always after it, which is
+// what keeps a commit's or job's Finished after its Started.
+package clock
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+)
+
+// tick is how far the clock moves forwards when the wall clock hasn't passed
+// its last timestamp.
+const tick = time.Microsecond
+
+// Clock is a hybrid logical clock.
+type Clock struct {
+	wall func() time.Time
+
+	mu sync.Mutex
+	// last is the latest timestamp that the clock has returned or observed
+	last time.Time
+}
+
+// New returns a Clock that follows wall.
+func New(wall func() time.Time) *Clock {
+	return &Clock{wall: wall}
+}
+
+// Observe moves the clock forwards to the latest of ts, if it's behind it.
+// Nil timestamps are ignored.
+func (c *Clock) Observe(ts ...*types.Timestamp) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, t := range ts {
+		if t == nil {
+			continue
+		}
+		observed, err := types.TimestampFromProto(t)
+		if err != nil {
+			continue
+		}
+		if observed.After(c.last) {
+			c.last = observed
+		}
+	}
+}
+
+// Now returns a timestamp after every one that the clock has returned or
+// observed. It's the wall clock's time, unless that's behind.
+func (c *Clock) Now() *types.Timestamp {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := c.wall()
+	if !t.After(c.last) {
+		t = c.last.Add(tick)
+	}
+	c.last = t
+	result, err := types.TimestampProto(t)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// After observes ts and then returns Now, so the result is after all of ts.
+func (c *Clock) After(ts ...*types.Timestamp) *types.Timestamp {
+	c.Observe(ts...)
+	return c.Now()
+}
+
+var defaultClock = New(time.Now)
+
+// Observe moves the process's clock forwards to the latest of ts.
+func Observe(ts ...*types.Timestamp) {
+	defaultClock.Observe(ts...)
+}
+
+// Now returns a timestamp from the process's clock.
+func Now() *types.Timestamp {
+	return defaultClock.Now()
+}
+
+// After returns a timestamp from the process's clock that's after all of ts.
+func After(ts ...*types.Timestamp) *types.Timestamp {
+	return defaultClock.After(ts...)
+}
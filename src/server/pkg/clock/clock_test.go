@@ -0,0 +1,46 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+)
+
+func toTime(t *testing.T, ts *types.Timestamp) time.Time {
+	result, err := types.TimestampFromProto(ts)
+	require.NoError(t, err)
+	return result
+}
+
+func TestClockFollowsWall(t *testing.T) {
+	wall := time.Unix(1000, 0)
+	c := New(func() time.Time { return wall })
+	require.True(t, wall.Equal(toTime(t, c.Now())))
+	wall = wall.Add(time.Second)
+	require.True(t, wall.Equal(toTime(t, c.Now())))
+}
+
+func TestClockNeverGoesBackwards(t *testing.T) {
+	wall := time.Unix(1000, 0)
+	c := New(func() time.Time { return wall })
+	first := toTime(t, c.Now())
+	wall = wall.Add(-time.Hour)
+	second := toTime(t, c.Now())
+	require.True(t, second.After(first))
+	third := toTime(t, c.Now())
+	require.True(t, third.After(second))
+}
+
+func TestClockAfterSkewedTimestamp(t *testing.T) {
+	wall := time.Unix(1000, 0)
+	c := New(func() time.Time { return wall })
+	// a timestamp from a pachd whose clock is a minute ahead of this one's
+	skewed, err := types.TimestampProto(wall.Add(time.Minute))
+	require.NoError(t, err)
+	finished := toTime(t, c.After(skewed, nil))
+	require.True(t, finished.After(toTime(t, skewed)))
+	// the clock stays ahead of the observed timestamp
+	require.True(t, toTime(t, c.Now()).After(finished))
+}
@@ -49,13 +49,44 @@ func Run(run func(args []string) error) func(*cobra.Command, []string) {
 }
 
 // ErrorAndExit errors with the given format and args, and then exits.
+// Within RunWithoutExit it returns to RunWithoutExit instead of exiting.
 func ErrorAndExit(format string, args ...interface{}) {
 	if errString := strings.TrimSpace(fmt.Sprintf(format, args...)); errString != "" {
 		fmt.Fprintf(os.Stderr, "%s\n", errString)
 	}
+	if noExit {
+		panic(errExit{})
+	}
 	os.Exit(1)
 }
 
+// errExit is what ErrorAndExit panics with, rather than exiting, within
+// RunWithoutExit.
+type errExit struct{}
+
+// noExit is true while RunWithoutExit is running.
+var noExit bool
+
+// RunWithoutExit calls f, which typically executes a command, such that
+// ErrorAndExit stops f rather than exiting the process. It returns true if
+// ErrorAndExit was called. This allows a process to run many commands, e.g.
+// pachctl shell. ErrorAndExit must only be called from the goroutine which
+// called RunWithoutExit.
+func RunWithoutExit(f func()) (exited bool) {
+	noExit = true
+	defer func() {
+		noExit = false
+		if r := recover(); r != nil {
+			if _, ok := r.(errExit); !ok {
+				panic(r)
+			}
+			exited = true
+		}
+	}()
+	f()
+	return false
+}
+
 // ParseCommits takes a slice of arguments of the form "repo/commit-id" or
 // "repo" (in which case we consider the commit ID to be empty), and returns
 // a list of Commits
@@ -11,6 +11,7 @@ import (
 	"github.com/pachyderm/pachyderm/src/server/pkg/watch"
 
 	etcd "github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/mvcc/mvccpb"
 	"github.com/gogo/protobuf/proto"
 )
 
@@ -25,10 +26,25 @@ type collection struct {
 	// To be clear, this is only necessary because of `Delete`, where we
 	// need to know the type in order to properly remove secondary indexes.
 	template proto.Message
+	// cipher encrypts the collection's values, it's nil if they're stored
+	// in plaintext
+	cipher *Cipher
+	// notFound and exists are wrapped by the collection's ErrNotFound and
+	// ErrExists errors, see WithErrors
+	notFound error
+	exists   error
 }
 
 // NewCollection creates a new collection.
 func NewCollection(etcdClient *etcd.Client, prefix string, indexes []Index, template proto.Message) Collection {
+	return NewEncryptedCollection(etcdClient, prefix, indexes, template, nil)
+}
+
+// NewEncryptedCollection creates a new collection whose values are encrypted
+// with cipher. Keys and secondary indexes aren't encrypted, so don't index a
+// collection on fields that need to be kept secret. If cipher is nil, values
+// are stored in plaintext, as they are by NewCollection.
+func NewEncryptedCollection(etcdClient *etcd.Client, prefix string, indexes []Index, template proto.Message, cipher *Cipher) Collection {
 	// We want to ensure that the prefix always ends with a trailing
 	// slash.  Otherwise, when you list the items under a collection
 	// such as `foo`, you might end up listing items under `foobar`
@@ -42,9 +58,20 @@ func NewCollection(etcdClient *etcd.Client, prefix string, indexes []Index, temp
 		etcdClient: etcdClient,
 		indexes:    indexes,
 		template:   template,
+		cipher:     cipher,
 	}
 }
 
+// WithErrors returns a copy of c whose ErrNotFound and ErrExists errors wrap
+// notFound and exists, so that callers (and pachd's clients) can tell them
+// apart from those of other collections with errors.Is. Either may be nil.
+func WithErrors(c Collection, notFound error, exists error) Collection {
+	copied := *c.(*collection)
+	copied.notFound = notFound
+	copied.exists = exists
+	return &copied
+}
+
 func (c *collection) ReadWrite(stm STM) ReadWriteCollection {
 	return &readWriteCollection{
 		collection: c,
@@ -71,6 +98,74 @@ func (c *collection) path(key string) string {
 	return path.Join(c.prefix, key)
 }
 
+// unmarshal decrypts a value read from etcd and unmarshals it into val.
+func (c *collection) unmarshal(valStr string, val proto.Message) error {
+	valStr, err := c.cipher.Decrypt(valStr)
+	if err != nil {
+		return err
+	}
+	return proto.UnmarshalText(valStr, val)
+}
+
+// decrypted returns a watcher whose events carry the decrypted values of the
+// events from watcher.
+func (c *collection) decrypted(watcher watch.Watcher) watch.Watcher {
+	if c.cipher == nil {
+		return watcher
+	}
+	eventCh := make(chan *watch.Event)
+	done := make(chan struct{})
+	go func() {
+		defer close(eventCh)
+		defer watcher.Close()
+		for {
+			var ev *watch.Event
+			var ok bool
+			select {
+			case ev, ok = <-watcher.Watch():
+			case <-done:
+				return
+			}
+			if !ok {
+				return
+			}
+			if err := c.decryptEvent(ev); err != nil {
+				ev = &watch.Event{
+					Type: watch.EventError,
+					Err:  err,
+				}
+			}
+			select {
+			case eventCh <- ev:
+			case <-done:
+				return
+			}
+			if ev.Type == watch.EventError {
+				return
+			}
+		}
+	}()
+	return watch.MakeWatcher(eventCh, done)
+}
+
+func (c *collection) decryptEvent(ev *watch.Event) error {
+	if ev.Value != nil {
+		value, err := c.cipher.Decrypt(string(ev.Value))
+		if err != nil {
+			return err
+		}
+		ev.Value = []byte(value)
+	}
+	if ev.PrevValue != nil {
+		prevValue, err := c.cipher.Decrypt(string(ev.PrevValue))
+		if err != nil {
+			return err
+		}
+		ev.PrevValue = []byte(prevValue)
+	}
+	return nil
+}
+
 // See the documentation for `Index` for details.
 func (c *collection) indexDir(index Index, indexVal string) string {
 	indexDir := c.prefix
@@ -92,9 +187,9 @@ type readWriteCollection struct {
 func (c *readWriteCollection) Get(key string, val proto.Message) error {
 	valStr := c.stm.Get(c.path(key))
 	if valStr == "" {
-		return ErrNotFound{c.prefix, key}
+		return ErrNotFound{c.prefix, key, c.notFound}
 	}
-	return proto.UnmarshalText(valStr, val)
+	return c.unmarshal(valStr, val)
 }
 
 func cloneProtoMsg(original proto.Message) proto.Message {
@@ -174,14 +269,14 @@ func (c *readWriteCollection) Put(key string, val proto.Message) {
 			}
 		}
 	}
-	c.stm.Put(c.path(key), val.String())
+	c.stm.Put(c.path(key), c.cipher.Encrypt(val.String()))
 }
 
 func (c *readWriteCollection) Create(key string, val proto.Message) error {
 	fullKey := c.path(key)
 	valStr := c.stm.Get(fullKey)
 	if valStr != "" {
-		return ErrExists{c.prefix, key}
+		return ErrExists{c.prefix, key, c.exists}
 	}
 	c.Put(key, val)
 	return nil
@@ -190,7 +285,7 @@ func (c *readWriteCollection) Create(key string, val proto.Message) error {
 func (c *readWriteCollection) Delete(key string) error {
 	fullKey := c.path(key)
 	if c.stm.Get(fullKey) == "" {
-		return ErrNotFound{c.prefix, key}
+		return ErrNotFound{c.prefix, key, c.notFound}
 	}
 	if c.indexes != nil && c.template != nil {
 		val := proto.Clone(c.template)
@@ -231,7 +326,7 @@ func (c *readWriteIntCollection) Create(key string, val int) error {
 	fullKey := c.path(key)
 	valStr := c.stm.Get(fullKey)
 	if valStr != "" {
-		return ErrExists{c.prefix, key}
+		return ErrExists{c.prefix, key, c.exists}
 	}
 	c.stm.Put(fullKey, strconv.Itoa(val))
 	return nil
@@ -240,7 +335,7 @@ func (c *readWriteIntCollection) Create(key string, val int) error {
 func (c *readWriteIntCollection) Get(key string) (int, error) {
 	valStr := c.stm.Get(c.path(key))
 	if valStr == "" {
-		return 0, ErrNotFound{c.prefix, key}
+		return 0, ErrNotFound{c.prefix, key, c.notFound}
 	}
 	return strconv.Atoi(valStr)
 }
@@ -249,7 +344,7 @@ func (c *readWriteIntCollection) Increment(key string) error {
 	fullKey := c.path(key)
 	valStr := c.stm.Get(fullKey)
 	if valStr == "" {
-		return ErrNotFound{c.prefix, key}
+		return ErrNotFound{c.prefix, key, c.notFound}
 	}
 	val, err := strconv.Atoi(valStr)
 	if err != nil {
@@ -263,7 +358,7 @@ func (c *readWriteIntCollection) Decrement(key string) error {
 	fullKey := c.path(key)
 	valStr := c.stm.Get(fullKey)
 	if valStr == "" {
-		return ErrNotFound{c.prefix, key}
+		return ErrNotFound{c.prefix, key, c.notFound}
 	}
 	val, err := strconv.Atoi(valStr)
 	if err != nil {
@@ -276,7 +371,7 @@ func (c *readWriteIntCollection) Decrement(key string) error {
 func (c *readWriteIntCollection) Delete(key string) error {
 	fullKey := c.path(key)
 	if c.stm.Get(fullKey) == "" {
-		return ErrNotFound{c.prefix, key}
+		return ErrNotFound{c.prefix, key, c.notFound}
 	}
 	c.stm.Del(fullKey)
 	return nil
@@ -294,10 +389,10 @@ func (c *readonlyCollection) Get(key string, val proto.Message) error {
 	}
 
 	if len(resp.Kvs) == 0 {
-		return ErrNotFound{c.prefix, key}
+		return ErrNotFound{c.prefix, key, c.notFound}
 	}
 
-	return proto.UnmarshalText(string(resp.Kvs[0].Value), val)
+	return c.unmarshal(string(resp.Kvs[0].Value), val)
 }
 
 // an indirect iterator goes through a list of keys and retrieve those
@@ -335,43 +430,104 @@ func (c *readonlyCollection) GetByIndex(index Index, val interface{}) (Iterator,
 	}, nil
 }
 
+// listPageSize is the number of objects that List reads from etcd at a time,
+// so that collections with millions of objects can be listed without holding
+// all of them in memory.
+var listPageSize int64 = 1000
+
 // List returns an iteraor that can be used to iterate over the collection.
 // The objects are sorted by revision time in descending order, i.e. newer
-// objects are returned first.
+// objects are returned first. Objects are read from etcd a page at a time,
+// and all pages are read at the revision that the first one was, so the
+// iterator sees a consistent snapshot of the collection.
 func (c *readonlyCollection) List() (Iterator, error) {
-	resp, err := c.etcdClient.Get(c.ctx, c.prefix, etcd.WithPrefix(), etcd.WithSort(etcd.SortByModRevision, etcd.SortDescend))
-	if err != nil {
+	i := &iterator{
+		col: c,
+	}
+	if err := i.nextPage(); err != nil {
 		return nil, err
 	}
-	return &iterator{
-		resp: resp,
-	}, nil
+	return i, nil
 }
 
 type iterator struct {
 	index int
-	resp  *etcd.GetResponse
+	kvs   []*mvccpb.KeyValue
+	col   *readonlyCollection
+	// rev is the etcd revision that all pages are read at
+	rev int64
+	// maxModRev is the largest mod revision of the objects in the next
+	// page, it's 0 once the last page has been read
+	maxModRev int64
+}
+
+// nextPage reads the next page of objects into i.kvs. Objects that were
+// modified in the same etcd revision are always read in the same page, since
+// pages are delimited by mod revision.
+func (i *iterator) nextPage() error {
+	opts := []etcd.OpOption{etcd.WithPrefix(), etcd.WithSort(etcd.SortByModRevision, etcd.SortDescend), etcd.WithLimit(listPageSize)}
+	if i.rev != 0 {
+		opts = append(opts, etcd.WithRev(i.rev), etcd.WithMaxModRev(i.maxModRev))
+	}
+	resp, err := i.col.etcdClient.Get(i.col.ctx, i.col.prefix, opts...)
+	if err != nil {
+		return err
+	}
+	if i.rev == 0 {
+		i.rev = resp.Header.Revision
+	}
+	i.index = 0
+	i.kvs = resp.Kvs
+	i.maxModRev = 0
+	if !resp.More || len(resp.Kvs) == 0 {
+		return nil
+	}
+	// The page may have cut the objects of its last mod revision in two,
+	// so drop them and read all of them at once.
+	lastModRev := resp.Kvs[len(resp.Kvs)-1].ModRevision
+	for len(i.kvs) > 0 && i.kvs[len(i.kvs)-1].ModRevision == lastModRev {
+		i.kvs = i.kvs[:len(i.kvs)-1]
+	}
+	lastResp, err := i.col.etcdClient.Get(i.col.ctx, i.col.prefix, etcd.WithPrefix(), etcd.WithRev(i.rev),
+		etcd.WithMinModRev(lastModRev), etcd.WithMaxModRev(lastModRev))
+	if err != nil {
+		return err
+	}
+	i.kvs = append(i.kvs, lastResp.Kvs...)
+	if lastModRev > 1 {
+		i.maxModRev = lastModRev - 1
+	}
+	return nil
 }
 
 func (i *iterator) Next(key *string, val proto.Message) (ok bool, retErr error) {
-	if i.index < len(i.resp.Kvs) {
-		kv := i.resp.Kvs[i.index]
-		i.index++
-
-		*key = path.Base(string(kv.Key))
-		if err := proto.UnmarshalText(string(kv.Value), val); err != nil {
+	for i.index >= len(i.kvs) {
+		if i.maxModRev == 0 {
+			return false, nil
+		}
+		if err := i.nextPage(); err != nil {
 			return false, err
 		}
+	}
+	kv := i.kvs[i.index]
+	i.index++
 
-		return true, nil
+	*key = path.Base(string(kv.Key))
+	if err := i.col.unmarshal(string(kv.Value), val); err != nil {
+		return false, err
 	}
-	return false, nil
+
+	return true, nil
 }
 
 // Watch a collection, returning the current content of the collection as
 // well as any future additions.
 func (c *readonlyCollection) Watch() (watch.Watcher, error) {
-	return watch.NewWatcher(c.ctx, c.etcdClient, c.prefix)
+	watcher, err := watch.NewWatcher(c.ctx, c.etcdClient, c.prefix)
+	if err != nil {
+		return nil, err
+	}
+	return c.decrypted(watcher), nil
 }
 
 // WatchByIndex watches items in a collection that match a particular index
@@ -436,11 +592,15 @@ func (c *readonlyCollection) WatchByIndex(index Index, val interface{}) (watch.W
 			eventCh <- directEv
 		}
 	}()
-	return watch.MakeWatcher(eventCh, done), nil
+	return c.decrypted(watch.MakeWatcher(eventCh, done)), nil
 }
 
 // WatchOne watches a given item.  The first value returned from the watch
 // will be the current value of the item.
 func (c *readonlyCollection) WatchOne(key string) (watch.Watcher, error) {
-	return watch.NewWatcher(c.ctx, c.etcdClient, c.path(key))
+	watcher, err := watch.NewWatcher(c.ctx, c.etcdClient, c.path(key))
+	if err != nil {
+		return nil, err
+	}
+	return c.decrypted(watcher), nil
 }
@@ -3,6 +3,7 @@ package collection
 import (
 	"context"
 	"fmt"
+	"sort"
 	"testing"
 	"time"
 
@@ -290,6 +291,60 @@ func TestMultiIndex(t *testing.T) {
 	require.Equal(t, j2, job)
 }
 
+func TestListPages(t *testing.T) {
+	etcdClient, err := getEtcdClient()
+	require.NoError(t, err)
+	uuidPrefix := uuid.NewWithoutDashes()
+
+	defer func(pageSize int64) { listPageSize = pageSize }(listPageSize)
+	listPageSize = 3
+
+	jobInfos := NewCollection(etcdClient, uuidPrefix, nil, &pps.JobInfo{})
+	// expected holds the IDs of the jobs put in each revision, newest first
+	var expected [][]string
+	put := func(IDs ...string) {
+		_, err := NewSTM(context.Background(), etcdClient, func(stm STM) error {
+			jobInfos := jobInfos.ReadWrite(stm)
+			for _, ID := range IDs {
+				jobInfos.Put(ID, &pps.JobInfo{Job: &pps.Job{ID}})
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		expected = append([][]string{IDs}, expected...)
+	}
+	for i := 0; i < 10; i++ {
+		put(fmt.Sprintf("j%d", i))
+	}
+	// more jobs than fit in a page, in one revision
+	put("k0", "k1", "k2", "k3", "k4")
+	put("l0", "l1")
+
+	iter, err := jobInfos.ReadOnly(context.Background()).List()
+	require.NoError(t, err)
+	// jobs put after List is called aren't listed
+	put("m0")
+	var IDs []string
+	for {
+		var ID string
+		ok, err := iter.Next(&ID, &pps.JobInfo{})
+		require.NoError(t, err)
+		if !ok {
+			break
+		}
+		IDs = append(IDs, ID)
+	}
+	// jobs put in the same revision may be listed in any order
+	for _, revisionIDs := range expected[1:] {
+		require.True(t, len(IDs) >= len(revisionIDs))
+		listed := append([]string{}, IDs[:len(revisionIDs)]...)
+		sort.Strings(listed)
+		require.Equal(t, revisionIDs, listed)
+		IDs = IDs[len(revisionIDs):]
+	}
+	require.Equal(t, 0, len(IDs))
+}
+
 func getEtcdClient() (*etcd.Client, error) {
 	etcdClient, err := etcd.New(etcd.Config{
 		Endpoints:   []string{"localhost:2379"},
@@ -0,0 +1,96 @@
+package collection
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// encryptedPrefix marks values that were encrypted by a Cipher. Proto text
+// never starts with it, so values written before encryption was turned on
+// can still be read.
+const encryptedPrefix = "enc:v1:"
+
+// KeySize is the size, in bytes, of the keys that NewCipher accepts.
+const KeySize = 32
+
+// A Cipher encrypts the values of a collection before they're written to
+// etcd, and decrypts them when they're read back, so that anyone who can
+// read etcd directly can't read them. A nil *Cipher leaves values in
+// plaintext.
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// NewCipher returns a Cipher that encrypts values with AES-256-GCM under
+// key, which must be KeySize bytes long.
+func NewCipher(key []byte) (*Cipher, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("encryption key must be %d bytes, not %d", KeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Cipher{aead: aead}, nil
+}
+
+// Encrypt encrypts value. If c is nil it returns value unchanged.
+func (c *Cipher) Encrypt(value string) string {
+	if c == nil {
+		return value
+	}
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		// crypto/rand only fails if the OS can't give us randomness, in
+		// which case there's nothing sensible left to do
+		panic(fmt.Sprintf("could not generate nonce: %v", err))
+	}
+	sealed := c.aead.Seal(nonce, nonce, []byte(value), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed)
+}
+
+// Decrypt decrypts a value returned by Encrypt. Values that aren't encrypted
+// are returned unchanged, so that collections can be read while their
+// values are still being rewritten in encrypted form.
+func (c *Cipher) Decrypt(value string) (string, error) {
+	if !strings.HasPrefix(value, encryptedPrefix) {
+		return value, nil
+	}
+	if c == nil {
+		return "", fmt.Errorf("value is encrypted, but no encryption key was configured")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted value: %v", err)
+	}
+	nonceSize := c.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return "", fmt.Errorf("malformed encrypted value: too short")
+	}
+	plaintext, err := c.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return "", fmt.Errorf("could not decrypt value (was it encrypted with a different key?): %v", err)
+	}
+	return string(plaintext), nil
+}
+
+// CipherFromKey returns a Cipher for a base64-encoded key, which is how
+// pachd and its workers are given the key, or nil if key is empty.
+func CipherFromKey(key string) (*Cipher, error) {
+	if key == "" {
+		return nil, nil
+	}
+	rawKey, err := base64.StdEncoding.DecodeString(strings.TrimSpace(key))
+	if err != nil {
+		return nil, fmt.Errorf("encryption key must be base64-encoded: %v", err)
+	}
+	return NewCipher(rawKey)
+}
@@ -0,0 +1,57 @@
+package collection
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+)
+
+func newTestCipher(t *testing.T) *Cipher {
+	key := make([]byte, KeySize)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	cipher, err := CipherFromKey(base64.StdEncoding.EncodeToString(key))
+	require.NoError(t, err)
+	return cipher
+}
+
+func TestCipher(t *testing.T) {
+	cipher := newTestCipher(t)
+	pipelineInfo := &pps.PipelineInfo{
+		Pipeline: &pps.Pipeline{Name: "p1"},
+		Transform: &pps.Transform{
+			Env: map[string]string{"PASSWORD": "hunter2"},
+		},
+	}
+	value := pipelineInfo.String()
+
+	encrypted := cipher.Encrypt(value)
+	require.False(t, strings.Contains(encrypted, "hunter2"))
+	require.NotEqual(t, encrypted, cipher.Encrypt(value))
+	decrypted, err := cipher.Decrypt(encrypted)
+	require.NoError(t, err)
+	require.Equal(t, value, decrypted)
+
+	// values written before encryption was turned on are still readable
+	decrypted, err = cipher.Decrypt(value)
+	require.NoError(t, err)
+	require.Equal(t, value, decrypted)
+
+	// but encrypted values can't be read without the right key
+	_, err = newTestCipher(t).Decrypt(encrypted)
+	require.YesError(t, err)
+	var noCipher *Cipher
+	_, err = noCipher.Decrypt(encrypted)
+	require.YesError(t, err)
+	require.Equal(t, value, noCipher.Encrypt(value))
+
+	noCipher, err = CipherFromKey("")
+	require.NoError(t, err)
+	require.True(t, noCipher == nil)
+	_, err = CipherFromKey(base64.StdEncoding.EncodeToString([]byte("too short")))
+	require.YesError(t, err)
+}
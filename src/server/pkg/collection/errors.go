@@ -7,23 +7,39 @@ import "fmt"
 type ErrNotFound struct {
 	Type string
 	Key  string
+	// Kind, if it's set, is the error that the collection's not-found
+	// errors wrap (see WithErrors)
+	Kind error
 }
 
 func (e ErrNotFound) Error() string {
 	return fmt.Sprintf("%s %s not found", e.Type, e.Key)
 }
 
+// Unwrap returns e.Kind.
+func (e ErrNotFound) Unwrap() error {
+	return e.Kind
+}
+
 // ErrExists indicates that a key was found to exist when it was expected not
 // to.
 type ErrExists struct {
 	Type string
 	Key  string
+	// Kind, if it's set, is the error that the collection's exists errors
+	// wrap (see WithErrors)
+	Kind error
 }
 
 func (e ErrExists) Error() string {
 	return fmt.Sprintf("%s %s already exists", e.Type, e.Key)
 }
 
+// Unwrap returns e.Kind.
+func (e ErrExists) Unwrap() error {
+	return e.Kind
+}
+
 // ErrMalformedValue indicates that a value was malformed, such as when it was
 // supposed to be parseable as an int but wasn't.
 type ErrMalformedValue struct {
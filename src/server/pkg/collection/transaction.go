@@ -53,6 +53,11 @@ func NewSTM(ctx context.Context, c *v3.Client, apply func(STM) error) (*v3.TxnRe
 	return newSTMSerializable(ctx, c, apply)
 }
 
+// MaxTxnOps is the most operations that etcd accepts in a single transaction
+// (its --max-txn-ops default). Callers that write an unbounded number of keys
+// must split the writes across several STMs.
+const MaxTxnOps = 128
+
 // newSTMRepeatable initiates new repeatable read transaction; reads within
 // the same transaction attempt always return the same data.
 func newSTMRepeatable(ctx context.Context, c *v3.Client, apply func(STM) error) (*v3.TxnResponse, error) {
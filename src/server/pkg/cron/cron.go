@@ -0,0 +1,189 @@
+// Package cron parses cron schedules, such as "0 * * * *", and finds the
+// times that they're due.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a cron schedule.
+type Schedule interface {
+	// Next returns the first time after t that the schedule is due.
+	Next(t time.Time) time.Time
+}
+
+// descriptors are the shorthands for common schedules.
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var dayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// Parse parses spec, which is either five fields (minute, hour, day of
+// month, month and day of week, in UTC), a descriptor such as "@daily", or
+// "@every <duration>", e.g. "@every 15m".
+func Parse(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron schedule %q: %v", spec, err)
+		}
+		if d < time.Second {
+			return nil, fmt.Errorf("invalid cron schedule %q: it must be at least a second", spec)
+		}
+		return every(d), nil
+	}
+	if expanded, ok := descriptors[spec]; ok {
+		spec = expanded
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron schedule %q: it must have 5 fields (minute, hour, day of month, month and day of week)", spec)
+	}
+	s := &fieldSchedule{}
+	var err error
+	if s.minute, err = parseField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("invalid minute in cron schedule %q: %v", spec, err)
+	}
+	if s.hour, err = parseField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("invalid hour in cron schedule %q: %v", spec, err)
+	}
+	if s.dom, err = parseField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("invalid day of month in cron schedule %q: %v", spec, err)
+	}
+	if s.month, err = parseField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, fmt.Errorf("invalid month in cron schedule %q: %v", spec, err)
+	}
+	// 7 is also Sunday
+	if s.dow, err = parseField(fields[4], 0, 7, dayNames); err != nil {
+		return nil, fmt.Errorf("invalid day of week in cron schedule %q: %v", spec, err)
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domStar = fields[2] == "*" || strings.HasPrefix(fields[2], "*/")
+	s.dowStar = fields[4] == "*" || strings.HasPrefix(fields[4], "*/")
+	return s, nil
+}
+
+// every is a schedule that's due at a fixed interval.
+type every time.Duration
+
+func (e every) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e)).Truncate(time.Second)
+}
+
+// fieldSchedule is a five field schedule, whose fields are bitsets of the
+// values that they match.
+type fieldSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// domStar and dowStar are whether the day of month and day of week
+	// fields are unrestricted. If neither is, a day matches if either does.
+	domStar, dowStar bool
+}
+
+// parseField parses a comma-separated list of values, ranges ("1-5") and
+// steps ("*/15" or "0-30/10") between min and max.
+func parseField(field string, min int, max int, names map[string]int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q", part[i+1:])
+			}
+			part = part[:i]
+		}
+		start, end := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if start, err = parseValue(bounds[0], names); err != nil {
+				return 0, err
+			}
+			end = start
+			if len(bounds) == 2 {
+				if end, err = parseValue(bounds[1], names); err != nil {
+					return 0, err
+				}
+			} else if step != 1 {
+				// "5/15" means from 5, every 15
+				end = max
+			}
+		}
+		if start < min || end > max || start > end {
+			return 0, fmt.Errorf("%q is outside of %d-%d", part, min, max)
+		}
+		for i := start; i <= end; i += step {
+			bits |= 1 << uint(i)
+		}
+	}
+	return bits, nil
+}
+
+func parseValue(value string, names map[string]int) (int, error) {
+	if n, ok := names[strings.ToLower(value)]; ok {
+		return n, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", value)
+	}
+	return n, nil
+}
+
+// maxYears bounds the search for a schedule's next time, as schedules such as
+// "0 0 30 2 *" are never due.
+const maxYears = 5
+
+func (s *fieldSchedule) Next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(maxYears, 0, 0)
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (s *fieldSchedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
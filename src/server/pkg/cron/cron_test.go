@@ -0,0 +1,64 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+)
+
+func parseTime(t *testing.T, value string) time.Time {
+	result, err := time.Parse(time.RFC3339, value)
+	require.NoError(t, err)
+	return result
+}
+
+func TestNext(t *testing.T) {
+	for _, test := range []struct {
+		spec     string
+		from     string
+		expected string
+	}{
+		{"* * * * *", "2017-06-01T10:15:30Z", "2017-06-01T10:16:00Z"},
+		{"0 * * * *", "2017-06-01T10:15:00Z", "2017-06-01T11:00:00Z"},
+		{"*/15 * * * *", "2017-06-01T10:15:00Z", "2017-06-01T10:30:00Z"},
+		{"30 2 * * *", "2017-06-01T10:15:00Z", "2017-06-02T02:30:00Z"},
+		{"@daily", "2017-12-31T23:59:00Z", "2018-01-01T00:00:00Z"},
+		{"0 9 * * mon-fri", "2017-06-02T10:00:00Z", "2017-06-05T09:00:00Z"},
+		{"0 0 1 feb *", "2017-06-01T00:00:00Z", "2018-02-01T00:00:00Z"},
+		{"0 0 29 2 *", "2017-03-01T00:00:00Z", "2020-02-29T00:00:00Z"},
+		// either the day of month or the day of week matches
+		{"0 0 15 * 0", "2017-06-01T00:00:00Z", "2017-06-04T00:00:00Z"},
+		{"0 0 * * 7", "2017-06-01T00:00:00Z", "2017-06-04T00:00:00Z"},
+		{"@every 90m", "2017-06-01T10:15:00Z", "2017-06-01T11:45:00Z"},
+	} {
+		schedule, err := Parse(test.spec)
+		require.NoError(t, err)
+		require.Equal(t, parseTime(t, test.expected), schedule.Next(parseTime(t, test.from)), test.spec)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"@every soon",
+		"@every 1ms",
+		"@sometimes",
+	} {
+		_, err := Parse(spec)
+		require.YesError(t, err, spec)
+	}
+}
+
+func TestNeverDue(t *testing.T) {
+	schedule, err := Parse("0 0 30 2 *")
+	require.NoError(t, err)
+	require.True(t, schedule.Next(time.Now()).IsZero())
+}
@@ -1,14 +1,20 @@
 package assets
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
+	"net"
+	"path"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pachyderm/pachyderm/src/server/pfs/server"
 	"github.com/pachyderm/pachyderm/src/server/pkg/deploy"
+	"github.com/pachyderm/pachyderm/src/server/pkg/etcdutil"
 	"github.com/ugorji/go/codec"
 	"k8s.io/kubernetes/pkg/api"
 	"k8s.io/kubernetes/pkg/api/resource"
@@ -19,6 +25,7 @@ import (
 	// kubectl, we need the api version to interact correctly with deployments
 	"k8s.io/kubernetes/pkg/api/v1"
 	"k8s.io/kubernetes/pkg/apis/extensions"
+	"k8s.io/kubernetes/pkg/util/intstr"
 )
 
 var (
@@ -40,7 +47,8 @@ var (
 	amazonSecretName        = "amazon-secret"
 	googleSecretName        = "google-secret"
 	microsoftSecretName     = "microsoft-secret"
-	trueVal                 = true
+	tlsVolumeName           = "pachd-tls-cert"
+	flexVolumeName          = "pfs-flexvolume"
 	jsonEncoderHandle       = &codec.JsonHandle{
 		BasicHandle: codec.BasicHandle{
 			EncodeOptions: codec.EncodeOptions{Canonical: true},
@@ -49,6 +57,113 @@ var (
 	}
 )
 
+// EtcdKeySecretItem is the item of the etcd encryption secret (see
+// AssetOpts.EtcdKeySecret) that holds the base64-encoded key.
+const EtcdKeySecretItem = "key"
+
+// EtcdKeySecretEnv returns the env vars that give pachd, or one of its
+// workers, the key in secretName that sensitive metadata is encrypted with in
+// etcd. It returns nil if secretName is empty.
+func EtcdKeySecretEnv(secretName string) []api.EnvVar {
+	if secretName == "" {
+		return nil
+	}
+	return []api.EnvVar{{
+		Name: "ETCD_ENCRYPTION_KEY",
+		ValueFrom: &api.EnvVarSource{
+			SecretKeyRef: &api.SecretKeySelector{
+				LocalObjectReference: api.LocalObjectReference{
+					Name: secretName,
+				},
+				Key: EtcdKeySecretItem,
+			},
+		},
+	}}
+}
+
+// The items of the secret that holds the credentials of an external etcd,
+// see AssetOpts.EtcdCredentialsSecret.
+const (
+	EtcdUsernameItem = "username"
+	EtcdPasswordItem = "password"
+)
+
+// etcdTLSVolumeName is the volume of the secret holding the certificates of
+// an external etcd, see AssetOpts.EtcdTLSSecret.
+const etcdTLSVolumeName = "etcd-tls"
+
+// The defaults of AssetOpts.PachdDrainTimeout, PachdProbePeriod and
+// PachdProbeFailureThreshold, which match pachd's and kubernetes' own.
+const (
+	defaultPachdDrainTimeout          = 30 * time.Second
+	defaultPachdProbePeriod           = 10 * time.Second
+	defaultPachdProbeFailureThreshold = 3
+)
+
+// The defaults of AssetOpts.PachdPort, PachdHTTPPort and WorkerPort. pachd's
+// service always exposes 650 and 651, whichever ports pachd listens on.
+const (
+	defaultPachdPort     = 650
+	defaultPachdHTTPPort = 651
+	defaultWorkerPort    = 80
+)
+
+// s3GatewayPort is the port that pachd serves its S3 gateway on, see s3.Server.
+const s3GatewayPort = 600
+
+// ExternalEtcdEnv returns the env vars that connect pachd, or one of its
+// workers, to an external etcd at endpoints, as the user in
+// credentialsSecret if it's non-empty. It returns nil if endpoints is empty,
+// i.e. if pachyderm deploys its own etcd.
+func ExternalEtcdEnv(endpoints []string, credentialsSecret string) []api.EnvVar {
+	if len(endpoints) == 0 {
+		return nil
+	}
+	env := []api.EnvVar{{
+		Name:  "ETCD_ENDPOINTS",
+		Value: strings.Join(endpoints, ","),
+	}}
+	if credentialsSecret == "" {
+		return env
+	}
+	for _, item := range []struct{ env, key string }{
+		{"ETCD_USERNAME", EtcdUsernameItem},
+		{"ETCD_PASSWORD", EtcdPasswordItem},
+	} {
+		env = append(env, api.EnvVar{
+			Name: item.env,
+			ValueFrom: &api.EnvVarSource{
+				SecretKeyRef: &api.SecretKeySelector{
+					LocalObjectReference: api.LocalObjectReference{
+						Name: credentialsSecret,
+					},
+					Key: item.key,
+				},
+			},
+		})
+	}
+	return env
+}
+
+// ExternalEtcdTLSVolumeAndMount returns the volume of tlsSecret, which holds
+// the certificates that pachd and its workers connect to an external etcd
+// with, and its mount at etcdutil.TLSDir.
+func ExternalEtcdTLSVolumeAndMount(tlsSecret string) (api.Volume, api.VolumeMount) {
+	volume := api.Volume{
+		Name: etcdTLSVolumeName,
+		VolumeSource: api.VolumeSource{
+			Secret: &api.SecretVolumeSource{
+				SecretName: tlsSecret,
+			},
+		},
+	}
+	mount := api.VolumeMount{
+		Name:      etcdTLSVolumeName,
+		MountPath: etcdutil.TLSDir,
+	}
+	return volume, mount
+}
+
 type backend int
 
 const (
@@ -86,6 +201,10 @@ type AssetOpts struct {
 	// a default size.
 	PachdNonCacheMemRequest string
 
+	// PachdReplicas is the number of pachd pods. pachds split up the masters
+	// of pipelines and jobs between them. If 0, one pachd is deployed.
+	PachdReplicas int
+
 	// EtcdCPURequest is the amount of CPU (in cores) we request for each etcd
 	// node. If empty, assets.go will choose a default size.
 	EtcdCPURequest string
@@ -93,6 +212,266 @@ type AssetOpts struct {
 	// EtcdMemRequest is the amount of memory we request for each etcd node. If
 	// empty, assets.go will choose a default size.
 	EtcdMemRequest string
+
+	// Namespace is the kubernetes namespace that Pachyderm is deployed in. If
+	// empty, the namespace of the kubectl context is used.
+	Namespace string
+
+	// StorageClass is the name of an existing kubernetes StorageClass which
+	// is used to provision etcd's volumes when etcd is deployed as a
+	// StatefulSet. If empty, assets.go creates a StorageClass appropriate
+	// for the backend.
+	StorageClass string
+
+	// Registry is the docker registry (optionally with a path prefix, e.g.
+	// "registry.example.com/mirror") that Pachyderm's images, and the image
+	// of pipelines which don't name one, are pulled from. If empty, the
+	// images' default registries are used.
+	Registry string
+
+	// Upgrade, if true, means that only the assets which can be upgraded in
+	// place are written, i.e. not etcd or the object store's secrets, which
+	// hold the data of the existing deployment.
+	Upgrade bool
+
+	// TLSSecret is the name of an existing kubernetes TLS secret (e.g. one
+	// issued by cert-manager) holding pachd's certificate. If set, pachd
+	// serves TLS on its gRPC port.
+	TLSSecret string
+
+	// EtcdKeySecret is the name of an existing kubernetes secret holding the
+	// key that pachd encrypts tokens, ACLs, pipelines and jobs with in etcd.
+	// If empty, they're stored in plaintext.
+	EtcdKeySecret string
+
+	// EtcdEndpoints, if set, are the endpoints of an existing etcd cluster
+	// (e.g. "https://etcd-0.example.com:2379") that pachd uses, instead of
+	// deploying its own. EtcdTLSSecret and EtcdCredentialsSecret are the
+	// names of existing kubernetes secrets holding the certificates that
+	// pachd connects to it with (see etcdutil.Options) and the user that it
+	// authenticates as (see EtcdUsernameItem), if it requires them.
+	EtcdEndpoints         []string
+	EtcdTLSSecret         string
+	EtcdCredentialsSecret string
+
+	// EtcdCompactionInterval and EtcdDefragmentInterval are how often pachd
+	// compacts and defragments etcd (e.g. "1h"), see
+	// etcdutil.MaintenanceOptions. If empty, pachd's defaults are used.
+	EtcdCompactionInterval string
+	EtcdDefragmentInterval string
+
+	// WorkerNetworkPolicies, if true, makes pachd create a network policy for
+	// each pipeline's workers, which only lets them connect to pachd, etcd,
+	// the object store and the destinations in the pipeline's spec.
+	WorkerNetworkPolicies bool
+
+	// PrepullImages, if true, makes pachd pull each pipeline's images onto
+	// the nodes that its workers may run on as soon as the pipeline is
+	// created, with a DaemonSet, so that its first job doesn't wait for them.
+	PrepullImages bool
+
+	// RegistryCredentials, if set, are the ECR, GCR and ACR registries whose
+	// short-lived credentials pachd refreshes, for pipelines' workers to
+	// pull their images with, see pps_server.RefreshRegistryCredentials.
+	RegistryCredentials []string
+
+	// AllowedImagePrefixes, if not empty, are the only images that pipelines
+	// may use, see pps_server.imageAllowed.
+	AllowedImagePrefixes []string
+
+	// RequireNonRoot and RequireResourceLimits, if true, make pachd reject
+	// pipelines that don't run as a non-root user, or don't limit their
+	// workers' CPU and memory, respectively.
+	RequireNonRoot        bool
+	RequireResourceLimits bool
+
+	// WorkerDefaultCPURequest, WorkerDefaultMemoryRequest,
+	// WorkerDefaultCPULimit and WorkerDefaultMemoryLimit are the resources
+	// that pachd gives pipelines' workers which don't set them in their
+	// specs, and WorkerMaxCPU and WorkerMaxMemory are the most that a
+	// pipeline's spec may set, see pps_server.WorkerResources. Those that are
+	// empty aren't set.
+	WorkerDefaultCPURequest    string
+	WorkerDefaultMemoryRequest string
+	WorkerDefaultCPULimit      string
+	WorkerDefaultMemoryLimit   string
+	WorkerMaxCPU               string
+	WorkerMaxMemory            string
+
+	// MaxWorkers, if not 0, caps the total number of pipelines' and jobs'
+	// workers, see pps_server.scaleUpWorkersWithinQuota.
+	MaxWorkers int
+	// TeamWeights are the weights of the teams that pipelines belong to,
+	// which decide how the workers under MaxWorkers are shared between
+	// teams, see pps.SchedulingSpec. It's passed on to pachd as
+	// TEAM_WEIGHTS, see ParseTeamWeights.
+	TeamWeights map[string]int64
+
+	// PrivilegedWorkers, if true, runs the user code of pipelines which
+	// don't set run_as_user in privileged containers, e.g. for pipelines
+	// that run docker. Otherwise no container that pachyderm creates is
+	// privileged, so that they can run under restrictive security policies,
+	// such as OpenShift's restricted SCC.
+	PrivilegedWorkers bool
+
+	// SQLEgress lets pipelines load their output into databases, with the
+	// credentials in secrets that pachd reads, see pps.SQLEgress.
+	SQLEgress bool
+
+	// SQLInputs lets pipelines snapshot the results of queries into repos,
+	// with the credentials in secrets that pachd reads, see pps.SQLInput.
+	SQLInputs bool
+
+	// ReplicateTo and ReplicateFrom are the URLs of the buckets that pachd
+	// writes its backups to as a primary, or restores them from as a
+	// secondary, every ReplicationInterval, see admin_server.Replicate.
+	ReplicateTo         string
+	ReplicateFrom       string
+	ReplicationInterval string
+
+	// LineageEndpoint is the URL that pachd exports jobs' lineage to, as
+	// OpenLineage events in LineageNamespace, see
+	// pps_server.ExportLineage.
+	LineageEndpoint  string
+	LineageNamespace string
+
+	// EventSink is where pachd publishes events as commits on EventBranches
+	// are finished, and jobs of EventPipelines finish, see
+	// pps_server.PublishEvents.
+	EventSink      string
+	EventBranches  []string
+	EventPipelines []string
+
+	// MetricsExporter is where pachd exports metrics about pipelines, jobs
+	// and repos to, see stats.NewExporter. If it's "prometheus", pachd's
+	// pods are annotated to be scraped, and if it's "datadog", pachd sends
+	// them to the Datadog agent on its node.
+	MetricsExporter string
+
+	// DownloadURL is the URL that clients reach pachd's HTTP port at, which
+	// the download URLs of files that pachd returns start with. Files can't
+	// be downloaded by URL if it's empty.
+	DownloadURL string
+
+	// FlexVolume, if true, installs the FlexVolume driver that mounts PFS
+	// commits into pods (see package flexvolume) on every node, in kubelet's
+	// plugin directory FlexVolumePluginDir.
+	FlexVolume          bool
+	FlexVolumePluginDir string
+
+	// SystemNodePool is where pachd, etcd and the dashboard run, and
+	// WorkerNodePool is where pipelines' workers run, so that workers don't
+	// compete with etcd for their nodes. Their zero values schedule pods on
+	// any node.
+	SystemNodePool NodePool
+	WorkerNodePool NodePool
+
+	// WorkerEnv is env vars, e.g. HTTP proxies, that are set in every
+	// pipeline's workers, unless the pipeline sets them itself. It's passed
+	// on to pachd as WORKER_ENV, see ParseWorkerEnv.
+	WorkerEnv map[string]string
+
+	// PachdDrainTimeout is how long pachd waits for the requests it's serving
+	// to finish when it's stopped, see handOffOnTerm. PachdProbePeriod and
+	// PachdProbeFailureThreshold are how often kubernetes probes pachd's
+	// health, and how many probes must fail in a row before pachd is removed
+	// from its service (or restarted, if it stops answering). Those that are
+	// 0 get the defaults below.
+	PachdDrainTimeout          time.Duration
+	PachdProbePeriod           time.Duration
+	PachdProbeFailureThreshold int
+
+	// MigrationDryRun, if true, makes pachd log the migrations of etcd's
+	// schema that it would run when it starts, instead of running them, see
+	// migration.Options.
+	MigrationDryRun bool
+
+	// DynamicNodePorts, if true, lets kubernetes choose the node ports of
+	// pachd's, etcd's and dash's services, instead of using fixed ones
+	// (e.g. 30650), which can only be used by one Pachyderm instance per
+	// cluster.
+	DynamicNodePorts bool
+
+	// NoRBAC, if true, means that the RBAC roles that grant pachd's service
+	// account the permissions it needs aren't written, e.g. because the
+	// cluster doesn't use RBAC, or its roles are managed separately.
+	NoRBAC bool
+
+	// PachdPort and PachdHTTPPort are the ports that pachd listens on for
+	// gRPC and HTTP (its health checks and traces), and WorkerPort is the
+	// port that pipelines' workers listen on. If 0, the defaults are used.
+	PachdPort     int
+	PachdHTTPPort int
+	WorkerPort    int
+
+	// IPv6, if true, means that etcd listens on IPv6's unspecified address
+	// rather than IPv4's, for IPv6-only clusters. pachd and its workers
+	// listen on both.
+	IPv6 bool
+}
+
+// ports returns the ports that pachd listens on for gRPC and HTTP, and that
+// pipelines' workers listen on.
+func ports(opts *AssetOpts) (pachdPort int, pachdHTTPPort int, workerPort int) {
+	pachdPort, pachdHTTPPort, workerPort = defaultPachdPort, defaultPachdHTTPPort, defaultWorkerPort
+	if opts.PachdPort != 0 {
+		pachdPort = opts.PachdPort
+	}
+	if opts.PachdHTTPPort != 0 {
+		pachdHTTPPort = opts.PachdHTTPPort
+	}
+	if opts.WorkerPort != 0 {
+		workerPort = opts.WorkerPort
+	}
+	return pachdPort, pachdHTTPPort, workerPort
+}
+
+// etcdListenURL returns the URL that etcd listens on port at, on every
+// address.
+func etcdListenURL(opts *AssetOpts, port int) string {
+	host := "0.0.0.0"
+	if opts.IPv6 {
+		host = "::"
+	}
+	return "http://" + net.JoinHostPort(host, strconv.Itoa(port))
+}
+
+// nodePort returns port, or 0 (i.e. any port that kubernetes chooses) if
+// opts.DynamicNodePorts is set.
+func nodePort(opts *AssetOpts, port int32) int32 {
+	if opts.DynamicNodePorts {
+		return 0
+	}
+	return port
+}
+
+// clusterScopedName returns the name of a cluster-scoped object, such as a
+// StorageClass or PersistentVolume, which has to differ between Pachyderm
+// instances deployed to different namespaces. Instances in the default
+// namespace keep the original names, so that they can be upgraded.
+func clusterScopedName(namespace string, name string) string {
+	if namespace == "" || namespace == api.NamespaceDefault {
+		return name
+	}
+	return name + "-" + namespace
+}
+
+// EtcdStorageClassName returns the name of the StorageClass that's created
+// for etcd's volumes when Pachyderm is deployed to namespace.
+func EtcdStorageClassName(namespace string) string {
+	return clusterScopedName(namespace, etcdStorageClassName)
+}
+
+// EtcdVolumeName returns the name of etcd's PersistentVolume when Pachyderm
+// is deployed to namespace with a static etcd volume.
+func EtcdVolumeName(namespace string) string {
+	return clusterScopedName(namespace, etcdVolumeName)
+}
+
+// ClusterRoleName returns the name of pachd's ClusterRole and
+// ClusterRoleBinding when Pachyderm is deployed to namespace.
+func ClusterRoleName(namespace string) string {
+	return clusterScopedName(namespace, serviceAccountName)
 }
 
 // fillDefaultResourceRequests sets any of:
@@ -147,15 +526,167 @@ func fillDefaultResourceRequests(opts *AssetOpts, persistentDiskBackend backend)
 }
 
 // ServiceAccount returns a kubernetes service account for use with Pachyderm.
-func ServiceAccount() *api.ServiceAccount {
+func ServiceAccount(opts *AssetOpts) *api.ServiceAccount {
 	return &api.ServiceAccount{
 		TypeMeta: unversioned.TypeMeta{
 			Kind:       "ServiceAccount",
 			APIVersion: "v1",
 		},
 		ObjectMeta: api.ObjectMeta{
-			Name:   serviceAccountName,
-			Labels: labels(""),
+			Name:      serviceAccountName,
+			Namespace: opts.Namespace,
+			Labels:    labels(""),
+		},
+	}
+}
+
+// rbacAPIVersion is the version of kubernetes' RBAC API that pachd's roles are
+// written with. The kubernetes client that pachyderm uses predates it, so
+// they're written as raw json, like etcd's StatefulSet.
+const rbacAPIVersion = "rbac.authorization.k8s.io/v1beta1"
+
+// Role returns the role that grants pachd's service account the permissions
+// that pachd needs in its namespace, and no more: managing its pipelines'
+// workers (their replication controllers, services and pods, their network
+// policies if opts.WorkerNetworkPolicies is set, the DaemonSets that pull
+// their images if opts.PrepullImages is, and the secret that they pull their
+// images with if opts.RegistryCredentials is), reading their logs, and
+// reading the secrets of SQL egresses and inputs if opts.SQLEgress or
+// opts.SQLInputs is set.
+func Role(opts *AssetOpts) interface{} {
+	rules := []interface{}{
+		map[string]interface{}{
+			"apiGroups": []string{""},
+			"resources": []string{"replicationcontrollers"},
+			"verbs":     []string{"get", "list", "create", "update", "delete"},
+		},
+		map[string]interface{}{
+			"apiGroups": []string{""},
+			"resources": []string{"services"},
+			"verbs":     []string{"get", "create", "delete"},
+		},
+		map[string]interface{}{
+			"apiGroups": []string{""},
+			"resources": []string{"pods"},
+			"verbs":     []string{"list", "delete"},
+		},
+		map[string]interface{}{
+			"apiGroups": []string{""},
+			"resources": []string{"pods/log"},
+			"verbs":     []string{"get"},
+		},
+	}
+	if opts.WorkerNetworkPolicies {
+		rules = append(rules, map[string]interface{}{
+			"apiGroups": []string{"networking.k8s.io"},
+			"resources": []string{"networkpolicies"},
+			"verbs":     []string{"create", "delete"},
+		})
+	}
+	if opts.PrepullImages {
+		rules = append(rules, map[string]interface{}{
+			"apiGroups": []string{"extensions"},
+			"resources": []string{"daemonsets"},
+			"verbs":     []string{"get", "create", "delete"},
+		})
+	}
+	if len(opts.RegistryCredentials) > 0 {
+		rules = append(rules, map[string]interface{}{
+			"apiGroups": []string{""},
+			"resources": []string{"secrets"},
+			"verbs":     []string{"create", "update"},
+		})
+	}
+	if opts.SQLEgress || opts.SQLInputs {
+		rules = append(rules, map[string]interface{}{
+			"apiGroups": []string{""},
+			"resources": []string{"secrets"},
+			"verbs":     []string{"get"},
+		})
+	}
+	return map[string]interface{}{
+		"apiVersion": rbacAPIVersion,
+		"kind":       "Role",
+		"metadata": map[string]interface{}{
+			"name":      serviceAccountName,
+			"namespace": opts.Namespace,
+			"labels":    labels(""),
+		},
+		"rules": rules,
+	}
+}
+
+// RoleBinding returns the binding of Role to pachd's service account.
+func RoleBinding(opts *AssetOpts) interface{} {
+	return map[string]interface{}{
+		"apiVersion": rbacAPIVersion,
+		"kind":       "RoleBinding",
+		"metadata": map[string]interface{}{
+			"name":      serviceAccountName,
+			"namespace": opts.Namespace,
+			"labels":    labels(""),
+		},
+		"subjects": serviceAccountSubjects(opts),
+		"roleRef": map[string]interface{}{
+			"apiGroup": "rbac.authorization.k8s.io",
+			"kind":     "Role",
+			"name":     serviceAccountName,
+		},
+	}
+}
+
+// ClusterRole returns the role that grants pachd the one permission it needs
+// outside of its namespace: listing the cluster's nodes, which is how many
+// workers pipelines with coefficient parallelism get.
+func ClusterRole(opts *AssetOpts) interface{} {
+	return map[string]interface{}{
+		"apiVersion": rbacAPIVersion,
+		"kind":       "ClusterRole",
+		"metadata": map[string]interface{}{
+			"name":   ClusterRoleName(opts.Namespace),
+			"labels": labels(""),
+		},
+		"rules": []interface{}{
+			map[string]interface{}{
+				"apiGroups": []string{""},
+				"resources": []string{"nodes"},
+				"verbs":     []string{"list"},
+			},
+		},
+	}
+}
+
+// ClusterRoleBinding returns the binding of ClusterRole to pachd's service
+// account.
+func ClusterRoleBinding(opts *AssetOpts) interface{} {
+	return map[string]interface{}{
+		"apiVersion": rbacAPIVersion,
+		"kind":       "ClusterRoleBinding",
+		"metadata": map[string]interface{}{
+			"name":   ClusterRoleName(opts.Namespace),
+			"labels": labels(""),
+		},
+		"subjects": serviceAccountSubjects(opts),
+		"roleRef": map[string]interface{}{
+			"apiGroup": "rbac.authorization.k8s.io",
+			"kind":     "ClusterRole",
+			"name":     ClusterRoleName(opts.Namespace),
+		},
+	}
+}
+
+// serviceAccountSubjects returns the subjects of a binding to pachd's service
+// account.
+func serviceAccountSubjects(opts *AssetOpts) []interface{} {
+	namespace := opts.Namespace
+	if namespace == "" {
+		namespace = api.NamespaceDefault
+	}
+	return []interface{}{
+		map[string]interface{}{
+			"kind":      "ServiceAccount",
+			"name":      serviceAccountName,
+			"namespace": namespace,
 		},
 	}
 }
@@ -222,7 +753,7 @@ func PachdDeployment(opts *AssetOpts, objectStoreBackend backend, hostPath strin
 	mem := resource.MustParse(opts.BlockCacheSize)
 	mem.Add(resource.MustParse(opts.PachdNonCacheMemRequest))
 	cpu := resource.MustParse(opts.PachdCPURequest)
-	image := pachdImage
+	image := AddRegistry(opts.Registry, pachdImage)
 	if opts.Version != "" {
 		image += ":" + opts.Version
 	}
@@ -231,6 +762,10 @@ func PachdDeployment(opts *AssetOpts, objectStoreBackend backend, hostPath strin
 	if opts.Version == deploy.DevVersionTag {
 		opts.Metrics = false
 	}
+	pachdReplicas := int32(1)
+	if opts.PachdReplicas > 0 {
+		pachdReplicas = int32(opts.PachdReplicas)
+	}
 	volumes := []api.Volume{
 		{
 			Name: "pach-disk",
@@ -264,17 +799,305 @@ func PachdDeployment(opts *AssetOpts, objectStoreBackend backend, hostPath strin
 		volumes = append(volumes, volume)
 		volumeMounts = append(volumeMounts, mount)
 	}
-	return &extensions.Deployment{
+	pachdPort, pachdHTTPPort, workerPort := ports(opts)
+	env := []api.EnvVar{
+		{
+			Name:  "PACH_ROOT",
+			Value: "/pach",
+		},
+		{
+			Name:  "PORT",
+			Value: strconv.Itoa(pachdPort),
+		},
+		{
+			Name:  "HTTP_PORT",
+			Value: strconv.Itoa(pachdHTTPPort),
+		},
+		{
+			Name:  "WORKER_PORT",
+			Value: strconv.Itoa(workerPort),
+		},
+		{
+			Name:  "NUM_SHARDS",
+			Value: fmt.Sprintf("%d", opts.PachdShards),
+		},
+		{
+			Name:  "STORAGE_BACKEND",
+			Value: backendEnvVar,
+		},
+		{
+			Name:  "STORAGE_HOST_PATH",
+			Value: storageHostPath,
+		},
+		{
+			Name: "PACHD_POD_NAMESPACE",
+			ValueFrom: &api.EnvVarSource{
+				FieldRef: &api.ObjectFieldSelector{
+					APIVersion: "v1",
+					FieldPath:  "metadata.namespace",
+				},
+			},
+		},
+		{
+			Name:  "WORKER_IMAGE",
+			Value: fmt.Sprintf("%s:%s", AddRegistry(opts.Registry, "pachyderm/worker"), opts.Version),
+		},
+		{
+			Name:  "WORKER_SIDECAR_IMAGE",
+			Value: fmt.Sprintf("%s:%s", AddRegistry(opts.Registry, pachdImage), opts.Version),
+		},
+		{
+			Name:  "WORKER_IMAGE_PULL_POLICY",
+			Value: "IfNotPresent",
+		},
+		{
+			Name:  "IMAGE_REGISTRY",
+			Value: opts.Registry,
+		},
+		{
+			Name:  "PACHD_VERSION",
+			Value: opts.Version,
+		},
+		{
+			Name:  "METRICS",
+			Value: strconv.FormatBool(opts.Metrics),
+		},
+		{
+			Name:  "LOG_LEVEL",
+			Value: opts.LogLevel,
+		},
+		{
+			Name:  "BLOCK_CACHE_BYTES",
+			Value: opts.BlockCacheSize,
+		},
+		{
+			Name:  "WORKER_NETWORK_POLICIES",
+			Value: strconv.FormatBool(opts.WorkerNetworkPolicies),
+		},
+		{
+			Name:  "PREPULL_IMAGES",
+			Value: strconv.FormatBool(opts.PrepullImages),
+		},
+		{
+			Name:  "REGISTRY_CREDENTIALS",
+			Value: strings.Join(opts.RegistryCredentials, ","),
+		},
+		{
+			Name:  "ALLOWED_IMAGE_PREFIXES",
+			Value: strings.Join(opts.AllowedImagePrefixes, ","),
+		},
+		{
+			Name:  "REQUIRE_NON_ROOT",
+			Value: strconv.FormatBool(opts.RequireNonRoot),
+		},
+		{
+			Name:  "REQUIRE_RESOURCE_LIMITS",
+			Value: strconv.FormatBool(opts.RequireResourceLimits),
+		},
+		{
+			Name:  "MIGRATION_DRY_RUN",
+			Value: strconv.FormatBool(opts.MigrationDryRun),
+		},
+	}
+	if opts.EtcdKeySecret != "" {
+		// pachd passes the secret's name on to the workers it creates
+		env = append(env, EtcdKeySecretEnv(opts.EtcdKeySecret)...)
+		env = append(env, api.EnvVar{
+			Name:  "ETCD_ENCRYPTION_SECRET",
+			Value: opts.EtcdKeySecret,
+		})
+	}
+	if opts.EtcdCompactionInterval != "" {
+		env = append(env, api.EnvVar{
+			Name:  "ETCD_COMPACTION_INTERVAL",
+			Value: opts.EtcdCompactionInterval,
+		})
+	}
+	if opts.EtcdDefragmentInterval != "" {
+		env = append(env, api.EnvVar{
+			Name:  "ETCD_DEFRAGMENT_INTERVAL",
+			Value: opts.EtcdDefragmentInterval,
+		})
+	}
+	for _, resource := range []struct {
+		name  string
+		value string
+	}{
+		{"WORKER_DEFAULT_CPU_REQUEST", opts.WorkerDefaultCPURequest},
+		{"WORKER_DEFAULT_MEMORY_REQUEST", opts.WorkerDefaultMemoryRequest},
+		{"WORKER_DEFAULT_CPU_LIMIT", opts.WorkerDefaultCPULimit},
+		{"WORKER_DEFAULT_MEMORY_LIMIT", opts.WorkerDefaultMemoryLimit},
+		{"WORKER_MAX_CPU", opts.WorkerMaxCPU},
+		{"WORKER_MAX_MEMORY", opts.WorkerMaxMemory},
+	} {
+		if resource.value != "" {
+			env = append(env, api.EnvVar{
+				Name:  resource.name,
+				Value: resource.value,
+			})
+		}
+	}
+	if opts.MaxWorkers > 0 {
+		env = append(env, api.EnvVar{
+			Name:  "MAX_WORKERS",
+			Value: strconv.Itoa(opts.MaxWorkers),
+		})
+	}
+	if len(opts.TeamWeights) > 0 {
+		var weights []string
+		for team, weight := range opts.TeamWeights {
+			weights = append(weights, fmt.Sprintf("%s=%d", team, weight))
+		}
+		sort.Strings(weights)
+		env = append(env, api.EnvVar{
+			Name:  "TEAM_WEIGHTS",
+			Value: strings.Join(weights, ","),
+		})
+	}
+	if opts.PrivilegedWorkers {
+		env = append(env, api.EnvVar{
+			Name:  "PRIVILEGED_WORKERS",
+			Value: "true",
+		})
+	}
+	if opts.SQLEgress {
+		env = append(env, api.EnvVar{
+			Name:  "SQL_EGRESS",
+			Value: "true",
+		})
+	}
+	if opts.SQLInputs {
+		env = append(env, api.EnvVar{
+			Name:  "SQL_INPUTS",
+			Value: "true",
+		})
+	}
+	if opts.ReplicateTo != "" {
+		env = append(env, api.EnvVar{
+			Name:  "REPLICATE_TO",
+			Value: opts.ReplicateTo,
+		})
+	}
+	if opts.ReplicateFrom != "" {
+		env = append(env, api.EnvVar{
+			Name:  "REPLICATE_FROM",
+			Value: opts.ReplicateFrom,
+		})
+	}
+	if opts.ReplicationInterval != "" {
+		env = append(env, api.EnvVar{
+			Name:  "REPLICATION_INTERVAL",
+			Value: opts.ReplicationInterval,
+		})
+	}
+	if opts.LineageEndpoint != "" {
+		env = append(env, api.EnvVar{
+			Name:  "LINEAGE_ENDPOINT",
+			Value: opts.LineageEndpoint,
+		})
+	}
+	if opts.LineageNamespace != "" {
+		env = append(env, api.EnvVar{
+			Name:  "LINEAGE_NAMESPACE",
+			Value: opts.LineageNamespace,
+		})
+	}
+	if opts.EventSink != "" {
+		env = append(env, api.EnvVar{
+			Name:  "EVENT_SINK",
+			Value: opts.EventSink,
+		}, api.EnvVar{
+			Name:  "EVENT_BRANCHES",
+			Value: strings.Join(opts.EventBranches, ","),
+		}, api.EnvVar{
+			Name:  "EVENT_PIPELINES",
+			Value: strings.Join(opts.EventPipelines, ","),
+		})
+	}
+	if opts.MetricsExporter != "" {
+		env = append(env, api.EnvVar{
+			Name:  "METRICS_EXPORTER",
+			Value: opts.MetricsExporter,
+		})
+	}
+	if opts.DownloadURL != "" {
+		env = append(env, api.EnvVar{
+			Name:  "DOWNLOAD_URL",
+			Value: opts.DownloadURL,
+		})
+	}
+	if opts.MetricsExporter == "datadog" {
+		// the Datadog agent runs on every node, with a host port, by
+		// default
+		env = append(env, api.EnvVar{
+			Name: "DD_AGENT_HOST",
+			ValueFrom: &api.EnvVarSource{
+				FieldRef: &api.ObjectFieldSelector{
+					APIVersion: "v1",
+					FieldPath:  "status.hostIP",
+				},
+			},
+		})
+	}
+	env = append(env, opts.WorkerNodePool.env()...)
+	if len(opts.WorkerEnv) > 0 {
+		// the values are JSON encoded, as env vars such as NO_PROXY
+		// commonly hold commas; marshalling a map of strings can't fail
+		workerEnv, _ := json.Marshal(opts.WorkerEnv)
+		env = append(env, api.EnvVar{
+			Name:  "WORKER_ENV",
+			Value: string(workerEnv),
+		})
+	}
+	if opts.PachdDrainTimeout > 0 {
+		env = append(env, api.EnvVar{
+			Name:  "DRAIN_TIMEOUT",
+			Value: opts.PachdDrainTimeout.String(),
+		})
+	}
+	if len(opts.EtcdEndpoints) > 0 {
+		// pachd passes the secrets' names on to the workers it creates
+		env = append(env, ExternalEtcdEnv(opts.EtcdEndpoints, opts.EtcdCredentialsSecret)...)
+		env = append(env, api.EnvVar{
+			Name:  "ETCD_CREDENTIALS_SECRET",
+			Value: opts.EtcdCredentialsSecret,
+		}, api.EnvVar{
+			Name:  "ETCD_TLS_SECRET",
+			Value: opts.EtcdTLSSecret,
+		})
+		if opts.EtcdTLSSecret != "" {
+			volume, mount := ExternalEtcdTLSVolumeAndMount(opts.EtcdTLSSecret)
+			volumes = append(volumes, volume)
+			volumeMounts = append(volumeMounts, mount)
+		}
+	}
+	if opts.TLSSecret != "" {
+		// pachd reads the certificate from /pachd-tls-cert, see TLS_CERT_DIR
+		volumes = append(volumes, api.Volume{
+			Name: tlsVolumeName,
+			VolumeSource: api.VolumeSource{
+				Secret: &api.SecretVolumeSource{
+					SecretName: opts.TLSSecret,
+				},
+			},
+		})
+		volumeMounts = append(volumeMounts, api.VolumeMount{
+			Name:      tlsVolumeName,
+			MountPath: "/" + tlsVolumeName,
+		})
+	}
+	deployment := &extensions.Deployment{
 		TypeMeta: unversioned.TypeMeta{
 			Kind:       "Deployment",
 			APIVersion: "extensions/v1beta1",
 		},
 		ObjectMeta: api.ObjectMeta{
-			Name:   pachdName,
-			Labels: labels(pachdName),
+			Name:      pachdName,
+			Namespace: opts.Namespace,
+			Labels:    labels(pachdName),
 		},
 		Spec: extensions.DeploymentSpec{
-			Replicas: 1,
+			Replicas: pachdReplicas,
 			Selector: &unversioned.LabelSelector{
 				MatchLabels: labels(pachdName),
 			},
@@ -288,76 +1111,24 @@ func PachdDeployment(opts *AssetOpts, objectStoreBackend backend, hostPath strin
 						{
 							Name:  pachdName,
 							Image: image,
-							Env: []api.EnvVar{
-								{
-									Name:  "PACH_ROOT",
-									Value: "/pach",
-								},
-								{
-									Name:  "NUM_SHARDS",
-									Value: fmt.Sprintf("%d", opts.PachdShards),
-								},
-								{
-									Name:  "STORAGE_BACKEND",
-									Value: backendEnvVar,
-								},
-								{
-									Name:  "STORAGE_HOST_PATH",
-									Value: storageHostPath,
-								},
-								{
-									Name: "PACHD_POD_NAMESPACE",
-									ValueFrom: &api.EnvVarSource{
-										FieldRef: &api.ObjectFieldSelector{
-											APIVersion: "v1",
-											FieldPath:  "metadata.namespace",
-										},
-									},
-								},
-								{
-									Name:  "WORKER_IMAGE",
-									Value: fmt.Sprintf("pachyderm/worker:%s", opts.Version),
-								},
-								{
-									Name:  "WORKER_SIDECAR_IMAGE",
-									Value: fmt.Sprintf("pachyderm/pachd:%s", opts.Version),
-								},
-								{
-									Name:  "WORKER_IMAGE_PULL_POLICY",
-									Value: "IfNotPresent",
-								},
-								{
-									Name:  "PACHD_VERSION",
-									Value: opts.Version,
-								},
-								{
-									Name:  "METRICS",
-									Value: strconv.FormatBool(opts.Metrics),
-								},
-								{
-									Name:  "LOG_LEVEL",
-									Value: opts.LogLevel,
-								},
-								{
-									Name:  "BLOCK_CACHE_BYTES",
-									Value: opts.BlockCacheSize,
-								},
-							},
+							Env:   env,
 							Ports: []api.ContainerPort{
 								{
-									ContainerPort: 650,
+									ContainerPort: int32(pachdPort),
 									Protocol:      "TCP",
 									Name:          "api-grpc-port",
 								},
 								{
-									ContainerPort: 651,
+									ContainerPort: int32(pachdHTTPPort),
 									Name:          "trace-port",
 								},
+								{
+									ContainerPort: s3GatewayPort,
+									Protocol:      "TCP",
+									Name:          "s3gateway-port",
+								},
 							},
-							VolumeMounts: volumeMounts,
-							SecurityContext: &api.SecurityContext{
-								Privileged: &trueVal, // god is this dumb
-							},
+							VolumeMounts:    volumeMounts,
 							ImagePullPolicy: "IfNotPresent",
 							Resources: api.ResourceRequirements{
 								Requests: api.ResourceList{
@@ -365,10 +1136,133 @@ func PachdDeployment(opts *AssetOpts, objectStoreBackend backend, hostPath strin
 									api.ResourceMemory: mem,
 								},
 							},
+							LivenessProbe:  pachdProbe(opts, "/healthz/live"),
+							ReadinessProbe: pachdProbe(opts, "/healthz/ready"),
+						},
+					},
+					ServiceAccountName:            serviceAccountName,
+					Volumes:                       volumes,
+					TerminationGracePeriodSeconds: pachdTerminationGracePeriod(opts),
+				},
+			},
+		},
+	}
+	opts.SystemNodePool.Apply(&deployment.Spec.Template)
+	if opts.MetricsExporter == "prometheus" {
+		// Prometheus's conventional annotations, which its kubernetes
+		// service discovery is usually configured to scrape pods by
+		template := &deployment.Spec.Template
+		if template.Annotations == nil {
+			template.Annotations = make(map[string]string)
+		}
+		template.Annotations["prometheus.io/scrape"] = "true"
+		template.Annotations["prometheus.io/port"] = strconv.Itoa(pachdHTTPPort)
+		template.Annotations["prometheus.io/path"] = "/metrics"
+	}
+	return deployment
+}
+
+// pachdProbe returns a probe of pachd's health endpoint at path, which pachd
+// serves on its trace port, see health.Server.
+func pachdProbe(opts *AssetOpts, path string) *api.Probe {
+	period := opts.PachdProbePeriod
+	if period == 0 {
+		period = defaultPachdProbePeriod
+	}
+	failureThreshold := opts.PachdProbeFailureThreshold
+	if failureThreshold == 0 {
+		failureThreshold = defaultPachdProbeFailureThreshold
+	}
+	return &api.Probe{
+		Handler: api.Handler{
+			HTTPGet: &api.HTTPGetAction{
+				Path: path,
+				Port: intstr.FromString("trace-port"),
+			},
+		},
+		// pachd checks etcd and its object store, each for up to 5 seconds,
+		// before it answers the readiness probe
+		TimeoutSeconds:   10,
+		PeriodSeconds:    int32(period.Seconds()),
+		FailureThreshold: int32(failureThreshold),
+	}
+}
+
+// pachdTerminationGracePeriod returns how long kubernetes waits for pachd to
+// exit before it kills it. That's long enough for pachd to drain its requests
+// and hand its masters off, which takes up to 20 seconds.
+func pachdTerminationGracePeriod(opts *AssetOpts) *int64 {
+	drainTimeout := opts.PachdDrainTimeout
+	if drainTimeout == 0 {
+		drainTimeout = defaultPachdDrainTimeout
+	}
+	seconds := int64(drainTimeout.Seconds()) + 10
+	if seconds < 30 {
+		seconds = 30
+	}
+	return &seconds
+}
+
+// DefaultFlexVolumePluginDir is where kubelet looks for FlexVolume drivers,
+// unless it's run with --volume-plugin-dir.
+const DefaultFlexVolumePluginDir = "/usr/libexec/kubernetes/kubelet-plugins/volume/exec"
+
+// FlexVolumeDaemonSet returns the DaemonSet that installs the FlexVolume
+// driver, from pachd's image, on every node. It must be created after
+// pachd's service, whose address it tells the driver.
+func FlexVolumeDaemonSet(opts *AssetOpts) *extensions.DaemonSet {
+	image := AddRegistry(opts.Registry, pachdImage)
+	if opts.Version != "" {
+		image += ":" + opts.Version
+	}
+	pluginDir := opts.FlexVolumePluginDir
+	if pluginDir == "" {
+		pluginDir = DefaultFlexVolumePluginDir
+	}
+	return &extensions.DaemonSet{
+		TypeMeta: unversioned.TypeMeta{
+			Kind:       "DaemonSet",
+			APIVersion: "extensions/v1beta1",
+		},
+		ObjectMeta: api.ObjectMeta{
+			Name:      flexVolumeName,
+			Namespace: opts.Namespace,
+			Labels:    labels(flexVolumeName),
+		},
+		Spec: extensions.DaemonSetSpec{
+			Selector: &unversioned.LabelSelector{
+				MatchLabels: labels(flexVolumeName),
+			},
+			Template: api.PodTemplateSpec{
+				ObjectMeta: api.ObjectMeta{
+					Name:   flexVolumeName,
+					Labels: labels(flexVolumeName),
+				},
+				Spec: api.PodSpec{
+					Containers: []api.Container{
+						{
+							Name:    flexVolumeName,
+							Image:   image,
+							Command: []string{"/pfs-flexvolume", "install", "/flexvolume"},
+							VolumeMounts: []api.VolumeMount{
+								{
+									Name:      "plugin-dir",
+									MountPath: "/flexvolume",
+								},
+							},
+							ImagePullPolicy: "IfNotPresent",
+						},
+					},
+					Volumes: []api.Volume{
+						{
+							Name: "plugin-dir",
+							VolumeSource: api.VolumeSource{
+								HostPath: &api.HostPathVolumeSource{
+									Path: pluginDir,
+								},
+							},
 						},
 					},
-					ServiceAccountName: serviceAccountName,
-					Volumes:            volumes,
 				},
 			},
 		},
@@ -376,15 +1270,16 @@ func PachdDeployment(opts *AssetOpts, objectStoreBackend backend, hostPath strin
 }
 
 // PachdService returns a pachd service.
-func PachdService() *v1.Service {
+func PachdService(opts *AssetOpts) *v1.Service {
 	return &v1.Service{
 		TypeMeta: unversioned.TypeMeta{
 			Kind:       "Service",
 			APIVersion: "v1",
 		},
 		ObjectMeta: v1.ObjectMeta{
-			Name:   pachdName,
-			Labels: labels(pachdName),
+			Name:      pachdName,
+			Namespace: opts.Namespace,
+			Labels:    labels(pachdName),
 		},
 		Spec: v1.ServiceSpec{
 			Type: v1.ServiceTypeNodePort,
@@ -393,14 +1288,22 @@ func PachdService() *v1.Service {
 			},
 			Ports: []v1.ServicePort{
 				{
-					Port:     650,
-					Name:     "api-grpc-port",
-					NodePort: 30650,
+					Port:       650,
+					Name:       "api-grpc-port",
+					NodePort:   nodePort(opts, 30650),
+					TargetPort: intstr.FromString("api-grpc-port"),
 				},
 				{
-					Port:     651,
-					Name:     "trace-port",
-					NodePort: 30651,
+					Port:       651,
+					Name:       "trace-port",
+					NodePort:   nodePort(opts, 30651),
+					TargetPort: intstr.FromString("trace-port"),
+				},
+				{
+					Port:       s3GatewayPort,
+					Name:       "s3gateway-port",
+					NodePort:   nodePort(opts, 30600),
+					TargetPort: intstr.FromString("s3gateway-port"),
 				},
 			},
 		},
@@ -435,14 +1338,15 @@ func EtcdDeployment(opts *AssetOpts, hostPath string) *extensions.Deployment {
 			},
 		}
 	}
-	return &extensions.Deployment{
+	deployment := &extensions.Deployment{
 		TypeMeta: unversioned.TypeMeta{
 			Kind:       "Deployment",
 			APIVersion: "extensions/v1beta1",
 		},
 		ObjectMeta: api.ObjectMeta{
-			Name:   etcdName,
-			Labels: labels(etcdName),
+			Name:      etcdName,
+			Namespace: opts.Namespace,
+			Labels:    labels(etcdName),
 		},
 		Spec: extensions.DeploymentSpec{
 			Replicas: 1,
@@ -458,14 +1362,13 @@ func EtcdDeployment(opts *AssetOpts, hostPath string) *extensions.Deployment {
 					Containers: []api.Container{
 						{
 							Name:  etcdName,
-							Image: etcdImage,
+							Image: AddRegistry(opts.Registry, etcdImage),
 							//TODO figure out how to get a cluster of these to talk to each other
 							Command: []string{
 								"/usr/local/bin/etcd",
-								"--listen-client-urls=http://0.0.0.0:2379",
-								"--advertise-client-urls=http://0.0.0.0:2379",
+								"--listen-client-urls=" + etcdListenURL(opts, 2379),
+								"--advertise-client-urls=" + etcdListenURL(opts, 2379),
 								"--data-dir=/var/data/etcd",
-								"--auto-compaction-retention=1",
 							},
 							Ports: []api.ContainerPort{
 								{
@@ -497,17 +1400,19 @@ func EtcdDeployment(opts *AssetOpts, hostPath string) *extensions.Deployment {
 			},
 		},
 	}
+	opts.SystemNodePool.Apply(&deployment.Spec.Template)
+	return deployment
 }
 
 // EtcdStorageClass creates a storage class used for dynamic volume
 // provisioning.  Currently dynamic volume provisioning only works
 // on AWS and GCE.
-func EtcdStorageClass(backend backend) (interface{}, error) {
+func EtcdStorageClass(opts *AssetOpts, backend backend) (interface{}, error) {
 	sc := map[string]interface{}{
 		"apiVersion": "storage.k8s.io/v1beta1",
 		"kind":       "StorageClass",
 		"metadata": map[string]interface{}{
-			"name":   etcdStorageClassName,
+			"name":   EtcdStorageClassName(opts.Namespace),
 			"labels": labels(etcdName),
 		},
 	}
@@ -537,7 +1442,7 @@ func EtcdVolume(persistentDiskBackend backend, opts *AssetOpts,
 			APIVersion: "v1",
 		},
 		ObjectMeta: api.ObjectMeta{
-			Name:   etcdVolumeName,
+			Name:   EtcdVolumeName(opts.Namespace),
 			Labels: labels(etcdName),
 		},
 		Spec: api.PersistentVolumeSpec{
@@ -593,15 +1498,16 @@ func EtcdVolume(persistentDiskBackend backend, opts *AssetOpts,
 //
 // Note that if you're controlling Etcd with a Stateful Set, this is
 // unneccessary (the stateful set controller will create PVCs automatically).
-func EtcdVolumeClaim(size int) *api.PersistentVolumeClaim {
+func EtcdVolumeClaim(opts *AssetOpts, size int) *api.PersistentVolumeClaim {
 	return &api.PersistentVolumeClaim{
 		TypeMeta: unversioned.TypeMeta{
 			Kind:       "PersistentVolumeClaim",
 			APIVersion: "v1",
 		},
 		ObjectMeta: api.ObjectMeta{
-			Name:   etcdVolumeClaimName,
-			Labels: labels(etcdName),
+			Name:      etcdVolumeClaimName,
+			Namespace: opts.Namespace,
+			Labels:    labels(etcdName),
 		},
 		Spec: api.PersistentVolumeClaimSpec{
 			Resources: api.ResourceRequirements{
@@ -610,17 +1516,17 @@ func EtcdVolumeClaim(size int) *api.PersistentVolumeClaim {
 				},
 			},
 			AccessModes: []api.PersistentVolumeAccessMode{api.ReadWriteOnce},
-			VolumeName:  etcdVolumeName,
+			VolumeName:  EtcdVolumeName(opts.Namespace),
 		},
 	}
 }
 
 // EtcdNodePortService returns a NodePort etcd service. This will let non-etcd
 // pods talk to etcd
-func EtcdNodePortService(local bool) *v1.Service {
+func EtcdNodePortService(opts *AssetOpts, local bool) *v1.Service {
 	var clientNodePort int32
 	if local {
-		clientNodePort = 32379
+		clientNodePort = nodePort(opts, 32379)
 	}
 	return &v1.Service{
 		TypeMeta: unversioned.TypeMeta{
@@ -628,8 +1534,9 @@ func EtcdNodePortService(local bool) *v1.Service {
 			APIVersion: "v1",
 		},
 		ObjectMeta: v1.ObjectMeta{
-			Name:   etcdName,
-			Labels: labels(etcdName),
+			Name:      etcdName,
+			Namespace: opts.Namespace,
+			Labels:    labels(etcdName),
 		},
 		Spec: v1.ServiceSpec{
 			Type: v1.ServiceTypeNodePort,
@@ -649,15 +1556,16 @@ func EtcdNodePortService(local bool) *v1.Service {
 
 // EtcdHeadlessService returns a headless etcd service, which is only for DNS
 // resolution.
-func EtcdHeadlessService() *v1.Service {
+func EtcdHeadlessService(opts *AssetOpts) *v1.Service {
 	return &v1.Service{
 		TypeMeta: unversioned.TypeMeta{
 			Kind:       "Service",
 			APIVersion: "v1",
 		},
 		ObjectMeta: v1.ObjectMeta{
-			Name:   etcdHeadlessServiceName,
-			Labels: labels(etcdName),
+			Name:      etcdHeadlessServiceName,
+			Namespace: opts.Namespace,
+			Labels:    labels(etcdName),
 		},
 		Spec: v1.ServiceSpec{
 			Selector: map[string]string{
@@ -665,6 +1573,10 @@ func EtcdHeadlessService() *v1.Service {
 			},
 			ClusterIP: "None",
 			Ports: []v1.ServicePort{
+				{
+					Name: "client-port",
+					Port: 2379,
+				},
 				{
 					Name: "peer-port",
 					Port: 2380,
@@ -688,29 +1600,34 @@ func EtcdStatefulSet(opts *AssetOpts, backend backend, diskSpace int) interface{
 	// actually run it below via '/bin/sh -c ${CMD}'
 	etcdCmd := []string{
 		"/usr/local/bin/etcd",
-		"--listen-client-urls=http://0.0.0.0:2379",
-		"--advertise-client-urls=http://0.0.0.0:2379",
-		"--listen-peer-urls=http://0.0.0.0:2380",
+		"--listen-client-urls=" + etcdListenURL(opts, 2379),
+		// each member advertises its own address, so that pachd can check
+		// and defragment the members one at a time
+		"--advertise-client-urls=http://${ETCD_NAME}.etcd-headless.${NAMESPACE}.svc.cluster.local:2379",
+		"--listen-peer-urls=" + etcdListenURL(opts, 2380),
 		"--data-dir=/var/data/etcd",
 		"--initial-cluster-token=pach-cluster", // unique ID
 		"--initial-advertise-peer-urls=http://${ETCD_NAME}.etcd-headless.${NAMESPACE}.svc.cluster.local:2380",
 		"--initial-cluster=" + strings.Join(initialCluster, ","),
-		"--auto-compaction-retention=1",
 	}
 	for i, str := range etcdCmd {
 		etcdCmd[i] = fmt.Sprintf("\"%s\"", str) // quote all arguments, for shell
 	}
 
 	var pvcTemplates []interface{}
-	switch backend {
-	case googleBackend, amazonBackend:
+	storageClass := EtcdStorageClassName(opts.Namespace)
+	if opts.StorageClass != "" {
+		storageClass = opts.StorageClass
+	}
+	switch {
+	case opts.StorageClass != "" || backend == googleBackend || backend == amazonBackend:
 		pvcTemplates = []interface{}{
 			map[string]interface{}{
 				"metadata": map[string]interface{}{
 					"name":   etcdVolumeClaimName,
 					"labels": labels(etcdName),
 					"annotations": map[string]string{
-						"volume.beta.kubernetes.io/storage-class": etcdStorageClassName,
+						"volume.beta.kubernetes.io/storage-class": storageClass,
 					},
 				},
 				"spec": map[string]interface{}{
@@ -742,14 +1659,24 @@ func EtcdStatefulSet(opts *AssetOpts, backend backend, diskSpace int) interface{
 		}
 	}
 
+	// schedule etcd on the system node pool, see NodePool.Apply
+	podMetadata := map[string]interface{}{
+		"name":   etcdName,
+		"labels": labels(etcdName),
+	}
+	if annotations := opts.SystemNodePool.annotations(); annotations != nil {
+		podMetadata["annotations"] = annotations
+	}
+
 	// As of March 17, 2017, the Kubernetes client does not include structs for
 	// Stateful Set, so we generate the kubernetes manifest using raw json.
-	return map[string]interface{}{
+	statefulSet := map[string]interface{}{
 		"apiVersion": "apps/v1beta1",
 		"kind":       "StatefulSet",
 		"metadata": map[string]interface{}{
-			"name":   etcdName,
-			"labels": labels(etcdName),
+			"name":      etcdName,
+			"namespace": opts.Namespace,
+			"labels":    labels(etcdName),
 		},
 		"spec": map[string]interface{}{
 			// Effectively configures a RC
@@ -761,15 +1688,29 @@ func EtcdStatefulSet(opts *AssetOpts, backend backend, diskSpace int) interface{
 
 			// pod template
 			"template": map[string]interface{}{
-				"metadata": map[string]interface{}{
-					"name":   etcdName,
-					"labels": labels(etcdName),
-				},
+				"metadata": podMetadata,
 				"spec": map[string]interface{}{
+					// spread the members across nodes, so that losing a
+					// node doesn't lose etcd's quorum
+					"affinity": map[string]interface{}{
+						"podAntiAffinity": map[string]interface{}{
+							"preferredDuringSchedulingIgnoredDuringExecution": []interface{}{
+								map[string]interface{}{
+									"weight": 100,
+									"podAffinityTerm": map[string]interface{}{
+										"labelSelector": map[string]interface{}{
+											"matchLabels": labels(etcdName),
+										},
+										"topologyKey": "kubernetes.io/hostname",
+									},
+								},
+							},
+						},
+					},
 					"containers": []interface{}{
 						map[string]interface{}{
 							"name":    etcdName,
-							"image":   etcdImage,
+							"image":   AddRegistry(opts.Registry, etcdImage),
 							"command": []string{"/bin/sh", "-c"},
 							"args":    []string{strings.Join(etcdCmd, " ")},
 							// Use the downward API to pass the pod name to etcd. This sets
@@ -821,18 +1762,45 @@ func EtcdStatefulSet(opts *AssetOpts, backend backend, diskSpace int) interface{
 			"volumeClaimTemplates": pvcTemplates,
 		},
 	}
+	if len(opts.SystemNodePool.NodeSelector) > 0 {
+		template := statefulSet["spec"].(map[string]interface{})["template"].(map[string]interface{})
+		template["spec"].(map[string]interface{})["nodeSelector"] = opts.SystemNodePool.NodeSelector
+	}
+	return statefulSet
+}
+
+// EtcdPodDisruptionBudget returns a PodDisruptionBudget which stops
+// voluntary disruptions (e.g. draining nodes) from taking down more of the
+// members of etcd's StatefulSet than it can lose without losing its quorum.
+func EtcdPodDisruptionBudget(opts *AssetOpts) interface{} {
+	return map[string]interface{}{
+		"apiVersion": "policy/v1beta1",
+		"kind":       "PodDisruptionBudget",
+		"metadata": map[string]interface{}{
+			"name":      etcdName,
+			"namespace": opts.Namespace,
+			"labels":    labels(etcdName),
+		},
+		"spec": map[string]interface{}{
+			"minAvailable": opts.EtcdNodes/2 + 1,
+			"selector": map[string]interface{}{
+				"matchLabels": labels(etcdName),
+			},
+		},
+	}
 }
 
 // DashDeployment creates a Deployment for the pachyderm dashboard.
-func DashDeployment(dashImage string) *extensions.Deployment {
-	return &extensions.Deployment{
+func DashDeployment(opts *AssetOpts) *extensions.Deployment {
+	deployment := &extensions.Deployment{
 		TypeMeta: unversioned.TypeMeta{
 			Kind:       "Deployment",
 			APIVersion: "extensions/v1beta1",
 		},
 		ObjectMeta: api.ObjectMeta{
-			Name:   dashName,
-			Labels: labels(dashName),
+			Name:      dashName,
+			Namespace: opts.Namespace,
+			Labels:    labels(dashName),
 		},
 		Spec: extensions.DeploymentSpec{
 			Selector: &unversioned.LabelSelector{
@@ -847,7 +1815,7 @@ func DashDeployment(dashImage string) *extensions.Deployment {
 					Containers: []api.Container{
 						{
 							Name:  dashName,
-							Image: dashImage,
+							Image: AddRegistry(opts.Registry, opts.DashImage),
 							Ports: []api.ContainerPort{
 								{
 									ContainerPort: 8080,
@@ -858,7 +1826,7 @@ func DashDeployment(dashImage string) *extensions.Deployment {
 						},
 						{
 							Name:  grpcProxyName,
-							Image: grpcProxyImage,
+							Image: AddRegistry(opts.Registry, grpcProxyImage),
 							Ports: []api.ContainerPort{
 								{
 									ContainerPort: 8081,
@@ -872,18 +1840,21 @@ func DashDeployment(dashImage string) *extensions.Deployment {
 			},
 		},
 	}
+	opts.SystemNodePool.Apply(&deployment.Spec.Template)
+	return deployment
 }
 
 // DashService creates a Service for the pachyderm dashboard.
-func DashService() *v1.Service {
+func DashService(opts *AssetOpts) *v1.Service {
 	return &v1.Service{
 		TypeMeta: unversioned.TypeMeta{
 			Kind:       "Service",
 			APIVersion: "v1",
 		},
 		ObjectMeta: v1.ObjectMeta{
-			Name:   dashName,
-			Labels: labels(dashName),
+			Name:      dashName,
+			Namespace: opts.Namespace,
+			Labels:    labels(dashName),
 		},
 		Spec: v1.ServiceSpec{
 			Type:     v1.ServiceTypeNodePort,
@@ -892,12 +1863,12 @@ func DashService() *v1.Service {
 				{
 					Port:     8080,
 					Name:     "dash-http",
-					NodePort: 30080,
+					NodePort: nodePort(opts, 30080),
 				},
 				{
 					Port:     8081,
 					Name:     "grpc-proxy-http",
-					NodePort: 30081,
+					NodePort: nodePort(opts, 30081),
 				},
 			},
 		},
@@ -910,7 +1881,7 @@ func DashService() *v1.Service {
 //   secret - S3 secret access key
 //   endpoint  - S3 compatible endpoint
 //   secure - set to true for a secure connection.
-func MinioSecret(bucket string, id string, secret string, endpoint string, secure bool) *api.Secret {
+func MinioSecret(opts *AssetOpts, bucket string, id string, secret string, endpoint string, secure bool) *api.Secret {
 	secureV := "0"
 	if secure {
 		secureV = "1"
@@ -921,8 +1892,9 @@ func MinioSecret(bucket string, id string, secret string, endpoint string, secur
 			APIVersion: "v1",
 		},
 		ObjectMeta: api.ObjectMeta{
-			Name:   minioSecretName,
-			Labels: labels(minioSecretName),
+			Name:      minioSecretName,
+			Namespace: opts.Namespace,
+			Labels:    labels(minioSecretName),
 		},
 		Data: map[string][]byte{
 			"bucket":   []byte(bucket),
@@ -940,15 +1912,16 @@ func MinioSecret(bucket string, id string, secret string, endpoint string, secur
 //   secret - AWS secret access key
 //   token  - AWS access token
 //   region - AWS region
-func AmazonSecret(bucket string, distribution string, id string, secret string, token string, region string) *api.Secret {
+func AmazonSecret(opts *AssetOpts, bucket string, distribution string, id string, secret string, token string, region string) *api.Secret {
 	return &api.Secret{
 		TypeMeta: unversioned.TypeMeta{
 			Kind:       "Secret",
 			APIVersion: "v1",
 		},
 		ObjectMeta: api.ObjectMeta{
-			Name:   amazonSecretName,
-			Labels: labels(amazonSecretName),
+			Name:      amazonSecretName,
+			Namespace: opts.Namespace,
+			Labels:    labels(amazonSecretName),
 		},
 		Data: map[string][]byte{
 			"bucket":       []byte(bucket),
@@ -962,15 +1935,16 @@ func AmazonSecret(bucket string, distribution string, id string, secret string,
 }
 
 // GoogleSecret creates a google secret with a bucket name.
-func GoogleSecret(bucket string) *api.Secret {
+func GoogleSecret(opts *AssetOpts, bucket string) *api.Secret {
 	return &api.Secret{
 		TypeMeta: unversioned.TypeMeta{
 			Kind:       "Secret",
 			APIVersion: "v1",
 		},
 		ObjectMeta: api.ObjectMeta{
-			Name:   googleSecretName,
-			Labels: labels(googleSecretName),
+			Name:      googleSecretName,
+			Namespace: opts.Namespace,
+			Labels:    labels(googleSecretName),
 		},
 		Data: map[string][]byte{
 			"bucket": []byte(bucket),
@@ -982,15 +1956,16 @@ func GoogleSecret(bucket string) *api.Secret {
 //   container - Azure blob container
 //   id    	   - Azure storage account name
 //   secret    - Azure storage account key
-func MicrosoftSecret(container string, id string, secret string) *api.Secret {
+func MicrosoftSecret(opts *AssetOpts, container string, id string, secret string) *api.Secret {
 	return &api.Secret{
 		TypeMeta: unversioned.TypeMeta{
 			Kind:       "Secret",
 			APIVersion: "v1",
 		},
 		ObjectMeta: api.ObjectMeta{
-			Name:   microsoftSecretName,
-			Labels: labels(microsoftSecretName),
+			Name:      microsoftSecretName,
+			Namespace: opts.Namespace,
+			Labels:    labels(microsoftSecretName),
 		},
 		Data: map[string][]byte{
 			"container": []byte(container),
@@ -1004,9 +1979,9 @@ func MicrosoftSecret(container string, id string, secret string) *api.Secret {
 // dashboard to 'w'
 func WriteDashboardAssets(w io.Writer, opts *AssetOpts) {
 	encoder := codec.NewEncoder(w, jsonEncoderHandle)
-	DashService().CodecEncodeSelf(encoder)
+	DashService(opts).CodecEncodeSelf(encoder)
 	fmt.Fprintf(w, "\n")
-	DashDeployment(opts.DashImage).CodecEncodeSelf(encoder)
+	DashDeployment(opts).CodecEncodeSelf(encoder)
 	fmt.Fprintf(w, "\n")
 }
 
@@ -1028,54 +2003,75 @@ func WriteAssets(w io.Writer, opts *AssetOpts, objectStoreBackend backend,
 	}
 	encoder := codec.NewEncoder(w, jsonEncoderHandle)
 
-	ServiceAccount().CodecEncodeSelf(encoder)
+	ServiceAccount(opts).CodecEncodeSelf(encoder)
 	fmt.Fprintf(w, "\n")
-
-	if opts.EtcdNodes > 0 && opts.EtcdVolume != "" {
-		return fmt.Errorf("only one of --dynamic-etcd-nodes and --static-etcd-volume should be given, but not both")
+	if !opts.NoRBAC {
+		for _, role := range []interface{}{Role(opts), RoleBinding(opts), ClusterRole(opts), ClusterRoleBinding(opts)} {
+			encoder.Encode(role)
+			fmt.Fprintf(w, "\n")
+		}
 	}
 
-	// In the dynamic route, we create a storage class which dynamically
-	// provisions volumes, and run etcd as a statful set.
-	// In the static route, we create a single volume, a single volume
-	// claim, and run etcd as a replication controller with a single node.
-	if objectStoreBackend == localBackend {
-		EtcdDeployment(opts, hostPath).CodecEncodeSelf(encoder)
-		fmt.Fprintf(w, "\n")
-	} else if opts.EtcdNodes > 0 {
-		sc, err := EtcdStorageClass(persistentDiskBackend)
-		if err != nil {
-			return err
+	// etcd holds the state of the existing cluster, so it isn't touched by
+	// upgrades, and an external etcd isn't deployed at all
+	if !opts.Upgrade && len(opts.EtcdEndpoints) == 0 {
+		if opts.EtcdNodes > 0 && opts.EtcdVolume != "" {
+			return fmt.Errorf("only one of --dynamic-etcd-nodes and --static-etcd-volume should be given, but not both")
 		}
-		if sc != nil {
-			encoder.Encode(sc)
+
+		// In the dynamic route, we create a storage class which dynamically
+		// provisions volumes, and run etcd as a statful set.
+		// In the static route, we create a single volume, a single volume
+		// claim, and run etcd as a replication controller with a single node.
+		if objectStoreBackend == localBackend {
+			EtcdDeployment(opts, hostPath).CodecEncodeSelf(encoder)
 			fmt.Fprintf(w, "\n")
+		} else if opts.EtcdNodes > 0 {
+			// If the user gave us a storage class to use, it already exists
+			if opts.StorageClass == "" {
+				sc, err := EtcdStorageClass(opts, persistentDiskBackend)
+				if err != nil {
+					return err
+				}
+				if sc != nil {
+					encoder.Encode(sc)
+					fmt.Fprintf(w, "\n")
+				}
+			}
+			EtcdHeadlessService(opts).CodecEncodeSelf(encoder)
+			fmt.Fprintf(w, "\n")
+			encoder.Encode(EtcdStatefulSet(opts, persistentDiskBackend, volumeSize))
+			fmt.Fprintf(w, "\n")
+			if opts.EtcdNodes > 1 {
+				encoder.Encode(EtcdPodDisruptionBudget(opts))
+				fmt.Fprintf(w, "\n")
+			}
+		} else if opts.EtcdVolume != "" || persistentDiskBackend == localBackend {
+			volume, err := EtcdVolume(persistentDiskBackend, opts, hostPath, opts.EtcdVolume, volumeSize)
+			if err != nil {
+				return err
+			}
+			volume.CodecEncodeSelf(encoder)
+			fmt.Fprintf(w, "\n")
+			EtcdVolumeClaim(opts, volumeSize).CodecEncodeSelf(encoder)
+			fmt.Fprintf(w, "\n")
+			EtcdDeployment(opts, "").CodecEncodeSelf(encoder)
+			fmt.Fprintf(w, "\n")
+		} else {
+			return fmt.Errorf("unless deploying locally, either --etcd-nodes or --etcd-volume needs to be provided")
 		}
-		EtcdHeadlessService().CodecEncodeSelf(encoder)
-		fmt.Fprintf(w, "\n")
-		encoder.Encode(EtcdStatefulSet(opts, persistentDiskBackend, volumeSize))
-		fmt.Fprintf(w, "\n")
-	} else if opts.EtcdVolume != "" || persistentDiskBackend == localBackend {
-		volume, err := EtcdVolume(persistentDiskBackend, opts, hostPath, opts.EtcdVolume, volumeSize)
-		if err != nil {
-			return err
-		}
-		volume.CodecEncodeSelf(encoder)
+		EtcdNodePortService(opts, objectStoreBackend == localBackend).CodecEncodeSelf(encoder)
 		fmt.Fprintf(w, "\n")
-		EtcdVolumeClaim(volumeSize).CodecEncodeSelf(encoder)
-		fmt.Fprintf(w, "\n")
-		EtcdDeployment(opts, "").CodecEncodeSelf(encoder)
-		fmt.Fprintf(w, "\n")
-	} else {
-		return fmt.Errorf("unless deploying locally, either --etcd-nodes or --etcd-volume needs to be provided")
 	}
-	EtcdNodePortService(objectStoreBackend == localBackend).CodecEncodeSelf(encoder)
-	fmt.Fprintf(w, "\n")
 
-	PachdService().CodecEncodeSelf(encoder)
+	PachdService(opts).CodecEncodeSelf(encoder)
 	fmt.Fprintf(w, "\n")
 	PachdDeployment(opts, objectStoreBackend, hostPath).CodecEncodeSelf(encoder)
 	fmt.Fprintf(w, "\n")
+	if opts.FlexVolume {
+		FlexVolumeDaemonSet(opts).CodecEncodeSelf(encoder)
+		fmt.Fprintf(w, "\n")
+	}
 	if opts.EnableDash {
 		WriteDashboardAssets(w, opts)
 	}
@@ -1116,7 +2112,10 @@ func WriteCustomAssets(w io.Writer, opts *AssetOpts, args []string, objectStoreB
 			return fmt.Errorf("Did not recognize the choice of persistent-disk")
 		}
 		encoder := codec.NewEncoder(w, jsonEncoderHandle)
-		MinioSecret(args[2], args[3], args[4], args[5], secure).CodecEncodeSelf(encoder)
+		if opts.Upgrade {
+			return nil
+		}
+		MinioSecret(opts, args[2], args[3], args[4], args[5], secure).CodecEncodeSelf(encoder)
 		fmt.Fprintf(w, "\n")
 		return nil
 	default:
@@ -1130,8 +2129,11 @@ func WriteAmazonAssets(w io.Writer, opts *AssetOpts, bucket string, id string, s
 	if err := WriteAssets(w, opts, amazonBackend, amazonBackend, volumeSize, ""); err != nil {
 		return err
 	}
+	if opts.Upgrade {
+		return nil
+	}
 	encoder := codec.NewEncoder(w, jsonEncoderHandle)
-	AmazonSecret(bucket, distribution, id, secret, token, region).CodecEncodeSelf(encoder)
+	AmazonSecret(opts, bucket, distribution, id, secret, token, region).CodecEncodeSelf(encoder)
 	fmt.Fprintf(w, "\n")
 	return nil
 }
@@ -1141,8 +2143,11 @@ func WriteGoogleAssets(w io.Writer, opts *AssetOpts, bucket string, volumeSize i
 	if err := WriteAssets(w, opts, googleBackend, googleBackend, volumeSize, ""); err != nil {
 		return err
 	}
+	if opts.Upgrade {
+		return nil
+	}
 	encoder := codec.NewEncoder(w, jsonEncoderHandle)
-	GoogleSecret(bucket).CodecEncodeSelf(encoder)
+	GoogleSecret(opts, bucket).CodecEncodeSelf(encoder)
 	fmt.Fprintf(w, "\n")
 	return nil
 }
@@ -1152,12 +2157,178 @@ func WriteMicrosoftAssets(w io.Writer, opts *AssetOpts, container string, id str
 	if err := WriteAssets(w, opts, microsoftBackend, microsoftBackend, volumeSize, ""); err != nil {
 		return err
 	}
+	if opts.Upgrade {
+		return nil
+	}
 	encoder := codec.NewEncoder(w, jsonEncoderHandle)
-	MicrosoftSecret(container, id, secret).CodecEncodeSelf(encoder)
+	MicrosoftSecret(opts, container, id, secret).CodecEncodeSelf(encoder)
 	fmt.Fprintf(w, "\n")
 	return nil
 }
 
+// NodePool is the set of nodes that some of pachyderm's pods are scheduled on,
+// those with all of NodeSelector's labels. Tolerations let the pods onto nodes
+// whose taints keep other pods off, e.g. nodes dedicated to pipelines'
+// workers.
+type NodePool struct {
+	NodeSelector map[string]string
+	Tolerations  []api.Toleration
+}
+
+// ParseNodePool parses a node pool from a list of "key=value" node labels,
+// and a list of tolerations, each of which is "key=value:effect", "key:effect"
+// (which tolerates any value), or either without the effect (which tolerates
+// any effect).
+func ParseNodePool(nodeSelector []string, tolerations []string) (NodePool, error) {
+	var result NodePool
+	for _, label := range nodeSelector {
+		parts := strings.SplitN(label, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return NodePool{}, fmt.Errorf("invalid node selector %q, it should be \"key=value\"", label)
+		}
+		if result.NodeSelector == nil {
+			result.NodeSelector = make(map[string]string)
+		}
+		result.NodeSelector[parts[0]] = parts[1]
+	}
+	for _, t := range tolerations {
+		var toleration api.Toleration
+		if i := strings.LastIndex(t, ":"); i >= 0 {
+			toleration.Effect = api.TaintEffect(t[i+1:])
+			t = t[:i]
+			switch toleration.Effect {
+			case api.TaintEffectNoSchedule, api.TaintEffectPreferNoSchedule:
+			default:
+				return NodePool{}, fmt.Errorf("invalid toleration effect %q, it should be NoSchedule or PreferNoSchedule", toleration.Effect)
+			}
+		}
+		if parts := strings.SplitN(t, "=", 2); len(parts) == 2 {
+			toleration.Key = parts[0]
+			toleration.Operator = api.TolerationOpEqual
+			toleration.Value = parts[1]
+		} else {
+			toleration.Key = t
+			toleration.Operator = api.TolerationOpExists
+		}
+		if toleration.Key == "" {
+			return NodePool{}, fmt.Errorf("invalid toleration %q, it has no key", t)
+		}
+		result.Tolerations = append(result.Tolerations, toleration)
+	}
+	return result, nil
+}
+
+// ParseWorkerEnv parses vars, env vars as "name=value", into the env vars
+// that are set in every pipeline's workers.
+func ParseWorkerEnv(vars []string) (map[string]string, error) {
+	result := make(map[string]string)
+	for _, v := range vars {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid env var %q, must be \"name=value\"", v)
+		}
+		if _, ok := result[parts[0]]; ok {
+			return nil, fmt.Errorf("env var %s is given more than once", parts[0])
+		}
+		result[parts[0]] = parts[1]
+	}
+	return result, nil
+}
+
+// ParseTeamWeights parses weights, teams' weights as "team=weight", where
+// each weight is a positive integer.
+func ParseTeamWeights(weights []string) (map[string]int64, error) {
+	result := make(map[string]int64)
+	for _, w := range weights {
+		parts := strings.SplitN(w, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid team weight %q, must be \"team=weight\"", w)
+		}
+		weight, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil || weight < 1 {
+			return nil, fmt.Errorf("invalid team weight %q, the weight must be a positive integer", w)
+		}
+		result[parts[0]] = weight
+	}
+	return result, nil
+}
+
+// Apply schedules the pods created from template on p's nodes. Tolerations
+// are set with the pods' annotation, which is where this version of
+// kubernetes' API keeps them.
+func (p NodePool) Apply(template *api.PodTemplateSpec) {
+	if len(p.NodeSelector) > 0 {
+		template.Spec.NodeSelector = p.NodeSelector
+	}
+	for key, value := range p.annotations() {
+		if template.Annotations == nil {
+			template.Annotations = make(map[string]string)
+		}
+		template.Annotations[key] = value
+	}
+}
+
+// annotations returns the annotations that hold p's tolerations.
+func (p NodePool) annotations() map[string]string {
+	if len(p.Tolerations) == 0 {
+		return nil
+	}
+	// marshalling tolerations can't fail
+	tolerations, _ := json.Marshal(p.Tolerations)
+	return map[string]string{api.TolerationsAnnotationKey: string(tolerations)}
+}
+
+// env returns the env vars that pass p on to pachd, as WORKER_NODE_SELECTOR
+// and WORKER_TOLERATIONS, which ParseNodePool parses.
+func (p NodePool) env() []api.EnvVar {
+	var env []api.EnvVar
+	if len(p.NodeSelector) > 0 {
+		var labels []string
+		for key, value := range p.NodeSelector {
+			labels = append(labels, key+"="+value)
+		}
+		sort.Strings(labels)
+		env = append(env, api.EnvVar{
+			Name:  "WORKER_NODE_SELECTOR",
+			Value: strings.Join(labels, ","),
+		})
+	}
+	if len(p.Tolerations) > 0 {
+		var tolerations []string
+		for _, toleration := range p.Tolerations {
+			t := toleration.Key
+			if toleration.Operator != api.TolerationOpExists {
+				t += "=" + toleration.Value
+			}
+			if toleration.Effect != "" {
+				t += ":" + string(toleration.Effect)
+			}
+			tolerations = append(tolerations, t)
+		}
+		env = append(env, api.EnvVar{
+			Name:  "WORKER_TOLERATIONS",
+			Value: strings.Join(tolerations, ","),
+		})
+	}
+	return env
+}
+
+// AddRegistry switches the registry that an image is pulled from to
+// 'registry', unless 'registry' is empty. 'registry' may include a path
+// prefix, e.g. "registry.example.com/mirror".
+func AddRegistry(registry string, imageName string) string {
+	if registry == "" {
+		return imageName
+	}
+	parts := strings.SplitN(imageName, "/", 2)
+	// image names whose first component looks like a host (which is how
+	// docker tells them apart) already have a registry, which we replace
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		imageName = parts[1]
+	}
+	return path.Join(registry, imageName)
+}
+
 func labels(name string) map[string]string {
 	return map[string]string{
 		"app":   name,
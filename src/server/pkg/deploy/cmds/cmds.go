@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"os"
@@ -11,38 +12,103 @@ import (
 	"strings"
 	"time"
 
+	"github.com/pachyderm/pachyderm/src/client/pkg/config"
 	"github.com/pachyderm/pachyderm/src/client/version"
 	"github.com/pachyderm/pachyderm/src/server/pkg/cmdutil"
 	"github.com/pachyderm/pachyderm/src/server/pkg/deploy"
 	"github.com/pachyderm/pachyderm/src/server/pkg/deploy/assets"
+	"github.com/pachyderm/pachyderm/src/server/pkg/deploy/helm"
+	"github.com/pachyderm/pachyderm/src/server/pkg/events"
 	_metrics "github.com/pachyderm/pachyderm/src/server/pkg/metrics"
+	registrycreds "github.com/pachyderm/pachyderm/src/server/pkg/registry"
+	"github.com/pachyderm/pachyderm/src/server/pkg/stats"
 
+	"github.com/ghodss/yaml"
 	"github.com/spf13/cobra"
 	"go.pedge.io/pkg/cobra"
+	"k8s.io/kubernetes/pkg/api/resource"
 )
 
 var defaultDashImage = "pachyderm/dash:0.3.21"
 
-func maybeKcCreate(dryRun bool, manifest *bytes.Buffer) error {
+// contextNamespace returns the namespace of the active pachctl context, or
+// "" if there isn't one.
+func contextNamespace() (string, error) {
+	cfg, err := config.Read()
+	if err != nil {
+		return "", err
+	}
+	context, err := cfg.CurrentContext()
+	if err != nil || context == nil {
+		return "", err
+	}
+	return context.Namespace, nil
+}
+
+// encodeManifest converts manifest, a stream of JSON objects as written by
+// the assets package, to the given output format: "json" leaves it as it is,
+// "yaml" converts it to a stream of YAML documents separated by "---".
+func encodeManifest(manifest []byte, output string) ([]byte, error) {
+	switch output {
+	case "json":
+		return manifest, nil
+	case "yaml":
+		result := &bytes.Buffer{}
+		decoder := json.NewDecoder(bytes.NewReader(manifest))
+		for decoder.More() {
+			var object json.RawMessage
+			if err := decoder.Decode(&object); err != nil {
+				return nil, err
+			}
+			document, err := yaml.JSONToYAML(object)
+			if err != nil {
+				return nil, err
+			}
+			if result.Len() > 0 {
+				result.WriteString("---\n")
+			}
+			result.Write(document)
+		}
+		return result.Bytes(), nil
+	}
+	return nil, fmt.Errorf("unrecognized output format %q, must be \"json\", \"yaml\" or \"helm\"", output)
+}
+
+func maybeKcCreate(dryRun bool, output string, manifest *bytes.Buffer, opts *assets.AssetOpts) error {
 	if dryRun {
-		_, err := os.Stdout.Write(manifest.Bytes())
+		if output == "helm" {
+			return helm.WriteChart(os.Stdout, manifest.Bytes(), opts.Version)
+		}
+		encoded, err := encodeManifest(manifest.Bytes(), output)
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(encoded)
 		return err
 	}
+	// When upgrading, the objects already exist, so we update them instead
+	// of creating them. Deployments roll their pods over to the new spec.
+	command := "create"
+	if opts.Upgrade {
+		command = "apply"
+	}
 	return cmdutil.RunIO(
 		cmdutil.IO{
 			Stdin:  manifest,
 			Stdout: os.Stdout,
 			Stderr: os.Stderr,
-		}, "kubectl", "create", "-f", "-")
+		}, "kubectl", command, "-f", "-")
 }
 
 // DeployCmd returns a cobra.Command to deploy pachyderm.
 func DeployCmd(noMetrics *bool) *cobra.Command {
 	metrics := !*noMetrics
 	var pachdShards int
+	var pachdReplicas int
 	var hostPath string
 	var dev bool
 	var dryRun bool
+	var output string
 	var secure bool
 	var etcdNodes int
 	var etcdVolume string
@@ -58,6 +124,61 @@ func DeployCmd(noMetrics *bool) *cobra.Command {
 	var enableDash bool
 	var dashOnly bool
 	var dashImage string
+	var namespace string
+	var storageClass string
+	var registry string
+	var upgrade bool
+	var tlsSecret string
+	var etcdKeySecret string
+	var workerNetworkPolicies bool
+	var prepullImages bool
+	var allowedImages []string
+	var registryCredentials []string
+	var requireNonRoot bool
+	var requireResourceLimits bool
+	var migrationDryRun bool
+	var dynamicNodePorts bool
+	var noRBAC bool
+	var etcdEndpoints []string
+	var etcdTLSSecret string
+	var etcdCredentialsSecret string
+	var etcdCompactionInterval string
+	var etcdDefragmentInterval string
+	var workerDefaultCPURequest string
+	var workerDefaultMemoryRequest string
+	var workerDefaultCPULimit string
+	var workerDefaultMemoryLimit string
+	var workerMaxCPU string
+	var workerMaxMemory string
+	var maxWorkers int
+	var teamWeights []string
+	var privilegedWorkers bool
+	var sqlEgress bool
+	var sqlInputs bool
+	var replicateTo string
+	var replicateFrom string
+	var replicationInterval string
+	var lineageEndpoint string
+	var lineageNamespace string
+	var eventSink string
+	var eventBranches []string
+	var eventPipelines []string
+	var metricsExporter string
+	var downloadURL string
+	var flexVolume bool
+	var flexVolumePluginDir string
+	var pachdDrainTimeout time.Duration
+	var pachdProbePeriod time.Duration
+	var pachdProbeFailureThreshold int
+	var pachdPort int
+	var pachdHTTPPort int
+	var workerPort int
+	var ipv6 bool
+	var systemNodeSelector []string
+	var systemTolerations []string
+	var workerNodeSelector []string
+	var workerTolerations []string
+	var workerEnvVars []string
 
 	deployLocal := &cobra.Command{
 		Use:   "local",
@@ -75,7 +196,7 @@ func DeployCmd(noMetrics *bool) *cobra.Command {
 			if err := assets.WriteLocalAssets(manifest, opts, hostPath); err != nil {
 				return err
 			}
-			return maybeKcCreate(dryRun, manifest)
+			return maybeKcCreate(dryRun, output, manifest, opts)
 		}),
 	}
 	deployLocal.Flags().StringVar(&hostPath, "host-path", "/var/pachyderm", "Location on the host machine where PFS metadata will be stored.")
@@ -103,7 +224,7 @@ func DeployCmd(noMetrics *bool) *cobra.Command {
 			if err = assets.WriteGoogleAssets(manifest, opts, args[0], volumeSize); err != nil {
 				return err
 			}
-			return maybeKcCreate(dryRun, manifest)
+			return maybeKcCreate(dryRun, output, manifest, opts)
 		}),
 	}
 
@@ -123,7 +244,7 @@ func DeployCmd(noMetrics *bool) *cobra.Command {
 			if err != nil {
 				return err
 			}
-			return maybeKcCreate(dryRun, manifest)
+			return maybeKcCreate(dryRun, output, manifest, opts)
 		}),
 	}
 	deployCustom.Flags().BoolVarP(&secure, "secure", "s", false, "Enable secure access to a Minio server.")
@@ -159,7 +280,7 @@ func DeployCmd(noMetrics *bool) *cobra.Command {
 			if err = assets.WriteAmazonAssets(manifest, opts, args[0], args[1], args[2], args[3], args[4], volumeSize, cloudfrontDistribution); err != nil {
 				return err
 			}
-			return maybeKcCreate(dryRun, manifest)
+			return maybeKcCreate(dryRun, output, manifest, opts)
 		}),
 	}
 	deployAmazon.Flags().StringVar(&cloudfrontDistribution, "cloudfront-distribution", "",
@@ -196,7 +317,7 @@ func DeployCmd(noMetrics *bool) *cobra.Command {
 			if err = assets.WriteMicrosoftAssets(manifest, opts, args[0], args[1], args[2], volumeSize); err != nil {
 				return err
 			}
-			return maybeKcCreate(dryRun, manifest)
+			return maybeKcCreate(dryRun, output, manifest, opts)
 		}),
 	}
 
@@ -205,8 +326,139 @@ func DeployCmd(noMetrics *bool) *cobra.Command {
 		Short: "Deploy a Pachyderm cluster.",
 		Long:  "Deploy a Pachyderm cluster.",
 		PersistentPreRun: cmdutil.Run(func([]string) error {
+			if tlsSecret != "" && (enableDash || dashOnly) {
+				return fmt.Errorf("the dashboard doesn't support TLS yet, --tls-secret can't be used with --dashboard or --dashboard-only")
+			}
+			if len(etcdEndpoints) == 0 && (etcdTLSSecret != "" || etcdCredentialsSecret != "") {
+				return fmt.Errorf("--etcd-tls-secret and --etcd-credentials-secret can only be used with --etcd-endpoints")
+			}
+			for flag, interval := range map[string]string{
+				"--etcd-compaction-interval": etcdCompactionInterval,
+				"--etcd-defragment-interval": etcdDefragmentInterval,
+				"--replication-interval":     replicationInterval,
+			} {
+				if _, err := time.ParseDuration(interval); err != nil {
+					return fmt.Errorf("invalid %s: %v", flag, err)
+				}
+			}
+			if lineageEndpoint != "" {
+				if u, err := url.Parse(lineageEndpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+					return fmt.Errorf("invalid --lineage-endpoint %q, it must be an http or https URL", lineageEndpoint)
+				}
+			}
+			if eventSink != "" {
+				if _, err := events.NewSink(eventSink); err != nil {
+					return fmt.Errorf("invalid --event-sink: %v", err)
+				}
+			} else if len(eventBranches) > 0 || len(eventPipelines) > 0 {
+				return fmt.Errorf("--event-branches and --event-pipelines need an --event-sink to publish events to")
+			}
+			if downloadURL != "" {
+				if u, err := url.Parse(downloadURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+					return fmt.Errorf("invalid --download-url %q, it must be an http or https URL", downloadURL)
+				}
+			}
+			if metricsExporter != "" {
+				if err := stats.Validate(metricsExporter); err != nil {
+					return fmt.Errorf("invalid --metrics-exporter: %v", err)
+				}
+			}
+			for _, host := range registryCredentials {
+				if _, err := registrycreds.ProviderOf(host); err != nil {
+					return fmt.Errorf("invalid --registry-credentials: %v", err)
+				}
+			}
+			for flag, cpu := range map[string]string{
+				"--worker-default-cpu-request": workerDefaultCPURequest,
+				"--worker-default-cpu-limit":   workerDefaultCPULimit,
+				"--worker-max-cpu":             workerMaxCPU,
+			} {
+				if cpu == "" {
+					continue
+				}
+				if _, err := strconv.ParseFloat(cpu, 32); err != nil {
+					return fmt.Errorf("invalid %s: %v", flag, err)
+				}
+			}
+			for flag, memory := range map[string]string{
+				"--worker-default-memory-request": workerDefaultMemoryRequest,
+				"--worker-default-memory-limit":   workerDefaultMemoryLimit,
+				"--worker-max-memory":             workerMaxMemory,
+			} {
+				if memory == "" {
+					continue
+				}
+				if _, err := resource.ParseQuantity(memory); err != nil {
+					return fmt.Errorf("invalid %s: %v", flag, err)
+				}
+			}
+			if maxWorkers < 0 {
+				return fmt.Errorf("--max-workers can't be negative")
+			}
+			if pachdDrainTimeout < 0 {
+				return fmt.Errorf("--pachd-drain-timeout can't be negative")
+			}
+			if pachdProbePeriod < time.Second {
+				return fmt.Errorf("--pachd-probe-period must be at least 1s")
+			}
+			if pachdProbeFailureThreshold < 1 {
+				return fmt.Errorf("--pachd-probe-failure-threshold must be at least 1")
+			}
+			for flag, port := range map[string]int{
+				"--pachd-port":      pachdPort,
+				"--pachd-http-port": pachdHTTPPort,
+				"--worker-port":     workerPort,
+			} {
+				if port < 1 || port > 65535 {
+					return fmt.Errorf("invalid %s %d, must be between 1 and 65535", flag, port)
+				}
+			}
+			if pachdPort == pachdHTTPPort {
+				return fmt.Errorf("--pachd-port and --pachd-http-port must be different")
+			}
+			systemNodePool, err := assets.ParseNodePool(systemNodeSelector, systemTolerations)
+			if err != nil {
+				return fmt.Errorf("invalid --system-node-selector or --system-tolerations: %v", err)
+			}
+			workerNodePool, err := assets.ParseNodePool(workerNodeSelector, workerTolerations)
+			if err != nil {
+				return fmt.Errorf("invalid --worker-node-selector or --worker-tolerations: %v", err)
+			}
+			parsedTeamWeights, err := assets.ParseTeamWeights(teamWeights)
+			if err != nil {
+				return fmt.Errorf("invalid --team-weights: %v", err)
+			}
+			workerEnv, err := assets.ParseWorkerEnv(workerEnvVars)
+			if err != nil {
+				return fmt.Errorf("invalid --worker-env: %v", err)
+			}
+			if replicateTo != "" && replicateFrom != "" {
+				return fmt.Errorf("--replicate-to and --replicate-from can't be used together, a cluster is either a primary or a secondary")
+			}
+			if pachdReplicas < 1 {
+				return fmt.Errorf("--pachd-replicas must be at least 1")
+			}
+			if len(etcdEndpoints) > 0 && (etcdNodes > 0 || etcdVolume != "") {
+				return fmt.Errorf("--etcd-endpoints can't be used with --dynamic-etcd-nodes or --static-etcd-volume, etcd isn't deployed when it's given")
+			}
+			if output == "helm" {
+				if !dryRun {
+					return fmt.Errorf("--output helm writes a chart, rather than deploying, so it needs --dry-run")
+				}
+				if namespace != "" {
+					return fmt.Errorf("--namespace can't be used with --output helm, charts are deployed in their release's namespace")
+				}
+				namespace = helm.Namespace
+			}
+			if namespace == "" {
+				var err error
+				if namespace, err = contextNamespace(); err != nil {
+					return err
+				}
+			}
 			opts = &assets.AssetOpts{
 				PachdShards:             uint64(pachdShards),
+				PachdReplicas:           pachdReplicas,
 				Version:                 version.PrettyPrintVersion(version.Version),
 				LogLevel:                logLevel,
 				Metrics:                 metrics,
@@ -220,18 +472,129 @@ func DeployCmd(noMetrics *bool) *cobra.Command {
 				EnableDash:              enableDash,
 				DashOnly:                dashOnly,
 				DashImage:               dashImage,
+				Namespace:               namespace,
+				StorageClass:            storageClass,
+				Registry:                registry,
+				Upgrade:                 upgrade,
+				TLSSecret:               tlsSecret,
+				EtcdKeySecret:           etcdKeySecret,
+				WorkerNetworkPolicies:   workerNetworkPolicies,
+				PrepullImages:           prepullImages,
+				AllowedImagePrefixes:    allowedImages,
+				RegistryCredentials:     registryCredentials,
+				RequireNonRoot:          requireNonRoot,
+				RequireResourceLimits:   requireResourceLimits,
+				MigrationDryRun:         migrationDryRun,
+				DynamicNodePorts:        dynamicNodePorts,
+				NoRBAC:                  noRBAC,
+				EtcdEndpoints:           etcdEndpoints,
+				EtcdTLSSecret:           etcdTLSSecret,
+				EtcdCredentialsSecret:   etcdCredentialsSecret,
+				EtcdCompactionInterval:  etcdCompactionInterval,
+				EtcdDefragmentInterval:  etcdDefragmentInterval,
+				// Defaults and maximums of workers' resources
+				WorkerDefaultCPURequest:    workerDefaultCPURequest,
+				WorkerDefaultMemoryRequest: workerDefaultMemoryRequest,
+				WorkerDefaultCPULimit:      workerDefaultCPULimit,
+				WorkerDefaultMemoryLimit:   workerDefaultMemoryLimit,
+				WorkerMaxCPU:               workerMaxCPU,
+				WorkerMaxMemory:            workerMaxMemory,
+				MaxWorkers:                 maxWorkers,
+				TeamWeights:                parsedTeamWeights,
+				PrivilegedWorkers:          privilegedWorkers,
+				SQLEgress:                  sqlEgress,
+				SQLInputs:                  sqlInputs,
+				ReplicateTo:                replicateTo,
+				ReplicateFrom:              replicateFrom,
+				ReplicationInterval:        replicationInterval,
+				LineageEndpoint:            lineageEndpoint,
+				LineageNamespace:           lineageNamespace,
+				EventSink:                  eventSink,
+				EventBranches:              eventBranches,
+				EventPipelines:             eventPipelines,
+				MetricsExporter:            metricsExporter,
+				DownloadURL:                downloadURL,
+				FlexVolume:                 flexVolume,
+				FlexVolumePluginDir:        flexVolumePluginDir,
+				PachdDrainTimeout:          pachdDrainTimeout,
+				PachdProbePeriod:           pachdProbePeriod,
+				PachdProbeFailureThreshold: pachdProbeFailureThreshold,
+				SystemNodePool:             systemNodePool,
+				WorkerNodePool:             workerNodePool,
+				WorkerEnv:                  workerEnv,
+				PachdPort:                  pachdPort,
+				PachdHTTPPort:              pachdHTTPPort,
+				WorkerPort:                 workerPort,
+				IPv6:                       ipv6,
 			}
 			return nil
 		}),
 	}
 	deploy.PersistentFlags().IntVar(&pachdShards, "shards", 16, "Number of Pachd nodes (stateless Pachyderm API servers).")
+	deploy.PersistentFlags().IntVar(&pachdReplicas, "pachd-replicas", 1, "Number of pachd pods. The pods split up the work of running pipelines between them; pachd can also be scaled after it's deployed with 'kubectl scale deployment pachd'.")
 	deploy.PersistentFlags().IntVar(&etcdNodes, "dynamic-etcd-nodes", 0, "Deploy etcd as a StatefulSet with the given number of pods.  The persistent volumes used by these pods are provisioned dynamically.  Note that StatefulSet is currently a beta kubernetes feature, which might be unavailable in older versions of kubernetes.")
 	deploy.PersistentFlags().StringVar(&etcdVolume, "static-etcd-volume", "", "Deploy etcd as a ReplicationController with one pod.  The pod uses the given persistent volume.")
 	deploy.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Don't actually deploy pachyderm to Kubernetes, instead just print the manifest.")
+	deploy.PersistentFlags().StringVarP(&output, "output", "o", "json", "The format of the manifest printed by --dry-run, \"json\" or \"yaml\", or \"helm\" for a Helm chart, as a gzipped tar archive, whose templates are the manifest's objects.")
 	deploy.PersistentFlags().StringVar(&logLevel, "log-level", "info", "The level of log messages to print options are, from least to most verbose: \"error\", \"info\", \"debug\".")
 	deploy.PersistentFlags().BoolVar(&enableDash, "dashboard", false, "Deploy the Pachyderm UI along with Pachyderm (experimental)")
 	deploy.PersistentFlags().BoolVar(&dashOnly, "dashboard-only", false, "Only deploy the Pachyderm UI (experimental), without the rest of pachyderm. This is for launching the UI adjacent to an existing Pachyderm cluster")
 	deploy.PersistentFlags().StringVar(&dashImage, "dash-image", defaultDashImage, "Image URL for pachyderm dashboard")
+	deploy.PersistentFlags().StringVar(&namespace, "namespace", "", "Kubernetes namespace to deploy Pachyderm to, defaults to the namespace of the active pachctl context, or else of the current kubectl context.")
+	deploy.PersistentFlags().StringVar(&storageClass, "storage-class", "", "The name of an existing StorageClass to use for etcd's volumes when deploying with --dynamic-etcd-nodes, instead of creating one.")
+	deploy.PersistentFlags().StringVar(&registry, "registry", "", "The docker registry to pull Pachyderm's images (pachd, worker, etcd and dash), and the image of pipelines which don't name one, from, e.g. \"my-registry.example.com:5000\" or \"my-registry.example.com/mirror\".")
+	deploy.PersistentFlags().BoolVar(&upgrade, "upgrade", false, "Upgrade an existing Pachyderm cluster in place to this version of Pachyderm. Only pachd (and dash) are updated, etcd and the object store's secrets are left as they are, so no data is lost. Pipelines' workers are upgraded when pachd restarts.")
+	deploy.PersistentFlags().StringVar(&tlsSecret, "tls-secret", "", "The name of an existing kubernetes TLS secret (with tls.crt, tls.key and optionally ca.crt) that pachd serves TLS with. pachctl then needs the certificate's CA, see 'pachctl config set-context --server-cas'.")
+	deploy.PersistentFlags().StringVar(&etcdKeySecret, "etcd-key-secret", "", "The name of an existing kubernetes secret whose \"key\" item is a base64-encoded 32-byte key that pachd encrypts auth tokens, ACLs, pipelines and jobs with in etcd. Pass the same secret when upgrading, data encrypted with it can't be read without it.")
+	deploy.PersistentFlags().BoolVar(&workerNetworkPolicies, "worker-network-policies", false, "Restrict the network access of pipelines' workers with network policies, so that they can only connect to pachd, etcd, DNS, the object store and the destinations in their pipelines' allowed_egress. Needs a network plugin which enforces egress policies.")
+	deploy.PersistentFlags().BoolVar(&prepullImages, "prepull-images", false, "Pull each pipeline's image onto all of the nodes that its workers may run on as soon as the pipeline is created, so that its first job doesn't wait for big images to be pulled. Runs a pod on each of those nodes for each pipeline until its images have been pulled.")
+	deploy.PersistentFlags().StringSliceVar(&registryCredentials, "registry-credentials", nil, "Registries (e.g. \"123456789012.dkr.ecr.us-east-1.amazonaws.com\", \"gcr.io\" or \"example.azurecr.io\") whose short-lived tokens pachd gets from their cloud provider and keeps refreshed, for pipelines' workers to pull their images with, instead of an image_pull_secret that expires. Can be given more than once.")
+	deploy.PersistentFlags().StringSliceVar(&allowedImages, "allowed-images", nil, "Only let pipelines use images that start with one of these prefixes, e.g. \"registry.example.com/\" for a whole registry or \"ubuntu\" for one image. Can be given more than once. If not given, pipelines may use any image.")
+	deploy.PersistentFlags().BoolVar(&requireNonRoot, "require-non-root", false, "Reject pipelines that don't set transform.run_as_user, so that pipelines' code never runs as root (or in a privileged container).")
+	deploy.PersistentFlags().BoolVar(&requireResourceLimits, "require-resource-limits", false, "Reject pipelines that don't limit their workers' CPU and memory with resource_limits.")
+	deploy.PersistentFlags().BoolVar(&dynamicNodePorts, "dynamic-node-ports", false, "Let kubernetes choose the node ports of pachd's, etcd's and dash's services, instead of 30650 and so on, so that several Pachyderm instances can be deployed to the same cluster in different namespaces.")
+	deploy.PersistentFlags().BoolVar(&noRBAC, "no-rbac", false, "Don't write the RBAC roles that grant pachd the permissions it needs, for clusters which don't use RBAC, or whose roles are managed separately.")
+	deploy.PersistentFlags().BoolVar(&migrationDryRun, "migration-dry-run", false, "Make pachd log the migrations of its metadata that it would run when it starts, and the keys they would change, instead of running them. pachd doesn't start if there are any.")
+	deploy.PersistentFlags().StringSliceVar(&etcdEndpoints, "etcd-endpoints", nil, "The endpoints of an existing etcd cluster (e.g. \"https://etcd-0.example.com:2379\") for pachd to use, instead of deploying its own. Can be given more than once. The cluster must serve etcd's v2 API as well as its v3 API.")
+	deploy.PersistentFlags().StringVar(&etcdTLSSecret, "etcd-tls-secret", "", "The name of an existing kubernetes secret holding the CA certificate (ca.crt) that the etcd given with --etcd-endpoints is verified with, and optionally a client certificate (tls.crt and tls.key) that pachd and its workers present to it.")
+	deploy.PersistentFlags().StringVar(&etcdCredentialsSecret, "etcd-credentials-secret", "", "The name of an existing kubernetes secret whose \"username\" and \"password\" items are the user that pachd and its workers authenticate to the etcd given with --etcd-endpoints as.")
+	deploy.PersistentFlags().StringVar(&etcdCompactionInterval, "etcd-compaction-interval", "1h", "How often pachd compacts etcd's history, keeping the history of the last interval. \"0\" turns compaction off.")
+	deploy.PersistentFlags().StringVar(&etcdDefragmentInterval, "etcd-defragment-interval", "24h", "How often pachd defragments etcd's members, one at a time, to give the space freed by compaction back to the filesystem. \"0\" turns defragmentation off.")
+	deploy.PersistentFlags().StringVar(&workerDefaultCPURequest, "worker-default-cpu-request", "", "The CPU (in cores) that pachd requests for pipelines' workers whose specs don't set resource_spec.cpu.")
+	deploy.PersistentFlags().StringVar(&workerDefaultMemoryRequest, "worker-default-memory-request", "", "The memory (e.g. \"512M\") that pachd requests for pipelines' workers whose specs don't set resource_spec.memory.")
+	deploy.PersistentFlags().StringVar(&workerDefaultCPULimit, "worker-default-cpu-limit", "", "The CPU (in cores) that pachd limits pipelines' workers to if their specs don't set resource_limits.cpu.")
+	deploy.PersistentFlags().StringVar(&workerDefaultMemoryLimit, "worker-default-memory-limit", "", "The memory (e.g. \"1G\") that pachd limits pipelines' workers to if their specs don't set resource_limits.memory.")
+	deploy.PersistentFlags().StringVar(&workerMaxCPU, "worker-max-cpu", "", "The most CPU (in cores) that a pipeline may request or be limited to. Pipelines that don't set resource_limits.cpu are limited to it.")
+	deploy.PersistentFlags().StringVar(&workerMaxMemory, "worker-max-memory", "", "The most memory (e.g. \"8G\") that a pipeline may request or be limited to. Pipelines that don't set resource_limits.memory are limited to it.")
+	deploy.PersistentFlags().IntVar(&maxWorkers, "max-workers", 0, "The most workers that all pipelines and jobs may have between them. Jobs wait for workers when it's reached, and pipelines with more parallelism get this many workers. 0 means no limit.")
+	deploy.PersistentFlags().StringSliceVar(&teamWeights, "team-weights", nil, "A team's weight (\"team=weight\"), which decides its share of the workers under --max-workers, relative to the other teams' weights, when their pipelines' jobs are waiting for workers. Teams that aren't given a weight have a weight of 1. Can be given more than once.")
+	deploy.PersistentFlags().BoolVar(&privilegedWorkers, "privileged-workers", false, "Run the code of pipelines which don't set run_as_user in privileged containers, e.g. for pipelines that run docker. By default no container that Pachyderm creates is privileged.")
+	deploy.PersistentFlags().BoolVar(&sqlEgress, "sql-egress", false, "Let pipelines load their output into Postgres or Snowflake tables with egress.sql. pachd reads the databases' credentials from the kubernetes secrets that pipelines name, so it's given permission to read the namespace's secrets.")
+	deploy.PersistentFlags().BoolVar(&sqlInputs, "sql-inputs", false, "Let pipelines have SQL inputs, which snapshot the results of queries against Postgres or Snowflake into repos on a cron schedule. pachd reads the databases' credentials from the kubernetes secrets that pipelines name, so it's given permission to read the namespace's secrets.")
+	deploy.PersistentFlags().StringVar(&replicateTo, "replicate-to", "", "The URL of a bucket (e.g. \"s3://bucket/replication\") that pachd writes incremental backups of the cluster's metadata and objects to, for a secondary cluster deployed with --replicate-from to restore.")
+	deploy.PersistentFlags().StringVar(&replicateFrom, "replicate-from", "", "The URL of a bucket that a primary cluster deployed with --replicate-to writes its backups to. pachd restores them as they're written, until it's promoted with 'pachctl promote-replica'. The cluster must start out empty.")
+	deploy.PersistentFlags().StringVar(&replicationInterval, "replication-interval", "5m", "How often a primary writes a backup to the bucket given with --replicate-to, and a secondary checks for new ones in the bucket given with --replicate-from.")
+	deploy.PersistentFlags().StringVar(&lineageEndpoint, "lineage-endpoint", "", "The URL (e.g. Marquez's \"http://marquez:5000/api/v1/lineage\") that pachd exports jobs' lineage to, as OpenLineage events, as they start and finish.")
+	deploy.PersistentFlags().StringVar(&lineageNamespace, "lineage-namespace", "pachyderm", "The OpenLineage namespace of the pipelines and repos in the lineage exported to --lineage-endpoint.")
+	deploy.PersistentFlags().StringVar(&eventSink, "event-sink", "", "Where pachd publishes events, as JSON, when commits on --event-branches are finished and jobs of --event-pipelines finish: an SNS topic's ARN (\"arn:aws:sns:<region>:<account>:<topic>\"), a Pub/Sub topic (\"projects/<project>/topics/<topic>\") or a webhook's http or https URL.")
+	deploy.PersistentFlags().StringSliceVar(&eventBranches, "event-branches", nil, "Branches, as \"<repo>@<branch>\" or \"<repo>\" for master, that pachd publishes an event to --event-sink for as each of their commits is finished. Can be given more than once.")
+	deploy.PersistentFlags().StringSliceVar(&eventPipelines, "event-pipelines", nil, "Pipelines, or \"*\" for all of them, that pachd publishes an event to --event-sink for as each of their jobs succeeds, fails or is stopped. Can be given more than once.")
+	deploy.PersistentFlags().StringVar(&metricsExporter, "metrics-exporter", "", "Where pachd exports metrics about pipelines, jobs and repos, tagged by pipeline or repo: \"prometheus\" (served on pachd's HTTP port, at /metrics), \"statsd://<host>[:<port>]\", \"datadog\" (the Datadog agent on pachd's node) or \"datadog://<host>[:<port>]\".")
+	deploy.PersistentFlags().StringVar(&downloadURL, "download-url", "", "The URL that clients reach pachd's HTTP port at (e.g. through an ingress, \"https://pachyderm.example.com\"), which the signed, expiring download URLs of files that 'pachctl get-file-url' returns start with. Files can't be downloaded by URL if it's unset.")
+	deploy.PersistentFlags().BoolVar(&flexVolume, "flexvolume", false, "Install the FlexVolume driver \"pachyderm.io/pfs\" on every node, with a DaemonSet, so that any pod can mount a commit read only with a flexVolume. Nodes need FUSE.")
+	deploy.PersistentFlags().StringVar(&flexVolumePluginDir, "flexvolume-plugin-dir", assets.DefaultFlexVolumePluginDir, "The directory, on each node, that kubelet looks for FlexVolume drivers in, which some providers (e.g. GKE's \"/home/kubernetes/flexvolume\") change.")
+	deploy.PersistentFlags().DurationVar(&pachdDrainTimeout, "pachd-drain-timeout", 30*time.Second, "How long a pachd that's being stopped (e.g. when it's upgraded) waits for the requests it's serving, such as long GetFile and FlushCommit streams, to finish. Kubernetes gives pachd this long, plus 10 seconds, to exit.")
+	deploy.PersistentFlags().DurationVar(&pachdProbePeriod, "pachd-probe-period", 10*time.Second, "How often kubernetes checks pachd's health. pachd is ready when it can reach etcd and the object store.")
+	deploy.PersistentFlags().IntVar(&pachdProbeFailureThreshold, "pachd-probe-failure-threshold", 3, "How many of pachd's health checks must fail in a row before kubernetes stops sending it requests (or restarts it, if it stops answering).")
+	deploy.PersistentFlags().IntVar(&pachdPort, "pachd-port", 650, "The port that pachd listens on for gRPC. pachd's service exposes port 650 whichever port pachd listens on.")
+	deploy.PersistentFlags().IntVar(&pachdHTTPPort, "pachd-http-port", 651, "The port that pachd listens on for HTTP (its health checks). pachd's service exposes port 651 whichever port pachd listens on.")
+	deploy.PersistentFlags().IntVar(&workerPort, "worker-port", 80, "The port that pipelines' workers listen on. It must be at least 1024 for pipelines whose code doesn't run as root. Existing pipelines' workers keep their port until the pipeline is updated.")
+	deploy.PersistentFlags().BoolVar(&ipv6, "ipv6", false, "Deploy to an IPv6-only cluster, which makes etcd listen on IPv6 rather than IPv4. pachd and pipelines' workers listen on both either way.")
+	deploy.PersistentFlags().StringSliceVar(&systemNodeSelector, "system-node-selector", nil, "A node label (\"key=value\") that pachd, etcd and the dashboard only run on nodes with, e.g. \"cloud.google.com/gke-nodepool=system\" for a GKE node pool. Can be given more than once.")
+	deploy.PersistentFlags().StringSliceVar(&systemTolerations, "system-tolerations", nil, "A node taint (\"key=value:effect\", or \"key:effect\" for any value) that pachd, etcd and the dashboard tolerate, for nodes that are tainted to keep other pods off. Can be given more than once.")
+	deploy.PersistentFlags().StringSliceVar(&workerNodeSelector, "worker-node-selector", nil, "A node label (\"key=value\") that pipelines' workers only run on nodes with, so that they don't compete with pachd and etcd for their nodes. Can be given more than once.")
+	deploy.PersistentFlags().StringSliceVar(&workerTolerations, "worker-tolerations", nil, "A node taint (\"key=value:effect\", or \"key:effect\" for any value) that pipelines' workers tolerate, e.g. \"dedicated=pachyderm-workers:NoSchedule\" for nodes dedicated to them. Can be given more than once.")
+	deploy.PersistentFlags().StringSliceVar(&workerEnvVars, "worker-env", nil, "An env var (\"name=value\") that's set in every pipeline's workers, unless the pipeline's transform sets it, e.g. \"HTTPS_PROXY=http://proxy:3128\". Values with commas must be quoted, e.g. '\"NO_PROXY=localhost,.svc\"'. Can be given more than once. Existing pipelines' workers get it when the pipeline is updated.")
 	deploy.AddCommand(deployLocal)
 	deploy.AddCommand(deployAmazon)
 	deploy.AddCommand(deployGoogle)
@@ -271,6 +634,7 @@ func DeployCmd(noMetrics *bool) *cobra.Command {
 func Cmds(noMetrics *bool) []*cobra.Command {
 	deploy := DeployCmd(noMetrics)
 	var all bool
+	var namespace string
 	undeploy := &cobra.Command{
 		Use:   "undeploy",
 		Short: "Tear down a deployed Pachyderm cluster.",
@@ -301,26 +665,54 @@ Are you sure you want to proceed? yN
 				Stdout: os.Stdout,
 				Stderr: os.Stderr,
 			}
-			if err := cmdutil.RunIO(io, "kubectl", "delete", "job", "-l", "suite=pachyderm"); err != nil {
+			if namespace == "" {
+				var err error
+				if namespace, err = contextNamespace(); err != nil {
+					return err
+				}
+			}
+			kubectl := func(args ...string) error {
+				if namespace != "" {
+					args = append(args, "--namespace", namespace)
+				}
+				return cmdutil.RunIO(io, append([]string{"kubectl"}, args...)...)
+			}
+			if err := kubectl("delete", "job", "-l", "suite=pachyderm"); err != nil {
+				return err
+			}
+			if err := kubectl("delete", "all", "-l", "suite=pachyderm"); err != nil {
+				return err
+			}
+			if err := kubectl("delete", "sa", "-l", "suite=pachyderm"); err != nil {
 				return err
 			}
-			if err := cmdutil.RunIO(io, "kubectl", "delete", "all", "-l", "suite=pachyderm"); err != nil {
+			// "all" doesn't include etcd's PodDisruptionBudget
+			if err := kubectl("delete", "poddisruptionbudget", "-l", "suite=pachyderm"); err != nil {
 				return err
 			}
-			if err := cmdutil.RunIO(io, "kubectl", "delete", "sa", "-l", "suite=pachyderm"); err != nil {
+			if err := kubectl("delete", "secret", "-l", "suite=pachyderm"); err != nil {
 				return err
 			}
-			if err := cmdutil.RunIO(io, "kubectl", "delete", "secret", "-l", "suite=pachyderm"); err != nil {
+			// pachd's roles are deleted unless the cluster doesn't use RBAC
+			// (or pachyderm was deployed with --no-rbac), in which case there's
+			// nothing to delete. Like StorageClasses, ClusterRoles aren't
+			// namespaced, so they're deleted by name.
+			if err := kubectl("delete", "role,rolebinding", "-l", "suite=pachyderm"); err != nil {
+				fmt.Fprintf(os.Stderr, "could not delete pachd's roles, which is expected if the cluster doesn't use RBAC: %v\n", err)
+			} else if err := kubectl("delete", "clusterrole,clusterrolebinding", assets.ClusterRoleName(namespace), "--ignore-not-found"); err != nil {
 				return err
 			}
 			if all {
-				if err := cmdutil.RunIO(io, "kubectl", "delete", "storageclass", "-l", "suite=pachyderm"); err != nil {
+				// StorageClasses and PersistentVolumes aren't namespaced, so
+				// they're deleted by name, which leaves those of Pachyderm
+				// instances in other namespaces alone.
+				if err := kubectl("delete", "storageclass", assets.EtcdStorageClassName(namespace), "--ignore-not-found"); err != nil {
 					return err
 				}
-				if err := cmdutil.RunIO(io, "kubectl", "delete", "pvc", "-l", "suite=pachyderm"); err != nil {
+				if err := kubectl("delete", "pvc", "-l", "suite=pachyderm"); err != nil {
 					return err
 				}
-				if err := cmdutil.RunIO(io, "kubectl", "delete", "pv", "-l", "suite=pachyderm"); err != nil {
+				if err := kubectl("delete", "pv", assets.EtcdVolumeName(namespace), "--ignore-not-found"); err != nil {
 					return err
 				}
 			}
@@ -335,5 +727,6 @@ removed, making metadata such repos, commits, pipelines, and jobs
 unrecoverable. If your persistent volume was manually provisioned (i.e. if
 you used the "--static-etcd-volume" flag), the underlying volume will not be
 removed.`)
+	undeploy.Flags().StringVar(&namespace, "namespace", "", "Kubernetes namespace to undeploy Pachyderm from, defaults to the namespace of the active pachctl context, or else of the current kubectl context.")
 	return []*cobra.Command{deploy, undeploy}
 }
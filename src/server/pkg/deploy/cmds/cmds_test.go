@@ -0,0 +1,30 @@
+package cmds
+
+import (
+	"testing"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+)
+
+func TestEncodeManifest(t *testing.T) {
+	manifest := []byte(`{"kind":"ServiceAccount","metadata":{"name":"pachyderm"}}
+{"kind":"Service","metadata":{"name":"etcd"}}
+`)
+	encoded, err := encodeManifest(manifest, "json")
+	require.NoError(t, err)
+	require.Equal(t, string(manifest), string(encoded))
+
+	encoded, err = encodeManifest(manifest, "yaml")
+	require.NoError(t, err)
+	require.Equal(t, `kind: ServiceAccount
+metadata:
+  name: pachyderm
+---
+kind: Service
+metadata:
+  name: etcd
+`, string(encoded))
+
+	_, err = encodeManifest(manifest, "xml")
+	require.YesError(t, err)
+}
@@ -0,0 +1,283 @@
+// Package helm packages the manifests that 'pachctl deploy' generates as Helm
+// charts, so that clusters that are managed with Helm can be deployed and
+// upgraded with a chart that's always in step with 'pachctl deploy', rather
+// than one that's maintained by hand.
+//
+// The chart's templates are the manifest's objects, with the values that
+// are most often tuned after deploying (pachd's and etcd's resources, pachd's
+// replicas and log level, and the version of Pachyderm's images) replaced
+// by references to the chart's values, whose defaults are what the manifest
+// had. The objects are deployed in the release's namespace.
+package helm
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+)
+
+const (
+	// Namespace is the namespace that manifests are generated in for
+	// WriteChart, which replaces it with the release's namespace. It's
+	// replaced wherever it appears, e.g. in the names of cluster-scoped
+	// objects and in the subjects of role bindings, as well as in objects'
+	// metadata.
+	Namespace = "helm-release-namespace"
+
+	// chartName is the name of the chart, and of its directory in the
+	// archive that WriteChart writes.
+	chartName = "pachyderm"
+)
+
+// chartVersion is a SemVer version, which charts' versions must be.
+var chartVersion = regexp.MustCompile(`^v?[0-9]+\.[0-9]+\.[0-9]+(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`)
+
+// WriteChart writes the chart of manifest, a stream of JSON objects as
+// written by the assets package (in Namespace), to w, as a gzipped tar
+// archive that 'helm install' takes. version is the version of Pachyderm's
+// images in manifest.
+func WriteChart(w io.Writer, manifest []byte, version string) error {
+	c := &chart{
+		version: version,
+		values:  make(map[string]interface{}),
+		params:  make(map[string]*param),
+	}
+	files := make(map[string][]byte)
+	decoder := json.NewDecoder(bytes.NewReader(manifest))
+	decoder.UseNumber()
+	for decoder.More() {
+		var object map[string]interface{}
+		if err := decoder.Decode(&object); err != nil {
+			return err
+		}
+		template, err := c.template(object)
+		if err != nil {
+			return err
+		}
+		kind, _ := object["kind"].(string)
+		name, _ := lookup(object, "metadata", "name").(string)
+		base := fmt.Sprintf("templates/%s-%s", strings.ToLower(kind), strings.Replace(name, Namespace, "release", -1))
+		file := base + ".yaml"
+		for i := 2; files[file] != nil; i++ {
+			file = fmt.Sprintf("%s-%d.yaml", base, i)
+		}
+		files[file] = template
+	}
+
+	values, err := yaml.Marshal(c.values)
+	if err != nil {
+		return err
+	}
+	files["values.yaml"] = append([]byte("# The defaults are the values that 'pachctl deploy' generated the chart with.\n"), values...)
+	appVersion := version
+	if !chartVersion.MatchString(version) {
+		// e.g. the "local" version of development builds
+		version = "0.0.0-" + regexp.MustCompile(`[^0-9A-Za-z.-]`).ReplaceAllString(version, "-")
+	}
+	files["Chart.yaml"] = []byte(fmt.Sprintf(`apiVersion: v1
+name: %s
+version: %s
+appVersion: %q
+description: Pachyderm, as deployed by 'pachctl deploy'.
+home: https://pachyderm.io
+`, chartName, version, appVersion))
+	return writeArchive(w, files)
+}
+
+// param is a value of the chart, which is referred to in its templates.
+type param struct {
+	// key is the value's key in the chart's values, e.g. "pachd.replicas"
+	key string
+	// placeholder stands in for the value in objects until they're
+	// written as YAML, when it's replaced by a reference to the value
+	placeholder string
+	// quote is whether the value is a string, which must be quoted in
+	// templates, or a number
+	quote bool
+}
+
+type chart struct {
+	version string
+	values  map[string]interface{}
+	// params are the chart's values, by key
+	params map[string]*param
+}
+
+// template returns the template of object, as YAML.
+func (c *chart) template(object map[string]interface{}) ([]byte, error) {
+	c.parameterize(object)
+	data, err := json.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+	template, err := yaml.JSONToYAML(data)
+	if err != nil {
+		return nil, err
+	}
+	result := string(template)
+	for _, p := range c.params {
+		ref := fmt.Sprintf("{{ .Values.%s }}", p.key)
+		if p.quote {
+			ref = fmt.Sprintf("{{ .Values.%s | quote }}", p.key)
+		}
+		result = strings.Replace(result, p.placeholder, ref, -1)
+	}
+	result = strings.Replace(result, Namespace, "{{ .Release.Namespace }}", -1)
+	return []byte(result), nil
+}
+
+// parameterize replaces the values in object that the chart parameterizes
+// with their params' placeholders.
+func (c *chart) parameterize(object map[string]interface{}) {
+	kind, _ := object["kind"].(string)
+	name, _ := lookup(object, "metadata", "name").(string)
+	if c.version != "" {
+		c.replaceVersion(object)
+	}
+	switch kind {
+	case "Deployment", "StatefulSet", "ReplicationController", "DaemonSet":
+	default:
+		return
+	}
+	if name == "pachd" {
+		if spec, ok := object["spec"].(map[string]interface{}); ok {
+			if replicas, ok := spec["replicas"]; ok {
+				spec["replicas"] = c.param("pachd.replicas", replicas, false)
+			}
+		}
+	}
+	containers, _ := lookup(object, "spec", "template", "spec", "containers").([]interface{})
+	for _, container := range containers {
+		container, ok := container.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		switch container["name"] {
+		case "pachd", "etcd":
+		default:
+			continue
+		}
+		prefix := container["name"].(string)
+		if requests, ok := lookup(container, "resources", "requests").(map[string]interface{}); ok {
+			for _, resource := range []string{"cpu", "memory"} {
+				if value, ok := requests[resource]; ok {
+					requests[resource] = c.param(prefix+".resources.requests."+resource, value, true)
+				}
+			}
+		}
+		if prefix != "pachd" {
+			continue
+		}
+		env, _ := container["env"].([]interface{})
+		for _, e := range env {
+			if e, ok := e.(map[string]interface{}); ok && e["name"] == "LOG_LEVEL" {
+				if value, ok := e["value"]; ok {
+					e["value"] = c.param("pachd.logLevel", value, true)
+				}
+			}
+		}
+	}
+}
+
+// replaceVersion replaces the versions of Pachyderm's images (e.g. of
+// "pachyderm/pachd:1.7.0") in object, including in env vars that name them,
+// with the version param's placeholder.
+func (c *chart) replaceVersion(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for k, v := range value {
+			value[k] = c.replaceVersion(v)
+		}
+	case []interface{}:
+		for i, v := range value {
+			value[i] = c.replaceVersion(v)
+		}
+	case string:
+		suffix := ":" + c.version
+		if strings.HasSuffix(value, suffix) && strings.Contains(value, "pachyderm/") {
+			p := c.param("version", c.version, false)
+			return strings.TrimSuffix(value, suffix) + ":" + p
+		}
+	}
+	return value
+}
+
+// param returns the placeholder of the param key, whose default is value,
+// adding it if it's new.
+func (c *chart) param(key string, value interface{}, quote bool) string {
+	p, ok := c.params[key]
+	if !ok {
+		p = &param{
+			key:         key,
+			placeholder: fmt.Sprintf("HELM_VALUE_%d_", len(c.params)),
+			quote:       quote,
+		}
+		c.params[key] = p
+		setValue(c.values, strings.Split(key, "."), value)
+	}
+	return p.placeholder
+}
+
+func setValue(values map[string]interface{}, path []string, value interface{}) {
+	for _, k := range path[:len(path)-1] {
+		child, ok := values[k].(map[string]interface{})
+		if !ok {
+			child = make(map[string]interface{})
+			values[k] = child
+		}
+		values = child
+	}
+	values[path[len(path)-1]] = value
+}
+
+// lookup returns the value at path in object, or nil if there isn't one.
+func lookup(object map[string]interface{}, path ...string) interface{} {
+	var value interface{} = object
+	for _, k := range path {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = m[k]
+	}
+	return value
+}
+
+// writeArchive writes files, under the chart's directory, to w as a gzipped
+// tar archive.
+func writeArchive(w io.Writer, files map[string][]byte) error {
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	now := time.Now()
+	for _, name := range names {
+		if err := tw.WriteHeader(&tar.Header{
+			Name:    chartName + "/" + name,
+			Mode:    0644,
+			Size:    int64(len(files[name])),
+			ModTime: now,
+		}); err != nil {
+			return err
+		}
+		if _, err := tw.Write(files[name]); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
@@ -0,0 +1,134 @@
+package helm
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+)
+
+const manifest = `{
+  "kind": "ClusterRoleBinding",
+  "metadata": {"name": "pachyderm-helm-release-namespace"},
+  "subjects": [{"kind": "ServiceAccount", "name": "pachyderm", "namespace": "helm-release-namespace"}]
+}
+{
+  "kind": "Deployment",
+  "metadata": {"name": "pachd", "namespace": "helm-release-namespace"},
+  "spec": {
+    "replicas": 2,
+    "template": {
+      "spec": {
+        "containers": [{
+          "name": "pachd",
+          "image": "pachyderm/pachd:1.7.0",
+          "env": [
+            {"name": "LOG_LEVEL", "value": "info"},
+            {"name": "WORKER_IMAGE", "value": "pachyderm/worker:1.7.0"}
+          ],
+          "resources": {"requests": {"cpu": "1", "memory": "2G"}}
+        }]
+      }
+    }
+  }
+}
+{
+  "kind": "StatefulSet",
+  "metadata": {"name": "etcd", "namespace": "helm-release-namespace"},
+  "spec": {
+    "replicas": 3,
+    "template": {
+      "spec": {
+        "containers": [{
+          "name": "etcd",
+          "image": "quay.io/coreos/etcd:v3.1.4",
+          "resources": {"requests": {"cpu": "1", "memory": "2G"}}
+        }]
+      }
+    }
+  }
+}
+`
+
+func readChart(t *testing.T, r io.Reader) map[string]string {
+	gr, err := gzip.NewReader(r)
+	require.NoError(t, err)
+	tr := tar.NewReader(gr)
+	files := make(map[string]string)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		data, err := ioutil.ReadAll(tr)
+		require.NoError(t, err)
+		files[header.Name] = string(data)
+	}
+	return files
+}
+
+func TestWriteChart(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.NoError(t, WriteChart(buf, []byte(manifest), "1.7.0"))
+	files := readChart(t, buf)
+	require.Equal(t, 5, len(files))
+
+	require.True(t, strings.Contains(files["pachyderm/Chart.yaml"], "version: 1.7.0\n"))
+	require.Equal(t, `# The defaults are the values that 'pachctl deploy' generated the chart with.
+etcd:
+  resources:
+    requests:
+      cpu: "1"
+      memory: 2G
+pachd:
+  logLevel: info
+  replicas: 2
+  resources:
+    requests:
+      cpu: "1"
+      memory: 2G
+version: 1.7.0
+`, files["pachyderm/values.yaml"])
+
+	pachd := files["pachyderm/templates/deployment-pachd.yaml"]
+	for _, ref := range []string{
+		"namespace: {{ .Release.Namespace }}\n",
+		"replicas: {{ .Values.pachd.replicas }}\n",
+		"image: pachyderm/pachd:{{ .Values.version }}\n",
+		"value: {{ .Values.pachd.logLevel | quote }}\n",
+		"value: pachyderm/worker:{{ .Values.version }}\n",
+		"cpu: {{ .Values.pachd.resources.requests.cpu | quote }}\n",
+		"memory: {{ .Values.pachd.resources.requests.memory | quote }}\n",
+	} {
+		require.True(t, strings.Contains(pachd, ref), "%q isn't in:\n%s", ref, pachd)
+	}
+
+	// etcd's replicas go with its members' URLs, so they aren't a value,
+	// nor is the version of its image, which isn't Pachyderm's
+	etcd := files["pachyderm/templates/statefulset-etcd.yaml"]
+	for _, ref := range []string{
+		"replicas: 3\n",
+		"image: quay.io/coreos/etcd:v3.1.4\n",
+		"cpu: {{ .Values.etcd.resources.requests.cpu | quote }}\n",
+	} {
+		require.True(t, strings.Contains(etcd, ref), "%q isn't in:\n%s", ref, etcd)
+	}
+
+	binding := files["pachyderm/templates/clusterrolebinding-pachyderm-release.yaml"]
+	require.True(t, strings.Contains(binding, "name: pachyderm-{{ .Release.Namespace }}\n"), binding)
+	require.False(t, strings.Contains(binding, Namespace), binding)
+}
+
+func TestWriteChartDevVersion(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.NoError(t, WriteChart(buf, []byte(manifest), "local"))
+	files := readChart(t, buf)
+	require.True(t, strings.Contains(files["pachyderm/Chart.yaml"], "version: 0.0.0-local\n"))
+	require.True(t, strings.Contains(files["pachyderm/Chart.yaml"], "appVersion: \"local\"\n"))
+}
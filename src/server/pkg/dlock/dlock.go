@@ -0,0 +1,126 @@
+// Package dlock implements locks in etcd, which pachds use to make sure that
+// only one of them does a given piece of work (such as running a pipeline's
+// master) at a time.
+package dlock
+
+import (
+	"fmt"
+
+	etcd "github.com/coreos/etcd/clientv3"
+	"go.pedge.io/lion/proto"
+	"golang.org/x/net/context"
+)
+
+// TTL is the number of seconds that a lock outlives the pachd that holds it,
+// if that pachd dies or loses its connection to etcd without unlocking it.
+const TTL = 15
+
+// DLock is a lock in etcd. It's held through a lease, which is kept alive for
+// as long as the lock is held, so that the lock of a pachd which has died is
+// released once the lease expires.
+type DLock interface {
+	// Lock blocks until the lock is acquired, or ctx is done. It returns a
+	// context which is cancelled if the lock is lost, i.e. if the lease
+	// through which it's held expires, and which should be used for all of
+	// the work that the lock protects.
+	Lock(ctx context.Context) (context.Context, error)
+	// Unlock releases the lock.
+	Unlock(ctx context.Context) error
+}
+
+type etcdLock struct {
+	client *etcd.Client
+	key    string
+
+	leaseID etcd.LeaseID
+	cancel  context.CancelFunc
+}
+
+// NewDLock returns a DLock, which is held by writing key.
+func NewDLock(client *etcd.Client, key string) DLock {
+	return &etcdLock{
+		client: client,
+		key:    key,
+	}
+}
+
+func (l *etcdLock) Lock(ctx context.Context) (context.Context, error) {
+	resp, err := l.client.Grant(ctx, TTL)
+	if err != nil {
+		return nil, err
+	}
+	leaseID := resp.ID
+	// lockCtx is cancelled when the lock is released, which stops the
+	// lease's keep alives
+	lockCtx, cancel := context.WithCancel(ctx)
+	keepAlives, err := l.client.KeepAlive(lockCtx, leaseID)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	for {
+		txnResp, err := l.client.Txn(ctx).
+			If(etcd.Compare(etcd.CreateRevision(l.key), "=", 0)).
+			Then(etcd.OpPut(l.key, "", etcd.WithLease(leaseID))).
+			Commit()
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		if txnResp.Succeeded {
+			break
+		}
+		// Someone else holds the lock; wait for it to be released
+		if err := l.waitForDelete(ctx, txnResp.Header.Revision); err != nil {
+			cancel()
+			return nil, err
+		}
+	}
+	go func() {
+		// The keep alive channel is closed when lockCtx is done, or when
+		// the lease can't be kept alive any more, in which case the lock is
+		// lost
+		for range keepAlives {
+		}
+		select {
+		case <-lockCtx.Done():
+		default:
+			protolion.Errorf("lost the lock %s", l.key)
+		}
+		cancel()
+	}()
+	l.leaseID = leaseID
+	l.cancel = cancel
+	return lockCtx, nil
+}
+
+// waitForDelete blocks until l.key is deleted after revision.
+func (l *etcdLock) waitForDelete(ctx context.Context, revision int64) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for resp := range l.client.Watch(ctx, l.key, etcd.WithRev(revision+1)) {
+		if err := resp.Err(); err != nil {
+			return err
+		}
+		for _, event := range resp.Events {
+			if event.Type == etcd.EventTypeDelete {
+				return nil
+			}
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("watch of %s closed unexpectedly", l.key)
+}
+
+func (l *etcdLock) Unlock(ctx context.Context) error {
+	if l.cancel == nil {
+		return fmt.Errorf("%s is not locked", l.key)
+	}
+	l.cancel()
+	l.cancel = nil
+	// Revoking the lease deletes the key, which releases the lock
+	_, err := l.client.Revoke(ctx, l.leaseID)
+	return err
+}
@@ -0,0 +1,71 @@
+package dlock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	"github.com/pachyderm/pachyderm/src/client/pkg/uuid"
+
+	etcd "github.com/coreos/etcd/clientv3"
+	"golang.org/x/net/context"
+)
+
+func TestLock(t *testing.T) {
+	etcdClient, err := getEtcdClient()
+	require.NoError(t, err)
+	key := uuid.NewWithoutDashes()
+
+	lock1 := NewDLock(etcdClient, key)
+	ctx1, err := lock1.Lock(context.Background())
+	require.NoError(t, err)
+
+	// the second lock blocks until the first is released
+	locked := make(chan context.Context)
+	lock2 := NewDLock(etcdClient, key)
+	go func() {
+		ctx2, err := lock2.Lock(context.Background())
+		require.NoError(t, err)
+		locked <- ctx2
+	}()
+	select {
+	case <-locked:
+		t.Fatal("acquired a lock that's already held")
+	case <-time.After(time.Second):
+	}
+
+	require.NoError(t, lock1.Unlock(context.Background()))
+	require.YesError(t, ctx1.Err())
+	var ctx2 context.Context
+	select {
+	case ctx2 = <-locked:
+	case <-time.After(10 * time.Second):
+		t.Fatal("lock wasn't acquired after it was released")
+	}
+	require.NoError(t, ctx2.Err())
+	require.NoError(t, lock2.Unlock(context.Background()))
+}
+
+func TestLockCancelled(t *testing.T) {
+	etcdClient, err := getEtcdClient()
+	require.NoError(t, err)
+	key := uuid.NewWithoutDashes()
+
+	lock1 := NewDLock(etcdClient, key)
+	_, err = lock1.Lock(context.Background())
+	require.NoError(t, err)
+	defer lock1.Unlock(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = NewDLock(etcdClient, key).Lock(ctx)
+	require.YesError(t, err)
+}
+
+func getEtcdClient() (*etcd.Client, error) {
+	return etcd.New(etcd.Config{
+		Endpoints:   []string{"localhost:2379"},
+		DialOptions: client.EtcdDialOptions(),
+	})
+}
@@ -0,0 +1,134 @@
+// Package etcdutil connects pachd and its workers to etcd, which is either
+// the etcd that pachyderm deploys or an existing cluster that pachyderm is
+// deployed against.
+package etcdutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	etcd "github.com/coreos/etcd/clientv3"
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pkg/discovery"
+	"github.com/pachyderm/pachyderm/src/client/pkg/grpcutil"
+)
+
+// TLSDir is where pachd and its workers mount the certificates of an
+// external etcd, see Options.TLSDir.
+const TLSDir = "/etcd-tls"
+
+// Options say how to connect to etcd.
+type Options struct {
+	// Endpoints are the URLs of etcd's members, e.g.
+	// "https://etcd-0.example.com:2379".
+	Endpoints []string
+	// TLSDir is a directory which, if it holds a CA certificate
+	// (grpcutil.TLSCAFile), has the certificate that etcd's is verified
+	// with, and, if it holds a certificate and key (grpcutil.TLSCertFile and
+	// grpcutil.TLSKeyFile), the client certificate that pachd presents to
+	// etcd. TLS is used if it holds a CA certificate, or if any of Endpoints
+	// are https:// URLs (in which case the host's CAs verify etcd).
+	TLSDir string
+	// Username and Password authenticate to etcd, if Username is set.
+	Username string
+	Password string
+}
+
+// Endpoints parses endpoints, a comma-separated list of etcd's endpoints. If
+// it's empty, it returns the endpoint of the etcd that pachyderm deploys,
+// whose service is at host.
+func Endpoints(endpoints string, host string) ([]string, error) {
+	var result []string
+	for _, endpoint := range strings.Split(endpoints, ",") {
+		if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+			result = append(result, endpoint)
+		}
+	}
+	if len(result) > 0 {
+		return result, nil
+	}
+	if host == "" {
+		return nil, fmt.Errorf("neither ETCD_ENDPOINTS nor ETCD_PORT_2379_TCP_ADDR is set")
+	}
+	return []string{"http://" + net.JoinHostPort(host, "2379")}, nil
+}
+
+// TLSConfig returns the TLS config that etcd is connected to with, or nil if
+// it's connected to without TLS.
+func (o *Options) TLSConfig() (*tls.Config, error) {
+	useTLS := false
+	for _, endpoint := range o.Endpoints {
+		if strings.HasPrefix(endpoint, "https://") {
+			useTLS = true
+		}
+	}
+	config := &tls.Config{}
+	if o.TLSDir != "" {
+		caFile := filepath.Join(o.TLSDir, grpcutil.TLSCAFile)
+		ca, err := ioutil.ReadFile(caFile)
+		switch {
+		case err == nil:
+			config.RootCAs = x509.NewCertPool()
+			if !config.RootCAs.AppendCertsFromPEM(ca) {
+				return nil, fmt.Errorf("no certificates found in %s", caFile)
+			}
+			useTLS = true
+		case !os.IsNotExist(err):
+			return nil, err
+		}
+		certFile := filepath.Join(o.TLSDir, grpcutil.TLSCertFile)
+		if _, err := os.Stat(certFile); err == nil {
+			cert, err := tls.LoadX509KeyPair(certFile, filepath.Join(o.TLSDir, grpcutil.TLSKeyFile))
+			if err != nil {
+				return nil, fmt.Errorf("error loading etcd's client certificate from %s: %v", o.TLSDir, err)
+			}
+			config.Certificates = []tls.Certificate{cert}
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	if !useTLS {
+		return nil, nil
+	}
+	return config, nil
+}
+
+// Config returns the config of a client of etcd's v3 API.
+func (o *Options) Config() (etcd.Config, error) {
+	tlsConfig, err := o.TLSConfig()
+	if err != nil {
+		return etcd.Config{}, err
+	}
+	return etcd.Config{
+		Endpoints:   o.Endpoints,
+		DialOptions: client.EtcdDialOptions(),
+		TLS:         tlsConfig,
+		Username:    o.Username,
+		Password:    o.Password,
+	}, nil
+}
+
+// NewClient returns a client of etcd's v3 API.
+func (o *Options) NewClient() (*etcd.Client, error) {
+	config, err := o.Config()
+	if err != nil {
+		return nil, err
+	}
+	return etcd.New(config)
+}
+
+// NewDiscoveryClient returns a client of etcd's v2 API, which pachd's
+// sharder uses.
+func (o *Options) NewDiscoveryClient() (discovery.Client, error) {
+	tlsConfig, err := o.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	return discovery.NewEtcdClientWithAuth(tlsConfig, o.Username, o.Password, o.Endpoints...), nil
+}
@@ -0,0 +1,53 @@
+package etcdutil
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/grpcutil"
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+)
+
+func TestEndpoints(t *testing.T) {
+	endpoints, err := Endpoints("", "10.0.0.1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"http://10.0.0.1:2379"}, endpoints)
+
+	// IPv6 service addresses are bracketed
+	endpoints, err = Endpoints("", "fd00::1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"http://[fd00::1]:2379"}, endpoints)
+
+	endpoints, err = Endpoints("https://etcd-0:2379, https://etcd-1:2379,", "10.0.0.1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"https://etcd-0:2379", "https://etcd-1:2379"}, endpoints)
+
+	_, err = Endpoints("", "")
+	require.YesError(t, err)
+}
+
+func TestTLSConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "etcd-tls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// no CA and no https endpoints: no TLS
+	options := &Options{Endpoints: []string{"http://etcd:2379"}, TLSDir: dir}
+	config, err := options.TLSConfig()
+	require.NoError(t, err)
+	require.True(t, config == nil)
+
+	// https endpoints are verified with the host's CAs
+	options.Endpoints = []string{"https://etcd:2379"}
+	config, err = options.TLSConfig()
+	require.NoError(t, err)
+	require.True(t, config != nil && config.RootCAs == nil)
+
+	// a CA that isn't a certificate is an error
+	caFile := filepath.Join(dir, grpcutil.TLSCAFile)
+	require.NoError(t, ioutil.WriteFile(caFile, []byte("not a certificate"), 0644))
+	_, err = options.TLSConfig()
+	require.YesError(t, err)
+}
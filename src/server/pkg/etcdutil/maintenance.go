@@ -0,0 +1,198 @@
+package etcdutil
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	etcd "github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/etcdserver/api/v3rpc/rpctypes"
+	"github.com/coreos/etcd/etcdserver/etcdserverpb"
+	"github.com/pachyderm/pachyderm/src/client/admin"
+	"go.pedge.io/lion/proto"
+	"golang.org/x/net/context"
+)
+
+// statusTimeout and defragmentTimeout bound requests to one of etcd's
+// members, so that a member that's down doesn't hold up the others.
+const (
+	statusTimeout     = 10 * time.Second
+	defragmentTimeout = 5 * time.Minute
+)
+
+// MaintenanceOptions configure Maintain. An interval of 0 turns that part of
+// the maintenance off.
+type MaintenanceOptions struct {
+	// CompactionInterval is how often etcd's history is compacted. Each
+	// compaction keeps the history of the last interval, so that watches
+	// which have fallen behind by less than that can catch up.
+	CompactionInterval time.Duration
+	// DefragmentInterval is how often each of etcd's members is
+	// defragmented, which gives the space freed by compactions back to the
+	// filesystem. Members are defragmented one at a time, as each stops
+	// serving requests while it's defragmented.
+	DefragmentInterval time.Duration
+}
+
+// Maintain compacts and defragments etcd periodically, until ctx is done.
+// Errors are logged, and the maintenance is retried at the next interval.
+func Maintain(ctx context.Context, config etcd.Config, options MaintenanceOptions) {
+	var compactions, defragmentations <-chan time.Time
+	if options.CompactionInterval > 0 {
+		ticker := time.NewTicker(options.CompactionInterval)
+		defer ticker.Stop()
+		compactions = ticker.C
+	}
+	if options.DefragmentInterval > 0 {
+		ticker := time.NewTicker(options.DefragmentInterval)
+		defer ticker.Stop()
+		defragmentations = ticker.C
+	}
+	// revision is the revision that etcd was at at the last compaction,
+	// which the next compaction compacts up to
+	var revision int64
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-compactions:
+			next, err := compact(ctx, config, revision)
+			if err != nil {
+				protolion.Errorf("error compacting etcd: %v", err)
+				continue
+			}
+			revision = next
+		case <-defragmentations:
+			if err := Defragment(ctx, config); err != nil {
+				protolion.Errorf("error defragmenting etcd: %v", err)
+			}
+		}
+	}
+}
+
+// compact compacts etcd's history up to revision, if it's set, and returns
+// the revision that etcd is now at.
+func compact(ctx context.Context, config etcd.Config, revision int64) (int64, error) {
+	client, err := etcd.New(config)
+	if err != nil {
+		return 0, err
+	}
+	defer client.Close()
+	// any read returns etcd's current revision
+	resp, err := client.Get(ctx, "\x00", etcd.WithCountOnly())
+	if err != nil {
+		return 0, err
+	}
+	if revision > 0 {
+		if _, err := client.Compact(ctx, revision, etcd.WithCompactPhysical()); err != nil && err != rpctypes.ErrCompacted {
+			return 0, err
+		}
+		protolion.Infof("compacted etcd's history up to revision %d", revision)
+	}
+	return resp.Header.Revision, nil
+}
+
+// Defragment defragments each of etcd's members in turn. It stops at the
+// first member that fails, as the ones after it are likely to fail too.
+func Defragment(ctx context.Context, config etcd.Config) error {
+	members, err := members(ctx, config)
+	if err != nil {
+		return err
+	}
+	for _, member := range members {
+		if member.endpoint == "" {
+			continue
+		}
+		if err := withMember(ctx, config, member.endpoint, defragmentTimeout, func(ctx context.Context, client *etcd.Client) error {
+			_, err := client.Defragment(ctx, member.endpoint)
+			return err
+		}); err != nil {
+			return fmt.Errorf("error defragmenting %s: %v", member.Name, err)
+		}
+		protolion.Infof("defragmented etcd member %s", member.Name)
+	}
+	return nil
+}
+
+// Status returns the health of each of etcd's members. A member that can't
+// be reached is reported as unhealthy, rather than failing the request.
+func Status(ctx context.Context, config etcd.Config) ([]*admin.EtcdMemberStatus, error) {
+	members, err := members(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	var result []*admin.EtcdMemberStatus
+	for _, member := range members {
+		status := &admin.EtcdMemberStatus{
+			Name:     member.Name,
+			Endpoint: member.endpoint,
+		}
+		result = append(result, status)
+		if member.endpoint == "" {
+			status.Error = "member hasn't started"
+			continue
+		}
+		if err := withMember(ctx, config, member.endpoint, statusTimeout, func(ctx context.Context, client *etcd.Client) error {
+			resp, err := client.Status(ctx, member.endpoint)
+			if err != nil {
+				return err
+			}
+			status.Healthy = true
+			status.Leader = resp.Leader == member.ID
+			status.DbSize = resp.DbSize
+			return nil
+		}); err != nil {
+			status.Error = err.Error()
+		}
+	}
+	return result, nil
+}
+
+// member is one of etcd's members, with the endpoint that it's reached at,
+// which is empty if it hasn't started.
+type member struct {
+	*etcdserverpb.Member
+	endpoint string
+}
+
+func members(ctx context.Context, config etcd.Config) ([]member, error) {
+	client, err := etcd.New(config)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	resp, err := client.MemberList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var result []member
+	for _, m := range resp.Members {
+		var endpoint string
+		if len(m.ClientURLs) > 0 {
+			endpoint = m.ClientURLs[0]
+		}
+		// A lone member that advertises its listening address (as the etcd
+		// that pachyderm deploys with one member does, 0.0.0.0 or [::]) can
+		// only be reached at the endpoint that pachd already uses.
+		if u, err := url.Parse(endpoint); err == nil && u.Host != "" && net.ParseIP(u.Hostname()).IsUnspecified() && len(resp.Members) == 1 {
+			endpoint = config.Endpoints[0]
+		}
+		result = append(result, member{Member: m, endpoint: endpoint})
+	}
+	return result, nil
+}
+
+// withMember calls f with a client of just the member at endpoint, as
+// etcd's maintenance requests act on the member that they're sent to.
+func withMember(ctx context.Context, config etcd.Config, endpoint string, timeout time.Duration, f func(ctx context.Context, client *etcd.Client) error) error {
+	config.Endpoints = []string{endpoint}
+	client, err := etcd.New(config)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return f(ctx, client)
+}
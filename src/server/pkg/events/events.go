@@ -0,0 +1,116 @@
+// Package events publishes structured messages about what happens in
+// pachyderm, such as commits being finished and jobs finishing, to Amazon
+// SNS, Google Cloud Pub/Sub or webhooks, so that downstream systems can react
+// to them rather than poll ListCommit.
+package events
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+const (
+	// CommitFinished is the type of the events of commits being finished on
+	// a branch.
+	CommitFinished = "commit.finished"
+	// JobFinished is the type of the events of jobs reaching a terminal
+	// state, i.e. succeeding, failing or being stopped.
+	JobFinished = "job.finished"
+)
+
+// httpTimeout bounds each request that publishes an event.
+const httpTimeout = 30 * time.Second
+
+// Event is a message that's published to a sink, as JSON.
+type Event struct {
+	// ID identifies what the event is about, e.g. "commit/images@<commit>"
+	// or "job/<job>". Events are published at least once, so consumers
+	// should use it to ignore duplicates.
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	// Time is when the commit or job finished, in RFC 3339.
+	Time string `json:"time"`
+
+	Repo   string `json:"repo,omitempty"`
+	Branch string `json:"branch,omitempty"`
+	Commit string `json:"commit,omitempty"`
+	// SizeBytes is the size of a finished commit.
+	SizeBytes uint64 `json:"sizeBytes,omitempty"`
+
+	Job string `json:"job,omitempty"`
+	// Pipeline is the pipeline of a job, if it has one.
+	Pipeline string `json:"pipeline,omitempty"`
+	// State is a job's terminal state, e.g. "JOB_SUCCESS".
+	State string `json:"state,omitempty"`
+	// OutputRepo and OutputCommit are where a job wrote its output.
+	OutputRepo   string `json:"outputRepo,omitempty"`
+	OutputCommit string `json:"outputCommit,omitempty"`
+}
+
+// Sink is somewhere that events are published to.
+type Sink interface {
+	// Publish publishes event. Errors that publishing again won't fix, e.g.
+	// because the sink rejected the event as invalid, are RejectedErrors.
+	Publish(ctx context.Context, event *Event) error
+}
+
+var (
+	snsTopic    = regexp.MustCompile(`^arn:aws(-[a-z]+)*:sns:([a-z0-9-]+):[0-9]{12}:[A-Za-z0-9_.-]+$`)
+	pubsubTopic = regexp.MustCompile(`^projects/([a-z][a-z0-9.:-]*)/topics/([A-Za-z][A-Za-z0-9._~+%-]*)$`)
+)
+
+// NewSink returns the sink that target names, which is either an SNS topic's
+// ARN ("arn:aws:sns:<region>:<account>:<topic>"), a Pub/Sub topic
+// ("projects/<project>/topics/<topic>"), or the http or https URL of a
+// webhook.
+func NewSink(target string) (Sink, error) {
+	httpClient := &http.Client{Timeout: httpTimeout}
+	if match := snsTopic.FindStringSubmatch(target); match != nil {
+		return &snsSink{httpClient: httpClient, topic: target, region: match[2]}, nil
+	}
+	if pubsubTopic.MatchString(target) {
+		return &pubsubSink{topic: target}, nil
+	}
+	u, err := url.Parse(target)
+	if err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" {
+		return &webhookSink{httpClient: httpClient, url: target}, nil
+	}
+	return nil, fmt.Errorf("invalid event sink %q, it must be an SNS topic's ARN (arn:aws:sns:<region>:<account>:<topic>), a Pub/Sub topic (projects/<project>/topics/<topic>) or a webhook's http(s) URL", target)
+}
+
+// RejectedError is the error of an event that a sink rejected, which won't be
+// accepted if it's published again.
+type RejectedError struct {
+	Status string
+	Body   string
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("the event was rejected (%s): %s", e.Status, e.Body)
+}
+
+// IsRejected returns true if err is a RejectedError.
+func IsRejected(err error) bool {
+	_, ok := err.(*RejectedError)
+	return ok
+}
+
+// checkResponse returns an error if resp isn't a success. Client errors,
+// other than being throttled, are RejectedErrors.
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode/100 == 4 && resp.StatusCode != http.StatusTooManyRequests {
+		return &RejectedError{Status: resp.Status, Body: string(body)}
+	}
+	return fmt.Errorf("%s: %s", resp.Status, body)
+}
@@ -0,0 +1,97 @@
+package events
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	"golang.org/x/net/context"
+)
+
+func TestNewSink(t *testing.T) {
+	sink, err := NewSink("arn:aws:sns:us-east-1:123456789012:commits")
+	require.NoError(t, err)
+	require.Equal(t, "us-east-1", sink.(*snsSink).region)
+	require.Equal(t, "https://sns.us-east-1.amazonaws.com/", sink.(*snsSink).endpoint())
+	sink, err = NewSink("arn:aws-cn:sns:cn-north-1:123456789012:commits")
+	require.NoError(t, err)
+	require.Equal(t, "https://sns.cn-north-1.amazonaws.com.cn/", sink.(*snsSink).endpoint())
+
+	sink, err = NewSink("projects/my-project/topics/commits")
+	require.NoError(t, err)
+	_, ok := sink.(*pubsubSink)
+	require.True(t, ok)
+
+	sink, err = NewSink("https://example.com/hooks/pachyderm")
+	require.NoError(t, err)
+	_, ok = sink.(*webhookSink)
+	require.True(t, ok)
+
+	for _, target := range []string{
+		"",
+		"arn:aws:sqs:us-east-1:123456789012:commits",
+		"projects/my-project/subscriptions/commits",
+		"ftp://example.com",
+		"example.com/hooks",
+	} {
+		_, err := NewSink(target)
+		require.YesError(t, err, target)
+	}
+}
+
+func TestSNSPublishBody(t *testing.T) {
+	form, err := url.ParseQuery(snsPublishBody("arn:aws:sns:us-east-1:123456789012:commits", CommitFinished, `{"id":"x"}`))
+	require.NoError(t, err)
+	require.Equal(t, "Publish", form.Get("Action"))
+	require.Equal(t, "arn:aws:sns:us-east-1:123456789012:commits", form.Get("TopicArn"))
+	require.Equal(t, `{"id":"x"}`, form.Get("Message"))
+	require.Equal(t, "type", form.Get("MessageAttributes.entry.1.Name"))
+	require.Equal(t, CommitFinished, form.Get("MessageAttributes.entry.1.Value.StringValue"))
+}
+
+func TestPubSubPublishBody(t *testing.T) {
+	body, err := pubsubPublishBody(&Event{ID: "job/abc", Type: JobFinished, State: "JOB_SUCCESS"})
+	require.NoError(t, err)
+	var request pubsubRequest
+	require.NoError(t, json.Unmarshal(body, &request))
+	require.Equal(t, 1, len(request.Messages))
+	require.Equal(t, JobFinished, request.Messages[0].Attributes["type"])
+	data, err := base64.StdEncoding.DecodeString(request.Messages[0].Data)
+	require.NoError(t, err)
+	var event Event
+	require.NoError(t, json.Unmarshal(data, &event))
+	require.Equal(t, "job/abc", event.ID)
+	require.Equal(t, "JOB_SUCCESS", event.State)
+}
+
+func TestWebhook(t *testing.T) {
+	status := http.StatusOK
+	var received Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, CommitFinished, r.Header.Get("X-Pachyderm-Event"))
+		data, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(data, &received))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+	sink, err := NewSink(server.URL)
+	require.NoError(t, err)
+	event := &Event{ID: "commit/images@abc", Type: CommitFinished, Repo: "images", Branch: "master", Commit: "abc"}
+	require.NoError(t, sink.Publish(context.Background(), event))
+	require.Equal(t, *event, received)
+
+	// client errors aren't retried, but throttling and server errors are
+	status = http.StatusBadRequest
+	require.True(t, IsRejected(sink.Publish(context.Background(), event)))
+	for _, status = range []int{http.StatusTooManyRequests, http.StatusBadGateway} {
+		err := sink.Publish(context.Background(), event)
+		require.YesError(t, err)
+		require.False(t, IsRejected(err))
+	}
+}
@@ -0,0 +1,90 @@
+package events
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2/google"
+)
+
+// pubsubScope is the OAuth2 scope that publishing to Pub/Sub needs.
+const pubsubScope = "https://www.googleapis.com/auth/pubsub"
+
+// pubsubSink publishes events to a Pub/Sub topic with Pub/Sub's REST API,
+// as pachd's service account, i.e. GOOGLE_APPLICATION_CREDENTIALS', or its
+// node's.
+type pubsubSink struct {
+	topic string
+
+	mu         sync.Mutex
+	httpClient *http.Client
+}
+
+// pubsubRequest is the body of a publish.
+type pubsubRequest struct {
+	Messages []pubsubMessage `json:"messages"`
+}
+
+type pubsubMessage struct {
+	// Data is the base64 of the event's JSON
+	Data       string            `json:"data"`
+	Attributes map[string]string `json:"attributes"`
+}
+
+func (p *pubsubSink) Publish(ctx context.Context, event *Event) error {
+	httpClient, err := p.client()
+	if err != nil {
+		return err
+	}
+	body, err := pubsubPublishBody(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", fmt.Sprintf("https://pubsub.googleapis.com/v1/%s:publish", p.topic), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkResponse(resp)
+}
+
+// client returns the HTTP client that authenticates p's requests, which
+// refreshes its token as it expires.
+func (p *pubsubSink) client() (*http.Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.httpClient == nil {
+		httpClient, err := google.DefaultClient(context.Background(), pubsubScope)
+		if err != nil {
+			return nil, err
+		}
+		httpClient.Timeout = httpTimeout
+		p.httpClient = httpClient
+	}
+	return p.httpClient, nil
+}
+
+// pubsubPublishBody returns the body of a publish of event. Its type is an
+// attribute, so that subscriptions can filter on it.
+func pubsubPublishBody(event *Event) ([]byte, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&pubsubRequest{
+		Messages: []pubsubMessage{{
+			Data:       base64.StdEncoding.EncodeToString(data),
+			Attributes: map[string]string{"type": event.Type},
+		}},
+	})
+}
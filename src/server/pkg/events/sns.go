@@ -0,0 +1,72 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+	"golang.org/x/net/context"
+)
+
+// snsSink publishes events to an SNS topic with SNS's Publish, which is
+// called directly, as the AWS SDK's SNS client isn't vendored. pachd
+// authenticates to AWS as the SDK does by default, e.g. with its node's
+// instance role.
+type snsSink struct {
+	httpClient *http.Client
+	topic      string
+	region     string
+}
+
+func (s *snsSink) Publish(ctx context.Context, event *Event) error {
+	message, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	sess, err := session.NewSession()
+	if err != nil {
+		return err
+	}
+	body := snsPublishBody(s.topic, event.Type, string(message))
+	req, err := http.NewRequest("POST", s.endpoint(), strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	if _, err := v4.NewSigner(sess.Config.Credentials).Sign(req, strings.NewReader(body), "sns", s.region, time.Now()); err != nil {
+		return err
+	}
+	resp, err := s.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkResponse(resp)
+}
+
+func (s *snsSink) endpoint() string {
+	if strings.HasPrefix(s.region, "cn-") {
+		return fmt.Sprintf("https://sns.%s.amazonaws.com.cn/", s.region)
+	}
+	return fmt.Sprintf("https://sns.%s.amazonaws.com/", s.region)
+}
+
+// snsPublishBody returns the form of a Publish of message to topic. The
+// event's type is a message attribute, so that subscriptions can filter on
+// it.
+func snsPublishBody(topic string, eventType string, message string) string {
+	form := url.Values{}
+	form.Set("Action", "Publish")
+	form.Set("Version", "2010-03-31")
+	form.Set("TopicArn", topic)
+	form.Set("Message", message)
+	form.Set("MessageAttributes.entry.1.Name", "type")
+	form.Set("MessageAttributes.entry.1.Value.DataType", "String")
+	form.Set("MessageAttributes.entry.1.Value.StringValue", eventType)
+	return form.Encode()
+}
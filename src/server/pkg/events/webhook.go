@@ -0,0 +1,35 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"golang.org/x/net/context"
+)
+
+// webhookSink POSTs each event's JSON to a URL. The event's type is also in
+// the X-Pachyderm-Event header.
+type webhookSink struct {
+	httpClient *http.Client
+	url        string
+}
+
+func (w *webhookSink) Publish(ctx context.Context, event *Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", w.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Pachyderm-Event", event.Type)
+	resp, err := w.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkResponse(resp)
+}
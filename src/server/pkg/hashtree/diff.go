@@ -0,0 +1,90 @@
+package hashtree
+
+// Diff calls 'f', in no particular order, with the path of each file that
+// differs between 'newTree' and 'oldTree', along with the file's node in each
+// of them, which is nil if the file isn't in that tree. Directories whose
+// hashes are the same in both trees are skipped, so only the parts of stored
+// trees that differ are read.
+func Diff(newTree HashTree, oldTree HashTree, f func(path string, newNode *NodeProto, oldNode *NodeProto) error) error {
+	newNode, err := getIfExists(newTree, "/")
+	if err != nil {
+		return err
+	}
+	oldNode, err := getIfExists(oldTree, "/")
+	if err != nil {
+		return err
+	}
+	return diff(newTree, oldTree, "/", newNode, oldNode, f)
+}
+
+func diff(newTree HashTree, oldTree HashTree, path string, newNode *NodeProto, oldNode *NodeProto, f func(string, *NodeProto, *NodeProto) error) error {
+	if newNode != nil && oldNode != nil && newNode.nodetype() == oldNode.nodetype() &&
+		string(newNode.Hash) == string(oldNode.Hash) {
+		return nil
+	}
+	// a file that replaced a directory, or the reverse, is reported as a
+	// new or deleted file
+	newFile, oldFile := fileOrNil(newNode), fileOrNil(oldNode)
+	if newFile != nil || oldFile != nil {
+		if err := f(path, newFile, oldFile); err != nil {
+			return err
+		}
+	}
+	newChildren, err := children(newTree, path, newNode)
+	if err != nil {
+		return err
+	}
+	oldChildren, err := children(oldTree, path, oldNode)
+	if err != nil {
+		return err
+	}
+	for name, newChild := range newChildren {
+		if err := diff(newTree, oldTree, join(path, name), newChild, oldChildren[name], f); err != nil {
+			return err
+		}
+	}
+	for name, oldChild := range oldChildren {
+		if _, ok := newChildren[name]; !ok {
+			if err := diff(newTree, oldTree, join(path, name), nil, oldChild, f); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// getIfExists returns the node at 'path' in 'tree', or nil if there isn't one.
+func getIfExists(tree HashTree, path string) (*NodeProto, error) {
+	node, err := tree.Get(path)
+	if err != nil {
+		if Code(err) == PathNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return node, nil
+}
+
+func fileOrNil(node *NodeProto) *NodeProto {
+	if node != nil && node.nodetype() == file {
+		return node
+	}
+	return nil
+}
+
+// children returns the children of 'node', the node at 'path' in 'tree', by
+// name, if it's a directory.
+func children(tree HashTree, path string, node *NodeProto) (map[string]*NodeProto, error) {
+	if node == nil || node.nodetype() != directory {
+		return nil, nil
+	}
+	nodes, err := tree.List(path)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]*NodeProto, len(nodes))
+	for _, node := range nodes {
+		result[node.Name] = node
+	}
+	return result, nil
+}
@@ -4,6 +4,8 @@ import (
 	"crypto/sha256"
 	"fmt"
 	pathlib "path"
+	"sort"
+	"strings"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/pachyderm/pachyderm/src/client/pfs"
@@ -75,6 +77,10 @@ func Deserialize(serialized []byte) (HashTree, error) {
 		return nil, errorf(Unsupported, "unsupported HashTreeProto "+
 			"version %d", h.Version)
 	}
+	if len(h.Subtrees) > 0 {
+		return nil, errorf(Unsupported, "HashTreeProto is stored in chunks, "+
+			"it must be read with Load")
+	}
 	return h, nil
 }
 
@@ -109,6 +115,14 @@ func (h *HashTreeProto) Get(path string) (*NodeProto, error) {
 }
 
 func list(fs map[string]*NodeProto, path string) ([]*NodeProto, error) {
+	return listPage(fs, path, "", 0)
+}
+
+// listPage lists the children of the directory at 'path' whose names sort
+// after 'from', up to 'number' of them (or all of them, if 'number' is 0).
+// Children are kept sorted, so the first child after 'from' is found with a
+// binary search, and only the page that's returned is looked up in 'fs'.
+func listPage(fs map[string]*NodeProto, path string, from string, number int64) ([]*NodeProto, error) {
 	path = clean(path)
 
 	node, err := get(fs, path)
@@ -120,9 +134,20 @@ func list(fs map[string]*NodeProto, path string) ([]*NodeProto, error) {
 		return nil, errorf(PathConflict, "the file at \"%s\" is not a directory",
 			path)
 	}
+	children := d.Children
+	if from != "" {
+		i := sort.SearchStrings(children, from)
+		if i < len(children) && children[i] == from {
+			i++
+		}
+		children = children[i:]
+	}
+	if number > 0 && int64(len(children)) > number {
+		children = children[:number]
+	}
 	var ok bool
-	result := make([]*NodeProto, len(d.Children))
-	for i, child := range d.Children {
+	result := make([]*NodeProto, len(children))
+	for i, child := range children {
 		result[i], ok = fs[join(path, child)]
 		if !ok {
 			return nil, errorf(Internal, "could not find node for the child \"%s\" "+
@@ -138,6 +163,11 @@ func (h *HashTreeProto) List(path string) ([]*NodeProto, error) {
 	return list(h.Fs, path)
 }
 
+// ListPage implements HashTree.ListPage
+func (h *HashTreeProto) ListPage(path string, from string, number int64) ([]*NodeProto, error) {
+	return listPage(h.Fs, path, from, number)
+}
+
 func glob(fs map[string]*NodeProto, pattern string) ([]*NodeProto, error) {
 	// "*" should be an allowed pattern, but our paths always start with "/", so
 	// modify the pattern to fit our path structure.
@@ -159,11 +189,14 @@ func glob(fs map[string]*NodeProto, pattern string) ([]*NodeProto, error) {
 			res = append(res, nodeCopy)
 		}
 	}
+	// fs is a map, so sort the results to make them (and the datums that
+	// pipelines create from them) deterministic
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
 	return res, nil
 }
 
-// Glob returns a list of files and directories that match 'pattern'.
-// The nodes returned have their 'Name' field set to their full paths.
+// Glob returns a list of files and directories that match 'pattern', sorted
+// by path. The nodes returned have their 'Name' field set to their full paths.
 func (h *HashTreeProto) Glob(pattern string) ([]*NodeProto, error) {
 	return glob(h.Fs, pattern)
 }
@@ -208,6 +241,18 @@ type hashtree struct {
 	// changed maps a path P to 'true' if P or one of its children has been
 	// modified in 'fs', and its hash needs to be updated.
 	changed map[string]bool
+
+	// store is where the chunks of a tree that was read with Load are, and
+	// subtrees maps each directory whose descendants haven't been read from
+	// 'store' yet to the chunk they're in (see HashTreeProto.Subtrees). Nodes
+	// are only added to 'fs' once they're needed.
+	store    ObjectStore
+	subtrees map[string]*pfs.Object
+
+	// chunks maps each directory whose descendants were read from a chunk in
+	// 'store', and haven't been modified since, to that chunk, so that they
+	// don't need to be stored again.
+	chunks map[string]*pfs.Object
 }
 
 // Open returns the hashtree since it's already an OpenHashTree
@@ -217,18 +262,35 @@ func (h *hashtree) Open() OpenHashTree {
 
 // Get retrieves the contents of a file.
 func (h *hashtree) Get(path string) (*NodeProto, error) {
+	if err := h.loadParent(path); err != nil {
+		return nil, err
+	}
 	return get(h.fs, path)
 }
 
 // List retrieves the list of files and subdirectories of the directory at
 // 'path'.
 func (h *hashtree) List(path string) ([]*NodeProto, error) {
+	if err := h.load(path); err != nil {
+		return nil, err
+	}
 	return list(h.fs, path)
 }
 
-// Glob returns a list of files and directories that match 'pattern'.
-// The nodes returned have their 'Name' field set to their full paths.
+// ListPage implements HashTree.ListPage
+func (h *hashtree) ListPage(path string, from string, number int64) ([]*NodeProto, error) {
+	if err := h.load(path); err != nil {
+		return nil, err
+	}
+	return listPage(h.fs, path, from, number)
+}
+
+// Glob returns a list of files and directories that match 'pattern', sorted
+// by path. The nodes returned have their 'Name' field set to their full paths.
 func (h *hashtree) Glob(pattern string) ([]*NodeProto, error) {
+	if err := h.loadGlob(pattern); err != nil {
+		return nil, err
+	}
 	return glob(h.fs, pattern)
 }
 
@@ -239,6 +301,9 @@ func (h *hashtree) Size() int64 {
 
 // Walk implements HashTree.Walk
 func (h *hashtree) Walk(f func(string, *NodeProto) error) error {
+	if err := h.loadAll(); err != nil {
+		return err
+	}
 	return walk(h.fs, f)
 }
 
@@ -398,21 +463,52 @@ func (h *hashtree) removeFromMap(path string) error {
 // Finish makes a deep copy of the OpenHashTree, updates all of the hashes in
 // the copy, and returns the copy
 func (h *hashtree) Finish() (HashTree, error) {
+	// The chunks with changed nodes have to be stored again
+	for path := range h.chunks {
+		if h.changed[path] {
+			delete(h.chunks, path)
+		}
+	}
 	if err := h.canonicalize(""); err != nil {
 		return nil, err
 	}
 	// Create a shallow copy of 'h'
 	innerp := &HashTreeProto{
-		Fs:      h.fs,
-		Version: 1,
+		Fs:       h.fs,
+		Subtrees: h.subtrees,
+		Version:  1,
 	}
 	// convert the shallow copy of 'h' to a deep copy with proto.Clone()
-	return proto.Clone(innerp).(*HashTreeProto), nil
+	result := proto.Clone(innerp).(*HashTreeProto)
+	if len(result.Subtrees) == 0 && len(h.chunks) == 0 {
+		return result, nil
+	}
+	// Some of the tree hasn't been read from the store, the copy reads it
+	// from there as it's needed
+	chunks := make(map[string]*pfs.Object, len(h.chunks))
+	for path, object := range h.chunks {
+		chunks[path] = object
+	}
+	if result.Subtrees == nil {
+		result.Subtrees = make(map[string]*pfs.Object)
+	}
+	return &storedTree{
+		h: &hashtree{
+			fs:       result.Fs,
+			changed:  make(map[string]bool),
+			store:    h.store,
+			subtrees: result.Subtrees,
+			chunks:   chunks,
+		},
+	}, nil
 }
 
 // PutFile appends data to a file (and creates the file if it doesn't exist).
 func (h *hashtree) PutFile(path string, objects []*pfs.Object, size int64) error {
 	path = clean(path)
+	if err := h.loadParent(path); err != nil {
+		return err
+	}
 
 	// Detect any path conflicts before modifying 'h'
 	if err := h.visit(path, nop); err != nil {
@@ -456,9 +552,38 @@ func (h *hashtree) PutFile(path string, objects []*pfs.Object, size int64) error
 	return nil
 }
 
+// PutProvenance records that the file at 'path' was written by the datums in
+// 'provenance'.
+func (h *hashtree) PutProvenance(path string, provenance ...*pfs.FileProvenance) error {
+	path = clean(path)
+	if err := h.loadParent(path); err != nil {
+		return err
+	}
+	node, ok := h.fs[path]
+	if !ok {
+		return errorf(PathNotFound, "no file at \"%s\"", path)
+	}
+	if node.nodetype() != file {
+		return errorf(PathConflict, "could not put provenance at \"%s\"; a "+
+			"node of type %s is there", path, node.nodetype().tostring())
+	}
+	node.FileNode.Provenance = append(node.FileNode.Provenance, provenance...)
+
+	// Mark nodes as 'changed' back to root, so that the chunks they're in are
+	// stored again
+	h.changed[path] = true
+	return h.visit(path, func(node *NodeProto, parent, child string) error {
+		h.changed[parent] = true
+		return nil
+	})
+}
+
 // PutDir creates a directory (or does nothing if one exists).
 func (h *hashtree) PutDir(path string) error {
 	path = clean(path)
+	if err := h.loadParent(path); err != nil {
+		return err
+	}
 
 	// Detect any path conflicts before modifying 'h'
 	if err := h.visit(path, nop); err != nil {
@@ -501,6 +626,9 @@ func (h *hashtree) PutDir(path string) error {
 // DeleteFile deletes a regular file or directory (along with its children).
 func (h *hashtree) DeleteFile(path string) error {
 	path = clean(path)
+	if err := h.loadParent(path); err != nil {
+		return err
+	}
 
 	// Remove 'path' and all nodes underneath it from h.fs
 	node, ok := h.fs[path]
@@ -508,6 +636,17 @@ func (h *hashtree) DeleteFile(path string) error {
 		return errorf(PathNotFound, "no file at \"%s\"", path)
 	}
 	h.removeFromMap(path) // Deletes children recursively
+	// The chunks under 'path' that haven't been loaded are dropped as well
+	for subtree := range h.subtrees {
+		if subtree == path || strings.HasPrefix(subtree, path+"/") {
+			delete(h.subtrees, subtree)
+		}
+	}
+	for chunk := range h.chunks {
+		if chunk == path || strings.HasPrefix(chunk, path+"/") {
+			delete(h.chunks, chunk)
+		}
+	}
 	size := node.SubtreeSize
 
 	// Remove 'path' from its parent directory
@@ -542,6 +681,9 @@ func (h *hashtree) DeleteFile(path string) error {
 // GetOpen retrieves a file.
 func (h *hashtree) GetOpen(path string) (*OpenNode, error) {
 	path = clean(path)
+	if err := h.loadParent(path); err != nil {
+		return nil, err
+	}
 	np, ok := h.fs[path]
 	if !ok {
 		return nil, errorf(PathNotFound, "no node at \"%s\"", path)
@@ -628,6 +770,8 @@ func (h *hashtree) mergeNode(path string, srcs []HashTree) (int64, error) {
 			// done in canonicalize)
 			destNode.FileNode.Objects = append(destNode.FileNode.Objects,
 				n.FileNode.Objects...)
+			destNode.FileNode.Provenance = append(destNode.FileNode.Provenance,
+				n.FileNode.Provenance...)
 			sizeDelta += n.SubtreeSize
 		default:
 			return sizeDelta, errorf(Internal, "malformed node at \"%s\" in source "+
@@ -658,6 +802,11 @@ func (h *hashtree) mergeNode(path string, srcs []HashTree) (int64, error) {
 // - Code(e) is the error code of the first error encountered
 // - e.Error() contains the error messages of the first 10 errors encountered
 func (h *hashtree) Merge(trees ...HashTree) error {
+	// Merging may touch any part of 'h'
+	if err := h.loadAll(); err != nil {
+		return err
+	}
+
 	// Skip empty trees
 	var nonEmptyTrees []HashTree
 	for _, tree := range trees {
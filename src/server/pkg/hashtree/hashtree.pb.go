@@ -37,6 +37,9 @@ type FileNodeProto struct {
 	// Object references an object in the object store which contains the content
 	// of the data.
 	Objects []*pfs.Object `protobuf:"bytes,4,rep,name=objects" json:"objects,omitempty"`
+	// Provenance identifies the datums that wrote to the file, if it was
+	// written by a pipeline.
+	Provenance []*pfs.FileProvenance `protobuf:"bytes,5,rep,name=provenance" json:"provenance,omitempty"`
 }
 
 func (m *FileNodeProto) Reset()                    { *m = FileNodeProto{} }
@@ -51,6 +54,13 @@ func (m *FileNodeProto) GetObjects() []*pfs.Object {
 	return nil
 }
 
+func (m *FileNodeProto) GetProvenance() []*pfs.FileProvenance {
+	if m != nil {
+		return m.Provenance
+	}
+	return nil
+}
+
 // DirectoryNodeProto is a node corresponding to a directory.
 type DirectoryNodeProto struct {
 	// Children of this directory. Note that paths are relative, so if "/foo/bar"
@@ -146,6 +156,12 @@ type HashTreeProto struct {
 	// Note that the key must end in "/" if an only if the value has .dir_node set
 	// (i.e. iff the path points to a directory).
 	Fs map[string]*NodeProto `protobuf:"bytes,2,rep,name=fs" json:"fs,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value"`
+	// Subtrees maps the path of each directory whose descendants are stored in
+	// a separate HashTreeProto, in the object store, to the object they're in.
+	// Commits' trees are stored in chunks like this so that reading or
+	// modifying them only loads the chunks that are touched (see Load and Store
+	// in store.go). Fs still contains the node of each such directory.
+	Subtrees map[string]*pfs.Object `protobuf:"bytes,3,rep,name=subtrees" json:"subtrees,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value"`
 }
 
 func (m *HashTreeProto) Reset()                    { *m = HashTreeProto{} }
@@ -167,6 +183,13 @@ func (m *HashTreeProto) GetFs() map[string]*NodeProto {
 	return nil
 }
 
+func (m *HashTreeProto) GetSubtrees() map[string]*pfs.Object {
+	if m != nil {
+		return m.Subtrees
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*FileNodeProto)(nil), "FileNodeProto")
 	proto.RegisterType((*DirectoryNodeProto)(nil), "DirectoryNodeProto")
@@ -177,27 +200,30 @@ func init() {
 func init() { proto.RegisterFile("server/pkg/hashtree/hashtree.proto", fileDescriptorHashtree) }
 
 var fileDescriptorHashtree = []byte{
-	// 342 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x64, 0x51, 0xd1, 0x4a, 0xe3, 0x40,
-	0x14, 0x65, 0x92, 0x76, 0xd3, 0xdc, 0xb4, 0xcb, 0x32, 0xbb, 0x2c, 0x43, 0x9f, 0x62, 0x40, 0x09,
-	0x08, 0x53, 0xa9, 0x20, 0xe2, 0x9b, 0xa0, 0xc5, 0x27, 0x95, 0xd1, 0xf7, 0x92, 0x26, 0x37, 0x66,
-	0x6c, 0x4c, 0xca, 0x4c, 0x5a, 0x68, 0xbf, 0xc3, 0x3f, 0xf1, 0x07, 0x65, 0xa6, 0x69, 0x4b, 0xf1,
-	0x61, 0xe0, 0x9c, 0x73, 0xcf, 0x85, 0x73, 0xcf, 0x40, 0xa4, 0x51, 0xad, 0x50, 0x8d, 0x16, 0xf3,
-	0xb7, 0x51, 0x91, 0xe8, 0xa2, 0x51, 0x88, 0x7b, 0xc0, 0x17, 0xaa, 0x6e, 0xea, 0xe1, 0xbf, 0xb4,
-	0x94, 0x58, 0x35, 0xa3, 0x45, 0xae, 0xcd, 0xdb, 0xaa, 0xd1, 0x15, 0x0c, 0x26, 0xb2, 0xc4, 0xc7,
-	0x3a, 0xc3, 0x67, 0x23, 0xd0, 0x53, 0xf0, 0xea, 0xd9, 0x3b, 0xa6, 0x8d, 0x66, 0x9d, 0xd0, 0x8d,
-	0x83, 0x71, 0xc0, 0x8d, 0xfb, 0xc9, 0x6a, 0x62, 0x37, 0x8b, 0x2e, 0x80, 0xde, 0x49, 0x85, 0x69,
-	0x53, 0xab, 0xf5, 0x61, 0x79, 0x08, 0xbd, 0xb4, 0x90, 0x65, 0xa6, 0xb0, 0x62, 0x6e, 0xe8, 0xc6,
-	0xbe, 0xd8, 0xf3, 0xe8, 0x8b, 0x80, 0x7f, 0x70, 0x52, 0xe8, 0x54, 0xc9, 0x07, 0x32, 0x12, 0x92,
-	0xd8, 0x17, 0x16, 0x1b, 0xcd, 0x64, 0x66, 0x4e, 0x48, 0xe2, 0xbe, 0xb0, 0x98, 0x9e, 0x40, 0x5f,
-	0x2f, 0x67, 0xe6, 0x8c, 0xa9, 0x96, 0x1b, 0x64, 0x6e, 0x48, 0x62, 0x57, 0x04, 0xad, 0xf6, 0x22,
-	0x37, 0x48, 0xcf, 0xc1, 0xcf, 0x65, 0x89, 0xd3, 0xaa, 0xce, 0x90, 0x75, 0x42, 0x12, 0x07, 0xe3,
-	0xdf, 0xfc, 0xe8, 0x28, 0xd1, 0xcb, 0x5b, 0x4a, 0x39, 0xf4, 0x32, 0xa9, 0xb6, 0xde, 0xae, 0xf5,
-	0xfe, 0xe5, 0x3f, 0x0f, 0x11, 0x5e, 0x26, 0x95, 0x61, 0xd1, 0x27, 0x81, 0xc1, 0x43, 0xa2, 0x8b,
-	0x57, 0x85, 0x6d, 0x72, 0x06, 0xde, 0x0a, 0x95, 0x96, 0x75, 0x65, 0xc3, 0x77, 0xc5, 0x8e, 0xd2,
-	0x33, 0x70, 0x72, 0xcd, 0x1c, 0xdb, 0xda, 0x7f, 0x7e, 0xb4, 0xc5, 0x27, 0xfa, 0xbe, 0x6a, 0xd4,
-	0x5a, 0x38, 0xb9, 0x1e, 0xde, 0x82, 0xd7, 0x52, 0xfa, 0x07, 0xdc, 0x39, 0xae, 0xdb, 0x16, 0x0c,
-	0xa4, 0x21, 0x74, 0x57, 0x49, 0xb9, 0x44, 0xdb, 0x42, 0x30, 0x06, 0x7e, 0x08, 0xb5, 0x1d, 0xdc,
-	0x38, 0xd7, 0x64, 0xf6, 0xcb, 0xfe, 0xde, 0xe5, 0x77, 0x00, 0x00, 0x00, 0xff, 0xff, 0xe7, 0x4f,
-	0x7c, 0xaa, 0xf9, 0x01, 0x00, 0x00,
+	// 389 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x6d, 0x52, 0x4d, 0x4f, 0xc2, 0x40,
+	0x10, 0x4d, 0x29, 0xb5, 0x30, 0x05, 0x63, 0x16, 0x63, 0x1a, 0xe2, 0x01, 0x9a, 0x68, 0x48, 0x4c,
+	0x16, 0x03, 0x17, 0xe2, 0xcd, 0x44, 0x09, 0x27, 0x25, 0x8b, 0x77, 0xc2, 0xc7, 0x22, 0x15, 0xdc,
+	0x92, 0xdd, 0x42, 0x82, 0xbf, 0xc4, 0xff, 0xe1, 0x1f, 0x74, 0x76, 0x5b, 0xa8, 0x55, 0x0f, 0x9b,
+	0xcc, 0xbc, 0x79, 0xf3, 0xf6, 0xcd, 0xec, 0x42, 0xa0, 0xb8, 0xdc, 0x71, 0xd9, 0xde, 0xac, 0x5e,
+	0xdb, 0xcb, 0x89, 0x5a, 0xc6, 0x92, 0xf3, 0x63, 0x40, 0x37, 0x32, 0x8a, 0xa3, 0xfa, 0xf9, 0x6c,
+	0x1d, 0x72, 0x11, 0xb7, 0x37, 0x0b, 0xa5, 0x4f, 0x82, 0x06, 0x2b, 0xa8, 0xf6, 0xc3, 0x35, 0x7f,
+	0x8a, 0xe6, 0x7c, 0xa8, 0x01, 0x72, 0x05, 0x6e, 0x34, 0x7d, 0xe3, 0xb3, 0x58, 0xf9, 0xc5, 0x86,
+	0xdd, 0xf2, 0x3a, 0x1e, 0xd5, 0xec, 0x67, 0x83, 0xb1, 0x43, 0x8d, 0x74, 0x01, 0x50, 0x60, 0xc7,
+	0xc5, 0x44, 0xcc, 0xb8, 0xef, 0x18, 0x66, 0xcd, 0x30, 0xb5, 0xdc, 0xf0, 0x58, 0x62, 0x3f, 0x68,
+	0xc1, 0x2d, 0x90, 0x87, 0x50, 0x62, 0x7f, 0x24, 0xf7, 0xd9, 0x8d, 0x75, 0x28, 0xcd, 0x96, 0xe1,
+	0x7a, 0x2e, 0xb9, 0xf0, 0x6d, 0x14, 0x2a, 0xb3, 0x63, 0x1e, 0x7c, 0x59, 0x50, 0xce, 0x98, 0x04,
+	0x8a, 0x62, 0xf2, 0xce, 0x7d, 0xab, 0x61, 0x21, 0xcb, 0xc4, 0x1a, 0xd3, 0x83, 0xfa, 0x05, 0xc4,
+	0x2a, 0xcc, 0xc4, 0xa4, 0x09, 0x15, 0xb5, 0x9d, 0xea, 0xd9, 0xc7, 0x2a, 0xfc, 0xe0, 0xa8, 0x6a,
+	0xb5, 0x6c, 0xe6, 0xa5, 0xd8, 0x08, 0x21, 0x72, 0x03, 0xe5, 0x05, 0x1a, 0x1d, 0x0b, 0x14, 0xc7,
+	0x41, 0x2d, 0xb4, 0x7f, 0x4a, 0x73, 0x9b, 0x60, 0xa5, 0x45, 0x9a, 0x12, 0x0a, 0xa5, 0x79, 0x28,
+	0x13, 0xae, 0x63, 0xb8, 0x35, 0xfa, 0x77, 0x10, 0xe6, 0x22, 0x49, 0x67, 0xc1, 0x67, 0x01, 0xaa,
+	0x03, 0x34, 0xf2, 0x82, 0xb7, 0x25, 0xce, 0x7d, 0x70, 0xf1, 0x7d, 0x54, 0x18, 0x09, 0x63, 0xde,
+	0x61, 0x87, 0x94, 0x5c, 0x43, 0x61, 0xa1, 0xd0, 0xbd, 0x5e, 0xe0, 0x05, 0xcd, 0x75, 0xd1, 0xbe,
+	0x7a, 0x14, 0xb1, 0xdc, 0x33, 0x64, 0x90, 0x1e, 0x94, 0x52, 0xff, 0xca, 0x6c, 0xc9, 0xeb, 0x5c,
+	0xfe, 0x62, 0x8f, 0xd2, 0x72, 0xd2, 0x73, 0x64, 0xd7, 0xef, 0xc1, 0x4d, 0x85, 0xc8, 0x19, 0xd8,
+	0x2b, 0xbe, 0x4f, 0xf7, 0xa7, 0x43, 0xd2, 0x00, 0x67, 0x37, 0x59, 0x6f, 0xb9, 0xd9, 0x9f, 0xd7,
+	0x01, 0x9a, 0x8d, 0x93, 0x14, 0xee, 0x0a, 0x3d, 0xab, 0x3e, 0x80, 0x6a, 0x4e, 0xfd, 0x1f, 0xa1,
+	0x66, 0x5e, 0x28, 0xf7, 0x6b, 0x32, 0xa5, 0xe9, 0x89, 0xf9, 0x76, 0xdd, 0x6f, 0x45, 0x08, 0xcc,
+	0xc7, 0xb2, 0x02, 0x00, 0x00,
 }
@@ -5,6 +5,7 @@ import (
 	"crypto/sha256"
 	"fmt"
 	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/golang/protobuf/proto"
@@ -481,6 +482,57 @@ func TestGlobFile(t *testing.T) {
 	}
 }
 
+func TestGlobFileSorted(t *testing.T) {
+	hTmp := NewHashTree()
+	for _, path := range []string{"/c", "/a", "/dir/b", "/b", "/dir/a"} {
+		hTmp.PutFile(path, obj(`hash:"20c27"`), 1)
+	}
+	h, err := hTmp.Finish()
+	require.NoError(t, err)
+
+	for _, tree := range []HashTree{hTmp, h} {
+		nodes, err := tree.Glob("*")
+		require.NoError(t, err)
+		var names []string
+		for _, node := range nodes {
+			names = append(names, node.Name)
+		}
+		require.Equal(t, []string{"/a", "/b", "/c", "/dir"}, names)
+	}
+}
+
+func TestListPage(t *testing.T) {
+	hTmp := NewHashTree()
+	for _, path := range []string{"/dir/d", "/dir/b", "/dir/e", "/dir/a", "/dir/c"} {
+		hTmp.PutFile(path, obj(`hash:"20c27"`), 1)
+	}
+	h, err := hTmp.Finish()
+	require.NoError(t, err)
+
+	names := func(nodes []*NodeProto) []string {
+		var result []string
+		for _, node := range nodes {
+			result = append(result, node.Name)
+		}
+		return result
+	}
+	for _, tree := range []HashTree{hTmp, h} {
+		nodes, err := tree.ListPage("/dir", "", 2)
+		require.NoError(t, err)
+		require.Equal(t, []string{"a", "b"}, names(nodes))
+		nodes, err = tree.ListPage("/dir", "b", 2)
+		require.NoError(t, err)
+		require.Equal(t, []string{"c", "d"}, names(nodes))
+		// 'from' doesn't have to be one of the children
+		nodes, err = tree.ListPage("/dir", "bb", 0)
+		require.NoError(t, err)
+		require.Equal(t, []string{"c", "d", "e"}, names(nodes))
+		nodes, err = tree.ListPage("/dir", "e", 2)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(nodes))
+	}
+}
+
 func TestMerge(t *testing.T) {
 	lTmp, rTmp := NewHashTree(), NewHashTree()
 	lTmp.PutFile("/foo-left", obj(`hash:"20c27"`), 1)
@@ -521,6 +573,27 @@ func TestMerge(t *testing.T) {
 	requireSame(t, expected, finish(t, h))
 }
 
+func TestMergeProvenance(t *testing.T) {
+	lTmp, rTmp := NewHashTree(), NewHashTree()
+	lTmp.PutFile("/shared", obj(`hash:"20c27"`), 1)
+	require.NoError(t, lTmp.PutProvenance("/shared", &pfs.FileProvenance{JobID: "job", DatumID: "left"}))
+	rTmp.PutFile("/shared", obj(`hash:"ebc57"`), 1)
+	require.NoError(t, rTmp.PutProvenance("/shared", &pfs.FileProvenance{JobID: "job", DatumID: "right"}))
+	require.YesError(t, rTmp.PutProvenance("/missing", &pfs.FileProvenance{JobID: "job", DatumID: "right"}))
+	require.YesError(t, rTmp.PutProvenance("/", &pfs.FileProvenance{JobID: "job", DatumID: "right"}))
+
+	h := NewHashTree()
+	require.NoError(t, h.Merge(finish(t, lTmp), finish(t, rTmp)))
+	node, err := finish(t, h).Get("/shared")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(node.FileNode.Objects))
+	var datums []string
+	for _, provenance := range node.FileNode.Provenance {
+		datums = append(datums, provenance.DatumID)
+	}
+	require.EqualOneOf(t, i("left,right", "right,left"), strings.Join(datums, ","))
+}
+
 // Test that Merge() works with empty hash trees
 func TestMergeEmpty(t *testing.T) {
 	expectedTmp := NewHashTree()
@@ -628,3 +701,46 @@ func TestListEmpty(t *testing.T) {
 	_, err = tree.Glob("/*")
 	require.NoError(t, err)
 }
+
+func TestDiff(t *testing.T) {
+	hTmp := NewHashTree()
+	require.NoError(t, hTmp.PutFile("/dir/same", obj(`hash:"same"`), 1))
+	require.NoError(t, hTmp.PutFile("/dir/modified", obj(`hash:"old"`), 1))
+	require.NoError(t, hTmp.PutFile("/deleted/file", obj(`hash:"deleted"`), 1))
+	require.NoError(t, hTmp.PutFile("/replaced", obj(`hash:"replaced"`), 1))
+	old := finish(t, hTmp)
+
+	hTmp = old.Open()
+	require.NoError(t, hTmp.DeleteFile("/dir/modified"))
+	require.NoError(t, hTmp.PutFile("/dir/modified", obj(`hash:"new"`), 2))
+	require.NoError(t, hTmp.DeleteFile("/deleted"))
+	require.NoError(t, hTmp.DeleteFile("/replaced"))
+	require.NoError(t, hTmp.PutFile("/replaced/file", obj(`hash:"added"`), 1))
+	require.NoError(t, hTmp.PutFile("/added", obj(`hash:"added"`), 1))
+	h := finish(t, hTmp)
+
+	changes := make(map[string]string)
+	require.NoError(t, Diff(h, old, func(path string, newNode *NodeProto, oldNode *NodeProto) error {
+		switch {
+		case oldNode == nil:
+			changes[path] = "added"
+		case newNode == nil:
+			changes[path] = "deleted"
+		default:
+			changes[path] = "modified"
+		}
+		return nil
+	}))
+	require.Equal(t, map[string]string{
+		"/dir/modified":  "modified",
+		"/deleted/file":  "deleted",
+		"/replaced":      "deleted",
+		"/replaced/file": "added",
+		"/added":         "added",
+	}, changes)
+
+	// identical trees have no differences
+	require.NoError(t, Diff(h, h, func(path string, newNode *NodeProto, oldNode *NodeProto) error {
+		return fmt.Errorf("unexpected difference at %s", path)
+	}))
+}
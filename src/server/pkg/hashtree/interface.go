@@ -59,7 +59,13 @@ type HashTree interface {
 	// 'path'.
 	List(path string) ([]*NodeProto, error)
 
-	// Glob returns a list of files and directories that match 'pattern'.
+	// ListPage is like List, but only returns the children of 'path' whose
+	// names sort after 'from', and at most 'number' of them (all of them if
+	// 'number' is 0), so that huge directories can be listed a page at a time.
+	ListPage(path string, from string, number int64) ([]*NodeProto, error)
+
+	// Glob returns a list of files and directories that match 'pattern',
+	// sorted by path.
 	Glob(pattern string) ([]*NodeProto, error)
 
 	// Size gets the size of the file system that this tree represents.
@@ -94,6 +100,10 @@ type OpenHashTree interface {
 	// PutFile appends data to a file (and creates the file if it doesn't exist).
 	PutFile(path string, objects []*pfs.Object, size int64) error
 
+	// PutProvenance records that the file at 'path', which must exist, was
+	// written by the datums in 'provenance'.
+	PutProvenance(path string, provenance ...*pfs.FileProvenance) error
+
 	// PutDir creates a directory (or does nothing if one exists).
 	PutDir(path string) error
 
@@ -0,0 +1,406 @@
+package hashtree
+
+import (
+	"bytes"
+	pathlib "path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+)
+
+// chunkSize is roughly how many nodes are stored in each chunk of a tree.
+// Directories are only split into chunks of their own, so a directory with
+// more than chunkSize children still has all of them in one chunk.
+const chunkSize = 10000
+
+// ObjectStore is where the chunks of stored trees are kept, see Store and
+// Load.
+type ObjectStore interface {
+	// Get returns the content of 'object'.
+	Get(object *pfs.Object) ([]byte, error)
+	// Put stores 'data' and returns the object it's stored in.
+	Put(data []byte) (*pfs.Object, error)
+}
+
+type objectStore struct {
+	c *client.APIClient
+}
+
+// NewObjectStore returns an ObjectStore which keeps chunks in pachyderm's
+// object store.
+func NewObjectStore(c *client.APIClient) ObjectStore {
+	return &objectStore{c: c}
+}
+
+func (s *objectStore) Get(object *pfs.Object) ([]byte, error) {
+	return s.c.ReadObject(object.Hash)
+}
+
+func (s *objectStore) Put(data []byte) (*pfs.Object, error) {
+	object, _, err := s.c.PutObject(bytes.NewReader(data))
+	return object, err
+}
+
+// Store splits 'tree' into chunks of about chunkSize nodes, each of which
+// holds some of the descendants of a directory, puts them in 'store' and
+// returns the object of the chunk at the root of the tree, which references
+// the rest. The chunks of a tree that was read with Load and haven't been
+// read since are referenced as they are, so storing a modified tree only
+// stores the chunks that were modified, and the work it does is proportional
+// to the number of nodes that were modified rather than to the size of the
+// tree.
+func Store(store ObjectStore, tree HashTree) (*pfs.Object, error) {
+	c := &chunker{
+		store: store,
+		split: make(map[string]bool),
+	}
+	switch t := tree.(type) {
+	case *HashTreeProto:
+		c.fs, c.subtrees = t.Fs, t.Subtrees
+	case *storedTree:
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		c.fs, c.subtrees, c.chunks = t.h.fs, t.h.subtrees, t.h.chunks
+	default:
+		return nil, errorf(Internal, "HashTree is of the wrong concrete type")
+	}
+	if object, ok := c.chunks[""]; ok {
+		// Nothing has been modified
+		return object, nil
+	}
+	c.plan("")
+	return c.put("")
+}
+
+// Load returns the tree whose root chunk is 'object', which Store put in
+// 'store'. The rest of the tree's chunks are read from 'store' as they're
+// needed. Trees that were serialized whole, with Serialize, can be read with
+// Load as well, as they're trees with a single chunk.
+func Load(store ObjectStore, object *pfs.Object) (HashTree, error) {
+	h := newStoredHashtree(store)
+	if err := h.loadChunk("", object); err != nil {
+		return nil, err
+	}
+	return &storedTree{h: h}, nil
+}
+
+func newStoredHashtree(store ObjectStore) *hashtree {
+	return &hashtree{
+		fs:       make(map[string]*NodeProto),
+		changed:  make(map[string]bool),
+		store:    store,
+		subtrees: make(map[string]*pfs.Object),
+		chunks:   make(map[string]*pfs.Object),
+	}
+}
+
+// storedTree is a finished tree which is read from its chunks as it's used.
+// Reading it may load chunks, so it's guarded by a mutex, so that it can be
+// read concurrently (e.g. from pfs's cache).
+type storedTree struct {
+	mu sync.Mutex
+	h  *hashtree
+}
+
+// Open returns a copy of the tree which loads its chunks as it needs them,
+// like one returned by Load, so that opening a big tree that has been read
+// whole doesn't copy all of it. Trees with modified chunks that haven't been
+// stored, or whose root chunk can't be read again, are copied instead.
+func (t *storedTree) Open() OpenHashTree {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if object, ok := t.h.chunks[""]; ok {
+		h := newStoredHashtree(t.h.store)
+		if err := h.loadChunk("", object); err == nil {
+			return h
+		}
+	}
+	h := newStoredHashtree(t.h.store)
+	for path, node := range t.h.fs {
+		h.fs[path] = proto.Clone(node).(*NodeProto)
+	}
+	for path, object := range t.h.subtrees {
+		h.subtrees[path] = object
+	}
+	for path, object := range t.h.chunks {
+		h.chunks[path] = object
+	}
+	return h
+}
+
+// Get implements HashTree.Get
+func (t *storedTree) Get(path string) (*NodeProto, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.h.Get(path)
+}
+
+// List implements HashTree.List
+func (t *storedTree) List(path string) ([]*NodeProto, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.h.List(path)
+}
+
+// ListPage implements HashTree.ListPage
+func (t *storedTree) ListPage(path string, from string, number int64) ([]*NodeProto, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.h.ListPage(path, from, number)
+}
+
+// Glob implements HashTree.Glob
+func (t *storedTree) Glob(pattern string) ([]*NodeProto, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.h.Glob(pattern)
+}
+
+// Size implements HashTree.Size
+func (t *storedTree) Size() int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.h.Size()
+}
+
+// Walk implements HashTree.Walk
+func (t *storedTree) Walk(f func(string, *NodeProto) error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.h.Walk(f)
+}
+
+// loadChunk reads the chunk 'object', which holds descendants of the
+// directory at 'path', from h.store and adds its nodes to h.fs.
+func (h *hashtree) loadChunk(path string, object *pfs.Object) error {
+	data, err := h.store.Get(object)
+	if err != nil {
+		return err
+	}
+	chunk := &HashTreeProto{}
+	if err := proto.Unmarshal(data, chunk); err != nil {
+		return errorf(CannotDeserialize, "could not deserialize the chunk of "+
+			"\"%s\": %s", path, err)
+	}
+	if chunk.Version != 1 {
+		return errorf(Unsupported, "unsupported HashTreeProto version %d",
+			chunk.Version)
+	}
+	for p, node := range chunk.Fs {
+		h.fs[p] = node
+	}
+	for p, o := range chunk.Subtrees {
+		h.subtrees[p] = o
+	}
+	delete(h.subtrees, path)
+	h.chunks[path] = object
+	return nil
+}
+
+// load loads the chunks that hold the children of the directory at 'path',
+// and of its ancestors, if they haven't been loaded yet.
+func (h *hashtree) load(path string) error {
+	if len(h.subtrees) == 0 {
+		return nil
+	}
+	path = clean(path)
+	// Ancestors are loaded root first, as loading a chunk reveals the chunks
+	// below it
+	for i := 0; i <= len(path); i++ {
+		if i < len(path) && path[i] != '/' {
+			continue
+		}
+		if object, ok := h.subtrees[path[:i]]; ok {
+			if err := h.loadChunk(path[:i], object); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// loadParent loads the chunk that holds the node at 'path', and those of its
+// ancestors.
+func (h *hashtree) loadParent(path string) error {
+	path = clean(path)
+	if path == "" {
+		// The root is always in the first chunk
+		return nil
+	}
+	parent, _ := split(path)
+	return h.load(parent)
+}
+
+// loadGlob loads the chunks that may hold nodes that match 'pattern'.
+func (h *hashtree) loadGlob(pattern string) error {
+	pattern = clean(pattern)
+	// Wildcards don't match "/", so only paths with as many components as
+	// 'pattern' can match it
+	depth := strings.Count(pattern, "/")
+	for {
+		loaded := false
+		for path, object := range h.subtrees {
+			pathDepth := strings.Count(path, "/")
+			if pathDepth >= depth {
+				continue
+			}
+			matched, err := pathlib.Match(patternPrefix(pattern, pathDepth), path)
+			if err != nil {
+				if err == pathlib.ErrBadPattern {
+					return errorf(MalformedGlob, "glob \"%s\" is malformed", pattern)
+				}
+				return err
+			}
+			if matched {
+				if err := h.loadChunk(path, object); err != nil {
+					return err
+				}
+				loaded = true
+			}
+		}
+		if !loaded {
+			return nil
+		}
+	}
+}
+
+// patternPrefix returns the first 'depth' components of 'pattern'.
+func patternPrefix(pattern string, depth int) string {
+	n := 0
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] == '/' {
+			if n == depth {
+				return pattern[:i]
+			}
+			n++
+		}
+	}
+	return pattern
+}
+
+// loadAll loads all of the chunks of the tree.
+func (h *hashtree) loadAll() error {
+	for len(h.subtrees) > 0 {
+		for path, object := range h.subtrees {
+			if err := h.loadChunk(path, object); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// chunker splits a tree into chunks and stores them, see Store.
+type chunker struct {
+	store    ObjectStore
+	fs       map[string]*NodeProto
+	subtrees map[string]*pfs.Object
+	// chunks are the chunks which haven't been modified since they were
+	// loaded, which are referenced as they are, like 'subtrees'
+	chunks map[string]*pfs.Object
+	// split is the set of directories whose descendants are put in chunks of
+	// their own
+	split map[string]bool
+}
+
+type dirSize struct {
+	path string
+	size int
+}
+
+// plan decides which directories under the directory at 'path' are split
+// into chunks of their own, splitting the biggest ones until the rest fit
+// in a chunk, and returns the number of nodes under 'path' that are left in
+// the chunk that 'path' is in.
+func (c *chunker) plan(path string) int {
+	node, ok := c.fs[path]
+	if !ok || node.DirNode == nil {
+		return 0
+	}
+	if _, ok := c.subtrees[path]; ok {
+		// Already stored in a chunk of its own
+		return 0
+	}
+	if _, ok := c.chunks[path]; ok {
+		return 0
+	}
+	size := len(node.DirNode.Children)
+	var dirs []dirSize
+	for _, child := range node.DirNode.Children {
+		childPath := join(path, child)
+		if childSize := c.plan(childPath); childSize > 0 {
+			dirs = append(dirs, dirSize{childPath, childSize})
+			size += childSize
+		}
+	}
+	sort.Slice(dirs, func(i, j int) bool { return dirs[i].size > dirs[j].size })
+	for _, dir := range dirs {
+		if size <= chunkSize {
+			break
+		}
+		c.split[dir.path] = true
+		size -= dir.size
+	}
+	return size
+}
+
+// put stores the chunk holding the descendants of the directory at 'path'
+// that aren't split into chunks of their own, and returns its object.
+func (c *chunker) put(path string) (*pfs.Object, error) {
+	chunk := &HashTreeProto{
+		Version:  1,
+		Fs:       make(map[string]*NodeProto),
+		Subtrees: make(map[string]*pfs.Object),
+	}
+	if node, ok := c.fs[path]; ok && path == "" {
+		chunk.Fs[path] = node
+	}
+	if err := c.fill(chunk, path); err != nil {
+		return nil, err
+	}
+	data, err := proto.Marshal(chunk)
+	if err != nil {
+		return nil, err
+	}
+	return c.store.Put(data)
+}
+
+// fill adds the descendants of the directory at 'path' to 'chunk', storing
+// the directories under it that are split into chunks of their own.
+func (c *chunker) fill(chunk *HashTreeProto, path string) error {
+	if object, ok := c.subtrees[path]; ok {
+		chunk.Subtrees[path] = object
+		return nil
+	}
+	if object, ok := c.chunks[path]; ok {
+		chunk.Subtrees[path] = object
+		return nil
+	}
+	node, ok := c.fs[path]
+	if !ok || node.DirNode == nil {
+		return nil
+	}
+	for _, child := range node.DirNode.Children {
+		childPath := join(path, child)
+		childNode, ok := c.fs[childPath]
+		if !ok {
+			return errorf(Internal, "could not find node for the child \"%s\" "+
+				"while storing \"%s\"", childPath, path)
+		}
+		chunk.Fs[childPath] = childNode
+		if c.split[childPath] {
+			object, err := c.put(childPath)
+			if err != nil {
+				return err
+			}
+			chunk.Subtrees[childPath] = object
+		} else if err := c.fill(chunk, childPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package hashtree
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+)
+
+// memStore is an ObjectStore in memory, which counts the chunks read from it
+// and written to it
+type memStore struct {
+	objects map[string][]byte
+	gets    int
+	puts    int
+}
+
+func newMemStore() *memStore {
+	return &memStore{objects: make(map[string][]byte)}
+}
+
+func (s *memStore) Get(object *pfs.Object) ([]byte, error) {
+	data, ok := s.objects[object.Hash]
+	if !ok {
+		return nil, fmt.Errorf("object %s not found", object.Hash)
+	}
+	s.gets++
+	return data, nil
+}
+
+func (s *memStore) Put(data []byte) (*pfs.Object, error) {
+	hash := sha256.Sum256(data)
+	object := &pfs.Object{Hash: hex.EncodeToString(hash[:])}
+	s.objects[object.Hash] = data
+	s.puts++
+	return object, nil
+}
+
+// bigTree returns a tree with enough files that it's stored in several
+// chunks
+func bigTree(t *testing.T) HashTree {
+	h := NewHashTree()
+	for i := 0; i < 4; i++ {
+		for j := 0; j < chunkSize/2; j++ {
+			path := fmt.Sprintf("/dir-%d/file-%05d", i, j)
+			require.NoError(t, h.PutFile(path, obj(`hash:"20c27"`), 1))
+		}
+	}
+	require.NoError(t, h.PutFile("/file", obj(`hash:"20c27"`), 1))
+	tree, err := h.Finish()
+	require.NoError(t, err)
+	return tree
+}
+
+func TestStoreLoad(t *testing.T) {
+	tree := bigTree(t)
+	store := newMemStore()
+	object, err := Store(store, tree)
+	require.NoError(t, err)
+	require.True(t, len(store.objects) > 1)
+
+	loaded, err := Load(store, object)
+	require.NoError(t, err)
+	require.Equal(t, tree.Size(), loaded.Size())
+	// Reading a file only loads the chunks on its path
+	node, err := loaded.Get("/dir-2/file-00042")
+	require.NoError(t, err)
+	expected, err := tree.Get("/dir-2/file-00042")
+	require.NoError(t, err)
+	require.Equal(t, expected, node)
+	require.True(t, store.gets < len(store.objects))
+
+	nodes, err := loaded.List("/dir-1")
+	require.NoError(t, err)
+	require.Equal(t, chunkSize/2, len(nodes))
+	nodes, err = loaded.Glob("/dir-3/file-0000*")
+	require.NoError(t, err)
+	require.Equal(t, 10, len(nodes))
+	require.Equal(t, "/dir-3/file-00000", nodes[0].Name)
+	nodes, err = loaded.Glob("/*")
+	require.NoError(t, err)
+	require.Equal(t, 5, len(nodes))
+
+	var walked int
+	require.NoError(t, loaded.Walk(func(path string, node *NodeProto) error {
+		walked++
+		return nil
+	}))
+	require.Equal(t, 2*chunkSize+6, walked)
+	require.Equal(t, len(store.objects), store.gets)
+}
+
+func TestStoreModified(t *testing.T) {
+	store := newMemStore()
+	object, err := Store(store, bigTree(t))
+	require.NoError(t, err)
+	numChunks := len(store.objects)
+
+	loaded, err := Load(store, object)
+	require.NoError(t, err)
+	open := loaded.Open()
+	require.NoError(t, open.PutFile("/dir-0/file-00000", obj(`hash:"ebc57"`), 1))
+	require.NoError(t, open.DeleteFile("/dir-1"))
+	finished, err := open.Finish()
+	require.NoError(t, err)
+	store.gets = 0
+	object, err = Store(store, finished)
+	require.NoError(t, err)
+	// Only the chunks that were modified were stored again
+	require.True(t, len(store.objects) < 2*numChunks)
+
+	loaded, err = Load(store, object)
+	require.NoError(t, err)
+	node, err := loaded.Get("/dir-0/file-00000")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(node.FileNode.Objects))
+	_, err = loaded.Get("/dir-1/file-00000")
+	require.YesError(t, err)
+	require.Equal(t, PathNotFound, Code(err))
+	require.Equal(t, int64(3*chunkSize/2+2), loaded.Size())
+
+	// The hashes are the same as if the tree had been modified in memory
+	h := bigTree(t).Open()
+	require.NoError(t, h.PutFile("/dir-0/file-00000", obj(`hash:"ebc57"`), 1))
+	require.NoError(t, h.DeleteFile("/dir-1"))
+	expected, err := h.Finish()
+	require.NoError(t, err)
+	root, err := loaded.Get("/")
+	require.NoError(t, err)
+	expectedRoot, err := expected.Get("/")
+	require.NoError(t, err)
+	require.Equal(t, expectedRoot.Hash, root.Hash)
+}
+
+func TestDeserializeStored(t *testing.T) {
+	store := newMemStore()
+	object, err := Store(store, bigTree(t))
+	require.NoError(t, err)
+	_, err = Deserialize(store.objects[object.Hash])
+	require.YesError(t, err)
+	require.Equal(t, Unsupported, Code(err))
+}
+
+func TestStoreUnmodifiedChunks(t *testing.T) {
+	store := newMemStore()
+	object, err := Store(store, bigTree(t))
+	require.NoError(t, err)
+	numChunks := len(store.objects)
+
+	loaded, err := Load(store, object)
+	require.NoError(t, err)
+	// Read the whole tree, as e.g. a job's Glob would
+	require.NoError(t, loaded.Walk(func(string, *NodeProto) error { return nil }))
+
+	// Storing it again doesn't store anything
+	store.puts = 0
+	stored, err := Store(store, loaded)
+	require.NoError(t, err)
+	require.Equal(t, object.Hash, stored.Hash)
+	require.Equal(t, 0, store.puts)
+
+	// Modifying one file only stores the chunks on its path, even though the
+	// rest of the tree has been read
+	open := loaded.Open()
+	require.NoError(t, open.PutFile("/dir-3/file-00001", obj(`hash:"ebc57"`), 1))
+	finished, err := open.Finish()
+	require.NoError(t, err)
+	store.gets = 0
+	object, err = Store(store, finished)
+	require.NoError(t, err)
+	require.True(t, store.gets < numChunks)
+	require.True(t, store.puts < numChunks)
+
+	// The tree is the same as if it had been modified in memory
+	h := bigTree(t).Open()
+	require.NoError(t, h.PutFile("/dir-3/file-00001", obj(`hash:"ebc57"`), 1))
+	expected, err := h.Finish()
+	require.NoError(t, err)
+	loaded, err = Load(store, object)
+	require.NoError(t, err)
+	var walked int
+	require.NoError(t, loaded.Walk(func(path string, node *NodeProto) error {
+		expectedNode, err := expected.Get(path)
+		require.NoError(t, err)
+		require.Equal(t, expectedNode.Hash, node.Hash)
+		walked++
+		return nil
+	}))
+	require.Equal(t, 2*chunkSize+6, walked)
+}
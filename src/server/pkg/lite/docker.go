@@ -0,0 +1,299 @@
+package lite
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"golang.org/x/net/context"
+	"k8s.io/kubernetes/pkg/api"
+)
+
+const (
+	// clusterLabel is the label of every container that a dockerRuntime
+	// runs, and podLabel is the label of the name of its pod.
+	clusterLabel = "io.pachyderm.lite"
+	podLabel     = "io.pachyderm.lite.pod"
+)
+
+// dockerRuntime runs each pod as a group of Docker containers that share the
+// network namespace of a pause container, as kubelet does.
+type dockerRuntime struct {
+	client *docker.Client
+	// dir is where pods' emptyDir volumes are created
+	dir        string
+	secretsDir string
+	pauseImage string
+}
+
+func newDockerRuntime(client *docker.Client, dir string, secretsDir string, pauseImage string) *dockerRuntime {
+	return &dockerRuntime{
+		client:     client,
+		dir:        dir,
+		secretsDir: secretsDir,
+		pauseImage: pauseImage,
+	}
+}
+
+// containerName returns the name of the Docker container that runs pod's
+// container.
+func containerName(pod *api.Pod, container string) string {
+	return fmt.Sprintf("pachyderm-lite-%s-%s", pod.Name, container)
+}
+
+func (r *dockerRuntime) podDir(pod *api.Pod) string {
+	return filepath.Join(r.dir, "pods", pod.Name)
+}
+
+func (r *dockerRuntime) start(pod *api.Pod, env []api.EnvVar) (string, error) {
+	volumes, err := r.volumes(pod)
+	if err != nil {
+		return "", err
+	}
+	labels := map[string]string{clusterLabel: "true", podLabel: pod.Name}
+	if err := r.pull(r.pauseImage, api.PullIfNotPresent); err != nil {
+		return "", err
+	}
+	pause, err := r.client.CreateContainer(docker.CreateContainerOptions{
+		Name:   containerName(pod, "pause"),
+		Config: &docker.Config{Image: r.pauseImage, Labels: labels},
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := r.client.StartContainer(pause.ID, nil); err != nil {
+		return "", err
+	}
+	pauseInfo, err := r.client.InspectContainer(pause.ID)
+	if err != nil {
+		return "", err
+	}
+	ip := pauseInfo.NetworkSettings.IPAddress
+	run := func(container *api.Container) (string, error) {
+		if err := r.pull(container.Image, container.ImagePullPolicy); err != nil {
+			return "", err
+		}
+		containerEnv, err := containerEnv(pod, container, ip, env, r.secretsDir)
+		if err != nil {
+			return "", err
+		}
+		config := &docker.Config{
+			Image:      container.Image,
+			Entrypoint: container.Command,
+			Cmd:        container.Args,
+			Env:        containerEnv,
+			WorkingDir: container.WorkingDir,
+			Labels:     labels,
+		}
+		hostConfig := &docker.HostConfig{
+			NetworkMode: "container:" + pause.ID,
+		}
+		for _, mount := range container.VolumeMounts {
+			hostPath, ok := volumes[mount.Name]
+			if !ok {
+				return "", fmt.Errorf("container %s mounts volume %s, which pod %s doesn't have", container.Name, mount.Name, pod.Name)
+			}
+			bind := hostPath + ":" + mount.MountPath
+			if mount.ReadOnly {
+				bind += ":ro"
+			}
+			hostConfig.Binds = append(hostConfig.Binds, bind)
+		}
+		if security := container.SecurityContext; security != nil {
+			if security.RunAsUser != nil {
+				config.User = strconv.FormatInt(*security.RunAsUser, 10)
+			}
+			if security.Privileged != nil {
+				hostConfig.Privileged = *security.Privileged
+			}
+		}
+		if memory, ok := container.Resources.Limits[api.ResourceMemory]; ok {
+			hostConfig.Memory = memory.Value()
+		}
+		created, err := r.client.CreateContainer(docker.CreateContainerOptions{
+			Name:       containerName(pod, container.Name),
+			Config:     config,
+			HostConfig: hostConfig,
+		})
+		if err != nil {
+			return "", err
+		}
+		return created.ID, r.client.StartContainer(created.ID, nil)
+	}
+	for i := range pod.Spec.InitContainers {
+		container := &pod.Spec.InitContainers[i]
+		id, err := run(container)
+		if err != nil {
+			return "", err
+		}
+		exitCode, err := r.client.WaitContainer(id)
+		if err != nil {
+			return "", err
+		}
+		if exitCode != 0 {
+			return "", fmt.Errorf("init container %s exited with code %d", container.Name, exitCode)
+		}
+	}
+	for i := range pod.Spec.Containers {
+		if _, err := run(&pod.Spec.Containers[i]); err != nil {
+			return "", err
+		}
+	}
+	return ip, nil
+}
+
+// volumes returns the host directory of each of pod's volumes, creating
+// the directories of its emptyDir volumes.
+func (r *dockerRuntime) volumes(pod *api.Pod) (map[string]string, error) {
+	result := make(map[string]string)
+	for _, volume := range pod.Spec.Volumes {
+		switch {
+		case volume.EmptyDir != nil:
+			dir := filepath.Join(r.podDir(pod), volume.Name)
+			// containers may not run as root
+			if err := os.MkdirAll(dir, 0777); err != nil {
+				return nil, err
+			}
+			if err := os.Chmod(dir, 0777); err != nil {
+				return nil, err
+			}
+			result[volume.Name] = dir
+		case volume.HostPath != nil:
+			result[volume.Name] = volume.HostPath.Path
+		case volume.Secret != nil:
+			dir := filepath.Join(r.secretsDir, volume.Secret.SecretName)
+			if _, err := os.Stat(dir); err != nil {
+				return nil, fmt.Errorf("error mounting secret %s in pod %s: %v", volume.Secret.SecretName, pod.Name, err)
+			}
+			result[volume.Name] = dir
+		default:
+			return nil, fmt.Errorf("volume %s of pod %s is of a type that pachd's lite mode doesn't support", volume.Name, pod.Name)
+		}
+	}
+	return result, nil
+}
+
+// pull pulls image, if policy calls for it.
+func (r *dockerRuntime) pull(image string, policy api.PullPolicy) error {
+	if policy == api.PullNever {
+		return nil
+	}
+	if policy != api.PullAlways {
+		if _, err := r.client.InspectImage(image); err == nil {
+			return nil
+		} else if err != docker.ErrNoSuchImage {
+			return err
+		}
+	}
+	repository, tag := docker.ParseRepositoryTag(image)
+	if tag == "" {
+		tag = "latest"
+	}
+	if err := r.client.PullImage(docker.PullImageOptions{
+		Repository: repository,
+		Tag:        tag,
+	}, docker.AuthConfiguration{}); err != nil {
+		return fmt.Errorf("error pulling image %s: %v", image, err)
+	}
+	return nil
+}
+
+func (r *dockerRuntime) exited(pod *api.Pod) (string, error) {
+	for _, container := range pod.Spec.Containers {
+		info, err := r.client.InspectContainer(containerName(pod, container.Name))
+		if err != nil {
+			if _, ok := err.(*docker.NoSuchContainer); ok {
+				return fmt.Sprintf("container %s was removed", container.Name), nil
+			}
+			return "", err
+		}
+		if !info.State.Running {
+			return fmt.Sprintf("container %s exited with code %d", container.Name, info.State.ExitCode), nil
+		}
+	}
+	return "", nil
+}
+
+func (r *dockerRuntime) remove(pod *api.Pod) error {
+	if err := r.removeContainers(podLabel + "=" + pod.Name); err != nil {
+		return err
+	}
+	return os.RemoveAll(r.podDir(pod))
+}
+
+func (r *dockerRuntime) removeAll() error {
+	if err := r.removeContainers(clusterLabel); err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.Join(r.dir, "pods"))
+}
+
+// removeContainers removes the containers with label, which is either a
+// label's name or "name=value".
+func (r *dockerRuntime) removeContainers(label string) error {
+	containers, err := r.client.ListContainers(docker.ListContainersOptions{
+		All:     true,
+		Filters: map[string][]string{"label": {label}},
+	})
+	if err != nil {
+		return err
+	}
+	for _, container := range containers {
+		if err := r.client.RemoveContainer(docker.RemoveContainerOptions{
+			ID:            container.ID,
+			RemoveVolumes: true,
+			Force:         true,
+		}); err != nil {
+			if _, ok := err.(*docker.NoSuchContainer); !ok {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *dockerRuntime) logs(ctx context.Context, pod *api.Pod, options *api.PodLogOptions, w io.Writer) error {
+	container := options.Container
+	if container == "" {
+		if len(pod.Spec.Containers) != 1 {
+			return fmt.Errorf("a container name must be given for pod %s, one of %v", pod.Name, containerNames(pod))
+		}
+		container = pod.Spec.Containers[0].Name
+	}
+	tail := "all"
+	if options.TailLines != nil {
+		tail = strconv.FormatInt(*options.TailLines, 10)
+	}
+	var since int64
+	if options.SinceSeconds != nil {
+		since = time.Now().Unix() - *options.SinceSeconds
+	}
+	if options.SinceTime != nil {
+		since = options.SinceTime.Unix()
+	}
+	return r.client.Logs(docker.LogsOptions{
+		Context:      ctx,
+		Container:    containerName(pod, container),
+		OutputStream: w,
+		ErrorStream:  w,
+		Tail:         tail,
+		Since:        since,
+		Follow:       options.Follow,
+		Stdout:       true,
+		Stderr:       true,
+		Timestamps:   options.Timestamps,
+	})
+}
+
+func containerNames(pod *api.Pod) string {
+	var names []string
+	for _, container := range pod.Spec.Containers {
+		names = append(names, container.Name)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,523 @@
+package lite
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/uuid"
+
+	"go.pedge.io/lion/proto"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/errors"
+	"k8s.io/kubernetes/pkg/api/resource"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/api/v1"
+	"k8s.io/kubernetes/pkg/labels"
+	kuberuntime "k8s.io/kubernetes/pkg/runtime"
+	"k8s.io/kubernetes/pkg/types"
+)
+
+var (
+	rcResource      = api.Resource("replicationcontrollers")
+	podResource     = api.Resource("pods")
+	serviceResource = api.Resource("services")
+	secretResource  = api.Resource("secrets")
+)
+
+// kubeServer serves the subset of kubernetes' API that pachd uses:
+// replication controllers, pods, services, secrets and nodes, in any
+// namespace (they're all in one). It runs the pods of its replication
+// controllers through a podRuntime, see reconcile.
+type kubeServer struct {
+	runtime podRuntime
+	// statePath is the file that replication controllers and services are
+	// saved to, so that they outlive pachd
+	statePath string
+	// secretsDir holds a directory of files, one per key, for each secret
+	secretsDir string
+	hostIP     string
+
+	mu       sync.Mutex
+	rcs      map[string]*api.ReplicationController
+	services map[string]*api.Service
+	pods     map[string]*api.Pod
+	// failed is when each failed pod failed, see reconcile
+	failed map[string]unversioned.Time
+}
+
+// state is what a kubeServer saves to its statePath.
+type state struct {
+	ReplicationControllers []*api.ReplicationController `json:"replicationControllers"`
+	Services               []*api.Service               `json:"services"`
+}
+
+func newKubeServer(runtime podRuntime, statePath string, secretsDir string, hostIP string) (*kubeServer, error) {
+	s := &kubeServer{
+		runtime:    runtime,
+		statePath:  statePath,
+		secretsDir: secretsDir,
+		hostIP:     hostIP,
+		rcs:        make(map[string]*api.ReplicationController),
+		services:   make(map[string]*api.Service),
+		pods:       make(map[string]*api.Pod),
+		failed:     make(map[string]unversioned.Time),
+	}
+	data, err := ioutil.ReadFile(statePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, err
+	}
+	var saved state
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", statePath, err)
+	}
+	for _, rc := range saved.ReplicationControllers {
+		s.rcs[rc.Name] = rc
+	}
+	for _, service := range saved.Services {
+		s.services[service.Name] = service
+	}
+	return s, nil
+}
+
+// save writes s's replication controllers and services to its statePath.
+// s.mu must be held.
+func (s *kubeServer) save() error {
+	var saved state
+	for _, rc := range s.rcs {
+		saved.ReplicationControllers = append(saved.ReplicationControllers, rc)
+	}
+	for _, service := range s.services {
+		saved.Services = append(saved.Services, service)
+	}
+	data, err := json.Marshal(&saved)
+	if err != nil {
+		return err
+	}
+	tmp := s.statePath + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.statePath)
+}
+
+// putRC creates or replaces rc.
+func (s *kubeServer) putRC(rc *api.ReplicationController) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	setCreated(&rc.ObjectMeta)
+	s.rcs[rc.Name] = rc
+	return s.save()
+}
+
+// putServices creates or replaces services.
+func (s *kubeServer) putServices(services ...*api.Service) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, service := range services {
+		setCreated(&service.ObjectMeta)
+		s.services[service.Name] = service
+	}
+	return s.save()
+}
+
+func setCreated(meta *api.ObjectMeta) {
+	meta.UID = types.UID(uuid.NewWithoutDashes())
+	meta.CreationTimestamp = unversioned.Now()
+}
+
+func (s *kubeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 3 || parts[0] != "api" || parts[1] != "v1" {
+		writeError(w, errors.NewNotFound(unversioned.GroupResource{}, r.URL.Path))
+		return
+	}
+	parts = parts[2:]
+	if len(parts) == 1 && parts[0] == "nodes" && r.Method == "GET" {
+		writeObject(w, http.StatusOK, s.nodes())
+		return
+	}
+	if len(parts) < 3 || parts[0] != "namespaces" {
+		writeError(w, errors.NewNotFound(unversioned.GroupResource{}, r.URL.Path))
+		return
+	}
+	namespace, resource := parts[1], strings.ToLower(parts[2])
+	var name string
+	if len(parts) > 3 {
+		name = parts[3]
+	}
+	if len(parts) == 5 && resource == "pods" && parts[4] == "log" && r.Method == "GET" {
+		s.servePodLogs(w, r, name)
+		return
+	}
+	if len(parts) > 4 {
+		writeError(w, errors.NewNotFound(unversioned.GroupResource{}, r.URL.Path))
+		return
+	}
+	status := http.StatusOK
+	if r.Method == "POST" {
+		status = http.StatusCreated
+	}
+	obj, err := s.handle(r, namespace, resource, name)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeObject(w, status, obj)
+}
+
+// handle serves r, a request for the resource named name in namespace (or
+// for all of them, if name is empty).
+func (s *kubeServer) handle(r *http.Request, namespace string, resource string, name string) (kuberuntime.Object, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case resource == "replicationcontrollers" && r.Method == "GET" && name == "":
+		selector, err := labelSelector(r)
+		if err != nil {
+			return nil, err
+		}
+		result := &api.ReplicationControllerList{}
+		for _, rc := range s.rcs {
+			if selector.Matches(labels.Set(rc.Labels)) {
+				result.Items = append(result.Items, *withNamespace(rc, namespace).(*api.ReplicationController))
+			}
+		}
+		sort.Slice(result.Items, func(i, j int) bool { return result.Items[i].Name < result.Items[j].Name })
+		return result, nil
+	case resource == "replicationcontrollers" && r.Method == "GET":
+		rc, ok := s.rcs[name]
+		if !ok {
+			return nil, errors.NewNotFound(rcResource, name)
+		}
+		return withNamespace(rc, namespace), nil
+	case resource == "replicationcontrollers" && r.Method == "POST" && name == "":
+		rc := &api.ReplicationController{}
+		if err := decode(r, rc); err != nil {
+			return nil, err
+		}
+		if _, ok := s.rcs[rc.Name]; ok {
+			return nil, errors.NewAlreadyExists(rcResource, rc.Name)
+		}
+		setCreated(&rc.ObjectMeta)
+		s.rcs[rc.Name] = rc
+		return withNamespace(rc, namespace), s.save()
+	case resource == "replicationcontrollers" && r.Method == "PUT":
+		rc := &api.ReplicationController{}
+		if err := decode(r, rc); err != nil {
+			return nil, err
+		}
+		old, ok := s.rcs[name]
+		if !ok {
+			return nil, errors.NewNotFound(rcResource, name)
+		}
+		rc.UID, rc.CreationTimestamp, rc.Status = old.UID, old.CreationTimestamp, old.Status
+		s.rcs[name] = rc
+		return withNamespace(rc, namespace), s.save()
+	case resource == "replicationcontrollers" && r.Method == "DELETE":
+		options := &api.DeleteOptions{}
+		if err := decode(r, options); err != nil {
+			return nil, err
+		}
+		rc, ok := s.rcs[name]
+		if !ok {
+			return nil, errors.NewNotFound(rcResource, name)
+		}
+		delete(s.rcs, name)
+		if options.OrphanDependents == nil || !*options.OrphanDependents {
+			for _, pod := range s.rcPods(rc, true) {
+				s.removePod(pod)
+			}
+		}
+		return success(), s.save()
+	case resource == "pods" && r.Method == "GET" && name == "":
+		selector, err := labelSelector(r)
+		if err != nil {
+			return nil, err
+		}
+		result := &api.PodList{}
+		for _, pod := range s.pods {
+			if selector.Matches(labels.Set(pod.Labels)) {
+				result.Items = append(result.Items, *withNamespace(pod, namespace).(*api.Pod))
+			}
+		}
+		sort.Slice(result.Items, func(i, j int) bool { return result.Items[i].Name < result.Items[j].Name })
+		return result, nil
+	case resource == "pods" && r.Method == "GET":
+		pod, ok := s.pods[name]
+		if !ok {
+			return nil, errors.NewNotFound(podResource, name)
+		}
+		return withNamespace(pod, namespace), nil
+	case resource == "pods" && r.Method == "DELETE":
+		pod, ok := s.pods[name]
+		if !ok {
+			return nil, errors.NewNotFound(podResource, name)
+		}
+		s.removePod(pod)
+		return success(), nil
+	case resource == "services" && r.Method == "GET" && name == "":
+		selector, err := labelSelector(r)
+		if err != nil {
+			return nil, err
+		}
+		result := &api.ServiceList{}
+		for _, service := range s.services {
+			if selector.Matches(labels.Set(service.Labels)) {
+				result.Items = append(result.Items, *withNamespace(s.withClusterIP(service), namespace).(*api.Service))
+			}
+		}
+		sort.Slice(result.Items, func(i, j int) bool { return result.Items[i].Name < result.Items[j].Name })
+		return result, nil
+	case resource == "services" && r.Method == "GET":
+		service, ok := s.services[name]
+		if !ok {
+			return nil, errors.NewNotFound(serviceResource, name)
+		}
+		return withNamespace(s.withClusterIP(service), namespace), nil
+	case resource == "services" && r.Method == "POST" && name == "":
+		service := &api.Service{}
+		if err := decode(r, service); err != nil {
+			return nil, err
+		}
+		if _, ok := s.services[service.Name]; ok {
+			return nil, errors.NewAlreadyExists(serviceResource, service.Name)
+		}
+		setCreated(&service.ObjectMeta)
+		s.services[service.Name] = service
+		return withNamespace(s.withClusterIP(service), namespace), s.save()
+	case resource == "services" && r.Method == "DELETE":
+		if _, ok := s.services[name]; !ok {
+			return nil, errors.NewNotFound(serviceResource, name)
+		}
+		delete(s.services, name)
+		return success(), s.save()
+	case resource == "secrets" && r.Method == "GET" && name != "":
+		secret, err := s.readSecret(name)
+		if err != nil {
+			return nil, err
+		}
+		return withNamespace(secret, namespace), nil
+	case resource == "secrets" && (r.Method == "POST" && name == "" || r.Method == "PUT"):
+		secret := &api.Secret{}
+		if err := decode(r, secret); err != nil {
+			return nil, err
+		}
+		_, err := s.readSecret(secret.Name)
+		if r.Method == "POST" && err == nil {
+			return nil, errors.NewAlreadyExists(secretResource, secret.Name)
+		} else if r.Method == "PUT" && err != nil {
+			return nil, err
+		}
+		if err := s.writeSecret(secret); err != nil {
+			return nil, err
+		}
+		return withNamespace(secret, namespace), nil
+	}
+	return nil, errors.NewBadRequest(fmt.Sprintf("%s %s isn't supported by pachd's lite mode", r.Method, r.URL.Path))
+}
+
+// servePodLogs writes the logs of one of the pod name's containers, see
+// podRuntime.logs.
+func (s *kubeServer) servePodLogs(w http.ResponseWriter, r *http.Request, name string) {
+	options := &api.PodLogOptions{}
+	if err := api.ParameterCodec.DecodeParameters(r.URL.Query(), v1.SchemeGroupVersion, options); err != nil {
+		writeError(w, errors.NewBadRequest(err.Error()))
+		return
+	}
+	s.mu.Lock()
+	pod, ok := s.pods[name]
+	var phase api.PodPhase
+	if ok {
+		phase = pod.Status.Phase
+	}
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, errors.NewNotFound(podResource, name))
+		return
+	}
+	if phase == api.PodPending {
+		// pachd's GetLogs skips pods whose logs fail with this reason, as
+		// it does in kubernetes
+		writeError(w, errors.NewBadRequest(fmt.Sprintf("container %q in pod %q is waiting to start: PodInitializing", options.Container, name)))
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusOK)
+	var out io.Writer = w
+	if flusher, ok := w.(http.Flusher); ok {
+		out = &flushWriter{w, flusher}
+	}
+	if err := s.runtime.logs(r.Context(), pod, options, out); err != nil {
+		protolion.Errorf("error reading the logs of pod %s: %v", name, err)
+	}
+}
+
+// flushWriter flushes each write to an HTTP response, so that followed logs
+// are streamed.
+type flushWriter struct {
+	w       io.Writer
+	flusher http.Flusher
+}
+
+func (f *flushWriter) Write(p []byte) (int, error) {
+	n, err := f.w.Write(p)
+	f.flusher.Flush()
+	return n, err
+}
+
+// nodes returns this machine, as the cluster's only node.
+func (s *kubeServer) nodes() *api.NodeList {
+	hostname, _ := os.Hostname()
+	cpus := resource.MustParse(strconv.Itoa(runtime.NumCPU()))
+	return &api.NodeList{
+		Items: []api.Node{{
+			ObjectMeta: api.ObjectMeta{Name: hostname},
+			Status: api.NodeStatus{
+				Capacity:    api.ResourceList{api.ResourceCPU: cpus},
+				Allocatable: api.ResourceList{api.ResourceCPU: cpus},
+				Addresses:   []api.NodeAddress{{Type: api.NodeInternalIP, Address: s.hostIP}},
+				Conditions:  []api.NodeCondition{{Type: api.NodeReady, Status: api.ConditionTrue}},
+			},
+		}},
+	}
+}
+
+// readSecret reads the secret name from s.secretsDir.
+func (s *kubeServer) readSecret(name string) (*api.Secret, error) {
+	dir := filepath.Join(s.secretsDir, name)
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.NewNotFound(secretResource, name)
+		}
+		return nil, err
+	}
+	secret := &api.Secret{
+		ObjectMeta: api.ObjectMeta{Name: name},
+		Data:       make(map[string][]byte),
+	}
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		if secret.Data[file.Name()], err = ioutil.ReadFile(filepath.Join(dir, file.Name())); err != nil {
+			return nil, err
+		}
+	}
+	return secret, nil
+}
+
+// writeSecret replaces the files of secret in s.secretsDir.
+func (s *kubeServer) writeSecret(secret *api.Secret) error {
+	dir := filepath.Join(s.secretsDir, secret.Name)
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	for key, value := range secret.Data {
+		if err := ioutil.WriteFile(filepath.Join(dir, key), value, 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withClusterIP returns service with the IP it's reached at: its ClusterIP,
+// if it has one, or else the IP of one of the running pods it selects, as
+// there's no proxy in front of them.
+func (s *kubeServer) withClusterIP(service *api.Service) *api.Service {
+	if service.Spec.ClusterIP != "" {
+		return service
+	}
+	result := *service
+	result.Spec.ClusterIP = s.podIP(service.Spec.Selector)
+	return &result
+}
+
+// withNamespace returns a copy of obj in namespace, which is what pachd
+// expects of the objects it reads back.
+func withNamespace(obj kuberuntime.Object, namespace string) kuberuntime.Object {
+	copied, err := api.Scheme.Copy(obj)
+	if err != nil {
+		panic(fmt.Sprintf("error copying %T: %v", obj, err))
+	}
+	meta, err := api.ObjectMetaFor(copied)
+	if err == nil {
+		meta.Namespace = namespace
+	}
+	return copied
+}
+
+func labelSelector(r *http.Request) (labels.Selector, error) {
+	options := &api.ListOptions{}
+	if err := api.ParameterCodec.DecodeParameters(r.URL.Query(), v1.SchemeGroupVersion, options); err != nil {
+		return nil, errors.NewBadRequest(err.Error())
+	}
+	if options.LabelSelector == nil {
+		return labels.Everything(), nil
+	}
+	return options.LabelSelector, nil
+}
+
+// decode decodes r's body into obj. An empty body leaves obj as it is.
+func decode(r *http.Request, obj kuberuntime.Object) error {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	if len(body) == 0 {
+		return nil
+	}
+	if err := kuberuntime.DecodeInto(api.Codecs.UniversalDecoder(), body, obj); err != nil {
+		return errors.NewBadRequest(err.Error())
+	}
+	return nil
+}
+
+var codec = api.Codecs.LegacyCodec(v1.SchemeGroupVersion)
+
+func writeObject(w http.ResponseWriter, status int, obj kuberuntime.Object) {
+	data, err := kuberuntime.Encode(codec, obj)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	statusErr, ok := err.(*errors.StatusError)
+	if !ok {
+		statusErr = errors.NewInternalError(err)
+	}
+	status := statusErr.ErrStatus
+	data, err := kuberuntime.Encode(codec, &status)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(int(status.Code))
+	w.Write(data)
+}
+
+func success() *unversioned.Status {
+	return &unversioned.Status{Status: unversioned.StatusSuccess}
+}
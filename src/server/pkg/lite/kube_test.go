@@ -0,0 +1,298 @@
+package lite
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+
+	"golang.org/x/net/context"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/client/restclient"
+	kube "k8s.io/kubernetes/pkg/client/unversioned"
+	"k8s.io/kubernetes/pkg/labels"
+)
+
+// fakeRuntime runs pods without containers.
+type fakeRuntime struct {
+	mu      sync.Mutex
+	running map[string]bool
+	// exits are the reasons that pods have exited, by name
+	exits  map[string]string
+	nextIP int
+}
+
+func (r *fakeRuntime) start(pod *api.Pod, env []api.EnvVar) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.running[pod.Name] = true
+	r.nextIP++
+	return fmt.Sprintf("172.17.0.%d", r.nextIP), nil
+}
+
+func (r *fakeRuntime) exited(pod *api.Pod) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.exits[pod.Name], nil
+}
+
+func (r *fakeRuntime) remove(pod *api.Pod) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.running, pod.Name)
+	return nil
+}
+
+func (r *fakeRuntime) removeAll() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.running = make(map[string]bool)
+	return nil
+}
+
+func (r *fakeRuntime) logs(ctx context.Context, pod *api.Pod, options *api.PodLogOptions, w io.Writer) error {
+	_, err := fmt.Fprintf(w, "logs of %s/%s\n", pod.Name, options.Container)
+	return err
+}
+
+func (r *fakeRuntime) numRunning() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.running)
+}
+
+func newTestServer(t *testing.T, dir string) (*kubeServer, *fakeRuntime, *kube.Client, func()) {
+	runtime := &fakeRuntime{running: make(map[string]bool), exits: make(map[string]string)}
+	s, err := newKubeServer(runtime, filepath.Join(dir, "state.json"), filepath.Join(dir, "secrets"), "10.0.0.1")
+	require.NoError(t, err)
+	server := httptest.NewServer(s)
+	kubeClient, err := kube.New(&restclient.Config{Host: server.URL})
+	require.NoError(t, err)
+	return s, runtime, kubeClient, server.Close
+}
+
+// reconcileUntil reconciles s until it has n pods that are running.
+func reconcileUntil(t *testing.T, s *kubeServer, n int) {
+	for i := 0; i < 100; i++ {
+		s.reconcile()
+		s.mu.Lock()
+		running := 0
+		for _, pod := range s.pods {
+			if pod.Status.Phase == api.PodRunning {
+				running++
+			}
+		}
+		s.mu.Unlock()
+		if running == n {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("server never had %d running pods", n)
+}
+
+func testRC(name string, replicas int32) *api.ReplicationController {
+	labels := map[string]string{"app": name}
+	return &api.ReplicationController{
+		ObjectMeta: api.ObjectMeta{Name: name, Labels: labels},
+		Spec: api.ReplicationControllerSpec{
+			Replicas: replicas,
+			Selector: labels,
+			Template: &api.PodTemplateSpec{
+				ObjectMeta: api.ObjectMeta{Labels: labels},
+				Spec: api.PodSpec{
+					Containers: []api.Container{{Name: "user", Image: "ubuntu:16.04"}},
+				},
+			},
+		},
+	}
+}
+
+func TestReplicationControllers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lite")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	s, runtime, kubeClient, closeServer := newTestServer(t, dir)
+	defer closeServer()
+
+	rcs := kubeClient.ReplicationControllers("default")
+	_, err = rcs.Create(testRC("pipeline-foo", 2))
+	require.NoError(t, err)
+	_, err = rcs.Create(testRC("pipeline-foo", 2))
+	require.YesError(t, err)
+	require.Matches(t, "already exists", err.Error())
+	_, err = rcs.Get("pipeline-bar")
+	require.YesError(t, err)
+	require.Matches(t, "not found", err.Error())
+
+	reconcileUntil(t, s, 2)
+	require.Equal(t, 2, runtime.numRunning())
+	pods, err := kubeClient.Pods("default").List(api.ListOptions{
+		LabelSelector: labels.SelectorFromSet(map[string]string{"app": "pipeline-foo"}),
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(pods.Items))
+	for _, pod := range pods.Items {
+		require.Equal(t, "default", pod.Namespace)
+		require.Equal(t, api.PodRunning, pod.Status.Phase)
+	}
+	logs, err := kubeClient.Pods("default").GetLogs(pods.Items[0].Name, &api.PodLogOptions{Container: "user"}).Do().Raw()
+	require.NoError(t, err)
+	require.Equal(t, fmt.Sprintf("logs of %s/user\n", pods.Items[0].Name), string(logs))
+
+	rc, err := rcs.Get("pipeline-foo")
+	require.NoError(t, err)
+	require.Equal(t, int32(2), rc.Status.Replicas)
+	rc.Spec.Replicas = 1
+	_, err = rcs.Update(rc)
+	require.NoError(t, err)
+	reconcileUntil(t, s, 1)
+
+	// a deleted pod is replaced
+	pods, err = kubeClient.Pods("default").List(api.ListOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(pods.Items))
+	require.NoError(t, kubeClient.Pods("default").Delete(pods.Items[0].Name, nil))
+	reconcileUntil(t, s, 1)
+	replaced, err := kubeClient.Pods("default").List(api.ListOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(replaced.Items))
+	require.NotEqual(t, pods.Items[0].Name, replaced.Items[0].Name)
+
+	// replication controllers outlive the server
+	s2, _, kubeClient2, closeServer2 := newTestServer(t, dir)
+	defer closeServer2()
+	_, err = kubeClient2.ReplicationControllers("default").Get("pipeline-foo")
+	require.NoError(t, err)
+	reconcileUntil(t, s2, 1)
+
+	falseVal := false
+	require.NoError(t, rcs.Delete("pipeline-foo", &api.DeleteOptions{OrphanDependents: &falseVal}))
+	pods, err = kubeClient.Pods("default").List(api.ListOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 0, len(pods.Items))
+}
+
+func TestFailedPods(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lite")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	s, runtime, kubeClient, closeServer := newTestServer(t, dir)
+	defer closeServer()
+
+	_, err = kubeClient.ReplicationControllers("default").Create(testRC("pipeline-foo", 1))
+	require.NoError(t, err)
+	reconcileUntil(t, s, 1)
+	pods, err := kubeClient.Pods("default").List(api.ListOptions{})
+	require.NoError(t, err)
+	name := pods.Items[0].Name
+	runtime.mu.Lock()
+	runtime.exits[name] = "container user exited with code 1"
+	runtime.mu.Unlock()
+
+	// the failed pod isn't replaced until restartBackoff has passed
+	s.reconcile()
+	pod, err := kubeClient.Pods("default").Get(name)
+	require.NoError(t, err)
+	require.Equal(t, api.PodFailed, pod.Status.Phase)
+	require.Equal(t, "container user exited with code 1", pod.Status.Message)
+	s.reconcile()
+	pods, err = kubeClient.Pods("default").List(api.ListOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(pods.Items))
+
+	s.mu.Lock()
+	s.failed[name] = unversioned.NewTime(time.Now().Add(-restartBackoff))
+	s.mu.Unlock()
+	reconcileUntil(t, s, 1)
+	pods, err = kubeClient.Pods("default").List(api.ListOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(pods.Items))
+
+	s.mu.Lock()
+	s.failed[name] = unversioned.NewTime(time.Now().Add(-failedPodTTL - time.Second))
+	s.mu.Unlock()
+	s.reconcile()
+	_, err = kubeClient.Pods("default").Get(name)
+	require.YesError(t, err)
+	require.Matches(t, "not found", err.Error())
+}
+
+func TestServices(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lite")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	s, _, kubeClient, closeServer := newTestServer(t, dir)
+	defer closeServer()
+
+	rc := testRC("etcd", 1)
+	require.NoError(t, s.putRC(rc))
+	require.NoError(t, s.putServices(etcdService(rc)))
+	reconcileUntil(t, s, 1)
+	service, err := kubeClient.Services("default").Get("etcd")
+	require.NoError(t, err)
+	require.Equal(t, "172.17.0.1", service.Spec.ClusterIP)
+
+	s.mu.Lock()
+	env := s.serviceEnv()
+	s.mu.Unlock()
+	require.Equal(t, api.EnvVar{Name: "ETCD_SERVICE_HOST", Value: "172.17.0.1"}, env[0])
+	require.Equal(t, api.EnvVar{Name: "ETCD_PORT_2379_TCP_ADDR", Value: "172.17.0.1"}, env[len(env)-1])
+
+	require.NoError(t, kubeClient.Services("default").Delete("etcd"))
+	_, err = kubeClient.Services("default").Get("etcd")
+	require.YesError(t, err)
+}
+
+func TestContainerEnv(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lite")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	s, _, kubeClient, closeServer := newTestServer(t, dir)
+	defer closeServer()
+
+	_, err = kubeClient.Secrets("default").Create(&api.Secret{
+		ObjectMeta: api.ObjectMeta{Name: "creds"},
+		Data:       map[string][]byte{"password": []byte("hunter2")},
+	})
+	require.NoError(t, err)
+	secret, err := kubeClient.Secrets("default").Get("creds")
+	require.NoError(t, err)
+	require.Equal(t, "hunter2", string(secret.Data["password"]))
+
+	pod := &api.Pod{ObjectMeta: api.ObjectMeta{Name: "pipeline-foo-abcde", Namespace: "default"}}
+	container := &api.Container{
+		Env: []api.EnvVar{
+			{Name: "FOO", Value: "bar"},
+			{Name: "PPS_POD_NAME", ValueFrom: &api.EnvVarSource{
+				FieldRef: &api.ObjectFieldSelector{FieldPath: "metadata.name"},
+			}},
+			{Name: "PPS_WORKER_IP", ValueFrom: &api.EnvVarSource{
+				FieldRef: &api.ObjectFieldSelector{FieldPath: "status.podIP"},
+			}},
+			{Name: "PASSWORD", ValueFrom: &api.EnvVarSource{
+				SecretKeyRef: &api.SecretKeySelector{
+					LocalObjectReference: api.LocalObjectReference{Name: "creds"},
+					Key:                  "password",
+				},
+			}},
+		},
+	}
+	env, err := containerEnv(pod, container, "172.17.0.2", []api.EnvVar{{Name: "PACHD_PORT_650_TCP_ADDR", Value: "10.0.0.1"}}, s.secretsDir)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"PACHD_PORT_650_TCP_ADDR=10.0.0.1",
+		"FOO=bar",
+		"PPS_POD_NAME=pipeline-foo-abcde",
+		"PPS_WORKER_IP=172.17.0.2",
+		"PASSWORD=hunter2",
+	}, env)
+}
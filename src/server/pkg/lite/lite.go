@@ -0,0 +1,212 @@
+// Package lite runs pachd on one machine, without kubernetes, so that
+// pipelines can be developed against a local Docker daemon. pachd talks to
+// an in-process stand-in for the parts of kubernetes' API that it uses, which
+// runs the pods of the replication controllers that pachd creates (its
+// workers, and etcd) as Docker containers.
+package lite
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/server/pkg/deploy/assets"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"golang.org/x/net/context"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/client/restclient"
+	kube "k8s.io/kubernetes/pkg/client/unversioned"
+)
+
+const (
+	// etcdName is the name of the replication controller, and service,
+	// that run etcd, as in a kubernetes deployment.
+	etcdName = "etcd"
+	// etcdStartTimeout bounds how long Start waits for etcd's pod to run,
+	// which includes pulling its image.
+	etcdStartTimeout = 5 * time.Minute
+	// reconcileInterval is how often pods are started and removed to match
+	// their replication controllers.
+	reconcileInterval = time.Second
+)
+
+// Options configures a Cluster.
+type Options struct {
+	// Dir is where the cluster keeps its state: its replication
+	// controllers and services, secrets (Dir/secrets/<name>/<key>), pods'
+	// emptyDir volumes and etcd's data.
+	Dir string
+	// HostIP is the address of this machine that containers reach pachd
+	// at, which is given to them as the address of the pachd service.
+	HostIP string
+	// Etcd is whether the cluster runs etcd, rather than pachd using an
+	// external one.
+	Etcd bool
+	// Registry is the registry that etcd's image is pulled from, see
+	// assets.AddRegistry.
+	Registry string
+	// KubeAddress is the address that the cluster serves its kubernetes
+	// API on. It defaults to a free port on localhost.
+	KubeAddress string
+	// PauseImage is the image of the container that owns each pod's
+	// network namespace. It defaults to DefaultPauseImage.
+	PauseImage string
+}
+
+// DefaultPauseImage is the default Options.PauseImage, the one kubernetes
+// uses.
+const DefaultPauseImage = "gcr.io/google_containers/pause-amd64:3.0"
+
+// Cluster is a local stand-in for the kubernetes cluster that pachd runs in.
+type Cluster struct {
+	server      *kubeServer
+	runtime     podRuntime
+	listener    net.Listener
+	cancel      context.CancelFunc
+	etcdAddress string
+}
+
+// Start starts a Cluster, and, if opts.Etcd is set, waits for its etcd to
+// run. The containers of pods run by an earlier Cluster in opts.Dir are
+// removed; the pods of its replication controllers are started again.
+func Start(opts Options) (*Cluster, error) {
+	if opts.PauseImage == "" {
+		opts.PauseImage = DefaultPauseImage
+	}
+	secretsDir := filepath.Join(opts.Dir, "secrets")
+	if err := os.MkdirAll(secretsDir, 0700); err != nil {
+		return nil, err
+	}
+	dockerClient, err := docker.NewClientFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to docker: %v", err)
+	}
+	runtime := newDockerRuntime(dockerClient, opts.Dir, secretsDir, opts.PauseImage)
+	if err := runtime.removeAll(); err != nil {
+		return nil, err
+	}
+	server, err := newKubeServer(runtime, filepath.Join(opts.Dir, "state.json"), secretsDir, opts.HostIP)
+	if err != nil {
+		return nil, err
+	}
+	if err := server.putServices(clusterServices(opts)...); err != nil {
+		return nil, err
+	}
+	etcd := etcdRC(opts)
+	if opts.Etcd {
+		if err := server.putRC(etcd); err != nil {
+			return nil, err
+		}
+		if err := server.putServices(etcdService(etcd)); err != nil {
+			return nil, err
+		}
+	}
+	if opts.KubeAddress == "" {
+		opts.KubeAddress = "127.0.0.1:0"
+	}
+	listener, err := net.Listen("tcp", opts.KubeAddress)
+	if err != nil {
+		return nil, err
+	}
+	go http.Serve(listener, server)
+	ctx, cancel := context.WithCancel(context.Background())
+	go server.run(ctx, reconcileInterval)
+	c := &Cluster{
+		server:   server,
+		runtime:  runtime,
+		listener: listener,
+		cancel:   cancel,
+	}
+	if opts.Etcd {
+		if c.etcdAddress, err = server.waitForPodIP(etcd.Spec.Selector, etcdStartTimeout); err != nil {
+			c.Close()
+			return nil, fmt.Errorf("error starting etcd: %v", err)
+		}
+	}
+	return c, nil
+}
+
+// KubeClient returns a client of the cluster's kubernetes API.
+func (c *Cluster) KubeClient() (*kube.Client, error) {
+	return kube.New(&restclient.Config{
+		Host: "http://" + c.listener.Addr().String(),
+	})
+}
+
+// EtcdAddress returns the address of the cluster's etcd, if it runs one.
+func (c *Cluster) EtcdAddress() string {
+	return c.etcdAddress
+}
+
+// Close removes the containers of the cluster's pods. Its replication
+// controllers are kept, so their pods are started again by the next Cluster
+// in the same Dir.
+func (c *Cluster) Close() error {
+	c.cancel()
+	c.listener.Close()
+	return c.runtime.removeAll()
+}
+
+// clusterServices returns the services that point outside of the cluster's
+// pods: pachd, and kubernetes itself, whose addresses containers are given in
+// their env, as pachd's workers expect. Their ports are the ones in a
+// kubernetes deployment, as workers expect e.g. PACHD_PORT_650_TCP_ADDR,
+// though pachd serves its API on whichever port it's given locally.
+func clusterServices(opts Options) []*api.Service {
+	return []*api.Service{
+		{
+			ObjectMeta: api.ObjectMeta{Name: "kubernetes"},
+			Spec: api.ServiceSpec{
+				ClusterIP: opts.HostIP,
+				Ports:     []api.ServicePort{{Name: "https", Port: 443, Protocol: api.ProtocolTCP}},
+			},
+		},
+		{
+			ObjectMeta: api.ObjectMeta{Name: "pachd"},
+			Spec: api.ServiceSpec{
+				ClusterIP: opts.HostIP,
+				Ports:     []api.ServicePort{{Name: "api-grpc-port", Port: 650, Protocol: api.ProtocolTCP}},
+			},
+		},
+	}
+}
+
+// etcdRC returns the replication controller that runs etcd, with the same
+// pod as a local kubernetes deployment, see assets.EtcdDeployment.
+func etcdRC(opts Options) *api.ReplicationController {
+	// resource requests aren't enforced locally
+	deployment := assets.EtcdDeployment(&assets.AssetOpts{
+		Registry:       opts.Registry,
+		EtcdCPURequest: "0",
+		EtcdMemRequest: "0",
+	}, opts.Dir)
+	return &api.ReplicationController{
+		ObjectMeta: api.ObjectMeta{
+			Name:   etcdName,
+			Labels: deployment.Labels,
+		},
+		Spec: api.ReplicationControllerSpec{
+			Replicas: 1,
+			Selector: deployment.Spec.Selector.MatchLabels,
+			Template: &deployment.Spec.Template,
+		},
+	}
+}
+
+// etcdService returns the service of etcd's pod, which rc runs.
+func etcdService(rc *api.ReplicationController) *api.Service {
+	return &api.Service{
+		ObjectMeta: api.ObjectMeta{
+			Name:   etcdName,
+			Labels: rc.Labels,
+		},
+		Spec: api.ServiceSpec{
+			Selector: rc.Spec.Selector,
+			Ports:    []api.ServicePort{{Name: "client-port", Port: 2379, Protocol: api.ProtocolTCP}},
+		},
+	}
+}
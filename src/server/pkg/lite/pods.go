@@ -0,0 +1,352 @@
+package lite
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/uuid"
+
+	"go.pedge.io/lion/proto"
+	"golang.org/x/net/context"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/labels"
+)
+
+const (
+	// restartBackoff is how long a replication controller waits, after one
+	// of its pods fails, before it starts more, so that a pod that can't
+	// start isn't retried in a tight loop.
+	restartBackoff = 10 * time.Second
+	// failedPodTTL is how long failed pods, and their containers, are kept
+	// so that their logs can be read.
+	failedPodTTL = time.Minute
+)
+
+// podRuntime runs the containers of pods.
+type podRuntime interface {
+	// start runs pod's init containers to completion, and then starts its
+	// containers, with env added to each one's. It returns pod's IP.
+	start(pod *api.Pod, env []api.EnvVar) (string, error)
+	// exited returns why one of pod's containers has exited, or "" if
+	// they're all running.
+	exited(pod *api.Pod) (string, error)
+	// remove stops and removes pod's containers.
+	remove(pod *api.Pod) error
+	// removeAll stops and removes the containers of every pod.
+	removeAll() error
+	// logs writes the logs of the container of pod named in options to w.
+	logs(ctx context.Context, pod *api.Pod, options *api.PodLogOptions, w io.Writer) error
+}
+
+// run reconciles s's pods every interval, until ctx is done.
+func (s *kubeServer) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		s.reconcile()
+	}
+}
+
+// reconcile does what kubernetes' controllers and kubelet would: it fails
+// the running pods whose containers have exited, and starts and removes
+// pods so that each replication controller has as many pods that haven't
+// failed as it has replicas. Failed pods are kept for failedPodTTL, and
+// aren't replaced for restartBackoff.
+func (s *kubeServer) reconcile() {
+	s.failExited()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for name, failed := range s.failed {
+		if now.Sub(failed.Time) > failedPodTTL {
+			s.removePod(s.pods[name])
+		}
+	}
+	for _, rc := range s.rcs {
+		if rc.Spec.Template == nil {
+			continue
+		}
+		pods := s.rcPods(rc, false)
+		replicas := int(rc.Spec.Replicas)
+		if len(pods) < replicas && !s.recentlyFailed(rc, now) {
+			for i := len(pods); i < replicas; i++ {
+				s.startPod(rc)
+			}
+		}
+		// the newest pods are removed first
+		for i := replicas; i < len(pods); i++ {
+			s.removePod(pods[i])
+		}
+		rc.Status.Replicas = int32(len(s.rcPods(rc, false)))
+	}
+}
+
+// failExited fails each running pod that one of whose containers has
+// exited. It doesn't hold s.mu while the runtime checks.
+func (s *kubeServer) failExited() {
+	s.mu.Lock()
+	var running []*api.Pod
+	for _, pod := range s.pods {
+		if pod.Status.Phase == api.PodRunning {
+			running = append(running, pod)
+		}
+	}
+	s.mu.Unlock()
+	for _, pod := range running {
+		reason, err := s.runtime.exited(pod)
+		if err != nil {
+			protolion.Errorf("error checking the containers of pod %s: %v", pod.Name, err)
+			continue
+		}
+		if reason == "" {
+			continue
+		}
+		s.mu.Lock()
+		if s.pods[pod.Name] == pod && pod.Status.Phase == api.PodRunning {
+			protolion.Infof("pod %s failed: %s", pod.Name, reason)
+			s.fail(pod, reason)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// rcPods returns rc's pods, oldest first, including the ones that have
+// failed if all is set. s.mu must be held.
+func (s *kubeServer) rcPods(rc *api.ReplicationController, all bool) []*api.Pod {
+	var result []*api.Pod
+	if len(rc.Spec.Selector) == 0 {
+		return nil
+	}
+	selector := labels.SelectorFromSet(rc.Spec.Selector)
+	for _, pod := range s.pods {
+		if (all || pod.Status.Phase != api.PodFailed) && selector.Matches(labels.Set(pod.Labels)) {
+			result = append(result, pod)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].CreationTimestamp.Equal(result[j].CreationTimestamp) {
+			return result[i].CreationTimestamp.Before(result[j].CreationTimestamp)
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// recentlyFailed returns true if one of rc's pods failed within
+// restartBackoff of now. s.mu must be held.
+func (s *kubeServer) recentlyFailed(rc *api.ReplicationController, now time.Time) bool {
+	for _, pod := range s.rcPods(rc, true) {
+		if failed, ok := s.failed[pod.Name]; ok && now.Sub(failed.Time) < restartBackoff {
+			return true
+		}
+	}
+	return false
+}
+
+// startPod creates a pod from rc's template, which is pending until the
+// runtime has started its containers. s.mu must be held.
+func (s *kubeServer) startPod(rc *api.ReplicationController) {
+	pod := &api.Pod{
+		ObjectMeta: api.ObjectMeta{
+			Name:        fmt.Sprintf("%s-%s", rc.Name, uuid.NewWithoutDashes()[:5]),
+			Namespace:   rc.Namespace,
+			Labels:      rc.Spec.Template.Labels,
+			Annotations: rc.Spec.Template.Annotations,
+		},
+		Spec: rc.Spec.Template.Spec,
+		Status: api.PodStatus{
+			Phase:  api.PodPending,
+			HostIP: s.hostIP,
+			Conditions: []api.PodCondition{
+				{Type: api.PodScheduled, Status: api.ConditionTrue},
+			},
+		},
+	}
+	setCreated(&pod.ObjectMeta)
+	s.pods[pod.Name] = pod
+	env := s.serviceEnv()
+	go func() {
+		ip, err := s.runtime.start(pod, env)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.pods[pod.Name] != pod {
+			// the pod was removed while it was starting
+			go s.removeContainers(pod)
+			return
+		}
+		if err != nil {
+			protolion.Errorf("error starting pod %s: %v", pod.Name, err)
+			s.fail(pod, err.Error())
+			return
+		}
+		now := unversioned.Now()
+		pod.Status.Phase = api.PodRunning
+		pod.Status.PodIP = ip
+		pod.Status.StartTime = &now
+		pod.Status.Conditions = append(pod.Status.Conditions, api.PodCondition{
+			Type:   api.PodReady,
+			Status: api.ConditionTrue,
+		})
+	}()
+}
+
+// fail marks pod as failed, for reason. s.mu must be held.
+func (s *kubeServer) fail(pod *api.Pod, reason string) {
+	pod.Status.Phase = api.PodFailed
+	pod.Status.Message = reason
+	s.failed[pod.Name] = unversioned.Now()
+}
+
+// removePod removes pod, and its containers. The containers of pods that
+// are still starting are removed once they've started. s.mu must be held.
+func (s *kubeServer) removePod(pod *api.Pod) {
+	if pod == nil {
+		return
+	}
+	delete(s.pods, pod.Name)
+	delete(s.failed, pod.Name)
+	if pod.Status.Phase != api.PodPending {
+		go s.removeContainers(pod)
+	}
+}
+
+func (s *kubeServer) removeContainers(pod *api.Pod) {
+	if err := s.runtime.remove(pod); err != nil {
+		protolion.Errorf("error removing the containers of pod %s: %v", pod.Name, err)
+	}
+}
+
+// podIP returns the IP of the first running pod, by name, that selector
+// selects, or "" if there isn't one. s.mu must be held.
+func (s *kubeServer) podIP(selector map[string]string) string {
+	if len(selector) == 0 {
+		return ""
+	}
+	var names []string
+	for name, pod := range s.pods {
+		if pod.Status.Phase == api.PodRunning && labels.SelectorFromSet(selector).Matches(labels.Set(pod.Labels)) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+	return s.pods[names[0]].Status.PodIP
+}
+
+// waitForPodIP waits for a pod that selector selects to run, for at most
+// timeout, and returns its IP.
+func (s *kubeServer) waitForPodIP(selector map[string]string, timeout time.Duration) (string, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		s.mu.Lock()
+		ip := s.podIP(selector)
+		s.mu.Unlock()
+		if ip != "" {
+			return ip, nil
+		}
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("no pod with labels %v was running after %v", selector, timeout)
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// serviceEnv returns the env that kubernetes gives containers for each
+// service that has an IP, e.g. ETCD_SERVICE_HOST and
+// ETCD_PORT_2379_TCP_ADDR for the service etcd. s.mu must be held.
+func (s *kubeServer) serviceEnv() []api.EnvVar {
+	var names []string
+	for name := range s.services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var result []api.EnvVar
+	for _, name := range names {
+		service := s.withClusterIP(s.services[name])
+		ip := service.Spec.ClusterIP
+		if ip == "" || ip == api.ClusterIPNone || len(service.Spec.Ports) == 0 {
+			continue
+		}
+		prefix := strings.ToUpper(strings.Replace(name, "-", "_", -1))
+		url := func(port api.ServicePort) string {
+			return fmt.Sprintf("%s://%s:%d", strings.ToLower(protocol(port)), ip, port.Port)
+		}
+		first := service.Spec.Ports[0]
+		result = append(result,
+			api.EnvVar{Name: prefix + "_SERVICE_HOST", Value: ip},
+			api.EnvVar{Name: prefix + "_SERVICE_PORT", Value: strconv.Itoa(int(first.Port))},
+			api.EnvVar{Name: prefix + "_PORT", Value: url(first)},
+		)
+		for _, port := range service.Spec.Ports {
+			portPrefix := fmt.Sprintf("%s_PORT_%d_%s", prefix, port.Port, protocol(port))
+			result = append(result,
+				api.EnvVar{Name: portPrefix, Value: url(port)},
+				api.EnvVar{Name: portPrefix + "_PROTO", Value: strings.ToLower(protocol(port))},
+				api.EnvVar{Name: portPrefix + "_PORT", Value: strconv.Itoa(int(port.Port))},
+				api.EnvVar{Name: portPrefix + "_ADDR", Value: ip},
+			)
+		}
+	}
+	return result
+}
+
+func protocol(port api.ServicePort) string {
+	if port.Protocol == "" {
+		return string(api.ProtocolTCP)
+	}
+	return string(port.Protocol)
+}
+
+// containerEnv returns the env of container, one of pod's, as "name=value"
+// strings: serviceEnv, followed by the container's own env, with references
+// to pod's fields and to the keys of secrets in secretsDir resolved.
+func containerEnv(pod *api.Pod, container *api.Container, podIP string, serviceEnv []api.EnvVar, secretsDir string) ([]string, error) {
+	var result []string
+	for _, env := range serviceEnv {
+		result = append(result, env.Name+"="+env.Value)
+	}
+	for _, env := range container.Env {
+		value := env.Value
+		if source := env.ValueFrom; source != nil {
+			switch {
+			case source.FieldRef != nil:
+				switch source.FieldRef.FieldPath {
+				case "metadata.name":
+					value = pod.Name
+				case "metadata.namespace":
+					value = pod.Namespace
+				case "status.podIP":
+					value = podIP
+				case "status.hostIP":
+					value = pod.Status.HostIP
+				default:
+					return nil, fmt.Errorf("env var %s references the field %s, which pachd's lite mode doesn't support", env.Name, source.FieldRef.FieldPath)
+				}
+			case source.SecretKeyRef != nil:
+				data, err := ioutil.ReadFile(filepath.Join(secretsDir, source.SecretKeyRef.Name, source.SecretKeyRef.Key))
+				if err != nil {
+					return nil, fmt.Errorf("error reading key %s of secret %s for env var %s: %v", source.SecretKeyRef.Key, source.SecretKeyRef.Name, env.Name, err)
+				}
+				value = string(data)
+			default:
+				return nil, fmt.Errorf("env var %s has a source that pachd's lite mode doesn't support", env.Name)
+			}
+		}
+		result = append(result, env.Name+"="+value)
+	}
+	return result, nil
+}
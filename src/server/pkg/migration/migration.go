@@ -0,0 +1,246 @@
+// Package migration versions the schema of the metadata that pachd keeps in
+// etcd. When pachd starts, it runs the migrations between the schema version
+// that etcd is at and the one that pachd uses, so that pachd can be upgraded
+// without wiping the cluster.
+package migration
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	etcd "github.com/coreos/etcd/clientv3"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+	"go.pedge.io/lion/proto"
+	"golang.org/x/net/context"
+)
+
+// versionKey is the etcd key that holds etcd's schema version. Clusters
+// which predate migrations don't have it, and are at version 0.
+const versionKey = "pachyderm_schema_version"
+
+// errDryRun aborts the transaction of a migration that's run in a dry run.
+var errDryRun = errors.New("dry run")
+
+// Env is what migrations read and write etcd with.
+type Env struct {
+	EtcdClient *etcd.Client
+	// PFSPrefix, PPSPrefix and AuthPrefix are the etcd prefixes of PFS's,
+	// PPS's and auth's collections.
+	PFSPrefix  string
+	PPSPrefix  string
+	AuthPrefix string
+	// Cipher encrypts the values of the collections that pachd encrypts,
+	// see col.NewEncryptedCollection.
+	Cipher *col.Cipher
+}
+
+// A Migration changes etcd from one schema version to the next.
+type Migration struct {
+	// Version is the schema version that Up migrates etcd to, from
+	// Version-1. Down migrates it back.
+	Version     int
+	Description string
+	// Up and Down read what they rewrite with env.EtcdClient and write
+	// through stm. Each runs in a single transaction along with the change
+	// to etcd's schema version, so that a migration is either applied in
+	// full or not at all, even if several pachds run it at once.
+	Up   func(env *Env, stm col.STM) error
+	Down func(env *Env, stm col.STM) error
+}
+
+// Migrations are pachd's migrations, in order. Version 0 is the schema that
+// pachd used before it had any; each change to the schema since adds a
+// migration to the end.
+var Migrations []*Migration
+
+// Latest returns the schema version that migrations migrate etcd to.
+func Latest(migrations []*Migration) int {
+	return len(migrations)
+}
+
+// Options configure Run.
+type Options struct {
+	// Target is the schema version to migrate etcd to, or the latest if
+	// it's negative. If it's earlier than etcd's version, Run rolls back
+	// the migrations after it.
+	Target int
+	// DryRun, if true, makes Run log the migrations that it would run, and
+	// the keys they would write, without changing etcd. Each migration sees
+	// etcd as it is, not as the migrations before it would leave it.
+	DryRun bool
+}
+
+// Run migrates etcd from its schema version to options.Target, and returns
+// the version that etcd is then at.
+func Run(ctx context.Context, env *Env, migrations []*Migration, options Options) (int, error) {
+	if err := validate(migrations); err != nil {
+		return 0, err
+	}
+	current, err := Version(ctx, env.EtcdClient)
+	if err != nil {
+		return 0, err
+	}
+	target := options.Target
+	if target < 0 {
+		target = Latest(migrations)
+	}
+	steps, err := plan(migrations, current, target)
+	if err != nil {
+		return 0, err
+	}
+	for _, step := range steps {
+		if err := runStep(ctx, env, step, options.DryRun); err != nil {
+			return 0, err
+		}
+	}
+	if options.DryRun {
+		return current, nil
+	}
+	return target, nil
+}
+
+// Version returns etcd's schema version.
+func Version(ctx context.Context, etcdClient *etcd.Client) (int, error) {
+	resp, err := etcdClient.Get(ctx, versionKey)
+	if err != nil {
+		return 0, err
+	}
+	if len(resp.Kvs) == 0 {
+		return 0, nil
+	}
+	return parseVersion(string(resp.Kvs[0].Value))
+}
+
+func parseVersion(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	version, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("malformed schema version %q: %v", value, err)
+	}
+	return version, nil
+}
+
+// validate returns an error if migrations aren't numbered 1, 2, 3... in
+// order, or if any of them can't be both applied and rolled back.
+func validate(migrations []*Migration) error {
+	for i, migration := range migrations {
+		if migration.Version != i+1 {
+			return fmt.Errorf("migration %d (%s) should have version %d", migration.Version, migration.Description, i+1)
+		}
+		if migration.Up == nil || migration.Down == nil {
+			return fmt.Errorf("migration %d (%s) must have both Up and Down", migration.Version, migration.Description)
+		}
+	}
+	return nil
+}
+
+// A step applies or rolls back one migration.
+type step struct {
+	migration *Migration
+	up        bool
+}
+
+// from and to are the schema versions that step migrates etcd between.
+func (s step) from() int {
+	if s.up {
+		return s.migration.Version - 1
+	}
+	return s.migration.Version
+}
+
+func (s step) to() int {
+	if s.up {
+		return s.migration.Version
+	}
+	return s.migration.Version - 1
+}
+
+func (s step) String() string {
+	if s.up {
+		return fmt.Sprintf("migration %d (%s)", s.migration.Version, s.migration.Description)
+	}
+	return fmt.Sprintf("rollback of migration %d (%s)", s.migration.Version, s.migration.Description)
+}
+
+// plan returns the steps that migrate etcd from schema version current to
+// target, in the order in which they must run.
+func plan(migrations []*Migration, current int, target int) ([]step, error) {
+	latest := Latest(migrations)
+	if current > latest {
+		return nil, fmt.Errorf("etcd is at schema version %d, which is newer than this pachd's (%d); roll it back with the newer pachd before downgrading", current, latest)
+	}
+	if target > latest {
+		return nil, fmt.Errorf("can't migrate to schema version %d, this pachd's latest is %d", target, latest)
+	}
+	var steps []step
+	for version := current + 1; version <= target; version++ {
+		steps = append(steps, step{migration: migrations[version-1], up: true})
+	}
+	for version := current; version > target; version-- {
+		steps = append(steps, step{migration: migrations[version-1], up: false})
+	}
+	return steps, nil
+}
+
+func runStep(ctx context.Context, env *Env, step step, dryRun bool) error {
+	f := step.migration.Down
+	if step.up {
+		f = step.migration.Up
+	}
+	if dryRun {
+		protolion.Infof("dry run: would run %s", step)
+	} else {
+		protolion.Infof("running %s", step)
+	}
+	if _, err := col.NewSTM(ctx, env.EtcdClient, func(stm col.STM) error {
+		version, err := parseVersion(stm.Get(versionKey))
+		if err != nil {
+			return err
+		}
+		if step.up && version >= step.to() || !step.up && version <= step.to() {
+			// another pachd has already run it
+			return nil
+		}
+		if version != step.from() {
+			return fmt.Errorf("etcd's schema version changed to %d while running %s", version, step)
+		}
+		if dryRun {
+			stm = &dryRunSTM{STM: stm}
+		}
+		if err := f(env, stm); err != nil {
+			return err
+		}
+		stm.Put(versionKey, strconv.Itoa(step.to()))
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	}); err != nil && err != errDryRun {
+		return fmt.Errorf("error running %s: %v", step, err)
+	}
+	return nil
+}
+
+// dryRunSTM logs the keys that a migration writes. Its transaction is
+// aborted, so they're never written.
+type dryRunSTM struct {
+	col.STM
+}
+
+func (s *dryRunSTM) Put(key, val string, opts ...etcd.OpOption) {
+	protolion.Infof("dry run: would put %s", key)
+	s.STM.Put(key, val, opts...)
+}
+
+func (s *dryRunSTM) Del(key string) {
+	protolion.Infof("dry run: would delete %s", key)
+	s.STM.Del(key)
+}
+
+func (s *dryRunSTM) DelAll(key string) {
+	protolion.Infof("dry run: would delete everything under %s", key)
+	s.STM.DelAll(key)
+}
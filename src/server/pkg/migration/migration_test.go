@@ -0,0 +1,71 @@
+package migration
+
+import (
+	"testing"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+)
+
+func noop(env *Env, stm col.STM) error { return nil }
+
+func testMigrations(n int) []*Migration {
+	var migrations []*Migration
+	for i := 1; i <= n; i++ {
+		migrations = append(migrations, &Migration{Version: i, Up: noop, Down: noop})
+	}
+	return migrations
+}
+
+func versions(steps []step) []int {
+	var result []int
+	for _, step := range steps {
+		result = append(result, step.to())
+	}
+	return result
+}
+
+func TestPlan(t *testing.T) {
+	migrations := testMigrations(3)
+	steps, err := plan(migrations, 0, 3)
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 2, 3}, versions(steps))
+	for _, step := range steps {
+		require.True(t, step.up)
+	}
+
+	steps, err = plan(migrations, 1, 3)
+	require.NoError(t, err)
+	require.Equal(t, []int{2, 3}, versions(steps))
+
+	steps, err = plan(migrations, 3, 3)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(steps))
+
+	// rollbacks run the newest migration's Down first
+	steps, err = plan(migrations, 3, 1)
+	require.NoError(t, err)
+	require.Equal(t, []int{2, 1}, versions(steps))
+	require.Equal(t, 3, steps[0].from())
+	require.False(t, steps[0].up)
+
+	// etcd was migrated by a newer pachd
+	_, err = plan(migrations, 4, 3)
+	require.YesError(t, err)
+	_, err = plan(migrations, 0, 4)
+	require.YesError(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	require.NoError(t, validate(nil))
+	require.NoError(t, validate(testMigrations(3)))
+	require.NoError(t, validate(Migrations))
+
+	migrations := testMigrations(3)
+	migrations[1].Version = 3
+	require.YesError(t, validate(migrations))
+
+	migrations = testMigrations(3)
+	migrations[2].Down = nil
+	require.YesError(t, validate(migrations))
+}
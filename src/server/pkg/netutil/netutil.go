@@ -5,7 +5,8 @@ import (
 	"net"
 )
 
-// ExternalIP returns the external IP address of the host.
+// ExternalIP returns the external IP address of the host. IPv4 addresses are
+// preferred, but on IPv6-only hosts it returns a global IPv6 address.
 //
 // Taken from https://code.google.com/p/whispering-gophers/source/browse/util/helper.go.
 func ExternalIP() (string, error) {
@@ -13,6 +14,7 @@ func ExternalIP() (string, error) {
 	if err != nil {
 		return "", err
 	}
+	var ipv6 string
 	for _, iface := range ifaces {
 		if iface.Flags&net.FlagUp == 0 {
 			continue // interface down
@@ -35,12 +37,19 @@ func ExternalIP() (string, error) {
 			if ip == nil || ip.IsLoopback() {
 				continue
 			}
-			ip = ip.To4()
-			if ip == nil {
-				continue // not an ipv4 address
+			if ip.To4() == nil {
+				// not an ipv4 address, link-local ipv6 addresses can't be
+				// reached from other hosts
+				if ipv6 == "" && ip.IsGlobalUnicast() {
+					ipv6 = ip.String()
+				}
+				continue
 			}
-			return ip.String(), nil
+			return ip.To4().String(), nil
 		}
 	}
+	if ipv6 != "" {
+		return ipv6, nil
+	}
 	return "", errors.New("are you connected to the network?")
 }
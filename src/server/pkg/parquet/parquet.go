@@ -0,0 +1,401 @@
+// Package parquet selects some of the columns and row groups of Parquet
+// files, without decoding their pages, so that clients that only need part
+// of a file don't have to read all of it.
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	// Magic starts and ends every Parquet file.
+	Magic = "PAR1"
+	// TailSize is the size of the end of a Parquet file that says how long
+	// its metadata is: the metadata's length, and Magic.
+	TailSize = 8
+)
+
+// The ids of the fields of Parquet's thrift structs that Select reads or
+// changes.
+const (
+	// FileMetaData
+	fileSchema       = 2
+	fileNumRows      = 3
+	fileRowGroups    = 4
+	fileColumnOrders = 7
+
+	// SchemaElement
+	schemaName        = 4
+	schemaNumChildren = 5
+
+	// RowGroup
+	rowGroupColumns             = 1
+	rowGroupTotalByteSize       = 2
+	rowGroupNumRows             = 3
+	rowGroupSortingColumns      = 4
+	rowGroupFileOffset          = 5
+	rowGroupTotalCompressedSize = 6
+	rowGroupOrdinal             = 7
+
+	// ColumnChunk
+	chunkFilePath                = 1
+	chunkFileOffset              = 2
+	chunkMetaData                = 3
+	chunkOffsetIndexOffset       = 4
+	chunkOffsetIndexLength       = 5
+	chunkColumnIndexOffset       = 6
+	chunkColumnIndexLength       = 7
+	chunkCryptoMetaData          = 8
+	chunkEncryptedColumnMetadata = 9
+
+	// ColumnMetaData
+	metaTotalUncompressedSize = 6
+	metaTotalCompressedSize   = 7
+	metaDataPageOffset        = 9
+	metaIndexPageOffset       = 10
+	metaDictionaryPageOffset  = 11
+	metaBloomFilterOffset     = 14
+	metaBloomFilterLength     = 15
+)
+
+// Range is a range of bytes of a Parquet file.
+type Range struct {
+	Offset int64
+	Size   int64
+}
+
+// Selection is a Parquet file that holds some of the columns and row groups
+// of another. Its content is Magic, then the ranges of the other file in
+// Ranges, in order, then Footer.
+type Selection struct {
+	Ranges []Range
+	// Footer is the selection's metadata, its length and Magic.
+	Footer []byte
+}
+
+// Size returns the size of the Parquet file that s is.
+func (s *Selection) Size() int64 {
+	size := int64(len(Magic) + len(s.Footer))
+	for _, r := range s.Ranges {
+		size += r.Size
+	}
+	return size
+}
+
+// MetadataSize returns the size of the metadata of a Parquet file, given its
+// last TailSize bytes. The metadata is right before them.
+func MetadataSize(tail []byte) (int64, error) {
+	if len(tail) != TailSize {
+		return 0, fmt.Errorf("file is too short to be a Parquet file")
+	}
+	switch string(tail[4:]) {
+	case Magic:
+	case "PARE":
+		return 0, fmt.Errorf("Parquet files with encrypted footers aren't supported")
+	default:
+		return 0, fmt.Errorf("file isn't a Parquet file")
+	}
+	return int64(binary.LittleEndian.Uint32(tail[:4])), nil
+}
+
+// Select returns the Parquet file that holds the columns and row groups,
+// given by their indexes, of the file whose size is size, and whose metadata
+// is metadata. Columns are top-level columns, all of a nested column's
+// leaves are selected with it. Columns and row groups are in the selection
+// in the same order as in the file, whatever order they're given in, and
+// all of them are selected if none are given.
+func Select(metadata []byte, size int64, columns []string, rowGroups []int64) (*Selection, error) {
+	file, err := decodeStruct(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding Parquet metadata: %v", err)
+	}
+	// the column chunks are in the file before its metadata
+	dataEnd := size - TailSize - int64(len(metadata))
+	if dataEnd < int64(len(Magic)) {
+		return nil, fmt.Errorf("file is too short to be a Parquet file")
+	}
+
+	schema, err := file.structs(fileSchema)
+	if err != nil {
+		return nil, err
+	}
+	topLevel, err := topLevelColumns(schema)
+	if err != nil {
+		return nil, err
+	}
+	selected, err := selectColumns(topLevel, columns)
+	if err != nil {
+		return nil, err
+	}
+	// the leaves of the selected columns, which have column chunks
+	var leaves []int
+	newSchema := []*tstruct{schema[0]}
+	for _, c := range selected {
+		for leaf := c.firstLeaf; leaf < c.firstLeaf+c.leaves; leaf++ {
+			leaves = append(leaves, leaf)
+		}
+		newSchema = append(newSchema, schema[c.start:c.end]...)
+	}
+	numLeaves := 0
+	for _, c := range topLevel {
+		numLeaves += c.leaves
+	}
+	schema[0].setInt(schemaNumChildren, int64(len(selected)))
+	file.setStructs(fileSchema, newSchema)
+
+	if orders, err := file.structs(fileColumnOrders); err != nil {
+		return nil, err
+	} else if orders != nil {
+		if len(orders) != numLeaves {
+			return nil, fmt.Errorf("Parquet metadata has %d column orders for %d columns", len(orders), numLeaves)
+		}
+		var newOrders []*tstruct
+		for _, leaf := range leaves {
+			newOrders = append(newOrders, orders[leaf])
+		}
+		file.setStructs(fileColumnOrders, newOrders)
+	}
+
+	groups, err := file.structs(fileRowGroups)
+	if err != nil {
+		return nil, err
+	}
+	groups, err = selectRowGroups(groups, rowGroups)
+	if err != nil {
+		return nil, err
+	}
+	s := &Selection{}
+	offset := int64(len(Magic))
+	var numRows int64
+	for _, group := range groups {
+		chunks, err := group.structs(rowGroupColumns)
+		if err != nil {
+			return nil, err
+		}
+		if len(chunks) != numLeaves {
+			return nil, fmt.Errorf("Parquet row group has %d column chunks for %d columns", len(chunks), numLeaves)
+		}
+		var newChunks []*tstruct
+		var compressed, uncompressed int64
+		for _, leaf := range leaves {
+			chunk := chunks[leaf]
+			r, err := moveChunk(chunk, offset, dataEnd)
+			if err != nil {
+				return nil, err
+			}
+			if len(newChunks) == 0 && group.get(rowGroupFileOffset) != nil {
+				group.setInt(rowGroupFileOffset, offset)
+			}
+			s.add(r)
+			offset += r.Size
+			compressed += r.Size
+			meta := chunk.get(chunkMetaData).s
+			n, _ := meta.getInt(metaTotalUncompressedSize)
+			uncompressed += n
+			newChunks = append(newChunks, chunk)
+		}
+		group.setStructs(rowGroupColumns, newChunks)
+		group.setInt(rowGroupTotalByteSize, uncompressed)
+		if group.get(rowGroupTotalCompressedSize) != nil {
+			group.setInt(rowGroupTotalCompressedSize, compressed)
+		}
+		// sorting columns are referred to by their indexes, which change,
+		// and ordinals are only needed to decrypt files
+		group.del(rowGroupSortingColumns)
+		group.del(rowGroupOrdinal)
+		n, _ := group.getInt(rowGroupNumRows)
+		numRows += n
+	}
+	file.setStructs(fileRowGroups, groups)
+	file.setInt(fileNumRows, numRows)
+
+	encoded := encodeStruct(file)
+	var footer bytes.Buffer
+	footer.Write(encoded)
+	var length [4]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(len(encoded)))
+	footer.Write(length[:])
+	footer.WriteString(Magic)
+	s.Footer = footer.Bytes()
+	return s, nil
+}
+
+// add appends r to s's ranges, merging it with the last one if it follows
+// it, so that adjacent column chunks are read together.
+func (s *Selection) add(r Range) {
+	if n := len(s.Ranges); n > 0 && s.Ranges[n-1].Offset+s.Ranges[n-1].Size == r.Offset {
+		s.Ranges[n-1].Size += r.Size
+		return
+	}
+	s.Ranges = append(s.Ranges, r)
+}
+
+// column is a top-level column of a schema.
+type column struct {
+	name string
+	// start and end are the indexes in the schema of the column's element
+	// and of the element after its last descendant
+	start, end int
+	// firstLeaf is the index among all of the schema's leaves of the
+	// column's first, and leaves is how many leaves it has (1 if it isn't
+	// nested)
+	firstLeaf, leaves int
+}
+
+// topLevelColumns returns the columns of schema, which is a tree of
+// elements, flattened depth-first, whose root is the schema itself.
+func topLevelColumns(schema []*tstruct) ([]*column, error) {
+	if len(schema) == 0 {
+		return nil, fmt.Errorf("Parquet metadata has no schema")
+	}
+	n, _ := schema[0].getInt(schemaNumChildren)
+	var columns []*column
+	i, leaf := 1, 0
+	for c := int64(0); c < n; c++ {
+		if i >= len(schema) {
+			return nil, fmt.Errorf("Parquet schema is truncated")
+		}
+		end, leaves, err := subtree(schema, i, 0)
+		if err != nil {
+			return nil, err
+		}
+		name := schema[i].get(schemaName)
+		if name == nil {
+			return nil, fmt.Errorf("Parquet schema has an unnamed column")
+		}
+		columns = append(columns, &column{
+			name:      string(name.b),
+			start:     i,
+			end:       end,
+			firstLeaf: leaf,
+			leaves:    leaves,
+		})
+		i, leaf = end, leaf+leaves
+	}
+	if i != len(schema) {
+		return nil, fmt.Errorf("Parquet schema has %d elements that aren't in its tree", len(schema)-i)
+	}
+	return columns, nil
+}
+
+// subtree returns the index of the element after the last descendant of
+// the element at i, and how many leaves it has.
+func subtree(schema []*tstruct, i int, depth int) (int, int, error) {
+	if depth > maxDepth {
+		return 0, 0, fmt.Errorf("Parquet schema is nested too deeply")
+	}
+	n, _ := schema[i].getInt(schemaNumChildren)
+	if n <= 0 {
+		return i + 1, 1, nil
+	}
+	j, leaves := i+1, 0
+	for c := int64(0); c < n; c++ {
+		if j >= len(schema) {
+			return 0, 0, fmt.Errorf("Parquet schema is truncated")
+		}
+		end, l, err := subtree(schema, j, depth+1)
+		if err != nil {
+			return 0, 0, err
+		}
+		j, leaves = end, leaves+l
+	}
+	return j, leaves, nil
+}
+
+func selectColumns(columns []*column, names []string) ([]*column, error) {
+	if len(names) == 0 {
+		return columns, nil
+	}
+	byName := make(map[string]int)
+	for i, c := range columns {
+		byName[c.name] = i
+	}
+	selected := make(map[int]bool)
+	for _, name := range names {
+		i, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("Parquet file has no column %q", name)
+		}
+		selected[i] = true
+	}
+	var result []*column
+	for i, c := range columns {
+		if selected[i] {
+			result = append(result, c)
+		}
+	}
+	return result, nil
+}
+
+func selectRowGroups(groups []*tstruct, indexes []int64) ([]*tstruct, error) {
+	if len(indexes) == 0 {
+		return groups, nil
+	}
+	selected := make(map[int64]bool)
+	for _, i := range indexes {
+		if i < 0 || i >= int64(len(groups)) {
+			return nil, fmt.Errorf("Parquet file has no row group %d, it has %d", i, len(groups))
+		}
+		selected[i] = true
+	}
+	var result []*tstruct
+	for i, group := range groups {
+		if selected[int64(i)] {
+			result = append(result, group)
+		}
+	}
+	return result, nil
+}
+
+// moveChunk returns the range of the file that chunk's pages are in, and
+// changes its offsets to those it has in a file where they start at offset.
+// Pages must be before dataEnd. Column and offset indexes and bloom filters
+// aren't in the range, so they're removed from chunk.
+func moveChunk(chunk *tstruct, offset int64, dataEnd int64) (Range, error) {
+	if chunk.get(chunkFilePath) != nil {
+		return Range{}, fmt.Errorf("Parquet files whose columns are in other files aren't supported")
+	}
+	if chunk.get(chunkCryptoMetaData) != nil || chunk.get(chunkEncryptedColumnMetadata) != nil {
+		return Range{}, fmt.Errorf("Parquet files with encrypted columns aren't supported")
+	}
+	metaValue := chunk.get(chunkMetaData)
+	if metaValue == nil || metaValue.s == nil {
+		return Range{}, fmt.Errorf("Parquet column chunk has no metadata")
+	}
+	meta := metaValue.s
+	start, ok := meta.getInt(metaDataPageOffset)
+	if !ok {
+		return Range{}, fmt.Errorf("Parquet column chunk has no data page offset")
+	}
+	// the dictionary page, if there is one, is before the data pages. Some
+	// writers set its offset to 0 when there isn't one.
+	for _, id := range []int16{metaDictionaryPageOffset, metaIndexPageOffset} {
+		if o, ok := meta.getInt(id); ok && o > 0 && o < start {
+			start = o
+		}
+	}
+	size, ok := meta.getInt(metaTotalCompressedSize)
+	if !ok {
+		return Range{}, fmt.Errorf("Parquet column chunk has no size")
+	}
+	if start < int64(len(Magic)) || size < 0 || start+size > dataEnd {
+		return Range{}, fmt.Errorf("Parquet column chunk at %d, of %d bytes, is outside of the file's data", start, size)
+	}
+	delta := offset - start
+	for _, id := range []int16{metaDataPageOffset, metaDictionaryPageOffset, metaIndexPageOffset} {
+		if o, ok := meta.getInt(id); ok && o > 0 {
+			meta.setInt(id, o+delta)
+		}
+	}
+	if o, ok := chunk.getInt(chunkFileOffset); ok && o > 0 {
+		chunk.setInt(chunkFileOffset, o+delta)
+	}
+	for _, id := range []int16{chunkOffsetIndexOffset, chunkOffsetIndexLength, chunkColumnIndexOffset, chunkColumnIndexLength} {
+		chunk.del(id)
+	}
+	meta.del(metaBloomFilterOffset)
+	meta.del(metaBloomFilterLength)
+	return Range{Offset: start, Size: size}, nil
+}
@@ -0,0 +1,219 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+)
+
+func i32(i int64) *value  { return &value{typ: compactI32, i: i} }
+func i64(i int64) *value  { return &value{typ: compactI64, i: i} }
+func str(s string) *value { return &value{typ: compactBinary, b: []byte(s)} }
+
+func structOf(fields ...*field) *tstruct { return &tstruct{fields: fields} }
+func f(id int16, v *value) *field        { return &field{id: id, v: v} }
+
+// writeFile writes a Parquet file with columns "a", "b", a group of "x" and
+// "y", and "c", and rowGroups row groups. The content of each column chunk
+// is a dictionary page and a data page, which say which chunk they are.
+func writeFile(t *testing.T, rowGroups int) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(Magic)
+	file := structOf(f(1, i32(1)))
+	file.setStructs(fileSchema, []*tstruct{
+		structOf(f(schemaName, str("schema")), f(schemaNumChildren, i32(3))),
+		structOf(f(1, i32(2)), f(schemaName, str("a"))),
+		structOf(f(schemaName, str("b")), f(schemaNumChildren, i32(2))),
+		structOf(f(1, i32(2)), f(schemaName, str("x"))),
+		structOf(f(1, i32(6)), f(schemaName, str("y"))),
+		structOf(f(1, i32(5)), f(schemaName, str("c"))),
+	})
+	file.setInt(fileNumRows, int64(10*rowGroups))
+	var groups []*tstruct
+	for g := 0; g < rowGroups; g++ {
+		var chunks []*tstruct
+		start := int64(buf.Len())
+		for _, leaf := range []string{"a", "b.x", "b.y", "c"} {
+			dictionary := int64(buf.Len())
+			fmt.Fprintf(&buf, "dictionary %d %s;", g, leaf)
+			data := int64(buf.Len())
+			fmt.Fprintf(&buf, "data %d %s;", g, leaf)
+			size := int64(buf.Len()) - dictionary
+			chunks = append(chunks, structOf(
+				f(chunkFileOffset, i64(dictionary)),
+				f(chunkMetaData, &value{typ: compactStruct, s: structOf(
+					f(metaTotalUncompressedSize, i64(2*size)),
+					f(metaTotalCompressedSize, i64(size)),
+					f(metaDataPageOffset, i64(data)),
+					f(metaDictionaryPageOffset, i64(dictionary)),
+				)}),
+				f(chunkColumnIndexOffset, i64(1)),
+				f(chunkColumnIndexLength, i32(1)),
+			))
+		}
+		group := structOf(f(rowGroupNumRows, i64(10)), f(rowGroupFileOffset, i64(start)))
+		group.setStructs(rowGroupColumns, chunks)
+		groups = append(groups, group)
+	}
+	file.setStructs(fileRowGroups, groups)
+	file.set(6, str("pachyderm"))
+	metadata := encodeStruct(file)
+	buf.Write(metadata)
+	var length [4]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(len(metadata)))
+	buf.Write(length[:])
+	buf.WriteString(Magic)
+	return buf.Bytes()
+}
+
+// read returns the Parquet file that s is, given the file it selects from.
+func read(t *testing.T, file []byte, s *Selection) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(Magic)
+	for _, r := range s.Ranges {
+		buf.Write(file[r.Offset : r.Offset+r.Size])
+	}
+	buf.Write(s.Footer)
+	require.Equal(t, s.Size(), int64(buf.Len()))
+	return buf.Bytes()
+}
+
+// metadata returns the decoded metadata of a Parquet file.
+func metadata(t *testing.T, file []byte) *tstruct {
+	n, err := MetadataSize(file[len(file)-TailSize:])
+	require.NoError(t, err)
+	m, err := decodeStruct(file[int64(len(file))-TailSize-n : len(file)-TailSize])
+	require.NoError(t, err)
+	return m
+}
+
+// chunks returns the names of the columns of the file, and the contents of
+// its column chunks, as found through its metadata.
+func chunks(t *testing.T, file []byte) ([]string, []string) {
+	m := metadata(t, file)
+	schema, err := m.structs(fileSchema)
+	require.NoError(t, err)
+	var names []string
+	for _, element := range schema[1:] {
+		names = append(names, string(element.get(schemaName).b))
+	}
+	groups, err := m.structs(fileRowGroups)
+	require.NoError(t, err)
+	var contents []string
+	for _, group := range groups {
+		columns, err := group.structs(rowGroupColumns)
+		require.NoError(t, err)
+		for _, chunk := range columns {
+			meta := chunk.get(chunkMetaData).s
+			dictionary, _ := meta.getInt(metaDictionaryPageOffset)
+			data, _ := meta.getInt(metaDataPageOffset)
+			size, _ := meta.getInt(metaTotalCompressedSize)
+			contents = append(contents, string(file[dictionary:data])+string(file[data:dictionary+size]))
+			// the indexes aren't copied
+			require.Nil(t, chunk.get(chunkColumnIndexOffset))
+		}
+	}
+	return names, contents
+}
+
+func TestSelect(t *testing.T) {
+	file := writeFile(t, 3)
+	n, err := MetadataSize(file[len(file)-TailSize:])
+	require.NoError(t, err)
+	footer := file[int64(len(file))-TailSize-n : len(file)-TailSize]
+
+	// everything
+	s, err := Select(footer, int64(len(file)), nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, []Range{{4, int64(len(file)) - TailSize - n - 4}}, s.Ranges)
+	names, contents := chunks(t, read(t, file, s))
+	require.Equal(t, []string{"a", "b", "x", "y", "c"}, names)
+	require.Equal(t, 12, len(contents))
+
+	// columns are in the order of the file, and nested columns' leaves are
+	// selected together
+	s, err = Select(footer, int64(len(file)), []string{"c", "b"}, []int64{2, 0})
+	require.NoError(t, err)
+	selected := read(t, file, s)
+	names, contents = chunks(t, selected)
+	require.Equal(t, []string{"b", "x", "y", "c"}, names)
+	require.Equal(t, []string{
+		"dictionary 0 b.x;data 0 b.x;",
+		"dictionary 0 b.y;data 0 b.y;",
+		"dictionary 0 c;data 0 c;",
+		"dictionary 2 b.x;data 2 b.x;",
+		"dictionary 2 b.y;data 2 b.y;",
+		"dictionary 2 c;data 2 c;",
+	}, contents)
+	// adjacent chunks are read together
+	require.Equal(t, 2, len(s.Ranges))
+	m := metadata(t, selected)
+	numRows, _ := m.getInt(fileNumRows)
+	require.Equal(t, int64(20), numRows)
+	schema, err := m.structs(fileSchema)
+	require.NoError(t, err)
+	numChildren, _ := schema[0].getInt(schemaNumChildren)
+	require.Equal(t, int64(2), numChildren)
+	groups, err := m.structs(fileRowGroups)
+	require.NoError(t, err)
+	fileOffset, _ := groups[1].getInt(rowGroupFileOffset)
+	columns, err := groups[1].structs(rowGroupColumns)
+	require.NoError(t, err)
+	chunkOffset, _ := columns[0].getInt(chunkFileOffset)
+	require.Equal(t, chunkOffset, fileOffset)
+	require.Equal(t, "pachyderm", string(m.get(6).b))
+
+	_, err = Select(footer, int64(len(file)), []string{"x"}, nil)
+	require.YesError(t, err)
+	_, err = Select(footer, int64(len(file)), nil, []int64{3})
+	require.YesError(t, err)
+	// the footer must agree with the file's size
+	_, err = Select(footer, int64(len(footer)), nil, nil)
+	require.YesError(t, err)
+}
+
+func TestMetadataSize(t *testing.T) {
+	_, err := MetadataSize([]byte("\x10\x00\x00\x00PARE"))
+	require.YesError(t, err)
+	_, err = MetadataSize([]byte("a,b,c\n1,"))
+	require.YesError(t, err)
+	n, err := MetadataSize([]byte("\x10\x01\x00\x00PAR1"))
+	require.NoError(t, err)
+	require.Equal(t, int64(272), n)
+}
+
+func TestThrift(t *testing.T) {
+	// every type, with field ids that need the long form of field headers
+	s := structOf(
+		f(1, &value{typ: compactTrue, i: 1}),
+		f(2, &value{typ: compactTrue, i: 0}),
+		f(3, &value{typ: compactByte, i: -3}),
+		f(4, &value{typ: compactI16, i: -300}),
+		f(5, &value{typ: compactDouble, b: []byte{1, 2, 3, 4, 5, 6, 7, 8}}),
+		f(40, &value{typ: compactList, elemType: compactI32, elems: []*value{i32(1), i32(-2)}}),
+		f(41, &value{typ: compactMap, keyType: compactBinary, elemType: compactI64, elems: []*value{str("k"), i64(1 << 40)}}),
+		f(42, &value{typ: compactMap}),
+		f(43, &value{typ: compactStruct, s: structOf(f(1, str("nested")))}),
+	)
+	var elems []*value
+	for i := 0; i < 20; i++ {
+		elems = append(elems, &value{typ: compactTrue, i: 1})
+	}
+	s.set(44, &value{typ: compactList, elemType: compactTrue, elems: elems})
+	encoded := encodeStruct(s)
+	decoded, err := decodeStruct(encoded)
+	require.NoError(t, err)
+	require.Equal(t, encoded, encodeStruct(decoded))
+	require.Equal(t, int64(1), decoded.get(1).i)
+	require.Equal(t, int64(0), decoded.get(2).i)
+	require.Equal(t, int64(-300), decoded.get(4).i)
+	require.Equal(t, 20, len(decoded.get(44).elems))
+
+	_, err = decodeStruct(encoded[:len(encoded)-1])
+	require.YesError(t, err)
+	_, err = decodeStruct(bytes.Repeat([]byte{0x1c}, 1000))
+	require.YesError(t, err)
+}
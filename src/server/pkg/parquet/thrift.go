@@ -0,0 +1,382 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// The types of thrift's compact protocol.
+const (
+	compactTrue   = 1
+	compactFalse  = 2
+	compactByte   = 3
+	compactI16    = 4
+	compactI32    = 5
+	compactI64    = 6
+	compactDouble = 7
+	compactBinary = 8
+	compactList   = 9
+	compactSet    = 10
+	compactMap    = 11
+	compactStruct = 12
+)
+
+// maxDepth bounds how deeply structs and lists can be nested, so that a
+// corrupt footer can't exhaust the stack.
+const maxDepth = 64
+
+// value is a thrift value, decoded generically, so that the parts of a
+// footer that Select doesn't understand are encoded again as they were.
+// Which of its fields is set depends on typ: i holds integers and booleans,
+// b binaries and doubles' bytes, s structs, and elems lists', sets' and
+// maps' elements, maps' alternating between keys and values.
+type value struct {
+	typ   byte
+	i     int64
+	b     []byte
+	s     *tstruct
+	elems []*value
+	// elemType is the type of a list's or set's elements, and keyType that
+	// of a map's keys, whose values are of type elemType
+	elemType byte
+	keyType  byte
+}
+
+// tstruct is a decoded thrift struct.
+type tstruct struct {
+	fields []*field
+}
+
+type field struct {
+	id int16
+	v  *value
+}
+
+func (s *tstruct) get(id int16) *value {
+	for _, f := range s.fields {
+		if f.id == id {
+			return f.v
+		}
+	}
+	return nil
+}
+
+// getInt returns the integer field id, and whether it's set.
+func (s *tstruct) getInt(id int16) (int64, bool) {
+	if v := s.get(id); v != nil {
+		return v.i, true
+	}
+	return 0, false
+}
+
+// setInt sets the integer field id, which must already be set, or be an
+// i64 if it isn't.
+func (s *tstruct) setInt(id int16, i int64) {
+	if v := s.get(id); v != nil {
+		v.i = i
+		return
+	}
+	s.set(id, &value{typ: compactI64, i: i})
+}
+
+func (s *tstruct) set(id int16, v *value) {
+	for _, f := range s.fields {
+		if f.id == id {
+			f.v = v
+			return
+		}
+	}
+	// fields are kept in order of their ids, which is how thrift writes them
+	i := 0
+	for i < len(s.fields) && s.fields[i].id < id {
+		i++
+	}
+	s.fields = append(s.fields, nil)
+	copy(s.fields[i+1:], s.fields[i:])
+	s.fields[i] = &field{id: id, v: v}
+}
+
+func (s *tstruct) del(id int16) {
+	for i, f := range s.fields {
+		if f.id == id {
+			s.fields = append(s.fields[:i], s.fields[i+1:]...)
+			return
+		}
+	}
+}
+
+// structs returns the structs in the list field id.
+func (s *tstruct) structs(id int16) ([]*tstruct, error) {
+	v := s.get(id)
+	if v == nil {
+		return nil, nil
+	}
+	if v.typ != compactList || v.elemType != compactStruct {
+		return nil, fmt.Errorf("field %d isn't a list of structs", id)
+	}
+	var result []*tstruct
+	for _, elem := range v.elems {
+		result = append(result, elem.s)
+	}
+	return result, nil
+}
+
+// setStructs sets the list field id to structs.
+func (s *tstruct) setStructs(id int16, structs []*tstruct) {
+	v := &value{typ: compactList, elemType: compactStruct}
+	for _, elem := range structs {
+		v.elems = append(v.elems, &value{typ: compactStruct, s: elem})
+	}
+	s.set(id, v)
+}
+
+// decoder decodes thrift's compact protocol.
+type decoder struct {
+	buf   []byte
+	depth int
+}
+
+// decodeStruct decodes the struct at the start of buf.
+func decodeStruct(buf []byte) (*tstruct, error) {
+	d := &decoder{buf: buf}
+	return d.readStruct()
+}
+
+func (d *decoder) readStruct() (*tstruct, error) {
+	if d.depth++; d.depth > maxDepth {
+		return nil, fmt.Errorf("structs are nested too deeply")
+	}
+	defer func() { d.depth-- }()
+	s := &tstruct{}
+	var id int16
+	for {
+		header, err := d.readByte()
+		if err != nil {
+			return nil, err
+		}
+		if header == 0 {
+			return s, nil
+		}
+		typ := header & 0x0f
+		if delta := header >> 4; delta != 0 {
+			id += int16(delta)
+		} else {
+			n, err := d.readZigzag()
+			if err != nil {
+				return nil, err
+			}
+			id = int16(n)
+		}
+		var v *value
+		if typ == compactTrue || typ == compactFalse {
+			// booleans' values are their fields' types
+			v = &value{typ: typ, i: int64(compactFalse - typ)}
+		} else if v, err = d.readValue(typ); err != nil {
+			return nil, err
+		}
+		s.fields = append(s.fields, &field{id: id, v: v})
+	}
+}
+
+func (d *decoder) readValue(typ byte) (*value, error) {
+	v := &value{typ: typ}
+	switch typ {
+	case compactTrue, compactFalse, compactByte:
+		// booleans are bytes in lists
+		b, err := d.readByte()
+		if err != nil {
+			return nil, err
+		}
+		v.i = int64(int8(b))
+	case compactI16, compactI32, compactI64:
+		i, err := d.readZigzag()
+		if err != nil {
+			return nil, err
+		}
+		v.i = i
+	case compactDouble:
+		b, err := d.read(8)
+		if err != nil {
+			return nil, err
+		}
+		v.b = b
+	case compactBinary:
+		n, err := d.readVarint()
+		if err != nil {
+			return nil, err
+		}
+		if v.b, err = d.read(n); err != nil {
+			return nil, err
+		}
+	case compactList, compactSet:
+		header, err := d.readByte()
+		if err != nil {
+			return nil, err
+		}
+		v.elemType = header & 0x0f
+		n := uint64(header >> 4)
+		if n == 15 {
+			if n, err = d.readVarint(); err != nil {
+				return nil, err
+			}
+		}
+		if err := d.readElems(v, n, v.elemType); err != nil {
+			return nil, err
+		}
+	case compactMap:
+		n, err := d.readVarint()
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			break
+		}
+		header, err := d.readByte()
+		if err != nil {
+			return nil, err
+		}
+		v.keyType, v.elemType = header>>4, header&0x0f
+		if err := d.readElems(v, 2*n, v.keyType, v.elemType); err != nil {
+			return nil, err
+		}
+	case compactStruct:
+		s, err := d.readStruct()
+		if err != nil {
+			return nil, err
+		}
+		v.s = s
+	default:
+		return nil, fmt.Errorf("unknown thrift type %d", typ)
+	}
+	return v, nil
+}
+
+// readElems reads n elements into v, whose types cycle through types.
+func (d *decoder) readElems(v *value, n uint64, types ...byte) error {
+	// each element is at least a byte, which bounds how many there can be
+	if n > uint64(len(d.buf)) {
+		return fmt.Errorf("list of %d elements is longer than the footer", n)
+	}
+	if d.depth++; d.depth > maxDepth {
+		return fmt.Errorf("lists are nested too deeply")
+	}
+	defer func() { d.depth-- }()
+	for i := uint64(0); i < n; i++ {
+		elem, err := d.readValue(types[i%uint64(len(types))])
+		if err != nil {
+			return err
+		}
+		v.elems = append(v.elems, elem)
+	}
+	return nil
+}
+
+func (d *decoder) readByte() (byte, error) {
+	if len(d.buf) == 0 {
+		return 0, fmt.Errorf("footer is truncated")
+	}
+	b := d.buf[0]
+	d.buf = d.buf[1:]
+	return b, nil
+}
+
+func (d *decoder) read(n uint64) ([]byte, error) {
+	if n > uint64(len(d.buf)) {
+		return nil, fmt.Errorf("footer is truncated")
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b, nil
+}
+
+func (d *decoder) readVarint() (uint64, error) {
+	n, size := binary.Uvarint(d.buf)
+	if size <= 0 {
+		return 0, fmt.Errorf("invalid varint in footer")
+	}
+	d.buf = d.buf[size:]
+	return n, nil
+}
+
+func (d *decoder) readZigzag() (int64, error) {
+	n, err := d.readVarint()
+	if err != nil {
+		return 0, err
+	}
+	return int64(n>>1) ^ -int64(n&1), nil
+}
+
+// encodeStruct encodes s with thrift's compact protocol.
+func encodeStruct(s *tstruct) []byte {
+	var buf bytes.Buffer
+	writeStruct(&buf, s)
+	return buf.Bytes()
+}
+
+func writeStruct(buf *bytes.Buffer, s *tstruct) {
+	var last int16
+	for _, f := range s.fields {
+		typ := f.v.typ
+		if typ == compactTrue || typ == compactFalse {
+			typ = compactFalse
+			if f.v.i != 0 {
+				typ = compactTrue
+			}
+		}
+		if delta := f.id - last; delta > 0 && delta <= 15 {
+			buf.WriteByte(byte(delta)<<4 | typ)
+		} else {
+			buf.WriteByte(typ)
+			writeVarint(buf, zigzag(int64(f.id)))
+		}
+		last = f.id
+		if typ != compactTrue && typ != compactFalse {
+			writeValue(buf, f.v)
+		}
+	}
+	buf.WriteByte(0)
+}
+
+func writeValue(buf *bytes.Buffer, v *value) {
+	switch v.typ {
+	case compactTrue, compactFalse, compactByte:
+		buf.WriteByte(byte(v.i))
+	case compactI16, compactI32, compactI64:
+		writeVarint(buf, zigzag(v.i))
+	case compactDouble:
+		buf.Write(v.b)
+	case compactBinary:
+		writeVarint(buf, uint64(len(v.b)))
+		buf.Write(v.b)
+	case compactList, compactSet:
+		if n := len(v.elems); n < 15 {
+			buf.WriteByte(byte(n)<<4 | v.elemType)
+		} else {
+			buf.WriteByte(0xf0 | v.elemType)
+			writeVarint(buf, uint64(n))
+		}
+		for _, elem := range v.elems {
+			writeValue(buf, elem)
+		}
+	case compactMap:
+		writeVarint(buf, uint64(len(v.elems)/2))
+		if len(v.elems) > 0 {
+			buf.WriteByte(v.keyType<<4 | v.elemType)
+		}
+		for _, elem := range v.elems {
+			writeValue(buf, elem)
+		}
+	case compactStruct:
+		writeStruct(buf, v.s)
+	}
+}
+
+func writeVarint(buf *bytes.Buffer, n uint64) {
+	var b [binary.MaxVarintLen64]byte
+	buf.Write(b[:binary.PutUvarint(b[:], n)])
+}
+
+func zigzag(n int64) uint64 {
+	return uint64(n<<1) ^ uint64(n>>63)
+}
@@ -0,0 +1,46 @@
+// Package readonly keeps whether the cluster is read-only for maintenance
+// (see admin.SetReadOnly) in etcd, where every pachd can see it.
+package readonly
+
+import (
+	etcd "github.com/coreos/etcd/clientv3"
+	"github.com/gogo/protobuf/proto"
+	"github.com/pachyderm/pachyderm/src/client/admin"
+	"golang.org/x/net/context"
+)
+
+// key is the etcd key of the cluster's admin.ReadOnlyStatus, which only
+// exists while the cluster is read-only.
+const key = "pachyderm_read_only"
+
+// Get returns the cluster's ReadOnlyStatus, or nil if it isn't read-only.
+func Get(ctx context.Context, etcdClient *etcd.Client) (*admin.ReadOnlyStatus, error) {
+	resp, err := etcdClient.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, nil
+	}
+	status := &admin.ReadOnlyStatus{}
+	if err := proto.Unmarshal(resp.Kvs[0].Value, status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// Set makes the cluster read-only, for the reason in status.
+func Set(ctx context.Context, etcdClient *etcd.Client, status *admin.ReadOnlyStatus) error {
+	value, err := proto.Marshal(status)
+	if err != nil {
+		return err
+	}
+	_, err = etcdClient.Put(ctx, key, string(value))
+	return err
+}
+
+// Clear makes the cluster writable again.
+func Clear(ctx context.Context, etcdClient *etcd.Client) error {
+	_, err := etcdClient.Delete(ctx, key)
+	return err
+}
@@ -0,0 +1,115 @@
+package registry
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+const (
+	// azureTokenURL is where Azure's instance metadata service gives out
+	// the access tokens of VMs' managed identities.
+	azureTokenURL = "http://169.254.169.254/metadata/identity/oauth2/token"
+	// acrUsername is the username that ACR's refresh tokens are used with.
+	acrUsername = "00000000-0000-0000-0000-000000000000"
+)
+
+// acrAuth gets a refresh token for the ACR registry host, by exchanging the
+// access token of the managed identity of pachd's node for one. If pachd's
+// node has several identities, AZURE_CLIENT_ID picks one.
+func acrAuth(ctx context.Context, httpClient *http.Client, host string) (*Auth, error) {
+	query := url.Values{
+		"api-version": {"2018-02-01"},
+		"resource":    {"https://management.azure.com/"},
+	}
+	if clientID := os.Getenv("AZURE_CLIENT_ID"); clientID != "" {
+		query.Set("client_id", clientID)
+	}
+	req, err := http.NewRequest("GET", azureTokenURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata", "true")
+	var aadToken struct {
+		AccessToken string `json:"access_token"`
+		ExpiresOn   string `json:"expires_on"`
+	}
+	if err := doJSON(ctx, httpClient, req, &aadToken); err != nil {
+		return nil, fmt.Errorf("error getting managed identity token: %v", err)
+	}
+
+	form := url.Values{
+		"grant_type":   {"access_token"},
+		"service":      {host},
+		"access_token": {aadToken.AccessToken},
+	}
+	req, err = http.NewRequest("POST", "https://"+host+"/oauth2/exchange", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	var acrToken struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := doJSON(ctx, httpClient, req, &acrToken); err != nil {
+		return nil, fmt.Errorf("error exchanging managed identity token: %v", err)
+	}
+	expiry, err := jwtExpiry(acrToken.RefreshToken)
+	if err != nil {
+		// the refresh token lasts at least as long as the token it was
+		// exchanged for
+		seconds, err := strconv.ParseInt(aadToken.ExpiresOn, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expiry %q of managed identity token", aadToken.ExpiresOn)
+		}
+		expiry = time.Unix(seconds, 0)
+	}
+	return &Auth{
+		Username: acrUsername,
+		Password: acrToken.RefreshToken,
+		Expiry:   expiry,
+	}, nil
+}
+
+func doJSON(ctx context.Context, httpClient *http.Client, req *http.Request, result interface{}) error {
+	resp, err := httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return readError(resp)
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// jwtExpiry returns the expiry (the "exp" claim) of the JWT token, without
+// verifying it.
+func jwtExpiry(token string) (time.Time, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, fmt.Errorf("invalid JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, err
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return time.Time{}, err
+	}
+	if claims.Exp == 0 {
+		return time.Time{}, fmt.Errorf("JWT has no expiry")
+	}
+	return time.Unix(claims.Exp, 0), nil
+}
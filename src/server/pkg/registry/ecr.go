@@ -0,0 +1,72 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+	"golang.org/x/net/context"
+)
+
+// ecrAuth gets a token for the ECR registry of account in region, with
+// ECR's GetAuthorizationToken, which is called directly, as the AWS SDK's
+// ECR client isn't vendored.
+func ecrAuth(ctx context.Context, httpClient *http.Client, account string, region string, suffix string) (*Auth, error) {
+	sess, err := session.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	body := []byte(fmt.Sprintf(`{"registryIds":[%q]}`, account))
+	req, err := http.NewRequest("POST", fmt.Sprintf("https://api.ecr.%s.amazonaws.com%s/", region, suffix), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "AmazonEC2ContainerRegistry_V20150921.GetAuthorizationToken")
+	if _, err := v4.NewSigner(sess.Config.Credentials).Sign(req, bytes.NewReader(body), "ecr", region, time.Now()); err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, readError(resp)
+	}
+	var result struct {
+		AuthorizationData []struct {
+			AuthorizationToken string  `json:"authorizationToken"`
+			ExpiresAt          float64 `json:"expiresAt"`
+		} `json:"authorizationData"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if len(result.AuthorizationData) == 0 {
+		return nil, fmt.Errorf("ECR returned no token")
+	}
+	data := result.AuthorizationData[0]
+	// the token is the base64 of "<username>:<password>"
+	token, err := base64.StdEncoding.DecodeString(data.AuthorizationToken)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token from ECR: %v", err)
+	}
+	parts := strings.SplitN(string(token), ":", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid token from ECR")
+	}
+	seconds, fraction := math.Modf(data.ExpiresAt)
+	return &Auth{
+		Username: parts[0],
+		Password: parts[1],
+		Expiry:   time.Unix(int64(seconds), int64(fraction*1e9)),
+	}, nil
+}
@@ -0,0 +1,27 @@
+package registry
+
+import (
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2/google"
+)
+
+// gcrScope is the OAuth2 scope that GCR's tokens need.
+const gcrScope = "https://www.googleapis.com/auth/cloud-platform"
+
+// gcrAuth gets an access token for GCR, as pachd's service account, i.e.
+// GOOGLE_APPLICATION_CREDENTIALS', or its node's.
+func gcrAuth(ctx context.Context) (*Auth, error) {
+	tokenSource, err := google.DefaultTokenSource(ctx, gcrScope)
+	if err != nil {
+		return nil, err
+	}
+	token, err := tokenSource.Token()
+	if err != nil {
+		return nil, err
+	}
+	return &Auth{
+		Username: "oauth2accesstoken",
+		Password: token.AccessToken,
+		Expiry:   token.Expiry,
+	}, nil
+}
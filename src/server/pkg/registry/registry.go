@@ -0,0 +1,118 @@
+// Package registry gets short-lived credentials for the docker registries of
+// cloud providers (ECR, GCR and ACR), which pachd keeps in a secret that
+// pipelines' workers pull their images with, so that pipelines can use
+// private images without a static imagePullSecret that expires. It also
+// resolves images against their registries, see ResolveImage.
+package registry
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// Provider is a cloud provider whose registries' credentials can be gotten.
+type Provider string
+
+const (
+	// ECR is Amazon's Elastic Container Registry, whose tokens last 12
+	// hours. pachd authenticates to AWS as the SDK does by default, e.g.
+	// with its node's instance role.
+	ECR Provider = "ecr"
+	// GCR is Google's Container Registry (and Artifact Registry), whose
+	// tokens are the OAuth2 access tokens of pachd's service account, which
+	// last an hour.
+	GCR Provider = "gcr"
+	// ACR is Azure's Container Registry, whose tokens are exchanged for the
+	// access token of pachd's node's managed identity.
+	ACR Provider = "acr"
+)
+
+// httpTimeout bounds the requests that are made to get credentials.
+const httpTimeout = 30 * time.Second
+
+var (
+	ecrHost = regexp.MustCompile(`^([0-9]{12})\.dkr\.ecr\.([a-z0-9-]+)\.amazonaws\.com(\.cn)?$`)
+	gcrHost = regexp.MustCompile(`^(([a-z]+\.)?gcr\.io|[a-z0-9-]+-docker\.pkg\.dev)$`)
+	acrHost = regexp.MustCompile(`^[a-z0-9]+\.azurecr\.(io|cn|us|de)$`)
+)
+
+// ProviderOf returns the provider of the registry host, e.g.
+// "123456789012.dkr.ecr.us-east-1.amazonaws.com", "gcr.io" or
+// "example.azurecr.io". It errors if credentials can't be gotten for host.
+func ProviderOf(host string) (Provider, error) {
+	switch {
+	case ecrHost.MatchString(host):
+		return ECR, nil
+	case gcrHost.MatchString(host):
+		return GCR, nil
+	case acrHost.MatchString(host):
+		return ACR, nil
+	}
+	return "", fmt.Errorf("can't get credentials for registry %q, only ECR (<account>.dkr.ecr.<region>.amazonaws.com), GCR (gcr.io, <region>-docker.pkg.dev) and ACR (<name>.azurecr.io) registries are supported", host)
+}
+
+// Auth is the credentials for a registry.
+type Auth struct {
+	Username string
+	Password string
+	// Expiry is when the credentials stop working.
+	Expiry time.Time
+}
+
+// GetAuth gets credentials for the registry host.
+func GetAuth(ctx context.Context, host string) (*Auth, error) {
+	provider, err := ProviderOf(host)
+	if err != nil {
+		return nil, err
+	}
+	httpClient := &http.Client{Timeout: httpTimeout}
+	var auth *Auth
+	switch provider {
+	case ECR:
+		match := ecrHost.FindStringSubmatch(host)
+		auth, err = ecrAuth(ctx, httpClient, match[1], match[2], match[3])
+	case GCR:
+		auth, err = gcrAuth(ctx)
+	case ACR:
+		auth, err = acrAuth(ctx, httpClient, host)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting credentials for %s: %v", host, err)
+	}
+	return auth, nil
+}
+
+// DockerConfigJSON returns the .dockerconfigjson of a
+// kubernetes.io/dockerconfigjson secret holding auths, by registry host.
+func DockerConfigJSON(auths map[string]*Auth) ([]byte, error) {
+	type dockerAuth struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		Auth     string `json:"auth"`
+	}
+	config := struct {
+		Auths map[string]dockerAuth `json:"auths"`
+	}{Auths: make(map[string]dockerAuth)}
+	for host, auth := range auths {
+		config.Auths[host] = dockerAuth{
+			Username: auth.Username,
+			Password: auth.Password,
+			Auth:     base64.StdEncoding.EncodeToString([]byte(auth.Username + ":" + auth.Password)),
+		}
+	}
+	return json.Marshal(config)
+}
+
+// readError returns an error describing the unsuccessful response resp.
+func readError(resp *http.Response) error {
+	var body [1024]byte
+	n, _ := resp.Body.Read(body[:])
+	return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body[:n])))
+}
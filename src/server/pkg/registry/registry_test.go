@@ -0,0 +1,115 @@
+package registry
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+
+	"golang.org/x/net/context"
+)
+
+func TestProviderOf(t *testing.T) {
+	for host, expected := range map[string]Provider{
+		"123456789012.dkr.ecr.us-east-1.amazonaws.com":     ECR,
+		"123456789012.dkr.ecr.cn-north-1.amazonaws.com.cn": ECR,
+		"gcr.io":                               GCR,
+		"eu.gcr.io":                            GCR,
+		"us-central1-docker.pkg.dev":           GCR,
+		"example.azurecr.io":                   ACR,
+		"docker.io":                            "",
+		"registry.example.com":                 "",
+		"1234.dkr.ecr.us-east-1.amazonaws.com": "",
+		"evil.gcr.io.example.com":              "",
+	} {
+		provider, err := ProviderOf(host)
+		if expected == "" {
+			require.YesError(t, err, host)
+			continue
+		}
+		require.NoError(t, err, host)
+		require.Equal(t, expected, provider, host)
+	}
+}
+
+func TestDockerConfigJSON(t *testing.T) {
+	data, err := DockerConfigJSON(map[string]*Auth{
+		"gcr.io": {Username: "oauth2accesstoken", Password: "token", Expiry: time.Now()},
+	})
+	require.NoError(t, err)
+	var config map[string]map[string]map[string]string
+	require.NoError(t, json.Unmarshal(data, &config))
+	auth := config["auths"]["gcr.io"]
+	require.Equal(t, "oauth2accesstoken", auth["username"])
+	require.Equal(t, "token", auth["password"])
+	require.Equal(t, base64.StdEncoding.EncodeToString([]byte("oauth2accesstoken:token")), auth["auth"])
+}
+
+func TestJWTExpiry(t *testing.T) {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"exp":1500000000}`))
+	expiry, err := jwtExpiry("header." + payload + ".signature")
+	require.NoError(t, err)
+	require.Equal(t, time.Unix(1500000000, 0), expiry)
+	_, err = jwtExpiry("opaque")
+	require.YesError(t, err)
+}
+
+func TestParseImage(t *testing.T) {
+	for image, expected := range map[string][3]string{
+		"ubuntu":              {"docker.io", "library/ubuntu", "latest"},
+		"ubuntu:16.04":        {"docker.io", "library/ubuntu", "16.04"},
+		"pachyderm/worker":    {"docker.io", "pachyderm/worker", "latest"},
+		"localhost/image:tag": {"localhost", "image", "tag"},
+		"registry.example.com:5000/team/image:v1":   {"registry.example.com:5000", "team/image", "v1"},
+		"gcr.io/project/image@sha256:0123456789abc": {"gcr.io", "project/image", "sha256:0123456789abc"},
+	} {
+		host, repo, reference, err := ParseImage(image)
+		require.NoError(t, err, image)
+		require.Equal(t, expected, [3]string{host, repo, reference}, image)
+	}
+	for _, image := range []string{"", "Ubuntu", "ubuntu:", "gcr.io/"} {
+		_, _, _, err := ParseImage(image)
+		require.YesError(t, err, image)
+	}
+}
+
+func TestResolveImage(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			username, password, ok := r.BasicAuth()
+			if r.URL.Query().Get("scope") != "repository:team/image:pull" || !ok || username != "user" || password != "password" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"token": "token"}`))
+		case "/v2/team/image/manifests/v1":
+			if r.Header.Get("Authorization") != "Bearer token" {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="registry",scope="repository:team/image:pull"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Docker-Content-Digest", "sha256:digest")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "https://")
+	auth := &Auth{Username: "user", Password: "password"}
+
+	digest, err := resolveImage(context.Background(), server.Client(), host+"/team/image:v1", auth)
+	require.NoError(t, err)
+	require.Equal(t, "sha256:digest", digest)
+	_, err = resolveImage(context.Background(), server.Client(), host+"/team/image:v1", nil)
+	require.YesError(t, err)
+	_, err = resolveImage(context.Background(), server.Client(), host+"/team/image:v2", auth)
+	require.YesError(t, err)
+	require.Equal(t, ImageNotFoundError{host + "/team/image:v2"}, err)
+}
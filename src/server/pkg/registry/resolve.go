@@ -0,0 +1,183 @@
+package registry
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+const (
+	// dockerHub is the registry of images whose names don't start with one,
+	// and dockerHubAPI the host that its API is served from.
+	dockerHub    = "docker.io"
+	dockerHubAPI = "registry-1.docker.io"
+)
+
+// manifestTypes are the manifests that ResolveImage accepts, which are those
+// that docker pulls.
+var manifestTypes = []string{
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.oci.image.index.v1+json",
+}
+
+// challengeParam matches the parameters of a WWW-Authenticate header, e.g.
+// realm="https://auth.docker.io/token".
+var challengeParam = regexp.MustCompile(`([a-z]+)="([^"]*)"`)
+
+// ImageNotFoundError is returned by ResolveImage for images that their
+// registry says don't exist. Registries that need credentials often refuse
+// to say, and return unauthorized instead.
+type ImageNotFoundError struct {
+	Image string
+}
+
+func (e ImageNotFoundError) Error() string {
+	return fmt.Sprintf("image %s doesn't exist", e.Image)
+}
+
+// ResolveImage returns the digest of image's manifest, as its registry
+// serves it, so that images which don't exist, or can't be pulled with auth,
+// are caught before any workers fail to pull them. auth may be nil for
+// public images.
+func ResolveImage(ctx context.Context, image string, auth *Auth) (string, error) {
+	return resolveImage(ctx, &http.Client{Timeout: httpTimeout}, image, auth)
+}
+
+func resolveImage(ctx context.Context, httpClient *http.Client, image string, auth *Auth) (string, error) {
+	host, repo, reference, err := ParseImage(image)
+	if err != nil {
+		return "", err
+	}
+	if host == dockerHub {
+		host = dockerHubAPI
+	}
+	manifestURL := fmt.Sprintf("https://%s/v2/%s/manifests/%s", host, repo, reference)
+	resp, err := headManifest(ctx, httpClient, manifestURL, "")
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		// registries ask for credentials even for public images, which
+		// get a token anonymously
+		authorization, err := authorize(ctx, httpClient, resp.Header.Get("WWW-Authenticate"), auth)
+		if err != nil {
+			return "", fmt.Errorf("error authenticating to %s: %v", host, err)
+		}
+		if resp, err = headManifest(ctx, httpClient, manifestURL, authorization); err != nil {
+			return "", err
+		}
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return "", ImageNotFoundError{image}
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return "", fmt.Errorf("image %s can't be pulled, either it doesn't exist or its registry's credentials don't allow it: %s", image, resp.Status)
+	default:
+		return "", fmt.Errorf("error resolving image %s: %v", image, readError(resp))
+	}
+	if digest := resp.Header.Get("Docker-Content-Digest"); digest != "" {
+		return digest, nil
+	}
+	if strings.HasPrefix(reference, "sha256:") {
+		return reference, nil
+	}
+	return "", fmt.Errorf("registry %s didn't return the digest of image %s", host, image)
+}
+
+// ParseImage splits image into its registry's host, its repo and its tag or
+// digest, filling in the defaults that docker does, e.g. "ubuntu" is
+// "docker.io", "library/ubuntu" and "latest".
+func ParseImage(image string) (host string, repo string, reference string, retErr error) {
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, reference = name[:i], name[i+1:]
+	} else if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, reference = name[:i], name[i+1:]
+	} else {
+		reference = "latest"
+	}
+	host = dockerHub
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		host, name = parts[0], parts[1]
+	}
+	if name == "" || reference == "" || strings.ToLower(name) != name {
+		return "", "", "", fmt.Errorf("invalid image name %q", image)
+	}
+	if host == dockerHub && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	return host, name, reference, nil
+}
+
+func headManifest(ctx context.Context, httpClient *http.Client, manifestURL string, authorization string) (*http.Response, error) {
+	req, err := http.NewRequest("HEAD", manifestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join(manifestTypes, ", "))
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	resp, err := httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	// a HEAD response has no body to read
+	resp.Body.Close()
+	return resp, nil
+}
+
+// authorize returns the Authorization header that answers challenge, the
+// WWW-Authenticate header of a registry's response.
+func authorize(ctx context.Context, httpClient *http.Client, challenge string, auth *Auth) (string, error) {
+	params := make(map[string]string)
+	for _, match := range challengeParam.FindAllStringSubmatch(challenge, -1) {
+		params[match[1]] = match[2]
+	}
+	switch {
+	case strings.HasPrefix(challenge, "Basic"):
+		if auth == nil {
+			return "", fmt.Errorf("the registry needs credentials")
+		}
+		req := &http.Request{Header: make(http.Header)}
+		req.SetBasicAuth(auth.Username, auth.Password)
+		return req.Header.Get("Authorization"), nil
+	case strings.HasPrefix(challenge, "Bearer"):
+		if params["realm"] == "" {
+			return "", fmt.Errorf("invalid challenge %q", challenge)
+		}
+		query := url.Values{}
+		for _, param := range []string{"service", "scope"} {
+			if params[param] != "" {
+				query.Set(param, params[param])
+			}
+		}
+		req, err := http.NewRequest("GET", params["realm"]+"?"+query.Encode(), nil)
+		if err != nil {
+			return "", err
+		}
+		if auth != nil {
+			req.SetBasicAuth(auth.Username, auth.Password)
+		}
+		var token struct {
+			Token       string `json:"token"`
+			AccessToken string `json:"access_token"`
+		}
+		if err := doJSON(ctx, httpClient, req, &token); err != nil {
+			return "", err
+		}
+		if token.Token == "" {
+			token.Token = token.AccessToken
+		}
+		return "Bearer " + token.Token, nil
+	}
+	return "", fmt.Errorf("unsupported challenge %q", challenge)
+}
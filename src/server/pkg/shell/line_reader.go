@@ -0,0 +1,339 @@
+package shell
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+)
+
+// A Completer returns the candidates for completing word, the (unquoted)
+// word which ends at the cursor, given the complete words before it in the
+// line. Candidates which don't start with word are ignored, so a Completer
+// may return every value that's valid at that position. A candidate which
+// ends in "/" is assumed to be incomplete, so completing it doesn't end the
+// word.
+type Completer func(words []string, word string) []string
+
+// A LineReader reads lines typed at a terminal, with line editing, history
+// and tab completion. If its input isn't a terminal it just reads lines.
+type LineReader struct {
+	in       *os.File
+	r        *bufio.Reader
+	out      io.Writer
+	prompt   string
+	complete Completer
+	terminal bool
+	history  []string
+}
+
+// NewLineReader returns a LineReader which reads from in, displays prompt
+// and echoes input to out, and completes words with complete.
+func NewLineReader(in *os.File, out io.Writer, prompt string, complete Completer) *LineReader {
+	return &LineReader{
+		in:       in,
+		r:        bufio.NewReader(in),
+		out:      out,
+		prompt:   prompt,
+		complete: complete,
+		terminal: isatty.IsTerminal(in.Fd()),
+	}
+}
+
+// ReadLine reads a line, without its trailing newline. It returns io.EOF
+// when the input ends, or when CTRL-D is typed on an empty line.
+func (l *LineReader) ReadLine() (string, error) {
+	if !l.terminal {
+		line, err := l.r.ReadString('\n')
+		if err == io.EOF && line != "" {
+			err = nil
+		}
+		return strings.TrimRight(line, "\r\n"), err
+	}
+	restore, err := l.makeRaw()
+	if err != nil {
+		return "", err
+	}
+	defer restore()
+	return l.edit()
+}
+
+// makeRaw puts the terminal into a mode in which each key is read as it's
+// typed, without being echoed or generating signals, and returns a function
+// which restores its previous mode.
+func (l *LineReader) makeRaw() (func() error, error) {
+	state, err := l.stty("-g")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := l.stty("-icanon", "-echo", "-isig", "min", "1", "time", "0"); err != nil {
+		return nil, err
+	}
+	return func() error {
+		_, err := l.stty(strings.TrimSpace(state))
+		return err
+	}, nil
+}
+
+func (l *LineReader) stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = l.in
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("error configuring terminal: %v", err)
+	}
+	return string(out), nil
+}
+
+// width returns the width of the terminal, or 80 if it can't be determined.
+func (l *LineReader) width() int {
+	size, err := l.stty("size")
+	if err != nil {
+		return 80
+	}
+	fields := strings.Fields(size)
+	if len(fields) != 2 {
+		return 80
+	}
+	width, err := strconv.Atoi(fields[1])
+	if err != nil || width <= 0 {
+		return 80
+	}
+	return width
+}
+
+// edit reads a line from a terminal in raw mode.
+func (l *LineReader) edit() (string, error) {
+	var line []rune
+	pos := 0
+	// history[historyPos] is the line being shown, the line being typed is
+	// at len(history)
+	historyPos := len(l.history)
+	var typed []rune
+	setLine := func(newLine []rune) {
+		line = append([]rune(nil), newLine...)
+		pos = len(line)
+	}
+	showHistory := func(i int) {
+		if i < 0 || i > len(l.history) || i == historyPos {
+			return
+		}
+		if historyPos == len(l.history) {
+			typed = line
+		}
+		historyPos = i
+		if i == len(l.history) {
+			setLine(typed)
+		} else {
+			setLine([]rune(l.history[i]))
+		}
+	}
+	l.redraw(line, pos)
+	for {
+		r, _, err := l.r.ReadRune()
+		if err != nil {
+			return "", err
+		}
+		switch r {
+		case '\r', '\n':
+			fmt.Fprint(l.out, "\r\n")
+			result := string(line)
+			if strings.TrimSpace(result) != "" && (len(l.history) == 0 || l.history[len(l.history)-1] != result) {
+				l.history = append(l.history, result)
+			}
+			return result, nil
+		case 3: // CTRL-C abandons the line
+			fmt.Fprint(l.out, "^C\r\n")
+			line, pos = nil, 0
+			historyPos = len(l.history)
+		case 4: // CTRL-D
+			if len(line) == 0 {
+				fmt.Fprint(l.out, "\r\n")
+				return "", io.EOF
+			}
+			if pos < len(line) {
+				line = append(line[:pos], line[pos+1:]...)
+			}
+		case 127, 8: // backspace
+			if pos > 0 {
+				line = append(line[:pos-1], line[pos:]...)
+				pos--
+			}
+		case 1: // CTRL-A
+			pos = 0
+		case 5: // CTRL-E
+			pos = len(line)
+		case 2: // CTRL-B
+			if pos > 0 {
+				pos--
+			}
+		case 6: // CTRL-F
+			if pos < len(line) {
+				pos++
+			}
+		case 11: // CTRL-K
+			line = line[:pos]
+		case 21: // CTRL-U
+			line = line[pos:]
+			pos = 0
+		case 23: // CTRL-W deletes the word before the cursor
+			start := pos
+			for start > 0 && line[start-1] == ' ' {
+				start--
+			}
+			for start > 0 && line[start-1] != ' ' {
+				start--
+			}
+			line = append(line[:start], line[pos:]...)
+			pos = start
+		case 16: // CTRL-P
+			showHistory(historyPos - 1)
+		case 14: // CTRL-N
+			showHistory(historyPos + 1)
+		case '\t':
+			insert, candidates := completeLine(l.complete, string(line[:pos]))
+			if insert != "" {
+				line = append(line[:pos], append([]rune(insert), line[pos:]...)...)
+				pos += len([]rune(insert))
+			} else if len(candidates) > 0 {
+				fmt.Fprint(l.out, "\r\n")
+				l.printCandidates(candidates)
+			}
+		case 27: // escape sequences, for the arrow keys etc.
+			if err := l.escape(&line, &pos, showHistory, historyPos); err != nil {
+				return "", err
+			}
+		default:
+			if r >= ' ' {
+				line = append(line[:pos], append([]rune{r}, line[pos:]...)...)
+				pos++
+			}
+		}
+		l.redraw(line, pos)
+	}
+}
+
+// escape handles the rest of an escape sequence, the ESC having been read.
+func (l *LineReader) escape(line *[]rune, pos *int, showHistory func(int), historyPos int) error {
+	r, _, err := l.r.ReadRune()
+	if err != nil {
+		return err
+	}
+	if r != '[' && r != 'O' {
+		return nil
+	}
+	if r, _, err = l.r.ReadRune(); err != nil {
+		return err
+	}
+	switch r {
+	case 'A':
+		showHistory(historyPos - 1)
+	case 'B':
+		showHistory(historyPos + 1)
+	case 'C':
+		if *pos < len(*line) {
+			*pos++
+		}
+	case 'D':
+		if *pos > 0 {
+			*pos--
+		}
+	case 'H':
+		*pos = 0
+	case 'F':
+		*pos = len(*line)
+	case '3': // delete, ESC [ 3 ~
+		if r, _, err = l.r.ReadRune(); err != nil {
+			return err
+		}
+		if r == '~' && *pos < len(*line) {
+			*line = append((*line)[:*pos], (*line)[*pos+1:]...)
+		}
+	}
+	return nil
+}
+
+// redraw rewrites the current line of the terminal with the prompt and
+// line, and moves the cursor to pos.
+func (l *LineReader) redraw(line []rune, pos int) {
+	fmt.Fprintf(l.out, "\r%s%s\x1b[K", l.prompt, string(line))
+	if pos < len(line) {
+		fmt.Fprintf(l.out, "\x1b[%dD", len(line)-pos)
+	}
+}
+
+// printCandidates prints candidates in columns.
+func (l *LineReader) printCandidates(candidates []string) {
+	width := 0
+	for _, candidate := range candidates {
+		if len(candidate) > width {
+			width = len(candidate)
+		}
+	}
+	width += 2
+	columns := l.width() / width
+	if columns < 1 {
+		columns = 1
+	}
+	for i, candidate := range candidates {
+		fmt.Fprintf(l.out, "%-*s", width, candidate)
+		if (i+1)%columns == 0 || i == len(candidates)-1 {
+			fmt.Fprint(l.out, "\r\n")
+		}
+	}
+}
+
+// completeLine completes the word which ends at the end of line. It returns
+// the text to insert at the end of line, or, if the candidates have no
+// common prefix beyond what's already been typed, the sorted candidates.
+func completeLine(complete Completer, line string) (string, []string) {
+	s := split(line)
+	word := ""
+	if s.inWord {
+		word = s.last
+	}
+	var candidates []string
+	seen := make(map[string]bool)
+	for _, candidate := range complete(s.words, word) {
+		if strings.HasPrefix(candidate, word) && !seen[candidate] {
+			seen[candidate] = true
+			candidates = append(candidates, candidate)
+		}
+	}
+	switch len(candidates) {
+	case 0:
+		return "", nil
+	case 1:
+		candidate := candidates[0]
+		insert := quote(candidate[len(word):], s.quote)
+		if !strings.HasSuffix(candidate, "/") {
+			if s.quote != 0 {
+				insert += string(s.quote)
+			}
+			insert += " "
+		}
+		return insert, nil
+	}
+	prefix := commonPrefix(candidates)
+	if len(prefix) > len(word) {
+		return quote(prefix[len(word):], s.quote), nil
+	}
+	sort.Strings(candidates)
+	return "", candidates
+}
+
+func commonPrefix(words []string) string {
+	prefix := words[0]
+	for _, word := range words[1:] {
+		for !strings.HasPrefix(word, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
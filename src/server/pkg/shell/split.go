@@ -0,0 +1,103 @@
+package shell
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Split splits line into words the way a POSIX shell does (without any
+// expansion): words are separated by unquoted whitespace, single quotes
+// preserve everything between them, double quotes preserve everything but
+// backslash escapes, and an unquoted backslash escapes the next character.
+func Split(line string) ([]string, error) {
+	s := split(line)
+	if s.quote != 0 {
+		return nil, fmt.Errorf("unterminated %c", s.quote)
+	}
+	if s.inWord {
+		s.words = append(s.words, s.last)
+	}
+	return s.words, nil
+}
+
+// splitState is the result of splitting a line which may end part way
+// through a word, as happens when it's being completed.
+type splitState struct {
+	// words are the complete words in the line
+	words []string
+	// last is the (unquoted) word at the end of the line, if inWord is true
+	last   string
+	inWord bool
+	// quote is the quote that the line ends inside of, or 0
+	quote rune
+}
+
+func split(line string) splitState {
+	var s splitState
+	var word []rune
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			// within double quotes a backslash only escapes " and \
+			if s.quote == '"' && r != '"' && r != '\\' {
+				word = append(word, '\\')
+			}
+			word = append(word, r)
+			escaped = false
+		case s.quote == '\'':
+			if r == '\'' {
+				s.quote = 0
+			} else {
+				word = append(word, r)
+			}
+		case r == '\\':
+			escaped = true
+			s.inWord = true
+		case s.quote == '"':
+			if r == '"' {
+				s.quote = 0
+			} else {
+				word = append(word, r)
+			}
+		case r == '\'' || r == '"':
+			s.quote = r
+			s.inWord = true
+		case unicode.IsSpace(r):
+			if s.inWord {
+				s.words = append(s.words, string(word))
+				word = word[:0]
+				s.inWord = false
+			}
+		default:
+			word = append(word, r)
+			s.inWord = true
+		}
+	}
+	s.last = string(word)
+	return s
+}
+
+// quote returns word escaped so that it's read back as a single word by
+// Split, either as a whole word (inQuote == 0) or as the continuation of a
+// word which is within inQuote.
+func quote(word string, inQuote rune) string {
+	var escape string
+	switch inQuote {
+	case '\'':
+		return strings.Replace(word, "'", `'\''`, -1)
+	case '"':
+		escape = `"\`
+	default:
+		escape = " \t'\"\\"
+	}
+	var result []rune
+	for _, r := range word {
+		if strings.ContainsRune(escape, r) {
+			result = append(result, '\\')
+		}
+		result = append(result, r)
+	}
+	return string(result)
+}
@@ -0,0 +1,72 @@
+package shell
+
+import (
+	"testing"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+)
+
+func TestSplit(t *testing.T) {
+	words, err := Split(`put-file  repo master -f "my file" 'a "b"' c\ d "e\"\f"`)
+	require.NoError(t, err)
+	require.Equal(t, []string{"put-file", "repo", "master", "-f", "my file", `a "b"`, "c d", `e"\f`}, words)
+
+	words, err = Split(`list-repo ""`)
+	require.NoError(t, err)
+	require.Equal(t, []string{"list-repo", ""}, words)
+
+	words, err = Split("  ")
+	require.NoError(t, err)
+	require.Equal(t, 0, len(words))
+
+	_, err = Split(`get-file "repo`)
+	require.YesError(t, err)
+}
+
+func TestQuote(t *testing.T) {
+	for _, word := range []string{"plain", "my file", `a "b" 'c'`, `back\slash`} {
+		for _, quoted := range []string{
+			quote(word, 0),
+			`"` + quote(word, '"') + `"`,
+			"'" + quote(word, '\'') + "'",
+		} {
+			words, err := Split(quoted)
+			require.NoError(t, err)
+			require.Equal(t, []string{word}, words)
+		}
+	}
+}
+
+func TestCompleteLine(t *testing.T) {
+	complete := func(words []string, word string) []string {
+		if len(words) == 0 {
+			return []string{"list-repo", "list-commit", "inspect-repo"}
+		}
+		return []string{"images", "my repo", "dir/"}
+	}
+	insert, candidates := completeLine(complete, "ins")
+	require.Equal(t, "pect-repo ", insert)
+	require.Equal(t, 0, len(candidates))
+
+	// the candidates' common prefix is inserted
+	insert, _ = completeLine(complete, "l")
+	require.Equal(t, "ist-", insert)
+
+	// if there's no common prefix the candidates are returned
+	insert, candidates = completeLine(complete, "list-")
+	require.Equal(t, "", insert)
+	require.Equal(t, []string{"list-commit", "list-repo"}, candidates)
+
+	insert, _ = completeLine(complete, "inspect-repo my")
+	require.Equal(t, `\ repo `, insert)
+	insert, _ = completeLine(complete, `inspect-repo "my`)
+	require.Equal(t, ` repo" `, insert)
+
+	// completing a directory doesn't end the word
+	insert, _ = completeLine(complete, "inspect-repo d")
+	require.Equal(t, "ir/", insert)
+
+	insert, candidates = completeLine(complete, "inspect-repo x")
+	require.Equal(t, "", insert)
+	require.Equal(t, 0, len(candidates))
+}
@@ -0,0 +1,104 @@
+package sqldb
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+	"golang.org/x/net/context"
+)
+
+// ValidateEgress returns an error if egress isn't a SQL egress that Load can
+// load output with.
+func ValidateEgress(egress *pps.SQLEgress) error {
+	if err := validateURL(egress.URL); err != nil {
+		return err
+	}
+	if egress.Secret == "" {
+		return fmt.Errorf("SQL egress must set the secret with its database's credentials")
+	}
+	return nil
+}
+
+// Load loads the files in commit into the tables of the database that egress
+// names, authenticating with the items of its secret, and returns how many
+// rows it loaded into each table. Files whose extension isn't .csv, .json,
+// .jsonl or .ndjson are skipped. CSV files must start with a header that
+// names their columns, and JSON files hold objects whose keys name the
+// columns.
+func Load(ctx context.Context, pachClient *client.APIClient, commit *pfs.Commit, egress *pps.SQLEgress, secret map[string][]byte) (_ []*pps.SQLEgressLoad, retErr error) {
+	files := make(map[string][]string)
+	if err := pachClient.Walk(commit.Repo.Name, commit.ID, "/", func(fileInfo *pfs.FileInfo) error {
+		if fileInfo.FileType != pfs.FileType_FILE {
+			return nil
+		}
+		if table, ok := tableOf(fileInfo.File.Path); ok {
+			files[table] = append(files[table], fileInfo.File.Path)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	var tables []string
+	for table := range files {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	db, err := open(ctx, egress.URL, secret)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := db.close(); err != nil && retErr == nil {
+			retErr = err
+		}
+	}()
+	var loads []*pps.SQLEgressLoad
+	for _, table := range tables {
+		if egress.Truncate {
+			if err := db.truncate(ctx, table); err != nil {
+				return nil, fmt.Errorf("error truncating %s: %v", table, err)
+			}
+		}
+		load := &pps.SQLEgressLoad{Table: table}
+		for _, file := range files[table] {
+			r, err := pachClient.GetFileReader(commit.Repo.Name, commit.ID, file, 0, 0)
+			if err != nil {
+				return nil, err
+			}
+			rows, err := newRowReader(file, r)
+			if err != nil {
+				return nil, fmt.Errorf("error reading %s: %v", file, err)
+			}
+			n, err := db.load(ctx, table, rows)
+			if err != nil {
+				return nil, fmt.Errorf("error loading %s into %s: %v", file, table, err)
+			}
+			load.Rows += n
+		}
+		loads = append(loads, load)
+	}
+	if err := db.commit(ctx); err != nil {
+		return nil, err
+	}
+	return loads, nil
+}
+
+// tableOf returns the table that the file at p is loaded into, which is its
+// top-level path component, without its extension if it's the file itself.
+// It returns false if the file isn't CSV or JSON.
+func tableOf(p string) (string, bool) {
+	if formatOf(p) == "" {
+		return "", false
+	}
+	parts := strings.SplitN(strings.TrimPrefix(path.Clean("/"+p), "/"), "/", 2)
+	if len(parts) == 1 {
+		return strings.TrimSuffix(parts[0], path.Ext(parts[0])), true
+	}
+	return parts[0], true
+}
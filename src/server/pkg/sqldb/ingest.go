@@ -0,0 +1,129 @@
+package sqldb
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+
+	"github.com/pachyderm/pachyderm/src/client/pps"
+	"golang.org/x/net/context"
+)
+
+// ValidateInput returns an error if input isn't a SQL input that Snapshot can
+// snapshot.
+func ValidateInput(input *pps.SQLInput) error {
+	if err := validateURL(input.URL); err != nil {
+		return err
+	}
+	if input.Secret == "" {
+		return fmt.Errorf("SQL input must set the secret with its database's credentials")
+	}
+	if input.Query == "" {
+		return fmt.Errorf("SQL input must set its query")
+	}
+	switch input.Format {
+	case "", "csv", "parquet":
+	default:
+		return fmt.Errorf("unsupported SQL input format %q, only csv and parquet are supported", input.Format)
+	}
+	return nil
+}
+
+// SnapshotFile returns the path of the file that input's snapshots are
+// written to.
+func SnapshotFile(input *pps.SQLInput) string {
+	if input.Format == "parquet" {
+		return "snapshot.parquet"
+	}
+	return "snapshot.csv"
+}
+
+// Snapshot runs input's query against its database, authenticating with the
+// items of its secret, writes the results to w, in input's format, and
+// returns how many rows it wrote.
+func Snapshot(ctx context.Context, input *pps.SQLInput, secret map[string][]byte, w io.Writer) (_ int64, retErr error) {
+	db, err := open(ctx, input.URL, secret)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err := db.close(); err != nil && retErr == nil {
+			retErr = err
+		}
+	}()
+	results, err := db.query(ctx, input.Query)
+	if err != nil {
+		return 0, err
+	}
+	var sw snapshotWriter
+	if input.Format == "parquet" {
+		sw, err = newParquetWriter(w, results.columns())
+	} else {
+		sw, err = newCSVWriter(w, results.columns())
+	}
+	if err != nil {
+		return 0, err
+	}
+	var rows int64
+	for {
+		values, err := results.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		if err := sw.write(values); err != nil {
+			return 0, err
+		}
+		rows++
+	}
+	if err := sw.close(); err != nil {
+		return 0, err
+	}
+	return rows, nil
+}
+
+// snapshotWriter writes the rows of a query's results to a snapshot.
+type snapshotWriter interface {
+	// write writes a row, where nil values are NULL.
+	write(values []*string) error
+	// close writes anything that's buffered, it doesn't close the underlying
+	// writer.
+	close() error
+}
+
+// csvWriter writes snapshots as CSV, starting with a header that names the
+// columns. NULLs are written as empty fields, as SQL egress reads them.
+type csvWriter struct {
+	w      *csv.Writer
+	record []string
+}
+
+func newCSVWriter(w io.Writer, columns []column) (*csvWriter, error) {
+	c := &csvWriter{w: csv.NewWriter(w)}
+	var header []string
+	for _, column := range columns {
+		header = append(header, column.name)
+	}
+	if err := c.w.Write(header); err != nil {
+		return nil, err
+	}
+	c.record = make([]string, len(columns))
+	return c, nil
+}
+
+func (c *csvWriter) write(values []*string) error {
+	for i, value := range values {
+		c.record[i] = ""
+		if value != nil {
+			c.record[i] = *value
+		}
+	}
+	return c.w.Write(c.record)
+}
+
+func (c *csvWriter) close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
@@ -0,0 +1,392 @@
+package sqldb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// parquetRowGroupSize is how many rows are buffered, per column, before
+// they're written out as a row group.
+const parquetRowGroupSize = 100000
+
+// parquetMagic starts and ends every parquet file.
+const parquetMagic = "PAR1"
+
+// The values of parquet's thrift enums that parquetWriter uses.
+const (
+	parquetBoolean   = 0
+	parquetInt64     = 2
+	parquetDouble    = 5
+	parquetByteArray = 6
+
+	parquetOptional = 1
+	parquetUTF8     = 0
+
+	parquetDataPage     = 0
+	parquetPlain        = 0
+	parquetRLE          = 3
+	parquetUncompressed = 0
+)
+
+// parquetWriter writes snapshots as parquet files. Each column is OPTIONAL,
+// so that it can hold NULLs, and each of its chunks is a single uncompressed
+// data page, with PLAIN encoded values. Text columns are UTF8 byte arrays.
+type parquetWriter struct {
+	w       *countingWriter
+	columns []*parquetColumn
+	// rows is how many rows are buffered in columns
+	rows      int64
+	numRows   int64
+	rowGroups []*parquetRowGroup
+}
+
+// parquetRowGroup is a row group that's been written.
+type parquetRowGroup struct {
+	chunks []*parquetChunk
+	rows   int64
+}
+
+// parquetColumn buffers the values of a column for the current row group.
+type parquetColumn struct {
+	column
+	// present is, for each row, whether its value isn't NULL
+	present []bool
+	values  bytes.Buffer
+	bools   []bool
+}
+
+// parquetChunk is where a column chunk was written.
+type parquetChunk struct {
+	typ       int32
+	path      string
+	numValues int64
+	offset    int64
+	size      int64
+}
+
+func newParquetWriter(w io.Writer, columns []column) (*parquetWriter, error) {
+	p := &parquetWriter{w: &countingWriter{w: w}}
+	names := make(map[string]bool)
+	for _, c := range columns {
+		if names[c.name] {
+			return nil, fmt.Errorf("the query's results have more than one column named %q, which parquet doesn't allow", c.name)
+		}
+		names[c.name] = true
+		p.columns = append(p.columns, &parquetColumn{column: c})
+	}
+	if _, err := io.WriteString(p.w, parquetMagic); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *parquetWriter) write(values []*string) error {
+	for i, value := range values {
+		c := p.columns[i]
+		c.present = append(c.present, value != nil)
+		if value == nil {
+			continue
+		}
+		if err := c.append(*value); err != nil {
+			return fmt.Errorf("error writing column %q: %v", c.name, err)
+		}
+	}
+	p.rows++
+	if p.rows >= parquetRowGroupSize {
+		return p.flush()
+	}
+	return nil
+}
+
+// append appends value, PLAIN encoded, to c's values.
+func (c *parquetColumn) append(value string) error {
+	var buf [8]byte
+	switch c.typ {
+	case intColumn:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		binary.LittleEndian.PutUint64(buf[:], uint64(n))
+		c.values.Write(buf[:])
+	case floatColumn:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		binary.LittleEndian.PutUint64(buf[:], math.Float64bits(f))
+		c.values.Write(buf[:])
+	case boolColumn:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		c.bools = append(c.bools, b)
+	default:
+		binary.LittleEndian.PutUint32(buf[:4], uint32(len(value)))
+		c.values.Write(buf[:4])
+		c.values.WriteString(value)
+	}
+	return nil
+}
+
+func (c *parquetColumn) physicalType() int32 {
+	switch c.typ {
+	case intColumn:
+		return parquetInt64
+	case floatColumn:
+		return parquetDouble
+	case boolColumn:
+		return parquetBoolean
+	}
+	return parquetByteArray
+}
+
+// flush writes the buffered rows as a row group.
+func (p *parquetWriter) flush() error {
+	if p.rows == 0 {
+		return nil
+	}
+	var chunks []*parquetChunk
+	for _, c := range p.columns {
+		var page bytes.Buffer
+		// definition levels are 1 for values and 0 for NULLs, RLE/bit-packed
+		// hybrid encoded as a single bit-packed run, after their length
+		levels := encodeBitPackedRun(c.present)
+		var length [4]byte
+		binary.LittleEndian.PutUint32(length[:], uint32(len(levels)))
+		page.Write(length[:])
+		page.Write(levels)
+		if c.typ == boolColumn {
+			page.Write(packBits(c.bools))
+		} else {
+			page.Write(c.values.Bytes())
+		}
+
+		header := &compactWriter{}
+		header.i32(1, parquetDataPage)
+		header.i32(2, int32(page.Len()))
+		header.i32(3, int32(page.Len()))
+		header.beginStruct(5)
+		header.i32(1, int32(len(c.present)))
+		header.i32(2, parquetPlain)
+		header.i32(3, parquetRLE)
+		header.i32(4, parquetRLE)
+		header.endStruct()
+		header.stop()
+
+		chunk := &parquetChunk{
+			typ:       c.physicalType(),
+			path:      c.name,
+			numValues: int64(len(c.present)),
+			offset:    p.w.n,
+			size:      int64(header.buf.Len() + page.Len()),
+		}
+		if _, err := p.w.Write(header.buf.Bytes()); err != nil {
+			return err
+		}
+		if _, err := p.w.Write(page.Bytes()); err != nil {
+			return err
+		}
+		chunks = append(chunks, chunk)
+		c.present = c.present[:0]
+		c.values.Reset()
+		c.bools = c.bools[:0]
+	}
+	p.rowGroups = append(p.rowGroups, &parquetRowGroup{chunks: chunks, rows: p.rows})
+	p.numRows += p.rows
+	p.rows = 0
+	return nil
+}
+
+// close writes the buffered rows and the file's footer, which describes its
+// schema and where each of its column chunks is.
+func (p *parquetWriter) close() error {
+	if err := p.flush(); err != nil {
+		return err
+	}
+	m := &compactWriter{}
+	m.i32(1, 1)
+	m.beginList(2, compactStruct, len(p.columns)+1)
+	m.beginElement()
+	m.binary(4, "schema")
+	m.i32(5, int32(len(p.columns)))
+	m.endStruct()
+	for _, c := range p.columns {
+		m.beginElement()
+		m.i32(1, c.physicalType())
+		m.i32(3, parquetOptional)
+		m.binary(4, c.name)
+		if c.typ == textColumn {
+			m.i32(6, parquetUTF8)
+		}
+		m.endStruct()
+	}
+	m.i64(3, p.numRows)
+	m.beginList(4, compactStruct, len(p.rowGroups))
+	for _, rowGroup := range p.rowGroups {
+		m.beginElement()
+		m.beginList(1, compactStruct, len(rowGroup.chunks))
+		var size int64
+		for _, chunk := range rowGroup.chunks {
+			m.beginElement()
+			m.i64(2, chunk.offset)
+			m.beginStruct(3)
+			m.i32(1, chunk.typ)
+			m.beginList(2, compactI32, 2)
+			m.varint(zigzag(parquetPlain))
+			m.varint(zigzag(parquetRLE))
+			m.beginList(3, compactBinary, 1)
+			m.varint(uint64(len(chunk.path)))
+			m.buf.WriteString(chunk.path)
+			m.i32(4, parquetUncompressed)
+			m.i64(5, chunk.numValues)
+			m.i64(6, chunk.size)
+			m.i64(7, chunk.size)
+			m.i64(9, chunk.offset)
+			m.endStruct()
+			m.endStruct()
+			size += chunk.size
+		}
+		m.i64(2, size)
+		m.i64(3, rowGroup.rows)
+		m.endStruct()
+	}
+	m.binary(6, "pachyderm")
+	m.stop()
+
+	var length [4]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(m.buf.Len()))
+	for _, b := range [][]byte{m.buf.Bytes(), length[:], []byte(parquetMagic)} {
+		if _, err := p.w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeBitPackedRun encodes values, with a bit width of 1, as one
+// bit-packed run of parquet's RLE/bit-packed hybrid encoding.
+func encodeBitPackedRun(values []bool) []byte {
+	groups := (len(values) + 7) / 8
+	buf := make([]byte, binary.MaxVarintLen64)
+	buf = buf[:binary.PutUvarint(buf, uint64(groups<<1|1))]
+	return append(buf, packBits(values)...)
+}
+
+// packBits packs values into bytes, least significant bit first.
+func packBits(values []bool) []byte {
+	packed := make([]byte, (len(values)+7)/8)
+	for i, value := range values {
+		if value {
+			packed[i/8] |= 1 << uint(i%8)
+		}
+	}
+	return packed
+}
+
+// countingWriter counts the bytes written to w, so that the footer can say
+// where each column chunk is.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// The types of thrift's compact protocol that compactWriter uses.
+const (
+	compactI32    = 5
+	compactI64    = 6
+	compactBinary = 8
+	compactList   = 9
+	compactStruct = 12
+)
+
+// compactWriter writes thrift structs with thrift's compact protocol, which
+// is how parquet's page headers and footer are encoded.
+type compactWriter struct {
+	buf bytes.Buffer
+	// field is the id of the last field written in the current struct, and
+	// fields are those of the structs that it's nested in
+	field  int16
+	fields []int16
+}
+
+func (c *compactWriter) fieldHeader(id int16, typ byte) {
+	if delta := id - c.field; delta > 0 && delta <= 15 {
+		c.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		c.buf.WriteByte(typ)
+		c.varint(zigzag(int64(id)))
+	}
+	c.field = id
+}
+
+func (c *compactWriter) i32(id int16, value int32) {
+	c.fieldHeader(id, compactI32)
+	c.varint(zigzag(int64(value)))
+}
+
+func (c *compactWriter) i64(id int16, value int64) {
+	c.fieldHeader(id, compactI64)
+	c.varint(zigzag(value))
+}
+
+func (c *compactWriter) binary(id int16, value string) {
+	c.fieldHeader(id, compactBinary)
+	c.varint(uint64(len(value)))
+	c.buf.WriteString(value)
+}
+
+// beginStruct starts a struct field, which endStruct ends.
+func (c *compactWriter) beginStruct(id int16) {
+	c.fieldHeader(id, compactStruct)
+	c.beginElement()
+}
+
+// beginList starts a list field of n elements of type typ. The elements are
+// written after it, each struct starting with beginElement.
+func (c *compactWriter) beginList(id int16, typ byte, n int) {
+	c.fieldHeader(id, compactList)
+	if n < 15 {
+		c.buf.WriteByte(byte(n)<<4 | typ)
+	} else {
+		c.buf.WriteByte(0xf0 | typ)
+		c.varint(uint64(n))
+	}
+}
+
+// beginElement starts a struct that's an element of a list.
+func (c *compactWriter) beginElement() {
+	c.fields = append(c.fields, c.field)
+	c.field = 0
+}
+
+func (c *compactWriter) endStruct() {
+	c.stop()
+	c.field = c.fields[len(c.fields)-1]
+	c.fields = c.fields[:len(c.fields)-1]
+}
+
+// stop ends the fields of a struct.
+func (c *compactWriter) stop() {
+	c.buf.WriteByte(0)
+}
+
+func (c *compactWriter) varint(value uint64) {
+	var buf [binary.MaxVarintLen64]byte
+	c.buf.Write(buf[:binary.PutUvarint(buf[:], value)])
+}
+
+func zigzag(value int64) uint64 {
+	return uint64(value<<1) ^ uint64(value>>63)
+}
@@ -0,0 +1,671 @@
+package sqldb
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/net/context"
+)
+
+const (
+	// postgresProtocolVersion is version 3.0 of postgres' wire protocol
+	postgresProtocolVersion = 196608
+	// postgresSSLRequestCode asks the server to switch to TLS
+	postgresSSLRequestCode = 80877103
+	// postgresCopyChunkSize is how much CSV is buffered before it's sent
+	postgresCopyChunkSize = 64 * 1024
+)
+
+// postgresConfig is where a postgres database is, and how to connect to it.
+type postgresConfig struct {
+	addr     string
+	host     string
+	user     string
+	database string
+	// sslMode is "disable", "require" (TLS, without verifying the server's
+	// certificate, which is the default) or "verify-full"
+	sslMode string
+}
+
+// parsePostgresURL parses a URL like
+// "postgres://user@host:5432/database?sslmode=verify-full".
+func parsePostgresURL(u *url.URL) (*postgresConfig, error) {
+	config := &postgresConfig{
+		host:     u.Hostname(),
+		database: strings.TrimPrefix(u.Path, "/"),
+		sslMode:  u.Query().Get("sslmode"),
+	}
+	if config.host == "" {
+		return nil, fmt.Errorf("postgres URL %q has no host", u.Host)
+	}
+	port := u.Port()
+	if port == "" {
+		port = "5432"
+	}
+	config.addr = net.JoinHostPort(config.host, port)
+	if u.User != nil {
+		config.user = u.User.Username()
+		if _, ok := u.User.Password(); ok {
+			return nil, fmt.Errorf("postgres URL mustn't include a password, it's read from the SQL egress' secret")
+		}
+	}
+	if config.user == "" {
+		return nil, fmt.Errorf("postgres URL must include a user, e.g. postgres://user@host/database")
+	}
+	if config.database == "" {
+		config.database = config.user
+	}
+	switch config.sslMode {
+	case "":
+		config.sslMode = "require"
+	case "disable", "require", "verify-full":
+	default:
+		return nil, fmt.Errorf("invalid sslmode %q, it must be disable, require or verify-full", config.sslMode)
+	}
+	return config, nil
+}
+
+// postgres is a connection to a postgres database (or another database that
+// speaks its protocol). Output is loaded with COPY, and everything it does
+// happens in a single transaction.
+type postgres struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func openPostgres(ctx context.Context, u *url.URL, password string) (database, error) {
+	config, err := parsePostgresURL(u)
+	if err != nil {
+		return nil, err
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", config.addr)
+	if err != nil {
+		return nil, err
+	}
+	p := &postgres{conn: conn, r: bufio.NewReader(conn)}
+	defer p.watch(ctx)()
+	if config.sslMode != "disable" {
+		if err := p.startTLS(config); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if err := p.startup(config, password); err != nil {
+		p.conn.Close()
+		return nil, err
+	}
+	if _, err := p.exec("BEGIN"); err != nil {
+		p.conn.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+// watch closes the connection if ctx is cancelled before the returned
+// function is called, which interrupts any blocked reads and writes.
+func (p *postgres) watch(ctx context.Context) func() {
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			p.conn.Close()
+		case <-done:
+		}
+	}()
+	return func() { close(done) }
+}
+
+func (p *postgres) startTLS(config *postgresConfig) error {
+	request := make([]byte, 8)
+	binary.BigEndian.PutUint32(request[0:4], 8)
+	binary.BigEndian.PutUint32(request[4:8], postgresSSLRequestCode)
+	if _, err := p.conn.Write(request); err != nil {
+		return err
+	}
+	response, err := p.r.ReadByte()
+	if err != nil {
+		return err
+	}
+	if response != 'S' {
+		return fmt.Errorf("postgres server doesn't support TLS, add sslmode=disable to the URL to connect without it")
+	}
+	tlsConn := tls.Client(p.conn, &tls.Config{
+		ServerName:         config.host,
+		InsecureSkipVerify: config.sslMode == "require",
+	})
+	if err := tlsConn.Handshake(); err != nil {
+		return err
+	}
+	p.conn = tlsConn
+	p.r = bufio.NewReader(tlsConn)
+	return nil
+}
+
+// startup logs in, and waits until the server's ready for queries.
+func (p *postgres) startup(config *postgresConfig, password string) error {
+	var body bytes.Buffer
+	binary.Write(&body, binary.BigEndian, uint32(postgresProtocolVersion))
+	for _, param := range []string{"user", config.user, "database", config.database, "application_name", "pachyderm"} {
+		body.WriteString(param)
+		body.WriteByte(0)
+	}
+	body.WriteByte(0)
+	message := make([]byte, 4, 4+body.Len())
+	binary.BigEndian.PutUint32(message, uint32(4+body.Len()))
+	if _, err := p.conn.Write(append(message, body.Bytes()...)); err != nil {
+		return err
+	}
+	var scram *scramClient
+	for {
+		typ, body, err := p.receive()
+		if err != nil {
+			return err
+		}
+		switch typ {
+		case 'R':
+			if len(body) < 4 {
+				return fmt.Errorf("invalid authentication request")
+			}
+			switch code := binary.BigEndian.Uint32(body); code {
+			case 0: // authenticated
+			case 3: // cleartext password
+				err = p.send('p', cstring(password))
+			case 5: // MD5 password
+				if len(body) < 8 {
+					return fmt.Errorf("invalid MD5 authentication request")
+				}
+				inner := md5Hex(password + config.user)
+				err = p.send('p', cstring("md5"+md5Hex(inner+string(body[4:8]))))
+			case 10: // SASL
+				mechanisms := strings.Split(strings.TrimRight(string(body[4:]), "\x00"), "\x00")
+				if !contains(mechanisms, "SCRAM-SHA-256") {
+					return fmt.Errorf("unsupported SASL mechanisms %v", mechanisms)
+				}
+				// postgres ignores SCRAM's username, it uses the user above
+				if scram, err = newSCRAMClient("", password); err != nil {
+					return err
+				}
+				first := scram.clientFirst()
+				var response bytes.Buffer
+				response.Write(cstring("SCRAM-SHA-256"))
+				binary.Write(&response, binary.BigEndian, uint32(len(first)))
+				response.WriteString(first)
+				err = p.send('p', response.Bytes())
+			case 11: // SASL continue
+				if scram == nil {
+					return fmt.Errorf("unexpected SASL continue")
+				}
+				var final string
+				if final, err = scram.clientFinal(string(body[4:])); err != nil {
+					return err
+				}
+				err = p.send('p', []byte(final))
+			case 12: // SASL final
+				if scram == nil {
+					return fmt.Errorf("unexpected SASL final")
+				}
+				err = scram.verify(string(body[4:]))
+			default:
+				return fmt.Errorf("unsupported postgres authentication method %d", code)
+			}
+			if err != nil {
+				return err
+			}
+		case 'E':
+			return postgresError(body)
+		case 'Z':
+			return nil
+		}
+	}
+}
+
+// send sends a message of type typ.
+func (p *postgres) send(typ byte, body []byte) error {
+	message := make([]byte, 5, 5+len(body))
+	message[0] = typ
+	binary.BigEndian.PutUint32(message[1:], uint32(4+len(body)))
+	_, err := p.conn.Write(append(message, body...))
+	return err
+}
+
+// receive receives the next message, skipping notices and the server's
+// parameters.
+func (p *postgres) receive() (byte, []byte, error) {
+	for {
+		header := make([]byte, 5)
+		if _, err := io.ReadFull(p.r, header); err != nil {
+			return 0, nil, err
+		}
+		length := binary.BigEndian.Uint32(header[1:])
+		if length < 4 {
+			return 0, nil, fmt.Errorf("invalid postgres message length %d", length)
+		}
+		body := make([]byte, length-4)
+		if _, err := io.ReadFull(p.r, body); err != nil {
+			return 0, nil, err
+		}
+		switch header[0] {
+		case 'N', 'S', 'K': // notice, parameter status, backend key
+			continue
+		}
+		return header[0], body, nil
+	}
+}
+
+// exec runs query, and returns its command tag, e.g. "COPY 10".
+func (p *postgres) exec(query string) (string, error) {
+	if err := p.send('Q', cstring(query)); err != nil {
+		return "", err
+	}
+	return p.finish()
+}
+
+// finish reads the results of a query until the server is ready for the
+// next one, and returns its command tag, or its error.
+func (p *postgres) finish() (string, error) {
+	var tag string
+	var queryErr error
+	for {
+		typ, body, err := p.receive()
+		if err != nil {
+			return "", err
+		}
+		switch typ {
+		case 'C':
+			tag = strings.TrimRight(string(body), "\x00")
+		case 'E':
+			queryErr = postgresError(body)
+		case 'G':
+			// the query is a COPY, which isn't expected here
+			if err := p.send('f', cstring("unexpected COPY")); err != nil {
+				return "", err
+			}
+		case 'Z':
+			return tag, queryErr
+		}
+	}
+}
+
+func (p *postgres) truncate(ctx context.Context, table string) error {
+	defer p.watch(ctx)()
+	_, err := p.exec("TRUNCATE TABLE " + quoteTable(table))
+	return err
+}
+
+func (p *postgres) load(ctx context.Context, table string, rows rowReader) (int64, error) {
+	defer p.watch(ctx)()
+	columns := rows.columns()
+	if len(columns) == 0 {
+		return 0, nil
+	}
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = quoteName(column)
+	}
+	query := fmt.Sprintf("COPY %s (%s) FROM STDIN WITH (FORMAT csv)", quoteTable(table), strings.Join(quoted, ", "))
+	if err := p.send('Q', cstring(query)); err != nil {
+		return 0, err
+	}
+	for copying := false; !copying; {
+		typ, body, err := p.receive()
+		if err != nil {
+			return 0, err
+		}
+		switch typ {
+		case 'G':
+			copying = true
+		case 'E':
+			// e.g. the table doesn't exist, the rest of the response is just
+			// the server saying it's ready again
+			p.finish()
+			return 0, postgresError(body)
+		}
+	}
+	var buf bytes.Buffer
+	for {
+		values, err := rows.next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			if err := p.send('f', cstring(err.Error())); err != nil {
+				return 0, err
+			}
+			p.finish()
+			return 0, err
+		}
+		writePostgresCSV(&buf, values)
+		if buf.Len() >= postgresCopyChunkSize {
+			if err := p.send('d', buf.Bytes()); err != nil {
+				return 0, err
+			}
+			buf.Reset()
+		}
+	}
+	if buf.Len() > 0 {
+		if err := p.send('d', buf.Bytes()); err != nil {
+			return 0, err
+		}
+	}
+	if err := p.send('c', nil); err != nil {
+		return 0, err
+	}
+	tag, err := p.finish()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimPrefix(tag, "COPY "), 10, 64)
+}
+
+func (p *postgres) query(ctx context.Context, query string) (resultReader, error) {
+	stop := p.watch(ctx)
+	// snapshots mustn't change the database
+	if _, err := p.exec("SET TRANSACTION READ ONLY"); err != nil {
+		stop()
+		return nil, err
+	}
+	if err := p.send('Q', cstring(query)); err != nil {
+		stop()
+		return nil, err
+	}
+	for {
+		typ, body, err := p.receive()
+		if err != nil {
+			stop()
+			return nil, err
+		}
+		switch typ {
+		case 'T':
+			columns, err := parseRowDescription(body)
+			if err != nil {
+				stop()
+				return nil, err
+			}
+			return &postgresResults{p: p, cols: columns, stop: stop}, nil
+		case 'E':
+			p.finish()
+			stop()
+			return nil, postgresError(body)
+		case 'Z':
+			stop()
+			return nil, fmt.Errorf("query returned no rows, it must be a SELECT")
+		}
+	}
+}
+
+// postgresTypes are the types of the columns whose values aren't text, by
+// their type's OID.
+var postgresTypes = map[uint32]columnType{
+	16:  boolColumn,  // bool
+	20:  intColumn,   // int8
+	21:  intColumn,   // int2
+	23:  intColumn,   // int4
+	700: floatColumn, // float4
+	701: floatColumn, // float8
+}
+
+// parseRowDescription returns the columns in the body of a RowDescription.
+func parseRowDescription(body []byte) ([]column, error) {
+	if len(body) < 2 {
+		return nil, fmt.Errorf("invalid postgres row description")
+	}
+	n := int(binary.BigEndian.Uint16(body))
+	body = body[2:]
+	columns := make([]column, n)
+	for i := range columns {
+		end := bytes.IndexByte(body, 0)
+		// each name is followed by 18 bytes of its table, type and format
+		if end < 0 || len(body) < end+19 {
+			return nil, fmt.Errorf("invalid postgres row description")
+		}
+		columns[i].name = string(body[:end])
+		columns[i].typ = postgresTypes[binary.BigEndian.Uint32(body[end+7:])]
+		body = body[end+19:]
+	}
+	return columns, nil
+}
+
+// postgresResults reads the rows of a query's results as they're received.
+type postgresResults struct {
+	p    *postgres
+	cols []column
+	// stop stops watching the query's context, once its results are read
+	stop func()
+	done bool
+}
+
+func (r *postgresResults) columns() []column {
+	return r.cols
+}
+
+func (r *postgresResults) next() ([]*string, error) {
+	if r.done {
+		return nil, io.EOF
+	}
+	for {
+		typ, body, err := r.p.receive()
+		if err != nil {
+			r.finish()
+			return nil, err
+		}
+		switch typ {
+		case 'D':
+			values, err := parseDataRow(body, len(r.cols))
+			if err != nil {
+				r.finish()
+				return nil, err
+			}
+			return values, nil
+		case 'E':
+			r.p.finish()
+			r.finish()
+			return nil, postgresError(body)
+		case 'T':
+			r.p.finish()
+			r.finish()
+			return nil, fmt.Errorf("query returned more than one set of rows, it must be a single SELECT")
+		case 'Z':
+			r.finish()
+			return nil, io.EOF
+		}
+	}
+}
+
+func (r *postgresResults) finish() {
+	if !r.done {
+		r.done = true
+		r.stop()
+	}
+}
+
+// parseDataRow returns the values in the body of a DataRow.
+func parseDataRow(body []byte, n int) ([]*string, error) {
+	if len(body) < 2 || int(binary.BigEndian.Uint16(body)) != n {
+		return nil, fmt.Errorf("invalid postgres data row")
+	}
+	body = body[2:]
+	values := make([]*string, n)
+	for i := range values {
+		if len(body) < 4 {
+			return nil, fmt.Errorf("invalid postgres data row")
+		}
+		length := int32(binary.BigEndian.Uint32(body))
+		body = body[4:]
+		if length < 0 {
+			// NULL
+			continue
+		}
+		if len(body) < int(length) {
+			return nil, fmt.Errorf("invalid postgres data row")
+		}
+		value := string(body[:length])
+		values[i] = &value
+		body = body[length:]
+	}
+	return values, nil
+}
+
+func (p *postgres) commit(ctx context.Context) error {
+	defer p.watch(ctx)()
+	_, err := p.exec("COMMIT")
+	return err
+}
+
+// close closes the connection, which rolls back the transaction if it
+// wasn't committed.
+func (p *postgres) close() error {
+	p.send('X', nil)
+	return p.conn.Close()
+}
+
+// writePostgresCSV writes a row in the CSV format that COPY reads, where
+// NULL is an empty, unquoted value.
+func writePostgresCSV(buf *bytes.Buffer, values []*string) {
+	for i, value := range values {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if value != nil {
+			buf.WriteByte('"')
+			buf.WriteString(strings.Replace(*value, `"`, `""`, -1))
+			buf.WriteByte('"')
+		}
+	}
+	buf.WriteByte('\n')
+}
+
+// postgresError returns the error in the body of an ErrorResponse.
+func postgresError(body []byte) error {
+	fields := make(map[byte]string)
+	for len(body) > 1 {
+		end := bytes.IndexByte(body[1:], 0)
+		if end < 0 {
+			break
+		}
+		fields[body[0]] = string(body[1 : 1+end])
+		body = body[2+end:]
+	}
+	return fmt.Errorf("%s: %s (SQLSTATE %s)", fields['S'], fields['M'], fields['C'])
+}
+
+func cstring(s string) []byte {
+	return append([]byte(s), 0)
+}
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// scramClient authenticates with SCRAM-SHA-256 (RFC 7677), which is how
+// postgres checks passwords by default.
+type scramClient struct {
+	password        string
+	clientNonce     string
+	clientFirstBare string
+	serverSignature []byte
+}
+
+func newSCRAMClient(user string, password string) (*scramClient, error) {
+	nonce := make([]byte, 18)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return newSCRAMClientWithNonce(user, password, base64.StdEncoding.EncodeToString(nonce)), nil
+}
+
+func newSCRAMClientWithNonce(user string, password string, nonce string) *scramClient {
+	return &scramClient{
+		password:        password,
+		clientNonce:     nonce,
+		clientFirstBare: "n=" + user + ",r=" + nonce,
+	}
+}
+
+// clientFirst is the client's first message, without channel binding.
+func (s *scramClient) clientFirst() string {
+	return "n,," + s.clientFirstBare
+}
+
+// clientFinal returns the client's proof of the password, in response to the
+// server's first message.
+func (s *scramClient) clientFinal(serverFirst string) (string, error) {
+	attrs := scramAttributes(serverFirst)
+	nonce := attrs["r"]
+	if !strings.HasPrefix(nonce, s.clientNonce) || len(nonce) == len(s.clientNonce) {
+		return "", fmt.Errorf("invalid SCRAM nonce from server")
+	}
+	salt, err := base64.StdEncoding.DecodeString(attrs["s"])
+	if err != nil {
+		return "", fmt.Errorf("invalid SCRAM salt from server: %v", err)
+	}
+	iterations, err := strconv.Atoi(attrs["i"])
+	if err != nil || iterations < 1 {
+		return "", fmt.Errorf("invalid SCRAM iteration count %q from server", attrs["i"])
+	}
+	saltedPassword := pbkdf2.Key([]byte(s.password), salt, iterations, sha256.Size, sha256.New)
+	clientKey := hmacSHA256(saltedPassword, "Client Key")
+	storedKey := sha256.Sum256(clientKey)
+	clientFinalWithoutProof := "c=biws,r=" + nonce
+	authMessage := s.clientFirstBare + "," + serverFirst + "," + clientFinalWithoutProof
+	proof := hmacSHA256(storedKey[:], authMessage)
+	for i := range proof {
+		proof[i] ^= clientKey[i]
+	}
+	s.serverSignature = hmacSHA256(hmacSHA256(saltedPassword, "Server Key"), authMessage)
+	return clientFinalWithoutProof + ",p=" + base64.StdEncoding.EncodeToString(proof), nil
+}
+
+// verify checks the server's signature in its final message, which proves
+// that it knows the password too.
+func (s *scramClient) verify(serverFinal string) error {
+	attrs := scramAttributes(serverFinal)
+	if e, ok := attrs["e"]; ok {
+		return fmt.Errorf("SCRAM authentication failed: %s", e)
+	}
+	signature, err := base64.StdEncoding.DecodeString(attrs["v"])
+	if err != nil || !hmac.Equal(signature, s.serverSignature) {
+		return fmt.Errorf("invalid SCRAM server signature")
+	}
+	return nil
+}
+
+func scramAttributes(message string) map[string]string {
+	attrs := make(map[string]string)
+	for _, attr := range strings.Split(message, ",") {
+		if len(attr) >= 2 && attr[1] == '=' {
+			attrs[attr[:1]] = attr[2:]
+		}
+	}
+	return attrs
+}
+
+func hmacSHA256(key []byte, message string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(message))
+	return h.Sum(nil)
+}
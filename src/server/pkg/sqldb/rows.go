@@ -0,0 +1,226 @@
+package sqldb
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+// rowReader reads the rows of a file that's loaded into a table.
+type rowReader interface {
+	// columns are the names of the columns that the rows' values are in. An
+	// empty file has none.
+	columns() []string
+	// next returns the values of the next row, in the order of columns, where
+	// nil is NULL. It returns io.EOF after the last row.
+	next() ([]*string, error)
+}
+
+// formatOf returns the format of the file at p, "csv" or "json", or "" if
+// it's neither.
+func formatOf(p string) string {
+	switch strings.ToLower(path.Ext(p)) {
+	case ".csv":
+		return "csv"
+	case ".json", ".jsonl", ".ndjson":
+		return "json"
+	}
+	return ""
+}
+
+// newRowReader returns a rowReader for the file at p, whose content is r.
+func newRowReader(p string, r io.Reader) (rowReader, error) {
+	switch formatOf(p) {
+	case "csv":
+		return newCSVRows(r)
+	case "json":
+		return newJSONRows(r)
+	}
+	return nil, fmt.Errorf("%s is neither CSV nor JSON", p)
+}
+
+// csvRows reads a CSV file, whose first record names its columns. Empty
+// fields are NULL.
+type csvRows struct {
+	r      *csv.Reader
+	header []string
+}
+
+func newCSVRows(r io.Reader) (*csvRows, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err == io.EOF {
+		return &csvRows{r: cr}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	cr.FieldsPerRecord = len(header)
+	return &csvRows{r: cr, header: header}, nil
+}
+
+func (c *csvRows) columns() []string {
+	return c.header
+}
+
+func (c *csvRows) next() ([]*string, error) {
+	if c.header == nil {
+		return nil, io.EOF
+	}
+	record, err := c.r.Read()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]*string, len(record))
+	for i := range record {
+		if record[i] != "" {
+			values[i] = &record[i]
+		}
+	}
+	return values, nil
+}
+
+// jsonRows reads a JSON file holding a stream of objects (e.g. JSON lines),
+// or an array of them. The keys of the first object name the columns, which
+// the other objects may leave out (they're NULL), but not add to. Objects'
+// values that are objects or arrays are loaded as JSON.
+type jsonRows struct {
+	d *json.Decoder
+	// array is whether the objects are in an array
+	array bool
+	// first is the first object, which is read to find the columns
+	first map[string]interface{}
+	keys  []string
+	index map[string]int
+}
+
+func newJSONRows(r io.Reader) (*jsonRows, error) {
+	br := bufio.NewReader(r)
+	// peek past any whitespace, to see whether the objects are in an array
+	var array bool
+	for {
+		b, err := br.ReadByte()
+		if err == io.EOF {
+			return &jsonRows{}, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if strings.ContainsRune(" \t\r\n", rune(b)) {
+			continue
+		}
+		if err := br.UnreadByte(); err != nil {
+			return nil, err
+		}
+		array = b == '['
+		break
+	}
+	j := &jsonRows{d: json.NewDecoder(br), array: array}
+	j.d.UseNumber()
+	if array {
+		// consume the opening bracket
+		if _, err := j.d.Token(); err != nil {
+			return nil, err
+		}
+	}
+	first, err := j.read()
+	if err == io.EOF {
+		return &jsonRows{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	j.first = first
+	j.index = make(map[string]int)
+	for key := range first {
+		j.keys = append(j.keys, key)
+	}
+	sort.Strings(j.keys)
+	for i, key := range j.keys {
+		j.index[key] = i
+	}
+	return j, nil
+}
+
+// read reads the next object.
+func (j *jsonRows) read() (map[string]interface{}, error) {
+	if !j.d.More() {
+		if j.array {
+			// consume the closing bracket
+			if _, err := j.d.Token(); err != nil {
+				return nil, err
+			}
+			j.array = false
+			if j.d.More() {
+				return nil, fmt.Errorf("unexpected JSON after array")
+			}
+		}
+		return nil, io.EOF
+	}
+	var object map[string]interface{}
+	if err := j.d.Decode(&object); err != nil {
+		return nil, err
+	}
+	if object == nil {
+		return nil, fmt.Errorf("expected a JSON object, got null")
+	}
+	return object, nil
+}
+
+func (j *jsonRows) columns() []string {
+	return j.keys
+}
+
+func (j *jsonRows) next() ([]*string, error) {
+	if j.d == nil {
+		return nil, io.EOF
+	}
+	object := j.first
+	j.first = nil
+	if object == nil {
+		var err error
+		if object, err = j.read(); err != nil {
+			return nil, err
+		}
+	}
+	values := make([]*string, len(j.keys))
+	for key, value := range object {
+		i, ok := j.index[key]
+		if !ok {
+			return nil, fmt.Errorf("object has key %q, which the first object doesn't", key)
+		}
+		if values[i], ok = jsonValue(value); !ok {
+			return nil, fmt.Errorf("invalid value of %q: %v", key, value)
+		}
+	}
+	return values, nil
+}
+
+// jsonValue returns the text that a JSON value is loaded as, which is nil for
+// null.
+func jsonValue(value interface{}) (*string, bool) {
+	var s string
+	switch value := value.(type) {
+	case nil:
+		return nil, true
+	case string:
+		s = value
+	case json.Number:
+		s = value.String()
+	case bool:
+		if value {
+			s = "true"
+		} else {
+			s = "false"
+		}
+	default:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, false
+		}
+		s = string(data)
+	}
+	return &s, true
+}
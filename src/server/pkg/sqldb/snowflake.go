@@ -0,0 +1,395 @@
+package sqldb
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+const (
+	// snowflakeBatchSize is how many rows are inserted by each statement
+	snowflakeBatchSize = 10000
+	// snowflakePollInterval is how often a running statement's status is
+	// checked
+	snowflakePollInterval = time.Second
+	// snowflakeTokenLifetime is how long the JWTs that authenticate requests
+	// are valid for, Snowflake rejects ones that are valid for over an hour
+	snowflakeTokenLifetime = 59 * time.Minute
+)
+
+// snowflakeConfig is where a Snowflake database is, and how to connect to
+// it.
+type snowflakeConfig struct {
+	// account is the account identifier, e.g. "myorg-myaccount" or
+	// "xy12345.us-east-1"
+	account   string
+	user      string
+	database  string
+	schema    string
+	warehouse string
+	role      string
+}
+
+// parseSnowflakeURL parses a URL like
+// "snowflake://user@account/database/schema?warehouse=wh&role=role".
+func parseSnowflakeURL(u *url.URL) (*snowflakeConfig, error) {
+	config := &snowflakeConfig{
+		account:   u.Host,
+		warehouse: u.Query().Get("warehouse"),
+		role:      u.Query().Get("role"),
+	}
+	if config.account == "" {
+		return nil, fmt.Errorf("snowflake URL has no account")
+	}
+	if u.User != nil {
+		config.user = u.User.Username()
+		if _, ok := u.User.Password(); ok {
+			return nil, fmt.Errorf("snowflake URL mustn't include a password, it authenticates with the private key in the SQL egress' secret")
+		}
+	}
+	if config.user == "" {
+		return nil, fmt.Errorf("snowflake URL must include a user, e.g. snowflake://user@account/database/schema")
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("snowflake URL must include a database and a schema, e.g. snowflake://user@account/database/schema")
+	}
+	config.database, config.schema = parts[0], parts[1]
+	return config, nil
+}
+
+// snowflake is a connection to Snowflake, over its SQL API. Each statement
+// commits on its own, so a load that fails part way leaves the tables that
+// were already loaded loaded.
+type snowflake struct {
+	config     *snowflakeConfig
+	key        *rsa.PrivateKey
+	httpClient *http.Client
+	endpoint   string
+}
+
+func openSnowflake(ctx context.Context, u *url.URL, privateKey []byte) (database, error) {
+	config, err := parseSnowflakeURL(u)
+	if err != nil {
+		return nil, err
+	}
+	key, err := parseRSAPrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return &snowflake{
+		config:     config,
+		key:        key,
+		httpClient: http.DefaultClient,
+		endpoint:   fmt.Sprintf("https://%s.snowflakecomputing.com/api/v2/statements", strings.ToLower(config.account)),
+	}, nil
+}
+
+// parseRSAPrivateKey parses a PEM encoded RSA private key, in either PKCS #8
+// or PKCS #1 form.
+func parseRSAPrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("snowflake private key isn't PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snowflake private key: %v", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("snowflake private key must be an RSA key")
+	}
+	return rsaKey, nil
+}
+
+// token returns a JWT that authenticates requests as the user, signed with
+// their private key, see
+// https://docs.snowflake.com/en/developer-guide/sql-api/authenticating.
+func (s *snowflake) token() (string, error) {
+	publicKey, err := x509.MarshalPKIXPublicKey(&s.key.PublicKey)
+	if err != nil {
+		return "", err
+	}
+	fingerprint := sha256.Sum256(publicKey)
+	// the account in the token mustn't include its region
+	account := strings.ToUpper(strings.SplitN(s.config.account, ".", 2)[0])
+	subject := account + "." + strings.ToUpper(s.config.user)
+	now := time.Now()
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"iss": subject + ".SHA256:" + base64.StdEncoding.EncodeToString(fingerprint[:]),
+		"sub": subject,
+		"iat": now.Unix(),
+		"exp": now.Add(snowflakeTokenLifetime).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// snowflakeBinding is the value of a statement's bind variable, which is an
+// array of values when a statement inserts many rows.
+type snowflakeBinding struct {
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// snowflakeResponse is the part of the SQL API's responses that's read.
+type snowflakeResponse struct {
+	Code            string `json:"code"`
+	Message         string `json:"message"`
+	SQLState        string `json:"sqlState"`
+	StatementHandle string `json:"statementHandle"`
+	Stats           struct {
+		NumRowsInserted int64 `json:"numRowsInserted"`
+	} `json:"stats"`
+	ResultSetMetaData struct {
+		RowType []struct {
+			Name      string `json:"name"`
+			Type      string `json:"type"`
+			Precision int    `json:"precision"`
+			Scale     int    `json:"scale"`
+		} `json:"rowType"`
+		PartitionInfo []struct{} `json:"partitionInfo"`
+	} `json:"resultSetMetaData"`
+	// Data are the rows of a query's results, in one of its partitions
+	Data [][]*string `json:"data"`
+}
+
+// exec runs statement, and waits until it's finished.
+func (s *snowflake) exec(ctx context.Context, statement string, bindings map[string]snowflakeBinding) (*snowflakeResponse, error) {
+	request := map[string]interface{}{
+		"statement": statement,
+		"database":  s.config.database,
+		"schema":    s.config.schema,
+	}
+	if s.config.warehouse != "" {
+		request["warehouse"] = s.config.warehouse
+	}
+	if s.config.role != "" {
+		request["role"] = s.config.role
+	}
+	if bindings != nil {
+		request["bindings"] = bindings
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	resp, status, err := s.do(ctx, "POST", s.endpoint, body)
+	for err == nil && status == http.StatusAccepted {
+		// the statement is still running
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(snowflakePollInterval):
+		}
+		resp, status, err = s.do(ctx, "GET", s.endpoint+"/"+url.PathEscape(resp.StatementHandle), nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("snowflake error %s: %s (SQLSTATE %s)", resp.Code, resp.Message, resp.SQLState)
+	}
+	return resp, nil
+}
+
+func (s *snowflake) do(ctx context.Context, method string, endpoint string, body []byte) (*snowflakeResponse, int, error) {
+	token, err := s.token()
+	if err != nil {
+		return nil, 0, err
+	}
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, endpoint, bodyReader)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("X-Snowflake-Authorization-Token-Type", "KEYPAIR_JWT")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "pachyderm")
+	resp, err := s.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	result := &snowflakeResponse{}
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, 0, fmt.Errorf("invalid response from snowflake (%s): %s", resp.Status, data)
+	}
+	return result, resp.StatusCode, nil
+}
+
+func (s *snowflake) truncate(ctx context.Context, table string) error {
+	_, err := s.exec(ctx, "TRUNCATE TABLE IF EXISTS "+quoteSnowflakeTable(table), nil)
+	return err
+}
+
+func (s *snowflake) load(ctx context.Context, table string, rows rowReader) (int64, error) {
+	columns := rows.columns()
+	if len(columns) == 0 {
+		return 0, nil
+	}
+	quoted := make([]string, len(columns))
+	placeholders := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = quoteName(strings.ToUpper(column))
+		placeholders[i] = "?"
+	}
+	statement := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", quoteSnowflakeTable(table), strings.Join(quoted, ", "), strings.Join(placeholders, ", "))
+	var loaded int64
+	for done := false; !done; {
+		batch := make([][]*string, len(columns))
+		var n int
+		for n < snowflakeBatchSize {
+			values, err := rows.next()
+			if err == io.EOF {
+				done = true
+				break
+			} else if err != nil {
+				return loaded, err
+			}
+			for i := range columns {
+				batch[i] = append(batch[i], values[i])
+			}
+			n++
+		}
+		if n == 0 {
+			break
+		}
+		bindings := make(map[string]snowflakeBinding)
+		for i := range columns {
+			bindings[strconv.Itoa(i+1)] = snowflakeBinding{Type: "TEXT", Value: batch[i]}
+		}
+		resp, err := s.exec(ctx, statement, bindings)
+		if err != nil {
+			return loaded, err
+		}
+		loaded += resp.Stats.NumRowsInserted
+	}
+	return loaded, nil
+}
+
+func (s *snowflake) query(ctx context.Context, query string) (resultReader, error) {
+	resp, err := s.exec(ctx, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	var columns []column
+	for _, rowType := range resp.ResultSetMetaData.RowType {
+		c := column{name: rowType.Name}
+		switch {
+		case rowType.Type == "fixed" && rowType.Scale == 0 && rowType.Precision <= 18:
+			c.typ = intColumn
+		case rowType.Type == "real":
+			c.typ = floatColumn
+		case rowType.Type == "boolean":
+			c.typ = boolColumn
+		}
+		columns = append(columns, c)
+	}
+	return &snowflakeResults{
+		ctx:        ctx,
+		s:          s,
+		handle:     resp.StatementHandle,
+		cols:       columns,
+		data:       resp.Data,
+		partitions: len(resp.ResultSetMetaData.PartitionInfo),
+	}, nil
+}
+
+// snowflakeResults reads the rows of a query's results. Large results are
+// split into partitions, which are fetched as they're read.
+type snowflakeResults struct {
+	ctx    context.Context
+	s      *snowflake
+	handle string
+	cols   []column
+	// data are the rows of the partition that's being read
+	data       [][]*string
+	partition  int
+	partitions int
+}
+
+func (r *snowflakeResults) columns() []column {
+	return r.cols
+}
+
+func (r *snowflakeResults) next() ([]*string, error) {
+	for len(r.data) == 0 {
+		if r.partition+1 >= r.partitions {
+			return nil, io.EOF
+		}
+		r.partition++
+		resp, status, err := r.s.do(r.ctx, "GET", fmt.Sprintf("%s/%s?partition=%d", r.s.endpoint, url.PathEscape(r.handle), r.partition), nil)
+		if err != nil {
+			return nil, err
+		}
+		if status != http.StatusOK {
+			return nil, fmt.Errorf("snowflake error %s: %s (SQLSTATE %s)", resp.Code, resp.Message, resp.SQLState)
+		}
+		r.data = resp.Data
+	}
+	row := r.data[0]
+	r.data = r.data[1:]
+	if len(row) != len(r.cols) {
+		return nil, fmt.Errorf("snowflake returned a row with %d values, rather than %d", len(row), len(r.cols))
+	}
+	return row, nil
+}
+
+// commit is a no-op, each of Snowflake's statements commits on its own.
+func (s *snowflake) commit(ctx context.Context) error {
+	return nil
+}
+
+func (s *snowflake) close() error {
+	return nil
+}
+
+// quoteSnowflakeTable quotes each part of a table's name. Snowflake
+// upper-cases unquoted names, so the parts are upper-cased before they're
+// quoted, which makes e.g. "users" load into a table created as users or
+// USERS, but not into one whose quoted name is lower-case.
+func quoteSnowflakeTable(table string) string {
+	return quoteTable(strings.ToUpper(table))
+}
@@ -0,0 +1,117 @@
+// Package sqldb moves data between pachyderm and SQL databases. It loads the
+// CSV and JSON files of jobs' output commits into the tables of a database
+// (see pps.SQLEgress), and snapshots the results of queries for SQL inputs
+// (see pps.SQLInput). It talks to databases over their own protocols
+// (postgres' wire protocol and Snowflake's SQL API), as no database/sql
+// drivers are vendored.
+package sqldb
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// database is a connection to a database. The tables that are loaded only
+// change once commit is called, if the database supports transactions.
+type database interface {
+	// truncate deletes every row of table.
+	truncate(ctx context.Context, table string) error
+	// load appends rows to table, and returns how many it appended.
+	load(ctx context.Context, table string, rows rowReader) (int64, error)
+	// query runs query, whose results must be read until the resultReader
+	// returns io.EOF (or an error) before the database is used again.
+	query(ctx context.Context, query string) (resultReader, error)
+	commit(ctx context.Context) error
+	close() error
+}
+
+// column is a column of a query's results.
+type column struct {
+	name string
+	typ  columnType
+}
+
+// columnType is the type of a column's values. Values are always read as
+// text, the type is what they're written as in parquet snapshots.
+type columnType int
+
+const (
+	textColumn columnType = iota
+	intColumn
+	floatColumn
+	boolColumn
+)
+
+// resultReader reads the results of a query.
+type resultReader interface {
+	columns() []column
+	// next returns the values of the next row, in the order of columns, where
+	// nil is NULL. It returns io.EOF after the last row.
+	next() ([]*string, error)
+}
+
+// open connects to the database at rawURL, with the credentials in secret,
+// which are the items of a kubernetes secret.
+func open(ctx context.Context, rawURL string, secret map[string][]byte) (database, error) {
+	u, err := parseURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "postgres", "postgresql":
+		return openPostgres(ctx, u, string(secret["password"]))
+	case "snowflake":
+		return openSnowflake(ctx, u, secret["private_key"])
+	}
+	return nil, unsupportedError(u)
+}
+
+// validateURL returns an error if rawURL isn't the URL of a database that
+// open can connect to.
+func validateURL(rawURL string) error {
+	u, err := parseURL(rawURL)
+	if err != nil {
+		return err
+	}
+	switch u.Scheme {
+	case "postgres", "postgresql":
+		_, err = parsePostgresURL(u)
+	case "snowflake":
+		_, err = parseSnowflakeURL(u)
+	default:
+		err = unsupportedError(u)
+	}
+	return err
+}
+
+// parseURL parses a database's URL, which may also be a JDBC URL, e.g.
+// "jdbc:postgresql://host/db".
+func parseURL(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimPrefix(rawURL, "jdbc:"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid database URL: %v", err)
+	}
+	return u, nil
+}
+
+func unsupportedError(u *url.URL) error {
+	return fmt.Errorf("unsupported database %q, only postgres and snowflake are supported", u.Scheme)
+}
+
+// quoteName quotes a table's or column's name.
+func quoteName(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
+
+// quoteTable quotes each part of a table's name, which may include its
+// schema, e.g. "analytics.users".
+func quoteTable(table string) string {
+	parts := strings.Split(table, ".")
+	for i, part := range parts {
+		parts[i] = quoteName(part)
+	}
+	return strings.Join(parts, ".")
+}
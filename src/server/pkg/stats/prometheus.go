@@ -0,0 +1,108 @@
+package stats
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// prometheusExporter keeps the metrics in Prometheus's default registry,
+// which it serves, e.g. on pachd's /metrics, for Prometheus to scrape.
+// Counters are named "pachyderm_<name>_total", and timings are histograms
+// named "pachyderm_<name>_seconds".
+type prometheusExporter struct {
+	counters   map[*Metric]*prometheus.CounterVec
+	gauges     map[*Metric]*prometheus.GaugeVec
+	histograms map[*Metric]*prometheus.HistogramVec
+
+	mu sync.Mutex
+	// snapshots are the tags of each gauge's last snapshot, so that the
+	// ones that aren't in the next snapshot can be deleted
+	snapshots map[*Metric]map[string][]string
+}
+
+// durationBuckets are timings' histograms' buckets, from a second to a few
+// days, since jobs can take anything in between.
+var durationBuckets = prometheus.ExponentialBuckets(1, 4, 10)
+
+func newPrometheusExporter() (*prometheusExporter, error) {
+	p := &prometheusExporter{
+		counters:   make(map[*Metric]*prometheus.CounterVec),
+		gauges:     make(map[*Metric]*prometheus.GaugeVec),
+		histograms: make(map[*Metric]*prometheus.HistogramVec),
+		snapshots:  make(map[*Metric]map[string][]string),
+	}
+	for _, metric := range Metrics {
+		// the registry is global, so RegisterOrGet returns the vectors of
+		// an exporter that was created before, rather than an error
+		switch metric.Type {
+		case Counter:
+			c, err := prometheus.RegisterOrGet(prometheus.NewCounterVec(prometheus.CounterOpts{
+				Namespace: "pachyderm",
+				Name:      metric.Name + "_total",
+				Help:      metric.Help,
+			}, metric.Tags))
+			if err != nil {
+				return nil, err
+			}
+			p.counters[metric] = c.(*prometheus.CounterVec)
+		case Gauge:
+			c, err := prometheus.RegisterOrGet(prometheus.NewGaugeVec(prometheus.GaugeOpts{
+				Namespace: "pachyderm",
+				Name:      metric.Name,
+				Help:      metric.Help,
+			}, metric.Tags))
+			if err != nil {
+				return nil, err
+			}
+			p.gauges[metric] = c.(*prometheus.GaugeVec)
+		case Timing:
+			c, err := prometheus.RegisterOrGet(prometheus.NewHistogramVec(prometheus.HistogramOpts{
+				Namespace: "pachyderm",
+				Name:      metric.Name + "_seconds",
+				Help:      metric.Help,
+				Buckets:   durationBuckets,
+			}, metric.Tags))
+			if err != nil {
+				return nil, err
+			}
+			p.histograms[metric] = c.(*prometheus.HistogramVec)
+		}
+	}
+	return p, nil
+}
+
+func (p *prometheusExporter) Record(metric *Metric, value float64, tags ...string) {
+	switch metric.Type {
+	case Counter:
+		p.counters[metric].WithLabelValues(tags...).Add(value)
+	case Gauge:
+		p.gauges[metric].WithLabelValues(tags...).Set(value)
+	case Timing:
+		p.histograms[metric].WithLabelValues(tags...).Observe(value)
+	}
+}
+
+func (p *prometheusExporter) Snapshot(metric *Metric, samples []Sample) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	gauge := p.gauges[metric]
+	snapshot := make(map[string][]string)
+	for _, sample := range samples {
+		gauge.WithLabelValues(sample.Tags...).Set(sample.Value)
+		snapshot[strings.Join(sample.Tags, "\x00")] = sample.Tags
+	}
+	for key, tags := range p.snapshots[metric] {
+		if _, ok := snapshot[key]; !ok {
+			gauge.DeleteLabelValues(tags...)
+		}
+	}
+	p.snapshots[metric] = snapshot
+}
+
+// ServeHTTP serves the metrics in Prometheus's format.
+func (p *prometheusExporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	prometheus.UninstrumentedHandler().ServeHTTP(w, r)
+}
@@ -0,0 +1,163 @@
+// Package stats exports pachd's metrics about pipelines, jobs and repos to
+// monitoring systems: Prometheus, which scrapes them from pachd, or statsd
+// and Datadog's agent, which pachd sends them to. Each metric is tagged with
+// the pipeline or repo that it's about.
+package stats
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+)
+
+// Type is the type of a metric.
+type Type int
+
+const (
+	// Counter is a metric whose recorded values are added up.
+	Counter Type = iota
+	// Gauge is a metric whose value is the last one recorded.
+	Gauge
+	// Timing is a metric whose recorded values are durations, in seconds,
+	// which are aggregated into a distribution.
+	Timing
+)
+
+// Metric is one of the metrics that pachd exports.
+type Metric struct {
+	// Name is the metric's name, without the "pachyderm" namespace that
+	// exporters prefix it with.
+	Name string
+	Help string
+	Type Type
+	// Tags are the names of the metric's tags, whose values are given, in
+	// the same order, when it's recorded.
+	Tags []string
+}
+
+// The metrics that pachd exports.
+var (
+	// JobsFinished counts the jobs that succeed, fail or are stopped.
+	JobsFinished = &Metric{
+		Name: "jobs_finished",
+		Help: "The number of jobs that have finished, by pipeline and state.",
+		Type: Counter,
+		Tags: []string{"pipeline", "state"},
+	}
+	// JobDuration is how long finished jobs took.
+	JobDuration = &Metric{
+		Name: "job_duration",
+		Help: "How long finished jobs took, by pipeline and state.",
+		Type: Timing,
+		Tags: []string{"pipeline", "state"},
+	}
+	// DatumsProcessed counts the datums that finished jobs processed.
+	DatumsProcessed = &Metric{
+		Name: "datums_processed",
+		Help: "The number of datums that finished jobs have processed, by pipeline.",
+		Type: Counter,
+		Tags: []string{"pipeline"},
+	}
+	// Jobs is the number of each pipeline's jobs that are in each state.
+	Jobs = &Metric{
+		Name: "jobs",
+		Help: "The number of jobs, by pipeline and state.",
+		Type: Gauge,
+		Tags: []string{"pipeline", "state"},
+	}
+	// RepoSize is the size of each repo.
+	RepoSize = &Metric{
+		Name: "repo_size_bytes",
+		Help: "The size of repos, by repo.",
+		Type: Gauge,
+		Tags: []string{"repo"},
+	}
+
+	// Metrics are all of the metrics that pachd exports.
+	Metrics = []*Metric{JobsFinished, JobDuration, DatumsProcessed, Jobs, RepoSize}
+)
+
+// Sample is a gauge's value for one set of tags.
+type Sample struct {
+	Tags  []string
+	Value float64
+}
+
+// Exporter is somewhere that metrics are exported to.
+type Exporter interface {
+	// Record records value for metric, with the given values of its tags.
+	Record(metric *Metric, value float64, tags ...string)
+	// Snapshot sets the gauge metric to samples, forgetting its values for
+	// tags that aren't in samples, e.g. those of repos that were deleted.
+	Snapshot(metric *Metric, samples []Sample)
+}
+
+const (
+	// Prometheus is the target of the exporter that serves metrics for
+	// Prometheus to scrape.
+	Prometheus = "prometheus"
+	// Datadog is the target of the exporter that sends metrics to the
+	// Datadog agent on pachd's node, at $DD_AGENT_HOST.
+	Datadog = "datadog"
+
+	// defaultStatsdPort is the port that statsd and Datadog's agent listen
+	// on by default.
+	defaultStatsdPort = "8125"
+)
+
+type target struct {
+	scheme string
+	// address is the "<host>:<port>" that metrics are sent to, or empty
+	// for Prometheus and the Datadog agent on pachd's node.
+	address string
+}
+
+// Validate returns an error if target isn't an exporter's target, see
+// NewExporter.
+func Validate(target string) error {
+	_, err := parseTarget(target)
+	return err
+}
+
+func parseTarget(t string) (*target, error) {
+	if t == Prometheus || t == Datadog {
+		return &target{scheme: t}, nil
+	}
+	u, err := url.Parse(t)
+	if err == nil && (u.Scheme == "statsd" || u.Scheme == Datadog) && u.Host != "" && (u.Path == "" || u.Path == "/") {
+		address := u.Host
+		if u.Port() == "" {
+			address = net.JoinHostPort(u.Hostname(), defaultStatsdPort)
+		}
+		return &target{scheme: u.Scheme, address: address}, nil
+	}
+	return nil, fmt.Errorf("invalid metrics exporter %q, it must be %q, \"statsd://<host>[:<port>]\", %q (the agent on pachd's node) or \"datadog://<host>[:<port>]\"", t, Prometheus, Datadog)
+}
+
+// NewExporter returns the exporter that target names, which is either
+// "prometheus", "statsd://<host>[:<port>]", "datadog://<host>[:<port>]", or
+// "datadog", for the Datadog agent at $DD_AGENT_HOST. statsd's and Datadog's
+// port defaults to 8125.
+func NewExporter(t string) (Exporter, error) {
+	target, err := parseTarget(t)
+	if err != nil {
+		return nil, err
+	}
+	switch target.scheme {
+	case Prometheus:
+		return newPrometheusExporter()
+	case Datadog:
+		address := target.address
+		if address == "" {
+			host := os.Getenv("DD_AGENT_HOST")
+			if host == "" {
+				return nil, fmt.Errorf("DD_AGENT_HOST isn't set, so the Datadog agent's address isn't known")
+			}
+			address = net.JoinHostPort(host, defaultStatsdPort)
+		}
+		return newStatsdExporter(address, true)
+	default:
+		return newStatsdExporter(target.address, false)
+	}
+}
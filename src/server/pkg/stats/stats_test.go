@@ -0,0 +1,144 @@
+package stats
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+)
+
+func TestValidate(t *testing.T) {
+	for _, target := range []string{
+		"prometheus",
+		"datadog",
+		"statsd://statsd.monitoring:8125",
+		"statsd://10.0.0.1",
+		"datadog://dd-agent.monitoring:8125",
+	} {
+		require.NoError(t, Validate(target), target)
+	}
+	for _, target := range []string{
+		"",
+		"statsd",
+		"statsd://",
+		"graphite://graphite:2003",
+		"statsd://statsd:8125/metrics",
+	} {
+		require.YesError(t, Validate(target), target)
+	}
+	target, err := parseTarget("datadog://dd-agent")
+	require.NoError(t, err)
+	require.Equal(t, "dd-agent:8125", target.address)
+}
+
+// listen returns a UDP connection for an exporter to send metrics to, and a
+// function that returns the next packet it receives.
+func listen(t *testing.T) (*net.UDPConn, func() string) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	buf := make([]byte, 65535)
+	return conn, func() string {
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(10*time.Second)))
+		n, _, err := conn.ReadFromUDP(buf)
+		require.NoError(t, err)
+		return string(buf[:n])
+	}
+}
+
+func TestStatsd(t *testing.T) {
+	conn, receive := listen(t)
+	defer conn.Close()
+	exporter, err := NewExporter("statsd://" + conn.LocalAddr().String())
+	require.NoError(t, err)
+
+	exporter.Record(JobsFinished, 1, "edges", "success")
+	require.Equal(t, "pachyderm.jobs_finished.edges.success:1|c", receive())
+	exporter.Record(JobDuration, 1.5, "edges", "success")
+	require.Equal(t, "pachyderm.job_duration.edges.success:1500|ms", receive())
+	exporter.Snapshot(RepoSize, []Sample{
+		{Tags: []string{"images"}, Value: 1024},
+		{Tags: []string{"my.repo"}, Value: 0},
+	})
+	require.Equal(t, "pachyderm.repo_size_bytes.images:1024|g\npachyderm.repo_size_bytes.my_repo:0|g", receive())
+}
+
+func TestDatadog(t *testing.T) {
+	conn, receive := listen(t)
+	defer conn.Close()
+	host, port, err := net.SplitHostPort(conn.LocalAddr().String())
+	require.NoError(t, err)
+	exporter, err := NewExporter("datadog://" + net.JoinHostPort(host, port))
+	require.NoError(t, err)
+	exporter.Record(JobsFinished, 1, "edges", "failure")
+	require.Equal(t, "pachyderm.jobs_finished:1|c|#pipeline:edges,state:failure", receive())
+
+	// the agent on pachd's node is found through DD_AGENT_HOST, on the
+	// default port
+	defer os.Unsetenv("DD_AGENT_HOST")
+	require.NoError(t, os.Unsetenv("DD_AGENT_HOST"))
+	_, err = NewExporter("datadog")
+	require.YesError(t, err)
+	require.NoError(t, os.Setenv("DD_AGENT_HOST", host))
+	exporter, err = NewExporter("datadog")
+	require.NoError(t, err)
+	require.Equal(t, net.JoinHostPort(host, defaultStatsdPort), exporter.(*statsdExporter).conn.RemoteAddr().String())
+}
+
+func TestStatsdPackets(t *testing.T) {
+	conn, receive := listen(t)
+	defer conn.Close()
+	exporter, err := NewExporter("statsd://" + conn.LocalAddr().String())
+	require.NoError(t, err)
+	var samples []Sample
+	for i := 0; i < 100; i++ {
+		samples = append(samples, Sample{Tags: []string{strings.Repeat("r", 50)}, Value: 1})
+	}
+	exporter.Snapshot(RepoSize, samples)
+	lines := 0
+	for lines < len(samples) {
+		packet := receive()
+		require.True(t, len(packet) <= maxPacketSize)
+		lines += len(strings.Split(packet, "\n"))
+	}
+	require.Equal(t, len(samples), lines)
+}
+
+func TestPrometheus(t *testing.T) {
+	exporter, err := NewExporter("prometheus")
+	require.NoError(t, err)
+	exporter.Record(JobsFinished, 1, "edges", "success")
+	exporter.Record(JobsFinished, 1, "edges", "success")
+	exporter.Record(JobDuration, 90, "edges", "success")
+	exporter.Snapshot(RepoSize, []Sample{
+		{Tags: []string{"images"}, Value: 1024},
+		{Tags: []string{"scratch"}, Value: 10},
+	})
+	// scratch was deleted
+	exporter.Snapshot(RepoSize, []Sample{
+		{Tags: []string{"images"}, Value: 2048},
+	})
+
+	server := httptest.NewServer(exporter.(*prometheusExporter))
+	defer server.Close()
+	resp, err := http.Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	body := string(data)
+	for _, line := range []string{
+		`pachyderm_jobs_finished_total{pipeline="edges",state="success"} 2`,
+		`pachyderm_job_duration_seconds_count{pipeline="edges",state="success"} 1`,
+		`pachyderm_job_duration_seconds_sum{pipeline="edges",state="success"} 90`,
+		`pachyderm_repo_size_bytes{repo="images"} 2048`,
+	} {
+		require.True(t, strings.Contains(body, line+"\n"), "%q isn't in:\n%s", line, body)
+	}
+	require.False(t, strings.Contains(body, `repo="scratch"`), body)
+}
@@ -0,0 +1,107 @@
+package stats
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.pedge.io/lion/proto"
+)
+
+// maxPacketSize is the most that statsdExporter puts in a packet, which is
+// what fits in an Ethernet frame, so that packets aren't fragmented.
+const maxPacketSize = 1432
+
+// unsafeChars are the characters that are replaced in tags' values, which
+// have meanings in the statsd protocol, or in statsd's names.
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// statsdExporter sends metrics over UDP, in statsd's protocol, as
+// "pachyderm.<name>". Plain statsd doesn't have tags, so their values are
+// appended to the name, e.g. "pachyderm.jobs_finished.edges.success",
+// whereas Datadog's dialect of the protocol tags metrics, e.g.
+// "pachyderm.jobs_finished:1|c|#pipeline:edges,state:success". Timings are
+// sent in milliseconds.
+type statsdExporter struct {
+	conn    net.Conn
+	datadog bool
+}
+
+func newStatsdExporter(address string, datadog bool) (*statsdExporter, error) {
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, err
+	}
+	return &statsdExporter{conn: conn, datadog: datadog}, nil
+}
+
+func (s *statsdExporter) Record(metric *Metric, value float64, tags ...string) {
+	s.send([]string{s.line(metric, value, tags)})
+}
+
+func (s *statsdExporter) Snapshot(metric *Metric, samples []Sample) {
+	var lines []string
+	for _, sample := range samples {
+		lines = append(lines, s.line(metric, sample.Value, sample.Tags))
+	}
+	s.send(lines)
+}
+
+// line formats the statsd line that records value for metric.
+func (s *statsdExporter) line(metric *Metric, value float64, tags []string) string {
+	var typ string
+	switch metric.Type {
+	case Counter:
+		typ = "c"
+	case Gauge:
+		typ = "g"
+	case Timing:
+		typ = "ms"
+		value *= 1000
+	}
+	name := "pachyderm." + metric.Name
+	formatted := strconv.FormatFloat(value, 'f', -1, 64)
+	if !s.datadog {
+		for _, tag := range tags {
+			name += "." + unsafeChars.ReplaceAllString(tag, "_")
+		}
+		return fmt.Sprintf("%s:%s|%s", name, formatted, typ)
+	}
+	var ddTags []string
+	for i, tag := range tags {
+		if i < len(metric.Tags) {
+			ddTags = append(ddTags, metric.Tags[i]+":"+unsafeChars.ReplaceAllString(tag, "_"))
+		}
+	}
+	if len(ddTags) == 0 {
+		return fmt.Sprintf("%s:%s|%s", name, formatted, typ)
+	}
+	return fmt.Sprintf("%s:%s|%s|#%s", name, formatted, typ, strings.Join(ddTags, ","))
+}
+
+// send sends lines, as few packets as they fit in. Metrics are sent on a
+// best-effort basis, so errors are only logged.
+func (s *statsdExporter) send(lines []string) {
+	var packet string
+	flush := func() {
+		if packet == "" {
+			return
+		}
+		if _, err := s.conn.Write([]byte(packet)); err != nil {
+			protolion.Errorf("error sending metrics to %s: %v", s.conn.RemoteAddr(), err)
+		}
+		packet = ""
+	}
+	for _, line := range lines {
+		if packet != "" && len(packet)+1+len(line) > maxPacketSize {
+			flush()
+		}
+		if packet != "" {
+			packet += "\n"
+		}
+		packet += line
+	}
+	flush()
+}
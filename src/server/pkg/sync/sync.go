@@ -2,9 +2,11 @@
 package sync
 
 import (
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"syscall"
 
@@ -40,21 +42,28 @@ func NewPuller() *Puller {
 
 // Pull clones an entire repo at a certain commit.
 // root is the local path you want to clone to.
-// fileInfo is the file/dir we are puuling.
+// file is the file/dir we are pulling, if it's a directory the structure
+// beneath it is preserved under root, if it's a file it's written to root.
 // pipes causes the function to create named pipes in place of files, thus
 // lazily downloading the data as it's needed.
+// concurrency is the maximum number of files downloaded at once.
 func (p *Puller) Pull(client *pachclient.APIClient, root string, repo, commit, file string, pipes bool, concurrency int) error {
 	limiter := limit.New(concurrency)
 	var eg errgroup.Group
+	// paths in FileInfos are absolute, file may not be
+	file = filepath.Join("/", file)
 	if err := client.Walk(repo, commit, file, func(fileInfo *pfs.FileInfo) error {
-		if fileInfo.FileType != pfs.FileType_FILE {
-			return nil
-		}
-		basepath, err := filepath.Rel(file, fileInfo.File.Path)
+		basepath, err := filepath.Rel(file, filepath.Join("/", fileInfo.File.Path))
 		if err != nil {
 			return err
 		}
 		path := filepath.Join(root, basepath)
+		if fileInfo.FileType == pfs.FileType_DIR {
+			return os.MkdirAll(path, 0700)
+		}
+		if fileInfo.FileType != pfs.FileType_FILE {
+			return nil
+		}
 		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
 			return err
 		}
@@ -129,6 +138,53 @@ func (p *Puller) Pull(client *pachclient.APIClient, root string, repo, commit, f
 	return eg.Wait()
 }
 
+// RestoreMetadata restores the metadata that was recorded when the files
+// under file were uploaded with PutFilesOptions.PreserveMetadata, to the
+// copies of them under root that Pull wrote: it sets their modes and
+// modification times and creates the recorded symlinks. The local copy of
+// the MetadataFile is removed. It does nothing if file has no MetadataFile.
+func RestoreMetadata(client *pachclient.APIClient, root string, repo, commit, file string) error {
+	metadata, err := client.GetFileMetadata(repo, commit, file)
+	if err != nil {
+		return err
+	}
+	if len(metadata) == 0 {
+		return nil
+	}
+	if err := os.Remove(filepath.Join(root, pachclient.MetadataFile)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, m := range metadata {
+		path := filepath.Join(root, filepath.FromSlash(m.Path))
+		if rel, err := filepath.Rel(root, path); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("metadata for %s is outside of %s", m.Path, root)
+		}
+		if m.Symlink != "" {
+			if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+				return err
+			}
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			if err := os.Symlink(m.Symlink, path); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.Chmod(path, m.Mode.Perm()); err != nil {
+			if os.IsNotExist(err) {
+				// the file was deleted after it was uploaded
+				continue
+			}
+			return err
+		}
+		if err := os.Chtimes(path, m.ModTime, m.ModTime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // CleanUp cleans up blocked syscalls for pipes that were never opened. It also
 // returns any errors that might have been encountered while trying to read
 // data for the pipes. CleanUp should be called after all code that might
@@ -170,43 +226,9 @@ func (p *Puller) CleanUp() error {
 
 // Push puts files under root into an open commit.
 func Push(client *pachclient.APIClient, root string, commit *pfs.Commit, overwrite bool) error {
-	var g errgroup.Group
-	if err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
-		g.Go(func() (retErr error) {
-			if path == root || info.IsDir() {
-				return nil
-			}
-
-			f, err := os.Open(path)
-			if err != nil {
-				return err
-			}
-			defer func() {
-				if err := f.Close(); err != nil && retErr == nil {
-					retErr = err
-				}
-			}()
-
-			relPath, err := filepath.Rel(root, path)
-			if err != nil {
-				return err
-			}
-
-			if overwrite {
-				if err := client.DeleteFile(commit.Repo.Name, commit.ID, relPath); err != nil {
-					return err
-				}
-			}
-
-			_, err = client.PutFile(commit.Repo.Name, commit.ID, relPath, f)
-			return err
-		})
-		return nil
-	}); err != nil {
-		return err
-	}
-
-	return g.Wait()
+	return client.PutFiles(commit.Repo.Name, commit.ID, root, &pachclient.PutFilesOptions{
+		Overwrite: overwrite,
+	})
 }
 
 // PushObj pushes data from commit to an object store.
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"crypto/sha256"
+	"encoding/binary"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -37,6 +38,9 @@ const (
 	// The maximum number of concurrent download/upload operations
 	concurrency = 10
 	maxLogItems = 10
+	// userCodeGracePeriod is how long user code has to exit once it's sent
+	// SIGTERM, when its datum is cancelled, before it's sent SIGKILL
+	userCodeGracePeriod = 30 * time.Second
 )
 
 var (
@@ -63,8 +67,18 @@ type APIServer struct {
 	started time.Time
 	// Func to cancel the currently running datum
 	cancel func()
+	// The currently running datum, which Process waits for
+	run *datumRun
 	// The k8s pod name of this worker
 	workerName string
+
+	// statsd collects the metrics that the user code reports, it's nil if
+	// it couldn't be started
+	statsd *statsdServer
+
+	// rendezvous is this worker's rank among its peers, it's nil unless the
+	// pipeline has a rendezvous, see JoinRendezvous
+	rendezvous *rendezvous
 }
 
 type taggedLogger struct {
@@ -93,6 +107,7 @@ func (a *APIServer) getTaggedLogger(req *ProcessRequest) *taggedLogger {
 			Hash: d.FileInfo.Hash,
 		})
 	}
+	result.template.DatumID = DatumID(req.Data)
 	return result
 }
 
@@ -156,6 +171,7 @@ func NewPipelineAPIServer(pachClient *client.APIClient, pipelineInfo *pps.Pipeli
 			WorkerID:     os.Getenv(client.PPSPodNameEnv),
 		},
 		workerName: workerName,
+		statsd:     startStatsdServer(),
 	}
 	return server
 }
@@ -167,10 +183,23 @@ func NewJobAPIServer(pachClient *client.APIClient, jobInfo *pps.JobInfo, workerN
 		jobInfo:        jobInfo,
 		logMsgTemplate: pps.LogMessage{},
 		workerName:     workerName,
+		statsd:         startStatsdServer(),
 	}
 	return server
 }
 
+// startStatsdServer starts the server that user code reports metrics to.
+// Metrics are optional, so the worker runs without them if it can't be
+// started.
+func startStatsdServer() *statsdServer {
+	s, err := newStatsdServer()
+	if err != nil {
+		log.Printf("error starting statsd server, user code metrics are disabled: %v", err)
+		return nil
+	}
+	return s
+}
+
 func (a *APIServer) downloadData(inputs []*Input, puller *filesync.Puller) error {
 	for _, input := range inputs {
 		file := input.FileInfo.File
@@ -192,13 +221,27 @@ func (a *APIServer) runUserCode(ctx context.Context, logger *taggedLogger, envir
 	} else {
 		return fmt.Errorf("malformed APIServer: has neither pipelineInfo or jobInfo; this is likely a bug")
 	}
-	cmd := exec.CommandContext(ctx, transform.Cmd[0], transform.Cmd[1:]...)
+	cmd := exec.Command(transform.Cmd[0], transform.Cmd[1:]...)
 	cmd.Stdin = strings.NewReader(strings.Join(transform.Stdin, "\n") + "\n")
 	cmd.Stdout = logger.userLogger()
 	cmd.Stderr = logger.userLogger()
+	// the user code runs in its own process group, so that the processes
+	// it starts are signalled with it
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
 	logger.Logf("running user code")
 	cmd.Env = environ
-	err := cmd.Run()
+	if err := cmd.Start(); err != nil {
+		logger.Logf("user code failed to start, err: %+v", err)
+		return err
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = stopUserCode(cmd, logger, done)
+	}
 	if err != nil {
 		logger.Logf("user code finished, err: %+v", err)
 	} else {
@@ -223,10 +266,43 @@ func (a *APIServer) runUserCode(ctx context.Context, logger *taggedLogger, envir
 
 }
 
-func (a *APIServer) uploadOutput(ctx context.Context, tag string, logger *taggedLogger, inputs []*Input) error {
+// stopUserCode stops the user code that cmd runs, whose datum has been
+// cancelled, by sending its process group SIGTERM, and then SIGKILL if it
+// hasn't exited after userCodeGracePeriod. done receives cmd's exit.
+func stopUserCode(cmd *exec.Cmd, logger *taggedLogger, done <-chan error) error {
+	logger.Logf("datum cancelled, sending SIGTERM to user code")
+	if err := syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM); err != nil {
+		logger.Logf("error sending SIGTERM to user code: %v", err)
+	}
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(userCodeGracePeriod):
+	}
+	logger.Logf("user code didn't exit within %v, sending SIGKILL", userCodeGracePeriod)
+	if err := syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL); err != nil {
+		logger.Logf("error sending SIGKILL to user code: %v", err)
+	}
+	return <-done
+}
+
+// uploadOutput uploads the datum's output, and returns its size.
+func (a *APIServer) uploadOutput(ctx context.Context, jobID string, tags []string, logger *taggedLogger, inputs []*Input) (int64, error) {
 	// hashtree is not thread-safe--guard with 'lock'
 	var lock sync.Mutex
 	tree := hashtree.NewHashTree()
+	// every file is recorded as written by this datum, so that InspectFile
+	// can trace it back to its inputs
+	provenance := &pfs.FileProvenance{
+		JobID:   jobID,
+		DatumID: DatumID(inputs),
+	}
+	putFile := func(path string, objects []*pfs.Object, size int64) error {
+		if err := tree.PutFile(path, objects, size); err != nil {
+			return err
+		}
+		return tree.PutProvenance(path, provenance)
+	}
 
 	// Upload all files in output directory
 	var g errgroup.Group
@@ -318,7 +394,7 @@ func (a *APIServer) uploadOutput(ctx context.Context, tag string, logger *tagged
 
 							lock.Lock()
 							defer lock.Unlock()
-							return tree.PutFile(subRelPath, fileInfo.Objects, int64(fileInfo.SizeBytes))
+							return putFile(subRelPath, fileInfo.Objects, int64(fileInfo.SizeBytes))
 						})
 					}
 				}
@@ -346,6 +422,15 @@ func (a *APIServer) uploadOutput(ctx context.Context, tag string, logger *tagged
 			if err != nil {
 				return err
 			}
+			// a file that's still being written to, e.g. by a process the
+			// user code left behind, would be uploaded truncated
+			fileInfo, err := f.Stat()
+			if err != nil {
+				return err
+			}
+			if int64(size) != fileInfo.Size() {
+				return fmt.Errorf("%s changed while it was being uploaded (%d bytes were uploaded, it's %d bytes)", relPath, size, fileInfo.Size())
+			}
 			object, err := putObjClient.CloseAndRecv()
 			if err != nil {
 				return err
@@ -353,32 +438,37 @@ func (a *APIServer) uploadOutput(ctx context.Context, tag string, logger *tagged
 
 			lock.Lock()
 			defer lock.Unlock()
-			return tree.PutFile(relPath, []*pfs.Object{object}, int64(size))
+			return putFile(relPath, []*pfs.Object{object}, int64(size))
 		})
 		return nil
 	}); err != nil {
-		return err
+		return 0, err
 	}
 
 	if err := g.Wait(); err != nil {
-		return err
+		return 0, err
 	}
 
 	finTree, err := tree.Finish()
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	treeBytes, err := hashtree.Serialize(finTree)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	if _, _, err := a.pachClient.PutObject(bytes.NewReader(treeBytes), tag); err != nil {
-		return err
+	// The output is staged: the files and the tree are content addressed,
+	// so until the tree is tagged, nothing refers to them, and a worker
+	// that dies before then leaves nothing in the output commit. Tagging
+	// the tree promotes the whole datum's output at once, it's the tags
+	// that the master merges and that retries check for.
+	object, _, err := a.pachClient.PutObject(bytes.NewReader(treeBytes))
+	if err != nil {
+		return 0, err
 	}
-
-	return nil
+	return finTree.Size(), a.pachClient.TagObject(object.Hash, tags...)
 }
 
 // cleanUpData removes everything under /pfs
@@ -440,8 +530,51 @@ func (a *APIServer) cleanUpData() error {
 	return err
 }
 
-// HashDatum computes and returns the hash of a datum + pipeline.
-func (a *APIServer) HashDatum(data []*Input) (string, error) {
+// DatumHashVersion is the version of the function that HashDatum and
+// globalDatumTag hash datums with, which new and updated pipelines use. The
+// hashes decide whether a datum's output from an earlier job is reused, so
+// whenever the function changes, this must be bumped, and the old function
+// kept for the pipelines that were created with it.
+const DatumHashVersion = 1
+
+// HashDatum computes and returns the hash of a datum + pipeline. cacheKey is
+// the datum's cache key, see DatumCacheKey.
+func (a *APIServer) HashDatum(data []*Input, cacheKey string) (string, error) {
+	if a.pipelineInfo == nil && a.jobInfo == nil {
+		return "", fmt.Errorf("malformed APIServer: has neither pipelineInfo or jobInfo; this is likely a bug")
+	}
+	if a.pipelineInfo == nil || a.pipelineInfo.DatumHashVersion == 0 {
+		return a.hashDatumV0(data, cacheKey)
+	}
+	if err := a.checkDatumHashVersion(); err != nil {
+		return "", err
+	}
+	transform, err := proto.Marshal(a.pipelineInfo.Transform)
+	if err != nil {
+		return "", err
+	}
+	// the pipeline's version and ID aren't hashed, so that updating the
+	// pipeline without changing what it computes, e.g. its parallelism,
+	// or recreating it, doesn't reprocess its datums; the salt is how
+	// users force that
+	parts := [][]byte{transform, []byte(a.pipelineInfo.Pipeline.Name), []byte(a.pipelineInfo.Salt)}
+	return hashDatumV1("datum", data, withCacheKey(parts, cacheKey)...), nil
+}
+
+// withCacheKey returns parts, the parts of a datum's hash, with cacheKey
+// added to them. Most datums have no cache key, and their hashes mustn't
+// change, so it's only added if it's set.
+func withCacheKey(parts [][]byte, cacheKey string) [][]byte {
+	if cacheKey == "" {
+		return parts
+	}
+	return append(parts, []byte(cacheKey))
+}
+
+// hashDatumV0 is version 0 of HashDatum, which pipelines created before
+// datum hashes were versioned use. It mustn't change, other than by adding
+// cacheKey, if it's set.
+func (a *APIServer) hashDatumV0(data []*Input, cacheKey string) (string, error) {
 	hash := sha256.New()
 	for _, datum := range data {
 		hash.Write([]byte(datum.Name))
@@ -457,58 +590,184 @@ func (a *APIServer) HashDatum(data []*Input) (string, error) {
 		hash.Write([]byte(a.pipelineInfo.Pipeline.Name))
 		hash.Write([]byte(a.pipelineInfo.ID))
 		hash.Write([]byte(strconv.Itoa(int(a.pipelineInfo.Version))))
-	} else if a.jobInfo != nil {
+	} else {
 		bytes, err := proto.Marshal(a.jobInfo.Transform)
 		if err != nil {
 			return "", err
 		}
 		hash.Write(bytes)
 		hash.Write([]byte(a.jobInfo.Job.ID))
+	}
+	hash.Write([]byte(cacheKey))
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// hashDatumV1 is version 1 of the datum hash. Unlike version 0, each thing
+// that's hashed is prefixed with its length, so that different datums can't
+// hash the same by moving bytes between, e.g., an input's name and path.
+func hashDatumV1(kind string, data []*Input, parts ...[]byte) string {
+	hash := sha256.New()
+	write := func(b []byte) {
+		var length [8]byte
+		binary.BigEndian.PutUint64(length[:], uint64(len(b)))
+		hash.Write(length[:])
+		hash.Write(b)
+	}
+	write([]byte(fmt.Sprintf("%s v1", kind)))
+	write([]byte(strconv.Itoa(len(data))))
+	for _, datum := range data {
+		write([]byte(datum.Name))
+		write([]byte(datum.FileInfo.File.Path))
+		write(datum.FileInfo.Hash)
+	}
+	for _, part := range parts {
+		write(part)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// checkDatumHashVersion returns an error if the pipeline's datums are hashed
+// with a version of the hash that this worker doesn't know, e.g. because
+// pachd was downgraded, since hashing them with another version would reuse
+// the wrong outputs, or none.
+func (a *APIServer) checkDatumHashVersion() error {
+	if version := a.pipelineInfo.DatumHashVersion; version < 0 || version > DatumHashVersion {
+		return fmt.Errorf("pipeline %s's datums are hashed with version %d of the datum hash, but this worker only knows versions up to %d; update the pipeline to rehash them", a.pipelineInfo.Pipeline.Name, version, DatumHashVersion)
+	}
+	return nil
+}
+
+// globalDatumTag returns the tag of the output of processing 'data' with the
+// worker's transform, whichever pipeline or job it's processed by. Unlike
+// the tags from HashDatum, which are only reused by the pipeline that
+// produced them, these are shared across the cluster, so that identical
+// work in different pipelines, or in a pipeline that's been deleted and
+// recreated, isn't done again. cacheKey is the datum's cache key, as in
+// HashDatum.
+func (a *APIServer) globalDatumTag(data []*Input, cacheKey string) (string, error) {
+	var transform *pps.Transform
+	if a.pipelineInfo != nil {
+		transform = a.pipelineInfo.Transform
+	} else if a.jobInfo != nil {
+		transform = a.jobInfo.Transform
 	} else {
 		return "", fmt.Errorf("malformed APIServer: has neither pipelineInfo or jobInfo; this is likely a bug")
 	}
+	bytes, err := proto.Marshal(transform)
+	if err != nil {
+		return "", err
+	}
+	if a.pipelineInfo != nil && a.pipelineInfo.DatumHashVersion != 0 {
+		if err := a.checkDatumHashVersion(); err != nil {
+			return "", err
+		}
+		// the salt is hashed, so that changing it also stops outputs
+		// being reused from other pipelines
+		return hashDatumV1("global", data, withCacheKey([][]byte{bytes, []byte(a.pipelineInfo.Salt)}, cacheKey)...), nil
+	}
+	hash := sha256.New()
+	hash.Write([]byte("global"))
+	for _, datum := range data {
+		hash.Write([]byte(datum.Name))
+		hash.Write([]byte(datum.FileInfo.File.Path))
+		hash.Write(datum.FileInfo.Hash)
+	}
+	hash.Write(bytes)
+	hash.Write([]byte(cacheKey))
 	return hex.EncodeToString(hash.Sum(nil)), nil
 }
 
-// Process processes a datum.
-func (a *APIServer) Process(ctx context.Context, req *ProcessRequest) (resp *ProcessResponse, retErr error) {
+// Process processes a datum. The datum is processed in the background, so
+// that it isn't lost if the master that sent it goes away, e.g. because its
+// pachd is restarted during an upgrade: the worker finishes the datum anyway,
+// and the master that takes over the job gets its result by sending it again.
+func (a *APIServer) Process(ctx context.Context, req *ProcessRequest) (*ProcessResponse, error) {
+	run, err := a.startDatum(req)
+	if err != nil {
+		return nil, err
+	}
+	select {
+	case <-run.done:
+		return run.resp, run.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// datumRun is a datum that's being processed, see Process.
+type datumRun struct {
+	jobID   string
+	datumID string
+	// cancelled is set by Cancel, the datum is still running until its user
+	// code has stopped
+	cancelled bool
+	// done is closed once the datum has been processed, and resp and err
+	// are set
+	done chan struct{}
+	resp *ProcessResponse
+	err  error
+}
+
+// startDatum starts processing the datum that req describes, or returns the
+// datum that's already being processed if it's the same one.
+func (a *APIServer) startDatum(req *ProcessRequest) (*datumRun, error) {
 	// We cannot run more than one user process at once; otherwise they'd be
 	// writing to the same output directory. Acquire lock to make sure only one
 	// user process runs at a time.
-	// set the status for the datum
-	ctx, cancel := context.WithCancel(ctx)
-	if err := func() error {
-		a.statusMu.Lock()
-		defer a.statusMu.Unlock()
-		if a.jobID != "" {
-			// we error in this case so that callers have a chance to find a
-			// non-busy worker
-			return fmt.Errorf("worker busy")
+	a.statusMu.Lock()
+	defer a.statusMu.Unlock()
+	datumID := DatumID(req.Data)
+	if a.run != nil {
+		if a.run.jobID == req.JobID && a.run.datumID == datumID && !a.run.cancelled {
+			a.getTaggedLogger(req).Logf("resuming datum that's already being processed")
+			return a.run, nil
 		}
-		a.jobID = req.JobID
-		a.data = req.Data
-		a.started = time.Now()
-		a.cancel = cancel
-		return nil
-	}(); err != nil {
-		return nil, err
+		// we error in this case so that callers have a chance to find a
+		// non-busy worker
+		return nil, fmt.Errorf("worker busy")
 	}
-	// unset the status when this function exits
-	defer func() {
+	// The datum's context isn't the request's, so that it's processed even
+	// if the request is cancelled. It's cancelled by Cancel.
+	ctx, cancel := context.WithCancel(context.Background())
+	run := &datumRun{
+		jobID:   req.JobID,
+		datumID: datumID,
+		done:    make(chan struct{}),
+	}
+	// set the status for the datum
+	a.jobID = req.JobID
+	a.data = req.Data
+	a.started = time.Now()
+	a.cancel = cancel
+	a.run = run
+	go func() {
+		defer cancel()
+		run.resp, run.err = a.processDatum(ctx, req)
+		// unset the status now that the datum is done
 		a.statusMu.Lock()
 		defer a.statusMu.Unlock()
-		a.jobID = ""
-		a.data = nil
-		a.started = time.Time{}
-		a.cancel = nil
+		if a.run == run {
+			a.jobID = ""
+			a.data = nil
+			a.started = time.Time{}
+			a.cancel = nil
+			a.run = nil
+		}
+		close(run.done)
 	}()
+	return run, nil
+}
+
+// processDatum downloads the datum that req describes, runs the user's code on
+// it and uploads its output.
+func (a *APIServer) processDatum(ctx context.Context, req *ProcessRequest) (resp *ProcessResponse, retErr error) {
 	logger := a.getTaggedLogger(req)
 	logger.Logf("Received request")
 
 	// Hash inputs and check if output is in s3 already. Note: ppsserver sorts
 	// inputs by input name for both jobs and pipelines, so this hash is stable
 	// even if a.Inputs are reordered by the user
-	tag, err := a.HashDatum(req.Data)
+	tag, err := a.HashDatum(req.Data, req.CacheKey)
 	if err != nil {
 		return nil, err
 	}
@@ -519,11 +778,41 @@ func (a *APIServer) Process(ctx context.Context, req *ProcessRequest) (resp *Pro
 			Tag: &pfs.Tag{tag},
 		}, nil
 	}
+	// The same inputs may have been processed with the same transform by
+	// another pipeline, in which case its output is reused
+	globalTag, err := a.globalDatumTag(req.Data, req.CacheKey)
+	if err != nil {
+		return nil, err
+	}
+	if objectInfo, err := a.pachClient.InspectTag(ctx, &pfs.Tag{globalTag}); err == nil {
+		logger.Logf("skipping input, as it's already been processed by another pipeline")
+		if err := a.pachClient.TagObject(objectInfo.Object.Hash, tag); err != nil {
+			return nil, err
+		}
+		return &ProcessResponse{
+			Tag: &pfs.Tag{tag},
+		}, nil
+	}
+
+	// /pfs is a volume that outlives the worker's container, so if the
+	// worker died while processing a datum, its input and partial output
+	// are still there, and would end up in this datum's output
+	if err := a.cleanUpData(); err != nil {
+		return nil, err
+	}
+
+	// stats are recorded for InspectDatum once the user code has run
+	stats := &pps.ProcessStats{}
+	for _, input := range req.Data {
+		stats.DownloadBytes += input.FileInfo.SizeBytes
+	}
 
 	// Download input data
 	logger.Logf("input has not been processed, downloading data")
 	puller := filesync.NewPuller()
+	downloadStart := time.Now()
 	err = a.downloadData(req.Data, puller)
+	stats.DownloadTime = types.DurationProto(time.Since(downloadStart))
 	// We run these cleanup functions no matter what, so that if
 	// downloadData partially succeeded, we still clean up the resources.
 	defer func() {
@@ -543,19 +832,33 @@ func (a *APIServer) Process(ctx context.Context, req *ProcessRequest) (resp *Pro
 		return nil, err
 	}
 
-	environ := a.userCodeEnviron(req)
+	environ, err := a.userCodeEnviron(ctx, req)
+	if err != nil {
+		return nil, err
+	}
 
 	// Create output directory (currently /pfs/out) and run user code
 	if err := os.MkdirAll(client.PPSOutputPath, 0666); err != nil {
 		return nil, err
 	}
 	logger.Logf("beginning to process user input")
+	if a.statsd != nil {
+		a.statsd.reset()
+	}
+	processStart := time.Now()
 	err = a.runUserCode(ctx, logger, environ)
+	stats.ProcessTime = types.DurationProto(time.Since(processStart))
 	logger.Logf("finished processing user input")
+	if ctx.Err() != nil {
+		// the datum was cancelled, which isn't the user code's failure
+		return nil, ctx.Err()
+	}
 	if err != nil {
 		logger.Logf("failed to process datum with error: %+v", err)
+		a.putDatumStats(req, stats, logger)
 		return &ProcessResponse{
 			Failed: true,
+			Reason: err.Error(),
 		}, nil
 	}
 	// CleanUp is idempotent so we can call it however many times we want.
@@ -568,7 +871,9 @@ func (a *APIServer) Process(ctx context.Context, req *ProcessRequest) (resp *Pro
 		logger.Logf("puller encountered an error while cleaning up: %+v", err)
 		return nil, err
 	}
-	if err := a.uploadOutput(ctx, tag, logger, req.Data); err != nil {
+	uploadStart := time.Now()
+	outputSize, err := a.uploadOutput(ctx, req.JobID, []string{tag, globalTag}, logger, req.Data)
+	if err != nil {
 		// If uploading failed because the user program outputed a special
 		// file, then there's no point in retrying.  Thus we signal that
 		// there's some problem with the user code so the job doesn't
@@ -576,15 +881,38 @@ func (a *APIServer) Process(ctx context.Context, req *ProcessRequest) (resp *Pro
 		if err == errSpecialFile {
 			return &ProcessResponse{
 				Failed: true,
+				Reason: err.Error(),
 			}, nil
 		}
 		return nil, err
 	}
+	stats.UploadTime = types.DurationProto(time.Since(uploadStart))
+	stats.UploadBytes = uint64(outputSize)
+	a.putDatumStats(req, stats, logger)
+	var metrics []*pps.UserMetric
+	if a.statsd != nil {
+		metrics = a.statsd.flush()
+	}
 	return &ProcessResponse{
-		Tag: &pfs.Tag{tag},
+		Tag:     &pfs.Tag{tag},
+		Metrics: metrics,
 	}, nil
 }
 
+// putDatumStats stores the stats of the processing of the datum that req
+// describes, for InspectDatum. They're only informational, so an error
+// storing them is logged rather than failing the datum.
+func (a *APIServer) putDatumStats(req *ProcessRequest, stats *pps.ProcessStats, logger *taggedLogger) {
+	statsBytes, err := proto.Marshal(stats)
+	if err != nil {
+		logger.Logf("error marshalling datum stats: %v", err)
+		return
+	}
+	if _, _, err := a.pachClient.PutObject(bytes.NewReader(statsBytes), DatumStatsTag(req.JobID, DatumID(req.Data))); err != nil {
+		logger.Logf("error storing datum stats: %v", err)
+	}
+}
+
 // Status returns the status of the current worker.
 func (a *APIServer) Status(ctx context.Context, _ *types.Empty) (*pps.WorkerStatus, error) {
 	a.statusMu.Lock()
@@ -602,23 +930,97 @@ func (a *APIServer) Status(ctx context.Context, _ *types.Empty) (*pps.WorkerStat
 	return result, nil
 }
 
-// Cancel cancels the currently running datum
+// Cancel cancels the currently running datum, if it's one of request.JobID's
+// and matches request.DataFilters. Its user code is stopped, see
+// stopUserCode.
 func (a *APIServer) Cancel(ctx context.Context, request *CancelRequest) (*CancelResponse, error) {
 	a.statusMu.Lock()
 	defer a.statusMu.Unlock()
-	if request.JobID != a.jobID {
+	if a.run == nil || a.run.cancelled || request.JobID != a.jobID {
 		return &CancelResponse{Success: false}, nil
 	}
 	if !MatchDatum(request.DataFilters, a.datum()) {
 		return &CancelResponse{Success: false}, nil
 	}
 	a.cancel()
-	// clear the status since we're no longer processing this datum
-	a.jobID = ""
-	a.data = nil
-	a.started = time.Time{}
-	a.cancel = nil
-	return &CancelResponse{Success: true}, nil
+	a.run.cancelled = true
+	// the status is cleared once the user code has stopped, so that no other
+	// datum is started while it's still writing to the output directory
+	return &CancelResponse{Success: true, DatumID: a.run.datumID}, nil
+}
+
+// Merge merges the hashtrees in request into one, which it uploads and
+// returns. The master merges a job's output by having its workers merge it a
+// group of trees at a time, see pps/server's mergeTrees. The merged tree is
+// tagged with a hash of the trees it's made of, so that if the master
+// restarts, the merges that were already done are reused.
+func (a *APIServer) Merge(ctx context.Context, request *MergeRequest) (*MergeResponse, error) {
+	tag := mergeTag(request.Trees)
+	if objectInfo, err := a.pachClient.InspectTag(ctx, &pfs.Tag{tag}); err == nil {
+		return &MergeResponse{
+			Tag:  &pfs.Tag{tag},
+			Tree: objectInfo.Object,
+		}, nil
+	}
+	trees := make([]hashtree.HashTree, len(request.Trees))
+	limiter := limit.New(concurrency)
+	var eg errgroup.Group
+	for i, treeTag := range request.Trees {
+		i, treeTag := i, treeTag
+		limiter.Acquire()
+		eg.Go(func() error {
+			defer limiter.Release()
+			getTagClient, err := a.pachClient.ObjectAPIClient.GetTag(ctx, treeTag)
+			if err != nil {
+				return fmt.Errorf("failed to retrieve hashtree %s: %v", treeTag.Name, err)
+			}
+			var buffer bytes.Buffer
+			if err := grpcutil.WriteFromStreamingBytesClient(getTagClient, &buffer); err != nil {
+				return fmt.Errorf("failed to retrieve hashtree %s: %v", treeTag.Name, err)
+			}
+			trees[i], err = hashtree.Deserialize(buffer.Bytes())
+			if err != nil {
+				return fmt.Errorf("failed to deserialize hashtree %s: %v", treeTag.Name, err)
+			}
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+	// the trees are merged all at once, merging them one at a time would
+	// copy the merged tree for each of them
+	tree := hashtree.NewHashTree()
+	if err := tree.Merge(trees...); err != nil {
+		return nil, err
+	}
+	finishedTree, err := tree.Finish()
+	if err != nil {
+		return nil, err
+	}
+	data, err := hashtree.Serialize(finishedTree)
+	if err != nil {
+		return nil, err
+	}
+	object, _, err := a.pachClient.PutObject(bytes.NewReader(data), tag)
+	if err != nil {
+		return nil, err
+	}
+	return &MergeResponse{
+		Tag:  &pfs.Tag{tag},
+		Tree: object,
+	}, nil
+}
+
+// mergeTag returns the tag of the hashtree that merging trees produces.
+func mergeTag(trees []*pfs.Tag) string {
+	hash := sha256.New()
+	hash.Write([]byte("merge"))
+	for _, tree := range trees {
+		hash.Write([]byte(tree.Name))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
 }
 
 func (a *APIServer) datum() []*pps.Datum {
@@ -632,6 +1034,22 @@ func (a *APIServer) datum() []*pps.Datum {
 	return result
 }
 
-func (a *APIServer) userCodeEnviron(req *ProcessRequest) []string {
-	return append(os.Environ(), fmt.Sprintf("PACH_JOB_ID=%s", req.JobID))
+func (a *APIServer) userCodeEnviron(ctx context.Context, req *ProcessRequest) ([]string, error) {
+	environ := append(os.Environ(), fmt.Sprintf("PACH_JOB_ID=%s", req.JobID))
+	if a.rendezvous != nil {
+		rendezvousEnviron, err := a.rendezvous.environ(ctx)
+		if err != nil {
+			return nil, err
+		}
+		environ = append(environ, rendezvousEnviron...)
+	}
+	if a.statsd != nil {
+		// STATSD_HOST and STATSD_PORT are read by many statsd clients
+		addr := a.statsd.addr()
+		environ = append(environ,
+			fmt.Sprintf("%s=%s", client.PPSStatsdAddrEnv, addr),
+			fmt.Sprintf("STATSD_HOST=%s", addr.IP),
+			fmt.Sprintf("STATSD_PORT=%d", addr.Port))
+	}
+	return environ, nil
 }
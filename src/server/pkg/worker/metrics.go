@@ -0,0 +1,231 @@
+package worker
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client/pkg/uuid"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+)
+
+const (
+	// statsdFlushPrefix prefixes the packets that statsdServer sends itself,
+	// see statsdServer.flush.
+	statsdFlushPrefix = "_pach_flush:"
+	// statsdFlushTimeout is how long flush waits for the packets that the
+	// user code sent to be read.
+	statsdFlushTimeout = 10 * time.Second
+	// maxStatsdPacketSize is the biggest packet that statsdServer reads,
+	// which is the most that fits in a UDP datagram.
+	maxStatsdPacketSize = 65535
+)
+
+// statsdServer collects the metrics that user code sends it, in the statsd
+// protocol, so that pipelines can report metrics, e.g. the number of records
+// they parsed, which are tied to their jobs, without running their own
+// metrics infrastructure. Counters ("<name>:<value>|c") are summed, gauges
+// ("<name>:<value>|g") keep their last value, other types of metrics are
+// ignored. The worker only processes one datum at a time, so the metrics
+// which are collected between reset and flush are the datum's.
+type statsdServer struct {
+	conn *net.UDPConn
+
+	mu      sync.Mutex
+	metrics map[string]*pps.UserMetric
+	// flushes are closed once the packet that flush sent with their token
+	// has been read
+	flushes map[string]chan struct{}
+}
+
+// newStatsdServer starts a statsdServer listening on localhost, on a port
+// that's picked by the OS, so that it doesn't clash with the user code's.
+func newStatsdServer() (*statsdServer, error) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		return nil, err
+	}
+	s := &statsdServer{
+		conn:    conn,
+		metrics: make(map[string]*pps.UserMetric),
+		flushes: make(map[string]chan struct{}),
+	}
+	go s.serve()
+	return s, nil
+}
+
+// addr is the address that user code sends metrics to.
+func (s *statsdServer) addr() *net.UDPAddr {
+	return s.conn.LocalAddr().(*net.UDPAddr)
+}
+
+func (s *statsdServer) serve() {
+	buf := make([]byte, maxStatsdPacketSize)
+	for {
+		n, _, err := s.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		s.handle(string(buf[:n]))
+	}
+}
+
+// handle records the metrics in packet, which holds one metric per line.
+func (s *statsdServer) handle(packet string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, line := range strings.Split(packet, "\n") {
+		if strings.HasPrefix(line, statsdFlushPrefix) {
+			if done, ok := s.flushes[strings.TrimPrefix(line, statsdFlushPrefix)]; ok {
+				close(done)
+			}
+			continue
+		}
+		m, err := parseStatsdLine(line)
+		if err != nil {
+			continue
+		}
+		key := m.Type.String() + ":" + m.Name
+		metric, ok := s.metrics[key]
+		if !ok {
+			metric = &pps.UserMetric{Name: m.Name, Type: m.Type}
+			s.metrics[key] = metric
+		}
+		if m.Type == pps.UserMetricType_COUNTER || m.relative {
+			metric.Value += m.Value
+		} else {
+			metric.Value = m.Value
+		}
+	}
+}
+
+// reset forgets the metrics that have been collected so far.
+func (s *statsdServer) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.metrics = make(map[string]*pps.UserMetric)
+}
+
+// flush returns the metrics that have been collected since the last reset,
+// sorted by name. The user code's packets may not have been read yet when it
+// exits, so flush sends a packet of its own, and waits for it to be read,
+// which it is after the packets that were sent before it.
+func (s *statsdServer) flush() []*pps.UserMetric {
+	token := uuid.NewWithoutDashes()
+	done := make(chan struct{})
+	s.mu.Lock()
+	s.flushes[token] = done
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.flushes, token)
+	}()
+	if conn, err := net.DialUDP("udp", nil, s.addr()); err == nil {
+		conn.Write([]byte(statsdFlushPrefix + token))
+		conn.Close()
+		select {
+		case <-done:
+		case <-time.After(statsdFlushTimeout):
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result []*pps.UserMetric
+	for _, metric := range s.metrics {
+		result = append(result, &pps.UserMetric{
+			Name:  metric.Name,
+			Type:  metric.Type,
+			Value: metric.Value,
+		})
+	}
+	sortUserMetrics(result)
+	return result
+}
+
+// statsdMetric is a metric that statsdServer has read.
+type statsdMetric struct {
+	pps.UserMetric
+	// relative is true for gauges which are changed by their value, rather
+	// than set to it, which are sent with a sign, e.g. "queue:+1|g"
+	relative bool
+}
+
+// parseStatsdLine parses a metric of the form <name>:<value>|<type>, which
+// may be followed by a sample rate (|@<rate>) and tags (|#<tags>), which
+// are ignored, except that counters are scaled by their sample rates.
+func parseStatsdLine(line string) (*statsdMetric, error) {
+	line = strings.TrimSpace(line)
+	colon := strings.IndexByte(line, ':')
+	if colon <= 0 {
+		return nil, fmt.Errorf("invalid metric %q", line)
+	}
+	fields := strings.Split(line[colon+1:], "|")
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("invalid metric %q", line)
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value in metric %q: %v", line, err)
+	}
+	m := &statsdMetric{UserMetric: pps.UserMetric{Name: line[:colon], Value: value}}
+	switch fields[1] {
+	case "c":
+		m.Type = pps.UserMetricType_COUNTER
+		for _, field := range fields[2:] {
+			if strings.HasPrefix(field, "@") {
+				rate, err := strconv.ParseFloat(field[1:], 64)
+				if err != nil || rate <= 0 {
+					return nil, fmt.Errorf("invalid sample rate in metric %q", line)
+				}
+				m.Value /= rate
+			}
+		}
+	case "g":
+		m.Type = pps.UserMetricType_GAUGE
+		m.relative = strings.HasPrefix(fields[0], "+") || strings.HasPrefix(fields[0], "-")
+	default:
+		return nil, fmt.Errorf("unsupported type in metric %q", line)
+	}
+	return m, nil
+}
+
+// MergeUserMetrics returns metrics with more merged into it, as a job's
+// metrics are merged with its datums': counters are summed, and gauges take
+// their value from more. Neither metrics nor more are modified.
+func MergeUserMetrics(metrics []*pps.UserMetric, more []*pps.UserMetric) []*pps.UserMetric {
+	merged := make(map[string]*pps.UserMetric)
+	var result []*pps.UserMetric
+	for _, ms := range [][]*pps.UserMetric{metrics, more} {
+		for _, m := range ms {
+			key := m.Type.String() + ":" + m.Name
+			if metric, ok := merged[key]; ok {
+				if m.Type == pps.UserMetricType_COUNTER {
+					metric.Value += m.Value
+				} else {
+					metric.Value = m.Value
+				}
+				continue
+			}
+			metric := &pps.UserMetric{Name: m.Name, Type: m.Type, Value: m.Value}
+			merged[key] = metric
+			result = append(result, metric)
+		}
+	}
+	sortUserMetrics(result)
+	return result
+}
+
+func sortUserMetrics(metrics []*pps.UserMetric) {
+	sort.Slice(metrics, func(i, j int) bool {
+		if metrics[i].Name != metrics[j].Name {
+			return metrics[i].Name < metrics[j].Name
+		}
+		return metrics[i].Type < metrics[j].Type
+	})
+}
@@ -0,0 +1,129 @@
+package worker
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	etcd "github.com/coreos/etcd/clientv3"
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+	"golang.org/x/net/context"
+)
+
+// rendezvousPollInterval is how often a worker checks whether a rank has
+// been freed, or all of its peers have claimed theirs.
+const rendezvousPollInterval = time.Second
+
+// rendezvous is a worker's rank among the workers of its pipeline or job,
+// see pps.Rendezvous.
+type rendezvous struct {
+	etcdClient *etcd.Client
+	// prefix is where the workers' ranks are in etcd, each rank's key holds
+	// the IP of the worker that claimed it
+	prefix string
+	rank   int
+	count  int
+	port   int32
+}
+
+// JoinRendezvous claims the lowest free rank among the 'count' workers whose
+// ranks are under prefix in etcd, until the lease leaseID expires, and tells
+// the user code its rank and its peers' addresses from then on. If every rank
+// is claimed, e.g. because the worker is replacing one whose lease hasn't
+// expired yet, it waits for one to be freed, so it must be called before the
+// worker is discoverable by pachd.
+func (a *APIServer) JoinRendezvous(ctx context.Context, etcdClient *etcd.Client, prefix string, ip string, count int, leaseID etcd.LeaseID) error {
+	var transform *pps.Transform
+	if a.pipelineInfo != nil {
+		transform = a.pipelineInfo.Transform
+	} else {
+		transform = a.jobInfo.Transform
+	}
+	port := transform.Rendezvous.GetPort()
+	if port == 0 {
+		port = client.PPSDefaultRendezvousPort
+	}
+	if count <= 0 {
+		return fmt.Errorf("invalid number of workers %d", count)
+	}
+	for {
+		for rank := 0; rank < count; rank++ {
+			key := path.Join(prefix, strconv.Itoa(rank))
+			resp, err := etcdClient.Txn(ctx).
+				If(etcd.Compare(etcd.CreateRevision(key), "=", 0)).
+				Then(etcd.OpPut(key, ip, etcd.WithLease(leaseID))).
+				Commit()
+			if err != nil {
+				return err
+			}
+			if resp.Succeeded {
+				a.rendezvous = &rendezvous{
+					etcdClient: etcdClient,
+					prefix:     prefix,
+					rank:       rank,
+					count:      count,
+					port:       port,
+				}
+				return nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(rendezvousPollInterval):
+		}
+	}
+}
+
+// peers returns the IPs of all of the workers, by rank. It waits until every
+// rank is claimed, as the user code can't connect to workers that don't
+// exist yet.
+func (r *rendezvous) peers(ctx context.Context) ([]string, error) {
+	for {
+		resp, err := r.etcdClient.Get(ctx, r.prefix+"/", etcd.WithPrefix())
+		if err != nil {
+			return nil, err
+		}
+		peers := make([]string, r.count)
+		var claimed int
+		for _, kv := range resp.Kvs {
+			rank, err := strconv.Atoi(path.Base(string(kv.Key)))
+			if err != nil || rank < 0 || rank >= r.count {
+				continue
+			}
+			peers[rank] = string(kv.Value)
+			claimed++
+		}
+		if claimed == r.count {
+			return peers, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(rendezvousPollInterval):
+		}
+	}
+}
+
+// environ returns the env vars that tell the user code its rank and how to
+// reach its peers, both as PACH_* vars and as the vars that
+// torch.distributed's env:// initialization reads.
+func (r *rendezvous) environ(ctx context.Context) ([]string, error) {
+	peers, err := r.peers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return []string{
+		fmt.Sprintf("%s=%d", client.PPSWorkerRankEnv, r.rank),
+		fmt.Sprintf("%s=%s", client.PPSWorkerAddrsEnv, strings.Join(peers, ",")),
+		fmt.Sprintf("%s=%s", client.PPSMasterAddrEnv, peers[0]),
+		fmt.Sprintf("%s=%d", client.PPSMasterPortEnv, r.port),
+		fmt.Sprintf("RANK=%d", r.rank),
+		fmt.Sprintf("WORLD_SIZE=%d", r.count),
+		fmt.Sprintf("MASTER_ADDR=%s", peers[0]),
+		fmt.Sprintf("MASTER_PORT=%d", r.port),
+	}, nil
+}
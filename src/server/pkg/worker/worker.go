@@ -1,7 +1,15 @@
 package worker
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"strings"
+
 	"github.com/pachyderm/pachyderm/src/client/pps"
+
+	"github.com/gogo/protobuf/types"
 )
 
 // MatchDatum checks if a datum matches a filter.  To match each string in
@@ -23,3 +31,75 @@ dataFilters:
 	}
 	return matchesData
 }
+
+// DatumID returns the ID of the datum made up of data, which identifies it
+// within its job (it's the ID returned by ListDatum).
+func DatumID(data []*Input) string {
+	hash := sha256.New()
+	for _, input := range data {
+		hash.Write([]byte(input.Name))
+		hash.Write([]byte(input.FileInfo.File.Path))
+		hash.Write(input.FileInfo.Hash)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// DatumStatsTag returns the tag of the object that the stats of the datum
+// with ID datumID are stored in, once the job with ID jobID has processed it
+// (see pps.DatumInfo.Stats).
+func DatumStatsTag(jobID string, datumID string) string {
+	return "stats-" + jobID + "-" + datumID
+}
+
+// DatumCacheKey returns the cache key (see ProcessRequest.CacheKey) of the
+// datum made up of data in the job that jobInfo describes. It's made up of
+// the period of the job's datum cache TTL that the job started in, and the
+// IDs of the job's cache invalidations whose globs match the datum.
+func DatumCacheKey(jobInfo *pps.JobInfo, data []*Input) (string, error) {
+	var parts []string
+	if jobInfo.DatumCacheTTL != nil {
+		ttl, err := types.DurationFromProto(jobInfo.DatumCacheTTL)
+		if err != nil {
+			return "", err
+		}
+		started, err := types.TimestampFromProto(jobInfo.Started)
+		if err != nil {
+			return "", err
+		}
+		if ttl > 0 {
+			parts = append(parts, fmt.Sprintf("period %d", started.UnixNano()/int64(ttl)))
+		}
+	}
+	for _, invalidation := range jobInfo.DatumCacheInvalidations {
+		matched, err := matchDatumCacheInvalidation(invalidation.Glob, data)
+		if err != nil {
+			return "", err
+		}
+		if matched {
+			parts = append(parts, "invalidation "+invalidation.ID)
+		}
+	}
+	return strings.Join(parts, "\n"), nil
+}
+
+// matchDatumCacheInvalidation returns true if glob, the glob of a cache
+// invalidation, matches the datum made up of data, i.e. if it's empty or
+// it matches the path of one of data's files.
+func matchDatumCacheInvalidation(glob string, data []*Input) (bool, error) {
+	if glob == "" {
+		return true, nil
+	}
+	// the paths of files always start with "/", so "*" matches the
+	// files at the top of an input, as it does in an input's glob
+	glob = path.Join("/", glob)
+	for _, input := range data {
+		matched, err := path.Match(glob, input.FileInfo.File.Path)
+		if err != nil {
+			return false, fmt.Errorf("glob \"%s\" is malformed", glob)
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -14,6 +14,8 @@ It has these top-level messages:
 	ProcessResponse
 	CancelRequest
 	CancelResponse
+	MergeRequest
+	MergeResponse
 */
 package worker
 
@@ -79,6 +81,10 @@ type ProcessRequest struct {
 	JobID string `protobuf:"bytes,2,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
 	// The datum to process
 	Data []*Input `protobuf:"bytes,1,rep,name=data" json:"data,omitempty"`
+	// cache_key is hashed along with the datum, so that its output from
+	// earlier jobs isn't reused once it's expired or been cleared, see
+	// DatumCacheKey. It's empty if neither has happened.
+	CacheKey string `protobuf:"bytes,3,opt,name=cache_key,json=cacheKey,proto3" json:"cache_key,omitempty"`
 }
 
 func (m *ProcessRequest) Reset()                    { *m = ProcessRequest{} }
@@ -100,11 +106,22 @@ func (m *ProcessRequest) GetData() []*Input {
 	return nil
 }
 
+func (m *ProcessRequest) GetCacheKey() string {
+	if m != nil {
+		return m.CacheKey
+	}
+	return ""
+}
+
 // ProcessResponse contains a tag, only if the processing was successful.
 type ProcessResponse struct {
 	Tag *pfs.Tag `protobuf:"bytes,1,opt,name=tag" json:"tag,omitempty"`
 	// If true, the user program has errored
 	Failed bool `protobuf:"varint,2,opt,name=failed,proto3" json:"failed,omitempty"`
+	// If failed is true, why the datum failed
+	Reason string `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	// The metrics that the user code reported while processing the datum
+	Metrics []*pps.UserMetric `protobuf:"bytes,4,rep,name=metrics" json:"metrics,omitempty"`
 }
 
 func (m *ProcessResponse) Reset()                    { *m = ProcessResponse{} }
@@ -126,6 +143,20 @@ func (m *ProcessResponse) GetFailed() bool {
 	return false
 }
 
+func (m *ProcessResponse) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+func (m *ProcessResponse) GetMetrics() []*pps.UserMetric {
+	if m != nil {
+		return m.Metrics
+	}
+	return nil
+}
+
 type CancelRequest struct {
 	JobID       string   `protobuf:"bytes,2,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
 	DataFilters []string `protobuf:"bytes,1,rep,name=data_filters,json=dataFilters" json:"data_filters,omitempty"`
@@ -152,6 +183,8 @@ func (m *CancelRequest) GetDataFilters() []string {
 
 type CancelResponse struct {
 	Success bool `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	// The ID of the datum that was cancelled, if success is true
+	DatumID string `protobuf:"bytes,2,opt,name=datum_id,json=datumId,proto3" json:"datum_id,omitempty"`
 }
 
 func (m *CancelResponse) Reset()                    { *m = CancelResponse{} }
@@ -166,12 +199,73 @@ func (m *CancelResponse) GetSuccess() bool {
 	return false
 }
 
+func (m *CancelResponse) GetDatumID() string {
+	if m != nil {
+		return m.DatumID
+	}
+	return ""
+}
+
+// MergeRequest asks a worker to merge some of the hashtrees output by a job's
+// datums (or by earlier merges) into one.
+type MergeRequest struct {
+	JobID string `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	// The tags of the hashtrees to merge
+	Trees []*pfs.Tag `protobuf:"bytes,2,rep,name=trees" json:"trees,omitempty"`
+}
+
+func (m *MergeRequest) Reset()                    { *m = MergeRequest{} }
+func (m *MergeRequest) String() string            { return proto.CompactTextString(m) }
+func (*MergeRequest) ProtoMessage()               {}
+func (*MergeRequest) Descriptor() ([]byte, []int) { return fileDescriptorWorkerService, []int{5} }
+
+func (m *MergeRequest) GetJobID() string {
+	if m != nil {
+		return m.JobID
+	}
+	return ""
+}
+
+func (m *MergeRequest) GetTrees() []*pfs.Tag {
+	if m != nil {
+		return m.Trees
+	}
+	return nil
+}
+
+// MergeResponse contains the merged hashtree.
+type MergeResponse struct {
+	Tag  *pfs.Tag    `protobuf:"bytes,1,opt,name=tag" json:"tag,omitempty"`
+	Tree *pfs.Object `protobuf:"bytes,2,opt,name=tree" json:"tree,omitempty"`
+}
+
+func (m *MergeResponse) Reset()                    { *m = MergeResponse{} }
+func (m *MergeResponse) String() string            { return proto.CompactTextString(m) }
+func (*MergeResponse) ProtoMessage()               {}
+func (*MergeResponse) Descriptor() ([]byte, []int) { return fileDescriptorWorkerService, []int{6} }
+
+func (m *MergeResponse) GetTag() *pfs.Tag {
+	if m != nil {
+		return m.Tag
+	}
+	return nil
+}
+
+func (m *MergeResponse) GetTree() *pfs.Object {
+	if m != nil {
+		return m.Tree
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*Input)(nil), "worker.Input")
 	proto.RegisterType((*ProcessRequest)(nil), "worker.ProcessRequest")
 	proto.RegisterType((*ProcessResponse)(nil), "worker.ProcessResponse")
 	proto.RegisterType((*CancelRequest)(nil), "worker.CancelRequest")
 	proto.RegisterType((*CancelResponse)(nil), "worker.CancelResponse")
+	proto.RegisterType((*MergeRequest)(nil), "worker.MergeRequest")
+	proto.RegisterType((*MergeResponse)(nil), "worker.MergeResponse")
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -188,6 +282,7 @@ type WorkerClient interface {
 	Process(ctx context.Context, in *ProcessRequest, opts ...grpc.CallOption) (*ProcessResponse, error)
 	Status(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*pps.WorkerStatus, error)
 	Cancel(ctx context.Context, in *CancelRequest, opts ...grpc.CallOption) (*CancelResponse, error)
+	Merge(ctx context.Context, in *MergeRequest, opts ...grpc.CallOption) (*MergeResponse, error)
 }
 
 type workerClient struct {
@@ -225,12 +320,22 @@ func (c *workerClient) Cancel(ctx context.Context, in *CancelRequest, opts ...gr
 	return out, nil
 }
 
+func (c *workerClient) Merge(ctx context.Context, in *MergeRequest, opts ...grpc.CallOption) (*MergeResponse, error) {
+	out := new(MergeResponse)
+	err := grpc.Invoke(ctx, "/worker.Worker/Merge", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for Worker service
 
 type WorkerServer interface {
 	Process(context.Context, *ProcessRequest) (*ProcessResponse, error)
 	Status(context.Context, *google_protobuf.Empty) (*pps.WorkerStatus, error)
 	Cancel(context.Context, *CancelRequest) (*CancelResponse, error)
+	Merge(context.Context, *MergeRequest) (*MergeResponse, error)
 }
 
 func RegisterWorkerServer(s *grpc.Server, srv WorkerServer) {
@@ -291,6 +396,24 @@ func _Worker_Cancel_Handler(srv interface{}, ctx context.Context, dec func(inter
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Worker_Merge_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MergeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WorkerServer).Merge(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/worker.Worker/Merge",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WorkerServer).Merge(ctx, req.(*MergeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Worker_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "worker.Worker",
 	HandlerType: (*WorkerServer)(nil),
@@ -307,6 +430,10 @@ var _Worker_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Cancel",
 			Handler:    _Worker_Cancel_Handler,
 		},
+		{
+			MethodName: "Merge",
+			Handler:    _Worker_Merge_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "server/pkg/worker/worker_service.proto",
@@ -315,32 +442,40 @@ var _Worker_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("server/pkg/worker/worker_service.proto", fileDescriptorWorkerService) }
 
 var fileDescriptorWorkerService = []byte{
-	// 424 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x94, 0x92, 0xc1, 0x6e, 0xd4, 0x30,
-	0x10, 0x86, 0x1b, 0xb6, 0x9b, 0x66, 0xa7, 0xb4, 0x08, 0x0b, 0x96, 0x28, 0x1c, 0x08, 0x39, 0xa0,
-	0x55, 0x0f, 0x89, 0x54, 0xc4, 0x01, 0x89, 0x13, 0xd0, 0x4a, 0xe1, 0x84, 0x4c, 0x11, 0x07, 0x0e,
-	0x91, 0x93, 0x1d, 0x47, 0x6e, 0xdd, 0x38, 0xc4, 0x0e, 0xa8, 0x3c, 0x19, 0x4f, 0xc3, 0x81, 0x27,
-	0x41, 0xb6, 0x13, 0xd0, 0xc2, 0xa9, 0x07, 0x2b, 0x33, 0xff, 0xc4, 0x33, 0xdf, 0xcc, 0x18, 0x9e,
-	0x69, 0x1c, 0xbe, 0xe2, 0x50, 0xf4, 0x57, 0x6d, 0xf1, 0x4d, 0x0d, 0x57, 0x38, 0x4c, 0x9f, 0xca,
-	0x06, 0x44, 0x83, 0x79, 0x3f, 0x28, 0xa3, 0x48, 0xe8, 0xd5, 0xe4, 0x41, 0x23, 0x05, 0x76, 0xa6,
-	0xe8, 0xb9, 0xb6, 0xc7, 0x47, 0xff, 0xaa, 0xbd, 0xb6, 0x67, 0x56, 0x5b, 0xd5, 0x2a, 0x67, 0x16,
-	0xd6, 0x9a, 0xd4, 0xc7, 0xad, 0x52, 0xad, 0xc4, 0xc2, 0x79, 0xf5, 0xc8, 0x0b, 0xbc, 0xee, 0xcd,
-	0x8d, 0x0f, 0x66, 0x9f, 0x61, 0x59, 0x76, 0xfd, 0x68, 0xc8, 0x09, 0xac, 0xb8, 0x90, 0x58, 0x89,
-	0x8e, 0xab, 0x38, 0x48, 0x83, 0xcd, 0xe1, 0xe9, 0x51, 0x6e, 0x0b, 0x9e, 0x0b, 0x89, 0x65, 0xc7,
-	0x15, 0x8d, 0xf8, 0x64, 0x11, 0x02, 0xfb, 0x1d, 0xbb, 0xc6, 0xf8, 0x4e, 0x1a, 0x6c, 0x56, 0xd4,
-	0xd9, 0x56, 0x93, 0xec, 0xfb, 0x4d, 0xbc, 0x48, 0x83, 0x4d, 0x44, 0x9d, 0x9d, 0x7d, 0x84, 0xe3,
-	0xf7, 0x83, 0x6a, 0x50, 0x6b, 0x8a, 0x5f, 0x46, 0xd4, 0x86, 0xa4, 0x10, 0x5e, 0xaa, 0xba, 0x12,
-	0x5b, 0x7f, 0xf7, 0xf5, 0xea, 0xd7, 0xcf, 0x27, 0xcb, 0x77, 0xaa, 0x2e, 0xdf, 0xd2, 0xe5, 0xa5,
-	0xaa, 0xcb, 0x2d, 0x79, 0x0a, 0xfb, 0x5b, 0x66, 0x58, 0x1c, 0xa4, 0x0b, 0x87, 0xe0, 0xc7, 0x90,
-	0x3b, 0x48, 0xea, 0x42, 0xd9, 0x19, 0xdc, 0xfb, 0x93, 0x56, 0xf7, 0xaa, 0xd3, 0x48, 0x12, 0x58,
-	0x18, 0xd6, 0x4e, 0xdc, 0x91, 0xe3, 0xbe, 0x60, 0x2d, 0xb5, 0x22, 0x59, 0x43, 0xc8, 0x99, 0x90,
-	0xe8, 0x6b, 0x46, 0x74, 0xf2, 0xb2, 0x0b, 0x38, 0x7a, 0xc3, 0xba, 0x06, 0xe5, 0x6d, 0xe0, 0xee,
-	0x5a, 0x82, 0x8a, 0x0b, 0x69, 0x70, 0xd0, 0x0e, 0x72, 0x45, 0x0f, 0xad, 0x76, 0xee, 0xa5, 0xec,
-	0x04, 0x8e, 0xe7, 0xac, 0x13, 0x5b, 0x0c, 0x07, 0x7a, 0x6c, 0x2c, 0xae, 0xe3, 0x8b, 0xe8, 0xec,
-	0x9e, 0xfe, 0x08, 0x20, 0xfc, 0xe4, 0xfa, 0x23, 0xaf, 0xe0, 0x60, 0xea, 0x89, 0xac, 0xe7, 0x9e,
-	0x77, 0x67, 0x97, 0x3c, 0xfa, 0x4f, 0xf7, 0x05, 0xb2, 0x3d, 0xf2, 0x02, 0xc2, 0x0f, 0x86, 0x99,
-	0xd1, 0x5e, 0xf6, 0xdb, 0xce, 0xe7, 0x6d, 0xe7, 0x67, 0x76, 0xdb, 0xc9, 0xfd, 0xdc, 0x3e, 0x13,
-	0x5f, 0xcc, 0xff, 0x9a, 0xed, 0x91, 0x97, 0x10, 0x7a, 0x56, 0xf2, 0x70, 0xce, 0xbd, 0x33, 0x91,
-	0x64, 0xfd, 0xaf, 0x3c, 0x57, 0xac, 0x43, 0x97, 0xff, 0xf9, 0xef, 0x00, 0x00, 0x00, 0xff, 0xff,
-	0xb8, 0xdc, 0x3a, 0x7b, 0xcf, 0x02, 0x00, 0x00,
+	// 559 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x95, 0x93, 0xcd, 0x6e, 0xd3, 0x40,
+	0x10, 0xc7, 0x09, 0x49, 0x1c, 0x7b, 0xd2, 0xb4, 0x62, 0xd5, 0x86, 0xc8, 0x95, 0x68, 0xf1, 0xa1,
+	0x2a, 0x1c, 0x6c, 0x29, 0x15, 0x48, 0x48, 0x9c, 0xa0, 0x54, 0x0a, 0x50, 0x51, 0x2d, 0x45, 0x1c,
+	0x38, 0x44, 0x8e, 0x33, 0x36, 0x6e, 0x12, 0xaf, 0xbb, 0xbb, 0x01, 0x85, 0x27, 0xe0, 0x29, 0x39,
+	0xf0, 0x04, 0x3c, 0x02, 0xfb, 0x61, 0xb7, 0xa4, 0x15, 0x12, 0x1c, 0x2c, 0xcf, 0xfe, 0x66, 0x67,
+	0xe7, 0x3f, 0x33, 0xbb, 0x70, 0x20, 0x90, 0x7f, 0x41, 0x1e, 0x95, 0xb3, 0x2c, 0xfa, 0xca, 0xf8,
+	0x4c, 0x99, 0xf6, 0x37, 0xd6, 0x8e, 0x3c, 0xc1, 0xb0, 0xe4, 0x4c, 0x32, 0xe2, 0x58, 0xea, 0x6f,
+	0x27, 0xf3, 0x1c, 0x0b, 0x19, 0x95, 0xa9, 0xd0, 0x9f, 0xf5, 0x5e, 0xd3, 0x52, 0xe8, 0xaf, 0xa6,
+	0x19, 0xcb, 0x98, 0x31, 0x23, 0x6d, 0x55, 0x74, 0x37, 0x63, 0x2c, 0x9b, 0x63, 0x64, 0x56, 0x93,
+	0x65, 0x1a, 0xe1, 0xa2, 0x94, 0x2b, 0xeb, 0x0c, 0x3e, 0x41, 0x7b, 0x54, 0x94, 0x4b, 0x49, 0x1e,
+	0x83, 0x97, 0xe6, 0x73, 0x1c, 0xe7, 0x45, 0xca, 0x06, 0x8d, 0xfd, 0xc6, 0x61, 0x77, 0xd8, 0x0b,
+	0x75, 0xc2, 0x13, 0x45, 0x47, 0x0a, 0x52, 0x37, 0xad, 0x2c, 0x42, 0xa0, 0x55, 0xc4, 0x0b, 0x1c,
+	0xdc, 0x55, 0xdb, 0x3c, 0x6a, 0x6c, 0xcd, 0xe6, 0xf1, 0xb7, 0xd5, 0xa0, 0xa9, 0x98, 0x4b, 0x8d,
+	0x1d, 0x70, 0xd8, 0x3c, 0xe3, 0x2c, 0x41, 0x21, 0x28, 0x5e, 0x2e, 0x51, 0x48, 0xb2, 0x0f, 0xce,
+	0x05, 0x9b, 0x8c, 0xf3, 0xa9, 0x8d, 0x7d, 0xe1, 0xfd, 0xfc, 0xb1, 0xd7, 0x7e, 0xcd, 0x26, 0xa3,
+	0x63, 0xda, 0x56, 0x8e, 0xd1, 0x94, 0x3c, 0x84, 0xd6, 0x34, 0x96, 0xb1, 0x92, 0xd0, 0x34, 0x12,
+	0x6c, 0x1b, 0x42, 0x23, 0x92, 0x1a, 0x17, 0xd9, 0x05, 0x2f, 0x89, 0x93, 0xcf, 0x38, 0x9e, 0xa1,
+	0xcd, 0xe7, 0x51, 0xd7, 0x80, 0x37, 0xb8, 0x0a, 0xbe, 0x37, 0x60, 0xeb, 0x2a, 0xa9, 0x28, 0x59,
+	0x21, 0x90, 0xf8, 0xd0, 0x94, 0x71, 0x56, 0x55, 0xe5, 0x9a, 0xaa, 0xce, 0xe3, 0x8c, 0x6a, 0x48,
+	0xfa, 0xe0, 0xa4, 0xb1, 0x2a, 0xcc, 0x2a, 0x72, 0x69, 0xb5, 0xd2, 0x9c, 0x63, 0x2c, 0x58, 0x51,
+	0x65, 0xa8, 0x56, 0xe4, 0x11, 0x74, 0x16, 0x28, 0x79, 0x9e, 0x88, 0x41, 0xcb, 0x48, 0xdc, 0x0a,
+	0xf5, 0x00, 0x3e, 0xa8, 0xe9, 0x9d, 0x1a, 0x4e, 0x6b, 0x7f, 0x70, 0x0e, 0xbd, 0x97, 0x71, 0x91,
+	0xe0, 0xfc, 0x7f, 0xaa, 0xdf, 0xd0, 0x25, 0x8e, 0x55, 0xab, 0x25, 0x72, 0x61, 0xba, 0xe0, 0xd1,
+	0xae, 0x66, 0x27, 0x16, 0x05, 0x14, 0x36, 0xeb, 0x53, 0xab, 0xf2, 0x06, 0xd0, 0x11, 0xcb, 0x44,
+	0x57, 0x6c, 0x4a, 0x74, 0x69, 0xbd, 0x24, 0x07, 0xe0, 0xaa, 0xd0, 0xe5, 0xe2, 0x3a, 0x65, 0x57,
+	0xa5, 0xec, 0x1c, 0x6b, 0xa6, 0x92, 0x76, 0x8c, 0x73, 0x34, 0x0d, 0xce, 0x60, 0xe3, 0x14, 0x79,
+	0x86, 0xb7, 0x85, 0x36, 0xfe, 0x22, 0xf4, 0x01, 0xb4, 0x25, 0x47, 0x14, 0xea, 0xd8, 0xe6, 0x5a,
+	0x53, 0x2d, 0x0e, 0xde, 0x42, 0xaf, 0x3a, 0xf1, 0x1f, 0x66, 0xb0, 0x07, 0x2d, 0x1d, 0x65, 0x24,
+	0x76, 0x87, 0x5d, 0xe3, 0x7c, 0x37, 0xb9, 0xc0, 0x44, 0x4d, 0x5c, 0x3b, 0x86, 0xbf, 0x1a, 0xe0,
+	0x7c, 0x34, 0x17, 0x81, 0x3c, 0x87, 0x4e, 0x35, 0x5e, 0xd2, 0xaf, 0x2f, 0xc7, 0xfa, 0x25, 0xf3,
+	0xef, 0xdf, 0xe2, 0x56, 0x43, 0x70, 0x87, 0x3c, 0x01, 0xe7, 0xbd, 0x54, 0x45, 0xeb, 0x60, 0xfb,
+	0x2c, 0xc2, 0xfa, 0x59, 0x84, 0xaf, 0xf4, 0xb3, 0xf0, 0xef, 0x99, 0x71, 0xda, 0x64, 0x76, 0xab,
+	0x0a, 0x7b, 0x06, 0x8e, 0xed, 0x39, 0xd9, 0xa9, 0xcf, 0x5e, 0x9b, 0xac, 0xdf, 0xbf, 0x89, 0xaf,
+	0x32, 0x3e, 0x85, 0xb6, 0x69, 0x04, 0xd9, 0xae, 0xb7, 0xfc, 0xd9, 0x69, 0x7f, 0xe7, 0x06, 0xad,
+	0xe3, 0x26, 0x8e, 0xd1, 0x75, 0xf4, 0x1b, 0x02, 0x51, 0x58, 0x39, 0x30, 0x04, 0x00, 0x00,
 }
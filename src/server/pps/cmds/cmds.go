@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"os/exec"
 	"os/user"
 	"sort"
 	"strings"
@@ -17,6 +18,7 @@ import (
 
 	"github.com/fsouza/go-dockerclient"
 	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
 	pach "github.com/pachyderm/pachyderm/src/client"
 	"github.com/pachyderm/pachyderm/src/client/pkg/uuid"
 	ppsclient "github.com/pachyderm/pachyderm/src/client/pps"
@@ -95,16 +97,46 @@ func newPipelineManifestReader(path string) (result *pipelineManifestReader, ret
 	return result, nil
 }
 
-func (r *pipelineManifestReader) nextCreatePipelineRequest() (*ppsclient.CreatePipelineRequest, error) {
+func (r *pipelineManifestReader) nextCreatePipelineRequest(strict bool) (*ppsclient.CreatePipelineRequest, error) {
 	var result ppsclient.CreatePipelineRequest
+	if err := unmarshalSpec(r.decoder, &result, strict); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (r *pipelineManifestReader) nextTransactionOp() (*ppsclient.TransactionOp, error) {
+	var result ppsclient.TransactionOp
 	if err := jsonpb.UnmarshalNext(r.decoder, &result); err != nil {
 		return nil, err
 	}
 	return &result, nil
 }
 
+// unmarshalSpec unmarshals the next spec in decoder into spec. Specs with
+// fields that spec doesn't have, which are most likely misspelled, are
+// rejected if strict is set, and otherwise the fields are ignored with a
+// warning.
+func unmarshalSpec(decoder *json.Decoder, spec proto.Message, strict bool) error {
+	var raw json.RawMessage
+	if err := decoder.Decode(&raw); err != nil {
+		return err
+	}
+	err := jsonpb.Unmarshal(bytes.NewReader(raw), spec)
+	if err == nil || !strings.HasPrefix(err.Error(), "unknown field ") {
+		return err
+	}
+	if strict {
+		return fmt.Errorf("%v, pass --strict=false to ignore unknown fields", err)
+	}
+	fmt.Printf("WARNING: %v will be ignored.\n", err)
+	spec.Reset()
+	unmarshaler := &jsonpb.Unmarshaler{AllowUnknownFields: true}
+	return unmarshaler.Unmarshal(bytes.NewReader(raw), spec)
+}
+
 // Cmds returns a slice containing pps commands.
-func Cmds(address string, noMetrics *bool) ([]*cobra.Command, error) {
+func Cmds(noMetrics *bool) ([]*cobra.Command, error) {
 	metrics := !*noMetrics
 	marshaller := &jsonpb.Marshaler{Indent: "  "}
 
@@ -121,6 +153,11 @@ parent job and the commit it creates will use the parent job's commit as a
 parent.
 If the job fails the commit it creates will not be finished.
 The increase the throughput of a job increase the Shard paremeter.
+
+A job starts in the starting state, and is running once it has workers. It
+finishes as a success, a failure, or stopped (see stop-job), and never leaves
+the state it finished in. A job that can't be run for 30 minutes, because its
+workers have been lost, fails, and inspect-job shows why.
 `,
 		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
 			return nil
@@ -144,12 +181,14 @@ The increase the throughput of a job increase the Shard paremeter.
 	var registry string
 	var username string
 	var password string
+	var dryRun bool
+	var strict bool
 	createJob := &cobra.Command{
 		Use:   "create-job -f job.json",
 		Short: "Create a new job. Returns the id of the created job.",
 		Long:  fmt.Sprintf("Create a new job from a spec, the spec looks like this\n%s", exampleCreateJobRequest),
 		Run: cmdutil.RunFixedArgs(0, func(args []string) (retErr error) {
-			client, err := pach.NewMetricsClientFromAddress(address, metrics, "user")
+			client, err := pach.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return err
 			}
@@ -183,7 +222,7 @@ The increase the throughput of a job increase the Shard paremeter.
 			}
 			var request ppsclient.CreateJobRequest
 			decoder := json.NewDecoder(jobReader)
-			if err := jsonpb.UnmarshalNext(decoder, &request); err != nil {
+			if err := unmarshalSpec(decoder, &request, strict); err != nil {
 				return sanitizeErr(err)
 			}
 			if len(request.Inputs) != 0 {
@@ -196,6 +235,16 @@ The increase the throughput of a job increase the Shard paremeter.
 				}
 				request.Transform.Image = pushedImage
 			}
+			if dryRun {
+				dryRunInfo, err := client.PpsAPIClient.DryRunJob(
+					context.Background(),
+					&request,
+				)
+				if err != nil {
+					return sanitizeErr(err)
+				}
+				return printDryRunInfo(dryRunInfo)
+			}
 			job, err := client.PpsAPIClient.CreateJob(
 				context.Background(),
 				&request,
@@ -212,6 +261,8 @@ The increase the throughput of a job increase the Shard paremeter.
 	createJob.Flags().StringVarP(&registry, "registry", "r", "docker.io", "The registry to push images to.")
 	createJob.Flags().StringVarP(&username, "username", "u", "", "The username to push images as, defaults to your OS username.")
 	createJob.Flags().StringVarP(&password, "password", "", "", "Your password for the registry being pushed to.")
+	createJob.Flags().BoolVar(&dryRun, "dry-run", false, "Validate the job, and print it and the datums it would process, without creating it.")
+	createJob.Flags().BoolVar(&strict, "strict", true, "Reject specs with unknown fields, which are most likely misspelled, rather than ignoring the fields.")
 
 	var block bool
 	inspectJob := &cobra.Command{
@@ -219,7 +270,7 @@ The increase the throughput of a job increase the Shard paremeter.
 		Short: "Return info about a job.",
 		Long:  "Return info about a job.",
 		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
-			client, err := pach.NewMetricsClientFromAddress(address, metrics, "user")
+			client, err := pach.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return err
 			}
@@ -256,7 +307,7 @@ Examples:
 	$ pachctl list-job -p foo bar/YYY
 ` + codeend,
 		Run: func(cmd *cobra.Command, args []string) {
-			client, err := pach.NewMetricsClientFromAddress(address, metrics, "user")
+			client, err := pach.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				cmdutil.ErrorAndExit("error from InspectJob: %v", sanitizeErr(err))
 			}
@@ -267,7 +318,8 @@ Examples:
 				cmdutil.ErrorAndExit("error from InspectJob: %v", sanitizeErr(err))
 			}
 
-			jobInfos, err := client.ListJob(pipelineName, commits)
+			// only the fields that are printed are needed
+			jobInfos, err := client.ListJobNoFull(pipelineName, commits)
 			if err != nil {
 				cmdutil.ErrorAndExit("error from InspectJob: %v", sanitizeErr(err))
 			}
@@ -288,12 +340,74 @@ Examples:
 	}
 	listJob.Flags().StringVarP(&pipelineName, "pipeline", "p", "", "Limit to jobs made by pipeline.")
 
+	var pipelines cmdutil.RepeatedStringArg
+	flushJob := &cobra.Command{
+		Use:   "flush-job commit [commit ...]",
+		Short: "Wait for all jobs caused by the specified commits to finish and return them.",
+		Long: `Wait for all jobs caused by the specified commits to finish and return them.
+
+This includes the jobs caused by the output commits of those jobs, and so on.
+If any of the jobs fail, flush-job exits with an error once all of the jobs
+have finished.
+
+Examples:
+
+` + codestart + `# return jobs caused by foo/XXX and bar/YYY
+$ pachctl flush-job foo/XXX bar/YYY
+
+# return jobs caused by foo/XXX leading to pipelines bar and baz
+$ pachctl flush-job foo/XXX -p bar -p baz
+` + codeend,
+		Run: cmdutil.Run(func(args []string) error {
+			commits, err := cmdutil.ParseCommits(args)
+			if err != nil {
+				return err
+			}
+
+			client, err := pach.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+
+			jobIter, err := client.FlushJob(commits, pipelines)
+			if err != nil {
+				return err
+			}
+			defer jobIter.Close()
+
+			writer := tabwriter.NewWriter(os.Stdout, 0, 1, 1, ' ', 0)
+			pretty.PrintJobHeader(writer)
+			var failed int
+			for {
+				jobInfo, err := jobIter.Next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					return sanitizeErr(err)
+				}
+				if jobInfo.State != ppsclient.JobState_JOB_SUCCESS {
+					failed++
+				}
+				pretty.PrintJobInfo(writer, jobInfo)
+			}
+			if err := writer.Flush(); err != nil {
+				return err
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d job(s) did not succeed", failed)
+			}
+			return nil
+		}),
+	}
+	flushJob.Flags().VarP(&pipelines, "pipeline", "p", "Wait only for jobs leading to a specific set of pipelines")
+
 	deleteJob := &cobra.Command{
 		Use:   "delete-job job-id",
 		Short: "Delete a job.",
 		Long:  "Delete a job.",
 		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
-			client, err := pach.NewMetricsClientFromAddress(address, metrics, "user")
+			client, err := pach.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return err
 			}
@@ -307,9 +421,15 @@ Examples:
 	stopJob := &cobra.Command{
 		Use:   "stop-job job-id",
 		Short: "Stop a job.",
-		Long:  "Stop a job.  The job will be stopped immediately.",
+		Long: `Stop a job.  The job will be stopped immediately.
+
+The user code of the datums that the job's workers are processing is sent
+SIGTERM, and then SIGKILL if it hasn't exited 30 seconds later. Those datums
+are shown as stopped by list-datum, and the job's progress counts the datums
+that had been processed before it was stopped. Jobs that have already finished
+can't be stopped.`,
 		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
-			client, err := pach.NewMetricsClientFromAddress(address, metrics, "user")
+			client, err := pach.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return err
 			}
@@ -325,7 +445,7 @@ Examples:
 		Short: "Restart a datum.",
 		Long:  "Restart a datum.",
 		Run: cmdutil.RunFixedArgs(2, func(args []string) error {
-			client, err := pach.NewMetricsClientFromAddress(address, metrics, "user")
+			client, err := pach.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return fmt.Errorf("error from GetLogs: %v", sanitizeErr(err))
 			}
@@ -350,12 +470,17 @@ Examples:
 	var (
 		jobID       string
 		commaInputs string // comma-separated list of input files of interest
+		datumID     string
 		raw         bool
+		follow      bool
+		tail        int64
 	)
 	getLogs := &cobra.Command{
 		Use:   "get-logs [--pipeline=<pipeline>|--job=<job id>]",
 		Short: "Return logs from a job.",
-		Long: `Return logs from a job.
+		Long: `Return logs from a job. The lines logged by all of the job's workers are
+merged in the order they were logged; use --raw to see the worker and datum
+that logged each line.
 
 Examples:
 
@@ -367,9 +492,16 @@ Examples:
 
 	# return logs emitted by the pipeline \"filter\" while processing /apple.txt and a file with the hash 123aef
 	$ pachctl get-logs --pipeline=filter --inputs=/apple.txt,123aef
+
+	# return logs emitted by job aedfa12aedf while processing one of its datums (see list-datum)
+	$ pachctl get-logs --job=aedfa12aedf --datum=<datum id>
+
+	# return the last 10 lines logged by each worker of the "filter" pipeline,
+	# and keep returning lines as they're logged, until interrupted
+	$ pachctl get-logs --pipeline=filter --tail=10 --follow
 ` + codeend,
 		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
-			client, err := pach.NewMetricsClientFromAddress(address, metrics, "user")
+			client, err := pach.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return fmt.Errorf("error from GetLogs: %v", sanitizeErr(err))
 			}
@@ -393,7 +525,7 @@ Examples:
 
 			// Issue RPC
 			marshaler := &jsonpb.Marshaler{}
-			iter := client.GetLogs(pipelineName, jobID, data)
+			iter := client.GetLogsWithOptions(pipelineName, jobID, data, datumID, follow, tail)
 			for iter.Next() {
 				var messageStr string
 				if raw {
@@ -420,7 +552,180 @@ Examples:
 		"this job (accepts job ID)")
 	getLogs.Flags().StringVar(&commaInputs, "inputs", "", "Filter for log lines "+
 		"generated while processing these files (accepts PFS paths or file hashes)")
+	getLogs.Flags().StringVar(&datumID, "datum", "", "Filter for log lines "+
+		"generated while processing this datum (accepts a datum ID from list-datum)")
 	getLogs.Flags().BoolVar(&raw, "raw", false, "Return log messages verbatim from server.")
+	getLogs.Flags().BoolVarP(&follow, "follow", "f", false, "Keep returning log "+
+		"lines as they're logged, until interrupted.")
+	getLogs.Flags().Int64VarP(&tail, "tail", "t", 0, "Only return the last "+
+		"this many lines logged by each worker (0 means all lines).")
+
+	listDatum := &cobra.Command{
+		Use:   "list-datum job-id",
+		Short: "Return the datums in a job.",
+		Long: `Return the datums in a job, with their input files and state.
+
+Only failed datums are recorded while a job runs, so the datums of a job which
+hasn't finished are shown as pending unless they've failed. Failed datums show
+the reason that the user code failed.
+
+Examples:
+
+	` + codestart + `# return the datums in job aedfa12aedf
+	$ pachctl list-datum aedfa12aedf
+
+	# return the datums in job aedfa12aedf as JSON
+	$ pachctl list-datum aedfa12aedf --raw
+` + codeend,
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			client, err := pach.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			datumInfos, err := client.ListDatum(args[0])
+			if err != nil {
+				return sanitizeErr(err)
+			}
+			if raw {
+				marshaller := &jsonpb.Marshaler{Indent: "  "}
+				for _, datumInfo := range datumInfos {
+					if err := marshaller.Marshal(os.Stdout, datumInfo); err != nil {
+						return err
+					}
+					fmt.Println()
+				}
+				return nil
+			}
+			writer := tabwriter.NewWriter(os.Stdout, 20, 1, 3, ' ', 0)
+			pretty.PrintDatumInfoHeader(writer)
+			for _, datumInfo := range datumInfos {
+				pretty.PrintDatumInfo(writer, datumInfo)
+			}
+			return writer.Flush()
+		}),
+	}
+	listDatum.Flags().BoolVar(&raw, "raw", false, "Print the DatumInfos as JSON.")
+
+	inspectDatum := &cobra.Command{
+		Use:   "inspect-datum job-id datum-id",
+		Short: "Return info about a datum.",
+		Long:  "Return info about a datum, datum-id is an ID returned by list-datum. This includes the input files of the datum, with their hashes and sizes, and how long the datum took to download, process and upload.",
+		Run: cmdutil.RunFixedArgs(2, func(args []string) error {
+			client, err := pach.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			datumInfo, err := client.InspectDatum(args[0], args[1])
+			if err != nil {
+				return sanitizeErr(err)
+			}
+			if raw {
+				marshaller := &jsonpb.Marshaler{Indent: "  "}
+				if err := marshaller.Marshal(os.Stdout, datumInfo); err != nil {
+					return err
+				}
+				fmt.Println()
+				return nil
+			}
+			return pretty.PrintDetailedDatumInfo(datumInfo)
+		}),
+	}
+	inspectDatum.Flags().BoolVar(&raw, "raw", false, "Print the DatumInfo as JSON.")
+
+	explainReprocessingCmd := &cobra.Command{
+		Use:   "explain-reprocessing pipeline-name job-id|repo/commit [previous-job-id]",
+		Short: "Explain which datums of a job are reprocessed, and why.",
+		Long: `Explain which datums of a job are reprocessed, and why.
+
+A pipeline's workers don't process a datum again if the same version of the
+pipeline has already processed the same input files, the output from before is
+reused instead. This compares the datums of a job with those of an earlier job
+of the pipeline, by default the one which started before it, and explains why
+each datum is or isn't reprocessed: the pipeline was updated, the datum's
+files are new or have changed, or the datum failed before. The job may be
+given by its ID, or by one of its input commits. Datums of the earlier job
+whose files aren't input to the job anymore are listed too.
+
+Examples:
+
+	` + codestart + `# explain which datums job aedfa12aedf of pipeline edges reprocesses
+	$ pachctl explain-reprocessing edges aedfa12aedf
+
+	# explain which datums the job triggered by commit 1234 in repo images reprocesses
+	$ pachctl explain-reprocessing edges images/1234
+
+	# compare job aedfa12aedf with job 5678
+	$ pachctl explain-reprocessing edges aedfa12aedf 5678
+` + codeend,
+		Run: cmdutil.RunBoundedArgs(2, 3, func(args []string) error {
+			client, err := pach.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			var jobInfo *ppsclient.JobInfo
+			if strings.Contains(args[1], "/") {
+				commits, err := cmdutil.ParseCommits(args[1:2])
+				if err != nil {
+					return err
+				}
+				commitInfo, err := client.InspectCommit(commits[0].Repo.Name, commits[0].ID)
+				if err != nil {
+					return sanitizeErr(err)
+				}
+				if jobInfo, err = inputJob(client, args[0], commitInfo.Commit.Repo.Name, commitInfo.Commit.ID); err != nil {
+					return sanitizeErr(err)
+				}
+			} else {
+				if jobInfo, err = client.InspectJob(args[1], false); err != nil {
+					return sanitizeErr(err)
+				}
+				if jobInfo.Pipeline == nil || jobInfo.Pipeline.Name != args[0] {
+					return fmt.Errorf("job %s isn't a job of pipeline %s", args[1], args[0])
+				}
+			}
+			var prevJobInfo *ppsclient.JobInfo
+			if len(args) == 3 {
+				if prevJobInfo, err = client.InspectJob(args[2], false); err != nil {
+					return sanitizeErr(err)
+				}
+				if prevJobInfo.Pipeline == nil || prevJobInfo.Pipeline.Name != args[0] {
+					return fmt.Errorf("job %s isn't a job of pipeline %s", args[2], args[0])
+				}
+			} else if prevJobInfo, err = previousJob(client, jobInfo); err != nil {
+				return sanitizeErr(err)
+			}
+			datumInfos, err := client.ListDatum(jobInfo.Job.ID)
+			if err != nil {
+				return sanitizeErr(err)
+			}
+			var prevDatumInfos []*ppsclient.DatumInfo
+			if prevJobInfo != nil {
+				fmt.Printf("Comparing job %s with job %s.\n", jobInfo.Job.ID, prevJobInfo.Job.ID)
+				if prevDatumInfos, err = client.ListDatum(prevJobInfo.Job.ID); err != nil {
+					return sanitizeErr(err)
+				}
+			} else {
+				fmt.Printf("Job %s is the first job of pipeline %s.\n", jobInfo.Job.ID, args[0])
+			}
+			explanations, removed := explainReprocessing(jobInfo, datumInfos, prevJobInfo, prevDatumInfos)
+			reprocessed := 0
+			for _, explanation := range explanations {
+				if explanation.reprocessed {
+					reprocessed++
+				}
+			}
+			fmt.Printf("%d of %d datums are reprocessed, %d datums of the earlier job were removed.\n\n", reprocessed, len(explanations), len(removed))
+			writer := tabwriter.NewWriter(os.Stdout, 20, 1, 3, ' ', 0)
+			pretty.PrintDatumExplanationHeader(writer)
+			for _, explanation := range explanations {
+				pretty.PrintDatumExplanation(writer, explanation.datumInfo, explanation.reprocessed, explanation.reason)
+			}
+			for _, datumInfo := range removed {
+				pretty.PrintDatumExplanation(writer, datumInfo, false, "removed, its files aren't input to the job")
+			}
+			return writer.Flush()
+		}),
+	}
 
 	pipeline := &cobra.Command{
 		Use:   "pipeline",
@@ -449,12 +754,12 @@ All jobs created by a pipeline will create commits in the pipeline's repo.
 			if err != nil {
 				return err
 			}
-			client, err := pach.NewMetricsClientFromAddress(address, metrics, "user")
+			client, err := pach.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return sanitizeErr(err)
 			}
 			for {
-				request, err := cfgReader.nextCreatePipelineRequest()
+				request, err := cfgReader.nextCreatePipelineRequest(strict)
 				if err == io.EOF {
 					break
 				} else if err != nil {
@@ -470,6 +775,19 @@ All jobs created by a pipeline will create commits in the pipeline's repo.
 					}
 					request.Transform.Image = pushedImage
 				}
+				if dryRun {
+					dryRunInfo, err := client.PpsAPIClient.DryRunPipeline(
+						context.Background(),
+						request,
+					)
+					if err != nil {
+						return sanitizeErr(err)
+					}
+					if err := printDryRunInfo(dryRunInfo); err != nil {
+						return err
+					}
+					continue
+				}
 				if _, err := client.PpsAPIClient.CreatePipeline(
 					context.Background(),
 					request,
@@ -486,22 +804,28 @@ All jobs created by a pipeline will create commits in the pipeline's repo.
 	createPipeline.Flags().StringVarP(&username, "username", "u", "", "The username to push images as, defaults to your OS username.")
 	createPipeline.Flags().StringVarP(&password, "password", "", "", "Your password for the registry being pushed to.")
 	createPipeline.Flags().StringVarP(&description, "description", "d", "", "A description of the repo.")
+	createPipeline.Flags().BoolVar(&dryRun, "dry-run", false, "Validate the pipeline, and print it and the datums its first job would process, without creating it.")
+	createPipeline.Flags().BoolVar(&strict, "strict", true, "Reject specs with unknown fields, which are most likely misspelled, rather than ignoring the fields.")
 
 	updatePipeline := &cobra.Command{
 		Use:   "update-pipeline -f pipeline.json",
 		Short: "Update an existing Pachyderm pipeline.",
-		Long:  fmt.Sprintf("Update a Pachyderm pipeline with a new %s", pipelineSpec),
+		Long: fmt.Sprintf(`Update a Pachyderm pipeline with a new %s
+
+Updating a running pipeline with the spec it already has does nothing: no new
+version of the pipeline is made, and its input isn't processed again. A
+stopped or failed pipeline is restarted by it.`, pipelineSpec),
 		Run: cmdutil.RunFixedArgs(0, func(args []string) (retErr error) {
 			cfgReader, err := newPipelineManifestReader(pipelinePath)
 			if err != nil {
 				return err
 			}
-			client, err := pach.NewMetricsClientFromAddress(address, metrics, "user")
+			client, err := pach.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return sanitizeErr(err)
 			}
 			for {
-				request, err := cfgReader.nextCreatePipelineRequest()
+				request, err := cfgReader.nextCreatePipelineRequest(strict)
 				if err == io.EOF {
 					break
 				} else if err != nil {
@@ -515,6 +839,19 @@ All jobs created by a pipeline will create commits in the pipeline's repo.
 					}
 					request.Transform.Image = pushedImage
 				}
+				if dryRun {
+					dryRunInfo, err := client.PpsAPIClient.DryRunPipeline(
+						context.Background(),
+						request,
+					)
+					if err != nil {
+						return sanitizeErr(err)
+					}
+					if err := printDryRunInfo(dryRunInfo); err != nil {
+						return err
+					}
+					continue
+				}
 				if _, err := client.PpsAPIClient.CreatePipeline(
 					context.Background(),
 					request,
@@ -530,13 +867,85 @@ All jobs created by a pipeline will create commits in the pipeline's repo.
 	updatePipeline.Flags().StringVarP(&registry, "registry", "r", "docker.io", "The registry to push images to.")
 	updatePipeline.Flags().StringVarP(&username, "username", "u", "", "The username to push images as, defaults to your OS username.")
 	updatePipeline.Flags().StringVarP(&password, "password", "", "", "Your password for the registry being pushed to.")
+	updatePipeline.Flags().BoolVar(&dryRun, "dry-run", false, "Validate the pipeline, and print it and the datums its first job would process, without updating it.")
+	updatePipeline.Flags().BoolVar(&strict, "strict", true, "Reject specs with unknown fields, which are most likely misspelled, rather than ignoring the fields.")
+
+	var editor string
+	editPipeline := &cobra.Command{
+		Use:   "edit-pipeline pipeline-name",
+		Short: "Edit the spec of an existing Pachyderm pipeline.",
+		Long: `Edit the spec of an existing Pachyderm pipeline in a text editor.
+
+The pipeline's current spec is opened in $EDITOR (or the editor given by
+--editor). When the editor exits, the edited spec is used to update the
+pipeline, exactly as if it had been passed to update-pipeline. If the spec
+isn't valid it is left in a temporary file so that it can be fixed and passed
+to update-pipeline.`,
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			client, err := pach.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			pipelineInfo, err := client.InspectPipeline(args[0])
+			if err != nil {
+				return err
+			}
+			spec, err := marshaller.MarshalToString(ppsclient.CreatePipelineRequestFromInfo(pipelineInfo))
+			if err != nil {
+				return err
+			}
+			f, err := ioutil.TempFile("", args[0])
+			if err != nil {
+				return err
+			}
+			specPath := f.Name()
+			if _, err := f.WriteString(spec + "\n"); err != nil {
+				f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+			if err := runEditor(editor, specPath); err != nil {
+				return err
+			}
+			editedSpec, err := ioutil.ReadFile(specPath)
+			if err != nil {
+				return err
+			}
+			if string(editedSpec) == spec+"\n" {
+				fmt.Println("Pipeline unchanged.")
+				return os.Remove(specPath)
+			}
+			cfgReader, err := newPipelineManifestReader(specPath)
+			if err != nil {
+				return err
+			}
+			request, err := cfgReader.nextCreatePipelineRequest(true)
+			if err != nil {
+				return fmt.Errorf("%v\nthe edited spec was left in %s", describeSyntaxError(err, cfgReader.buf), specPath)
+			}
+			if request.Pipeline == nil || request.Pipeline.Name != args[0] {
+				return fmt.Errorf("the name of the pipeline cannot be changed\nthe edited spec was left in %s", specPath)
+			}
+			request.Update = true
+			if _, err := client.PpsAPIClient.CreatePipeline(
+				context.Background(),
+				request,
+			); err != nil {
+				return fmt.Errorf("%v\nthe edited spec was left in %s", sanitizeErr(err), specPath)
+			}
+			return os.Remove(specPath)
+		}),
+	}
+	editPipeline.Flags().StringVar(&editor, "editor", "", "The editor to use, defaults to $EDITOR or vi.")
 
 	inspectPipeline := &cobra.Command{
 		Use:   "inspect-pipeline pipeline-name",
 		Short: "Return info about a pipeline.",
 		Long:  "Return info about a pipeline.",
 		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
-			client, err := pach.NewMetricsClientFromAddress(address, metrics, "user")
+			client, err := pach.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return err
 			}
@@ -556,7 +965,7 @@ All jobs created by a pipeline will create commits in the pipeline's repo.
 		Short: "Return info about all pipelines.",
 		Long:  "Return info about all pipelines.",
 		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
-			client, err := pach.NewMetricsClientFromAddress(address, metrics, "user")
+			client, err := pach.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return err
 			}
@@ -579,7 +988,7 @@ All jobs created by a pipeline will create commits in the pipeline's repo.
 		Short: "Delete a pipeline.",
 		Long:  "Delete a pipeline.",
 		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
-			client, err := pach.NewMetricsClientFromAddress(address, metrics, "user")
+			client, err := pach.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return err
 			}
@@ -596,7 +1005,7 @@ All jobs created by a pipeline will create commits in the pipeline's repo.
 		Short: "Restart a stopped pipeline.",
 		Long:  "Restart a stopped pipeline.",
 		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
-			client, err := pach.NewMetricsClientFromAddress(address, metrics, "user")
+			client, err := pach.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return err
 			}
@@ -612,7 +1021,7 @@ All jobs created by a pipeline will create commits in the pipeline's repo.
 		Short: "Stop a running pipeline.",
 		Long:  "Stop a running pipeline.",
 		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
-			client, err := pach.NewMetricsClientFromAddress(address, metrics, "user")
+			client, err := pach.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return err
 			}
@@ -623,17 +1032,105 @@ All jobs created by a pipeline will create commits in the pipeline's repo.
 		}),
 	}
 
+	var glob string
+	clearDatumCache := &cobra.Command{
+		Use:   "clear-datum-cache pipeline-name",
+		Short: "Make a pipeline process its datums again.",
+		Long: `Stop a pipeline reusing the outputs of its datums from earlier jobs, so that
+its next job processes them again, e.g. because its transform's output depends
+on something other than its input. The pipeline's spec is left as it is.
+
+Examples:
+
+` + codestart + `# make pipeline "foo" process all of its datums again
+$ pachctl clear-datum-cache foo
+
+# make pipeline "foo" process the datums with a .csv input file again
+$ pachctl clear-datum-cache foo --glob "*.csv"
+
+# process them now, rather than waiting for new input
+$ pachctl run-pipeline foo
+` + codeend,
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			client, err := pach.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			if err := client.ClearDatumCache(args[0], glob); err != nil {
+				cmdutil.ErrorAndExit("error from ClearDatumCache: %s", err.Error())
+			}
+			return nil
+		}),
+	}
+	clearDatumCache.Flags().StringVarP(&glob, "glob", "g", "", "Only clear the outputs of the datums with an input file whose path matches this glob.")
+
 	var specPath string
+	var triggerKey string
 	runPipeline := &cobra.Command{
-		Use:   "run-pipeline pipeline-name [-f job.json]",
+		Use:   "run-pipeline pipeline-name [repo/commit-or-branch ...] [-f job.json]",
 		Short: "Run a pipeline once.",
-		Long:  fmt.Sprintf("Run a pipeline once, optionally overriding some pipeline options by providing a spec.  The spec looks like this:\n%s", exampleRunPipelineSpec),
-		Run: cmdutil.RunFixedArgs(1, func(args []string) (retErr error) {
-			client, err := pach.NewMetricsClientFromAddress(address, metrics, "user")
+		Long: fmt.Sprintf(`Run a pipeline once on a specific set of input commits, without waiting
+for new commits to arrive. Inputs which aren't given a commit are run on the
+head of their branch. The resulting job is the same as one which the pipeline
+would have created, so its output commit has the input commits as provenance.
+
+Examples:
+
+`+codestart+`# run pipeline "foo" on the head of each of its input branches
+$ pachctl run-pipeline foo
+
+# run pipeline "foo" on commit "XXX" in its input repo "bar"
+$ pachctl run-pipeline foo bar/XXX
+
+# run pipeline "foo" on the head of branch "test" in its input repo "bar"
+$ pachctl run-pipeline foo bar/test
+
+# run pipeline "foo" once for the key "daily.2017-06-01", however many
+# times this is retried
+$ pachctl run-pipeline foo --trigger-key daily.2017-06-01
+`+codeend+`
+
+With --trigger-key, the pipeline is run once per key: if it's already been
+run with the key, the job that was created then is printed, and no new job is
+created. Schedulers such as Airflow can retry such runs safely, and wait for
+them with inspect-trigger.
+
+Alternatively, some pipeline options can be overridden by providing a spec.
+The spec looks like this:
+%s`, exampleRunPipelineSpec),
+		Run: cmdutil.Run(func(args []string) (retErr error) {
+			if len(args) < 1 {
+				return fmt.Errorf("pipeline-name is required")
+			}
+			client, err := pach.NewOnUserMachine(metrics, "user")
 			if err != nil {
 				return err
 			}
 
+			if specPath == "" {
+				commits, err := cmdutil.ParseCommits(args[1:])
+				if err != nil {
+					return err
+				}
+				var job *ppsclient.Job
+				if triggerKey != "" {
+					job, err = client.TriggerPipeline(args[0], triggerKey, commits)
+				} else {
+					job, err = client.RunPipeline(args[0], commits)
+				}
+				if err != nil {
+					return err
+				}
+				fmt.Println(job.ID)
+				return nil
+			}
+			if len(args) > 1 {
+				return fmt.Errorf("input commits cannot be given along with a spec")
+			}
+			if triggerKey != "" {
+				return fmt.Errorf("a trigger key cannot be given along with a spec")
+			}
+
 			request := &ppsclient.CreateJobRequest{
 				Pipeline: &ppsclient.Pipeline{
 					Name: args[0],
@@ -659,7 +1156,7 @@ All jobs created by a pipeline will create commits in the pipeline's repo.
 
 				specReader = io.TeeReader(specFile, &buf)
 				decoder := json.NewDecoder(specReader)
-				if err := jsonpb.UnmarshalNext(decoder, request); err != nil {
+				if err := unmarshalSpec(decoder, request, strict); err != nil {
 					return err
 				}
 			}
@@ -676,25 +1173,126 @@ All jobs created by a pipeline will create commits in the pipeline's repo.
 		}),
 	}
 	runPipeline.Flags().StringVarP(&specPath, "file", "f", "", "The file containing the run-pipeline spec, - reads from stdin.")
+	runPipeline.Flags().StringVarP(&triggerKey, "trigger-key", "k", "", "Run the pipeline once for this key, however many times the command is run.")
+	runPipeline.Flags().BoolVar(&strict, "strict", true, "Reject specs with unknown fields, which are most likely misspelled, rather than ignoring the fields.")
+
+	inspectTrigger := &cobra.Command{
+		Use:   "inspect-trigger pipeline-name trigger-key",
+		Short: "Return info about the job that a pipeline was triggered with a key to run.",
+		Long: `Return info about the job that a pipeline was triggered with a key to run, with
+run-pipeline --trigger-key.
+
+With --block, the command waits for the job to finish, and exits with an
+error if the job fails, so that schedulers can use it as a task's status.
+
+Examples:
+
+` + codestart + `# wait for the job that pipeline "foo" ran for the key "daily.2017-06-01"
+$ pachctl inspect-trigger foo daily.2017-06-01 --block
+` + codeend,
+		Run: cmdutil.RunFixedArgs(2, func(args []string) error {
+			client, err := pach.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			jobInfo, err := client.InspectTrigger(args[0], args[1], block)
+			if err != nil {
+				cmdutil.ErrorAndExit("error from InspectTrigger: %s", err.Error())
+			}
+			if err := pretty.PrintDetailedJobInfo(jobInfo); err != nil {
+				return err
+			}
+			if block && jobInfo.State != ppsclient.JobState_JOB_SUCCESS {
+				cmdutil.ErrorAndExit("job %s finished in state %s", jobInfo.Job.ID, jobInfo.State)
+			}
+			return nil
+		}),
+	}
+	inspectTrigger.Flags().BoolVarP(&block, "block", "b", false, "block until the job has either succeeded or failed")
+
+	var transactionPath string
+	runTransaction := &cobra.Command{
+		Use:   "run-transaction -f transaction.json",
+		Short: "Apply a set of PFS and PPS operations atomically.",
+		Long: `Apply a set of PFS and PPS operations atomically: if one of them fails, the
+ones that have been applied are rolled back. The commits that the transaction
+finishes are finished together, so a pipeline whose inputs are several of
+them runs a single job on all of them, rather than one per commit.
+
+The file holds the operations, each of which is a JSON object with one of
+the fields "start_commit", "finish_commit", "delete_file" and
+"create_pipeline", whose value is the request for that operation. Commits are
+started first, then all of the commits are finished, along with the files
+deleted from them, and finally pipelines are created. Files can only be
+deleted from commits that the transaction starts or finishes. The commits
+that the transaction started are printed.
+
+Examples:
+
+` + codestart + `# finish the open commits on the master branches of "images" and "labels",
+# which pipelines taking both as inputs will process in one job
+$ cat transaction.json
+{"finish_commit": {"commit": {"repo": {"name": "images"}, "id": "master"}}}
+{"finish_commit": {"commit": {"repo": {"name": "labels"}, "id": "master"}}}
+$ pachctl run-transaction -f transaction.json
+` + codeend,
+		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
+			opReader, err := newPipelineManifestReader(transactionPath)
+			if err != nil {
+				return err
+			}
+			var ops []*ppsclient.TransactionOp
+			for {
+				op, err := opReader.nextTransactionOp()
+				if err == io.EOF {
+					break
+				} else if err != nil {
+					return describeSyntaxError(err, opReader.buf)
+				}
+				ops = append(ops, op)
+			}
+			client, err := pach.NewOnUserMachine(metrics, "user")
+			if err != nil {
+				return err
+			}
+			transactionInfo, err := client.RunTransaction(ops)
+			if err != nil {
+				return err
+			}
+			for _, commit := range transactionInfo.Started {
+				fmt.Printf("%s/%s\n", commit.Repo.Name, commit.ID)
+			}
+			return nil
+		}),
+	}
+	runTransaction.Flags().StringVarP(&transactionPath, "file", "f", "-", "The file containing the transaction's operations, it can be a url or local file. - reads from stdin.")
 
 	var result []*cobra.Command
 	result = append(result, job)
 	result = append(result, createJob)
 	result = append(result, inspectJob)
 	result = append(result, listJob)
+	result = append(result, flushJob)
 	result = append(result, deleteJob)
 	result = append(result, stopJob)
 	result = append(result, restartDatum)
+	result = append(result, listDatum)
+	result = append(result, inspectDatum)
+	result = append(result, explainReprocessingCmd)
 	result = append(result, getLogs)
 	result = append(result, pipeline)
 	result = append(result, createPipeline)
 	result = append(result, updatePipeline)
+	result = append(result, editPipeline)
 	result = append(result, inspectPipeline)
 	result = append(result, listPipeline)
 	result = append(result, deletePipeline)
 	result = append(result, startPipeline)
 	result = append(result, stopPipeline)
+	result = append(result, clearDatumCache)
 	result = append(result, runPipeline)
+	result = append(result, inspectTrigger)
+	result = append(result, runTransaction)
 	return result, nil
 }
 
@@ -734,6 +1332,41 @@ func sanitizeErr(err error) error {
 	return errors.New(grpc.ErrorDesc(err))
 }
 
+// runEditor opens filePath in editor, which defaults to $EDITOR, and waits
+// for it to exit.
+func runEditor(editor string, filePath string) error {
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	// $EDITOR may contain arguments, e.g. "emacs -nw"
+	args := strings.Fields(editor)
+	cmd := exec.Command(args[0], append(args[1:], filePath)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("error running editor %s: %v", editor, err)
+	}
+	return nil
+}
+
+// printDryRunInfo prints what a dry run would have created, as JSON, and
+// its warnings on stderr.
+func printDryRunInfo(dryRunInfo *ppsclient.DryRunInfo) error {
+	for _, warning := range dryRunInfo.Warnings {
+		fmt.Fprintf(os.Stderr, "WARNING: %s\n", warning)
+	}
+	marshaller := &jsonpb.Marshaler{Indent: "  "}
+	if err := marshaller.Marshal(os.Stdout, dryRunInfo); err != nil {
+		return err
+	}
+	fmt.Println()
+	return nil
+}
+
 // pushImage pushes an image as registry/user/image. Registry and user can be
 // left empty.
 func pushImage(registry string, username string, password string, image string) (string, error) {
@@ -1,6 +1,7 @@
 package cmds
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -16,6 +17,7 @@ import (
 	"github.com/fsouza/go-dockerclient"
 	"github.com/pachyderm/pachyderm/src/client/pkg/config"
 	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	ppsclient "github.com/pachyderm/pachyderm/src/client/pps"
 	"github.com/spf13/cobra"
 )
 
@@ -38,15 +40,20 @@ const badJSON2 = `
 func rootCmd() *cobra.Command {
 	rootCmd := &cobra.Command{}
 	noMetrics := false
-	cmds, _ := Cmds("0.0.0.0:30650", &noMetrics)
+	cmds, _ := Cmds(&noMetrics)
 	for _, cmd := range cmds {
 		rootCmd.AddCommand(cmd)
 	}
 	return rootCmd
 }
 
-func testJSONSyntaxErrorsReported(inputFile string, inputFileValue string, inputCommand []string) {
-	ioutil.WriteFile(inputFile, []byte(inputFileValue), 0644)
+// badJSONDirEnv names the temporary directory that testBadJSON's
+// subprocess writes its input file to, and runs its command in.
+const badJSONDirEnv = "BAD_JSON_DIR"
+
+func testJSONSyntaxErrorsReported(t *testing.T, inputFile string, inputFileValue string, inputCommand []string) {
+	require.NoError(t, os.Chdir(os.Getenv(badJSONDirEnv)))
+	require.NoError(t, ioutil.WriteFile(inputFile, []byte(inputFileValue), 0644))
 	os.Args = inputCommand
 	rootCmd().Execute()
 }
@@ -59,24 +66,23 @@ func testBadJSON(t *testing.T, testName string, inputFile string, inputFileValue
 	require.NoError(t, err)
 
 	if os.Getenv("BE_CRASHER") == "1" {
-		testJSONSyntaxErrorsReported(inputFile, inputFileValue, inputCommand)
+		testJSONSyntaxErrorsReported(t, inputFile, inputFileValue, inputCommand)
 		return
 	}
 
+	dir, err := ioutil.TempDir("", testName)
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
 	b := backoff.NewExponentialBackOff()
 	b.MaxElapsedTime = 120 * time.Second
 	var actualOutput []byte
 	backoff.RetryNotify(func() error {
 
 		cmd := exec.Command(os.Args[0], fmt.Sprintf("-test.run=%v", testName))
-		cmd.Env = append(os.Environ(), "BE_CRASHER=1")
+		cmd.Env = append(os.Environ(), "BE_CRASHER=1", badJSONDirEnv+"="+dir)
 		out, err := cmd.CombinedOutput()
 
-		// Do our cleanup here, since we have an exit 1 in the actual run:
-		wd, _ := os.Getwd()
-		fileName := filepath.Join(wd, inputFile)
-		os.Remove(fileName)
-
 		require.YesError(t, err)
 		if e, ok := err.(*exec.ExitError); ok && !e.Success() {
 			if strings.Contains(string(out), "connection error") {
@@ -152,21 +158,20 @@ invalid character 'a' looking for beginning of object key string
 
 	if os.Getenv("BE_CRASHER") == "1" {
 		os.Args = rawCmd
-		ioutil.WriteFile("bad2.json", []byte(badJSON2), 0644)
-		os.Stdin, _ = os.Open("bad2.json")
+		inputFile := filepath.Join(os.Getenv(badJSONDirEnv), "bad2.json")
+		require.NoError(t, ioutil.WriteFile(inputFile, []byte(badJSON2), 0644))
+		os.Stdin, _ = os.Open(inputFile)
 		rootCmd().Execute()
 		return
 	}
 
+	dir, err := ioutil.TempDir("", testName)
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
 	cmd := exec.Command(os.Args[0], fmt.Sprintf("-test.run=%v", testName))
-	cmd.Env = append(os.Environ(), "BE_CRASHER=1")
+	cmd.Env = append(os.Environ(), "BE_CRASHER=1", badJSONDirEnv+"="+dir)
 	out, err := cmd.CombinedOutput()
 
-	// Put our cleanup here, since we have an exit 1 in the actual run:
-
-	wd, _ := os.Getwd()
-	os.Remove(filepath.Join(wd, "bad2.json"))
-
 	require.YesError(t, err)
 	if e, ok := err.(*exec.ExitError); ok && !e.Success() {
 		require.Equal(t, descriptiveOutput, string(out))
@@ -209,3 +214,24 @@ func TestPushImages(t *testing.T) {
 	os.Args = []string{"pachctl", "create-pipeline", "--push-images", "-f", "test-push-images.json"}
 	require.NoError(t, rootCmd().Execute())
 }
+
+func TestUnmarshalSpecStrict(t *testing.T) {
+	spec := `{"pipeline": {"name": "foo"}, "parallelism_spce": {"constant": 4}}`
+
+	request := &ppsclient.CreatePipelineRequest{}
+	err := unmarshalSpec(json.NewDecoder(strings.NewReader(spec)), request, true)
+	require.YesError(t, err)
+	require.True(t, strings.Contains(err.Error(), `"parallelism_spce"`))
+
+	// unknown fields are also found in nested messages
+	request = &ppsclient.CreatePipelineRequest{}
+	err = unmarshalSpec(json.NewDecoder(strings.NewReader(`{"pipeline": {"nmae": "foo"}}`)), request, true)
+	require.YesError(t, err)
+	require.True(t, strings.Contains(err.Error(), `"nmae"`))
+
+	// without strict, the rest of the spec is read
+	request = &ppsclient.CreatePipelineRequest{}
+	require.NoError(t, unmarshalSpec(json.NewDecoder(strings.NewReader(spec)), request, false))
+	require.Equal(t, "foo", request.Pipeline.Name)
+	require.Nil(t, request.ParallelismSpec)
+}
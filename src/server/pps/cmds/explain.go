@@ -0,0 +1,191 @@
+package cmds
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gogo/protobuf/proto"
+	pach "github.com/pachyderm/pachyderm/src/client"
+	ppsclient "github.com/pachyderm/pachyderm/src/client/pps"
+)
+
+// datumExplanation says whether a datum of a job is processed, or whether
+// the output of an earlier job is reused for it, and why.
+type datumExplanation struct {
+	datumInfo   *ppsclient.DatumInfo
+	reprocessed bool
+	reason      string
+}
+
+// explainReprocessing compares the datums of jobInfo with those of
+// prevJobInfo, an earlier job of the same pipeline, which may be nil. Workers
+// skip a datum if a job with the same datum hash, see specChange, has already
+// processed the same input files, so a datum is reprocessed if the pipeline
+// was changed in between, if its files are new or changed, or if it failed.
+// The datums of prevJobInfo which jobInfo doesn't have are returned too, their
+// output isn't in jobInfo's output commit.
+func explainReprocessing(jobInfo *ppsclient.JobInfo, datumInfos []*ppsclient.DatumInfo, prevJobInfo *ppsclient.JobInfo, prevDatumInfos []*ppsclient.DatumInfo) ([]*datumExplanation, []*ppsclient.DatumInfo) {
+	var explanations []*datumExplanation
+	if prevJobInfo == nil {
+		for _, datumInfo := range datumInfos {
+			explanations = append(explanations, &datumExplanation{
+				datumInfo:   datumInfo,
+				reprocessed: true,
+				reason:      "there's no previous job",
+			})
+		}
+		return explanations, nil
+	}
+	prevDatums := make(map[string]*ppsclient.DatumInfo)
+	prevHashes := make(map[string][]byte)
+	for _, datumInfo := range prevDatumInfos {
+		prevDatums[datumInfo.ID] = datumInfo
+		for _, fileInfo := range datumInfo.Data {
+			prevHashes[datumFileKey(fileInfo.File.Commit.Repo.Name, fileInfo.File.Path)] = fileInfo.Hash
+		}
+	}
+	specChange := specChange(jobInfo, prevJobInfo)
+	ids := make(map[string]bool)
+	for _, datumInfo := range datumInfos {
+		ids[datumInfo.ID] = true
+		explanation := &datumExplanation{
+			datumInfo:   datumInfo,
+			reprocessed: true,
+		}
+		explanations = append(explanations, explanation)
+		if specChange != "" {
+			explanation.reason = specChange
+			continue
+		}
+		if prevDatumInfo, ok := prevDatums[datumInfo.ID]; ok {
+			if prevDatumInfo.State == ppsclient.DatumState_DATUM_FAILED {
+				explanation.reason = fmt.Sprintf("it failed in job %s: %s", prevJobInfo.Job.ID, prevDatumInfo.Reason)
+				continue
+			}
+			explanation.reprocessed = false
+			explanation.reason = "its files are unchanged"
+			continue
+		}
+		var changedFiles []string
+		var newFiles []string
+		for _, fileInfo := range datumInfo.Data {
+			file := datumFileKey(fileInfo.File.Commit.Repo.Name, fileInfo.File.Path)
+			hash, ok := prevHashes[file]
+			switch {
+			case !ok:
+				newFiles = append(newFiles, file)
+			case !bytes.Equal(hash, fileInfo.Hash):
+				changedFiles = append(changedFiles, file)
+			}
+		}
+		var reasons []string
+		if len(changedFiles) > 0 {
+			reasons = append(reasons, "changed files: "+strings.Join(changedFiles, ", "))
+		}
+		if len(newFiles) > 0 {
+			reasons = append(reasons, "new files: "+strings.Join(newFiles, ", "))
+		}
+		if len(reasons) == 0 {
+			reasons = append(reasons, "its files were in a different datum")
+		}
+		explanation.reason = strings.Join(reasons, "; ")
+	}
+	var removed []*ppsclient.DatumInfo
+	for _, datumInfo := range prevDatumInfos {
+		if !ids[datumInfo.ID] {
+			removed = append(removed, datumInfo)
+		}
+	}
+	return explanations, removed
+}
+
+// specChange returns why all of jobInfo's datums are reprocessed because of
+// a change to its pipeline since prevJobInfo, or "" if there wasn't one.
+func specChange(jobInfo *ppsclient.JobInfo, prevJobInfo *ppsclient.JobInfo) string {
+	if jobInfo.DatumHashVersion != prevJobInfo.DatumHashVersion {
+		return fmt.Sprintf("the pipeline's datums are hashed with version %d of the datum hash, rather than %d", jobInfo.DatumHashVersion, prevJobInfo.DatumHashVersion)
+	}
+	// version 0 of the datum hash hashes the pipeline's ID and version,
+	// later versions don't
+	if jobInfo.DatumHashVersion == 0 {
+		switch {
+		case jobInfo.PipelineID != prevJobInfo.PipelineID:
+			return "the pipeline was recreated"
+		case jobInfo.PipelineVersion != prevJobInfo.PipelineVersion:
+			reason := fmt.Sprintf("the pipeline was updated from version %d to %d", prevJobInfo.PipelineVersion, jobInfo.PipelineVersion)
+			if !proto.Equal(jobInfo.Transform, prevJobInfo.Transform) {
+				reason += ", which changed its transform"
+			}
+			return reason
+		}
+	}
+	switch {
+	case !proto.Equal(jobInfo.Transform, prevJobInfo.Transform):
+		return "the transform changed"
+	case jobInfo.Salt != prevJobInfo.Salt:
+		return "the pipeline's salt changed"
+	}
+	return ""
+}
+
+func datumFileKey(repo string, path string) string {
+	return repo + ":" + path
+}
+
+// previousJob returns the job of jobInfo's pipeline which started last before
+// jobInfo did, or nil if there isn't one.
+func previousJob(client *pach.APIClient, jobInfo *ppsclient.JobInfo) (*ppsclient.JobInfo, error) {
+	jobInfos, err := client.ListJob(jobInfo.Pipeline.Name, nil)
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(ByCreationTime(jobInfos))
+	var result *ppsclient.JobInfo
+	for _, candidate := range jobInfos {
+		if candidate.Job.ID == jobInfo.Job.ID {
+			break
+		}
+		result = candidate
+	}
+	return result, nil
+}
+
+// inputJob returns the job of pipeline which has commitID in repo as input.
+// If there's more than one, e.g. because the job was rerun, the last one to
+// start is returned.
+func inputJob(client *pach.APIClient, pipeline string, repo string, commitID string) (*ppsclient.JobInfo, error) {
+	jobInfos, err := client.ListJob(pipeline, nil)
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(ByCreationTime(jobInfos))
+	var result *ppsclient.JobInfo
+	for _, jobInfo := range jobInfos {
+		if hasInputCommit(jobInfo.Input, repo, commitID) {
+			result = jobInfo
+		}
+	}
+	if result == nil {
+		return nil, fmt.Errorf("no job of pipeline %s has %s/%s as input", pipeline, repo, commitID)
+	}
+	return result, nil
+}
+
+func hasInputCommit(input *ppsclient.Input, repo string, commitID string) bool {
+	switch {
+	case input == nil:
+		return false
+	case input.Atom != nil:
+		return input.Atom.Repo == repo && input.Atom.Commit == commitID
+	case input.SQL != nil:
+		return input.SQL.Repo == repo && input.SQL.Commit == commitID
+	}
+	for _, input := range append(input.Cross, input.Union...) {
+		if hasInputCommit(input, repo, commitID) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package cmds
+
+import (
+	"testing"
+
+	pach "github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	ppsclient "github.com/pachyderm/pachyderm/src/client/pps"
+)
+
+func TestExplainReprocessing(t *testing.T) {
+	datum := func(id string, state ppsclient.DatumState, files ...string) *ppsclient.DatumInfo {
+		datumInfo := &ppsclient.DatumInfo{ID: id, State: state}
+		for i := 0; i < len(files); i += 2 {
+			datumInfo.Data = append(datumInfo.Data, &pfs.FileInfo{
+				File: pach.NewFile("images", "master", files[i]),
+				Hash: []byte(files[i+1]),
+			})
+		}
+		return datumInfo
+	}
+	transform := &ppsclient.Transform{Cmd: []string{"sh"}}
+	prevJobInfo := &ppsclient.JobInfo{Job: pach.NewJob("prev"), PipelineID: "p", PipelineVersion: 1, Transform: transform}
+	prevDatumInfos := []*ppsclient.DatumInfo{
+		datum("a", ppsclient.DatumState_DATUM_SUCCESS, "/a", "1"),
+		datum("b", ppsclient.DatumState_DATUM_FAILED, "/b", "1"),
+		datum("c", ppsclient.DatumState_DATUM_SUCCESS, "/c", "1"),
+		datum("d", ppsclient.DatumState_DATUM_SUCCESS, "/d", "1"),
+	}
+	prevDatumInfos[1].Reason = "exit status 1"
+	jobInfo := &ppsclient.JobInfo{Job: pach.NewJob("job"), PipelineID: "p", PipelineVersion: 1, Transform: transform}
+	datumInfos := []*ppsclient.DatumInfo{
+		datum("a", ppsclient.DatumState_DATUM_SUCCESS, "/a", "1"),
+		datum("b", ppsclient.DatumState_DATUM_SUCCESS, "/b", "1"),
+		datum("c2", ppsclient.DatumState_DATUM_SUCCESS, "/c", "2"),
+		datum("e", ppsclient.DatumState_DATUM_SUCCESS, "/e", "1"),
+	}
+
+	explanations, removed := explainReprocessing(jobInfo, datumInfos, prevJobInfo, prevDatumInfos)
+	require.Equal(t, 4, len(explanations))
+	require.False(t, explanations[0].reprocessed)
+	require.True(t, explanations[1].reprocessed)
+	require.Equal(t, "it failed in job prev: exit status 1", explanations[1].reason)
+	require.True(t, explanations[2].reprocessed)
+	require.Equal(t, "changed files: images:/c", explanations[2].reason)
+	require.True(t, explanations[3].reprocessed)
+	require.Equal(t, "new files: images:/e", explanations[3].reason)
+	require.Equal(t, 2, len(removed))
+	require.Equal(t, "c", removed[0].ID)
+	require.Equal(t, "d", removed[1].ID)
+
+	// updating the pipeline reprocesses every datum
+	jobInfo.PipelineVersion = 2
+	explanations, _ = explainReprocessing(jobInfo, datumInfos, prevJobInfo, prevDatumInfos)
+	for _, explanation := range explanations {
+		require.True(t, explanation.reprocessed)
+		require.Equal(t, "the pipeline was updated from version 1 to 2", explanation.reason)
+	}
+
+	// with later versions of the datum hash, only updates that change what
+	// the pipeline computes reprocess every datum
+	prevJobInfo.DatumHashVersion = 1
+	jobInfo.DatumHashVersion = 1
+	explanations, _ = explainReprocessing(jobInfo, datumInfos, prevJobInfo, prevDatumInfos)
+	require.False(t, explanations[0].reprocessed)
+	jobInfo.Salt = "2"
+	explanations, _ = explainReprocessing(jobInfo, datumInfos, prevJobInfo, prevDatumInfos)
+	for _, explanation := range explanations {
+		require.True(t, explanation.reprocessed)
+		require.Equal(t, "the pipeline's salt changed", explanation.reason)
+	}
+	prevJobInfo.DatumHashVersion = 0
+	explanations, _ = explainReprocessing(jobInfo, datumInfos, prevJobInfo, prevDatumInfos)
+	require.Equal(t, "the pipeline's datums are hashed with version 1 of the datum hash, rather than 0", explanations[0].reason)
+
+	explanations, removed = explainReprocessing(jobInfo, datumInfos, nil, nil)
+	require.Equal(t, 4, len(explanations))
+	require.True(t, explanations[0].reprocessed)
+	require.Equal(t, 0, len(removed))
+}
@@ -2,15 +2,19 @@ package pretty
 
 import (
 	"bytes"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"strings"
 	"text/tabwriter"
 	"text/template"
 
 	"github.com/fatih/color"
+	"github.com/gogo/protobuf/types"
+	pfsclient "github.com/pachyderm/pachyderm/src/client/pfs"
 	ppsclient "github.com/pachyderm/pachyderm/src/client/pps"
 	"github.com/pachyderm/pachyderm/src/server/pkg/pretty"
 )
@@ -111,6 +115,57 @@ func PrintJobCountsHeader(w io.Writer) {
 	fmt.Fprintf(w, strings.ToUpper(jobState(ppsclient.JobState_JOB_SUCCESS))+"\t\n")
 }
 
+// PrintDatumInfoHeader prints a datum header.
+func PrintDatumInfoHeader(w io.Writer) {
+	// because STATE is a colorful field it has to be at the end of the line,
+	// otherwise the terminal escape characters will trip up the tabwriter
+	fmt.Fprint(w, "ID\tFILES\tREASON\tSTATE\t\n")
+}
+
+// PrintDatumInfo pretty-prints datum info.
+func PrintDatumInfo(w io.Writer, datumInfo *ppsclient.DatumInfo) {
+	fmt.Fprintf(w, "%s\t", datumInfo.ID)
+	fmt.Fprintf(w, "%s\t", datumFileNames(datumInfo))
+	if datumInfo.Reason != "" {
+		fmt.Fprintf(w, "%s\t", datumInfo.Reason)
+	} else {
+		fmt.Fprintf(w, "-\t")
+	}
+	fmt.Fprintf(w, "%s\t\n", datumState(datumInfo.State))
+}
+
+// PrintDatumExplanationHeader prints a header for explanations of whether
+// datums are reprocessed.
+func PrintDatumExplanationHeader(w io.Writer) {
+	fmt.Fprint(w, "ID\tFILES\tREPROCESSED\tREASON\t\n")
+}
+
+// PrintDatumExplanation pretty-prints whether a datum is reprocessed and why.
+func PrintDatumExplanation(w io.Writer, datumInfo *ppsclient.DatumInfo, reprocessed bool, reason string) {
+	fmt.Fprintf(w, "%s\t", datumInfo.ID)
+	fmt.Fprintf(w, "%s\t", datumFileNames(datumInfo))
+	if reprocessed {
+		fmt.Fprintf(w, "yes\t")
+	} else {
+		fmt.Fprintf(w, "no\t")
+	}
+	fmt.Fprintf(w, "%s\t\n", reason)
+}
+
+// PrintDatumFileHeader prints a header for the files of a datum.
+func PrintDatumFileHeader(w io.Writer) {
+	fmt.Fprint(w, "REPO\tCOMMIT\tPATH\tHASH\tSIZE\t\n")
+}
+
+// PrintDatumFile pretty-prints one of the files of a datum.
+func PrintDatumFile(w io.Writer, fileInfo *pfsclient.FileInfo) {
+	fmt.Fprintf(w, "%s\t", fileInfo.File.Commit.Repo.Name)
+	fmt.Fprintf(w, "%s\t", fileInfo.File.Commit.ID)
+	fmt.Fprintf(w, "%s\t", fileInfo.File.Path)
+	fmt.Fprintf(w, "%s\t", hex.EncodeToString(fileInfo.Hash))
+	fmt.Fprintf(w, "%s\t\n", pretty.Size(fileInfo.SizeBytes))
+}
+
 // PrintDetailedJobInfo pretty-prints detailed job info.
 func PrintDetailedJobInfo(jobInfo *ppsclient.JobInfo) error {
 	template, err := template.New("JobInfo").Funcs(funcMap).Parse(
@@ -119,14 +174,21 @@ Pipeline: {{.Pipeline.Name}} {{end}} {{if .ParentJob}}
 Parent: {{.ParentJob.ID}} {{end}}
 Started: {{prettyAgo .Started}} {{if .Finished}}
 Duration: {{prettyDuration .Started .Finished}} {{end}}
-State: {{jobState .State}}
+State: {{jobState .State}}{{if .Reason}}
+Reason: {{.Reason}}{{end}}
 Progress: {{.DataProcessed}} / {{.DataTotal}}
-Worker Status:
+{{ if .UserMetrics }}User Metrics:
+{{userMetrics .UserMetrics}}{{end}}Worker Status:
 {{workerStatus .}}Restarts: {{.Restart}}
 ParallelismSpec: {{.ParallelismSpec}}
 {{ if .ResourceSpec }}ResourceSpec:
 	CPU: {{ .ResourceSpec.Cpu }}
-	Memory: {{ .ResourceSpec.Memory }} {{end}}
+	Memory: {{ .ResourceSpec.Memory }} {{if .ResourceSpec.Gpu}}
+	GPU: {{ .ResourceSpec.Gpu }} {{end}}{{end}}
+{{ if .ResourceLimits }}ResourceLimits:
+	CPU: {{ .ResourceLimits.Cpu }}
+	Memory: {{ .ResourceLimits.Memory }} {{if .ResourceLimits.Gpu}}
+	GPU: {{ .ResourceLimits.Gpu }} {{end}}{{end}}
 {{ if .Service }}Service:
 	{{ if .Service.InternalPort }}InternalPort: {{ .Service.InternalPort }} {{end}}
 	{{ if .Service.ExternalPort }}ExternalPort: {{ .Service.ExternalPort }} {{end}} {{end}}Input:
@@ -134,7 +196,9 @@ ParallelismSpec: {{.ParallelismSpec}}
 Transform:
 {{prettyTransform .Transform}} {{if .OutputCommit}}
 Output Commit: {{.OutputCommit.ID}} {{end}} {{ if .Egress }}
-Egress: {{.Egress.URL}} {{end}}
+Egress: {{egress .Egress}} {{end}}{{ if .SQLEgressLoads }}
+SQL Egress:
+{{sqlEgressLoads .SQLEgressLoads}}{{end}}
 `)
 	if err != nil {
 		return err
@@ -151,18 +215,31 @@ func PrintDetailedPipelineInfo(pipelineInfo *ppsclient.PipelineInfo) error {
 	template, err := template.New("PipelineInfo").Funcs(funcMap).Parse(
 		`Name: {{.Pipeline.Name}}{{if .Description}}
 Description: {{.Description}}{{end}}
-Created: {{prettyAgo .CreatedAt}}
+Created: {{prettyAgo .CreatedAt}}{{if .Author}}
+Author: {{.Author}}{{end}}
 State: {{pipelineState .State}}
-Parallelism Spec: {{.ParallelismSpec}}
+Parallelism Spec: {{.ParallelismSpec}}{{if .SchedulingSpec}}
+Priority: {{.SchedulingSpec.Priority}}{{if .SchedulingSpec.Team}}
+Team: {{.SchedulingSpec.Team}}{{end}}{{end}}{{if .Salt}}
+Salt: {{.Salt}}{{end}}
+Datum Hash Version: {{.DatumHashVersion}}{{if .DatumCacheTTL}}
+Datum Cache TTL: {{duration .DatumCacheTTL}}{{end}}{{if .DatumCacheInvalidations}}
+Datum Cache Cleared:{{range .DatumCacheInvalidations}}
+	{{prettyAgo .Created}}{{if .Glob}} ({{.Glob}}){{end}}{{end}}{{end}}
 {{ if .ResourceSpec }}ResourceSpec:
 	CPU: {{ .ResourceSpec.Cpu }}
-	Memory: {{ .ResourceSpec.Memory }} {{end}}
+	Memory: {{ .ResourceSpec.Memory }} {{if .ResourceSpec.Gpu}}
+	GPU: {{ .ResourceSpec.Gpu }} {{end}}{{end}}
+{{ if .ResourceLimits }}ResourceLimits:
+	CPU: {{ .ResourceLimits.Cpu }}
+	Memory: {{ .ResourceLimits.Memory }} {{if .ResourceLimits.Gpu}}
+	GPU: {{ .ResourceLimits.Gpu }} {{end}}{{end}}
 Input:
 {{pipelineInput .}}
 Output Branch: {{.OutputBranch}}
 Transform:
 {{prettyTransform .Transform}}
-{{ if .Egress }}Egress: {{.Egress.URL}} {{end}}
+{{ if .Egress }}Egress: {{egress .Egress}} {{end}}
 {{if .RecentError}} Recent Error: {{.RecentError}} {{end}}
 Job Counts:
 {{jobCounts .JobCounts}}
@@ -177,6 +254,26 @@ Job Counts:
 	return nil
 }
 
+// PrintDetailedDatumInfo pretty-prints detailed datum info.
+func PrintDetailedDatumInfo(datumInfo *ppsclient.DatumInfo) error {
+	template, err := template.New("DatumInfo").Funcs(funcMap).Parse(
+		`ID: {{.ID}}
+Job: {{.Job.ID}}
+State: {{datumState .State}}{{if .Reason}}
+Reason: {{.Reason}}{{end}}
+Files:
+{{datumFiles .}}{{if .Stats}}Stats:
+{{datumStats .Stats}}{{end}}`)
+	if err != nil {
+		return err
+	}
+	err = template.Execute(os.Stdout, datumInfo)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
 func jobState(jobState ppsclient.JobState) string {
 	switch jobState {
 	case ppsclient.JobState_JOB_STARTING:
@@ -209,6 +306,20 @@ func pipelineState(pipelineState ppsclient.PipelineState) string {
 	return "-"
 }
 
+func datumState(datumState ppsclient.DatumState) string {
+	switch datumState {
+	case ppsclient.DatumState_DATUM_PENDING:
+		return color.New(color.FgYellow).SprintFunc()("pending")
+	case ppsclient.DatumState_DATUM_SUCCESS:
+		return color.New(color.FgGreen).SprintFunc()("success")
+	case ppsclient.DatumState_DATUM_FAILED:
+		return color.New(color.FgRed).SprintFunc()("failed")
+	case ppsclient.DatumState_DATUM_STOPPED:
+		return color.New(color.FgYellow).SprintFunc()("stopped")
+	}
+	return "-"
+}
+
 func jobInput(jobInfo *ppsclient.JobInfo) string {
 	if jobInfo.Input == nil {
 		return ""
@@ -232,6 +343,77 @@ func workerStatus(jobInfo *ppsclient.JobInfo) string {
 	return buffer.String()
 }
 
+func userMetrics(metrics []*ppsclient.UserMetric) string {
+	var buffer bytes.Buffer
+	writer := tabwriter.NewWriter(&buffer, 20, 1, 3, ' ', 0)
+	fmt.Fprintf(writer, "NAME\tTYPE\tVALUE\t\n")
+	for _, metric := range metrics {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t\n", metric.Name, strings.ToLower(metric.Type.String()), strconv.FormatFloat(metric.Value, 'f', -1, 64))
+	}
+	// can't error because buffer can't error on Write
+	writer.Flush()
+	return buffer.String()
+}
+
+// egress returns where output is pushed to, which is a database's URL if it's
+// loaded into a database.
+func egress(egress *ppsclient.Egress) string {
+	if egress.SQL != nil {
+		return fmt.Sprintf("%s (sql)", egress.SQL.URL)
+	}
+	return egress.URL
+}
+
+func sqlEgressLoads(loads []*ppsclient.SQLEgressLoad) string {
+	var buffer bytes.Buffer
+	writer := tabwriter.NewWriter(&buffer, 20, 1, 3, ' ', 0)
+	fmt.Fprintf(writer, "TABLE\tROWS\t\n")
+	for _, load := range loads {
+		fmt.Fprintf(writer, "%s\t%d\t\n", load.Table, load.Rows)
+	}
+	// can't error because buffer can't error on Write
+	writer.Flush()
+	return buffer.String()
+}
+
+func datumFileNames(datumInfo *ppsclient.DatumInfo) string {
+	var files []string
+	for _, fileInfo := range datumInfo.Data {
+		files = append(files, fmt.Sprintf("%s:%s", fileInfo.File.Commit.Repo.Name, fileInfo.File.Path))
+	}
+	return strings.Join(files, ", ")
+}
+
+func datumFiles(datumInfo *ppsclient.DatumInfo) string {
+	var buffer bytes.Buffer
+	writer := tabwriter.NewWriter(&buffer, 20, 1, 3, ' ', 0)
+	PrintDatumFileHeader(writer)
+	for _, fileInfo := range datumInfo.Data {
+		PrintDatumFile(writer, fileInfo)
+	}
+	// can't error because buffer can't error on Write
+	writer.Flush()
+	return buffer.String()
+}
+
+func duration(d *types.Duration) string {
+	result, err := types.DurationFromProto(d)
+	if err != nil {
+		return "-"
+	}
+	return result.String()
+}
+
+func datumStats(stats *ppsclient.ProcessStats) string {
+	var buffer bytes.Buffer
+	fmt.Fprintf(&buffer, "  Downloaded: %s in %s\n", pretty.Size(stats.DownloadBytes), duration(stats.DownloadTime))
+	fmt.Fprintf(&buffer, "  Processed in: %s\n", duration(stats.ProcessTime))
+	if stats.UploadTime != nil {
+		fmt.Fprintf(&buffer, "  Uploaded: %s in %s\n", pretty.Size(stats.UploadBytes), duration(stats.UploadTime))
+	}
+	return buffer.String()
+}
+
 func pipelineInput(pipelineInfo *ppsclient.PipelineInfo) string {
 	if pipelineInfo.Input == nil {
 		return ""
@@ -263,6 +445,8 @@ func shorthandInput(input *ppsclient.Input) string {
 	switch {
 	case input.Atom != nil:
 		return fmt.Sprintf("%s:%s", input.Atom.Repo, input.Atom.Glob)
+	case input.SQL != nil:
+		return fmt.Sprintf("sql:%s", input.SQL.Name)
 	case input.Cross != nil:
 		var subInput []string
 		for _, input := range input.Cross {
@@ -282,11 +466,18 @@ func shorthandInput(input *ppsclient.Input) string {
 var funcMap = template.FuncMap{
 	"pipelineState":   pipelineState,
 	"jobState":        jobState,
+	"datumState":      datumState,
+	"datumFiles":      datumFiles,
+	"datumStats":      datumStats,
 	"workerStatus":    workerStatus,
+	"userMetrics":     userMetrics,
+	"egress":          egress,
+	"sqlEgressLoads":  sqlEgressLoads,
 	"pipelineInput":   pipelineInput,
 	"jobInput":        jobInput,
 	"prettyAgo":       pretty.Ago,
 	"prettyDuration":  pretty.Duration,
+	"duration":        duration,
 	"jobCounts":       jobCounts,
 	"prettyTransform": prettyTransform,
 }
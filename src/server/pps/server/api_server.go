@@ -3,12 +3,18 @@ package server
 import (
 	"bufio"
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"math"
+	"net"
 	"net/url"
+	"path"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	client "github.com/pachyderm/pachyderm/src/client"
@@ -17,11 +23,16 @@ import (
 	"github.com/pachyderm/pachyderm/src/client/pkg/grpcutil"
 	"github.com/pachyderm/pachyderm/src/client/pkg/uuid"
 	"github.com/pachyderm/pachyderm/src/client/pps"
+	authserver "github.com/pachyderm/pachyderm/src/server/auth/server"
 	"github.com/pachyderm/pachyderm/src/server/pkg/backoff"
+	"github.com/pachyderm/pachyderm/src/server/pkg/clock"
 	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
-	"github.com/pachyderm/pachyderm/src/server/pkg/hashtree"
+	"github.com/pachyderm/pachyderm/src/server/pkg/cron"
+	"github.com/pachyderm/pachyderm/src/server/pkg/deploy/assets"
+	"github.com/pachyderm/pachyderm/src/server/pkg/dlock"
 	"github.com/pachyderm/pachyderm/src/server/pkg/metrics"
 	"github.com/pachyderm/pachyderm/src/server/pkg/obj"
+	"github.com/pachyderm/pachyderm/src/server/pkg/sqldb"
 	pfs_sync "github.com/pachyderm/pachyderm/src/server/pkg/sync"
 	"github.com/pachyderm/pachyderm/src/server/pkg/watch"
 	workerpkg "github.com/pachyderm/pachyderm/src/server/pkg/worker"
@@ -34,7 +45,9 @@ import (
 	"go.pedge.io/lion/proto"
 	"go.pedge.io/proto/rpclog"
 	"golang.org/x/net/context"
+	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 
 	"k8s.io/kubernetes/pkg/api"
 	"k8s.io/kubernetes/pkg/api/errors"
@@ -62,15 +75,15 @@ var (
 )
 
 func newErrJobNotFound(job string) error {
-	return fmt.Errorf("job %v not found", job)
+	return client.Errorf(client.ErrJobNotFound, "job %v not found", job)
 }
 
 func newErrPipelineNotFound(pipeline string) error {
-	return fmt.Errorf("pipeline %v not found", pipeline)
+	return client.Errorf(client.ErrPipelineNotFound, "pipeline %v not found", pipeline)
 }
 
 func newErrPipelineExists(pipeline string) error {
-	return fmt.Errorf("pipeline %v already exists", pipeline)
+	return client.Errorf(client.ErrPipelineExists, "pipeline %v already exists", pipeline)
 }
 
 type errEmptyInput struct {
@@ -112,6 +125,10 @@ func GetExpectedNumWorkers(kubeClient *kube.Client, spec *pps.ParallelismSpec) (
 		coefficient = 1
 	} else if spec.Strategy == pps.ParallelismSpec_COEFFICIENT {
 		coefficient = spec.Coefficient
+	} else if spec.Strategy == pps.ParallelismSpec_AUTOSCALE {
+		// Autoscaled workers start at their minimum, and are scaled up for
+		// each job, see scaleUpWorkers
+		return spec.Min, nil
 	} else {
 		return 0, fmt.Errorf("Unable to interpret ParallelismSpec strategy %s", spec.Strategy)
 	}
@@ -121,7 +138,9 @@ func GetExpectedNumWorkers(kubeClient *kube.Client, spec *pps.ParallelismSpec) (
 
 	// Start ('coefficient' * 'nodes') workers. Determine number of workers
 	nodeList, err := kubeClient.Nodes().List(api.ListOptions{})
-	if err != nil {
+	if errors.IsForbidden(err) {
+		return 0, fmt.Errorf("pachd isn't allowed to list k8s nodes, which it needs to determine parallelism; either grant its service account the ClusterRole that 'pachctl deploy' writes, or give the pipeline a constant parallelism: %v", err)
+	} else if err != nil {
 		return 0, fmt.Errorf("unable to retrieve node list from k8s to determine parallelism: %v", err)
 	}
 	if len(nodeList.Items) == 0 {
@@ -160,10 +179,79 @@ type apiServer struct {
 	storageRoot           string
 	storageBackend        string
 	storageHostPath       string
+	// internalToken is sent with the server's requests to PFS, see
+	// authserver.InternalToken
+	internalToken string
+	// peerCreds secure the server's connections to PFS if pachd serves
+	// TLS, see grpcutil.LoadTLS
+	peerCreds credentials.TransportCredentials
+	// etcdKeySecret is the kubernetes secret that holds the key pipelines
+	// and jobs are encrypted with in etcd, workers read it from there
+	etcdKeySecret string
+	// externalEtcdEndpoints, if pachd uses an external etcd, are its
+	// endpoints, which workers connect to with the certificates in
+	// etcdTLSSecret and the credentials in etcdCredentialsSecret, see
+	// assets.ExternalEtcdEnv
+	externalEtcdEndpoints []string
+	etcdTLSSecret         string
+	etcdCredentialsSecret string
+	// workerNetworkPolicies is true if workers' network access is
+	// restricted, see workerNetworkPolicy
+	workerNetworkPolicies bool
+	// prepullImages is true if workers' images are pulled onto the nodes
+	// when their RC is created, see prepullDaemonSet
+	prepullImages bool
+	// registryCredentials is true if workers pull their images with the
+	// credentials in RegistryCredentialsSecret, see
+	// RefreshRegistryCredentials
+	registryCredentials bool
+	// allowedImagePrefixes are the images that pipelines may use, see
+	// imageAllowed. Any image may be used if it's empty.
+	allowedImagePrefixes []string
+	// requireNonRoot and requireResourceLimits are the cluster's policies
+	// for pipelines, see validatePolicies
+	requireNonRoot        bool
+	requireResourceLimits bool
 	reporter              *metrics.Reporter
+	// workerResources are the cluster's defaults and maximums for workers'
+	// resources
+	workerResources WorkerResources
+	// maxWorkers, if not 0, caps the total number of workers of all
+	// pipelines and jobs, see scaleUpWorkersWithinQuota
+	maxWorkers int32
+	// teamWeights are the weights of the teams that pipelines belong to, see
+	// pps.SchedulingSpec. Teams that aren't in it have a weight of 1.
+	teamWeights map[string]int64
+	// workerNodePool is the nodes that workers are scheduled on
+	workerNodePool assets.NodePool
+	// defaultUserImage is the image of pipelines which don't name one, i.e.
+	// DefaultUserImage from pachd's registry
+	defaultUserImage string
+	// privilegedWorkers, if true, runs the user code of pipelines which
+	// don't set run_as_user in privileged containers, see userSecurityContext
+	privilegedWorkers bool
+	// sqlEgress is true if pipelines may load their output into databases,
+	// see pps.SQLEgress
+	sqlEgress bool
+	// sqlInputs is true if pipelines may snapshot databases' tables into
+	// repos, see pps.SQLInput and runSQLInputs
+	sqlInputs bool
+	// workerPort is the port that workers listen on
+	workerPort uint16
+	// workerEnv is the env vars that are set in every pipeline's workers,
+	// unless the pipeline's transform sets them
+	workerEnv map[string]string
+	// masters are the masters of pipelines and jobs that are running on
+	// this pachd, see runMaster
+	masters sync.WaitGroup
 	// collections
 	pipelines col.Collection
 	jobs      col.Collection
+	// datums holds the datums of a job which have failed
+	datums func(jobID string) col.Collection
+	// batcher batches up the writes that jobs' masters make as datums are
+	// processed
+	batcher *batcher
 }
 
 func (a *apiServer) validateInput(ctx context.Context, input *pps.Input, job bool) error {
@@ -224,6 +312,59 @@ func (a *apiServer) validateInput(ctx context.Context, input *pps.Input, job boo
 				}
 			}
 		}
+		if input.SQL != nil {
+			if set {
+				result = fmt.Errorf("multiple input types set")
+				return
+			}
+			set = true
+			switch {
+			case len(input.SQL.Name) == 0:
+				result = fmt.Errorf("input must specify a name")
+				return
+			case input.SQL.Name == "out":
+				result = fmt.Errorf("input cannot be named \"out\", as pachyderm " +
+					"already creates /pfs/out to collect job output")
+				return
+			case input.SQL.Commit == "" && job:
+				result = fmt.Errorf("input must specify a commit")
+				return
+			}
+			if _, ok := names[input.SQL.Name]; ok {
+				result = fmt.Errorf("conflicting input names: %s", input.SQL.Name)
+				return
+			}
+			names[input.SQL.Name] = true
+			if job {
+				pfsClient, err := a.getPFSClient()
+				if err != nil {
+					result = err
+					return
+				}
+				_, err = pfsClient.InspectCommit(ctx, &pfs.InspectCommitRequest{
+					Commit: client.NewCommit(input.SQL.Repo, input.SQL.Commit),
+				})
+				if err != nil {
+					result = err
+					return
+				}
+			} else {
+				// the input's repo is created with the pipeline, so only its
+				// query and schedule are checked
+				if !a.sqlInputs {
+					result = fmt.Errorf("pipelines can't have SQL inputs, as pachd wasn't deployed with --sql-inputs")
+					return
+				}
+				if err := sqldb.ValidateInput(input.SQL); err != nil {
+					result = err
+					return
+				}
+				if _, err := cron.Parse(input.SQL.Spec); err != nil {
+					result = err
+					return
+				}
+			}
+		}
 		if input.Cross != nil {
 			if set {
 				result = fmt.Errorf("multiple input types set")
@@ -265,6 +406,8 @@ func name(input *pps.Input) string {
 	switch {
 	case input.Atom != nil:
 		return input.Atom.Name
+	case input.SQL != nil:
+		return input.SQL.Name
 	case input.Cross != nil:
 		if len(input.Cross) > 0 {
 			return name(input.Cross[0])
@@ -297,14 +440,73 @@ func inputCommits(input *pps.Input) []*pfs.Commit {
 		if input.Atom != nil {
 			result = append(result, client.NewCommit(input.Atom.Repo, input.Atom.Commit))
 		}
+		if input.SQL != nil {
+			result = append(result, client.NewCommit(input.SQL.Repo, input.SQL.Commit))
+		}
 	})
 	return result
 }
 
 func (a *apiServer) validateJob(ctx context.Context, jobInfo *pps.JobInfo) error {
+	if err := validateTransform(jobInfo.Transform); err != nil {
+		return err
+	}
+	// pipelines' images and policies are checked when the pipelines are
+	// created, so that changing pachd's configuration doesn't break existing
+	// pipelines
+	if jobInfo.Pipeline == nil {
+		if err := a.validateImage(jobInfo.Transform); err != nil {
+			return err
+		}
+		if err := validateResources(jobInfo.ResourceSpec, jobInfo.ResourceLimits); err != nil {
+			return err
+		}
+		if err := validateParallelism(jobInfo.ParallelismSpec); err != nil {
+			return err
+		}
+		if err := a.validatePolicies(jobInfo.Transform, jobInfo.ResourceSpec, jobInfo.ResourceLimits); err != nil {
+			return err
+		}
+		var sqlInput bool
+		visit(jobInfo.Input, func(input *pps.Input) {
+			sqlInput = sqlInput || input.SQL != nil
+		})
+		if sqlInput {
+			return fmt.Errorf("only pipelines can have SQL inputs")
+		}
+	}
 	return a.validateInput(ctx, jobInfo.Input, true)
 }
 
+// validateTransform checks that each of transform's secrets is either mounted
+// or sets an environment variable, which is what the workers do with them.
+func validateTransform(transform *pps.Transform) error {
+	if transform == nil {
+		return nil
+	}
+	for _, secret := range transform.Secrets {
+		if secret.Name == "" {
+			return fmt.Errorf("secrets need a name")
+		}
+		if secret.MountPath == "" && secret.EnvVar == "" {
+			return fmt.Errorf("secret %s needs a mount path or an env var", secret.Name)
+		}
+		if secret.MountPath == "" && len(secret.Items) > 0 {
+			return fmt.Errorf("secret %s has items but no mount path to mount them at", secret.Name)
+		}
+		if (secret.EnvVar == "") != (secret.Key == "") {
+			return fmt.Errorf("secret %s needs both an env var and the key whose value it's set to", secret.Name)
+		}
+	}
+	if transform.RunAsUser < 0 {
+		return fmt.Errorf("invalid run_as_user %d, UIDs can't be negative", transform.RunAsUser)
+	}
+	if port := transform.Rendezvous.GetPort(); port < 0 || port > 65535 {
+		return fmt.Errorf("invalid rendezvous port %d", port)
+	}
+	return validateAllowedEgress(transform.AllowedEgress)
+}
+
 func translateJobInputs(inputs []*pps.JobInput) *pps.Input {
 	result := &pps.Input{}
 	for _, input := range inputs {
@@ -346,61 +548,188 @@ func (a *apiServer) CreateJob(ctx context.Context, request *pps.CreateJobRequest
 	metricsFn := metrics.ReportUserAction(ctx, a.reporter, "CreateJob")
 	defer func(start time.Time) { metricsFn(start, retErr) }(time.Now())
 
-	// First translate Inputs field to Input field.
-	if len(request.Inputs) > 0 {
-		if request.Input != nil {
-			return nil, fmt.Errorf("cannot set both Inputs and Input field")
+	if request.DryRun {
+		// the job isn't created, so it has no ID
+		if _, err := a.dryRunJob(ctx, request); err != nil {
+			return nil, err
 		}
-		request.Input = translateJobInputs(request.Inputs)
+		return &pps.Job{}, nil
+	}
+	return a.createJob(ctx, request, false, "")
+}
+
+// createJob creates the job that request describes. If once is true, and
+// request.Pipeline has already created a job with request.Input, that job is
+// returned rather than a new one. Likewise, if triggerKey is set and
+// request.Pipeline has already been triggered with it, the job that the
+// trigger created is returned. Both are checked in the same transaction that
+// creates the job, so pipeline masters (or retried triggers) that race to
+// create a job for the same input (or key) create just one.
+func (a *apiServer) createJob(ctx context.Context, request *pps.CreateJobRequest, once bool, triggerKey string) (*pps.Job, error) {
+	if err := translateJobRequest(request); err != nil {
+		return nil, err
+	}
+	if once && request.Pipeline == nil {
+		return nil, fmt.Errorf("only a pipeline's jobs can be created once per input")
+	}
+	if triggerKey != "" && (once || request.Pipeline == nil) {
+		return nil, fmt.Errorf("only a pipeline's jobs can be created once per trigger key")
 	}
 
 	job := &pps.Job{uuid.NewWithoutUnderscores()}
-	sortInput(request.Input)
+	// existingJob is the job that the pipeline has already created for this
+	// input, if once is true
+	var existingJob *pps.Job
 	_, err := col.NewSTM(ctx, a.etcdClient, func(stm col.STM) error {
-		jobInfo := &pps.JobInfo{
-			Job:             job,
-			Transform:       request.Transform,
-			Pipeline:        request.Pipeline,
-			ParallelismSpec: request.ParallelismSpec,
-			Input:           request.Input,
-			OutputRepo:      request.OutputRepo,
-			OutputBranch:    request.OutputBranch,
-			Started:         now(),
-			Finished:        nil,
-			OutputCommit:    nil,
-			Service:         request.Service,
-			ParentJob:       request.ParentJob,
-			ResourceSpec:    request.ResourceSpec,
-		}
-		if request.Pipeline != nil {
-			pipelineInfo := new(pps.PipelineInfo)
-			if err := a.pipelines.ReadWrite(stm).Get(request.Pipeline.Name, pipelineInfo); err != nil {
+		existingJob = nil
+		jobInfo, err := a.newJobInfo(stm, job, request)
+		if err != nil {
+			return err
+		}
+		// jobKey is the key under which the job is recorded, if it's
+		// created once per input or trigger key
+		var jobKey string
+		if once {
+			var err error
+			jobKey, err = a.pipelineJobKey(jobInfo.PipelineID, jobInfo.PipelineVersion, request.Input)
+			if err != nil {
 				return err
 			}
-			jobInfo.PipelineVersion = pipelineInfo.Version
-			jobInfo.PipelineID = pipelineInfo.ID
-			jobInfo.Transform = pipelineInfo.Transform
-			jobInfo.ParallelismSpec = pipelineInfo.ParallelismSpec
-			jobInfo.OutputRepo = &pfs.Repo{pipelineInfo.Pipeline.Name}
-			jobInfo.OutputBranch = pipelineInfo.OutputBranch
-			jobInfo.Egress = pipelineInfo.Egress
-			jobInfo.ResourceSpec = pipelineInfo.ResourceSpec
-		} else {
-			if jobInfo.OutputRepo == nil {
-				jobInfo.OutputRepo = &pfs.Repo{job.ID}
+		} else if triggerKey != "" {
+			jobKey = a.pipelineTriggerKey(jobInfo.PipelineID, triggerKey)
+		}
+		if jobKey != "" {
+			if jobID := stm.Get(jobKey); jobID != "" {
+				// A job that's since been deleted doesn't count, so that
+				// the input is processed again, as it would be without once
+				if err := a.jobs.ReadWrite(stm).Get(jobID, new(pps.JobInfo)); err == nil {
+					existingJob = &pps.Job{jobID}
+					return nil
+				} else if !isNotFoundErr(err) {
+					return err
+				}
 			}
 		}
 		if err := a.validateJob(ctx, jobInfo); err != nil {
 			return err
 		}
-		return a.updateJobState(stm, jobInfo, pps.JobState_JOB_STARTING)
+		// The job's parent is the last job created to commit to its output
+		// branch, so that it commits after it. This is read in the same
+		// transaction that creates the job, so that jobs created
+		// concurrently, or by different pachds, are still put in order.
+		var branchKey string
+		if jobInfo.OutputBranch != "" {
+			branchKey = a.outputBranchJobKey(jobInfo.OutputRepo, jobInfo.OutputBranch)
+			if jobID := stm.Get(branchKey); jobID != "" && jobInfo.ParentJob == nil {
+				jobInfo.ParentJob = &pps.Job{jobID}
+			}
+		}
+		if err := a.updateJobState(stm, jobInfo, pps.JobState_JOB_STARTING); err != nil {
+			return err
+		}
+		if jobKey != "" {
+			stm.Put(jobKey, job.ID)
+		}
+		if branchKey != "" {
+			stm.Put(branchKey, job.ID)
+		}
+		return nil
 	})
 	if err != nil {
 		return nil, err
 	}
+	if existingJob != nil {
+		return existingJob, nil
+	}
 	return job, nil
 }
 
+// translateJobRequest translates request's Inputs field to its Input field,
+// and sorts its input.
+func translateJobRequest(request *pps.CreateJobRequest) error {
+	if len(request.Inputs) > 0 {
+		if request.Input != nil {
+			return fmt.Errorf("cannot set both Inputs and Input field")
+		}
+		request.Input = translateJobInputs(request.Inputs)
+	}
+	sortInput(request.Input)
+	return nil
+}
+
+// newJobInfo returns the JobInfo of job, which request describes. The
+// fields of a pipeline's job that come from the pipeline are read from it in
+// stm.
+func (a *apiServer) newJobInfo(stm col.STM, job *pps.Job, request *pps.CreateJobRequest) (*pps.JobInfo, error) {
+	jobInfo := &pps.JobInfo{
+		Job:             job,
+		Transform:       request.Transform,
+		Pipeline:        request.Pipeline,
+		ParallelismSpec: request.ParallelismSpec,
+		Input:           request.Input,
+		OutputRepo:      request.OutputRepo,
+		OutputBranch:    request.OutputBranch,
+		Started:         clock.Now(),
+		Finished:        nil,
+		OutputCommit:    nil,
+		Service:         request.Service,
+		ParentJob:       request.ParentJob,
+		ResourceSpec:    request.ResourceSpec,
+		ResourceLimits:  request.ResourceLimits,
+	}
+	if request.Pipeline != nil {
+		pipelineInfo := new(pps.PipelineInfo)
+		if err := a.pipelines.ReadWrite(stm).Get(request.Pipeline.Name, pipelineInfo); err != nil {
+			return nil, err
+		}
+		jobInfo.PipelineVersion = pipelineInfo.Version
+		jobInfo.PipelineID = pipelineInfo.ID
+		jobInfo.Transform = pipelineInfo.Transform
+		jobInfo.ParallelismSpec = pipelineInfo.ParallelismSpec
+		jobInfo.OutputRepo = &pfs.Repo{pipelineInfo.Pipeline.Name}
+		jobInfo.OutputBranch = pipelineInfo.OutputBranch
+		jobInfo.Egress = pipelineInfo.Egress
+		jobInfo.ResourceSpec = pipelineInfo.ResourceSpec
+		jobInfo.ResourceLimits = pipelineInfo.ResourceLimits
+		jobInfo.Salt = pipelineInfo.Salt
+		jobInfo.DatumHashVersion = pipelineInfo.DatumHashVersion
+		jobInfo.DatumCacheTTL = pipelineInfo.DatumCacheTTL
+		jobInfo.DatumCacheInvalidations = pipelineInfo.DatumCacheInvalidations
+		jobInfo.SchedulingSpec = pipelineInfo.SchedulingSpec
+	} else {
+		if jobInfo.OutputRepo == nil {
+			jobInfo.OutputRepo = &pfs.Repo{job.ID}
+		}
+		jobInfo.ResourceSpec, jobInfo.ResourceLimits = a.workerResources.applyDefaults(jobInfo.ResourceSpec, jobInfo.ResourceLimits)
+	}
+	return jobInfo, nil
+}
+
+// pipelineJobKey returns the key, under pipelineJobsPrefix, of the job that
+// a pipeline's version creates for input.
+func (a *apiServer) pipelineJobKey(pipelineID string, pipelineVersion uint64, input *pps.Input) (string, error) {
+	data, err := proto.Marshal(input)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(data)
+	return path.Join(a.etcdPrefix, pipelineJobsPrefix, pipelineID, fmt.Sprint(pipelineVersion), hex.EncodeToString(hash[:])), nil
+}
+
+// outputBranchJobKey returns the key, under outputBranchJobsPrefix, of the
+// last job created to commit to branch of repo.
+func (a *apiServer) outputBranchJobKey(repo *pfs.Repo, branch string) string {
+	return path.Join(a.etcdPrefix, outputBranchJobsPrefix, repo.Name, branch)
+}
+
+// pipelineTriggerKey returns the key, under pipelineTriggersPrefix, of the
+// job that a pipeline creates when it's triggered with triggerKey. Keys are
+// hashed, as they're chosen by users and may contain anything.
+func (a *apiServer) pipelineTriggerKey(pipelineID string, triggerKey string) string {
+	hash := sha256.Sum256([]byte(triggerKey))
+	return path.Join(a.etcdPrefix, pipelineTriggersPrefix, pipelineID, hex.EncodeToString(hash[:]))
+}
+
 func (a *apiServer) InspectJob(ctx context.Context, request *pps.InspectJobRequest) (response *pps.JobInfo, retErr error) {
 	func() { a.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
@@ -457,7 +786,12 @@ func (a *apiServer) InspectJob(ctx context.Context, request *pps.InspectJobReque
 	} else {
 		workerPoolID = JobRcName(jobInfo.Job.ID)
 	}
-	workerStatus, err := status(ctx, workerPoolID, a.etcdClient, a.etcdPrefix)
+	workerPort, err := a.workerServicePort(workerPoolID)
+	if err != nil {
+		protolion.Errorf("failed to get worker status with err: %s", err.Error())
+		return jobInfo, nil
+	}
+	workerStatus, err := status(ctx, workerPoolID, a.etcdClient, a.etcdPrefix, workerPort)
 	if err != nil {
 		protolion.Errorf("failed to get worker status with err: %s", err.Error())
 	} else {
@@ -502,6 +836,10 @@ func (a *apiServer) ListJob(ctx context.Context, request *pps.ListJobRequest) (r
 		if !ok {
 			break
 		}
+		if request.NoFull {
+			jobInfos = append(jobInfos, briefJobInfo(&jobInfo))
+			continue
+		}
 		if jobInfo.Input == nil {
 			jobInfo.Input = translateJobInputs(jobInfo.Inputs)
 		}
@@ -511,6 +849,219 @@ func (a *apiServer) ListJob(ctx context.Context, request *pps.ListJobRequest) (r
 	return &pps.JobInfos{jobInfos}, nil
 }
 
+// briefJobInfo returns the fields of jobInfo that ListJob returns when
+// NoFull is set, see pps.ListJobRequest.
+func briefJobInfo(jobInfo *pps.JobInfo) *pps.JobInfo {
+	return &pps.JobInfo{
+		Job:             jobInfo.Job,
+		PipelineID:      jobInfo.PipelineID,
+		Pipeline:        jobInfo.Pipeline,
+		PipelineVersion: jobInfo.PipelineVersion,
+		ParentJob:       jobInfo.ParentJob,
+		Started:         jobInfo.Started,
+		Finished:        jobInfo.Finished,
+		OutputCommit:    jobInfo.OutputCommit,
+		State:           jobInfo.State,
+		Stopped:         jobInfo.Stopped,
+		OutputRepo:      jobInfo.OutputRepo,
+		OutputBranch:    jobInfo.OutputBranch,
+		Restart:         jobInfo.Restart,
+		DataProcessed:   jobInfo.DataProcessed,
+		DataTotal:       jobInfo.DataTotal,
+	}
+}
+
+func (a *apiServer) FlushJob(request *pps.FlushJobRequest, server pps.API_FlushJobServer) (retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, nil, retErr, time.Since(start)) }(time.Now())
+	ctx := server.Context()
+	metricsFn := metrics.ReportUserAction(ctx, a.reporter, "FlushJob")
+	defer func(start time.Time) { metricsFn(start, retErr) }(time.Now())
+
+	pfsClient, err := a.getPFSClient()
+	if err != nil {
+		return err
+	}
+	// Resolve the commits, which may be given as branches
+	commits := make(map[string]bool)
+	repos := make(map[string]bool)
+	for _, commit := range request.Commits {
+		commitInfo, err := pfsClient.InspectCommit(ctx, &pfs.InspectCommitRequest{
+			Commit: commit,
+		})
+		if err != nil {
+			return err
+		}
+		commits[commitInfo.Commit.ID] = true
+		repos[commitInfo.Commit.Repo.Name] = true
+	}
+
+	pipelineInfos, err := a.ListPipeline(ctx, &pps.ListPipelineRequest{})
+	if err != nil {
+		return err
+	}
+	provenance := pipelineProvenance(pipelineInfos.PipelineInfo)
+	toPipelines := make(map[string]bool)
+	for _, pipeline := range request.ToPipelines {
+		if _, ok := provenance[pipeline.Name]; !ok {
+			return newErrPipelineNotFound(pipeline.Name)
+		}
+		toPipelines[pipeline.Name] = true
+	}
+	// Find the pipelines that will run jobs for the commits. If ToPipelines
+	// is set, we only need to wait for those pipelines and the pipelines
+	// upstream of them.
+	downstream := make(map[string]bool)
+	var pipelinesToFlush []*pps.PipelineInfo
+	for _, pipelineInfo := range pipelineInfos.PipelineInfo {
+		name := pipelineInfo.Pipeline.Name
+		isDownstream := false
+		for repo := range repos {
+			if provenance[name][repo] {
+				isDownstream = true
+			}
+		}
+		if !isDownstream {
+			continue
+		}
+		downstream[name] = true
+		if len(toPipelines) > 0 && !toPipelines[name] {
+			isUpstream := false
+			for toPipeline := range toPipelines {
+				if provenance[toPipeline][name] {
+					isUpstream = true
+				}
+			}
+			if !isUpstream {
+				continue
+			}
+		}
+		pipelinesToFlush = append(pipelinesToFlush, pipelineInfo)
+	}
+	// A pipeline's provenance is a strict superset of the provenance of the
+	// pipelines upstream of it, so this puts the pipelines in the order in
+	// which their jobs run.
+	sort.SliceStable(pipelinesToFlush, func(i, j int) bool {
+		return len(provenance[pipelinesToFlush[i].Pipeline.Name]) < len(provenance[pipelinesToFlush[j].Pipeline.Name])
+	})
+
+	hasProvenance := make(map[string]bool)
+	succeeded := make(map[string]bool)
+nextPipeline:
+	for _, pipelineInfo := range pipelinesToFlush {
+		// If a job upstream of this pipeline didn't succeed it has no output
+		// commit, so this pipeline won't run a job.
+		for _, commit := range inputCommits(pipelineInfo.Input) {
+			if downstream[commit.Repo.Name] && !succeeded[commit.Repo.Name] {
+				continue nextPipeline
+			}
+		}
+		jobInfo, err := a.flushJob(ctx, pfsClient, pipelineInfo.Pipeline, commits, hasProvenance)
+		if err != nil {
+			return err
+		}
+		if jobInfo.State == pps.JobState_JOB_SUCCESS {
+			succeeded[pipelineInfo.Pipeline.Name] = true
+		}
+		if len(toPipelines) == 0 || toPipelines[pipelineInfo.Pipeline.Name] {
+			if err := server.Send(jobInfo); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// pipelineProvenance returns the names of the repos (including those of
+// other pipelines) that each pipeline's output is derived from.
+func pipelineProvenance(pipelineInfos []*pps.PipelineInfo) map[string]map[string]bool {
+	inputs := make(map[string][]*pfs.Commit)
+	for _, pipelineInfo := range pipelineInfos {
+		inputs[pipelineInfo.Pipeline.Name] = inputCommits(pipelineInfo.Input)
+	}
+	result := make(map[string]map[string]bool)
+	var visit func(name string) map[string]bool
+	visit = func(name string) map[string]bool {
+		if provenance, ok := result[name]; ok {
+			return provenance
+		}
+		provenance := make(map[string]bool)
+		// set this before recursing, in case of a cycle
+		result[name] = provenance
+		for _, commit := range inputs[name] {
+			provenance[commit.Repo.Name] = true
+			if _, ok := inputs[commit.Repo.Name]; ok {
+				for repo := range visit(commit.Repo.Name) {
+					provenance[repo] = true
+				}
+			}
+		}
+		return provenance
+	}
+	for name := range inputs {
+		visit(name)
+	}
+	return result
+}
+
+// flushJob waits for the job of pipeline that has one of commits as provenance
+// to finish, and returns it. hasProvenance caches whether input commits have
+// one of commits as provenance.
+func (a *apiServer) flushJob(ctx context.Context, pfsClient pfs.APIClient, pipeline *pps.Pipeline, commits map[string]bool, hasProvenance map[string]bool) (*pps.JobInfo, error) {
+	watcher, err := a.jobs.ReadOnly(ctx).WatchByIndex(jobsPipelineIndex, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer watcher.Close()
+	for {
+		ev, ok := <-watcher.Watch()
+		if !ok {
+			return nil, fmt.Errorf("the stream for job updates closed unexpectedly")
+		}
+		switch ev.Type {
+		case watch.EventError:
+			return nil, ev.Err
+		case watch.EventPut:
+			var jobID string
+			var jobInfo pps.JobInfo
+			if err := ev.Unmarshal(&jobID, &jobInfo); err != nil {
+				return nil, err
+			}
+			if jobInfo.Input == nil {
+				jobInfo.Input = translateJobInputs(jobInfo.Inputs)
+			}
+			for _, commit := range inputCommits(jobInfo.Input) {
+				provenant, ok := hasProvenance[commit.ID]
+				if !ok {
+					provenant = commits[commit.ID]
+					if !provenant {
+						commitInfo, err := pfsClient.InspectCommit(ctx, &pfs.InspectCommitRequest{
+							Commit: commit,
+						})
+						if err != nil {
+							return nil, err
+						}
+						for _, prov := range commitInfo.Provenance {
+							if commits[prov.ID] {
+								provenant = true
+							}
+						}
+					}
+					hasProvenance[commit.ID] = provenant
+				}
+				if provenant {
+					// Index entries aren't updated when the job is, so we
+					// wait for it to finish separately
+					return a.InspectJob(ctx, &pps.InspectJobRequest{
+						Job:        jobInfo.Job,
+						BlockState: true,
+					})
+				}
+			}
+		}
+	}
+}
+
 func (a *apiServer) DeleteJob(ctx context.Context, request *pps.DeleteJobRequest) (response *types.Empty, retErr error) {
 	func() { a.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
@@ -518,6 +1069,7 @@ func (a *apiServer) DeleteJob(ctx context.Context, request *pps.DeleteJobRequest
 	defer func(start time.Time) { metricsFn(start, retErr) }(time.Now())
 
 	_, err := col.NewSTM(ctx, a.etcdClient, func(stm col.STM) error {
+		a.datums(request.Job.ID).ReadWrite(stm).DeleteAll()
 		return a.jobs.ReadWrite(stm).Delete(request.Job.ID)
 	})
 	if err != nil {
@@ -532,20 +1084,60 @@ func (a *apiServer) StopJob(ctx context.Context, request *pps.StopJobRequest) (r
 	metricsFn := metrics.ReportUserAction(ctx, a.reporter, "StopJob")
 	defer func(start time.Time) { metricsFn(start, retErr) }(time.Now())
 
+	jobInfo := new(pps.JobInfo)
 	_, err := col.NewSTM(ctx, a.etcdClient, func(stm col.STM) error {
 		jobs := a.jobs.ReadWrite(stm)
-		jobInfo := new(pps.JobInfo)
 		if err := jobs.Get(request.Job.ID, jobInfo); err != nil {
 			return err
 		}
+		// finished jobs stay in the state they finished in
+		if jobStateToStopped(jobInfo.State) {
+			return fmt.Errorf("job %s has already finished", request.Job.ID)
+		}
 		return a.updateJobState(stm, jobInfo, pps.JobState_JOB_STOPPED)
 	})
 	if err != nil {
 		return nil, err
 	}
+	// Stopping the job cancels its master, but not the datums that its
+	// workers are processing
+	if err := a.stopJobDatums(ctx, jobInfo); err != nil {
+		protolion.Errorf("error stopping the datums of job %s: %v", jobInfo.Job.ID, err)
+	}
 	return &types.Empty{}, nil
 }
 
+// stopJobDatums stops the user code of the datums of jobInfo, which has been
+// stopped, on all of its workers, and records those datums as stopped.
+func (a *apiServer) stopJobDatums(ctx context.Context, jobInfo *pps.JobInfo) error {
+	var workerPoolID string
+	if jobInfo.Pipeline != nil {
+		workerPoolID = PipelineRcName(jobInfo.Pipeline.Name, jobInfo.PipelineVersion)
+	} else {
+		workerPoolID = JobRcName(jobInfo.Job.ID)
+	}
+	workerPort, err := a.workerServicePort(workerPoolID)
+	if err != nil {
+		return err
+	}
+	datumIDs, err := stopDatums(ctx, workerPoolID, a.etcdClient, a.etcdPrefix, workerPort, jobInfo.Job.ID)
+	if err != nil || len(datumIDs) == 0 {
+		return err
+	}
+	_, err = col.NewSTM(ctx, a.etcdClient, func(stm col.STM) error {
+		datums := a.datums(jobInfo.Job.ID).ReadWrite(stm)
+		for _, datumID := range datumIDs {
+			datums.Put(datumID, &pps.DatumInfo{
+				ID:    datumID,
+				Job:   jobInfo.Job,
+				State: pps.DatumState_DATUM_STOPPED,
+			})
+		}
+		return nil
+	})
+	return err
+}
+
 func (a *apiServer) RestartDatum(ctx context.Context, request *pps.RestartDatumRequest) (response *types.Empty, retErr error) {
 	func() { a.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
@@ -564,37 +1156,212 @@ func (a *apiServer) RestartDatum(ctx context.Context, request *pps.RestartDatumR
 	} else {
 		workerPoolID = JobRcName(jobInfo.Job.ID)
 	}
-	if err := cancel(ctx, workerPoolID, a.etcdClient, a.etcdPrefix, request.Job.ID, request.DataFilters); err != nil {
+	workerPort, err := a.workerServicePort(workerPoolID)
+	if err != nil {
+		return nil, err
+	}
+	if err := cancel(ctx, workerPoolID, a.etcdClient, a.etcdPrefix, workerPort, request.Job.ID, request.DataFilters); err != nil {
 		return nil, err
 	}
 	return &types.Empty{}, nil
 }
 
-func (a *apiServer) lookupRcNameForPipeline(ctx context.Context, pipeline *pps.Pipeline) (string, error) {
-	var pipelineInfo pps.PipelineInfo
-	err := a.pipelines.ReadOnly(ctx).Get(pipeline.Name, &pipelineInfo)
+func (a *apiServer) ListDatum(ctx context.Context, request *pps.ListDatumRequest) (response *pps.DatumInfos, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	metricsFn := metrics.ReportUserAction(ctx, a.reporter, "ListDatum")
+	defer func(start time.Time) { metricsFn(start, retErr) }(time.Now())
+
+	datumInfos, err := a.listDatum(ctx, request.Job)
 	if err != nil {
-		return "", fmt.Errorf("could not get pipeline information for %s: %s", pipeline.Name, err.Error())
+		return nil, err
 	}
-	return PipelineRcName(pipeline.Name, pipelineInfo.Version), nil
+	return &pps.DatumInfos{DatumInfo: datumInfos}, nil
 }
 
-func (a *apiServer) GetLogs(request *pps.GetLogsRequest, apiGetLogsServer pps.API_GetLogsServer) (retErr error) {
+func (a *apiServer) InspectDatum(ctx context.Context, request *pps.InspectDatumRequest) (response *pps.DatumInfo, retErr error) {
 	func() { a.Log(request, nil, nil, 0) }()
-	defer func(start time.Time) { a.Log(request, nil, retErr, time.Since(start)) }(time.Now())
-	// No deadline in request, but we create one here, since we do expect the call
-	// to finish reasonably quickly
-	ctx, _ := context.WithTimeout(context.Background(), 60*time.Second)
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	metricsFn := metrics.ReportUserAction(ctx, a.reporter, "InspectDatum")
+	defer func(start time.Time) { metricsFn(start, retErr) }(time.Now())
 
-	// Validate request
-	if request.Pipeline == nil && request.Job == nil {
-		return fmt.Errorf("must set either pipeline or job filter in call to GetLogs")
+	var result *pps.DatumInfo
+	if err := a.listDatumF(ctx, request.Job, func(datumInfo *pps.DatumInfo) error {
+		if datumInfo.ID == request.ID {
+			result = datumInfo
+			return errDatumFound
+		}
+		return nil
+	}); err != nil && err != errDatumFound {
+		return nil, err
+	}
+	if result == nil {
+		return nil, fmt.Errorf("datum %s not found in job %s", request.ID, request.Job.ID)
 	}
+	stats, err := a.getDatumStats(ctx, result.Job.ID, result.ID)
+	if err != nil {
+		return nil, err
+	}
+	result.Stats = stats
+	return result, nil
+}
 
-	// Get list of pods containing logs we're interested in (based on pipeline and
-	// job filters)
-	var rcName string
-	if request.Pipeline != nil {
+// getDatumStats returns the stats that a worker stored when it processed the
+// datum with ID datumID for the job with ID jobID, or nil if it didn't
+// process it, e.g. because its output was reused from an earlier job.
+func (a *apiServer) getDatumStats(ctx context.Context, jobID string, datumID string) (*pps.ProcessStats, error) {
+	objClient, err := a.getObjectClient()
+	if err != nil {
+		return nil, err
+	}
+	tag := &pfs.Tag{Name: workerpkg.DatumStatsTag(jobID, datumID)}
+	if _, err := objClient.InspectTag(ctx, tag); err != nil {
+		// the datum has no stats
+		return nil, nil
+	}
+	getTagClient, err := objClient.GetTag(ctx, tag)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := grpcutil.WriteFromStreamingBytesClient(getTagClient, &buf); err != nil {
+		return nil, err
+	}
+	stats := &pps.ProcessStats{}
+	if err := proto.Unmarshal(buf.Bytes(), stats); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// errDatumFound stops listDatumF once InspectDatum has found its datum.
+var errDatumFound = fmt.Errorf("datum found")
+
+// listDatum returns the datums of job.
+func (a *apiServer) listDatum(ctx context.Context, job *pps.Job) ([]*pps.DatumInfo, error) {
+	var result []*pps.DatumInfo
+	if err := a.listDatumF(ctx, job, func(datumInfo *pps.DatumInfo) error {
+		result = append(result, datumInfo)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// listDatumF calls f with each of the datums of job, in the order the job
+// processes them, stopping at the first error f returns. The datums are
+// generated one at a time, so they aren't all held in memory. Only failed
+// datums, and those which were being processed when the job was stopped, are
+// recorded as they're processed, so the datums of a job which hasn't finished
+// are pending otherwise.
+func (a *apiServer) listDatumF(ctx context.Context, job *pps.Job, f func(*pps.DatumInfo) error) error {
+	jobInfo, err := a.InspectJob(ctx, &pps.InspectJobRequest{
+		Job: job,
+	})
+	if err != nil {
+		return err
+	}
+	recorded := make(map[string]*pps.DatumInfo)
+	iter, err := a.datums(jobInfo.Job.ID).ReadOnly(ctx).List()
+	if err != nil {
+		return err
+	}
+	for {
+		var key string
+		datumInfo := new(pps.DatumInfo)
+		ok, err := iter.Next(&key, datumInfo)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		recorded[datumInfo.ID] = datumInfo
+	}
+	// every datum of a job is processed, even after one has failed
+	state := pps.DatumState_DATUM_PENDING
+	if jobInfo.State == pps.JobState_JOB_SUCCESS || jobInfo.State == pps.JobState_JOB_FAILURE {
+		state = pps.DatumState_DATUM_SUCCESS
+	}
+	pfsClient, err := a.getPFSClient()
+	if err != nil {
+		return err
+	}
+	df, err := newDatumFactory(ctx, pfsClient, jobInfo.Input)
+	if err != nil {
+		return err
+	}
+	numDatums := df.Len()
+	for i := 0; i < numDatums; i++ {
+		files := df.Datum(i)
+		id := workerpkg.DatumID(files)
+		datumInfo, ok := recorded[id]
+		if !ok {
+			datumInfo = &pps.DatumInfo{
+				ID:    id,
+				Job:   jobInfo.Job,
+				State: state,
+			}
+		}
+		// stopped datums are recorded by ID alone
+		if len(datumInfo.Data) == 0 {
+			for _, file := range files {
+				datumInfo.Data = append(datumInfo.Data, file.FileInfo)
+			}
+		}
+		if err := f(datumInfo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// putFailedDatum records that the datum made up of files failed, for
+// ListDatum. It's written to etcd with the next batch, see batcher.
+func (a *apiServer) putFailedDatum(job *pps.Job, files []*workerpkg.Input, reason string) {
+	datumInfo := &pps.DatumInfo{
+		ID:     workerpkg.DatumID(files),
+		Job:    job,
+		State:  pps.DatumState_DATUM_FAILED,
+		Reason: reason,
+	}
+	for _, file := range files {
+		datumInfo.Data = append(datumInfo.Data, file.FileInfo)
+	}
+	a.batcher.putDatum(datumInfo)
+}
+
+func (a *apiServer) lookupRcNameForPipeline(ctx context.Context, pipeline *pps.Pipeline) (string, error) {
+	var pipelineInfo pps.PipelineInfo
+	err := a.pipelines.ReadOnly(ctx).Get(pipeline.Name, &pipelineInfo)
+	if err != nil {
+		return "", fmt.Errorf("could not get pipeline information for %s: %s", pipeline.Name, err.Error())
+	}
+	return PipelineRcName(pipeline.Name, pipelineInfo.Version), nil
+}
+
+func (a *apiServer) GetLogs(request *pps.GetLogsRequest, apiGetLogsServer pps.API_GetLogsServer) (retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, nil, retErr, time.Since(start)) }(time.Now())
+	ctx := apiGetLogsServer.Context()
+	if !request.Follow {
+		// No deadline in request, but we create one here, since we do expect
+		// the call to finish reasonably quickly
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, 60*time.Second)
+		defer cancel()
+	}
+
+	// Validate request
+	if request.Pipeline == nil && request.Job == nil {
+		return fmt.Errorf("must set either pipeline or job filter in call to GetLogs")
+	}
+
+	// Get list of pods containing logs we're interested in (based on pipeline and
+	// job filters)
+	var rcName string
+	if request.Pipeline != nil {
 		// If the user provides a pipeline, get logs from the pipeline RC directly
 		var err error
 		rcName, err = a.lookupRcNameForPipeline(ctx, request.Pipeline)
@@ -630,104 +1397,386 @@ func (a *apiServer) GetLogs(request *pps.GetLogsRequest, apiGetLogsServer pps.AP
 		return fmt.Errorf("no pods belonging to the rc \"%s\" were found", rcName)
 	}
 
-	// Spawn one goroutine per pod. Each goro writes its pod's logs to a channel
-	// and channels are read into the output server in a stable order.
-	// (sort the pods to make sure that the order of log lines is stable)
+	// Spawn one goroutine per pod. Each goro writes the lines its pod has
+	// already logged to its own channel, and the channels are merged into the
+	// output server in timestamp order, so that what the workers were doing
+	// at the same time is returned together. (the pods are sorted so that
+	// lines logged at the same time are returned in a stable order)
+	// When following logs, the pods' new lines are then written to one
+	// channel and returned in the order they're read, as they're logged.
 	sort.Sort(podSlice(pods))
 	logChs := make([]chan *pps.LogMessage, len(pods))
+	for i := range logChs {
+		logChs[i] = make(chan *pps.LogMessage)
+	}
+	followCh := make(chan *pps.LogMessage)
 	errCh := make(chan error)
 	done := make(chan struct{})
 	defer close(done)
-	for i := 0; i < len(pods); i++ {
-		logChs[i] = make(chan *pps.LogMessage)
-	}
+	var wg sync.WaitGroup
 	for i, pod := range pods {
 		i := i
 		pod := pod
+		wg.Add(1)
 		go func() {
-			defer close(logChs[i]) // Main thread reads from here, so must close
-			// Get full set of logs from pod i
-			result := a.kubeClient.Pods(a.namespace).GetLogs(
-				pod.ObjectMeta.Name, &api.PodLogOptions{
-					Container: client.PPSWorkerUserContainerName,
-				}).Do()
-			fullLogs, err := result.Raw()
-			if err != nil {
-				if apiStatus, ok := err.(errors.APIStatus); ok &&
-					strings.Contains(apiStatus.Status().Message, "PodInitializing") {
-					return // No logs to collect from this node, just skip it
-				}
+			defer wg.Done()
+			var once sync.Once
+			closeLogCh := func() { once.Do(func() { close(logChs[i]) }) }
+			if err := a.podLogs(ctx, pod, request, logChs[i], closeLogCh, followCh, done); err != nil {
 				select {
 				case errCh <- err:
 				case <-done:
 				}
-				return
 			}
+			closeLogCh() // Main thread reads from here, so must close
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(followCh)
+	}()
+	if err := mergeLogs(logChs, errCh, apiGetLogsServer.Send); err != nil {
+		return err
+	}
+	if !request.Follow {
+		return nil
+	}
+	for {
+		select {
+		case msg, ok := <-followCh:
+			if !ok {
+				return nil
+			}
+			if err := apiGetLogsServer.Send(msg); err != nil {
+				return err
+			}
+		case err := <-errCh:
+			return err
+		}
+	}
+}
 
-			// Parse pods' log lines, and filter out irrelevant ones
-			scanner := bufio.NewScanner(bytes.NewReader(fullLogs))
-			for scanner.Scan() {
-				logBytes := scanner.Bytes()
-				msg := new(pps.LogMessage)
-				if err := jsonpb.Unmarshal(bytes.NewReader(logBytes), msg); err != nil {
-					protolion.Errorf("Error parsing log message: %+v", err)
-					msg.Message = string(logBytes)
-					select {
-					case logChs[i] <- msg:
-					case <-done:
-						return
+// mergeLogs reads the log lines from each of logChs, which are each in the
+// order they were logged, until they're all closed, and sends them in
+// timestamp order. Lines without a timestamp are sent as soon as they're
+// read, so they stay next to the lines logged before them. It returns the
+// first error read from errCh.
+func mergeLogs(logChs []chan *pps.LogMessage, errCh <-chan error, send func(*pps.LogMessage) error) error {
+	// heads holds the next line from each channel that's still open
+	heads := make([]*pps.LogMessage, len(logChs))
+	open := make([]bool, len(logChs))
+	for i := range open {
+		open[i] = true
+	}
+	for {
+		next := -1
+		for i, logCh := range logChs {
+			if open[i] && heads[i] == nil {
+				select {
+				case msg, ok := <-logCh:
+					if !ok {
+						open[i] = false
+						continue
 					}
-					continue
+					heads[i] = msg
+				case err := <-errCh:
+					return err
 				}
+			}
+			if heads[i] != nil && (next == -1 || logBefore(heads[i], heads[next])) {
+				next = i
+			}
+		}
+		if next == -1 {
+			return nil
+		}
+		if err := send(heads[next]); err != nil {
+			return err
+		}
+		heads[next] = nil
+	}
+}
 
-				// Filter out log lines that don't match on pipeline or job
-				if request.Pipeline != nil && request.Pipeline.Name != msg.PipelineName {
-					continue
-				}
-				if request.Job != nil && request.Job.ID != msg.JobID {
-					continue
-				}
+// logBefore returns true if a was logged before b, or if a has no timestamp.
+func logBefore(a *pps.LogMessage, b *pps.LogMessage) bool {
+	if a.Ts == nil || b.Ts == nil {
+		return a.Ts == nil && b.Ts != nil
+	}
+	if a.Ts.Seconds != b.Ts.Seconds {
+		return a.Ts.Seconds < b.Ts.Seconds
+	}
+	return a.Ts.Nanos < b.Ts.Nanos
+}
 
-				if !workerpkg.MatchDatum(request.DataFilters, msg.Data) {
-					continue
-				}
+// podLogs writes the log lines of pod which match request to logCh, and
+// then calls closeLogCh, unless done is closed first. If request.Follow is
+// set, it then writes lines to followCh as they're logged, until ctx is
+// cancelled. Lines are tagged with the pod that logged them, if the worker
+// didn't tag them itself.
+func (a *apiServer) podLogs(ctx context.Context, pod api.Pod, request *pps.GetLogsRequest, logCh chan<- *pps.LogMessage, closeLogCh func(), followCh chan<- *pps.LogMessage, done <-chan struct{}) error {
+	send := func(msg *pps.LogMessage) bool {
+		select {
+		case logCh <- msg:
+			return true
+		case <-done:
+			return false
+		}
+	}
+	parse := func(logBytes []byte) *pps.LogMessage {
+		msg := parseLogLine(logBytes, request)
+		if msg != nil && msg.WorkerID == "" {
+			msg.WorkerID = pod.ObjectMeta.Name
+		}
+		return msg
+	}
 
-				// Log message passes all filters -- return it
-				select {
-				case logChs[i] <- msg:
-				case <-done:
-					return
-				}
-			}
-		}()
+	// Get full set of logs from pod
+	result := a.kubeClient.Pods(a.namespace).GetLogs(
+		pod.ObjectMeta.Name, &api.PodLogOptions{
+			Container: client.PPSWorkerUserContainerName,
+		}).Do()
+	fullLogs, err := result.Raw()
+	if err != nil {
+		if apiStatus, ok := err.(errors.APIStatus); ok &&
+			strings.Contains(apiStatus.Status().Message, "PodInitializing") {
+			return nil // No logs to collect from this node, just skip it
+		}
+		return err
 	}
-nextLogCh:
-	for _, logCh := range logChs {
-		for {
-			select {
-			case msg, ok := <-logCh:
-				if !ok {
-					continue nextLogCh
-				}
-				if err := apiGetLogsServer.Send(msg); err != nil {
-					return err
-				}
-			case err := <-errCh:
-				return err
+
+	// Parse pod's log lines, and filter out irrelevant ones. If we're only
+	// returning the last request.Tail lines they're buffered.
+	var tail []*pps.LogMessage
+	lines := 0
+	scanner := bufio.NewScanner(bytes.NewReader(fullLogs))
+	for scanner.Scan() {
+		lines++
+		msg := parse(scanner.Bytes())
+		if msg == nil {
+			continue
+		}
+		if request.Tail > 0 {
+			tail = append(tail, msg)
+			if int64(len(tail)) > request.Tail {
+				tail = tail[1:]
 			}
+			continue
+		}
+		if !send(msg) {
+			return nil
 		}
 	}
-	return nil
+	for _, msg := range tail {
+		if !send(msg) {
+			return nil
+		}
+	}
+	closeLogCh()
+	if !request.Follow {
+		return nil
+	}
+	logCh = followCh
+
+	// Follow the pod's logs, skipping the lines which have already been read
+	stream, err := a.kubeClient.Pods(a.namespace).GetLogs(
+		pod.ObjectMeta.Name, &api.PodLogOptions{
+			Container: client.PPSWorkerUserContainerName,
+			Follow:    true,
+		}).Stream()
+	if err != nil {
+		return err
+	}
+	go func() {
+		select {
+		case <-done:
+		case <-ctx.Done():
+		}
+		stream.Close()
+	}()
+	scanner = bufio.NewScanner(stream)
+	for scanner.Scan() {
+		if lines > 0 {
+			lines--
+			continue
+		}
+		msg := parse(scanner.Bytes())
+		if msg == nil {
+			continue
+		}
+		if !send(msg) {
+			return nil
+		}
+	}
+	select {
+	case <-ctx.Done():
+		// the stream was closed because the call was cancelled
+		return nil
+	default:
+	}
+	return scanner.Err()
+}
+
+// parseLogLine parses a line logged by a worker, returning nil if it doesn't
+// match request's filters. Lines which can't be parsed are always returned.
+func parseLogLine(logBytes []byte, request *pps.GetLogsRequest) *pps.LogMessage {
+	msg := new(pps.LogMessage)
+	if err := jsonpb.Unmarshal(bytes.NewReader(logBytes), msg); err != nil {
+		protolion.Errorf("Error parsing log message: %+v", err)
+		msg.Message = string(logBytes)
+		return msg
+	}
+
+	// Filter out log lines that don't match on pipeline, job or datum
+	if request.Pipeline != nil && request.Pipeline.Name != msg.PipelineName {
+		return nil
+	}
+	if request.Job != nil && request.Job.ID != msg.JobID {
+		return nil
+	}
+	if request.DatumID != "" && request.DatumID != msg.DatumID {
+		return nil
+	}
+	if !workerpkg.MatchDatum(request.DataFilters, msg.Data) {
+		return nil
+	}
+
+	// Log message passes all filters -- return it
+	return msg
+}
+
+// validateEgress checks that egress names where to write output to, and that
+// pachd lets pipelines write it into databases if it's a SQL egress.
+func (a *apiServer) validateEgress(egress *pps.Egress) error {
+	if egress == nil {
+		return nil
+	}
+	if egress.URL != "" && egress.SQL != nil {
+		return fmt.Errorf("egress can't set both a URL and sql")
+	}
+	if egress.SQL == nil {
+		return nil
+	}
+	if !a.sqlEgress {
+		return fmt.Errorf("pipelines can't use SQL egress, as pachd wasn't deployed with --sql-egress")
+	}
+	return sqldb.ValidateEgress(egress.SQL)
 }
 
 func (a *apiServer) validatePipeline(ctx context.Context, pipelineInfo *pps.PipelineInfo) error {
-	return a.validateInput(ctx, pipelineInfo.Input, false)
+	if err := validateTransform(pipelineInfo.Transform); err != nil {
+		return err
+	}
+	if err := a.validateImage(pipelineInfo.Transform); err != nil {
+		return err
+	}
+	if err := validateResources(pipelineInfo.ResourceSpec, pipelineInfo.ResourceLimits); err != nil {
+		return err
+	}
+	if err := validateParallelism(pipelineInfo.ParallelismSpec); err != nil {
+		return err
+	}
+	if err := validateScheduling(pipelineInfo.SchedulingSpec); err != nil {
+		return err
+	}
+	if err := a.validatePolicies(pipelineInfo.Transform, pipelineInfo.ResourceSpec, pipelineInfo.ResourceLimits); err != nil {
+		return fmt.Errorf("pipeline %s violates the cluster's policy: %v", pipelineInfo.Pipeline.Name, err)
+	}
+	if err := a.validateDAG(ctx, pipelineInfo); err != nil {
+		return err
+	}
+	if err := a.validateInput(ctx, pipelineInfo.Input, false); err != nil {
+		return err
+	}
+	if err := a.validateEgress(pipelineInfo.Egress); err != nil {
+		return err
+	}
 	if pipelineInfo.OutputBranch == "" {
 		return fmt.Errorf("pipeline needs to specify an output branch")
 	}
+	if pipelineInfo.DatumCacheTTL != nil {
+		ttl, err := types.DurationFromProto(pipelineInfo.DatumCacheTTL)
+		if err != nil {
+			return err
+		}
+		if ttl <= 0 {
+			return fmt.Errorf("datum cache TTL must be positive")
+		}
+	}
 	return nil
 }
 
+// validateDAG checks that pipelineInfo's input doesn't depend on its output
+// repo, either directly, on any of its branches, or through the pipelines
+// downstream of it, since the pipeline would wait for its own output, and so
+// never run. The error names the edge that closes the cycle.
+func (a *apiServer) validateDAG(ctx context.Context, pipelineInfo *pps.PipelineInfo) error {
+	name := pipelineInfo.Pipeline.Name
+	// inputs maps each pipeline to the repos that it takes as input, with
+	// pipelineInfo in place of the version of it that exists, if any
+	inputs := make(map[string][]string)
+	iter, err := a.pipelines.ReadOnly(ctx).List()
+	if err != nil {
+		return err
+	}
+	for {
+		var pipelineName string
+		existing := new(pps.PipelineInfo)
+		ok, err := iter.Next(&pipelineName, existing)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		if existing.Input == nil {
+			existing.Input = translatePipelineInputs(existing.Inputs)
+		}
+		inputs[existing.Pipeline.Name] = inputRepos(existing.Input)
+	}
+	inputs[name] = inputRepos(pipelineInfo.Input)
+
+	// path is the chain of pipelines, each taking the next one's output as
+	// input, that's being searched for a way back to pipelineInfo
+	path := []string{name}
+	visited := make(map[string]bool)
+	var search func(pipeline string) bool
+	search = func(pipeline string) bool {
+		for _, repo := range inputs[pipeline] {
+			if repo == name {
+				path = append(path, repo)
+				return true
+			}
+			if _, ok := inputs[repo]; !ok || visited[repo] {
+				continue
+			}
+			visited[repo] = true
+			path = append(path, repo)
+			if search(repo) {
+				return true
+			}
+			path = path[:len(path)-1]
+		}
+		return false
+	}
+	if !search(name) {
+		return nil
+	}
+	if len(path) == 2 {
+		return fmt.Errorf("pipeline %s takes its own output repo as input, so it would never run", name)
+	}
+	return fmt.Errorf("pipeline %s's input depends on its own output, so it would never run: pipeline %s takes %s as input, closing the cycle %s",
+		name, path[len(path)-2], name, strings.Join(path, " <- "))
+}
+
+// inputRepos returns the repos of input's atom and SQL inputs.
+func inputRepos(input *pps.Input) []string {
+	var result []string
+	for _, commit := range inputCommits(input) {
+		result = append(result, commit.Repo.Name)
+	}
+	return result
+}
+
 func translatePipelineInputs(inputs []*pps.PipelineInput) *pps.Input {
 	result := &pps.Input{}
 	for _, input := range inputs {
@@ -750,11 +1799,9 @@ func translatePipelineInputs(inputs []*pps.PipelineInput) *pps.Input {
 	return result
 }
 
-func (a *apiServer) CreatePipeline(ctx context.Context, request *pps.CreatePipelineRequest) (response *types.Empty, retErr error) {
-	func() { a.Log(request, nil, nil, 0) }()
-	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
-	metricsFn := metrics.ReportUserAction(ctx, a.reporter, "CreatePipeline")
-	defer func(start time.Time) { metricsFn(start, retErr) }(time.Now())
+// newPipelineInfo returns the PipelineInfo of the pipeline that request
+// describes, with its defaults filled in and validated.
+func (a *apiServer) newPipelineInfo(ctx context.Context, request *pps.CreatePipelineRequest) (*pps.PipelineInfo, error) {
 	// First translate Inputs field to Input field.
 	if len(request.Inputs) > 0 {
 		if request.Input != nil {
@@ -772,15 +1819,45 @@ func (a *apiServer) CreatePipeline(ctx context.Context, request *pps.CreatePipel
 		Input:              request.Input,
 		OutputBranch:       request.OutputBranch,
 		Egress:             request.Egress,
-		CreatedAt:          now(),
+		CreatedAt:          clock.Now(),
 		ScaleDownThreshold: request.ScaleDownThreshold,
 		ResourceSpec:       request.ResourceSpec,
+		ResourceLimits:     request.ResourceLimits,
 		Description:        request.Description,
+		Author:             authserver.Subject(ctx),
+		Salt:               request.Salt,
+		DatumCacheTTL:      request.DatumCacheTTL,
+		SchedulingSpec:     request.SchedulingSpec,
+		// updating a pipeline reprocesses its datums anyway if it was on
+		// an older version of the hash, so updated pipelines move to the
+		// current one
+		DatumHashVersion: workerpkg.DatumHashVersion,
 	}
 	setPipelineDefaults(pipelineInfo)
+	pipelineInfo.ResourceSpec, pipelineInfo.ResourceLimits = a.workerResources.applyDefaults(pipelineInfo.ResourceSpec, pipelineInfo.ResourceLimits)
 	if err := a.validatePipeline(ctx, pipelineInfo); err != nil {
 		return nil, err
 	}
+	sortInput(pipelineInfo.Input)
+	return pipelineInfo, nil
+}
+
+func (a *apiServer) CreatePipeline(ctx context.Context, request *pps.CreatePipelineRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	metricsFn := metrics.ReportUserAction(ctx, a.reporter, "CreatePipeline")
+	defer func(start time.Time) { metricsFn(start, retErr) }(time.Now())
+
+	pipelineInfo, err := a.newPipelineInfo(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if request.DryRun {
+		if _, err := a.dryRunPipeline(ctx, pipelineInfo, request.Update); err != nil {
+			return nil, err
+		}
+		return &types.Empty{}, nil
+	}
 
 	pfsClient, err := a.getPFSClient()
 	if err != nil {
@@ -789,8 +1866,21 @@ func (a *apiServer) CreatePipeline(ctx context.Context, request *pps.CreatePipel
 
 	pipelineName := pipelineInfo.Pipeline.Name
 
-	sortInput(pipelineInfo.Input)
 	if request.Update {
+		// An update that doesn't change the pipeline's spec, e.g. the same
+		// spec being applied again, leaves the pipeline as it is, rather
+		// than making a new version of it that processes its input again.
+		// Stopped and failed pipelines are still updated, as applying their
+		// spec again is how they're restarted.
+		currentPipelineInfo := new(pps.PipelineInfo)
+		if err := a.pipelines.ReadOnly(ctx).Get(pipelineName, currentPipelineInfo); err != nil {
+			return nil, err
+		}
+		if !currentPipelineInfo.Stopped &&
+			currentPipelineInfo.State != pps.PipelineState_PIPELINE_FAILURE &&
+			samePipelineSpec(currentPipelineInfo, pipelineInfo) {
+			return &types.Empty{}, nil
+		}
 		if _, err := a.StopPipeline(ctx, &pps.StopPipelineRequest{request.Pipeline}); err != nil {
 			return nil, err
 		}
@@ -801,6 +1891,10 @@ func (a *apiServer) CreatePipeline(ctx context.Context, request *pps.CreatePipel
 				return err
 			}
 			pipelineInfo.Version = oldPipelineInfo.Version + 1
+			// the pipeline's outputs stay cleared, since the datums of an
+			// update that doesn't change what the pipeline computes hash the
+			// same, and would reuse them otherwise
+			pipelineInfo.DatumCacheInvalidations = oldPipelineInfo.DatumCacheInvalidations
 			pipelines.Put(pipelineName, pipelineInfo)
 			return nil
 		})
@@ -854,6 +1948,9 @@ func (a *apiServer) CreatePipeline(ctx context.Context, request *pps.CreatePipel
 	// because it's a very common pattern to create many pipelines in a
 	// row, some of which depend on the existence of the output repos
 	// of upstream pipelines.
+	if err := a.createSQLInputRepos(ctx, pfsClient, pipelineInfo); err != nil {
+		return nil, err
+	}
 	var provenance []*pfs.Repo
 	for _, commit := range inputCommits(pipelineInfo.Input) {
 		provenance = append(provenance, commit.Repo)
@@ -869,6 +1966,52 @@ func (a *apiServer) CreatePipeline(ctx context.Context, request *pps.CreatePipel
 	return &types.Empty{}, err
 }
 
+// samePipelineSpec returns true if the pipelines a and b have the same spec,
+// i.e. if they differ only in the fields that pachd sets, such as their
+// versions and states.
+func samePipelineSpec(a *pps.PipelineInfo, b *pps.PipelineInfo) bool {
+	spec := func(pipelineInfo *pps.PipelineInfo) *pps.PipelineInfo {
+		result := proto.Clone(pipelineInfo).(*pps.PipelineInfo)
+		result.ID = ""
+		result.Version = 0
+		result.CreatedAt = nil
+		result.State = 0
+		result.RecentError = ""
+		result.JobCounts = nil
+		result.Stopped = false
+		result.Author = ""
+		result.DatumCacheInvalidations = nil
+		return result
+	}
+	return proto.Equal(spec(a), spec(b))
+}
+
+// restoreJobOutput moves the output branch of the pipeline back to the
+// output commit of jobInfo, one of its jobs, whose input one of the
+// pipeline's input branches has moved back to. Jobs that haven't succeeded
+// have no output commit, and are left alone. It returns false if the output
+// commit has been deleted, e.g. because one of its input commits was, and
+// with it its downstream commits, in which case the input needs to be
+// processed again.
+func (a *apiServer) restoreJobOutput(ctx context.Context, pfsClient pfs.APIClient, pipelineInfo *pps.PipelineInfo, jobInfo *pps.JobInfo) (bool, error) {
+	if jobInfo.OutputCommit == nil {
+		return true, nil
+	}
+	if _, err := pfsClient.InspectCommit(ctx, &pfs.InspectCommitRequest{Commit: jobInfo.OutputCommit}); err != nil {
+		if isNotFoundErr(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if _, err := pfsClient.SetBranch(ctx, &pfs.SetBranchRequest{
+		Commit: jobInfo.OutputCommit,
+		Branch: pipelineInfo.OutputBranch,
+	}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // setPipelineDefaults sets the default values for a pipeline info
 func setPipelineDefaults(pipelineInfo *pps.PipelineInfo) {
 	visit(pipelineInfo.Input, func(input *pps.Input) {
@@ -880,6 +2023,14 @@ func setPipelineDefaults(pipelineInfo *pps.PipelineInfo) {
 				input.Atom.Name = input.Atom.Repo
 			}
 		}
+		if input.SQL != nil {
+			if input.SQL.Repo == "" && input.SQL.Name != "" && pipelineInfo.Pipeline != nil {
+				input.SQL.Repo = ppsserver.SQLInputRepo(pipelineInfo.Pipeline, input.SQL).Name
+			}
+			if input.SQL.Format == "" {
+				input.SQL.Format = "csv"
+			}
+		}
 	})
 	if pipelineInfo.OutputBranch == "" {
 		// Output branches default to master
@@ -970,11 +2121,10 @@ func (a *apiServer) DeletePipeline(ctx context.Context, request *pps.DeletePipel
 					}
 					// We need to check again here because the job's state
 					// might've changed since we first retrieved it
-					if !jobStateToStopped(jobInfo.State) {
-						jobInfo.State = pps.JobState_JOB_STOPPED
+					if jobStateToStopped(jobInfo.State) {
+						return nil
 					}
-					jobs.Put(jobID, &jobInfo)
-					return nil
+					return a.updateJobState(stm, &jobInfo, pps.JobState_JOB_STOPPED)
 				}); err != nil {
 					return nil, err
 				}
@@ -994,7 +2144,13 @@ func (a *apiServer) DeletePipeline(ctx context.Context, request *pps.DeletePipel
 			protolion.Errorf("error deleting workers for pipeline: %v", pipelineName)
 		}
 		protolion.Infof("deleted workers for pipeline: %v", pipelineName)
-		return a.pipelines.ReadWrite(stm).Delete(request.Pipeline.Name)
+		if err := a.pipelines.ReadWrite(stm).Delete(request.Pipeline.Name); err != nil {
+			return err
+		}
+		stm.Del(a.outputBranchJobKey(&pfs.Repo{pipelineName}, pipelineInfo.OutputBranch))
+		stm.DelAll(path.Join(a.etcdPrefix, pipelineJobsPrefix, pipelineInfo.ID) + "/")
+		stm.DelAll(path.Join(a.etcdPrefix, pipelineTriggersPrefix, pipelineInfo.ID) + "/")
+		return nil
 	}); err != nil {
 		return nil, err
 	}
@@ -1022,16 +2178,230 @@ func (a *apiServer) StopPipeline(ctx context.Context, request *pps.StopPipelineR
 	if err := a.updatePipelineState(ctx, request.Pipeline.Name, pps.PipelineState_PIPELINE_STOPPED); err != nil {
 		return nil, err
 	}
-	return &types.Empty{}, nil
-}
-
-func (a *apiServer) RerunPipeline(ctx context.Context, request *pps.RerunPipelineRequest) (response *types.Empty, retErr error) {
-	func() { a.Log(request, nil, nil, 0) }()
-	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
-	metricsFn := metrics.ReportUserAction(ctx, a.reporter, "RerunPipeline")
-	defer func(start time.Time) { metricsFn(start, retErr) }(time.Now())
-
-	return nil, fmt.Errorf("TODO")
+	return &types.Empty{}, nil
+}
+
+func (a *apiServer) RerunPipeline(ctx context.Context, request *pps.RerunPipelineRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	metricsFn := metrics.ReportUserAction(ctx, a.reporter, "RerunPipeline")
+	defer func(start time.Time) { metricsFn(start, retErr) }(time.Now())
+
+	return nil, fmt.Errorf("TODO")
+}
+
+func (a *apiServer) ClearDatumCache(ctx context.Context, request *pps.ClearDatumCacheRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	metricsFn := metrics.ReportUserAction(ctx, a.reporter, "ClearDatumCache")
+	defer func(start time.Time) { metricsFn(start, retErr) }(time.Now())
+
+	if request.Glob != "" {
+		if _, err := path.Match(request.Glob, ""); err != nil {
+			return nil, fmt.Errorf("glob \"%s\" is malformed", request.Glob)
+		}
+	}
+	invalidation := &pps.DatumCacheInvalidation{
+		ID:      uuid.NewWithoutDashes(),
+		Glob:    request.Glob,
+		Created: clock.Now(),
+	}
+	_, err := col.NewSTM(ctx, a.etcdClient, func(stm col.STM) error {
+		pipelines := a.pipelines.ReadWrite(stm)
+		pipelineInfo := new(pps.PipelineInfo)
+		if err := pipelines.Get(request.Pipeline.Name, pipelineInfo); err != nil {
+			return err
+		}
+		// clearing every datum's output supersedes the earlier
+		// invalidations, which keeps the list short
+		if invalidation.Glob == "" {
+			pipelineInfo.DatumCacheInvalidations = nil
+		}
+		pipelineInfo.DatumCacheInvalidations = append(pipelineInfo.DatumCacheInvalidations, invalidation)
+		pipelines.Put(request.Pipeline.Name, pipelineInfo)
+		return nil
+	})
+	if isNotFoundErr(err) {
+		return nil, newErrPipelineNotFound(request.Pipeline.Name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+func (a *apiServer) RunPipeline(ctx context.Context, request *pps.RunPipelineRequest) (response *pps.Job, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	metricsFn := metrics.ReportUserAction(ctx, a.reporter, "RunPipeline")
+	defer func(start time.Time) { metricsFn(start, retErr) }(time.Now())
+
+	return a.runPipeline(ctx, request.Pipeline, request.Provenance, "")
+}
+
+func (a *apiServer) TriggerPipeline(ctx context.Context, request *pps.TriggerPipelineRequest) (response *pps.Job, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	metricsFn := metrics.ReportUserAction(ctx, a.reporter, "TriggerPipeline")
+	defer func(start time.Time) { metricsFn(start, retErr) }(time.Now())
+
+	if request.Key == "" {
+		return nil, fmt.Errorf("a trigger key must be given")
+	}
+	// A retried trigger returns the job that it created the first time
+	// without resolving its inputs again, which may no longer be possible
+	// (e.g. if a commit it was given has since been deleted). createJob
+	// checks the key again, in case another trigger with it is racing
+	// this one.
+	if job, err := a.triggeredJob(ctx, request.Pipeline, request.Key); err != nil {
+		return nil, err
+	} else if job != nil {
+		return job, nil
+	}
+	return a.runPipeline(ctx, request.Pipeline, request.Provenance, request.Key)
+}
+
+func (a *apiServer) InspectTrigger(ctx context.Context, request *pps.InspectTriggerRequest) (response *pps.JobInfo, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	metricsFn := metrics.ReportUserAction(ctx, a.reporter, "InspectTrigger")
+	defer func(start time.Time) { metricsFn(start, retErr) }(time.Now())
+
+	job, err := a.triggeredJob(ctx, request.Pipeline, request.Key)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return nil, fmt.Errorf("pipeline %s hasn't been triggered with key %q", request.Pipeline.Name, request.Key)
+	}
+	return a.InspectJob(ctx, &pps.InspectJobRequest{
+		Job:        job,
+		BlockState: request.BlockState,
+	})
+}
+
+// triggeredJob returns the job that pipeline created when it was triggered
+// with triggerKey, or nil if it hasn't been triggered with it, or the job has
+// since been deleted.
+func (a *apiServer) triggeredJob(ctx context.Context, pipeline *pps.Pipeline, triggerKey string) (*pps.Job, error) {
+	pipelineInfo := new(pps.PipelineInfo)
+	if err := a.pipelines.ReadOnly(ctx).Get(pipeline.Name, pipelineInfo); err != nil {
+		return nil, err
+	}
+	resp, err := a.etcdClient.Get(ctx, a.pipelineTriggerKey(pipelineInfo.ID, triggerKey))
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, nil
+	}
+	job := &pps.Job{string(resp.Kvs[0].Value)}
+	if err := a.jobs.ReadOnly(ctx).Get(job.ID, new(pps.JobInfo)); err != nil {
+		if isNotFoundErr(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return job, nil
+}
+
+// runPipeline creates a job for pipeline on the input commits in provenance,
+// and the heads of the branches of its other inputs. If triggerKey is set,
+// the job is created once per key, see createJob.
+func (a *apiServer) runPipeline(ctx context.Context, pipeline *pps.Pipeline, provenance []*pfs.Commit, triggerKey string) (*pps.Job, error) {
+	pipelineInfo := new(pps.PipelineInfo)
+	if err := a.pipelines.ReadOnly(ctx).Get(pipeline.Name, pipelineInfo); err != nil {
+		return nil, err
+	}
+	if pipelineInfo.Input == nil {
+		pipelineInfo.Input = translatePipelineInputs(pipelineInfo.Inputs)
+	}
+	pfsClient, err := a.getPFSClient()
+	if err != nil {
+		return nil, err
+	}
+
+	provenanceByRepo := make(map[string]*pfs.Commit)
+	for _, commit := range provenance {
+		if _, ok := provenanceByRepo[commit.Repo.Name]; ok {
+			return nil, fmt.Errorf("multiple commits given for repo %s", commit.Repo.Name)
+		}
+		provenanceByRepo[commit.Repo.Name] = commit
+	}
+	used := make(map[string]bool)
+	jobInput, err := headInput(ctx, pfsClient, pipelineInfo.Input, provenanceByRepo, used)
+	if err != nil {
+		return nil, err
+	}
+	for repo := range provenanceByRepo {
+		if !used[repo] {
+			return nil, fmt.Errorf("%s is not an input of pipeline %s", repo, pipeline.Name)
+		}
+	}
+
+	if triggerKey == "" {
+		return a.CreateJob(ctx, &pps.CreateJobRequest{
+			Pipeline: pipelineInfo.Pipeline,
+			Input:    jobInput,
+		})
+	}
+	return a.createJob(ctx, &pps.CreateJobRequest{
+		Pipeline: pipelineInfo.Pipeline,
+		Input:    jobInput,
+	}, false, triggerKey)
+}
+
+// headInput returns a job's input for a pipeline's input, with the input
+// commits filled in, resolving branch names to the commits they're currently
+// pointing at so that the job's provenance is stable. Inputs whose repos have
+// a commit in provenanceByRepo use it instead, and their repos are recorded
+// in used.
+func headInput(ctx context.Context, pfsClient pfs.APIClient, input *pps.Input, provenanceByRepo map[string]*pfs.Commit, used map[string]bool) (*pps.Input, error) {
+	jobInput := proto.Clone(input).(*pps.Input)
+	var visitErr error
+	visit(jobInput, func(input *pps.Input) {
+		if input.SQL != nil && visitErr == nil {
+			// SQL inputs' snapshots are committed to master
+			commitID := "master"
+			if commit, ok := provenanceByRepo[input.SQL.Repo]; ok {
+				used[input.SQL.Repo] = true
+				if commit.ID != "" {
+					commitID = commit.ID
+				}
+			}
+			commitInfo, err := pfsClient.InspectCommit(ctx, &pfs.InspectCommitRequest{
+				Commit: client.NewCommit(input.SQL.Repo, commitID),
+			})
+			if err != nil {
+				visitErr = err
+				return
+			}
+			input.SQL.Commit = commitInfo.Commit.ID
+		}
+		if input.Atom == nil || visitErr != nil {
+			return
+		}
+		commitID := input.Atom.Branch
+		if commit, ok := provenanceByRepo[input.Atom.Repo]; ok {
+			used[input.Atom.Repo] = true
+			if commit.ID != "" {
+				commitID = commit.ID
+			}
+		}
+		commitInfo, err := pfsClient.InspectCommit(ctx, &pfs.InspectCommitRequest{
+			Commit: client.NewCommit(input.Atom.Repo, commitID),
+		})
+		if err != nil {
+			visitErr = err
+			return
+		}
+		input.Atom.Commit = commitInfo.Commit.ID
+		input.Atom.FromCommit = ""
+	})
+	if visitErr != nil {
+		return nil, visitErr
+	}
+	return jobInput, nil
 }
 
 func (a *apiServer) DeleteAll(ctx context.Context, request *types.Empty) (response *types.Empty, retErr error) {
@@ -1155,7 +2525,10 @@ func (a *apiServer) pipelineWatcher(ctx context.Context, shard uint64) {
 				pipelineCtx, cancel := context.WithCancel(ctx)
 				a.setPipelineCancel(pipelineName, cancel)
 				protolion.Infof("launching pipeline manager for pipeline %s", pipelineInfo.Pipeline.Name)
-				go a.pipelineManager(pipelineCtx, &pipelineInfo)
+				a.masters.Add(1)
+				go a.runMaster(pipelineCtx, path.Join("pipelines", pipelineName), func(ctx context.Context) {
+					a.pipelineManager(ctx, &pipelineInfo)
+				})
 			case watch.EventDelete:
 				if cancel := a.deletePipelineCancel(pipelineName); cancel != nil {
 					protolion.Infof("cancelling pipeline: %s", pipelineName)
@@ -1214,7 +2587,10 @@ func (a *apiServer) jobWatcher(ctx context.Context, shard uint64) {
 				jobCtx, cancel := context.WithCancel(ctx)
 				a.setJobCancel(jobID, cancel)
 				protolion.Infof("launching job manager for job %s", jobInfo.Job.ID)
-				go a.jobManager(jobCtx, &jobInfo)
+				a.masters.Add(1)
+				go a.runMaster(jobCtx, path.Join("jobs", jobID), func(ctx context.Context) {
+					a.jobManager(ctx, &jobInfo)
+				})
 			case watch.EventDelete:
 				if cancel := a.deleteJobCancel(jobID); cancel != nil {
 					cancel()
@@ -1234,6 +2610,48 @@ func (a *apiServer) jobWatcher(ctx context.Context, shard uint64) {
 	})
 }
 
+// runMaster runs f, the master of a pipeline or job, while holding the lock
+// of that master, until ctx is done. The watchers that launch masters only
+// launch those in their pachd's shards, but during a change of membership two
+// pachds may briefly both own a shard, and the lock keeps them from running
+// the same master at once. If the lock is lost (because this pachd lost its
+// connection to etcd for long enough that another pachd may have taken over),
+// f is cancelled, and run again once the lock has been reacquired.
+//
+// The caller must call a.masters.Add(1) before runMaster, which calls
+// a.masters.Done() once the master has stopped and released its lock.
+func (a *apiServer) runMaster(ctx context.Context, name string, f func(ctx context.Context)) {
+	defer a.masters.Done()
+	lock := dlock.NewDLock(a.etcdClient, path.Join(a.etcdPrefix, masterLocksPrefix, name))
+	b := backoff.NewInfiniteBackOff()
+	backoff.RetryNotify(func() error {
+		lockCtx, err := lock.Lock(ctx)
+		if err != nil {
+			return err
+		}
+		f(lockCtx)
+		// f returns on its own once it's done, which is the case unless it
+		// returned because the lock was lost
+		lost := lockCtx.Err() != nil && ctx.Err() == nil
+		if err := lock.Unlock(context.Background()); err != nil {
+			protolion.Errorf("error releasing the lock of master %s: %v", name, err)
+		}
+		if lost {
+			return fmt.Errorf("lost the lock of master %s", name)
+		}
+		return nil
+	}, b, func(err error, d time.Duration) error {
+		select {
+		case <-ctx.Done():
+			// Exit the retry loop if context got cancelled
+			return err
+		default:
+		}
+		protolion.Errorf("error running master %s: %v; retrying in %v", name, err, d)
+		return nil
+	})
+}
+
 func isAlreadyExistsErr(err error) bool {
 	return err != nil && strings.Contains(err.Error(), "already exists")
 }
@@ -1308,33 +2726,58 @@ func (a *apiServer) numWorkers(ctx context.Context, rcName string) (int, error)
 	return int(workerRC.Spec.Replicas), nil
 }
 
-func (a *apiServer) scaleDownWorkers(ctx context.Context, rcName string) error {
+// scaleDownWorkers scales the RC rcName down to the workers that it keeps
+// while it has no jobs to run, see idleWorkers.
+func (a *apiServer) scaleDownWorkers(ctx context.Context, rcName string, parallelismSpec *pps.ParallelismSpec) error {
 	rc := a.kubeClient.ReplicationControllers(a.namespace)
 	workerRc, err := rc.Get(rcName)
 	if err != nil {
 		return err
 	}
-	workerRc.Spec.Replicas = 0
+	workerRc.Spec.Replicas = idleWorkers(parallelismSpec)
 	_, err = rc.Update(workerRc)
 	return err
 }
 
-func (a *apiServer) scaleUpWorkers(ctx context.Context, rcName string, parallelismSpec *pps.ParallelismSpec) error {
+// scaleUpWorkers scales the RC rcName up to the workers that a job with
+// numDatums datums needs. Autoscaled workers are only ever scaled up here,
+// so that a small job doesn't take workers away from a bigger one that's
+// still running. If the cluster's number of workers is capped, the job
+// queues for them under schedulingSpec, see scaleUpWorkersWithinQuota.
+func (a *apiServer) scaleUpWorkers(ctx context.Context, rcName string, parallelismSpec *pps.ParallelismSpec, schedulingSpec *pps.SchedulingSpec, numDatums int) error {
+	var parallelism int32
+	if parallelismSpec.GetStrategy() == pps.ParallelismSpec_AUTOSCALE {
+		parallelism = autoscaleWorkers(parallelismSpec, numDatums)
+		current, err := a.numWorkers(ctx, rcName)
+		if err != nil {
+			return err
+		}
+		if int32(current) >= parallelism {
+			return nil
+		}
+	} else {
+		expected, err := GetExpectedNumWorkers(a.kubeClient, parallelismSpec)
+		if err != nil {
+			return err
+		}
+		parallelism = int32(expected)
+	}
+	if a.maxWorkers > 0 {
+		return a.scaleUpWorkersWithinQuota(ctx, rcName, parallelism, schedulingSpec)
+	}
 	rc := a.kubeClient.ReplicationControllers(a.namespace)
 	workerRc, err := rc.Get(rcName)
 	if err != nil {
 		return err
 	}
-	parallelism, err := GetExpectedNumWorkers(a.kubeClient, parallelismSpec)
-	if err != nil {
-		return err
-	}
-	workerRc.Spec.Replicas = int32(parallelism)
+	workerRc.Spec.Replicas = parallelism
 	_, err = rc.Update(workerRc)
 	return err
 }
 
-func (a *apiServer) workerServiceIP(ctx context.Context, deploymentName string) (string, error) {
+// workerServiceAddress returns the address of the service in front of the
+// workers of deploymentName.
+func (a *apiServer) workerServiceAddress(ctx context.Context, deploymentName string) (string, error) {
 	service, err := a.kubeClient.Services(a.namespace).Get(deploymentName)
 	if err != nil {
 		return "", err
@@ -1342,7 +2785,27 @@ func (a *apiServer) workerServiceIP(ctx context.Context, deploymentName string)
 	if service.Spec.ClusterIP == "" {
 		return "", fmt.Errorf("IP not assigned")
 	}
-	return service.Spec.ClusterIP, nil
+	return net.JoinHostPort(service.Spec.ClusterIP, strconv.Itoa(int(servicePort(service, a.workerPort)))), nil
+}
+
+// workerServicePort returns the port that the workers of deploymentName
+// listen on. That's their service's port, as workers that were created
+// before pachd's worker port was changed keep listening on the old one.
+func (a *apiServer) workerServicePort(deploymentName string) (uint16, error) {
+	service, err := a.kubeClient.Services(a.namespace).Get(deploymentName)
+	if err != nil {
+		return 0, err
+	}
+	return servicePort(service, a.workerPort), nil
+}
+
+// servicePort returns the port of a workers' service, or defaultPort if it
+// doesn't have one.
+func servicePort(service *api.Service, defaultPort uint16) uint16 {
+	if len(service.Spec.Ports) == 0 {
+		return defaultPort
+	}
+	return uint16(service.Spec.Ports[0].Port)
 }
 
 func (a *apiServer) pipelineManager(ctx context.Context, pipelineInfo *pps.PipelineInfo) {
@@ -1369,6 +2832,9 @@ func (a *apiServer) pipelineManager(ctx context.Context, pipelineInfo *pps.Pipel
 		if err != nil {
 			return err
 		}
+		if err := a.createSQLInputRepos(ctx, pfsClient, pipelineInfo); err != nil {
+			return err
+		}
 		// Create the output repo; if it already exists, do nothing
 		if _, err := pfsClient.CreateRepo(ctx, &pfs.CreateRepoRequest{
 			Repo:       &pfs.Repo{pipelineName},
@@ -1378,6 +2844,8 @@ func (a *apiServer) pipelineManager(ctx context.Context, pipelineInfo *pps.Pipel
 				return err
 			}
 		}
+		// Snapshot the pipeline's SQL inputs until it's stopped
+		go a.runSQLInputs(ctx, pipelineInfo)
 
 		// Create a k8s replication controller that runs the workers
 		if err := a.createWorkersForPipeline(pipelineInfo); err != nil {
@@ -1419,10 +2887,20 @@ func (a *apiServer) pipelineManager(ctx context.Context, pipelineInfo *pps.Pipel
 		for {
 			var branchSet *branchSet
 			select {
+			case <-ctx.Done():
+				// Return, rather than wait for a branch set that's never
+				// sent, so that the master's lock is released
+				return ctx.Err()
 			case branchSet = <-branchSetFactory.Chan():
 			case completedJob := <-jobCompletionCh:
 				delete(runningJobSet, completedJob.ID)
 				if len(runningJobSet) == 0 {
+					// Workers that are still on an older version of
+					// pachyderm (see upgradeWorkerRc) can be restarted now
+					// without interrupting any of their datums
+					if err := a.restartOutdatedWorkers(PipelineRcName(pipelineInfo.Pipeline.Name, pipelineInfo.Version)); err != nil {
+						return err
+					}
 					// If the scaleDownThreshold is nil, we interpret it
 					// as "no scale down".  We then use a threshold of
 					// (practically) infinity.  This practically disables
@@ -1434,6 +2912,11 @@ func (a *apiServer) pipelineManager(ctx context.Context, pipelineInfo *pps.Pipel
 						if err != nil {
 							return err
 						}
+					} else if a.maxWorkers > 0 || pipelineInfo.ParallelismSpec.GetStrategy() == pps.ParallelismSpec_AUTOSCALE {
+						// Idle pipelines mustn't hold on to workers
+						// that other pipelines' jobs are waiting for,
+						// or that they were autoscaled to for a job
+						scaleDownThreshold = workerQuotaScaleDownThreshold
 					} else {
 						scaleDownThreshold = time.Duration(math.MaxInt64)
 					}
@@ -1457,16 +2940,27 @@ func (a *apiServer) pipelineManager(ctx context.Context, pipelineInfo *pps.Pipel
 			case <-scaleDownCh:
 				// We need to check if there's indeed no running job,
 				// because it might happen that the timer expired while
-				// we were creating a job.
+				// we were creating a job.  Jobs created by RunPipeline
+				// aren't created here, so we pick them up from etcd.
+				runningJobList, err := a.getRunningJobsForPipeline(ctx, pipelineInfo)
+				if err != nil {
+					return err
+				}
+				for _, job := range runningJobList {
+					if !runningJobSet[job.Job.ID] {
+						go a.watchJobCompletion(ctx, job.Job, jobCompletionCh)
+						runningJobSet[job.Job.ID] = true
+					}
+				}
 				if len(runningJobSet) == 0 {
-					if err := a.scaleDownWorkers(ctx, PipelineRcName(pipelineInfo.Pipeline.Name, pipelineInfo.Version)); err != nil {
+					if err := a.scaleDownWorkers(ctx, PipelineRcName(pipelineInfo.Pipeline.Name, pipelineInfo.Version), pipelineInfo.ParallelismSpec); err != nil {
 						return err
 					}
 				}
 				continue nextInput
 			}
 			if branchSet.Err != nil {
-				return err
+				return branchSet.Err
 			}
 
 			// (create JobInput for new processing job)
@@ -1484,6 +2978,16 @@ func (a *apiServer) pipelineManager(ctx context.Context, pipelineInfo *pps.Pipel
 					}
 					input.Atom.FromCommit = ""
 				}
+				if input.SQL != nil {
+					for _, branch := range branchSet.Branches {
+						if input.SQL.Repo == branch.Head.Repo.Name && branch.Name == "master" {
+							input.SQL.Commit = branch.Head.ID
+						}
+					}
+					if input.SQL.Commit == "" {
+						visitErr = fmt.Errorf("didn't find input commit for %s/master", input.SQL.Repo)
+					}
+				}
 			})
 			if visitErr != nil {
 				return visitErr
@@ -1504,6 +3008,7 @@ func (a *apiServer) pipelineManager(ctx context.Context, pipelineInfo *pps.Pipel
 
 			// Check if any of the jobs in jobIter have been run already. If so, skip
 			// this input.
+			var rerun bool
 			for {
 				var jobID string
 				var jobInfo pps.JobInfo
@@ -1521,19 +3026,32 @@ func (a *apiServer) pipelineManager(ctx context.Context, pipelineInfo *pps.Pipel
 					}
 				}
 				if jobInfo.PipelineID == pipelineInfo.ID && jobInfo.PipelineVersion == pipelineInfo.Version {
-					// TODO(derek): we should check if the output commit exists.  If the
-					// output commit has been deleted, we should re-run the job.
-					continue nextInput
+					if !branchSet.Rewound {
+						continue nextInput
+					}
+					// An input branch moved back to inputs that have
+					// been processed already, so the output branch moves
+					// back to their output, unless it's been deleted
+					restored, err := a.restoreJobOutput(ctx, pfsClient, pipelineInfo, &jobInfo)
+					if err != nil {
+						return err
+					}
+					if restored {
+						continue nextInput
+					}
+					rerun = true
 				}
 			}
 
-			job, err = a.CreateJob(ctx, &pps.CreateJobRequest{
+			// The check above is repeated when the job is created, in case
+			// the master of this pipeline on another pachd (which this one
+			// may have taken over from) has created it since, unless the
+			// input is being processed again because the output of the job
+			// that processed it has been deleted
+			job, err = a.createJob(ctx, &pps.CreateJobRequest{
 				Pipeline: pipelineInfo.Pipeline,
 				Input:    jobInput,
-				// TODO(derek): Note that once the pipeline restarts, the `job`
-				// variable is lost and we don't know who is our parent job.
-				ParentJob: job,
-			})
+			}, !rerun, "")
 			if err != nil {
 				return err
 			}
@@ -1609,6 +3127,9 @@ func (a *apiServer) updatePipelineState(ctx context.Context, pipelineName string
 }
 
 func (a *apiServer) updateJobState(stm col.STM, jobInfo *pps.JobInfo, state pps.JobState) error {
+	if !validJobTransition(jobInfo.State, state) {
+		return fmt.Errorf("job %s can't move from %s to %s", jobInfo.Job.ID, jobInfo.State, state)
+	}
 	// Update job counts
 	if jobInfo.Pipeline != nil {
 		pipelines := a.pipelines.ReadWrite(stm)
@@ -1632,8 +3153,34 @@ func (a *apiServer) updateJobState(stm col.STM, jobInfo *pps.JobInfo, state pps.
 	return nil
 }
 
+// waitForParentJob waits for jobInfo's parent job to finish, to ensure that
+// output commits are ordered as their jobs are, and that the job doesn't
+// contend for workers with its parent. A parent that's been deleted isn't
+// waited for.
+func (a *apiServer) waitForParentJob(ctx context.Context, jobInfo *pps.JobInfo) error {
+	if jobInfo.ParentJob == nil {
+		return nil
+	}
+	if err := a.jobs.ReadOnly(ctx).Get(jobInfo.ParentJob.ID, new(pps.JobInfo)); err != nil {
+		if isNotFoundErr(err) {
+			return nil
+		}
+		return err
+	}
+	// If the parent is deleted while it's waited for, this errors, and the
+	// retry finds that it's gone
+	_, err := a.InspectJob(ctx, &pps.InspectJobRequest{
+		Job:        jobInfo.ParentJob,
+		BlockState: true,
+	})
+	return err
+}
+
 func (a *apiServer) jobManager(ctx context.Context, jobInfo *pps.JobInfo) {
 	jobID := jobInfo.Job.ID
+	// the job is retried until it finishes, unless the watchdog decides
+	// that it's been orphaned
+	watchdog := newJobWatchdog(jobOrphanTimeout)
 	b := backoff.NewInfiniteBackOff()
 	backoff.RetryNotify(func() error {
 		// We use a new context for this particular instance of the retry
@@ -1642,27 +3189,14 @@ func (a *apiServer) jobManager(ctx context.Context, jobInfo *pps.JobInfo) {
 		ctx, cancel := context.WithCancel(ctx)
 		defer cancel()
 
-		if jobInfo.ParentJob != nil {
-			// Wait for the parent job to finish, to ensure that output
-			// commits are ordered correctly, and that this job doesn't
-			// contend for workers with its parent.
-			if _, err := a.InspectJob(ctx, &pps.InspectJobRequest{
-				Job:        jobInfo.ParentJob,
-				BlockState: true,
-			}); err != nil {
-				return err
-			}
+		if err := watchdog.wait(func() error { return a.waitForParentJob(ctx, jobInfo) }); err != nil {
+			return err
 		}
 
 		pfsClient, err := a.getPFSClient()
 		if err != nil {
 			return err
 		}
-		objectClient, err := a.getObjectClient()
-		if err != nil {
-			return err
-		}
-
 		// Create workers and output repo if 'jobInfo' belongs to an orphan job
 		if jobInfo.Pipeline == nil {
 			// Create output repo for this job
@@ -1699,117 +3233,156 @@ func (a *apiServer) jobManager(ctx context.Context, jobInfo *pps.JobInfo) {
 			}()
 		}
 
-		// Set the state of this job to 'RUNNING'
+		// the job's datums, which autoscaled workers are scaled up for
+		df, err := newDatumFactory(ctx, pfsClient, jobInfo.Input)
+		if err != nil {
+			return err
+		}
+		numDatums := df.Len()
+
+		// Start worker pool. We scale up the workers before we run a job,
+		// to ensure that the job will have workers to use.  Note that
+		// scaling a RC is idempotent: nothing happens if the workers have
+		// already been scaled. If the cluster's number of workers is
+		// capped, this waits until there are enough workers for the job,
+		// which stays JOB_STARTING until then.
+		var rcName string
+		if jobInfo.Pipeline != nil {
+			rcName = PipelineRcName(jobInfo.Pipeline.Name, jobInfo.PipelineVersion)
+		} else {
+			rcName = JobRcName(jobInfo.Job.ID)
+		}
+		if err := watchdog.wait(func() error {
+			return a.scaleUpWorkers(ctx, rcName, jobInfo.ParallelismSpec, jobInfo.SchedulingSpec, numDatums)
+		}); err != nil {
+			return err
+		}
+		if jobInfo.ParallelismSpec.GetStrategy() == pps.ParallelismSpec_AUTOSCALE {
+			go a.trimUnschedulableWorkers(ctx, rcName, jobInfo.ParallelismSpec)
+		}
+
+		// Set the state of this job to 'RUNNING', and forget any datums
+		// which failed in a previous attempt at it, once they've been
+		// written
+		if err := a.batcher.flush(ctx); err != nil {
+			return err
+		}
+		var stopped bool
+		// the metrics of the datums which were processed before this
+		// master took over the job, which aren't reported again
+		var userMetrics []*pps.UserMetric
 		_, err = col.NewSTM(ctx, a.etcdClient, func(stm col.STM) error {
 			jobs := a.jobs.ReadWrite(stm)
 			jobInfo := new(pps.JobInfo)
 			if err := jobs.Get(jobID, jobInfo); err != nil {
 				return err
 			}
+			// The job may have been finished by the master that this one
+			// has taken over from
+			if stopped = jobStateToStopped(jobInfo.State); stopped {
+				return nil
+			}
+			userMetrics = jobInfo.UserMetrics
+			a.datums(jobID).ReadWrite(stm).DeleteAll()
 			return a.updateJobState(stm, jobInfo, pps.JobState_JOB_RUNNING)
 		})
 		if err != nil {
 			return err
 		}
-
-		// Start worker pool
-		var rcName string
-		if jobInfo.Pipeline != nil {
-			// We scale up the workers before we run a job, to ensure
-			// that the job will have workers to use.  Note that scaling
-			// a RC is idempotent: nothing happens if the workers have
-			// already been scaled.
-			rcName = PipelineRcName(jobInfo.Pipeline.Name, jobInfo.PipelineVersion)
-			if err := a.scaleUpWorkers(ctx, rcName, jobInfo.ParallelismSpec); err != nil {
-				return err
-			}
-		} else {
-			rcName = JobRcName(jobInfo.Job.ID)
+		if stopped {
+			return nil
 		}
 
 		failed := false
+		// set if the watchdog decides that the job's been orphaned while
+		// its datums are processed
+		var orphaned int32
 		numWorkers, err := a.numWorkers(ctx, rcName)
 		if err != nil {
 			return err
 		}
 		limiter := limit.New(numWorkers)
-		// process all datums
-		df, err := newDatumFactory(ctx, pfsClient, jobInfo.Input)
-		if err != nil {
-			return err
-		}
-		tree := hashtree.NewHashTree()
-		var treeMu sync.Mutex
+		// the tags of the hashtrees that datums output, by datum index,
+		// which are merged once they've all been processed
+		var datumTags []indexedTag
+		var datumTagsMu sync.Mutex
 
 		processedData := int64(0)
-		setProcessedData := int64(0)
-		totalData := int64(df.Len())
+		// datums are generated as workers become free to process them,
+		// see datumFactory
+		totalData := int64(numDatums)
 		var progressMu sync.Mutex
-		updateProgress := func(processed int64) {
+		updateProgress := func(processed int64, metrics []*pps.UserMetric) {
 			progressMu.Lock()
 			defer progressMu.Unlock()
 			processedData += processed
-			// so as not to overwhelm etcd we update at most 100 times per job
-			if (float64(processedData-setProcessedData)/float64(totalData)) > .01 ||
-				processedData == 0 || processedData == totalData {
-				// we setProcessedData even though the update below may fail,
-				// if we didn't we'd retry updating the progress on the next
-				// datum, this would lead to more accurate progress but
-				// progress isn't that important and we don't want to overwelm
-				// etcd.
-				setProcessedData = processedData
-				if _, err := col.NewSTM(ctx, a.etcdClient, func(stm col.STM) error {
-					jobs := a.jobs.ReadWrite(stm)
-					jobInfo := new(pps.JobInfo)
-					if err := jobs.Get(jobID, jobInfo); err != nil {
-						return err
-					}
-					jobInfo.DataProcessed = processedData
-					jobInfo.DataTotal = totalData
-					jobs.Put(jobInfo.Job.ID, jobInfo)
-					return nil
-				}); err != nil {
-					protolion.Errorf("error updating job progress: %+v", err)
-				}
-			}
+			userMetrics = workerpkg.MergeUserMetrics(userMetrics, metrics)
+			// so as not to overwhelm etcd, progress is written with the
+			// progress of other jobs, see batcher
+			a.batcher.setProgress(jobID, processedData, totalData, userMetrics)
 		}
 		// set the initial values
-		updateProgress(0)
+		updateProgress(0, nil)
 
-		serviceAddr, err := a.workerServiceIP(ctx, rcName)
+		serviceAddr, err := a.workerServiceAddress(ctx, rcName)
 		if err != nil {
 			return err
 		}
-		pool := grpcutil.NewPool(fmt.Sprintf("%s:%d", serviceAddr, client.PPSWorkerPort), numWorkers, client.PachDialOptions()...)
+		pool := grpcutil.NewPool(serviceAddr, numWorkers, client.PachDialOptions()...)
 		defer func() {
 			if err := pool.Close(); err != nil {
 				protolion.Errorf("error closing pool: %+v", pool)
 			}
 		}()
-		for i := 0; i < df.Len(); i++ {
+		pauser := &pauser{a: a, jobID: jobID}
+		for i := 0; i < numDatums; i++ {
+			if err := watchdog.wait(func() error { return pauser.wait(ctx) }); err != nil {
+				return err
+			}
 			limiter.Acquire()
 			files := df.Datum(i)
+			index := i
+			cacheKey, err := workerpkg.DatumCacheKey(jobInfo, files)
+			if err != nil {
+				limiter.Release()
+				return err
+			}
 			go func() {
 				userCodeFailures := 0
+				// reason is why the user code last failed
+				var reason string
+				// the metrics that the user code reported for the datum,
+				// which are only counted once it's been processed
+				var metrics []*pps.UserMetric
 				defer limiter.Release()
 				b := backoff.NewInfiniteBackOff()
 				b.Multiplier = 1
 				if err := backoff.RetryNotify(func() error {
 					conn, err := pool.Get(ctx)
 					if err != nil {
+						if watchdog.failed() {
+							atomic.StoreInt32(&orphaned, 1)
+						}
 						return fmt.Errorf("error from connection pool: %v", err)
 					}
 					workerClient := workerpkg.NewWorkerClient(conn)
 					resp, err := workerClient.Process(ctx, &workerpkg.ProcessRequest{
-						JobID: jobInfo.Job.ID,
-						Data:  files,
+						JobID:    jobInfo.Job.ID,
+						Data:     files,
+						CacheKey: cacheKey,
 					})
 					if err != nil {
 						if err := conn.Close(); err != nil {
 							protolion.Errorf("error closing conn: %+v", err)
 						}
+						if watchdog.failed() {
+							atomic.StoreInt32(&orphaned, 1)
+						}
 						return fmt.Errorf("Process() call failed: %v", err)
 					}
+					// the worker processed the datum, even if the user
+					// code failed to
+					watchdog.progress()
 					defer func() {
 						if err := pool.Put(conn); err != nil {
 							protolion.Errorf("error Putting conn: %+v", err)
@@ -1817,82 +3390,76 @@ func (a *apiServer) jobManager(ctx context.Context, jobInfo *pps.JobInfo) {
 					}()
 					if resp.Failed {
 						userCodeFailures++
+						reason = resp.Reason
 						return fmt.Errorf("user code failed for datum %v", files)
 					}
-					getTagClient, err := objectClient.GetTag(ctx, resp.Tag)
-					if err != nil {
-						return fmt.Errorf("failed to retrieve hashtree after processing for datum %v: %v", files, err)
-					}
-					var buffer bytes.Buffer
-					if err := grpcutil.WriteFromStreamingBytesClient(getTagClient, &buffer); err != nil {
-						return fmt.Errorf("failed to retrieve hashtree after processing for datum %v: %v", files, err)
-					}
-					subTree, err := hashtree.Deserialize(buffer.Bytes())
-					if err != nil {
-						return fmt.Errorf("failed deserialize hashtree after processing for datum %v: %v", files, err)
-					}
-					treeMu.Lock()
-					defer treeMu.Unlock()
-					return tree.Merge(subTree)
+					metrics = resp.Metrics
+					datumTagsMu.Lock()
+					defer datumTagsMu.Unlock()
+					datumTags = append(datumTags, indexedTag{index, resp.Tag})
+					return nil
 				}, b, func(err error, d time.Duration) error {
 					select {
 					case <-ctx.Done():
 						return err
 					default:
 					}
+					if atomic.LoadInt32(&orphaned) != 0 {
+						return err
+					}
 					if userCodeFailures > MaximumRetriesPerDatum {
 						protolion.Errorf("job %s failed to process datum %+v %d times failing", jobID, files, userCodeFailures)
 						failed = true
+						a.putFailedDatum(jobInfo.Job, files, reason)
 						return err
 					}
 					protolion.Errorf("job %s failed to process datum %+v with: %+v, retrying in: %+v", jobID, files, err, d)
 					return nil
 				}); err == nil {
-					go updateProgress(1)
+					// this isn't done in the background, so that the
+					// datum's metrics are counted before the job finishes
+					updateProgress(1, metrics)
 				}
 			}()
 		}
 		limiter.Wait()
+		if atomic.LoadInt32(&orphaned) != 0 {
+			return fmt.Errorf("the job's datums could not be processed")
+		}
 
 		// check if the job failed
 		if failed {
+			// the job's failed datums are written before it's finished
+			if err := a.batcher.flush(ctx); err != nil {
+				return err
+			}
 			_, err = col.NewSTM(ctx, a.etcdClient, func(stm col.STM) error {
 				jobs := a.jobs.ReadWrite(stm)
 				jobInfo := new(pps.JobInfo)
 				if err := jobs.Get(jobID, jobInfo); err != nil {
 					return err
 				}
-				jobInfo.Finished = now()
+				jobInfo.Finished = clock.After(jobInfo.Started)
+				progressMu.Lock()
+				jobInfo.UserMetrics = userMetrics
+				progressMu.Unlock()
 				return a.updateJobState(stm, jobInfo, pps.JobState_JOB_FAILURE)
 			})
 			return err
 		}
 
-		finishedTree, err := tree.Finish()
-		if err != nil {
-			return err
-		}
-
-		data, err := hashtree.Serialize(finishedTree)
-		if err != nil {
-			return err
-		}
-
-		putObjClient, err := objectClient.PutObject(ctx)
-		if err != nil {
-			return err
-		}
-		for _, chunk := range grpcutil.Chunk(data, grpcutil.MaxMsgSize/2) {
-			if err := putObjClient.Send(&pfs.PutObjectRequest{
-				Value: chunk,
-			}); err != nil {
-				return err
-			}
+		// merge the datums' output in the order of the datums, so that
+		// files which several datums write to are the same every time
+		sort.Slice(datumTags, func(i, j int) bool { return datumTags[i].index < datumTags[j].index })
+		tags := make([]*pfs.Tag, len(datumTags))
+		for i, datumTag := range datumTags {
+			tags[i] = datumTag.tag
 		}
-		object, err := putObjClient.CloseAndRecv()
+		object, err := a.mergeTrees(ctx, jobID, pool, numWorkers, tags, watchdog)
 		if err != nil {
 			return err
 		}
+		watchdog.progress()
 
 		var provenance []*pfs.Commit
 		for _, commit := range inputCommits(jobInfo.Input) {
@@ -1911,7 +3478,7 @@ func (a *apiServer) jobManager(ctx context.Context, jobInfo *pps.JobInfo) {
 			return err
 		}
 
-		if jobInfo.Egress != nil {
+		if jobInfo.Egress.GetURL() != "" {
 			objClient, err := obj.NewClientFromURLAndSecret(ctx, jobInfo.Egress.URL)
 			if err != nil {
 				return err
@@ -1928,6 +3495,20 @@ func (a *apiServer) jobManager(ctx context.Context, jobInfo *pps.JobInfo) {
 				return err
 			}
 		}
+		var sqlEgressLoads []*pps.SQLEgressLoad
+		if sqlEgress := jobInfo.Egress.GetSQL(); sqlEgress != nil {
+			secret, err := a.kubeClient.Secrets(a.namespace).Get(sqlEgress.Secret)
+			if err != nil {
+				return fmt.Errorf("could not get SQL egress secret %s: %v", sqlEgress.Secret, err)
+			}
+			pachClient := client.APIClient{
+				PfsAPIClient: pfsClient,
+			}
+			sqlEgressLoads, err = sqldb.Load(ctx, &pachClient, outputCommit, sqlEgress, secret.Data)
+			if err != nil {
+				return fmt.Errorf("error loading output into %s: %v", sqlEgress.URL, err)
+			}
+		}
 
 		// Record the job's output commit and 'Finished' timestamp, and mark the job
 		// as a SUCCESS
@@ -1938,11 +3519,15 @@ func (a *apiServer) jobManager(ctx context.Context, jobInfo *pps.JobInfo) {
 				return err
 			}
 			jobInfo.OutputCommit = outputCommit
-			jobInfo.Finished = now()
+			jobInfo.Finished = clock.After(jobInfo.Started)
 			// By definition, we will have processed all datums at this point
 			jobInfo.DataProcessed = totalData
 			// likely already set but just in case it failed
 			jobInfo.DataTotal = totalData
+			progressMu.Lock()
+			jobInfo.UserMetrics = userMetrics
+			progressMu.Unlock()
+			jobInfo.SQLEgressLoads = sqlEgressLoads
 			return a.updateJobState(stm, jobInfo, pps.JobState_JOB_SUCCESS)
 		})
 		return err
@@ -1954,6 +3539,19 @@ func (a *apiServer) jobManager(ctx context.Context, jobInfo *pps.JobInfo) {
 		default:
 		}
 
+		// a stopped pipeline's workers are deleted, so its jobs can't run
+		// until it's started again
+		if a.pipelineStopped(ctx, jobInfo) {
+			watchdog.progress()
+		} else if watchdog.failed() {
+			protolion.Errorf("job %s has been orphaned, failing it: %v", jobInfo.Job.ID, err)
+			if err := a.failOrphanedJob(ctx, jobID, err); err != nil {
+				protolion.Errorf("error failing job %s: %v", jobInfo.Job.ID, err)
+				return nil
+			}
+			return err
+		}
+
 		protolion.Errorf("error running jobManager for job %s: %v; retrying in %v", jobInfo.Job.ID, err, d)
 
 		// Increment the job's restart count
@@ -1975,6 +3573,91 @@ func (a *apiServer) jobManager(ctx context.Context, jobInfo *pps.JobInfo) {
 	})
 }
 
+// mergeFanIn is how many hashtrees each worker merges at once when a job's
+// output is merged.
+const mergeFanIn = 64
+
+// indexedTag is the tag of the hashtree output by the datum with index index.
+type indexedTag struct {
+	index int
+	tag   *pfs.Tag
+}
+
+// mergeTrees merges the hashtrees with tags tags, which datums of job jobID
+// output, into a single hashtree and returns its object. Merging all of a
+// job's datums in one place takes time quadratic in their number, so instead
+// the trees are merged by the job's workers, a group of mergeFanIn at a time,
+// and then the results of those merges are merged, and so on, until there's a
+// single tree left. Merges are retried until they succeed, or watchdog decides
+// that the job has been orphaned.
+func (a *apiServer) mergeTrees(ctx context.Context, jobID string, pool *grpcutil.Pool, numWorkers int, tags []*pfs.Tag, watchdog *jobWatchdog) (*pfs.Object, error) {
+	for {
+		// there's always at least one group, so that a job with no datums
+		// outputs an empty tree
+		numGroups := (len(tags) + mergeFanIn - 1) / mergeFanIn
+		if numGroups == 0 {
+			numGroups = 1
+		}
+		responses := make([]*workerpkg.MergeResponse, numGroups)
+		limiter := limit.New(numWorkers)
+		var eg errgroup.Group
+		for i := 0; i < numGroups; i++ {
+			i := i
+			group := tags[i*mergeFanIn:]
+			if len(group) > mergeFanIn {
+				group = group[:mergeFanIn]
+			}
+			limiter.Acquire()
+			eg.Go(func() error {
+				defer limiter.Release()
+				b := backoff.NewInfiniteBackOff()
+				return backoff.RetryNotify(func() error {
+					conn, err := pool.Get(ctx)
+					if err != nil {
+						return fmt.Errorf("error from connection pool: %v", err)
+					}
+					resp, err := workerpkg.NewWorkerClient(conn).Merge(ctx, &workerpkg.MergeRequest{
+						JobID: jobID,
+						Trees: group,
+					})
+					if err != nil {
+						if err := conn.Close(); err != nil {
+							protolion.Errorf("error closing conn: %+v", err)
+						}
+						return fmt.Errorf("Merge() call failed: %v", err)
+					}
+					if err := pool.Put(conn); err != nil {
+						protolion.Errorf("error Putting conn: %+v", err)
+					}
+					responses[i] = resp
+					return nil
+				}, b, func(err error, d time.Duration) error {
+					select {
+					case <-ctx.Done():
+						return err
+					default:
+					}
+					if watchdog.failed() {
+						return err
+					}
+					protolion.Errorf("job %s failed to merge hashtrees with: %+v, retrying in: %+v", jobID, err, d)
+					return nil
+				})
+			})
+		}
+		if err := eg.Wait(); err != nil {
+			return nil, err
+		}
+		if numGroups == 1 {
+			return responses[0].Tree, nil
+		}
+		tags = make([]*pfs.Tag, numGroups)
+		for i, resp := range responses {
+			tags[i] = resp.Tag
+		}
+	}
+}
+
 // jobStateToStopped defines what job states are "stopped" states,
 // meaning that jobs in this state should not be managed by jobManager
 func jobStateToStopped(state pps.JobState) bool {
@@ -2015,11 +3698,48 @@ func parseResourceList(resources *pps.ResourceSpec) (*api.ResourceList, error) {
 	return &result, nil
 }
 
+// parseResourceLimits is like parseResourceList, but leaves out the
+// resources that limits doesn't set, as a limit of 0 would mean that the
+// workers can't use them at all.
+func parseResourceLimits(limits *pps.ResourceSpec) (*api.ResourceList, error) {
+	if limits == nil {
+		return nil, nil
+	}
+	var result api.ResourceList = make(map[api.ResourceName]resource.Quantity)
+	if limits.Cpu != 0 {
+		cpuQuantity, err := resource.ParseQuantity(fmt.Sprintf("%f", limits.Cpu))
+		if err != nil {
+			return nil, fmt.Errorf("could not parse cpu limit: %s", err)
+		}
+		result[api.ResourceCPU] = cpuQuantity
+	}
+	if limits.Memory != "" {
+		memQuantity, err := resource.ParseQuantity(limits.Memory)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse memory limit: %s", err)
+		}
+		result[api.ResourceMemory] = memQuantity
+	}
+	if limits.Gpu != 0 {
+		gpuQuantity, err := resource.ParseQuantity(fmt.Sprintf("%d", limits.Gpu))
+		if err != nil {
+			return nil, fmt.Errorf("could not parse gpu limit: %s", err)
+		}
+		result[api.ResourceNvidiaGPU] = gpuQuantity
+	}
+	return &result, nil
+}
+
 func (a *apiServer) createWorkersForOrphanJob(jobInfo *pps.JobInfo) error {
 	parallelism, err := GetExpectedNumWorkers(a.kubeClient, jobInfo.ParallelismSpec)
 	if err != nil {
 		return err
 	}
+	if a.maxWorkers > 0 {
+		// The workers are scaled up within the cluster's maximum when
+		// they have a job to run, see scaleUpWorkersWithinQuota
+		parallelism = 0
+	}
 	var resources *api.ResourceList
 	if jobInfo.ResourceSpec != nil {
 		resources, err = parseResourceList(jobInfo.ResourceSpec)
@@ -2027,10 +3747,15 @@ func (a *apiServer) createWorkersForOrphanJob(jobInfo *pps.JobInfo) error {
 			return err
 		}
 	}
+	limits, err := parseResourceLimits(jobInfo.ResourceLimits)
+	if err != nil {
+		return err
+	}
 	options := a.getWorkerOptions(
 		JobRcName(jobInfo.Job.ID),
 		int32(parallelism),
 		resources,
+		limits,
 		jobInfo.Transform)
 	// Set the job name env
 	options.workerEnv = append(options.workerEnv, api.EnvVar{
@@ -2045,6 +3770,11 @@ func (a *apiServer) createWorkersForPipeline(pipelineInfo *pps.PipelineInfo) err
 	if err != nil {
 		return err
 	}
+	if a.maxWorkers > 0 {
+		// The workers are scaled up within the cluster's maximum when
+		// they have a job to run, see scaleUpWorkersWithinQuota
+		parallelism = 0
+	}
 	var resources *api.ResourceList
 	if pipelineInfo.ResourceSpec != nil {
 		resources, err = parseResourceList(pipelineInfo.ResourceSpec)
@@ -2052,10 +3782,15 @@ func (a *apiServer) createWorkersForPipeline(pipelineInfo *pps.PipelineInfo) err
 			return err
 		}
 	}
+	limits, err := parseResourceLimits(pipelineInfo.ResourceLimits)
+	if err != nil {
+		return err
+	}
 	options := a.getWorkerOptions(
 		PipelineRcName(pipelineInfo.Pipeline.Name, pipelineInfo.Version),
 		int32(parallelism),
 		resources,
+		limits,
 		pipelineInfo.Transform)
 	// Set the pipeline name env
 	options.workerEnv = append(options.workerEnv, api.EnvVar{
@@ -2066,6 +3801,12 @@ func (a *apiServer) createWorkersForPipeline(pipelineInfo *pps.PipelineInfo) err
 }
 
 func (a *apiServer) deleteWorkers(rcName string) error {
+	if err := a.deleteWorkerNetworkPolicy(rcName); err != nil {
+		return err
+	}
+	if err := a.deletePrepullDaemonSet(rcName); err != nil {
+		return err
+	}
 	if err := a.kubeClient.Services(a.namespace).Delete(rcName); err != nil {
 		if !isNotFoundErr(err) {
 			return err
@@ -2113,11 +3854,38 @@ func (a *apiServer) DeleteShard(shard uint64) error {
 	return nil
 }
 
+// StopMasters stops the masters that this pachd is running, and waits until
+// ctx is done for them to release their locks, so that the pachds which take
+// over this one's shards can take them over right away. It's called when pachd
+// is shutting down, after it's left the sharder.
+func (a *apiServer) StopMasters(ctx context.Context) error {
+	func() {
+		a.shardLock.Lock()
+		defer a.shardLock.Unlock()
+		for shard, ctxAndCancel := range a.shardCtxs {
+			ctxAndCancel.cancel()
+			delete(a.shardCtxs, shard)
+		}
+	}()
+	stopped := make(chan struct{})
+	go func() {
+		a.masters.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		// write what the masters have batched up before they stopped
+		return a.batcher.flush(ctx)
+	case <-ctx.Done():
+		return fmt.Errorf("masters didn't stop in time: %v", ctx.Err())
+	}
+}
+
 func (a *apiServer) getPFSClient() (pfs.APIClient, error) {
 	if a.pachConn == nil {
 		var onceErr error
 		a.pachConnOnce.Do(func() {
-			pachConn, err := grpc.Dial(a.address, client.PachDialOptions()...)
+			pachConn, err := grpc.Dial(a.address, append(client.PeerDialOptions(a.peerCreds), client.AuthTokenDialOptions(a.internalToken)...)...)
 			if err != nil {
 				onceErr = err
 			}
@@ -2134,7 +3902,7 @@ func (a *apiServer) getObjectClient() (pfs.ObjectAPIClient, error) {
 	if a.pachConn == nil {
 		var onceErr error
 		a.pachConnOnce.Do(func() {
-			pachConn, err := grpc.Dial(a.address, client.PachDialOptions()...)
+			pachConn, err := grpc.Dial(a.address, append(client.PeerDialOptions(a.peerCreds), client.AuthTokenDialOptions(a.internalToken)...)...)
 			if err != nil {
 				onceErr = err
 			}
@@ -2185,11 +3953,3 @@ func (s podSlice) Swap(i, j int) {
 func (s podSlice) Less(i, j int) bool {
 	return s[i].ObjectMeta.Name < s[j].ObjectMeta.Name
 }
-
-func now() *types.Timestamp {
-	t, err := types.TimestampProto(time.Now())
-	if err != nil {
-		panic(err)
-	}
-	return t
-}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client/pps"
+
+	"go.pedge.io/lion/proto"
+	"golang.org/x/net/context"
+
+	"k8s.io/kubernetes/pkg/api"
+)
+
+// validateParallelism checks that spec's AUTOSCALE range, if it has one, is
+// well formed.
+func validateParallelism(spec *pps.ParallelismSpec) error {
+	if spec.GetStrategy() != pps.ParallelismSpec_AUTOSCALE {
+		return nil
+	}
+	if spec.Max == 0 {
+		return fmt.Errorf("parallelism spec with the AUTOSCALE strategy must set max")
+	}
+	if spec.Min > spec.Max {
+		return fmt.Errorf("parallelism spec's min (%d) exceeds its max (%d)", spec.Min, spec.Max)
+	}
+	return nil
+}
+
+// autoscaleWorkers returns the number of workers that a job with numDatums
+// datums gets under spec, an AUTOSCALE parallelism spec: one per datum,
+// within spec's range.
+func autoscaleWorkers(spec *pps.ParallelismSpec, numDatums int) int32 {
+	workers := uint64(numDatums)
+	if workers < spec.Min {
+		workers = spec.Min
+	}
+	if workers > spec.Max {
+		workers = spec.Max
+	}
+	return int32(workers)
+}
+
+// idleWorkers returns the number of workers that the RC of a pipeline (or
+// orphan job) with spec keeps while it has no jobs to run.
+func idleWorkers(spec *pps.ParallelismSpec) int32 {
+	if spec.GetStrategy() == pps.ParallelismSpec_AUTOSCALE {
+		return int32(spec.Min)
+	}
+	return 0
+}
+
+// trimUnschedulableWorkers scales the RC rcName, whose workers are
+// autoscaled under spec, down by the number of its workers that kubernetes
+// can't schedule, as the cluster's nodes don't have room for them, but not
+// below spec.Min. It checks every workerQuotaInterval, until ctx is done, so
+// that a job's workers are scaled to what the cluster has room for.
+func (a *apiServer) trimUnschedulableWorkers(ctx context.Context, rcName string, spec *pps.ParallelismSpec) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(workerQuotaInterval):
+		}
+		pods, err := a.rcPods(rcName)
+		if err != nil {
+			protolion.Errorf("error listing %s's workers to autoscale them: %v", rcName, err)
+			continue
+		}
+		unschedulable := unschedulablePods(pods)
+		if unschedulable == 0 {
+			continue
+		}
+		rc := a.kubeClient.ReplicationControllers(a.namespace)
+		workerRc, err := rc.Get(rcName)
+		if err != nil {
+			protolion.Errorf("error getting %s to autoscale its workers: %v", rcName, err)
+			continue
+		}
+		replicas := workerRc.Spec.Replicas - unschedulable
+		if replicas < int32(spec.Min) {
+			replicas = int32(spec.Min)
+		}
+		if replicas >= workerRc.Spec.Replicas {
+			continue
+		}
+		protolion.Infof("%s has %d workers that can't be scheduled, scaling it down to %d workers", rcName, unschedulable, replicas)
+		workerRc.Spec.Replicas = replicas
+		if _, err := rc.Update(workerRc); err != nil {
+			protolion.Errorf("error scaling down %s: %v", rcName, err)
+		}
+	}
+}
+
+// unschedulablePods returns the number of pods which kubernetes has failed
+// to schedule onto a node.
+func unschedulablePods(pods []api.Pod) int32 {
+	var result int32
+	for _, pod := range pods {
+		if pod.Status.Phase != api.PodPending {
+			continue
+		}
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == api.PodScheduled && condition.Status == api.ConditionFalse &&
+				condition.Reason == "Unschedulable" {
+				result++
+				break
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,166 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client/pps"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+
+	"go.pedge.io/lion/proto"
+	"golang.org/x/net/context"
+)
+
+const (
+	// batchInterval is how often the writes that jobs' masters make as
+	// datums are processed are written to etcd.
+	batchInterval = time.Second
+	// maxBatchWrites bounds the number of writes in each etcd transaction,
+	// which etcd limits the number of operations in.
+	maxBatchWrites = 50
+)
+
+// progress is a job's progress, see pps.JobInfo.DataProcessed, and the
+// metrics that its user code has reported so far, see pps.UserMetric.
+type progress struct {
+	processed   int64
+	total       int64
+	userMetrics []*pps.UserMetric
+}
+
+// batcher batches up the writes that jobs' masters make to etcd as datums
+// are processed, i.e. the progress of jobs and the datums which have failed,
+// and writes them together every batchInterval, so that big jobs don't
+// overwhelm etcd. Only the latest progress of each job is written.
+type batcher struct {
+	a  *apiServer
+	mu sync.Mutex
+	// progress is the progress of each job which hasn't been written yet
+	progress map[string]progress
+	// datums are the failed datums which haven't been written yet
+	datums []*pps.DatumInfo
+	// flushMu makes sure that writes are flushed in the order they're made
+	flushMu sync.Mutex
+}
+
+func newBatcher(a *apiServer) *batcher {
+	return &batcher{
+		a:        a,
+		progress: make(map[string]progress),
+	}
+}
+
+// run flushes the batcher every batchInterval, until ctx is done.
+func (b *batcher) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(batchInterval):
+		}
+		if err := b.flush(ctx); err != nil {
+			protolion.Errorf("error writing jobs' progress and failed datums: %+v", err)
+		}
+	}
+}
+
+// setProgress records the progress and user metrics of the job with ID
+// jobID.
+func (b *batcher) setProgress(jobID string, processed int64, total int64, userMetrics []*pps.UserMetric) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.progress[jobID] = progress{processed: processed, total: total, userMetrics: userMetrics}
+}
+
+// putDatum records a datum which has failed.
+func (b *batcher) putDatum(datumInfo *pps.DatumInfo) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.datums = append(b.datums, datumInfo)
+}
+
+// flush writes everything that's been recorded so far. Jobs' masters flush
+// before they change the state of their jobs, so that the job's failed
+// datums are there once it's finished. Datums which can't be written are
+// kept for the next flush, progress is dropped, as it's overwritten soon
+// enough.
+func (b *batcher) flush(ctx context.Context) error {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+	b.mu.Lock()
+	jobProgress, datums := b.progress, b.datums
+	b.progress, b.datums = make(map[string]progress), nil
+	b.mu.Unlock()
+
+	var jobIDs []string
+	for jobID := range jobProgress {
+		jobIDs = append(jobIDs, jobID)
+	}
+	for len(jobIDs) > 0 || len(datums) > 0 {
+		n := maxBatchWrites
+		batchJobIDs := jobIDs
+		if len(batchJobIDs) > n {
+			batchJobIDs = batchJobIDs[:n]
+		}
+		n -= len(batchJobIDs)
+		batchDatums := datums
+		if len(batchDatums) > n {
+			batchDatums = batchDatums[:n]
+		}
+		if _, err := col.NewSTM(ctx, b.a.etcdClient, func(stm col.STM) error {
+			jobs := b.a.jobs.ReadWrite(stm)
+			// the writes of jobs which have been deleted are dropped
+			jobInfos := make(map[string]*pps.JobInfo)
+			getJob := func(jobID string) (*pps.JobInfo, error) {
+				if jobInfo, ok := jobInfos[jobID]; ok {
+					return jobInfo, nil
+				}
+				jobInfo := new(pps.JobInfo)
+				if err := jobs.Get(jobID, jobInfo); err != nil {
+					if _, ok := err.(col.ErrNotFound); !ok {
+						return nil, err
+					}
+					jobInfo = nil
+				}
+				jobInfos[jobID] = jobInfo
+				return jobInfo, nil
+			}
+			for _, jobID := range batchJobIDs {
+				jobInfo, err := getJob(jobID)
+				if err != nil {
+					return err
+				}
+				// a job's progress is final once it has succeeded or
+				// failed. Stopped jobs' masters are cancelled before
+				// they can flush, so the progress they'd made is still
+				// written.
+				if jobInfo == nil || (jobStateToStopped(jobInfo.State) && jobInfo.State != pps.JobState_JOB_STOPPED) {
+					continue
+				}
+				jobInfo.DataProcessed = jobProgress[jobID].processed
+				jobInfo.DataTotal = jobProgress[jobID].total
+				jobInfo.UserMetrics = jobProgress[jobID].userMetrics
+				jobs.Put(jobID, jobInfo)
+			}
+			for _, datumInfo := range batchDatums {
+				jobInfo, err := getJob(datumInfo.Job.ID)
+				if err != nil {
+					return err
+				}
+				if jobInfo == nil {
+					continue
+				}
+				b.a.datums(datumInfo.Job.ID).ReadWrite(stm).Put(datumInfo.ID, datumInfo)
+			}
+			return nil
+		}); err != nil {
+			b.mu.Lock()
+			b.datums = append(datums, b.datums...)
+			b.mu.Unlock()
+			return err
+		}
+		jobIDs = jobIDs[len(batchJobIDs):]
+		datums = datums[len(batchDatums):]
+	}
+	return nil
+}
@@ -5,12 +5,17 @@ import (
 	"github.com/pachyderm/pachyderm/src/client/pfs"
 	"github.com/pachyderm/pachyderm/src/client/pps"
 
+	"github.com/gogo/protobuf/types"
 	"golang.org/x/net/context"
 )
 
 type branchSet struct {
 	Branches []*pfs.Branch
-	Err      error
+	// Rewound is set if, since the last branch set, one of the branches
+	// moved back to a commit that it's had before, e.g. because its head
+	// was deleted, so that a job for the branch set may already exist
+	Rewound bool
+	Err     error
 }
 
 type branchSetFactory interface {
@@ -18,6 +23,13 @@ type branchSetFactory interface {
 	Close()
 }
 
+// branchCommit is a commit that a branch a branchSetFactory subscribes to
+// has received.
+type branchCommit struct {
+	branch     string
+	commitInfo *pfs.CommitInfo
+}
+
 type branchSetFactoryImpl struct {
 	ch     chan *branchSet
 	cancel context.CancelFunc
@@ -47,14 +59,24 @@ func newBranchSetFactory(_ctx context.Context, pfsClient pfs.APIClient, input *p
 				uniqueBranches[input.Atom.Repo][input.Atom.Branch] = nil
 			}
 		}
+		if input.SQL != nil {
+			// each of a SQL input's snapshots is a commit to master
+			uniqueBranches[input.SQL.Repo] = map[string]*pfs.Commit{"master": nil}
+		}
 	})
 
 	var numBranches int
-	branchCh := make(chan *pfs.Branch)
+	// fromCommits are the branches that are subscribed to from a commit,
+	// which may not receive a transaction's commits
+	fromCommits := make(map[string]bool)
+	branchCh := make(chan *branchCommit)
 	errCh := make(chan error)
 	for repoName, branches := range uniqueBranches {
 		for branchName, fromCommit := range branches {
 			numBranches++
+			if fromCommit != nil {
+				fromCommits[branchKey(repoName, branchName)] = true
+			}
 			stream, err := pfsClient.SubscribeCommit(ctx, &pfs.SubscribeCommitRequest{
 				Repo:   &pfs.Repo{repoName},
 				Branch: branchName,
@@ -76,9 +98,9 @@ func newBranchSetFactory(_ctx context.Context, pfsClient pfs.APIClient, input *p
 					select {
 					case <-ctx.Done():
 						return
-					case branchCh <- &pfs.Branch{
-						Name: branchName,
-						Head: commitInfo.Commit,
+					case branchCh <- &branchCommit{
+						branch:     branchName,
+						commitInfo: commitInfo,
 					}:
 					}
 				}
@@ -89,12 +111,16 @@ func newBranchSetFactory(_ctx context.Context, pfsClient pfs.APIClient, input *p
 	ch := make(chan *branchSet)
 	go func() {
 		var currentBranchSet []*pfs.Branch
+		transactions := newTransactionTracker(uniqueBranches, fromCommits)
+		// received maps each branch to the commits it has received
+		received := make(map[string]map[string]bool)
+		var rewound bool
 		for {
-			var newBranch *pfs.Branch
+			var newCommit *branchCommit
 			select {
 			case <-ctx.Done():
 				return
-			case newBranch = <-branchCh:
+			case newCommit = <-branchCh:
 			case err := <-errCh:
 				select {
 				case <-ctx.Done():
@@ -104,6 +130,19 @@ func newBranchSetFactory(_ctx context.Context, pfsClient pfs.APIClient, input *p
 				}:
 				}
 			}
+			newBranch := &pfs.Branch{
+				Name: newCommit.branch,
+				Head: newCommit.commitInfo.Commit,
+			}
+			transactions.add(newCommit.branch, newCommit.commitInfo)
+			key := branchKey(newBranch.Head.Repo.Name, newBranch.Name)
+			if received[key] == nil {
+				received[key] = make(map[string]bool)
+			}
+			if received[key][newBranch.Head.ID] {
+				rewound = true
+			}
+			received[key][newBranch.Head.ID] = true
 
 			var found bool
 			for i, branch := range currentBranchSet {
@@ -115,7 +154,10 @@ func newBranchSetFactory(_ctx context.Context, pfsClient pfs.APIClient, input *p
 			if !found {
 				currentBranchSet = append(currentBranchSet, newBranch)
 			}
-			if len(currentBranchSet) == numBranches {
+			// while some of a transaction's commits haven't been received,
+			// the branch set would combine them with their branches' old
+			// heads, so no job is created for it
+			if len(currentBranchSet) == numBranches && !transactions.waiting() {
 				newBranchSet := make([]*pfs.Branch, numBranches)
 				copy(newBranchSet, currentBranchSet)
 				select {
@@ -123,8 +165,10 @@ func newBranchSetFactory(_ctx context.Context, pfsClient pfs.APIClient, input *p
 					return
 				case ch <- &branchSet{
 					Branches: newBranchSet,
+					Rewound:  rewound,
 				}:
 				}
+				rewound = false
 			}
 		}
 		panic("unreachable")
@@ -137,3 +181,105 @@ func newBranchSetFactory(_ctx context.Context, pfsClient pfs.APIClient, input *p
 
 	return f, nil
 }
+
+func branchKey(repo string, branch string) string {
+	return repo + "/" + branch
+}
+
+// transactionTracker tracks the commits of transactions (see
+// pfs.FinishCommits) that a branchSetFactory has received, so that a branch
+// set is only emitted once every branch that a transaction finished a
+// commit on has received that commit.
+type transactionTracker struct {
+	// subscribed are the branches that the factory subscribes to, except
+	// those in fromCommits
+	subscribed map[string]bool
+	// pending maps the ID of each transaction that some of the branches
+	// haven't received the commit of yet to those branches
+	pending map[string]map[string]bool
+	// finished maps each pending transaction's ID to when its commits were
+	// finished
+	finished map[string]*types.Timestamp
+	// latest maps each branch to when the last commit that it received was
+	// finished
+	latest map[string]*types.Timestamp
+}
+
+func newTransactionTracker(branches map[string]map[string]*pfs.Commit, fromCommits map[string]bool) *transactionTracker {
+	t := &transactionTracker{
+		subscribed: make(map[string]bool),
+		pending:    make(map[string]map[string]bool),
+		finished:   make(map[string]*types.Timestamp),
+		latest:     make(map[string]*types.Timestamp),
+	}
+	for repo, branches := range branches {
+		for branch := range branches {
+			if key := branchKey(repo, branch); !fromCommits[key] {
+				t.subscribed[key] = true
+			}
+		}
+	}
+	return t
+}
+
+// add records that branch has received commitInfo.
+func (t *transactionTracker) add(branch string, commitInfo *pfs.CommitInfo) {
+	key := branchKey(commitInfo.Commit.Repo.Name, branch)
+	t.latest[key] = commitInfo.Finished
+	// the commits of a branch are finished in order, so a branch that has
+	// received a commit finished after a transaction's won't receive the
+	// transaction's, e.g. because it was moved
+	for id, waiting := range t.pending {
+		if waiting[key] && after(commitInfo.Finished, t.finished[id]) {
+			t.done(id, key)
+		}
+	}
+	transaction := commitInfo.Transaction
+	if transaction == nil {
+		return
+	}
+	if _, ok := t.pending[transaction.ID]; !ok {
+		waiting := make(map[string]bool)
+		for _, b := range transaction.Branches {
+			k := branchKey(b.Head.Repo.Name, b.Name)
+			// branches that have received a commit finished since have
+			// received the transaction's, or won't
+			if t.subscribed[k] && after(commitInfo.Finished, t.latest[k]) {
+				waiting[k] = true
+			}
+		}
+		t.pending[transaction.ID] = waiting
+		t.finished[transaction.ID] = commitInfo.Finished
+	}
+	t.done(transaction.ID, key)
+}
+
+// done records that the branch key won't receive any more of the commits
+// of the transaction id.
+func (t *transactionTracker) done(id string, key string) {
+	delete(t.pending[id], key)
+	if len(t.pending[id]) == 0 {
+		delete(t.pending, id)
+		delete(t.finished, id)
+	}
+}
+
+// waiting returns whether some branches have yet to receive the commits of
+// a transaction that other branches have received.
+func (t *transactionTracker) waiting() bool {
+	return len(t.pending) > 0
+}
+
+// after returns whether a is after b, where unset timestamps are before any
+// other.
+func after(a *types.Timestamp, b *types.Timestamp) bool {
+	switch {
+	case a == nil:
+		return false
+	case b == nil:
+		return true
+	case a.Seconds != b.Seconds:
+		return a.Seconds > b.Seconds
+	}
+	return a.Nanos > b.Nanos
+}
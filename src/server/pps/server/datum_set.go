@@ -2,6 +2,7 @@ package server
 
 import (
 	"fmt"
+	"io"
 
 	"github.com/pachyderm/pachyderm/src/client"
 	"github.com/pachyderm/pachyderm/src/client/pfs"
@@ -11,41 +12,54 @@ import (
 	"golang.org/x/net/context"
 )
 
+// datumFactory enumerates the datums of an input. Datums are generated by
+// index as they're needed, rather than all up front: only the files matched
+// by each atom input are held in memory, so a cross of inputs takes the sum of
+// their sizes, not the product.
 type datumFactory interface {
 	Len() int
 	Datum(i int) []*workerpkg.Input
 }
 
 type atomDatumFactory struct {
-	inputs []*workerpkg.Input
-	index  int
+	input     *pps.AtomInput
+	fileInfos []*pfs.FileInfo
 }
 
 func newAtomDatumFactory(ctx context.Context, pfsClient pfs.APIClient, input *pps.AtomInput) (datumFactory, error) {
-	result := &atomDatumFactory{}
-	fileInfos, err := pfsClient.GlobFile(ctx, &pfs.GlobFileRequest{
+	result := &atomDatumFactory{input: input}
+	// the files are streamed, so that globs which match huge numbers of
+	// files aren't limited by the max message size
+	stream, err := pfsClient.GlobFileStream(ctx, &pfs.GlobFileRequest{
 		Commit:  client.NewCommit(input.Repo, input.Commit),
 		Pattern: input.Glob,
 	})
 	if err != nil {
 		return nil, err
 	}
-	for _, fileInfo := range fileInfos.FileInfo {
-		result.inputs = append(result.inputs, &workerpkg.Input{
-			FileInfo: fileInfo,
-			Name:     input.Name,
-			Lazy:     input.Lazy,
-		})
+	for {
+		fileInfo, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.fileInfos = append(result.fileInfos, fileInfo)
 	}
 	return result, nil
 }
 
 func (d *atomDatumFactory) Len() int {
-	return len(d.inputs)
+	return len(d.fileInfos)
 }
 
 func (d *atomDatumFactory) Datum(i int) []*workerpkg.Input {
-	return []*workerpkg.Input{d.inputs[i]}
+	return []*workerpkg.Input{{
+		FileInfo: d.fileInfos[i],
+		Name:     d.input.Name,
+		Lazy:     d.input.Lazy,
+	}}
 }
 
 type unionDatumFactory struct {
@@ -125,6 +139,14 @@ func newDatumFactory(ctx context.Context, pfsClient pfs.APIClient, input *pps.In
 	switch {
 	case input.Atom != nil:
 		return newAtomDatumFactory(ctx, pfsClient, input.Atom)
+	case input.SQL != nil:
+		// a SQL input's snapshot is a single datum
+		return newAtomDatumFactory(ctx, pfsClient, &pps.AtomInput{
+			Name:   input.SQL.Name,
+			Repo:   input.SQL.Repo,
+			Commit: input.SQL.Commit,
+			Glob:   "/",
+		})
 	case input.Union != nil:
 		return newUnionDatumFactory(ctx, pfsClient, input.Union)
 	case input.Cross != nil:
@@ -0,0 +1,165 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+	pfsserver "github.com/pachyderm/pachyderm/src/server/pfs/server"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+	"github.com/pachyderm/pachyderm/src/server/pkg/metrics"
+	"github.com/pachyderm/pachyderm/src/server/pkg/registry"
+	workerpkg "github.com/pachyderm/pachyderm/src/server/pkg/worker"
+
+	"golang.org/x/net/context"
+)
+
+func (a *apiServer) DryRunPipeline(ctx context.Context, request *pps.CreatePipelineRequest) (response *pps.DryRunInfo, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	metricsFn := metrics.ReportUserAction(ctx, a.reporter, "DryRunPipeline")
+	defer func(start time.Time) { metricsFn(start, retErr) }(time.Now())
+
+	pipelineInfo, err := a.newPipelineInfo(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return a.dryRunPipeline(ctx, pipelineInfo, request.Update)
+}
+
+func (a *apiServer) DryRunJob(ctx context.Context, request *pps.CreateJobRequest) (response *pps.DryRunInfo, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	metricsFn := metrics.ReportUserAction(ctx, a.reporter, "DryRunJob")
+	defer func(start time.Time) { metricsFn(start, retErr) }(time.Now())
+
+	return a.dryRunJob(ctx, request)
+}
+
+// dryRunPipeline makes the checks that creating pipelineInfo would, past
+// those of newPipelineInfo: that its name can name its output repo, and that
+// the pipeline exists if, and only if, it's being updated. Its image is
+// resolved, and its datums are those of the heads of its input branches.
+func (a *apiServer) dryRunPipeline(ctx context.Context, pipelineInfo *pps.PipelineInfo, update bool) (*pps.DryRunInfo, error) {
+	name := pipelineInfo.Pipeline.Name
+	if err := pfsserver.ValidateRepoName(name); err != nil {
+		return nil, err
+	}
+	existing := new(pps.PipelineInfo)
+	if err := a.pipelines.ReadOnly(ctx).Get(name, existing); err == nil {
+		if !update {
+			return nil, newErrPipelineExists(name)
+		}
+		pipelineInfo.Version = existing.Version + 1
+	} else if !isNotFoundErr(err) {
+		return nil, err
+	} else if update {
+		return nil, fmt.Errorf("pipeline %s doesn't exist, so it can't be updated", name)
+	}
+
+	result := &pps.DryRunInfo{PipelineInfo: pipelineInfo}
+	if err := a.dryRunImage(ctx, pipelineInfo.Transform, result); err != nil {
+		return nil, err
+	}
+	pfsClient, err := a.getPFSClient()
+	if err != nil {
+		return nil, err
+	}
+	input, err := headInput(ctx, pfsClient, pipelineInfo.Input, nil, nil)
+	if isNotFoundErr(err) {
+		// one of the input branches has no commits yet (or it's a SQL
+		// input, whose repo is created with the pipeline), so the
+		// pipeline wouldn't run yet
+		return result, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if result.Datums, err = dryRunDatums(ctx, pfsClient, input); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// dryRunJob makes the checks that createJob would for request, and returns
+// the job, which has no ID, as it isn't created.
+func (a *apiServer) dryRunJob(ctx context.Context, request *pps.CreateJobRequest) (*pps.DryRunInfo, error) {
+	if err := translateJobRequest(request); err != nil {
+		return nil, err
+	}
+	var jobInfo *pps.JobInfo
+	if _, err := col.NewSTM(ctx, a.etcdClient, func(stm col.STM) error {
+		var err error
+		if jobInfo, err = a.newJobInfo(stm, &pps.Job{}, request); err != nil {
+			return err
+		}
+		return a.validateJob(ctx, jobInfo)
+	}); err != nil {
+		return nil, err
+	}
+
+	result := &pps.DryRunInfo{JobInfo: jobInfo}
+	if err := a.dryRunImage(ctx, jobInfo.Transform, result); err != nil {
+		return nil, err
+	}
+	pfsClient, err := a.getPFSClient()
+	if err != nil {
+		return nil, err
+	}
+	if result.Datums, err = dryRunDatums(ctx, pfsClient, jobInfo.Input); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// dryRunImage resolves the image of transform against its registry, with
+// the credentials that pachd refreshes for it, if any. Only images that the
+// registry says don't exist are errors: registries that pachd can't reach,
+// or that need credentials that only workers have, are warned about in
+// result.
+func (a *apiServer) dryRunImage(ctx context.Context, transform *pps.Transform, result *pps.DryRunInfo) error {
+	image := a.defaultUserImage
+	if transform != nil && transform.Image != "" {
+		image = transform.Image
+	}
+	host, _, _, err := registry.ParseImage(image)
+	if err != nil {
+		return err
+	}
+	var auth *registry.Auth
+	if _, err := registry.ProviderOf(host); err == nil && a.registryCredentials {
+		if auth, err = registry.GetAuth(ctx, host); err != nil {
+			result.Warnings = append(result.Warnings, err.Error())
+		}
+	}
+	digest, err := registry.ResolveImage(ctx, image, auth)
+	if _, ok := err.(registry.ImageNotFoundError); ok {
+		return err
+	} else if err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("couldn't resolve image %s: %v", image, err))
+		return nil
+	}
+	result.ImageDigest = digest
+	return nil
+}
+
+// dryRunDatums returns the datums of input, which are all pending.
+func dryRunDatums(ctx context.Context, pfsClient pfs.APIClient, input *pps.Input) ([]*pps.DatumInfo, error) {
+	df, err := newDatumFactory(ctx, pfsClient, input)
+	if err != nil {
+		return nil, err
+	}
+	var result []*pps.DatumInfo
+	for i := 0; i < df.Len(); i++ {
+		files := df.Datum(i)
+		datumInfo := &pps.DatumInfo{
+			ID:    workerpkg.DatumID(files),
+			State: pps.DatumState_DATUM_PENDING,
+		}
+		for _, file := range files {
+			datumInfo.Data = append(datumInfo.Data, file.FileInfo)
+		}
+		result = append(result, datumInfo)
+	}
+	return result, nil
+}
@@ -0,0 +1,405 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+	"github.com/pachyderm/pachyderm/src/server/pkg/backoff"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+	"github.com/pachyderm/pachyderm/src/server/pkg/dlock"
+	"github.com/pachyderm/pachyderm/src/server/pkg/events"
+	"github.com/pachyderm/pachyderm/src/server/pkg/watch"
+
+	etcd "github.com/coreos/etcd/clientv3"
+	"github.com/gogo/protobuf/types"
+	"go.pedge.io/lion/proto"
+	"golang.org/x/net/context"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc/credentials"
+)
+
+const (
+	// eventsPrefix records which events have been published, so that each
+	// is published once, even if the pachd publishing events changes.
+	eventsPrefix = "/events"
+	// eventsLockKey is the lock held by the pachd that publishes events.
+	eventsLockKey = "/events_lock"
+)
+
+// EventOptions configure the publishing of events about commits and jobs to
+// a sink, such as an SNS topic.
+type EventOptions struct {
+	// Sink is where events are published to, see events.NewSink. Events
+	// aren't published if it's empty.
+	Sink string
+	// Branches are the branches, as "<repo>@<branch>" or "<repo>" for
+	// master, whose finished commits are published.
+	Branches []string
+	// Pipelines are the pipelines whose finished jobs are published, or "*"
+	// for every pipeline.
+	Pipelines []string
+}
+
+// PublishEvents publishes an event to options.Sink as each commit on
+// options.Branches is finished, and as each job of options.Pipelines
+// succeeds, fails or is stopped. Only the pachd that holds the events lock
+// does so, so it can be run on every pachd. It only returns if options.Sink
+// is empty or invalid, or it can't connect to etcd.
+func PublishEvents(etcdConfig etcd.Config, etcdPrefix string, cipher *col.Cipher, address string, internalToken string, peerCreds credentials.TransportCredentials, options EventOptions) {
+	if options.Sink == "" {
+		return
+	}
+	sink, err := events.NewSink(options.Sink)
+	if err != nil {
+		protolion.Errorf("invalid event sink; this pachd won't publish events: %v", err)
+		return
+	}
+	var branches []*pfs.Commit
+	for _, b := range options.Branches {
+		branch, err := parseEventBranch(b)
+		if err != nil {
+			protolion.Errorf("invalid event branch; this pachd won't publish events: %v", err)
+			return
+		}
+		branches = append(branches, branch)
+	}
+	etcdClient, err := etcd.New(etcdConfig)
+	if err != nil {
+		protolion.Errorf("error connecting to etcd; this pachd won't publish events: %v", err)
+		return
+	}
+	defer etcdClient.Close()
+	p := &eventPublisher{
+		etcdClient: etcdClient,
+		etcdPrefix: etcdPrefix,
+		jobs: col.NewEncryptedCollection(
+			etcdClient,
+			path.Join(etcdPrefix, jobsPrefix),
+			nil,
+			&pps.JobInfo{},
+			cipher,
+		),
+		sink:      sink,
+		branches:  branches,
+		pipelines: make(map[string]bool),
+	}
+	for _, pipeline := range options.Pipelines {
+		p.pipelines[pipeline] = true
+	}
+	lock := dlock.NewDLock(etcdClient, path.Join(etcdPrefix, eventsLockKey))
+	b := backoff.NewInfiniteBackOff()
+	backoff.RetryNotify(func() error {
+		ctx, err := lock.Lock(context.Background())
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := lock.Unlock(context.Background()); err != nil {
+				protolion.Errorf("error releasing the events lock: %v", err)
+			}
+		}()
+		pachClient, err := client.NewFromAddress(address, client.WithAuthToken(internalToken), client.WithTransportCredentials(peerCreds))
+		if err != nil {
+			return err
+		}
+		defer pachClient.Close()
+		return p.publish(ctx, pachClient)
+	}, b, func(err error, d time.Duration) error {
+		protolion.Errorf("error publishing events to %s: %v; retrying in %v", options.Sink, err, d)
+		return nil
+	})
+}
+
+// parseEventBranch parses a branch of EventOptions.Branches, returning it
+// as a commit whose ID is the branch's name.
+func parseEventBranch(branch string) (*pfs.Commit, error) {
+	parts := strings.Split(branch, "@")
+	if len(parts) > 2 || parts[0] == "" || len(parts) == 2 && parts[1] == "" {
+		return nil, fmt.Errorf("%q isn't a branch, branches are <repo>@<branch>", branch)
+	}
+	if len(parts) == 1 {
+		return client.NewCommit(parts[0], "master"), nil
+	}
+	return client.NewCommit(parts[0], parts[1]), nil
+}
+
+type eventPublisher struct {
+	etcdClient *etcd.Client
+	etcdPrefix string
+	jobs       col.Collection
+	sink       events.Sink
+	branches   []*pfs.Commit
+	pipelines  map[string]bool
+	// since is when events were first published. Jobs that finished before
+	// then aren't published, so that configuring a sink doesn't publish
+	// every job there's ever been.
+	since time.Time
+}
+
+// publish publishes the events of each branch and of jobs until ctx is done
+// or an event can't be published.
+func (p *eventPublisher) publish(ctx context.Context, pachClient *client.APIClient) error {
+	since, err := p.publishingSince(ctx)
+	if err != nil {
+		return err
+	}
+	p.since = since
+	eg, ctx := errgroup.WithContext(ctx)
+	for _, branch := range p.branches {
+		branch := branch
+		eg.Go(func() error {
+			return p.publishBranch(ctx, pachClient, branch)
+		})
+	}
+	if len(p.pipelines) > 0 {
+		eg.Go(func() error {
+			return p.publishJobs(ctx)
+		})
+	}
+	return eg.Wait()
+}
+
+// publishingSince returns when events were first published, recording now
+// as that time if they haven't been yet.
+func (p *eventPublisher) publishingSince(ctx context.Context) (time.Time, error) {
+	key := path.Join(p.etcdPrefix, eventsPrefix, "since")
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	if _, err := p.etcdClient.Txn(ctx).
+		If(etcd.Compare(etcd.CreateRevision(key), "=", 0)).
+		Then(etcd.OpPut(key, now)).
+		Commit(); err != nil {
+		return time.Time{}, err
+	}
+	resp, err := p.etcdClient.Get(ctx, key)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(resp.Kvs) == 0 {
+		return time.Time{}, fmt.Errorf("%s was deleted", key)
+	}
+	return time.Parse(time.RFC3339Nano, string(resp.Kvs[0].Value))
+}
+
+func (p *eventPublisher) branchKey(branch *pfs.Commit) string {
+	return path.Join(p.etcdPrefix, eventsPrefix, "branches", branch.Repo.Name+"@"+branch.ID)
+}
+
+// publishBranch publishes an event for each commit that's finished on
+// branch, after the last one that was published. If none have been, it
+// starts after the branch's current head, so that the commits that were
+// finished before the branch was configured aren't published.
+func (p *eventPublisher) publishBranch(ctx context.Context, pachClient *client.APIClient, branch *pfs.Commit) error {
+	key := p.branchKey(branch)
+	resp, err := p.etcdClient.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	var from *pfs.Commit
+	if len(resp.Kvs) > 0 {
+		from = client.NewCommit(branch.Repo.Name, string(resp.Kvs[0].Value))
+		if _, err := pachClient.PfsAPIClient.InspectCommit(ctx, &pfs.InspectCommitRequest{Commit: from}); err != nil {
+			if !isNotFoundErr(err) {
+				return err
+			}
+			// the last commit that was published has been deleted, so
+			// start again from the branch's head
+			protolion.Infof("the last published commit %s@%s of branch %s is gone, publishing the commits after the branch's head", from.Repo.Name, from.ID, branch.ID)
+			from = nil
+		}
+	}
+	if from == nil {
+		from, err = eventBranchHead(ctx, pachClient, branch)
+		if err != nil {
+			return err
+		}
+	}
+	stream, err := pachClient.PfsAPIClient.SubscribeCommit(ctx, &pfs.SubscribeCommitRequest{
+		Repo:   branch.Repo,
+		Branch: branch.ID,
+		From:   from,
+	})
+	if err != nil {
+		return err
+	}
+	for {
+		commitInfo, err := stream.Recv()
+		if err == io.EOF {
+			return fmt.Errorf("the subscription to %s@%s ended", branch.Repo.Name, branch.ID)
+		}
+		if err != nil {
+			return err
+		}
+		if err := p.send(ctx, newCommitEvent(branch.ID, commitInfo)); err != nil {
+			return err
+		}
+		if _, err := p.etcdClient.Put(ctx, key, commitInfo.Commit.ID); err != nil {
+			return err
+		}
+	}
+}
+
+// eventBranchHead returns the last commit on branch that's finished, or nil
+// if there isn't one, including if the branch doesn't exist yet.
+func eventBranchHead(ctx context.Context, pachClient *client.APIClient, branch *pfs.Commit) (*pfs.Commit, error) {
+	commitInfo, err := pachClient.PfsAPIClient.InspectCommit(ctx, &pfs.InspectCommitRequest{Commit: branch})
+	if err != nil {
+		if isNotFoundErr(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if commitInfo.Finished != nil {
+		return commitInfo.Commit, nil
+	}
+	// the head is still open, so it's published once it's finished
+	return commitInfo.ParentCommit, nil
+}
+
+// publishJobs publishes an event for each job that finishes, as it's
+// written to etcd, until ctx is done or an event can't be published. The
+// watch replays the jobs that already exist, so events that weren't
+// published before a failure are published when it's retried.
+func (p *eventPublisher) publishJobs(ctx context.Context) error {
+	watcher, err := p.jobs.ReadOnly(ctx).Watch()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+	for {
+		var ev *watch.Event
+		var ok bool
+		select {
+		case ev, ok = <-watcher.Watch():
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if !ok {
+			return fmt.Errorf("the watch of jobs was closed")
+		}
+		switch ev.Type {
+		case watch.EventError:
+			return ev.Err
+		case watch.EventDelete:
+			jobID := path.Base(string(ev.Key))
+			if _, err := p.etcdClient.Delete(ctx, p.jobKey(jobID)); err != nil {
+				return err
+			}
+		case watch.EventPut:
+			var jobID string
+			jobInfo := &pps.JobInfo{}
+			if err := ev.Unmarshal(&jobID, jobInfo); err != nil {
+				return err
+			}
+			if err := p.publishJob(ctx, jobInfo); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (p *eventPublisher) jobKey(jobID string) string {
+	return path.Join(p.etcdPrefix, eventsPrefix, "jobs", jobID)
+}
+
+// publishJob publishes jobInfo's event, if it's finished, it's one of the
+// configured pipelines' and its event hasn't been published yet.
+func (p *eventPublisher) publishJob(ctx context.Context, jobInfo *pps.JobInfo) error {
+	switch jobInfo.State {
+	case pps.JobState_JOB_SUCCESS, pps.JobState_JOB_FAILURE, pps.JobState_JOB_STOPPED:
+	default:
+		return nil
+	}
+	pipeline := ""
+	if jobInfo.Pipeline != nil {
+		pipeline = jobInfo.Pipeline.Name
+	}
+	if pipeline == "" || !p.pipelines["*"] && !p.pipelines[pipeline] {
+		return nil
+	}
+	if jobInfo.Finished != nil {
+		finished, err := types.TimestampFromProto(jobInfo.Finished)
+		if err != nil {
+			return err
+		}
+		if finished.Before(p.since) {
+			return nil
+		}
+	}
+	key := p.jobKey(jobInfo.Job.ID)
+	resp, err := p.etcdClient.Get(ctx, key, etcd.WithCountOnly())
+	if err != nil {
+		return err
+	}
+	if resp.Count > 0 {
+		return nil
+	}
+	if err := p.send(ctx, newJobEvent(jobInfo)); err != nil {
+		return err
+	}
+	_, err = p.etcdClient.Put(ctx, key, jobInfo.State.String())
+	return err
+}
+
+// send publishes event to the sink. Events that the sink rejects as invalid
+// are logged and dropped, rather than retried forever.
+func (p *eventPublisher) send(ctx context.Context, event *events.Event) error {
+	err := p.sink.Publish(ctx, event)
+	if events.IsRejected(err) {
+		protolion.Errorf("the event sink rejected the %s event %s: %v", event.Type, event.ID, err)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error publishing the %s event %s: %v", event.Type, event.ID, err)
+	}
+	return nil
+}
+
+func newCommitEvent(branch string, commitInfo *pfs.CommitInfo) *events.Event {
+	commit := commitInfo.Commit
+	return &events.Event{
+		ID:        fmt.Sprintf("commit/%s@%s", commit.Repo.Name, commit.ID),
+		Type:      events.CommitFinished,
+		Time:      eventTime(commitInfo.Finished),
+		Repo:      commit.Repo.Name,
+		Branch:    branch,
+		Commit:    commit.ID,
+		SizeBytes: commitInfo.SizeBytes,
+	}
+}
+
+func newJobEvent(jobInfo *pps.JobInfo) *events.Event {
+	event := &events.Event{
+		ID:    fmt.Sprintf("job/%s", jobInfo.Job.ID),
+		Type:  events.JobFinished,
+		Time:  eventTime(jobInfo.Finished),
+		Job:   jobInfo.Job.ID,
+		State: jobInfo.State.String(),
+	}
+	if jobInfo.Pipeline != nil {
+		event.Pipeline = jobInfo.Pipeline.Name
+	}
+	if jobInfo.OutputRepo != nil {
+		event.OutputRepo = jobInfo.OutputRepo.Name
+	}
+	if jobInfo.OutputCommit != nil {
+		event.OutputRepo = jobInfo.OutputCommit.Repo.Name
+		event.OutputCommit = jobInfo.OutputCommit.ID
+	}
+	return event
+}
+
+// eventTime formats t as an event's time, or now if t isn't set.
+func eventTime(t *types.Timestamp) string {
+	if t != nil {
+		if t, err := types.TimestampFromProto(t); err == nil {
+			return t.UTC().Format(time.RFC3339Nano)
+		}
+	}
+	return time.Now().UTC().Format(time.RFC3339Nano)
+}
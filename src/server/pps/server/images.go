@@ -0,0 +1,70 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pachyderm/pachyderm/src/client/pps"
+)
+
+// dockerHub is the registry of images whose names don't start with one.
+const dockerHub = "docker.io/"
+
+// normalizeImage returns the full name of an image, i.e. with the registry
+// that docker pulls it from, so that e.g. "ubuntu" and
+// "docker.io/library/ubuntu" are the same image.
+func normalizeImage(image string) string {
+	parts := strings.SplitN(image, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		// image already names its registry
+		return image
+	}
+	if len(parts) == 1 {
+		return dockerHub + "library/" + image
+	}
+	return dockerHub + image
+}
+
+// imageAllowed returns true if image matches one of prefixes, which are
+// registries (e.g. "registry.example.com/"), repos (e.g.
+// "registry.example.com/team/image") or images (e.g. "ubuntu:16.04").
+// A prefix that doesn't end in "/" only matches whole path components, so
+// that "registry.example.com" doesn't match
+// "registry.example.com.attacker.com/image".
+func imageAllowed(image string, prefixes []string) bool {
+	image = normalizeImage(image)
+	for _, prefix := range prefixes {
+		if strings.HasSuffix(prefix, "/") {
+			if strings.HasPrefix(image, prefix) || strings.HasPrefix(image, normalizeImage(prefix)) {
+				return true
+			}
+			continue
+		}
+		prefix = normalizeImage(prefix)
+		if image == prefix {
+			return true
+		}
+		for _, sep := range []string{"/", ":", "@"} {
+			if strings.HasPrefix(image, prefix+sep) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// validateImage returns an error if pachd only allows some images, and
+// transform's isn't one of them.
+func (a *apiServer) validateImage(transform *pps.Transform) error {
+	if len(a.allowedImagePrefixes) == 0 {
+		return nil
+	}
+	image := a.defaultUserImage
+	if transform != nil && transform.Image != "" {
+		image = transform.Image
+	}
+	if !imageAllowed(image, a.allowedImagePrefixes) {
+		return fmt.Errorf("image %s isn't allowed, images must start with one of: %s", image, strings.Join(a.allowedImagePrefixes, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client/pps"
+	"github.com/pachyderm/pachyderm/src/server/pkg/clock"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+
+	"golang.org/x/net/context"
+)
+
+// jobOrphanTimeout is how long a job's master may fail to make any progress
+// on it, e.g. because its workers have been lost, before the job is failed.
+// It's longer than it takes kubernetes to reschedule the workers of a node
+// that's gone away, so that jobs survive that.
+const jobOrphanTimeout = 30 * time.Minute
+
+// validJobTransition returns true if a job in state from may move to state
+// to, see pps.JobState. A running job moves to JOB_RUNNING again each time
+// its master restarts it.
+func validJobTransition(from pps.JobState, to pps.JobState) bool {
+	switch from {
+	case pps.JobState_JOB_STARTING:
+		return to != pps.JobState_JOB_STARTING
+	case pps.JobState_JOB_RUNNING:
+		return to != pps.JobState_JOB_STARTING
+	default:
+		return false
+	}
+}
+
+// jobWatchdog decides when a job has been orphaned: when its master has done
+// nothing but fail, at running it or at processing its datums, for longer
+// than timeout. Time that the master spends waiting, for the job's parent or
+// for workers, doesn't count, and nor does time that the job's pipeline is
+// stopped for, as its workers are deleted while it is.
+type jobWatchdog struct {
+	timeout time.Duration
+
+	mu sync.Mutex
+	// failingSince is when the master first failed since it last made
+	// progress, or zero if it hasn't
+	failingSince time.Time
+}
+
+func newJobWatchdog(timeout time.Duration) *jobWatchdog {
+	return &jobWatchdog{timeout: timeout}
+}
+
+// progress records that the master made progress on the job.
+func (w *jobWatchdog) progress() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.failingSince = time.Time{}
+}
+
+// wait calls f, which waits for something the job needs, and doesn't count
+// the time that it takes towards the job being orphaned.
+func (w *jobWatchdog) wait(f func() error) error {
+	start := time.Now()
+	err := f()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.failingSince.IsZero() {
+		w.failingSince = w.failingSince.Add(time.Since(start))
+	}
+	return err
+}
+
+// failed records that the master failed, and returns true if it's now been
+// failing for longer than the timeout.
+func (w *jobWatchdog) failed() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.failingSince.IsZero() {
+		w.failingSince = time.Now()
+	}
+	return time.Since(w.failingSince) > w.timeout
+}
+
+// pipelineStopped returns true if jobInfo's pipeline is stopped, in which
+// case its master failing to run it is expected.
+func (a *apiServer) pipelineStopped(ctx context.Context, jobInfo *pps.JobInfo) bool {
+	if jobInfo.Pipeline == nil {
+		return false
+	}
+	pipelineInfo := new(pps.PipelineInfo)
+	if err := a.pipelines.ReadOnly(ctx).Get(jobInfo.Pipeline.Name, pipelineInfo); err != nil {
+		return false
+	}
+	return pipelineInfo.Stopped && pipelineInfo.Version == jobInfo.PipelineVersion
+}
+
+// failOrphanedJob moves the job jobID, which its master has given up on, to
+// JOB_FAILURE, unless it's already finished.
+func (a *apiServer) failOrphanedJob(ctx context.Context, jobID string, cause error) error {
+	_, err := col.NewSTM(ctx, a.etcdClient, func(stm col.STM) error {
+		jobs := a.jobs.ReadWrite(stm)
+		jobInfo := new(pps.JobInfo)
+		if err := jobs.Get(jobID, jobInfo); err != nil {
+			return err
+		}
+		if jobStateToStopped(jobInfo.State) {
+			return nil
+		}
+		jobInfo.Finished = clock.After(jobInfo.Started)
+		jobInfo.Reason = fmt.Sprintf("no progress could be made on the job for %v, its workers were most likely lost: %v", jobOrphanTimeout, cause)
+		return a.updateJobState(stm, jobInfo, pps.JobState_JOB_FAILURE)
+	})
+	return err
+}
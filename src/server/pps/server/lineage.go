@@ -0,0 +1,366 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client/pps"
+	"github.com/pachyderm/pachyderm/src/server/pkg/backoff"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+	"github.com/pachyderm/pachyderm/src/server/pkg/dlock"
+	"github.com/pachyderm/pachyderm/src/server/pkg/watch"
+
+	etcd "github.com/coreos/etcd/clientv3"
+	"github.com/gogo/protobuf/types"
+	"go.pedge.io/lion/proto"
+	"golang.org/x/net/context"
+)
+
+const (
+	// lineagePrefix records the last OpenLineage event that's been exported
+	// for each job, so that each event is exported once, even if the pachd
+	// exporting lineage changes.
+	lineagePrefix = "/lineage"
+	// lineageLockKey is the lock held by the pachd that exports lineage.
+	lineageLockKey = "/lineage_lock"
+
+	openLineageProducer  = "https://github.com/pachyderm/pachyderm"
+	openLineageSchemaURL = "https://openlineage.io/spec/1-0-5/OpenLineage.json#/definitions/RunEvent"
+	// openLineageVersionFacetSchemaURL is the schema of the facet that
+	// records the commits of repos that jobs read and wrote
+	openLineageVersionFacetSchemaURL = "https://openlineage.io/spec/facets/1-0-0/DatasetVersionDatasetFacet.json#/$defs/DatasetVersionDatasetFacet"
+)
+
+// LineageOptions configure the export of jobs' lineage, as OpenLineage run
+// events, to a lineage service such as Marquez.
+type LineageOptions struct {
+	// Endpoint is the URL that events are POSTed to, e.g. Marquez's
+	// http://marquez:5000/api/v1/lineage. Lineage isn't exported if it's
+	// empty.
+	Endpoint string
+	// Namespace is the OpenLineage namespace of the jobs (pipelines) and
+	// datasets (repos) in events.
+	Namespace string
+}
+
+// ExportLineage exports an OpenLineage run event to options.Endpoint as each
+// job starts and finishes, naming the input commits that it read and the
+// output commit that it wrote. Only the pachd that holds the lineage lock
+// does so, so it can be run on every pachd. It only returns if
+// options.Endpoint is empty, or it can't connect to etcd.
+func ExportLineage(etcdConfig etcd.Config, etcdPrefix string, cipher *col.Cipher, options LineageOptions) {
+	if options.Endpoint == "" {
+		return
+	}
+	etcdClient, err := etcd.New(etcdConfig)
+	if err != nil {
+		protolion.Errorf("error connecting to etcd; this pachd won't export lineage: %v", err)
+		return
+	}
+	defer etcdClient.Close()
+	e := &lineageExporter{
+		etcdClient: etcdClient,
+		etcdPrefix: etcdPrefix,
+		jobs: col.NewEncryptedCollection(
+			etcdClient,
+			path.Join(etcdPrefix, jobsPrefix),
+			nil,
+			&pps.JobInfo{},
+			cipher,
+		),
+		options:    options,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+	lock := dlock.NewDLock(etcdClient, path.Join(etcdPrefix, lineageLockKey))
+	b := backoff.NewInfiniteBackOff()
+	backoff.RetryNotify(func() error {
+		ctx, err := lock.Lock(context.Background())
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := lock.Unlock(context.Background()); err != nil {
+				protolion.Errorf("error releasing the lineage lock: %v", err)
+			}
+		}()
+		return e.export(ctx)
+	}, b, func(err error, d time.Duration) error {
+		protolion.Errorf("error exporting lineage to %s: %v; retrying in %v", options.Endpoint, err, d)
+		return nil
+	})
+}
+
+type lineageExporter struct {
+	etcdClient *etcd.Client
+	etcdPrefix string
+	jobs       col.Collection
+	options    LineageOptions
+	httpClient *http.Client
+	// exported caches the records in lineagePrefix
+	exported map[string]string
+}
+
+// export exports the events of every job, as they're written to etcd, until
+// ctx is done or an event can't be exported. The watch replays the jobs that
+// already exist, so events that weren't exported before a failure (or
+// before lineage was configured) are exported when it's retried.
+func (e *lineageExporter) export(ctx context.Context) error {
+	e.exported = make(map[string]string)
+	watcher, err := e.jobs.ReadOnly(ctx).Watch()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+	for {
+		var ev *watch.Event
+		var ok bool
+		select {
+		case ev, ok = <-watcher.Watch():
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if !ok {
+			return fmt.Errorf("the watch of jobs was closed")
+		}
+		switch ev.Type {
+		case watch.EventError:
+			return ev.Err
+		case watch.EventDelete:
+			jobID := path.Base(string(ev.Key))
+			if _, err := e.etcdClient.Delete(ctx, e.exportedKey(jobID)); err != nil {
+				return err
+			}
+			delete(e.exported, jobID)
+		case watch.EventPut:
+			var jobID string
+			jobInfo := &pps.JobInfo{}
+			if err := ev.Unmarshal(&jobID, jobInfo); err != nil {
+				return err
+			}
+			if err := e.exportJob(ctx, jobInfo); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (e *lineageExporter) exportedKey(jobID string) string {
+	return path.Join(e.etcdPrefix, lineagePrefix, jobID)
+}
+
+// exportJob exports the events of jobInfo's state that haven't been exported
+// yet. A job that's first seen once it's finished (e.g. because it finished
+// while no pachd held the lineage lock) is exported as having started, then
+// finished.
+func (e *lineageExporter) exportJob(ctx context.Context, jobInfo *pps.JobInfo) error {
+	jobID := jobInfo.Job.ID
+	exported, ok := e.exported[jobID]
+	if !ok {
+		resp, err := e.etcdClient.Get(ctx, e.exportedKey(jobID))
+		if err != nil {
+			return err
+		}
+		if len(resp.Kvs) > 0 {
+			exported = string(resp.Kvs[0].Value)
+		}
+	}
+	eventType := lineageEventType(jobInfo.State)
+	var eventTypes []string
+	switch {
+	case exported == eventType:
+		return nil
+	case exported == "" && eventType != "START":
+		eventTypes = []string{"START", eventType}
+	case exported == "" || exported == "START":
+		eventTypes = []string{eventType}
+	default:
+		// the job has already been exported as having finished
+		return nil
+	}
+	for _, eventType := range eventTypes {
+		if err := e.post(ctx, newLineageEvent(e.options.Namespace, jobInfo, eventType)); err != nil {
+			return err
+		}
+		if _, err := e.etcdClient.Put(ctx, e.exportedKey(jobID), eventType); err != nil {
+			return err
+		}
+		e.exported[jobID] = eventType
+	}
+	return nil
+}
+
+// post POSTs event to the lineage endpoint. Events that the endpoint rejects
+// as invalid are logged and dropped, rather than retried forever.
+func (e *lineageExporter) post(ctx context.Context, event *lineageEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", e.options.Endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := e.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode/100 == 2:
+		return nil
+	case resp.StatusCode/100 == 4 && resp.StatusCode != http.StatusTooManyRequests:
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		protolion.Errorf("%s rejected the %s event of run %s (%s): %s", e.options.Endpoint, event.EventType, event.Run.RunID, resp.Status, body)
+		return nil
+	default:
+		return fmt.Errorf("%s responded to the %s event of run %s with %s", e.options.Endpoint, event.EventType, event.Run.RunID, resp.Status)
+	}
+}
+
+// lineageEventType is the type of the OpenLineage event that a job in state
+// is exported as.
+func lineageEventType(state pps.JobState) string {
+	switch state {
+	case pps.JobState_JOB_SUCCESS:
+		return "COMPLETE"
+	case pps.JobState_JOB_FAILURE:
+		return "FAIL"
+	case pps.JobState_JOB_STOPPED:
+		return "ABORT"
+	default:
+		return "START"
+	}
+}
+
+// lineageEvent is an OpenLineage RunEvent, see
+// https://openlineage.io/spec/1-0-5/OpenLineage.json
+type lineageEvent struct {
+	EventType string           `json:"eventType"`
+	EventTime string           `json:"eventTime"`
+	Run       lineageRun       `json:"run"`
+	Job       lineageJob       `json:"job"`
+	Inputs    []lineageDataset `json:"inputs"`
+	Outputs   []lineageDataset `json:"outputs"`
+	Producer  string           `json:"producer"`
+	SchemaURL string           `json:"schemaURL"`
+}
+
+type lineageRun struct {
+	RunID  string                 `json:"runId"`
+	Facets map[string]interface{} `json:"facets,omitempty"`
+}
+
+type lineageJob struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+type lineageDataset struct {
+	Namespace string                 `json:"namespace"`
+	Name      string                 `json:"name"`
+	Facets    map[string]interface{} `json:"facets,omitempty"`
+}
+
+// newLineageEvent returns the event of type eventType for a job. The job is
+// its pipeline, its run is the job, and its datasets are repos, whose
+// versions are the commits that the job read and wrote.
+func newLineageEvent(namespace string, jobInfo *pps.JobInfo, eventType string) *lineageEvent {
+	eventTime := jobInfo.Finished
+	if eventType == "START" {
+		eventTime = jobInfo.Started
+	}
+	t, err := types.TimestampFromProto(eventTime)
+	if err != nil {
+		t = time.Now()
+	}
+	jobName := jobInfo.Job.ID
+	if jobInfo.Pipeline != nil {
+		jobName = jobInfo.Pipeline.Name
+	}
+	event := &lineageEvent{
+		EventType: eventType,
+		EventTime: t.UTC().Format(time.RFC3339Nano),
+		Run: lineageRun{
+			RunID: jobIDToRunID(jobInfo.Job.ID),
+			Facets: map[string]interface{}{
+				"pachyderm": map[string]interface{}{
+					"_producer":       openLineageProducer,
+					"_schemaURL":      openLineageProducer + "#pachyderm",
+					"jobId":           jobInfo.Job.ID,
+					"pipelineVersion": jobInfo.PipelineVersion,
+				},
+			},
+		},
+		Job:       lineageJob{Namespace: namespace, Name: jobName},
+		Inputs:    []lineageDataset{},
+		Outputs:   []lineageDataset{},
+		Producer:  openLineageProducer,
+		SchemaURL: openLineageSchemaURL,
+	}
+	seen := make(map[string]bool)
+	addInput := func(repo string, commitID string) {
+		if repo == "" || seen[repo+"@"+commitID] {
+			return
+		}
+		seen[repo+"@"+commitID] = true
+		event.Inputs = append(event.Inputs, newLineageDataset(namespace, repo, commitID))
+	}
+	if jobInfo.Input != nil {
+		visit(jobInfo.Input, func(input *pps.Input) {
+			if input.Atom != nil {
+				addInput(input.Atom.Repo, input.Atom.Commit)
+			}
+			if input.SQL != nil {
+				addInput(input.SQL.Repo, input.SQL.Commit)
+			}
+		})
+	}
+	for _, input := range jobInfo.Inputs {
+		if input.Commit != nil {
+			addInput(input.Commit.Repo.Name, input.Commit.ID)
+		}
+	}
+	switch {
+	case jobInfo.OutputCommit != nil:
+		event.Outputs = append(event.Outputs, newLineageDataset(namespace, jobInfo.OutputCommit.Repo.Name, jobInfo.OutputCommit.ID))
+	case jobInfo.OutputRepo != nil:
+		event.Outputs = append(event.Outputs, newLineageDataset(namespace, jobInfo.OutputRepo.Name, ""))
+	}
+	return event
+}
+
+func newLineageDataset(namespace string, repo string, commitID string) lineageDataset {
+	dataset := lineageDataset{Namespace: namespace, Name: repo}
+	if commitID != "" {
+		dataset.Facets = map[string]interface{}{
+			"version": map[string]interface{}{
+				"_producer":      openLineageProducer,
+				"_schemaURL":     openLineageVersionFacetSchemaURL,
+				"datasetVersion": commitID,
+			},
+		}
+	}
+	return dataset
+}
+
+// jobIDToRunID returns the UUID that identifies a job's run. OpenLineage
+// requires run IDs to be UUIDs, which job IDs are, without their dashes, but
+// those of jobs created by older versions of pachyderm may not be, in which
+// case the UUID is derived from a hash of the ID.
+func jobIDToRunID(jobID string) string {
+	id, err := hex.DecodeString(jobID)
+	if err != nil || len(id) != 16 {
+		hash := sha256.Sum256([]byte(jobID))
+		id = hash[:16]
+	}
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:16])
+}
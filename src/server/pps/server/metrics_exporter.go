@@ -0,0 +1,218 @@
+package server
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+	"github.com/pachyderm/pachyderm/src/server/pkg/backoff"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+	"github.com/pachyderm/pachyderm/src/server/pkg/dlock"
+	"github.com/pachyderm/pachyderm/src/server/pkg/stats"
+	"github.com/pachyderm/pachyderm/src/server/pkg/watch"
+
+	etcd "github.com/coreos/etcd/clientv3"
+	"github.com/gogo/protobuf/types"
+	"go.pedge.io/lion/proto"
+	"golang.org/x/net/context"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc/credentials"
+)
+
+const (
+	// metricsLockKey is the lock held by the pachd that exports metrics.
+	metricsLockKey = "/metrics_lock"
+	// metricsInterval is how often the gauges of pipelines' jobs and repos'
+	// sizes are exported.
+	metricsInterval = 30 * time.Second
+)
+
+// ExportMetrics exports metrics about jobs, pipelines and repos to exporter:
+// a job's metrics as it finishes, and pipelines' and repos' every
+// metricsInterval. Only the pachd that holds the metrics lock exports them,
+// so that jobs aren't counted by each pachd, so it can be run on every pachd.
+// It only returns if exporter is nil, or it can't connect to etcd.
+func ExportMetrics(etcdConfig etcd.Config, etcdPrefix string, cipher *col.Cipher, address string, internalToken string, peerCreds credentials.TransportCredentials, exporter stats.Exporter) {
+	if exporter == nil {
+		return
+	}
+	etcdClient, err := etcd.New(etcdConfig)
+	if err != nil {
+		protolion.Errorf("error connecting to etcd; this pachd won't export metrics: %v", err)
+		return
+	}
+	defer etcdClient.Close()
+	m := &metricsExporter{
+		jobs: col.NewEncryptedCollection(
+			etcdClient,
+			path.Join(etcdPrefix, jobsPrefix),
+			nil,
+			&pps.JobInfo{},
+			cipher,
+		),
+		exporter: exporter,
+	}
+	lock := dlock.NewDLock(etcdClient, path.Join(etcdPrefix, metricsLockKey))
+	b := backoff.NewInfiniteBackOff()
+	backoff.RetryNotify(func() error {
+		ctx, err := lock.Lock(context.Background())
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := lock.Unlock(context.Background()); err != nil {
+				protolion.Errorf("error releasing the metrics lock: %v", err)
+			}
+		}()
+		pachClient, err := client.NewFromAddress(address, client.WithAuthToken(internalToken), client.WithTransportCredentials(peerCreds))
+		if err != nil {
+			return err
+		}
+		defer pachClient.Close()
+		return m.export(ctx, pachClient)
+	}, b, func(err error, d time.Duration) error {
+		protolion.Errorf("error exporting metrics: %v; retrying in %v", err, d)
+		return nil
+	})
+}
+
+type metricsExporter struct {
+	jobs     col.Collection
+	exporter stats.Exporter
+	// since is when this pachd took the metrics lock. Jobs that finished
+	// before then aren't counted, since the pachd that held the lock before
+	// counted them, if there was one.
+	since time.Time
+	// exported are the finished jobs that have been counted, so that they
+	// aren't counted again when they're written again
+	exported map[string]bool
+}
+
+// export exports metrics until ctx is done or the jobs can't be watched.
+func (m *metricsExporter) export(ctx context.Context, pachClient *client.APIClient) error {
+	m.since = time.Now()
+	m.exported = make(map[string]bool)
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.Go(func() error {
+		return m.exportJobs(ctx)
+	})
+	eg.Go(func() error {
+		ticker := time.NewTicker(metricsInterval)
+		defer ticker.Stop()
+		for {
+			// failing to list pipelines or repos doesn't stop the jobs'
+			// metrics, they're listed again at the next tick
+			if err := m.exportGauges(pachClient); err != nil {
+				protolion.Errorf("error exporting the metrics of pipelines and repos: %v", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	})
+	return eg.Wait()
+}
+
+// exportJobs exports the metrics of each job that finishes, as it's written
+// to etcd.
+func (m *metricsExporter) exportJobs(ctx context.Context) error {
+	watcher, err := m.jobs.ReadOnly(ctx).Watch()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+	for {
+		var ev *watch.Event
+		var ok bool
+		select {
+		case ev, ok = <-watcher.Watch():
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if !ok {
+			return fmt.Errorf("the watch of jobs was closed")
+		}
+		switch ev.Type {
+		case watch.EventError:
+			return ev.Err
+		case watch.EventDelete:
+			delete(m.exported, path.Base(string(ev.Key)))
+		case watch.EventPut:
+			var jobID string
+			jobInfo := &pps.JobInfo{}
+			if err := ev.Unmarshal(&jobID, jobInfo); err != nil {
+				return err
+			}
+			m.exportJob(jobInfo)
+		}
+	}
+}
+
+// exportJob exports jobInfo's metrics, if it's a pipeline's job that's
+// finished since m.since and they haven't been exported yet.
+func (m *metricsExporter) exportJob(jobInfo *pps.JobInfo) {
+	switch jobInfo.State {
+	case pps.JobState_JOB_SUCCESS, pps.JobState_JOB_FAILURE, pps.JobState_JOB_STOPPED:
+	default:
+		return
+	}
+	if jobInfo.Pipeline == nil || jobInfo.Finished == nil || m.exported[jobInfo.Job.ID] {
+		return
+	}
+	finished, err := types.TimestampFromProto(jobInfo.Finished)
+	if err != nil || finished.Before(m.since) {
+		return
+	}
+	m.exported[jobInfo.Job.ID] = true
+	pipeline, state := jobInfo.Pipeline.Name, jobStateTag(jobInfo.State)
+	m.exporter.Record(stats.JobsFinished, 1, pipeline, state)
+	m.exporter.Record(stats.DatumsProcessed, float64(jobInfo.DataProcessed), pipeline)
+	if jobInfo.Started != nil {
+		if started, err := types.TimestampFromProto(jobInfo.Started); err == nil {
+			m.exporter.Record(stats.JobDuration, finished.Sub(started).Seconds(), pipeline, state)
+		}
+	}
+}
+
+// exportGauges exports the number of each pipeline's jobs in each state,
+// and the size of each repo.
+func (m *metricsExporter) exportGauges(pachClient *client.APIClient) error {
+	pipelineInfos, err := pachClient.ListPipeline()
+	if err != nil {
+		return err
+	}
+	var jobs []stats.Sample
+	for _, pipelineInfo := range pipelineInfos {
+		for state, count := range pipelineInfo.JobCounts {
+			jobs = append(jobs, stats.Sample{
+				Tags:  []string{pipelineInfo.Pipeline.Name, jobStateTag(pps.JobState(state))},
+				Value: float64(count),
+			})
+		}
+	}
+	m.exporter.Snapshot(stats.Jobs, jobs)
+	repoInfos, err := pachClient.ListRepo(nil)
+	if err != nil {
+		return err
+	}
+	var sizes []stats.Sample
+	for _, repoInfo := range repoInfos {
+		sizes = append(sizes, stats.Sample{
+			Tags:  []string{repoInfo.Repo.Name},
+			Value: float64(repoInfo.SizeBytes),
+		})
+	}
+	m.exporter.Snapshot(stats.RepoSize, sizes)
+	return nil
+}
+
+// jobStateTag returns the value of metrics' state tag for state, e.g.
+// "success" for JOB_SUCCESS.
+func jobStateTag(state pps.JobState) string {
+	return strings.ToLower(strings.TrimPrefix(state.String(), "JOB_"))
+}
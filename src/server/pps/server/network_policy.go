@@ -0,0 +1,213 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/pachyderm/pachyderm/src/client/pps"
+
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+)
+
+// The vendored kubernetes client only knows the ingress-only
+// extensions/v1beta1 NetworkPolicy, so worker network policies are written
+// as networking.k8s.io/v1 objects, which can restrict egress, by hand.
+type networkPolicy struct {
+	unversioned.TypeMeta `json:",inline"`
+	ObjectMeta           api.ObjectMeta    `json:"metadata"`
+	Spec                 networkPolicySpec `json:"spec"`
+}
+
+type networkPolicySpec struct {
+	PodSelector unversioned.LabelSelector `json:"podSelector"`
+	PolicyTypes []string                  `json:"policyTypes"`
+	Ingress     []networkPolicyRule       `json:"ingress"`
+	Egress      []networkPolicyRule       `json:"egress"`
+}
+
+// networkPolicyRule is an ingress rule if it has From, and an egress rule if
+// it has To. A rule without peers matches every peer, and one without ports
+// every port.
+type networkPolicyRule struct {
+	Ports []networkPolicyPort `json:"ports,omitempty"`
+	From  []networkPolicyPeer `json:"from,omitempty"`
+	To    []networkPolicyPeer `json:"to,omitempty"`
+}
+
+type networkPolicyPort struct {
+	Protocol string `json:"protocol"`
+	Port     int32  `json:"port"`
+}
+
+type networkPolicyPeer struct {
+	PodSelector *unversioned.LabelSelector `json:"podSelector,omitempty"`
+	IPBlock     *ipBlock                   `json:"ipBlock,omitempty"`
+}
+
+type ipBlock struct {
+	CIDR   string   `json:"cidr"`
+	Except []string `json:"except,omitempty"`
+}
+
+// privateCIDRs and privateIPv6CIDRs are the private IPv4 and IPv6 (unique
+// local) ranges, which a cluster's VPC is usually in. Workers may reach the
+// object store on any other address.
+var (
+	privateCIDRs     = []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16"}
+	privateIPv6CIDRs = []string{"fc00::/7"}
+)
+
+// podsOf returns a peer matching the pods of one of pachyderm's own apps,
+// e.g. pachd.
+func podsOf(app string) networkPolicyPeer {
+	return networkPolicyPeer{
+		PodSelector: &unversioned.LabelSelector{
+			MatchLabels: labels(app),
+		},
+	}
+}
+
+func tcpPorts(ports ...int32) []networkPolicyPort {
+	var result []networkPolicyPort
+	for _, port := range ports {
+		result = append(result, networkPolicyPort{Protocol: "TCP", Port: port})
+	}
+	return result
+}
+
+// etcdPorts returns the ports of an external etcd's endpoints, which
+// workers may connect to on any address, as etcd's address is usually a
+// hostname.
+func etcdPorts(endpoints []string) []int32 {
+	var result []int32
+	seen := make(map[int32]bool)
+	for _, endpoint := range endpoints {
+		if i := strings.Index(endpoint, "://"); i >= 0 {
+			endpoint = endpoint[i+len("://"):]
+		}
+		port := int32(2379)
+		if _, p, err := net.SplitHostPort(strings.TrimSuffix(endpoint, "/")); err == nil {
+			if n, err := strconv.ParseInt(p, 10, 32); err == nil {
+				port = int32(n)
+			}
+		}
+		if !seen[port] {
+			seen[port] = true
+			result = append(result, port)
+		}
+	}
+	return result
+}
+
+// workerNetworkPolicy returns the network policy of the workers in
+// options.rcName. Only pachd may connect to them, and they may only connect
+// to pachd, etcd (or the ports of externalEtcdEndpoints), DNS, the object
+// store (over HTTPS, to any public address) and the destinations in
+// options.allowedEgress. Workers with a rendezvous may also connect to each
+// other.
+func workerNetworkPolicy(options *workerOptions, externalEtcdEndpoints []string) *networkPolicy {
+	egress := []networkPolicyRule{
+		{To: []networkPolicyPeer{podsOf("pachd"), podsOf("etcd")}},
+		{Ports: []networkPolicyPort{{Protocol: "UDP", Port: 53}, {Protocol: "TCP", Port: 53}}},
+		{
+			Ports: tcpPorts(443),
+			To: []networkPolicyPeer{
+				{IPBlock: &ipBlock{CIDR: "0.0.0.0/0", Except: privateCIDRs}},
+				{IPBlock: &ipBlock{CIDR: "::/0", Except: privateIPv6CIDRs}},
+			},
+		},
+	}
+	if len(externalEtcdEndpoints) > 0 {
+		egress = append(egress, networkPolicyRule{Ports: tcpPorts(etcdPorts(externalEtcdEndpoints)...)})
+	}
+	for _, allowed := range options.allowedEgress {
+		egress = append(egress, networkPolicyRule{
+			Ports: tcpPorts(allowed.Ports...),
+			To:    []networkPolicyPeer{{IPBlock: &ipBlock{CIDR: allowed.CIDR}}},
+		})
+	}
+	ingress := []networkPolicyRule{{From: []networkPolicyPeer{podsOf("pachd")}}}
+	if options.rendezvous {
+		workers := networkPolicyPeer{
+			PodSelector: &unversioned.LabelSelector{
+				MatchLabels: options.labels,
+			},
+		}
+		egress = append(egress, networkPolicyRule{To: []networkPolicyPeer{workers}})
+		ingress = append(ingress, networkPolicyRule{From: []networkPolicyPeer{workers}})
+	}
+	return &networkPolicy{
+		TypeMeta: unversioned.TypeMeta{
+			Kind:       "NetworkPolicy",
+			APIVersion: "networking.k8s.io/v1",
+		},
+		ObjectMeta: api.ObjectMeta{
+			Name:   options.rcName,
+			Labels: options.labels,
+		},
+		Spec: networkPolicySpec{
+			PodSelector: unversioned.LabelSelector{
+				MatchLabels: options.labels,
+			},
+			PolicyTypes: []string{"Ingress", "Egress"},
+			Ingress:     ingress,
+			Egress:      egress,
+		},
+	}
+}
+
+func validateAllowedEgress(allowedEgress []*pps.AllowedEgress) error {
+	for _, allowed := range allowedEgress {
+		if _, _, err := net.ParseCIDR(allowed.CIDR); err != nil {
+			return fmt.Errorf("invalid allowed egress CIDR %q: %v", allowed.CIDR, err)
+		}
+		for _, port := range allowed.Ports {
+			if port < 1 || port > 65535 {
+				return fmt.Errorf("invalid allowed egress port %d for %s", port, allowed.CIDR)
+			}
+		}
+	}
+	return nil
+}
+
+func (a *apiServer) networkPoliciesPath() string {
+	return fmt.Sprintf("/apis/networking.k8s.io/v1/namespaces/%s/networkpolicies", a.namespace)
+}
+
+// createWorkerNetworkPolicy restricts the network access of the workers
+// in options.rcName, if pachd is configured to.
+func (a *apiServer) createWorkerNetworkPolicy(options *workerOptions) error {
+	if !a.workerNetworkPolicies {
+		return nil
+	}
+	body, err := json.Marshal(workerNetworkPolicy(options, a.externalEtcdEndpoints))
+	if err != nil {
+		return err
+	}
+	if err := a.kubeClient.Post().
+		AbsPath(a.networkPoliciesPath()).
+		SetHeader("Content-Type", "application/json").
+		Body(body).
+		Do().
+		Error(); err != nil && !isAlreadyExistsErr(err) {
+		return fmt.Errorf("could not create network policy for %s: %v", options.rcName, err)
+	}
+	return nil
+}
+
+func (a *apiServer) deleteWorkerNetworkPolicy(rcName string) error {
+	if !a.workerNetworkPolicies {
+		return nil
+	}
+	if err := a.kubeClient.Delete().
+		AbsPath(a.networkPoliciesPath(), rcName).
+		Do().
+		Error(); err != nil && !isNotFoundErr(err) {
+		return err
+	}
+	return nil
+}
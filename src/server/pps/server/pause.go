@@ -0,0 +1,55 @@
+package server
+
+import (
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/server/pkg/readonly"
+
+	"go.pedge.io/lion/proto"
+	"golang.org/x/net/context"
+)
+
+// pauseInterval is how often a job's master checks whether jobs have been
+// paused (see admin.SetReadOnly) while it's starting datums, and whether
+// they've been resumed while they're paused.
+const pauseInterval = 10 * time.Second
+
+// pauser holds a job's master back from starting datums while jobs are
+// paused. Datums which have already been started are left to finish.
+type pauser struct {
+	a     *apiServer
+	jobID string
+	// checked is when the master last checked whether jobs are paused, so
+	// that etcd isn't read for every datum
+	checked time.Time
+}
+
+// wait returns once jobs aren't paused, or ctx is done.
+func (p *pauser) wait(ctx context.Context) error {
+	if time.Since(p.checked) < pauseInterval {
+		return nil
+	}
+	paused := false
+	for {
+		status, err := readonly.Get(ctx, p.a.etcdClient)
+		if err != nil {
+			return err
+		}
+		p.checked = time.Now()
+		if status == nil || !status.PauseJobs {
+			if paused {
+				protolion.Infof("job %s has been resumed", p.jobID)
+			}
+			return nil
+		}
+		if !paused {
+			protolion.Infof("job %s is paused while the cluster is read-only for maintenance (%s)", p.jobID, status.Reason)
+			paused = true
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pauseInterval):
+		}
+	}
+}
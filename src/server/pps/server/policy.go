@@ -0,0 +1,156 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/pachyderm/pachyderm/src/client/pps"
+
+	"k8s.io/kubernetes/pkg/api/resource"
+)
+
+// validateResources checks that limits can be parsed, and that they're at
+// least as large as the resources that the workers request, as kubernetes
+// won't create workers otherwise.
+func validateResources(requests *pps.ResourceSpec, limits *pps.ResourceSpec) error {
+	if limits == nil {
+		return nil
+	}
+	if limits.Cpu < 0 || limits.Gpu < 0 {
+		return fmt.Errorf("resource limits can't be negative")
+	}
+	if _, err := parseResourceLimits(limits); err != nil {
+		return err
+	}
+	if requests == nil {
+		return nil
+	}
+	if limits.Cpu != 0 && requests.Cpu > limits.Cpu {
+		return fmt.Errorf("cpu request (%v) exceeds cpu limit (%v)", requests.Cpu, limits.Cpu)
+	}
+	if limits.Gpu != 0 && requests.Gpu > limits.Gpu {
+		return fmt.Errorf("gpu request (%d) exceeds gpu limit (%d)", requests.Gpu, limits.Gpu)
+	}
+	if limits.Memory != "" && requests.Memory != "" {
+		requested, err := resource.ParseQuantity(requests.Memory)
+		if err != nil {
+			return fmt.Errorf("could not parse memory quantity: %s", err)
+		}
+		limit, err := resource.ParseQuantity(limits.Memory)
+		if err != nil {
+			return fmt.Errorf("could not parse memory limit: %s", err)
+		}
+		if requested.Cmp(limit) > 0 {
+			return fmt.Errorf("memory request (%s) exceeds memory limit (%s)", requests.Memory, limits.Memory)
+		}
+	}
+	return nil
+}
+
+// validatePolicies checks that a pipeline (or orphan job) follows the
+// policies that pachd was configured with. If requireNonRoot is set, its code
+// must run as a non-root user, which also means that its workers aren't
+// privileged, and so can't mount the host's filesystems or devices (see
+// userSecurityContext). If requireResourceLimits is set, it must limit its
+// workers' CPU and memory, so that they can't starve the other pods on their
+// nodes. Its workers' requests and limits mustn't exceed the cluster's
+// maximums, see WorkerResources. requests and limits are checked after the
+// cluster's defaults have been applied to them.
+func (a *apiServer) validatePolicies(transform *pps.Transform, requests *pps.ResourceSpec, limits *pps.ResourceSpec) error {
+	if a.requireNonRoot && transform.GetRunAsUser() == 0 {
+		return fmt.Errorf("pipelines must run as a non-root user, set transform.run_as_user to a UID other than 0")
+	}
+	if a.requireResourceLimits && (limits.GetCpu() == 0 || limits.GetMemory() == "") {
+		return fmt.Errorf("pipelines must limit the resources their workers use, set resource_limits.cpu and resource_limits.memory")
+	}
+	return a.workerResources.validateMax(requests, limits)
+}
+
+// WorkerResources are the cluster's defaults and caps for the resources of
+// pipelines' (and orphan jobs') workers. They're applied when a pipeline is
+// created, so that changing them doesn't change existing pipelines.
+type WorkerResources struct {
+	// DefaultRequests are the resources that workers request, for those
+	// that their pipeline's resource_spec doesn't set.
+	DefaultRequests pps.ResourceSpec
+	// DefaultLimits are the limits of workers' resources, for those that
+	// their pipeline's resource_limits doesn't set.
+	DefaultLimits pps.ResourceSpec
+	// Max caps workers' requests and limits: a pipeline which requests or
+	// is limited to more is rejected, and a worker whose limit is set
+	// neither by its pipeline nor by DefaultLimits is limited to Max.
+	Max pps.ResourceSpec
+}
+
+// applyDefaults returns the requests and limits of a pipeline's workers, with
+// the resources that they don't set filled in from r's defaults.
+func (r *WorkerResources) applyDefaults(requests *pps.ResourceSpec, limits *pps.ResourceSpec) (*pps.ResourceSpec, *pps.ResourceSpec) {
+	return fillResources(requests, &r.DefaultRequests), fillResources(fillResources(limits, &r.DefaultLimits), &r.Max)
+}
+
+// fillResources returns spec, with the CPU and memory that it doesn't set
+// taken from defaults. It returns spec itself if defaults sets neither.
+func fillResources(spec *pps.ResourceSpec, defaults *pps.ResourceSpec) *pps.ResourceSpec {
+	if defaults.Cpu == 0 && defaults.Memory == "" {
+		return spec
+	}
+	result := &pps.ResourceSpec{}
+	if spec != nil {
+		*result = *spec
+	}
+	if result.Cpu == 0 {
+		result.Cpu = defaults.Cpu
+	}
+	if result.Memory == "" {
+		result.Memory = defaults.Memory
+	}
+	return result
+}
+
+// validateMax checks that neither requests nor limits exceed r.Max.
+func (r *WorkerResources) validateMax(requests *pps.ResourceSpec, limits *pps.ResourceSpec) error {
+	for _, spec := range []struct {
+		name string
+		*pps.ResourceSpec
+	}{{"resource_spec", requests}, {"resource_limits", limits}} {
+		if spec.ResourceSpec == nil {
+			continue
+		}
+		if r.Max.Cpu != 0 && spec.Cpu > r.Max.Cpu {
+			return fmt.Errorf("%s.cpu (%v) exceeds the cluster's maximum (%v)", spec.name, spec.Cpu, r.Max.Cpu)
+		}
+		if r.Max.Memory != "" && spec.Memory != "" {
+			memory, err := resource.ParseQuantity(spec.Memory)
+			if err != nil {
+				return fmt.Errorf("could not parse %s.memory: %s", spec.name, err)
+			}
+			max, err := resource.ParseQuantity(r.Max.Memory)
+			if err != nil {
+				return fmt.Errorf("could not parse the cluster's maximum memory: %s", err)
+			}
+			if memory.Cmp(max) > 0 {
+				return fmt.Errorf("%s.memory (%s) exceeds the cluster's maximum (%s)", spec.name, spec.Memory, r.Max.Memory)
+			}
+		}
+	}
+	return nil
+}
+
+// Validate checks that r's memory quantities can be parsed, and that its
+// defaults don't exceed its maximums, which would make every pipeline that
+// relies on them invalid.
+func (r *WorkerResources) Validate() error {
+	for _, spec := range []*pps.ResourceSpec{&r.DefaultRequests, &r.DefaultLimits, &r.Max} {
+		if spec.Cpu < 0 {
+			return fmt.Errorf("CPU can't be negative")
+		}
+		if spec.Memory != "" {
+			if _, err := resource.ParseQuantity(spec.Memory); err != nil {
+				return fmt.Errorf("could not parse memory quantity %q: %s", spec.Memory, err)
+			}
+		}
+	}
+	if err := r.validateMax(&r.DefaultRequests, &r.DefaultLimits); err != nil {
+		return fmt.Errorf("default %v", err)
+	}
+	return validateResources(&r.DefaultRequests, &r.DefaultLimits)
+}
@@ -0,0 +1,194 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/server/pkg/backoff"
+
+	"go.pedge.io/lion/proto"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/apis/extensions"
+)
+
+// PrepullArg is the argument that the worker binary is run with in the
+// user's image by the pods that pull it onto the nodes, it makes the worker
+// exit straight away. The worker is copied into the image and run, rather
+// than a command from the image, as user images may not have a shell.
+const PrepullArg = "--prepull"
+
+// prepullTimeout is how long pachd waits for a prepull DaemonSet's pods to
+// pull their images before deleting it anyway, see awaitPrepull.
+const prepullTimeout = time.Hour
+
+// prepullName returns the name of the DaemonSet that pulls the images of the
+// workers in the RC rcName onto the nodes, see createPrepullDaemonSet.
+func prepullName(rcName string) string {
+	return fmt.Sprintf("%s-prepull", rcName)
+}
+
+// prepullDaemonSet returns a DaemonSet whose pods pull the user's image and
+// the worker image onto each of the nodes that options' workers may be
+// scheduled on, so that the workers don't have to wait for big images to be
+// pulled when they're scheduled. The images are pulled by init containers:
+// the first copies the worker binary out of the worker image, which the
+// second runs in the user's image with PrepullArg. Once they've finished the
+// pods idle until the DaemonSet is deleted.
+func (a *apiServer) prepullDaemonSet(options *workerOptions) *extensions.DaemonSet {
+	pullPolicy := a.workerImagePullPolicy
+	if pullPolicy == "" {
+		pullPolicy = "IfNotPresent"
+	}
+	name := prepullName(options.rcName)
+	labels := labels(name)
+	volumeMounts := []api.VolumeMount{
+		{
+			Name:      "pach-bin",
+			MountPath: "/pach-bin",
+		},
+	}
+	template := api.PodTemplateSpec{
+		ObjectMeta: api.ObjectMeta{
+			Name:   name,
+			Labels: labels,
+		},
+		Spec: api.PodSpec{
+			InitContainers: []api.Container{
+				{
+					Name:            "init",
+					Image:           a.workerImage,
+					Command:         []string{"/pach/worker.sh"},
+					ImagePullPolicy: api.PullPolicy(pullPolicy),
+					VolumeMounts:    volumeMounts,
+				},
+				{
+					Name:            "pull",
+					Image:           options.userImage,
+					Command:         []string{"/pach-bin/worker", PrepullArg},
+					ImagePullPolicy: api.PullPolicy(pullPolicy),
+					VolumeMounts:    volumeMounts,
+				},
+			},
+			Containers: []api.Container{
+				{
+					Name:            "pause",
+					Image:           a.workerImage,
+					Command:         []string{"/bin/sh", "-c", "while true; do sleep 3600; done"},
+					ImagePullPolicy: api.PullPolicy(pullPolicy),
+				},
+			},
+			RestartPolicy:    "Always",
+			ImagePullSecrets: options.imagePullSecrets,
+			Volumes: []api.Volume{
+				{
+					Name: "pach-bin",
+					VolumeSource: api.VolumeSource{
+						EmptyDir: &api.EmptyDirVolumeSource{},
+					},
+				},
+			},
+		},
+	}
+	a.workerNodePool.Apply(&template)
+	return &extensions.DaemonSet{
+		TypeMeta: unversioned.TypeMeta{
+			Kind:       "DaemonSet",
+			APIVersion: "extensions/v1beta1",
+		},
+		ObjectMeta: api.ObjectMeta{
+			Name:   name,
+			Labels: labels,
+		},
+		Spec: extensions.DaemonSetSpec{
+			Selector: &unversioned.LabelSelector{
+				MatchLabels: labels,
+			},
+			Template: template,
+		},
+	}
+}
+
+// createPrepullDaemonSet starts pulling the images of the workers in
+// options.rcName onto the nodes, if pachd is configured to. The DaemonSet is
+// deleted once they've been pulled, see awaitPrepull.
+func (a *apiServer) createPrepullDaemonSet(options *workerOptions) error {
+	if !a.prepullImages {
+		return nil
+	}
+	daemonSet := a.prepullDaemonSet(options)
+	if _, err := a.kubeClient.Extensions().DaemonSets(a.namespace).Create(daemonSet); err != nil && !isAlreadyExistsErr(err) {
+		return fmt.Errorf("could not create image pre-pull DaemonSet for %s: %v", options.rcName, err)
+	}
+	go a.awaitPrepull(options.rcName)
+	return nil
+}
+
+// awaitPrepull deletes the prepull DaemonSet of the workers in rcName once
+// all of its pods have pulled their images, so that they don't keep taking
+// up the nodes' pod limits. It gives up waiting after prepullTimeout, e.g. if
+// some of the nodes can't pull the images.
+func (a *apiServer) awaitPrepull(rcName string) {
+	b := backoff.NewExponentialBackOff()
+	b.MaxElapsedTime = prepullTimeout
+	if err := backoff.Retry(func() error {
+		return a.prepulled(rcName)
+	}, b); err != nil {
+		protolion.Errorf("images of %s weren't pulled onto every node within %v: %v", rcName, prepullTimeout, err)
+	}
+	if err := a.deletePrepullDaemonSet(rcName); err != nil {
+		protolion.Errorf("error deleting image pre-pull DaemonSet for %s: %v", rcName, err)
+	}
+}
+
+// prepulled returns nil once every pod of the prepull DaemonSet of the
+// workers in rcName has pulled its images, which it has once it's running,
+// or if the DaemonSet has been deleted.
+func (a *apiServer) prepulled(rcName string) error {
+	name := prepullName(rcName)
+	daemonSet, err := a.kubeClient.Extensions().DaemonSets(a.namespace).Get(name)
+	if err != nil {
+		if isNotFoundErr(err) {
+			return nil
+		}
+		return err
+	}
+	pods, err := a.rcPods(name)
+	if err != nil {
+		return err
+	}
+	var pulled int32
+	for _, pod := range pods {
+		if pod.Status.Phase == api.PodRunning {
+			pulled++
+		}
+	}
+	desired := daemonSet.Status.DesiredNumberScheduled
+	if desired == 0 || pulled < desired {
+		return fmt.Errorf("%d of %d nodes have pulled the images", pulled, desired)
+	}
+	return nil
+}
+
+// deletePrepullDaemonSet deletes the DaemonSet that pulled the images of the
+// workers in rcName, and its pods, which this version of kubernetes' API
+// doesn't delete along with it.
+func (a *apiServer) deletePrepullDaemonSet(rcName string) error {
+	if !a.prepullImages {
+		return nil
+	}
+	name := prepullName(rcName)
+	if err := a.kubeClient.Extensions().DaemonSets(a.namespace).Delete(name); err != nil && !isNotFoundErr(err) {
+		return err
+	}
+	pods, err := a.rcPods(name)
+	if err != nil {
+		return err
+	}
+	for _, pod := range pods {
+		if err := a.kubeClient.Pods(a.namespace).Delete(pod.Name, nil); err != nil && !isNotFoundErr(err) {
+			return err
+		}
+	}
+	return nil
+}
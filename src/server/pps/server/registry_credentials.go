@@ -0,0 +1,135 @@
+package server
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/server/pkg/backoff"
+	"github.com/pachyderm/pachyderm/src/server/pkg/dlock"
+	"github.com/pachyderm/pachyderm/src/server/pkg/registry"
+
+	etcd "github.com/coreos/etcd/clientv3"
+	"go.pedge.io/lion/proto"
+	"golang.org/x/net/context"
+	"k8s.io/kubernetes/pkg/api"
+	kube "k8s.io/kubernetes/pkg/client/unversioned"
+)
+
+const (
+	// RegistryCredentialsSecret is the kubernetes secret that holds the
+	// credentials which pachd refreshes for registries, which all workers
+	// pull their images with, see RefreshRegistryCredentials.
+	RegistryCredentialsSecret = "pachyderm-registry-credentials"
+	// registryCredentialsLockKey is the lock held by the pachd that
+	// refreshes registries' credentials.
+	registryCredentialsLockKey = "/registry_credentials_lock"
+	// minRegistryCredentialsRefresh and maxRegistryCredentialsRefresh bound
+	// how often registries' credentials are refreshed.
+	minRegistryCredentialsRefresh = time.Minute
+	maxRegistryCredentialsRefresh = time.Hour
+)
+
+// RefreshRegistryCredentials keeps RegistryCredentialsSecret filled with
+// credentials for registries (e.g. "123456789012.dkr.ecr.us-east-1.amazonaws.com"
+// or "gcr.io"), which are short-lived tokens that pachd gets from the
+// registries' cloud providers, see registry.GetAuth. The credentials are
+// refreshed by the time half of their lifetime has passed, so that workers
+// which are started overnight can still pull their images. Only the pachd
+// that holds the registry credentials lock refreshes them, so it can be run
+// on every pachd. It only returns if registries is empty, or it can't
+// connect to etcd.
+func RefreshRegistryCredentials(etcdConfig etcd.Config, etcdPrefix string, kubeClient *kube.Client, namespace string, registries []string) {
+	if len(registries) == 0 {
+		return
+	}
+	etcdClient, err := etcd.New(etcdConfig)
+	if err != nil {
+		protolion.Errorf("error connecting to etcd; this pachd won't refresh registry credentials: %v", err)
+		return
+	}
+	defer etcdClient.Close()
+	lock := dlock.NewDLock(etcdClient, path.Join(etcdPrefix, registryCredentialsLockKey))
+	b := backoff.NewInfiniteBackOff()
+	backoff.RetryNotify(func() error {
+		ctx, err := lock.Lock(context.Background())
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := lock.Unlock(context.Background()); err != nil {
+				protolion.Errorf("error releasing the registry credentials lock: %v", err)
+			}
+		}()
+		for {
+			expiry, err := refreshRegistryCredentials(ctx, kubeClient, namespace, registries)
+			if err != nil {
+				return err
+			}
+			b.Reset()
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(registryCredentialsRefreshInterval(time.Until(expiry))):
+			}
+		}
+	}, b, func(err error, d time.Duration) error {
+		protolion.Errorf("error refreshing registry credentials: %v; retrying in %v", err, d)
+		return nil
+	})
+}
+
+// refreshRegistryCredentials writes new credentials for registries into
+// RegistryCredentialsSecret, and returns when the first of them expires.
+func refreshRegistryCredentials(ctx context.Context, kubeClient *kube.Client, namespace string, registries []string) (time.Time, error) {
+	auths := make(map[string]*registry.Auth)
+	var expiry time.Time
+	for _, host := range registries {
+		auth, err := registry.GetAuth(ctx, host)
+		if err != nil {
+			return time.Time{}, err
+		}
+		auths[host] = auth
+		if expiry.IsZero() || auth.Expiry.Before(expiry) {
+			expiry = auth.Expiry
+		}
+	}
+	config, err := registry.DockerConfigJSON(auths)
+	if err != nil {
+		return time.Time{}, err
+	}
+	secret := &api.Secret{
+		ObjectMeta: api.ObjectMeta{
+			Name:   RegistryCredentialsSecret,
+			Labels: labels(RegistryCredentialsSecret),
+		},
+		Type: api.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{
+			api.DockerConfigJsonKey: config,
+		},
+	}
+	secrets := kubeClient.Secrets(namespace)
+	if _, err := secrets.Update(secret); isNotFoundErr(err) {
+		_, err = secrets.Create(secret)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("could not create secret %s: %v", RegistryCredentialsSecret, err)
+		}
+	} else if err != nil {
+		return time.Time{}, fmt.Errorf("could not update secret %s: %v", RegistryCredentialsSecret, err)
+	}
+	protolion.Infof("refreshed the credentials of registries %v, which expire at %v", registries, expiry)
+	return expiry, nil
+}
+
+// registryCredentialsRefreshInterval returns how long to wait before
+// refreshing credentials which expire in lifetime.
+func registryCredentialsRefreshInterval(lifetime time.Duration) time.Duration {
+	interval := lifetime / 2
+	if interval < minRegistryCredentialsRefresh {
+		return minRegistryCredentialsRefresh
+	}
+	if interval > maxRegistryCredentialsRefresh {
+		return maxRegistryCredentialsRefresh
+	}
+	return interval
+}
@@ -8,12 +8,14 @@ import (
 	"github.com/pachyderm/pachyderm/src/client/pkg/shard"
 	ppsclient "github.com/pachyderm/pachyderm/src/client/pps"
 	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+	"github.com/pachyderm/pachyderm/src/server/pkg/deploy/assets"
 	"github.com/pachyderm/pachyderm/src/server/pkg/metrics"
 	ppsserver "github.com/pachyderm/pachyderm/src/server/pps"
 
 	etcd "github.com/coreos/etcd/clientv3"
 	"go.pedge.io/proto/rpclog"
 	"golang.org/x/net/context"
+	"google.golang.org/grpc/credentials"
 	kube "k8s.io/kubernetes/pkg/client/unversioned"
 )
 
@@ -22,11 +24,30 @@ type APIServer interface {
 	ppsclient.APIServer
 	shard.Frontend
 	shard.Server
+	// StopMasters stops the masters of pipelines and jobs that this pachd
+	// is running, so that other pachds can take them over.
+	StopMasters(ctx context.Context) error
 }
 
 const (
 	pipelinesPrefix = "/pipelines"
-	jobsPrefix      = "/jobs"
+	jobsPrefix      = ppsserver.JobsPrefix
+	datumsPrefix    = "/datums"
+	// pipelineJobsPrefix maps each input that a pipeline has created a job
+	// for to that job, so that a pipeline creates one job per input, however
+	// many pachds are running its master.
+	pipelineJobsPrefix = "/pipeline_jobs"
+	// pipelineTriggersPrefix maps each key that a pipeline has been
+	// triggered with (see TriggerPipeline) to the job that was created for
+	// it, so that retried triggers don't create more jobs.
+	pipelineTriggersPrefix = "/pipeline_triggers"
+	// outputBranchJobsPrefix maps each output branch to the last job created
+	// to commit to it, which is the parent of the next one, so that jobs
+	// commit their output in the order they were created.
+	outputBranchJobsPrefix = "/output_branch_jobs"
+	// masterLocksPrefix holds the locks of pipelines' and jobs' masters,
+	// which make sure that each master runs on one pachd at a time.
+	masterLocksPrefix = "/master_locks"
 )
 
 var (
@@ -50,7 +71,7 @@ var (
 
 // NewAPIServer creates an APIServer.
 func NewAPIServer(
-	etcdAddress string,
+	etcdConfig etcd.Config,
 	etcdPrefix string,
 	hasher *ppsserver.Hasher,
 	address string,
@@ -62,12 +83,32 @@ func NewAPIServer(
 	storageRoot string,
 	storageBackend string,
 	storageHostPath string,
+	internalToken string,
+	peerCreds credentials.TransportCredentials,
+	cipher *col.Cipher,
+	etcdKeySecret string,
+	externalEtcdEndpoints []string,
+	etcdTLSSecret string,
+	etcdCredentialsSecret string,
+	workerNetworkPolicies bool,
+	prepullImages bool,
+	registryCredentials bool,
+	allowedImagePrefixes []string,
+	requireNonRoot bool,
+	requireResourceLimits bool,
+	workerResources WorkerResources,
+	maxWorkers int32,
+	teamWeights map[string]int64,
+	workerNodePool assets.NodePool,
+	defaultUserImage string,
+	privilegedWorkers bool,
+	sqlEgress bool,
+	sqlInputs bool,
+	workerPort uint16,
+	workerEnv map[string]string,
 	reporter *metrics.Reporter,
 ) (APIServer, error) {
-	etcdClient, err := etcd.New(etcd.Config{
-		Endpoints:   []string{etcdAddress},
-		DialOptions: client.EtcdDialOptions(),
-	})
+	etcdClient, err := etcd.New(etcdConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -91,19 +132,53 @@ func NewAPIServer(
 		storageRoot:           storageRoot,
 		storageBackend:        storageBackend,
 		storageHostPath:       storageHostPath,
+		internalToken:         internalToken,
+		peerCreds:             peerCreds,
+		etcdKeySecret:         etcdKeySecret,
+		externalEtcdEndpoints: externalEtcdEndpoints,
+		etcdTLSSecret:         etcdTLSSecret,
+		etcdCredentialsSecret: etcdCredentialsSecret,
+		workerNetworkPolicies: workerNetworkPolicies,
+		prepullImages:         prepullImages,
+		registryCredentials:   registryCredentials,
+		allowedImagePrefixes:  allowedImagePrefixes,
+		requireNonRoot:        requireNonRoot,
+		requireResourceLimits: requireResourceLimits,
+		workerResources:       workerResources,
+		maxWorkers:            maxWorkers,
+		teamWeights:           teamWeights,
+		workerNodePool:        workerNodePool,
+		defaultUserImage:      defaultUserImage,
+		privilegedWorkers:     privilegedWorkers,
+		sqlEgress:             sqlEgress,
+		sqlInputs:             sqlInputs,
+		workerPort:            workerPort,
+		workerEnv:             workerEnv,
 		reporter:              reporter,
-		pipelines: col.NewCollection(
+		pipelines: col.WithErrors(col.NewEncryptedCollection(
 			etcdClient,
 			path.Join(etcdPrefix, pipelinesPrefix),
 			[]col.Index{stoppedIndex},
 			&ppsclient.PipelineInfo{},
-		),
-		jobs: col.NewCollection(
+			cipher,
+		), client.ErrPipelineNotFound, client.ErrPipelineExists),
+		jobs: col.WithErrors(col.NewEncryptedCollection(
 			etcdClient,
 			path.Join(etcdPrefix, jobsPrefix),
 			[]col.Index{jobsPipelineIndex, stoppedIndex, jobsInputIndex},
 			&ppsclient.JobInfo{},
-		),
+			cipher,
+		), client.ErrJobNotFound, nil),
+		datums: func(jobID string) col.Collection {
+			return col.NewCollection(
+				etcdClient,
+				path.Join(etcdPrefix, datumsPrefix, jobID),
+				nil,
+				&ppsclient.DatumInfo{},
+			)
+		},
 	}
+	apiServer.batcher = newBatcher(apiServer)
+	go apiServer.batcher.run(context.Background())
 	return apiServer, nil
 }
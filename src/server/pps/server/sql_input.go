@@ -0,0 +1,213 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+	col "github.com/pachyderm/pachyderm/src/server/pkg/collection"
+	"github.com/pachyderm/pachyderm/src/server/pkg/cron"
+	"github.com/pachyderm/pachyderm/src/server/pkg/sqldb"
+
+	"github.com/gogo/protobuf/types"
+	"go.pedge.io/lion/proto"
+	"golang.org/x/net/context"
+)
+
+// sqlInputRetryInterval is how long a SQL input waits to snapshot its query
+// again after a snapshot fails.
+const sqlInputRetryInterval = time.Minute
+
+// createSQLInputRepos creates the repos that pipelineInfo's SQL inputs commit
+// their snapshots to, if they don't exist yet.
+func (a *apiServer) createSQLInputRepos(ctx context.Context, pfsClient pfs.APIClient, pipelineInfo *pps.PipelineInfo) error {
+	var result error
+	visit(pipelineInfo.Input, func(input *pps.Input) {
+		if input.SQL == nil || result != nil {
+			return
+		}
+		if _, err := pfsClient.CreateRepo(ctx, &pfs.CreateRepoRequest{
+			Repo:        client.NewRepo(input.SQL.Repo),
+			Description: fmt.Sprintf("snapshots of the SQL input %s of pipeline %s", input.SQL.Name, pipelineInfo.Pipeline.Name),
+		}); err != nil && !isAlreadyExistsErr(err) {
+			result = err
+		}
+	})
+	return result
+}
+
+// runSQLInputs snapshots each of pipelineInfo's SQL inputs on its schedule,
+// until ctx is cancelled. It's run by the pipeline's master, so that each
+// snapshot is taken once.
+func (a *apiServer) runSQLInputs(ctx context.Context, pipelineInfo *pps.PipelineInfo) {
+	visit(pipelineInfo.Input, func(input *pps.Input) {
+		if input.SQL != nil {
+			go a.runSQLInput(ctx, pipelineInfo.Pipeline.Name, input.SQL)
+		}
+	})
+}
+
+func (a *apiServer) runSQLInput(ctx context.Context, pipelineName string, input *pps.SQLInput) {
+	schedule, err := cron.Parse(input.Spec)
+	if err != nil {
+		// the schedule was validated when the pipeline was created
+		protolion.Errorf("invalid schedule for SQL input %s of pipeline %s: %v", input.Name, pipelineName, err)
+		return
+	}
+	pfsClient, err := a.getPFSClient()
+	if err != nil {
+		protolion.Errorf("error snapshotting SQL input %s of pipeline %s: %v", input.Name, pipelineName, err)
+		return
+	}
+	pachClient := &client.APIClient{
+		PfsAPIClient: pfsClient,
+	}
+	for {
+		next, err := nextSnapshot(pachClient, input, schedule)
+		if err == nil {
+			if next.IsZero() {
+				protolion.Errorf("the schedule %q of SQL input %s of pipeline %s is never due", input.Spec, input.Name, pipelineName)
+				return
+			}
+			if !sleepUntil(ctx, next) {
+				return
+			}
+			err = a.snapshotSQLInput(ctx, pachClient, input)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			protolion.Errorf("pipeline %s: %s%v", pipelineName, sqlInputErrorPrefix(input), err)
+		}
+		if err := a.setSQLInputError(ctx, pipelineName, input, err); err != nil {
+			protolion.Errorf("error updating pipeline %s: %v", pipelineName, err)
+		}
+		if err != nil && !sleepUntil(ctx, time.Now().Add(sqlInputRetryInterval)) {
+			return
+		}
+	}
+}
+
+// nextSnapshot returns when input's next snapshot is due, which is right away
+// if it hasn't been snapshotted yet, or otherwise when its schedule is next
+// due after the last snapshot finished.
+func nextSnapshot(pachClient *client.APIClient, input *pps.SQLInput, schedule cron.Schedule) (time.Time, error) {
+	commitInfos, err := pachClient.ListCommit(input.Repo, "master", "", 1)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(commitInfos) == 0 || commitInfos[0].Finished == nil {
+		return time.Now(), nil
+	}
+	last, err := types.TimestampFromProto(commitInfos[0].Finished)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return schedule.Next(last), nil
+}
+
+// sleepUntil waits until t, and returns false if ctx is cancelled first.
+func sleepUntil(ctx context.Context, t time.Time) bool {
+	timer := time.NewTimer(t.Sub(time.Now()))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// snapshotSQLInput runs input's query and commits its results to master of
+// input's repo. The results are written to a temporary file first, so that a
+// query that fails part way doesn't commit a partial snapshot.
+func (a *apiServer) snapshotSQLInput(ctx context.Context, pachClient *client.APIClient, input *pps.SQLInput) (retErr error) {
+	secret, err := a.kubeClient.Secrets(a.namespace).Get(input.Secret)
+	if err != nil {
+		return fmt.Errorf("could not get SQL input secret %s: %v", input.Secret, err)
+	}
+	f, err := ioutil.TempFile("", "sql-input")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := f.Close(); err != nil && retErr == nil {
+			retErr = err
+		}
+		if err := os.Remove(f.Name()); err != nil && retErr == nil {
+			retErr = err
+		}
+	}()
+	rows, err := sqldb.Snapshot(ctx, input, secret.Data, f)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	commit, err := pachClient.StartCommit(input.Repo, "master")
+	if err != nil {
+		return err
+	}
+	// a snapshot that isn't finished is deleted, as its open commit would
+	// keep the next snapshot from starting one on master
+	defer func() {
+		if retErr == nil {
+			return
+		}
+		if err := pachClient.DeleteCommit(input.Repo, commit.ID); err != nil {
+			protolion.Errorf("error deleting unfinished snapshot %s@%s of SQL input %s: %v", input.Repo, commit.ID, input.Name, err)
+		}
+	}()
+	// each snapshot replaces the last
+	file := sqldb.SnapshotFile(input)
+	if err := pachClient.DeleteFile(input.Repo, commit.ID, file); err != nil && !isNotFoundErr(err) {
+		return err
+	}
+	if _, err := pachClient.PutFile(input.Repo, commit.ID, file, f); err != nil {
+		return err
+	}
+	if err := pachClient.FinishCommit(input.Repo, commit.ID); err != nil {
+		return err
+	}
+	protolion.Infof("snapshotted %d rows of SQL input %s into %s@%s", rows, input.Name, input.Repo, commit.ID)
+	return nil
+}
+
+// sqlInputErrorPrefix starts the errors of input's snapshots.
+func sqlInputErrorPrefix(input *pps.SQLInput) string {
+	return fmt.Sprintf("error snapshotting SQL input %s: ", input.Name)
+}
+
+// setSQLInputError records err, the error of input's last snapshot, as the
+// pipeline's recent error. If err is nil, it clears the recent error if it's
+// one of input's.
+func (a *apiServer) setSQLInputError(ctx context.Context, pipelineName string, input *pps.SQLInput, err error) error {
+	recentError := ""
+	if err != nil {
+		recentError = sqlInputErrorPrefix(input) + err.Error()
+	}
+	_, stmErr := col.NewSTM(ctx, a.etcdClient, func(stm col.STM) error {
+		pipelines := a.pipelines.ReadWrite(stm)
+		pipelineInfo := new(pps.PipelineInfo)
+		if err := pipelines.Get(pipelineName, pipelineInfo); err != nil {
+			return err
+		}
+		if pipelineInfo.RecentError == recentError ||
+			(err == nil && !strings.HasPrefix(pipelineInfo.RecentError, sqlInputErrorPrefix(input))) {
+			return nil
+		}
+		pipelineInfo.RecentError = recentError
+		pipelines.Put(pipelineName, pipelineInfo)
+		return nil
+	})
+	return stmErr
+}
@@ -0,0 +1,285 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+	"github.com/pachyderm/pachyderm/src/server/pkg/metrics"
+	ppsserver "github.com/pachyderm/pachyderm/src/server/pps"
+
+	"go.pedge.io/lion/proto"
+	"golang.org/x/net/context"
+)
+
+// RunTransaction applies the ops of a transaction. They're applied by kind,
+// rather than in the order they're given: first commits are started, then
+// all of the commits that the transaction finishes are finished together,
+// along with the files that it deletes from them, by pfs.FinishCommits, and
+// finally pipelines are created, so that the finished commits are the first
+// that they process. Until the commits are finished nothing that the
+// transaction does is visible to pipelines. If an op fails, the ones before
+// it are rolled back: the pipelines are deleted, and so are the commits that
+// the transaction started, whose branches are moved back to where they were.
+// Commits that were started before the transaction can't be reopened once
+// it's finished them, so pipelines are dry run before anything is applied.
+func (a *apiServer) RunTransaction(ctx context.Context, request *pps.RunTransactionRequest) (response *pps.TransactionInfo, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	metricsFn := metrics.ReportUserAction(ctx, a.reporter, "RunTransaction")
+	defer func(start time.Time) { metricsFn(start, retErr) }(time.Now())
+
+	if len(request.Ops) == 0 {
+		return nil, fmt.Errorf("transaction has no ops")
+	}
+	for i, op := range request.Ops {
+		if err := validateTransactionOp(op); err != nil {
+			return nil, fmt.Errorf("op %d: %v", i, err)
+		}
+	}
+	pipelines := make(map[string]bool)
+	for _, op := range request.Ops {
+		if op.CreatePipeline == nil {
+			continue
+		}
+		name := op.CreatePipeline.Pipeline.Name
+		if pipelines[name] {
+			return nil, fmt.Errorf("pipeline %s is created more than once", name)
+		}
+		pipelines[name] = true
+		// newPipelineInfo translates the request's Inputs in place, which
+		// CreatePipeline does again
+		dryRun := *op.CreatePipeline
+		pipelineInfo, err := a.newPipelineInfo(ctx, &dryRun)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := a.dryRunPipeline(ctx, pipelineInfo, false); err != nil {
+			return nil, err
+		}
+	}
+	pfsClient, err := a.getPFSClient()
+	if err != nil {
+		return nil, err
+	}
+	r := &transactionRollback{pfsClient: pfsClient}
+	defer func() {
+		if retErr != nil {
+			// the rollback runs even if the request was canceled
+			r.run(context.Background(), a)
+		}
+	}()
+
+	response = &pps.TransactionInfo{}
+	started := make(map[string]bool)
+	for _, op := range request.Ops {
+		if op.StartCommit == nil {
+			continue
+		}
+		if err := r.saveBranch(ctx, op.StartCommit.Parent.Repo, op.StartCommit.Branch); err != nil {
+			return nil, err
+		}
+		commit, err := pfsClient.StartCommit(ctx, op.StartCommit)
+		if err != nil {
+			return nil, err
+		}
+		r.commits = append(r.commits, commit)
+		started[commit.FullID()] = true
+		response.Started = append(response.Started, commit)
+	}
+	var finish []*pfs.Commit
+	finishing := make(map[string]bool)
+	for _, op := range request.Ops {
+		if op.FinishCommit == nil {
+			continue
+		}
+		commitInfo, err := openCommit(ctx, pfsClient, op.FinishCommit.Commit)
+		if err != nil {
+			return nil, err
+		}
+		finish = append(finish, commitInfo.Commit)
+		finishing[commitInfo.Commit.FullID()] = true
+	}
+	// files are only deleted from commits that the rollback can undo the
+	// deletion in: commits that are finished, by FinishCommits, which
+	// deletes them only if the commits are finished, and commits that the
+	// transaction starts, which the rollback deletes
+	var deleteOnFinish []*pfs.File
+	for _, op := range request.Ops {
+		if op.DeleteFile == nil {
+			continue
+		}
+		commitInfo, err := openCommit(ctx, pfsClient, op.DeleteFile.File.Commit)
+		if err != nil {
+			return nil, err
+		}
+		file := &pfs.File{Commit: commitInfo.Commit, Path: op.DeleteFile.File.Path}
+		switch id := commitInfo.Commit.FullID(); {
+		case finishing[id]:
+			deleteOnFinish = append(deleteOnFinish, file)
+		case started[id]:
+			if _, err := pfsClient.DeleteFile(ctx, &pfs.DeleteFileRequest{File: file}); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("file %s is deleted from commit %s, which the transaction neither starts nor finishes, so the deletion couldn't be rolled back", file.Path, id)
+		}
+	}
+	if len(finish) > 0 {
+		if response.Transaction, err = pfsClient.FinishCommits(ctx, &pfs.FinishCommitsRequest{
+			Commits:     finish,
+			DeleteFiles: deleteOnFinish,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	for _, op := range request.Ops {
+		if op.CreatePipeline == nil {
+			continue
+		}
+		// the output repo is only deleted by the rollback if the
+		// pipeline creates it
+		outputRepo := ppsserver.PipelineRepo(op.CreatePipeline.Pipeline)
+		_, err := pfsClient.InspectRepo(ctx, &pfs.InspectRepoRequest{Repo: outputRepo})
+		if err != nil && !isNotFoundErr(err) {
+			return nil, err
+		}
+		createsRepo := err != nil
+		if _, err := a.CreatePipeline(ctx, op.CreatePipeline); err != nil {
+			return nil, err
+		}
+		r.pipelines = append(r.pipelines, op.CreatePipeline.Pipeline)
+		if createsRepo {
+			r.repos = append(r.repos, outputRepo)
+		}
+	}
+	return response, nil
+}
+
+// openCommit returns the info of commit, or an error if it's finished.
+func openCommit(ctx context.Context, pfsClient pfs.APIClient, commit *pfs.Commit) (*pfs.CommitInfo, error) {
+	commitInfo, err := pfsClient.InspectCommit(ctx, &pfs.InspectCommitRequest{Commit: commit})
+	if err != nil {
+		return nil, err
+	}
+	if commitInfo.Finished != nil {
+		return nil, fmt.Errorf("commit %s has already been finished", commitInfo.Commit.FullID())
+	}
+	return commitInfo, nil
+}
+
+func validateTransactionOp(op *pps.TransactionOp) error {
+	var n int
+	for _, set := range []bool{op.StartCommit != nil, op.FinishCommit != nil, op.DeleteFile != nil, op.CreatePipeline != nil} {
+		if set {
+			n++
+		}
+	}
+	if n != 1 {
+		return fmt.Errorf("exactly one operation must be set, got %d", n)
+	}
+	switch {
+	case op.StartCommit != nil:
+		if op.StartCommit.Parent == nil || op.StartCommit.Parent.Repo == nil {
+			return fmt.Errorf("start_commit must set parent.repo")
+		}
+	case op.FinishCommit != nil:
+		if op.FinishCommit.Commit == nil || op.FinishCommit.Commit.Repo == nil {
+			return fmt.Errorf("finish_commit must set commit")
+		}
+	case op.DeleteFile != nil:
+		if op.DeleteFile.File == nil || op.DeleteFile.File.Commit == nil || op.DeleteFile.File.Commit.Repo == nil {
+			return fmt.Errorf("delete_file must set file")
+		}
+	case op.CreatePipeline != nil:
+		if op.CreatePipeline.Pipeline == nil {
+			return fmt.Errorf("create_pipeline must set pipeline")
+		}
+		if op.CreatePipeline.Update {
+			// an update can't be rolled back
+			return fmt.Errorf("pipelines can't be updated in a transaction")
+		}
+		if op.CreatePipeline.DryRun {
+			return fmt.Errorf("pipelines can't be dry run in a transaction, see DryRunPipeline")
+		}
+	}
+	return nil
+}
+
+// transactionRollback undoes the ops of a transaction that have been
+// applied.
+type transactionRollback struct {
+	pfsClient pfs.APIClient
+	// commits are the commits that were started, and branches the branches
+	// that they were started on, with their heads before then, whose IDs are
+	// empty for branches that didn't exist
+	commits  []*pfs.Commit
+	branches []*pfs.Branch
+	// pipelines are the pipelines that were created, and repos the output
+	// repos that they created
+	pipelines []*pps.Pipeline
+	repos     []*pfs.Repo
+}
+
+// saveBranch records the head of branch, so that run can restore it.
+func (r *transactionRollback) saveBranch(ctx context.Context, repo *pfs.Repo, branch string) error {
+	if branch == "" {
+		return nil
+	}
+	saved := &pfs.Branch{Name: branch}
+	commitInfo, err := r.pfsClient.InspectCommit(ctx, &pfs.InspectCommitRequest{
+		Commit: &pfs.Commit{Repo: repo, ID: branch},
+	})
+	if err != nil && !isNotFoundErr(err) {
+		return err
+	}
+	if err == nil {
+		saved.Head = commitInfo.Commit
+	} else {
+		saved.Head = &pfs.Commit{Repo: repo}
+	}
+	r.branches = append(r.branches, saved)
+	return nil
+}
+
+// run rolls back the ops, in reverse. Errors are logged, since there's no
+// more that can be done about them.
+func (r *transactionRollback) run(ctx context.Context, a *apiServer) {
+	for i := len(r.pipelines) - 1; i >= 0; i-- {
+		if _, err := a.DeletePipeline(ctx, &pps.DeletePipelineRequest{
+			Pipeline:   r.pipelines[i],
+			DeleteJobs: true,
+		}); err != nil {
+			protolion.Errorf("error rolling back the creation of pipeline %s: %v", r.pipelines[i].Name, err)
+		}
+	}
+	for _, repo := range r.repos {
+		if _, err := r.pfsClient.DeleteRepo(ctx, &pfs.DeleteRepoRequest{Repo: repo, Force: true}); err != nil {
+			protolion.Errorf("error rolling back the creation of repo %s: %v", repo.Name, err)
+		}
+	}
+	// deleting the commits also deletes the files deleted from them, and
+	// the commits of jobs that processed them
+	for i := len(r.commits) - 1; i >= 0; i-- {
+		if _, err := r.pfsClient.DeleteCommit(ctx, &pfs.DeleteCommitRequest{Commit: r.commits[i]}); err != nil {
+			protolion.Errorf("error rolling back the start of commit %s: %v", r.commits[i].FullID(), err)
+		}
+	}
+	for i := len(r.branches) - 1; i >= 0; i-- {
+		branch := r.branches[i]
+		var err error
+		if branch.Head.ID != "" {
+			_, err = r.pfsClient.SetBranch(ctx, &pfs.SetBranchRequest{Commit: branch.Head, Branch: branch.Name})
+		} else {
+			_, err = r.pfsClient.DeleteBranch(ctx, &pfs.DeleteBranchRequest{Repo: branch.Head.Repo, Branch: branch.Name})
+			if isNotFoundErr(err) {
+				// deleting the branch's only commit deleted it
+				err = nil
+			}
+		}
+		if err != nil {
+			protolion.Errorf("error rolling back branch %s of repo %s: %v", branch.Name, branch.Head.Repo.Name, err)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	etcd "github.com/coreos/etcd/clientv3"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+	"github.com/pachyderm/pachyderm/src/server/pkg/dlock"
+
+	"go.pedge.io/lion/proto"
+	"golang.org/x/net/context"
+
+	"k8s.io/kubernetes/pkg/api"
+	kube_labels "k8s.io/kubernetes/pkg/labels"
+)
+
+const (
+	// workerQuotaLock is the lock (see dlock) that pachds hold while they
+	// scale workers up under maxWorkers, so that two pachds can't both take
+	// the last of the cluster's workers.
+	workerQuotaLock = "/worker_quota_lock"
+	// workerQuotaInterval is how often a job that's waiting for workers
+	// checks whether they're available.
+	workerQuotaInterval = 10 * time.Second
+	// workerQuotaScaleDownThreshold is how long the workers of a pipeline
+	// without a scale_down_threshold are kept after its last job finishes, if
+	// the number of workers is capped, so that idle pipelines don't hold on
+	// to workers that other pipelines' jobs are waiting for.
+	workerQuotaScaleDownThreshold = 5 * time.Minute
+	// workerQueuePrefix is the etcd prefix of the jobs that are waiting for
+	// workers under maxWorkers, as queuedJobs keyed by their RCs' names.
+	// They're put with leases that last a few workerQuotaIntervals, so that
+	// the jobs of a pachd that has died aren't waited for.
+	workerQueuePrefix = "/worker_queue"
+	// teamAnnotation is the annotation of worker RCs that holds their
+	// pipelines' teams, see pps.SchedulingSpec, so that each team's workers
+	// can be counted.
+	teamAnnotation = "pachyderm.io/team"
+)
+
+// queuedJob is a job that's waiting for workers, see
+// scaleUpWorkersWithinQuota.
+type queuedJob struct {
+	RcName   string
+	Priority int64
+	Team     string
+	// Queued is when the job started waiting
+	Queued time.Time
+}
+
+// validateScheduling checks that spec is well formed.
+func validateScheduling(spec *pps.SchedulingSpec) error {
+	if strings.ContainsAny(spec.GetTeam(), ",=") {
+		return fmt.Errorf("team %q can't contain commas or equals signs", spec.GetTeam())
+	}
+	return nil
+}
+
+// jobAhead returns true if job x gets workers before job y, given the number
+// of workers that each team has and the teams' weights: the job with the
+// higher priority goes first, then the job whose team has the fewest workers
+// relative to its weight, then the job that has waited longest.
+func jobAhead(x, y *queuedJob, teamWorkers map[string]int32, teamWeights map[string]int64) bool {
+	if x.Priority != y.Priority {
+		return x.Priority > y.Priority
+	}
+	if x.Team != y.Team {
+		// x's share of the workers is less than y's if
+		// workers(x)/weight(x) < workers(y)/weight(y)
+		xShare := int64(teamWorkers[x.Team]) * teamWeight(teamWeights, y.Team)
+		yShare := int64(teamWorkers[y.Team]) * teamWeight(teamWeights, x.Team)
+		if xShare != yShare {
+			return xShare < yShare
+		}
+	}
+	if !x.Queued.Equal(y.Queued) {
+		return x.Queued.Before(y.Queued)
+	}
+	return x.RcName < y.RcName
+}
+
+// teamWeight returns team's weight, which is 1 unless pachd was deployed
+// with another.
+func teamWeight(teamWeights map[string]int64, team string) int64 {
+	if weight, ok := teamWeights[team]; ok && weight > 0 {
+		return weight
+	}
+	return 1
+}
+
+// scaleUpWorkersWithinQuota scales the RC rcName up to parallelism workers,
+// once the total number of workers in the cluster allows it. A job that
+// needs more workers than are left waits (in the JOB_STARTING state) until
+// other pipelines' workers have been scaled down, or ctx is done. A pipeline
+// whose parallelism alone exceeds maxWorkers gets maxWorkers workers.
+//
+// Jobs that are waiting are queued in etcd, and workers go to the first job
+// in the queue (see jobAhead) under the job's schedulingSpec, so a job that
+// is behind others waits even if there are enough workers for it, rather
+// than taking the workers that they're waiting for.
+func (a *apiServer) scaleUpWorkersWithinQuota(ctx context.Context, rcName string, parallelism int32, schedulingSpec *pps.SchedulingSpec) (retErr error) {
+	if parallelism > a.maxWorkers {
+		protolion.Infof("%s's parallelism (%d) exceeds the cluster's maximum number of workers, scaling it to %d", rcName, parallelism, a.maxWorkers)
+		parallelism = a.maxWorkers
+	}
+	job := &queuedJob{
+		RcName:   rcName,
+		Priority: schedulingSpec.GetPriority(),
+		Team:     schedulingSpec.GetTeam(),
+		Queued:   time.Now(),
+	}
+	waiting := false
+	defer func() {
+		if !waiting {
+			return
+		}
+		// ctx may be done, which is why the job stopped waiting
+		if _, err := a.etcdClient.Delete(context.Background(), a.workerQueueKey(rcName)); err != nil && retErr == nil {
+			retErr = err
+		}
+	}()
+	for {
+		scaled, err := a.tryScaleUpWorkers(ctx, job, parallelism)
+		if err != nil {
+			return err
+		}
+		if scaled {
+			if waiting {
+				protolion.Infof("%s got its %d workers", rcName, parallelism)
+			}
+			return nil
+		}
+		if !waiting {
+			protolion.Infof("%s is waiting for %d workers, the cluster's maximum of %d workers has been reached or other jobs are ahead of it", rcName, parallelism, a.maxWorkers)
+			waiting = true
+		}
+		if err := a.queueJob(ctx, job); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(workerQuotaInterval):
+		}
+	}
+}
+
+// tryScaleUpWorkers scales the RC of job to parallelism workers, and returns
+// true, if that keeps the cluster's total number of workers within
+// maxWorkers and no other queued job is ahead of job.
+func (a *apiServer) tryScaleUpWorkers(ctx context.Context, job *queuedJob, parallelism int32) (bool, error) {
+	lock := dlock.NewDLock(a.etcdClient, path.Join(a.etcdPrefix, workerQuotaLock))
+	if _, err := lock.Lock(ctx); err != nil {
+		return false, err
+	}
+	defer func() {
+		if err := lock.Unlock(ctx); err != nil {
+			protolion.Errorf("error releasing the worker quota lock: %v", err)
+		}
+	}()
+	rcs := a.kubeClient.ReplicationControllers(a.namespace)
+	workerRc, err := rcs.Get(job.RcName)
+	if err != nil {
+		return false, err
+	}
+	if workerRc.Spec.Replicas == parallelism {
+		return true, nil
+	}
+	if parallelism > workerRc.Spec.Replicas {
+		total, teamWorkers, err := a.countWorkers()
+		if err != nil {
+			return false, err
+		}
+		if total-workerRc.Spec.Replicas+parallelism > a.maxWorkers {
+			return false, nil
+		}
+		queue, err := a.queuedJobs(ctx)
+		if err != nil {
+			return false, err
+		}
+		for _, other := range queue {
+			if other.RcName != job.RcName && jobAhead(other, job, teamWorkers, a.teamWeights) {
+				return false, nil
+			}
+		}
+	}
+	if workerRc.Annotations == nil {
+		workerRc.Annotations = make(map[string]string)
+	}
+	workerRc.Annotations[teamAnnotation] = job.Team
+	workerRc.Spec.Replicas = parallelism
+	if _, err := rcs.Update(workerRc); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// countWorkers returns the number of workers that all of the worker RCs of
+// this Pachyderm instance maintain, and the number that each team's RCs
+// maintain. RCs that haven't been scaled up under maxWorkers yet count
+// towards the default team, "".
+func (a *apiServer) countWorkers() (int32, map[string]int32, error) {
+	rcList, err := a.kubeClient.ReplicationControllers(a.namespace).List(api.ListOptions{
+		LabelSelector: kube_labels.SelectorFromSet(map[string]string{"suite": suite}),
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	var total int32
+	teamWorkers := make(map[string]int32)
+	for _, rc := range rcList.Items {
+		if isWorkerRcName(rc.Name) {
+			total += rc.Spec.Replicas
+			teamWorkers[rc.Annotations[teamAnnotation]] += rc.Spec.Replicas
+		}
+	}
+	return total, teamWorkers, nil
+}
+
+func (a *apiServer) workerQueueKey(rcName string) string {
+	return path.Join(a.etcdPrefix, workerQueuePrefix, rcName)
+}
+
+// queueJob puts job in the queue of jobs that are waiting for workers, with
+// a lease that expires unless it's put again within a few
+// workerQuotaIntervals.
+func (a *apiServer) queueJob(ctx context.Context, job *queuedJob) error {
+	value, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	resp, err := a.etcdClient.Grant(ctx, int64(3*workerQuotaInterval/time.Second))
+	if err != nil {
+		return err
+	}
+	_, err = a.etcdClient.Put(ctx, a.workerQueueKey(job.RcName), string(value), etcd.WithLease(resp.ID))
+	return err
+}
+
+// queuedJobs returns the jobs that are waiting for workers.
+func (a *apiServer) queuedJobs(ctx context.Context) ([]*queuedJob, error) {
+	resp, err := a.etcdClient.Get(ctx, a.workerQueueKey("")+"/", etcd.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+	var result []*queuedJob
+	for _, kv := range resp.Kvs {
+		job := &queuedJob{}
+		if err := json.Unmarshal(kv.Value, job); err != nil {
+			return nil, err
+		}
+		result = append(result, job)
+	}
+	return result, nil
+}
+
+// isWorkerRcName returns true if name is the name of a worker RC, see
+// PipelineRcName and JobRcName, rather than of another of Pachyderm's RCs
+// (such as etcd's).
+func isWorkerRcName(name string) bool {
+	return strings.HasPrefix(name, "pipeline-") || strings.HasPrefix(name, "job-")
+}
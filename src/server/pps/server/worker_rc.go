@@ -2,11 +2,14 @@ package server
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 
 	client "github.com/pachyderm/pachyderm/src/client"
 	"github.com/pachyderm/pachyderm/src/client/pps"
 	"github.com/pachyderm/pachyderm/src/server/pkg/deploy/assets"
+	"go.pedge.io/lion/proto"
 
 	"k8s.io/kubernetes/pkg/api"
 	"k8s.io/kubernetes/pkg/api/unversioned"
@@ -21,10 +24,20 @@ type workerOptions struct {
 	labels       map[string]string // k8s labels attached to the Deployment and workers
 	parallelism  int32             // Number of replicas the RC maintains
 	resources    *api.ResourceList // Resources requested by pipeline/job pods
+	limits       *api.ResourceList // Most resources that pipeline/job pods may use
+	runAsUser    int64             // UID that the user's code runs as, if not 0
 	workerEnv    []api.EnvVar      // Environment vars set in the user container
 	volumes      []api.Volume      // Volumes that we expose to the user container
 	volumeMounts []api.VolumeMount // Paths where we mount each volume in 'volumes'
 
+	// Destinations outside of the cluster that the workers may connect to,
+	// see workerNetworkPolicy
+	allowedEgress []*pps.AllowedEgress
+
+	// Whether the workers may connect to each other, and their service is
+	// headless, so that its DNS name resolves to their IPs, see pps.Rendezvous
+	rendezvous bool
+
 	// Secrets that we mount in the worker container (e.g. for reading/writing to
 	// s3)
 	imagePullSecrets []api.LocalObjectReference
@@ -72,6 +85,9 @@ func (a *apiServer) workerPodSpec(options *workerOptions) api.PodSpec {
 		Name:  "STORAGE_BACKEND",
 		Value: a.storageBackend,
 	}}
+	// the sidecar reads auth's state, which may be encrypted
+	sidecarEnv = append(sidecarEnv, assets.EtcdKeySecretEnv(a.etcdKeySecret)...)
+	sidecarEnv = append(sidecarEnv, assets.ExternalEtcdEnv(a.externalEtcdEndpoints, a.etcdCredentialsSecret)...)
 	// This only happens in local deployment.  We want the workers to be
 	// able to read from/write to the hostpath volume as well.
 	storageVolumeName := "pach-disk"
@@ -98,6 +114,11 @@ func (a *apiServer) workerPodSpec(options *workerOptions) api.PodSpec {
 		options.volumes = append(options.volumes, secretVolume)
 		sidecarVolumeMounts = append(sidecarVolumeMounts, secretMount)
 	}
+	if a.etcdTLSSecret != "" {
+		// getWorkerOptions has already added the volume
+		_, etcdTLSMount := assets.ExternalEtcdTLSVolumeAndMount(a.etcdTLSSecret)
+		sidecarVolumeMounts = append(sidecarVolumeMounts, etcdTLSMount)
+	}
 	podSpec := api.PodSpec{
 		InitContainers: []api.Container{
 			{
@@ -111,12 +132,10 @@ func (a *apiServer) workerPodSpec(options *workerOptions) api.PodSpec {
 		},
 		Containers: []api.Container{
 			{
-				Name:    client.PPSWorkerUserContainerName,
-				Image:   options.userImage,
-				Command: []string{"/pach-bin/guest.sh"},
-				SecurityContext: &api.SecurityContext{
-					Privileged: &trueVal, // god is this dumb
-				},
+				Name:            client.PPSWorkerUserContainerName,
+				Image:           options.userImage,
+				Command:         []string{"/pach-bin/guest.sh"},
+				SecurityContext: a.userSecurityContext(options.runAsUser),
 				ImagePullPolicy: api.PullPolicy(pullPolicy),
 				Env:             options.workerEnv,
 				VolumeMounts:    options.volumeMounts,
@@ -135,18 +154,40 @@ func (a *apiServer) workerPodSpec(options *workerOptions) api.PodSpec {
 		ImagePullSecrets: options.imagePullSecrets,
 	}
 	if options.resources != nil {
-		podSpec.Containers[0].Resources = api.ResourceRequirements{
-			Requests: *options.resources,
-		}
+		podSpec.Containers[0].Resources.Requests = *options.resources
+	}
+	if options.limits != nil {
+		podSpec.Containers[0].Resources.Limits = *options.limits
 	}
 	return podSpec
 }
 
-func (a *apiServer) getWorkerOptions(rcName string, parallelism int32, resources *api.ResourceList, transform *pps.Transform) *workerOptions {
+// userSecurityContext returns the security context of the container that
+// runs the user's code. If the pipeline runs as a non-root user kubernetes
+// also refuses to start it as root, otherwise it's only privileged if pachd
+// was deployed with privileged workers. Without a security context the
+// container runs as its image's user, or, on OpenShift, as the UID that the
+// project assigns.
+func (a *apiServer) userSecurityContext(runAsUser int64) *api.SecurityContext {
+	if runAsUser == 0 {
+		if !a.privilegedWorkers {
+			return nil
+		}
+		return &api.SecurityContext{
+			Privileged: &trueVal,
+		}
+	}
+	return &api.SecurityContext{
+		RunAsUser:    &runAsUser,
+		RunAsNonRoot: &trueVal,
+	}
+}
+
+func (a *apiServer) getWorkerOptions(rcName string, parallelism int32, resources *api.ResourceList, limits *api.ResourceList, transform *pps.Transform) *workerOptions {
 	labels := labels(rcName)
 	userImage := transform.Image
 	if userImage == "" {
-		userImage = DefaultUserImage
+		userImage = a.defaultUserImage
 	}
 
 	var workerEnv []api.EnvVar
@@ -159,6 +200,20 @@ func (a *apiServer) getWorkerOptions(rcName string, parallelism int32, resources
 			},
 		)
 	}
+	// the cluster's env vars, which the pipeline's own override
+	var clusterEnv []string
+	for name := range a.workerEnv {
+		if _, ok := transform.Env[name]; !ok {
+			clusterEnv = append(clusterEnv, name)
+		}
+	}
+	sort.Strings(clusterEnv)
+	for _, name := range clusterEnv {
+		workerEnv = append(workerEnv, api.EnvVar{
+			Name:  name,
+			Value: a.workerEnv[name],
+		})
+	}
 	// We use Kubernetes' "Downward API" so the workers know their IP
 	// addresses, which they will then post on etcd so the job managers
 	// can discover the workers.
@@ -185,20 +240,72 @@ func (a *apiServer) getWorkerOptions(rcName string, parallelism int32, resources
 		Name:  client.PPSEtcdPrefixEnv,
 		Value: a.etcdPrefix,
 	})
+	workerEnv = append(workerEnv, api.EnvVar{
+		Name:  client.PPSWorkerPortEnv,
+		Value: strconv.Itoa(int(a.workerPort)),
+	})
+	// the worker reads its pipeline or job from etcd, which may be encrypted
+	workerEnv = append(workerEnv, assets.EtcdKeySecretEnv(a.etcdKeySecret)...)
+	workerEnv = append(workerEnv, assets.ExternalEtcdEnv(a.externalEtcdEndpoints, a.etcdCredentialsSecret)...)
+	if transform.Rendezvous != nil {
+		// the workers claim ranks up to the number of workers
+		workerEnv = append(workerEnv, api.EnvVar{
+			Name:  client.PPSWorkerCountEnv,
+			Value: strconv.Itoa(int(parallelism)),
+		})
+	}
 
 	var volumes []api.Volume
 	var volumeMounts []api.VolumeMount
-	for _, secret := range transform.Secrets {
+	volumeNames := make(map[string]bool)
+	if a.etcdTLSSecret != "" {
+		etcdTLSVolume, etcdTLSMount := assets.ExternalEtcdTLSVolumeAndMount(a.etcdTLSSecret)
+		volumes = append(volumes, etcdTLSVolume)
+		volumeMounts = append(volumeMounts, etcdTLSMount)
+		volumeNames[etcdTLSVolume.Name] = true
+	}
+	for i, secret := range transform.Secrets {
+		if secret.EnvVar != "" {
+			workerEnv = append(workerEnv, api.EnvVar{
+				Name: secret.EnvVar,
+				ValueFrom: &api.EnvVarSource{
+					SecretKeyRef: &api.SecretKeySelector{
+						LocalObjectReference: api.LocalObjectReference{
+							Name: secret.Name,
+						},
+						Key: secret.Key,
+					},
+				},
+			})
+		}
+		if secret.MountPath == "" {
+			continue
+		}
+		// the same secret may be mounted more than once, e.g. with
+		// different items, but volumes need distinct names
+		volumeName := secret.Name
+		if volumeNames[volumeName] {
+			volumeName = fmt.Sprintf("%s-%d", secret.Name, i)
+		}
+		volumeNames[volumeName] = true
+		var items []api.KeyToPath
+		for _, item := range secret.Items {
+			items = append(items, api.KeyToPath{
+				Key:  item,
+				Path: item,
+			})
+		}
 		volumes = append(volumes, api.Volume{
-			Name: secret.Name,
+			Name: volumeName,
 			VolumeSource: api.VolumeSource{
 				Secret: &api.SecretVolumeSource{
 					SecretName: secret.Name,
+					Items:      items,
 				},
 			},
 		})
 		volumeMounts = append(volumeMounts, api.VolumeMount{
-			Name:      secret.Name,
+			Name:      volumeName,
 			MountPath: secret.MountPath,
 		})
 	}
@@ -229,17 +336,24 @@ func (a *apiServer) getWorkerOptions(rcName string, parallelism int32, resources
 	for _, secret := range transform.ImagePullSecrets {
 		imagePullSecrets = append(imagePullSecrets, api.LocalObjectReference{Name: secret})
 	}
+	if a.registryCredentials {
+		imagePullSecrets = append(imagePullSecrets, api.LocalObjectReference{Name: RegistryCredentialsSecret})
+	}
 
 	return &workerOptions{
 		rcName:           rcName,
 		labels:           labels,
 		parallelism:      int32(parallelism),
 		resources:        resources,
+		limits:           limits,
+		runAsUser:        transform.RunAsUser,
 		userImage:        userImage,
 		workerEnv:        workerEnv,
 		volumes:          volumes,
 		volumeMounts:     volumeMounts,
 		imagePullSecrets: imagePullSecrets,
+		allowedEgress:    transform.AllowedEgress,
+		rendezvous:       transform.Rendezvous != nil,
 	}
 }
 
@@ -265,10 +379,14 @@ func (a *apiServer) createWorkerRc(options *workerOptions) error {
 			},
 		},
 	}
+	a.workerNodePool.Apply(rc.Spec.Template)
 	if _, err := a.kubeClient.ReplicationControllers(a.namespace).Create(rc); err != nil {
 		if !isAlreadyExistsErr(err) {
 			return err
 		}
+		if err := a.upgradeWorkerRc(rc); err != nil {
+			return err
+		}
 	}
 
 	service := &api.Service{
@@ -284,12 +402,15 @@ func (a *apiServer) createWorkerRc(options *workerOptions) error {
 			Selector: options.labels,
 			Ports: []api.ServicePort{
 				{
-					Port: client.PPSWorkerPort,
+					Port: int32(a.workerPort),
 					Name: "grpc-port",
 				},
 			},
 		},
 	}
+	if options.rendezvous {
+		service.Spec.ClusterIP = api.ClusterIPNone
+	}
 
 	if _, err := a.kubeClient.Services(a.namespace).Create(service); err != nil {
 		if !isAlreadyExistsErr(err) {
@@ -297,5 +418,98 @@ func (a *apiServer) createWorkerRc(options *workerOptions) error {
 		}
 	}
 
+	if err := a.createPrepullDaemonSet(options); err != nil {
+		return err
+	}
+	return a.createWorkerNetworkPolicy(options)
+}
+
+// upgradeWorkerRc updates the existing worker RC with the same name as 'rc' if
+// its workers use different pachyderm images than 'rc' does, which happens
+// when pachd has been upgraded since the RC was created. The RC's existing
+// pods keep running, so that an upgrade doesn't interrupt the datums that
+// they're processing; they're replaced by restartOutdatedWorkers. The workers
+// keep listening on their service's port, even if pachd's worker port has
+// changed since.
+func (a *apiServer) upgradeWorkerRc(rc *api.ReplicationController) error {
+	rcs := a.kubeClient.ReplicationControllers(a.namespace)
+	oldRc, err := rcs.Get(rc.Name)
+	if err != nil {
+		return err
+	}
+	if oldRc.Spec.Template == nil || workerImages(oldRc.Spec.Template.Spec) == workerImages(rc.Spec.Template.Spec) {
+		return nil
+	}
+	workerPort, err := a.workerServicePort(rc.Name)
+	if err != nil {
+		return err
+	}
+	setWorkerPort(&rc.Spec.Template.Spec, workerPort)
+	// Keep the old RC's number of replicas, as the workers might have been
+	// scaled down.
+	oldRc.Spec.Template = rc.Spec.Template
+	if _, err := rcs.Update(oldRc); err != nil {
+		return err
+	}
+	protolion.Infof("upgraded workers %s to %s", rc.Name, workerImages(rc.Spec.Template.Spec))
 	return nil
 }
+
+// restartOutdatedWorkers deletes the pods of the RC rcName that use different
+// pachyderm images than the RC does, see upgradeWorkerRc, so that they're
+// recreated with its images. It's called once none of the workers' jobs are
+// running.
+func (a *apiServer) restartOutdatedWorkers(rcName string) error {
+	rc, err := a.kubeClient.ReplicationControllers(a.namespace).Get(rcName)
+	if err != nil {
+		return err
+	}
+	if rc.Spec.Template == nil {
+		return nil
+	}
+	images := workerImages(rc.Spec.Template.Spec)
+	pods, err := a.rcPods(rcName)
+	if err != nil {
+		return err
+	}
+	for _, pod := range pods {
+		if workerImages(pod.Spec) == images {
+			continue
+		}
+		if err := a.kubeClient.Pods(a.namespace).Delete(pod.Name, nil); err != nil {
+			if !isNotFoundErr(err) {
+				return err
+			}
+		}
+		protolion.Infof("restarted worker %s with %s", pod.Name, images)
+	}
+	return nil
+}
+
+// setWorkerPort sets the port that the workers of spec listen on.
+func setWorkerPort(spec *api.PodSpec, port uint16) {
+	for _, containers := range [][]api.Container{spec.InitContainers, spec.Containers} {
+		for _, container := range containers {
+			for i := range container.Env {
+				if container.Env[i].Name == client.PPSWorkerPortEnv {
+					container.Env[i].Value = strconv.Itoa(int(port))
+				}
+			}
+		}
+	}
+}
+
+// workerImages returns the pachyderm images (as opposed to the user's image)
+// used by a worker pod.
+func workerImages(spec api.PodSpec) string {
+	var images []string
+	for _, container := range spec.InitContainers {
+		images = append(images, container.Image)
+	}
+	for _, container := range spec.Containers {
+		if container.Name != client.PPSWorkerUserContainerName {
+			images = append(images, container.Image)
+		}
+	}
+	return strings.Join(images, ",")
+}
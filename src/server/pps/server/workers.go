@@ -3,7 +3,9 @@ package server
 import (
 	"context"
 	"fmt"
+	"net"
 	"path"
+	"strconv"
 
 	"github.com/gogo/protobuf/types"
 	"github.com/pachyderm/pachyderm/src/client"
@@ -18,8 +20,8 @@ const (
 	workerEtcdPrefix = "workers"
 )
 
-func status(ctx context.Context, id string, etcdClient *etcd.Client, etcdPrefix string) ([]*pps.WorkerStatus, error) {
-	workerClients, err := workerClients(ctx, id, etcdClient, etcdPrefix)
+func status(ctx context.Context, id string, etcdClient *etcd.Client, etcdPrefix string, workerPort uint16) ([]*pps.WorkerStatus, error) {
+	workerClients, err := workerClients(ctx, id, etcdClient, etcdPrefix, workerPort)
 	if err != nil {
 		return nil, err
 	}
@@ -35,8 +37,8 @@ func status(ctx context.Context, id string, etcdClient *etcd.Client, etcdPrefix
 }
 
 func cancel(ctx context.Context, id string, etcdClient *etcd.Client,
-	etcdPrefix string, jobID string, dataFilter []string) error {
-	workerClients, err := workerClients(ctx, id, etcdClient, etcdPrefix)
+	etcdPrefix string, workerPort uint16, jobID string, dataFilter []string) error {
+	workerClients, err := workerClients(ctx, id, etcdClient, etcdPrefix, workerPort)
 	if err != nil {
 		return err
 	}
@@ -59,7 +61,32 @@ func cancel(ctx context.Context, id string, etcdClient *etcd.Client,
 	return nil
 }
 
-func workerClients(ctx context.Context, id string, etcdClient *etcd.Client, etcdPrefix string) ([]workerpkg.WorkerClient, error) {
+// stopDatums cancels the datums of the job with ID jobID that the workers of
+// id are processing, whatever they are, and returns their IDs.
+func stopDatums(ctx context.Context, id string, etcdClient *etcd.Client,
+	etcdPrefix string, workerPort uint16, jobID string) ([]string, error) {
+	workerClients, err := workerClients(ctx, id, etcdClient, etcdPrefix, workerPort)
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, workerClient := range workerClients {
+		resp, err := workerClient.Cancel(ctx, &workerpkg.CancelRequest{
+			JobID: jobID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if resp.Success {
+			result = append(result, resp.DatumID)
+		}
+	}
+	return result, nil
+}
+
+// workerClients returns clients of the workers of id, which register their IP
+// addresses (IPv4 or IPv6) in etcd, on workerPort.
+func workerClients(ctx context.Context, id string, etcdClient *etcd.Client, etcdPrefix string, workerPort uint16) ([]workerpkg.WorkerClient, error) {
 	resp, err := etcdClient.Get(ctx, path.Join(etcdPrefix, workerEtcdPrefix, id), etcd.WithPrefix())
 	if err != nil {
 		return nil, err
@@ -67,7 +94,7 @@ func workerClients(ctx context.Context, id string, etcdClient *etcd.Client, etcd
 
 	var result []workerpkg.WorkerClient
 	for _, kv := range resp.Kvs {
-		conn, err := grpc.Dial(fmt.Sprintf("%s:%d", path.Base(string(kv.Key)), client.PPSWorkerPort),
+		conn, err := grpc.Dial(net.JoinHostPort(path.Base(string(kv.Key)), strconv.Itoa(int(workerPort))),
 			client.PachDialOptions()...)
 		if err != nil {
 			return nil, err
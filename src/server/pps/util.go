@@ -7,6 +7,10 @@ import (
 	ppsclient "github.com/pachyderm/pachyderm/src/client/pps"
 )
 
+// JobsPrefix is the prefix, under PPS's etcd prefix, of the collection in
+// which PPS keeps JobInfos, keyed by their IDs.
+const JobsPrefix = "/jobs"
+
 // JobRepo creates a pfs repo for a given job.
 func JobRepo(job *ppsclient.Job) *pfs.Repo {
 	return &pfs.Repo{Name: fmt.Sprintf("job_%s", job.ID)}
@@ -16,3 +20,12 @@ func JobRepo(job *ppsclient.Job) *pfs.Repo {
 func PipelineRepo(pipeline *ppsclient.Pipeline) *pfs.Repo {
 	return &pfs.Repo{Name: pipeline.Name}
 }
+
+// SQLInputRepo returns the repo that a pipeline's SQL input commits its
+// snapshots to, which is "<pipeline>_<name>" unless the input names one.
+func SQLInputRepo(pipeline *ppsclient.Pipeline, input *ppsclient.SQLInput) *pfs.Repo {
+	if input.Repo != "" {
+		return &pfs.Repo{Name: input.Repo}
+	}
+	return &pfs.Repo{Name: fmt.Sprintf("%s_%s", pipeline.Name, input.Name)}
+}
@@ -0,0 +1,248 @@
+package rest
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/server/pkg/backoff"
+
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+// ingestPrefix is the path under which files are pushed into repos, by
+// POST /v1/repos/<repo>/files/<path>, see ingestHandler.
+const ingestPrefix = Prefix + "repos/"
+
+// ingestChunkSize is the size of the chunks that pushed files are sent to
+// pachd in, which is kept well below gRPC's maximum message size.
+const ingestChunkSize = 1 << 20
+
+// ingestHandler serves POST /v1/repos/<repo>/files/<path>, which writes the
+// request's body to <path>, in a new commit on a branch (master, unless the
+// branch query parameter says otherwise) of <repo>, for producers, such as
+// webhooks and curl, which can POST a file but can't speak gRPC. If the
+// request is a multipart/form-data upload, each of its files is written
+// under <path>, by its filename, in the same commit. The file replaces the
+// one at <path>, unless the append query parameter is true. If the commit
+// query parameter is set, the files are written to that open commit, which
+// is left open, rather than a new one. The response is the commit.
+type ingestHandler struct {
+	marshaler runtime.Marshaler
+	pfsClient pfs.APIClient
+}
+
+func (h *ingestHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// the errors of the REST API's other routes include the headers and
+	// trailers of pachd's response, which the ingest routes don't forward
+	ctx := runtime.NewServerMetadataContext(r.Context(), runtime.ServerMetadata{})
+	ctx, err := runtime.AnnotateContext(ctx, r)
+	if err != nil {
+		h.httpError(runtime.NewServerMetadataContext(r.Context(), runtime.ServerMetadata{}), w, r, err)
+		return
+	}
+	// webhooks can usually only be given a bearer token, which is taken as
+	// the user's auth token, like the Grpc-Metadata-Authn-Token header
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		ctx = metadata.NewContext(ctx, metadata.Join(metadataFromContext(ctx), metadata.Pairs("authn-token", strings.TrimPrefix(auth, "Bearer "))))
+	}
+	if r.Method != "POST" {
+		h.httpError(ctx, w, r, grpc.Errorf(codes.Unimplemented, "%s isn't allowed on %s, files are pushed with POST", r.Method, r.URL.Path))
+		return
+	}
+	repo, filePath, err := parseIngestPath(r.URL.Path)
+	if err != nil {
+		h.httpError(ctx, w, r, grpc.Errorf(codes.InvalidArgument, "%v", err))
+		return
+	}
+	query := r.URL.Query()
+	branch := query.Get("branch")
+	if branch == "" {
+		branch = "master"
+	}
+	var appendFiles bool
+	if s := query.Get("append"); s != "" {
+		if appendFiles, err = strconv.ParseBool(s); err != nil {
+			h.httpError(ctx, w, r, grpc.Errorf(codes.InvalidArgument, "invalid append %q", s))
+			return
+		}
+	}
+
+	// the files are read before the commit is started, as a multipart
+	// upload may be malformed, in which case nothing should be committed
+	var files func(put func(filePath string, r io.Reader) error) error
+	if mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err == nil && mediaType == "multipart/form-data" {
+		mr := multipart.NewReader(r.Body, params["boundary"])
+		files = func(put func(string, io.Reader) error) error {
+			for {
+				part, err := mr.NextPart()
+				if err == io.EOF {
+					return nil
+				}
+				if err != nil {
+					return grpc.Errorf(codes.InvalidArgument, "malformed multipart upload: %v", err)
+				}
+				// form fields that aren't files are ignored
+				if part.FileName() == "" {
+					continue
+				}
+				if err := put(path.Join(filePath, path.Base(part.FileName())), part); err != nil {
+					return err
+				}
+			}
+		}
+	} else {
+		if filePath == "" {
+			h.httpError(ctx, w, r, grpc.Errorf(codes.InvalidArgument, "a file's path must be given, e.g. %s%s/files/<path>", ingestPrefix, repo))
+			return
+		}
+		files = func(put func(string, io.Reader) error) error {
+			return put(filePath, r.Body)
+		}
+	}
+	put := func(commit *pfs.Commit) func(string, io.Reader) error {
+		return func(filePath string, r io.Reader) error {
+			if !appendFiles {
+				if _, err := h.pfsClient.DeleteFile(ctx, &pfs.DeleteFileRequest{
+					File: &pfs.File{Commit: commit, Path: filePath},
+				}); err != nil && !strings.Contains(err.Error(), "not found") {
+					return err
+				}
+			}
+			return h.putFile(ctx, &pfs.File{Commit: commit, Path: filePath}, r)
+		}
+	}
+
+	if commitID := query.Get("commit"); commitID != "" {
+		commit := client.NewCommit(repo, commitID)
+		if err := files(put(commit)); err != nil {
+			h.httpError(ctx, w, r, err)
+			return
+		}
+		h.writeCommit(ctx, w, r, commit)
+		return
+	}
+	commit, err := h.startCommit(ctx, repo, branch)
+	if err != nil {
+		h.httpError(ctx, w, r, err)
+		return
+	}
+	if err := files(put(commit)); err != nil {
+		h.pfsClient.DeleteCommit(ctx, &pfs.DeleteCommitRequest{Commit: commit})
+		h.httpError(ctx, w, r, err)
+		return
+	}
+	if _, err := h.pfsClient.FinishCommit(ctx, &pfs.FinishCommitRequest{Commit: commit}); err != nil {
+		h.httpError(ctx, w, r, err)
+		return
+	}
+	h.writeCommit(ctx, w, r, commit)
+}
+
+// startCommit starts a commit on branch of repo. Another producer may have
+// a commit open on the branch, in which case startCommit waits for them to
+// finish it.
+func (h *ingestHandler) startCommit(ctx context.Context, repo string, branch string) (*pfs.Commit, error) {
+	var commit *pfs.Commit
+	b := backoff.NewExponentialBackOff()
+	b.MaxElapsedTime = time.Minute
+	if err := backoff.RetryNotify(func() error {
+		var err error
+		commit, err = h.pfsClient.StartCommit(ctx, &pfs.StartCommitRequest{
+			Parent: client.NewCommit(repo, ""),
+			Branch: branch,
+		})
+		return err
+	}, b, func(err error, d time.Duration) error {
+		if !strings.Contains(err.Error(), "has not been finished") {
+			return err
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return commit, nil
+}
+
+func (h *ingestHandler) putFile(ctx context.Context, file *pfs.File, r io.Reader) error {
+	putFileClient, err := h.pfsClient.PutFile(ctx)
+	if err != nil {
+		return err
+	}
+	request := &pfs.PutFileRequest{File: file}
+	buf := make([]byte, ingestChunkSize)
+	sent := false
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 || !sent {
+			// at least one request is sent, so that empty files are
+			// created
+			request.Value = buf[:n]
+			if err := putFileClient.Send(request); err != nil {
+				return err
+			}
+			sent = true
+			// File is only needed on the first request
+			request.File = nil
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			putFileClient.CloseSend()
+			return grpc.Errorf(codes.InvalidArgument, "could not read the request's body: %v", err)
+		}
+	}
+	_, err = putFileClient.CloseAndRecv()
+	return err
+}
+
+func (h *ingestHandler) writeCommit(ctx context.Context, w http.ResponseWriter, r *http.Request, commit *pfs.Commit) {
+	data, err := h.marshaler.Marshal(commit)
+	if err != nil {
+		h.httpError(ctx, w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", h.marshaler.ContentType())
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// httpError writes err as the gateway writes the errors of the REST API's
+// other routes.
+func (h *ingestHandler) httpError(ctx context.Context, w http.ResponseWriter, r *http.Request, err error) {
+	runtime.HTTPError(ctx, h.marshaler, w, r, err)
+}
+
+// parseIngestPath parses a path of the form /v1/repos/<repo>/files/<path>.
+// path may be empty, e.g. for multipart uploads to the root of a repo.
+func parseIngestPath(urlPath string) (repo string, filePath string, retErr error) {
+	parts := strings.SplitN(strings.TrimPrefix(urlPath, ingestPrefix), "/", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] != "files" {
+		return "", "", fmt.Errorf("invalid path %s, files are pushed to %s<repo>/files/<path>", urlPath, ingestPrefix)
+	}
+	if len(parts) == 3 {
+		filePath = strings.Trim(parts[2], "/")
+	}
+	return parts[0], filePath, nil
+}
+
+func metadataFromContext(ctx context.Context) metadata.MD {
+	md, ok := metadata.FromContext(ctx)
+	if !ok {
+		return metadata.MD{}
+	}
+	return md
+}
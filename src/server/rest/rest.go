@@ -0,0 +1,144 @@
+// Package rest serves a REST API, which speaks JSON, in front of pachd's PFS
+// and PPS gRPC APIs, for clients (such as dashboards written in Javascript)
+// that can't easily use gRPC. The API's routes are the google.api.http
+// options of pfs.proto and pps.proto, and are described by
+// src/client/pfs/pfs.swagger.json and src/client/pps/pps.swagger.json.
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pps"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+	golangproto "github.com/golang/protobuf/proto"
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// Prefix is the path under which the REST API is served, e.g. repos are
+// listed by GET /v1/pfs/repos, and files are pushed into repos by
+// POST /v1/repos/<repo>/files/<path>.
+const Prefix = "/v1/"
+
+// NewHandler returns an http.Handler that serves the REST API, by making
+// requests to the pachd at pachdAddress, which it dials with opts. Requests
+// are authenticated by their Grpc-Metadata-Authn-Token header, which is
+// forwarded to pachd as the request's auth token (pushes may also use an
+// Authorization: Bearer header, see ingestHandler). The handler's connections
+// to pachd are closed when ctx is done.
+func NewHandler(ctx context.Context, pachdAddress string, opts []grpc.DialOption) (http.Handler, error) {
+	m := &marshaler{
+		m: jsonpb.Marshaler{OrigName: true},
+		u: jsonpb.Unmarshaler{AllowUnknownFields: true},
+	}
+	mux := runtime.NewServeMux(runtime.WithMarshalerOption(runtime.MIMEWildcard, m))
+	if err := pfs.RegisterAPIHandlerFromEndpoint(ctx, mux, pachdAddress, opts); err != nil {
+		return nil, err
+	}
+	if err := pps.RegisterAPIHandlerFromEndpoint(ctx, mux, pachdAddress, opts); err != nil {
+		return nil, err
+	}
+	conn, err := grpc.Dial(pachdAddress, opts...)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+	ingest := &ingestHandler{
+		marshaler: m,
+		pfsClient: pfs.NewAPIClient(conn),
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, ingestPrefix) {
+			ingest.ServeHTTP(w, r)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}), nil
+}
+
+// marshaler is runtime.JSONPb, except that it uses gogo's jsonpb, which
+// (unlike golang's, which runtime.JSONPb uses) knows the names of the enums
+// in pachd's messages, as they're generated by gogo.
+type marshaler struct {
+	m jsonpb.Marshaler
+	u jsonpb.Unmarshaler
+}
+
+func (*marshaler) ContentType() string {
+	return "application/json"
+}
+
+func (m *marshaler) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := m.marshalTo(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (m *marshaler) marshalTo(w io.Writer, v interface{}) error {
+	switch v := v.(type) {
+	case proto.Message:
+		return m.m.Marshal(w, v)
+	case map[string]golangproto.Message:
+		// the chunks of streamed responses, see
+		// runtime.ForwardResponseStream
+		var keys []string
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		if _, err := io.WriteString(w, "{"); err != nil {
+			return err
+		}
+		for i, key := range keys {
+			if i > 0 {
+				if _, err := io.WriteString(w, ","); err != nil {
+					return err
+				}
+			}
+			if _, err := fmt.Fprintf(w, "%q:", key); err != nil {
+				return err
+			}
+			if err := m.m.Marshal(w, v[key]); err != nil {
+				return err
+			}
+		}
+		_, err := io.WriteString(w, "}")
+		return err
+	}
+	return json.NewEncoder(w).Encode(v)
+}
+
+func (m *marshaler) Unmarshal(data []byte, v interface{}) error {
+	return m.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+func (m *marshaler) NewDecoder(r io.Reader) runtime.Decoder {
+	d := json.NewDecoder(r)
+	return runtime.DecoderFunc(func(v interface{}) error {
+		if msg, ok := v.(proto.Message); ok {
+			return m.u.UnmarshalNext(d, msg)
+		}
+		return d.Decode(v)
+	})
+}
+
+func (m *marshaler) NewEncoder(w io.Writer) runtime.Encoder {
+	return runtime.EncoderFunc(func(v interface{}) error {
+		return m.marshalTo(w, v)
+	})
+}
@@ -0,0 +1,237 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/pachyderm/pachyderm/src/client"
+	"github.com/pachyderm/pachyderm/src/client/pfs"
+	"github.com/pachyderm/pachyderm/src/client/pkg/require"
+	"github.com/pachyderm/pachyderm/src/client/pkg/uuid"
+
+	golangproto "github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+func TestMarshaler(t *testing.T) {
+	m := &marshaler{}
+	m.m.OrigName = true
+	m.u.AllowUnknownFields = true
+
+	// enums are marshalled, and unmarshalled, by name
+	data, err := m.Marshal(&pfs.FileInfo{
+		File:      &pfs.File{Commit: &pfs.Commit{Repo: &pfs.Repo{Name: "repo"}, ID: "master"}, Path: "/foo"},
+		FileType:  pfs.FileType_DIR,
+		SizeBytes: 1,
+	})
+	require.NoError(t, err)
+	require.True(t, strings.Contains(string(data), `"file_type":"DIR"`))
+	require.True(t, strings.Contains(string(data), `"size_bytes":"1"`))
+	fileInfo := &pfs.FileInfo{}
+	require.NoError(t, m.Unmarshal(data, fileInfo))
+	require.Equal(t, pfs.FileType_DIR, fileInfo.FileType)
+	require.Equal(t, "repo", fileInfo.File.Commit.Repo.Name)
+
+	// request bodies may have fields that pachd doesn't know about
+	createRepo := &pfs.CreateRepoRequest{}
+	require.NoError(t, m.NewDecoder(bytes.NewBufferString(`{"repo":{"name":"foo"},"unknown":1}`)).Decode(createRepo))
+	require.Equal(t, "foo", createRepo.Repo.Name)
+
+	// the chunks of streamed responses
+	data, err = m.Marshal(map[string]golangproto.Message{"result": &pfs.Repo{Name: "foo"}})
+	require.NoError(t, err)
+	require.Equal(t, `{"result":{"name":"foo"}}`, string(data))
+}
+
+func TestRESTAPI(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	address := "0.0.0.0:30650"
+	if addr := os.Getenv("PACHD_PORT_650_TCP_ADDR"); addr != "" {
+		address = net.JoinHostPort(addr, "650")
+	}
+	c, err := client.NewFromAddress(address)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	handler, err := NewHandler(ctx, address, []grpc.DialOption{grpc.WithInsecure()})
+	require.NoError(t, err)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	repo := "rest" + uuid.NewWithoutDashes()[0:12]
+
+	resp, err := http.Post(server.URL+"/v1/pfs/repos", "application/json", strings.NewReader(fmt.Sprintf(`{"repo":{"name":%q}}`, repo)))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	_, err = c.PutFile(repo, "master", "dir/foo", strings.NewReader("foo"))
+	require.NoError(t, err)
+
+	var repoInfo map[string]interface{}
+	get(t, fmt.Sprintf("%s/v1/pfs/repos/%s", server.URL, repo), &repoInfo)
+	require.Equal(t, repo, repoInfo["repo"].(map[string]interface{})["name"])
+
+	var fileInfo map[string]interface{}
+	get(t, fmt.Sprintf("%s/v1/pfs/repos/%s/commits/master/files/dir/foo", server.URL, repo), &fileInfo)
+	require.Equal(t, "FILE", fileInfo["file_type"])
+	require.Equal(t, "3", fileInfo["size_bytes"])
+
+	var listFile map[string][]map[string]interface{}
+	get(t, fmt.Sprintf("%s/v1/pfs/repos/%s/commits/master/tree/dir", server.URL, repo), &listFile)
+	require.Equal(t, 1, len(listFile["file_info"]))
+
+	resp, err = http.Get(fmt.Sprintf("%s/v1/pfs/repos/%s", server.URL, "missing"+repo))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.NotEqual(t, http.StatusOK, resp.StatusCode)
+
+	req, err := http.NewRequest("DELETE", fmt.Sprintf("%s/v1/pfs/repos/%s", server.URL, repo), nil)
+	require.NoError(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	_, err = c.InspectRepo(repo)
+	require.YesError(t, err)
+}
+
+func TestParseIngestPath(t *testing.T) {
+	for urlPath, expected := range map[string][2]string{
+		"/v1/repos/foo/files/bar":      {"foo", "bar"},
+		"/v1/repos/foo/files/bar/baz/": {"foo", "bar/baz"},
+		"/v1/repos/foo/files/":         {"foo", ""},
+		"/v1/repos/foo/files":          {"foo", ""},
+		"/v1/repos/foo":                {},
+		"/v1/repos//files/bar":         {},
+		"/v1/repos/foo/commits/bar":    {},
+	} {
+		repo, filePath, err := parseIngestPath(urlPath)
+		if expected[0] == "" {
+			require.YesError(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		require.Equal(t, expected[0], repo)
+		require.Equal(t, expected[1], filePath)
+	}
+}
+
+func TestIngestBadRequests(t *testing.T) {
+	// none of these requests get as far as pachd
+	server := httptest.NewServer(&ingestHandler{marshaler: &marshaler{}})
+	defer server.Close()
+	for _, test := range []struct {
+		method string
+		path   string
+		status int
+	}{
+		{"GET", "/v1/repos/foo/files/bar", http.StatusNotImplemented},
+		{"POST", "/v1/repos/foo/bar", http.StatusBadRequest},
+		{"POST", "/v1/repos/foo/files/", http.StatusBadRequest},
+		{"POST", "/v1/repos/foo/files/bar?append=maybe", http.StatusBadRequest},
+	} {
+		req, err := http.NewRequest(test.method, server.URL+test.path, strings.NewReader("foo"))
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		resp.Body.Close()
+		require.Equal(t, test.status, resp.StatusCode)
+		require.NotEqual(t, "", body["error"])
+	}
+}
+
+func TestIngest(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	address := "0.0.0.0:30650"
+	if addr := os.Getenv("PACHD_PORT_650_TCP_ADDR"); addr != "" {
+		address = net.JoinHostPort(addr, "650")
+	}
+	c, err := client.NewFromAddress(address)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	handler, err := NewHandler(ctx, address, []grpc.DialOption{grpc.WithInsecure()})
+	require.NoError(t, err)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	repo := "ingest" + uuid.NewWithoutDashes()[0:12]
+	require.NoError(t, c.CreateRepo(repo))
+
+	push := func(path string, contentType string, body io.Reader) string {
+		resp, err := http.Post(fmt.Sprintf("%s/v1/repos/%s/files/%s", server.URL, repo, path), contentType, body)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		commit := &pfs.Commit{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(commit))
+		require.Equal(t, repo, commit.Repo.Name)
+		return commit.ID
+	}
+	getFile := func(commitID string, path string) string {
+		var buffer bytes.Buffer
+		require.NoError(t, c.GetFile(repo, commitID, path, 0, 0, &buffer))
+		return buffer.String()
+	}
+
+	// each push is a commit, which replaces the file unless append is set
+	commit1 := push("dir/foo", "application/octet-stream", strings.NewReader("foo"))
+	commit2 := push("dir/foo", "application/octet-stream", strings.NewReader("bar"))
+	require.NotEqual(t, commit1, commit2)
+	require.Equal(t, "foo", getFile(commit1, "dir/foo"))
+	require.Equal(t, "bar", getFile(commit2, "dir/foo"))
+	commit3 := push("dir/foo?append=true", "application/octet-stream", strings.NewReader("baz"))
+	require.Equal(t, "barbaz", getFile(commit3, "dir/foo"))
+	commitInfo, err := c.InspectCommit(repo, "master")
+	require.NoError(t, err)
+	require.Equal(t, commit3, commitInfo.Commit.ID)
+
+	// the files of multipart uploads are written in one commit
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for _, name := range []string{"a", "b"} {
+		part, err := w.CreateFormFile("file", name)
+		require.NoError(t, err)
+		_, err = part.Write([]byte(name))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	commit4 := push("uploads", w.FormDataContentType(), &body)
+	require.Equal(t, "a", getFile(commit4, "uploads/a"))
+	require.Equal(t, "b", getFile(commit4, "uploads/b"))
+
+	// other branches
+	commit5 := push("foo?branch=other", "text/plain", strings.NewReader("other"))
+	require.Equal(t, "other", getFile("other", "foo"))
+	commitInfo, err = c.InspectCommit(repo, "master")
+	require.NoError(t, err)
+	require.Equal(t, commit4, commitInfo.Commit.ID)
+	require.NotEqual(t, commit4, commit5)
+
+	resp, err := http.Post(fmt.Sprintf("%s/v1/repos/%s/files/foo", server.URL, "missing"+repo), "text/plain", strings.NewReader("foo"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.NotEqual(t, http.StatusOK, resp.StatusCode)
+}
+
+func get(t *testing.T, url string, v interface{}) {
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(v))
+}